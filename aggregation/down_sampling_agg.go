@@ -72,9 +72,12 @@ func putFloat64Slice(sl *[]float64) {
 // DownSamplingMultiSeriesInto merges field data from source time range => target time range,
 // data will be merged into DownSamplingResult
 // for example: source range[5,182]=>target range[0,6], ratio:30, source interval:10s, target interval:5min.
+// Values in same target slot are aggregated by agg type, such as sum for delta sum field, last value for gauge field,
+// for last value, the value of the latest source slot is kept, if same source slot, the value of later decoder is kept,
+// so decoders must be ordered from older to newer(e.g. compaction merges files in that order).
 func DownSamplingMultiSeriesInto(
 	target timeutil.SlotRange, ratio uint16,
-	fieldType field.Type, decoders []*encoding.TSDDecoder,
+	aggType field.AggType, decoders []*encoding.TSDDecoder,
 	emitValue func(targetPos int, value float64),
 ) {
 	targetValues := make([]float64, infBlockSize)
@@ -90,6 +93,11 @@ func DownSamplingMultiSeriesInto(
 	// first loop: filled target values with inf value
 	// inf value is invalid, and won't be emitted after downsampling
 	fillInfBlock(targetValues)
+	// source slot of target values, only used for last value
+	var sourceSlots []uint16
+	if aggType == field.LastValue {
+		sourceSlots = make([]uint16, length)
+	}
 
 	// second loop: iterating tsd decoder
 	for _, decoder := range decoders {
@@ -109,12 +117,21 @@ func DownSamplingMultiSeriesInto(
 			if targetPos >= length {
 				break
 			}
-			// not set before
-			if math.IsInf(targetValues[targetPos], 1) {
+			switch {
+			case math.IsInf(targetValues[targetPos], 1):
+				// not set before
 				targetValues[targetPos] = value
+			case sourceSlots == nil:
 				// set before, aggregate
-			} else {
-				targetValues[targetPos] = fieldType.AggType().Aggregate(targetValues[targetPos], value)
+				targetValues[targetPos] = aggType.Aggregate(targetValues[targetPos], value)
+			case movingSourceSlot >= sourceSlots[targetPos]:
+				// set before, keep the value of latest source slot
+				targetValues[targetPos] = value
+			default:
+				continue
+			}
+			if sourceSlots != nil {
+				sourceSlots[targetPos] = movingSourceSlot
 			}
 		}
 	}
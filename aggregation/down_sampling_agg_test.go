@@ -22,6 +22,10 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/pkg/encoding"
+	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/series/field"
 )
 
 func Test_fillInfBlock(t *testing.T) {
@@ -39,3 +43,41 @@ func assertBlockInf(t *testing.T, size int) {
 	assert.Len(t, sl, size)
 	putFloat64Slice(&sl)
 }
+
+func TestDownSamplingMultiSeriesInto(t *testing.T) {
+	// counter(delta sum) and gauge field of one series, slot 0~5 with value 1~6
+	encoder := encoding.NewTSDEncoder(0)
+	for i := 0; i < 6; i++ {
+		encoder.AppendTime(true)
+		encoder.AppendValue(math.Float64bits(float64(i + 1)))
+	}
+	data, err := encoder.BytesWithoutTime()
+	assert.NoError(t, err)
+
+	downSampling := func(aggType field.AggType, decoders ...*encoding.TSDDecoder) map[int]float64 {
+		result := make(map[int]float64)
+		DownSamplingMultiSeriesInto(timeutil.SlotRange{Start: 0, End: 1}, 3, aggType, decoders,
+			func(targetPos int, value float64) {
+				if !math.IsInf(value, 1) {
+					result[targetPos] = value
+				}
+			})
+		return result
+	}
+	newDecoder := func(start, end uint16) *encoding.TSDDecoder {
+		decoder := encoding.GetTSDDecoder()
+		decoder.ResetWithTimeRange(data, start, end)
+		return decoder
+	}
+	// counter sums the deltas
+	assert.Equal(t, map[int]float64{0: 6, 1: 15}, downSampling(field.SumField.AggType(), newDecoder(0, 5)))
+	// gauge keeps the last value
+	assert.Equal(t, map[int]float64{0: 3, 1: 6}, downSampling(field.GaugeField.AggType(), newDecoder(0, 5)))
+	// override
+	assert.Equal(t, map[int]float64{0: 3, 1: 6}, downSampling(field.Max, newDecoder(0, 5)))
+	assert.Equal(t, map[int]float64{0: 1, 1: 4}, downSampling(field.Min, newDecoder(0, 5)))
+	// gauge keeps the value of latest slot, even if the decoder with later slot comes first
+	assert.Equal(t, map[int]float64{0: 3, 1: 6},
+		downSampling(field.GaugeField.AggType(), newDecoder(0, 5), nil, newDecoder(0, 1)))
+	assert.Empty(t, downSampling(field.GaugeField.AggType(), nil))
+}
@@ -0,0 +1,105 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package ingest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	netHTTP "net/http"
+	"strings"
+
+	ingestCommon "github.com/lindb/lindb/ingestion/common"
+)
+
+// ErrBodyTooLarge represents the ingestion request body exceeds the max body size.
+var ErrBodyTooLarge = errors.New("ingestion request body too large")
+
+// limitedBody records if the body reading fails because of exceeding the max size.
+type limitedBody struct {
+	io.ReadCloser
+	read     int64
+	maxSize  int64
+	exceeded bool
+}
+
+// newLimitedBody limits the body reading by http.MaxBytesReader.
+func newLimitedBody(w netHTTP.ResponseWriter, body io.ReadCloser, maxSize int64) *limitedBody {
+	return &limitedBody{
+		ReadCloser: netHTTP.MaxBytesReader(w, body, maxSize),
+		maxSize:    maxSize,
+	}
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.read += int64(n)
+	if err != nil && err != io.EOF && b.read >= b.maxSize {
+		b.exceeded = true
+	}
+	return n, err
+}
+
+// limitBody limits the size of request body, for gzip request, decodes the body and limits the decoded size also,
+// so that parser reads the plain body. Returns the limited bodies and release function.
+func limitBody(w netHTTP.ResponseWriter, req *netHTTP.Request, maxSize int64) (bodies []*limitedBody, release func(), err error) {
+	release = func() {}
+	if maxSize <= 0 {
+		return nil, release, nil
+	}
+	if req.ContentLength > maxSize {
+		return nil, release, fmt.Errorf("%w: %d > %d", ErrBodyTooLarge, req.ContentLength, maxSize)
+	}
+	rawBody := newLimitedBody(w, req.Body, maxSize)
+	req.Body = rawBody
+	bodies = append(bodies, rawBody)
+	if !strings.EqualFold(req.Header.Get("Content-Encoding"), "gzip") {
+		return bodies, release, nil
+	}
+	gzipReader, err := ingestCommon.GetGzipReader(rawBody)
+	if err != nil {
+		if rawBody.exceeded {
+			return nil, release, fmt.Errorf("%w: > %d", ErrBodyTooLarge, maxSize)
+		}
+		return nil, release, fmt.Errorf("ingestion corrupted gzip data: %w", err)
+	}
+	decodedBody := newLimitedBody(w, gzipReader, maxSize)
+	req.Body = decodedBody
+	req.Header.Del("Content-Encoding")
+	bodies = append(bodies, decodedBody)
+	return bodies, func() { ingestCommon.PutGzipReader(gzipReader) }, nil
+}
+
+// checkBodyExceeded returns ErrBodyTooLarge if any body reading exceeds the max size.
+func checkBodyExceeded(bodies []*limitedBody) error {
+	for _, body := range bodies {
+		if body.exceeded {
+			return fmt.Errorf("%w: > %d", ErrBodyTooLarge, body.maxSize)
+		}
+	}
+	return nil
+}
+
+// requestBodySize returns the bytes read from the raw body(wire size, compressed for gzip request),
+// uses content length if body is not limited.
+func requestBodySize(req *netHTTP.Request, bodies []*limitedBody) int64 {
+	if len(bodies) == 0 {
+		return req.ContentLength
+	}
+	return bodies[0].read
+}
@@ -0,0 +1,114 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package ingest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/app/broker/deps"
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/internal/concurrent"
+	"github.com/lindb/lindb/internal/linmetric"
+	"github.com/lindb/lindb/pkg/ltoml"
+	"github.com/lindb/lindb/replica"
+)
+
+func Test_Write_MaxBodySize(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cm := replica.NewMockChannelManager(ctrl)
+	api := NewInfluxWriter(&deps.HTTPDeps{
+		BrokerCfg: &config.Broker{
+			BrokerBase: config.BrokerBase{
+				Ingestion: config.Ingestion{
+					IngestTimeout: ltoml.Duration(time.Second * 2),
+					MaxBodySize:   ltoml.Size(1024),
+				},
+			},
+		},
+		CM: cm,
+		IngestLimiter: concurrent.NewLimiter(
+			context.TODO(),
+			32,
+			time.Second,
+			linmetric.NewScope("body_limit_write_test")),
+	})
+	r := gin.New()
+	api.Register(r)
+	line := "cpu,host=1.1.1.1 value=1\n"
+	do := func(body []byte, contentLength int64, gzipped bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, InfluxWritePath+"?db=test", bytes.NewReader(body))
+		req.ContentLength = contentLength
+		if gzipped {
+			req.Header.Set("Content-Encoding", "gzip")
+		}
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, req)
+		return resp
+	}
+	compress := func(data string) []byte {
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		_, _ = io.WriteString(w, data)
+		_ = w.Close()
+		return buf.Bytes()
+	}
+	overLimit := strings.Repeat(line, 100)
+
+	// case 1: content length over limit
+	resp := do([]byte(overLimit), int64(len(overLimit)), false)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.Code)
+	// case 2: chunked body over limit
+	resp = do([]byte(overLimit), -1, false)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.Code)
+	// case 3: compressed body under limit, but decompressed body over limit
+	compressed := compress(overLimit)
+	assert.Less(t, len(compressed), 1024)
+	resp = do(compressed, int64(len(compressed)), true)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.Code)
+	// case 4: corrupted gzip body
+	resp = do([]byte("bad"), 3, true)
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	// case 5: under limit
+	cm.EXPECT().Write(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(2)
+	resp = do([]byte(line), int64(len(line)), false)
+	assert.Equal(t, http.StatusNoContent, resp.Code)
+	compressed = compress(line)
+	resp = do(compressed, int64(len(compressed)), true)
+	assert.Equal(t, http.StatusNoContent, resp.Code)
+}
+
+func Test_requestBodySize(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPut, "/", strings.NewReader("cpu f1=1"))
+	assert.Equal(t, int64(8), requestBodySize(req, nil))
+	// gzip request, compressed body read from wire and decoded body
+	assert.Equal(t, int64(10), requestBodySize(req, []*limitedBody{{read: 10}, {read: 30}}))
+}
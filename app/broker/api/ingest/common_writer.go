@@ -19,61 +19,163 @@ package ingest
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	netHTTP "net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
 	"github.com/lindb/lindb/app/broker/deps"
-	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/config"
 	ingestCommon "github.com/lindb/lindb/ingestion/common"
+	"github.com/lindb/lindb/internal/linmetric"
 	"github.com/lindb/lindb/pkg/http"
+	"github.com/lindb/lindb/pkg/http/middleware"
 	"github.com/lindb/lindb/series/metric"
 	"github.com/lindb/lindb/series/tag"
 )
 
+var (
+	batchMetricsHistogram = linmetric.NewScope("lindb.ingestion.batch_metrics").
+				NewHistogram().WithExponentValueBuckets(1, 1024*1024, 40)
+	batchBytesHistogram = linmetric.NewScope("lindb.ingestion.batch_bytes").
+				NewHistogram().WithExponentValueBuckets(64, 256*1024*1024, 40)
+)
+
+// WriteTokenHeader is the response header of write token, returned if rows are acked by storage(ack level is
+// leader or quorum), query with the write token(writeToken=xxx) waits until the written rows are visible.
+const WriteTokenHeader = "X-Lindb-Write-Token"
+
 type parserFunc func(req *netHTTP.Request, enrichedTags tag.Tags, namespace string) (*metric.BrokerBatchRows, error)
 
+// WriteError represents the rejected metric with its index in request.
+type WriteError struct {
+	Index  int    `json:"index"`
+	Reason string `json:"reason"`
+}
+
+// WriteSummary represents the write result with per-metric status,
+// returned if request with query: summary=true.
+type WriteSummary struct {
+	Accepted int          `json:"accepted"`
+	Rejected int          `json:"rejected"`
+	Errors   []WriteError `json:"errors,omitempty"`
+}
+
+// newWriteSummary creates the write summary of decoded metrics.
+func newWriteSummary(metrics *metric.BrokerBatchRows) *WriteSummary {
+	rejections := metrics.Rejections()
+	summary := &WriteSummary{
+		Accepted: metrics.Len(),
+		Rejected: len(rejections),
+	}
+	for _, rejection := range rejections {
+		summary.Errors = append(summary.Errors, WriteError{
+			Index:  rejection.Index,
+			Reason: rejection.Err.Error(),
+		})
+	}
+	return summary
+}
+
 type commonWriter struct {
 	deps   *deps.HTTPDeps
 	parser parserFunc
 }
 
 func (cw *commonWriter) Write(c *gin.Context) {
-	if err := cw.deps.IngestLimiter.Do(func() error {
-		return cw.realWrite(c)
+	var summary *WriteSummary
+	if err := cw.deps.IngestLimiter.Do(func() (err error) {
+		summary, err = cw.realWrite(c)
+		return err
 	}); err != nil {
+		switch {
+		case errors.Is(err, ErrBodyTooLarge):
+			http.RequestEntityTooLarge(c, err)
+			return
+		case errors.Is(err, middleware.ErrAccessDenied):
+			http.Forbidden(c, err)
+			return
+		}
 		http.Error(c, err)
+	} else if summary != nil {
+		http.OK(c, summary)
 	} else {
 		http.NoContent(c)
 	}
 }
 
-func (cw *commonWriter) realWrite(c *gin.Context) error {
+// realWrite writes the metrics, returns the write summary if request wants it.
+func (cw *commonWriter) realWrite(c *gin.Context) (*WriteSummary, error) {
+	// stamps the accepted time before parsing, end-to-end write latency starts from here
+	acceptedAt := time.Now().UnixNano()
 	var param struct {
 		Database  string `form:"db" binding:"required"`
 		Namespace string `form:"ns"`
+		AckLevel  string `form:"ackLevel"`
 	}
 	err := c.ShouldBindQuery(&param)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if param.AckLevel != "" && !config.IsValidWriteAckLevel(param.AckLevel) {
+		return nil, fmt.Errorf("write ack level: %s is invalid", param.AckLevel)
 	}
 	ctx, cancel := context.WithTimeout(context.Background(),
 		cw.deps.BrokerCfg.BrokerBase.Ingestion.IngestTimeout.Duration())
 	defer cancel()
 
 	if param.Namespace == "" {
-		param.Namespace = constants.DefaultNamespace
+		param.Namespace = config.GlobalBrokerConfig().Ingestion.DefaultNamespace
+	}
+	if err := cw.deps.ACL.Check(c.Request, param.Namespace, config.ACLOperationWrite); err != nil {
+		return nil, err
 	}
 	enrichedTags, err := ingestCommon.ExtractEnrichTags(c.Request)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	bodies, releaseBody, err := limitBody(c.Writer, c.Request,
+		int64(cw.deps.BrokerCfg.BrokerBase.Ingestion.MaxBodySize))
+	if err != nil {
+		return nil, err
+	}
+	defer releaseBody()
 	metrics, err := cw.parser(c.Request, enrichedTags, param.Namespace)
+	// parser may ignore the read error and returns partial metrics, reject it if body exceeds
+	if exceededErr := checkBodyExceeded(bodies); exceededErr != nil {
+		if metrics != nil {
+			metrics.Release()
+		}
+		return nil, exceededErr
+	}
+	if metrics != nil {
+		// records batch size distribution of clients, including rejected metrics
+		batchMetricsHistogram.UpdateValue(float64(metrics.Len() + len(metrics.Rejections())))
+	}
+	if size := requestBodySize(c.Request, bodies); size > 0 {
+		batchBytesHistogram.UpdateValue(float64(size))
+	}
+	var summary *WriteSummary
+	if metrics != nil && ingestCommon.IsSummaryResponse(c.Request) {
+		summary = newWriteSummary(metrics)
+	}
 	if err != nil {
-		return err
+		if summary != nil && errors.Is(err, ingestCommon.ErrEmptyMetrics) {
+			// all metrics are rejected, reports them by summary
+			return summary, nil
+		}
+		return nil, err
 	}
+	// overrides the write ack level of database if request specifies it
+	metrics.SetAckLevel(param.AckLevel)
+	metrics.SetAcceptedAt(acceptedAt)
 	if err := cw.deps.CM.Write(ctx, param.Database, metrics); err != nil {
-		return err
+		return nil, err
+	}
+	if token := metrics.WriteToken(); len(token) > 0 {
+		c.Header(WriteTokenHeader, token.Encode())
 	}
-	return nil
+	return summary, nil
 }
@@ -0,0 +1,89 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package ingest
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/app/broker/deps"
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/internal/concurrent"
+	"github.com/lindb/lindb/internal/linmetric"
+	"github.com/lindb/lindb/internal/mock"
+	"github.com/lindb/lindb/pkg/ltoml"
+	"github.com/lindb/lindb/replica"
+	"github.com/lindb/lindb/series/metric"
+)
+
+func Test_Write_BatchSizeHistogram(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	oldMetricsHistogram, oldBytesHistogram := batchMetricsHistogram, batchBytesHistogram
+	defer func() {
+		batchMetricsHistogram, batchBytesHistogram = oldMetricsHistogram, oldBytesHistogram
+		ctrl.Finish()
+	}()
+	batchMetricsHistogram = linmetric.NewHistogram().WithExponentValueBuckets(1, 1024, 10)
+	batchBytesHistogram = linmetric.NewHistogram().WithExponentValueBuckets(1, 1024, 10)
+
+	cm := replica.NewMockChannelManager(ctrl)
+	start := time.Now().UnixNano()
+	cm.EXPECT().Write(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ string, rows *metric.BrokerBatchRows) error {
+			// accepted time is stamped when request is accepted
+			assert.GreaterOrEqual(t, rows.AcceptedAt(), start)
+			return nil
+		}).AnyTimes()
+	body := "cpu,host=a f1=1\ncpu,host=b f1=2\ncpu,host=c f1=3\n"
+	// content length is used if body is not limited
+	for _, maxBodySize := range []ltoml.Size{0, 1024} {
+		api := NewInfluxWriter(&deps.HTTPDeps{
+			BrokerCfg: &config.Broker{
+				BrokerBase: config.BrokerBase{
+					Ingestion: config.Ingestion{
+						IngestTimeout: ltoml.Duration(time.Second * 2),
+						MaxBodySize:   maxBodySize,
+					},
+				},
+			},
+			CM: cm,
+			IngestLimiter: concurrent.NewLimiter(
+				context.TODO(),
+				32,
+				time.Second,
+				linmetric.NewScope("batch_size_write_test")),
+		})
+		r := gin.New()
+		api.Register(r)
+
+		resp := mock.DoRequest(t, r, http.MethodPut, InfluxWritePath+"?db=test", body)
+		assert.Equal(t, http.StatusNoContent, resp.Code)
+	}
+	count, sum := batchMetricsHistogram.Total()
+	assert.Equal(t, 2.0, count)
+	assert.Equal(t, 6.0, sum)
+	count, sum = batchBytesHistogram.Total()
+	assert.Equal(t, 2.0, count)
+	assert.Equal(t, float64(2*len(body)), sum)
+}
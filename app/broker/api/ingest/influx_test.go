@@ -21,6 +21,8 @@ import (
 	"context"
 	"io"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -33,8 +35,12 @@ import (
 	"github.com/lindb/lindb/internal/concurrent"
 	"github.com/lindb/lindb/internal/linmetric"
 	"github.com/lindb/lindb/internal/mock"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/encoding"
+	"github.com/lindb/lindb/pkg/http/middleware"
 	"github.com/lindb/lindb/pkg/ltoml"
 	"github.com/lindb/lindb/replica"
+	"github.com/lindb/lindb/series/metric"
 )
 
 func Test_Influx_Write(t *testing.T) {
@@ -97,3 +103,177 @@ measurement value=12 1439587925
 `)
 	assert.Equal(t, http.StatusNoContent, resp.Code)
 }
+
+func Test_Influx_Write_summary(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cm := replica.NewMockChannelManager(ctrl)
+	api := NewInfluxWriter(&deps.HTTPDeps{
+		BrokerCfg: &config.Broker{
+			BrokerBase: config.BrokerBase{
+				Ingestion: config.Ingestion{
+					IngestTimeout: ltoml.Duration(time.Second * 2),
+				},
+			},
+		},
+		CM: cm,
+		IngestLimiter: concurrent.NewLimiter(
+			context.TODO(),
+			32,
+			time.Second,
+			linmetric.NewScope("influx_write_summary_test")),
+	})
+	r := gin.New()
+	api.Register(r)
+
+	cm.EXPECT().Write(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+	resp := mock.DoRequest(t, r, http.MethodPut, InfluxWritePath+"?db=test&summary=true", `
+# comment line is not counted
+measurement,foo=bar value=12 1439587925
+bad_line
+measurement value=12 1439587925
+`)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	summary := WriteSummary{}
+	assert.NoError(t, encoding.JSONUnmarshal(resp.Body.Bytes(), &summary))
+	assert.Equal(t, WriteSummary{Accepted: 2, Rejected: 1, Errors: summary.Errors}, summary)
+	assert.Len(t, summary.Errors, 1)
+	assert.Equal(t, 1, summary.Errors[0].Index)
+}
+
+func Test_Influx_Write_DefaultNamespace(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	brokerCfg := config.NewDefaultBrokerBase()
+	brokerCfg.Ingestion.DefaultNamespace = "my-ns"
+	config.SetGlobalBrokerConfig(brokerCfg)
+	defer config.SetGlobalBrokerConfig(config.NewDefaultBrokerBase())
+
+	cm := replica.NewMockChannelManager(ctrl)
+	api := NewInfluxWriter(&deps.HTTPDeps{
+		BrokerCfg: &config.Broker{
+			BrokerBase: config.BrokerBase{
+				Ingestion: config.Ingestion{
+					IngestTimeout: ltoml.Duration(time.Second * 2),
+				},
+			},
+		},
+		CM: cm,
+		IngestLimiter: concurrent.NewLimiter(
+			context.TODO(),
+			32,
+			time.Second,
+			linmetric.NewScope("influx_write_ns_test")),
+	})
+	r := gin.New()
+	api.Register(r)
+
+	var namespaces []string
+	cm.EXPECT().Write(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ string, rows *metric.BrokerBatchRows) error {
+			for _, row := range rows.Rows() {
+				m := row.Metric()
+				namespaces = append(namespaces, string(m.Namespace()))
+			}
+			return nil
+		}).Times(2)
+	// empty namespace, stored under configured default namespace
+	resp := mock.DoRequest(t, r, http.MethodPut, InfluxWritePath+"?db=test", "measurement value=12 1439587925")
+	assert.Equal(t, http.StatusNoContent, resp.Code)
+	resp = mock.DoRequest(t, r, http.MethodPut, InfluxWritePath+"?db=test&ns=ns", "measurement value=12 1439587925")
+	assert.Equal(t, http.StatusNoContent, resp.Code)
+	assert.Equal(t, []string{"my-ns", "ns"}, namespaces)
+}
+
+func Test_Influx_Write_AckLevel(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cm := replica.NewMockChannelManager(ctrl)
+	api := NewInfluxWriter(&deps.HTTPDeps{
+		BrokerCfg: &config.Broker{
+			BrokerBase: config.BrokerBase{
+				Ingestion: config.Ingestion{
+					IngestTimeout: ltoml.Duration(time.Second * 2),
+				},
+			},
+		},
+		CM: cm,
+		IngestLimiter: concurrent.NewLimiter(
+			context.TODO(),
+			32,
+			time.Second,
+			linmetric.NewScope("influx_write_ack_level_test")),
+	})
+	r := gin.New()
+	api.Register(r)
+
+	// unknown ack level
+	resp := mock.DoRequest(t, r, http.MethodPut, InfluxWritePath+"?db=test&ackLevel=all", "measurement value=12 1439587925")
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+
+	var ackLevels []string
+	cm.EXPECT().Write(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ string, rows *metric.BrokerBatchRows) error {
+			ackLevels = append(ackLevels, rows.AckLevel())
+			if rows.AckLevel() != "" {
+				rows.AddWriteToken(models.WriteToken{{ShardID: 1, FamilyTime: 10, Leader: 1, Sequence: 5}})
+			}
+			return nil
+		}).Times(2)
+	// empty ack level uses the ack level of database
+	resp = mock.DoRequest(t, r, http.MethodPut, InfluxWritePath+"?db=test", "measurement value=12 1439587925")
+	assert.Equal(t, http.StatusNoContent, resp.Code)
+	assert.Empty(t, resp.Header().Get(WriteTokenHeader))
+	resp = mock.DoRequest(t, r, http.MethodPut, InfluxWritePath+"?db=test&ackLevel=quorum", "measurement value=12 1439587925")
+	assert.Equal(t, http.StatusNoContent, resp.Code)
+	assert.Equal(t, []string{"", config.WriteAckLevelQuorum}, ackLevels)
+	// returns write token acked by storage
+	token, err := models.DecodeWriteToken(resp.Header().Get(WriteTokenHeader))
+	assert.NoError(t, err)
+	assert.Equal(t, models.WriteToken{{ShardID: 1, FamilyTime: 10, Leader: 1, Sequence: 5}}, token)
+}
+
+func Test_Influx_Write_ACL(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cm := replica.NewMockChannelManager(ctrl)
+	api := NewInfluxWriter(&deps.HTTPDeps{
+		BrokerCfg: &config.Broker{
+			BrokerBase: config.BrokerBase{
+				Ingestion: config.Ingestion{
+					IngestTimeout: ltoml.Duration(time.Second * 2),
+				},
+			},
+		},
+		CM: cm,
+		IngestLimiter: concurrent.NewLimiter(
+			context.TODO(),
+			32,
+			time.Second,
+			linmetric.NewScope("influx_write_acl_test")),
+		ACL: middleware.NewNamespaceACL([]config.ACL{
+			{Token: "token-a", Namespaces: []string{"ns-a"}, Operations: []string{config.ACLOperationWrite}},
+		}),
+	})
+	r := gin.New()
+	api.Register(r)
+
+	write := func(ns string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, InfluxWritePath+"?db=test&ns="+ns,
+			strings.NewReader("measurement value=12 1439587925"))
+		req.Header.Set("Authorization", "Bearer token-a")
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, req)
+		return resp
+	}
+	cm.EXPECT().Write(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+	assert.Equal(t, http.StatusNoContent, write("ns-a").Code)
+	assert.Equal(t, http.StatusForbidden, write("ns-b").Code)
+	// no token
+	resp := mock.DoRequest(t, r, http.MethodPut, InfluxWritePath+"?db=test&ns=ns-a", "measurement value=12 1439587925")
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+}
@@ -33,6 +33,7 @@ import (
 	"github.com/lindb/lindb/internal/concurrent"
 	"github.com/lindb/lindb/internal/linmetric"
 	"github.com/lindb/lindb/internal/mock"
+	"github.com/lindb/lindb/pkg/encoding"
 	"github.com/lindb/lindb/pkg/ltoml"
 	protoMetricsV1 "github.com/lindb/lindb/proto/gen/v1/metrics"
 	"github.com/lindb/lindb/replica"
@@ -93,3 +94,66 @@ func Test_NativeWriter(t *testing.T) {
 	assert.Equal(t, http.StatusInternalServerError, resp.Code)
 
 }
+
+func Test_NativeWriter_summary(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cm := replica.NewMockChannelManager(ctrl)
+	api := NewProtoWriter(&deps.HTTPDeps{
+		BrokerCfg: &config.Broker{
+			BrokerBase: config.BrokerBase{
+				Ingestion: config.Ingestion{
+					IngestTimeout: ltoml.Duration(time.Second * 2),
+				},
+			},
+		},
+		CM: cm,
+		IngestLimiter: concurrent.NewLimiter(
+			context.TODO(),
+			32,
+			time.Second,
+			linmetric.NewScope("proto_write_summary_test")),
+	})
+	r := gin.New()
+	api.Register(r)
+
+	simpleFields := []*protoMetricsV1.SimpleField{
+		{Name: "counter", Type: protoMetricsV1.SimpleFieldType_DELTA_SUM, Value: 23},
+	}
+	// mixed valid/invalid metrics
+	cm.EXPECT().Write(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+	metricList := protoMetricsV1.MetricList{Metrics: []*protoMetricsV1.Metric{
+		{Name: "m1", SimpleFields: simpleFields},
+		{Name: "", SimpleFields: simpleFields},
+		{Name: "m3", SimpleFields: simpleFields},
+		{Name: "m4"},
+	}}
+	data, _ := metricList.Marshal()
+	resp := mock.DoRequest(t, r, http.MethodPost, ProtoWritePath+"?db=test&summary=true", string(data))
+	assert.Equal(t, http.StatusOK, resp.Code)
+	summary := WriteSummary{}
+	assert.NoError(t, encoding.JSONUnmarshal(resp.Body.Bytes(), &summary))
+	assert.Equal(t, 2, summary.Accepted)
+	assert.Equal(t, 2, summary.Rejected)
+	assert.Len(t, summary.Errors, 2)
+	assert.Equal(t, 1, summary.Errors[0].Index)
+	assert.Equal(t, 3, summary.Errors[1].Index)
+	assert.NotEmpty(t, summary.Errors[0].Reason)
+
+	// all metrics are invalid
+	metricList = protoMetricsV1.MetricList{Metrics: []*protoMetricsV1.Metric{
+		{Name: "", SimpleFields: simpleFields},
+	}}
+	data, _ = metricList.Marshal()
+	resp = mock.DoRequest(t, r, http.MethodPost, ProtoWritePath+"?db=test&summary=true", string(data))
+	assert.Equal(t, http.StatusOK, resp.Code)
+	summary = WriteSummary{}
+	assert.NoError(t, encoding.JSONUnmarshal(resp.Body.Bytes(), &summary))
+	assert.Equal(t, 0, summary.Accepted)
+	assert.Equal(t, 1, summary.Rejected)
+	assert.Equal(t, 0, summary.Errors[0].Index)
+	// default all-or-nothing response
+	resp = mock.DoRequest(t, r, http.MethodPost, ProtoWritePath+"?db=test", string(data))
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+}
@@ -25,6 +25,7 @@ import (
 
 	"github.com/lindb/lindb/app/broker/api/admin"
 	"github.com/lindb/lindb/app/broker/deps"
+	"github.com/lindb/lindb/config"
 	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/pkg/encoding"
 	"github.com/lindb/lindb/pkg/http"
@@ -74,6 +75,10 @@ func (d *MetadataAPI) Suggest(c *gin.Context) {
 	if err := d.deps.QueryLimiter.Do(func() error {
 		return d.suggestWithLimit(c)
 	}); err != nil {
+		if errors.Is(err, middleware.ErrAccessDenied) {
+			http.Forbidden(c, err)
+			return
+		}
 		http.Error(c, err)
 	}
 }
@@ -101,6 +106,11 @@ func (d *MetadataAPI) suggestWithLimit(c *gin.Context) error {
 		if param.Database == "" {
 			return errDatabaseNameRequired
 		}
+		if metaQuery.Type != stmt.Namespace {
+			if err := d.deps.ACL.Check(c.Request, aclNamespace(metaQuery.Namespace), config.ACLOperationRead); err != nil {
+				return err
+			}
+		}
 		if err := d.suggest(c, param.Database, metaQuery); err != nil {
 			return err
 		}
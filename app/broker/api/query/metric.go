@@ -19,16 +19,26 @@ package query
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	"github.com/gin-gonic/gin"
 
 	"github.com/lindb/lindb/app/broker/deps"
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/pkg/http"
 	"github.com/lindb/lindb/pkg/http/middleware"
+	brokerQuery "github.com/lindb/lindb/query/broker"
+	"github.com/lindb/lindb/sql"
+	"github.com/lindb/lindb/sql/stmt"
 )
 
 var (
 	MetricQueryPath = "/query/metric"
+
+	errUnknownExplainMode = errors.New("unknown explain mode")
 )
 
 // MetricAPI represents the metric query api
@@ -54,27 +64,78 @@ func (m *MetricAPI) Register(route gin.IRoutes) {
 
 // Search searches the metric data based on database and sql.
 func (m *MetricAPI) Search(c *gin.Context) {
-	if err := m.deps.QueryLimiter.Do(func() error {
-		return m.searchWithLimit(c)
+	if retryAfter, allowed := m.deps.TokenQueryLimiter.Allow(middleware.RequestToken(c.Request)); !allowed {
+		http.TooManyRequests(c, brokerQuery.ErrQueryRateLimited, retryAfter)
+		return
+	}
+	// limits concurrent queries of database before taking global query token,
+	// so that the queued queries of one database cannot starve others.
+	if err := m.deps.DatabaseQueryLimiter.Do(c.Request.Context(), c.Query("db"), func() error {
+		return m.deps.QueryLimiter.Do(func() error {
+			return m.searchWithLimit(c)
+		})
 	}); err != nil {
-		http.Error(c, err)
+		switch {
+		case errors.Is(err, middleware.ErrAccessDenied):
+			http.Forbidden(c, err)
+		case errors.Is(err, brokerQuery.ErrTooManyQueries):
+			http.ServiceUnavailable(c, err)
+		default:
+			http.Error(c, err)
+		}
 	}
 }
 
 func (m *MetricAPI) searchWithLimit(c *gin.Context) error {
 	var param struct {
-		Database string `form:"db" binding:"required"`
-		SQL      string `form:"sql" binding:"required"`
+		Database   string `form:"db" binding:"required"`
+		SQL        string `form:"sql" binding:"required"`
+		Explain    string `form:"explain"`
+		LargeScan  bool   `form:"largeScan"`
+		WriteToken string `form:"writeToken"` // returned by write api, waits written data visible(read-your-writes)
+		Stream     bool   `form:"stream"`     // streams result set in chunks as server-sent events
 	}
 	err := c.ShouldBind(&param)
 	if err != nil {
 		return err
 	}
+	explain := brokerQuery.ExplainMode(param.Explain)
+	switch explain {
+	case brokerQuery.ExplainNone, brokerQuery.ExplainAnalyze, brokerQuery.ExplainPlan, brokerQuery.ExplainTrace:
+	default:
+		return fmt.Errorf("%w: %s", errUnknownExplainMode, param.Explain)
+	}
+	if m.deps.ACL != nil {
+		if err := m.checkACL(c, param.SQL); err != nil {
+			return err
+		}
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), m.deps.BrokerCfg.Query.Timeout.Duration())
+	parentCtx := context.Background()
+	if param.Stream {
+		// cancels the query if client closes the stream mid-way
+		parentCtx = c.Request.Context()
+	}
+	ctx, cancel := context.WithTimeout(parentCtx, m.deps.BrokerCfg.Query.Timeout.Duration())
 	defer cancel()
 
-	metricQuery := m.deps.QueryFactory.NewMetricQuery(ctx, param.Database, param.SQL)
+	var opts []brokerQuery.MetricQueryOption
+	if param.LargeScan {
+		opts = append(opts, brokerQuery.WithLargeScan())
+	}
+	if param.WriteToken != "" {
+		if _, err := models.DecodeWriteToken(param.WriteToken); err != nil {
+			return err
+		}
+		opts = append(opts, brokerQuery.WithWriteToken(param.WriteToken))
+	}
+	if m.deps.BrokerCfg.Query.ShardFailurePolicy == config.ShardFailurePolicyBestEffort {
+		opts = append(opts, brokerQuery.WithBestEffort())
+	}
+	metricQuery := m.deps.QueryFactory.NewMetricQuery(ctx, param.Database, param.SQL, explain, opts...)
+	if param.Stream {
+		return m.stream(c, metricQuery)
+	}
 	resultSet, err := metricQuery.WaitResponse()
 	if err != nil {
 		return err
@@ -82,3 +143,42 @@ func (m *MetricAPI) searchWithLimit(c *gin.Context) error {
 	http.OK(c, resultSet)
 	return nil
 }
+
+// stream sends the result set in chunks as server-sent events, the error after streaming started
+// is sent as error event, because the response status has been written.
+func (m *MetricAPI) stream(c *gin.Context, metricQuery brokerQuery.MetricQuery) error {
+	streaming := false
+	err := metricQuery.StreamResponse(func(resultSet *models.ResultSet) error {
+		streaming = true
+		c.SSEvent("result", resultSet)
+		c.Writer.Flush()
+		return c.Request.Context().Err()
+	})
+	if err != nil && streaming {
+		c.SSEvent("error", err.Error())
+		c.Writer.Flush()
+		return nil
+	}
+	return err
+}
+
+// checkACL checks if the request is allowed to read the namespace of query.
+func (m *MetricAPI) checkACL(c *gin.Context, ql string) error {
+	statement, err := sql.Parse(ql)
+	if err != nil {
+		return err
+	}
+	query, ok := statement.(*stmt.Query)
+	if !ok {
+		return errWrongQueryStmt
+	}
+	return m.deps.ACL.Check(c.Request, aclNamespace(query.Namespace), config.ACLOperationRead)
+}
+
+// aclNamespace returns the namespace checked by acl, empty namespace is queried as default namespace by storage.
+func aclNamespace(namespace string) string {
+	if namespace == "" {
+		return constants.DefaultNamespace
+	}
+	return namespace
+}
@@ -21,6 +21,9 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 	"time"
 
@@ -30,11 +33,13 @@ import (
 
 	"github.com/lindb/lindb/app/broker/deps"
 	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/coordinator/broker"
 	"github.com/lindb/lindb/internal/concurrent"
 	"github.com/lindb/lindb/internal/linmetric"
 	"github.com/lindb/lindb/internal/mock"
 	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/http/middleware"
 	"github.com/lindb/lindb/pkg/ltoml"
 	brokerQuery "github.com/lindb/lindb/query/broker"
 )
@@ -47,7 +52,7 @@ func TestMetricAPI_Search(t *testing.T) {
 	metricQuery := brokerQuery.NewMockMetricQuery(ctrl)
 	stateMgr := broker.NewMockStateManager(ctrl)
 
-	queryFactory.EXPECT().NewMetricQuery(gomock.Any(), gomock.Any(), gomock.Any()).Return(metricQuery)
+	queryFactory.EXPECT().NewMetricQuery(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(metricQuery)
 
 	api := NewMetricAPI(&deps.HTTPDeps{
 		BrokerCfg:    &config.Broker{Query: config.Query{Timeout: ltoml.Duration(time.Second)}},
@@ -66,6 +71,62 @@ func TestMetricAPI_Search(t *testing.T) {
 	metricQuery.EXPECT().WaitResponse().Return(&models.ResultSet{}, nil)
 	resp := mock.DoRequest(t, r, http.MethodGet, MetricQueryPath+"?db=test&sql=select f from cpu", "")
 	assert.Equal(t, http.StatusOK, resp.Code)
+
+	// opt into large scan
+	queryFactory.EXPECT().NewMetricQuery(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(metricQuery)
+	metricQuery.EXPECT().WaitResponse().Return(&models.ResultSet{}, nil)
+	resp = mock.DoRequest(t, r, http.MethodGet, MetricQueryPath+"?db=test&sql=select f from cpu&largeScan=true", "")
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	// best-effort query if some shards fail
+	api.deps.BrokerCfg.Query.ShardFailurePolicy = config.ShardFailurePolicyBestEffort
+	queryFactory.EXPECT().NewMetricQuery(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(metricQuery)
+	metricQuery.EXPECT().WaitResponse().Return(&models.ResultSet{Warnings: []string{"query shards [1] failed"}}, nil)
+	resp = mock.DoRequest(t, r, http.MethodGet, MetricQueryPath+"?db=test&sql=select f from cpu", "")
+	assert.Equal(t, http.StatusOK, resp.Code)
+}
+
+func TestMetricAPI_Search_Stream(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	queryFactory := brokerQuery.NewMockFactory(ctrl)
+	metricQuery := brokerQuery.NewMockMetricQuery(ctrl)
+	api := NewMetricAPI(&deps.HTTPDeps{
+		BrokerCfg:    &config.Broker{Query: config.Query{Timeout: ltoml.Duration(time.Second)}},
+		QueryFactory: queryFactory,
+		QueryLimiter: concurrent.NewLimiter(
+			context.TODO(),
+			2,
+			time.Second*5,
+			linmetric.NewScope("metric_data_search_stream"),
+		),
+	})
+	r := gin.New()
+	api.Register(r)
+	queryFactory.EXPECT().NewMetricQuery(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(metricQuery).AnyTimes()
+
+	// case 1: stream result in chunks
+	metricQuery.EXPECT().StreamResponse(gomock.Any()).DoAndReturn(func(send func(rs *models.ResultSet) error) error {
+		_ = send(&models.ResultSet{MetricName: "cpu", Series: []*models.Series{models.NewSeries(map[string]string{"host": "1"})}})
+		return send(&models.ResultSet{MetricName: "cpu", Series: []*models.Series{models.NewSeries(map[string]string{"host": "2"})}})
+	})
+	resp := mock.DoRequest(t, r, http.MethodGet, MetricQueryPath+"?db=test&sql=select f from cpu&stream=true", "")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, 2, strings.Count(resp.Body.String(), "event:result"))
+	// case 2: failure before streaming
+	metricQuery.EXPECT().StreamResponse(gomock.Any()).Return(fmt.Errorf("err"))
+	resp = mock.DoRequest(t, r, http.MethodGet, MetricQueryPath+"?db=test&sql=select f from cpu&stream=true", "")
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	// case 3: failure after streaming started, sends error event
+	metricQuery.EXPECT().StreamResponse(gomock.Any()).DoAndReturn(func(send func(rs *models.ResultSet) error) error {
+		_ = send(&models.ResultSet{MetricName: "cpu"})
+		return fmt.Errorf("err")
+	})
+	resp = mock.DoRequest(t, r, http.MethodGet, MetricQueryPath+"?db=test&sql=select f from cpu&stream=true", "")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), "event:error")
 }
 
 func TestNewMetricAPI_Search_Err(t *testing.T) {
@@ -92,11 +153,114 @@ func TestNewMetricAPI_Search_Err(t *testing.T) {
 	// param error
 	resp := mock.DoRequest(t, r, http.MethodGet, MetricQueryPath, "")
 	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	// unknown explain mode
+	resp = mock.DoRequest(t, r, http.MethodGet, MetricQueryPath+"?db=test&sql=select f from cpu&explain=bad", "")
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	// bad write token
+	resp = mock.DoRequest(t, r, http.MethodGet, MetricQueryPath+"?db=test&sql=select f from cpu&writeToken=bad!", "")
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
 
 	metricQuery := brokerQuery.NewMockMetricQuery(ctrl)
-	queryFactory.EXPECT().NewMetricQuery(gomock.Any(), gomock.Any(), gomock.Any()).Return(metricQuery)
+	queryFactory.EXPECT().NewMetricQuery(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(metricQuery)
 	metricQuery.EXPECT().WaitResponse().Return(&models.ResultSet{}, fmt.Errorf("err"))
 
 	resp = mock.DoRequest(t, r, http.MethodGet, MetricQueryPath+"?db=test&sql=select f from cpu", "")
 	assert.Equal(t, http.StatusInternalServerError, resp.Code)
 }
+
+func TestMetricAPI_Search_ACL(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	queryFactory := brokerQuery.NewMockFactory(ctrl)
+	api := NewMetricAPI(&deps.HTTPDeps{
+		BrokerCfg:    &config.Broker{Query: config.Query{Timeout: ltoml.Duration(time.Second)}},
+		QueryFactory: queryFactory,
+		QueryLimiter: concurrent.NewLimiter(
+			context.TODO(),
+			2,
+			time.Second*5,
+			linmetric.NewScope("metric_data_search_acl"),
+		),
+		ACL: middleware.NewNamespaceACL([]config.ACL{
+			{Token: "token-a", Namespaces: []string{"ns-a"}, Operations: []string{config.ACLOperationRead}},
+			{Token: "token-b", Namespaces: []string{constants.DefaultNamespace}, Operations: []string{config.ACLOperationRead}},
+		}),
+	})
+	r := gin.New()
+	api.Register(r)
+
+	searchWithToken := func(token, sql string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, MetricQueryPath+"?db=test&sql="+url.QueryEscape(sql), nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, req)
+		return resp
+	}
+	search := func(sql string) *httptest.ResponseRecorder {
+		return searchWithToken("token-a", sql)
+	}
+	// namespace out of scope
+	assert.Equal(t, http.StatusForbidden, search("select f on 'ns-b' from cpu").Code)
+	// default namespace out of scope
+	assert.Equal(t, http.StatusForbidden, search("select f from cpu").Code)
+	// empty namespace is checked as default namespace
+	assert.Equal(t, http.StatusForbidden, search("select f on '' from cpu").Code)
+	// bad sql
+	assert.Equal(t, http.StatusInternalServerError, search("select f").Code)
+
+	metricQuery := brokerQuery.NewMockMetricQuery(ctrl)
+	queryFactory.EXPECT().NewMetricQuery(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(metricQuery)
+	metricQuery.EXPECT().WaitResponse().Return(&models.ResultSet{}, nil)
+	assert.Equal(t, http.StatusOK, search("select f on 'ns-a' from cpu").Code)
+
+	// empty namespace is allowed by default namespace
+	queryFactory.EXPECT().NewMetricQuery(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(metricQuery)
+	metricQuery.EXPECT().WaitResponse().Return(&models.ResultSet{}, nil)
+	assert.Equal(t, http.StatusOK, searchWithToken("token-b", "select f on '' from cpu").Code)
+}
+
+func Test_aclNamespace(t *testing.T) {
+	assert.Equal(t, constants.DefaultNamespace, aclNamespace(""))
+	assert.Equal(t, "ns", aclNamespace("ns"))
+}
+
+func TestMetricAPI_Search_RateLimited(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	queryFactory := brokerQuery.NewMockFactory(ctrl)
+	api := NewMetricAPI(&deps.HTTPDeps{
+		BrokerCfg:    &config.Broker{Query: config.Query{Timeout: ltoml.Duration(time.Second)}},
+		QueryFactory: queryFactory,
+		QueryLimiter: concurrent.NewLimiter(
+			context.TODO(),
+			2,
+			time.Second*5,
+			linmetric.NewScope("metric_data_search_rate_limited"),
+		),
+		TokenQueryLimiter: brokerQuery.NewTokenRateLimiter(config.Query{}, []config.ACL{
+			{Token: "token-a", MaxQueriesPerSecond: 0.1, QueryBurst: 1},
+		}),
+	})
+	r := gin.New()
+	api.Register(r)
+
+	search := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, MetricQueryPath+"?db=test&sql="+url.QueryEscape("select f from cpu"), nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, req)
+		return resp
+	}
+	metricQuery := brokerQuery.NewMockMetricQuery(ctrl)
+	queryFactory.EXPECT().NewMetricQuery(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(metricQuery).Times(2)
+	metricQuery.EXPECT().WaitResponse().Return(&models.ResultSet{}, nil).Times(2)
+	assert.Equal(t, http.StatusOK, search("token-a").Code)
+	// exceeds the limit of token
+	resp := search("token-a")
+	assert.Equal(t, http.StatusTooManyRequests, resp.Code)
+	assert.Equal(t, "10", resp.Header().Get("Retry-After"))
+	// other token is not limited
+	assert.Equal(t, http.StatusOK, search("token-b").Code)
+}
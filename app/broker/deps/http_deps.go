@@ -24,6 +24,7 @@ import (
 	"github.com/lindb/lindb/coordinator"
 	"github.com/lindb/lindb/coordinator/broker"
 	"github.com/lindb/lindb/internal/concurrent"
+	"github.com/lindb/lindb/pkg/http/middleware"
 	"github.com/lindb/lindb/pkg/state"
 	brokerQuery "github.com/lindb/lindb/query/broker"
 	"github.com/lindb/lindb/replica"
@@ -42,8 +43,14 @@ type HTTPDeps struct {
 	CM            replica.ChannelManager
 	IngestLimiter *concurrent.Limiter
 	QueryLimiter  *concurrent.Limiter
+	// DatabaseQueryLimiter limits concurrent queries of each database, nil if no limit
+	DatabaseQueryLimiter *brokerQuery.DatabaseLimiter
+	// TokenQueryLimiter limits query rate of each api token, nil if no limit
+	TokenQueryLimiter *brokerQuery.TokenRateLimiter
 
 	QueryFactory brokerQuery.Factory
+	// ACL checks namespace level access, nil if no acl configured
+	ACL *middleware.NamespaceACL
 
 	GlobalKeyValues tag.Tags
 }
@@ -38,6 +38,7 @@ import (
 	"github.com/lindb/lindb/monitoring"
 	"github.com/lindb/lindb/pkg/hostutil"
 	httppkg "github.com/lindb/lindb/pkg/http"
+	"github.com/lindb/lindb/pkg/http/middleware"
 	"github.com/lindb/lindb/pkg/logger"
 	"github.com/lindb/lindb/pkg/state"
 	"github.com/lindb/lindb/pkg/timeutil"
@@ -90,6 +91,7 @@ type runtime struct {
 	registry            discovery.Registry
 	stateMachineFactory discovery.StateMachineFactory
 	stateMgr            broker.StateManager
+	circuitBreakers     rpc.CircuitBreakers // nil if circuit breaker disabled
 
 	grpcServer rpc.GRPCServer
 	rpcHandler *rpcHandler
@@ -164,6 +166,12 @@ func (r *runtime) Run() error {
 		{Key: []byte("role"), Value: []byte(constants.BrokerRole)},
 	}
 
+	grpcCfg := r.config.BrokerBase.GRPC
+	rpc.SetConnPoolOption(rpc.ConnPoolOption{
+		MinSize:     grpcCfg.ConnPoolMinSize,
+		MaxSize:     grpcCfg.ConnPoolMaxSize,
+		IdleTimeout: grpcCfg.ConnPoolIdleTimeout.Duration(),
+	})
 	tackClientFct := rpc.NewTaskClientFactory(r.ctx, r.node)
 	r.factory = factory{
 		taskClient:    tackClientFct,
@@ -171,11 +179,15 @@ func (r *runtime) Run() error {
 		connectionMgr: rpc.NewConnectionManager(tackClientFct),
 	}
 
+	if r.config.BrokerBase.CircuitBreaker.FailureThreshold > 0 {
+		r.circuitBreakers = rpc.NewCircuitBreakers(r.config.BrokerBase.CircuitBreaker)
+	}
 	r.stateMgr = broker.NewStateManager(
 		r.ctx,
 		*r.node,
 		r.factory.connectionMgr,
-		r.factory.taskClient)
+		r.factory.taskClient,
+		r.circuitBreakers)
 
 	r.buildServiceDependency()
 
@@ -285,6 +297,7 @@ func (r *runtime) Stop() {
 	if r.factory.connectionMgr != nil {
 		_ = r.factory.connectionMgr.Close()
 	}
+	_ = rpc.GetClientConnFactory().Close()
 	r.log.Info("close connections successfully")
 
 	// finally shutdown rpc server
@@ -316,15 +329,21 @@ func (r *runtime) startHTTPServer() {
 			r.config.BrokerBase.Ingestion.IngestTimeout.Duration(),
 			linmetric.NewScope("lindb.broker.ingestion_limiter"),
 		),
+		ACL: middleware.NewNamespaceACL(r.config.BrokerBase.ACL),
 		QueryLimiter: concurrent.NewLimiter(
 			r.ctx,
 			r.config.Query.QueryConcurrency,
 			r.config.Query.Timeout.Duration(),
 			linmetric.NewScope("lindb.broker.query_limiter"),
 		),
+		DatabaseQueryLimiter: brokerQuery.NewDatabaseLimiter(r.config.Query, r.stateMgr),
+		TokenQueryLimiter:    brokerQuery.NewTokenRateLimiter(r.config.Query, r.config.BrokerBase.ACL),
 		QueryFactory: brokerQuery.NewQueryFactory(
 			r.stateMgr,
 			r.srv.taskManager,
+			brokerQuery.NewResultCache(
+				r.config.Query.ResultCacheSize,
+				r.config.Query.ResultCacheTTL.Duration()),
 		),
 		GlobalKeyValues: r.globalKeyValues,
 	})
@@ -363,6 +382,7 @@ func (r *runtime) buildServiceDependency() {
 		r.factory.taskServer,
 		r.queryPool,
 		r.config.Query.Timeout.Duration(),
+		r.circuitBreakers,
 	)
 
 	// close connections in connection-manager
@@ -0,0 +1,211 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package api
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/models"
+	httppkg "github.com/lindb/lindb/pkg/http"
+	"github.com/lindb/lindb/tsdb"
+)
+
+var (
+	// DatabasesPath represents the path of listing databases in storage engine.
+	DatabasesPath = "/engine/databases"
+	// ShardsPath represents the path of listing shards of database in storage engine.
+	ShardsPath = "/engine/shards"
+	// ShardEventsPath represents the path of listing lifecycle events of shard in storage engine.
+	ShardEventsPath = "/engine/shard/events"
+	// SeriesWALStatusPath represents the path of listing series wal recovery status of databases in storage engine.
+	SeriesWALStatusPath = "/engine/series-wal/status"
+	// MetricIDMappingPath represents the path of dumping metric id mapping of database in storage engine.
+	MetricIDMappingPath = "/engine/metric/id-mapping"
+	// ShardPausePath represents the path of pausing writes of shard in storage engine.
+	ShardPausePath = "/engine/shard/pause"
+	// ShardResumePath represents the path of resuming writes of shard in storage engine.
+	ShardResumePath = "/engine/shard/resume"
+	// ShardCompactPath represents the path of compacting data families of shard in storage engine.
+	ShardCompactPath = "/engine/shard/compact"
+)
+
+// defaultCompactTimeout is the default timeout of waiting shard compaction completed.
+const defaultCompactTimeout = 5 * time.Minute
+
+// EngineAPI represents the read-only inspection rest api of storage engine.
+type EngineAPI struct {
+	engine tsdb.Engine
+}
+
+// NewEngineAPI creates storage engine api instance.
+func NewEngineAPI(engine tsdb.Engine) *EngineAPI {
+	return &EngineAPI{
+		engine: engine,
+	}
+}
+
+// Register adds storage engine url route.
+func (e *EngineAPI) Register(route gin.IRoutes) {
+	route.GET(DatabasesPath, e.ListDatabases)
+	route.GET(ShardsPath, e.ListShards)
+	route.GET(ShardEventsPath, e.ListShardEvents)
+	route.GET(SeriesWALStatusPath, e.ListSeriesWALStatus)
+	route.GET(MetricIDMappingPath, e.DumpMetricIDMapping)
+	route.PUT(ShardPausePath, e.PauseShard)
+	route.PUT(ShardResumePath, e.ResumeShard)
+	route.PUT(ShardCompactPath, e.CompactShard)
+}
+
+// ListDatabases lists all databases with shard ids and storage size.
+func (e *EngineAPI) ListDatabases(c *gin.Context) {
+	httppkg.OK(c, e.engine.Databases())
+}
+
+// ListShards lists all shards of database with segment count and storage size.
+func (e *EngineAPI) ListShards(c *gin.Context) {
+	var param struct {
+		Database string `form:"db" binding:"required"`
+	}
+	if err := c.ShouldBindQuery(&param); err != nil {
+		httppkg.Error(c, err)
+		return
+	}
+	shards := e.engine.ShardsOf(param.Database)
+	if shards == nil {
+		httppkg.NotFound(c)
+		return
+	}
+	httppkg.OK(c, shards)
+}
+
+// ListShardEvents lists the latest lifecycle events(segment/family creation, flush, close) of shard
+// in chronological order.
+func (e *EngineAPI) ListShardEvents(c *gin.Context) {
+	var param struct {
+		Database string `form:"db" binding:"required"`
+		ShardID  *int   `form:"shardID" binding:"required"`
+	}
+	if err := c.ShouldBindQuery(&param); err != nil {
+		httppkg.Error(c, err)
+		return
+	}
+	shard, ok := e.engine.GetShard(param.Database, models.ShardID(*param.ShardID))
+	if !ok {
+		httppkg.NotFound(c)
+		return
+	}
+	httppkg.OK(c, shard.Events())
+}
+
+// ListSeriesWALStatus lists the series wal recovery status(need recovery, pending entries, last sync time)
+// of all databases, used to observe recovery progress during startup.
+func (e *EngineAPI) ListSeriesWALStatus(c *gin.Context) {
+	httppkg.OK(c, e.engine.SeriesWALStatus())
+}
+
+// DumpMetricIDMapping dumps the cached and backend stored id mapping(sequence, tags hash => series id)
+// of metric for each shard under database, highlighting divergences between them.
+func (e *EngineAPI) DumpMetricIDMapping(c *gin.Context) {
+	var param struct {
+		Database string  `form:"db" binding:"required"`
+		MetricID *uint32 `form:"metricID" binding:"required"`
+	}
+	if err := c.ShouldBindQuery(&param); err != nil {
+		httppkg.Error(c, err)
+		return
+	}
+	dumps, err := e.engine.DumpMetricIDMapping(param.Database, *param.MetricID)
+	if errors.Is(err, constants.ErrDatabaseNotFound) {
+		httppkg.NotFound(c)
+		return
+	}
+	if err != nil {
+		httppkg.Error(c, err)
+		return
+	}
+	httppkg.OK(c, dumps)
+}
+
+// PauseShard pauses writes of shard, writes are rejected with retriable error until resumed,
+// reads continue during the pause.
+func (e *EngineAPI) PauseShard(c *gin.Context) {
+	e.switchShardWrite(c, e.engine.PauseShard)
+}
+
+// ResumeShard resumes writes of shard paused by PauseShard.
+func (e *EngineAPI) ResumeShard(c *gin.Context) {
+	e.switchShardWrite(c, e.engine.ResumeShard)
+}
+
+// switchShardWrite pauses/resumes writes of shard by given switch function.
+func (e *EngineAPI) switchShardWrite(c *gin.Context, fn func(databaseName string, shardID models.ShardID) error) {
+	var param struct {
+		Database string `form:"db" binding:"required"`
+		ShardID  *int   `form:"shardID" binding:"required"`
+	}
+	if err := c.ShouldBindQuery(&param); err != nil {
+		httppkg.Error(c, err)
+		return
+	}
+	err := fn(param.Database, models.ShardID(*param.ShardID))
+	if errors.Is(err, constants.ErrShardNotFound) {
+		httppkg.NotFound(c)
+		return
+	}
+	if err != nil {
+		httppkg.Error(c, err)
+		return
+	}
+	httppkg.OK(c, "success")
+}
+
+// CompactShard compacts the level0 files of shard's data families immediately(all families if familyTime not set),
+// returns when compaction completed or timeout.
+func (e *EngineAPI) CompactShard(c *gin.Context) {
+	var param struct {
+		Database   string        `form:"db" binding:"required"`
+		ShardID    *int          `form:"shardID" binding:"required"`
+		FamilyTime int64         `form:"familyTime"`
+		Timeout    time.Duration `form:"timeout"`
+	}
+	if err := c.ShouldBindQuery(&param); err != nil {
+		httppkg.Error(c, err)
+		return
+	}
+	timeout := param.Timeout
+	if timeout <= 0 {
+		timeout = defaultCompactTimeout
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+	defer cancel()
+	err := e.engine.CompactShard(ctx, param.Database, models.ShardID(*param.ShardID), param.FamilyTime)
+	if errors.Is(err, constants.ErrNotFound) {
+		httppkg.NotFound(c)
+		return
+	}
+	if err != nil {
+		httppkg.Error(c, err)
+		return
+	}
+	httppkg.OK(c, "success")
+}
@@ -0,0 +1,146 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/internal/mock"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/tsdb"
+	"github.com/lindb/lindb/tsdb/indexdb"
+)
+
+func TestEngineAPI(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	engine := tsdb.NewMockEngine(ctrl)
+	api := NewEngineAPI(engine)
+	r := gin.New()
+	api.Register(r)
+
+	// case 1: list databases
+	engine.EXPECT().Databases().Return([]tsdb.DatabaseInfo{{Name: "db", ShardIDs: nil, Size: 10}})
+	resp := mock.DoRequest(t, r, http.MethodGet, DatabasesPath, "")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), `"name":"db"`)
+	// case 2: list shards without database
+	resp = mock.DoRequest(t, r, http.MethodGet, ShardsPath, "")
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	// case 3: database not found
+	engine.EXPECT().ShardsOf("db").Return(nil)
+	resp = mock.DoRequest(t, r, http.MethodGet, ShardsPath+"?db=db", "")
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+	// case 4: list shards
+	engine.EXPECT().ShardsOf("db").Return([]tsdb.ShardInfo{{ShardID: 1, Path: "db/1", Segments: 2, Size: 10}})
+	resp = mock.DoRequest(t, r, http.MethodGet, ShardsPath+"?db=db", "")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), `"segments":2`)
+	// case 5: list shard events without shard id
+	resp = mock.DoRequest(t, r, http.MethodGet, ShardEventsPath+"?db=db", "")
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	// case 6: shard not found
+	engine.EXPECT().GetShard("db", models.ShardID(0)).Return(nil, false)
+	resp = mock.DoRequest(t, r, http.MethodGet, ShardEventsPath+"?db=db&shardID=0", "")
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+	// case 7: list shard events
+	shard := tsdb.NewMockShard(ctrl)
+	shard.EXPECT().Events().Return([]tsdb.ShardEvent{{Timestamp: 10, Type: tsdb.FamilyFlushEvent, Target: "db/1/10", Size: 100}})
+	engine.EXPECT().GetShard("db", models.ShardID(1)).Return(shard, true)
+	resp = mock.DoRequest(t, r, http.MethodGet, ShardEventsPath+"?db=db&shardID=1", "")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), `"type":"family_flush"`)
+	// case 8: list series wal status
+	engine.EXPECT().SeriesWALStatus().Return([]tsdb.DatabaseRecoveryStatus{{Name: "db", NeedRecovery: true, PendingEntries: 10}})
+	resp = mock.DoRequest(t, r, http.MethodGet, SeriesWALStatusPath, "")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), `"needRecovery":true`)
+	// case 9: dump metric id mapping without metric id
+	resp = mock.DoRequest(t, r, http.MethodGet, MetricIDMappingPath+"?db=db", "")
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	// case 10: database not found
+	engine.EXPECT().DumpMetricIDMapping("db", uint32(1)).Return(nil, fmt.Errorf("%w", constants.ErrDatabaseNotFound))
+	resp = mock.DoRequest(t, r, http.MethodGet, MetricIDMappingPath+"?db=db&metricID=1", "")
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+	// case 11: dump failure
+	engine.EXPECT().DumpMetricIDMapping("db", uint32(1)).Return(nil, fmt.Errorf("err"))
+	resp = mock.DoRequest(t, r, http.MethodGet, MetricIDMappingPath+"?db=db&metricID=1", "")
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	// case 12: dump metric id mapping
+	engine.EXPECT().DumpMetricIDMapping("db", uint32(1)).Return([]tsdb.ShardMetricIDMappingDump{{
+		ShardID: 1,
+		MetricIDMappingDump: &indexdb.MetricIDMappingDump{
+			MetricID:    1,
+			Cached:      &indexdb.MetricIDMappingState{Sequence: 2, Series: map[uint64]uint32{100: 1, 200: 2}},
+			Backend:     &indexdb.MetricIDMappingState{Sequence: 1, Series: map[uint64]uint32{100: 1}},
+			Divergences: []indexdb.MappingDivergence{{TagsHash: 200, CachedSeriesID: 2}},
+		},
+	}}, nil)
+	resp = mock.DoRequest(t, r, http.MethodGet, MetricIDMappingPath+"?db=db&metricID=1", "")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), `"shardID":1`)
+	assert.Contains(t, resp.Body.String(), `"divergences":[{"tagsHash":200,"cachedSeriesID":2,"backendSeriesID":0}]`)
+	// case 13: pause shard without shard id
+	resp = mock.DoRequest(t, r, http.MethodPut, ShardPausePath+"?db=db", "")
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	// case 14: pause shard not found
+	engine.EXPECT().PauseShard("db", models.ShardID(1)).Return(fmt.Errorf("%w", constants.ErrShardNotFound))
+	resp = mock.DoRequest(t, r, http.MethodPut, ShardPausePath+"?db=db&shardID=1", "")
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+	// case 15: pause shard
+	engine.EXPECT().PauseShard("db", models.ShardID(1)).Return(nil)
+	resp = mock.DoRequest(t, r, http.MethodPut, ShardPausePath+"?db=db&shardID=1", "")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	// case 16: resume shard failure
+	engine.EXPECT().ResumeShard("db", models.ShardID(1)).Return(fmt.Errorf("err"))
+	resp = mock.DoRequest(t, r, http.MethodPut, ShardResumePath+"?db=db&shardID=1", "")
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	// case 17: resume shard
+	engine.EXPECT().ResumeShard("db", models.ShardID(1)).Return(nil)
+	resp = mock.DoRequest(t, r, http.MethodPut, ShardResumePath+"?db=db&shardID=1", "")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	// case 18: compact shard param invalid
+	resp = mock.DoRequest(t, r, http.MethodPut, ShardCompactPath+"?db=db", "")
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	// case 19: compact data family not found
+	engine.EXPECT().CompactShard(gomock.Any(), "db", models.ShardID(1), int64(10)).
+		Return(fmt.Errorf("%w", constants.ErrDataFamilyNotFound))
+	resp = mock.DoRequest(t, r, http.MethodPut, ShardCompactPath+"?db=db&shardID=1&familyTime=10", "")
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+	// case 20: compact shard timeout
+	engine.EXPECT().CompactShard(gomock.Any(), "db", models.ShardID(1), int64(0)).
+		DoAndReturn(func(ctx context.Context, _ string, _ models.ShardID, _ int64) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+	resp = mock.DoRequest(t, r, http.MethodPut, ShardCompactPath+"?db=db&shardID=1&timeout=10ms", "")
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	// case 21: compact shard
+	engine.EXPECT().CompactShard(gomock.Any(), "db", models.ShardID(1), int64(0)).Return(nil)
+	resp = mock.DoRequest(t, r, http.MethodPut, ShardCompactPath+"?db=db&shardID=1", "")
+	assert.Equal(t, http.StatusOK, resp.Code)
+}
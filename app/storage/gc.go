@@ -0,0 +1,53 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package storage
+
+import (
+	"math"
+	"runtime/debug"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/pkg/logger"
+	"github.com/lindb/lindb/pkg/ltoml"
+)
+
+// applyGCSettings applies the gc tuning config to go runtime, logs the effective settings.
+// Runtime settings(GOGC/GOMEMLIMIT env) are kept if not configured.
+func applyGCSettings(gcCfg *config.GC, log *logger.Logger) {
+	if gcCfg.Percent != 0 {
+		debug.SetGCPercent(gcCfg.Percent)
+	}
+	if gcCfg.MemoryLimit > 0 {
+		if memoryLimitSupported {
+			setMemoryLimit(int64(gcCfg.MemoryLimit))
+		} else {
+			log.Warn("gc memory limit is not supported by go runtime, ignore it",
+				logger.String("memoryLimit", gcCfg.MemoryLimit.String()))
+		}
+	}
+	// read effective settings
+	gcPercent := debug.SetGCPercent(100)
+	debug.SetGCPercent(gcPercent)
+	memoryLimit := "unlimited"
+	if limit := setMemoryLimit(-1); limit != math.MaxInt64 {
+		memoryLimit = ltoml.Size(limit).String()
+	}
+	log.Info("gc settings applied",
+		logger.Int("percent", gcPercent),
+		logger.String("memoryLimit", memoryLimit))
+}
@@ -0,0 +1,51 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build go1.19
+// +build go1.19
+
+package storage
+
+import (
+	"runtime/debug"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/pkg/logger"
+	"github.com/lindb/lindb/pkg/ltoml"
+)
+
+func TestApplyGCSettings(t *testing.T) {
+	gcPercent := debug.SetGCPercent(100)
+	memoryLimit := debug.SetMemoryLimit(-1)
+	defer func() {
+		debug.SetGCPercent(gcPercent)
+		debug.SetMemoryLimit(memoryLimit)
+	}()
+	log := logger.GetLogger("storage", "Test")
+
+	// case 1: apply gc percent and memory limit
+	applyGCSettings(&config.GC{Percent: 50, MemoryLimit: ltoml.Size(1024 * 1024 * 1024)}, log)
+	assert.Equal(t, int64(1024*1024*1024), debug.SetMemoryLimit(-1))
+	assert.Equal(t, 50, debug.SetGCPercent(50))
+	// case 2: keep runtime settings if not configured
+	applyGCSettings(&config.GC{}, log)
+	assert.Equal(t, int64(1024*1024*1024), debug.SetMemoryLimit(-1))
+	assert.Equal(t, 50, debug.SetGCPercent(50))
+}
@@ -20,10 +20,13 @@ package rpc
 import (
 	"context"
 	"io"
+	"sync"
+	"time"
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
+	"github.com/lindb/lindb/config"
 	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/pkg/encoding"
@@ -50,15 +53,74 @@ func NewWriteHandler(
 	}
 }
 
+const (
+	// defaultFlowControlInterval is used if flow control interval of wal is not configured.
+	defaultFlowControlInterval = time.Second
+	// maxPendingAcks is the max number of responses waiting to be sent of write stream,
+	// receiving is blocked if reached.
+	maxPendingAcks = 1024
+)
+
+// pendingAck represents the response of write request, which is sent after wal synced or quorum acked.
+type pendingAck struct {
+	resp       *protoWriteV1.WriteResponse
+	acceptedAt int64 // time(unix nano) when broker accepts the record
+	sync       bool  // flushes wal to disk before responding
+	quorum     bool  // waits majority replicas acked before responding
+}
+
+// wait waits the wal synced or acked by majority replicas if required.
+func (a *pendingAck) wait(p replica.Partition) error {
+	if a.sync {
+		if err := p.SyncLog(a.resp.Sequence); err != nil {
+			return err
+		}
+	}
+	if a.quorum {
+		return p.WaitQuorum(a.resp.Sequence)
+	}
+	return nil
+}
+
 // Write does metric write request.
 func (r *WriteHandler) Write(server protoWriteV1.WriteService_WriteServer) error {
-	familyState, err := r.getFamilyInfoFromCtx(server.Context())
+	p, err := r.prepareWrite(server.Context())
+	if err != nil {
+		return err
+	}
+	return r.handleWrite(p, server.Recv, server.Send)
+}
+
+// StreamWrite does metric write request like Write, and sends flow control signal periodically
+// based on the wal queue depth, so that client pauses sending when wal is backpressured.
+func (r *WriteHandler) StreamWrite(server protoWriteV1.WriteService_StreamWriteServer) error {
+	p, err := r.prepareWrite(server.Context())
+	if err != nil {
+		return err
+	}
+	// grpc stream does not support sending concurrently
+	var sendLock sync.Mutex
+	send := func(resp *protoWriteV1.WriteResponse) error {
+		sendLock.Lock()
+		defer sendLock.Unlock()
+		return server.Send(resp)
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go r.sendFlowControl(server.Context(), done, p, send)
+
+	return r.handleWrite(p, server.Recv, send)
+}
+
+// prepareWrite returns the wal partition of write stream, builds replica relation for leader.
+func (r *WriteHandler) prepareWrite(ctx context.Context) (replica.Partition, error) {
+	familyState, err := r.getFamilyInfoFromCtx(ctx)
 	if err != nil {
 		r.logger.Error("get param err", logger.Error(err))
-		return status.Error(codes.InvalidArgument, err.Error())
+		return nil, status.Error(codes.InvalidArgument, err.Error())
 	}
 	if len(familyState.Shard.Replica.Replicas) == 0 {
-		return status.Error(codes.InvalidArgument, "replicas cannot be empty")
+		return nil, status.Error(codes.InvalidArgument, "replicas cannot be empty")
 	}
 
 	p, err := r.getOrCreatePartition(
@@ -68,17 +130,51 @@ func (r *WriteHandler) Write(server protoWriteV1.WriteService_WriteServer) error
 		familyState.Shard.Leader)
 	if err != nil {
 		r.logger.Error("get or create wal partition err, when do write", logger.Error(err))
-		return status.Error(codes.Internal, err.Error())
+		return nil, status.Error(codes.Internal, err.Error())
 	}
 	err = p.BuildReplicaForLeader(familyState.Shard.Leader, familyState.Shard.Replica.Replicas)
 	if err != nil {
 		r.logger.Error("build replica replica err", logger.Error(err))
-		return status.Error(codes.Internal, err.Error())
+		return nil, status.Error(codes.Internal, err.Error())
 	}
+	return p, nil
+}
+
+// handleWrite handles write request from stream until stream closed,
+// responses are sent by ack goroutine in order of requests, so that waiting wal synced
+// or quorum acked does not block receiving the following requests.
+func (r *WriteHandler) handleWrite(
+	p replica.Partition,
+	recv func() (*protoWriteV1.WriteRequest, error),
+	send func(resp *protoWriteV1.WriteResponse) error,
+) error {
+	acks := make(chan *pendingAck, maxPendingAcks)
+	ackDone := make(chan struct{})
+	var ackErr error
+	go func() {
+		defer close(ackDone)
+		ackErr = r.sendAcks(p, acks, send)
+	}()
+
+	err := r.receiveWrite(p, recv, acks, ackDone)
+	// waits pending responses sent
+	close(acks)
+	<-ackDone
+	if err != nil {
+		return err
+	}
+	return ackErr
+}
 
-	// handle write request from stream
+// receiveWrite receives write request and writes wal, then puts the response into ack queue.
+func (r *WriteHandler) receiveWrite(
+	p replica.Partition,
+	recv func() (*protoWriteV1.WriteRequest, error),
+	acks chan<- *pendingAck,
+	ackDone <-chan struct{},
+) error {
 	for {
-		req, err := server.Recv()
+		req, err := recv()
 		if err == io.EOF {
 			return nil
 		}
@@ -87,18 +183,81 @@ func (r *WriteHandler) Write(server protoWriteV1.WriteService_WriteServer) error
 			return status.Error(codes.Internal, err.Error())
 		}
 
-		resp := &protoWriteV1.WriteResponse{}
+		ack := &pendingAck{resp: &protoWriteV1.WriteResponse{}, acceptedAt: req.AcceptedAt}
 		// write wal log
-		err = p.WriteLog(req.Record)
+		if err := p.WriteLog(req.Record); err != nil {
+			ack.resp.Err = err.Error()
+		} else {
+			// last appended msg(including current msg), broker returns it as write token for read-your-writes
+			ack.resp.Sequence = p.ReplicaAckIndex()
+			if len(req.Record) > 0 {
+				ack.sync = req.Sync
+				ack.quorum = req.Quorum
+			}
+		}
 
-		if err != nil {
-			resp.Err = err.Error()
+		select {
+		case acks <- ack:
+		case <-ackDone:
+			// send response failure, returns the err of ack goroutine
+			return nil
 		}
+	}
+}
 
-		if err := server.Send(resp); err != nil {
+// sendAcks sends the responses in order after wal synced or quorum acked if required.
+func (r *WriteHandler) sendAcks(
+	p replica.Partition,
+	acks <-chan *pendingAck,
+	send func(resp *protoWriteV1.WriteResponse) error,
+) error {
+	for ack := range acks {
+		if err := ack.wait(p); err != nil {
+			ack.resp.Err = err.Error()
+		} else if ack.sync {
+			// echo accepted time only after wal synced, so that broker measures end-to-end durable write latency by its clock
+			ack.resp.AcceptedAt = ack.acceptedAt
+		}
+		if err := send(ack.resp); err != nil {
 			return status.Error(codes.Internal, err.Error())
 		}
 	}
+	return nil
+}
+
+// sendFlowControl sends flow control signal of wal partition periodically until stream done.
+func (r *WriteHandler) sendFlowControl(
+	ctx context.Context,
+	done <-chan struct{},
+	p replica.Partition,
+	send func(resp *protoWriteV1.WriteResponse) error,
+) {
+	interval := config.GlobalStorageConfig().WAL.FlowControlInterval.Duration()
+	if interval <= 0 {
+		interval = defaultFlowControlInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-done:
+			return
+		case <-ticker.C:
+			queueDepth, backpressure := p.FlowControl()
+			if err := send(&protoWriteV1.WriteResponse{
+				FlowControl: &protoWriteV1.FlowControl{
+					Backpressure: backpressure,
+					QueueDepth:   queueDepth,
+				},
+			}); err != nil {
+				r.logger.Warn("send flow control signal err", logger.Error(err))
+				return
+			}
+		}
+	}
 }
 
 // getFamilyInfoFromCtx returns family state metadata from rpc context.
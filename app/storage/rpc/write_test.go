@@ -22,13 +22,17 @@ import (
 	"fmt"
 	"io"
 	"strconv"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"google.golang.org/grpc/metadata"
 
+	"github.com/lindb/lindb/config"
 	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/pkg/ltoml"
 	protoWriteV1 "github.com/lindb/lindb/proto/gen/v1/write"
 	"github.com/lindb/lindb/replica"
 )
@@ -88,17 +92,161 @@ func TestWriteHandler_Write(t *testing.T) {
 	replicaServer.EXPECT().Recv().Return(nil, io.EOF)
 	err = r.Write(replicaServer)
 	assert.NoError(t, err)
-	// case 9: write wal err
+	// case 9: write wal err, send response err
 	replicaServer.EXPECT().Recv().Return(&protoWriteV1.WriteRequest{}, nil)
 	p.EXPECT().WriteLog(gomock.Any()).Return(fmt.Errorf("err"))
 	replicaServer.EXPECT().Send(gomock.Any()).Return(fmt.Errorf("err"))
+	replicaServer.EXPECT().Recv().Return(nil, io.EOF).MaxTimes(1)
 	err = r.Write(replicaServer)
 	assert.Error(t, err)
-	// case 10: write wal ok
-	replicaServer.EXPECT().Recv().Return(&protoWriteV1.WriteRequest{}, nil)
+	// case 10: write wal ok without sync, accepted time isn't echoed
+	replicaServer.EXPECT().Recv().Return(&protoWriteV1.WriteRequest{Record: []byte{1}, AcceptedAt: 100}, nil)
 	p.EXPECT().WriteLog(gomock.Any()).Return(nil)
-	replicaServer.EXPECT().Send(gomock.Any()).Return(nil)
+	p.EXPECT().ReplicaAckIndex().Return(int64(9))
+	replicaServer.EXPECT().Send(&protoWriteV1.WriteResponse{Sequence: 9}).Return(nil)
 	replicaServer.EXPECT().Recv().Return(nil, io.EOF)
 	err = r.Write(replicaServer)
 	assert.NoError(t, err)
+	// case 11: quorum write syncs wal and waits majority replicas acked
+	// echoes accepted time after wal synced
+	replicaServer.EXPECT().Recv().Return(&protoWriteV1.WriteRequest{Record: []byte{1}, Sync: true, Quorum: true, AcceptedAt: 100}, nil)
+	p.EXPECT().WriteLog(gomock.Any()).Return(nil)
+	p.EXPECT().ReplicaAckIndex().Return(int64(10))
+	p.EXPECT().SyncLog(int64(10)).Return(nil)
+	p.EXPECT().WaitQuorum(int64(10)).Return(nil)
+	replicaServer.EXPECT().Send(&protoWriteV1.WriteResponse{AcceptedAt: 100, Sequence: 10}).Return(nil)
+	// case 12: quorum write not acked in time
+	replicaServer.EXPECT().Recv().Return(&protoWriteV1.WriteRequest{Record: []byte{1}, Quorum: true}, nil)
+	p.EXPECT().WriteLog(gomock.Any()).Return(nil)
+	p.EXPECT().ReplicaAckIndex().Return(int64(11))
+	p.EXPECT().WaitQuorum(int64(11)).Return(replica.ErrQuorumAckTimeout)
+	replicaServer.EXPECT().Send(&protoWriteV1.WriteResponse{
+		Sequence: 11,
+		Err:      replica.ErrQuorumAckTimeout.Error(),
+	}).Return(nil)
+	// case 13: sync wal err
+	replicaServer.EXPECT().Recv().Return(&protoWriteV1.WriteRequest{Record: []byte{1}, Sync: true, AcceptedAt: 100}, nil)
+	p.EXPECT().WriteLog(gomock.Any()).Return(nil)
+	p.EXPECT().ReplicaAckIndex().Return(int64(12))
+	p.EXPECT().SyncLog(int64(12)).Return(fmt.Errorf("sync err"))
+	replicaServer.EXPECT().Send(&protoWriteV1.WriteResponse{Sequence: 12, Err: "sync err"}).Return(nil)
+	// case 14: empty record, no need to wait
+	replicaServer.EXPECT().Recv().Return(&protoWriteV1.WriteRequest{Sync: true, Quorum: true}, nil)
+	p.EXPECT().WriteLog(gomock.Any()).Return(nil)
+	p.EXPECT().ReplicaAckIndex().Return(int64(12))
+	replicaServer.EXPECT().Send(&protoWriteV1.WriteResponse{Sequence: 12}).Return(nil)
+	replicaServer.EXPECT().Recv().Return(nil, io.EOF)
+	err = r.Write(replicaServer)
+	assert.NoError(t, err)
+}
+
+func TestWriteHandler_Write_AsyncAck(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	p := replica.NewMockPartition(ctrl)
+	r := NewWriteHandler(nil)
+
+	// quorum ack of first request is released after all requests received
+	received := make(chan struct{})
+	var seq int64
+	p.EXPECT().WriteLog(gomock.Any()).Return(nil).Times(3)
+	p.EXPECT().ReplicaAckIndex().DoAndReturn(func() int64 {
+		seq++
+		return seq
+	}).Times(3)
+	p.EXPECT().WaitQuorum(int64(1)).DoAndReturn(func(_ int64) error {
+		<-received
+		return nil
+	})
+	p.EXPECT().WaitQuorum(gomock.Any()).Return(nil).Times(2)
+	reqs := 0
+	recv := func() (*protoWriteV1.WriteRequest, error) {
+		if reqs == 3 {
+			close(received)
+			return nil, io.EOF
+		}
+		reqs++
+		return &protoWriteV1.WriteRequest{Record: []byte{1}, Quorum: true}, nil
+	}
+	var sequences []int64
+	send := func(resp *protoWriteV1.WriteResponse) error {
+		sequences = append(sequences, resp.Sequence)
+		return nil
+	}
+	assert.NoError(t, r.handleWrite(p, recv, send))
+	// responses are sent in order of requests
+	assert.Equal(t, []int64{1, 2, 3}, sequences)
+}
+
+func TestWriteHandler_StreamWrite(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defaultCfg := config.GlobalStorageConfig()
+	defer func() {
+		config.SetGlobalStorageConfig(defaultCfg)
+		ctrl.Finish()
+	}()
+	cfg := *defaultCfg
+	cfg.WAL.FlowControlInterval = ltoml.Duration(time.Millisecond)
+	config.SetGlobalStorageConfig(&cfg)
+
+	walMgr := replica.NewMockWriteAheadLogManager(ctrl)
+	server := protoWriteV1.NewMockWriteService_StreamWriteServer(ctrl)
+	r := NewWriteHandler(walMgr)
+
+	// case 1: family state not exist
+	server.EXPECT().Context().Return(context.TODO())
+	assert.Error(t, r.StreamWrite(server))
+
+	// case 2: write with flow control signals
+	ctx := metadata.NewIncomingContext(context.TODO(),
+		metadata.Pairs(constants.RPCMetaKeyFamilyState,
+			`{"database":"test-db","shard":{"id":1,"leader":2,"replica":{"replicas":[1,2]}},"familyTime":12321}`))
+	server.EXPECT().Context().Return(ctx).AnyTimes()
+	wal := replica.NewMockWriteAheadLog(ctrl)
+	p := replica.NewMockPartition(ctrl)
+	walMgr.EXPECT().GetOrCreateLog(gomock.Any()).Return(wal)
+	wal.EXPECT().GetOrCreatePartition(gomock.Any(), gomock.Any(), gomock.Any()).Return(p, nil)
+	p.EXPECT().BuildReplicaForLeader(gomock.Any(), gomock.Any()).Return(nil)
+	p.EXPECT().WriteLog(gomock.Any()).Return(nil)
+	p.EXPECT().ReplicaAckIndex().Return(int64(1))
+	gomock.InOrder(
+		p.EXPECT().FlowControl().Return(int64(100), true),
+		p.EXPECT().FlowControl().Return(int64(10), false).AnyTimes(),
+	)
+
+	var (
+		lock     sync.Mutex
+		signals  []*protoWriteV1.FlowControl
+		writeAck int
+	)
+	server.EXPECT().Send(gomock.Any()).DoAndReturn(func(resp *protoWriteV1.WriteResponse) error {
+		lock.Lock()
+		defer lock.Unlock()
+		if resp.FlowControl != nil {
+			signals = append(signals, resp.FlowControl)
+		} else {
+			writeAck++
+		}
+		return nil
+	}).AnyTimes()
+	gomock.InOrder(
+		server.EXPECT().Recv().Return(&protoWriteV1.WriteRequest{Record: []byte{1}}, nil),
+		server.EXPECT().Recv().DoAndReturn(func() (*protoWriteV1.WriteRequest, error) {
+			// wait flow control signals sent in background
+			assert.Eventually(t, func() bool {
+				lock.Lock()
+				defer lock.Unlock()
+				return len(signals) >= 2
+			}, time.Second, time.Millisecond)
+			return nil, io.EOF
+		}),
+	)
+	assert.NoError(t, r.StreamWrite(server))
+
+	lock.Lock()
+	defer lock.Unlock()
+	assert.Equal(t, 1, writeAck)
+	assert.Equal(t, &protoWriteV1.FlowControl{Backpressure: true, QueueDepth: 100}, signals[0])
+	assert.Equal(t, &protoWriteV1.FlowControl{QueueDepth: 10}, signals[1])
 }
@@ -26,6 +26,7 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/lindb/lindb/app/storage/api"
 	rpchandler "github.com/lindb/lindb/app/storage/rpc"
 	"github.com/lindb/lindb/config"
 	"github.com/lindb/lindb/constants"
@@ -37,6 +38,7 @@ import (
 	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/monitoring"
 	"github.com/lindb/lindb/pkg/encoding"
+	"github.com/lindb/lindb/pkg/fileutil"
 	"github.com/lindb/lindb/pkg/hostutil"
 	httppkg "github.com/lindb/lindb/pkg/http"
 	"github.com/lindb/lindb/pkg/logger"
@@ -51,6 +53,8 @@ import (
 	"github.com/lindb/lindb/rpc"
 	"github.com/lindb/lindb/series/tag"
 	"github.com/lindb/lindb/tsdb"
+	"github.com/lindb/lindb/tsdb/indexdb"
+	"github.com/lindb/lindb/tsdb/metadb"
 )
 
 // factory represents all factories for storage
@@ -135,6 +139,14 @@ func (r *runtime) Run() error {
 		return fmt.Errorf("failed to get server ip address, error: %s", err)
 	}
 
+	// pre-flight check tsdb dir, fails fast with an actionable error
+	if err := checkTSDBDir(&r.config.StorageBase.TSDB); err != nil {
+		r.state = server.Failed
+		return err
+	}
+
+	applyGCSettings(&r.config.StorageBase.GC, r.log)
+
 	// start tsdb engine for storage server
 	engine, err := tsdb.NewEngine()
 	if err != nil {
@@ -164,6 +176,12 @@ func (r *runtime) Run() error {
 		{Key: []byte("role"), Value: []byte(constants.StorageRole)},
 	}
 
+	grpcCfg := r.config.StorageBase.GRPC
+	rpc.SetConnPoolOption(rpc.ConnPoolOption{
+		MinSize:     grpcCfg.ConnPoolMinSize,
+		MaxSize:     grpcCfg.ConnPoolMaxSize,
+		IdleTimeout: grpcCfg.ConnPoolIdleTimeout.Duration(),
+	})
 	r.factory = factory{taskServer: rpc.NewTaskServerFactory()}
 	r.stateMgr = storage.NewStateManager(r.ctx, r.node, engine)
 
@@ -327,6 +345,7 @@ func (r *runtime) Stop() {
 		r.server.Stop()
 		r.log.Info("stopped GRPC server")
 	}
+	_ = rpc.GetClientConnFactory().Close()
 
 	// close the storage engine
 	if r.engine != nil {
@@ -349,6 +368,9 @@ func (r *runtime) startHTTPServer() {
 	r.httpServer = httppkg.NewServer(r.config.StorageBase.HTTP, false)
 	explore := monitoring.NewExploreAPI(r.globalKeyValues)
 	explore.Register(r.httpServer.GetAPIRouter())
+	health := monitoring.NewHealthAPI(indexdb.CheckSyncHealth, indexdb.CheckWarmup, metadb.CheckPreload, tsdb.CheckSegmentOpen)
+	health.Register(r.httpServer.GetAPIRouter())
+	api.NewEngineAPI(r.engine).Register(r.httpServer.GetAPIRouter())
 
 	go func() {
 		if err := r.httpServer.Run(); err != http.ErrServerClosed {
@@ -419,9 +441,45 @@ func (r *runtime) nativePusher() {
 func (r *runtime) systemCollector() {
 	r.log.Info("system collector is running")
 
-	go monitoring.NewSystemCollector(
+	collector := monitoring.NewSystemCollector(
 		r.ctx,
 		r.config.StorageBase.TSDB.Dir,
 		&r.node.StatelessNode,
-		constants.StorageRole).Run()
+		constants.StorageRole).
+		WithDataDirs(r.config.StorageBase.TSDB.DataDirs)
+	if r.config.Monitor.DatabaseDiskUsage {
+		collector.WithShardDiskUsage(r.shardDiskUsage)
+	}
+	go collector.Run()
+}
+
+// shardDiskUsage returns the disk usage of shards which storage engine reports, database name -> shard id -> used.
+func (r *runtime) shardDiskUsage() map[string]map[models.ShardID]int64 {
+	result := make(map[string]map[models.ShardID]int64)
+	for _, databaseName := range r.engine.DatabaseNames() {
+		shards := r.engine.ShardsOf(databaseName)
+		if shards == nil {
+			// database dropped
+			continue
+		}
+		usages := make(map[models.ShardID]int64, len(shards))
+		for _, shard := range shards {
+			usages[shard.ShardID] = shard.Size
+		}
+		result[databaseName] = usages
+	}
+	return result
+}
+
+// checkTSDBDir checks the tsdb dir exists(creates it if create-dir-if-missing enabled) and is writable.
+func checkTSDBDir(tsdbCfg *config.TSDB) error {
+	dir := tsdbCfg.Dir
+	if !fileutil.Exist(dir) && !tsdbCfg.CreateDirIfMissing {
+		return fmt.Errorf("tsdb dir[%s] does not exist, create it or set tsdb.create-dir-if-missing = true", dir)
+	}
+	if err := fileutil.CheckWritable(dir); err != nil {
+		return fmt.Errorf("tsdb dir[%s] is not writable: %s, "+
+			"check the permission of the dir and its parents or change tsdb.dir", dir, err)
+	}
+	return nil
 }
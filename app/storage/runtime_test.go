@@ -20,7 +20,9 @@ package storage
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strconv"
 	"testing"
 	"time"
@@ -35,9 +37,11 @@ import (
 	"github.com/lindb/lindb/internal/server"
 	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/pkg/encoding"
+	"github.com/lindb/lindb/pkg/fileutil"
 	"github.com/lindb/lindb/pkg/hostutil"
 	"github.com/lindb/lindb/pkg/ltoml"
 	"github.com/lindb/lindb/pkg/state"
+	"github.com/lindb/lindb/tsdb"
 )
 
 type testStorageRuntimeSuite struct {
@@ -60,10 +64,64 @@ var cfg = config.Storage{
 		GRPC: config.GRPC{
 			Port: 9999,
 		},
-		TSDB: config.TSDB{Dir: "/tmp/test/data"},
+		TSDB: config.TSDB{Dir: "/tmp/test/data", CreateDirIfMissing: true},
 	}, Monitor: *config.NewDefaultMonitor(),
 }
 
+func TestStorageRun_TSDBDir_Err(t *testing.T) {
+	dir := t.TempDir()
+	run := func(tsdbCfg config.TSDB) error {
+		storageCfg := cfg
+		storageCfg.StorageBase.Indicator = 5
+		storageCfg.StorageBase.TSDB = tsdbCfg
+		storage := NewStorageRuntime("test-version", &storageCfg)
+		err := storage.Run()
+		assert.Equal(t, server.Failed, storage.State())
+		return err
+	}
+	// case 1: dir not exist
+	notExist := filepath.Join(dir, "not-exist")
+	err := run(config.TSDB{Dir: notExist})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), notExist)
+	assert.Contains(t, err.Error(), "create-dir-if-missing")
+	assert.False(t, fileutil.Exist(notExist))
+	// case 2: dir is a file
+	file := filepath.Join(dir, "file")
+	assert.NoError(t, ioutil.WriteFile(file, []byte("abc"), 0644))
+	err = run(config.TSDB{Dir: file, CreateDirIfMissing: true})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "tsdb dir["+file+"] is not writable")
+
+	if os.Getuid() != 0 {
+		// case 3: read-only dir
+		readOnly := filepath.Join(dir, "read-only")
+		assert.NoError(t, os.Mkdir(readOnly, 0555))
+		err = run(config.TSDB{Dir: readOnly, CreateDirIfMissing: true})
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "tsdb dir["+readOnly+"] is not writable")
+	}
+	// case 4: create dir if missing
+	assert.NoError(t, checkTSDBDir(&config.TSDB{Dir: notExist, CreateDirIfMissing: true}))
+	assert.True(t, fileutil.Exist(notExist))
+}
+
+func TestStorageRun_shardDiskUsage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	engine := tsdb.NewMockEngine(ctrl)
+	r := &runtime{engine: engine}
+	engine.EXPECT().DatabaseNames().Return([]string{"db1", "db2", "dropped"})
+	engine.EXPECT().ShardsOf("db1").Return([]tsdb.ShardInfo{{ShardID: 1, Size: 10}, {ShardID: 2, Size: 20}})
+	engine.EXPECT().ShardsOf("db2").Return([]tsdb.ShardInfo{})
+	engine.EXPECT().ShardsOf("dropped").Return(nil)
+	assert.Equal(t, map[string]map[models.ShardID]int64{
+		"db1": {1: 10, 2: 20},
+		"db2": {},
+	}, r.shardDiskUsage())
+}
+
 func (ts *testStorageRuntimeSuite) TestStorageRun(c *check.C) {
 	fmt.Println("run TestStorageRun...")
 	// test normal storage run
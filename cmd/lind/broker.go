@@ -22,6 +22,7 @@ import (
 
 	"github.com/lindb/lindb/app/broker"
 	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/internal/linmetric"
 	"github.com/lindb/lindb/pkg/logger"
 	"github.com/lindb/lindb/pkg/ltoml"
 
@@ -85,11 +86,17 @@ func serveBroker(cmd *cobra.Command, args []string) error {
 	if err := logger.InitLogger(brokerCfg.Logging, brokerLogFileName); err != nil {
 		return fmt.Errorf("init logger error: %s", err)
 	}
+	linmetric.SetNativeHistogram(brokerCfg.Monitor.NativeHistogram)
 
 	// start broker server
 	brokerRuntime := broker.NewBrokerRuntime(config.Version, &brokerCfg, true)
+	currentCfg := &brokerCfg
 	return run(ctx, brokerRuntime, func() error {
-		newBrokerCfg := config.Broker{}
-		return config.LoadAndSetBrokerConfig(cfg, defaultBrokerCfgFile, &newBrokerCfg)
+		reloadedCfg, ignored, err := config.ReloadBrokerConfig(cfg, defaultBrokerCfgFile, currentCfg)
+		if err != nil {
+			return err
+		}
+		currentCfg = reloadedCfg
+		return applyReloadedConfig(reloadedCfg.Logging, ignored)
 	})
 }
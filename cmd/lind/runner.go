@@ -23,6 +23,7 @@ import (
 	_ "net/http/pprof"
 	"os"
 
+	"github.com/lindb/lindb/config"
 	"github.com/lindb/lindb/internal/server"
 	"github.com/lindb/lindb/pkg/logger"
 
@@ -81,3 +82,16 @@ func run(ctx context.Context, service server.Service, reloadConfigFunc func() er
 
 	return nil
 }
+
+// applyReloadedConfig applies the reloaded log level to running logger(kept if running with debug),
+// warns the changed fields which are ignored until restart.
+func applyReloadedConfig(loggingCfg config.Logging, ignored []string) error {
+	if len(ignored) > 0 {
+		logger.GetLogger("cmd", "Main").Warn("config fields changed but need restart to take effect, ignored",
+			logger.Any("fields", ignored))
+	}
+	if debug {
+		return nil
+	}
+	return logger.RunningAtomicLevel.UnmarshalText([]byte(loggingCfg.Level))
+}
@@ -24,6 +24,7 @@ import (
 
 	"github.com/lindb/lindb/app/standalone"
 	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/internal/linmetric"
 	"github.com/lindb/lindb/pkg/logger"
 	"github.com/lindb/lindb/pkg/ltoml"
 )
@@ -88,11 +89,17 @@ func serveStandalone(cmd *cobra.Command, args []string) error {
 	if err := logger.InitLogger(standaloneCfg.Logging, standaloneLogFileName); err != nil {
 		return fmt.Errorf("init logger error: %s", err)
 	}
+	linmetric.SetNativeHistogram(standaloneCfg.Monitor.NativeHistogram)
 
 	// run cluster as standalone mode
 	runtime := standalone.NewStandaloneRuntime(config.Version, &standaloneCfg)
+	currentCfg := &standaloneCfg
 	return run(ctx, runtime, func() error {
-		newStandaloneCfg := config.Standalone{}
-		return config.LoadAndSetStandAloneConfig(cfg, defaultStandaloneCfgFile, &newStandaloneCfg)
+		reloadedCfg, ignored, err := config.ReloadStandaloneConfig(cfg, defaultStandaloneCfgFile, currentCfg)
+		if err != nil {
+			return err
+		}
+		currentCfg = reloadedCfg
+		return applyReloadedConfig(reloadedCfg.Logging, ignored)
 	})
 }
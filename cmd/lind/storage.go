@@ -19,11 +19,15 @@ package main
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/lindb/lindb/app/storage"
 	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/internal/linmetric"
 	"github.com/lindb/lindb/pkg/logger"
 	"github.com/lindb/lindb/pkg/ltoml"
+	"github.com/lindb/lindb/pkg/queue"
+	"github.com/lindb/lindb/tsdb/wal"
 
 	"github.com/spf13/cobra"
 )
@@ -51,9 +55,14 @@ func newStorageCmd() *cobra.Command {
 	runStorageCmd.PersistentFlags().StringVar(&cfg, "config", "",
 		fmt.Sprintf("storage config file path, default is %s", defaultStorageCfgFile))
 
+	dumpWALCmd.PersistentFlags().StringVar(&walType, "type", "series",
+		"wal type, series: series wal of index database, replica: replication wal of shard partition")
+	dumpWALCmd.PersistentFlags().StringVar(&walPath, "path", "", "wal directory path")
+
 	storageCmd.AddCommand(
 		runStorageCmd,
 		initializeStorageConfigCmd,
+		dumpWALCmd,
 	)
 	return storageCmd
 }
@@ -73,6 +82,29 @@ var initializeStorageConfigCmd = &cobra.Command{
 	},
 }
 
+var (
+	walType string
+	walPath string
+)
+
+var dumpWALCmd = &cobra.Command{
+	Use:   "dump-wal",
+	Short: "dump the content of wal for debugging, storage must not be running on the wal",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(walPath) == 0 {
+			return fmt.Errorf("wal path cannot be empty")
+		}
+		switch walType {
+		case "series":
+			return wal.DumpSeriesWAL(walPath, os.Stdout)
+		case "replica":
+			return queue.DumpFanOutQueue(walPath, os.Stdout)
+		default:
+			return fmt.Errorf("unknown wal type: %s", walType)
+		}
+	},
+}
+
 func serveStorage(cmd *cobra.Command, args []string) error {
 	ctx := newCtxWithSignals()
 
@@ -84,11 +116,17 @@ func serveStorage(cmd *cobra.Command, args []string) error {
 	if err := logger.InitLogger(storageCfg.Logging, storageLogFileName); err != nil {
 		return fmt.Errorf("init logger error: %s", err)
 	}
+	linmetric.SetNativeHistogram(storageCfg.Monitor.NativeHistogram)
 
 	// start storage server
 	storageRuntime := storage.NewStorageRuntime(config.Version, &storageCfg)
+	currentCfg := &storageCfg
 	return run(ctx, storageRuntime, func() error {
-		newStorageCfg := config.Storage{}
-		return config.LoadAndSetStorageConfig(cfg, defaultStorageCfgFile, &newStorageCfg)
+		reloadedCfg, ignored, err := config.ReloadStorageConfig(cfg, defaultStorageCfgFile, currentCfg)
+		if err != nil {
+			return err
+		}
+		currentCfg = reloadedCfg
+		return applyReloadedConfig(reloadedCfg.Logging, ignored)
 	})
 }
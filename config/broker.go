@@ -18,22 +18,46 @@
 package config
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/url"
+	"path"
 	"runtime"
+	"strings"
 	"time"
 
+	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/pkg/ltoml"
 )
 
 // HTTP represents a HTTP level configuration of broker.
 type HTTP struct {
-	Port         uint16         `toml:"port"`
-	IdleTimeout  ltoml.Duration `toml:"idle-timeout"`
-	WriteTimeout ltoml.Duration `toml:"write-timeout"`
-	ReadTimeout  ltoml.Duration `toml:"read-timeout"`
+	Port         uint16         `toml:"port" json:"port"`
+	IdleTimeout  ltoml.Duration `toml:"idle-timeout" json:"idleTimeout"`
+	WriteTimeout ltoml.Duration `toml:"write-timeout" json:"writeTimeout"`
+	ReadTimeout  ltoml.Duration `toml:"read-timeout" json:"readTimeout"`
+	// Compression enables gzip compression of response if client accepts.
+	Compression        bool       `toml:"compression" json:"compression"`
+	CompressionMinSize ltoml.Size `toml:"compression-min-size" json:"compressionMinSize"`
+	// MaxConnections limits the number of concurrent connections, 0 means unlimited.
+	MaxConnections int `toml:"max-connections" json:"maxConnections"`
+	// HandlerTimeout limits the duration of handling a request, 0 means no limit.
+	HandlerTimeout ltoml.Duration `toml:"handler-timeout" json:"handlerTimeout"`
+	// CORSAllowedOrigins is the origins which are allowed to do cross-origin requests,
+	// empty means CORS is disabled, "*" means all origins.
+	CORSAllowedOrigins   []string `toml:"cors-allowed-origins" json:"corsAllowedOrigins"`
+	CORSAllowedMethods   []string `toml:"cors-allowed-methods" json:"corsAllowedMethods"`
+	CORSAllowedHeaders   []string `toml:"cors-allowed-headers" json:"corsAllowedHeaders"`
+	CORSAllowCredentials bool     `toml:"cors-allow-credentials" json:"corsAllowCredentials"`
+	// PrometheusMetrics enables /metrics endpoint in Prometheus text exposition format.
+	PrometheusMetrics bool `toml:"prometheus-metrics" json:"prometheusMetrics"`
 }
 
 func (h *HTTP) TOML() string {
+	corsAllowedOrigins, _ := json.Marshal(h.CORSAllowedOrigins)
+	corsAllowedMethods, _ := json.Marshal(h.CORSAllowedMethods)
+	corsAllowedHeaders, _ := json.Marshal(h.CORSAllowedHeaders)
 	return fmt.Sprintf(`
 ## Controls how HTTP Server are configured.
 ##
@@ -47,17 +71,143 @@ idle-timeout = "%s"
 write-timeout = "%s"	
 ## maximum duration for reading the entire request, including the body.
 ## Default: 5s
-read-timeout = "%s"`,
+read-timeout = "%s"
+## whether compresses response with gzip if request's Accept-Encoding contains gzip.
+## Default: true
+compression = %v
+## response smaller than this size will not be compressed.
+## Default: 1KiB
+compression-min-size = "%s"
+## maximum number of concurrent connections, new connections beyond it are rejected
+## with 503 Service Unavailable, protects server from connection bursts and slow clients.
+## Default: 0(unlimited)
+max-connections = %d
+## maximum duration of handling a request, request context is canceled and
+## 503 Service Unavailable is returned if handler exceeds it.
+## Default: 0s(no limit)
+handler-timeout = "%s"
+## origins allowed to do cross-origin requests, such as ["https://dashboard.example.com"],
+## empty means CORS is disabled and browsers block all cross-origin requests.
+## "*" allows all origins, it cannot be combined with other origins or cors-allow-credentials,
+## because browsers reject credentialed responses with wildcard origin.
+## Default: []
+cors-allowed-origins = %s
+## methods allowed for cross-origin requests.
+## Default: ["GET", "POST", "PUT", "DELETE", "HEAD"]
+cors-allowed-methods = %s
+## headers allowed for cross-origin requests.
+## Default: ["Origin", "Content-Length", "Content-Type", "Authorization"]
+cors-allowed-headers = %s
+## whether cross-origin requests can include credentials like cookies and http authentication.
+## Default: false
+cors-allow-credentials = %v
+## whether exposes the internal metrics on /metrics endpoint in Prometheus text exposition format,
+## so that the node can be scraped by Prometheus, OpenMetrics format with histogram exemplars
+## is returned if scraper accepts application/openmetrics-text.
+## Default: false
+prometheus-metrics = %v`,
 		h.Port,
 		h.IdleTimeout.Duration().String(),
 		h.WriteTimeout.Duration().String(),
 		h.ReadTimeout.Duration().String(),
+		h.Compression,
+		h.CompressionMinSize.String(),
+		h.MaxConnections,
+		h.HandlerTimeout.Duration().String(),
+		corsAllowedOrigins,
+		corsAllowedMethods,
+		corsAllowedHeaders,
+		h.CORSAllowCredentials,
+		h.PrometheusMetrics,
 	)
 }
 
+// checkCORSCfg checks the CORS config of http, origin must be "*" or scheme://host[:port].
+func checkCORSCfg(h *HTTP) error {
+	if len(h.CORSAllowedMethods) == 0 {
+		h.CORSAllowedMethods = defaultCORSAllowedMethods()
+	}
+	if len(h.CORSAllowedHeaders) == 0 {
+		h.CORSAllowedHeaders = defaultCORSAllowedHeaders()
+	}
+	for _, origin := range h.CORSAllowedOrigins {
+		if origin == "*" {
+			if len(h.CORSAllowedOrigins) > 1 {
+				return fmt.Errorf("http cors allowed origin: '*' cannot be combined with other origins")
+			}
+			if h.CORSAllowCredentials {
+				return fmt.Errorf("http cors allowed origin: '*' cannot be used with cors-allow-credentials")
+			}
+			continue
+		}
+		u, err := url.Parse(origin)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || strings.Contains(u.Host, "*") ||
+			u.Path != "" || u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+			return fmt.Errorf("http cors allowed origin: %s is invalid, must be scheme://host[:port]", origin)
+		}
+	}
+	return nil
+}
+
+func defaultCORSAllowedMethods() []string {
+	return []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodHead}
+}
+
+func defaultCORSAllowedHeaders() []string {
+	return []string{"Origin", "Content-Length", "Content-Type", "Authorization"}
+}
+
 type Ingestion struct {
-	MaxConcurrency int            `toml:"max-write-concurrency"`
-	IngestTimeout  ltoml.Duration `toml:"ingest-timeout"`
+	MaxConcurrency     int            `toml:"max-write-concurrency" json:"maxWriteConcurrency"`
+	IngestTimeout      ltoml.Duration `toml:"ingest-timeout" json:"ingestTimeout"`
+	NormalizeFieldName bool           `toml:"normalize-field-name" json:"normalizeFieldName"`
+	ClampClockSkew     bool           `toml:"clamp-clock-skew" json:"clampClockSkew"`
+	MaxFutureSkew      ltoml.Duration `toml:"max-future-skew" json:"maxFutureSkew"`
+	MaxPastAge         ltoml.Duration `toml:"max-past-age" json:"maxPastAge"`
+	MaxBackfillAge     ltoml.Duration `toml:"max-backfill-age" json:"maxBackfillAge"`
+	MaxBodySize        ltoml.Size     `toml:"max-body-size" json:"maxBodySize"`
+	DefaultNamespace   string         `toml:"default-namespace" json:"defaultNamespace"`
+	DefaultFieldType   string         `toml:"default-field-type" json:"defaultFieldType"`
+	DedupPolicy        string         `toml:"dedup-policy" json:"dedupPolicy"`
+	Sampling           []Sampling     `toml:"sampling" json:"sampling"`
+}
+
+const (
+	// SamplingModeKeepOneIn keeps 1 sample in every keep-one-in samples of each series.
+	SamplingModeKeepOneIn = "keep-one-in-n"
+	// SamplingModeRateLimit keeps at most 1 sample of each series in min-interval by sample timestamp.
+	SamplingModeRateLimit = "rate-limit"
+)
+
+const (
+	// FieldTypeGauge represents the gauge field type of ingestion.
+	FieldTypeGauge = "gauge"
+	// FieldTypeDeltaSum represents the delta sum field type of ingestion.
+	FieldTypeDeltaSum = "delta-sum"
+	// FieldTypeMax represents the max field type of ingestion.
+	FieldTypeMax = "max"
+	// FieldTypeMin represents the min field type of ingestion.
+	FieldTypeMin = "min"
+)
+
+const (
+	// DedupPolicyNone disables the deduplication of metrics within a single batch.
+	DedupPolicyNone = "none"
+	// DedupPolicyFirst keeps the first occurrence of duplicate metrics within a single batch.
+	DedupPolicyFirst = "first"
+	// DedupPolicyLast keeps the last occurrence of duplicate metrics within a single batch.
+	DedupPolicyLast = "last"
+	// DedupPolicySum keeps the first occurrence of duplicate metrics within a single batch,
+	// with simple field values summed up.
+	DedupPolicySum = "sum"
+)
+
+// Sampling represents the ingest-time subsampling policy of metrics whose name matches the pattern.
+type Sampling struct {
+	Pattern     string         `toml:"pattern" json:"pattern"` // glob pattern of metric name, such as "jvm_gc_*"
+	Mode        string         `toml:"mode" json:"mode"`
+	KeepOneIn   int            `toml:"keep-one-in" json:"keepOneIn"`
+	MinInterval ltoml.Duration `toml:"min-interval" json:"minInterval"`
 }
 
 func (i *Ingestion) TOML() string {
@@ -69,9 +219,71 @@ func (i *Ingestion) TOML() string {
 max-concurrency = %d
 ## maximum duration before timeout for server ingesting metrics
 ## Default: 5s
-ingest-timeout = "%s"`,
+ingest-timeout = "%s"
+## whether normalizes field name(lowercase, replace separators with '_') before indexing,
+## if enabled, field name in query will be normalized also, field names of histogram are kept as is.
+## Default: false
+normalize-field-name = %v
+## whether clamps now to the latest time ever read when node clock jumps backward(more than 1s),
+## metrics written without timestamp and acceptance window use the clamped time,
+## so that they do not land in the past family or get rejected.
+## Clock skew is always detected and logged even if disabled.
+## Default: false
+clamp-clock-skew = %v
+## metrics with timestamp later than now + max-future-skew will be rejected,
+## 0s means no limit.
+## Default: 1h
+max-future-skew = "%s"
+## metrics with timestamp earlier than now - max-past-age will be rejected,
+## 0s means no limit, database's behind option still works.
+## Default: 0s
+max-past-age = "%s"
+## backfill(write with query: backfill=true) bypasses the timestamp acceptance window,
+## but metrics with timestamp earlier than now - max-backfill-age will still be rejected.
+## Default: 720h
+max-backfill-age = "%s"
+## maximum size of ingestion request body, request exceeds it will be rejected with 413,
+## for gzip request, both the compressed body and decompressed body are limited.
+## 0 means no limit.
+## Default: 32MiB
+max-body-size = "%s"
+## namespace of metrics which are written without namespace,
+## cannot contain '|'.
+## Default: default-ns
+default-namespace = "%s"
+## field type applied to simple fields which are written without type,
+## one of gauge/delta-sum/max/min, empty means such fields are rejected.
+## Default: ""
+default-field-type = "%s"
+## deduplication policy of fields with the same series and timestamp within a single batch,
+## metric is dropped if all of its fields are duplicate,
+## one of none/first/last/sum, sum adds up simple field values into the first occurrence.
+## Default: last
+dedup-policy = "%s"
+
+## Subsampling policies for high-frequency metrics, applied before writing into replication channel.
+## Metric name is matched against pattern(glob) of policies in order, the first matched policy works.
+## keep-one-in-n: keeps 1 sample in every keep-one-in samples of each series.
+## rate-limit: keeps at most 1 sample of each series in min-interval(by sample timestamp).
+## [[broker.ingestion.sampling]]
+## pattern = "jvm_gc_*"
+## mode = "keep-one-in-n"
+## keep-one-in = 10
+## [[broker.ingestion.sampling]]
+## pattern = "http_request"
+## mode = "rate-limit"
+## min-interval = "10s"`,
 		i.MaxConcurrency,
-		i.IngestTimeout.Duration().String())
+		i.IngestTimeout.Duration().String(),
+		i.NormalizeFieldName,
+		i.ClampClockSkew,
+		i.MaxFutureSkew.Duration().String(),
+		i.MaxPastAge.Duration().String(),
+		i.MaxBackfillAge.Duration().String(),
+		i.MaxBodySize.String(),
+		i.DefaultNamespace,
+		i.DefaultFieldType,
+		i.DedupPolicy)
 }
 
 // User represents user model
@@ -91,8 +303,64 @@ password = "%s"`,
 
 // Write represents config for write replication in broker.
 type Write struct {
-	BatchTimeout   ltoml.Duration `toml:"batch-timout"`
-	BatchBlockSize ltoml.Size     `toml:"batch-block-size"`
+	BatchTimeout   ltoml.Duration  `toml:"batch-timeout" json:"batchTimeout"`
+	BatchBlockSize ltoml.Size      `toml:"batch-block-size" json:"batchBlockSize"`
+	AckLevel       string          `toml:"ack-level" json:"ackLevel"`
+	StreamWrite    bool            `toml:"stream-write" json:"streamWrite"`
+	Databases      []DatabaseWrite `toml:"database" json:"database"`
+}
+
+const (
+	// WriteAckLevelAsync returns success after rows are buffered in broker(fire-and-forget).
+	WriteAckLevelAsync = "async"
+	// WriteAckLevelLeader returns success after rows are written and flushed into wal of shard leader.
+	WriteAckLevelLeader = "leader"
+	// WriteAckLevelQuorum returns success after rows are acked by majority replicas of shard.
+	WriteAckLevelQuorum = "quorum"
+)
+
+// IsValidWriteAckLevel returns if the write ack level is known.
+func IsValidWriteAckLevel(ackLevel string) bool {
+	switch ackLevel {
+	case WriteAckLevelAsync, WriteAckLevelLeader, WriteAckLevelQuorum:
+		return true
+	default:
+		return false
+	}
+}
+
+// DatabaseWrite represents the write replication config overrides of database,
+// zero value means using the global config.
+type DatabaseWrite struct {
+	Name           string         `toml:"name" json:"name"`
+	BatchTimeout   ltoml.Duration `toml:"batch-timeout" json:"batchTimeout"`
+	BatchBlockSize ltoml.Size     `toml:"batch-block-size" json:"batchBlockSize"`
+	AckLevel       string         `toml:"ack-level" json:"ackLevel"`
+}
+
+// ForDatabase returns the write replication config of database, merges the overrides over global config.
+func (rc *Write) ForDatabase(database string) Write {
+	cfg := Write{
+		BatchTimeout:   rc.BatchTimeout,
+		BatchBlockSize: rc.BatchBlockSize,
+		AckLevel:       rc.AckLevel,
+		StreamWrite:    rc.StreamWrite,
+	}
+	for _, override := range rc.Databases {
+		if override.Name != database {
+			continue
+		}
+		if override.BatchTimeout > 0 {
+			cfg.BatchTimeout = override.BatchTimeout
+		}
+		if override.BatchBlockSize > 0 {
+			cfg.BatchBlockSize = override.BatchBlockSize
+		}
+		if override.AckLevel != "" {
+			cfg.AckLevel = override.AckLevel
+		}
+	}
+	return cfg
 }
 
 func (rc *Write) TOML() string {
@@ -100,22 +368,91 @@ func (rc *Write) TOML() string {
 ## Write Configuration for writing replication block
 ## 
 ## Broker will write at least this often,
-## even if the configured batch-size if not reached.
+## even if the configured batch-block-size if not reached.
 batch-timeout = "%s"
-## Broker will sending block to storage node in this size
-batch-size = "%s"`,
+## Broker will sending block to storage node in this size,
+## unit is byte, supports human readable size such as "256KiB"/"1MiB".
+batch-block-size = "%s"
+## When the write returns success to client, can be overridden by request query: ackLevel=leader.
+## async: after rows are buffered in broker, lowest latency, rows may be lost if broker or storage crashes.
+## leader: after rows are written and flushed into wal of shard leader, rows may be lost if leader's disk fails.
+## quorum: after rows are acked by majority replicas of shard, highest latency and durability.
+## Rows are flushed to storage immediately instead of batching if ack level is leader or quorum.
+## Default: async
+ack-level = "%s"
+## Writes to storage by stream write with flow control, pauses sending if storage signals backpressure.
+## Enable it after all storage nodes are upgraded, storage nodes of old version don't support it.
+## Default: false
+stream-write = %v
+
+## Overrides the write configuration for specific database,
+## options not set will use the global configuration above.
+## [[broker.write.database]]
+## name = "_internal"
+## batch-timeout = "500ms"
+## batch-block-size = "64KiB"
+## ack-level = "quorum"`,
 		rc.BatchTimeout.String(),
 		rc.BatchBlockSize.String(),
+		rc.AckLevel,
+		rc.StreamWrite,
 	)
 }
 
+// CircuitBreaker represents the config of circuit breaker for storage nodes in broker.
+type CircuitBreaker struct {
+	FailureThreshold int            `toml:"failure-threshold" json:"failureThreshold"`
+	SlowThreshold    ltoml.Duration `toml:"slow-threshold" json:"slowThreshold"`
+	OpenDuration     ltoml.Duration `toml:"open-duration" json:"openDuration"`
+}
+
+func (cb *CircuitBreaker) TOML() string {
+	return fmt.Sprintf(`
+## Circuit breaker of storage node trips after consecutive failed or slow responses,
+## then broker routes queries around the node to other replicas if available.
+##
+## Num. of consecutive failures to trip the breaker, 0 means disable circuit breaker.
+## Default: 5
+failure-threshold = %d
+## Response slower than this duration is treated as failure, 0s means disable slow check.
+## Default: 0s
+slow-threshold = "%s"
+## Duration the breaker keeps open before probing the node by a query,
+## breaker closes if the probe succeeds, otherwise keeps open for another duration.
+## Default: 30s
+open-duration = "%s"`,
+		cb.FailureThreshold,
+		cb.SlowThreshold.String(),
+		cb.OpenDuration.String(),
+	)
+}
+
+const (
+	// ACLOperationRead represents querying metric data/metadata of namespace.
+	ACLOperationRead = "read"
+	// ACLOperationWrite represents writing metrics into namespace.
+	ACLOperationWrite = "write"
+)
+
+// ACL represents the namespaces and operations which the token is allowed to access.
+type ACL struct {
+	Token      string   `toml:"token" json:"token"`
+	Namespaces []string `toml:"namespaces" json:"namespaces"` // "*" means all namespaces
+	Operations []string `toml:"operations" json:"operations"`
+	// overrides the query rate limit of token, 0 means using [query] config
+	MaxQueriesPerSecond float64 `toml:"max-queries-per-second" json:"maxQueriesPerSecond"`
+	QueryBurst          int     `toml:"query-burst" json:"queryBurst"`
+}
+
 // BrokerBase represents a broker configuration
 type BrokerBase struct {
-	HTTP      HTTP      `toml:"http"`
-	Ingestion Ingestion `toml:"ingestion"`
-	Write     Write     `toml:"write"`
-	User      User      `toml:"user"`
-	GRPC      GRPC      `toml:"grpc"`
+	HTTP           HTTP           `toml:"http" json:"http"`
+	Ingestion      Ingestion      `toml:"ingestion" json:"ingestion"`
+	Write          Write          `toml:"write" json:"write"`
+	CircuitBreaker CircuitBreaker `toml:"circuit-breaker" json:"circuitBreaker"`
+	User           User           `toml:"user" json:"user"`
+	GRPC           GRPC           `toml:"grpc" json:"grpc"`
+	ACL            []ACL          `toml:"acl" json:"acl"`
 }
 
 func (bb *BrokerBase) TOML() string {
@@ -128,12 +465,26 @@ func (bb *BrokerBase) TOML() string {
 
 [broker.write]%s
 
+[broker.circuit-breaker]%s
+
 [broker.user]%s
 
+## Namespace level access control of ingestion and query,
+## request carries the token by header: Authorization: Bearer <token>.
+## If no acl configured, all requests are allowed.
+## [[broker.acl]]
+## token = "team-a-token"
+## namespaces = ["team-a"]
+## operations = ["read", "write"]
+## ## overrides max-queries-per-second-per-token/query-burst-per-token of [query] for the token
+## max-queries-per-second = 10.0
+## query-burst = 20
+
 [broker.grpc]%s`,
 		bb.HTTP.TOML(),
 		bb.Ingestion.TOML(),
 		bb.Write.TOML(),
+		bb.CircuitBreaker.TOML(),
 		bb.User.TOML(),
 		bb.GRPC.TOML(),
 	)
@@ -142,23 +493,41 @@ func (bb *BrokerBase) TOML() string {
 func NewDefaultBrokerBase() *BrokerBase {
 	return &BrokerBase{
 		HTTP: HTTP{
-			Port:         9000,
-			IdleTimeout:  ltoml.Duration(time.Minute * 2),
-			ReadTimeout:  ltoml.Duration(time.Second * 5),
-			WriteTimeout: ltoml.Duration(time.Second * 5),
+			Port:               9000,
+			IdleTimeout:        ltoml.Duration(time.Minute * 2),
+			ReadTimeout:        ltoml.Duration(time.Second * 5),
+			WriteTimeout:       ltoml.Duration(time.Second * 5),
+			Compression:        true,
+			CompressionMinSize: ltoml.Size(1024),
+			CORSAllowedOrigins: []string{},
+			CORSAllowedMethods: defaultCORSAllowedMethods(),
+			CORSAllowedHeaders: defaultCORSAllowedHeaders(),
 		},
 		Ingestion: Ingestion{
-			MaxConcurrency: runtime.GOMAXPROCS(-1) * 2,
-			IngestTimeout:  ltoml.Duration(time.Second * 5),
+			MaxConcurrency:   runtime.GOMAXPROCS(-1) * 2,
+			IngestTimeout:    ltoml.Duration(time.Second * 5),
+			MaxFutureSkew:    ltoml.Duration(time.Hour),
+			MaxBackfillAge:   ltoml.Duration(30 * 24 * time.Hour),
+			MaxBodySize:      ltoml.Size(32 * 1024 * 1024),
+			DefaultNamespace: constants.DefaultNamespace,
+			DedupPolicy:      DedupPolicyLast,
 		},
 		Write: Write{
 			BatchTimeout:   ltoml.Duration(time.Second * 2),
 			BatchBlockSize: ltoml.Size(256 * 1024),
+			AckLevel:       WriteAckLevelAsync,
+		},
+		CircuitBreaker: CircuitBreaker{
+			FailureThreshold: 5,
+			OpenDuration:     ltoml.Duration(30 * time.Second),
 		},
 		GRPC: GRPC{
 			Port:                 9001,
 			MaxConcurrentStreams: runtime.GOMAXPROCS(-1) * 2,
 			ConnectTimeout:       ltoml.Duration(time.Second * 3),
+			ConnPoolMinSize:      1,
+			ConnPoolMaxSize:      4,
+			ConnPoolIdleTimeout:  ltoml.Duration(time.Minute * 5),
 		},
 		User: User{
 			UserName: "admin",
@@ -169,11 +538,11 @@ func NewDefaultBrokerBase() *BrokerBase {
 
 // Broker represents a broker configuration with common settings
 type Broker struct {
-	Coordinator RepoState  `toml:"coordinator"`
-	Query       Query      `toml:"query"`
-	BrokerBase  BrokerBase `toml:"broker"`
-	Monitor     Monitor    `toml:"monitor"`
-	Logging     Logging    `toml:"logging"`
+	Coordinator RepoState  `toml:"coordinator" json:"coordinator"`
+	Query       Query      `toml:"query" json:"query"`
+	BrokerBase  BrokerBase `toml:"broker" json:"broker"`
+	Monitor     Monitor    `toml:"monitor" json:"monitor"`
+	Logging     Logging    `toml:"logging" json:"logging"`
 }
 
 // NewDefaultBrokerTOML creates broker default toml config
@@ -186,7 +555,10 @@ func NewDefaultBrokerTOML() string {
 
 %s
 
+%s
+
 %s`,
+		envOverrideTOMLComment,
 		NewDefaultCoordinator().TOML(),
 		NewDefaultQuery().TOML(),
 		NewDefaultBrokerBase().TOML(),
@@ -213,14 +585,63 @@ func checkBrokerBaseCfg(brokerBaseCfg *BrokerBase) error {
 	if brokerBaseCfg.HTTP.IdleTimeout <= 0 {
 		brokerBaseCfg.HTTP.IdleTimeout = defaultBrokerCfg.HTTP.IdleTimeout
 	}
+	if brokerBaseCfg.HTTP.CompressionMinSize <= 0 {
+		brokerBaseCfg.HTTP.CompressionMinSize = defaultBrokerCfg.HTTP.CompressionMinSize
+	}
+	if brokerBaseCfg.HTTP.MaxConnections < 0 {
+		brokerBaseCfg.HTTP.MaxConnections = 0
+	}
+	if brokerBaseCfg.HTTP.HandlerTimeout < 0 {
+		brokerBaseCfg.HTTP.HandlerTimeout = 0
+	}
+	if err := checkCORSCfg(&brokerBaseCfg.HTTP); err != nil {
+		return err
+	}
 
 	// ingestion
+	if brokerBaseCfg.Ingestion.MaxBackfillAge <= 0 {
+		brokerBaseCfg.Ingestion.MaxBackfillAge = defaultBrokerCfg.Ingestion.MaxBackfillAge
+	}
 	if brokerBaseCfg.Ingestion.IngestTimeout <= 0 {
 		brokerBaseCfg.Ingestion.IngestTimeout = defaultBrokerCfg.Ingestion.IngestTimeout
 	}
 	if brokerBaseCfg.Ingestion.MaxConcurrency <= 0 {
 		brokerBaseCfg.Ingestion.MaxConcurrency = defaultBrokerCfg.Ingestion.MaxConcurrency
 	}
+	if brokerBaseCfg.Ingestion.DefaultNamespace == "" {
+		brokerBaseCfg.Ingestion.DefaultNamespace = defaultBrokerCfg.Ingestion.DefaultNamespace
+	}
+	// same as the sanitizing rule of namespace when ingesting
+	if strings.Contains(brokerBaseCfg.Ingestion.DefaultNamespace, "|") {
+		return fmt.Errorf("ingestion default namespace: %s cannot contain '|'", brokerBaseCfg.Ingestion.DefaultNamespace)
+	}
+	switch brokerBaseCfg.Ingestion.DefaultFieldType {
+	case "", FieldTypeGauge, FieldTypeDeltaSum, FieldTypeMax, FieldTypeMin:
+	default:
+		return fmt.Errorf("ingestion default field type: %s is invalid", brokerBaseCfg.Ingestion.DefaultFieldType)
+	}
+	switch brokerBaseCfg.Ingestion.DedupPolicy {
+	case "", DedupPolicyNone, DedupPolicyFirst, DedupPolicyLast, DedupPolicySum:
+	default:
+		return fmt.Errorf("ingestion dedup policy: %s is invalid", brokerBaseCfg.Ingestion.DedupPolicy)
+	}
+	for _, sampling := range brokerBaseCfg.Ingestion.Sampling {
+		if _, err := path.Match(sampling.Pattern, ""); err != nil || sampling.Pattern == "" {
+			return fmt.Errorf("ingestion sampling pattern: %s is invalid", sampling.Pattern)
+		}
+		switch sampling.Mode {
+		case SamplingModeKeepOneIn:
+			if sampling.KeepOneIn < 1 {
+				return fmt.Errorf("ingestion sampling: %s, keep-one-in must be positive", sampling.Pattern)
+			}
+		case SamplingModeRateLimit:
+			if sampling.MinInterval <= 0 {
+				return fmt.Errorf("ingestion sampling: %s, min-interval must be positive", sampling.Pattern)
+			}
+		default:
+			return fmt.Errorf("ingestion sampling: %s, unknown mode: %s", sampling.Pattern, sampling.Mode)
+		}
+	}
 	// write check
 	if brokerBaseCfg.Write.BatchTimeout <= 0 {
 		brokerBaseCfg.Write.BatchTimeout = defaultBrokerCfg.Write.BatchTimeout
@@ -228,6 +649,55 @@ func checkBrokerBaseCfg(brokerBaseCfg *BrokerBase) error {
 	if brokerBaseCfg.Write.BatchBlockSize <= 0 {
 		brokerBaseCfg.Write.BatchBlockSize = defaultBrokerCfg.Write.BatchBlockSize
 	}
+	if brokerBaseCfg.Write.AckLevel == "" {
+		brokerBaseCfg.Write.AckLevel = defaultBrokerCfg.Write.AckLevel
+	}
+	if !IsValidWriteAckLevel(brokerBaseCfg.Write.AckLevel) {
+		return fmt.Errorf("write ack level: %s is invalid", brokerBaseCfg.Write.AckLevel)
+	}
+	// circuit breaker check
+	if brokerBaseCfg.CircuitBreaker.FailureThreshold < 0 {
+		brokerBaseCfg.CircuitBreaker.FailureThreshold = 0
+	}
+	if brokerBaseCfg.CircuitBreaker.SlowThreshold < 0 {
+		brokerBaseCfg.CircuitBreaker.SlowThreshold = 0
+	}
+	if brokerBaseCfg.CircuitBreaker.OpenDuration <= 0 {
+		brokerBaseCfg.CircuitBreaker.OpenDuration = defaultBrokerCfg.CircuitBreaker.OpenDuration
+	}
+	tokens := make(map[string]struct{})
+	for _, acl := range brokerBaseCfg.ACL {
+		if acl.Token == "" {
+			return fmt.Errorf("acl token cannot be empty")
+		}
+		if _, ok := tokens[acl.Token]; ok {
+			return fmt.Errorf("acl token is duplicated")
+		}
+		tokens[acl.Token] = struct{}{}
+		for _, op := range acl.Operations {
+			switch op {
+			case ACLOperationRead, ACLOperationWrite:
+			default:
+				return fmt.Errorf("acl operation: %s is unknown", op)
+			}
+		}
+		if acl.MaxQueriesPerSecond < 0 || acl.QueryBurst < 0 {
+			return fmt.Errorf("acl query rate limit cannot be negative")
+		}
+	}
+	databases := make(map[string]struct{})
+	for _, override := range brokerBaseCfg.Write.Databases {
+		if override.Name == "" {
+			return fmt.Errorf("write database name cannot be empty")
+		}
+		if _, ok := databases[override.Name]; ok {
+			return fmt.Errorf("write database: %s is duplicated", override.Name)
+		}
+		if override.AckLevel != "" && !IsValidWriteAckLevel(override.AckLevel) {
+			return fmt.Errorf("write database: %s, ack level: %s is invalid", override.Name, override.AckLevel)
+		}
+		databases[override.Name] = struct{}{}
+	}
 
 	return nil
 }
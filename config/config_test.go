@@ -18,9 +18,13 @@
 package config
 
 import (
+	"encoding/json"
+	"math"
 	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/BurntSushi/toml"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/lindb/lindb/pkg/ltoml"
@@ -81,6 +85,43 @@ func Test_NewConfig(t *testing.T) {
 	assert.Equal(t, standaloneCfg.Monitor, *NewDefaultMonitor())
 }
 
+func Test_JSON_RoundTrip(t *testing.T) {
+	brokerCfg := &Broker{
+		Coordinator: *NewDefaultCoordinator(),
+		Query:       *NewDefaultQuery(),
+		BrokerBase:  *NewDefaultBrokerBase(),
+		Monitor:     *NewDefaultMonitor(),
+		Logging:     *NewDefaultLogging(),
+	}
+	data, err := json.Marshal(brokerCfg)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"batchTimeout":"2s"`)
+	var newBrokerCfg Broker
+	assert.NoError(t, json.Unmarshal(data, &newBrokerCfg))
+	assert.Equal(t, *brokerCfg, newBrokerCfg)
+
+	storageCfg := &Storage{
+		Coordinator: *NewDefaultCoordinator(),
+		Query:       *NewDefaultQuery(),
+		StorageBase: *NewDefaultStorageBase(),
+		Monitor:     *NewDefaultMonitor(),
+		Logging:     *NewDefaultLogging(),
+	}
+	data, err = json.Marshal(storageCfg)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"maxMemDBSize":524288000`)
+	var newStorageCfg Storage
+	assert.NoError(t, json.Unmarshal(data, &newStorageCfg))
+	assert.Equal(t, *storageCfg, newStorageCfg)
+
+	// marshal by value
+	data, err = json.Marshal(*storageCfg)
+	assert.NoError(t, err)
+	newStorageCfg = Storage{}
+	assert.NoError(t, json.Unmarshal(data, &newStorageCfg))
+	assert.Equal(t, *storageCfg, newStorageCfg)
+}
+
 func Test_Global(t *testing.T) {
 	assert.NotNil(t, GlobalBrokerConfig())
 	assert.NotNil(t, GlobalStorageConfig())
@@ -102,14 +143,140 @@ func Test_checkBrokerBaseCfg(t *testing.T) {
 
 	// ok
 	brokerCfg3 := &BrokerBase{
-		GRPC: GRPC{Port: 2379},
-		HTTP: HTTP{Port: 9000},
+		GRPC:           GRPC{Port: 2379},
+		HTTP:           HTTP{Port: 9000, MaxConnections: -1, HandlerTimeout: ltoml.Duration(-time.Second)},
+		CircuitBreaker: CircuitBreaker{FailureThreshold: -1, SlowThreshold: ltoml.Duration(-time.Second)},
 	}
 	assert.NoError(t, checkBrokerBaseCfg(brokerCfg3))
+	assert.Zero(t, brokerCfg3.CircuitBreaker.FailureThreshold)
+	assert.Zero(t, brokerCfg3.CircuitBreaker.SlowThreshold)
+	assert.Equal(t, NewDefaultBrokerBase().CircuitBreaker.OpenDuration, brokerCfg3.CircuitBreaker.OpenDuration)
+	assert.Equal(t, 1, brokerCfg3.GRPC.ConnPoolMinSize)
+	assert.Equal(t, 1, brokerCfg3.GRPC.ConnPoolMaxSize)
+	assert.Equal(t, ltoml.Duration(5*time.Minute), brokerCfg3.GRPC.ConnPoolIdleTimeout)
+	assert.Equal(t, WriteAckLevelAsync, brokerCfg3.Write.AckLevel)
+	assert.Zero(t, brokerCfg3.HTTP.MaxConnections)
+	assert.Zero(t, brokerCfg3.HTTP.HandlerTimeout)
 	assert.NotZero(t, brokerCfg3.HTTP.ReadTimeout)
 	assert.NotZero(t, brokerCfg3.HTTP.IdleTimeout)
 	assert.NotZero(t, brokerCfg3.HTTP.WriteTimeout)
 	assert.NotZero(t, brokerCfg3.Ingestion.IngestTimeout)
+	assert.NotZero(t, brokerCfg3.HTTP.CompressionMinSize)
+	assert.Equal(t, "default-ns", brokerCfg3.Ingestion.DefaultNamespace)
+
+	assert.Empty(t, brokerCfg3.HTTP.CORSAllowedOrigins)
+	assert.NotEmpty(t, brokerCfg3.HTTP.CORSAllowedMethods)
+	assert.NotEmpty(t, brokerCfg3.HTTP.CORSAllowedHeaders)
+
+	// default namespace failure
+	brokerCfg3.Ingestion.DefaultNamespace = "a|b"
+	assert.Error(t, checkBrokerBaseCfg(brokerCfg3))
+	brokerCfg3.Ingestion.DefaultNamespace = "ns"
+	assert.NoError(t, checkBrokerBaseCfg(brokerCfg3))
+
+	// default field type failure
+	brokerCfg3.Ingestion.DefaultFieldType = "sum"
+	assert.Error(t, checkBrokerBaseCfg(brokerCfg3))
+	brokerCfg3.Ingestion.DefaultFieldType = FieldTypeGauge
+	assert.NoError(t, checkBrokerBaseCfg(brokerCfg3))
+	brokerCfg3.Ingestion.DefaultFieldType = ""
+
+	// dedup policy failure
+	brokerCfg3.Ingestion.DedupPolicy = "max"
+	assert.Error(t, checkBrokerBaseCfg(brokerCfg3))
+	brokerCfg3.Ingestion.DedupPolicy = DedupPolicySum
+	assert.NoError(t, checkBrokerBaseCfg(brokerCfg3))
+	brokerCfg3.Ingestion.DedupPolicy = DedupPolicyLast
+
+	// ingestion sampling failure
+	brokerCfg3.Ingestion.Sampling = []Sampling{{Pattern: "[", Mode: SamplingModeKeepOneIn, KeepOneIn: 1}}
+	assert.Error(t, checkBrokerBaseCfg(brokerCfg3))
+	brokerCfg3.Ingestion.Sampling = []Sampling{{Pattern: "cpu", Mode: SamplingModeKeepOneIn}}
+	assert.Error(t, checkBrokerBaseCfg(brokerCfg3))
+	brokerCfg3.Ingestion.Sampling = []Sampling{{Pattern: "cpu", Mode: SamplingModeRateLimit}}
+	assert.Error(t, checkBrokerBaseCfg(brokerCfg3))
+	brokerCfg3.Ingestion.Sampling = []Sampling{{Pattern: "cpu", Mode: "unknown"}}
+	assert.Error(t, checkBrokerBaseCfg(brokerCfg3))
+	brokerCfg3.Ingestion.Sampling = []Sampling{
+		{Pattern: "cpu*", Mode: SamplingModeKeepOneIn, KeepOneIn: 10},
+		{Pattern: "memory", Mode: SamplingModeRateLimit, MinInterval: ltoml.Duration(time.Second)},
+	}
+	assert.NoError(t, checkBrokerBaseCfg(brokerCfg3))
+
+	// acl failure
+	brokerCfg3.ACL = []ACL{{}}
+	assert.Error(t, checkBrokerBaseCfg(brokerCfg3))
+	brokerCfg3.ACL = []ACL{{Token: "a"}, {Token: "a"}}
+	assert.Error(t, checkBrokerBaseCfg(brokerCfg3))
+	brokerCfg3.ACL = []ACL{{Token: "a", Operations: []string{"delete"}}}
+	assert.Error(t, checkBrokerBaseCfg(brokerCfg3))
+	brokerCfg3.ACL = []ACL{{Token: "a", MaxQueriesPerSecond: -1}}
+	assert.Error(t, checkBrokerBaseCfg(brokerCfg3))
+	brokerCfg3.ACL = []ACL{{Token: "a", Namespaces: []string{"ns"}, Operations: []string{ACLOperationRead}}}
+	assert.NoError(t, checkBrokerBaseCfg(brokerCfg3))
+
+	// write database override failure
+	brokerCfg3.Write.Databases = []DatabaseWrite{{}}
+	assert.Error(t, checkBrokerBaseCfg(brokerCfg3))
+	brokerCfg3.Write.Databases = []DatabaseWrite{{Name: "db"}, {Name: "db"}}
+	assert.Error(t, checkBrokerBaseCfg(brokerCfg3))
+	brokerCfg3.Write.Databases = []DatabaseWrite{{Name: "db", AckLevel: "all"}}
+	assert.Error(t, checkBrokerBaseCfg(brokerCfg3))
+	brokerCfg3.Write.Databases = []DatabaseWrite{{Name: "db", AckLevel: WriteAckLevelQuorum}}
+	assert.NoError(t, checkBrokerBaseCfg(brokerCfg3))
+	// write ack level failure
+	brokerCfg3.Write.AckLevel = "all"
+	assert.Error(t, checkBrokerBaseCfg(brokerCfg3))
+}
+
+func Test_Write_TOML(t *testing.T) {
+	defaultCfg := NewDefaultBrokerBase().Write
+	var cfg Write
+	_, err := toml.Decode(defaultCfg.TOML(), &cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, defaultCfg, cfg)
+	assert.Equal(t, ltoml.Size(256*1024), cfg.BatchBlockSize)
+
+	_, err = toml.Decode(`batch-block-size = "1MiB"`, &cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, ltoml.Size(1024*1024), cfg.BatchBlockSize)
+}
+
+func Test_Write_ForDatabase(t *testing.T) {
+	var cfg Write
+	_, err := toml.Decode(`
+batch-timeout = "2s"
+batch-block-size = "256KiB"
+stream-write = true
+
+[[database]]
+name = "critical"
+batch-timeout = "100ms"
+ack-level = "quorum"
+
+[[database]]
+name = "bulk"
+batch-timeout = "10s"
+batch-block-size = "1MiB"`, &cfg)
+	assert.NoError(t, err)
+	assert.Len(t, cfg.Databases, 2)
+
+	// not override
+	c := cfg.ForDatabase("other")
+	assert.Equal(t, ltoml.Duration(2*time.Second), c.BatchTimeout)
+	assert.Equal(t, ltoml.Size(256*1024), c.BatchBlockSize)
+	assert.Empty(t, c.AckLevel)
+	assert.Empty(t, c.Databases)
+	// partial override
+	c = cfg.ForDatabase("critical")
+	assert.Equal(t, ltoml.Duration(100*time.Millisecond), c.BatchTimeout)
+	assert.Equal(t, ltoml.Size(256*1024), c.BatchBlockSize)
+	assert.Equal(t, WriteAckLevelQuorum, c.AckLevel)
+	// full override
+	c = cfg.ForDatabase("bulk")
+	assert.Equal(t, ltoml.Duration(10*time.Second), c.BatchTimeout)
+	assert.Equal(t, ltoml.Size(1024*1024), c.BatchBlockSize)
+	assert.True(t, c.StreamWrite)
 }
 
 func Test_checkStorageBaseCfg(t *testing.T) {
@@ -138,7 +305,8 @@ func Test_checkStorageBaseCfg(t *testing.T) {
 	storageCfg4 := &StorageBase{
 		Indicator: 1,
 		GRPC:      GRPC{Port: 2379},
-		TSDB:      TSDB{Dir: "/tmp/lindb"},
+		TSDB: TSDB{Dir: "/tmp/lindb", WarmupTopN: -1, MetadataCacheSize: -1, TagValueCacheSize: -1,
+			MaxOpenFamilies: -1, MaxExemplars: -1},
 	}
 	assert.NoError(t, checkStorageBaseCfg(storageCfg4))
 	assert.NotZero(t, storageCfg4.TSDB.MaxMemDBSize)
@@ -150,6 +318,104 @@ func Test_checkStorageBaseCfg(t *testing.T) {
 	assert.NotZero(t, storageCfg4.TSDB.FlushConcurrency)
 	assert.NotZero(t, storageCfg4.TSDB.MaxSeriesIDsNumber)
 	assert.NotZero(t, storageCfg4.TSDB.MaxTagKeysNumber)
+	assert.NotZero(t, storageCfg4.TSDB.SeriesSyncConcurrency)
+	assert.NotZero(t, storageCfg4.TSDB.MaxSeriesWALBacklog)
+	assert.NotZero(t, storageCfg4.TSDB.SeriesReportInterval)
+	assert.Zero(t, storageCfg4.TSDB.TagCardinalityPrecision)
+	assert.NotZero(t, storageCfg4.TSDB.BackendMaxRetries)
+	assert.NotZero(t, storageCfg4.TSDB.BackendRetryBackoff)
+	assert.Equal(t, BackendIntegrityCheckOff, storageCfg4.TSDB.BackendIntegrityCheck)
+	assert.NotZero(t, storageCfg4.TSDB.MaxTagKeysStaleness)
+	assert.NotZero(t, storageCfg4.TSDB.MaxCachedTagKeys)
+	assert.Zero(t, storageCfg4.TSDB.WarmupTopN)
+	assert.Zero(t, storageCfg4.TSDB.MetadataCacheSize)
+	assert.Zero(t, storageCfg4.TSDB.TagValueCacheSize)
+	assert.Equal(t, IndexFlushStrategyFull, storageCfg4.TSDB.IndexFlushStrategy)
+	assert.NotZero(t, storageCfg4.TSDB.IndexFlushChunkSize)
+	assert.Zero(t, storageCfg4.TSDB.IndexTagKeyIdleTTL)
+	assert.Equal(t, 4, storageCfg4.TSDB.IndexFlushConcurrency)
+	assert.Equal(t, 1, storageCfg4.TSDB.WritePartitions)
+	assert.Zero(t, storageCfg4.TSDB.BlockCacheSize)
+	assert.Equal(t, ReadStrategyMMap, storageCfg4.TSDB.ReadStrategy)
+	assert.Zero(t, storageCfg4.TSDB.MaxOpenFamilies)
+	assert.Equal(t, 4, storageCfg4.TSDB.SegmentOpenConcurrency)
+	assert.NotZero(t, storageCfg4.TSDB.ShardEventLogSize)
+	assert.Zero(t, storageCfg4.TSDB.MaxExemplars)
+	assert.Zero(t, storageCfg4.TSDB.WriteProfileSampleRate)
+	assert.Equal(t, FlushSyncPolicyAlways, storageCfg4.TSDB.FlushSyncPolicy)
+	assert.NotZero(t, storageCfg4.TSDB.FlushSyncInterval)
+	assert.Equal(t, WALBacklogPolicyBlock, storageCfg4.WAL.BacklogPolicy)
+	assert.NotZero(t, storageCfg4.WAL.BacklogBlockTimeout)
+	assert.Zero(t, storageCfg4.WAL.ApplyMaxRetries)
+	assert.NotZero(t, storageCfg4.WAL.ApplyRetryBackoff)
+	assert.NotEmpty(t, storageCfg4.WAL.DeadLetterDir)
+	assert.NotZero(t, storageCfg4.WAL.FlowControlInterval)
+	assert.Equal(t, ltoml.Duration(5*time.Second), storageCfg4.WAL.QuorumAckTimeout)
+	assert.Zero(t, storageCfg4.WAL.FlowControlHighWatermark)
+
+	// backend integrity check error
+	storageCfg4.TSDB.BackendIntegrityCheck = "fix"
+	assert.Error(t, checkStorageBaseCfg(storageCfg4))
+	storageCfg4.TSDB.BackendIntegrityCheck = BackendIntegrityCheckRefuse
+
+	// index flush strategy error
+	storageCfg4.TSDB.IndexFlushStrategy = "lazy"
+	assert.Error(t, checkStorageBaseCfg(storageCfg4))
+	storageCfg4.TSDB.IndexFlushStrategy = IndexFlushStrategyIncremental
+
+	// data dirs
+	assert.Empty(t, storageCfg4.TSDB.DataDirs)
+	assert.Equal(t, storageCfg4.TSDB.Dir, storageCfg4.TSDB.ShardDataDir(1))
+	storageCfg4.TSDB.DataDirs = []string{"/disk1/data", ""}
+	assert.Error(t, checkStorageBaseCfg(storageCfg4))
+	storageCfg4.TSDB.DataDirs = []string{"/disk1/data", "/disk1/data/"}
+	assert.Error(t, checkStorageBaseCfg(storageCfg4))
+	storageCfg4.TSDB.DataDirs = []string{"/disk1/data", "/disk2/data"}
+	assert.Equal(t, "/disk1/data", storageCfg4.TSDB.ShardDataDir(0))
+	assert.Equal(t, "/disk2/data", storageCfg4.TSDB.ShardDataDir(1))
+	assert.Equal(t, "/disk1/data", storageCfg4.TSDB.ShardDataDir(2))
+
+	// read strategy error
+	storageCfg4.TSDB.ReadStrategy = "direct-io"
+	assert.Error(t, checkStorageBaseCfg(storageCfg4))
+	storageCfg4.TSDB.ReadStrategy = ReadStrategyPRead
+
+	// flush sync policy error
+	storageCfg4.TSDB.FlushSyncPolicy = "fdatasync"
+	assert.Error(t, checkStorageBaseCfg(storageCfg4))
+	storageCfg4.TSDB.FlushSyncPolicy = FlushSyncPolicyInterval
+
+	// write profile sample rate error
+	storageCfg4.TSDB.WriteProfileSampleRate = 1.5
+	assert.Error(t, checkStorageBaseCfg(storageCfg4))
+	storageCfg4.TSDB.WriteProfileSampleRate = -0.1
+	assert.Error(t, checkStorageBaseCfg(storageCfg4))
+	storageCfg4.TSDB.WriteProfileSampleRate = 0.01
+
+	// tag cardinality precision error
+	storageCfg4.TSDB.TagCardinalityPrecision = 20
+	assert.Error(t, checkStorageBaseCfg(storageCfg4))
+	storageCfg4.TSDB.TagCardinalityPrecision = 0
+
+	// wal backlog policy error
+	storageCfg4.WAL.BacklogPolicy = "spill"
+	assert.Error(t, checkStorageBaseCfg(storageCfg4))
+	storageCfg4.WAL.BacklogPolicy = WALBacklogPolicyBlock
+
+	// wal flow control watermark error
+	storageCfg4.WAL.FlowControlLowWatermark = 10
+	assert.Error(t, checkStorageBaseCfg(storageCfg4))
+	storageCfg4.WAL.FlowControlHighWatermark = 100
+	assert.NoError(t, checkStorageBaseCfg(storageCfg4))
+
+	// gc config error
+	storageCfg4.GC.Percent = -2
+	assert.Error(t, checkStorageBaseCfg(storageCfg4))
+	storageCfg4.GC.Percent = -1
+	storageCfg4.GC.MemoryLimit = math.MaxUint64
+	assert.Error(t, checkStorageBaseCfg(storageCfg4))
+	storageCfg4.GC.MemoryLimit = ltoml.Size(1024 * 1024 * 1024)
+	assert.NoError(t, checkStorageBaseCfg(storageCfg4))
 }
 
 func Test_checkCoordinatorCfg(t *testing.T) {
@@ -164,3 +430,103 @@ func Test_checkCoordinatorCfg(t *testing.T) {
 
 	assert.Equal(t, "/1/2", repo.WithSubNamespace("2").Namespace)
 }
+
+func Test_checkQueryCfg(t *testing.T) {
+	queryCfg := Query{MaxSeriesPerQuery: -1}
+	assert.NoError(t, checkQueryCfg(&queryCfg))
+	assert.Zero(t, queryCfg.MaxSeriesPerQuery)
+	assert.Equal(t, NewDefaultQuery().Timeout, queryCfg.Timeout)
+	assert.Equal(t, NewDefaultQuery().ResultCacheTTL, queryCfg.ResultCacheTTL)
+	assert.Equal(t, DatabaseLimitPolicyQueue, queryCfg.DatabaseLimitPolicy)
+	assert.Equal(t, ShardFailurePolicyStrict, queryCfg.ShardFailurePolicy)
+	assert.Equal(t, NewDefaultQuery().WriteVisibleTimeout, queryCfg.WriteVisibleTimeout)
+	assert.Equal(t, NewDefaultQuery().ResultChunkSize, queryCfg.ResultChunkSize)
+
+	queryCfg.DatabaseLimitPolicy = DatabaseLimitPolicyReject
+	assert.NoError(t, checkQueryCfg(&queryCfg))
+	queryCfg.DatabaseLimitPolicy = "drop"
+	assert.Error(t, checkQueryCfg(&queryCfg))
+
+	queryCfg.DatabaseLimitPolicy = DatabaseLimitPolicyQueue
+	queryCfg.ShardFailurePolicy = ShardFailurePolicyBestEffort
+	assert.NoError(t, checkQueryCfg(&queryCfg))
+	queryCfg.ShardFailurePolicy = "ignore"
+	assert.Error(t, checkQueryCfg(&queryCfg))
+}
+
+func TestQuery_IsNumericTagKey(t *testing.T) {
+	queryCfg := NewDefaultQuery()
+	assert.False(t, queryCfg.IsNumericTagKey("port"))
+	queryCfg.NumericTagKeys = []string{"port", "code"}
+	assert.True(t, queryCfg.IsNumericTagKey("port"))
+	assert.False(t, queryCfg.IsNumericTagKey("host"))
+}
+
+func TestParseFieldAlias(t *testing.T) {
+	alias, err := ParseFieldAlias("cpu:usage_total=usage")
+	assert.NoError(t, err)
+	assert.Equal(t, FieldAlias{Metric: "cpu", Field: "usage_total", AliasOf: "usage"}, alias)
+	alias, err = ParseFieldAlias("ns|http:server:latency = cost")
+	assert.NoError(t, err)
+	assert.Equal(t, FieldAlias{Metric: "ns|http:server", Field: "latency", AliasOf: "cost"}, alias)
+
+	for _, alias := range []string{"cpu", "cpu=usage", "cpu:usage", ":a=b", "cpu:=b", "cpu:a=", "cpu:a=a"} {
+		_, err = ParseFieldAlias(alias)
+		assert.Error(t, err, alias)
+	}
+}
+
+func Test_checkFieldAliases(t *testing.T) {
+	assert.NoError(t, checkFieldAliases(nil))
+	assert.NoError(t, checkFieldAliases([]string{"cpu:a=b", "cpu:c=d", "mem:a=b"}))
+	assert.Error(t, checkFieldAliases([]string{"cpu:a=b", "cpu:a=c"}))
+	assert.Error(t, checkFieldAliases([]string{"cpu:a=b", "cpu:c=b"}))
+	assert.Error(t, checkFieldAliases([]string{"cpu"}))
+	assert.Error(t, checkTSDBCfg(&TSDB{Dir: "/tmp/lindb", FieldAliases: []string{"cpu"}}))
+}
+
+func TestParseFieldRetention(t *testing.T) {
+	retention, err := ParseFieldRetention("http:detail_*=72h")
+	assert.NoError(t, err)
+	assert.Equal(t, FieldRetention{Metric: "http", FieldPattern: "detail_*", Retention: 72 * time.Hour}, retention)
+	retention, err = ParseFieldRetention("ns|http:server:cost = 1h")
+	assert.NoError(t, err)
+	assert.Equal(t, FieldRetention{Metric: "ns|http:server", FieldPattern: "cost", Retention: time.Hour}, retention)
+
+	for _, retention := range []string{"http", "http=1h", "http:a", ":a=1h", "http:=1h", "http:a=", "http:a=1x", "http:a=-1h", "http:[=1h"} {
+		_, err = ParseFieldRetention(retention)
+		assert.Error(t, err, retention)
+	}
+	cfg := &TSDB{Dir: "/tmp/lindb", FieldRetentions: []string{"http:a=1h"}}
+	assert.NoError(t, checkTSDBCfg(cfg))
+	assert.Equal(t, NewDefaultStorageBase().TSDB.FieldRetentionInterval, cfg.FieldRetentionInterval)
+	assert.Error(t, checkTSDBCfg(&TSDB{Dir: "/tmp/lindb", FieldRetentions: []string{"http"}}))
+}
+
+func Test_CheckCORSCfg(t *testing.T) {
+	cases := []struct {
+		origins     []string
+		credentials bool
+		wantErr     bool
+	}{
+		{origins: nil},
+		{origins: []string{"*"}},
+		{origins: []string{"https://dashboard.lindb.io", "http://localhost:3000"}, credentials: true},
+		{origins: []string{"*"}, credentials: true, wantErr: true},
+		{origins: []string{"*", "https://dashboard.lindb.io"}, wantErr: true},
+		{origins: []string{"dashboard.lindb.io"}, wantErr: true},
+		{origins: []string{"ftp://dashboard.lindb.io"}, wantErr: true},
+		{origins: []string{"https://dashboard.lindb.io/path"}, wantErr: true},
+		{origins: []string{"https://*.lindb.io"}, wantErr: true},
+		{origins: []string{"://"}, wantErr: true},
+	}
+	for _, c := range cases {
+		h := &HTTP{CORSAllowedOrigins: c.origins, CORSAllowCredentials: c.credentials}
+		err := checkCORSCfg(h)
+		assert.Equal(t, c.wantErr, err != nil, c.origins)
+		if err == nil {
+			assert.Equal(t, defaultCORSAllowedMethods(), h.CORSAllowedMethods)
+			assert.Equal(t, defaultCORSAllowedHeaders(), h.CORSAllowedHeaders)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package config
+
+import (
+	"encoding"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// EnvPrefix represents the prefix of environment variables which override config values.
+const EnvPrefix = "LINDB"
+
+// envOverrideTOMLComment documents the environment variable overrides in default toml config.
+const envOverrideTOMLComment = `## Config values can be overridden by environment variables after parsing this file,
+## named by LINDB_ prefix with the toml path in upper case, '.' and '-' replaced by '_',
+## e.g. LINDB_BROKER_HTTP_PORT=9000 overrides port of [broker.http],
+## LINDB_COORDINATOR_ENDPOINTS=http://etcd1:2379,http://etcd2:2379 overrides endpoints of [coordinator].
+## Lists are comma separated, arrays of tables(e.g. [[broker.acl]]) cannot be overridden.`
+
+// for testing
+var (
+	lookupEnvFunc = os.LookupEnv
+)
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// applyEnvOverrides overrides the fields of config by environment variables,
+// variable name is EnvPrefix with toml path of field, e.g. LINDB_BROKER_HTTP_PORT for broker.http.port.
+// cfg must be a pointer of config struct, returns error if the value of variable is invalid.
+func applyEnvOverrides(cfg interface{}) error {
+	return applyEnvOverridesToStruct(EnvPrefix, reflect.ValueOf(cfg).Elem())
+}
+
+// applyEnvOverridesToStruct overrides the fields of struct value recursively.
+func applyEnvOverridesToStruct(prefix string, v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		key := strings.Split(f.Tag.Get("toml"), ",")[0]
+		if key == "" || key == "-" || f.PkgPath != "" {
+			continue
+		}
+		name := prefix + "_" + strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(key))
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Struct && !fv.Addr().Type().Implements(textUnmarshalerType) {
+			if err := applyEnvOverridesToStruct(name, fv); err != nil {
+				return err
+			}
+			continue
+		}
+		value, ok := lookupEnvFunc(name)
+		if !ok {
+			continue
+		}
+		if err := setFieldValue(fv, value); err != nil {
+			return fmt.Errorf("invalid value of environment variable %s: %q, %w", name, value, err)
+		}
+	}
+	return nil
+}
+
+// setFieldValue parses the value of environment variable into field.
+func setFieldValue(fv reflect.Value, value string) error {
+	if fv.Addr().Type().Implements(textUnmarshalerType) {
+		return fv.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value))
+	}
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(value, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(n)
+	case reflect.Slice:
+		if fv.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported type: %s", fv.Type())
+		}
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		fv.Set(reflect.ValueOf(items).Convert(fv.Type()))
+	default:
+		return fmt.Errorf("unsupported type: %s", fv.Type())
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/pkg/ltoml"
+)
+
+func mockEnv(env map[string]string) {
+	lookupEnvFunc = func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}
+}
+
+func Test_EnvOverrides(t *testing.T) {
+	defer func() {
+		lookupEnvFunc = os.LookupEnv
+	}()
+	brokerCfgPath := filepath.Join(t.TempDir(), "broker.toml")
+	assert.NoError(t, ltoml.WriteConfig(brokerCfgPath, NewDefaultBrokerTOML()))
+
+	// case 1: env overrides toml values
+	mockEnv(map[string]string{
+		"LINDB_BROKER_HTTP_PORT":              "9000",
+		"LINDB_BROKER_HTTP_COMPRESSION":       "false",
+		"LINDB_BROKER_WRITE_BATCH_TIMEOUT":    "10s",
+		"LINDB_BROKER_WRITE_BATCH_BLOCK_SIZE": "1MiB",
+		"LINDB_COORDINATOR_ENDPOINTS":         "http://etcd1:2379, http://etcd2:2379",
+		"LINDB_COORDINATOR_USERNAME":          "admin",
+	})
+	var brokerCfg Broker
+	assert.NoError(t, LoadAndSetBrokerConfig(brokerCfgPath, "", &brokerCfg))
+	assert.Equal(t, uint16(9000), brokerCfg.BrokerBase.HTTP.Port)
+	assert.False(t, brokerCfg.BrokerBase.HTTP.Compression)
+	assert.Equal(t, ltoml.Duration(10*time.Second), brokerCfg.BrokerBase.Write.BatchTimeout)
+	assert.Equal(t, ltoml.Size(1024*1024), brokerCfg.BrokerBase.Write.BatchBlockSize)
+	assert.Equal(t, []string{"http://etcd1:2379", "http://etcd2:2379"}, brokerCfg.Coordinator.Endpoints)
+	assert.Equal(t, "admin", brokerCfg.Coordinator.Username)
+	assert.Equal(t, uint16(9000), GlobalBrokerConfig().HTTP.Port)
+	// not overridden
+	assert.Equal(t, NewDefaultBrokerBase().GRPC.Port, brokerCfg.BrokerBase.GRPC.Port)
+
+	// case 2: invalid env values
+	for _, env := range []map[string]string{
+		{"LINDB_BROKER_HTTP_PORT": "abc"},
+		{"LINDB_BROKER_HTTP_PORT": "70000"},
+		{"LINDB_BROKER_HTTP_COMPRESSION": "yes!"},
+		{"LINDB_BROKER_WRITE_BATCH_TIMEOUT": "10"},
+		{"LINDB_QUERY_QUERY_CONCURRENCY": "1.5"},
+		{"LINDB_BROKER_ACL": "token"},
+	} {
+		mockEnv(env)
+		brokerCfg = Broker{}
+		err := LoadAndSetBrokerConfig(brokerCfgPath, "", &brokerCfg)
+		assert.Error(t, err)
+		for name := range env {
+			assert.Contains(t, err.Error(), name)
+		}
+	}
+	// case 3: env value is validated by config check
+	mockEnv(map[string]string{"LINDB_BROKER_HTTP_PORT": "0"})
+	brokerCfg = Broker{}
+	assert.EqualError(t, LoadAndSetBrokerConfig(brokerCfgPath, "", &brokerCfg),
+		"failed checking broker config: http port cannot be empty")
+
+	// case 4: storage config
+	storageCfgPath := filepath.Join(t.TempDir(), "storage.toml")
+	assert.NoError(t, ltoml.WriteConfig(storageCfgPath, NewDefaultStorageTOML()))
+	mockEnv(map[string]string{
+		"LINDB_STORAGE_GRPC_PORT":                       "3000",
+		"LINDB_STORAGE_TSDB_MAX_MEMDB_SIZE":             "100MiB",
+		"LINDB_STORAGE_TSDB_MAX_MEM_USAGE_BEFORE_FLUSH": "0.8",
+		"LINDB_STORAGE_TSDB_MAX_SERIESIDS":              "100",
+	})
+	var storageCfg Storage
+	assert.NoError(t, LoadAndSetStorageConfig(storageCfgPath, "", &storageCfg))
+	assert.Equal(t, uint16(3000), storageCfg.StorageBase.GRPC.Port)
+	assert.Equal(t, ltoml.Size(100*1024*1024), storageCfg.StorageBase.TSDB.MaxMemDBSize)
+	assert.Equal(t, 0.8, storageCfg.StorageBase.TSDB.MaxMemUsageBeforeFlush)
+	assert.Equal(t, 100, storageCfg.StorageBase.TSDB.MaxSeriesIDsNumber)
+	mockEnv(map[string]string{"LINDB_STORAGE_TSDB_MAX_MEM_USAGE_BEFORE_FLUSH": "high"})
+	storageCfg = Storage{}
+	assert.Error(t, LoadAndSetStorageConfig(storageCfgPath, "", &storageCfg))
+
+	// case 5: standalone config
+	standaloneCfgPath := filepath.Join(t.TempDir(), "standalone.toml")
+	assert.NoError(t, ltoml.WriteConfig(standaloneCfgPath, NewDefaultStandaloneTOML()))
+	mockEnv(map[string]string{"LINDB_ETCD_URL": "http://localhost:2380"})
+	var standaloneCfg Standalone
+	assert.NoError(t, LoadAndSetStandAloneConfig(standaloneCfgPath, "", &standaloneCfg))
+	assert.Equal(t, "http://localhost:2380", standaloneCfg.ETCD.URL)
+	mockEnv(map[string]string{"LINDB_STORAGE_INDICATOR": "x"})
+	standaloneCfg = Standalone{}
+	assert.Error(t, LoadAndSetStandAloneConfig(standaloneCfgPath, "", &standaloneCfg))
+}
@@ -95,9 +95,12 @@ func NewDefaultCoordinator() *RepoState {
 
 // GRPC represents grpc server config
 type GRPC struct {
-	Port                 uint16         `toml:"port"`
-	MaxConcurrentStreams int            `toml:"max-concurrent-streams"`
-	ConnectTimeout       ltoml.Duration `toml:"connect-timeout"`
+	Port                 uint16         `toml:"port" json:"port"`
+	MaxConcurrentStreams int            `toml:"max-concurrent-streams" json:"maxConcurrentStreams"`
+	ConnectTimeout       ltoml.Duration `toml:"connect-timeout" json:"connectTimeout"`
+	ConnPoolMinSize      int            `toml:"conn-pool-min-size" json:"connPoolMinSize"`
+	ConnPoolMaxSize      int            `toml:"conn-pool-max-size" json:"connPoolMaxSize"`
+	ConnPoolIdleTimeout  ltoml.Duration `toml:"conn-pool-idle-timeout" json:"connPoolIdleTimeout"`
 }
 
 func (g *GRPC) TOML() string {
@@ -108,10 +111,27 @@ port = %d
 max-concurrent-streams = %d
 ## connect-timeout sets the timeout for connection establishment.
 ## Default: 3s
-connect-timeout = "%s"`,
+connect-timeout = "%s"
+## Connections to each target node are pooled, reused by streams of queries and writes,
+## stream is created on the connection with the least active streams,
+## new connection is created if all connections are active and pool is not full.
+##
+## conn-pool-min-size is the min num. of connections kept for each target node.
+## Default: 1
+conn-pool-min-size = %d
+## conn-pool-max-size is the max num. of connections for each target node.
+## Default: 4
+conn-pool-max-size = %d
+## conn-pool-idle-timeout is the duration after which the connection without active streams is closed,
+## if there are more than conn-pool-min-size connections.
+## Default: 5m
+conn-pool-idle-timeout = "%s"`,
 		g.Port,
 		g.MaxConcurrentStreams,
 		g.ConnectTimeout.Duration().String(),
+		g.ConnPoolMinSize,
+		g.ConnPoolMaxSize,
+		g.ConnPoolIdleTimeout.Duration().String(),
 	)
 }
 
@@ -123,12 +143,55 @@ type StorageCluster struct {
 
 // Query represents query rpc config
 type Query struct {
-	QueryConcurrency int            `toml:"query-concurrency"`
-	IdleTimeout      ltoml.Duration `toml:"idle-timeout"`
-	Timeout          ltoml.Duration `toml:"timeout"`
+	QueryConcurrency   int            `toml:"query-concurrency" json:"queryConcurrency"`
+	IdleTimeout        ltoml.Duration `toml:"idle-timeout" json:"idleTimeout"`
+	Timeout            ltoml.Duration `toml:"timeout" json:"timeout"`
+	SlowQueryThreshold ltoml.Duration `toml:"slow-query-threshold" json:"slowQueryThreshold"`
+	ResultCacheSize    int            `toml:"result-cache-size" json:"resultCacheSize"`
+	ResultCacheTTL     ltoml.Duration `toml:"result-cache-ttl" json:"resultCacheTTL"`
+	MaxSeriesPerQuery  int            `toml:"max-series-per-query" json:"maxSeriesPerQuery"`
+
+	MaxConcurrentQueriesPerDB int    `toml:"max-concurrent-queries-per-db" json:"maxConcurrentQueriesPerDB"`
+	DatabaseLimitPolicy       string `toml:"database-limit-policy" json:"databaseLimitPolicy"`
+	ShardFailurePolicy        string `toml:"shard-failure-policy" json:"shardFailurePolicy"`
+	// MaxQueriesPerSecondPerToken limits the query rate of each api token, can be overridden by acl of token,
+	// the tokens not configured by acl share one limit, queries without token are not limited.
+	MaxQueriesPerSecondPerToken float64 `toml:"max-queries-per-second-per-token" json:"maxQueriesPerSecondPerToken"`
+	QueryBurstPerToken          int     `toml:"query-burst-per-token" json:"queryBurstPerToken"`
+	// NumericTagKeys are the tag keys whose values can be filtered by numeric comparison.
+	NumericTagKeys []string `toml:"numeric-tag-keys" json:"numericTagKeys"`
+	// WriteVisibleTimeout is the max duration storage waits the written data of write token visible.
+	WriteVisibleTimeout ltoml.Duration `toml:"write-visible-timeout" json:"writeVisibleTimeout"`
+	// MaxBitmapMemoryPerQuery limits the memory of intermediate series ids bitmaps when evaluating tag filter.
+	MaxBitmapMemoryPerQuery ltoml.Size `toml:"max-bitmap-memory-per-query" json:"maxBitmapMemoryPerQuery"`
+	// ResultChunkSize bounds the size of each chunk when streaming query result.
+	ResultChunkSize ltoml.Size `toml:"result-chunk-size" json:"resultChunkSize"`
+}
+
+const (
+	// DatabaseLimitPolicyQueue queues the query until other query of database completes or timeout.
+	DatabaseLimitPolicyQueue = "queue"
+	// DatabaseLimitPolicyReject rejects the query directly.
+	DatabaseLimitPolicyReject = "reject"
+
+	// ShardFailurePolicyStrict fails the whole query if any shard fails.
+	ShardFailurePolicyStrict = "strict"
+	// ShardFailurePolicyBestEffort returns the result of healthy shards with warning of failed shards.
+	ShardFailurePolicyBestEffort = "best-effort"
+)
+
+// IsNumericTagKey returns if the tag key is declared numeric.
+func (q *Query) IsNumericTagKey(tagKey string) bool {
+	for _, key := range q.NumericTagKeys {
+		if key == tagKey {
+			return true
+		}
+	}
+	return false
 }
 
 func (q *Query) TOML() string {
+	numericTagKeys, _ := json.Marshal(q.NumericTagKeys)
 	return fmt.Sprintf(`
 [query]
 ## Number of queries allowed to execute concurrently
@@ -139,18 +202,96 @@ query-concurrency = %d
 idle-timeout = "%s"
 ## Maximum timeout threshold for query.
 ## Default: 5s
-timeout = "%s"`,
+timeout = "%s"
+## Query which costs more than this threshold will be logged as slow query,
+## 0 means disable slow query log.
+## Default: 1s
+slow-query-threshold = "%s"
+## Max num. of query results cached in broker, which are keyed by query signature,
+## query whose time range includes the current interval is not cached, 0 means disable result cache.
+## Default: 0
+result-cache-size = %d
+## Cached query result expires after this duration.
+## Default: 10s
+result-cache-ttl = "%s"
+## Max num. of series a query can resolve in storage after filtering by tags and time range,
+## query exceeds it is rejected before scanning data unless it allows large scan explicitly(largeScan=true),
+## 0 means no limit.
+## Default: 0
+max-series-per-query = %d
+## Max num. of queries allowed to execute concurrently for each database in broker,
+## so that one database cannot starve others, 0 means no limit.
+## Default: 0
+max-concurrent-queries-per-db = %d
+## How to handle the query which exceeds max-concurrent-queries-per-db, queue or reject.
+## queue: waits until other query of database completes, rejects it after timeout.
+## reject: rejects it directly.
+## Default: queue
+database-limit-policy = "%s"
+## How to handle the query if some shards fail, strict or best-effort.
+## strict: fails the whole query.
+## best-effort: returns the result of healthy shards with warning of failed shards,
+## shards failed to receive the query or not responded before query timeout are also treated as failed.
+## Default: strict
+shard-failure-policy = "%s"
+## Max num. of queries per second allowed for each api token(Authorization: Bearer <token>),
+## query exceeds it is rejected with 429 and Retry-After header, can be overridden by [[broker.acl]],
+## the tokens not configured by [[broker.acl]] share one limit, queries without token are not limited, 0 means no limit.
+## Default: 0
+max-queries-per-second-per-token = %.2f
+## Max num. of queries allowed in a burst for each api token,
+## 0 means same as max-queries-per-second-per-token(at least 1).
+## Default: 0
+query-burst-per-token = %d
+## Tag keys whose values are numeric, such as ["port"], values of them can be filtered
+## by numeric comparison(>, >=, <, <=), non-numeric values never match the comparison.
+## Default: []
+numeric-tag-keys = %s
+## Max duration storage waits until the data written with write token(returned by write with ack level leader/quorum)
+## is visible before scanning, for query with writeToken=xxx(read-your-writes).
+## Default: 5s
+write-visible-timeout = "%s"
+## Max memory of intermediate series ids bitmaps a query can materialize when evaluating tag filter,
+## shared by all shards of the query in a storage node, checked when unions series ids of many tag values,
+## query exceeds it is aborted with error instead of exhausting memory,
+## 0 means no limit.
+## Default: 0
+max-bitmap-memory-per-query = "%s"
+## Max size of each chunk when storage sends query result to broker, and broker sends it to client
+## with stream=true, large result is split into multi chunks instead of being buffered in one response.
+## Default: 1MiB
+result-chunk-size = "%s"`,
 		q.QueryConcurrency,
 		q.IdleTimeout,
 		q.Timeout,
+		q.SlowQueryThreshold,
+		q.ResultCacheSize,
+		q.ResultCacheTTL,
+		q.MaxSeriesPerQuery,
+		q.MaxConcurrentQueriesPerDB,
+		q.DatabaseLimitPolicy,
+		q.ShardFailurePolicy,
+		q.MaxQueriesPerSecondPerToken,
+		q.QueryBurstPerToken,
+		numericTagKeys,
+		q.WriteVisibleTimeout,
+		q.MaxBitmapMemoryPerQuery,
+		q.ResultChunkSize,
 	)
 }
 
 func NewDefaultQuery() *Query {
 	return &Query{
-		QueryConcurrency: runtime.GOMAXPROCS(-1) * 2,
-		IdleTimeout:      ltoml.Duration(5 * time.Second),
-		Timeout:          ltoml.Duration(5 * time.Second),
+		QueryConcurrency:    runtime.GOMAXPROCS(-1) * 2,
+		IdleTimeout:         ltoml.Duration(5 * time.Second),
+		Timeout:             ltoml.Duration(5 * time.Second),
+		SlowQueryThreshold:  ltoml.Duration(time.Second),
+		ResultCacheTTL:      ltoml.Duration(10 * time.Second),
+		DatabaseLimitPolicy: DatabaseLimitPolicyQueue,
+		ShardFailurePolicy:  ShardFailurePolicyStrict,
+		NumericTagKeys:      []string{},
+		WriteVisibleTimeout: ltoml.Duration(5 * time.Second),
+		ResultChunkSize:     ltoml.Size(1024 * 1024),
 	}
 }
 
@@ -183,10 +324,19 @@ func checkGRPCCfg(grpcCfg *GRPC) error {
 	if grpcCfg.ConnectTimeout <= 0 {
 		grpcCfg.ConnectTimeout = ltoml.Duration(time.Second * 3)
 	}
+	if grpcCfg.ConnPoolMinSize <= 0 {
+		grpcCfg.ConnPoolMinSize = 1
+	}
+	if grpcCfg.ConnPoolMaxSize < grpcCfg.ConnPoolMinSize {
+		grpcCfg.ConnPoolMaxSize = grpcCfg.ConnPoolMinSize
+	}
+	if grpcCfg.ConnPoolIdleTimeout <= 0 {
+		grpcCfg.ConnPoolIdleTimeout = ltoml.Duration(time.Minute * 5)
+	}
 	return nil
 }
 
-func checkQueryCfg(queryCfg *Query) {
+func checkQueryCfg(queryCfg *Query) error {
 	defaultQuery := NewDefaultQuery()
 	if queryCfg.QueryConcurrency <= 0 {
 		queryCfg.QueryConcurrency = defaultQuery.QueryConcurrency
@@ -197,4 +347,37 @@ func checkQueryCfg(queryCfg *Query) {
 	if queryCfg.IdleTimeout <= 0 {
 		queryCfg.IdleTimeout = defaultQuery.IdleTimeout
 	}
+	if queryCfg.ResultCacheTTL <= 0 {
+		queryCfg.ResultCacheTTL = defaultQuery.ResultCacheTTL
+	}
+	if queryCfg.MaxSeriesPerQuery < 0 {
+		queryCfg.MaxSeriesPerQuery = defaultQuery.MaxSeriesPerQuery
+	}
+	switch queryCfg.DatabaseLimitPolicy {
+	case "":
+		queryCfg.DatabaseLimitPolicy = defaultQuery.DatabaseLimitPolicy
+	case DatabaseLimitPolicyQueue, DatabaseLimitPolicyReject:
+	default:
+		return fmt.Errorf("unknown database limit policy: %s", queryCfg.DatabaseLimitPolicy)
+	}
+	switch queryCfg.ShardFailurePolicy {
+	case "":
+		queryCfg.ShardFailurePolicy = defaultQuery.ShardFailurePolicy
+	case ShardFailurePolicyStrict, ShardFailurePolicyBestEffort:
+	default:
+		return fmt.Errorf("unknown shard failure policy: %s", queryCfg.ShardFailurePolicy)
+	}
+	if queryCfg.MaxQueriesPerSecondPerToken < 0 {
+		queryCfg.MaxQueriesPerSecondPerToken = defaultQuery.MaxQueriesPerSecondPerToken
+	}
+	if queryCfg.QueryBurstPerToken < 0 {
+		queryCfg.QueryBurstPerToken = defaultQuery.QueryBurstPerToken
+	}
+	if queryCfg.WriteVisibleTimeout <= 0 {
+		queryCfg.WriteVisibleTimeout = defaultQuery.WriteVisibleTimeout
+	}
+	if queryCfg.ResultChunkSize == 0 {
+		queryCfg.ResultChunkSize = defaultQuery.ResultChunkSize
+	}
+	return nil
 }
@@ -30,11 +30,13 @@ var (
 
 	globalBrokerCfg  atomic.Value
 	globalStorageCfg atomic.Value
+	globalQueryCfg   atomic.Value
 )
 
 func init() {
 	globalBrokerCfg.Store(NewDefaultBrokerBase())
 	globalStorageCfg.Store(NewDefaultStorageBase())
+	globalQueryCfg.Store(NewDefaultQuery())
 }
 
 // GlobalBrokerConfig returns the global broker config
@@ -47,52 +49,111 @@ func GlobalStorageConfig() *StorageBase {
 	return globalStorageCfg.Load().(*StorageBase)
 }
 
+// SetGlobalBrokerConfig sets the global broker config
+func SetGlobalBrokerConfig(brokerCfg *BrokerBase) {
+	globalBrokerCfg.Store(brokerCfg)
+}
+
 func SetGlobalStorageConfig(storageCfg *StorageBase) {
 	globalStorageCfg.Store(storageCfg)
 }
 
-// LoadAndSetBrokerConfig parses the broker config file
-// this config will be triggered to reload when receiving a SIGHUP signal
+// GlobalQueryConfig returns the global query config
+func GlobalQueryConfig() *Query {
+	return globalQueryCfg.Load().(*Query)
+}
+
+// SetGlobalQueryConfig sets the global query config
+func SetGlobalQueryConfig(queryCfg *Query) {
+	globalQueryCfg.Store(queryCfg)
+}
+
+// LoadAndSetBrokerConfig parses the broker config file, then applies environment variable overrides
+// the fields safe to change at runtime are reloaded when receiving a SIGHUP signal, see reload.go
 func LoadAndSetBrokerConfig(cfgName string, defaultPath string, brokerCfg *Broker) error {
+	if err := loadBrokerConfig(cfgName, defaultPath, brokerCfg); err != nil {
+		return err
+	}
+	globalBrokerCfg.Store(&brokerCfg.BrokerBase)
+	globalQueryCfg.Store(&brokerCfg.Query)
+	return nil
+}
+
+// loadBrokerConfig parses the broker config file, applies environment variable overrides, then checks it.
+func loadBrokerConfig(cfgName string, defaultPath string, brokerCfg *Broker) error {
 	if err := ltoml.LoadConfig(cfgName, defaultPath, &brokerCfg); err != nil {
 		return fmt.Errorf("decode broker config file error: %s", err)
 	}
-	checkQueryCfg(&brokerCfg.Query)
+	if err := applyEnvOverrides(brokerCfg); err != nil {
+		return fmt.Errorf("failed applying environment variable overrides: %s", err)
+	}
+	if err := checkQueryCfg(&brokerCfg.Query); err != nil {
+		return fmt.Errorf("failed check query config: %s", err)
+	}
 	if err := checkCoordinatorCfg(&brokerCfg.Coordinator); err != nil {
 		return fmt.Errorf("failed check coordinator config: %s", err)
 	}
 	if err := checkBrokerBaseCfg(&brokerCfg.BrokerBase); err != nil {
 		return fmt.Errorf("failed checking broker config: %s", err)
 	}
-	globalBrokerCfg.Store(&brokerCfg.BrokerBase)
 	return nil
 }
 
-// LoadAndSetStorageConfig parses the storage config file
-// this config will be triggered to reload when receiving a SIGHUP signal
+// LoadAndSetStorageConfig parses the storage config file, then applies environment variable overrides
+// the fields safe to change at runtime are reloaded when receiving a SIGHUP signal, see reload.go
 func LoadAndSetStorageConfig(cfgName string, defaultPath string, storageCfg *Storage) error {
+	if err := loadStorageConfig(cfgName, defaultPath, storageCfg); err != nil {
+		return err
+	}
+	globalStorageCfg.Store(&storageCfg.StorageBase)
+	globalQueryCfg.Store(&storageCfg.Query)
+	return nil
+}
+
+// loadStorageConfig parses the storage config file, applies environment variable overrides, then checks it.
+func loadStorageConfig(cfgName string, defaultPath string, storageCfg *Storage) error {
 	if err := ltoml.LoadConfig(cfgName, defaultPath, &storageCfg); err != nil {
 		return fmt.Errorf("decode storage config file error: %s", err)
 	}
-	checkQueryCfg(&storageCfg.Query)
+	if err := applyEnvOverrides(storageCfg); err != nil {
+		return fmt.Errorf("failed applying environment variable overrides: %s", err)
+	}
+	if err := checkQueryCfg(&storageCfg.Query); err != nil {
+		return fmt.Errorf("failed check query config: %s", err)
+	}
 	if err := checkCoordinatorCfg(&storageCfg.Coordinator); err != nil {
 		return fmt.Errorf("failed check coordinator config: %s", err)
 	}
 	if err := checkStorageBaseCfg(&storageCfg.StorageBase); err != nil {
 		return fmt.Errorf("failed checking storage config: %s", err)
 	}
-	globalStorageCfg.Store(&storageCfg.StorageBase)
 	return nil
 }
 
-// LoadAndSetStandAloneConfig parses the standalone config file
+// LoadAndSetStandAloneConfig parses the standalone config file, then applies environment variable overrides,
 // then sets the global broker and storage config
-// this config will be triggered to reload when receiving a SIGHUP signal
+// the fields safe to change at runtime are reloaded when receiving a SIGHUP signal, see reload.go
 func LoadAndSetStandAloneConfig(cfgName string, defaultPath string, standaloneCfg *Standalone) error {
+	if err := loadStandaloneConfig(cfgName, defaultPath, standaloneCfg); err != nil {
+		return err
+	}
+	globalBrokerCfg.Store(&standaloneCfg.BrokerBase)
+	globalStorageCfg.Store(&standaloneCfg.StorageBase)
+	globalQueryCfg.Store(&standaloneCfg.Query)
+	return nil
+}
+
+// loadStandaloneConfig parses the standalone config file, applies environment variable overrides, then checks it.
+func loadStandaloneConfig(cfgName string, defaultPath string, standaloneCfg *Standalone) error {
 	if err := ltoml.LoadConfig(cfgName, defaultPath, &standaloneCfg); err != nil {
 		return fmt.Errorf("decode standalone config file error: %s", err)
 	}
-	checkQueryCfg(&standaloneCfg.Query)
+	if err := applyEnvOverrides(standaloneCfg); err != nil {
+		return fmt.Errorf("failed applying environment variable overrides: %s", err)
+	}
+	if err := checkQueryCfg(&standaloneCfg.Query); err != nil {
+		return fmt.Errorf("failed check query config: %s", err)
+	}
 	if err := checkCoordinatorCfg(&standaloneCfg.Coordinator); err != nil {
 		return fmt.Errorf("failed check coordinator config: %s", err)
 	}
@@ -102,7 +163,5 @@ func LoadAndSetStandAloneConfig(cfgName string, defaultPath string, standaloneCf
 	if err := checkStorageBaseCfg(&standaloneCfg.StorageBase); err != nil {
 		return fmt.Errorf("failed checking storage config: %s", err)
 	}
-	globalBrokerCfg.Store(&standaloneCfg.BrokerBase)
-	globalStorageCfg.Store(&standaloneCfg.StorageBase)
 	return nil
 }
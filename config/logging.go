@@ -31,11 +31,11 @@ var (
 
 // Logging represents a logging configuration
 type Logging struct {
-	Dir        string     `toml:"dir"`
-	Level      string     `toml:"level"`
-	MaxSize    ltoml.Size `toml:"maxsize"`
-	MaxBackups uint16     `toml:"maxbackups"`
-	MaxAge     uint16     `toml:"maxage"`
+	Dir        string     `toml:"dir" json:"dir"`
+	Level      string     `toml:"level" json:"level"`
+	MaxSize    ltoml.Size `toml:"maxsize" json:"maxsize"`
+	MaxBackups uint16     `toml:"maxbackups" json:"maxbackups"`
+	MaxAge     uint16     `toml:"maxage" json:"maxage"`
 }
 
 // TOML returns Logging's toml config string
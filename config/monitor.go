@@ -31,9 +31,13 @@ var (
 
 // Monitor represents a configuration for the internal monitor
 type Monitor struct {
-	PushTimeout    ltoml.Duration `toml:"push-timeout"`
-	ReportInterval ltoml.Duration `toml:"report-interval"`
-	URL            string         `toml:"url"`
+	PushTimeout    ltoml.Duration `toml:"push-timeout" json:"pushTimeout"`
+	ReportInterval ltoml.Duration `toml:"report-interval" json:"reportInterval"`
+	URL            string         `toml:"url" json:"url"`
+	// NativeHistogram enables sparse exponential buckets for internal histograms.
+	NativeHistogram bool `toml:"native-histogram" json:"nativeHistogram"`
+	// DatabaseDiskUsage enables disk usage metric of each database/shard, based on shard sizes of storage engine.
+	DatabaseDiskUsage bool `toml:"database-disk-usage" json:"databaseDiskUsage"`
 }
 
 // TOML returns Monitor's toml config
@@ -49,10 +53,21 @@ push-timeout = "%s"
 ## Default: 10s
 report-interval = "%s"
 ## URL is the target of broker native ingestion url
-url = "%s"`,
+url = "%s"
+## whether internal histograms use native(sparse exponential) buckets instead of fixed buckets,
+## native histogram has better accuracy of quantile at the tails, but may produce more buckets.
+## Default: false
+native-histogram = %v
+## whether storage reports the disk usage of each database and shard on the report interval,
+## shard sizes come from storage engine which walks the directories of all shards,
+## may be costly if there are lots of files.
+## Default: false
+database-disk-usage = %v`,
 		m.PushTimeout.String(),
 		m.ReportInterval.String(),
 		m.URL,
+		m.NativeHistogram,
+		m.DatabaseDiskUsage,
 	)
 }
 
@@ -0,0 +1,145 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package config
+
+import (
+	"reflect"
+	"strings"
+)
+
+// ReloadBrokerConfig re-reads the broker config file, validates it by the existing checks,
+// then applies the fields safe to change at runtime onto a copy of current config and sets it as global config.
+// Returns the reloaded config and the toml paths of other changed fields(e.g. ports),
+// which are kept unchanged because they take effect only after restart.
+func ReloadBrokerConfig(cfgName string, defaultPath string, current *Broker) (*Broker, []string, error) {
+	newCfg := Broker{}
+	if err := loadBrokerConfig(cfgName, defaultPath, &newCfg); err != nil {
+		return nil, nil, err
+	}
+	reloaded := *current
+	reloadQueryCfg(&reloaded.Query, &newCfg.Query)
+	reloadLoggingCfg(&reloaded.Logging, &newCfg.Logging)
+	reloadIngestionCfg(&reloaded.BrokerBase.Ingestion, &newCfg.BrokerBase.Ingestion)
+	ignored := diffConfig("", reflect.ValueOf(reloaded), reflect.ValueOf(newCfg))
+
+	globalBrokerCfg.Store(&reloaded.BrokerBase)
+	globalQueryCfg.Store(&reloaded.Query)
+	return &reloaded, ignored, nil
+}
+
+// ReloadStorageConfig re-reads the storage config file, validates it by the existing checks,
+// then applies the fields safe to change at runtime onto a copy of current config and sets it as global config.
+// Returns the reloaded config and the toml paths of other changed fields(e.g. ports),
+// which are kept unchanged because they take effect only after restart.
+func ReloadStorageConfig(cfgName string, defaultPath string, current *Storage) (*Storage, []string, error) {
+	newCfg := Storage{}
+	if err := loadStorageConfig(cfgName, defaultPath, &newCfg); err != nil {
+		return nil, nil, err
+	}
+	reloaded := *current
+	reloadQueryCfg(&reloaded.Query, &newCfg.Query)
+	reloadLoggingCfg(&reloaded.Logging, &newCfg.Logging)
+	reloadTSDBCfg(&reloaded.StorageBase.TSDB, &newCfg.StorageBase.TSDB)
+	ignored := diffConfig("", reflect.ValueOf(reloaded), reflect.ValueOf(newCfg))
+
+	globalStorageCfg.Store(&reloaded.StorageBase)
+	globalQueryCfg.Store(&reloaded.Query)
+	return &reloaded, ignored, nil
+}
+
+// ReloadStandaloneConfig re-reads the standalone config file, validates it by the existing checks,
+// then applies the fields safe to change at runtime onto a copy of current config and sets it as global config.
+// Returns the reloaded config and the toml paths of other changed fields(e.g. ports),
+// which are kept unchanged because they take effect only after restart.
+func ReloadStandaloneConfig(cfgName string, defaultPath string, current *Standalone) (*Standalone, []string, error) {
+	newCfg := Standalone{}
+	if err := loadStandaloneConfig(cfgName, defaultPath, &newCfg); err != nil {
+		return nil, nil, err
+	}
+	reloaded := *current
+	reloadQueryCfg(&reloaded.Query, &newCfg.Query)
+	reloadLoggingCfg(&reloaded.Logging, &newCfg.Logging)
+	reloadIngestionCfg(&reloaded.BrokerBase.Ingestion, &newCfg.BrokerBase.Ingestion)
+	reloadTSDBCfg(&reloaded.StorageBase.TSDB, &newCfg.StorageBase.TSDB)
+	ignored := diffConfig("", reflect.ValueOf(reloaded), reflect.ValueOf(newCfg))
+
+	globalBrokerCfg.Store(&reloaded.BrokerBase)
+	globalStorageCfg.Store(&reloaded.StorageBase)
+	globalQueryCfg.Store(&reloaded.Query)
+	return &reloaded, ignored, nil
+}
+
+// reloadQueryCfg applies the query thresholds which are read on each query.
+// Timeouts, concurrency and rate limits(max-queries-per-second-per-token/query-burst-per-token) are excluded,
+// because they are captured by the query limiters and task manager when starting,
+// changing them is reported as ignored and takes effect only after restart.
+func reloadQueryCfg(current, newCfg *Query) {
+	current.SlowQueryThreshold = newCfg.SlowQueryThreshold
+	current.MaxSeriesPerQuery = newCfg.MaxSeriesPerQuery
+}
+
+// reloadLoggingCfg applies the log level, which is applied to running logger by caller.
+func reloadLoggingCfg(current, newCfg *Logging) {
+	current.Level = newCfg.Level
+}
+
+// reloadIngestionCfg applies the ingestion limits which are read on each write.
+// Ingest timeout and concurrency are excluded, because they are captured by the ingestion limiter when starting,
+// changing them is reported as ignored and takes effect only after restart.
+func reloadIngestionCfg(current, newCfg *Ingestion) {
+	current.NormalizeFieldName = newCfg.NormalizeFieldName
+	current.ClampClockSkew = newCfg.ClampClockSkew
+	current.MaxFutureSkew = newCfg.MaxFutureSkew
+	current.MaxPastAge = newCfg.MaxPastAge
+	current.MaxBackfillAge = newCfg.MaxBackfillAge
+	current.DefaultNamespace = newCfg.DefaultNamespace
+}
+
+// reloadTSDBCfg applies the flush thresholds and limits which are read on each flush check/write.
+func reloadTSDBCfg(current, newCfg *TSDB) {
+	current.MaxMemDBSize = newCfg.MaxMemDBSize
+	current.MutableMemDBTTL = newCfg.MutableMemDBTTL
+	current.MaxMemUsageBeforeFlush = newCfg.MaxMemUsageBeforeFlush
+	current.MaxSeriesWALBacklog = newCfg.MaxSeriesWALBacklog
+	current.MaxTagKeysNumber = newCfg.MaxTagKeysNumber
+	current.WriteProfileSampleRate = newCfg.WriteProfileSampleRate
+}
+
+// diffConfig returns the toml paths of fields whose values are different between two config struct values.
+func diffConfig(prefix string, a, b reflect.Value) (paths []string) {
+	t := a.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		key := strings.Split(f.Tag.Get("toml"), ",")[0]
+		if key == "" || key == "-" || f.PkgPath != "" {
+			continue
+		}
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		fa, fb := a.Field(i), b.Field(i)
+		if fa.Kind() == reflect.Struct {
+			paths = append(paths, diffConfig(path, fa, fb)...)
+			continue
+		}
+		if !reflect.DeepEqual(fa.Interface(), fb.Interface()) {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
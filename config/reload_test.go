@@ -0,0 +1,136 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/pkg/ltoml"
+)
+
+func storageTOML(query *Query, storageBase *StorageBase, logging *Logging) string {
+	return fmt.Sprintf("%s\n\n%s\n\n%s\n\n%s\n\n%s",
+		NewDefaultCoordinator().TOML(), query.TOML(), storageBase.TOML(), NewDefaultMonitor().TOML(), logging.TOML())
+}
+
+func Test_ReloadStorageConfig(t *testing.T) {
+	defer func() {
+		SetGlobalStorageConfig(NewDefaultStorageBase())
+		SetGlobalQueryConfig(NewDefaultQuery())
+	}()
+	cfgPath := filepath.Join(t.TempDir(), "storage.toml")
+	assert.NoError(t, ltoml.WriteConfig(cfgPath, NewDefaultStorageTOML()))
+	var storageCfg Storage
+	assert.NoError(t, LoadAndSetStorageConfig(cfgPath, "", &storageCfg))
+	assert.Equal(t, ltoml.Duration(time.Second), GlobalQueryConfig().SlowQueryThreshold)
+
+	// case 1: reload slow query threshold/log level/tsdb thresholds, port is ignored
+	query := NewDefaultQuery()
+	query.SlowQueryThreshold = ltoml.Duration(3 * time.Second)
+	query.MaxSeriesPerQuery = 1000
+	storageBase := NewDefaultStorageBase()
+	storageBase.GRPC.Port = 3000
+	storageBase.TSDB.MaxMemUsageBeforeFlush = 0.9
+	logging := NewDefaultLogging()
+	logging.Level = "debug"
+	assert.NoError(t, ltoml.WriteConfig(cfgPath, storageTOML(query, storageBase, logging)))
+	reloaded, ignored, err := ReloadStorageConfig(cfgPath, "", &storageCfg)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"storage.grpc.port"}, ignored)
+	assert.Equal(t, ltoml.Duration(3*time.Second), reloaded.Query.SlowQueryThreshold)
+	assert.Equal(t, ltoml.Duration(3*time.Second), GlobalQueryConfig().SlowQueryThreshold)
+	assert.Equal(t, 1000, GlobalQueryConfig().MaxSeriesPerQuery)
+	assert.Equal(t, "debug", reloaded.Logging.Level)
+	assert.Equal(t, 0.9, GlobalStorageConfig().TSDB.MaxMemUsageBeforeFlush)
+	assert.Equal(t, NewDefaultStorageBase().GRPC.Port, reloaded.StorageBase.GRPC.Port)
+	assert.Equal(t, NewDefaultStorageBase().GRPC.Port, GlobalStorageConfig().GRPC.Port)
+	// current config is not changed
+	assert.Equal(t, ltoml.Duration(time.Second), storageCfg.Query.SlowQueryThreshold)
+
+	// case 2: invalid config is not applied
+	storageBase.TSDB.FlushSyncPolicy = "fdatasync"
+	assert.NoError(t, ltoml.WriteConfig(cfgPath, storageTOML(query, storageBase, logging)))
+	_, _, err = ReloadStorageConfig(cfgPath, "", reloaded)
+	assert.Error(t, err)
+	assert.Equal(t, ltoml.Duration(3*time.Second), GlobalQueryConfig().SlowQueryThreshold)
+}
+
+func Test_ReloadBrokerConfig(t *testing.T) {
+	defer func() {
+		SetGlobalBrokerConfig(NewDefaultBrokerBase())
+		SetGlobalQueryConfig(NewDefaultQuery())
+	}()
+	cfgPath := filepath.Join(t.TempDir(), "broker.toml")
+	assert.NoError(t, ltoml.WriteConfig(cfgPath, NewDefaultBrokerTOML()))
+	var brokerCfg Broker
+	assert.NoError(t, LoadAndSetBrokerConfig(cfgPath, "", &brokerCfg))
+
+	brokerBase := NewDefaultBrokerBase()
+	brokerBase.HTTP.Port = 9001
+	brokerBase.Ingestion.MaxFutureSkew = ltoml.Duration(time.Minute)
+	query := NewDefaultQuery()
+	query.SlowQueryThreshold = ltoml.Duration(2 * time.Second)
+	assert.NoError(t, ltoml.WriteConfig(cfgPath, fmt.Sprintf("%s\n\n%s\n\n%s\n\n%s\n\n%s",
+		NewDefaultCoordinator().TOML(), query.TOML(), brokerBase.TOML(), NewDefaultMonitor().TOML(), NewDefaultLogging().TOML())))
+	reloaded, ignored, err := ReloadBrokerConfig(cfgPath, "", &brokerCfg)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"broker.http.port"}, ignored)
+	assert.Equal(t, ltoml.Duration(time.Minute), GlobalBrokerConfig().Ingestion.MaxFutureSkew)
+	assert.Equal(t, ltoml.Duration(2*time.Second), GlobalQueryConfig().SlowQueryThreshold)
+	assert.Equal(t, NewDefaultBrokerBase().HTTP.Port, reloaded.BrokerBase.HTTP.Port)
+
+	// timeouts and rate limits are captured by limiters when starting, so are ignored
+	brokerBase.HTTP.Port = NewDefaultBrokerBase().HTTP.Port
+	brokerBase.Ingestion.IngestTimeout = ltoml.Duration(time.Minute)
+	query.Timeout = ltoml.Duration(time.Minute)
+	query.MaxQueriesPerSecondPerToken = 10
+	assert.NoError(t, ltoml.WriteConfig(cfgPath, fmt.Sprintf("%s\n\n%s\n\n%s\n\n%s\n\n%s",
+		NewDefaultCoordinator().TOML(), query.TOML(), brokerBase.TOML(), NewDefaultMonitor().TOML(), NewDefaultLogging().TOML())))
+	reloaded, ignored, err = ReloadBrokerConfig(cfgPath, "", reloaded)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"query.timeout", "query.max-queries-per-second-per-token", "broker.ingestion.ingest-timeout"}, ignored)
+	assert.Equal(t, NewDefaultQuery().Timeout, GlobalQueryConfig().Timeout)
+	assert.Equal(t, NewDefaultBrokerBase().Ingestion.IngestTimeout, GlobalBrokerConfig().Ingestion.IngestTimeout)
+
+	_, _, err = ReloadBrokerConfig("not-exist", "", reloaded)
+	assert.Error(t, err)
+}
+
+func Test_ReloadStandaloneConfig(t *testing.T) {
+	defer func() {
+		SetGlobalBrokerConfig(NewDefaultBrokerBase())
+		SetGlobalStorageConfig(NewDefaultStorageBase())
+		SetGlobalQueryConfig(NewDefaultQuery())
+	}()
+	cfgPath := filepath.Join(t.TempDir(), "standalone.toml")
+	assert.NoError(t, ltoml.WriteConfig(cfgPath, NewDefaultStandaloneTOML()))
+	var standaloneCfg Standalone
+	assert.NoError(t, LoadAndSetStandAloneConfig(cfgPath, "", &standaloneCfg))
+
+	reloaded, ignored, err := ReloadStandaloneConfig(cfgPath, "", &standaloneCfg)
+	assert.NoError(t, err)
+	assert.Empty(t, ignored)
+	assert.Equal(t, standaloneCfg, *reloaded)
+
+	_, _, err = ReloadStandaloneConfig("not-exist", "", reloaded)
+	assert.Error(t, err)
+}
@@ -24,19 +24,19 @@ import (
 
 // Standalone represents the configuration of standalone mode
 type Standalone struct {
-	ETCD        ETCD        `toml:"etcd"`
-	Coordinator RepoState   `toml:"coordinator"`
-	Query       Query       `toml:"query"`
-	BrokerBase  BrokerBase  `toml:"broker"`
-	StorageBase StorageBase `toml:"storage"`
-	Logging     Logging     `toml:"logging"`
-	Monitor     Monitor     `toml:"monitor"`
+	ETCD        ETCD        `toml:"etcd" json:"etcd"`
+	Coordinator RepoState   `toml:"coordinator" json:"coordinator"`
+	Query       Query       `toml:"query" json:"query"`
+	BrokerBase  BrokerBase  `toml:"broker" json:"broker"`
+	StorageBase StorageBase `toml:"storage" json:"storage"`
+	Logging     Logging     `toml:"logging" json:"logging"`
+	Monitor     Monitor     `toml:"monitor" json:"monitor"`
 }
 
 // ETCD represents embed etcd's configuration
 type ETCD struct {
-	Dir string `toml:"dir"`
-	URL string `toml:"url"`
+	Dir string `toml:"dir" json:"dir"`
+	URL string `toml:"url" json:"url"`
 }
 
 // TOML returns ETCD's toml config string
@@ -78,8 +78,11 @@ func NewDefaultStandaloneTOML() string {
 
 %s
 
+%s
+
 %s`,
 
+		envOverrideTOMLComment,
 		NewDefaultETCD().TOML(),
 		NewDefaultCoordinator().TOML(),
 		NewDefaultQuery().TOML(),
@@ -18,33 +18,118 @@
 package config
 
 import (
+	"encoding/json"
 	"fmt"
 	"math"
+	"path"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"time"
 
 	"github.com/lindb/lindb/pkg/ltoml"
 )
 
+const (
+	// BackendIntegrityCheckOff skips integrity check of series id mapping storage.
+	BackendIntegrityCheckOff = "off"
+	// BackendIntegrityCheckWarn logs the corruption of series id mapping storage.
+	BackendIntegrityCheckWarn = "warn"
+	// BackendIntegrityCheckRefuse refuses to open the corrupted series id mapping storage.
+	BackendIntegrityCheckRefuse = "refuse"
+
+	// IndexFlushStrategyFull flushes all dirty inverted index in one operation.
+	IndexFlushStrategyFull = "full"
+	// IndexFlushStrategyIncremental flushes dirty inverted index in bounded chunks of tag keys across flush cycles.
+	IndexFlushStrategyIncremental = "incremental"
+
+	// ReadStrategyMMap reads sst files of data family through mmap, value blocks reference the mapped region,
+	// good for local disks, but page faults may cause unpredictable latency on network volumes.
+	ReadStrategyMMap = "mmap"
+	// ReadStrategyPRead reads value blocks of data family sst files by pread syscall,
+	// only index block is kept in memory, latency is predictable but each read copies data.
+	ReadStrategyPRead = "pread"
+
+	// FlushSyncPolicyAlways syncs the flushed file of data family and manifest on every flush.
+	FlushSyncPolicyAlways = "always"
+	// FlushSyncPolicyInterval syncs the flushed files of data family and manifest in batch every sync interval.
+	FlushSyncPolicyInterval = "interval"
+	// FlushSyncPolicyNone never syncs on data family flush, leaves writing back to OS.
+	FlushSyncPolicyNone = "none"
+)
+
 // TSDB represents the tsdb configuration
 type TSDB struct {
-	Dir                      string         `toml:"dir"`
-	MaxMemDBSize             ltoml.Size     `toml:"max-memdb-size"`
-	MaxMemDBTotalSize        ltoml.Size     `toml:"max-memdb-total-size"`
-	MaxMemDBNumber           int            `toml:"max-memdb-number"`
-	MutableMemDBTTL          ltoml.Duration `toml:"mutable-memdb-ttl"`
-	MaxMemUsageBeforeFlush   float64        `toml:"max-mem-usage-before-flush"`
-	TargetMemUsageAfterFlush float64        `toml:"target-mem-usage-after-flush"`
-	FlushConcurrency         int            `toml:"flush-concurrency"`
-	MaxSeriesIDsNumber       int            `toml:"max-seriesIDs"`
-	MaxTagKeysNumber         int            `toml:"max-tagKeys"`
+	Dir                      string         `toml:"dir" json:"dir"`
+	CreateDirIfMissing       bool           `toml:"create-dir-if-missing" json:"createDirIfMissing"`
+	DataDirs                 []string       `toml:"data-dirs" json:"dataDirs"`
+	MaxMemDBSize             ltoml.Size     `toml:"max-memdb-size" json:"maxMemDBSize"`
+	MaxMemDBTotalSize        ltoml.Size     `toml:"max-memdb-total-size" json:"maxMemDBTotalSize"`
+	MaxMemDBNumber           int            `toml:"max-memdb-number" json:"maxMemDBNumber"`
+	MutableMemDBTTL          ltoml.Duration `toml:"mutable-memdb-ttl" json:"mutableMemDBTTL"`
+	MaxMemUsageBeforeFlush   float64        `toml:"max-mem-usage-before-flush" json:"maxMemUsageBeforeFlush"`
+	TargetMemUsageAfterFlush float64        `toml:"target-mem-usage-after-flush" json:"targetMemUsageAfterFlush"`
+	FlushConcurrency         int            `toml:"flush-concurrency" json:"flushConcurrency"`
+	MaxSeriesIDsNumber       int            `toml:"max-seriesIDs" json:"maxSeriesIDs"`
+	MaxTagKeysNumber         int            `toml:"max-tagKeys" json:"maxTagKeys"`
+	SeriesSyncConcurrency    int            `toml:"series-sync-concurrency" json:"seriesSyncConcurrency"`
+	MaxSeriesWALBacklog      ltoml.Size     `toml:"max-series-wal-backlog" json:"maxSeriesWALBacklog"`
+	SeriesReportInterval     ltoml.Duration `toml:"series-report-interval" json:"seriesReportInterval"`
+	TagCardinalityPrecision  int            `toml:"tag-cardinality-precision" json:"tagCardinalityPrecision"`
+	BackendMaxRetries        int            `toml:"backend-max-retries" json:"backendMaxRetries"`
+	BackendRetryBackoff      ltoml.Duration `toml:"backend-retry-backoff" json:"backendRetryBackoff"`
+	BackendIntegrityCheck    string         `toml:"backend-integrity-check" json:"backendIntegrityCheck"`
+	MaxTagKeysStaleness      ltoml.Duration `toml:"max-tag-keys-staleness" json:"maxTagKeysStaleness"`
+	MaxCachedTagKeys         int            `toml:"max-cached-tag-keys" json:"maxCachedTagKeys"`
+	WarmupTopN               int            `toml:"warmup-top-n" json:"warmupTopN"`
+	PreloadMetrics           []string       `toml:"preload-metrics" json:"preloadMetrics"`
+	MetadataCacheSize        int            `toml:"metadata-cache-size" json:"metadataCacheSize"`
+	TagValueCacheSize        int            `toml:"tag-value-cache-size" json:"tagValueCacheSize"`
+	FieldAliases             []string       `toml:"field-aliases" json:"fieldAliases"`
+	FieldRetentions          []string       `toml:"field-retentions" json:"fieldRetentions"`
+	FieldRetentionInterval   ltoml.Duration `toml:"field-retention-interval" json:"fieldRetentionInterval"`
+	IndexFlushStrategy       string         `toml:"index-flush-strategy" json:"indexFlushStrategy"`
+	IndexFlushChunkSize      int            `toml:"index-flush-chunk-size" json:"indexFlushChunkSize"`
+	IndexTagKeyIdleTTL       ltoml.Duration `toml:"index-tag-key-idle-ttl" json:"indexTagKeyIdleTTL"`
+	IndexFlushConcurrency    int            `toml:"index-flush-concurrency" json:"indexFlushConcurrency"`
+	WritePartitions          int            `toml:"write-partitions" json:"writePartitions"`
+	BlockCacheSize           ltoml.Size     `toml:"block-cache-size" json:"blockCacheSize"`
+	ReadStrategy             string         `toml:"read-strategy" json:"readStrategy"`
+	MaxOpenFamilies          int            `toml:"max-open-families" json:"maxOpenFamilies"`
+	SegmentOpenConcurrency   int            `toml:"segment-open-concurrency" json:"segmentOpenConcurrency"`
+	ShardEventLogSize        int            `toml:"shard-event-log-size" json:"shardEventLogSize"`
+	MaxExemplars             int            `toml:"max-exemplars" json:"maxExemplars"`
+	WriteProfileSampleRate   float64        `toml:"write-profile-sample-rate" json:"writeProfileSampleRate"`
+	FlushSyncPolicy          string         `toml:"flush-sync-policy" json:"flushSyncPolicy"`
+	FlushSyncInterval        ltoml.Duration `toml:"flush-sync-interval" json:"flushSyncInterval"`
+}
+
+// ShardDataDir returns the directory which the data of shard stores in,
+// new shards are distributed across data-dirs by shard id.
+func (t *TSDB) ShardDataDir(shardID int) string {
+	if len(t.DataDirs) == 0 {
+		return t.Dir
+	}
+	return t.DataDirs[shardID%len(t.DataDirs)]
 }
 
 func (t *TSDB) TOML() string {
+	dataDirs, _ := json.Marshal(t.DataDirs)
+	preloadMetrics, _ := json.Marshal(t.PreloadMetrics)
+	fieldAliases, _ := json.Marshal(t.FieldAliases)
+	fieldRetentions, _ := json.Marshal(t.FieldRetentions)
 	return fmt.Sprintf(`
 ## The TSDB directory where the time series data and meta file stores.
 dir = "%s"
+## Creates the TSDB directory at startup if it does not exist,
+## otherwise storage node fails to start with missing directory.
+## Default: true
+create-dir-if-missing = %v
+## The directories where the data of shards stores, shards are distributed across them by shard id,
+## which lets a node use multiple physical disks without RAID, e.g. ["/disk1/data", "/disk2/data"].
+## Shards created before keep their data in the original directory.
+## Default: [](all shards store in dir)
+data-dirs = %s
 
 ## Flush configuration
 ## 
@@ -74,6 +159,152 @@ max-mem-usage-before-flush = %.2f
 target-mem-usage-after-flush = %.2f
 ## concurrency of goroutines for flushing. Default: Ceil(runtime.GOMAXPROCS(-1) / 2)
 flush-concurrency = %d
+## concurrency of goroutines for syncing series wal into id mapping storage,
+## series are partitioned by metric id across the workers.
+## Default: 1
+series-sync-concurrency = %d
+## The maximum size of series wal waiting for syncing,
+## storage node reports degraded health when this exceeds.
+## Default: 512 MiB
+max-series-wal-backlog = "%s"
+## The interval for reporting the number of series and growth rate(series per second) of each database,
+## used to catch runaway cardinality early.
+## Default: 1m
+series-report-interval = "%s"
+## The precision of HyperLogLog estimator for the number of distinct tag values of each tag key,
+## reported with the series count, memory of each tag key is 2^precision bytes per shard,
+## standard error is about 1.04/sqrt(2^precision), valid range is [4, 16].
+## Default: 10(1KiB memory, 3.25%% standard error), 0 disables the estimation
+tag-cardinality-precision = %d
+## The maximum retries of series id mapping storage operation when transient I/O error occurs.
+## Default: 3
+backend-max-retries = %d
+## The initial backoff between retries, doubles after each retry.
+## Default: 10ms
+backend-retry-backoff = "%s"
+## Integrity check of series id mapping storage when index database opens.
+## off: no check, for fast startup.
+## warn: logs the corrupted pages and continues opening.
+## refuse: refuses to open the corrupted storage, recovers it from backup or rebuilds it.
+## Recommended to enable on nodes suspected of disk corruption or unclean shutdown.
+## Default: off
+backend-integrity-check = "%s"
+## The maximum age of cached tag keys of metric which are used for searching series,
+## when metadata is unavailable, query gets best-effort result which may be stale.
+## Default: 5m
+max-tag-keys-staleness = "%s"
+## The maximum number of metrics whose tag keys are cached for stale query result,
+## the least recently used metric is evicted if exceeded.
+## Default: 10000
+max-cached-tag-keys = %d
+
+## Number of hottest metrics whose series id mapping is persisted when closing index database,
+## and loaded into cache when opening it. node reports ready only after warmup completes.
+## Default: 0(disable warmup)
+warmup-top-n = %d
+## Hot metrics whose tag keys and tag value dictionaries are preloaded into memory when opening
+## the metadata of each database, node reports ready only after preload completes.
+## Metric in spec namespace is declared as "namespace|metric-name", default namespace if omitted.
+## Default: []
+preload-metrics = %s
+## The maximum number of metrics whose metadata(tag keys/fields) are cached in memory for each database,
+## the least recently used metric is evicted and reloaded from metadata storage on demand.
+## Metric with metadata changes which are not synced into metadata storage is never evicted.
+## Default: 100000, 0 means unlimited
+metadata-cache-size = %d
+## The maximum number of tag values whose dictionaries(tag value => id) read from metadata storage
+## are cached in memory for each database, the dictionary of least recently used tag key is evicted.
+## Default: 1000000, 0 means unlimited
+tag-value-cache-size = %d
+## Field aliases of metrics, declared as "metric-name:new-field=old-field",
+## metric in spec namespace is declared as "namespace|metric-name:new-field=old-field".
+## The new field name resolves to the old field, so that querying the new name includes the data
+## written under the old name, and writing the new name is stored into the old field without rewriting data.
+## Default: []
+field-aliases = %s
+## Retention overrides of fields, declared as "metric-name:field-pattern=retention",
+## metric in spec namespace is declared as "namespace|metric-name:field-pattern=retention",
+## field pattern is glob pattern of field name, such as "http:detail_*=72h".
+## Data of matched fields is purged from data family which is older than retention,
+## other fields of same series are kept.
+## Default: []
+field-retentions = %s
+## Interval of checking data families which have fields need to be purged by field-retentions.
+## Default: 10m
+field-retention-interval = "%s"
+
+## Flush strategy of inverted index.
+## full: flushes all dirty tag keys in one operation.
+## incremental: flushes at most index-flush-chunk-size dirty tag keys per flush cycle, spreads the I/O.
+## Default: full
+index-flush-strategy = "%s"
+## The maximum number of tag keys flushed per flush cycle with incremental strategy.
+## Default: 1000
+index-flush-chunk-size = %d
+## Tag keys of inverted index in memory which are not written/queried within this window are flushed
+## into inverted family and evicted from memory, queries read them from inverted family on demand.
+## Saves memory for databases with many rarely-queried tag keys.
+## Default: 0(disable eviction)
+index-tag-key-idle-ttl = "%s"
+## The maximum number of databases whose index and memory data are flushed concurrently
+## when flushing all databases(e.g. forced flush, shutdown), bounds the I/O of flushing.
+## Default: 4
+index-flush-concurrency = %d
+
+## Number of write partitions of each memdb, metrics are partitioned by metric id,
+## concurrent writes to metrics of different partitions do not serialize on one lock.
+## Default: 1(disable partitioning)
+write-partitions = %d
+
+## The maximum size of recently scanned data blocks of data family cached in memory,
+## shared by all data families of the node, evicts the least recently used blocks when exceeds.
+## Repeated queries over the same time range read blocks from cache instead of disk.
+## Default: 0(disable block cache)
+block-cache-size = "%s"
+## Strategy for reading sst files of data family.
+## mmap: maps the whole file into memory, reads are served by page cache without copying,
+## but page faults may cause unpredictable latency on network volumes.
+## pread: keeps only the index block in memory, reads each value block by pread syscall,
+## latency is predictable but each read copies data, recommended for network volumes.
+## Default: mmap
+read-strategy = "%s"
+## The maximum number of data families whose sst file readers are kept open, shared by all shards of the node,
+## closes the readers of the least recently queried families when exceeds, reopens them on demand.
+## Limits file descriptors and mapped memory on nodes with many shards and long time ranges.
+## Default: 0(unlimited)
+max-open-families = %d
+## The maximum number of existing segments(kv stores) opened concurrently by all shards of the node,
+## bounds the file descriptors and memory spiked by opening many segments at once.
+## Default: 4
+segment-open-concurrency = %d
+## The number of latest lifecycle events(segment/family creation, flush, close) of each shard kept in memory
+## for post-mortem analysis, exposed via http api of storage engine.
+## Default: 256
+shard-event-log-size = %d
+## The maximum number of latest exemplars(samples linked to trace) of each shard kept in memory,
+## which are returned with the query result of fields, the oldest ones are overwritten when exceeds.
+## Memory is not allocated until the first exemplar written.
+## Default: 100000, 0 disables storing exemplars
+max-exemplars = %d
+## The ratio of write requests sampled for latency breakdown by stage(wal, checker, decode, metadata, index, memtable),
+## reported as histograms of lindb.tsdb.write_profile.stage_duration, used to find where ingest time goes.
+## Valid range is [0, 1], higher ratio costs more overhead on write path.
+## Default: 0(disable profiling)
+write-profile-sample-rate = %.2f
+## Policy for syncing the flushed files of data family to disk.
+## always: syncs the flushed file and manifest on every flush, flushed data survives power failure
+## once flush returns, but flush is slow on disks with high sync latency.
+## interval: syncs the flushed files and manifest in batch every flush-sync-interval(group commit),
+## flush doesn't wait for sync, the write sequences are acked to WAL after synced,
+## so that the flushes of last interval lost on power failure are replayed from WAL.
+## none: never syncs, the OS writes back dirty pages in its own pace,
+## the flushes not written back by OS may be lost on power failure,
+## which cannot be replayed from WAL, because the write sequences are acked by flush.
+## Default: always
+flush-sync-policy = "%s"
+## The interval for syncing the flushed files of data family if flush-sync-policy is interval.
+## Default: 1s
+flush-sync-interval = "%s"
 
 ## Time Series limitation
 ## 
@@ -84,6 +315,8 @@ max-seriesIDs = %d
 ## Default: 32
 max-tagKeys = %d`,
 		t.Dir,
+		t.CreateDirIfMissing,
+		dataDirs,
 		t.MaxMemDBSize.String(),
 		t.MaxMemDBTotalSize.String(),
 		t.MaxMemDBNumber,
@@ -91,6 +324,36 @@ max-tagKeys = %d`,
 		t.MaxMemUsageBeforeFlush,
 		t.TargetMemUsageAfterFlush,
 		t.FlushConcurrency,
+		t.SeriesSyncConcurrency,
+		t.MaxSeriesWALBacklog.String(),
+		t.SeriesReportInterval.String(),
+		t.TagCardinalityPrecision,
+		t.BackendMaxRetries,
+		t.BackendRetryBackoff.String(),
+		t.BackendIntegrityCheck,
+		t.MaxTagKeysStaleness.String(),
+		t.MaxCachedTagKeys,
+		t.WarmupTopN,
+		preloadMetrics,
+		t.MetadataCacheSize,
+		t.TagValueCacheSize,
+		fieldAliases,
+		fieldRetentions,
+		t.FieldRetentionInterval.String(),
+		t.IndexFlushStrategy,
+		t.IndexFlushChunkSize,
+		t.IndexTagKeyIdleTTL.String(),
+		t.IndexFlushConcurrency,
+		t.WritePartitions,
+		t.BlockCacheSize.String(),
+		t.ReadStrategy,
+		t.MaxOpenFamilies,
+		t.SegmentOpenConcurrency,
+		t.ShardEventLogSize,
+		t.MaxExemplars,
+		t.WriteProfileSampleRate,
+		t.FlushSyncPolicy,
+		t.FlushSyncInterval.String(),
 		t.MaxSeriesIDsNumber,
 		t.MaxTagKeysNumber,
 	)
@@ -98,11 +361,12 @@ max-tagKeys = %d`,
 
 // StorageBase represents a storage configuration
 type StorageBase struct {
-	HTTP      HTTP `toml:"http"`
-	Indicator int  `toml:"indicator"` // Indicator is unique id under current storage cluster.
-	GRPC      GRPC `toml:"grpc"`
-	TSDB      TSDB `toml:"tsdb"`
-	WAL       WAL  `toml:"wal"`
+	HTTP      HTTP `toml:"http" json:"http"`
+	Indicator int  `toml:"indicator" json:"indicator"` // Indicator is unique id under current storage cluster.
+	GRPC      GRPC `toml:"grpc" json:"grpc"`
+	TSDB      TSDB `toml:"tsdb" json:"tsdb"`
+	WAL       WAL  `toml:"wal" json:"wal"`
+	GC        GC   `toml:"gc" json:"gc"`
 }
 
 // TOML returns StorageBase's toml config string
@@ -120,20 +384,64 @@ indicator = %d
 
 [storage.wal]%s
 
-[storage.tsdb]%s`,
+[storage.tsdb]%s
+
+[storage.gc]%s`,
 		s.Indicator,
 		s.HTTP.TOML(),
 		s.GRPC.TOML(),
 		s.WAL.TOML(),
 		s.TSDB.TOML(),
+		s.GC.TOML(),
 	)
 }
 
+// GC represents the garbage collector tuning config of storage process,
+// applied at startup instead of GOGC/GOMEMLIMIT environment variables.
+type GC struct {
+	Percent     int        `toml:"percent" json:"percent"`
+	MemoryLimit ltoml.Size `toml:"memory-limit" json:"memoryLimit"`
+}
+
+func (g *GC) TOML() string {
+	return fmt.Sprintf(`
+## Garbage collection target percentage, same as GOGC,
+## a collection is triggered when newly allocated heap reaches this percentage of live heap.
+## Lower value collects more often with less memory, higher value collects less often.
+## -1 disables garbage collection unless memory-limit is reached.
+## Default: 0(keep the runtime setting, GOGC env or 100)
+percent = %d
+## Soft memory limit of the process, same as GOMEMLIMIT,
+## garbage collection runs more aggressively when total memory used by go runtime approaches it,
+## smooths latency spikes of flush and query caused by gc under heavy ingest.
+## Default: 0 B(keep the runtime setting, GOMEMLIMIT env or unlimited)
+memory-limit = "%s"`,
+		g.Percent,
+		g.MemoryLimit.String(),
+	)
+}
+
+const (
+	// WALBacklogPolicyBlock blocks writing until the backlog is consumed or timeout.
+	WALBacklogPolicyBlock = "block"
+	// WALBacklogPolicyDropOldest drops the oldest messages which are not replicated.
+	WALBacklogPolicyDropOldest = "drop-oldest"
+)
+
 // WAL represents config for write ahead log in storage.
 type WAL struct {
-	Dir                string         `toml:"dir"`
-	DataSizeLimit      int64          `toml:"data-size-limit"`
-	RemoveTaskInterval ltoml.Duration `toml:"remove-task-interval"`
+	Dir                      string         `toml:"dir" json:"dir"`
+	DataSizeLimit            int64          `toml:"data-size-limit" json:"dataSizeLimit"`
+	RemoveTaskInterval       ltoml.Duration `toml:"remove-task-interval" json:"removeTaskInterval"`
+	BacklogPolicy            string         `toml:"backlog-policy" json:"backlogPolicy"`
+	BacklogBlockTimeout      ltoml.Duration `toml:"backlog-block-timeout" json:"backlogBlockTimeout"`
+	ApplyMaxRetries          int            `toml:"apply-max-retries" json:"applyMaxRetries"`
+	ApplyRetryBackoff        ltoml.Duration `toml:"apply-retry-backoff" json:"applyRetryBackoff"`
+	DeadLetterDir            string         `toml:"dead-letter-dir" json:"deadLetterDir"`
+	FlowControlInterval      ltoml.Duration `toml:"flow-control-interval" json:"flowControlInterval"`
+	FlowControlHighWatermark int64          `toml:"flow-control-high-watermark" json:"flowControlHighWatermark"`
+	FlowControlLowWatermark  int64          `toml:"flow-control-low-watermark" json:"flowControlLowWatermark"`
+	QuorumAckTimeout         ltoml.Duration `toml:"quorum-ack-timeout" json:"quorumAckTimeout"`
 }
 
 func (rc *WAL) GetDataSizeLimit() int64 {
@@ -154,20 +462,57 @@ dir = "%s"
 ## file is created. It defaults to 512 megabytes, available size is in [1MB, 1GB]
 data-size-limit = %d
 ## interval for how often a new segment will be created
-remove-task-interval = "%s"`,
+remove-task-interval = "%s"
+## backlog-policy is the behavior when the wal reaches the data size limit,
+## because some replica node is down or slow.
+## block: blocks writing until the backlog is consumed, fails after backlog-block-timeout.
+## drop-oldest: drops the oldest messages which are not replicated yet.
+backlog-policy = "%s"
+## max time for blocking writing when the backlog policy is block
+backlog-block-timeout = "%s"
+## max retries when follower fails to apply a replicated message(e.g. transient disk error),
+## the message is moved to dead letter directory after all retries fail,
+## so that the replica stream can proceed. 0 means no retry.
+apply-max-retries = %d
+## initial backoff between apply retries, doubles after each retry.
+apply-retry-backoff = "%s"
+## directory where the replicated messages which fail to apply are preserved for manual replay,
+## path: dead-letter-dir/database/shard/family time/leader/sequence.msg,
+## file content is the snappy compressed replica message.
+dead-letter-dir = "%s"
+## interval for how often the flow control signal is sent to stream write client
+flow-control-interval = "%s"
+## stream write client is signaled to pause sending when the number of wal messages
+## not consumed by replicators reaches high watermark, 0 means no backpressure.
+flow-control-high-watermark = %d
+## backpressure is released when the number of wal messages not consumed drops to low watermark,
+## must not be greater than high watermark.
+flow-control-low-watermark = %d
+## max wait time for quorum replicas acking the write with quorum ack level,
+## write fails if quorum is not reached in time.
+quorum-ack-timeout = "%s"`,
 		rc.Dir,
 		rc.DataSizeLimit,
 		rc.RemoveTaskInterval.String(),
+		rc.BacklogPolicy,
+		rc.BacklogBlockTimeout.String(),
+		rc.ApplyMaxRetries,
+		rc.ApplyRetryBackoff.String(),
+		rc.DeadLetterDir,
+		rc.FlowControlInterval.String(),
+		rc.FlowControlHighWatermark,
+		rc.FlowControlLowWatermark,
+		rc.QuorumAckTimeout.String(),
 	)
 }
 
 // Storage represents a storage configuration with common settings
 type Storage struct {
-	Coordinator RepoState   `toml:"coordinator"`
-	Query       Query       `toml:"query"`
-	StorageBase StorageBase `toml:"storage"`
-	Monitor     Monitor     `toml:"monitor"`
-	Logging     Logging     `toml:"logging"`
+	Coordinator RepoState   `toml:"coordinator" json:"coordinator"`
+	Query       Query       `toml:"query" json:"query"`
+	StorageBase StorageBase `toml:"storage" json:"storage"`
+	Monitor     Monitor     `toml:"monitor" json:"monitor"`
+	Logging     Logging     `toml:"logging" json:"logging"`
 }
 
 // NewDefaultStorageBase returns a new default StorageBase struct
@@ -175,23 +520,46 @@ func NewDefaultStorageBase() *StorageBase {
 	return &StorageBase{
 		Indicator: 1,
 		HTTP: HTTP{
-			Port:         2892,
-			IdleTimeout:  ltoml.Duration(time.Minute * 2),
-			ReadTimeout:  ltoml.Duration(time.Second * 5),
-			WriteTimeout: ltoml.Duration(time.Second * 5),
+			Port:               2892,
+			IdleTimeout:        ltoml.Duration(time.Minute * 2),
+			ReadTimeout:        ltoml.Duration(time.Second * 5),
+			WriteTimeout:       ltoml.Duration(time.Second * 5),
+			Compression:        true,
+			CompressionMinSize: ltoml.Size(1024),
+			CORSAllowedOrigins: []string{},
+			CORSAllowedMethods: defaultCORSAllowedMethods(),
+			CORSAllowedHeaders: defaultCORSAllowedHeaders(),
 		},
 		GRPC: GRPC{
 			Port:                 2891,
 			MaxConcurrentStreams: runtime.GOMAXPROCS(-1) * 2,
 			ConnectTimeout:       ltoml.Duration(time.Second * 3),
+			ConnPoolMinSize:      1,
+			ConnPoolMaxSize:      4,
+			ConnPoolIdleTimeout:  ltoml.Duration(time.Minute * 5),
 		},
 		WAL: WAL{
-			Dir:                filepath.Join(defaultParentDir, "storage/wal"),
-			DataSizeLimit:      512,
-			RemoveTaskInterval: ltoml.Duration(time.Minute),
+			Dir:                      filepath.Join(defaultParentDir, "storage/wal"),
+			DataSizeLimit:            512,
+			RemoveTaskInterval:       ltoml.Duration(time.Minute),
+			BacklogPolicy:            WALBacklogPolicyBlock,
+			BacklogBlockTimeout:      ltoml.Duration(5 * time.Second),
+			ApplyMaxRetries:          3,
+			ApplyRetryBackoff:        ltoml.Duration(100 * time.Millisecond),
+			DeadLetterDir:            filepath.Join(defaultParentDir, "storage/dead-letter"),
+			FlowControlInterval:      ltoml.Duration(time.Second),
+			FlowControlHighWatermark: 100000,
+			FlowControlLowWatermark:  50000,
+			QuorumAckTimeout:         ltoml.Duration(5 * time.Second),
 		},
 		TSDB: TSDB{
 			Dir:                      filepath.Join(defaultParentDir, "storage/data"),
+			CreateDirIfMissing:       true,
+			DataDirs:                 []string{},
+			PreloadMetrics:           []string{},
+			FieldAliases:             []string{},
+			FieldRetentions:          []string{},
+			FieldRetentionInterval:   ltoml.Duration(10 * time.Minute),
 			MaxMemDBSize:             ltoml.Size(500 * 1024 * 1024),
 			MaxMemDBNumber:           5,
 			MaxMemDBTotalSize:        ltoml.Size(2 * 1024 * 1024 * 1024),
@@ -201,6 +569,27 @@ func NewDefaultStorageBase() *StorageBase {
 			FlushConcurrency:         int(math.Ceil(float64(runtime.GOMAXPROCS(-1)) / 2)),
 			MaxSeriesIDsNumber:       200000,
 			MaxTagKeysNumber:         32,
+			SeriesSyncConcurrency:    1,
+			MaxSeriesWALBacklog:      ltoml.Size(512 * 1024 * 1024),
+			SeriesReportInterval:     ltoml.Duration(time.Minute),
+			TagCardinalityPrecision:  10,
+			BackendMaxRetries:        3,
+			BackendRetryBackoff:      ltoml.Duration(10 * time.Millisecond),
+			BackendIntegrityCheck:    BackendIntegrityCheckOff,
+			MaxTagKeysStaleness:      ltoml.Duration(5 * time.Minute),
+			MaxCachedTagKeys:         10000,
+			MetadataCacheSize:        100000,
+			TagValueCacheSize:        1000000,
+			IndexFlushStrategy:       IndexFlushStrategyFull,
+			IndexFlushChunkSize:      1000,
+			IndexFlushConcurrency:    4,
+			WritePartitions:          1,
+			ReadStrategy:             ReadStrategyMMap,
+			SegmentOpenConcurrency:   4,
+			FlushSyncPolicy:          FlushSyncPolicyAlways,
+			FlushSyncInterval:        ltoml.Duration(time.Second),
+			ShardEventLogSize:        256,
+			MaxExemplars:             100000,
 		},
 	}
 }
@@ -215,7 +604,10 @@ func NewDefaultStorageTOML() string {
 
 %s
 
+%s
+
 %s`,
+		envOverrideTOMLComment,
 		NewDefaultCoordinator().TOML(),
 		NewDefaultQuery().TOML(),
 		NewDefaultStorageBase().TOML(),
@@ -229,6 +621,17 @@ func checkTSDBCfg(tsdbCfg *TSDB) error {
 	if tsdbCfg.Dir == "" {
 		return fmt.Errorf("tsdb dir cannot be empty")
 	}
+	dataDirs := make(map[string]struct{})
+	for _, dir := range tsdbCfg.DataDirs {
+		if dir == "" {
+			return fmt.Errorf("tsdb data dir cannot be empty")
+		}
+		dir = filepath.Clean(dir)
+		if _, ok := dataDirs[dir]; ok {
+			return fmt.Errorf("tsdb data dir: %s is duplicated", dir)
+		}
+		dataDirs[dir] = struct{}{}
+	}
 	if tsdbCfg.MaxMemDBSize <= 0 {
 		tsdbCfg.MaxMemDBSize = defaultStorageCfg.TSDB.MaxMemDBSize
 	}
@@ -256,15 +659,274 @@ func checkTSDBCfg(tsdbCfg *TSDB) error {
 	if tsdbCfg.MaxTagKeysNumber <= 0 {
 		tsdbCfg.MaxTagKeysNumber = defaultStorageCfg.TSDB.MaxTagKeysNumber
 	}
+	if tsdbCfg.SeriesSyncConcurrency <= 0 {
+		tsdbCfg.SeriesSyncConcurrency = defaultStorageCfg.TSDB.SeriesSyncConcurrency
+	}
+	if tsdbCfg.MaxSeriesWALBacklog <= 0 {
+		tsdbCfg.MaxSeriesWALBacklog = defaultStorageCfg.TSDB.MaxSeriesWALBacklog
+	}
+	if tsdbCfg.SeriesReportInterval <= 0 {
+		tsdbCfg.SeriesReportInterval = defaultStorageCfg.TSDB.SeriesReportInterval
+	}
+	if tsdbCfg.TagCardinalityPrecision != 0 &&
+		(tsdbCfg.TagCardinalityPrecision < 4 || tsdbCfg.TagCardinalityPrecision > 16) {
+		return fmt.Errorf("tag cardinality precision must be in [4, 16] or 0, got: %d", tsdbCfg.TagCardinalityPrecision)
+	}
+	if tsdbCfg.BackendMaxRetries <= 0 {
+		tsdbCfg.BackendMaxRetries = defaultStorageCfg.TSDB.BackendMaxRetries
+	}
+	if tsdbCfg.BackendRetryBackoff <= 0 {
+		tsdbCfg.BackendRetryBackoff = defaultStorageCfg.TSDB.BackendRetryBackoff
+	}
+	if tsdbCfg.MaxTagKeysStaleness <= 0 {
+		tsdbCfg.MaxTagKeysStaleness = defaultStorageCfg.TSDB.MaxTagKeysStaleness
+	}
+	if tsdbCfg.MaxCachedTagKeys <= 0 {
+		tsdbCfg.MaxCachedTagKeys = defaultStorageCfg.TSDB.MaxCachedTagKeys
+	}
+	if tsdbCfg.WarmupTopN < 0 {
+		tsdbCfg.WarmupTopN = 0
+	}
+	if tsdbCfg.MetadataCacheSize < 0 {
+		tsdbCfg.MetadataCacheSize = 0
+	}
+	if tsdbCfg.TagValueCacheSize < 0 {
+		tsdbCfg.TagValueCacheSize = 0
+	}
+	if err := checkFieldAliases(tsdbCfg.FieldAliases); err != nil {
+		return err
+	}
+	for _, retention := range tsdbCfg.FieldRetentions {
+		if _, err := ParseFieldRetention(retention); err != nil {
+			return err
+		}
+	}
+	if tsdbCfg.FieldRetentionInterval <= 0 {
+		tsdbCfg.FieldRetentionInterval = defaultStorageCfg.TSDB.FieldRetentionInterval
+	}
+	if tsdbCfg.IndexFlushChunkSize <= 0 {
+		tsdbCfg.IndexFlushChunkSize = defaultStorageCfg.TSDB.IndexFlushChunkSize
+	}
+	if tsdbCfg.IndexTagKeyIdleTTL < 0 {
+		tsdbCfg.IndexTagKeyIdleTTL = 0
+	}
+	if tsdbCfg.IndexFlushConcurrency <= 0 {
+		tsdbCfg.IndexFlushConcurrency = defaultStorageCfg.TSDB.IndexFlushConcurrency
+	}
+	if tsdbCfg.MaxOpenFamilies < 0 {
+		tsdbCfg.MaxOpenFamilies = 0
+	}
+	if tsdbCfg.SegmentOpenConcurrency <= 0 {
+		tsdbCfg.SegmentOpenConcurrency = defaultStorageCfg.TSDB.SegmentOpenConcurrency
+	}
+	if tsdbCfg.ShardEventLogSize <= 0 {
+		tsdbCfg.ShardEventLogSize = defaultStorageCfg.TSDB.ShardEventLogSize
+	}
+	if tsdbCfg.MaxExemplars < 0 {
+		tsdbCfg.MaxExemplars = 0
+	}
+	if tsdbCfg.WriteProfileSampleRate < 0 || tsdbCfg.WriteProfileSampleRate > 1 {
+		return fmt.Errorf("write profile sample rate must be in [0, 1], got: %v", tsdbCfg.WriteProfileSampleRate)
+	}
+	if tsdbCfg.WritePartitions <= 0 {
+		tsdbCfg.WritePartitions = defaultStorageCfg.TSDB.WritePartitions
+	}
+	switch tsdbCfg.IndexFlushStrategy {
+	case "":
+		tsdbCfg.IndexFlushStrategy = defaultStorageCfg.TSDB.IndexFlushStrategy
+	case IndexFlushStrategyFull, IndexFlushStrategyIncremental:
+	default:
+		return fmt.Errorf("unknown index flush strategy: %s", tsdbCfg.IndexFlushStrategy)
+	}
+	switch tsdbCfg.ReadStrategy {
+	case "":
+		tsdbCfg.ReadStrategy = defaultStorageCfg.TSDB.ReadStrategy
+	case ReadStrategyMMap, ReadStrategyPRead:
+	default:
+		return fmt.Errorf("unknown read strategy: %s", tsdbCfg.ReadStrategy)
+	}
+	switch tsdbCfg.FlushSyncPolicy {
+	case "":
+		tsdbCfg.FlushSyncPolicy = defaultStorageCfg.TSDB.FlushSyncPolicy
+	case FlushSyncPolicyAlways, FlushSyncPolicyInterval, FlushSyncPolicyNone:
+	default:
+		return fmt.Errorf("unknown flush sync policy: %s", tsdbCfg.FlushSyncPolicy)
+	}
+	if tsdbCfg.FlushSyncInterval <= 0 {
+		tsdbCfg.FlushSyncInterval = defaultStorageCfg.TSDB.FlushSyncInterval
+	}
+	switch tsdbCfg.BackendIntegrityCheck {
+	case "":
+		tsdbCfg.BackendIntegrityCheck = defaultStorageCfg.TSDB.BackendIntegrityCheck
+	case BackendIntegrityCheckOff, BackendIntegrityCheckWarn, BackendIntegrityCheckRefuse:
+	default:
+		return fmt.Errorf("unknown backend integrity check: %s", tsdbCfg.BackendIntegrityCheck)
+	}
+	return nil
+}
+
+// FieldAlias represents the new field name of metric which resolves to the old field.
+type FieldAlias struct {
+	Metric  string // metric name, "namespace|metric-name" if metric in spec namespace
+	Field   string // new field name
+	AliasOf string // old field name
+}
+
+// ParseFieldAlias parses the field alias declared as "metric-name:new-field=old-field".
+func ParseFieldAlias(alias string) (FieldAlias, error) {
+	eq := strings.LastIndex(alias, "=")
+	if eq < 0 {
+		return FieldAlias{}, fmt.Errorf("field alias: %s must be declared as metric-name:new-field=old-field", alias)
+	}
+	colon := strings.LastIndex(alias[:eq], ":")
+	if colon < 0 {
+		return FieldAlias{}, fmt.Errorf("field alias: %s must be declared as metric-name:new-field=old-field", alias)
+	}
+	fieldAlias := FieldAlias{
+		Metric:  strings.TrimSpace(alias[:colon]),
+		Field:   strings.TrimSpace(alias[colon+1 : eq]),
+		AliasOf: strings.TrimSpace(alias[eq+1:]),
+	}
+	if fieldAlias.Metric == "" || fieldAlias.Field == "" || fieldAlias.AliasOf == "" {
+		return FieldAlias{}, fmt.Errorf("field alias: %s has empty metric name or field name", alias)
+	}
+	if fieldAlias.Field == fieldAlias.AliasOf {
+		return FieldAlias{}, fmt.Errorf("field alias: %s cannot alias field to itself", alias)
+	}
+	return fieldAlias, nil
+}
+
+// checkFieldAliases checks if field aliases are valid,
+// each field of metric can be renamed once, and a new field name can be alias of only one field.
+func checkFieldAliases(aliases []string) error {
+	newFields := make(map[string]struct{})
+	oldFields := make(map[string]struct{})
+	for _, alias := range aliases {
+		fieldAlias, err := ParseFieldAlias(alias)
+		if err != nil {
+			return err
+		}
+		newKey := fieldAlias.Metric + ":" + fieldAlias.Field
+		oldKey := fieldAlias.Metric + ":" + fieldAlias.AliasOf
+		if _, ok := newFields[newKey]; ok {
+			return fmt.Errorf("field alias: %s is duplicated", alias)
+		}
+		if _, ok := oldFields[oldKey]; ok {
+			return fmt.Errorf("field alias: %s renames field more than once", alias)
+		}
+		newFields[newKey] = struct{}{}
+		oldFields[oldKey] = struct{}{}
+	}
 	return nil
 }
 
+// FieldRetention represents the retention override of fields whose name matches the pattern under metric.
+type FieldRetention struct {
+	Metric       string        // metric name, "namespace|metric-name" if metric in spec namespace
+	FieldPattern string        // glob pattern of field name
+	Retention    time.Duration // data of matched fields older than retention is purged
+}
+
+// ParseFieldRetention parses the field retention declared as "metric-name:field-pattern=retention".
+func ParseFieldRetention(retention string) (FieldRetention, error) {
+	eq := strings.LastIndex(retention, "=")
+	if eq < 0 {
+		return FieldRetention{}, fmt.Errorf("field retention: %s must be declared as metric-name:field-pattern=retention", retention)
+	}
+	colon := strings.LastIndex(retention[:eq], ":")
+	if colon < 0 {
+		return FieldRetention{}, fmt.Errorf("field retention: %s must be declared as metric-name:field-pattern=retention", retention)
+	}
+	fieldRetention := FieldRetention{
+		Metric:       strings.TrimSpace(retention[:colon]),
+		FieldPattern: strings.TrimSpace(retention[colon+1 : eq]),
+	}
+	if fieldRetention.Metric == "" || fieldRetention.FieldPattern == "" {
+		return FieldRetention{}, fmt.Errorf("field retention: %s has empty metric name or field pattern", retention)
+	}
+	if _, err := path.Match(fieldRetention.FieldPattern, ""); err != nil {
+		return FieldRetention{}, fmt.Errorf("field retention: %s has bad field pattern: %w", retention, err)
+	}
+	duration, err := time.ParseDuration(strings.TrimSpace(retention[eq+1:]))
+	if err != nil {
+		return FieldRetention{}, fmt.Errorf("field retention: %s has bad retention: %w", retention, err)
+	}
+	if duration <= 0 {
+		return FieldRetention{}, fmt.Errorf("field retention: %s must have positive retention", retention)
+	}
+	fieldRetention.Retention = duration
+	return fieldRetention, nil
+}
+
 func checkStorageBaseCfg(storageBaseCfg *StorageBase) error {
 	if storageBaseCfg.Indicator <= 0 {
 		return fmt.Errorf("indicator must > 0")
 	}
+	if storageBaseCfg.HTTP.CompressionMinSize <= 0 {
+		storageBaseCfg.HTTP.CompressionMinSize = NewDefaultStorageBase().HTTP.CompressionMinSize
+	}
+	if storageBaseCfg.HTTP.MaxConnections < 0 {
+		storageBaseCfg.HTTP.MaxConnections = 0
+	}
+	if storageBaseCfg.HTTP.HandlerTimeout < 0 {
+		storageBaseCfg.HTTP.HandlerTimeout = 0
+	}
+	if err := checkCORSCfg(&storageBaseCfg.HTTP); err != nil {
+		return err
+	}
 	if err := checkGRPCCfg(&storageBaseCfg.GRPC); err != nil {
 		return err
 	}
+	if err := checkWALCfg(&storageBaseCfg.WAL); err != nil {
+		return err
+	}
+	if err := checkGCCfg(&storageBaseCfg.GC); err != nil {
+		return err
+	}
 	return checkTSDBCfg(&storageBaseCfg.TSDB)
 }
+
+func checkGCCfg(gcCfg *GC) error {
+	if gcCfg.Percent < -1 {
+		return fmt.Errorf("gc percent must be >= -1, but got %d", gcCfg.Percent)
+	}
+	if gcCfg.MemoryLimit > math.MaxInt64 {
+		return fmt.Errorf("gc memory limit is too large: %d", gcCfg.MemoryLimit)
+	}
+	return nil
+}
+
+func checkWALCfg(walCfg *WAL) error {
+	defaultStorageCfg := NewDefaultStorageBase()
+	switch walCfg.BacklogPolicy {
+	case "":
+		walCfg.BacklogPolicy = defaultStorageCfg.WAL.BacklogPolicy
+	case WALBacklogPolicyBlock, WALBacklogPolicyDropOldest:
+	default:
+		return fmt.Errorf("unknown wal backlog policy: %s", walCfg.BacklogPolicy)
+	}
+	if walCfg.BacklogBlockTimeout <= 0 {
+		walCfg.BacklogBlockTimeout = defaultStorageCfg.WAL.BacklogBlockTimeout
+	}
+	if walCfg.ApplyMaxRetries < 0 {
+		walCfg.ApplyMaxRetries = defaultStorageCfg.WAL.ApplyMaxRetries
+	}
+	if walCfg.ApplyRetryBackoff <= 0 {
+		walCfg.ApplyRetryBackoff = defaultStorageCfg.WAL.ApplyRetryBackoff
+	}
+	if walCfg.DeadLetterDir == "" {
+		walCfg.DeadLetterDir = defaultStorageCfg.WAL.DeadLetterDir
+	}
+	if walCfg.FlowControlInterval <= 0 {
+		walCfg.FlowControlInterval = defaultStorageCfg.WAL.FlowControlInterval
+	}
+	if walCfg.QuorumAckTimeout <= 0 {
+		walCfg.QuorumAckTimeout = defaultStorageCfg.WAL.QuorumAckTimeout
+	}
+	if walCfg.FlowControlHighWatermark < 0 || walCfg.FlowControlLowWatermark < 0 ||
+		walCfg.FlowControlLowWatermark > walCfg.FlowControlHighWatermark {
+		return fmt.Errorf("invalid wal flow control watermark, high: %d, low: %d",
+			walCfg.FlowControlHighWatermark, walCfg.FlowControlLowWatermark)
+	}
+	return nil
+}
@@ -51,6 +51,8 @@ var (
 	ErrInfluxLineTooLong = errors.New("influx line is too long")
 
 	ErrBadEnrichTagQueryFormat = errors.New("enrich_tag has the wrong format")
+	// ErrShardWritePaused represents writes of shard are paused by admin, writer should retry later.
+	ErrShardWritePaused = errors.New("shard write is paused, retry later")
 	// ErrNoLiveReplica represents no live replica node for current shard.
 	ErrNoLiveReplica = errors.New("no live replica for shard")
 	// ErrNoLiveNode represents no live node for current cluster.
@@ -61,4 +63,12 @@ var (
 	ErrNoStorageCluster = errors.New("storage cluster not exist")
 	// ErrStatefulNodeExist represents stateful node already register.
 	ErrStatefulNodeExist = errors.New("stateful node already register")
+	// ErrStaleResult represents the result is served from cache because of dependency unavailable, may be stale.
+	ErrStaleResult = errors.New("result may be stale")
+	// ErrRegexScanLimitExceeded represents regex tag filter matches too many tag values under a tag key.
+	ErrRegexScanLimitExceeded = errors.New("regex scan limit exceeded")
+	// ErrTagKeyNotNumeric represents numeric comparison on the tag key which isn't declared numeric.
+	ErrTagKeyNotNumeric = errors.New("tag key is not declared numeric")
+	// ErrIncompatibleMetadata represents metric/field/tag ids of imported metadata are different from database's.
+	ErrIncompatibleMetadata = errors.New("metadata is incompatible")
 )
@@ -26,6 +26,10 @@ const (
 	DefaultMaxFieldsCount = math.MaxUint8
 	// MaxSuggestions represents the max number of suggestions count
 	MaxSuggestions = 10000
+	// MaxRegexScanTagValues represents the max number of tag values scanned by regex tag filter under a tag key,
+	// avoids pathological pattern scanning the whole tag value dictionary of a high cardinality tag key,
+	// returns ErrRegexScanLimitExceeded if more tag values need be scanned.
+	MaxRegexScanTagValues = 1000000
 
 	// MetricMaxAheadDuration controls the global max write ahead duration.
 	// If current timestamp is 2021-08-19 23:00:00, metric after 2021-08-20 23:00:00 will be dropped.
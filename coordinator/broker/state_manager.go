@@ -44,7 +44,8 @@ type StateManager interface {
 	// GetDatabaseCfg returns the database config by name.
 	GetDatabaseCfg(databaseName string) (models.Database, bool)
 	// GetQueryableReplicas returns the queryable replicas，
-	// and chooses the leader replica if the shard has multi-replica.
+	// and chooses the leader replica if the shard has multi-replica,
+	// chooses other live replica if the circuit breaker of leader is open.
 	// returns storage node => shard id list
 	GetQueryableReplicas(databaseName string) (map[string][]models.ShardID, error)
 	// GetStorage returns storage state by name.
@@ -76,6 +77,7 @@ type stateManager struct {
 	// connection manager
 	connectionManager rpc.ConnectionManager
 	taskClientFactory rpc.TaskClientFactory
+	circuitBreakers   rpc.CircuitBreakers // nil if circuit breaker disabled
 
 	events chan *discovery.Event
 	mutex  sync.RWMutex
@@ -99,6 +101,7 @@ func NewStateManager(
 	currentNode models.StatelessNode,
 	connectionManager rpc.ConnectionManager,
 	taskClientFactory rpc.TaskClientFactory,
+	circuitBreakers rpc.CircuitBreakers,
 ) StateManager {
 	c, cancel := context.WithCancel(ctx)
 	mgr := &stateManager{
@@ -107,6 +110,7 @@ func NewStateManager(
 		currentNode:       currentNode,
 		connectionManager: connectionManager,
 		taskClientFactory: taskClientFactory,
+		circuitBreakers:   circuitBreakers,
 		storages:          make(map[string]*models.StorageState),
 		databases:         make(map[string]models.Database),
 		nodes:             make(map[string]models.StatelessNode),
@@ -416,7 +420,7 @@ func (m *stateManager) GetQueryableReplicas(databaseName string) (map[string][]m
 	result := make(map[string][]models.ShardID)
 	for shardID, shardState := range shards {
 		if shardState.State == models.OnlineShard {
-			node := liveNodes[shardState.Leader]
+			node := m.chooseQueryableReplica(shardState, liveNodes)
 			nodeID := node.Indicator()
 			result[nodeID] = append(result[nodeID], shardID)
 		} else {
@@ -429,6 +433,33 @@ func (m *stateManager) GetQueryableReplicas(databaseName string) (map[string][]m
 	return result, nil
 }
 
+// chooseQueryableReplica chooses the leader replica of shard,
+// if the circuit breaker of leader is open, chooses other live replica whose breaker allows,
+// falls back to the leader if no replica available.
+func (m *stateManager) chooseQueryableReplica(
+	shardState models.ShardState,
+	liveNodes map[models.NodeID]models.StatefulNode,
+) models.StatefulNode {
+	leader := liveNodes[shardState.Leader]
+	if m.circuitBreakers == nil || m.circuitBreakers.Allow(leader.Indicator()) {
+		return leader
+	}
+	for _, replicaID := range shardState.Replica.Replicas {
+		if replicaID == shardState.Leader {
+			continue
+		}
+		replica, ok := liveNodes[replicaID]
+		if ok && m.circuitBreakers.Allow(replica.Indicator()) {
+			m.logger.Warn("circuit breaker of shard leader is open, query other replica",
+				logger.Any("shard", shardState.ID),
+				logger.String("leader", leader.Indicator()),
+				logger.String("replica", replica.Indicator()))
+			return replica
+		}
+	}
+	return leader
+}
+
 // buildShardAssign builds the data write channel and related shard state.
 func (m *stateManager) notifyShardStateChange(storageState *models.StorageState) {
 	liveNodes := storageState.LiveNodes
@@ -33,12 +33,12 @@ import (
 )
 
 func TestStateManager_Close(t *testing.T) {
-	mgr := NewStateManager(context.TODO(), models.StatelessNode{}, nil, nil)
+	mgr := NewStateManager(context.TODO(), models.StatelessNode{}, nil, nil, nil)
 	mgr.Close()
 }
 
 func TestStateManager_Handle_Event_Panic(t *testing.T) {
-	mgr := NewStateManager(context.TODO(), models.StatelessNode{}, nil, nil)
+	mgr := NewStateManager(context.TODO(), models.StatelessNode{}, nil, nil, nil)
 	// case 1: panic
 	mgr.EmitEvent(&discovery.Event{
 		Type: discovery.NodeFailure,
@@ -49,7 +49,7 @@ func TestStateManager_Handle_Event_Panic(t *testing.T) {
 }
 
 func TestStateManager_DatabaseConfig(t *testing.T) {
-	mgr := NewStateManager(context.TODO(), models.StatelessNode{}, nil, nil)
+	mgr := NewStateManager(context.TODO(), models.StatelessNode{}, nil, nil, nil)
 	// case 1: unmarshal database config err
 	mgr.EmitEvent(&discovery.Event{
 		Type:  discovery.DatabaseConfigChanged,
@@ -95,7 +95,7 @@ func TestStateManager_Node(t *testing.T) {
 	defer ctrl.Finish()
 
 	cm := rpc.NewMockConnectionManager(ctrl)
-	mgr := NewStateManager(context.TODO(), models.StatelessNode{HostIP: "3.3.3.3"}, cm, nil)
+	mgr := NewStateManager(context.TODO(), models.StatelessNode{HostIP: "3.3.3.3"}, cm, nil, nil)
 	// case 1: unmarshal node info err
 	mgr.EmitEvent(&discovery.Event{
 		Type:  discovery.NodeStartup,
@@ -139,7 +139,7 @@ func TestStateManager_Storage(t *testing.T) {
 	defer ctrl.Finish()
 
 	connectionMgr := rpc.NewMockConnectionManager(ctrl)
-	mgr := NewStateManager(context.TODO(), models.StatelessNode{}, connectionMgr, nil)
+	mgr := NewStateManager(context.TODO(), models.StatelessNode{}, connectionMgr, nil, nil)
 
 	// case 1: unmarshal storage state err
 	mgr.EmitEvent(&discovery.Event{
@@ -210,7 +210,7 @@ func TestStateManager_ShardState(t *testing.T) {
 	defer ctrl.Finish()
 
 	connectionMgr := rpc.NewMockConnectionManager(ctrl)
-	mgr := NewStateManager(context.TODO(), models.StatelessNode{}, connectionMgr, nil)
+	mgr := NewStateManager(context.TODO(), models.StatelessNode{}, connectionMgr, nil, nil)
 	c := 0
 	mgr.WatchShardStateChangeEvent(func(_ models.Database,
 		_ map[models.ShardID]models.ShardState,
@@ -295,3 +295,32 @@ func TestStateManager_ShardState(t *testing.T) {
 
 	assert.True(t, c > 0)
 }
+
+func TestStateManager_chooseQueryableReplica(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	breakers := rpc.NewMockCircuitBreakers(ctrl)
+	mgr := NewStateManager(context.TODO(), models.StatelessNode{}, nil, nil, breakers).(*stateManager)
+	liveNodes := map[models.NodeID]models.StatefulNode{
+		1: {StatelessNode: models.StatelessNode{HostIP: "1.1.1.1", GRPCPort: 9000}},
+		2: {StatelessNode: models.StatelessNode{HostIP: "2.2.2.2", GRPCPort: 9000}},
+	}
+	shardState := models.ShardState{
+		ID:      1,
+		State:   models.OnlineShard,
+		Leader:  1,
+		Replica: models.Replica{Replicas: []models.NodeID{1, 2, 3}},
+	}
+	// case 1: leader is healthy
+	breakers.EXPECT().Allow("1.1.1.1:9000").Return(true)
+	assert.Equal(t, liveNodes[1], mgr.chooseQueryableReplica(shardState, liveNodes))
+	// case 2: breaker of leader is open, route to other live replica
+	breakers.EXPECT().Allow("1.1.1.1:9000").Return(false)
+	breakers.EXPECT().Allow("2.2.2.2:9000").Return(true)
+	assert.Equal(t, liveNodes[2], mgr.chooseQueryableReplica(shardState, liveNodes))
+	// case 3: no replica available, fall back to leader
+	breakers.EXPECT().Allow("1.1.1.1:9000").Return(false)
+	breakers.EXPECT().Allow("2.2.2.2:9000").Return(false)
+	assert.Equal(t, liveNodes[1], mgr.chooseQueryableReplica(shardState, liveNodes))
+}
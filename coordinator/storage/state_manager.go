@@ -19,6 +19,8 @@ package storage
 
 import (
 	"context"
+	"fmt"
+	"io"
 	"path/filepath"
 	"strconv"
 	"sync"
@@ -44,6 +46,10 @@ type StateManager interface {
 	GetLiveNode(nodeID models.NodeID) (models.StatefulNode, bool)
 	// WatchNodeStateChangeEvent registers node state change event handle.
 	WatchNodeStateChangeEvent(nodeID models.NodeID, fn func(state models.NodeStateType))
+	// ImportShard imports the shard snapshot into local engine for rebalancing,
+	// shard assignment events are handled after importing completes,
+	// so that the node takes over the shard with imported data atomically.
+	ImportShard(param *models.DatabaseAssignment, shardID models.ShardID, reader io.Reader) error
 }
 
 // stateManager implements StateManager.
@@ -149,6 +155,34 @@ func (m *stateManager) processEvent(event *discovery.Event) {
 	}
 }
 
+// ImportShard imports the shard snapshot into local engine for rebalancing,
+// shard assignment events are handled after importing completes.
+func (m *stateManager) ImportShard(param *models.DatabaseAssignment, shardID models.ShardID, reader io.Reader) error {
+	if param == nil || param.ShardAssignment == nil {
+		return fmt.Errorf("shard assignment is empty")
+	}
+	// shard must be one of database's shards
+	if _, ok := param.ShardAssignment.Shards[shardID]; !ok {
+		return fmt.Errorf("shard[%d] not found in assignment of database[%s], num. of shards: %d",
+			shardID, param.ShardAssignment.Name, len(param.ShardAssignment.Shards))
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if err := m.engine.ImportShard(param.ShardAssignment.Name, shardID, param.Option, reader); err != nil {
+		m.logger.Error("import shard storage engine err",
+			logger.String("db", param.ShardAssignment.Name),
+			logger.Any("shardID", shardID),
+			logger.Error(err))
+		return err
+	}
+	m.logger.Info("import shard storage engine successfully",
+		logger.String("db", param.ShardAssignment.Name),
+		logger.Any("shardID", shardID))
+	return nil
+}
+
 // onShardAssignmentChange triggers when shard assignment changed after database config modified.
 func (m *stateManager) onShardAssignmentChange(key string, data []byte) {
 	m.logger.Info("shard assignment is changed",
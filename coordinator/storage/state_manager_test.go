@@ -160,3 +160,28 @@ func TestStateManager_OnShardAssignment(t *testing.T) {
 	time.Sleep(100 * time.Millisecond)
 	mgr.Close()
 }
+
+func TestStateManager_ImportShard(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	engine := tsdb.NewMockEngine(ctrl)
+	mgr := NewStateManager(context.TODO(), &models.StatefulNode{ID: 1}, engine)
+	defer mgr.(*stateManager).Close()
+
+	// case 1: assignment is empty
+	assert.Error(t, mgr.ImportShard(nil, 1, nil))
+	assert.Error(t, mgr.ImportShard(&models.DatabaseAssignment{}, 1, nil))
+	param := &models.DatabaseAssignment{ShardAssignment: &models.ShardAssignment{
+		Name:   "test",
+		Shards: map[models.ShardID]*models.Replica{0: {Replicas: []models.NodeID{1}}, 1: {Replicas: []models.NodeID{2}}},
+	}}
+	// case 2: shard not in assignment
+	assert.Error(t, mgr.ImportShard(param, 2, nil))
+	// case 3: import err
+	engine.EXPECT().ImportShard("test", models.ShardID(1), gomock.Any(), gomock.Any()).Return(fmt.Errorf("err"))
+	assert.Error(t, mgr.ImportShard(param, 1, nil))
+	// case 4: import ok
+	engine.EXPECT().ImportShard("test", models.ShardID(1), gomock.Any(), gomock.Any()).Return(nil)
+	assert.NoError(t, mgr.ImportShard(param, 1, nil))
+}
@@ -21,6 +21,7 @@ import (
 	"github.com/lindb/lindb/aggregation"
 	"github.com/lindb/lindb/internal/concurrent"
 	"github.com/lindb/lindb/pkg/timeutil"
+	protoCommonV1 "github.com/lindb/lindb/proto/gen/v1/common"
 	"github.com/lindb/lindb/series"
 )
 
@@ -43,10 +44,16 @@ type StorageQueryFlow interface {
 	Load(task concurrent.Task)
 	// Reduce reduces the down sampling aggregator's result.
 	Reduce(tags string, it series.GroupedIterator)
+	// ReduceExemplars reduces the exemplars of fields for the grouped series.
+	ReduceExemplars(tags string, exemplars []*protoCommonV1.Exemplar)
 	// ReduceTagValues reduces the group by tag values.
 	ReduceTagValues(tagKeyIndex int, tagValues map[uint32]string)
+	// Emit sends the result reduced so far as partial chunks if query streams result, invokes after each scanned batch.
+	Emit()
 	// Complete completes the query flow with error.
 	Complete(err error)
+	// Cancel cancels the query flow, stops executing pending tasks and sending the rest result chunks.
+	Cancel()
 }
 
 // QueryTask represents query task for data search flow.
@@ -37,6 +37,7 @@ require (
 	go.etcd.io/etcd v0.5.0-alpha.5.0.20200320040136-0eee733220fc
 	go.uber.org/atomic v1.6.0
 	go.uber.org/automaxprocs v1.4.0
+	go.uber.org/goleak v1.1.10
 	go.uber.org/zap v1.14.1
 	golang.org/x/sys v0.0.0-20211113001501-0c823b97ae02
 	google.golang.org/grpc v1.26.0
@@ -0,0 +1,36 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package common
+
+import (
+	"github.com/lindb/lindb/internal/linmetric"
+	"github.com/lindb/lindb/series/metric"
+)
+
+var (
+	clockScope           = linmetric.NewScope("lindb.ingestion.clock")
+	clockSkewCounter     = clockScope.NewCounter("skew_events")
+	clockSkewMillisGauge = clockScope.NewGauge("last_skew_ms")
+)
+
+func init() {
+	metric.AddClockSkewHandler(func(skew int64) {
+		clockSkewCounter.Incr()
+		clockSkewMillisGauge.Update(float64(skew))
+	})
+}
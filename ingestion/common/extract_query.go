@@ -21,13 +21,33 @@ import (
 	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/series/tag"
 
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
 	"strings"
 )
 
-const enrichTagsQueryKey = "enrich_tag"
+const (
+	enrichTagsQueryKey = "enrich_tag"
+	backfillQueryKey   = "backfill"
+	summaryQueryKey    = "summary"
+)
+
+// ErrEmptyMetrics represents no metric is decoded from request.
+var ErrEmptyMetrics = errors.New("empty metrics")
+
+// IsSummaryResponse checks if request wants the write summary with per-metric status from url query,
+// query: summary=true
+func IsSummaryResponse(req *http.Request) bool {
+	return strings.EqualFold(req.URL.Query().Get(summaryQueryKey), "true")
+}
+
+// IsBackfill checks if request is backfill mode from url query,
+// query: backfill=true
+func IsBackfill(req *http.Request) bool {
+	return strings.EqualFold(req.URL.Query().Get(backfillQueryKey), "true")
+}
 
 // ExtractEnrichTags extracts enriched tags from url query
 // query: enriched_tag=host=test&enriched_tag=ip=1.1.1.1&enriched_tag=zone=bj
@@ -30,6 +30,15 @@ func Test_ExtractEnrichTags(t *testing.T) {
 	assert.Len(t, tags, 1)
 }
 
+func Test_IsBackfill(t *testing.T) {
+	req, _ := http.NewRequest("GET", "http://lindb.io/write?backfill=true", nil)
+	assert.True(t, IsBackfill(req))
+	req, _ = http.NewRequest("GET", "http://lindb.io/write?backfill=false", nil)
+	assert.False(t, IsBackfill(req))
+	req, _ = http.NewRequest("GET", "http://lindb.io/write", nil)
+	assert.False(t, IsBackfill(req))
+}
+
 func Test_extractTagsFromQuery(t *testing.T) {
 	tags1, err := extractTagsFromQuery(make(map[string][]string))
 	assert.Nil(t, err)
@@ -18,6 +18,7 @@
 package flat
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -37,6 +38,7 @@ var (
 	flatDroppedMetricCounter   = flatIngestionScope.NewCounter("dropped_metrics")
 	flatUnmarshalMetricCounter = flatIngestionScope.NewCounter("ingested_metrics")
 	flatReadBytesCounter       = flatIngestionScope.NewCounter("read_bytes")
+	flatDecodeErrorCounterVec  = flatIngestionScope.NewCounterVec("decode_errors", "reason")
 	flatIngestionBlockScope    = flatIngestionScope.NewCounterVec("block", "size")
 	// small block
 	lt10KiBCounter  = flatIngestionBlockScope.WithTagValues("<10KiB")
@@ -50,6 +52,29 @@ var (
 
 var flatLogger = logger.GetLogger("ingestion", "Flat")
 
+// decodeErrorReasons maps flat metric validation errors to the reason tag of decode error metric
+var decodeErrorReasons = []struct {
+	err    error
+	reason string
+}{
+	{err: metric.ErrFlatMetricEmptyName, reason: "empty_name"},
+	{err: metric.ErrFlatMetricBadUTF8, reason: "bad_utf8"},
+	{err: metric.ErrFlatMetricNaNValue, reason: "nan_value"},
+	{err: metric.ErrFlatMetricTooManyTags, reason: "too_many_tags"},
+	{err: metric.ErrTimestampTooNew, reason: "future_timestamp"},
+	{err: metric.ErrTimestampTooOld, reason: "past_timestamp"},
+}
+
+// decodeErrorReason returns the reason of flat metric decode error
+func decodeErrorReason(err error) string {
+	for _, r := range decodeErrorReasons {
+		if errors.Is(err, r.err) {
+			return r.reason
+		}
+	}
+	return "other"
+}
+
 func Parse(req *http.Request, enrichedTags tag.Tags, namespace string) (*metric.BrokerBatchRows, error) {
 	var reader = req.Body
 	if strings.EqualFold(req.Header.Get("Content-Encoding"), "gzip") {
@@ -64,13 +89,13 @@ func Parse(req *http.Request, enrichedTags tag.Tags, namespace string) (*metric.
 	bufioReader, releaseBufioReaderFunc := ingestCommon.NewBufioReader(reader)
 	defer releaseBufioReaderFunc(bufioReader)
 
-	batch, err := parseFlatMetric(reader, enrichedTags, namespace)
+	batch, err := parseFlatMetric(reader, enrichedTags, namespace, ingestCommon.IsBackfill(req))
 	if err != nil {
 		flatCorruptedDataCounter.Incr()
 		return nil, err
 	}
 	if batch.Len() == 0 {
-		return nil, fmt.Errorf("empty metrics")
+		return batch, ingestCommon.ErrEmptyMetrics
 	}
 	flatUnmarshalMetricCounter.Add(float64(batch.Len()))
 	return batch, nil
@@ -80,10 +105,12 @@ func parseFlatMetric(
 	reader io.Reader,
 	enrichedTags tag.Tags,
 	namespace string,
+	backfill bool,
 ) (
 	batch *metric.BrokerBatchRows, err error,
 ) {
 	batch = metric.NewBrokerBatchRows()
+	batch.SetBackfill(backfill)
 
 	decoder, releaseFunc := metric.NewBrokerRowFlatDecoder(
 		reader,
@@ -91,6 +118,7 @@ func parseFlatMetric(
 		enrichedTags,
 	)
 	defer releaseFunc(decoder)
+	decoder.SetBackfill(backfill)
 
 	defer func() {
 		if r := recover(); r != nil {
@@ -102,6 +130,7 @@ func parseFlatMetric(
 		if err := batch.TryAppend(decoder.DecodeTo); err != nil {
 			flatLogger.Warn("failed ingesting flat metric", logger.Error(err))
 			flatDroppedMetricCounter.Incr()
+			flatDecodeErrorCounterVec.WithTagValues(decodeErrorReason(err)).Incr()
 		}
 	}
 
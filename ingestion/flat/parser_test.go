@@ -0,0 +1,103 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package flat
+
+import (
+	"bytes"
+	"math"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/internal/mock"
+	"github.com/lindb/lindb/pkg/fasttime"
+	"github.com/lindb/lindb/pkg/ltoml"
+	"github.com/lindb/lindb/pkg/timeutil"
+)
+
+func Test_Parse(t *testing.T) {
+	now := fasttime.UnixMilliseconds()
+	req, _ := http.NewRequest(http.MethodPut, "", bytes.NewReader(mock.BuildFlatMetric("cpu", 2, "v", 1, now)))
+	batch, err := Parse(req, nil, "ns")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, batch.Len())
+
+	req, _ = http.NewRequest(http.MethodPut, "", bytes.NewReader(nil))
+	_, err = Parse(req, nil, "ns")
+	assert.Error(t, err)
+}
+
+func Test_Parse_decodeErrors(t *testing.T) {
+	cfg := config.NewDefaultBrokerBase()
+	cfg.Ingestion.MaxPastAge = ltoml.Duration(24 * time.Hour)
+	config.SetGlobalBrokerConfig(cfg)
+	defer config.SetGlobalBrokerConfig(config.NewDefaultBrokerBase())
+
+	now := fasttime.UnixMilliseconds()
+	cases := []struct {
+		reason string
+		data   []byte
+	}{
+		{reason: "empty_name", data: mock.BuildFlatMetric("", 2, "v", 1, now)},
+		{reason: "bad_utf8", data: mock.BuildFlatMetric("cpu", 2, "v\xff", 1, now)},
+		{reason: "nan_value", data: mock.BuildFlatMetric("cpu", 2, "v", math.NaN(), now)},
+		{reason: "too_many_tags", data: mock.BuildFlatMetric("cpu", 100, "v", 1, now)},
+		{reason: "future_timestamp", data: mock.BuildFlatMetric("cpu", 2, "v", 1, now+2*timeutil.OneHour)},
+		{reason: "past_timestamp", data: mock.BuildFlatMetric("cpu", 2, "v", 1, now-2*timeutil.OneDay)},
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(c.reason, func(t *testing.T) {
+			counters := make(map[string]float64)
+			for _, r := range decodeErrorReasons {
+				counters[r.reason] = flatDecodeErrorCounterVec.WithTagValues(r.reason).Get()
+			}
+			req, _ := http.NewRequest(http.MethodPut, "", bytes.NewReader(c.data))
+			_, err := Parse(req, nil, "ns")
+			assert.Error(t, err)
+			for _, r := range decodeErrorReasons {
+				expect := counters[r.reason]
+				if r.reason == c.reason {
+					expect++
+				}
+				assert.Equal(t, expect, flatDecodeErrorCounterVec.WithTagValues(r.reason).Get())
+			}
+		})
+	}
+}
+
+func Test_Parse_backfill(t *testing.T) {
+	data := mock.BuildFlatMetric("cpu", 2, "v", 1, fasttime.UnixMilliseconds()+2*timeutil.OneHour)
+	req, _ := http.NewRequest(http.MethodPut, "?backfill=true", bytes.NewReader(data))
+	batch, err := Parse(req, nil, "ns")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, batch.Len())
+	assert.True(t, batch.IsBackfill())
+
+	req, _ = http.NewRequest(http.MethodPut, "", bytes.NewReader(data))
+	_, err = Parse(req, nil, "ns")
+	assert.Error(t, err)
+}
+
+func Test_decodeErrorReason(t *testing.T) {
+	assert.Equal(t, "other", decodeErrorReason(nil))
+	assert.Equal(t, "other", decodeErrorReason(assert.AnError))
+}
@@ -57,7 +57,9 @@ func Parse(req *http.Request, enrichedTags tag.Tags, namespace string) (*metric.
 	rowBuilder, releaseFunc := metric.NewRowBuilder()
 	defer releaseFunc(rowBuilder)
 
+	backfill := ingestCommon.IsBackfill(req)
 	batch := metric.NewBrokerBatchRows()
+	batch.SetBackfill(backfill)
 
 	for cr.HasNext() {
 		nextLine := cr.Next()
@@ -69,11 +71,12 @@ func Parse(req *http.Request, enrichedTags tag.Tags, namespace string) (*metric.
 		if bytes.HasPrefix(nextLine, []byte{'#'}) {
 			continue
 		}
-		if err := parseInfluxLine(rowBuilder, nextLine, namespace, multiplier); err != nil {
+		if err := parseInfluxLine(rowBuilder, nextLine, namespace, multiplier, backfill); err != nil {
 			influxLogger.Warn("ingest error",
 				logger.String("line", string(nextLine)),
 				logger.Error(err))
 			droppedMetricsCounter.Incr()
+			batch.Reject(err)
 			continue
 		}
 
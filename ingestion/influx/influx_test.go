@@ -21,12 +21,20 @@ import (
 	"github.com/klauspost/compress/gzip"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/pkg/fasttime"
+	"github.com/lindb/lindb/pkg/ltoml"
+	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/series/metric"
 	"github.com/lindb/lindb/series/tag"
 
 	"bytes"
+	"errors"
+	"fmt"
 	"net/http"
 	"strings"
 	"testing"
+	"time"
 )
 
 const _testBody = `
@@ -65,6 +73,31 @@ func Test_Parse(t *testing.T) {
 	assert.Len(t, batch.Rows(), 6)
 }
 
+func Test_Parse_TimestampWindow(t *testing.T) {
+	cfg := config.NewDefaultBrokerBase()
+	cfg.Ingestion.MaxPastAge = ltoml.Duration(time.Hour)
+	config.SetGlobalBrokerConfig(cfg)
+	defer config.SetGlobalBrokerConfig(config.NewDefaultBrokerBase())
+
+	now := fasttime.UnixMilliseconds()
+	body := fmt.Sprintf("measurement value=12 %d\nmeasurement value=12 %d\n", now, now-2*timeutil.OneHour)
+	// old timestamp is rejected
+	req, err := http.NewRequest(http.MethodPut, "?precision=ms", strings.NewReader(body))
+	assert.NoError(t, err)
+	batch, err := Parse(req, nil, "ns")
+	assert.NoError(t, err)
+	assert.Len(t, batch.Rows(), 1)
+	assert.Len(t, batch.Rejections(), 1)
+	assert.True(t, errors.Is(batch.Rejections()[0].Err, metric.ErrTimestampTooOld))
+	// backfill skips timestamp window
+	req, err = http.NewRequest(http.MethodPut, "?precision=ms&backfill=true", strings.NewReader(body))
+	assert.NoError(t, err)
+	batch, err = Parse(req, nil, "ns")
+	assert.NoError(t, err)
+	assert.Len(t, batch.Rows(), 2)
+	assert.Empty(t, batch.Rejections())
+}
+
 func Test_getGzipError(t *testing.T) {
 	req, err := http.NewRequest(http.MethodPut, "", strings.NewReader(_testBody))
 	assert.Nil(t, err)
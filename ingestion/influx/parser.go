@@ -57,6 +57,7 @@ func parseInfluxLine(
 	content []byte,
 	namespace string,
 	multiplier int64,
+	backfill bool,
 ) error {
 	// skip comment line
 	if bytes.HasPrefix(content, []byte{'#'}) {
@@ -108,6 +109,11 @@ func parseInfluxLine(
 	if err != nil {
 		return err
 	}
+	if !backfill {
+		if err := metric.CheckTimestampWindow(timestamp); err != nil {
+			return err
+		}
+	}
 	builder.AddTimestamp(timestamp)
 	return nil
 }
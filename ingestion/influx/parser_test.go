@@ -40,7 +40,7 @@ func Test_tooManyTags(t *testing.T) {
 		tagPair = append(tagPair, fmt.Sprintf("%s=%s", v, v))
 	}
 	line := fmt.Sprintf("mmm,%s x=1,y=2 1465839830100400200", strings.Join(tagPair, ","))
-	err := parseInfluxLine(builder, []byte(line), "ns", -1e6)
+	err := parseInfluxLine(builder, []byte(line), "ns", -1e6, false)
 	assert.NoError(t, err)
 	_, err = builder.Build()
 	assert.Error(t, err)
@@ -50,7 +50,7 @@ func Test_noTags_noTimestamp(t *testing.T) {
 	builder, releaseFunc := metric.NewRowBuilder()
 	defer releaseFunc(builder)
 
-	err := parseInfluxLine(builder, []byte("cpu value=1"), "ns2", -1e6)
+	err := parseInfluxLine(builder, []byte("cpu value=1"), "ns2", -1e6, false)
 	assert.Nil(t, err)
 	var row metric.BrokerRow
 	err = builder.BuildTo(&row)
@@ -75,7 +75,7 @@ func Test_badTimestamp(t *testing.T) {
 	}
 	for _, line := range lines {
 		builder.Reset()
-		err := parseInfluxLine(builder, []byte(line), "ns3", 1)
+		err := parseInfluxLine(builder, []byte(line), "ns3", 1, false)
 		assert.Equal(t, ErrBadTimestamp, err)
 	}
 }
@@ -100,7 +100,7 @@ func Test_tags(t *testing.T) {
 	}
 	for _, example := range examples {
 		builder.Reset()
-		err := parseInfluxLine(builder, []byte(example.Line), "ns", 1e6)
+		err := parseInfluxLine(builder, []byte(example.Line), "ns", 1e6, false)
 		assert.Nil(t, err)
 		var br metric.BrokerRow
 		assert.NoError(t, builder.BuildTo(&br))
@@ -129,7 +129,7 @@ func Test_InvalidLine(t *testing.T) {
 	}
 	for _, example := range examples {
 		builder.Reset()
-		err := parseInfluxLine(builder, []byte(example.Line), "ns", 1e6)
+		err := parseInfluxLine(builder, []byte(example.Line), "ns", 1e6, false)
 		if err == nil {
 			_, err = builder.Build()
 		}
@@ -155,7 +155,7 @@ func Test_metricName(t *testing.T) {
 	}
 	for _, example := range examples {
 		builder.Reset()
-		err := parseInfluxLine(builder, []byte(example.Line), "ns", 1e6)
+		err := parseInfluxLine(builder, []byte(example.Line), "ns", 1e6, false)
 		assert.NoError(t, err)
 		var row metric.BrokerRow
 		assert.NoError(t, builder.BuildTo(&row))
@@ -184,7 +184,7 @@ func Test_missingTagValues(t *testing.T) {
 	}
 	for _, example := range examples {
 		builder.Reset()
-		err := parseInfluxLine(builder, []byte(example.Line), "ns", -1e6)
+		err := parseInfluxLine(builder, []byte(example.Line), "ns", -1e6, false)
 		assert.Equal(t, example.Err, err)
 	}
 }
@@ -205,7 +205,7 @@ func Test_missingFieldNames(t *testing.T) {
 	}
 	for _, example := range examples {
 		builder.Reset()
-		err := parseInfluxLine(builder, []byte(example.Line), "ns", 1e6)
+		err := parseInfluxLine(builder, []byte(example.Line), "ns", 1e6, false)
 		assert.Equal(t, example.Err, err)
 		if example.FieldCount == 0 {
 			assert.Error(t, err)
@@ -401,7 +401,7 @@ func Test_parseUnescapedMetric(t *testing.T) {
 
 	for _, example := range examples {
 		builder.Reset()
-		err := parseInfluxLine(builder, []byte(example.Line), "ns", -1e6)
+		err := parseInfluxLine(builder, []byte(example.Line), "ns", -1e6, false)
 		assert.Nil(t, err)
 		var row metric.BrokerRow
 		assert.NoError(t, builder.BuildTo(&row))
@@ -448,7 +448,7 @@ func Test_parseBadFields(t *testing.T) {
 	defer releaseFunc(builder)
 	for _, line := range lines {
 		builder.Reset()
-		err := parseInfluxLine(builder, []byte(line), "ns", 1e6)
+		err := parseInfluxLine(builder, []byte(line), "ns", 1e6, false)
 		assert.Equal(t, ErrBadFields, err)
 	}
 }
@@ -18,6 +18,7 @@
 package proto
 
 import (
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -37,6 +38,7 @@ var (
 	nativeUnmarshalMetricCounter = protoIngestionScope.NewCounter("ingested_metrics")
 	droppedMetricCounter         = protoIngestionScope.NewCounter("dropped_metrics")
 	nativeReadBytesCounter       = protoIngestionScope.NewCounter("read_bytes")
+	outOfWindowMetricCounter     = protoIngestionScope.NewCounter("out_of_window_metrics")
 )
 
 func Parse(req *http.Request, enrichedTags tag.Tags, namespace string) (*metric.BrokerBatchRows, error) {
@@ -57,13 +59,13 @@ func Parse(req *http.Request, enrichedTags tag.Tags, namespace string) (*metric.
 	}
 
 	nativeReadBytesCounter.Add(float64(len(data)))
-	batch, err := parseProtoMetric(data, enrichedTags, namespace)
+	batch, err := parseProtoMetric(data, enrichedTags, namespace, ingestCommon.IsBackfill(req))
 	if err != nil {
 		nativeCorruptedDataCounter.Incr()
 		return nil, err
 	}
 	if batch.Len() == 0 {
-		return nil, fmt.Errorf("empty metrics")
+		return batch, ingestCommon.ErrEmptyMetrics
 	}
 	nativeUnmarshalMetricCounter.Add(float64(batch.Len()))
 	return batch, nil
@@ -73,13 +75,16 @@ func parseProtoMetric(
 	data []byte,
 	enrichedTags tag.Tags,
 	namespace string,
+	backfill bool,
 ) (
 	batch *metric.BrokerBatchRows, err error,
 ) {
 	batch = metric.NewBrokerBatchRows()
+	batch.SetBackfill(backfill)
 
 	converter, releaseFunc := metric.NewBrokerRowProtoConverter(strutil.String2ByteSlice(namespace), enrichedTags)
 	defer releaseFunc(converter)
+	converter.SetBackfill(backfill)
 
 	var ms protoMetricsV1.MetricList
 	if err := ms.Unmarshal(data); err != nil {
@@ -91,6 +96,9 @@ func parseProtoMetric(
 			return converter.ConvertTo(m, row)
 		}); err != nil {
 			droppedMetricCounter.Incr()
+			if errors.Is(err, metric.ErrTimestampOutOfWindow) {
+				outOfWindowMetricCounter.Incr()
+			}
 		}
 	}
 	return batch, nil
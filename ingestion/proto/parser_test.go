@@ -23,6 +23,8 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/lindb/lindb/pkg/fasttime"
+	"github.com/lindb/lindb/pkg/timeutil"
 	protoMetricsV1 "github.com/lindb/lindb/proto/gen/v1/metrics"
 	"github.com/lindb/lindb/series/tag"
 
@@ -94,9 +96,31 @@ func Test_Parser_empty(t *testing.T) {
 
 func Test_parseProtoMetric(t *testing.T) {
 	data, _ := testMetricList.Marshal()
-	batch, err := parseProtoMetric(data, nil, "ns")
+	batch, err := parseProtoMetric(data, nil, "ns", false)
 	assert.Nil(t, err)
 	m := batch.Rows()[0].Metric()
 	assert.Equal(t, "ns", string(m.Namespace()))
 	assert.Equal(t, 0, m.KeyValuesLength())
 }
+
+func Test_parseProtoMetric_outOfWindow(t *testing.T) {
+	ml := &protoMetricsV1.MetricList{Metrics: []*protoMetricsV1.Metric{
+		{
+			Name:      "a",
+			Timestamp: fasttime.UnixMilliseconds() + 2*timeutil.OneHour,
+			SimpleFields: []*protoMetricsV1.SimpleField{
+				{Name: "counter", Type: protoMetricsV1.SimpleFieldType_DELTA_SUM, Value: 23},
+			}},
+	}}
+	data, _ := ml.Marshal()
+	count := outOfWindowMetricCounter.Get()
+	batch, err := parseProtoMetric(data, nil, "ns", false)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, batch.Len())
+	assert.Equal(t, count+1, outOfWindowMetricCounter.Get())
+	// backfill bypasses timestamp acceptance window
+	batch, err = parseProtoMetric(data, nil, "ns", true)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, batch.Len())
+	assert.True(t, batch.IsBackfill())
+}
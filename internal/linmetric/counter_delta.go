@@ -18,6 +18,8 @@
 package linmetric
 
 import (
+	"sync"
+
 	"go.uber.org/atomic"
 
 	"github.com/lindb/lindb/proto/gen/v1/flatMetricsV1"
@@ -29,6 +31,9 @@ import (
 type BoundCounter struct {
 	delta     atomic.Float64
 	fieldName string
+
+	mu       sync.Mutex // lock for gathered and gathering
+	gathered float64    // sum of gathered delta values
 }
 
 func newCounter(fieldName string) *BoundCounter {
@@ -65,14 +70,26 @@ func (c *BoundCounter) Get() float64 {
 // gather returns the current cumulative counter value
 // and resets the delta value by spin lock.
 func (c *BoundCounter) gather() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	for {
 		v := c.delta.Load()
 		if c.delta.CAS(v, 0) {
+			c.gathered += v
 			return v
 		}
 	}
 }
 
+// cumulative returns the total counter value since created, which isn't reset by gathering.
+func (c *BoundCounter) cumulative() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.gathered + c.delta.Load()
+}
+
 func (c *BoundCounter) name() string { return c.fieldName }
 func (c *BoundCounter) flatType() flatMetricsV1.SimpleFieldType {
 	return flatMetricsV1.SimpleFieldTypeDeltaSum
@@ -31,6 +31,7 @@ type GaugeVec struct {
 	fieldName  string
 	mu         sync.RWMutex
 	gauges     map[string]*BoundGauge
+	seriesIDs  map[string]uint64 // id of series which gauge bound to
 }
 
 func newGaugeVec(metricName string, fieldName string, tags tag.Tags, tagKey ...string) *GaugeVec {
@@ -40,6 +41,7 @@ func newGaugeVec(metricName string, fieldName string, tags tag.Tags, tagKey ...s
 		tags:       tags,
 		tagKeys:    tagKey,
 		gauges:     make(map[string]*BoundGauge),
+		seriesIDs:  make(map[string]uint64),
 	}
 }
 
@@ -70,5 +72,25 @@ func (gv *GaugeVec) WithTagValues(tagValues ...string) *BoundGauge {
 	c = series.NewGauge(gv.fieldName)
 
 	gv.gauges[id] = c
+	gv.seriesIDs[id] = series.seriesID
 	return c
 }
+
+// DeleteTagValues removes the gauge bound with tag values, and the series is not exported anymore.
+func (gv *GaugeVec) DeleteTagValues(tagValues ...string) {
+	if len(tagValues) != len(gv.tagKeys) {
+		panic("count of tagKey and tagValue not match")
+	}
+	id := strings.Join(tagValues, ",")
+
+	gv.mu.Lock()
+	defer gv.mu.Unlock()
+
+	seriesID, ok := gv.seriesIDs[id]
+	if !ok {
+		return
+	}
+	delete(gv.gauges, id)
+	delete(gv.seriesIDs, id)
+	defaultRegistry.Unregister(seriesID)
+}
@@ -37,6 +37,31 @@ func Test_GaugeVec(t *testing.T) {
 	vec.WithTagValues("a", "b").Incr()
 }
 
+func Test_GaugeVec_DeleteTagValues(t *testing.T) {
+	scope := NewScope("vecg_delete")
+	vec := scope.NewGaugeVec("gauge", "1", "2")
+	assert.Panics(t, func() {
+		vec.DeleteTagValues("1")
+	})
+	registered := func(seriesID uint64) bool {
+		defaultRegistry.mu.RLock()
+		defer defaultRegistry.mu.RUnlock()
+		_, ok := defaultRegistry.series[seriesID]
+		return ok
+	}
+	vec.WithTagValues("a", "b").Update(1)
+	seriesID := vec.seriesIDs["a,b"]
+	assert.True(t, registered(seriesID))
+	vec.DeleteTagValues("a", "b")
+	assert.False(t, registered(seriesID))
+	assert.Empty(t, vec.gauges)
+	// not exist
+	vec.DeleteTagValues("a", "c")
+	// bound again
+	vec.WithTagValues("a", "b").Update(2)
+	assert.True(t, registered(vec.seriesIDs["a,b"]))
+}
+
 func Benchmark_GaugeVec(b *testing.B) {
 	scope := NewScope("vec_test")
 	vec := scope.NewGaugeVec("gauge", "1", "2")
@@ -21,6 +21,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/lindb/lindb/pkg/fasttime"
 	"github.com/lindb/lindb/series/metric"
 )
 
@@ -30,12 +31,24 @@ import (
 // a default created bucket will be automatically created,
 // however, you can also specify your own buckets.
 // Prometheus Histogram's buckets are cumulative where values in each buckets is cumulative,
+//
+// if native histogram is enabled, values are updated into sparse exponential buckets instead,
+// the fixed buckets specified by WithExponentBuckets/WithLinearBuckets are ignored.
 type BoundHistogram struct {
 	mu             sync.Mutex
 	bkts           *histogramBuckets
 	lastValues     []float64
 	lastTotalCount float64
 	lastTotalSum   float64
+	exemplars      []*Exemplar    // latest exemplar of each bucket
+	native         *nativeBuckets // not nil if updated as native histogram
+}
+
+// Exemplar represents a sample with trace id of histogram, used for drill-down from metric to trace.
+type Exemplar struct {
+	TraceID   string
+	Value     float64 // milliseconds as histogram
+	Timestamp int64   // milliseconds
 }
 
 func NewHistogram() *BoundHistogram {
@@ -55,6 +68,7 @@ func (h *BoundHistogram) afterResetBuckets() {
 	h.lastValues = cloneFloat64Slice(h.bkts.values)
 	h.lastTotalCount = h.bkts.totalCount
 	h.lastTotalSum = h.bkts.totalSum
+	h.exemplars = make([]*Exemplar, len(h.bkts.values))
 }
 
 func (h *BoundHistogram) WithExponentBuckets(lower, upper time.Duration, count int) *BoundHistogram {
@@ -75,6 +89,27 @@ func (h *BoundHistogram) WithLinearBuckets(lower, upper time.Duration, count int
 	return h
 }
 
+// WithExponentValueBuckets resets the buckets with exponent upper bounds for non-duration values, such as size.
+func (h *BoundHistogram) WithExponentValueBuckets(lower, upper float64, count int) *BoundHistogram {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.bkts.reset(lower, upper, count, exponentBucket)
+	h.afterResetBuckets()
+	return h
+}
+
+// UpdateValue updates the non-duration value, such as size.
+func (h *BoundHistogram) UpdateValue(v float64) {
+	h.UpdateMilliseconds(v)
+}
+
+// Total returns the total count and sum of values updated since created.
+func (h *BoundHistogram) Total() (count, sum float64) {
+	_, _, _, count, sum = h.cumulative()
+	return count, sum
+}
+
 func (h *BoundHistogram) UpdateDuration(d time.Duration) {
 	h.UpdateMilliseconds(float64(d.Nanoseconds() / 1e6))
 }
@@ -87,7 +122,46 @@ func (h *BoundHistogram) UpdateMilliseconds(s float64) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
-	h.bkts.Update(s)
+	h.update(s, "")
+}
+
+// UpdateMillisecondsWithExemplar updates the value, records it as the latest exemplar of its bucket with trace id.
+func (h *BoundHistogram) UpdateMillisecondsWithExemplar(s float64, traceID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.update(s, traceID)
+}
+
+// update updates the value into native or fixed buckets, records exemplar if trace id not empty.
+func (h *BoundHistogram) update(s float64, traceID string) {
+	if nativeHistogramEnabled.Load() {
+		if h.native == nil {
+			h.native = newNativeBuckets()
+		}
+		idx, ok := h.native.Update(s)
+		if ok && traceID != "" {
+			h.native.exemplars[idx] = newExemplar(s, traceID)
+		}
+		return
+	}
+	idx := h.bkts.Update(s)
+	if idx >= 0 && traceID != "" {
+		h.exemplars[idx] = newExemplar(s, traceID)
+	}
+}
+
+func newExemplar(s float64, traceID string) *Exemplar {
+	return &Exemplar{
+		TraceID:   traceID,
+		Value:     s,
+		Timestamp: fasttime.UnixMilliseconds(),
+	}
+}
+
+// UpdateSinceWithExemplar updates the duration since start with trace id as exemplar.
+func (h *BoundHistogram) UpdateSinceWithExemplar(start time.Time, traceID string) {
+	h.UpdateMillisecondsWithExemplar(float64(time.Since(start).Nanoseconds()/1e6), traceID)
 }
 
 func (h *BoundHistogram) UpdateSeconds(s float64) {
@@ -100,10 +174,35 @@ func (h *BoundHistogram) Update(f func()) {
 	h.UpdateSince(start)
 }
 
+// cumulative returns the upper bounds, cumulative counts and latest exemplars of buckets,
+// total count and sum since created.
+func (h *BoundHistogram) cumulative() (upperBounds, counts []float64, exemplars []*Exemplar, totalCount, totalSum float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.native != nil {
+		return h.native.cumulative()
+	}
+	upperBounds = cloneFloat64Slice(h.bkts.upperBounds)
+	exemplars = make([]*Exemplar, len(h.exemplars))
+	copy(exemplars, h.exemplars)
+	counts = make([]float64, len(h.bkts.values))
+	var sum float64
+	for idx, v := range h.bkts.values {
+		sum += v
+		counts[idx] = sum
+	}
+	return upperBounds, counts, exemplars, h.bkts.totalCount, h.bkts.totalSum
+}
+
 func (h *BoundHistogram) marshalToCompoundField(builder *metric.RowBuilder) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
+	if h.native != nil {
+		h.native.marshalToCompoundField(builder)
+		return
+	}
 	deltas := cloneFloat64Slice(h.bkts.values)
 	for idx := range deltas {
 		deltas[idx] -= h.lastValues[idx]
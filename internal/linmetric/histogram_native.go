@@ -0,0 +1,209 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package linmetric
+
+import (
+	"math"
+	"sort"
+
+	"go.uber.org/atomic"
+
+	"github.com/lindb/lindb/series/metric"
+)
+
+const (
+	// nativeHistogramSchema is the resolution of native histogram, the boundaries of buckets grow
+	// by factor 2^(2^-schema), schema 3 means factor ~1.09, relative error of quantile is within ~4.5%.
+	nativeHistogramSchema = 3
+	// minNativeHistogramSchema is the lowest resolution when reducing, schema -4 means factor 2^16.
+	minNativeHistogramSchema = -4
+	// maxNativeBuckets is the max number of buckets of native histogram, each bucket is written as a field,
+	// bounded under the max fields of metric(constants.DefaultMaxFieldsCount) with +Inf bucket and min/max/sum/count.
+	maxNativeBuckets = 200
+	// nativeZeroBucketIndex is the index of bucket which counts zero values.
+	nativeZeroBucketIndex = math.MinInt32
+)
+
+// nativeHistogramEnabled represents if histograms are updated as native histogram.
+var nativeHistogramEnabled atomic.Bool
+
+// SetNativeHistogram sets if histograms use native histogram instead of fixed buckets,
+// it should be set before histograms are updated(at startup).
+func SetNativeHistogram(enabled bool) {
+	nativeHistogramEnabled.Store(enabled)
+}
+
+// nativeBuckets is a sparse histogram with exponential buckets like Prometheus native histogram,
+// only buckets which have values are kept, bucket i counts values in (base^(i-1), base^i],
+// base = 2^(2^-schema), so that it keeps the same relative accuracy for all values, not thread-safe.
+// If the number of buckets exceeds maxNativeBuckets, the resolution is lowered by merging adjacent buckets,
+// the upper bounds of lower resolution are subset of the higher one, so no new field is created.
+type nativeBuckets struct {
+	schema         int
+	counts         map[int]float64
+	lastCounts     map[int]float64
+	exemplars      map[int]*Exemplar // latest exemplar of each bucket
+	min            float64
+	max            float64
+	totalCount     float64
+	totalSum       float64
+	lastTotalCount float64
+	lastTotalSum   float64
+}
+
+func newNativeBuckets() *nativeBuckets {
+	return &nativeBuckets{
+		schema:     nativeHistogramSchema,
+		counts:     make(map[int]float64),
+		lastCounts: make(map[int]float64),
+		exemplars:  make(map[int]*Exemplar),
+	}
+}
+
+// nativeBucketIndex returns the index of bucket which the value belongs to under the schema.
+func nativeBucketIndex(v float64, schema int) int {
+	if v == 0 {
+		return nativeZeroBucketIndex
+	}
+	return int(math.Ceil(math.Log2(v) * math.Exp2(float64(schema))))
+}
+
+// nativeBucketUpperBound returns the upper bound of bucket under the schema.
+func nativeBucketUpperBound(idx int, schema int) float64 {
+	if idx == nativeZeroBucketIndex {
+		return 0
+	}
+	return math.Exp2(float64(idx) / math.Exp2(float64(schema)))
+}
+
+// Update updates the value into bucket, returns the index of bucket, false if value is dropped.
+func (nb *nativeBuckets) Update(v float64) (int, bool) {
+	if math.IsNaN(v) || v < 0 || math.IsInf(v, 1) {
+		return 0, false
+	}
+	idx := nativeBucketIndex(v, nb.schema)
+	if _, ok := nb.counts[idx]; !ok && len(nb.counts) >= maxNativeBuckets && nb.schema > minNativeHistogramSchema {
+		nb.reduceSchema()
+		idx = nativeBucketIndex(v, nb.schema)
+	}
+	nb.counts[idx]++
+	nb.totalCount++
+	nb.totalSum += v
+	if nb.min == 0 || v < nb.min {
+		nb.min = v
+	}
+	if v > nb.max {
+		nb.max = v
+	}
+	return idx, true
+}
+
+// reduceSchema lowers the resolution until the number of buckets is under half of max buckets,
+// bucket i of schema s is merged into bucket ceil(i/2) of schema s-1.
+func (nb *nativeBuckets) reduceSchema() {
+	for len(nb.counts) > maxNativeBuckets/2 && nb.schema > minNativeHistogramSchema {
+		nb.schema--
+		nb.counts = mergeNativeBuckets(nb.counts)
+		nb.lastCounts = mergeNativeBuckets(nb.lastCounts)
+		exemplars := make(map[int]*Exemplar, len(nb.exemplars))
+		for idx, exemplar := range nb.exemplars {
+			idx = reduceNativeBucketIndex(idx)
+			// keeps the latest exemplar of merged buckets
+			if latest, ok := exemplars[idx]; !ok || exemplar.Timestamp > latest.Timestamp {
+				exemplars[idx] = exemplar
+			}
+		}
+		nb.exemplars = exemplars
+	}
+}
+
+// mergeNativeBuckets merges the counts of adjacent buckets when reducing schema by 1.
+func mergeNativeBuckets(counts map[int]float64) map[int]float64 {
+	merged := make(map[int]float64, len(counts)/2+1)
+	for idx, count := range counts {
+		merged[reduceNativeBucketIndex(idx)] += count
+	}
+	return merged
+}
+
+// reduceNativeBucketIndex returns the index of bucket which bucket idx is merged into when reducing schema by 1.
+func reduceNativeBucketIndex(idx int) int {
+	if idx == nativeZeroBucketIndex {
+		return idx
+	}
+	// ceil(idx/2)
+	return (idx + 1) >> 1
+}
+
+// sortedIndexes returns the indexes of all buckets in ascending order.
+func (nb *nativeBuckets) sortedIndexes() []int {
+	indexes := make([]int, 0, len(nb.counts))
+	for idx := range nb.counts {
+		indexes = append(indexes, idx)
+	}
+	sort.Ints(indexes)
+	return indexes
+}
+
+// cumulative returns the upper bounds, cumulative counts and latest exemplars of buckets(with +Inf bucket),
+// total count and sum since created.
+func (nb *nativeBuckets) cumulative() (upperBounds, counts []float64, exemplars []*Exemplar, totalCount, totalSum float64) {
+	indexes := nb.sortedIndexes()
+	upperBounds = make([]float64, 0, len(indexes)+1)
+	counts = make([]float64, 0, len(indexes)+1)
+	exemplars = make([]*Exemplar, 0, len(indexes)+1)
+	var sum float64
+	for _, idx := range indexes {
+		sum += nb.counts[idx]
+		upperBounds = append(upperBounds, nativeBucketUpperBound(idx, nb.schema))
+		counts = append(counts, sum)
+		exemplars = append(exemplars, nb.exemplars[idx])
+	}
+	upperBounds = append(upperBounds, math.Inf(1))
+	counts = append(counts, sum)
+	exemplars = append(exemplars, nil)
+	return upperBounds, counts, exemplars, nb.totalCount, nb.totalSum
+}
+
+// marshalToCompoundField writes the delta values of all buckets(with empty +Inf bucket) since last marshal.
+func (nb *nativeBuckets) marshalToCompoundField(builder *metric.RowBuilder) {
+	indexes := nb.sortedIndexes()
+	deltas := make([]float64, 0, len(indexes)+1)
+	upperBounds := make([]float64, 0, len(indexes)+1)
+	for _, idx := range indexes {
+		count := nb.counts[idx]
+		deltas = append(deltas, count-nb.lastCounts[idx])
+		upperBounds = append(upperBounds, nativeBucketUpperBound(idx, nb.schema))
+		nb.lastCounts[idx] = count
+	}
+	deltas = append(deltas, 0)
+	upperBounds = append(upperBounds, math.Inf(1))
+
+	_ = builder.AddCompoundFieldMMSC(
+		nb.min, nb.max,
+		nb.totalSum-nb.lastTotalSum,
+		nb.totalCount-nb.lastTotalCount,
+	)
+	_ = builder.AddCompoundFieldData(deltas, upperBounds)
+	// resets min and max
+	nb.min = 0
+	nb.max = 0
+	// resets total and sum
+	nb.lastTotalCount = nb.totalCount
+	nb.lastTotalSum = nb.totalSum
+}
@@ -0,0 +1,169 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package linmetric
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	flatbuffers "github.com/google/flatbuffers/go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/series/metric"
+)
+
+// histogramQuantile returns quantile with linear interpolation in bucket like Prometheus.
+func histogramQuantile(q float64, h *BoundHistogram) float64 {
+	upperBounds, counts, _, totalCount, _ := h.cumulative()
+	rank := q * totalCount
+	idx := sort.Search(len(counts), func(i int) bool { return counts[i] >= rank })
+	if math.IsInf(upperBounds[idx], 1) {
+		return upperBounds[idx-1]
+	}
+	var lower, lowerCount float64
+	if idx > 0 {
+		lower, lowerCount = upperBounds[idx-1], counts[idx-1]
+	}
+	return lower + (upperBounds[idx]-lower)*(rank-lowerCount)/(counts[idx]-lowerCount)
+}
+
+func Test_NativeHistogram_QuantileAccuracy(t *testing.T) {
+	defer SetNativeHistogram(false)
+
+	bucketed := NewHistogram()
+	SetNativeHistogram(true)
+	native := NewHistogram()
+
+	// skewed(log-normal) latency distribution, median 20ms with long tail
+	r := rand.New(rand.NewSource(1))
+	values := make([]float64, 100000)
+	for i := range values {
+		v := math.Exp(math.Log(20) + 1.5*r.NormFloat64())
+		values[i] = v
+		bucketed.bkts.Update(v) // updates fixed buckets directly, native histogram is enabled
+		native.UpdateMilliseconds(v)
+	}
+	sort.Float64s(values)
+	relativeErr := func(q float64, h *BoundHistogram) float64 {
+		expect := values[int(q*float64(len(values)))-1]
+		return math.Abs(histogramQuantile(q, h)-expect) / expect
+	}
+	for _, q := range []float64{0.5, 0.9, 0.99, 0.999} {
+		nativeErr := relativeErr(q, native)
+		bucketedErr := relativeErr(q, bucketed)
+		assert.Less(t, nativeErr, 0.05, q)
+		assert.Less(t, nativeErr, bucketedErr, q)
+	}
+}
+
+func Test_NativeHistogram(t *testing.T) {
+	defer SetNativeHistogram(false)
+	SetNativeHistogram(true)
+
+	h := NewHistogram()
+	h.UpdateMilliseconds(0)
+	h.UpdateMilliseconds(1)
+	h.UpdateMillisecondsWithExemplar(1.5, "trace-1")
+	h.UpdateMilliseconds(-1)         // dropped
+	h.UpdateMilliseconds(math.NaN()) // dropped
+	assert.Empty(t, h.bkts.values[0])
+	assert.Equal(t, float64(3), h.native.totalCount)
+	assert.Equal(t, float64(2.5), h.native.totalSum)
+
+	upperBounds, counts, exemplars, _, _ := h.cumulative()
+	assert.Equal(t, []float64{0, 1, nativeBucketUpperBound(nativeBucketIndex(1.5, nativeHistogramSchema), nativeHistogramSchema), math.Inf(1)}, upperBounds)
+	assert.Equal(t, []float64{1, 2, 3, 3}, counts)
+	assert.Equal(t, "trace-1", exemplars[2].TraceID)
+	assert.Nil(t, exemplars[0])
+
+	marshal := func() (bounds, deltas []float64, count float64) {
+		builder, releaseFunc := metric.NewRowBuilder()
+		defer releaseFunc(builder)
+		builder.AddMetricName([]byte("native"))
+		h.marshalToCompoundField(builder)
+		data, err := builder.Build()
+		assert.NoError(t, err)
+		var row metric.StorageRow
+		row.Unmarshal(data[flatbuffers.SizeUOffsetT:])
+		itr, ok := row.NewCompoundFieldIterator()
+		assert.True(t, ok)
+		for itr.HasNextBucket() {
+			bounds = append(bounds, itr.NextExplicitBound())
+			deltas = append(deltas, itr.NextValue())
+		}
+		return bounds, deltas, itr.Count()
+	}
+	bounds, deltas, count := marshal()
+	assert.Equal(t, upperBounds, bounds)
+	assert.Equal(t, []float64{1, 1, 1, 0}, deltas)
+	assert.Equal(t, float64(3), count)
+	// only delta since last marshal
+	h.UpdateMilliseconds(1)
+	bounds, deltas, count = marshal()
+	assert.Equal(t, upperBounds, bounds)
+	assert.Equal(t, []float64{0, 1, 0, 0}, deltas)
+	assert.Equal(t, float64(1), count)
+}
+
+func Test_NativeHistogram_MaxBuckets(t *testing.T) {
+	nb := newNativeBuckets()
+	nb.Update(0)
+	idx, _ := nb.Update(3)
+	nb.exemplars[idx] = &Exemplar{TraceID: "trace-1", Timestamp: 1}
+	for v := 1.0; v < math.Exp2(40); v *= 1.01 {
+		nb.Update(v)
+	}
+	assert.LessOrEqual(t, len(nb.counts), maxNativeBuckets)
+	assert.Less(t, nb.schema, nativeHistogramSchema)
+	assert.Equal(t, float64(1), nb.counts[nativeZeroBucketIndex])
+	var total float64
+	for _, count := range nb.counts {
+		total += count
+	}
+	assert.Equal(t, nb.totalCount, total)
+	// exemplar is kept in the merged bucket
+	assert.Equal(t, "trace-1", nb.exemplars[nativeBucketIndex(3, nb.schema)].TraceID)
+	upperBounds, counts, _, totalCount, _ := nb.cumulative()
+	assert.Equal(t, totalCount, counts[len(counts)-1])
+	// bounds of lower resolution are subset of bounds of the default resolution
+	for _, upperBound := range upperBounds[1 : len(upperBounds)-1] {
+		bound := nativeBucketUpperBound(nativeBucketIndex(upperBound, nativeHistogramSchema), nativeHistogramSchema)
+		assert.InEpsilon(t, upperBound, bound, 1e-9)
+	}
+}
+
+func Test_NativeHistogram_reduceSchema_delta(t *testing.T) {
+	nb := newNativeBuckets()
+	for i := 0; i < maxNativeBuckets; i++ {
+		nb.Update(math.Exp2((float64(i) - 0.5) / (1 << nativeHistogramSchema)))
+	}
+	assert.Len(t, nb.counts, maxNativeBuckets)
+	builder, releaseFunc := metric.NewRowBuilder()
+	defer releaseFunc(builder)
+	nb.marshalToCompoundField(builder)
+	// exceeds, merges counts and last counts, so that deltas keep correct
+	nb.Update(math.Exp2(1000))
+	assert.Less(t, nb.schema, nativeHistogramSchema)
+	var delta float64
+	for idx, count := range nb.counts {
+		delta += count - nb.lastCounts[idx]
+	}
+	assert.Equal(t, float64(1), delta)
+}
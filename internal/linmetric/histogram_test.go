@@ -54,6 +54,17 @@ func Test_Histogram(t *testing.T) {
 	}()
 }
 
+func Test_Histogram_Value(t *testing.T) {
+	h := NewHistogram().WithExponentValueBuckets(1, 1000, 4)
+	h.UpdateValue(1)
+	h.UpdateValue(50)
+	h.UpdateValue(2000)
+	count, sum := h.Total()
+	assert.Equal(t, 3.0, count)
+	assert.Equal(t, 2051.0, sum)
+	assert.Equal(t, 1.0, h.bkts.values[0])
+}
+
 func concurrentDo(f func()) {
 	var wg sync.WaitGroup
 	for range [100]struct{}{} {
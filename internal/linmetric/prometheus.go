@@ -0,0 +1,260 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package linmetric
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/lindb/lindb/series/tag"
+)
+
+const (
+	prometheusTypeCounter   = "counter"
+	prometheusTypeGauge     = "gauge"
+	prometheusTypeHistogram = "histogram"
+)
+
+// promFamily represents a metric family of Prometheus text exposition format.
+type promFamily struct {
+	typ    string
+	series []promSeries
+}
+
+// promSeries represents the sample lines of one series in the family.
+type promSeries struct {
+	labels string
+	lines  []string
+}
+
+const (
+	// PrometheusContentType is the content type of Prometheus text exposition format.
+	PrometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+	// OpenMetricsContentType is the content type of OpenMetrics text format.
+	OpenMetricsContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+)
+
+// WritePrometheus writes all metrics in Prometheus text exposition format(version 0.0.4).
+// Metric name is metric-name_field-name, tags are labels, counters are cumulative values
+// since process started, values of histogram are in milliseconds.
+func WritePrometheus(w io.Writer) error {
+	return defaultRegistry.writePrometheus(w, false)
+}
+
+// WriteOpenMetrics writes all metrics in OpenMetrics text format(version 1.0.0),
+// same as Prometheus format, except counters have _total suffix, histogram buckets
+// carry the latest exemplar, and output is terminated by # EOF.
+func WriteOpenMetrics(w io.Writer) error {
+	return defaultRegistry.writePrometheus(w, true)
+}
+
+// writePrometheus collects all series of registry, writes them grouped by metric family.
+func (r *registry) writePrometheus(w io.Writer, openMetrics bool) error {
+	r.mu.RLock()
+	seriesList := make([]*taggedSeries, 0, len(r.series))
+	for _, s := range r.series {
+		seriesList = append(seriesList, s)
+	}
+	r.mu.RUnlock()
+
+	families := make(map[string]*promFamily)
+	for _, s := range seriesList {
+		s.collectPrometheus(families, openMetrics)
+	}
+	names := make([]string, 0, len(families))
+	for name := range families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	bw := bufio.NewWriter(w)
+	for _, name := range names {
+		family := families[name]
+		sort.Slice(family.series, func(i, j int) bool {
+			return family.series[i].labels < family.series[j].labels
+		})
+		_, _ = bw.WriteString("# TYPE " + name + " " + family.typ + "\n")
+		for _, s := range family.series {
+			for _, line := range s.lines {
+				_, _ = bw.WriteString(line)
+				_ = bw.WriteByte('\n')
+			}
+		}
+	}
+	if openMetrics {
+		_, _ = bw.WriteString("# EOF\n")
+	}
+	return bw.Flush()
+}
+
+// collectPrometheus collects the fields of series into metric families.
+func (s *taggedSeries) collectPrometheus(families map[string]*promFamily, openMetrics bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.payload == nil {
+		return
+	}
+	labels := prometheusLabels(s.tags)
+	for _, sf := range s.payload.simpleFields {
+		var (
+			typ   string
+			value float64
+		)
+		switch f := sf.(type) {
+		case *BoundCounter:
+			typ, value = prometheusTypeCounter, f.cumulative()
+		case *BoundGauge:
+			typ, value = prometheusTypeGauge, f.Get()
+		case *BoundMax:
+			typ, value = prometheusTypeGauge, f.Get()
+		case *BoundMin:
+			typ, value = prometheusTypeGauge, f.Get()
+		default:
+			continue
+		}
+		name := sanitizePrometheusName(s.metricName + "_" + sf.name())
+		sampleName := name
+		if openMetrics && typ == prometheusTypeCounter {
+			// counter family name cannot have _total suffix, but its sample must have
+			name = strings.TrimSuffix(name, "_total")
+			sampleName = name + "_total"
+		}
+		addPrometheusSeries(families, name, typ, promSeries{
+			labels: labels,
+			lines:  []string{sampleName + wrapPrometheusLabels(labels) + " " + formatPrometheusValue(value)},
+		})
+	}
+	if s.payload.histogramDelta != nil {
+		name := sanitizePrometheusName(s.metricName)
+		upperBounds, counts, exemplars, totalCount, totalSum := s.payload.histogramDelta.cumulative()
+		lines := make([]string, 0, len(counts)+2)
+		for idx, count := range counts {
+			le := "le=\"" + formatPrometheusValue(upperBounds[idx]) + "\""
+			if labels != "" {
+				le = labels + "," + le
+			}
+			line := name + "_bucket{" + le + "} " + formatPrometheusValue(count)
+			if openMetrics && exemplars[idx] != nil {
+				line += formatOpenMetricsExemplar(exemplars[idx])
+			}
+			lines = append(lines, line)
+		}
+		lines = append(lines,
+			name+"_sum"+wrapPrometheusLabels(labels)+" "+formatPrometheusValue(totalSum),
+			name+"_count"+wrapPrometheusLabels(labels)+" "+formatPrometheusValue(totalCount),
+		)
+		addPrometheusSeries(families, name, prometheusTypeHistogram, promSeries{labels: labels, lines: lines})
+	}
+}
+
+// formatOpenMetricsExemplar returns the exemplar of OpenMetrics, such as: # {trace_id="abc"} 1.5 1520879607.789
+func formatOpenMetricsExemplar(e *Exemplar) string {
+	return " # {trace_id=\"" + escapePrometheusLabelValue(e.TraceID) + "\"} " + formatPrometheusValue(e.Value) +
+		" " + strconv.FormatFloat(float64(e.Timestamp)/1000, 'f', 3, 64)
+}
+
+// addPrometheusSeries adds series into the family, series is dropped if family has another type.
+func addPrometheusSeries(families map[string]*promFamily, name, typ string, series promSeries) {
+	family, ok := families[name]
+	if !ok {
+		family = &promFamily{typ: typ}
+		families[name] = family
+	}
+	if family.typ != typ {
+		return
+	}
+	family.series = append(family.series, series)
+}
+
+// prometheusLabels returns the labels of tags sorted by key, such as: k1="v1",k2="v2".
+func prometheusLabels(tags tag.Tags) string {
+	tags = tags.Clone()
+	sort.Slice(tags, func(i, j int) bool {
+		return bytes.Compare(tags[i].Key, tags[j].Key) < 0
+	})
+	var sb strings.Builder
+	for idx, kv := range tags {
+		if idx > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(sanitizePrometheusLabelName(string(kv.Key)))
+		sb.WriteString("=\"")
+		sb.WriteString(escapePrometheusLabelValue(string(kv.Value)))
+		sb.WriteByte('"')
+	}
+	return sb.String()
+}
+
+func wrapPrometheusLabels(labels string) string {
+	if labels == "" {
+		return ""
+	}
+	return "{" + labels + "}"
+}
+
+// sanitizePrometheusName replaces the chars not matching [a-zA-Z0-9_:] with '_'.
+func sanitizePrometheusName(name string) string {
+	return sanitizePrometheus(name, true)
+}
+
+// sanitizePrometheusLabelName replaces the chars not matching [a-zA-Z0-9_] with '_'.
+func sanitizePrometheusLabelName(name string) string {
+	return sanitizePrometheus(name, false)
+}
+
+func sanitizePrometheus(name string, allowColon bool) string {
+	b := []byte(name)
+	for idx, c := range b {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '_':
+		case c == ':' && allowColon:
+		default:
+			b[idx] = '_'
+		}
+	}
+	if len(b) > 0 && b[0] >= '0' && b[0] <= '9' {
+		// name cannot start with digit
+		return "_" + string(b)
+	}
+	return string(b)
+}
+
+var prometheusLabelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapePrometheusLabelValue(value string) string {
+	return prometheusLabelValueReplacer.Replace(value)
+}
+
+func formatPrometheusValue(v float64) string {
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
@@ -0,0 +1,97 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package linmetric
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_WritePrometheus(t *testing.T) {
+	scope := NewScope("lindb.prom_test", "role", "broker")
+	counter := scope.NewCounterVec("requests", "path").WithTagValues(`/api/"write"`)
+	counter.Add(3)
+	gauge := scope.Scope("conns").NewGauge("active")
+	gauge.Update(5)
+	histogram := scope.Scope("latency").NewHistogram().WithExponentBuckets(time.Millisecond, time.Second, 5)
+	histogram.UpdateMilliseconds(0.5)
+	histogram.UpdateMilliseconds(500)
+
+	// native gathering doesn't reset the cumulative counter
+	_, _ = NewGather().Gather()
+	counter.Incr()
+
+	var buf bytes.Buffer
+	assert.NoError(t, WritePrometheus(&buf))
+	output := buf.String()
+	assert.Contains(t, output, "# TYPE lindb_prom_test_requests counter\n"+
+		`lindb_prom_test_requests{path="/api/\"write\"",role="broker"} 4`+"\n")
+	assert.Contains(t, output, "# TYPE lindb_prom_test_conns_active gauge\n"+
+		`lindb_prom_test_conns_active{role="broker"} 5`+"\n")
+	assert.Contains(t, output, "# TYPE lindb_prom_test_latency histogram\n")
+	assert.Contains(t, output, `lindb_prom_test_latency_bucket{role="broker",le="1"} 1`+"\n")
+	assert.Contains(t, output, `lindb_prom_test_latency_bucket{role="broker",le="+Inf"} 2`+"\n")
+	assert.Contains(t, output, `lindb_prom_test_latency_sum{role="broker"} 500.5`+"\n")
+	assert.Contains(t, output, `lindb_prom_test_latency_count{role="broker"} 2`+"\n")
+	// each family has only one type line
+	assert.Equal(t, 1, strings.Count(output, "# TYPE lindb_prom_test_requests "))
+}
+
+func Test_Prometheus_Format(t *testing.T) {
+	assert.Equal(t, "lindb_a_b:c_d", sanitizePrometheusName("lindb.a-b:c d"))
+	assert.Equal(t, "_1m_rate", sanitizePrometheusName("1m.rate"))
+	assert.Equal(t, "a_b", sanitizePrometheusLabelName("a:b"))
+	assert.Equal(t, `a\\b\"c\nd`, escapePrometheusLabelValue("a\\b\"c\nd"))
+	assert.Equal(t, "NaN", formatPrometheusValue(math.NaN()))
+	assert.Equal(t, "+Inf", formatPrometheusValue(math.Inf(1)))
+	assert.Equal(t, "-Inf", formatPrometheusValue(math.Inf(-1)))
+	assert.Equal(t, "0.5", formatPrometheusValue(0.5))
+	assert.Empty(t, wrapPrometheusLabels(""))
+}
+
+func Test_WriteOpenMetrics(t *testing.T) {
+	scope := NewScope("lindb.openmetrics_test")
+	scope.NewCounter("requests_total").Add(2)
+	histogram := scope.Scope("latency").NewHistogram().WithLinearBuckets(time.Millisecond*10, time.Millisecond*30, 4)
+	histogram.UpdateMillisecondsWithExemplar(15, `trace"1`)
+	histogram.UpdateMillisecondsWithExemplar(16, "") // no exemplar
+	histogram.UpdateSinceWithExemplar(time.Now(), "trace-2")
+	histogram.UpdateMillisecondsWithExemplar(-1, "trace-3") // dropped
+
+	var buf bytes.Buffer
+	assert.NoError(t, WriteOpenMetrics(&buf))
+	output := buf.String()
+	assert.Contains(t, output, "# TYPE lindb_openmetrics_test_requests counter\n"+
+		"lindb_openmetrics_test_requests_total 2\n")
+	assert.Regexp(t, `lindb_openmetrics_test_latency_bucket\{le="10"\} 1 # \{trace_id="trace-2"\} 0 [0-9]+\.[0-9]{3}\n`, output)
+	assert.Regexp(t, `lindb_openmetrics_test_latency_bucket\{le="20"\} 3 # \{trace_id="trace\\"1"\} 15 [0-9]+\.[0-9]{3}\n`, output)
+	assert.Contains(t, output, "lindb_openmetrics_test_latency_bucket{le=\"+Inf\"} 3\n")
+	assert.NotContains(t, output, "trace-3")
+	assert.True(t, strings.HasSuffix(output, "# EOF\n"))
+
+	// prometheus format has no exemplars
+	buf.Reset()
+	assert.NoError(t, WritePrometheus(&buf))
+	assert.NotContains(t, buf.String(), "trace_id")
+	assert.Contains(t, buf.String(), "lindb_openmetrics_test_requests_total 2\n")
+}
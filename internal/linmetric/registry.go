@@ -54,6 +54,14 @@ func (r *registry) Register(seriesID uint64, series *taggedSeries) *taggedSeries
 	return series
 }
 
+// Unregister removes the series from registry, the series will not be exported anymore
+func (r *registry) Unregister(seriesID uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.series, seriesID)
+}
+
 // gatherMetricList transforms event-metrics to native lindb dto-proto format
 func (r *registry) gatherMetricList(
 	writer io.Writer, merger func(builder *metric.RowBuilder),
@@ -0,0 +1,63 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package mock
+
+import (
+	"strconv"
+
+	flatbuffers "github.com/google/flatbuffers/go"
+
+	"github.com/lindb/lindb/proto/gen/v1/flatMetricsV1"
+)
+
+// BuildFlatMetric builds a size prefixed flat metric with one delta sum field for testing,
+// tags are key0...keyN with the same value.
+func BuildFlatMetric(name string, tagsCount int, tagValue string, fieldValue float64, ts int64) []byte {
+	builder := flatbuffers.NewBuilder(1024)
+	var kvs []flatbuffers.UOffsetT
+	for i := 0; i < tagsCount; i++ {
+		key := builder.CreateString("key" + strconv.Itoa(i))
+		value := builder.CreateString(tagValue)
+		flatMetricsV1.KeyValueStart(builder)
+		flatMetricsV1.KeyValueAddKey(builder, key)
+		flatMetricsV1.KeyValueAddValue(builder, value)
+		kvs = append(kvs, flatMetricsV1.KeyValueEnd(builder))
+	}
+	flatMetricsV1.MetricStartKeyValuesVector(builder, len(kvs))
+	for i := len(kvs) - 1; i >= 0; i-- {
+		builder.PrependUOffsetT(kvs[i])
+	}
+	kvsVector := builder.EndVector(len(kvs))
+	fieldName := builder.CreateString("f1")
+	flatMetricsV1.SimpleFieldStart(builder)
+	flatMetricsV1.SimpleFieldAddName(builder, fieldName)
+	flatMetricsV1.SimpleFieldAddType(builder, flatMetricsV1.SimpleFieldTypeDeltaSum)
+	flatMetricsV1.SimpleFieldAddValue(builder, fieldValue)
+	f := flatMetricsV1.SimpleFieldEnd(builder)
+	flatMetricsV1.MetricStartSimpleFieldsVector(builder, 1)
+	builder.PrependUOffsetT(f)
+	fields := builder.EndVector(1)
+	metricName := builder.CreateString(name)
+	flatMetricsV1.MetricStart(builder)
+	flatMetricsV1.MetricAddName(builder, metricName)
+	flatMetricsV1.MetricAddTimestamp(builder, ts)
+	flatMetricsV1.MetricAddKeyValues(builder, kvsVector)
+	flatMetricsV1.MetricAddSimpleFields(builder, fields)
+	builder.FinishSizePrefixed(flatMetricsV1.MetricEnd(builder))
+	return builder.FinishedBytes()
+}
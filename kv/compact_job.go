@@ -20,6 +20,7 @@ package kv
 import (
 	"errors"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/lindb/lindb/internal/linmetric"
@@ -47,6 +48,8 @@ type compactJob struct {
 	state     *compactionState
 	newMerger NewMerger
 	rollup    Rollup
+	params    map[string]interface{} // merger params
+	rewrite   bool                   // merge input files even if there is only one input file
 }
 
 // newCompactJob creates a compaction job
@@ -59,11 +62,23 @@ func newCompactJob(family Family, state *compactionState, rollup Rollup) Compact
 	}
 }
 
+// newRewriteJob creates a rewrite job, merges all input files into new files by merger with the params,
+// even if there is only one input file.
+func newRewriteJob(family Family, state *compactionState, params map[string]interface{}) CompactJob {
+	return &compactJob{
+		family:    family,
+		newMerger: family.getNewMerger(),
+		state:     state,
+		params:    params,
+		rewrite:   true,
+	}
+}
+
 // Run runs compact job
 func (c *compactJob) Run() error {
 	compaction := c.state.compaction
 	switch {
-	case compaction.IsTrivialMove():
+	case compaction.IsTrivialMove() && !c.rewrite:
 		c.moveCompaction()
 	default:
 		if err := c.mergeCompaction(); err != nil {
@@ -123,8 +138,15 @@ func (c *compactJob) doMerge() error {
 	if err != nil {
 		return err
 	}
+	params := make(map[string]interface{}, len(c.params)+1)
+	for key, value := range c.params {
+		params[key] = value
+	}
 	if c.rollup != nil {
-		merger.Init(map[string]interface{}{RollupContext: c.rollup})
+		params[RollupContext] = c.rollup
+	}
+	if len(params) > 0 {
+		merger.Init(params)
 	}
 
 	var needMerge [][]byte
@@ -185,19 +207,25 @@ func (c *compactJob) installCompactionResults() {
 	c.family.commitEditLog(c.state.compaction.GetEditLog())
 }
 
-// makeInputIterator makes a merged iterator by compaction pick input files
+// makeInputIterator makes a merged iterator by compaction pick input files.
+// Invariant: values with same key are passed to merger from older to newer(newest last),
+// because merger may keep the latest value(e.g. last value of gauge). So up level files(older) are
+// iterated before level files, and files in same level are ordered by file number(creation sequence).
 func (c *compactJob) makeInputIterator() (table.Iterator, error) {
 	var its []table.Iterator
-	for which := 0; which < 2; which++ {
-		files := c.state.compaction.GetInputs()[which]
-		if len(files) > 0 {
-			for _, fileMeta := range files {
-				reader, err := c.state.snapshot.GetReader(fileMeta.GetFileNumber())
-				if err != nil {
-					return nil, err
-				}
-				its = append(its, reader.Iterator())
+	inputs := c.state.compaction.GetInputs()
+	for which := len(inputs) - 1; which >= 0; which-- {
+		files := make([]*version.FileMeta, len(inputs[which]))
+		copy(files, inputs[which])
+		sort.Slice(files, func(i, j int) bool {
+			return files[i].GetFileNumber() < files[j].GetFileNumber()
+		})
+		for _, fileMeta := range files {
+			reader, err := c.state.snapshot.GetReader(fileMeta.GetFileNumber())
+			if err != nil {
+				return nil, err
 			}
+			its = append(its, reader.Iterator())
 		}
 	}
 	return table.NewMergedIterator(its), nil
@@ -146,13 +146,14 @@ func TestCompactJob_merge_doMerge_fail(t *testing.T) {
 	merge := NewMockMerger(ctrl)
 
 	// test new store build fail
+	// up level files are iterated first
 	gomock.InOrder(
-		reader1.EXPECT().Iterator().Return(generateIterator(ctrl, map[uint32][]byte{
-			1: []byte("value1"),
-		})),
 		reader2.EXPECT().Iterator().Return(generateIterator(ctrl, map[uint32][]byte{
 			2: []byte("value2"),
 		})),
+		reader1.EXPECT().Iterator().Return(generateIterator(ctrl, map[uint32][]byte{
+			1: []byte("value1"),
+		})),
 	)
 	gomock.InOrder(
 		merge.EXPECT().Merge(gomock.Any(), gomock.Any()).Return(nil),
@@ -184,13 +185,14 @@ func TestCompactJob_output_fail(t *testing.T) {
 	merge := NewMockMerger(ctrl)
 
 	// test store build is empty
+	// up level files are iterated first
 	gomock.InOrder(
-		reader1.EXPECT().Iterator().Return(generateIterator(ctrl, map[uint32][]byte{
-			1: []byte("value1"),
-		})),
 		reader2.EXPECT().Iterator().Return(generateIterator(ctrl, map[uint32][]byte{
 			1: []byte("value2"),
 		})),
+		reader1.EXPECT().Iterator().Return(generateIterator(ctrl, map[uint32][]byte{
+			1: []byte("value1"),
+		})),
 	)
 	snapshot.EXPECT().GetReader(table.FileNumber(1)).Return(reader1, nil)
 	snapshot.EXPECT().GetReader(table.FileNumber(4)).Return(reader2, nil)
@@ -223,13 +225,14 @@ func TestCompactJob_output_fail(t *testing.T) {
 	assert.Equal(t, 0, len(state.outputs))
 
 	// test finish output fail
+	// up level files are iterated first
 	gomock.InOrder(
-		reader1.EXPECT().Iterator().Return(generateIterator(ctrl, map[uint32][]byte{
-			1: []byte("value1"),
-		})),
 		reader2.EXPECT().Iterator().Return(generateIterator(ctrl, map[uint32][]byte{
 			1: []byte("value2"),
 		})),
+		reader1.EXPECT().Iterator().Return(generateIterator(ctrl, map[uint32][]byte{
+			1: []byte("value1"),
+		})),
 	)
 	snapshot.EXPECT().GetReader(table.FileNumber(1)).Return(reader1, nil)
 	snapshot.EXPECT().GetReader(table.FileNumber(4)).Return(reader2, nil)
@@ -276,6 +279,33 @@ func TestCompactJob_finish_output_fail(t *testing.T) {
 	assert.NotNil(t, err)
 }
 
+func TestCompactJob_makeInputIterator_order(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	snapshot := version.NewMockSnapshot(ctrl)
+	for _, fileNumber := range []table.FileNumber{1, 2, 3, 4} {
+		reader := table.NewMockReader(ctrl)
+		reader.EXPECT().Iterator().Return(generateIterator(ctrl, map[uint32][]byte{
+			10: []byte(fmt.Sprintf("file%d", fileNumber)),
+		}))
+		snapshot.EXPECT().GetReader(fileNumber).Return(reader, nil)
+	}
+	// level files(newer) are unordered, up level files(older)
+	compaction := version.NewCompaction(1, 0,
+		[]*version.FileMeta{version.NewFileMeta(4, 1, 10, 100), version.NewFileMeta(3, 1, 10, 100)},
+		[]*version.FileMeta{version.NewFileMeta(2, 1, 10, 100), version.NewFileMeta(1, 1, 10, 100)})
+	job := &compactJob{state: newCompactionState(1000, snapshot, compaction)}
+	it, err := job.makeInputIterator()
+	assert.NoError(t, err)
+	var values []string
+	for it.HasNext() {
+		values = append(values, string(it.Value()))
+	}
+	// newest last
+	assert.Equal(t, []string{"file1", "file2", "file3", "file4"}, values)
+}
+
 func TestCompactJob_merge_compact(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -17,13 +17,25 @@
 
 package kv
 
-import "github.com/lindb/lindb/pkg/logger"
+import (
+	"errors"
+	"time"
+
+	"github.com/lindb/lindb/pkg/logger"
+)
 
 const dummy = ""
 const RollupContext = "RollupContext"
 const defaultMaxFileSize = uint32(256 * 1024 * 1024)
 const defaultCompactThreshold = 4
 const defaultRollupThreshold = 3
+const defaultSyncInterval = time.Second
 
 var defaultCompactCheckInterval = 60
+
+// compactWaitInterval is the interval of checking if background compaction of family completed for manual compaction.
+var compactWaitInterval = 100 * time.Millisecond
+
+// ErrFamilyBusy represents the family cannot be rewritten because other compaction is running.
+var ErrFamilyBusy = errors.New("family is busy with other compaction")
 var kvLogger = logger.GetLogger("kv", "Store")
@@ -0,0 +1,90 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package kv
+
+import (
+	"context"
+	"time"
+
+	"github.com/lindb/lindb/internal/linmetric"
+)
+
+//go:generate mockgen -source ./coordinator.go -destination=./coordinator_mock.go -package kv
+
+// Coordinator coordinates the heavy background operations(flush/compaction) of families which share the same disk,
+// at most one operation runs at a time, so that flush and compaction don't stall each other and the write path.
+type Coordinator interface {
+	// AcquireFlush blocks until no other operation is running, returns the function which releases it.
+	AcquireFlush() (release func())
+	// TryAcquireCompaction returns the function which releases it if no other operation is running,
+	// else returns false, and the compaction is deferred to next check cycle.
+	TryAcquireCompaction() (release func(), ok bool)
+	// AcquireCompaction blocks until no other operation is running or ctx done, returns the function which releases it.
+	AcquireCompaction(ctx context.Context) (release func(), err error)
+}
+
+// coordinator implements Coordinator interface based on semaphore.
+type coordinator struct {
+	sem                 chan struct{}
+	flushWaitTimer      *linmetric.BoundHistogram
+	deferredCompactions *linmetric.BoundCounter
+}
+
+// NewCoordinator creates the background operation coordinator,
+// records the time of flush waiting and the number of deferred compactions.
+func NewCoordinator(flushWaitTimer *linmetric.BoundHistogram, deferredCompactions *linmetric.BoundCounter) Coordinator {
+	return &coordinator{
+		sem:                 make(chan struct{}, 1),
+		flushWaitTimer:      flushWaitTimer,
+		deferredCompactions: deferredCompactions,
+	}
+}
+
+// AcquireFlush blocks until no other operation is running, returns the function which releases it.
+func (c *coordinator) AcquireFlush() (release func()) {
+	start := time.Now()
+	c.sem <- struct{}{}
+	c.flushWaitTimer.UpdateSince(start)
+	return c.release
+}
+
+// TryAcquireCompaction returns the function which releases it if no other operation is running.
+func (c *coordinator) TryAcquireCompaction() (release func(), ok bool) {
+	select {
+	case c.sem <- struct{}{}:
+		return c.release, true
+	default:
+		c.deferredCompactions.Incr()
+		return nil, false
+	}
+}
+
+// AcquireCompaction blocks until no other operation is running or ctx done, used by manual compaction.
+func (c *coordinator) AcquireCompaction(ctx context.Context) (release func(), err error) {
+	select {
+	case c.sem <- struct{}{}:
+		return c.release, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// release releases the running operation.
+func (c *coordinator) release() {
+	<-c.sem
+}
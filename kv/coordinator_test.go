@@ -0,0 +1,76 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package kv
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/internal/linmetric"
+)
+
+func TestCoordinator(t *testing.T) {
+	scope := linmetric.NewScope("kv_coordinator_test")
+	deferredCompactions := scope.NewCounter("deferred_compactions")
+	c := NewCoordinator(scope.Scope("flush_wait").NewHistogram(), deferredCompactions)
+
+	// compaction is running, flush waits until it completes
+	releaseCompaction, ok := c.TryAcquireCompaction()
+	assert.True(t, ok)
+	flushed := make(chan struct{})
+	go func() {
+		release := c.AcquireFlush()
+		close(flushed)
+		release()
+	}()
+	select {
+	case <-flushed:
+		assert.Fail(t, "flush should wait for compaction")
+	case <-time.After(50 * time.Millisecond):
+	}
+	releaseCompaction()
+	select {
+	case <-flushed:
+	case <-time.After(time.Second):
+		assert.Fail(t, "flush should run after compaction completes")
+	}
+
+	// flush is running, compaction is deferred
+	releaseFlush := c.AcquireFlush()
+	_, ok = c.TryAcquireCompaction()
+	assert.False(t, ok)
+	assert.Equal(t, float64(1), deferredCompactions.Get())
+	releaseFlush()
+	releaseCompaction, ok = c.TryAcquireCompaction()
+	assert.True(t, ok)
+	releaseCompaction()
+
+	// manual compaction waits until other operation completes or ctx done
+	releaseFlush = c.AcquireFlush()
+	ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Millisecond)
+	defer cancel()
+	_, err := c.AcquireCompaction(ctx)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	releaseFlush()
+	releaseCompaction, err = c.AcquireCompaction(context.TODO())
+	assert.NoError(t, err)
+	releaseCompaction()
+}
@@ -18,9 +18,11 @@
 package kv
 
 import (
+	"context"
 	"fmt"
 	"path/filepath"
 	"sync"
+	"time"
 
 	"go.uber.org/atomic"
 
@@ -35,6 +37,7 @@ import (
 // for testing
 var (
 	newCompactJobFunc = newCompactJob
+	newRewriteJobFunc = newRewriteJob
 	removeDirFunc     = fileutil.RemoveDir
 )
 
@@ -48,6 +51,18 @@ type Family interface {
 	NewFlusher() Flusher
 	// GetSnapshot returns current version's snapshot
 	GetSnapshot() version.Snapshot
+	// Rewrite merges all files of family into new files by merger with the params, such as purging data,
+	// returns ErrFamilyBusy if other compaction is running.
+	Rewrite(params map[string]interface{}) error
+	// Compact compacts all level0 files of family immediately, waits until compaction completed or ctx done,
+	// waits running background compaction of family completed firstly, so that files are not compacted twice.
+	Compact(ctx context.Context) error
+	// EvictReaders closes the cached readers of family's files if no snapshot is in use,
+	// returns false if family is in use, readers are reopened on demand.
+	EvictReaders() bool
+	// AfterSync invokes fn after the data flushed before is synced to disk based on sync policy,
+	// invokes fn immediately if sync policy isn't interval.
+	AfterSync(fn func())
 	// familyInfo return family info
 	familyInfo() string
 
@@ -55,6 +70,10 @@ type Family interface {
 	getFamilyVersion() version.FamilyVersion
 	// commitEditLog persists edit logs into manifest file.
 	commitEditLog(editLog version.EditLog) bool
+	// commitFlushEditLog persists edit logs of flush into manifest file based on sync policy.
+	commitFlushEditLog(editLog version.EditLog) bool
+	// syncTable syncs the flushed table file based on sync policy.
+	syncTable(fileNumber table.FileNumber) error
 	// newTableBuilder creates table builder instance for storing kv data.
 	newTableBuilder() (table.Builder, error)
 	// needCompact returns level0 files if need do compact job
@@ -148,6 +167,18 @@ func (f *family) GetSnapshot() version.Snapshot {
 	return f.familyVersion.GetSnapshot()
 }
 
+// EvictReaders closes the cached readers of family's files if no snapshot is in use,
+// returns false if family is in use, readers are reopened on demand.
+func (f *family) EvictReaders() bool {
+	return f.familyVersion.EvictReaders()
+}
+
+// AfterSync invokes fn after the data flushed before is synced to disk based on sync policy,
+// invokes fn immediately if sync policy isn't interval.
+func (f *family) AfterSync(fn func()) {
+	f.store.afterSync(fn)
+}
+
 // familyInfo return family info
 func (f *family) familyInfo() string {
 	return f.familyPath
@@ -163,17 +194,33 @@ func (f *family) newTableBuilder() (table.Builder, error) {
 // commitEditLog persists edit logs into manifest file.
 // returns true on committing successfully and false on failure
 func (f *family) commitEditLog(editLog version.EditLog) bool {
+	return f.commit(editLog, f.store.commitFamilyEditLog)
+}
+
+// commitFlushEditLog persists edit logs of flush into manifest file based on sync policy.
+// returns true on committing successfully and false on failure
+func (f *family) commitFlushEditLog(editLog version.EditLog) bool {
+	return f.commit(editLog, f.store.commitFlushEditLog)
+}
+
+// commit persists edit logs by commit function.
+func (f *family) commit(editLog version.EditLog, commitFn func(name string, editLog version.EditLog) error) bool {
 	if editLog == nil || editLog.IsEmpty() {
 		kvLogger.Warn("edit log is empty", logger.String("family", f.familyInfo()))
 		return true
 	}
-	if err := f.store.commitFamilyEditLog(f.name, editLog); err != nil {
+	if err := commitFn(f.name, editLog); err != nil {
 		kvLogger.Error("commit edit log error:", logger.String("family", f.familyInfo()), logger.Error(err))
 		return false
 	}
 	return true
 }
 
+// syncTable syncs the flushed table file based on sync policy.
+func (f *family) syncTable(fileNumber table.FileNumber) error {
+	return f.store.syncFile(filepath.Join(f.familyPath, version.Table(fileNumber)))
+}
+
 // needCompat returns level0 files if need do compact job
 func (f *family) needCompact() bool {
 	// has compaction job doing
@@ -225,6 +272,16 @@ func (f *family) backgroundCompactionJob() error {
 		// no compaction job need to do
 		return nil
 	}
+	if coordinator := f.store.Option().Coordinator; coordinator != nil {
+		release, ok := coordinator.TryAcquireCompaction()
+		if !ok {
+			// other heavy background operation is running, compact in next check cycle
+			kvLogger.Info("defer compact job, because other background operation is running",
+				logger.String("family", f.familyInfo()))
+			return nil
+		}
+		defer release()
+	}
 	compactionState := newCompactionState(f.maxFileSize, snapshot, compaction)
 	compactJob := f.newCompactJobFunc(f, compactionState, nil)
 	if err := compactJob.Run(); err != nil {
@@ -233,6 +290,93 @@ func (f *family) backgroundCompactionJob() error {
 	return nil
 }
 
+// Rewrite merges all files of family(level0 and level1) into level1 by merger with the params,
+// returns ErrFamilyBusy if other compaction is running.
+func (f *family) Rewrite(params map[string]interface{}) error {
+	if !f.compacting.CAS(false, true) {
+		return ErrFamilyBusy
+	}
+	defer f.compacting.Store(false)
+
+	snapshot := f.GetSnapshot()
+	defer func() {
+		snapshot.Close()
+		// clean up unused files, maybe some file not used
+		f.deleteObsoleteFiles()
+	}()
+	current := snapshot.GetCurrent()
+	levelInputs := current.GetFiles(0)
+	levelUpInputs := current.GetFiles(1)
+	if len(levelInputs) == 0 && len(levelUpInputs) == 0 {
+		// no data need to rewrite
+		return nil
+	}
+	if coordinator := f.store.Option().Coordinator; coordinator != nil {
+		release, ok := coordinator.TryAcquireCompaction()
+		if !ok {
+			return ErrFamilyBusy
+		}
+		defer release()
+	}
+	kvLogger.Info("starting rewrite job", logger.String("family", f.familyInfo()),
+		logger.Int32("files", int32(len(levelInputs)+len(levelUpInputs))))
+	compaction := version.NewCompaction(f.ID(), 0, levelInputs, levelUpInputs)
+	compactionState := newCompactionState(f.maxFileSize, snapshot, compaction)
+	return newRewriteJobFunc(f, compactionState, params).Run()
+}
+
+// Compact compacts all level0 files of family immediately, waits until compaction completed or ctx done,
+// waits running background compaction of family completed firstly, so that files are not compacted twice.
+// If ctx done, the started compaction job keeps running in background.
+func (f *family) Compact(ctx context.Context) error {
+	ticker := time.NewTicker(compactWaitInterval)
+	defer ticker.Stop()
+	for !f.compacting.CAS(false, true) {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	done := make(chan error, 1)
+	go func() {
+		defer f.compacting.Store(false)
+		done <- f.manualCompactionJob(ctx)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// manualCompactionJob compacts all level0 files of family, waits other heavy background operation completed.
+func (f *family) manualCompactionJob(ctx context.Context) error {
+	if coordinator := f.store.Option().Coordinator; coordinator != nil {
+		release, err := coordinator.AcquireCompaction(ctx)
+		if err != nil {
+			return err
+		}
+		defer release()
+	}
+	snapshot := f.GetSnapshot()
+	defer func() {
+		snapshot.Close()
+		// clean up unused files, maybe some file not used
+		f.deleteObsoleteFiles()
+	}()
+	compaction := snapshot.GetCurrent().PickL0Compaction(1)
+	if compaction == nil {
+		// no level0 files
+		return nil
+	}
+	kvLogger.Info("starting manual compact job", logger.String("family", f.familyInfo()),
+		logger.Int32("files", int32(len(compaction.GetLevelFiles()))))
+	compactionState := newCompactionState(f.maxFileSize, snapshot, compaction)
+	return f.newCompactJobFunc(f, compactionState, nil).Run()
+}
+
 // addPendingOutput add a file which current writing file number
 func (f *family) addPendingOutput(fileNumber table.FileNumber) {
 	f.pendingOutputs.Store(fileNumber, dummy)
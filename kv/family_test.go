@@ -18,6 +18,7 @@
 package kv
 
 import (
+	"context"
 	"fmt"
 	"path/filepath"
 	"testing"
@@ -26,6 +27,7 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/lindb/lindb/internal/linmetric"
 	"github.com/lindb/lindb/kv/table"
 	"github.com/lindb/lindb/kv/version"
 	"github.com/lindb/lindb/pkg/fileutil"
@@ -34,6 +36,26 @@ import (
 
 func init() {
 	RegisterMerger("mockMerger", newMockMerger)
+	RegisterMerger("mockDropMerger", func(flusher Flusher) (Merger, error) {
+		return &mockDropMerger{mockAppendMerger: mockAppendMerger{flusher: flusher}}, nil
+	})
+}
+
+// mockDropMerger drops the key of params when merging.
+type mockDropMerger struct {
+	mockAppendMerger
+	dropKey uint32
+}
+
+func (m *mockDropMerger) Init(params map[string]interface{}) {
+	m.dropKey = params["dropKey"].(uint32)
+}
+
+func (m *mockDropMerger) Merge(key uint32, values [][]byte) error {
+	if key == m.dropKey {
+		return nil
+	}
+	return m.mockAppendMerger.Merge(key, values)
 }
 
 func TestFamily_New(t *testing.T) {
@@ -100,6 +122,150 @@ func TestFamily_Data_Write_Read(t *testing.T) {
 	snapshot.Close()
 }
 
+func TestFamily_Rewrite(t *testing.T) {
+	testKVPath := filepath.Join(t.TempDir(), "test_data")
+	kv, err := NewStore("test_kv", DefaultStoreOption(testKVPath))
+	assert.NoError(t, err)
+	defer func() {
+		_ = kv.Close()
+	}()
+	f, err := kv.CreateFamily("f", FamilyOption{Merger: "mockDropMerger"})
+	assert.NoError(t, err)
+	// case 1: empty family
+	assert.NoError(t, f.Rewrite(map[string]interface{}{"dropKey": uint32(1)}))
+
+	flusher := f.NewFlusher()
+	assert.NoError(t, flusher.Add(1, []byte("test")))
+	assert.NoError(t, flusher.Add(10, []byte("test10")))
+	assert.NoError(t, flusher.Commit())
+	// case 2: family is compacting
+	f1 := f.(*family)
+	f1.compacting.Store(true)
+	assert.Equal(t, ErrFamilyBusy, f.Rewrite(map[string]interface{}{"dropKey": uint32(1)}))
+	f1.compacting.Store(false)
+	// case 3: rewrite one file, drop key 1
+	assert.NoError(t, f.Rewrite(map[string]interface{}{"dropKey": uint32(1)}))
+	snapshot := f.GetSnapshot()
+	assert.Zero(t, snapshot.GetCurrent().NumberOfFilesInLevel(0))
+	readers, err := snapshot.FindReaders(1)
+	assert.NoError(t, err)
+	assert.Empty(t, readers)
+	readers, err = snapshot.FindReaders(10)
+	assert.NoError(t, err)
+	assert.Len(t, readers, 1)
+	value, err := readers[0].Get(10)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("test10"), value)
+	snapshot.Close()
+}
+
+func TestFamily_Rewrite_coordinated(t *testing.T) {
+	testKVPath := filepath.Join(t.TempDir(), "test_data")
+	option := DefaultStoreOption(testKVPath)
+	option.Coordinator = NewCoordinator(
+		linmetric.NewScope("rewrite_test").NewHistogram(),
+		linmetric.NewScope("rewrite_test").NewCounter("deferred"))
+	kv, err := NewStore("test_kv", option)
+	assert.NoError(t, err)
+	defer func() {
+		_ = kv.Close()
+	}()
+	f, err := kv.CreateFamily("f", FamilyOption{Merger: "mockDropMerger"})
+	assert.NoError(t, err)
+	flusher := f.NewFlusher()
+	assert.NoError(t, flusher.Add(1, []byte("test")))
+	assert.NoError(t, flusher.Commit())
+
+	release, ok := option.Coordinator.TryAcquireCompaction()
+	assert.True(t, ok)
+	assert.Equal(t, ErrFamilyBusy, f.Rewrite(map[string]interface{}{"dropKey": uint32(1)}))
+	release()
+	assert.NoError(t, f.Rewrite(map[string]interface{}{"dropKey": uint32(1)}))
+}
+
+func TestFamily_Compact(t *testing.T) {
+	testKVPath := filepath.Join(t.TempDir(), "test_data")
+	option := DefaultStoreOption(testKVPath)
+	option.Coordinator = NewCoordinator(
+		linmetric.NewScope("compact_test").NewHistogram(),
+		linmetric.NewScope("compact_test").NewCounter("deferred"))
+	kv, err := NewStore("test_kv", option)
+	assert.NoError(t, err)
+	defer func() {
+		_ = kv.Close()
+	}()
+	f, err := kv.CreateFamily("f", FamilyOption{Merger: "mockMerger", CompactThreshold: 100})
+	assert.NoError(t, err)
+	// case 1: empty family
+	assert.NoError(t, f.Compact(context.TODO()))
+	// case 2: compact multiple small files into one file
+	for i := uint32(1); i <= 3; i++ {
+		flusher := f.NewFlusher()
+		assert.NoError(t, flusher.Add(i, []byte("test")))
+		assert.NoError(t, flusher.Commit())
+	}
+	snapshot := f.GetSnapshot()
+	assert.Equal(t, 3, snapshot.GetCurrent().NumberOfFilesInLevel(0))
+	snapshot.Close()
+	// case 3: background compaction is running, wait until timeout
+	f1 := f.(*family)
+	f1.compacting.Store(true)
+	ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Millisecond)
+	assert.Equal(t, context.DeadlineExceeded, f.Compact(ctx))
+	cancel()
+	f1.compacting.Store(false)
+	// case 4: other background operation is running, wait until timeout
+	release, ok := option.Coordinator.TryAcquireCompaction()
+	assert.True(t, ok)
+	ctx, cancel = context.WithTimeout(context.TODO(), 10*time.Millisecond)
+	assert.Equal(t, context.DeadlineExceeded, f.Compact(ctx))
+	cancel()
+	release()
+	// case 5: compact successfully
+	assert.NoError(t, f.Compact(context.TODO()))
+	snapshot = f.GetSnapshot()
+	assert.Zero(t, snapshot.GetCurrent().NumberOfFilesInLevel(0))
+	assert.Equal(t, 1, snapshot.GetCurrent().NumberOfFilesInLevel(1))
+	for i := uint32(1); i <= 3; i++ {
+		readers, err := snapshot.FindReaders(i)
+		assert.NoError(t, err)
+		assert.Len(t, readers, 1)
+	}
+	snapshot.Close()
+}
+
+func TestFamily_EvictReaders(t *testing.T) {
+	testKVPath := filepath.Join(t.TempDir(), "test_data")
+	kv, err := NewStore("test_kv", DefaultStoreOption(testKVPath))
+	assert.NoError(t, err)
+	defer func() {
+		_ = kv.Close()
+	}()
+	f, err := kv.CreateFamily("f", FamilyOption{Merger: "mockMerger"})
+	assert.NoError(t, err)
+	flusher := f.NewFlusher()
+	assert.NoError(t, flusher.Add(1, []byte("test")))
+	assert.NoError(t, flusher.Commit())
+
+	snapshot := f.GetSnapshot()
+	readers, err := snapshot.FindReaders(1)
+	assert.NoError(t, err)
+	assert.Len(t, readers, 1)
+	// case 1: family in use
+	assert.False(t, f.EvictReaders())
+	snapshot.Close()
+	// case 2: close readers, then reopen on demand
+	assert.True(t, f.EvictReaders())
+	snapshot = f.GetSnapshot()
+	defer snapshot.Close()
+	readers, err = snapshot.FindReaders(1)
+	assert.NoError(t, err)
+	assert.Len(t, readers, 1)
+	value, err := readers[0].Get(1)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("test"), value)
+}
+
 func TestFamily_commitEditLog(t *testing.T) {
 	testKVPath := t.TempDir()
 	ctrl := gomock.NewController(t)
@@ -272,3 +438,52 @@ func TestFamily_deleteObsoleteFiles(t *testing.T) {
 	}
 	f1.deleteObsoleteFiles()
 }
+
+func TestFamily_compact_coordinated(t *testing.T) {
+	testKVPath := t.TempDir()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	coordinator := NewCoordinator(
+		linmetric.NewScope("kv_family_test").Scope("flush_wait").NewHistogram(),
+		linmetric.NewScope("kv_family_test").NewCounter("deferred_compactions"))
+	option := DefaultStoreOption(testKVPath)
+	option.Coordinator = coordinator
+	store := NewMockStore(ctrl)
+	store.EXPECT().Option().Return(option).AnyTimes()
+	fv := version.NewMockFamilyVersion(ctrl)
+	snapshot := version.NewMockSnapshot(ctrl)
+	v := version.NewMockVersion(ctrl)
+	snapshot.EXPECT().Close().AnyTimes()
+	snapshot.EXPECT().GetCurrent().Return(v).AnyTimes()
+	fv.EXPECT().GetSnapshot().Return(snapshot).AnyTimes()
+	store.EXPECT().createFamilyVersion(gomock.Any(), gomock.Any()).Return(fv)
+	f, err := newFamily(store, FamilyOption{Merger: "mockMerger"})
+	assert.NoError(t, err)
+	fv.EXPECT().GetAllActiveFiles().Return(nil).AnyTimes()
+	fv.EXPECT().GetLiveRollupFiles().Return(nil).AnyTimes()
+	v.EXPECT().PickL0Compaction(gomock.Any()).
+		Return(version.NewCompaction(1, 0, nil, nil)).AnyTimes()
+	f1 := f.(*family)
+	compactJob := NewMockCompactJob(ctrl)
+	f1.newCompactJobFunc = func(family Family, state *compactionState, rollup Rollup) CompactJob {
+		return compactJob
+	}
+	// case 1: flush is running, compaction is deferred
+	release := coordinator.AcquireFlush()
+	err = f1.backgroundCompactionJob()
+	assert.NoError(t, err)
+	release()
+	// case 2: compaction runs, holds coordinator
+	compactJob.EXPECT().Run().DoAndReturn(func() error {
+		_, ok := coordinator.TryAcquireCompaction()
+		assert.False(t, ok)
+		return nil
+	})
+	err = f1.backgroundCompactionJob()
+	assert.NoError(t, err)
+	// coordinator is released after compaction
+	release, ok := coordinator.TryAcquireCompaction()
+	assert.True(t, ok)
+	release()
+}
@@ -107,6 +107,10 @@ func (sf *storeFlusher) Commit() (err error) {
 			err = fmt.Errorf("close table builder error when flush commit, error:%s", err)
 			return err
 		}
+		if err := sf.family.syncTable(builder.FileNumber()); err != nil {
+			err = fmt.Errorf("sync table file error when flush commit, error:%s", err)
+			return err
+		}
 
 		fileMeta := version.NewFileMeta(builder.FileNumber(), builder.MinKey(), builder.MaxKey(), builder.Size())
 		sf.editLog.Add(version.CreateNewFile(0, fileMeta))
@@ -116,7 +120,7 @@ func (sf *storeFlusher) Commit() (err error) {
 		sf.editLog.Add(version.CreateSequence(leader, seq))
 	}
 
-	if flag := sf.family.commitEditLog(sf.editLog); !flag {
+	if flag := sf.family.commitFlushEditLog(sf.editLog); !flag {
 		err = fmt.Errorf("commit edit log failure")
 		return err
 	}
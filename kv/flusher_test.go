@@ -74,7 +74,7 @@ func TestStoreFlusher_Commit(t *testing.T) {
 	family := NewMockFamily(ctrl)
 	gomock.InOrder(
 		family.EXPECT().ID().Return(version.FamilyID(10)),
-		family.EXPECT().commitEditLog(gomock.Any()).Return(false),
+		family.EXPECT().commitFlushEditLog(gomock.Any()).Return(false),
 	)
 	flusher := newStoreFlusher(family)
 	err := flusher.Commit()
@@ -84,7 +84,7 @@ func TestStoreFlusher_Commit(t *testing.T) {
 	family = NewMockFamily(ctrl)
 	gomock.InOrder(
 		family.EXPECT().ID().Return(version.FamilyID(10)),
-		family.EXPECT().commitEditLog(gomock.Any()).Return(true),
+		family.EXPECT().commitFlushEditLog(gomock.Any()).Return(true),
 	)
 	flusher = newStoreFlusher(family)
 	flusher.Sequence(1, 10)
@@ -105,14 +105,31 @@ func TestStoreFlusher_Commit(t *testing.T) {
 	err = flusher.Commit()
 	assert.Error(t, err)
 
+	// sync table file err
 	gomock.InOrder(
 		family.EXPECT().ID().Return(version.FamilyID(10)),
 		builder.EXPECT().Close().Return(nil),
 		builder.EXPECT().FileNumber().Return(table.FileNumber(10)),
+		family.EXPECT().syncTable(table.FileNumber(10)).Return(fmt.Errorf("err")),
+		builder.EXPECT().FileNumber().Return(table.FileNumber(10)),
+		family.EXPECT().removePendingOutput(table.FileNumber(10)),
+	)
+	flusher = newStoreFlusher(family)
+	f = flusher.(*storeFlusher)
+	f.builder = builder
+	err = flusher.Commit()
+	assert.Error(t, err)
+
+	gomock.InOrder(
+		family.EXPECT().ID().Return(version.FamilyID(10)),
+		builder.EXPECT().Close().Return(nil),
+		builder.EXPECT().FileNumber().Return(table.FileNumber(10)),
+		family.EXPECT().syncTable(table.FileNumber(10)).Return(nil),
+		builder.EXPECT().FileNumber().Return(table.FileNumber(10)),
 		builder.EXPECT().MinKey().Return(uint32(1)),
 		builder.EXPECT().MaxKey().Return(uint32(10)),
 		builder.EXPECT().Size().Return(uint32(100)),
-		family.EXPECT().commitEditLog(gomock.Any()).Return(false),
+		family.EXPECT().commitFlushEditLog(gomock.Any()).Return(false),
 		builder.EXPECT().FileNumber().Return(table.FileNumber(10)),
 		family.EXPECT().removePendingOutput(table.FileNumber(10)),
 	)
@@ -126,10 +143,12 @@ func TestStoreFlusher_Commit(t *testing.T) {
 		family.EXPECT().ID().Return(version.FamilyID(10)),
 		builder.EXPECT().Close().Return(nil),
 		builder.EXPECT().FileNumber().Return(table.FileNumber(10)),
+		family.EXPECT().syncTable(table.FileNumber(10)).Return(nil),
+		builder.EXPECT().FileNumber().Return(table.FileNumber(10)),
 		builder.EXPECT().MinKey().Return(uint32(1)),
 		builder.EXPECT().MaxKey().Return(uint32(10)),
 		builder.EXPECT().Size().Return(uint32(100)),
-		family.EXPECT().commitEditLog(gomock.Any()).Return(true),
+		family.EXPECT().commitFlushEditLog(gomock.Any()).Return(true),
 		builder.EXPECT().FileNumber().Return(table.FileNumber(10)),
 		family.EXPECT().removePendingOutput(table.FileNumber(10)),
 	)
@@ -17,6 +17,12 @@
 
 package kv
 
+import (
+	"time"
+
+	"github.com/lindb/lindb/kv/table"
+)
+
 // FamilyOption defines config items for family level
 type FamilyOption struct {
 	ID               int    `toml:"id"`
@@ -33,6 +39,12 @@ type StoreOption struct {
 	Levels               int    `toml:"levels"`               // num. of levels
 	CompactCheckInterval int    `toml:"compactCheckInterval"` // compact job check interval(number of seconds)
 	RollupCheckInterval  int    `toml:"rollupCheckInterval"`  // rollup job check interval(number of seconds)
+
+	ReadStrategy string           `toml:"-"` // strategy for reading sst files(mmap/pread), mmap if empty
+	BlockCache   table.BlockCache `toml:"-"` // cache for value blocks read from sst files, nil means disabled
+	Coordinator  Coordinator      `toml:"-"` // coordinates flush/compaction with others, nil means no coordination
+	SyncPolicy   string           `toml:"-"` // policy for syncing flushed files(always/interval/none), always if empty
+	SyncInterval time.Duration    `toml:"-"` // interval for syncing flushed files if sync policy is interval
 }
 
 // DefaultStoreOption builds default store option
@@ -72,6 +72,13 @@ type Store interface {
 	nextFileNumber() table.FileNumber
 	// commitFamilyEditLog persists edit logs to manifest file, then apply new version to family version
 	commitFamilyEditLog(name string, editLog version.EditLog) error
+	// commitFlushEditLog persists edit logs of flush to manifest file based on sync policy,
+	// then apply new version to family version
+	commitFlushEditLog(name string, editLog version.EditLog) error
+	// syncFile syncs the flushed file based on sync policy
+	syncFile(file string) error
+	// afterSync invokes fn after the flushed files and manifest are synced based on sync policy
+	afterSync(fn func())
 	// evictFamilyFile evicts family file reader from cache
 	evictFamilyFile(name string, fileNumber table.FileNumber)
 	// getRollup returns the rollup relation by interval
@@ -93,6 +100,7 @@ type store struct {
 
 	storeInfo *storeInfo
 	cache     table.Cache
+	syncer    *flushSyncer
 
 	rollupRelations map[timeutil.Interval]Rollup // save target kv store for rollup job
 
@@ -151,9 +159,10 @@ func NewStore(name string, option StoreOption) (s Store, err error) {
 	}()
 
 	// build store reader cache
-	store1.cache = table.NewCache(store1.option.Path)
+	store1.cache = table.NewCache(store1.option.Path, store1.option.ReadStrategy, store1.option.BlockCache)
 	// init version set
 	store1.versions = newVersionSetFunc(store1.option.Path, store1.cache, store1.option.Levels)
+	store1.syncer = newFlushSyncer(store1.option.SyncPolicy, store1.versions)
 
 	if isCreate {
 		// if store is new created, need dump store info to INFO file
@@ -181,6 +190,9 @@ func NewStore(name string, option StoreOption) (s Store, err error) {
 
 	// schedule compact job
 	store1.scheduleCompactJob()
+	if store1.syncer.policy == SyncPolicyInterval {
+		store1.scheduleSyncJob()
+	}
 	return store1, nil
 }
 
@@ -269,6 +281,13 @@ func (s *store) Close() error {
 	if err := s.cache.Close(); err != nil {
 		kvLogger.Error("close store cache error", logger.String("store", s.option.Path), logger.Error(err))
 	}
+	if s.syncer != nil && s.syncer.policy == SyncPolicyInterval {
+		// sync the flushes of last interval
+		if err := s.syncer.syncAll(); err != nil {
+			kvLogger.Error("sync flushed files error when close store",
+				logger.String("store", s.option.Path), logger.Error(err))
+		}
+	}
 	if err := s.versions.Destroy(); err != nil {
 		kvLogger.Error("destroy store version set error",
 			logger.String("store", s.option.Path), logger.Error(err))
@@ -301,7 +320,23 @@ func (s *store) nextFileNumber() table.FileNumber {
 
 // commitFamilyEditLog persists edit logs to manifest file, then apply new version to family version
 func (s *store) commitFamilyEditLog(name string, editLog version.EditLog) error {
-	return s.versions.CommitFamilyEditLog(name, editLog)
+	return s.syncer.commitSyncedEditLog(name, editLog)
+}
+
+// commitFlushEditLog persists edit logs of flush to manifest file based on sync policy,
+// then apply new version to family version
+func (s *store) commitFlushEditLog(name string, editLog version.EditLog) error {
+	return s.syncer.commitEditLog(name, editLog)
+}
+
+// syncFile syncs the flushed file based on sync policy
+func (s *store) syncFile(file string) error {
+	return s.syncer.syncFile(file)
+}
+
+// afterSync invokes fn after the flushed files and manifest are synced based on sync policy
+func (s *store) afterSync(fn func()) {
+	s.syncer.afterSync(fn)
 }
 
 // dumpStoreInfo persists store info to OPTIONS file
@@ -334,6 +369,29 @@ func (s *store) scheduleCompactJob() {
 	}()
 }
 
+// scheduleSyncJob schedules a background job which syncs the flushed files and manifest in batch
+func (s *store) scheduleSyncJob() {
+	interval := defaultSyncInterval
+	if s.option.SyncInterval > 0 {
+		interval = s.option.SyncInterval
+	}
+	ticker := time.NewTicker(interval)
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				if err := s.syncer.sync(); err != nil {
+					kvLogger.Error("sync flushed files error, retry next time",
+						logger.String("store", s.option.Path), logger.Error(err))
+				}
+			case <-s.ctx.Done():
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+}
+
 // compact checks if family need do compact, if need, does compaction job
 func (s *store) compact() {
 	s.rwMutex.RLock()
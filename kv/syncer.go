@@ -0,0 +1,222 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package kv
+
+import (
+	"os"
+	"sync"
+
+	"github.com/lindb/lindb/kv/version"
+	"github.com/lindb/lindb/pkg/fileutil"
+)
+
+const (
+	// SyncPolicyAlways syncs the flushed file and manifest on every flush,
+	// flushed data survives power failure once flush returns.
+	SyncPolicyAlways = "always"
+	// SyncPolicyInterval syncs the flushed files and manifest in batch every sync interval(group commit),
+	// flushes of the last interval may be lost on power failure.
+	SyncPolicyInterval = "interval"
+	// SyncPolicyNone never syncs on flush, the OS writes back dirty pages in its own pace,
+	// flushes not written back by OS may be lost on power failure.
+	SyncPolicyNone = "none"
+)
+
+// for testing
+var (
+	syncFileFunc = fileutil.SyncFile
+)
+
+// flushSyncer syncs the files and manifest written by flush based on sync policy.
+// Compaction/rollup don't go through it, because the input files are deleted after committing.
+type flushSyncer struct {
+	policy   string
+	versions version.StoreVersionSet
+
+	pendingFiles  []string // flushed files waiting for sync
+	dirtyManifest bool     // manifest has edit logs waiting for sync
+	afterSyncFns  []func() // invoked after the flushed files and manifest synced
+
+	mutex sync.Mutex
+}
+
+// newFlushSyncer creates the flush syncer, the policy is always if empty.
+func newFlushSyncer(policy string, versions version.StoreVersionSet) *flushSyncer {
+	if policy == "" {
+		policy = SyncPolicyAlways
+	}
+	return &flushSyncer{
+		policy:   policy,
+		versions: versions,
+	}
+}
+
+// syncFile syncs the flushed file based on sync policy.
+func (s *flushSyncer) syncFile(file string) error {
+	switch s.policy {
+	case SyncPolicyAlways:
+		return syncFileFunc(file)
+	case SyncPolicyInterval:
+		s.mutex.Lock()
+		s.pendingFiles = append(s.pendingFiles, file)
+		s.mutex.Unlock()
+	}
+	return nil
+}
+
+// commitEditLog persists the edit log of flush into manifest, then syncs manifest based on sync policy.
+func (s *flushSyncer) commitEditLog(family string, editLog version.EditLog) error {
+	switch s.policy {
+	case SyncPolicyAlways:
+		return s.versions.CommitFamilyEditLog(family, editLog)
+	case SyncPolicyInterval:
+		// hold the lock, make sure manifest is never synced before the files it refers
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		if err := s.versions.AppendFamilyEditLog(family, editLog); err != nil {
+			return err
+		}
+		s.dirtyManifest = true
+		return nil
+	default:
+		return s.versions.AppendFamilyEditLog(family, editLog)
+	}
+}
+
+// commitSyncedEditLog persists the edit log of compaction/rollup into manifest, then syncs manifest.
+// If policy is interval, syncs the pending files firstly, because manifest includes the edit logs of
+// flushes which refer them.
+func (s *flushSyncer) commitSyncedEditLog(family string, editLog version.EditLog) error {
+	if s.policy != SyncPolicyInterval {
+		return s.versions.CommitFamilyEditLog(family, editLog)
+	}
+	s.mutex.Lock()
+	remaining, err := syncFiles(s.pendingFiles)
+	s.pendingFiles = remaining
+	if err != nil {
+		s.mutex.Unlock()
+		return err
+	}
+	if err := s.versions.CommitFamilyEditLog(family, editLog); err != nil {
+		s.mutex.Unlock()
+		return err
+	}
+	s.dirtyManifest = false
+	fns := s.takeAfterSyncFns()
+	s.mutex.Unlock()
+
+	invokeAll(fns)
+	return nil
+}
+
+// afterSync invokes fn after the data flushed before is synced if policy is interval, else invokes it immediately,
+// because flushed data is synced on flush(always) or never synced(none).
+func (s *flushSyncer) afterSync(fn func()) {
+	if s.policy != SyncPolicyInterval {
+		fn()
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.afterSyncFns = append(s.afterSyncFns, fn)
+}
+
+// takeAfterSyncFns takes the functions waiting for sync, must be invoked with lock held.
+func (s *flushSyncer) takeAfterSyncFns() []func() {
+	fns := s.afterSyncFns
+	s.afterSyncFns = nil
+	return fns
+}
+
+// sync syncs the pending files, then the manifest, invoked every sync interval if policy is interval.
+func (s *flushSyncer) sync() error {
+	// sync most of pending files without blocking flush
+	s.mutex.Lock()
+	files := s.pendingFiles
+	s.pendingFiles = nil
+	s.mutex.Unlock()
+	if remaining, err := syncFiles(files); err != nil {
+		s.mutex.Lock()
+		s.pendingFiles = append(remaining, s.pendingFiles...)
+		s.mutex.Unlock()
+		return err
+	}
+
+	// sync manifest with lock held, make sure manifest is never synced before the files it refers,
+	// if some files are flushed meanwhile, sync manifest next time.
+	s.mutex.Lock()
+	if len(s.pendingFiles) > 0 {
+		s.mutex.Unlock()
+		return nil
+	}
+	if s.dirtyManifest {
+		if err := s.versions.SyncManifest(); err != nil {
+			s.mutex.Unlock()
+			return err
+		}
+		s.dirtyManifest = false
+	}
+	// all flushes registered functions before are synced
+	fns := s.takeAfterSyncFns()
+	s.mutex.Unlock()
+
+	invokeAll(fns)
+	return nil
+}
+
+// syncAll syncs all pending files and manifest with lock held, invoked when closing store.
+func (s *flushSyncer) syncAll() error {
+	s.mutex.Lock()
+	remaining, err := syncFiles(s.pendingFiles)
+	s.pendingFiles = remaining
+	if err != nil {
+		s.mutex.Unlock()
+		return err
+	}
+	if s.dirtyManifest {
+		if err := s.versions.SyncManifest(); err != nil {
+			s.mutex.Unlock()
+			return err
+		}
+		s.dirtyManifest = false
+	}
+	fns := s.takeAfterSyncFns()
+	s.mutex.Unlock()
+
+	invokeAll(fns)
+	return nil
+}
+
+// invokeAll invokes the functions waiting for sync in order.
+func invokeAll(fns []func()) {
+	for _, fn := range fns {
+		fn()
+	}
+}
+
+// syncFiles syncs files in order, returns the files not synced if fail.
+func syncFiles(files []string) (remaining []string, err error) {
+	for idx, file := range files {
+		// file may be removed by compaction already
+		if err := syncFileFunc(file); err != nil && !os.IsNotExist(err) {
+			return files[idx:], err
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,351 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package kv
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/kv/version"
+	"github.com/lindb/lindb/pkg/fileutil"
+)
+
+func TestFlushSyncer_Policy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		syncFileFunc = fileutil.SyncFile
+		ctrl.Finish()
+	}()
+	var syncedFiles []string
+	syncFileFunc = func(file string) error {
+		syncedFiles = append(syncedFiles, file)
+		return nil
+	}
+	versions := version.NewMockStoreVersionSet(ctrl)
+	editLog := version.NewEditLog(1)
+
+	// always, default policy
+	syncer := newFlushSyncer("", versions)
+	assert.Equal(t, SyncPolicyAlways, syncer.policy)
+	assert.NoError(t, syncer.syncFile("1.sst"))
+	assert.Equal(t, []string{"1.sst"}, syncedFiles)
+	versions.EXPECT().CommitFamilyEditLog("f", editLog).Return(nil)
+	assert.NoError(t, syncer.commitEditLog("f", editLog))
+
+	// none, never sync
+	syncedFiles = nil
+	syncer = newFlushSyncer(SyncPolicyNone, versions)
+	assert.NoError(t, syncer.syncFile("1.sst"))
+	versions.EXPECT().AppendFamilyEditLog("f", editLog).Return(nil)
+	assert.NoError(t, syncer.commitEditLog("f", editLog))
+	assert.NoError(t, syncer.sync())
+	assert.Empty(t, syncedFiles)
+
+	// interval, sync in batch, files first then manifest
+	syncer = newFlushSyncer(SyncPolicyInterval, versions)
+	assert.NoError(t, syncer.sync())
+	assert.NoError(t, syncer.syncFile("1.sst"))
+	assert.NoError(t, syncer.syncFile("2.sst"))
+	versions.EXPECT().AppendFamilyEditLog("f", editLog).Return(nil).Times(2)
+	assert.NoError(t, syncer.commitEditLog("f", editLog))
+	assert.NoError(t, syncer.commitEditLog("f", editLog))
+	assert.Empty(t, syncedFiles)
+	versions.EXPECT().SyncManifest().Return(nil)
+	assert.NoError(t, syncer.sync())
+	assert.Equal(t, []string{"1.sst", "2.sst"}, syncedFiles)
+	// nothing to sync
+	assert.NoError(t, syncer.sync())
+	assert.Len(t, syncedFiles, 2)
+}
+
+func TestFlushSyncer_Interval_CommitSynced(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		syncFileFunc = fileutil.SyncFile
+		ctrl.Finish()
+	}()
+	var synced []string
+	syncFileFunc = func(file string) error {
+		synced = append(synced, file)
+		return nil
+	}
+	versions := version.NewMockStoreVersionSet(ctrl)
+	editLog := version.NewEditLog(1)
+
+	// not interval, commits directly
+	syncer := newFlushSyncer(SyncPolicyNone, versions)
+	versions.EXPECT().CommitFamilyEditLog("f", editLog).Return(nil)
+	assert.NoError(t, syncer.commitSyncedEditLog("f", editLog))
+	acked := 0
+	syncer.afterSync(func() { acked++ })
+	assert.Equal(t, 1, acked)
+
+	// interval, pending files are synced before manifest synced by compaction's commit
+	syncer = newFlushSyncer(SyncPolicyInterval, versions)
+	assert.NoError(t, syncer.syncFile("1.sst"))
+	versions.EXPECT().AppendFamilyEditLog("f", editLog).Return(nil)
+	assert.NoError(t, syncer.commitEditLog("f", editLog))
+	syncer.afterSync(func() { acked++ })
+	assert.Equal(t, 1, acked)
+	versions.EXPECT().CommitFamilyEditLog("f", editLog).DoAndReturn(func(_ string, _ version.EditLog) error {
+		synced = append(synced, "MANIFEST")
+		return nil
+	})
+	assert.NoError(t, syncer.commitSyncedEditLog("f", editLog))
+	assert.Equal(t, []string{"1.sst", "MANIFEST"}, synced)
+	assert.False(t, syncer.dirtyManifest)
+	assert.Equal(t, 2, acked)
+
+	// sync file err, not commit
+	syncFileFunc = func(file string) error {
+		return fmt.Errorf("err")
+	}
+	assert.NoError(t, syncer.syncFile("2.sst"))
+	syncer.afterSync(func() { acked++ })
+	assert.Error(t, syncer.commitSyncedEditLog("f", editLog))
+	assert.Equal(t, []string{"2.sst"}, syncer.pendingFiles)
+	// commit err
+	syncFileFunc = func(file string) error {
+		return nil
+	}
+	versions.EXPECT().CommitFamilyEditLog("f", editLog).Return(fmt.Errorf("err"))
+	assert.Error(t, syncer.commitSyncedEditLog("f", editLog))
+	assert.Equal(t, 2, acked)
+	// acked after synced
+	assert.NoError(t, syncer.sync())
+	assert.Equal(t, 3, acked)
+}
+
+func TestFlushSyncer_Interval_AfterSync(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		syncFileFunc = fileutil.SyncFile
+		ctrl.Finish()
+	}()
+	versions := version.NewMockStoreVersionSet(ctrl)
+	editLog := version.NewEditLog(1)
+	syncer := newFlushSyncer(SyncPolicyInterval, versions)
+	var acked []int
+	flush := func(id int) {
+		assert.NoError(t, syncer.syncFile(fmt.Sprintf("%d.sst", id)))
+		assert.NoError(t, syncer.commitEditLog("f", editLog))
+		syncer.afterSync(func() { acked = append(acked, id) })
+	}
+	versions.EXPECT().AppendFamilyEditLog("f", editLog).Return(nil).AnyTimes()
+
+	// case 1: file flushed when syncing, not acked until manifest synced
+	syncFileFunc = func(file string) error {
+		if file == "1.sst" {
+			flush(2)
+		}
+		return nil
+	}
+	flush(1)
+	assert.NoError(t, syncer.sync())
+	assert.Empty(t, acked)
+	// case 2: sync manifest err
+	versions.EXPECT().SyncManifest().Return(fmt.Errorf("err"))
+	assert.Error(t, syncer.sync())
+	assert.Empty(t, acked)
+	// case 3: acked in order after synced
+	versions.EXPECT().SyncManifest().Return(nil)
+	assert.NoError(t, syncer.sync())
+	assert.Equal(t, []int{1, 2}, acked)
+	// case 4: acked when closing store
+	flush(3)
+	versions.EXPECT().SyncManifest().Return(nil)
+	assert.NoError(t, syncer.syncAll())
+	assert.Equal(t, []int{1, 2, 3}, acked)
+}
+
+func TestFlushSyncer_Interval_err(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		syncFileFunc = fileutil.SyncFile
+		ctrl.Finish()
+	}()
+	versions := version.NewMockStoreVersionSet(ctrl)
+	editLog := version.NewEditLog(1)
+	syncer := newFlushSyncer(SyncPolicyInterval, versions)
+
+	// commit err
+	versions.EXPECT().AppendFamilyEditLog("f", editLog).Return(fmt.Errorf("err"))
+	assert.Error(t, syncer.commitEditLog("f", editLog))
+	assert.False(t, syncer.dirtyManifest)
+
+	// file removed by compaction
+	syncFileFunc = func(file string) error {
+		return os.ErrNotExist
+	}
+	assert.NoError(t, syncer.syncFile("1.sst"))
+	assert.NoError(t, syncer.sync())
+	assert.Empty(t, syncer.pendingFiles)
+
+	// sync file err, retry next time
+	syncFileFunc = func(file string) error {
+		return fmt.Errorf("err")
+	}
+	assert.NoError(t, syncer.syncFile("2.sst"))
+	versions.EXPECT().AppendFamilyEditLog("f", editLog).Return(nil)
+	assert.NoError(t, syncer.commitEditLog("f", editLog))
+	assert.Error(t, syncer.sync())
+	assert.Equal(t, []string{"2.sst"}, syncer.pendingFiles)
+	assert.Error(t, syncer.syncAll())
+	assert.Equal(t, []string{"2.sst"}, syncer.pendingFiles)
+
+	// file flushed when syncing, sync manifest next time
+	syncFileFunc = func(file string) error {
+		if file == "2.sst" {
+			return syncer.syncFile("3.sst")
+		}
+		return nil
+	}
+	assert.NoError(t, syncer.sync())
+	assert.Equal(t, []string{"3.sst"}, syncer.pendingFiles)
+	assert.True(t, syncer.dirtyManifest)
+
+	// sync manifest err, retry next time
+	syncFileFunc = func(file string) error {
+		return nil
+	}
+	versions.EXPECT().SyncManifest().Return(fmt.Errorf("err"))
+	assert.Error(t, syncer.sync())
+	assert.Empty(t, syncer.pendingFiles)
+	assert.True(t, syncer.dirtyManifest)
+	versions.EXPECT().SyncManifest().Return(fmt.Errorf("err"))
+	assert.Error(t, syncer.syncAll())
+	versions.EXPECT().SyncManifest().Return(nil)
+	assert.NoError(t, syncer.sync())
+	assert.False(t, syncer.dirtyManifest)
+	assert.NoError(t, syncer.syncAll())
+}
+
+func TestStore_SyncPolicy(t *testing.T) {
+	defer func() {
+		syncFileFunc = fileutil.SyncFile
+	}()
+	var (
+		syncedFiles []string
+		mutex       sync.Mutex
+	)
+	syncFileFunc = func(file string) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+		syncedFiles = append(syncedFiles, filepath.Base(file))
+		return fileutil.SyncFile(file)
+	}
+	for _, policy := range []string{SyncPolicyAlways, SyncPolicyInterval, SyncPolicyNone} {
+		mutex.Lock()
+		syncedFiles = nil
+		mutex.Unlock()
+		option := DefaultStoreOption(filepath.Join(t.TempDir(), "test_data"))
+		option.SyncPolicy = policy
+		option.SyncInterval = time.Hour
+		store, err := NewStore("test_kv", option)
+		assert.NoError(t, err)
+		family, err := store.CreateFamily("f", FamilyOption{Merger: mergerStr})
+		assert.NoError(t, err)
+		flusher := family.NewFlusher()
+		assert.NoError(t, flusher.Add(1, []byte("value")))
+		assert.NoError(t, flusher.Commit())
+
+		mutex.Lock()
+		switch policy {
+		case SyncPolicyAlways:
+			assert.Len(t, syncedFiles, 1)
+		default:
+			assert.Empty(t, syncedFiles)
+		}
+		mutex.Unlock()
+		// flushed data is visible whatever the policy is
+		snapshot := family.GetSnapshot()
+		assert.Len(t, snapshot.GetCurrent().GetAllFiles(), 1)
+		snapshot.Close()
+
+		// pending files are synced when closing
+		assert.NoError(t, store.Close())
+		mutex.Lock()
+		switch policy {
+		case SyncPolicyNone:
+			assert.Empty(t, syncedFiles)
+		default:
+			assert.Len(t, syncedFiles, 1)
+		}
+		mutex.Unlock()
+
+		// reopen, data is recovered from manifest
+		store, err = NewStore("test_kv", option)
+		assert.NoError(t, err)
+		snapshot = store.GetFamily("f").GetSnapshot()
+		assert.Len(t, snapshot.GetCurrent().GetAllFiles(), 1)
+		snapshot.Close()
+		assert.NoError(t, store.Close())
+	}
+}
+
+// BenchmarkStore_Flush_SyncPolicy compares flush throughput of sync policies on a simulated slow disk,
+// which takes 2ms for each file sync.
+func BenchmarkStore_Flush_SyncPolicy(b *testing.B) {
+	defer func() {
+		syncFileFunc = fileutil.SyncFile
+	}()
+	syncFileFunc = func(file string) error {
+		time.Sleep(2 * time.Millisecond)
+		return fileutil.SyncFile(file)
+	}
+	value := make([]byte, 4096)
+	for _, policy := range []string{SyncPolicyAlways, SyncPolicyInterval, SyncPolicyNone} {
+		policy := policy
+		b.Run(policy, func(b *testing.B) {
+			option := DefaultStoreOption(filepath.Join(b.TempDir(), "test_data"))
+			option.SyncPolicy = policy
+			option.SyncInterval = 100 * time.Millisecond
+			store, err := NewStore("test_kv", option)
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer func() {
+				_ = store.Close()
+			}()
+			family, err := store.CreateFamily("f", FamilyOption{Merger: mergerStr})
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.SetBytes(int64(len(value)))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				flusher := family.NewFlusher()
+				if err := flusher.Add(1, value); err != nil {
+					b.Fatal(err)
+				}
+				if err := flusher.Commit(); err != nil {
+					b.Fatal(err)
+				}
+			}
+			// exclude syncing pending files when closing
+			b.StopTimer()
+		})
+	}
+}
@@ -0,0 +1,197 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package table
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/lindb/lindb/internal/linmetric"
+)
+
+//go:generate mockgen -source ./block_cache.go -destination=./block_cache_mock.go -package table
+
+// blockEntryOverhead is the approximate memory cost of each cached block besides its data.
+const blockEntryOverhead = 64
+
+var (
+	blockCacheScope     = linmetric.NewScope("lindb.kv.table.block_cache")
+	blockCacheHits      = blockCacheScope.NewCounter("hits")
+	blockCacheMisses    = blockCacheScope.NewCounter("misses")
+	blockCacheEvictions = blockCacheScope.NewCounter("evictions")
+	blockCacheSize      = blockCacheScope.NewGauge("size")
+)
+
+// BlockCache caches the value blocks read from sst files,
+// keeps recently scanned blocks in memory so that repeated queries do not read them from disk again.
+type BlockCache interface {
+	// Get returns the cached block of key in file.
+	Get(path string, key uint32) ([]byte, bool)
+	// Put caches the block of key in file.
+	Put(path string, key uint32, block []byte)
+	// Evict removes all cached blocks of file.
+	Evict(path string)
+}
+
+// blockKey represents the unique key of cached block.
+type blockKey struct {
+	path string
+	key  uint32
+}
+
+// blockEntry represents the cached block in lru list.
+type blockEntry struct {
+	key   blockKey
+	block []byte
+}
+
+// lruBlockCache implements BlockCache bounded by memory size, evicts the least recently used block.
+type lruBlockCache struct {
+	capacity int64
+	size     int64
+	lru      *list.List
+	blocks   map[blockKey]*list.Element
+	files    map[string]map[uint32]struct{}
+	mutex    sync.Mutex
+}
+
+// NewBlockCache creates block cache which holds at most capacity bytes,
+// returns nil if capacity <= 0, which means block cache is disabled.
+func NewBlockCache(capacity int64) BlockCache {
+	if capacity <= 0 {
+		return nil
+	}
+	return &lruBlockCache{
+		capacity: capacity,
+		lru:      list.New(),
+		blocks:   make(map[blockKey]*list.Element),
+		files:    make(map[string]map[uint32]struct{}),
+	}
+}
+
+// Get returns the cached block of key in file.
+func (c *lruBlockCache) Get(path string, key uint32) ([]byte, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	elem, ok := c.blocks[blockKey{path: path, key: key}]
+	if !ok {
+		blockCacheMisses.Incr()
+		return nil, false
+	}
+	blockCacheHits.Incr()
+	c.lru.MoveToFront(elem)
+	return elem.Value.(*blockEntry).block, true
+}
+
+// Put caches the block of key in file, the block is copied because it may reference mmap region.
+func (c *lruBlockCache) Put(path string, key uint32, block []byte) {
+	cost := int64(len(block) + blockEntryOverhead)
+	if cost > c.capacity {
+		// block is too large to cache
+		return
+	}
+	k := blockKey{path: path, key: key}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if elem, ok := c.blocks[k]; ok {
+		c.lru.MoveToFront(elem)
+		return
+	}
+	data := make([]byte, len(block))
+	copy(data, block)
+	c.blocks[k] = c.lru.PushFront(&blockEntry{key: k, block: data})
+	keys, ok := c.files[path]
+	if !ok {
+		keys = make(map[uint32]struct{})
+		c.files[path] = keys
+	}
+	keys[key] = struct{}{}
+	c.size += cost
+
+	for c.size > c.capacity {
+		c.removeElement(c.lru.Back())
+		blockCacheEvictions.Incr()
+	}
+	blockCacheSize.Update(float64(c.size))
+}
+
+// Evict removes all cached blocks of file.
+func (c *lruBlockCache) Evict(path string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	keys, ok := c.files[path]
+	if !ok {
+		return
+	}
+	for key := range keys {
+		if elem, ok := c.blocks[blockKey{path: path, key: key}]; ok {
+			c.removeElement(elem)
+		}
+	}
+	blockCacheSize.Update(float64(c.size))
+}
+
+// removeElement removes element from lru list and indexes, must be called under lock.
+func (c *lruBlockCache) removeElement(elem *list.Element) {
+	entry := c.lru.Remove(elem).(*blockEntry)
+	delete(c.blocks, entry.key)
+	if keys, ok := c.files[entry.key.path]; ok {
+		delete(keys, entry.key.key)
+		if len(keys) == 0 {
+			delete(c.files, entry.key.path)
+		}
+	}
+	c.size -= int64(len(entry.block) + blockEntryOverhead)
+}
+
+// cachedReader wraps the sst file reader, reads value blocks through block cache.
+type cachedReader struct {
+	Reader
+	cache BlockCache
+}
+
+// newCachedReader creates reader which reads value blocks through block cache.
+func newCachedReader(reader Reader, cache BlockCache) Reader {
+	return &cachedReader{
+		Reader: reader,
+		cache:  cache,
+	}
+}
+
+// Get returns value for giving key, reads from block cache first.
+func (r *cachedReader) Get(key uint32) ([]byte, error) {
+	path := r.Reader.Path()
+	if block, ok := r.cache.Get(path, key); ok {
+		return block, nil
+	}
+	block, err := r.Reader.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	r.cache.Put(path, key, block)
+	return block, nil
+}
+
+// Close removes cached blocks of file, then closes the underlying reader.
+func (r *cachedReader) Close() error {
+	r.cache.Evict(r.Reader.Path())
+	return r.Reader.Close()
+}
@@ -0,0 +1,196 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package table
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/config"
+)
+
+func TestBlockCache_Disabled(t *testing.T) {
+	assert.Nil(t, NewBlockCache(0))
+	assert.Nil(t, NewBlockCache(-1))
+}
+
+func TestBlockCache_GetPut(t *testing.T) {
+	cache := NewBlockCache(1024)
+	block, ok := cache.Get("f", 1)
+	assert.False(t, ok)
+	assert.Nil(t, block)
+
+	data := []byte("test")
+	cache.Put("f", 1, data)
+	// put exist block
+	cache.Put("f", 1, data)
+	// cached block is a copy
+	data[0] = 'x'
+	block, ok = cache.Get("f", 1)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("test"), block)
+	_, ok = cache.Get("f1", 1)
+	assert.False(t, ok)
+
+	// block is too large
+	cache.Put("f", 2, make([]byte, 1024))
+	_, ok = cache.Get("f", 2)
+	assert.False(t, ok)
+}
+
+func TestBlockCache_LRU(t *testing.T) {
+	// holds two blocks
+	cache := NewBlockCache(2 * (10 + blockEntryOverhead))
+	cache.Put("f", 1, make([]byte, 10))
+	cache.Put("f", 2, make([]byte, 10))
+	// touch block 1, block 2 becomes the least recently used
+	_, ok := cache.Get("f", 1)
+	assert.True(t, ok)
+	cache.Put("f", 3, make([]byte, 10))
+	_, ok = cache.Get("f", 2)
+	assert.False(t, ok)
+	_, ok = cache.Get("f", 1)
+	assert.True(t, ok)
+	_, ok = cache.Get("f", 3)
+	assert.True(t, ok)
+	assert.Equal(t, int64(2*(10+blockEntryOverhead)), cache.(*lruBlockCache).size)
+}
+
+func TestBlockCache_Evict(t *testing.T) {
+	cache := NewBlockCache(1024)
+	cache.Put("f1", 1, []byte("1"))
+	cache.Put("f1", 2, []byte("2"))
+	cache.Put("f2", 1, []byte("3"))
+	cache.Evict("f3")
+	cache.Evict("f1")
+	_, ok := cache.Get("f1", 1)
+	assert.False(t, ok)
+	_, ok = cache.Get("f1", 2)
+	assert.False(t, ok)
+	block, ok := cache.Get("f2", 1)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("3"), block)
+	assert.Equal(t, int64(1+blockEntryOverhead), cache.(*lruBlockCache).size)
+}
+
+func TestCachedReader(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockReader := NewMockReader(ctrl)
+	mockReader.EXPECT().Path().Return("f").AnyTimes()
+	r := newCachedReader(mockReader, NewBlockCache(1024))
+	// case 1: get err
+	mockReader.EXPECT().Get(uint32(1)).Return(nil, ErrKeyNotExist)
+	block, err := r.Get(1)
+	assert.Equal(t, ErrKeyNotExist, err)
+	assert.Nil(t, block)
+	// case 2: read from reader, then from cache
+	mockReader.EXPECT().Get(uint32(1)).Return([]byte("test"), nil)
+	block, err = r.Get(1)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("test"), block)
+	block, err = r.Get(1)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("test"), block)
+	// case 3: close evicts cached blocks
+	mockReader.EXPECT().Close().Return(fmt.Errorf("err"))
+	assert.Error(t, r.Close())
+	mockReader.EXPECT().Get(uint32(1)).Return([]byte("test"), nil)
+	_, err = r.Get(1)
+	assert.NoError(t, err)
+}
+
+func TestMapCache_GetReader_BlockCache(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		newMMapStoreReaderFunc = newMMapStoreReader
+		ctrl.Finish()
+	}()
+	mockReader := NewMockReader(ctrl)
+	newMMapStoreReaderFunc = func(path string) (r Reader, err error) {
+		return mockReader, nil
+	}
+	cache := NewCache(t.TempDir(), config.ReadStrategyMMap, NewBlockCache(1024))
+	r, err := cache.GetReader("f", "100000.sst")
+	assert.NoError(t, err)
+	assert.IsType(t, &cachedReader{}, r)
+}
+
+// countingReader counts the reads of underlying reader.
+type countingReader struct {
+	Reader
+	reads int
+}
+
+func (r *countingReader) Get(key uint32) ([]byte, error) {
+	r.reads++
+	return r.Reader.Get(key)
+}
+
+// BenchmarkBlockCache_RepeatedQuery reads the same window of blocks repeatedly,
+// reports the reads of sst file per query with and without block cache.
+func BenchmarkBlockCache_RepeatedQuery(b *testing.B) {
+	path := b.TempDir()
+	const numOfKeys = 1000
+	builder, err := NewStoreBuilder(10, path+"/000010.sst")
+	if err != nil {
+		b.Fatal(err)
+	}
+	block := make([]byte, 256)
+	for key := uint32(0); key < numOfKeys; key++ {
+		_ = builder.Add(key, block)
+	}
+	if err := builder.Close(); err != nil {
+		b.Fatal(err)
+	}
+
+	run := func(b *testing.B, blockCache BlockCache) {
+		reader, err := newMMapStoreReader(path + "/000010.sst")
+		if err != nil {
+			b.Fatal(err)
+		}
+		defer func() {
+			_ = reader.Close()
+		}()
+		counter := &countingReader{Reader: reader}
+		var r Reader = counter
+		if blockCache != nil {
+			r = newCachedReader(counter, blockCache)
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			// query over fixed window
+			for key := uint32(0); key < numOfKeys; key++ {
+				if _, err := r.Get(key); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+		b.ReportMetric(float64(counter.reads)/float64(b.N), "reads/op")
+	}
+	b.Run("no-cache", func(b *testing.B) {
+		run(b, nil)
+	})
+	b.Run("block-cache", func(b *testing.B) {
+		run(b, NewBlockCache(1024*1024))
+	})
+}
@@ -21,6 +21,7 @@ import (
 	"path/filepath"
 	"sync"
 
+	"github.com/lindb/lindb/config"
 	"github.com/lindb/lindb/internal/linmetric"
 	"github.com/lindb/lindb/pkg/logger"
 )
@@ -32,6 +33,7 @@ import (
 // for test
 var (
 	newMMapStoreReaderFunc   = newMMapStoreReader
+	newPReadStoreReaderFunc  = newPReadStoreReader
 	_once4Cache              sync.Once
 	_instanceCacheStatistics *cacheStatistics
 )
@@ -70,16 +72,21 @@ type Cache interface {
 
 // Cache caches table readers based on map
 type mapCache struct {
-	storePath string
-	readers   map[string]Reader
-	mutex     sync.Mutex
+	storePath    string
+	readStrategy string
+	readers      map[string]Reader
+	blockCache   BlockCache
+	mutex        sync.Mutex
 }
 
-// NewCache creates cache for store readers
-func NewCache(storePath string) Cache {
+// NewCache creates cache for store readers, readers read sst files by read strategy(mmap if empty),
+// and read value blocks through block cache if it isn't nil.
+func NewCache(storePath string, readStrategy string, blockCache BlockCache) Cache {
 	return &mapCache{
-		storePath: storePath,
-		readers:   make(map[string]Reader),
+		storePath:    storePath,
+		readStrategy: readStrategy,
+		readers:      make(map[string]Reader),
+		blockCache:   blockCache,
 	}
 }
 
@@ -119,10 +126,19 @@ func (c *mapCache) GetReader(family string, fileName string) (Reader, error) {
 	getCacheStatistics().cacheMisses.Incr()
 	// create new reader
 	path := filepath.Join(c.storePath, filePath)
-	newReader, err := newMMapStoreReaderFunc(path)
+	var newReader Reader
+	var err error
+	if c.readStrategy == config.ReadStrategyPRead {
+		newReader, err = newPReadStoreReaderFunc(path)
+	} else {
+		newReader, err = newMMapStoreReaderFunc(path)
+	}
 	if err != nil {
 		return nil, err
 	}
+	if c.blockCache != nil {
+		newReader = newCachedReader(newReader, c.blockCache)
+	}
 	c.readers[filePath] = newReader
 	return newReader, nil
 }
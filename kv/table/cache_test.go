@@ -23,6 +23,8 @@ import (
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/config"
 )
 
 func TestMapCache_GetReader(t *testing.T) {
@@ -31,7 +33,7 @@ func TestMapCache_GetReader(t *testing.T) {
 		newMMapStoreReaderFunc = newMMapStoreReader
 		ctrl.Finish()
 	}()
-	cache := NewCache(t.TempDir())
+	cache := NewCache(t.TempDir(), config.ReadStrategyMMap, nil)
 	// case 1: get reader err
 	newMMapStoreReaderFunc = func(path string) (r Reader, err error) {
 		return nil, fmt.Errorf("err")
@@ -48,7 +48,8 @@ type mergedIterator struct {
 	curValue []byte
 }
 
-// NewMergedIterator create merged iterator for multi iterators
+// NewMergedIterator create merged iterator for multi iterators,
+// the values with same key are returned in the order of iterators.
 func NewMergedIterator(its []Iterator) Iterator {
 	it := &mergedIterator{
 		its: its,
@@ -67,6 +68,7 @@ func (m *mergedIterator) initQueue() {
 				key:   it.Key(),
 				value: it.Value(),
 				index: i,
+				order: i,
 			})
 			i++
 		}
@@ -117,7 +119,8 @@ type item struct {
 	key   uint32
 	value []byte
 
-	index int
+	index int // index of item in priority queue
+	order int // order of iterator, keeps the values with same key in order of iterators
 }
 
 // priorityQueue implements heap.Interface and holds Items.
@@ -126,8 +129,11 @@ type priorityQueue []*item
 // Len returns the number of elements in priority queue
 func (pq priorityQueue) Len() int { return len(pq) }
 
-// Less compares key of item
+// Less compares key of item, then the order of iterator if same key
 func (pq priorityQueue) Less(i, j int) bool {
+	if pq[i].key == pq[j].key {
+		return pq[i].order < pq[j].order
+	}
 	return pq[i].key < pq[j].key
 }
 
@@ -111,6 +111,28 @@ func TestMergedIterator(t *testing.T) {
 	assert.Equal(t, len(keys), i)
 }
 
+func TestMergedIterator_sameKeyOrder(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var its []Iterator
+	for i := 0; i < 10; i++ {
+		its = append(its, generateIterator(ctrl, map[uint32][]byte{
+			1: {byte(i)},
+			2: {byte(i)},
+		}))
+	}
+	mergedIt := NewMergedIterator(its)
+	for _, key := range []uint32{1, 2} {
+		for i := 0; i < 10; i++ {
+			assert.True(t, mergedIt.HasNext())
+			assert.Equal(t, key, mergedIt.Key())
+			assert.Equal(t, []byte{byte(i)}, mergedIt.Value())
+		}
+	}
+	assert.False(t, mergedIt.HasNext())
+}
+
 func TestMergedIterator_complex(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
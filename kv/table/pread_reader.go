@@ -0,0 +1,199 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package table
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/lindb/roaring"
+
+	"github.com/lindb/lindb/pkg/encoding"
+)
+
+// for testing
+var (
+	openFileFunc = os.Open
+)
+
+// storePReadReader represents store file reader which reads value blocks by pread
+type storePReadReader struct {
+	path        string                       // path of sst-file
+	f           *os.File                     // opened sst-file
+	entriesSize int                          // length of entries block
+	keys        *roaring.Bitmap              // bitmap of keys
+	offsets     *encoding.FixedOffsetDecoder // offset of values
+}
+
+// newPReadStoreReader creates store file reader which reads value blocks by pread
+func newPReadStoreReader(path string) (r Reader, err error) {
+	f, err := openFileFunc(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			_ = f.Close()
+		}
+	}()
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if stat.Size() < sstFileFooterSize {
+		return nil, fmt.Errorf("length of sstfile:%s length is too short", path)
+	}
+	reader := &storePReadReader{
+		path: path,
+		f:    f,
+		keys: roaring.New(),
+	}
+	if err = reader.initialize(int(stat.Size())); err != nil {
+		return nil, err
+	}
+	return reader, nil
+}
+
+// initialize initializes store reader, reads index block(keys,offset etc.), then caches it
+func (r *storePReadReader) initialize(fileSize int) error {
+	// read and decode footer
+	footerStart := fileSize - sstFileFooterSize
+	footer := make([]byte, sstFileFooterSize)
+	if _, err := r.f.ReadAt(footer, int64(footerStart)); err != nil {
+		return fmt.Errorf("read footer of sstfile:%s error:%s", r.path, err)
+	}
+	// validate magic-number
+	if uint64Func(footer[magicNumberAtFooter:]) != magicNumberOffsetFile {
+		return fmt.Errorf("verify magic-number of sstfile:%s failure", r.path)
+	}
+	posOfOffset := int(binary.LittleEndian.Uint32(footer[0:4]))
+	posOfKeys := int(binary.LittleEndian.Uint32(footer[4:8]))
+	if !sort.IntsAreSorted([]int{
+		0, posOfOffset, posOfKeys, footerStart}) {
+		return fmt.Errorf("bad footer data, posOfOffsets: %d posOfKeys: %d,"+
+			" footerStart: %d", posOfOffset, posOfKeys, footerStart)
+	}
+	// read index block
+	indexBlock := make([]byte, footerStart-posOfOffset)
+	if _, err := r.f.ReadAt(indexBlock, int64(posOfOffset)); err != nil {
+		return fmt.Errorf("read index block of sstfile:%s error:%s", r.path, err)
+	}
+	// decode offsets
+	r.offsets = encoding.NewFixedOffsetDecoder()
+	if _, err := r.offsets.Unmarshal(indexBlock[:posOfKeys-posOfOffset]); err != nil {
+		return fmt.Errorf("unmarshal fixed-offsets decoder with error: %s", err)
+	}
+	// decode keys
+	if err := encoding.BitmapUnmarshal(r.keys, indexBlock[posOfKeys-posOfOffset:]); err != nil {
+		return fmt.Errorf("unmarshal keys data from file[%s] error:%s", r.path, err)
+	}
+	// validate keys and offsets
+	if r.offsets.Size() != int(r.keys.GetCardinality()) {
+		return fmt.Errorf("num. of keys != num. of offsets in file[%s]", r.path)
+	}
+	r.entriesSize = posOfOffset
+	return nil
+}
+
+// Path returns the file path
+func (r *storePReadReader) Path() string {
+	return r.path
+}
+
+// Get return value for key, if not exist return nil,false
+func (r *storePReadReader) Get(key uint32) ([]byte, error) {
+	if !r.keys.Contains(key) {
+		return nil, ErrKeyNotExist
+	}
+	// bitmap data's index from 1, so idx= get index - 1
+	idx := r.keys.Rank(key)
+	return r.getBlock(int(idx) - 1)
+}
+
+func (r *storePReadReader) getBlock(idx int) ([]byte, error) {
+	block, err := r.readBlock(idx)
+	if err == nil {
+		getReaderStatistics().getCounts.Incr()
+		getReaderStatistics().getBytes.Add(float64(len(block)))
+	} else {
+		getReaderStatistics().getErrors.Incr()
+	}
+	return block, err
+}
+
+// readBlock reads value block of index from sst file.
+func (r *storePReadReader) readBlock(idx int) ([]byte, error) {
+	startOffset, ok := r.offsets.Get(idx)
+	if !ok {
+		return nil, fmt.Errorf("corrupted FixedOffsetDecoder block, index: %d", idx)
+	}
+	endOffset, ok := r.offsets.Get(idx + 1)
+	if !ok {
+		endOffset = r.entriesSize
+	}
+	if startOffset < 0 || endOffset < startOffset || endOffset > r.entriesSize {
+		return nil, fmt.Errorf("corrupted FixedOffsetDecoder block, "+
+			"startOffset: %d, endOffset: %d, entries size: %d", startOffset, endOffset, r.entriesSize)
+	}
+	block := make([]byte, endOffset-startOffset)
+	if _, err := r.f.ReadAt(block, int64(startOffset)); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
+// Iterator iterates over a store's key/value pairs in key order.
+func (r *storePReadReader) Iterator() Iterator {
+	return &storePReadIterator{
+		reader: r,
+		keyIt:  r.keys.Iterator(),
+	}
+}
+
+// Close store reader, release resource
+func (r *storePReadReader) Close() error {
+	return r.f.Close()
+}
+
+// storePReadIterator iterates k/v pair using pread store reader
+type storePReadIterator struct {
+	reader *storePReadReader
+	keyIt  roaring.IntIterable
+
+	idx int
+}
+
+// HasNext returns if the iteration has more element.
+// It returns false if the iterator is exhausted.
+func (it *storePReadIterator) HasNext() bool {
+	return it.keyIt.HasNext()
+}
+
+// Key returns the key of the current key/value pair
+func (it *storePReadIterator) Key() uint32 {
+	return it.keyIt.Next()
+}
+
+// Value returns the value of the current key/value pair
+func (it *storePReadIterator) Value() []byte {
+	block, _ := it.reader.getBlock(it.idx)
+	it.idx++
+	return block
+}
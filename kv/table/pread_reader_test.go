@@ -0,0 +1,168 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package table
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/lindb/roaring"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/pkg/encoding"
+)
+
+func TestPReadReader_Fail(t *testing.T) {
+	defer func() {
+		openFileFunc = os.Open
+		uint64Func = binary.LittleEndian.Uint64
+		encoding.BitmapUnmarshal = bitmapUnmarshal
+	}()
+	path := t.TempDir()
+	// case 1: open file err
+	openFileFunc = func(name string) (*os.File, error) {
+		return nil, fmt.Errorf("err")
+	}
+	r, err := newPReadStoreReader(filepath.Join(path, "000010.sst"))
+	assert.Error(t, err)
+	assert.Nil(t, r)
+	openFileFunc = os.Open
+	// case 2: footer length err
+	shortFile := filepath.Join(path, "000011.sst")
+	assert.NoError(t, os.WriteFile(shortFile, []byte{1, 2, 3}, 0644))
+	r, err = newPReadStoreReader(shortFile)
+	assert.Error(t, err)
+	assert.Nil(t, r)
+	// case 3: magic number err
+	badFile := filepath.Join(path, "000012.sst")
+	assert.NoError(t, os.WriteFile(badFile,
+		[]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 0, 1, 2, 3, 4, 5}, 0644))
+	r, err = newPReadStoreReader(badFile)
+	assert.Error(t, err)
+	assert.Nil(t, r)
+
+	builder, err := NewStoreBuilder(10, filepath.Join(path, "000013.sst"))
+	assert.NoError(t, err)
+	_ = builder.Add(1, []byte("test"))
+	_ = builder.Add(10, []byte("test10"))
+	assert.NoError(t, builder.Close())
+	// case 4: unmarshal keys err
+	encoding.BitmapUnmarshal = func(bitmap *roaring.Bitmap, data []byte) error {
+		return fmt.Errorf("err")
+	}
+	r, err = newPReadStoreReader(filepath.Join(path, "000013.sst"))
+	assert.Error(t, err)
+	assert.Nil(t, r)
+	// case 5: offset's size != key's size
+	encoding.BitmapUnmarshal = func(bitmap *roaring.Bitmap, data []byte) error {
+		bitmap.AddRange(1, 1000)
+		return nil
+	}
+	r, err = newPReadStoreReader(filepath.Join(path, "000013.sst"))
+	assert.Error(t, err)
+	assert.Nil(t, r)
+	encoding.BitmapUnmarshal = bitmapUnmarshal
+	// case 6: get block err
+	r, err = newPReadStoreReader(filepath.Join(path, "000013.sst"))
+	assert.NoError(t, err)
+	block, err := r.(*storePReadReader).getBlock(2)
+	assert.Error(t, err)
+	assert.Nil(t, block)
+	assert.NoError(t, r.Close())
+	// case 7: read closed file err
+	_, err = r.Get(1)
+	assert.Error(t, err)
+}
+
+func TestReadStrategy_SameData(t *testing.T) {
+	path := t.TempDir()
+	builder, err := NewStoreBuilder(10, filepath.Join(path, "000010.sst"))
+	assert.NoError(t, err)
+	values := make(map[uint32][]byte)
+	for key := uint32(1); key <= 1000; key += 3 {
+		value := []byte(fmt.Sprintf("value-%d", key))
+		values[key] = value
+		assert.NoError(t, builder.Add(key, value))
+	}
+	assert.NoError(t, builder.Close())
+
+	mmapCache := NewCache(path, config.ReadStrategyMMap, nil)
+	preadCache := NewCache(path, config.ReadStrategyPRead, nil)
+	defer func() {
+		_ = mmapCache.Close()
+		_ = preadCache.Close()
+	}()
+	mmapReader, err := mmapCache.GetReader("", "000010.sst")
+	assert.NoError(t, err)
+	assert.IsType(t, &storeMMapReader{}, mmapReader)
+	preadReader, err := preadCache.GetReader("", "000010.sst")
+	assert.NoError(t, err)
+	assert.IsType(t, &storePReadReader{}, preadReader)
+	assert.Equal(t, mmapReader.Path(), preadReader.Path())
+
+	for key := uint32(0); key <= 1001; key++ {
+		mmapValue, mmapErr := mmapReader.Get(key)
+		preadValue, preadErr := preadReader.Get(key)
+		assert.Equal(t, mmapErr, preadErr)
+		assert.Equal(t, mmapValue, preadValue)
+		if value, ok := values[key]; ok {
+			assert.Equal(t, value, preadValue)
+		} else {
+			assert.Equal(t, ErrKeyNotExist, preadErr)
+		}
+	}
+
+	mmapIt := mmapReader.Iterator()
+	preadIt := preadReader.Iterator()
+	count := 0
+	for mmapIt.HasNext() {
+		assert.True(t, preadIt.HasNext())
+		assert.Equal(t, mmapIt.Key(), preadIt.Key())
+		assert.Equal(t, mmapIt.Value(), preadIt.Value())
+		count++
+	}
+	assert.False(t, preadIt.HasNext())
+	assert.Equal(t, len(values), count)
+}
+
+func TestMapCache_GetReader_PRead(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		newPReadStoreReaderFunc = newPReadStoreReader
+		ctrl.Finish()
+	}()
+	cache := NewCache(t.TempDir(), config.ReadStrategyPRead, nil)
+	newPReadStoreReaderFunc = func(path string) (r Reader, err error) {
+		return nil, fmt.Errorf("err")
+	}
+	r, err := cache.GetReader("f", "100000.sst")
+	assert.Error(t, err)
+	assert.Nil(t, r)
+	mockReader := NewMockReader(ctrl)
+	newPReadStoreReaderFunc = func(path string) (r Reader, err error) {
+		return mockReader, nil
+	}
+	r, err = cache.GetReader("f", "100000.sst")
+	assert.NoError(t, err)
+	assert.Equal(t, mockReader, r)
+}
@@ -26,6 +26,7 @@ import (
 	"github.com/lindb/roaring"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/lindb/lindb/config"
 	"github.com/lindb/lindb/pkg/encoding"
 	"github.com/lindb/lindb/pkg/fileutil"
 )
@@ -130,7 +131,7 @@ func TestReader(t *testing.T) {
 	err = builder.Close()
 	assert.Nil(t, err)
 
-	cache := NewCache(testKVPath)
+	cache := NewCache(testKVPath, config.ReadStrategyMMap, nil)
 
 	reader, err := cache.GetReader("", "000010.sst")
 	assert.NoError(t, err)
@@ -173,7 +174,7 @@ func TestStoreIterator(t *testing.T) {
 	err = builder.Close()
 	assert.Nil(t, err)
 
-	cache := NewCache(testKVPath)
+	cache := NewCache(testKVPath, config.ReadStrategyMMap, nil)
 	reader, err := cache.GetReader("", "000010.sst")
 	assert.NoError(t, err)
 
@@ -51,6 +51,9 @@ type FamilyVersion interface {
 	GetLiveRollupFiles() map[table.FileNumber]timeutil.Interval
 	// GetLiveReferenceFiles returns all rollup reference files
 	GetLiveReferenceFiles() map[FamilyID][]table.FileNumber
+	// EvictReaders closes the cached readers of all active files if no version is used by search/compact/rollup,
+	// returns false if any version is in use, readers are reopened on demand.
+	EvictReaders() bool
 	// removeVersion removes version from active versions
 	removeVersion(v Version)
 	// appendVersion swaps family's current version, then releases previous version
@@ -137,6 +140,27 @@ func (fv *familyVersion) GetLiveReferenceFiles() map[FamilyID][]table.FileNumber
 	return fv.current.GetReferenceFiles()
 }
 
+// EvictReaders closes the cached readers of all active files if no version is used by search/compact/rollup,
+// returns false if any version is in use, readers are reopened on demand.
+func (fv *familyVersion) EvictReaders() bool {
+	// hold write lock, make sure no snapshot is created when evicting
+	fv.mutex.Lock()
+	defer fv.mutex.Unlock()
+
+	for _, v := range fv.activeVersions {
+		if v.NumOfRef() > 0 {
+			return false
+		}
+	}
+	cache := fv.versionSet.getCache()
+	for _, v := range fv.activeVersions {
+		for _, file := range v.GetAllFiles() {
+			cache.Evict(fv.familyName, Table(file.GetFileNumber()))
+		}
+	}
+	return true
+}
+
 // removeVersion removes version from active versions,
 // cannot remove current version from active versions.
 func (fv *familyVersion) removeVersion(v Version) {
@@ -105,6 +105,27 @@ func TestFamilyVersion(t *testing.T) {
 	assert.Equal(t, 1, len(fv.activeVersions))
 }
 
+func TestFamilyVersion_EvictReaders(t *testing.T) {
+	initVersionSetTestData()
+	ctrl := gomock.NewController(t)
+	defer func() {
+		destroyVersionTestData()
+		ctrl.Finish()
+	}()
+
+	cache := table.NewMockCache(ctrl)
+	vs := NewStoreVersionSet(vsTestPath, cache, 2)
+	fv := vs.CreateFamilyVersion("f", 1)
+	snapshot := fv.GetSnapshot()
+	snapshot.GetCurrent().AddFile(1, NewFileMeta(12, 1, 50, 2014))
+	// case 1: version in use
+	assert.False(t, fv.EvictReaders())
+	// case 2: evict readers of all files
+	snapshot.Close()
+	cache.EXPECT().Evict("f", Table(12))
+	assert.True(t, fv.EvictReaders())
+}
+
 func TestFamilyVersion_Rollup(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer func() {
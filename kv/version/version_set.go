@@ -56,6 +56,11 @@ type StoreVersionSet interface {
 	ManifestFileNumber() table.FileNumber
 	// CommitFamilyEditLog persists edit logs to manifest file, then apply new version to family version
 	CommitFamilyEditLog(family string, editLog EditLog) error
+	// AppendFamilyEditLog likes CommitFamilyEditLog, but doesn't sync manifest file,
+	// manifest file is synced by SyncManifest or written back by OS.
+	AppendFamilyEditLog(family string, editLog EditLog) error
+	// SyncManifest syncs manifest file to disk.
+	SyncManifest() error
 	// CreateFamilyVersion creates family version using family name,
 	// if family version exist, return exist one
 	CreateFamilyVersion(family string, familyID FamilyID) FamilyVersion
@@ -138,6 +143,29 @@ func (vs *storeVersionSet) ManifestFileNumber() table.FileNumber {
 
 // CommitFamilyEditLog persists edit logs to manifest file, then apply new version to family version
 func (vs *storeVersionSet) CommitFamilyEditLog(family string, editLog EditLog) error {
+	return vs.commitFamilyEditLog(family, editLog, true)
+}
+
+// AppendFamilyEditLog likes CommitFamilyEditLog, but doesn't sync manifest file,
+// manifest file is synced by SyncManifest or written back by OS.
+func (vs *storeVersionSet) AppendFamilyEditLog(family string, editLog EditLog) error {
+	return vs.commitFamilyEditLog(family, editLog, false)
+}
+
+// SyncManifest syncs manifest file to disk.
+func (vs *storeVersionSet) SyncManifest() error {
+	vs.mutex.Lock()
+	defer vs.mutex.Unlock()
+
+	if vs.manifest == nil {
+		return nil
+	}
+	return vs.manifest.Sync()
+}
+
+// commitFamilyEditLog persists edit logs to manifest file, syncs manifest file if sync,
+// then apply new version to family version.
+func (vs *storeVersionSet) commitFamilyEditLog(family string, editLog EditLog, sync bool) error {
 	// get family version based on family name
 	familyVersion := vs.GetFamilyVersion(family)
 	if familyVersion == nil {
@@ -150,7 +178,7 @@ func (vs *storeVersionSet) CommitFamilyEditLog(family string, editLog EditLog) e
 	// add next file number init edit log for each delta edit log
 	editLog.Add(NewNextFileNumber(table.FileNumber(vs.nextFileNumber.Load())))
 	// persist edit log
-	if err := vs.persistEditLogs(vs.manifest, []EditLog{editLog}); err != nil {
+	if err := vs.persistEditLogs(vs.manifest, []EditLog{editLog}, sync); err != nil {
 		return err
 	}
 	// get current snapshot
@@ -308,7 +336,7 @@ func (vs *storeVersionSet) initJournal() error {
 		}
 		// need snapshot writes snapshot first
 		editLogs := vs.createSnapshot()
-		if err := vs.persistEditLogs(writer, editLogs); err != nil {
+		if err := vs.persistEditLogs(writer, editLogs, true); err != nil {
 			return err
 		}
 		// make sure write snapshot success, important!!!!!!!
@@ -402,8 +430,9 @@ func (vs *storeVersionSet) createStoreSnapshot() EditLog {
 	return editLog
 }
 
-// persistEditLogs persists edit logs into manifest file
-func (vs *storeVersionSet) persistEditLogs(writer bufioutil.BufioWriter, editLogs []EditLog) error {
+// persistEditLogs persists edit logs into manifest file, syncs manifest file after each edit log if sync,
+// else flushes them to OS.
+func (vs *storeVersionSet) persistEditLogs(writer bufioutil.BufioWriter, editLogs []EditLog, sync bool) error {
 	for _, editLog := range editLogs {
 		v, err := editLog.marshal()
 		if err != nil {
@@ -412,6 +441,12 @@ func (vs *storeVersionSet) persistEditLogs(writer bufioutil.BufioWriter, editLog
 		if _, err := writer.Write(v); err != nil {
 			return fmt.Errorf("write edit log error:%s", err)
 		}
+		if !sync {
+			if err := writer.Flush(); err != nil {
+				return fmt.Errorf("flush edit log error:%s", err)
+			}
+			continue
+		}
 		if err := writer.Sync(); err != nil {
 			return fmt.Errorf("sync edit log error:%s", err)
 		}
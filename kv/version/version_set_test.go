@@ -257,6 +257,38 @@ func TestStoreVersionSet_CommitFamilyEditLog_err(t *testing.T) {
 	manifest.EXPECT().Sync().Return(fmt.Errorf("err"))
 	err = vs.CommitFamilyEditLog("f", editLog)
 	assert.Error(t, err)
+	// case 5: flush manifest err without sync
+	manifest.EXPECT().Write(gomock.Any()).Return(10, nil)
+	manifest.EXPECT().Flush().Return(fmt.Errorf("err"))
+	err = vs.AppendFamilyEditLog("f", editLog)
+	assert.Error(t, err)
+}
+
+func TestStoreVersionSet_AppendFamilyEditLog(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	cache := table.NewMockCache(ctrl)
+	vs := NewStoreVersionSet(vsTestPath, cache, 2)
+	// manifest not init
+	assert.NoError(t, vs.SyncManifest())
+
+	familyID := FamilyID(1)
+	vs.CreateFamilyVersion("f", familyID)
+	manifest := bufioutil.NewMockBufioWriter(ctrl)
+	vs.(*storeVersionSet).manifest = manifest
+
+	editLog := NewEditLog(familyID)
+	editLog.Add(CreateNewFile(0, NewFileMeta(12, 1, 100, 2014)))
+	// edit log is flushed to OS without sync, version applied
+	manifest.EXPECT().Write(gomock.Any()).Return(10, nil)
+	manifest.EXPECT().Flush().Return(nil)
+	assert.NoError(t, vs.AppendFamilyEditLog("f", editLog))
+	snapshot := vs.GetFamilyVersion("f").GetSnapshot()
+	assert.Len(t, snapshot.GetCurrent().GetAllFiles(), 1)
+	snapshot.Close()
+	// sync manifest later
+	manifest.EXPECT().Sync().Return(nil)
+	assert.NoError(t, vs.SyncManifest())
 }
 
 func TestCreateFamily(t *testing.T) {
@@ -67,6 +67,8 @@ type SystemStat struct {
 	CPUStat       *CPUStat               `json:"cpuStat,omitempty"`       // cpu stat
 	MemoryStat    *mem.VirtualMemoryStat `json:"memoryStat,omitempty"`    // memory stat
 	DiskUsageStat *disk.UsageStat        `json:"diskUsageStat,omitempty"` // disk usage stat
+	// disk usage stat of each data directory, directory as key
+	DataDirUsageStats map[string]*disk.UsageStat `json:"dataDirUsageStats,omitempty"`
 }
 
 // MemoryStat represents the memory usage statistics in system
@@ -58,6 +58,7 @@ type StorageStats struct {
 	TotalCost             ltoml.Duration            `json:"totalCost"`
 	PlanCost              ltoml.Duration            `json:"planCost"`
 	TagFilterCost         ltoml.Duration            `json:"tagFilterCost"`
+	Plan                  *StoragePlanStats         `json:"plan,omitempty"`
 	Shards                map[ShardID]*ShardStats   `json:"shards,omitempty"`
 	CollectTagValuesStats map[string]ltoml.Duration `json:"collectTagValuesStats,omitempty"`
 
@@ -88,6 +89,13 @@ func (s *StorageStats) SetPlanCost(cost time.Duration) {
 	s.PlanCost = ltoml.Duration(cost)
 }
 
+// SetPlan sets the storage execute plan
+func (s *StorageStats) SetPlan(plan *StoragePlanStats) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.Plan = plan
+}
+
 // SetTagFilterCost sets tag filter cost
 func (s *StorageStats) SetTagFilterCost(cost time.Duration) {
 	s.mutex.Lock()
@@ -126,6 +134,16 @@ func (s *StorageStats) SetShardKVDataFilterCost(shardID ShardID, cost time.Durat
 	}
 }
 
+// SetShardNumOfFamilies sets the num. of data families which need be scanned in shard level
+func (s *StorageStats) SetShardNumOfFamilies(shardID ShardID, numOfFamilies int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	stats, ok := s.Shards[shardID]
+	if ok {
+		stats.NumOfFamilies = numOfFamilies
+	}
+}
+
 // SetShardGroupingCost sets get shard grouping context cost
 func (s *StorageStats) SetShardGroupingCost(shardID ShardID, cost time.Duration) {
 	s.mutex.Lock()
@@ -163,10 +181,22 @@ func (s *StorageStats) SetCollectTagValuesStats(tagKey string, cost time.Duratio
 	s.CollectTagValuesStats[tagKey] = ltoml.Duration(cost)
 }
 
+// StoragePlanStats represents the execute plan in storage side, tells which query logic is pushed down to storage.
+type StoragePlanStats struct {
+	MetricID      uint32   `json:"metricID"`
+	Fields        []string `json:"fields,omitempty"`
+	TagFilter     string   `json:"tagFilter,omitempty"` // tag filter pushed down to index
+	GroupBy       []string `json:"groupBy,omitempty"`   // group by pushed down to storage
+	Interval      int64    `json:"interval"`            // down sampling interval(ms)
+	IntervalRatio int      `json:"intervalRatio"`
+	ScanData      bool     `json:"scanData"` // false if only explain plan, data families are filtered but not scanned
+}
+
 // ShardStats represents the shard level stats
 type ShardStats struct {
 	SeriesFilterCost ltoml.Duration    `json:"seriesFilterCost"`
 	NumOfSeries      uint64            `json:"numOfSeries"`
+	NumOfFamilies    int               `json:"numOfFamilies"`
 	MemFilterCost    ltoml.Duration    `json:"memFilterCost"`
 	KVFilterCost     ltoml.Duration    `json:"kvFilterCost"`
 	GroupingCost     ltoml.Duration    `json:"groupingCost"`
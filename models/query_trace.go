@@ -0,0 +1,77 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package models
+
+import "sort"
+
+// QueryTrace represents the routing trace of metric query,
+// tells which shards of storage nodes are queried and how many series each shard contributes.
+type QueryTrace struct {
+	Shards []ShardTrace `json:"shards"`
+}
+
+// ShardTrace represents the trace of a shard queried.
+type ShardTrace struct {
+	Node        string  `json:"node"`
+	ShardID     ShardID `json:"shardID"`
+	NumOfSeries uint64  `json:"numOfSeries"`
+	// false if storage node returns no series search result of shard, e.g. metric not found.
+	Searched bool `json:"searched"`
+}
+
+// NewQueryTrace builds the query trace based on the leaf nodes of physical plan
+// and the per-shard results reported by storage nodes, sorted by node and shard id.
+func NewQueryTrace(leafs []Leaf, stats *QueryStats) *QueryTrace {
+	trace := &QueryTrace{}
+	for _, leaf := range leafs {
+		storageStats := stats.findStorageStats(leaf.Indicator)
+		for _, shardID := range leaf.ShardIDs {
+			shardTrace := ShardTrace{Node: leaf.Indicator, ShardID: shardID}
+			if storageStats != nil {
+				if shardStats, ok := storageStats.Shards[shardID]; ok {
+					shardTrace.Searched = true
+					shardTrace.NumOfSeries = shardStats.NumOfSeries
+				}
+			}
+			trace.Shards = append(trace.Shards, shardTrace)
+		}
+	}
+	sort.Slice(trace.Shards, func(i, j int) bool {
+		if trace.Shards[i].Node != trace.Shards[j].Node {
+			return trace.Shards[i].Node < trace.Shards[j].Node
+		}
+		return trace.Shards[i].ShardID < trace.Shards[j].ShardID
+	})
+	return trace
+}
+
+// findStorageStats returns the stats of storage node, searches stats of intermediate nodes if not found.
+func (s *QueryStats) findStorageStats(node string) *StorageStats {
+	if s == nil {
+		return nil
+	}
+	if stats, ok := s.StorageNodes[node]; ok {
+		return stats
+	}
+	for _, brokerStats := range s.BrokerNodes {
+		if stats := brokerStats.findStorageStats(node); stats != nil {
+			return stats
+		}
+	}
+	return nil
+}
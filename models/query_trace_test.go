@@ -0,0 +1,56 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewQueryTrace(t *testing.T) {
+	leafs := []Leaf{
+		{BaseNode: BaseNode{Indicator: "2.2.2.2:9000"}, ShardIDs: []ShardID{3, 1}},
+		{BaseNode: BaseNode{Indicator: "1.1.1.1:9000"}, ShardIDs: []ShardID{2}},
+		{BaseNode: BaseNode{Indicator: "3.3.3.3:9000"}, ShardIDs: []ShardID{4}},
+	}
+	// case 1: no stats
+	assert.Equal(t, &QueryTrace{Shards: []ShardTrace{
+		{Node: "1.1.1.1:9000", ShardID: 2},
+		{Node: "2.2.2.2:9000", ShardID: 1},
+		{Node: "2.2.2.2:9000", ShardID: 3},
+		{Node: "3.3.3.3:9000", ShardID: 4},
+	}}, NewQueryTrace(leafs, nil))
+	// case 2: stats from storage nodes directly and via intermediate node
+	stats := NewQueryStats()
+	storageStats := NewStorageStats()
+	storageStats.SetShardSeriesIDsSearchStats(1, 10, 0)
+	storageStats.SetShardSeriesIDsSearchStats(3, 0, 0)
+	stats.MergeStorageTaskStats("2.2.2.2:9000", storageStats)
+	brokerStats := NewQueryStats()
+	storageStats = NewStorageStats()
+	storageStats.SetShardSeriesIDsSearchStats(2, 20, 0)
+	brokerStats.MergeStorageTaskStats("1.1.1.1:9000", storageStats)
+	stats.MergeBrokerTaskStats("4.4.4.4:9000", brokerStats)
+	assert.Equal(t, &QueryTrace{Shards: []ShardTrace{
+		{Node: "1.1.1.1:9000", ShardID: 2, NumOfSeries: 20, Searched: true},
+		{Node: "2.2.2.2:9000", ShardID: 1, NumOfSeries: 10, Searched: true},
+		{Node: "2.2.2.2:9000", ShardID: 3, NumOfSeries: 0, Searched: true},
+		{Node: "3.3.3.3:9000", ShardID: 4},
+	}}, NewQueryTrace(leafs, stats))
+}
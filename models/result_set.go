@@ -30,6 +30,8 @@ type ResultSet struct {
 	Interval   int64       `json:"interval,omitempty"`
 	Series     []*Series   `json:"series,omitempty"`
 	Stats      *QueryStats `json:"stats,omitempty"`
+	Trace      *QueryTrace `json:"trace,omitempty"`
+	Warnings   []string    `json:"warnings,omitempty"`
 }
 
 // NewResultSet creates a new result set
@@ -44,8 +46,9 @@ func (rs *ResultSet) AddSeries(series *Series) {
 
 // Series represents one time series for metric
 type Series struct {
-	Tags   map[string]string            `json:"tags,omitempty"`
-	Fields map[string]map[int64]float64 `json:"fields,omitempty"`
+	Tags      map[string]string            `json:"tags,omitempty"`
+	Fields    map[string]map[int64]float64 `json:"fields,omitempty"`
+	Exemplars map[string][]*Exemplar       `json:"exemplars,omitempty"` // field name => exemplars
 }
 
 // NewSeries creates a new series
@@ -65,6 +68,23 @@ func (s *Series) AddField(fieldName string, points *Points) {
 	}
 }
 
+// AddExemplar adds an exemplar of field
+func (s *Series) AddExemplar(fieldName string, exemplar *Exemplar) {
+	if s.Exemplars == nil {
+		s.Exemplars = make(map[string][]*Exemplar)
+	}
+	s.Exemplars[fieldName] = append(s.Exemplars[fieldName], exemplar)
+}
+
+// Exemplar represents a sample of field which links to a trace
+type Exemplar struct {
+	TraceID   string  `json:"traceId"` // hex encoded
+	SpanID    string  `json:"spanId,omitempty"`
+	Duration  int64   `json:"duration,omitempty"`
+	Value     float64 `json:"value"`
+	Timestamp int64   `json:"timestamp"`
+}
+
 // Points represents the data points of the field
 type Points struct {
 	Points map[int64]float64 `json:"points,omitempty"`
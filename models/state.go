@@ -19,8 +19,11 @@ package models
 
 import (
 	"encoding/json"
+	"fmt"
 	"strconv"
 
+	"github.com/lithammer/go-jump-consistent-hash"
+
 	"github.com/lindb/lindb/config"
 	"github.com/lindb/lindb/pkg/encoding"
 	"github.com/lindb/lindb/pkg/timeutil"
@@ -143,6 +146,31 @@ func NewStorageState(name string) *StorageState {
 	}
 }
 
+// GetShardID returns the target shard of metric's hash(tags hash) via jump consistent hash,
+// when num. of shard grows from n to n+1, only 1/(n+1) of metrics move to the new shard.
+func GetShardID(hash uint64, numOfShard int32) ShardID {
+	return ShardID(jump.Hash(hash, numOfShard))
+}
+
+// RouteMetric returns the target shard and storage node(shard's leader) of metric's hash for spec database,
+// broker routing and tooling must use it for keeping routing consistent.
+func (s *StorageState) RouteMetric(database string, hash uint64) (ShardID, *StatefulNode, error) {
+	shards, ok := s.ShardStates[database]
+	if !ok || len(shards) == 0 {
+		return 0, nil, fmt.Errorf("shards of database(%s) not found", database)
+	}
+	shardID := GetShardID(hash, int32(len(shards)))
+	shard, ok := shards[shardID]
+	if !ok || shard.Leader == NoLeader {
+		return shardID, nil, fmt.Errorf("leader of shard(%s/%d) not found", database, shardID)
+	}
+	node, ok := s.LiveNodes[shard.Leader]
+	if !ok {
+		return shardID, nil, fmt.Errorf("leader(%d) of shard(%s/%d) is not alive", shard.Leader, database, shardID)
+	}
+	return shardID, &node, nil
+}
+
 func (s *StorageState) LeadersOnNode(nodeID NodeID) map[string][]ShardID {
 	result := make(map[string][]ShardID)
 	for name, shards := range s.ShardStates {
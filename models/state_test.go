@@ -73,3 +73,62 @@ func TestStorageState(t *testing.T) {
 	assert.Len(t, rs1, 1)
 	assert.Equal(t, rs1["test"], []ShardID{1})
 }
+
+func TestGetShardID_reassignment(t *testing.T) {
+	// deterministic
+	assert.Equal(t, GetShardID(1000, 5), GetShardID(1000, 5))
+
+	const numOfMetric = 100000
+	for numOfShard := int32(1); numOfShard < 10; numOfShard++ {
+		moved := 0
+		for i := uint64(0); i < numOfMetric; i++ {
+			hash := i * 0x9E3779B97F4A7C15
+			before := GetShardID(hash, numOfShard)
+			after := GetShardID(hash, numOfShard+1)
+			if before != after {
+				// metric only moves to the new shard
+				assert.Equal(t, ShardID(numOfShard), after)
+				moved++
+			}
+		}
+		// expect 1/(n+1) metrics moved, plus 2% tolerance
+		bound := numOfMetric/(int(numOfShard)+1) + numOfMetric/50
+		assert.LessOrEqual(t, moved, bound)
+	}
+}
+
+func TestStorageState_RouteMetric(t *testing.T) {
+	storageState := NewStorageState("test")
+	_, _, err := storageState.RouteMetric("db", 10)
+	assert.Error(t, err)
+
+	storageState.NodeOnline(StatefulNode{StatelessNode: StatelessNode{HostIP: "1.1.1.1", GRPCPort: 9000}, ID: 1})
+	storageState.NodeOnline(StatefulNode{StatelessNode: StatelessNode{HostIP: "1.1.1.2", GRPCPort: 9000}, ID: 2})
+	storageState.ShardStates["db"] = map[ShardID]ShardState{
+		0: {ID: 0, Leader: 1},
+		1: {ID: 1, Leader: 2},
+		2: {ID: 2, Leader: NoLeader},
+		3: {ID: 3, Leader: 3},
+	}
+	routes := make(map[uint64]NodeID)
+	for hash := uint64(0); hash < 1000; hash++ {
+		shardID, node, err := storageState.RouteMetric("db", hash)
+		assert.Equal(t, GetShardID(hash, 4), shardID)
+		switch shardID {
+		case 2, 3:
+			// no leader or leader not alive
+			assert.Error(t, err)
+		default:
+			assert.NoError(t, err)
+			assert.Equal(t, storageState.ShardStates["db"][shardID].Leader, node.ID)
+			routes[hash] = node.ID
+		}
+	}
+	// add new storage node, routing not changed
+	storageState.NodeOnline(StatefulNode{StatelessNode: StatelessNode{HostIP: "1.1.1.3", GRPCPort: 9000}, ID: 4})
+	for hash, nodeID := range routes {
+		_, node, err := storageState.RouteMetric("db", hash)
+		assert.NoError(t, err)
+		assert.Equal(t, nodeID, node.ID)
+	}
+}
@@ -0,0 +1,73 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package models
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"github.com/lindb/lindb/pkg/encoding"
+)
+
+// WriteSequence represents the sequence of write ahead log which includes the written data,
+// the data is visible after the sequence is replayed into memory database of shard's family.
+type WriteSequence struct {
+	ShardID    ShardID `json:"shardId"`
+	FamilyTime int64   `json:"familyTime"`
+	Leader     NodeID  `json:"leader"`
+	Sequence   int64   `json:"sequence"`
+}
+
+// WriteToken represents the write sequences of a write request,
+// query with write token waits until all sequences are visible(read-your-writes).
+type WriteToken []WriteSequence
+
+// Add adds the write sequence, keeps the max sequence for same shard/family/leader.
+func (t *WriteToken) Add(seq WriteSequence) {
+	for idx := range *t {
+		s := &(*t)[idx]
+		if s.ShardID == seq.ShardID && s.FamilyTime == seq.FamilyTime && s.Leader == seq.Leader {
+			if seq.Sequence > s.Sequence {
+				s.Sequence = seq.Sequence
+			}
+			return
+		}
+	}
+	*t = append(*t, seq)
+}
+
+// Encode returns the url safe string of write token.
+func (t WriteToken) Encode() string {
+	if len(t) == 0 {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(encoding.JSONMarshal(t))
+}
+
+// DecodeWriteToken decodes write token from string encoded by WriteToken.Encode.
+func DecodeWriteToken(token string) (WriteToken, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("bad write token: %w", err)
+	}
+	var t WriteToken
+	if err := encoding.JSONUnmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("bad write token: %w", err)
+	}
+	return t, nil
+}
@@ -0,0 +1,49 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteToken(t *testing.T) {
+	var token WriteToken
+	assert.Empty(t, token.Encode())
+
+	token.Add(WriteSequence{ShardID: 1, FamilyTime: 10, Leader: 1, Sequence: 5})
+	token.Add(WriteSequence{ShardID: 1, FamilyTime: 10, Leader: 1, Sequence: 3})
+	token.Add(WriteSequence{ShardID: 1, FamilyTime: 10, Leader: 2, Sequence: 1})
+	token.Add(WriteSequence{ShardID: 2, FamilyTime: 10, Leader: 1, Sequence: 7})
+	token.Add(WriteSequence{ShardID: 2, FamilyTime: 10, Leader: 1, Sequence: 8})
+	assert.Equal(t, WriteToken{
+		{ShardID: 1, FamilyTime: 10, Leader: 1, Sequence: 5},
+		{ShardID: 1, FamilyTime: 10, Leader: 2, Sequence: 1},
+		{ShardID: 2, FamilyTime: 10, Leader: 1, Sequence: 8},
+	}, token)
+
+	token2, err := DecodeWriteToken(token.Encode())
+	assert.NoError(t, err)
+	assert.Equal(t, token, token2)
+
+	_, err = DecodeWriteToken("!bad")
+	assert.Error(t, err)
+	_, err = DecodeWriteToken("YmFk")
+	assert.Error(t, err)
+}
@@ -0,0 +1,56 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package monitoring
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/lindb/lindb/constants"
+	httppkg "github.com/lindb/lindb/pkg/http"
+)
+
+// HealthCheckFunc checks if the node is healthy, returns err if degraded.
+type HealthCheckFunc func() error
+
+// HealthAPI represents node health(readiness) rest api.
+type HealthAPI struct {
+	checks []HealthCheckFunc
+}
+
+// NewHealthAPI creates health api instance with health checks.
+func NewHealthAPI(checks ...HealthCheckFunc) *HealthAPI {
+	return &HealthAPI{
+		checks: checks,
+	}
+}
+
+// Register adds health url route.
+func (d *HealthAPI) Register(route gin.IRoutes) {
+	route.GET(constants.HealthPath, d.Health)
+}
+
+// Health responses 503 with the reason if any health check fails.
+func (d *HealthAPI) Health(c *gin.Context) {
+	for _, check := range d.checks {
+		if err := check(); err != nil {
+			httppkg.ServiceUnavailable(c, err)
+			return
+		}
+	}
+	httppkg.OK(c, "ok")
+}
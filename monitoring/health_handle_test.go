@@ -0,0 +1,45 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package monitoring
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/internal/mock"
+)
+
+func TestHealthAPI_Health(t *testing.T) {
+	var checkErr error
+	api := NewHealthAPI(func() error {
+		return checkErr
+	})
+	r := gin.New()
+	api.Register(r)
+	resp := mock.DoRequest(t, r, http.MethodGet, constants.HealthPath, "")
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	checkErr = fmt.Errorf("degraded")
+	resp = mock.DoRequest(t, r, http.MethodGet, constants.HealthPath, "")
+	assert.Equal(t, http.StatusServiceUnavailable, resp.Code)
+}
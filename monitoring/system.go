@@ -46,6 +46,8 @@ type (
 	CPUStatGetter       func() (*models.CPUStat, error)
 	DiskUsageStatGetter func(ctx context.Context, path string) (*disk.UsageStat, error)
 	NetStatGetter       func(ctx context.Context) ([]net.IOCountersStat, error)
+	// ShardDiskUsageGetter returns the disk usage(bytes) of shards, database name -> shard id -> used.
+	ShardDiskUsageGetter func() map[string]map[models.ShardID]int64
 )
 
 // GetCPUs returns the number of logical cores in the system
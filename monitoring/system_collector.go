@@ -33,11 +33,18 @@ import (
 
 var sc *SystemCollector
 
+// for testing
+var (
+	getExistPathFunc = fileutil.GetExistPath
+)
+
 // SystemCollector collects the system stat
 type SystemCollector struct {
 	ctx             context.Context
 	interval        time.Duration
 	storage         string
+	dataDirs        []string
+	shardUsage      ShardDiskUsageGetter          // reports disk usage of databases if set
 	netStats        map[string]net.IOCountersStat // interface-name as key
 	netStatsUpdated map[string]time.Time          // last updated time
 	systemStat      *models.SystemStat
@@ -73,6 +80,16 @@ type SystemCollector struct {
 	inodesUsedGauge        *linmetric.BoundGauge
 	inodesTotalGauge       *linmetric.BoundGauge
 	inodesUsedPercentGauge *linmetric.BoundGauge
+	// disk usage of data directories
+	dataDirTotalGaugeVec       *linmetric.GaugeVec
+	dataDirUsedGaugeVec        *linmetric.GaugeVec
+	dataDirFreeGaugeVec        *linmetric.GaugeVec
+	dataDirUsedPercentGaugeVec *linmetric.GaugeVec
+	// disk usage of databases/shards
+	databaseUsedGaugeVec *linmetric.GaugeVec
+	shardUsedGaugeVec    *linmetric.GaugeVec
+	databaseUsages       map[string]int64            // database -> used
+	shardUsages          map[string]map[string]int64 // database -> shard -> used
 	// net
 	bytesSentCounterVec   *linmetric.DeltaCounterVec
 	bytesRecvCounterVec   *linmetric.DeltaCounterVec
@@ -113,6 +130,20 @@ func NewSystemCollector(
 	return sc
 }
 
+// WithDataDirs sets the data directories whose disk usage is reported separately,
+// used when storage distributes data across multiple disks.
+func (r *SystemCollector) WithDataDirs(dirs []string) *SystemCollector {
+	r.dataDirs = dirs
+	return r
+}
+
+// WithShardDiskUsage sets the getter of shards' disk usage which storage engine reports,
+// the disk usage of each database and shard is reported based on it.
+func (r *SystemCollector) WithShardDiskUsage(getter ShardDiskUsageGetter) *SystemCollector {
+	r.shardUsage = getter
+	return r
+}
+
 func (r *SystemCollector) boundMetrics() {
 	systemScope := linmetric.NewScope("lindb.monitor.system", "role", r.role)
 
@@ -140,6 +171,18 @@ func (r *SystemCollector) boundMetrics() {
 	r.diskFreeGauge = systemDiskScope.NewGauge("free")
 	r.diskUsedPercentGauge = systemDiskScope.NewGauge("used_percent")
 
+	dataDirScope := systemScope.Scope("data_dir_usage_stats")
+	// disk usage of data directories
+	r.dataDirTotalGaugeVec = dataDirScope.NewGaugeVec("total", "dir")
+	r.dataDirUsedGaugeVec = dataDirScope.NewGaugeVec("used", "dir")
+	r.dataDirFreeGaugeVec = dataDirScope.NewGaugeVec("free", "dir")
+	r.dataDirUsedPercentGaugeVec = dataDirScope.NewGaugeVec("used_percent", "dir")
+
+	databaseScope := systemScope.Scope("database_disk_usage_stats")
+	// disk usage of databases/shards
+	r.databaseUsedGaugeVec = databaseScope.NewGaugeVec("used", "db")
+	r.shardUsedGaugeVec = databaseScope.NewGaugeVec("shard_used", "db", "shard")
+
 	systemInodesScope := systemScope.Scope("disk_inodes_stats")
 	// disk inode
 	r.inodesFreeGauge = systemInodesScope.NewGauge("inodes_free")
@@ -193,6 +236,22 @@ func (r *SystemCollector) collect() {
 			collectorLogger.Error("get disk usage stat", logger.Error(err))
 		}
 	}
+	if len(r.dataDirs) > 0 {
+		stats := make(map[string]*disk.UsageStat, len(r.dataDirs))
+		for _, dir := range r.dataDirs {
+			stat, err := r.DiskUsageStatGetter(r.ctx, getExistPathFunc(dir))
+			if err != nil {
+				collectorLogger.Error("get disk usage stat of data dir",
+					logger.String("dir", dir), logger.Error(err))
+				continue
+			}
+			stats[dir] = stat
+		}
+		r.systemStat.DataDirUsageStats = stats
+	}
+	if r.shardUsage != nil {
+		r.collectDatabaseDiskUsage()
+	}
 	if stats, err := r.NetStatGetter(r.ctx); err != nil {
 		collectorLogger.Error("get net stat", logger.Error(err))
 	} else {
@@ -247,7 +306,55 @@ func (r *SystemCollector) logDiskUsageStat() {
 		r.inodesTotalGauge.Update(float64(stat.InodesTotal))
 		r.inodesUsedPercentGauge.Update(stat.InodesUsedPercent)
 	}
+	for dir, stat := range r.systemStat.DataDirUsageStats {
+		r.dataDirTotalGaugeVec.WithTagValues(dir).Update(float64(stat.Total))
+		r.dataDirUsedGaugeVec.WithTagValues(dir).Update(float64(stat.Used))
+		r.dataDirFreeGaugeVec.WithTagValues(dir).Update(float64(stat.Free))
+		r.dataDirUsedPercentGaugeVec.WithTagValues(dir).Update(stat.UsedPercent)
+	}
 }
+
+// collectDatabaseDiskUsage updates the disk usage of databases and shards based on shards' size of storage engine,
+// the series of dropped database/shard are removed.
+func (r *SystemCollector) collectDatabaseDiskUsage() {
+	databaseUsages := make(map[string]int64)
+	shardUsages := make(map[string]map[string]int64)
+	for databaseName, shards := range r.shardUsage() {
+		usages := make(map[string]int64, len(shards))
+		for shardID, size := range shards {
+			usages[shardID.String()] = size
+			databaseUsages[databaseName] += size
+		}
+		if _, ok := databaseUsages[databaseName]; !ok {
+			// database without shards
+			databaseUsages[databaseName] = 0
+		}
+		shardUsages[databaseName] = usages
+	}
+	for databaseName := range r.databaseUsages {
+		if _, ok := databaseUsages[databaseName]; !ok {
+			r.databaseUsedGaugeVec.DeleteTagValues(databaseName)
+		}
+	}
+	for databaseName, shards := range r.shardUsages {
+		for shardID := range shards {
+			if _, ok := shardUsages[databaseName][shardID]; !ok {
+				r.shardUsedGaugeVec.DeleteTagValues(databaseName, shardID)
+			}
+		}
+	}
+	for databaseName, size := range databaseUsages {
+		r.databaseUsedGaugeVec.WithTagValues(databaseName).Update(float64(size))
+	}
+	for databaseName, shards := range shardUsages {
+		for shardID, size := range shards {
+			r.shardUsedGaugeVec.WithTagValues(databaseName, shardID).Update(float64(size))
+		}
+	}
+	r.databaseUsages = databaseUsages
+	r.shardUsages = shardUsages
+}
+
 func (r *SystemCollector) logNetStat() {
 	for _, stat := range r.netStats {
 		lastStat, ok := r.netStats[stat.Name]
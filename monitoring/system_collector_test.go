@@ -24,11 +24,13 @@ import (
 	"time"
 
 	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/fileutil"
 
 	"github.com/golang/mock/gomock"
 	"github.com/shirou/gopsutil/disk"
 	"github.com/shirou/gopsutil/mem"
 	"github.com/shirou/gopsutil/net"
+	"github.com/stretchr/testify/assert"
 )
 
 func Test_NewSystemCollector(t *testing.T) {
@@ -93,3 +95,72 @@ func Test_SystemCollector_Collect(t *testing.T) {
 	collector.collect()
 	collector.collect()
 }
+
+func Test_SystemCollector_DataDirs(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	collector := NewSystemCollector(
+		ctx,
+		"/tmp",
+		&models.StatelessNode{},
+		"storage",
+	).WithDataDirs([]string{"/disk1/data", "/disk2/data"})
+	collector.DiskUsageStatGetter = func(ctx context.Context, path string) (*disk.UsageStat, error) {
+		if path == "/disk2" {
+			return nil, fmt.Errorf("error")
+		}
+		return &disk.UsageStat{Path: path, Total: 100, Used: 10}, nil
+	}
+	existPaths := map[string]string{"/disk1/data": "/disk1", "/disk2/data": "/disk2"}
+	getExistPathFunc = func(path string) string {
+		if p, ok := existPaths[path]; ok {
+			return p
+		}
+		return path
+	}
+	defer func() { getExistPathFunc = fileutil.GetExistPath }()
+	collector.collect()
+	stats := collector.nodeStat.System.DataDirUsageStats
+	assert.Len(t, stats, 1)
+	assert.Equal(t, "/disk1", stats["/disk1/data"].Path)
+	assert.Equal(t, uint64(100), stats["/disk1/data"].Total)
+}
+
+func Test_SystemCollector_DatabaseDiskUsage(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	usages := map[string]map[models.ShardID]int64{
+		"db1": {1: 100, 2: 50, 4: 200},
+		"db2": {3: 10},
+		"db3": {},
+	}
+	collector := NewSystemCollector(
+		ctx,
+		"",
+		&models.StatelessNode{},
+		"storage",
+	).WithShardDiskUsage(func() map[string]map[models.ShardID]int64 {
+		return usages
+	})
+	collector.collect()
+	db1Used := collector.databaseUsedGaugeVec.WithTagValues("db1").Get()
+	db2Used := collector.databaseUsedGaugeVec.WithTagValues("db2").Get()
+	assert.Equal(t, float64(350), db1Used)
+	assert.Equal(t, float64(10), db2Used)
+	assert.Greater(t, db1Used, db2Used)
+	assert.Zero(t, collector.databaseUsedGaugeVec.WithTagValues("db3").Get())
+	assert.Equal(t, float64(100), collector.shardUsedGaugeVec.WithTagValues("db1", "1").Get())
+	assert.Equal(t, float64(200), collector.shardUsedGaugeVec.WithTagValues("db1", "4").Get())
+	assert.Equal(t, float64(10), collector.shardUsedGaugeVec.WithTagValues("db2", "3").Get())
+
+	// series of dropped database/shard are removed
+	usages = map[string]map[models.ShardID]int64{
+		"db1": {1: 100, 4: 300},
+	}
+	collector.collect()
+	assert.Equal(t, float64(400), collector.databaseUsedGaugeVec.WithTagValues("db1").Get())
+	assert.Equal(t, float64(300), collector.shardUsedGaugeVec.WithTagValues("db1", "4").Get())
+	assert.Equal(t, map[string]int64{"db1": 400}, collector.databaseUsages)
+	assert.Equal(t, map[string]map[string]int64{"db1": {"1": 100, "4": 300}}, collector.shardUsages)
+}
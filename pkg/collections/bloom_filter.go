@@ -0,0 +1,148 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package collections
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+const bloomFilterHeaderLen = 4 + 4 + 4 // k + count + capacity
+
+// ErrBadBloomFilter represents the bloom filter data is corrupted.
+var ErrBadBloomFilter = errors.New("bad bloom filter data")
+
+// BloomFilter is a probabilistic set of 64-bit hash values,
+// which tells if a value is definitely absent or possibly present.
+// Not thread-safe.
+type BloomFilter struct {
+	bits     []uint64
+	k        uint32 // number of hash functions
+	count    uint32 // number of added values
+	capacity uint32 // expected number of values
+}
+
+// NewBloomFilter returns a new BloomFilter sized for capacity values with false positive rate.
+func NewBloomFilter(capacity uint32, fpRate float64) *BloomFilter {
+	if capacity == 0 {
+		capacity = 1
+	}
+	if fpRate <= 0 || fpRate >= 1 {
+		fpRate = 0.01
+	}
+	m := math.Ceil(-float64(capacity) * math.Log(fpRate) / (math.Ln2 * math.Ln2))
+	k := uint32(math.Round(m / float64(capacity) * math.Ln2))
+	if k == 0 {
+		k = 1
+	}
+	return &BloomFilter{
+		bits:     make([]uint64, (uint64(m)+63)/64),
+		k:        k,
+		capacity: capacity,
+	}
+}
+
+// Add adds the hash value.
+func (f *BloomFilter) Add(hash uint64) {
+	h1, h2 := f.hashes(hash)
+	m := uint32(len(f.bits) * 64)
+	for i := uint32(0); i < f.k; i++ {
+		pos := (h1 + i*h2) % m
+		f.bits[pos/64] |= 1 << (pos % 64)
+	}
+	f.count++
+}
+
+// Contains returns false if the hash value is definitely absent.
+func (f *BloomFilter) Contains(hash uint64) bool {
+	h1, h2 := f.hashes(hash)
+	m := uint32(len(f.bits) * 64)
+	for i := uint32(0); i < f.k; i++ {
+		pos := (h1 + i*h2) % m
+		if f.bits[pos/64]&(1<<(pos%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Count returns the number of added values.
+func (f *BloomFilter) Count() uint32 {
+	return f.count
+}
+
+// Capacity returns the expected number of values.
+func (f *BloomFilter) Capacity() uint32 {
+	return f.capacity
+}
+
+// Clone returns a deep copy of the filter.
+func (f *BloomFilter) Clone() *BloomFilter {
+	bits := make([]uint64, len(f.bits))
+	copy(bits, f.bits)
+	return &BloomFilter{
+		bits:     bits,
+		k:        f.k,
+		count:    f.count,
+		capacity: f.capacity,
+	}
+}
+
+// MarshalBinary returns the binary data of the filter.
+func (f *BloomFilter) MarshalBinary() []byte {
+	data := make([]byte, bloomFilterHeaderLen+len(f.bits)*8)
+	binary.LittleEndian.PutUint32(data[0:], f.k)
+	binary.LittleEndian.PutUint32(data[4:], f.count)
+	binary.LittleEndian.PutUint32(data[8:], f.capacity)
+	for i, word := range f.bits {
+		binary.LittleEndian.PutUint64(data[bloomFilterHeaderLen+i*8:], word)
+	}
+	return data
+}
+
+// UnmarshalBloomFilter creates the filter from binary data.
+func UnmarshalBloomFilter(data []byte) (*BloomFilter, error) {
+	if len(data) < bloomFilterHeaderLen+8 || (len(data)-bloomFilterHeaderLen)%8 != 0 {
+		return nil, ErrBadBloomFilter
+	}
+	f := &BloomFilter{
+		k:        binary.LittleEndian.Uint32(data[0:]),
+		count:    binary.LittleEndian.Uint32(data[4:]),
+		capacity: binary.LittleEndian.Uint32(data[8:]),
+		bits:     make([]uint64, (len(data)-bloomFilterHeaderLen)/8),
+	}
+	if f.k == 0 {
+		return nil, ErrBadBloomFilter
+	}
+	for i := range f.bits {
+		f.bits[i] = binary.LittleEndian.Uint64(data[bloomFilterHeaderLen+i*8:])
+	}
+	return f, nil
+}
+
+// hashes returns two hash values for double hashing, mixes the value first because it may be not well distributed.
+func (f *BloomFilter) hashes(hash uint64) (h1, h2 uint32) {
+	// splitmix64 finalizer
+	hash ^= hash >> 30
+	hash *= 0xbf58476d1ce4e5b9
+	hash ^= hash >> 27
+	hash *= 0x94d049bb133111eb
+	hash ^= hash >> 31
+	return uint32(hash), uint32(hash>>32) | 1
+}
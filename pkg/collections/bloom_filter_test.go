@@ -0,0 +1,70 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package collections
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBloomFilter(t *testing.T) {
+	f := NewBloomFilter(10000, 0.01)
+	for i := uint64(0); i < 10000; i++ {
+		f.Add(i)
+	}
+	assert.Equal(t, uint32(10000), f.Count())
+	assert.Equal(t, uint32(10000), f.Capacity())
+	// no false negative
+	for i := uint64(0); i < 10000; i++ {
+		assert.True(t, f.Contains(i))
+	}
+	// false positive rate
+	fp := 0
+	for i := uint64(10000); i < 20000; i++ {
+		if f.Contains(i) {
+			fp++
+		}
+	}
+	assert.Less(t, fp, 200)
+
+	f2 := f.Clone()
+	f2.Add(30000)
+	assert.True(t, f2.Contains(30000))
+	assert.Equal(t, uint32(10000), f.Count())
+
+	// invalid param
+	f3 := NewBloomFilter(0, 0)
+	f3.Add(1)
+	assert.True(t, f3.Contains(1))
+}
+
+func TestBloomFilter_Marshal(t *testing.T) {
+	f := NewBloomFilter(100, 0.01)
+	for i := uint64(0); i < 100; i++ {
+		f.Add(i * 31)
+	}
+	f2, err := UnmarshalBloomFilter(f.MarshalBinary())
+	assert.NoError(t, err)
+	assert.Equal(t, f, f2)
+
+	_, err = UnmarshalBloomFilter([]byte{1, 2, 3})
+	assert.Equal(t, ErrBadBloomFilter, err)
+	_, err = UnmarshalBloomFilter(make([]byte, bloomFilterHeaderLen+8))
+	assert.Equal(t, ErrBadBloomFilter, err)
+}
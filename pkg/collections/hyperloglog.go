@@ -0,0 +1,97 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package collections
+
+import (
+	"math"
+	"math/bits"
+)
+
+const (
+	// MinHyperLogLogPrecision represents the min precision of HyperLogLog, uses 16 registers.
+	MinHyperLogLogPrecision = 4
+	// MaxHyperLogLogPrecision represents the max precision of HyperLogLog, uses 65536 registers.
+	MaxHyperLogLogPrecision = 16
+)
+
+// HyperLogLog estimates the number of distinct 64-bit hash values(cardinality) with bounded memory,
+// it uses 2^precision registers of one byte, the standard error is about 1.04/sqrt(2^precision).
+// Not thread-safe.
+type HyperLogLog struct {
+	precision uint8
+	registers []uint8
+}
+
+// NewHyperLogLog returns a new HyperLogLog with precision, which is clamped into [4, 16].
+func NewHyperLogLog(precision int) *HyperLogLog {
+	if precision < MinHyperLogLogPrecision {
+		precision = MinHyperLogLogPrecision
+	}
+	if precision > MaxHyperLogLogPrecision {
+		precision = MaxHyperLogLogPrecision
+	}
+	return &HyperLogLog{
+		precision: uint8(precision),
+		registers: make([]uint8, 1<<precision),
+	}
+}
+
+// Add adds the hash value, the hash value should be uniformly distributed.
+func (h *HyperLogLog) Add(hash uint64) {
+	// first precision bits select the register, the remaining bits count the leading zeros
+	idx := hash >> (64 - h.precision)
+	// guard bit makes rank bounded when the remaining bits are all zero
+	w := hash<<h.precision | 1<<(h.precision-1)
+	rank := uint8(bits.LeadingZeros64(w)) + 1
+	if rank > h.registers[idx] {
+		h.registers[idx] = rank
+	}
+}
+
+// Count returns the estimated number of distinct hash values.
+func (h *HyperLogLog) Count() uint64 {
+	m := float64(len(h.registers))
+	sum := 0.0
+	zeros := 0
+	for _, rank := range h.registers {
+		sum += math.Ldexp(1, -int(rank))
+		if rank == 0 {
+			zeros++
+		}
+	}
+	estimate := h.alpha() * m * m / sum
+	// small range correction with linear counting
+	if estimate <= 2.5*m && zeros > 0 {
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return uint64(estimate + 0.5)
+}
+
+// alpha returns the bias correction constant by number of registers.
+func (h *HyperLogLog) alpha() float64 {
+	switch m := len(h.registers); m {
+	case 16:
+		return 0.673
+	case 32:
+		return 0.697
+	case 64:
+		return 0.709
+	default:
+		return 0.7213 / (1 + 1.079/float64(m))
+	}
+}
@@ -0,0 +1,55 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package collections
+
+import (
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHyperLogLog(t *testing.T) {
+	h := NewHyperLogLog(10)
+	assert.Zero(t, h.Count())
+	for _, distinct := range []int{10, 1000, 100000} {
+		h := NewHyperLogLog(10)
+		for i := 0; i < distinct; i++ {
+			hash := xxhash.Sum64String("value-" + strconv.Itoa(i))
+			// duplicated values are counted once
+			h.Add(hash)
+			h.Add(hash)
+		}
+		// standard error is about 3.25% with precision 10, accepts 3 times of it
+		errRate := math.Abs(float64(h.Count())-float64(distinct)) / float64(distinct)
+		assert.Less(t, errRate, 0.1, "distinct: %d, estimate: %d", distinct, h.Count())
+	}
+}
+
+func TestHyperLogLog_precision(t *testing.T) {
+	assert.Len(t, NewHyperLogLog(0).registers, 1<<MinHyperLogLogPrecision)
+	assert.Len(t, NewHyperLogLog(100).registers, 1<<MaxHyperLogLogPrecision)
+	for _, precision := range []int{4, 5, 6, 16} {
+		h := NewHyperLogLog(precision)
+		h.Add(0)
+		h.Add(math.MaxUint64)
+		assert.Equal(t, uint64(2), h.Count())
+	}
+}
@@ -67,6 +67,36 @@ func MkDir(path string) error {
 	return nil
 }
 
+// CheckWritable creates given dir if it not exist, then checks if files can be written into it
+func CheckWritable(path string) error {
+	if err := MkDirIfNotExist(path); err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(path, ".writable-check-")
+	if err != nil {
+		return err
+	}
+	name := f.Name()
+	if err := f.Close(); err != nil {
+		_ = removeFunc(name)
+		return err
+	}
+	return removeFunc(name)
+}
+
+// SyncFile commits the written data of file to stable storage.
+func SyncFile(file string) error {
+	f, err := os.OpenFile(file, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
 // ListDir reads the directory named by dirname and returns a list of filename.
 func ListDir(path string) ([]string, error) {
 	files, err := ioutil.ReadDir(path)
@@ -80,6 +110,24 @@ func ListDir(path string) ([]string, error) {
 	return result, nil
 }
 
+// DirSize returns the total size of regular files under the dir recursively
+func DirSize(path string) (int64, error) {
+	var size int64
+	err := filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return size, nil
+}
+
 // Exist check file or dir if exist
 func Exist(file string) bool {
 	if _, err := os.Stat(file); err != nil && os.IsNotExist(err) {
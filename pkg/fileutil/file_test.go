@@ -19,6 +19,7 @@ package fileutil
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"testing"
@@ -95,6 +96,20 @@ func TestListDir(t *testing.T) {
 	assert.Len(t, files, 1)
 }
 
+func TestDirSize(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, MkDir(filepath.Join(dir, "sub")))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "file1"), []byte("abc"), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "sub", "file2"), []byte("de"), 0644))
+	size, err := DirSize(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), size)
+
+	size, err = DirSize(filepath.Join(dir, "not_exist"))
+	assert.Error(t, err)
+	assert.Zero(t, size)
+}
+
 func TestRemoveFile(t *testing.T) {
 	_ = MkDirIfNotExist(testPath)
 
@@ -121,3 +136,31 @@ func TestRemoveFile(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Len(t, files, 1)
 }
+
+func TestCheckWritable(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, CheckWritable(filepath.Join(dir, "data")))
+	files, err := ListDir(filepath.Join(dir, "data"))
+	assert.NoError(t, err)
+	assert.Empty(t, files)
+
+	// path is a file
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "file"), []byte("abc"), 0644))
+	assert.Error(t, CheckWritable(filepath.Join(dir, "file")))
+
+	if os.Getuid() != 0 {
+		// read-only dir
+		readOnly := filepath.Join(dir, "read-only")
+		assert.NoError(t, os.Mkdir(readOnly, 0555))
+		assert.Error(t, CheckWritable(readOnly))
+	}
+}
+
+func TestSyncFile(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+	assert.NoError(t, ioutil.WriteFile(file, []byte("abc"), 0644))
+	assert.NoError(t, SyncFile(file))
+	// file not exist
+	assert.True(t, os.IsNotExist(SyncFile(filepath.Join(dir, "not-exist"))))
+}
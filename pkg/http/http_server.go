@@ -21,25 +21,32 @@ import (
 	"context"
 	"fmt"
 	"io/fs"
+	"net"
 	"net/http"
+	"strings"
 
 	"github.com/felixge/fgprof"
-	"github.com/gin-contrib/cors"
 	"github.com/gin-contrib/pprof"
 	"github.com/gin-gonic/gin"
 
 	"github.com/lindb/lindb"
 	"github.com/lindb/lindb/config"
 	"github.com/lindb/lindb/internal/conntrack"
+	"github.com/lindb/lindb/internal/linmetric"
 	"github.com/lindb/lindb/pkg/http/middleware"
 	"github.com/lindb/lindb/pkg/logger"
 )
 
-const _apiRootPath = "/api"
+const (
+	_apiRootPath = "/api"
+	// _prometheusMetricsPath is the path of metrics in Prometheus text exposition format.
+	_prometheusMetricsPath = "/metrics"
+)
 
 // Server represents http server with gin framework.
 type Server struct {
 	addr           string
+	cfg            config.HTTP
 	server         http.Server
 	gin            *gin.Engine
 	staticResource bool
@@ -51,6 +58,7 @@ type Server struct {
 func NewServer(cfg config.HTTP, staticResource bool) *Server {
 	s := &Server{
 		addr:           fmt.Sprintf(":%d", cfg.Port),
+		cfg:            cfg,
 		gin:            gin.New(),
 		staticResource: staticResource,
 		server: http.Server{
@@ -71,7 +79,17 @@ func (s *Server) init() {
 	// use AccessLog to log panic error with zap
 	s.gin.Use(middleware.AccessLog())
 	s.gin.Use(middleware.Recovery())
-	s.gin.Use(cors.Default())
+	if len(s.cfg.CORSAllowedOrigins) > 0 {
+		s.logger.Info("cross-origin requests are enabled", logger.Any("allowedOrigins", s.cfg.CORSAllowedOrigins))
+		s.gin.Use(middleware.CORS(s.cfg))
+	}
+	// register timeout before gzip, so that the timeout response is written without compression
+	if s.cfg.HandlerTimeout > 0 {
+		s.gin.Use(middleware.Timeout(s.cfg.HandlerTimeout.Duration(), middleware.IsStreamingRequest))
+	}
+	if s.cfg.Compression {
+		s.gin.Use(middleware.Gzip(int(s.cfg.CompressionMinSize)))
+	}
 
 	if logger.IsDebug() {
 		s.logger.Info("/debug/pprof is enabled")
@@ -79,6 +97,10 @@ func (s *Server) init() {
 		s.logger.Info("/debug/fgprof is enabled")
 		s.gin.GET("/debug/fgprof", gin.WrapH(fgprof.Handler()))
 	}
+	if s.cfg.PrometheusMetrics {
+		s.logger.Info("prometheus metrics endpoint is enabled", logger.String("path", _prometheusMetricsPath))
+		s.gin.GET(_prometheusMetricsPath, s.prometheusMetrics)
+	}
 	if s.staticResource {
 		// server static file
 		staticFS, err := fs.Sub(lindb.StaticContent, "web/static")
@@ -96,6 +118,22 @@ func (s *Server) init() {
 	}
 }
 
+// prometheusMetrics writes the internal metrics in OpenMetrics text format with exemplars
+// if request accepts it, otherwise in Prometheus text exposition format.
+func (s *Server) prometheusMetrics(c *gin.Context) {
+	write := linmetric.WritePrometheus
+	contentType := linmetric.PrometheusContentType
+	if strings.Contains(c.GetHeader("Accept"), "application/openmetrics-text") {
+		write = linmetric.WriteOpenMetrics
+		contentType = linmetric.OpenMetricsContentType
+	}
+	c.Header("Content-Type", contentType)
+	c.Status(http.StatusOK)
+	if err := write(c.Writer); err != nil {
+		s.logger.Warn("write prometheus metrics failure", logger.Error(err))
+	}
+}
+
 // GetAPIRouter returns api router.
 func (s *Server) GetAPIRouter() *gin.RouterGroup {
 	return s.gin.Group(_apiRootPath)
@@ -110,7 +148,12 @@ func (s *Server) Run() error {
 	if err != nil {
 		return err
 	}
-	return s.server.Serve(trackedListener)
+	var listener net.Listener = trackedListener
+	if s.cfg.MaxConnections > 0 {
+		s.logger.Info("limit concurrent connections of http server", logger.Int("maxConnections", s.cfg.MaxConnections))
+		listener = newLimitListener(trackedListener, s.addr, s.cfg.MaxConnections)
+	}
+	return s.server.Serve(listener)
 }
 
 // Close closes the server.
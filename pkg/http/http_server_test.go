@@ -19,15 +19,21 @@ package http
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"github.com/gin-gonic/gin"
+
 	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/internal/linmetric"
 
 	"github.com/stretchr/testify/assert"
 )
 
 func TestNewHTTPServer(t *testing.T) {
-	s := NewServer(config.HTTP{Port: 9999}, true)
+	s := NewServer(config.HTTP{Port: 9999, Compression: true}, true)
 	assert.NotNil(t, s.GetAPIRouter())
 	go func() {
 		_ = s.Run()
@@ -36,3 +42,94 @@ func TestNewHTTPServer(t *testing.T) {
 		_ = s.Close(context.TODO())
 	}()
 }
+
+func TestServer_CORS(t *testing.T) {
+	s := NewServer(config.HTTP{
+		Port:               9999,
+		CORSAllowedOrigins: []string{"https://dashboard.lindb.io"},
+		CORSAllowedMethods: []string{http.MethodGet, http.MethodPost},
+		CORSAllowedHeaders: []string{"Content-Type", "Authorization"},
+	}, false)
+	s.GetAPIRouter().GET("/cors", func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+	do := func(method, origin string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/cors", nil)
+		req.Header.Set("Origin", origin)
+		if method == http.MethodOptions {
+			req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+		}
+		resp := httptest.NewRecorder()
+		s.gin.ServeHTTP(resp, req)
+		return resp
+	}
+	// case 1: allowed origin
+	resp := do(http.MethodGet, "https://dashboard.lindb.io")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "https://dashboard.lindb.io", resp.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, resp.Header().Get("Access-Control-Allow-Credentials"))
+	// case 2: preflight of allowed origin
+	resp = do(http.MethodOptions, "https://dashboard.lindb.io")
+	assert.Equal(t, http.StatusNoContent, resp.Code)
+	assert.Equal(t, "https://dashboard.lindb.io", resp.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "GET,POST", resp.Header().Get("Access-Control-Allow-Methods"))
+	assert.Equal(t, "Content-Type,Authorization", resp.Header().Get("Access-Control-Allow-Headers"))
+	// case 3: disallowed origin
+	resp = do(http.MethodGet, "https://evil.example.com")
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+	assert.Empty(t, resp.Header().Get("Access-Control-Allow-Origin"))
+
+	// case 4: CORS disabled by default, no CORS headers
+	s = NewServer(config.HTTP{Port: 9999}, false)
+	s.GetAPIRouter().GET("/cors", func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+	resp = do(http.MethodGet, "https://dashboard.lindb.io")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Empty(t, resp.Header().Get("Access-Control-Allow-Origin"))
+
+	// case 5: wildcard origin
+	s = NewServer(config.HTTP{Port: 9999, CORSAllowedOrigins: []string{"*"}}, false)
+	s.GetAPIRouter().GET("/cors", func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+	resp = do(http.MethodGet, "https://any.example.com")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "*", resp.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestServer_PrometheusMetrics(t *testing.T) {
+	scope := linmetric.NewScope("lindb.http_test", "node", "n1")
+	scope.NewCounterVec("hits", "path").WithTagValues("/api").Add(2)
+	scope.Scope("latency").NewHistogram().UpdateMillisecondsWithExemplar(20, "trace-1")
+	do := func(s *Server, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, _prometheusMetricsPath, nil)
+		req.Header.Set("Accept", accept)
+		resp := httptest.NewRecorder()
+		s.gin.ServeHTTP(resp, req)
+		return resp
+	}
+	// case 1: disabled by default
+	resp := do(NewServer(config.HTTP{Port: 9999}, false), "")
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+	// case 2: enabled, prometheus text format without exemplars
+	s := NewServer(config.HTTP{Port: 9999, PrometheusMetrics: true}, false)
+	resp = do(s, "text/plain;version=0.0.4;q=0.5,*/*;q=0.1")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, linmetric.PrometheusContentType, resp.Header().Get("Content-Type"))
+	body := resp.Body.String()
+	assert.Contains(t, body, "# TYPE lindb_http_test_hits counter\n"+
+		`lindb_http_test_hits{node="n1",path="/api"} 2`+"\n")
+	assert.Contains(t, body, "# TYPE lindb_http_test_latency histogram\n")
+	assert.NotContains(t, body, "trace_id")
+	assert.NotContains(t, body, "# EOF")
+	// case 3: openmetrics format with exemplars
+	resp = do(s, "application/openmetrics-text;version=1.0.0,text/plain;version=0.0.4;q=0.5")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, linmetric.OpenMetricsContentType, resp.Header().Get("Content-Type"))
+	body = resp.Body.String()
+	assert.Contains(t, body, "# TYPE lindb_http_test_hits counter\n"+
+		`lindb_http_test_hits_total{node="n1",path="/api"} 2`+"\n")
+	assert.Regexp(t, `lindb_http_test_latency_bucket\{node="n1",le="[0-9.]+"\} 1 # \{trace_id="trace-1"\} 20 [0-9]+\.[0-9]{3}\n`, body)
+	assert.True(t, strings.HasSuffix(body, "# EOF\n"))
+}
@@ -0,0 +1,103 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package http
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"go.uber.org/atomic"
+
+	"github.com/lindb/lindb/internal/linmetric"
+)
+
+// rejectResponse is written to the connection rejected by limit listener before closing it.
+const rejectResponse = "HTTP/1.1 503 Service Unavailable\r\nConnection: close\r\nContent-Length: 0\r\n\r\n"
+
+// rejectWriteTimeout is the max time for writing reject response, so that slow clients cannot block accepting.
+const rejectWriteTimeout = 100 * time.Millisecond
+
+var (
+	httpServerScope  = linmetric.NewScope("lindb.http.server")
+	activeConnsVec   = httpServerScope.NewGaugeVec("active_conns", "addr")
+	rejectedConnsVec = httpServerScope.NewCounterVec("rejected_conns", "addr")
+)
+
+// limitListener limits the number of concurrent connections accepted by listener,
+// new connections beyond the limit are rejected with 503 response and closed immediately,
+// instead of queueing them, so that connection bursts or slow clients cannot exhaust resources.
+type limitListener struct {
+	net.Listener
+	maxConns int64
+	active   atomic.Int64
+
+	statistics struct {
+		activeConns   *linmetric.BoundGauge
+		rejectedConns *linmetric.BoundCounter
+	}
+}
+
+// newLimitListener creates a listener which accepts at most maxConns concurrent connections.
+func newLimitListener(ln net.Listener, addr string, maxConns int) net.Listener {
+	l := &limitListener{
+		Listener: ln,
+		maxConns: int64(maxConns),
+	}
+	l.statistics.activeConns = activeConnsVec.WithTagValues(addr)
+	l.statistics.rejectedConns = rejectedConnsVec.WithTagValues(addr)
+	return l
+}
+
+// Accept waits for and returns the next connection under the limit, rejects connections beyond the limit.
+func (l *limitListener) Accept() (net.Conn, error) {
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+		if l.active.Inc() > l.maxConns {
+			l.active.Dec()
+			l.statistics.rejectedConns.Incr()
+			_ = conn.SetWriteDeadline(time.Now().Add(rejectWriteTimeout))
+			_, _ = conn.Write([]byte(rejectResponse))
+			_ = conn.Close()
+			continue
+		}
+		l.statistics.activeConns.Update(float64(l.active.Load()))
+		return &limitConn{Conn: conn, listener: l}, nil
+	}
+}
+
+// release decreases the number of active connections.
+func (l *limitListener) release() {
+	l.statistics.activeConns.Update(float64(l.active.Dec()))
+}
+
+// limitConn releases the slot of limit listener when closed.
+type limitConn struct {
+	net.Conn
+	listener    *limitListener
+	releaseOnce sync.Once
+}
+
+// Close closes the connection, then releases the slot of limit listener once.
+func (c *limitConn) Close() error {
+	err := c.Conn.Close()
+	c.releaseOnce.Do(c.listener.release)
+	return err
+}
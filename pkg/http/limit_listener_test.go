@@ -0,0 +1,77 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package http
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimitListener(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	addr := ln.Addr().String()
+	limitLn := newLimitListener(ln, addr, 2).(*limitListener)
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})}
+	go func() {
+		_ = server.Serve(limitLn)
+	}()
+	defer func() {
+		_ = server.Close()
+	}()
+
+	// open connections up to the limit, keep them idle
+	var conns []net.Conn
+	for i := 0; i < 2; i++ {
+		conn, err := net.Dial("tcp", addr)
+		assert.NoError(t, err)
+		conns = append(conns, conn)
+	}
+	assert.Eventually(t, func() bool {
+		return limitLn.active.Load() == 2
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, float64(2), limitLn.statistics.activeConns.Get())
+
+	// excess connection is rejected
+	conn, err := net.Dial("tcp", addr)
+	assert.NoError(t, err)
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	_ = conn.Close()
+	assert.Equal(t, float64(1), limitLn.statistics.rejectedConns.Get())
+	assert.Equal(t, int64(2), limitLn.active.Load())
+
+	// connection is accepted after one closed
+	_ = conns[0].Close()
+	assert.Eventually(t, func() bool {
+		return limitLn.active.Load() == 1
+	}, time.Second, 10*time.Millisecond)
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	resp, err = client.Get("http://" + addr + "/")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	_ = resp.Body.Close()
+	_ = conns[1].Close()
+}
@@ -18,6 +18,7 @@
 package middleware
 
 import (
+	"encoding/hex"
 	"net"
 	"net/http"
 	"net/url"
@@ -36,6 +37,11 @@ var (
 	pathUnescapeFunc = url.PathUnescape
 )
 
+const (
+	traceParentHeader = "traceparent"
+	invalidTraceID    = "00000000000000000000000000000000"
+)
+
 var (
 	HTTPHandlerTimerVec = linmetric.
 		NewScope("lindb.http_server.handle_duration").
@@ -43,14 +49,30 @@ var (
 		WithExponentBuckets(time.Millisecond, time.Second*5, 20)
 )
 
+// WithHistogram records the latency of request into histogram,
+// the trace id of request is recorded as exemplar if request is traced.
 func WithHistogram(histogram *linmetric.BoundHistogram) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
-		defer histogram.UpdateSince(start)
+		traceID := TraceID(c.Request)
+		defer histogram.UpdateSinceWithExemplar(start, traceID)
 		c.Next()
 	}
 }
 
+// TraceID returns the trace id of request from W3C traceparent header(version-traceid-parentid-flags),
+// returns empty string if request is not traced or header is invalid.
+func TraceID(r *http.Request) string {
+	parts := strings.Split(r.Header.Get(traceParentHeader), "-")
+	if len(parts) < 4 || len(parts[1]) != 32 || parts[1] == invalidTraceID {
+		return ""
+	}
+	if _, err := hex.DecodeString(parts[1]); err != nil {
+		return ""
+	}
+	return strings.ToLower(parts[1])
+}
+
 // AccessLog returns access log middleware
 func AccessLog() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -18,14 +18,17 @@
 package middleware
 
 import (
+	"bytes"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"testing"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/lindb/lindb/internal/linmetric"
 	"github.com/lindb/lindb/internal/mock"
 )
 
@@ -58,3 +61,34 @@ func Test_real_ip(t *testing.T) {
 	req.RemoteAddr = "1.1.1.1:1023"
 	assert.Equal(t, "1.1.1.1", realIP(req))
 }
+
+func TestTraceID(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/exec", nil)
+	assert.Empty(t, TraceID(req))
+	req.Header.Set("traceparent", "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01")
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", TraceID(req))
+	req.Header.Set("traceparent", "00-00000000000000000000000000000000-00f067aa0ba902b7-01")
+	assert.Empty(t, TraceID(req))
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e47-00f067aa0ba902b7-01")
+	assert.Empty(t, TraceID(req))
+	req.Header.Set("traceparent", "00-xbf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	assert.Empty(t, TraceID(req))
+}
+
+func TestWithHistogram(t *testing.T) {
+	histogram := linmetric.NewScope("lindb.test.with_histogram").NewHistogram()
+	r := gin.New()
+	r.Use(WithHistogram(histogram))
+	r.GET("/test", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	req, _ := http.NewRequest(http.MethodGet, "/test", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	count, _ := histogram.Total()
+	assert.Equal(t, float64(1), count)
+
+	var buf bytes.Buffer
+	assert.NoError(t, linmetric.WriteOpenMetrics(&buf))
+	assert.Contains(t, buf.String(), `trace_id="4bf92f3577b34da6a3ce929d0e0e4736"`)
+}
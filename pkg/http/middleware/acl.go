@@ -0,0 +1,93 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/internal/linmetric"
+)
+
+// ErrAccessDenied represents the token is not allowed to access the namespace.
+var ErrAccessDenied = errors.New("access denied")
+
+var aclDeniedCounterVec = linmetric.NewScope("lindb.http_server.acl").NewCounterVec("denied", "operation")
+
+// aclEntry represents the allowed namespaces and operations of token.
+type aclEntry struct {
+	allNamespaces bool
+	namespaces    map[string]struct{}
+	operations    map[string]struct{}
+}
+
+// NamespaceACL checks if the request token is allowed to do the operation on namespace.
+type NamespaceACL struct {
+	entries map[string]*aclEntry // token => acl entry
+}
+
+// NewNamespaceACL creates namespace acl, returns nil if no acl configured(all requests are allowed).
+func NewNamespaceACL(acls []config.ACL) *NamespaceACL {
+	if len(acls) == 0 {
+		return nil
+	}
+	a := &NamespaceACL{entries: make(map[string]*aclEntry)}
+	for _, acl := range acls {
+		entry := &aclEntry{
+			namespaces: make(map[string]struct{}),
+			operations: make(map[string]struct{}),
+		}
+		for _, namespace := range acl.Namespaces {
+			if namespace == "*" {
+				entry.allNamespaces = true
+			}
+			entry.namespaces[namespace] = struct{}{}
+		}
+		for _, op := range acl.Operations {
+			entry.operations[op] = struct{}{}
+		}
+		a.entries[acl.Token] = entry
+	}
+	return a
+}
+
+// Check checks if the token of request is allowed to do the operation on namespace,
+// returns ErrAccessDenied if not. Nil acl allows all requests.
+func (a *NamespaceACL) Check(req *http.Request, namespace, operation string) error {
+	if a == nil {
+		return nil
+	}
+	entry, ok := a.entries[RequestToken(req)]
+	if ok {
+		_, allowedOp := entry.operations[operation]
+		_, allowedNamespace := entry.namespaces[namespace]
+		if allowedOp && (allowedNamespace || entry.allNamespaces) {
+			return nil
+		}
+	}
+	aclDeniedCounterVec.WithTagValues(operation).Incr()
+	return fmt.Errorf("%w: %s namespace: %s", ErrAccessDenied, operation, namespace)
+}
+
+// RequestToken returns the api token carried by request header: Authorization: Bearer <token>.
+func RequestToken(req *http.Request) string {
+	return strings.TrimSpace(strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer "))
+}
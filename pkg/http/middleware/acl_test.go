@@ -0,0 +1,66 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/config"
+)
+
+func TestNamespaceACL_Check(t *testing.T) {
+	newReq := func(token string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		return req
+	}
+	// case 1: no acl, all allowed
+	var acl *NamespaceACL
+	assert.Nil(t, NewNamespaceACL(nil))
+	assert.NoError(t, acl.Check(newReq(""), "a", config.ACLOperationWrite))
+
+	acl = NewNamespaceACL([]config.ACL{
+		{Token: "writer-a", Namespaces: []string{"a"}, Operations: []string{config.ACLOperationWrite}},
+		{Token: "reader-b", Namespaces: []string{"b"}, Operations: []string{config.ACLOperationRead}},
+		{Token: "admin", Namespaces: []string{"*"},
+			Operations: []string{config.ACLOperationRead, config.ACLOperationWrite}},
+	})
+	denied := aclDeniedCounterVec.WithTagValues(config.ACLOperationWrite).Get()
+	// case 2: allowed
+	assert.NoError(t, acl.Check(newReq("writer-a"), "a", config.ACLOperationWrite))
+	assert.NoError(t, acl.Check(newReq("reader-b"), "b", config.ACLOperationRead))
+	assert.NoError(t, acl.Check(newReq("admin"), "a", config.ACLOperationRead))
+	assert.NoError(t, acl.Check(newReq("admin"), "b", config.ACLOperationWrite))
+	// case 3: namespace out of scope
+	err := acl.Check(newReq("writer-a"), "b", config.ACLOperationWrite)
+	assert.True(t, errors.Is(err, ErrAccessDenied))
+	// case 4: operation not allowed
+	assert.True(t, errors.Is(acl.Check(newReq("writer-a"), "a", config.ACLOperationRead), ErrAccessDenied))
+	assert.True(t, errors.Is(acl.Check(newReq("reader-b"), "b", config.ACLOperationWrite), ErrAccessDenied))
+	// case 5: unknown/missing token
+	assert.True(t, errors.Is(acl.Check(newReq("unknown"), "a", config.ACLOperationWrite), ErrAccessDenied))
+	assert.True(t, errors.Is(acl.Check(newReq(""), "a", config.ACLOperationWrite), ErrAccessDenied))
+	assert.Equal(t, denied+4, aclDeniedCounterVec.WithTagValues(config.ACLOperationWrite).Get())
+}
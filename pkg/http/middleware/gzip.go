@@ -0,0 +1,142 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+var gzipWriterPool = sync.Pool{
+	New: func() interface{} {
+		return gzip.NewWriter(nil)
+	},
+}
+
+// gzipResponseWriter buffers the response until it reaches the min size,
+// then compresses the response, otherwise writes the buffered response as it is.
+type gzipResponseWriter struct {
+	gin.ResponseWriter
+
+	minSize int
+	buf     bytes.Buffer
+	gw      *gzip.Writer // not nil if compressing
+	plain   bool         // true if writing response without compression
+}
+
+func (w *gzipResponseWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *gzipResponseWriter) Write(data []byte) (int, error) {
+	switch {
+	case w.gw != nil:
+		return w.gw.Write(data)
+	case w.plain:
+		return w.ResponseWriter.Write(data)
+	}
+	w.buf.Write(data)
+	if w.buf.Len() < w.minSize {
+		return len(data), nil
+	}
+	if err := w.startGzip(); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+// startGzip starts compressing if response is not encoded, writes the buffered response.
+func (w *gzipResponseWriter) startGzip() error {
+	header := w.Header()
+	if header.Get("Content-Encoding") != "" {
+		return w.startPlain()
+	}
+	header.Set("Content-Encoding", "gzip")
+	header.Add("Vary", "Accept-Encoding")
+	header.Del("Content-Length")
+	gw := gzipWriterPool.Get().(*gzip.Writer)
+	gw.Reset(w.ResponseWriter)
+	w.gw = gw
+	_, err := w.gw.Write(w.buf.Bytes())
+	w.buf.Reset()
+	return err
+}
+
+// startPlain writes the buffered response without compression.
+func (w *gzipResponseWriter) startPlain() error {
+	w.plain = true
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	_, err := w.ResponseWriter.Write(w.buf.Bytes())
+	w.buf.Reset()
+	return err
+}
+
+// Flush writes the buffered response, response is compressed only if it reaches the min size.
+func (w *gzipResponseWriter) Flush() {
+	if w.gw == nil && !w.plain {
+		if w.buf.Len() >= w.minSize {
+			_ = w.startGzip()
+		} else {
+			_ = w.startPlain()
+		}
+	}
+	if w.gw != nil {
+		_ = w.gw.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// close completes the response after handler returns.
+func (w *gzipResponseWriter) close() {
+	if w.gw != nil {
+		_ = w.gw.Close()
+		w.gw.Reset(nil)
+		gzipWriterPool.Put(w.gw)
+		w.gw = nil
+		return
+	}
+	if !w.plain {
+		_ = w.startPlain()
+	}
+}
+
+// Gzip returns gzip compression middleware, compresses the response which size >= min size
+// if request accepts gzip encoding.
+func Gzip(minSize int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodHead ||
+			!strings.Contains(c.Request.Header.Get("Accept-Encoding"), "gzip") {
+			c.Next()
+			return
+		}
+		w := &gzipResponseWriter{ResponseWriter: c.Writer, minSize: minSize}
+		c.Writer = w
+		defer func() {
+			w.close()
+			c.Writer = w.ResponseWriter
+		}()
+		c.Next()
+	}
+}
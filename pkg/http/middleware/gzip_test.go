@@ -0,0 +1,89 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package middleware
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGzip(t *testing.T) {
+	large := strings.Repeat("lindb", 1000)
+	r := gin.New()
+	r.Use(Gzip(1024))
+	r.GET("/large", func(c *gin.Context) {
+		c.String(http.StatusOK, large)
+	})
+	r.GET("/small", func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+	r.GET("/encoded", func(c *gin.Context) {
+		c.Header("Content-Encoding", "br")
+		c.String(http.StatusOK, large)
+	})
+	r.GET("/flush", func(c *gin.Context) {
+		_, _ = c.Writer.WriteString("ok")
+		c.Writer.Flush()
+		_, _ = c.Writer.WriteString(large)
+	})
+	do := func(path string, acceptGzip bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if acceptGzip {
+			req.Header.Set("Accept-Encoding", "gzip, deflate")
+		}
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, req)
+		return resp
+	}
+
+	// case 1: large response, gzip requested
+	resp := do("/large", true)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "gzip", resp.Header().Get("Content-Encoding"))
+	assert.Equal(t, "Accept-Encoding", resp.Header().Get("Vary"))
+	assert.Less(t, resp.Body.Len(), len(large))
+	gr, err := gzip.NewReader(resp.Body)
+	assert.NoError(t, err)
+	body, err := ioutil.ReadAll(gr)
+	assert.NoError(t, err)
+	assert.Equal(t, large, string(body))
+	// case 2: large response, gzip not requested
+	resp = do("/large", false)
+	assert.Empty(t, resp.Header().Get("Content-Encoding"))
+	assert.Equal(t, large, resp.Body.String())
+	// case 3: small response, gzip requested
+	resp = do("/small", true)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Empty(t, resp.Header().Get("Content-Encoding"))
+	assert.Equal(t, "ok", resp.Body.String())
+	// case 4: response already encoded
+	resp = do("/encoded", true)
+	assert.Equal(t, "br", resp.Header().Get("Content-Encoding"))
+	assert.Equal(t, large, resp.Body.String())
+	// case 5: flushed before reaching min size, not compressed
+	resp = do("/flush", true)
+	assert.Empty(t, resp.Header().Get("Content-Encoding"))
+	assert.Equal(t, "ok"+large, resp.Body.String())
+}
@@ -0,0 +1,252 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/lindb/lindb/pkg/logger"
+)
+
+// timeoutResponseBody is the response body when handler exceeds the timeout.
+const timeoutResponseBody = "handle request timeout"
+
+// timeoutResponseWriter buffers the response of handler, writes the buffered response
+// after handler returns in time, discards all writes after timeout.
+// Once handler flushes(e.g. server-sent events), the response is committed and all writes
+// pass through to the underlying writer.
+type timeoutResponseWriter struct {
+	gin.ResponseWriter
+
+	mutex       sync.Mutex
+	header      http.Header
+	buf         bytes.Buffer
+	code        int
+	written     bool
+	timedOut    bool
+	passThrough bool
+}
+
+// newTimeoutResponseWriter creates a timeout response writer which inherits the header of writer.
+func newTimeoutResponseWriter(w gin.ResponseWriter) *timeoutResponseWriter {
+	return &timeoutResponseWriter{
+		ResponseWriter: w,
+		header:         w.Header().Clone(),
+		code:           http.StatusOK,
+	}
+}
+
+func (w *timeoutResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *timeoutResponseWriter) WriteHeader(code int) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	// ignores the code(-1) of rendering without status, same as gin's writer
+	if code <= 0 || w.timedOut || w.written || w.passThrough {
+		return
+	}
+	w.code = code
+}
+
+func (w *timeoutResponseWriter) WriteHeaderNow() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.written = true
+}
+
+func (w *timeoutResponseWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *timeoutResponseWriter) Write(data []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	w.written = true
+	if w.passThrough {
+		return w.ResponseWriter.Write(data)
+	}
+	return w.buf.Write(data)
+}
+
+// Flush commits the buffered response, then passes through all writes after it.
+func (w *timeoutResponseWriter) Flush() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.timedOut {
+		return
+	}
+	if !w.passThrough {
+		w.writeBuffered()
+		w.passThrough = true
+	}
+	w.ResponseWriter.Flush()
+}
+
+func (w *timeoutResponseWriter) Status() int {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	return w.code
+}
+
+func (w *timeoutResponseWriter) Size() int {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.passThrough {
+		return w.ResponseWriter.Size()
+	}
+	if !w.written {
+		return -1
+	}
+	return w.buf.Len()
+}
+
+func (w *timeoutResponseWriter) Written() bool {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	return w.written
+}
+
+// timeout marks the response timed out, writes 503 response to client.
+// If the response has been committed, handler ends it after seeing the canceled context.
+func (w *timeoutResponseWriter) timeout() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.passThrough {
+		return
+	}
+	w.timedOut = true
+	w.ResponseWriter.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.ResponseWriter.WriteHeader(http.StatusServiceUnavailable)
+	_, _ = w.ResponseWriter.WriteString(timeoutResponseBody)
+	w.ResponseWriter.Flush()
+}
+
+// complete writes the buffered response after handler returns in time.
+func (w *timeoutResponseWriter) complete() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.passThrough {
+		return
+	}
+	w.writeBuffered()
+}
+
+// writeBuffered writes the header and buffered body to the underlying writer.
+func (w *timeoutResponseWriter) writeBuffered() {
+	dst := w.ResponseWriter.Header()
+	for k, v := range w.header {
+		dst[k] = v
+	}
+	w.ResponseWriter.WriteHeader(w.code)
+	if w.buf.Len() > 0 {
+		_, _ = w.ResponseWriter.Write(w.buf.Bytes())
+		w.buf.Reset()
+	}
+}
+
+// IsStreamingRequest returns if the request is served as a long-lived stream or download,
+// e.g. server-sent events of streaming query, pprof profile/trace download.
+func IsStreamingRequest(req *http.Request) bool {
+	if strings.HasPrefix(req.URL.Path, "/debug/") {
+		return true
+	}
+	if strings.Contains(req.Header.Get("Accept"), "text/event-stream") {
+		return true
+	}
+	stream, _ := strconv.ParseBool(req.URL.Query().Get("stream"))
+	return stream
+}
+
+// Timeout returns handler timeout middleware, cancels the request context and responds
+// 503 Service Unavailable if the handler doesn't return within the timeout.
+// It waits for the handler returning before finishing the request, so that the gin context
+// isn't reused while handler is running, handler should respect the canceled context.
+// The request which exempt returns true is handled without timeout, exempt can be nil.
+func Timeout(timeout time.Duration, exempt func(req *http.Request) bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if exempt != nil && exempt(c.Request) {
+			c.Next()
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+
+		req := c.Request
+		c.Request = req.WithContext(ctx)
+		w := newTimeoutResponseWriter(c.Writer)
+		c.Writer = w
+
+		done := make(chan struct{})
+		var panicErr interface{}
+		go func() {
+			defer func() {
+				panicErr = recover()
+				close(done)
+			}()
+			c.Next()
+		}()
+
+		timedOut := false
+		select {
+		case <-done:
+		case <-ctx.Done():
+			// request context may be canceled by client, only responds 503 when deadline exceeded
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				timedOut = true
+				log.Warn("handle http request timeout",
+					logger.String("method", req.Method),
+					logger.String("path", req.URL.Path),
+					logger.String("timeout", timeout.String()))
+				w.timeout()
+			}
+			<-done
+		}
+		if !timedOut && panicErr == nil {
+			w.complete()
+		}
+		c.Writer = w.ResponseWriter
+		c.Request = req
+		if panicErr != nil {
+			// re-panic in request goroutine, so that recovery middleware can handle it
+			panic(panicErr)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package middleware
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeout(t *testing.T) {
+	large := strings.Repeat("lindb", 1000)
+	r := gin.New()
+	r.Use(Recovery())
+	r.Use(Timeout(50*time.Millisecond, IsStreamingRequest))
+	r.Use(Gzip(1024))
+	r.GET("/slow", func(c *gin.Context) {
+		select {
+		case <-c.Request.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+		c.String(http.StatusOK, "too late")
+	})
+	r.GET("/fast", func(c *gin.Context) {
+		c.Header("X-Lindb", "fast")
+		c.String(http.StatusCreated, large)
+	})
+	r.GET("/panic", func(c *gin.Context) {
+		panic("err")
+	})
+	flushed := make(chan struct{})
+	proceed := make(chan struct{})
+	r.GET("/stream", func(c *gin.Context) {
+		c.Status(http.StatusAccepted)
+		c.SSEvent("result", "first")
+		c.Writer.Flush()
+		close(flushed)
+		<-proceed
+		<-c.Request.Context().Done()
+		c.SSEvent("error", "last")
+	})
+	r.POST("/limit", func(c *gin.Context) {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, 10)
+		if _, err := ioutil.ReadAll(c.Request.Body); err != nil {
+			c.String(http.StatusRequestEntityTooLarge, err.Error())
+			return
+		}
+		c.String(http.StatusOK, "ok")
+	})
+	do := func(method, path string, acceptGzip bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(large))
+		if acceptGzip {
+			req.Header.Set("Accept-Encoding", "gzip")
+		}
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, req)
+		return resp
+	}
+
+	// case 1: slow handler exceeds timeout
+	now := time.Now()
+	resp := do(http.MethodGet, "/slow", true)
+	assert.Less(t, time.Since(now), time.Second)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.Code)
+	assert.Empty(t, resp.Header().Get("Content-Encoding"))
+	assert.Equal(t, timeoutResponseBody, resp.Body.String())
+	// case 2: fast handler with gzip response
+	resp = do(http.MethodGet, "/fast", true)
+	assert.Equal(t, http.StatusCreated, resp.Code)
+	assert.Equal(t, "fast", resp.Header().Get("X-Lindb"))
+	assert.Equal(t, "gzip", resp.Header().Get("Content-Encoding"))
+	gr, err := gzip.NewReader(resp.Body)
+	assert.NoError(t, err)
+	body, err := ioutil.ReadAll(gr)
+	assert.NoError(t, err)
+	assert.Equal(t, large, string(body))
+	// case 3: fast handler without gzip
+	resp = do(http.MethodGet, "/fast", false)
+	assert.Equal(t, http.StatusCreated, resp.Code)
+	assert.Equal(t, large, resp.Body.String())
+	// case 4: body limit
+	resp = do(http.MethodPost, "/limit", false)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.Code)
+	// case 5: panic is handled by recovery
+	resp = do(http.MethodGet, "/panic", false)
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	// case 6: flushed response passes through, not replaced by timeout response
+	resp = httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		r.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/stream", nil))
+		close(done)
+	}()
+	<-flushed
+	assert.True(t, resp.Flushed)
+	assert.Equal(t, http.StatusAccepted, resp.Code)
+	assert.Equal(t, "event:result\ndata:first\n\n", resp.Body.String())
+	close(proceed)
+	<-done
+	assert.Equal(t, http.StatusAccepted, resp.Code)
+	assert.Equal(t, "event:result\ndata:first\n\nevent:error\ndata:last\n\n", resp.Body.String())
+}
+
+func TestTimeout_exempt(t *testing.T) {
+	r := gin.New()
+	r.Use(Timeout(50*time.Millisecond, IsStreamingRequest))
+	r.GET("/slow", func(c *gin.Context) {
+		select {
+		case <-c.Request.Context().Done():
+		case <-time.After(100 * time.Millisecond):
+			c.String(http.StatusOK, "done")
+		}
+	})
+	r.GET("/debug/pprof/profile", func(c *gin.Context) {
+		select {
+		case <-c.Request.Context().Done():
+		case <-time.After(100 * time.Millisecond):
+			c.String(http.StatusOK, "profile")
+		}
+	})
+	do := func(path string, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, req)
+		return resp
+	}
+
+	// case 1: not exempt
+	resp := do("/slow", "")
+	assert.Equal(t, http.StatusServiceUnavailable, resp.Code)
+	resp = do("/slow?stream=false", "")
+	assert.Equal(t, http.StatusServiceUnavailable, resp.Code)
+	// case 2: streaming query
+	resp = do("/slow?stream=true", "")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "done", resp.Body.String())
+	// case 3: server-sent events
+	resp = do("/slow", "text/event-stream")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "done", resp.Body.String())
+	// case 4: profile download
+	resp = do("/debug/pprof/profile", "")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "profile", resp.Body.String())
+}
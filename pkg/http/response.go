@@ -19,7 +19,10 @@ package http
 
 import (
 	"errors"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -46,6 +49,31 @@ func Error(c *gin.Context, err error) {
 	response(c, http.StatusInternalServerError, err.Error())
 }
 
+// ServiceUnavailable responses error message and set the http status code 503.
+func ServiceUnavailable(c *gin.Context, err error) {
+	_ = c.Error(err)
+	response(c, http.StatusServiceUnavailable, err.Error())
+}
+
+// Forbidden responses error message and set the http status code 403.
+func Forbidden(c *gin.Context, err error) {
+	_ = c.Error(err)
+	response(c, http.StatusForbidden, err.Error())
+}
+
+// RequestEntityTooLarge responses error message and set the http status code 413.
+func RequestEntityTooLarge(c *gin.Context, err error) {
+	_ = c.Error(err)
+	response(c, http.StatusRequestEntityTooLarge, err.Error())
+}
+
+// TooManyRequests responses error message with Retry-After header(in seconds) and set the http status code 429.
+func TooManyRequests(c *gin.Context, err error, retryAfter time.Duration) {
+	_ = c.Error(err)
+	c.Header("Retry-After", strconv.Itoa(int(math.Max(1, math.Ceil(retryAfter.Seconds())))))
+	response(c, http.StatusTooManyRequests, err.Error())
+}
+
 // response responses json body for http restful api
 func response(c *gin.Context, httpCode int, content interface{}) {
 	c.JSON(httpCode, content)
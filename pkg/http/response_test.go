@@ -58,3 +58,27 @@ func TestError(t *testing.T) {
 	assert.Equal(t, http.StatusInternalServerError, resp.Code)
 	assert.Equal(t, `"err"`, resp.Body.String())
 }
+
+func TestServiceUnavailable(t *testing.T) {
+	resp := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(resp)
+	ServiceUnavailable(c, fmt.Errorf("err"))
+	assert.Equal(t, http.StatusServiceUnavailable, resp.Code)
+	assert.Equal(t, `"err"`, resp.Body.String())
+}
+
+func TestRequestEntityTooLarge(t *testing.T) {
+	resp := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(resp)
+	RequestEntityTooLarge(c, fmt.Errorf("err"))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.Code)
+	assert.Equal(t, `"err"`, resp.Body.String())
+}
+
+func TestForbidden(t *testing.T) {
+	resp := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(resp)
+	Forbidden(c, fmt.Errorf("err"))
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+	assert.Equal(t, `"err"`, resp.Body.String())
+}
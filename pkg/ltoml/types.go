@@ -20,6 +20,7 @@ package ltoml
 import (
 	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/dustin/go-humanize"
@@ -84,7 +85,7 @@ func (d *Duration) UnmarshalJSON(data []byte) (err error) {
 }
 
 // MarshalJSON converts a duration to a string for decoding json
-func (d *Duration) MarshalJSON() (data []byte, err error) {
+func (d Duration) MarshalJSON() (data []byte, err error) {
 	return jsoniter.Marshal(d.String())
 }
 
@@ -98,7 +99,7 @@ func (s Size) String() string {
 }
 
 // MarshalText converts a size to a string for decoding toml
-func (s *Size) MarshalText() (text []byte, err error) {
+func (s Size) MarshalText() (text []byte, err error) {
 	return []byte(s.String()), nil
 }
 
@@ -115,13 +116,18 @@ func (s *Size) UnmarshalText(text []byte) error {
 	return nil
 }
 
-// MarshalJSON converts a size to a human readable size
-func (s *Size) MarshalJSON() (data []byte, err error) {
-	return jsoniter.Marshal(s.String())
+// MarshalJSON converts a size to the exact byte count, human readable size is rounded.
+func (s Size) MarshalJSON() (data []byte, err error) {
+	return strconv.AppendUint(nil, uint64(s), 10), nil
 }
 
 // UnmarshalJSON parses a JSON value into a size value.
 func (s *Size) UnmarshalJSON(data []byte) (err error) {
+	if size, err := strconv.ParseUint(string(data), 10, 64); err == nil {
+		// parses byte count directly, avoids losing precision of float64
+		*s = Size(size)
+		return nil
+	}
 	var v interface{}
 	if err := jsoniter.Unmarshal(data, &v); err != nil {
 		return err
@@ -19,6 +19,8 @@ package ltoml
 
 import (
 	"encoding/json"
+	"fmt"
+	"math"
 	"testing"
 	"time"
 
@@ -91,7 +93,7 @@ func Test_Size(t *testing.T) {
 
 	txt, err = example1.Size.MarshalJSON()
 	assert.NoError(t, err)
-	assert.Equal(t, `"10 KiB"`, string(txt))
+	assert.Equal(t, `10240`, string(txt))
 
 	var s2 Example
 	assert.Error(t, json.Unmarshal([]byte(``), &s2))
@@ -106,4 +108,19 @@ func Test_Size(t *testing.T) {
 	assert.NoError(t, json.Unmarshal([]byte(`{"size": 10}`), &s2))
 	assert.Error(t, json.Unmarshal([]byte("{\"size\": \"\"\"\"}"), &s2))
 
+	// marshal by value is same as by pointer
+	data, err := json.Marshal(example1)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"size":10240}`, string(data))
+
+	// marshal the exact byte count, which is rounded by human readable size
+	for _, size := range []Size{0, 1, 1025, 1536*1024*1024 + 7, 1<<60 + 1, math.MaxUint64} {
+		data, err = json.Marshal(Example{Size: size})
+		assert.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf(`{"size":%d}`, uint64(size)), string(data))
+		var s3 Example
+		assert.NoError(t, json.Unmarshal(data, &s3))
+		assert.Equal(t, size, s3.Size)
+	}
+	assert.Equal(t, "1.5 GiB", Size(1536*1024*1024+7).String())
 }
@@ -19,8 +19,10 @@ package queue
 
 import (
 	"fmt"
+	"io"
 	"path"
 	"path/filepath"
+	"sort"
 	"sync"
 	"time"
 
@@ -29,6 +31,7 @@ import (
 	"github.com/lindb/lindb/pkg/fileutil"
 	"github.com/lindb/lindb/pkg/logger"
 	"github.com/lindb/lindb/pkg/queue/page"
+	"github.com/lindb/lindb/pkg/stream"
 )
 
 //go:generate mockgen -source ./fanout.go -destination ./fanout_mock.go -package queue
@@ -38,6 +41,7 @@ var (
 	newQueueFunc  = NewQueue
 	listDirFunc   = fileutil.ListDir
 	newFanOutFunc = NewFanOut
+	mapFileFunc   = fileutil.Map
 )
 
 // FanOutQueue represents a queue "produce once, consume multiple times".
@@ -54,12 +58,21 @@ type FanOutQueue interface {
 	// Sync checks all the FanOuts tailSeqs, update the tailSeq as the smallest one.
 	// Then syncs meta data to storage.
 	Sync()
+	// Flush flushes the appended messages to disk.
+	Flush() error
+	// Release syncs the tailSeq and removes the expired pages of acked messages immediately.
+	Release()
+	// Discard discards the messages with seq less than or equals to seq even if some FanOuts not ack them,
+	// returns the discarded message bytes.
+	Discard(seq int64) (discardedBytes int64)
 	// HeadSeq returns the headSeq which is the next seq for appending data.
 	HeadSeq() int64
 	// TailSeq returns the tailSeq which is the smallest seq among all the fanOut tailSeq.
 	TailSeq() int64
 	//SetAppendSeq sets append seq(head/tail seq)
 	SetAppendSeq(seq int64)
+	// Dump writes the FanOuts sequences and the messages not acked as readable text, without mutating queue.
+	Dump(w io.Writer) error
 	// Close persists Seq meta, FanOut seq meta, release resources.
 	Close()
 	// get the message data by spec consume sequence
@@ -204,6 +217,156 @@ func (fq *fanOutQueue) Sync() {
 	}
 }
 
+// Flush flushes the appended messages to disk.
+func (fq *fanOutQueue) Flush() error {
+	return fq.queue.Flush()
+}
+
+// Release syncs the tailSeq and removes the expired pages of acked messages immediately.
+func (fq *fanOutQueue) Release() {
+	fq.Sync()
+	fq.queue.RemoveExpired()
+}
+
+// Discard discards the messages with seq less than or equals to seq even if some FanOuts not ack them,
+// returns the discarded message bytes.
+func (fq *fanOutQueue) Discard(seq int64) (discardedBytes int64) {
+	fq.lock4map.RLock()
+	defer fq.lock4map.RUnlock()
+
+	if headSeq := fq.queue.HeadSeq(); seq > headSeq {
+		seq = headSeq
+	}
+	for _, fo := range fq.fanOutMap {
+		fo.discard(seq)
+	}
+	return fq.queue.Discard(seq)
+}
+
+// Dump writes the FanOuts sequences and the messages not acked as readable text, without mutating queue.
+func (fq *fanOutQueue) Dump(w io.Writer) error {
+	names := fq.FanOutNames()
+	sort.Strings(names)
+	if _, err := fmt.Fprintf(w, "queue=%s head=%d tail=%d\n", fq.dirPath, fq.HeadSeq(), fq.TailSeq()); err != nil {
+		return err
+	}
+	for _, name := range names {
+		fo, err := fq.GetOrCreateFanOut(name)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "fan-out=%s head=%d tail=%d\n", name, fo.HeadSeq(), fo.TailSeq()); err != nil {
+			return err
+		}
+	}
+	headSeq := fq.HeadSeq()
+	for seq := fq.TailSeq() + 1; seq < headSeq; seq++ {
+		msg, err := fq.get(seq)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "seq=%d length=%d\n", seq, len(msg)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DumpFanOutQueue writes the content of FanOutQueue under dirPath as readable text, used by offline tool.
+// The meta/index pages are mapped read-only, so that dumping never creates, truncates or writes queue files.
+func DumpFanOutQueue(dirPath string, w io.Writer) error {
+	if !fileutil.Exist(filepath.Join(dirPath, metaPath)) {
+		return fmt.Errorf("queue path: %s not exist", dirPath)
+	}
+	metaPage, err := mapPageReadOnly(filepath.Join(dirPath, metaPath), metaPageIndex, metaPageSize)
+	if err != nil {
+		return err
+	}
+	defer unmapPage(metaPage)
+	// same as FanOutQueue.HeadSeq/TailSeq
+	headSeq := int64(stream.ReadUint64(metaPage, queueHeadSeqOffset)) + 1
+	tailSeq := int64(stream.ReadUint64(metaPage, queueTailSeqOffset))
+	if _, err := fmt.Fprintf(w, "queue=%s head=%d tail=%d\n", dirPath, headSeq, tailSeq); err != nil {
+		return err
+	}
+	fanOutDir := filepath.Join(dirPath, fanOutDirName)
+	var names []string
+	if fileutil.Exist(fanOutDir) {
+		if names, err = listDirFunc(fanOutDir); err != nil {
+			return err
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		foMetaPage, err := mapPageReadOnly(filepath.Join(fanOutDir, name), metaPageIndex, fanOutMetaSize)
+		if err != nil {
+			return err
+		}
+		foHeadSeq := int64(stream.ReadUint64(foMetaPage, fanOutHeadSeqOffset))
+		foTailSeq := int64(stream.ReadUint64(foMetaPage, fanOutTailSeqOffset))
+		unmapPage(foMetaPage)
+		// same as NewFanOut, meta not persisted yet
+		if foHeadSeq == 0 && foTailSeq == 0 {
+			foTailSeq = tailSeq
+			foHeadSeq = foTailSeq
+		}
+		if _, err := fmt.Fprintf(w, "fan-out=%s head=%d tail=%d\n", name, foHeadSeq+1, foTailSeq); err != nil {
+			return err
+		}
+	}
+	indexPages := make(map[int64][]byte)
+	defer func() {
+		for _, indexPage := range indexPages {
+			unmapPage(indexPage)
+		}
+	}()
+	for seq := tailSeq + 1; seq < headSeq; seq++ {
+		indexPageID := seq / indexItemsPerPage
+		indexPage, ok := indexPages[indexPageID]
+		if !ok {
+			if !fileutil.Exist(pageFileName(filepath.Join(dirPath, indexPath), indexPageID)) {
+				return ErrMsgNotFound
+			}
+			if indexPage, err = mapPageReadOnly(filepath.Join(dirPath, indexPath), indexPageID, indexPageSize); err != nil {
+				return err
+			}
+			indexPages[indexPageID] = indexPage
+		}
+		indexOffset := int((seq % indexItemsPerPage) * indexItemLength)
+		messageLength := stream.ReadUint32(indexPage, indexOffset+messageLengthOffset)
+		if _, err := fmt.Fprintf(w, "seq=%d length=%d\n", seq, messageLength); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mapPageReadOnly maps the page file under dir read-only, the mapped bytes must be unmapped after used.
+func mapPageReadOnly(dir string, index int64, pageSize int) ([]byte, error) {
+	fileName := pageFileName(dir, index)
+	data, err := mapFileFunc(fileName)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < pageSize {
+		unmapPage(data)
+		return nil, fmt.Errorf("page file: %s is corrupted, size: %d less than page size: %d", fileName, len(data), pageSize)
+	}
+	return data, nil
+}
+
+// unmapPage unmaps the page mapped by mapPageReadOnly.
+func unmapPage(data []byte) {
+	if err := fileutil.Unmap(data); err != nil {
+		queueLogger.Warn("unmap page error when dump queue", logger.Error(err))
+	}
+}
+
+// pageFileName returns the page file name under dir, same as page factory.
+func pageFileName(dir string, index int64) string {
+	return filepath.Join(dir, fmt.Sprintf("%d.bat", index))
+}
+
 // Close persists Seq meta, FanOut seq meta, release resources.
 func (fq *fanOutQueue) Close() {
 	if fq.closed.CAS(false, true) {
@@ -282,6 +445,8 @@ type FanOut interface {
 	IsEmpty() bool
 	// Close persists  headSeq, tailSeq.
 	Close()
+	// discard skips the messages with seq less than or equals to seq.
+	discard(seq int64)
 }
 
 // fanOut implements FanOut.
@@ -442,6 +607,22 @@ func (f *fanOut) SetSeq(seq int64) {
 	f.metaPage.PutUint64(uint64(seq-1), fanOutTailSeqOffset)
 }
 
+// discard skips the messages with seq less than or equals to seq.
+func (f *fanOut) discard(seq int64) {
+	f.lock4headSeq.Lock()
+	defer f.lock4headSeq.Unlock()
+
+	if seq <= f.TailSeq() {
+		return
+	}
+	if seq > f.headSeq.Load() {
+		f.headSeq.Store(seq)
+	}
+	f.setTailSeq(seq)
+	f.metaPage.PutUint64(uint64(f.headSeq.Load()), fanOutHeadSeqOffset)
+	f.metaPage.PutUint64(uint64(seq), fanOutTailSeqOffset)
+}
+
 func (f *fanOut) setTailSeq(seq int64) {
 	f.tailSeq.Store(seq)
 }
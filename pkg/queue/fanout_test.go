@@ -18,11 +18,14 @@
 package queue
 
 import (
+	"bytes"
 	"fmt"
 	"math/rand"
+	"os"
 	"path"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -190,6 +193,107 @@ func TestFanOutQueue_Sync(t *testing.T) {
 	fo2.Ack(s2)
 }
 
+func TestFanOutQueue_Discard(t *testing.T) {
+	dir := path.Join(t.TempDir(), t.Name())
+
+	fq, err := NewFanOutQueue(dir, 1024, time.Minute)
+	assert.NoError(t, err)
+	defer fq.Close()
+
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, fq.Put([]byte("12345")))
+	}
+	fo1, err := fq.GetOrCreateFanOut("group-1")
+	assert.NoError(t, err)
+	fo1.Ack(fo1.Consume()) // 0
+	fo2, err := fq.GetOrCreateFanOut("group-2")
+	assert.NoError(t, err)
+	for i := 0; i < 8; i++ {
+		fo2.Consume()
+	}
+	fo2.Ack(7)
+	fq.Release()
+	assert.Equal(t, int64(0), fq.TailSeq())
+
+	// discard the messages not acked by group-1
+	assert.Equal(t, int64(5*5), fq.Discard(5))
+	assert.Equal(t, int64(5), fq.TailSeq())
+	assert.Equal(t, int64(5), fo1.TailSeq())
+	assert.Equal(t, int64(6), fo1.Consume())
+	// group-2 consumed ahead, keep its sequence
+	assert.Equal(t, int64(7), fo2.TailSeq())
+	assert.Equal(t, int64(8), fo2.Consume())
+	// seq > head seq
+	assert.Equal(t, int64(4*5), fq.Discard(100))
+	assert.Equal(t, int64(9), fo1.TailSeq())
+	assert.True(t, fo1.IsEmpty())
+	assert.Equal(t, SeqNoNewMessageAvailable, fo1.Consume())
+}
+
+func TestFanOutQueue_Dump(t *testing.T) {
+	dir := path.Join(t.TempDir(), t.Name())
+
+	fq, err := NewFanOutQueue(dir, 1024, time.Minute)
+	assert.NoError(t, err)
+	for i := 1; i <= 4; i++ {
+		assert.NoError(t, fq.Put([]byte(strings.Repeat("a", i))))
+	}
+	fo1, err := fq.GetOrCreateFanOut("group-1")
+	assert.NoError(t, err)
+	fo1.Consume()
+	fo1.Ack(fo1.Consume())
+	_, err = fq.GetOrCreateFanOut("group-0")
+	assert.NoError(t, err)
+	fq.Sync()
+	var buf bytes.Buffer
+	assert.NoError(t, fq.Dump(&buf))
+	expect := fmt.Sprintf("queue=%s head=4 tail=-1\n", dir) +
+		"fan-out=group-0 head=0 tail=-1\n" +
+		"fan-out=group-1 head=2 tail=1\n" +
+		"seq=0 length=1\n" +
+		"seq=1 length=2\n" +
+		"seq=2 length=3\n" +
+		"seq=3 length=4\n"
+	assert.Equal(t, expect, buf.String())
+	fq.Close()
+
+	// case: dump offline, queue files are not modified
+	snapshot := func() map[string][]byte {
+		files := make(map[string][]byte)
+		_ = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err == nil && !info.IsDir() {
+				files[path], _ = os.ReadFile(path)
+			}
+			return nil
+		})
+		return files
+	}
+	before := snapshot()
+	buf.Reset()
+	assert.NoError(t, DumpFanOutQueue(dir, &buf))
+	assert.Equal(t, expect, buf.String())
+	assert.Equal(t, before, snapshot())
+	// case: path not exist
+	assert.Error(t, DumpFanOutQueue(path.Join(dir, "not_exist"), &buf))
+	// case: map page failure
+	mapFileFunc = func(path string) ([]byte, error) {
+		return nil, fmt.Errorf("err")
+	}
+	assert.Error(t, DumpFanOutQueue(dir, &buf))
+	// case: page corrupted
+	corrupted := path.Join(t.TempDir(), "corrupted.bat")
+	assert.NoError(t, os.WriteFile(corrupted, []byte{1}, 0644))
+	mapFileFunc = func(path string) ([]byte, error) {
+		return fileutil.Map(corrupted)
+	}
+	assert.Error(t, DumpFanOutQueue(dir, &buf))
+	mapFileFunc = fileutil.Map
+	// case: index page not exist
+	assert.NoError(t, os.RemoveAll(path.Join(dir, indexPath)))
+	assert.Equal(t, ErrMsgNotFound, DumpFanOutQueue(dir, &buf))
+	assert.False(t, fileutil.Exist(path.Join(dir, indexPath)))
+}
+
 func TestFanOut_Close(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	dir := path.Join(t.TempDir(), t.Name())
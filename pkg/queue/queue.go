@@ -69,6 +69,13 @@ type Queue interface {
 	SetAppendSeq(seq int64)
 	// Ack advances the tailSeq to seq.
 	Ack(seq int64)
+	// Discard advances the tailSeq to seq even if the messages are not acked,
+	// then removes the expired pages, returns the discarded message bytes.
+	Discard(seq int64) (discardedBytes int64)
+	// RemoveExpired removes the expired pages of acked messages immediately, instead of waiting for remove task.
+	RemoveExpired()
+	// Flush flushes the appended messages and head/tail seq to disk.
+	Flush() error
 	// Close closes the queue.
 	Close()
 }
@@ -105,6 +112,7 @@ type queue struct {
 	expireIndexPage  atomic.Int64
 	closed           atomic.Bool
 	rwMutex          sync.RWMutex
+	lock4remove      sync.Mutex
 }
 
 // NewQueue returns Queue based on dirPath, dataSizeLimit is used to limit the total data/index size,
@@ -218,6 +226,21 @@ func (q *queue) Put(data []byte) error {
 	return nil
 }
 
+// Flush flushes the appended messages and head/tail seq to disk,
+// previous data/index pages are synced when switching to new page, so only syncs current pages.
+func (q *queue) Flush() error {
+	q.rwMutex.RLock()
+	defer q.rwMutex.RUnlock()
+
+	if err := q.dataPage.Sync(); err != nil {
+		return err
+	}
+	if err := q.indexPage.Sync(); err != nil {
+		return err
+	}
+	return q.metaPage.Sync()
+}
+
 // Get gets the message data at specific index
 func (q *queue) Get(sequence int64) (data []byte, err error) {
 	if err = q.validateSequence(sequence); err != nil {
@@ -320,6 +343,39 @@ func (q *queue) Ack(seq int64) {
 	}
 }
 
+// Discard advances the tailSeq to seq even if the messages are not acked,
+// then removes the expired pages, returns the discarded message bytes.
+func (q *queue) Discard(seq int64) (discardedBytes int64) {
+	q.rwMutex.Lock()
+	tailSeq := q.TailSeq()
+	if seq > q.HeadSeq() {
+		seq = q.HeadSeq()
+	}
+	if seq <= tailSeq {
+		q.rwMutex.Unlock()
+		return 0
+	}
+	for sequence := tailSeq + 1; sequence <= seq; sequence++ {
+		indexPage, ok := q.indexPageFct.GetPage(sequence / indexItemsPerPage)
+		if !ok {
+			continue
+		}
+		indexOffset := int((sequence % indexItemsPerPage) * indexItemLength)
+		discardedBytes += int64(indexPage.ReadUint32(indexOffset + messageLengthOffset))
+	}
+	q.tailSeq.Store(seq)
+	q.metaPage.PutUint64(uint64(seq), queueTailSeqOffset)
+	q.rwMutex.Unlock()
+
+	q.removeExpirePage()
+	return discardedBytes
+}
+
+// RemoveExpired removes the expired pages of acked messages immediately, instead of waiting for remove task.
+func (q *queue) RemoveExpired() {
+	q.removeExpirePage()
+}
+
 // IsEmpty returns if queue is empty
 func (q *queue) IsEmpty() bool {
 	q.rwMutex.RLock()
@@ -379,6 +435,9 @@ func (q *queue) initRemoveTask() {
 }
 
 func (q *queue) removeExpirePage() {
+	q.lock4remove.Lock()
+	defer q.lock4remove.Unlock()
+
 	ackSeq := q.TailSeq() // get current acked sequence
 	if ackSeq < 0 {
 		return
@@ -127,6 +127,32 @@ func TestQueue_Ack(t *testing.T) {
 	q.Close()
 }
 
+func TestQueue_Discard(t *testing.T) {
+	dir := path.Join(t.TempDir(), t.Name())
+
+	q, err := NewQueue(dir, 1024, time.Minute)
+	assert.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, q.Put([]byte("12345")))
+	}
+	// case 1: discard messages not acked
+	assert.Equal(t, int64(5*5), q.Discard(4))
+	assert.Equal(t, int64(4), q.TailSeq())
+	assert.Equal(t, int64(5), q.Size())
+	_, err = q.Get(4)
+	assert.Equal(t, ErrOutOfSequenceRange, err)
+	data, err := q.Get(5)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("12345"), data)
+	// case 2: seq <= tail seq
+	assert.Zero(t, q.Discard(2))
+	// case 3: seq > head seq
+	assert.Equal(t, int64(5*5), q.Discard(100))
+	assert.True(t, q.IsEmpty())
+	q.RemoveExpired()
+	q.Close()
+}
+
 func TestQueue_new_err(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	dir := path.Join(t.TempDir(), t.Name())
@@ -364,6 +390,40 @@ func TestQueue_Put_err(t *testing.T) {
 	q.Close()
 }
 
+func TestQueue_Flush(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	dir := path.Join(t.TempDir(), t.Name())
+
+	defer ctrl.Finish()
+
+	q, err := NewQueue(dir, 1024, time.Minute)
+	assert.NoError(t, err)
+	assert.NoError(t, q.Put([]byte("123")))
+	// case 1: flush pages
+	assert.NoError(t, q.Flush())
+
+	q1 := q.(*queue)
+	dataPage, indexPage, metaPage := q1.dataPage, q1.indexPage, q1.metaPage
+	mockPage := page.NewMockMappedPage(ctrl)
+	// case 2: sync data page err
+	mockPage.EXPECT().Sync().Return(fmt.Errorf("err"))
+	q1.dataPage = mockPage
+	assert.Error(t, q.Flush())
+	q1.dataPage = dataPage
+	// case 3: sync index page err
+	mockPage.EXPECT().Sync().Return(fmt.Errorf("err"))
+	q1.indexPage = mockPage
+	assert.Error(t, q.Flush())
+	q1.indexPage = indexPage
+	// case 4: sync meta page err
+	mockPage.EXPECT().Sync().Return(fmt.Errorf("err"))
+	q1.metaPage = mockPage
+	assert.Error(t, q.Flush())
+	q1.metaPage = metaPage
+
+	q.Close()
+}
+
 func TestQueue_Get_err(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	dir := path.Join(t.TempDir(), t.Name())
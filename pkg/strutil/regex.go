@@ -0,0 +1,89 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package strutil
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const (
+	// MaxRegexpLength represents the max length of regex pattern which can be compiled
+	MaxRegexpLength = 1024
+	// maxCachedRegexps represents the max number of compiled regex patterns in cache
+	maxCachedRegexps = 1024
+)
+
+var regexpCache = newRegexpCache(maxCachedRegexps)
+
+// CompileRegexp compiles the regex pattern, the compiled regex is cached for reuse.
+func CompileRegexp(pattern string) (*regexp.Regexp, error) {
+	return regexpCache.compile(pattern)
+}
+
+// AnchoredLiteralPrefix returns the literal prefix which all matches must start with,
+// returns empty string if the pattern is not anchored at the beginning of text.
+func AnchoredLiteralPrefix(rp *regexp.Regexp) string {
+	if !strings.HasPrefix(rp.String(), "^") {
+		return ""
+	}
+	prefix, _ := rp.LiteralPrefix()
+	return prefix
+}
+
+// compiledRegexps caches the compiled regex patterns,
+// cache is reset when it's full, because the patterns of query are usually few.
+type compiledRegexps struct {
+	capacity int
+	patterns map[string]*regexp.Regexp
+	lock     sync.RWMutex
+}
+
+// newRegexpCache creates the compiled regex cache with capacity
+func newRegexpCache(capacity int) *compiledRegexps {
+	return &compiledRegexps{
+		capacity: capacity,
+		patterns: make(map[string]*regexp.Regexp),
+	}
+}
+
+// compile returns the cached regex if exist, else compiles it and puts it into cache.
+func (c *compiledRegexps) compile(pattern string) (*regexp.Regexp, error) {
+	if len(pattern) > MaxRegexpLength {
+		return nil, fmt.Errorf("regex pattern too long, length: %d, max: %d", len(pattern), MaxRegexpLength)
+	}
+	c.lock.RLock()
+	rp, ok := c.patterns[pattern]
+	c.lock.RUnlock()
+	if ok {
+		return rp, nil
+	}
+	rp, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	c.lock.Lock()
+	if len(c.patterns) >= c.capacity {
+		c.patterns = make(map[string]*regexp.Regexp)
+	}
+	c.patterns[pattern] = rp
+	c.lock.Unlock()
+	return rp, nil
+}
@@ -0,0 +1,59 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package strutil
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompileRegexp(t *testing.T) {
+	rp, err := CompileRegexp("^b2[0-9]+$")
+	assert.NoError(t, err)
+	assert.True(t, rp.MatchString("b21"))
+	// hit cache
+	rp2, err := CompileRegexp("^b2[0-9]+$")
+	assert.NoError(t, err)
+	assert.Same(t, rp, rp2)
+	// bad pattern
+	_, err = CompileRegexp("b[")
+	assert.Error(t, err)
+	// pattern too long
+	_, err = CompileRegexp(strings.Repeat("a", MaxRegexpLength+1))
+	assert.Error(t, err)
+}
+
+func TestCompiledRegexps_reset(t *testing.T) {
+	cache := newRegexpCache(2)
+	_, _ = cache.compile("a")
+	_, _ = cache.compile("b")
+	assert.Len(t, cache.patterns, 2)
+	_, _ = cache.compile("c")
+	assert.Len(t, cache.patterns, 1)
+}
+
+func TestAnchoredLiteralPrefix(t *testing.T) {
+	rp, _ := CompileRegexp("^b2[0-9]")
+	assert.Equal(t, "b2", AnchoredLiteralPrefix(rp))
+	rp, _ = CompileRegexp("b2[0-9]+")
+	assert.Equal(t, "", AnchoredLiteralPrefix(rp))
+	rp, _ = CompileRegexp("^(a|b)c")
+	assert.Equal(t, "", AnchoredLiteralPrefix(rp))
+}
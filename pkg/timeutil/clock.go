@@ -0,0 +1,67 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package timeutil
+
+import (
+	"sync/atomic"
+)
+
+// Clock wraps the wall clock(milliseconds), detects backward jumps of wall clock,
+// and optionally clamps the time to a monotonic bound, so that time never goes backward.
+type Clock struct {
+	nowFunc   func() int64
+	threshold int64
+	onSkew    func(skew int64)
+
+	lastWall int64 // last read wall clock
+	bound    int64 // maximum time returned
+}
+
+// NewClock creates a clock, onSkew is invoked when wall clock jumps backward more than threshold(ms).
+func NewClock(nowFunc func() int64, threshold int64, onSkew func(skew int64)) *Clock {
+	now := nowFunc()
+	return &Clock{
+		nowFunc:   nowFunc,
+		threshold: threshold,
+		onSkew:    onSkew,
+		lastWall:  now,
+		bound:     now,
+	}
+}
+
+// Now returns current time in milliseconds,
+// if clamp, returns the maximum time ever returned when wall clock jumps backward.
+func (c *Clock) Now(clamp bool) int64 {
+	now := c.nowFunc()
+	prevWall := atomic.SwapInt64(&c.lastWall, now)
+	if skew := prevWall - now; skew > c.threshold && c.onSkew != nil {
+		c.onSkew(skew)
+	}
+	for {
+		bound := atomic.LoadInt64(&c.bound)
+		if now <= bound {
+			if clamp {
+				return bound
+			}
+			return now
+		}
+		if atomic.CompareAndSwapInt64(&c.bound, bound, now) {
+			return now
+		}
+	}
+}
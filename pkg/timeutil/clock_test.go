@@ -0,0 +1,53 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package timeutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClock_Now(t *testing.T) {
+	wall := int64(10 * OneMinute)
+	var skews []int64
+	clock := NewClock(func() int64 { return wall }, OneSecond, func(skew int64) {
+		skews = append(skews, skew)
+	})
+	assert.Equal(t, wall, clock.Now(true))
+	wall += OneSecond
+	assert.Equal(t, wall, clock.Now(false))
+
+	// small backward jump under threshold
+	wall -= 500
+	assert.Equal(t, wall, clock.Now(false))
+	assert.Equal(t, 10*OneMinute+OneSecond, clock.Now(true))
+	assert.Empty(t, skews)
+
+	// backward jump, detected once
+	wall -= 5 * OneMinute
+	assert.Equal(t, 10*OneMinute+OneSecond, clock.Now(true))
+	assert.Equal(t, wall, clock.Now(false))
+	assert.Equal(t, []int64{5 * OneMinute}, skews)
+
+	// wall clock catches up
+	wall = 11 * OneMinute
+	assert.Equal(t, wall, clock.Now(true))
+	assert.Equal(t, wall, clock.Now(false))
+	assert.Len(t, skews, 1)
+}
@@ -22,6 +22,7 @@ package protoCommonV1
 
 import (
 	context "context"
+	encoding_binary "encoding/binary"
 	fmt "fmt"
 	proto "github.com/golang/protobuf/proto"
 	grpc "google.golang.org/grpc"
@@ -76,16 +77,19 @@ type RequestType int32
 const (
 	RequestType_Data     RequestType = 0
 	RequestType_Metadata RequestType = 1
+	RequestType_Cancel   RequestType = 2
 )
 
 var RequestType_name = map[int32]string{
 	0: "Data",
 	1: "Metadata",
+	2: "Cancel",
 }
 
 var RequestType_value = map[string]int32{
 	"Data":     0,
 	"Metadata": 1,
+	"Cancel":   2,
 }
 
 func (x RequestType) String() string {
@@ -176,13 +180,18 @@ func (m *TaskRequest) GetPayload() []byte {
 }
 
 type TaskResponse struct {
-	TaskID               string   `protobuf:"bytes,1,opt,name=taskID,proto3" json:"taskID,omitempty"`
-	Type                 TaskType `protobuf:"varint,2,opt,name=type,proto3,enum=protoCommonV1.TaskType" json:"type,omitempty"`
-	Completed            bool     `protobuf:"varint,3,opt,name=completed,proto3" json:"completed,omitempty"`
-	ErrMsg               string   `protobuf:"bytes,4,opt,name=errMsg,proto3" json:"errMsg,omitempty"`
-	SendTime             int64    `protobuf:"varint,5,opt,name=sendTime,proto3" json:"sendTime,omitempty"`
-	Payload              []byte   `protobuf:"bytes,6,opt,name=payload,proto3" json:"payload,omitempty"`
-	Stats                []byte   `protobuf:"bytes,7,opt,name=stats,proto3" json:"stats,omitempty"`
+	TaskID    string   `protobuf:"bytes,1,opt,name=taskID,proto3" json:"taskID,omitempty"`
+	Type      TaskType `protobuf:"varint,2,opt,name=type,proto3,enum=protoCommonV1.TaskType" json:"type,omitempty"`
+	Completed bool     `protobuf:"varint,3,opt,name=completed,proto3" json:"completed,omitempty"`
+	ErrMsg    string   `protobuf:"bytes,4,opt,name=errMsg,proto3" json:"errMsg,omitempty"`
+	SendTime  int64    `protobuf:"varint,5,opt,name=sendTime,proto3" json:"sendTime,omitempty"`
+	Payload   []byte   `protobuf:"bytes,6,opt,name=payload,proto3" json:"payload,omitempty"`
+	Stats     []byte   `protobuf:"bytes,7,opt,name=stats,proto3" json:"stats,omitempty"`
+	// chunks is the sequence(from 1) of partial chunk if not completed,
+	// or the num. of partial chunks sent before the completed response.
+	Chunks int32 `protobuf:"varint,8,opt,name=chunks,proto3" json:"chunks,omitempty"`
+	// stale is set if the result may be stale, e.g. served from cache because of metadata unavailable.
+	Stale                bool     `protobuf:"varint,9,opt,name=stale,proto3" json:"stale,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -270,6 +279,20 @@ func (m *TaskResponse) GetStats() []byte {
 	return nil
 }
 
+func (m *TaskResponse) GetChunks() int32 {
+	if m != nil {
+		return m.Chunks
+	}
+	return 0
+}
+
+func (m *TaskResponse) GetStale() bool {
+	if m != nil {
+		return m.Stale
+	}
+	return false
+}
+
 type TimeSeriesList struct {
 	TimeSeriesList       []*TimeSeries     `protobuf:"bytes,1,rep,name=timeSeriesList,proto3" json:"timeSeriesList,omitempty"`
 	FieldAggSpecs        []*AggregatorSpec `protobuf:"bytes,2,rep,name=fieldAggSpecs,proto3" json:"fieldAggSpecs,omitempty"`
@@ -328,6 +351,7 @@ func (m *TimeSeriesList) GetFieldAggSpecs() []*AggregatorSpec {
 type TimeSeries struct {
 	Tags                 string            `protobuf:"bytes,1,opt,name=tags,proto3" json:"tags,omitempty"`
 	Fields               map[string][]byte `protobuf:"bytes,2,rep,name=fields,proto3" json:"fields,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	Exemplars            []*Exemplar       `protobuf:"bytes,3,rep,name=exemplars,proto3" json:"exemplars,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
 	XXX_unrecognized     []byte            `json:"-"`
 	XXX_sizecache        int32             `json:"-"`
@@ -380,6 +404,13 @@ func (m *TimeSeries) GetFields() map[string][]byte {
 	return nil
 }
 
+func (m *TimeSeries) GetExemplars() []*Exemplar {
+	if m != nil {
+		return m.Exemplars
+	}
+	return nil
+}
+
 type AggregatorSpec struct {
 	FieldName            string   `protobuf:"bytes,1,opt,name=fieldName,proto3" json:"fieldName,omitempty"`
 	FieldType            uint32   `protobuf:"varint,2,opt,name=fieldType,proto3" json:"fieldType,omitempty"`
@@ -443,6 +474,93 @@ func (m *AggregatorSpec) GetFuncTypeList() []uint32 {
 	return nil
 }
 
+type Exemplar struct {
+	FieldName            string   `protobuf:"bytes,1,opt,name=fieldName,proto3" json:"fieldName,omitempty"`
+	TraceID              []byte   `protobuf:"bytes,2,opt,name=traceID,proto3" json:"traceID,omitempty"`
+	SpanID               []byte   `protobuf:"bytes,3,opt,name=spanID,proto3" json:"spanID,omitempty"`
+	Duration             int64    `protobuf:"varint,4,opt,name=duration,proto3" json:"duration,omitempty"`
+	Value                float64  `protobuf:"fixed64,5,opt,name=value,proto3" json:"value,omitempty"`
+	Timestamp            int64    `protobuf:"varint,6,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Exemplar) Reset()         { *m = Exemplar{} }
+func (m *Exemplar) String() string { return proto.CompactTextString(m) }
+func (*Exemplar) ProtoMessage()    {}
+func (*Exemplar) Descriptor() ([]byte, []int) {
+	return fileDescriptor_555bd8c177793206, []int{5}
+}
+func (m *Exemplar) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *Exemplar) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_Exemplar.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *Exemplar) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Exemplar.Merge(m, src)
+}
+func (m *Exemplar) XXX_Size() int {
+	return m.Size()
+}
+func (m *Exemplar) XXX_DiscardUnknown() {
+	xxx_messageInfo_Exemplar.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Exemplar proto.InternalMessageInfo
+
+func (m *Exemplar) GetFieldName() string {
+	if m != nil {
+		return m.FieldName
+	}
+	return ""
+}
+
+func (m *Exemplar) GetTraceID() []byte {
+	if m != nil {
+		return m.TraceID
+	}
+	return nil
+}
+
+func (m *Exemplar) GetSpanID() []byte {
+	if m != nil {
+		return m.SpanID
+	}
+	return nil
+}
+
+func (m *Exemplar) GetDuration() int64 {
+	if m != nil {
+		return m.Duration
+	}
+	return 0
+}
+
+func (m *Exemplar) GetValue() float64 {
+	if m != nil {
+		return m.Value
+	}
+	return 0
+}
+
+func (m *Exemplar) GetTimestamp() int64 {
+	if m != nil {
+		return m.Timestamp
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterEnum("protoCommonV1.TaskType", TaskType_name, TaskType_value)
 	proto.RegisterEnum("protoCommonV1.RequestType", RequestType_name, RequestType_value)
@@ -452,48 +570,56 @@ func init() {
 	proto.RegisterType((*TimeSeries)(nil), "protoCommonV1.TimeSeries")
 	proto.RegisterMapType((map[string][]byte)(nil), "protoCommonV1.TimeSeries.FieldsEntry")
 	proto.RegisterType((*AggregatorSpec)(nil), "protoCommonV1.AggregatorSpec")
+	proto.RegisterType((*Exemplar)(nil), "protoCommonV1.Exemplar")
 }
 
 func init() { proto.RegisterFile("common.proto", fileDescriptor_555bd8c177793206) }
 
 var fileDescriptor_555bd8c177793206 = []byte{
-	// 564 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x53, 0x4d, 0x6f, 0xd3, 0x40,
-	0x10, 0xcd, 0xc6, 0x69, 0x9a, 0x8e, 0x9d, 0xc8, 0x5a, 0x21, 0x30, 0x01, 0xa2, 0xc8, 0x52, 0x25,
-	0xab, 0x48, 0x11, 0xb4, 0x17, 0x40, 0x70, 0x28, 0x2d, 0x1f, 0x15, 0x6d, 0x40, 0xdb, 0x50, 0xce,
-	0x8b, 0x3d, 0x35, 0x56, 0xfd, 0x85, 0x77, 0x5b, 0xc9, 0xff, 0xa4, 0xe2, 0x17, 0x71, 0xe4, 0xc2,
-	0x85, 0x13, 0x2a, 0x7f, 0x04, 0xed, 0xda, 0x69, 0xe2, 0xa8, 0x1c, 0x38, 0x79, 0xe7, 0xcd, 0xbc,
-	0x37, 0xf3, 0xd6, 0xb3, 0x60, 0xf9, 0x59, 0x92, 0x64, 0xe9, 0x24, 0x2f, 0x32, 0x99, 0xd1, 0xbe,
-	0xfe, 0xec, 0x69, 0xe8, 0xe4, 0xb1, 0xfb, 0x8b, 0x80, 0x39, 0xe3, 0xe2, 0x8c, 0xe1, 0xd7, 0x73,
-	0x14, 0x92, 0xba, 0x60, 0xe5, 0xbc, 0xc0, 0x54, 0x2a, 0xf0, 0x60, 0xdf, 0x21, 0x63, 0xe2, 0x6d,
-	0xb0, 0x06, 0x46, 0x1f, 0x42, 0x47, 0x96, 0x39, 0x3a, 0xed, 0x31, 0xf1, 0x06, 0xdb, 0x77, 0x26,
-	0x0d, 0xc5, 0x89, 0x2a, 0x9a, 0x95, 0x39, 0x32, 0x5d, 0x44, 0x9f, 0x83, 0x59, 0x54, 0xda, 0x0a,
-	0x74, 0x0c, 0xcd, 0x19, 0xae, 0x70, 0xd8, 0xa2, 0x82, 0x2d, 0x97, 0xeb, 0x71, 0xbe, 0x94, 0x22,
-	0xf2, 0x79, 0xfc, 0x21, 0xe6, 0xa9, 0xd3, 0x19, 0x13, 0xcf, 0x62, 0x0d, 0x8c, 0x3a, 0xb0, 0x9e,
-	0xf3, 0x32, 0xce, 0x78, 0xe0, 0xac, 0xe9, 0xf4, 0x3c, 0x74, 0x7f, 0x12, 0xb0, 0x2a, 0x73, 0x22,
-	0xcf, 0x52, 0x81, 0xf4, 0x36, 0x74, 0xe5, 0xb2, 0xaf, 0x3a, 0xfa, 0x3f, 0x47, 0xf7, 0x61, 0xc3,
-	0xcf, 0x92, 0x3c, 0x46, 0x89, 0x81, 0xf6, 0xd3, 0x63, 0x0b, 0x40, 0xb5, 0xc0, 0xa2, 0x38, 0x12,
-	0xa1, 0x9e, 0x75, 0x83, 0xd5, 0x11, 0x1d, 0x42, 0x4f, 0x60, 0x1a, 0xcc, 0xa2, 0x04, 0xf5, 0x98,
-	0x06, 0xbb, 0x8e, 0x97, 0x1d, 0x74, 0x1b, 0x0e, 0xe8, 0x2d, 0x58, 0x13, 0x92, 0x4b, 0xe1, 0xac,
-	0x6b, 0xbc, 0x0a, 0xdc, 0x4b, 0x02, 0x03, 0x45, 0x3c, 0xc6, 0x22, 0x42, 0x71, 0x18, 0x09, 0x49,
-	0x77, 0x61, 0x20, 0x1b, 0x88, 0x43, 0xc6, 0x86, 0x67, 0x6e, 0xdf, 0x5d, 0xf5, 0x72, 0x5d, 0xc4,
-	0x56, 0x08, 0x74, 0x0f, 0xfa, 0xa7, 0x11, 0xc6, 0xc1, 0x6e, 0x18, 0x1e, 0xe7, 0xe8, 0x0b, 0xa7,
-	0xad, 0x15, 0x1e, 0xac, 0x28, 0xec, 0x86, 0x61, 0x81, 0x21, 0x97, 0x59, 0xa1, 0xaa, 0x58, 0x93,
-	0xe3, 0x7e, 0x23, 0x00, 0x8b, 0x1e, 0x94, 0x42, 0x47, 0xf2, 0x50, 0xd4, 0xd7, 0xad, 0xcf, 0xf4,
-	0x05, 0x74, 0x35, 0x67, 0xde, 0x60, 0xf3, 0x9f, 0x23, 0x4e, 0x5e, 0xeb, 0xba, 0x57, 0xa9, 0x2c,
-	0x4a, 0x56, 0x93, 0x86, 0x4f, 0xc1, 0x5c, 0x82, 0xa9, 0x0d, 0xc6, 0x19, 0x96, 0x75, 0x03, 0x75,
-	0x54, 0x77, 0x76, 0xc1, 0xe3, 0xf3, 0xea, 0x6f, 0x5a, 0xac, 0x0a, 0x9e, 0xb5, 0x9f, 0x10, 0x37,
-	0x87, 0x41, 0x73, 0x7a, 0xf5, 0x2f, 0xb5, 0xec, 0x94, 0x27, 0x58, 0x6b, 0x2c, 0x80, 0xeb, 0xec,
-	0x6c, 0xbe, 0x1b, 0x7d, 0xb6, 0x00, 0xd4, 0x6e, 0x9e, 0x9e, 0xa7, 0xbe, 0x3a, 0xeb, 0x0b, 0x37,
-	0xc6, 0x86, 0xd7, 0x67, 0x0d, 0x6c, 0x6b, 0x07, 0x7a, 0xf3, 0xed, 0xa1, 0x26, 0xac, 0x7f, 0x9c,
-	0xbe, 0x9b, 0xbe, 0xff, 0x34, 0xb5, 0x5b, 0xd4, 0x06, 0xeb, 0x20, 0x95, 0x58, 0x24, 0x18, 0x44,
-	0x5c, 0xa2, 0x4d, 0x68, 0x0f, 0x3a, 0x87, 0xc8, 0x4f, 0xed, 0xf6, 0xd6, 0x26, 0x98, 0x4b, 0x0f,
-	0x42, 0x25, 0xf6, 0xb9, 0xe4, 0x76, 0x8b, 0x5a, 0xd0, 0x3b, 0x42, 0xc9, 0x03, 0x15, 0x91, 0xed,
-	0x93, 0xea, 0xe5, 0x1e, 0x63, 0x71, 0x11, 0xf9, 0x48, 0xdf, 0x40, 0xf7, 0x2d, 0x4f, 0x83, 0x18,
-	0xe9, 0xf0, 0x86, 0xfd, 0xad, 0x05, 0x87, 0xf7, 0x6e, 0xcc, 0x55, 0xcf, 0xc3, 0x6d, 0x79, 0xe4,
-	0x11, 0x79, 0x69, 0x7f, 0xbf, 0x1a, 0x91, 0x1f, 0x57, 0x23, 0xf2, 0xfb, 0x6a, 0x44, 0x2e, 0xff,
-	0x8c, 0x5a, 0x9f, 0xbb, 0x9a, 0xb3, 0xf3, 0x37, 0x00, 0x00, 0xff, 0xff, 0xdc, 0xb3, 0x4d, 0x15,
-	0x4a, 0x04, 0x00, 0x00,
+	// 680 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x54, 0xcd, 0x6e, 0xd3, 0x4a,
+	0x18, 0xcd, 0x24, 0x69, 0x7e, 0xbe, 0x38, 0x91, 0x35, 0xba, 0xba, 0xd7, 0x37, 0x40, 0x14, 0x59,
+	0x42, 0x8a, 0x8a, 0x14, 0xd1, 0x56, 0x48, 0x80, 0x60, 0x51, 0x9a, 0x02, 0x11, 0x6d, 0x40, 0xd3,
+	0x50, 0xd6, 0x83, 0xfd, 0x35, 0xb5, 0xea, 0x3f, 0x3c, 0x93, 0x8a, 0xac, 0x79, 0x89, 0x3e, 0x03,
+	0x4f, 0xc2, 0x12, 0xb6, 0xac, 0x50, 0x79, 0x11, 0x34, 0x63, 0x27, 0x8e, 0xa3, 0x22, 0x24, 0x56,
+	0xf6, 0x39, 0xf3, 0xfd, 0x9d, 0x99, 0x33, 0x03, 0x86, 0x13, 0x05, 0x41, 0x14, 0x0e, 0xe3, 0x24,
+	0x92, 0x11, 0x6d, 0xeb, 0xcf, 0x81, 0xa6, 0x4e, 0x77, 0xec, 0xef, 0x04, 0x5a, 0x53, 0x2e, 0x2e,
+	0x18, 0x7e, 0x98, 0xa3, 0x90, 0xd4, 0x06, 0x23, 0xe6, 0x09, 0x86, 0x52, 0x91, 0xe3, 0x91, 0x45,
+	0xfa, 0x64, 0xd0, 0x64, 0x05, 0x8e, 0xde, 0x83, 0xaa, 0x5c, 0xc4, 0x68, 0x95, 0xfb, 0x64, 0xd0,
+	0xd9, 0xfd, 0x6f, 0x58, 0xa8, 0x38, 0x54, 0x41, 0xd3, 0x45, 0x8c, 0x4c, 0x07, 0xd1, 0x27, 0xd0,
+	0x4a, 0xd2, 0xda, 0x8a, 0xb4, 0x2a, 0x3a, 0xa7, 0xbb, 0x91, 0xc3, 0xf2, 0x08, 0xb6, 0x1e, 0xae,
+	0xc7, 0x39, 0x5f, 0x08, 0xcf, 0xe1, 0xfe, 0x1b, 0x9f, 0x87, 0x56, 0xb5, 0x4f, 0x06, 0x06, 0x2b,
+	0x70, 0xd4, 0x82, 0x7a, 0xcc, 0x17, 0x7e, 0xc4, 0x5d, 0x6b, 0x4b, 0x2f, 0x2f, 0xa1, 0xfd, 0xa9,
+	0x0c, 0x46, 0x2a, 0x4e, 0xc4, 0x51, 0x28, 0x90, 0xfe, 0x0b, 0x35, 0xb9, 0xae, 0xab, 0x26, 0xff,
+	0x42, 0xd1, 0x6d, 0x68, 0x3a, 0x51, 0x10, 0xfb, 0x28, 0xd1, 0xd5, 0x7a, 0x1a, 0x2c, 0x27, 0x54,
+	0x0b, 0x4c, 0x92, 0x63, 0x31, 0xd3, 0xb3, 0x36, 0x59, 0x86, 0x68, 0x17, 0x1a, 0x02, 0x43, 0x77,
+	0xea, 0x05, 0xa8, 0xc7, 0xac, 0xb0, 0x15, 0x5e, 0x57, 0x50, 0x2b, 0x28, 0xa0, 0xff, 0xc0, 0x96,
+	0x90, 0x5c, 0x0a, 0xab, 0xae, 0xf9, 0x14, 0xa8, 0x1e, 0xce, 0xf9, 0x3c, 0xbc, 0x10, 0x56, 0xa3,
+	0x4f, 0x06, 0x5b, 0x2c, 0x43, 0x59, 0xb4, 0x8f, 0x56, 0x53, 0x4f, 0x95, 0x02, 0xfb, 0x8a, 0x40,
+	0x47, 0xb5, 0x39, 0xc1, 0xc4, 0x43, 0x71, 0xe4, 0x09, 0x49, 0xf7, 0xa1, 0x23, 0x0b, 0x8c, 0x45,
+	0xfa, 0x95, 0x41, 0x6b, 0xf7, 0xff, 0x4d, 0xe5, 0xab, 0x20, 0xb6, 0x91, 0x40, 0x0f, 0xa0, 0x7d,
+	0xe6, 0xa1, 0xef, 0xee, 0xcf, 0x66, 0x27, 0x31, 0x3a, 0xc2, 0x2a, 0xeb, 0x0a, 0x77, 0x36, 0x2a,
+	0xec, 0xcf, 0x66, 0x09, 0xce, 0xb8, 0x8c, 0x12, 0x15, 0xc5, 0x8a, 0x39, 0xf6, 0x37, 0x02, 0x90,
+	0xf7, 0xa0, 0x14, 0xaa, 0x92, 0xcf, 0x44, 0x76, 0x38, 0xfa, 0x9f, 0x3e, 0x85, 0x9a, 0xce, 0x59,
+	0x36, 0xb8, 0xfb, 0xdb, 0x11, 0x87, 0xcf, 0x75, 0xdc, 0x61, 0x28, 0x93, 0x05, 0xcb, 0x92, 0xe8,
+	0x03, 0x68, 0xe2, 0x47, 0x0c, 0x62, 0x9f, 0x27, 0xc2, 0xaa, 0xe8, 0x0a, 0x9b, 0xc7, 0x7b, 0x98,
+	0xad, 0xb3, 0x3c, 0xb2, 0xfb, 0x08, 0x5a, 0x6b, 0xd5, 0xa8, 0x09, 0x95, 0x0b, 0x5c, 0x64, 0x73,
+	0xa9, 0x5f, 0xb5, 0xd5, 0x97, 0xdc, 0x9f, 0xa7, 0x96, 0x31, 0x58, 0x0a, 0x1e, 0x97, 0x1f, 0x12,
+	0x3b, 0x86, 0x4e, 0x51, 0xb4, 0x32, 0x8c, 0x9e, 0x66, 0xc2, 0x03, 0xcc, 0x6a, 0xe4, 0xc4, 0x6a,
+	0x75, 0xba, 0x34, 0x60, 0x9b, 0xe5, 0x84, 0xba, 0x00, 0x67, 0xf3, 0xd0, 0x51, 0xff, 0xfa, 0x9c,
+	0x94, 0x84, 0x36, 0x2b, 0x70, 0xf6, 0x67, 0x02, 0x8d, 0xa5, 0x88, 0x3f, 0x34, 0xb3, 0xa0, 0x2e,
+	0x13, 0xee, 0xe0, 0x78, 0x94, 0x0d, 0xbe, 0x84, 0xca, 0x53, 0x22, 0xe6, 0xe1, 0x78, 0xa4, 0x2d,
+	0x6d, 0xb0, 0x0c, 0x29, 0xdf, 0xba, 0xf3, 0x84, 0x4b, 0x2f, 0x4a, 0x6f, 0x5f, 0x85, 0xad, 0x70,
+	0xbe, 0x09, 0xca, 0xd0, 0x24, 0xdb, 0x04, 0x35, 0x81, 0xf2, 0x8a, 0x90, 0x3c, 0x88, 0xb5, 0x9f,
+	0x2b, 0x2c, 0x27, 0xb6, 0xf7, 0xa0, 0xb1, 0xbc, 0x4f, 0xb4, 0x05, 0xf5, 0xb7, 0x93, 0x57, 0x93,
+	0xd7, 0xef, 0x26, 0x66, 0x89, 0x9a, 0x60, 0x8c, 0x43, 0x89, 0x49, 0x80, 0xae, 0xc7, 0x25, 0x9a,
+	0x84, 0x36, 0xa0, 0x7a, 0x84, 0xfc, 0xcc, 0x2c, 0x6f, 0xef, 0x40, 0x6b, 0xed, 0x89, 0x50, 0x0b,
+	0x23, 0x2e, 0xb9, 0x59, 0xa2, 0x06, 0x34, 0x8e, 0x51, 0x72, 0x57, 0x21, 0x42, 0x01, 0x6a, 0x07,
+	0x3c, 0x74, 0xd0, 0x37, 0xcb, 0xbb, 0xa7, 0xe9, 0xbb, 0x76, 0x82, 0xc9, 0xa5, 0xe7, 0x20, 0x7d,
+	0x01, 0xb5, 0x97, 0x3c, 0x74, 0x7d, 0xa4, 0xdd, 0x1b, 0x6e, 0x77, 0x56, 0xbc, 0x7b, 0xeb, 0xc6,
+	0xb5, 0xf4, 0xf1, 0xb0, 0x4b, 0x03, 0x72, 0x9f, 0x3c, 0x33, 0xbf, 0x5c, 0xf7, 0xc8, 0xd7, 0xeb,
+	0x1e, 0xf9, 0x71, 0xdd, 0x23, 0x57, 0x3f, 0x7b, 0xa5, 0xf7, 0x35, 0x9d, 0xb3, 0xf7, 0x6b, 0x00,
+	0xc8, 0x78, 0x5a, 0x1e, 0x68, 0x05, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -690,6 +816,21 @@ func (m *TaskResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i -= len(m.XXX_unrecognized)
 		copy(dAtA[i:], m.XXX_unrecognized)
 	}
+	if m.Stale {
+		i--
+		if m.Stale {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x48
+	}
+	if m.Chunks != 0 {
+		i = encodeVarintCommon(dAtA, i, uint64(m.Chunks))
+		i--
+		dAtA[i] = 0x40
+	}
 	if len(m.Stats) > 0 {
 		i -= len(m.Stats)
 		copy(dAtA[i:], m.Stats)
@@ -820,6 +961,20 @@ func (m *TimeSeries) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i -= len(m.XXX_unrecognized)
 		copy(dAtA[i:], m.XXX_unrecognized)
 	}
+	if len(m.Exemplars) > 0 {
+		for iNdEx := len(m.Exemplars) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Exemplars[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintCommon(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x1a
+		}
+	}
 	if len(m.Fields) > 0 {
 		for k := range m.Fields {
 			v := m.Fields[k]
@@ -908,6 +1063,70 @@ func (m *AggregatorSpec) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
+func (m *Exemplar) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Exemplar) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Exemplar) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if m.Timestamp != 0 {
+		i = encodeVarintCommon(dAtA, i, uint64(m.Timestamp))
+		i--
+		dAtA[i] = 0x30
+	}
+	if m.Value != 0 {
+		i -= 8
+		encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(math.Float64bits(float64(m.Value))))
+		i--
+		dAtA[i] = 0x29
+	}
+	if m.Duration != 0 {
+		i = encodeVarintCommon(dAtA, i, uint64(m.Duration))
+		i--
+		dAtA[i] = 0x20
+	}
+	if len(m.SpanID) > 0 {
+		i -= len(m.SpanID)
+		copy(dAtA[i:], m.SpanID)
+		i = encodeVarintCommon(dAtA, i, uint64(len(m.SpanID)))
+		i--
+		dAtA[i] = 0x1a
+	}
+	if len(m.TraceID) > 0 {
+		i -= len(m.TraceID)
+		copy(dAtA[i:], m.TraceID)
+		i = encodeVarintCommon(dAtA, i, uint64(len(m.TraceID)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.FieldName) > 0 {
+		i -= len(m.FieldName)
+		copy(dAtA[i:], m.FieldName)
+		i = encodeVarintCommon(dAtA, i, uint64(len(m.FieldName)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
 func encodeVarintCommon(dAtA []byte, offset int, v uint64) int {
 	offset -= sovCommon(v)
 	base := offset
@@ -980,6 +1199,12 @@ func (m *TaskResponse) Size() (n int) {
 	if l > 0 {
 		n += 1 + l + sovCommon(uint64(l))
 	}
+	if m.Chunks != 0 {
+		n += 1 + sovCommon(uint64(m.Chunks))
+	}
+	if m.Stale {
+		n += 2
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -1032,6 +1257,12 @@ func (m *TimeSeries) Size() (n int) {
 			n += mapEntrySize + 1 + sovCommon(uint64(mapEntrySize))
 		}
 	}
+	if len(m.Exemplars) > 0 {
+		for _, e := range m.Exemplars {
+			l = e.Size()
+			n += 1 + l + sovCommon(uint64(l))
+		}
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -1064,6 +1295,39 @@ func (m *AggregatorSpec) Size() (n int) {
 	return n
 }
 
+func (m *Exemplar) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.FieldName)
+	if l > 0 {
+		n += 1 + l + sovCommon(uint64(l))
+	}
+	l = len(m.TraceID)
+	if l > 0 {
+		n += 1 + l + sovCommon(uint64(l))
+	}
+	l = len(m.SpanID)
+	if l > 0 {
+		n += 1 + l + sovCommon(uint64(l))
+	}
+	if m.Duration != 0 {
+		n += 1 + sovCommon(uint64(m.Duration))
+	}
+	if m.Value != 0 {
+		n += 9
+	}
+	if m.Timestamp != 0 {
+		n += 1 + sovCommon(uint64(m.Timestamp))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
 func sovCommon(x uint64) (n int) {
 	return (math_bits.Len64(x|1) + 6) / 7
 }
@@ -1481,6 +1745,45 @@ func (m *TaskResponse) Unmarshal(dAtA []byte) error {
 				m.Stats = []byte{}
 			}
 			iNdEx = postIndex
+		case 8:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Chunks", wireType)
+			}
+			m.Chunks = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowCommon
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Chunks |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 9:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Stale", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowCommon
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Stale = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipCommon(dAtA[iNdEx:])
@@ -1809,6 +2112,9 @@ func (m *TimeSeries) Unmarshal(dAtA []byte) error {
 					if skippy < 0 {
 						return ErrInvalidLengthCommon
 					}
+					if (iNdEx + skippy) < 0 {
+						return ErrInvalidLengthCommon
+					}
 					if (iNdEx + skippy) > postIndex {
 						return io.ErrUnexpectedEOF
 					}
@@ -1817,6 +2123,40 @@ func (m *TimeSeries) Unmarshal(dAtA []byte) error {
 			}
 			m.Fields[mapkey] = mapvalue
 			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Exemplars", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowCommon
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthCommon
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthCommon
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Exemplars = append(m.Exemplars, &Exemplar{})
+			if err := m.Exemplars[len(m.Exemplars)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipCommon(dAtA[iNdEx:])
@@ -2023,6 +2363,209 @@ func (m *AggregatorSpec) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+func (m *Exemplar) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowCommon
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Exemplar: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Exemplar: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FieldName", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowCommon
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthCommon
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthCommon
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.FieldName = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TraceID", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowCommon
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthCommon
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthCommon
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.TraceID = append(m.TraceID[:0], dAtA[iNdEx:postIndex]...)
+			if m.TraceID == nil {
+				m.TraceID = []byte{}
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SpanID", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowCommon
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthCommon
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthCommon
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.SpanID = append(m.SpanID[:0], dAtA[iNdEx:postIndex]...)
+			if m.SpanID == nil {
+				m.SpanID = []byte{}
+			}
+			iNdEx = postIndex
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Duration", wireType)
+			}
+			m.Duration = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowCommon
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Duration |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 5:
+			if wireType != 1 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Value", wireType)
+			}
+			var v uint64
+			if (iNdEx + 8) > l {
+				return io.ErrUnexpectedEOF
+			}
+			v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
+			iNdEx += 8
+			m.Value = float64(math.Float64frombits(v))
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Timestamp", wireType)
+			}
+			m.Timestamp = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowCommon
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Timestamp |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipCommon(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthCommon
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthCommon
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func skipCommon(dAtA []byte) (n int, err error) {
 	l := len(dAtA)
 	iNdEx := 0
@@ -107,8 +107,32 @@ func (rcv *Exemplar) MutateDuration(n int64) bool {
 	return rcv._tab.MutateInt64Slot(8, n)
 }
 
+func (rcv *Exemplar) Value() float64 {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(10))
+	if o != 0 {
+		return rcv._tab.GetFloat64(o + rcv._tab.Pos)
+	}
+	return 0.0
+}
+
+func (rcv *Exemplar) MutateValue(n float64) bool {
+	return rcv._tab.MutateFloat64Slot(10, n)
+}
+
+func (rcv *Exemplar) Timestamp() int64 {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(12))
+	if o != 0 {
+		return rcv._tab.GetInt64(o + rcv._tab.Pos)
+	}
+	return 0
+}
+
+func (rcv *Exemplar) MutateTimestamp(n int64) bool {
+	return rcv._tab.MutateInt64Slot(12, n)
+}
+
 func ExemplarStart(builder *flatbuffers.Builder) {
-	builder.StartObject(3)
+	builder.StartObject(5)
 }
 func ExemplarAddSpanID(builder *flatbuffers.Builder, spanID flatbuffers.UOffsetT) {
 	builder.PrependUOffsetTSlot(0, flatbuffers.UOffsetT(spanID), 0)
@@ -125,6 +149,12 @@ func ExemplarStartTraceIDVector(builder *flatbuffers.Builder, numElems int) flat
 func ExemplarAddDuration(builder *flatbuffers.Builder, duration int64) {
 	builder.PrependInt64Slot(2, duration, 0)
 }
+func ExemplarAddValue(builder *flatbuffers.Builder, value float64) {
+	builder.PrependFloat64Slot(3, value, 0.0)
+}
+func ExemplarAddTimestamp(builder *flatbuffers.Builder, timestamp int64) {
+	builder.PrependInt64Slot(4, timestamp, 0)
+}
 func ExemplarEnd(builder *flatbuffers.Builder) flatbuffers.UOffsetT {
 	return builder.EndObject()
 }
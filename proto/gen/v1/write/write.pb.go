@@ -44,7 +44,13 @@ var _ = math.Inf
 const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
 
 type WriteRequest struct {
-	Record               []byte   `protobuf:"bytes,1,opt,name=record,proto3" json:"record,omitempty"`
+	Record []byte `protobuf:"bytes,1,opt,name=record,proto3" json:"record,omitempty"`
+	// acceptedAt is the time(unix nano) when broker ingestion accepts the request of the first row of record.
+	AcceptedAt int64 `protobuf:"varint,2,opt,name=acceptedAt,proto3" json:"acceptedAt,omitempty"`
+	// quorum waits until record is acked by majority replicas before responding.
+	Quorum bool `protobuf:"varint,3,opt,name=quorum,proto3" json:"quorum,omitempty"`
+	// sync flushes write ahead log to disk before responding.
+	Sync                 bool     `protobuf:"varint,4,opt,name=sync,proto3" json:"sync,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -90,8 +96,35 @@ func (m *WriteRequest) GetRecord() []byte {
 	return nil
 }
 
+func (m *WriteRequest) GetAcceptedAt() int64 {
+	if m != nil {
+		return m.AcceptedAt
+	}
+	return 0
+}
+
+func (m *WriteRequest) GetQuorum() bool {
+	if m != nil {
+		return m.Quorum
+	}
+	return false
+}
+
+func (m *WriteRequest) GetSync() bool {
+	if m != nil {
+		return m.Sync
+	}
+	return false
+}
+
 type WriteResponse struct {
-	Err                  string   `protobuf:"bytes,1,opt,name=err,proto3" json:"err,omitempty"`
+	Err string `protobuf:"bytes,1,opt,name=err,proto3" json:"err,omitempty"`
+	// flowControl is the flow control signal sent by server periodically in StreamWrite.
+	FlowControl *FlowControl `protobuf:"bytes,2,opt,name=flowControl,proto3" json:"flowControl,omitempty"`
+	// acceptedAt is echoed from write request after record is synced into write ahead log, 0 if not synced.
+	AcceptedAt int64 `protobuf:"varint,3,opt,name=acceptedAt,proto3" json:"acceptedAt,omitempty"`
+	// sequence is the sequence of write ahead log which includes the record.
+	Sequence             int64    `protobuf:"varint,4,opt,name=sequence,proto3" json:"sequence,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -137,26 +170,115 @@ func (m *WriteResponse) GetErr() string {
 	return ""
 }
 
+func (m *WriteResponse) GetFlowControl() *FlowControl {
+	if m != nil {
+		return m.FlowControl
+	}
+	return nil
+}
+
+func (m *WriteResponse) GetAcceptedAt() int64 {
+	if m != nil {
+		return m.AcceptedAt
+	}
+	return 0
+}
+
+func (m *WriteResponse) GetSequence() int64 {
+	if m != nil {
+		return m.Sequence
+	}
+	return 0
+}
+
+// FlowControl represents the server-driven flow control signal of write stream.
+type FlowControl struct {
+	// backpressure signals client to pause sending until backpressure is released.
+	Backpressure bool `protobuf:"varint,1,opt,name=backpressure,proto3" json:"backpressure,omitempty"`
+	// queueDepth is the number of write ahead log messages not consumed yet.
+	QueueDepth           int64    `protobuf:"varint,2,opt,name=queueDepth,proto3" json:"queueDepth,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *FlowControl) Reset()         { *m = FlowControl{} }
+func (m *FlowControl) String() string { return proto.CompactTextString(m) }
+func (*FlowControl) ProtoMessage()    {}
+func (*FlowControl) Descriptor() ([]byte, []int) {
+	return fileDescriptor_67966b2b12a73214, []int{2}
+}
+func (m *FlowControl) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *FlowControl) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_FlowControl.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *FlowControl) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FlowControl.Merge(m, src)
+}
+func (m *FlowControl) XXX_Size() int {
+	return m.Size()
+}
+func (m *FlowControl) XXX_DiscardUnknown() {
+	xxx_messageInfo_FlowControl.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FlowControl proto.InternalMessageInfo
+
+func (m *FlowControl) GetBackpressure() bool {
+	if m != nil {
+		return m.Backpressure
+	}
+	return false
+}
+
+func (m *FlowControl) GetQueueDepth() int64 {
+	if m != nil {
+		return m.QueueDepth
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterType((*WriteRequest)(nil), "protoWriteV1.WriteRequest")
 	proto.RegisterType((*WriteResponse)(nil), "protoWriteV1.WriteResponse")
+	proto.RegisterType((*FlowControl)(nil), "protoWriteV1.FlowControl")
 }
 
 func init() { proto.RegisterFile("write.proto", fileDescriptor_67966b2b12a73214) }
 
 var fileDescriptor_67966b2b12a73214 = []byte{
-	// 163 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0xe2, 0x2e, 0x2f, 0xca, 0x2c,
-	0x49, 0xd5, 0x2b, 0x28, 0xca, 0x2f, 0xc9, 0x17, 0xe2, 0x01, 0x53, 0xe1, 0x20, 0x91, 0x30, 0x43,
-	0x25, 0x35, 0x2e, 0x1e, 0x30, 0x33, 0x28, 0xb5, 0xb0, 0x34, 0xb5, 0xb8, 0x44, 0x48, 0x8c, 0x8b,
-	0xad, 0x28, 0x35, 0x39, 0xbf, 0x28, 0x45, 0x82, 0x51, 0x81, 0x51, 0x83, 0x27, 0x08, 0xca, 0x53,
-	0x52, 0xe4, 0xe2, 0x85, 0xaa, 0x2b, 0x2e, 0xc8, 0xcf, 0x2b, 0x4e, 0x15, 0x12, 0xe0, 0x62, 0x4e,
-	0x2d, 0x2a, 0x02, 0xab, 0xe2, 0x0c, 0x02, 0x31, 0x8d, 0xc2, 0xa0, 0x46, 0x05, 0xa7, 0x16, 0x95,
-	0x65, 0x26, 0xa7, 0x0a, 0xb9, 0x71, 0xb1, 0x82, 0xf9, 0x42, 0x52, 0x7a, 0xc8, 0x56, 0xea, 0x21,
-	0xdb, 0x27, 0x25, 0x8d, 0x55, 0x0e, 0x62, 0x87, 0x12, 0x83, 0x06, 0xa3, 0x01, 0xa3, 0x93, 0xc0,
-	0x89, 0x47, 0x72, 0x8c, 0x17, 0x1e, 0xc9, 0x31, 0x3e, 0x78, 0x24, 0xc7, 0x38, 0xe3, 0xb1, 0x1c,
-	0x43, 0x12, 0x1b, 0x58, 0x8f, 0x31, 0x20, 0x00, 0x00, 0xff, 0xff, 0x48, 0xda, 0x57, 0x19, 0xd8,
-	0x00, 0x00, 0x00,
+	// 315 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x51, 0xbd, 0x4e, 0xf3, 0x40,
+	0x10, 0xcc, 0x7d, 0xce, 0x17, 0x85, 0xb5, 0x91, 0xa2, 0x2b, 0x90, 0x09, 0x92, 0x15, 0xb9, 0x72,
+	0x15, 0x41, 0x28, 0xa9, 0xf8, 0x51, 0x2a, 0x1a, 0x2e, 0x12, 0xd4, 0xce, 0x65, 0x11, 0x11, 0x89,
+	0xcf, 0xde, 0xbb, 0x23, 0xe2, 0x4d, 0x10, 0x35, 0x0f, 0x43, 0xc9, 0x23, 0x20, 0xf3, 0x22, 0xc8,
+	0x87, 0x21, 0x0e, 0x50, 0x52, 0x79, 0x67, 0xbc, 0xbb, 0x33, 0x3b, 0x07, 0xfe, 0x8a, 0xe6, 0x06,
+	0x87, 0x39, 0x29, 0xa3, 0x78, 0xe0, 0x3e, 0x57, 0x15, 0x73, 0x79, 0x10, 0x13, 0x04, 0xae, 0x14,
+	0x58, 0x58, 0xd4, 0x86, 0xef, 0x40, 0x87, 0x50, 0x2a, 0x9a, 0x85, 0x6c, 0xc0, 0x92, 0x40, 0xd4,
+	0x88, 0x47, 0x00, 0xa9, 0x94, 0x98, 0x1b, 0x9c, 0x1d, 0x9b, 0xf0, 0xdf, 0x80, 0x25, 0x9e, 0x68,
+	0x30, 0xd5, 0x5c, 0x61, 0x15, 0xd9, 0x65, 0xe8, 0x0d, 0x58, 0xd2, 0x15, 0x35, 0xe2, 0x1c, 0xda,
+	0xfa, 0x3e, 0x93, 0x61, 0xdb, 0xb1, 0xae, 0x8e, 0x1f, 0x19, 0x6c, 0xd7, 0xa2, 0x3a, 0x57, 0x99,
+	0x46, 0xde, 0x03, 0x0f, 0x89, 0x9c, 0xe4, 0x96, 0xa8, 0x4a, 0x7e, 0x04, 0xfe, 0xf5, 0x42, 0xad,
+	0x4e, 0x55, 0x66, 0x48, 0x2d, 0x9c, 0xa0, 0x3f, 0xda, 0x1d, 0x36, 0xbd, 0x0f, 0xc7, 0xeb, 0x06,
+	0xd1, 0xec, 0xfe, 0x66, 0xd6, 0xfb, 0x61, 0xb6, 0x0f, 0x5d, 0x5d, 0xdd, 0x9b, 0x49, 0x74, 0xc6,
+	0x3c, 0xf1, 0x85, 0xe3, 0x0b, 0xf0, 0x1b, 0x7b, 0x79, 0x0c, 0xc1, 0x34, 0x95, 0xb7, 0x39, 0xa1,
+	0xd6, 0x96, 0xd0, 0x59, 0xec, 0x8a, 0x0d, 0xae, 0x92, 0x2b, 0x2c, 0x5a, 0x3c, 0xc3, 0xdc, 0xdc,
+	0x7c, 0x66, 0xb3, 0x66, 0x46, 0x4f, 0xac, 0x0e, 0x79, 0x82, 0x74, 0x37, 0x97, 0xc8, 0xc7, 0xf0,
+	0xdf, 0x61, 0xde, 0xdf, 0x3c, 0xa8, 0xf9, 0x12, 0xfd, 0xbd, 0x5f, 0xff, 0x7d, 0x04, 0x16, 0xb7,
+	0x12, 0xb6, 0xcf, 0xf8, 0x39, 0xf8, 0x13, 0x43, 0x98, 0x2e, 0xff, 0x62, 0xdb, 0x49, 0xef, 0xb9,
+	0x8c, 0xd8, 0x4b, 0x19, 0xb1, 0xd7, 0x32, 0x62, 0x0f, 0x6f, 0x51, 0x6b, 0xda, 0x71, 0x33, 0x87,
+	0xef, 0x03, 0x00, 0x9e, 0x30, 0x5c, 0x51, 0x40, 0x02, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -172,6 +294,9 @@ const _ = grpc.SupportPackageIsVersion4
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
 type WriteServiceClient interface {
 	Write(ctx context.Context, opts ...grpc.CallOption) (WriteService_WriteClient, error)
+	// StreamWrite writes metrics like Write, server sends flow control signal periodically,
+	// client pauses sending if backpressure is signaled.
+	StreamWrite(ctx context.Context, opts ...grpc.CallOption) (WriteService_StreamWriteClient, error)
 }
 
 type writeServiceClient struct {
@@ -213,9 +338,43 @@ func (x *writeServiceWriteClient) Recv() (*WriteResponse, error) {
 	return m, nil
 }
 
+func (c *writeServiceClient) StreamWrite(ctx context.Context, opts ...grpc.CallOption) (WriteService_StreamWriteClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_WriteService_serviceDesc.Streams[1], "/protoWriteV1.WriteService/StreamWrite", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &writeServiceStreamWriteClient{stream}
+	return x, nil
+}
+
+type WriteService_StreamWriteClient interface {
+	Send(*WriteRequest) error
+	Recv() (*WriteResponse, error)
+	grpc.ClientStream
+}
+
+type writeServiceStreamWriteClient struct {
+	grpc.ClientStream
+}
+
+func (x *writeServiceStreamWriteClient) Send(m *WriteRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *writeServiceStreamWriteClient) Recv() (*WriteResponse, error) {
+	m := new(WriteResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // WriteServiceServer is the server API for WriteService service.
 type WriteServiceServer interface {
 	Write(WriteService_WriteServer) error
+	// StreamWrite writes metrics like Write, server sends flow control signal periodically,
+	// client pauses sending if backpressure is signaled.
+	StreamWrite(WriteService_StreamWriteServer) error
 }
 
 // UnimplementedWriteServiceServer can be embedded to have forward compatible implementations.
@@ -225,6 +384,9 @@ type UnimplementedWriteServiceServer struct {
 func (*UnimplementedWriteServiceServer) Write(srv WriteService_WriteServer) error {
 	return status.Errorf(codes.Unimplemented, "method Write not implemented")
 }
+func (*UnimplementedWriteServiceServer) StreamWrite(srv WriteService_StreamWriteServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamWrite not implemented")
+}
 
 func RegisterWriteServiceServer(s *grpc.Server, srv WriteServiceServer) {
 	s.RegisterService(&_WriteService_serviceDesc, srv)
@@ -256,6 +418,32 @@ func (x *writeServiceWriteServer) Recv() (*WriteRequest, error) {
 	return m, nil
 }
 
+func _WriteService_StreamWrite_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(WriteServiceServer).StreamWrite(&writeServiceStreamWriteServer{stream})
+}
+
+type WriteService_StreamWriteServer interface {
+	Send(*WriteResponse) error
+	Recv() (*WriteRequest, error)
+	grpc.ServerStream
+}
+
+type writeServiceStreamWriteServer struct {
+	grpc.ServerStream
+}
+
+func (x *writeServiceStreamWriteServer) Send(m *WriteResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *writeServiceStreamWriteServer) Recv() (*WriteRequest, error) {
+	m := new(WriteRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 var _WriteService_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "protoWriteV1.WriteService",
 	HandlerType: (*WriteServiceServer)(nil),
@@ -267,6 +455,12 @@ var _WriteService_serviceDesc = grpc.ServiceDesc{
 			ServerStreams: true,
 			ClientStreams: true,
 		},
+		{
+			StreamName:    "StreamWrite",
+			Handler:       _WriteService_StreamWrite_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
 	},
 	Metadata: "write.proto",
 }
@@ -295,6 +489,31 @@ func (m *WriteRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i -= len(m.XXX_unrecognized)
 		copy(dAtA[i:], m.XXX_unrecognized)
 	}
+	if m.Sync {
+		i--
+		if m.Sync {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x20
+	}
+	if m.Quorum {
+		i--
+		if m.Quorum {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x18
+	}
+	if m.AcceptedAt != 0 {
+		i = encodeVarintWrite(dAtA, i, uint64(m.AcceptedAt))
+		i--
+		dAtA[i] = 0x10
+	}
 	if len(m.Record) > 0 {
 		i -= len(m.Record)
 		copy(dAtA[i:], m.Record)
@@ -329,6 +548,28 @@ func (m *WriteResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i -= len(m.XXX_unrecognized)
 		copy(dAtA[i:], m.XXX_unrecognized)
 	}
+	if m.Sequence != 0 {
+		i = encodeVarintWrite(dAtA, i, uint64(m.Sequence))
+		i--
+		dAtA[i] = 0x20
+	}
+	if m.AcceptedAt != 0 {
+		i = encodeVarintWrite(dAtA, i, uint64(m.AcceptedAt))
+		i--
+		dAtA[i] = 0x18
+	}
+	if m.FlowControl != nil {
+		{
+			size, err := m.FlowControl.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintWrite(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x12
+	}
 	if len(m.Err) > 0 {
 		i -= len(m.Err)
 		copy(dAtA[i:], m.Err)
@@ -339,6 +580,48 @@ func (m *WriteResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
+func (m *FlowControl) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *FlowControl) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *FlowControl) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if m.QueueDepth != 0 {
+		i = encodeVarintWrite(dAtA, i, uint64(m.QueueDepth))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.Backpressure {
+		i--
+		if m.Backpressure {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
 func encodeVarintWrite(dAtA []byte, offset int, v uint64) int {
 	offset -= sovWrite(v)
 	base := offset
@@ -360,6 +643,15 @@ func (m *WriteRequest) Size() (n int) {
 	if l > 0 {
 		n += 1 + l + sovWrite(uint64(l))
 	}
+	if m.AcceptedAt != 0 {
+		n += 1 + sovWrite(uint64(m.AcceptedAt))
+	}
+	if m.Quorum {
+		n += 2
+	}
+	if m.Sync {
+		n += 2
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -376,6 +668,34 @@ func (m *WriteResponse) Size() (n int) {
 	if l > 0 {
 		n += 1 + l + sovWrite(uint64(l))
 	}
+	if m.FlowControl != nil {
+		l = m.FlowControl.Size()
+		n += 1 + l + sovWrite(uint64(l))
+	}
+	if m.AcceptedAt != 0 {
+		n += 1 + sovWrite(uint64(m.AcceptedAt))
+	}
+	if m.Sequence != 0 {
+		n += 1 + sovWrite(uint64(m.Sequence))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *FlowControl) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Backpressure {
+		n += 2
+	}
+	if m.QueueDepth != 0 {
+		n += 1 + sovWrite(uint64(m.QueueDepth))
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -451,6 +771,65 @@ func (m *WriteRequest) Unmarshal(dAtA []byte) error {
 				m.Record = []byte{}
 			}
 			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field AcceptedAt", wireType)
+			}
+			m.AcceptedAt = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWrite
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.AcceptedAt |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Quorum", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWrite
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Quorum = bool(v != 0)
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Sync", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWrite
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Sync = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipWrite(dAtA[iNdEx:])
@@ -537,6 +916,173 @@ func (m *WriteResponse) Unmarshal(dAtA []byte) error {
 			}
 			m.Err = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FlowControl", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWrite
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthWrite
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthWrite
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.FlowControl == nil {
+				m.FlowControl = &FlowControl{}
+			}
+			if err := m.FlowControl.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field AcceptedAt", wireType)
+			}
+			m.AcceptedAt = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWrite
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.AcceptedAt |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Sequence", wireType)
+			}
+			m.Sequence = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWrite
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Sequence |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipWrite(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthWrite
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthWrite
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *FlowControl) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowWrite
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: FlowControl: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: FlowControl: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Backpressure", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWrite
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Backpressure = bool(v != 0)
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field QueueDepth", wireType)
+			}
+			m.QueueDepth = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowWrite
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.QueueDepth |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipWrite(dAtA[iNdEx:])
@@ -0,0 +1,143 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package brokerquery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/coordinator/broker"
+	"github.com/lindb/lindb/internal/linmetric"
+)
+
+// ErrTooManyQueries represents the database reaches the max concurrent queries, client can retry later.
+var ErrTooManyQueries = errors.New("too many concurrent queries for database, please retry later")
+
+var (
+	databaseLimiterScope      = linmetric.NewScope("lindb.broker.query.database_limiter")
+	activeQueriesGaugeVec     = databaseLimiterScope.NewGaugeVec("active_queries", "db")
+	queuedQueriesGaugeVec     = databaseLimiterScope.NewGaugeVec("queued_queries", "db")
+	rejectedQueriesCounterVec = databaseLimiterScope.NewCounterVec("rejected_queries", "db")
+)
+
+// DatabaseLimiter limits the concurrent queries of each database,
+// so that one database under heavy load cannot monopolize the query pool and starve others.
+type DatabaseLimiter struct {
+	maxConcurrency int
+	queue          bool          // queue the query if reaches the limit, else reject it directly
+	timeout        time.Duration // max wait time in queue
+	stateMgr       broker.StateManager
+
+	limiters map[string]*databaseLimiter // database name => limiter
+	mutex    sync.Mutex
+}
+
+// databaseLimiter represents the concurrent queries limiter of database.
+type databaseLimiter struct {
+	tokens chan struct{}
+
+	active   *linmetric.BoundGauge
+	queued   *linmetric.BoundGauge
+	rejected *linmetric.BoundCounter
+}
+
+// NewDatabaseLimiter creates the database limiter, returns nil if no limit.
+func NewDatabaseLimiter(cfg config.Query, stateMgr broker.StateManager) *DatabaseLimiter {
+	if cfg.MaxConcurrentQueriesPerDB <= 0 {
+		return nil
+	}
+	return &DatabaseLimiter{
+		maxConcurrency: cfg.MaxConcurrentQueriesPerDB,
+		queue:          cfg.DatabaseLimitPolicy != config.DatabaseLimitPolicyReject,
+		timeout:        cfg.Timeout.Duration(),
+		stateMgr:       stateMgr,
+		limiters:       make(map[string]*databaseLimiter),
+	}
+}
+
+// Do executes the query of database if not reaches the limit,
+// returns ErrTooManyQueries if rejected or waits timeout in queue. Nil limiter executes the query directly.
+// Query of not exist database executes directly(fails when planning), so that no limiter and metric
+// is created for arbitrary database name of request.
+func (l *DatabaseLimiter) Do(ctx context.Context, database string, query func() error) error {
+	if l == nil {
+		return query()
+	}
+	if _, ok := l.stateMgr.GetDatabaseCfg(database); !ok {
+		return query()
+	}
+	limiter := l.getLimiter(database)
+	select {
+	case limiter.tokens <- struct{}{}:
+		return limiter.execute(query)
+	default:
+		// reaches the limit
+	}
+	if !l.queue {
+		limiter.rejected.Incr()
+		return fmt.Errorf("%w, db: %s", ErrTooManyQueries, database)
+	}
+
+	limiter.queued.Incr()
+	timer := time.NewTimer(l.timeout)
+	defer timer.Stop()
+	select {
+	case limiter.tokens <- struct{}{}:
+		limiter.queued.Decr()
+		return limiter.execute(query)
+	case <-ctx.Done():
+		limiter.queued.Decr()
+		return ctx.Err()
+	case <-timer.C:
+		limiter.queued.Decr()
+		limiter.rejected.Incr()
+		return fmt.Errorf("%w, db: %s", ErrTooManyQueries, database)
+	}
+}
+
+// getLimiter returns the limiter of database, creates it if not exist.
+func (l *DatabaseLimiter) getLimiter(database string) *databaseLimiter {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	limiter, ok := l.limiters[database]
+	if !ok {
+		limiter = &databaseLimiter{
+			tokens:   make(chan struct{}, l.maxConcurrency),
+			active:   activeQueriesGaugeVec.WithTagValues(database),
+			queued:   queuedQueriesGaugeVec.WithTagValues(database),
+			rejected: rejectedQueriesCounterVec.WithTagValues(database),
+		}
+		l.limiters[database] = limiter
+	}
+	return limiter
+}
+
+// execute executes the query after token acquired, releases the token after query completed.
+func (l *databaseLimiter) execute(query func() error) error {
+	l.active.Incr()
+	defer func() {
+		l.active.Decr()
+		<-l.tokens
+	}()
+	return query()
+}
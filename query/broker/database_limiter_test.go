@@ -0,0 +1,150 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package brokerquery
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/coordinator/broker"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/ltoml"
+)
+
+func TestDatabaseLimiter_nil(t *testing.T) {
+	limiter := NewDatabaseLimiter(config.Query{}, nil)
+	assert.Nil(t, limiter)
+	assert.NoError(t, limiter.Do(context.TODO(), "db", func() error { return nil }))
+}
+
+// newDatabaseStateMgr returns the state manager which all databases exist except "not_exist".
+func newDatabaseStateMgr(ctrl *gomock.Controller) broker.StateManager {
+	stateMgr := broker.NewMockStateManager(ctrl)
+	stateMgr.EXPECT().GetDatabaseCfg(gomock.Any()).DoAndReturn(func(database string) (models.Database, bool) {
+		return models.Database{Name: database}, database != "not_exist"
+	}).AnyTimes()
+	return stateMgr
+}
+
+func TestDatabaseLimiter_notExist(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	limiter := NewDatabaseLimiter(config.Query{MaxConcurrentQueriesPerDB: 1}, newDatabaseStateMgr(ctrl))
+	assert.NoError(t, limiter.Do(context.TODO(), "not_exist", func() error { return nil }))
+	// no limiter created for not exist database
+	assert.Empty(t, limiter.limiters)
+}
+
+func TestDatabaseLimiter_Do(t *testing.T) {
+	for _, policy := range []string{config.DatabaseLimitPolicyReject, config.DatabaseLimitPolicyQueue} {
+		policy := policy
+		t.Run(policy, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			db := "limiter_" + policy
+			limiter := NewDatabaseLimiter(config.Query{
+				MaxConcurrentQueriesPerDB: 2,
+				DatabaseLimitPolicy:       policy,
+				Timeout:                   ltoml.Duration(50 * time.Millisecond),
+			}, newDatabaseStateMgr(ctrl))
+			rejected := rejectedQueriesCounterVec.WithTagValues(db).Get()
+			// occupy all tokens of database
+			release := make(chan struct{})
+			running := make(chan struct{}, 2)
+			for i := 0; i < 2; i++ {
+				go func() {
+					_ = limiter.Do(context.TODO(), db, func() error {
+						running <- struct{}{}
+						<-release
+						return nil
+					})
+				}()
+			}
+			<-running
+			<-running
+			assert.Equal(t, float64(2), activeQueriesGaugeVec.WithTagValues(db).Get())
+
+			// other database is not affected
+			assert.NoError(t, limiter.Do(context.TODO(), db+"_other", func() error { return nil }))
+			// exceeds the limit, rejected directly or after queue timeout
+			err := limiter.Do(context.TODO(), db, func() error { return nil })
+			assert.True(t, errors.Is(err, ErrTooManyQueries))
+			assert.Equal(t, rejected+1, rejectedQueriesCounterVec.WithTagValues(db).Get())
+			assert.Equal(t, float64(0), queuedQueriesGaugeVec.WithTagValues(db).Get())
+
+			close(release)
+			assert.Eventually(t, func() bool {
+				return activeQueriesGaugeVec.WithTagValues(db).Get() == 0
+			}, time.Second, 10*time.Millisecond)
+			assert.NoError(t, limiter.Do(context.TODO(), db, func() error { return nil }))
+		})
+	}
+}
+
+func TestDatabaseLimiter_queue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	db := "limiter_queue_wait"
+	limiter := NewDatabaseLimiter(config.Query{
+		MaxConcurrentQueriesPerDB: 1,
+		DatabaseLimitPolicy:       config.DatabaseLimitPolicyQueue,
+		Timeout:                   ltoml.Duration(time.Second),
+	}, newDatabaseStateMgr(ctrl))
+	release := make(chan struct{})
+	running := make(chan struct{})
+	go func() {
+		_ = limiter.Do(context.TODO(), db, func() error {
+			close(running)
+			<-release
+			return nil
+		})
+	}()
+	<-running
+	// case 1: queued query executes after token released
+	time.AfterFunc(50*time.Millisecond, func() { close(release) })
+	executed := false
+	assert.NoError(t, limiter.Do(context.TODO(), db, func() error {
+		executed = true
+		return nil
+	}))
+	assert.True(t, executed)
+	// case 2: context canceled when queuing
+	release = make(chan struct{})
+	running = make(chan struct{})
+	go func() {
+		_ = limiter.Do(context.TODO(), db, func() error {
+			close(running)
+			<-release
+			return nil
+		})
+	}()
+	<-running
+	ctx, cancel := context.WithCancel(context.TODO())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	assert.Equal(t, context.Canceled, limiter.Do(ctx, db, func() error { return nil }))
+	close(release)
+}
@@ -27,15 +27,18 @@ import (
 type queryFactory struct {
 	stateMgr    broker.StateManager
 	taskManager TaskManager
+	resultCache ResultCache // nil if result cache disabled
 }
 
 func NewQueryFactory(
 	stateMgr broker.StateManager,
 	taskManager TaskManager,
+	resultCache ResultCache,
 ) Factory {
 	return &queryFactory{
 		stateMgr:    stateMgr,
 		taskManager: taskManager,
+		resultCache: resultCache,
 	}
 }
 
@@ -43,8 +46,10 @@ func (qh *queryFactory) NewMetricQuery(
 	ctx context.Context,
 	databaseName string,
 	sql string,
+	explain ExplainMode,
+	opts ...MetricQueryOption,
 ) MetricQuery {
-	return newMetricQuery(ctx, databaseName, sql, qh)
+	return newMetricQuery(ctx, databaseName, sql, explain, qh, opts...)
 }
 
 func (qh *queryFactory) NewMetadataQuery(
@@ -31,11 +31,12 @@ func TestExecutorFactory_NewExecutor(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	factory := NewQueryFactory(nil, nil)
+	factory := NewQueryFactory(nil, nil, nil)
 	assert.NotNil(t, factory.NewMetricQuery(
 		context.Background(),
 		"",
-		""))
+		"",
+		ExplainNone))
 	assert.NotNil(t, factory.NewMetadataQuery(
 		context.Background(),
 		"",
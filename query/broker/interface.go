@@ -39,6 +39,44 @@ var (
 	ErrTimeout = errors.New("exceed timeout")
 )
 
+// ExplainMode represents how to explain the metric query.
+type ExplainMode string
+
+const (
+	// ExplainNone executes the query without explain.
+	ExplainNone ExplainMode = ""
+	// ExplainAnalyze executes the query, returns the execute stats with timing.
+	ExplainAnalyze ExplainMode = "analyze"
+	// ExplainPlan returns the execute plan(shards, series, data families) without scanning data.
+	ExplainPlan ExplainMode = "plan"
+	// ExplainTrace executes the query, returns the routing trace(shards queried and num. of series each contributes).
+	ExplainTrace ExplainMode = "trace"
+)
+
+// MetricQueryOption represents the option of metric query.
+type MetricQueryOption func(mq *metricQuery)
+
+// WithLargeScan allows the metric query scanning more series than max series per query.
+func WithLargeScan() MetricQueryOption {
+	return func(mq *metricQuery) {
+		mq.largeScan = true
+	}
+}
+
+// WithWriteToken waits until the data written with write token is visible in storage before scanning(read-your-writes).
+func WithWriteToken(token string) MetricQueryOption {
+	return func(mq *metricQuery) {
+		mq.writeToken = token
+	}
+}
+
+// WithBestEffort returns the result of healthy shards with warning of failed shards, instead of failing the query.
+func WithBestEffort() MetricQueryOption {
+	return func(mq *metricQuery) {
+		mq.bestEffort = true
+	}
+}
+
 // Executor represents a query executor both storage/broker side.
 // When returning query results the following is the order in which processing takes place:
 // 1) filtering
@@ -66,7 +104,11 @@ var (
 //    because of for the system availability.
 
 type MetricQuery interface {
+	// WaitResponse returns the whole result set after query completed.
 	WaitResponse() (*models.ResultSet, error)
+	// StreamResponse sends the result set in chunks bounded by result chunk size,
+	// stops if send returns err(client canceled).
+	StreamResponse(send func(resultSet *models.ResultSet) error) error
 }
 
 // MetadataExecutor represents the metadata query executor, includes:
@@ -85,6 +127,8 @@ type Factory interface {
 		ctx context.Context,
 		databaseName string,
 		sql string,
+		explain ExplainMode,
+		opts ...MetricQueryOption,
 	) MetricQuery
 
 	NewMetadataQuery(
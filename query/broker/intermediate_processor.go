@@ -198,8 +198,9 @@ func (p *intermediateTaskProcessor) makeTaskResponse(
 		if len(fields) > 0 {
 			// always have group by
 			timeSeriesList = append(timeSeriesList, &protoCommonV1.TimeSeries{
-				Tags:   itr.Tags(),
-				Fields: fields,
+				Tags:      itr.Tags(),
+				Fields:    fields,
+				Exemplars: event.Exemplars[itr.Tags()],
 			})
 		}
 	}
@@ -220,5 +221,6 @@ func (p *intermediateTaskProcessor) makeTaskResponse(
 		SendTime:  timeutil.NowNano(),
 		Stats:     stats,
 		Payload:   data,
+		Stale:     event.Stale,
 	}
 }
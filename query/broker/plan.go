@@ -18,9 +18,11 @@
 package brokerquery
 
 import (
+	"github.com/lindb/lindb/config"
 	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/pkg/timeutil"
 	"github.com/lindb/lindb/query"
+	"github.com/lindb/lindb/series/metric"
 	"github.com/lindb/lindb/sql"
 	"github.com/lindb/lindb/sql/stmt"
 )
@@ -74,6 +76,12 @@ func (p *brokerPlan) Plan() error {
 	}
 	// set query statement
 	p.query = qry.(*stmt.Query)
+	if config.GlobalBrokerConfig().Ingestion.NormalizeFieldName {
+		// field name normalized when ingestion, query need target the normalized field name
+		for _, item := range p.query.SelectItems {
+			normalizeFieldName(item)
+		}
+	}
 
 	if p.query.Interval <= 0 {
 		var interval timeutil.Interval
@@ -194,3 +202,22 @@ func (p *brokerPlan) buildLeafs(parentID string, nodeIDs []string, receivers []m
 		})
 	}
 }
+
+// normalizeFieldName normalizes all field names of select item expr.
+func normalizeFieldName(expr stmt.Expr) {
+	switch e := expr.(type) {
+	case *stmt.SelectItem:
+		normalizeFieldName(e.Expr)
+	case *stmt.FieldExpr:
+		e.Name = string(metric.NormalizeFieldName([]byte(e.Name)))
+	case *stmt.CallExpr:
+		for _, param := range e.Params {
+			normalizeFieldName(param)
+		}
+	case *stmt.ParenExpr:
+		normalizeFieldName(e.Expr)
+	case *stmt.BinaryExpr:
+		normalizeFieldName(e.Left)
+		normalizeFieldName(e.Right)
+	}
+}
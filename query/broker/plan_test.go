@@ -22,9 +22,11 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/lindb/lindb/config"
 	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/pkg/option"
 	"github.com/lindb/lindb/query"
+	"github.com/lindb/lindb/sql/stmt"
 )
 
 func TestBrokerPlan_Wrong_Case(t *testing.T) {
@@ -61,6 +63,34 @@ func TestBrokerPlan_quantile(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestBrokerPlan_NormalizeFieldName(t *testing.T) {
+	defaultCfg := config.GlobalBrokerConfig()
+	defer config.SetGlobalBrokerConfig(defaultCfg)
+	cfg := *defaultCfg
+	cfg.Ingestion.NormalizeFieldName = true
+	config.SetGlobalBrokerConfig(&cfg)
+
+	storageNodes := map[string][]models.ShardID{"1.1.1.1:9000": {1, 2, 4}}
+	currentNode := generateBrokerActiveNode("1.1.1.3", 8000)
+	plan := newBrokerPlan("select Usage_Total,sum(CPU_Usage)+Load from cpu",
+		models.Database{Option: option.DatabaseOption{Interval: "10s"}},
+		storageNodes, currentNode, nil)
+	err := plan.Plan()
+	assert.NoError(t, err)
+	assert.Equal(t, "usage_total", plan.query.SelectItems[0].(*stmt.SelectItem).Expr.Rewrite())
+	assert.Equal(t, "sum(cpu_usage)+load", plan.query.SelectItems[1].Rewrite())
+
+	// field-names of histogram are reserved
+	plan = newBrokerPlan("select HistogramSum,histogramCount,quantile(0.99) from cpu",
+		models.Database{Option: option.DatabaseOption{Interval: "10s"}},
+		storageNodes, currentNode, nil)
+	err = plan.Plan()
+	assert.NoError(t, err)
+	assert.Equal(t, "HistogramSum", plan.query.SelectItems[0].Rewrite())
+	assert.Equal(t, "HistogramCount", plan.query.SelectItems[1].Rewrite())
+	assert.Equal(t, "quantile(0.99)", plan.query.SelectItems[2].Rewrite())
+}
+
 func TestBrokerPlan_No_GroupBy(t *testing.T) {
 	storageNodes := map[string][]models.ShardID{"1.1.1.1:9000": {1, 2, 4}, "1.1.1.2:9000": {3, 5, 6}}
 	currentNode := generateBrokerActiveNode("1.1.1.3", 8000)
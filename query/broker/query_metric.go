@@ -19,14 +19,17 @@ package brokerquery
 
 import (
 	"context"
+	"encoding/hex"
 	"fmt"
 	"time"
 
 	"github.com/lindb/lindb/aggregation"
+	"github.com/lindb/lindb/config"
 	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/pkg/ltoml"
 	"github.com/lindb/lindb/pkg/timeutil"
+	protoCommonV1 "github.com/lindb/lindb/proto/gen/v1/common"
 	"github.com/lindb/lindb/query"
 	"github.com/lindb/lindb/series"
 	"github.com/lindb/lindb/series/tag"
@@ -37,9 +40,13 @@ import (
 type metricQuery struct {
 	queryFactory *queryFactory
 
-	ctx      context.Context
-	database string
-	sql      string
+	ctx        context.Context
+	database   string
+	sql        string
+	explain    ExplainMode
+	largeScan  bool   // allows scanning more series than max series per query
+	bestEffort bool   // returns result of healthy shards if some shards fail
+	writeToken string // waits data written with token visible before scanning
 
 	startTime   time.Time
 	endPlanTime time.Time
@@ -54,14 +61,21 @@ func newMetricQuery(
 	ctx context.Context,
 	database string,
 	sql string,
+	explain ExplainMode,
 	queryFactory *queryFactory,
+	opts ...MetricQueryOption,
 ) MetricQuery {
-	return &metricQuery{
+	mq := &metricQuery{
 		sql:          sql,
+		explain:      explain,
 		database:     database,
 		ctx:          ctx,
 		queryFactory: queryFactory,
 	}
+	for _, opt := range opts {
+		opt(mq)
+	}
+	return mq
 }
 
 // makePlan executes search logic in broker level,
@@ -98,6 +112,18 @@ func (mq *metricQuery) makePlan() error {
 	mq.startTime = startTime
 	mq.plan.physicalPlan.Database = mq.database
 	mq.stmtQuery = mq.plan.query
+	mq.stmtQuery.AllowLargeScan = mq.largeScan
+	mq.stmtQuery.BestEffort = mq.bestEffort
+	mq.stmtQuery.WriteToken = mq.writeToken
+	switch mq.explain {
+	case ExplainAnalyze:
+		mq.stmtQuery.Explain = true
+	case ExplainPlan:
+		mq.stmtQuery.Explain = true
+		mq.stmtQuery.ExplainPlan = true
+	case ExplainTrace:
+		mq.stmtQuery.Trace = true
+	}
 	mq.expression = aggregation.NewExpression(
 		mq.plan.query.TimeRange,
 		mq.plan.query.Interval.Int64(),
@@ -106,6 +132,12 @@ func (mq *metricQuery) makePlan() error {
 	return nil
 }
 
+// estimatedPointSize is the estimated size of one point(timestamp and value) in result set.
+const estimatedPointSize = 24
+
+// staleResultWarning is the warning of result which may be stale because metadata of storage unavailable.
+const staleResultWarning = "result may be stale, because metadata of storage is unavailable"
+
 // WaitResponse builds the plan, the dispatch the task by task-manager
 func (mq *metricQuery) WaitResponse() (*models.ResultSet, error) {
 	if err := mq.makePlan(); err != nil {
@@ -113,6 +145,70 @@ func (mq *metricQuery) WaitResponse() (*models.ResultSet, error) {
 	}
 	mq.endPlanTime = time.Now()
 
+	resultCache := mq.queryFactory.resultCache
+	if resultCache != nil {
+		if resultSet, ok := resultCache.Get(mq.database, mq.stmtQuery); ok {
+			return resultSet, nil
+		}
+	}
+
+	event, err := mq.waitEvent()
+	if err != nil {
+		return nil, err
+	}
+
+	resultSet := mq.makeResultSet(event)
+	// partial result cannot be cached
+	if resultCache != nil && len(resultSet.Warnings) == 0 {
+		resultCache.Put(mq.database, mq.stmtQuery, resultSet)
+	}
+	return resultSet, nil
+}
+
+// StreamResponse builds the plan, dispatches the task by task-manager, then sends the result set in chunks,
+// the size of each chunk is bounded by result chunk size, the last chunk carries the stats/trace/warnings.
+// Storage nodes send the result of each scanned batch, broker merges the result of all nodes per series
+// before sending, so that each series is sent once with its final value, same as WaitResponse, because
+// partial aggregates of non-additive function(avg etc.) or expression cannot be combined by client.
+// Stops sending if send returns err(client canceled).
+func (mq *metricQuery) StreamResponse(send func(resultSet *models.ResultSet) error) error {
+	if err := mq.makePlan(); err != nil {
+		return err
+	}
+	mq.stmtQuery.Stream = true
+	mq.endPlanTime = time.Now()
+
+	event, err := mq.waitEvent()
+	if err != nil {
+		return err
+	}
+
+	makeResultStartTime := time.Now()
+	chunkSize := int(config.GlobalQueryConfig().ResultChunkSize)
+	chunk := mq.newResultSet()
+	size := 0
+	mq.makeSeries(event, func(timeSeries *models.Series) bool {
+		chunk.AddSeries(timeSeries)
+		size += estimateSeriesSize(timeSeries)
+		if size < chunkSize {
+			return true
+		}
+		if err = send(chunk); err != nil {
+			return false
+		}
+		chunk = mq.newResultSet()
+		size = 0
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	mq.fillResultStats(chunk, event, makeResultStartTime)
+	return send(chunk)
+}
+
+// waitEvent submits the task, then waits the merged response of all leaf nodes.
+func (mq *metricQuery) waitEvent() (*series.TimeSeriesEvent, error) {
 	eventCh, err := mq.queryFactory.taskManager.SubmitMetricTask(
 		mq.ctx,
 		mq.plan.physicalPlan,
@@ -122,29 +218,61 @@ func (mq *metricQuery) WaitResponse() (*models.ResultSet, error) {
 	if err != nil {
 		return nil, err
 	}
-	var (
-		event *series.TimeSeriesEvent
-		ok    bool
-	)
 	select {
-	case event, ok = <-eventCh:
+	case event, ok := <-eventCh:
 		if !ok {
 			return nil, fmt.Errorf("missing response from sent tasks")
 		}
 		if event.Err != nil {
 			return nil, event.Err
 		}
+		return event, nil
 	case <-mq.ctx.Done():
+		if mq.bestEffort {
+			// task manager completes best-effort query with the result of leaf nodes responded in time
+			return mq.waitEventAfterTimeout(eventCh)
+		}
 		return nil, ErrTimeout
 	}
+}
 
-	return mq.makeResultSet(event), nil
+// waitEventAfterTimeout waits the result of best-effort query completed after timeout.
+func (mq *metricQuery) waitEventAfterTimeout(eventCh <-chan *series.TimeSeriesEvent) (*series.TimeSeriesEvent, error) {
+	event, ok := <-eventCh
+	if !ok {
+		return nil, ErrTimeout
+	}
+	if event.Err != nil {
+		return nil, event.Err
+	}
+	return event, nil
 }
 
 func (mq *metricQuery) makeResultSet(event *series.TimeSeriesEvent) (resultSet *models.ResultSet) {
 	makeResultStartTime := time.Now()
 
-	resultSet = new(models.ResultSet)
+	resultSet = mq.newResultSet()
+	mq.makeSeries(event, func(timeSeries *models.Series) bool {
+		resultSet.AddSeries(timeSeries)
+		return true
+	})
+	mq.fillResultStats(resultSet, event, makeResultStartTime)
+	return resultSet
+}
+
+// newResultSet creates a result set with metric name and time range of query.
+func (mq *metricQuery) newResultSet() *models.ResultSet {
+	resultSet := models.NewResultSet()
+	resultSet.MetricName = mq.stmtQuery.MetricName
+	resultSet.StartTime = mq.stmtQuery.TimeRange.Start
+	resultSet.EndTime = mq.stmtQuery.TimeRange.End
+	resultSet.Interval = mq.stmtQuery.Interval.Int64()
+	return resultSet
+}
+
+// makeSeries evaluates the expression of each time series, then emits the result series,
+// stops if emit returns false.
+func (mq *metricQuery) makeSeries(event *series.TimeSeriesEvent, emit func(timeSeries *models.Series) bool) {
 	//TODO merge stats for cross idc query?
 	groupByKeys := mq.stmtQuery.GroupBy
 	groupByKeysLength := len(groupByKeys)
@@ -163,7 +291,6 @@ func (mq *metricQuery) makeResultSet(event *series.TimeSeriesEvent) (resultSet *
 			}
 		}
 		timeSeries := models.NewSeries(tags)
-		resultSet.AddSeries(timeSeries)
 		mq.expression.Eval(ts)
 		rs := mq.expression.ResultSet()
 		for fieldName, values := range rs {
@@ -179,14 +306,31 @@ func (mq *metricQuery) makeResultSet(event *series.TimeSeriesEvent) (resultSet *
 			timeSeries.AddField(fieldName, points)
 		}
 		mq.expression.Reset()
+		if len(event.Exemplars) > 0 {
+			addExemplars(timeSeries, event.Exemplars[ts.Tags()])
+		}
+		if !emit(timeSeries) {
+			return
+		}
 	}
+}
 
-	resultSet.MetricName = mq.stmtQuery.MetricName
-	resultSet.StartTime = mq.stmtQuery.TimeRange.Start
-	resultSet.EndTime = mq.stmtQuery.TimeRange.End
-	resultSet.Interval = mq.stmtQuery.Interval.Int64()
-
-	resultSet.Stats = event.Stats
+// fillResultStats fills the warnings/trace/stats of query into result set.
+func (mq *metricQuery) fillResultStats(
+	resultSet *models.ResultSet,
+	event *series.TimeSeriesEvent,
+	makeResultStartTime time.Time,
+) {
+	resultSet.Warnings = mq.makeFailedShardsWarnings(event.FailedNodes)
+	if event.Stale {
+		resultSet.Warnings = append(resultSet.Warnings, staleResultWarning)
+	}
+	if mq.stmtQuery.Trace {
+		resultSet.Trace = models.NewQueryTrace(mq.plan.physicalPlan.Leafs, event.Stats)
+	}
+	if mq.stmtQuery.Explain {
+		resultSet.Stats = event.Stats
+	}
 	if resultSet.Stats != nil {
 		now := time.Now()
 		resultSet.Stats.PlanCost = ltoml.Duration(mq.endPlanTime.Sub(mq.startTime))
@@ -194,5 +338,44 @@ func (mq *metricQuery) makeResultSet(event *series.TimeSeriesEvent) (resultSet *
 		resultSet.Stats.ExpressCost = ltoml.Duration(now.Sub(makeResultStartTime))
 		resultSet.Stats.TotalCost = ltoml.Duration(now.Sub(mq.startTime))
 	}
-	return resultSet
+}
+
+// addExemplars adds the exemplars of fields into time series, trace/span id are hex encoded.
+func addExemplars(timeSeries *models.Series, exemplars []*protoCommonV1.Exemplar) {
+	for _, exemplar := range exemplars {
+		timeSeries.AddExemplar(exemplar.FieldName, &models.Exemplar{
+			TraceID:   hex.EncodeToString(exemplar.TraceID),
+			SpanID:    hex.EncodeToString(exemplar.SpanID),
+			Duration:  exemplar.Duration,
+			Value:     exemplar.Value,
+			Timestamp: exemplar.Timestamp,
+		})
+	}
+}
+
+// estimateSeriesSize returns the estimated size of series in result set.
+func estimateSeriesSize(timeSeries *models.Series) (size int) {
+	for tagKey, tagValue := range timeSeries.Tags {
+		size += len(tagKey) + len(tagValue)
+	}
+	for fieldName, points := range timeSeries.Fields {
+		size += len(fieldName) + len(points)*estimatedPointSize
+	}
+	return size
+}
+
+// makeFailedShardsWarnings returns the warnings of failed shards, which are ignored by best-effort query.
+func (mq *metricQuery) makeFailedShardsWarnings(failedNodes map[string]string) (warnings []string) {
+	if len(failedNodes) == 0 {
+		return nil
+	}
+	for _, leaf := range mq.plan.physicalPlan.Leafs {
+		errMsg, ok := failedNodes[leaf.Indicator]
+		if !ok {
+			continue
+		}
+		warnings = append(warnings, fmt.Sprintf("query shards %v on node %s failed: %s",
+			leaf.ShardIDs, leaf.Indicator, errMsg))
+	}
+	return warnings
 }
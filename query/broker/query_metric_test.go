@@ -27,10 +27,13 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/lindb/lindb/aggregation"
+	"github.com/lindb/lindb/aggregation/function"
+	"github.com/lindb/lindb/config"
 	"github.com/lindb/lindb/coordinator/broker"
 	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/pkg/option"
 	"github.com/lindb/lindb/pkg/timeutil"
+	protoCommonV1 "github.com/lindb/lindb/proto/gen/v1/common"
 	"github.com/lindb/lindb/series"
 	"github.com/lindb/lindb/series/field"
 	"github.com/lindb/lindb/sql"
@@ -62,7 +65,7 @@ func Test_MetricQuery(t *testing.T) {
 	qry := newMetricQuery(context.Background(),
 		"test_db",
 		"select f from cpu",
-		queryFactory)
+		ExplainNone, queryFactory)
 	stateMgr.EXPECT().GetDatabaseCfg("test_db").Return(models.Database{}, false)
 	_, err := qry.WaitResponse()
 	assert.Error(t, err)
@@ -74,7 +77,7 @@ func Test_MetricQuery(t *testing.T) {
 	qry = newMetricQuery(context.Background(),
 		"test_db",
 		"select f from cpu",
-		queryFactory)
+		ExplainNone, queryFactory)
 	stateMgr.EXPECT().GetQueryableReplicas("test_db").Return(nil, nil)
 	_, err = qry.WaitResponse()
 	assert.Error(t, err)
@@ -95,7 +98,7 @@ func Test_MetricQuery(t *testing.T) {
 	qry = newMetricQuery(context.Background(),
 		"test_db",
 		"select f fro",
-		queryFactory)
+		ExplainNone, queryFactory)
 	_, err = qry.WaitResponse()
 	assert.Error(t, err)
 
@@ -106,14 +109,14 @@ func Test_MetricQuery(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	qry = newMetricQuery(ctx,
 		"test_db", "select f from cpu",
-		queryFactory)
+		ExplainNone, queryFactory)
 	time.AfterFunc(time.Millisecond*200, cancel)
 	_, err = qry.WaitResponse()
 	assert.Error(t, err)
 
 	qry = newMetricQuery(context.Background(),
 		"test_db", "select f from cpu",
-		queryFactory)
+		ExplainNone, queryFactory)
 	// has error
 	eventCh2 := make(chan *series.TimeSeriesEvent)
 	taskManager.EXPECT().SubmitMetricTask(gomock.Any(), gomock.Any(), gomock.Any()).Return(eventCh2, nil)
@@ -130,6 +133,283 @@ func Test_MetricQuery(t *testing.T) {
 	_, err = qry.WaitResponse()
 	assert.Error(t, err)
 
+	// explain mode
+	cases := []struct {
+		explain     ExplainMode
+		analyze     bool
+		explainPlan bool
+		trace       bool
+	}{
+		{explain: ExplainNone},
+		{explain: ExplainAnalyze, analyze: true},
+		{explain: ExplainPlan, analyze: true, explainPlan: true},
+		{explain: ExplainTrace, trace: true},
+	}
+	for _, tt := range cases {
+		var q *stmt.Query
+		taskManager.EXPECT().SubmitMetricTask(gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ *models.PhysicalPlan, stmtQuery *stmt.Query) (<-chan *series.TimeSeriesEvent, error) {
+				q = stmtQuery
+				return nil, io.ErrClosedPipe
+			})
+		qry = newMetricQuery(context.Background(), "test_db", "select f from cpu", tt.explain, queryFactory)
+		_, err = qry.WaitResponse()
+		assert.Error(t, err)
+		assert.Equal(t, tt.analyze, q.Explain)
+		assert.Equal(t, tt.explainPlan, q.ExplainPlan)
+		assert.Equal(t, tt.trace, q.Trace)
+	}
+
+	// large scan option
+	var q *stmt.Query
+	taskManager.EXPECT().SubmitMetricTask(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ *models.PhysicalPlan, stmtQuery *stmt.Query) (<-chan *series.TimeSeriesEvent, error) {
+			q = stmtQuery
+			return nil, io.ErrClosedPipe
+		})
+	qry = newMetricQuery(context.Background(), "test_db", "select f from cpu", ExplainNone, queryFactory, WithLargeScan())
+	_, err = qry.WaitResponse()
+	assert.Error(t, err)
+	assert.True(t, q.AllowLargeScan)
+	assert.False(t, q.BestEffort)
+
+	// best effort option
+	taskManager.EXPECT().SubmitMetricTask(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ *models.PhysicalPlan, stmtQuery *stmt.Query) (<-chan *series.TimeSeriesEvent, error) {
+			q = stmtQuery
+			return nil, io.ErrClosedPipe
+		})
+	qry = newMetricQuery(context.Background(), "test_db", "select f from cpu", ExplainNone, queryFactory, WithBestEffort())
+	_, err = qry.WaitResponse()
+	assert.Error(t, err)
+	assert.True(t, q.BestEffort)
+	// best-effort query waits result of leafs responded in time after timeout
+	for _, event := range []*series.TimeSeriesEvent{
+		{FailedNodes: map[string]string{"1.1.1.1:9000": ErrTimeout.Error()}},
+		{Err: ErrTimeout},
+		nil,
+	} {
+		event := event
+		eventCh := make(chan *series.TimeSeriesEvent, 1)
+		taskManager.EXPECT().SubmitMetricTask(gomock.Any(), gomock.Any(), gomock.Any()).Return(eventCh, nil)
+		ctx, cancel = context.WithCancel(context.Background())
+		cancel()
+		time.AfterFunc(50*time.Millisecond, func() {
+			if event != nil {
+				eventCh <- event
+			}
+			close(eventCh)
+		})
+		qry = newMetricQuery(ctx, "test_db", "select f from cpu", ExplainNone, queryFactory, WithBestEffort())
+		_, err = qry.WaitResponse()
+		if event == nil || event.Err != nil {
+			assert.Equal(t, ErrTimeout, err)
+		} else {
+			assert.NoError(t, err)
+		}
+	}
+
+	// read-your-writes option
+	taskManager.EXPECT().SubmitMetricTask(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ *models.PhysicalPlan, stmtQuery *stmt.Query) (<-chan *series.TimeSeriesEvent, error) {
+			q = stmtQuery
+			return nil, io.ErrClosedPipe
+		})
+	qry = newMetricQuery(context.Background(), "test_db", "select f from cpu", ExplainNone, queryFactory, WithWriteToken("token"))
+	_, err = qry.WaitResponse()
+	assert.Error(t, err)
+	assert.Equal(t, "token", q.WriteToken)
+
+	// result cache, second query served from cache
+	queryFactory.resultCache = NewResultCache(10, time.Minute)
+	sql := "select f from cpu where time>'20190729 11:00:00' and time<'20190729 12:00:00'"
+	eventCh4 := make(chan *series.TimeSeriesEvent, 1)
+	eventCh4 <- &series.TimeSeriesEvent{}
+	taskManager.EXPECT().SubmitMetricTask(gomock.Any(), gomock.Any(), gomock.Any()).Return(eventCh4, nil)
+	rs1, err := newMetricQuery(context.Background(), "test_db", sql, ExplainNone, queryFactory).WaitResponse()
+	assert.NoError(t, err)
+	rs2, err := newMetricQuery(context.Background(), "test_db", sql, ExplainNone, queryFactory).WaitResponse()
+	assert.NoError(t, err)
+	assert.Equal(t, rs1, rs2)
+}
+
+func Test_MetricQuery_StreamResponse(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		config.SetGlobalQueryConfig(config.NewDefaultQuery())
+		ctrl.Finish()
+	}()
+
+	currentNode := generateBrokerActiveNode("1.1.1.3", 8000)
+	stateMgr := broker.NewMockStateManager(ctrl)
+	stateMgr.EXPECT().GetCurrentNode().Return(currentNode).AnyTimes()
+	stateMgr.EXPECT().GetLiveNodes().Return([]models.StatelessNode{currentNode}).AnyTimes()
+	stateMgr.EXPECT().GetDatabaseCfg("test_db").
+		Return(models.Database{Option: option.DatabaseOption{Interval: "10s"}}, true).AnyTimes()
+	stateMgr.EXPECT().GetQueryableReplicas("test_db").
+		Return(map[string][]models.ShardID{"1.1.1.1:9000": {1}}, nil).AnyTimes()
+	taskManager := NewMockTaskManager(ctrl)
+	queryFactory := &queryFactory{
+		stateMgr:    stateMgr,
+		taskManager: taskManager,
+		resultCache: NewResultCache(10, time.Minute),
+	}
+	newSeriesList := func(hosts ...string) (seriesList series.GroupedIterators) {
+		for _, host := range hosts {
+			it := series.NewMockGroupedIterator(ctrl)
+			it.EXPECT().Tags().Return(host).AnyTimes()
+			it.EXPECT().HasNext().Return(false).AnyTimes()
+			seriesList = append(seriesList, it)
+		}
+		return seriesList
+	}
+	mockEvent := func(hosts ...string) {
+		eventCh := make(chan *series.TimeSeriesEvent, 1)
+		eventCh <- &series.TimeSeriesEvent{SeriesList: newSeriesList(hosts...)}
+		taskManager.EXPECT().SubmitMetricTask(gomock.Any(), gomock.Any(), gomock.Any()).Return(eventCh, nil)
+	}
+	sql := "select f from cpu group by host"
+
+	// case 1: bad sql
+	err := newMetricQuery(context.Background(), "test_db", "select f fro", ExplainNone, queryFactory).
+		StreamResponse(func(_ *models.ResultSet) error { return nil })
+	assert.Error(t, err)
+	// case 2: submit task failure
+	taskManager.EXPECT().SubmitMetricTask(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, io.ErrClosedPipe)
+	err = newMetricQuery(context.Background(), "test_db", sql, ExplainNone, queryFactory).
+		StreamResponse(func(_ *models.ResultSet) error { return nil })
+	assert.Error(t, err)
+	// case 3: all series in one chunk
+	mockEvent("host1", "host2", "host3")
+	var chunks []*models.ResultSet
+	err = newMetricQuery(context.Background(), "test_db", sql, ExplainNone, queryFactory).
+		StreamResponse(func(rs *models.ResultSet) error {
+			chunks = append(chunks, rs)
+			return nil
+		})
+	assert.NoError(t, err)
+	assert.Len(t, chunks, 1)
+	assert.Len(t, chunks[0].Series, 3)
+	assert.Equal(t, "cpu", chunks[0].MetricName)
+	// case 4: stream in multiple chunks, each chunk has one series, the last chunk only has stats
+	queryCfg := config.NewDefaultQuery()
+	queryCfg.ResultChunkSize = 5
+	config.SetGlobalQueryConfig(queryCfg)
+	mockEvent("host1", "host2", "host3")
+	chunks = nil
+	err = newMetricQuery(context.Background(), "test_db", sql, ExplainNone, queryFactory).
+		StreamResponse(func(rs *models.ResultSet) error {
+			chunks = append(chunks, rs)
+			return nil
+		})
+	assert.NoError(t, err)
+	assert.Len(t, chunks, 4)
+	for i, host := range []string{"host1", "host2", "host3"} {
+		assert.Equal(t, map[string]string{"host": host}, chunks[i].Series[0].Tags)
+		assert.Equal(t, "cpu", chunks[i].MetricName)
+	}
+	assert.Empty(t, chunks[3].Series)
+	// case 5: client canceled, stop sending the rest chunks
+	mockEvent("host1", "host2", "host3")
+	sent := 0
+	err = newMetricQuery(context.Background(), "test_db", sql, ExplainNone, queryFactory).
+		StreamResponse(func(_ *models.ResultSet) error {
+			sent++
+			return context.Canceled
+		})
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, 1, sent)
+	// case 6: storage nodes are asked to stream result, timeout waiting the merged result
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	taskManager.EXPECT().SubmitMetricTask(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ *models.PhysicalPlan, stmtQuery *stmt.Query) (<-chan *series.TimeSeriesEvent, error) {
+			assert.True(t, stmtQuery.Stream)
+			return make(chan *series.TimeSeriesEvent), nil
+		})
+	err = newMetricQuery(ctx, "test_db", sql, ExplainNone, queryFactory).
+		StreamResponse(func(_ *models.ResultSet) error { return nil })
+	assert.Equal(t, ErrTimeout, err)
+}
+
+func Test_MetricQuery_StreamResponse_avg(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	currentNode := generateBrokerActiveNode("1.1.1.3", 8000)
+	stateMgr := broker.NewMockStateManager(ctrl)
+	stateMgr.EXPECT().GetCurrentNode().Return(currentNode).AnyTimes()
+	stateMgr.EXPECT().GetLiveNodes().Return([]models.StatelessNode{currentNode}).AnyTimes()
+	stateMgr.EXPECT().GetDatabaseCfg("test_db").
+		Return(models.Database{Option: option.DatabaseOption{Interval: "10s"}}, true).AnyTimes()
+	stateMgr.EXPECT().GetQueryableReplicas("test_db").
+		Return(map[string][]models.ShardID{"1.1.1.1:9000": {1}, "1.1.1.2:9000": {2}}, nil).AnyTimes()
+	taskManager := NewMockTaskManager(ctrl)
+	queryFactory := &queryFactory{
+		stateMgr:    stateMgr,
+		taskManager: taskManager,
+	}
+	// marshals the sum of latency/count of host1 at first point, like result of one scanned batch on storage
+	newPayload := func(q *stmt.Query, latency, count float64) []byte {
+		calc := q.Interval.Calculator()
+		segmentTime := calc.CalcSegmentTime(q.TimeRange.Start)
+		familyTime := calc.CalcFamilyStartTime(segmentTime, calc.CalcFamily(q.TimeRange.Start, segmentTime))
+		tsList := &protoCommonV1.TimeSeriesList{}
+		ts := &protoCommonV1.TimeSeries{Tags: "host1", Fields: make(map[string][]byte)}
+		for fieldName, value := range map[field.Name]float64{"latency": latency, "count": count} {
+			spec := aggregation.NewAggregatorSpec(fieldName, field.SumField)
+			spec.AddFunctionType(function.Sum)
+			seriesAgg := aggregation.NewSeriesAggregator(q.Interval, 1, q.TimeRange, spec)
+			agg, ok := seriesAgg.GetAggregator(familyTime)
+			assert.True(t, ok)
+			agg.AggregateBySlot(0, value)
+			data, err := seriesAgg.ResultSet().MarshalBinary()
+			assert.NoError(t, err)
+			ts.Fields[string(fieldName)] = data
+			tsList.FieldAggSpecs = append(tsList.FieldAggSpecs, &protoCommonV1.AggregatorSpec{
+				FieldName:    string(fieldName),
+				FieldType:    uint32(field.SumField),
+				FuncTypeList: []uint32{uint32(function.Sum)},
+			})
+		}
+		tsList.TimeSeriesList = append(tsList.TimeSeriesList, ts)
+		payload, err := tsList.Marshal()
+		assert.NoError(t, err)
+		return payload
+	}
+	// result of host1 is split into multi chunks on each node
+	taskManager.EXPECT().SubmitMetricTask(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ *models.PhysicalPlan, stmtQuery *stmt.Query) (<-chan *series.TimeSeriesEvent, error) {
+			eventCh := make(chan *series.TimeSeriesEvent, 1)
+			taskCtx := newMetricTaskContext("1", RootTask, "", "", stmtQuery, 2, eventCh)
+			taskCtx.WriteResponse(&protoCommonV1.TaskResponse{
+				Payload: newPayload(stmtQuery, 10, 1), Chunks: 1}, "1.1.1.1:9000")
+			taskCtx.WriteResponse(&protoCommonV1.TaskResponse{
+				Payload: newPayload(stmtQuery, 30, 1), Chunks: 1, Completed: true}, "1.1.1.1:9000")
+			taskCtx.WriteResponse(&protoCommonV1.TaskResponse{
+				Payload: newPayload(stmtQuery, 20, 2)}, "1.1.1.2:9000")
+			return eventCh, nil
+		}).Times(2)
+	sql := "select sum(latency)/sum(count) as avg_latency from cpu " +
+		"where time>'20190729 11:00:00' and time<'20190729 12:00:00' group by host"
+
+	rs, err := newMetricQuery(context.Background(), "test_db", sql, ExplainNone, queryFactory).WaitResponse()
+	assert.NoError(t, err)
+	var chunks []*models.ResultSet
+	err = newMetricQuery(context.Background(), "test_db", sql, ExplainNone, queryFactory).
+		StreamResponse(func(rs *models.ResultSet) error {
+			chunks = append(chunks, rs)
+			return nil
+		})
+	assert.NoError(t, err)
+	assert.Len(t, chunks, 1)
+	// each series is sent once with the value merged from all nodes
+	assert.Len(t, rs.Series, 1)
+	assert.Equal(t, rs.Series, chunks[0].Series)
+	assert.NotEmpty(t, rs.Series[0].Fields["avg_latency"])
+	for _, value := range rs.Series[0].Fields["avg_latency"] {
+		assert.Equal(t, 15.0, value)
+	}
 }
 
 // mockSingleIterator returns mock an iterator of single field
@@ -200,4 +480,78 @@ func Test_MetricQuery_makeResultSet(t *testing.T) {
 			ExpressCost: 200,
 		},
 	})
+
+	// exemplars of fields
+	gomock.InOrder(
+		timeSeries.EXPECT().HasNext().Return(true),
+		timeSeries.EXPECT().Next().Return(mockTimeSeries(ctrl, familyTime, "f1", field.SumField, field.Sum)),
+		timeSeries.EXPECT().HasNext().Return(false),
+	)
+	timeSeries.EXPECT().Tags().Return("")
+	rs := qry.makeResultSet(&series.TimeSeriesEvent{
+		SeriesList: []series.GroupedIterator{timeSeries},
+		Exemplars: map[string][]*protoCommonV1.Exemplar{
+			"": {{FieldName: "f1", TraceID: []byte{0xab, 0x01}, Value: 1, Timestamp: 10}},
+		},
+	})
+	assert.Equal(t, map[string][]*models.Exemplar{
+		"f1": {{TraceID: "ab01", Value: 1, Timestamp: 10}},
+	}, rs.Series[0].Exemplars)
+}
+
+func Test_MetricQuery_makeResultSet_Trace(t *testing.T) {
+	physicalPlan := models.NewPhysicalPlan(models.Root{Indicator: "1.1.1.3:8000", NumOfTask: 2})
+	physicalPlan.AddLeaf(models.Leaf{
+		BaseNode: models.BaseNode{Parent: "1.1.1.3:8000", Indicator: "1.1.1.1:9000"},
+		ShardIDs: []models.ShardID{1, 3},
+	})
+	physicalPlan.AddLeaf(models.Leaf{
+		BaseNode: models.BaseNode{Parent: "1.1.1.3:8000", Indicator: "1.1.1.2:9000"},
+		ShardIDs: []models.ShardID{2},
+	})
+	stats := models.NewQueryStats()
+	storageStats := models.NewStorageStats()
+	storageStats.SetShardSeriesIDsSearchStats(1, 10, 0)
+	storageStats.SetShardSeriesIDsSearchStats(3, 5, 0)
+	stats.MergeStorageTaskStats("1.1.1.1:9000", storageStats)
+	storageStats = models.NewStorageStats()
+	storageStats.SetShardSeriesIDsSearchStats(2, 0, 0)
+	stats.MergeStorageTaskStats("1.1.1.2:9000", storageStats)
+
+	qry := &metricQuery{
+		expression: aggregation.NewExpression(timeutil.TimeRange{Start: 1, End: 2}, timeutil.OneMinute, nil),
+		stmtQuery:  &stmt.Query{MetricName: "cpu", Trace: true},
+		plan:       &brokerPlan{physicalPlan: physicalPlan},
+	}
+	rs := qry.makeResultSet(&series.TimeSeriesEvent{Stats: stats})
+	// stats only returned for explain query
+	assert.Nil(t, rs.Stats)
+	assert.Equal(t, &models.QueryTrace{Shards: []models.ShardTrace{
+		{Node: "1.1.1.1:9000", ShardID: 1, NumOfSeries: 10, Searched: true},
+		{Node: "1.1.1.1:9000", ShardID: 3, NumOfSeries: 5, Searched: true},
+		{Node: "1.1.1.2:9000", ShardID: 2, NumOfSeries: 0, Searched: true},
+	}}, rs.Trace)
+}
+
+func Test_MetricQuery_makeResultSet_Warnings(t *testing.T) {
+	physicalPlan := models.NewPhysicalPlan(models.Root{Indicator: "1.1.1.3:8000", NumOfTask: 2})
+	physicalPlan.AddLeaf(models.Leaf{
+		BaseNode: models.BaseNode{Parent: "1.1.1.3:8000", Indicator: "1.1.1.1:9000"},
+		ShardIDs: []models.ShardID{1, 3},
+	})
+	physicalPlan.AddLeaf(models.Leaf{
+		BaseNode: models.BaseNode{Parent: "1.1.1.3:8000", Indicator: "1.1.1.2:9000"},
+		ShardIDs: []models.ShardID{2},
+	})
+	qry := &metricQuery{
+		expression: aggregation.NewExpression(timeutil.TimeRange{Start: 1, End: 2}, timeutil.OneMinute, nil),
+		stmtQuery:  &stmt.Query{MetricName: "cpu", BestEffort: true},
+		plan:       &brokerPlan{physicalPlan: physicalPlan},
+	}
+	rs := qry.makeResultSet(&series.TimeSeriesEvent{})
+	assert.Empty(t, rs.Warnings)
+	rs = qry.makeResultSet(&series.TimeSeriesEvent{FailedNodes: map[string]string{"1.1.1.1:9000": "read shard err"}})
+	assert.Equal(t, []string{"query shards [1 3] on node 1.1.1.1:9000 failed: read shard err"}, rs.Warnings)
+	rs = qry.makeResultSet(&series.TimeSeriesEvent{Stale: true})
+	assert.Equal(t, []string{staleResultWarning}, rs.Warnings)
 }
@@ -0,0 +1,159 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package brokerquery
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/lindb/lindb/internal/linmetric"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/encoding"
+	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/sql/stmt"
+)
+
+// for testing
+var (
+	nowFunc = time.Now
+)
+
+var (
+	resultCacheScope         = linmetric.NewScope("lindb.broker.query.result_cache")
+	resultCacheHitCounter    = resultCacheScope.NewCounter("hits")
+	resultCacheMissCounter   = resultCacheScope.NewCounter("misses")
+	resultCacheEvictCounter  = resultCacheScope.NewCounter("evictions")
+	resultCacheBypassCounter = resultCacheScope.NewCounter("bypasses")
+)
+
+// ResultCache caches the result set of metric query keyed by query signature,
+// so that identical queries re-issued by dashboards can be served without querying storage.
+type ResultCache interface {
+	// Get returns the cached result set of query if it is fresh.
+	Get(database string, query *stmt.Query) (*models.ResultSet, bool)
+	// Put caches the result set of query if query's time range doesn't include the live interval.
+	Put(database string, query *stmt.Query, resultSet *models.ResultSet)
+}
+
+// cacheEntry represents the cached result set with expire time.
+type cacheEntry struct {
+	key       string
+	resultSet *models.ResultSet
+	expireAt  time.Time
+}
+
+// lruResultCache implements ResultCache based on lru list with ttl.
+type lruResultCache struct {
+	size    int
+	ttl     time.Duration
+	entries map[string]*list.Element
+	lru     *list.List // front is the most recently used
+	mutex   sync.Mutex
+}
+
+// NewResultCache creates the result cache with max entries and ttl, returns nil if cache disabled(size or ttl <= 0).
+func NewResultCache(size int, ttl time.Duration) ResultCache {
+	if size <= 0 || ttl <= 0 {
+		return nil
+	}
+	return &lruResultCache{
+		size:    size,
+		ttl:     ttl,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// Get returns the cached result set of query if it is fresh.
+func (c *lruResultCache) Get(database string, query *stmt.Query) (*models.ResultSet, bool) {
+	if !cacheable(query) {
+		resultCacheBypassCounter.Incr()
+		return nil, false
+	}
+	key := querySignature(database, query)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		resultCacheMissCounter.Incr()
+		return nil, false
+	}
+	entry := elem.Value.(*cacheEntry)
+	if nowFunc().After(entry.expireAt) {
+		c.remove(elem)
+		resultCacheMissCounter.Incr()
+		return nil, false
+	}
+	c.lru.MoveToFront(elem)
+	resultCacheHitCounter.Incr()
+	return entry.resultSet, true
+}
+
+// Put caches the result set of query if query's time range doesn't include the live interval.
+func (c *lruResultCache) Put(database string, query *stmt.Query, resultSet *models.ResultSet) {
+	if resultSet == nil || !cacheable(query) {
+		return
+	}
+	key := querySignature(database, query)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	expireAt := nowFunc().Add(c.ttl)
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*cacheEntry)
+		entry.resultSet = resultSet
+		entry.expireAt = expireAt
+		c.lru.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(&cacheEntry{
+		key:       key,
+		resultSet: resultSet,
+		expireAt:  expireAt,
+	})
+	for c.lru.Len() > c.size {
+		c.remove(c.lru.Back())
+		resultCacheEvictCounter.Incr()
+	}
+}
+
+// remove removes the entry from cache, must be called with lock held.
+func (c *lruResultCache) remove(elem *list.Element) {
+	c.lru.Remove(elem)
+	delete(c.entries, elem.Value.(*cacheEntry).key)
+}
+
+// cacheable checks if the result of query can be cached, the query which needs explain stats, waits written data
+// visible(read-your-writes) or whose time range includes the current interval(data is still ingesting) cannot be cached.
+func cacheable(query *stmt.Query) bool {
+	if query.Explain || query.Trace || query.WriteToken != "" {
+		return false
+	}
+	liveIntervalStart := timeutil.Truncate(nowFunc().UnixNano()/int64(time.Millisecond), query.Interval.Int64())
+	return query.TimeRange.End < liveIntervalStart
+}
+
+// querySignature returns the normalized signature of query,
+// includes metric, filter, time range, interval and select items(aggregation).
+func querySignature(database string, query *stmt.Query) string {
+	return database + "|" + string(encoding.JSONMarshal(query))
+}
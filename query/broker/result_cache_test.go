@@ -0,0 +1,123 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package brokerquery
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/sql/stmt"
+)
+
+func TestNewResultCache(t *testing.T) {
+	assert.Nil(t, NewResultCache(0, time.Second))
+	assert.Nil(t, NewResultCache(10, 0))
+	assert.NotNil(t, NewResultCache(10, time.Second))
+}
+
+func TestResultCache_GetPut(t *testing.T) {
+	now := time.Date(2021, 7, 1, 10, 0, 0, 0, time.Local)
+	nowFunc = func() time.Time { return now }
+	defer func() {
+		nowFunc = time.Now
+	}()
+	nowMillis := now.UnixNano() / int64(time.Millisecond)
+	newQuery := func(metric string, end int64) *stmt.Query {
+		return &stmt.Query{
+			MetricName: metric,
+			TimeRange:  timeutil.TimeRange{Start: end - timeutil.OneHour, End: end},
+			Interval:   timeutil.Interval(10 * timeutil.OneSecond),
+		}
+	}
+	cache := NewResultCache(2, 10*time.Second)
+	rs := &models.ResultSet{MetricName: "cpu"}
+	q := newQuery("cpu", nowMillis-timeutil.OneMinute)
+
+	// case 1: miss
+	_, ok := cache.Get("db", q)
+	assert.False(t, ok)
+	// case 2: hit
+	hits := resultCacheHitCounter.Get()
+	cache.Put("db", q, rs)
+	rs1, ok := cache.Get("db", newQuery("cpu", nowMillis-timeutil.OneMinute))
+	assert.True(t, ok)
+	assert.Equal(t, rs, rs1)
+	assert.Equal(t, hits+1, resultCacheHitCounter.Get())
+	// case 3: other database/signature
+	_, ok = cache.Get("db2", q)
+	assert.False(t, ok)
+	q2 := newQuery("cpu", nowMillis-timeutil.OneMinute)
+	q2.Condition = &stmt.EqualsExpr{Key: "host", Value: "1.1.1.1"}
+	_, ok = cache.Get("db", q2)
+	assert.False(t, ok)
+	// case 4: ttl expired
+	now = now.Add(11 * time.Second)
+	nowMillis = now.UnixNano() / int64(time.Millisecond)
+	_, ok = cache.Get("db", q)
+	assert.False(t, ok)
+	// case 5: time range includes live interval, not cached
+	liveQuery := newQuery("cpu", nowMillis)
+	bypasses := resultCacheBypassCounter.Get()
+	cache.Put("db", liveQuery, rs)
+	_, ok = cache.Get("db", liveQuery)
+	assert.False(t, ok)
+	assert.Equal(t, bypasses+1, resultCacheBypassCounter.Get())
+	// case 6: explain query, not cached
+	explainQuery := newQuery("cpu", nowMillis-timeutil.OneMinute)
+	explainQuery.Explain = true
+	cache.Put("db", explainQuery, rs)
+	_, ok = cache.Get("db", explainQuery)
+	assert.False(t, ok)
+	traceQuery := newQuery("cpu", nowMillis-timeutil.OneMinute)
+	traceQuery.Trace = true
+	cache.Put("db", traceQuery, rs)
+	_, ok = cache.Get("db", traceQuery)
+	assert.False(t, ok)
+	// read-your-writes query, not cached
+	writeTokenQuery := newQuery("cpu", nowMillis-timeutil.OneMinute)
+	writeTokenQuery.WriteToken = "token"
+	cache.Put("db", writeTokenQuery, rs)
+	_, ok = cache.Get("db", writeTokenQuery)
+	assert.False(t, ok)
+	// case 7: evict least recently used
+	evictions := resultCacheEvictCounter.Get()
+	q1, q2, q3 := newQuery("m1", nowMillis-timeutil.OneMinute),
+		newQuery("m2", nowMillis-timeutil.OneMinute),
+		newQuery("m3", nowMillis-timeutil.OneMinute)
+	cache.Put("db", q1, rs)
+	cache.Put("db", q2, rs)
+	_, ok = cache.Get("db", q1)
+	assert.True(t, ok)
+	cache.Put("db", q3, rs)
+	assert.Equal(t, evictions+1, resultCacheEvictCounter.Get())
+	_, ok = cache.Get("db", q2)
+	assert.False(t, ok)
+	_, ok = cache.Get("db", q1)
+	assert.True(t, ok)
+	// case 8: update exist entry
+	rs2 := &models.ResultSet{MetricName: "m3"}
+	cache.Put("db", q3, rs2)
+	rs1, ok = cache.Get("db", q3)
+	assert.True(t, ok)
+	assert.Equal(t, rs2, rs1)
+	cache.Put("db", q3, nil)
+}
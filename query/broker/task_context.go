@@ -19,6 +19,7 @@ package brokerquery
 
 import (
 	"errors"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -177,10 +178,28 @@ type metricTaskContext struct {
 	// fieldname -> aggregator spec
 	// we will use it during intermediate tasks
 	aggregatorSpecs map[string]*protoCommonV1.AggregatorSpec
+	// tags -> exemplars of fields
+	exemplars map[string][]*protoCommonV1.Exemplar
 	// tolerantNotFounds keeps the number of how many not found errors can be returned
 	// if all nodes return not-found errors, it will be treated as a error
 	// other error will be returned immediately
 	tolerantNotFounds int32
+	// failedNodes keeps the error of failed nodes which are ignored by best-effort query
+	failedNodes map[string]string
+	// respondedNodes keeps the nodes whose whole result received
+	respondedNodes map[string]struct{}
+	// chunks keeps the chunks received of node whose result is split into multi chunks
+	chunks map[string]*resultChunks
+	// stale is set if result of any node may be stale
+	stale bool
+}
+
+// resultChunks represents the chunks of result received from one node,
+// chunks may arrive out of order because responses are handled concurrently.
+type resultChunks struct {
+	received  int32 // num. of partial chunks received
+	expected  int32 // num. of partial chunks sent before completed response
+	completed bool  // if completed response received
 }
 
 // metricTaskContext creates the task context based on params
@@ -213,12 +232,20 @@ func newMetricTaskContext(
 // checkError checks if a error should be returned.
 // node of the cluster may returns not found error,
 // ignoreResponse=true symbols that the response should be ignored
-func (c *metricTaskContext) checkError(errMsg string) (ignoreResponse bool, err error) {
+func (c *metricTaskContext) checkError(errMsg string, fromNode string) (ignoreResponse bool, err error) {
 	if errMsg == "" {
 		return false, nil
 	}
 	// real error
 	if !strings.Contains(errMsg, "not found") {
+		if c.bestEffort() {
+			// ignore failed node, returns result of other healthy nodes
+			if c.failedNodes == nil {
+				c.failedNodes = make(map[string]string)
+			}
+			c.failedNodes[fromNode] = errMsg
+			return true, nil
+		}
 		goto ReturnError
 	}
 	c.tolerantNotFounds--
@@ -231,47 +258,151 @@ ReturnError:
 	return true, errors.New(errMsg)
 }
 
+// bestEffort returns if the failed leaf nodes can be ignored,
+// only root task does it so that the failed nodes can be returned to user as warning.
+func (c *metricTaskContext) bestEffort() bool {
+	return c.taskType == RootTask && c.stmtQuery != nil && c.stmtQuery.BestEffort
+}
+
 func (c *metricTaskContext) WriteResponse(resp *protoCommonV1.TaskResponse, fromNode string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	c.expectResults--
+	if resp.Stale {
+		c.stale = true
+	}
+	if c.receiveChunk(resp, fromNode) {
+		c.expectResults--
+		if c.respondedNodes == nil {
+			c.respondedNodes = make(map[string]struct{})
+		}
+		c.respondedNodes[fromNode] = struct{}{}
+	}
 
 	// preventing close channel twice
 	if c.closed {
 		return
 	}
-	defer func() {
-		if c.expectResults <= 0 {
-			close(c.eventCh)
-			c.closed = true
-		}
-	}()
+	defer c.closeIfDone()
 
 	if err := c.handleTaskResponse(resp, fromNode); err != nil {
-		select {
-		case c.eventCh <- &series.TimeSeriesEvent{Err: err, Stats: c.stats}:
-		default:
-			// reader gone
-		}
+		c.sendEvent(&series.TimeSeriesEvent{Err: err, Stats: c.stats})
+		return
+	}
+	c.sendResult()
+}
+
+// failNodes completes the nodes not responded because of sending request failure or timeout,
+// best-effort query treats them as failed nodes and returns the result of other nodes, else returns err.
+func (c *metricTaskContext) failNodes(nodes []string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return
+	}
+	defer c.closeIfDone()
+
+	if !c.bestEffort() {
+		c.expectResults = 0
+		c.sendEvent(&series.TimeSeriesEvent{Err: err, Stats: c.stats})
 		return
 	}
+	for _, node := range nodes {
+		if _, ok := c.respondedNodes[node]; ok {
+			continue
+		}
+		if _, ok := c.failedNodes[node]; ok {
+			continue
+		}
+		if c.failedNodes == nil {
+			c.failedNodes = make(map[string]string)
+		}
+		c.failedNodes[node] = err.Error()
+		c.expectResults--
+	}
+	c.sendResult()
+}
+
+// closeIfDone closes the event channel after all nodes completed.
+func (c *metricTaskContext) closeIfDone() {
+	if c.expectResults <= 0 {
+		close(c.eventCh)
+		c.closed = true
+	}
+}
+
+// sendResult sends the merged result after all nodes completed.
+func (c *metricTaskContext) sendResult() {
 	// not done yet
 	if c.expectResults > 0 {
 		return
 	}
+	if c.groupAgg == nil && len(c.failedNodes) > 0 {
+		// all nodes failed or not found, no result can be returned
+		c.sendEvent(&series.TimeSeriesEvent{Err: c.failedNodesErr(), Stats: c.stats})
+		return
+	}
 
-	select {
-	case c.eventCh <- &series.TimeSeriesEvent{
+	var seriesList series.GroupedIterators
+	if c.groupAgg != nil {
+		seriesList = c.groupAgg.ResultSet()
+	}
+	c.sendEvent(&series.TimeSeriesEvent{
 		AggregatorSpecs: c.aggregatorSpecs,
-		SeriesList:      c.groupAgg.ResultSet(),
+		SeriesList:      seriesList,
+		Exemplars:       c.exemplars,
 		Stats:           c.stats,
-	}:
+		FailedNodes:     c.failedNodes,
+		Stale:           c.stale,
+	})
+}
+
+// sendEvent sends the event to reader, drops it if reader gone.
+func (c *metricTaskContext) sendEvent(event *series.TimeSeriesEvent) {
+	select {
+	case c.eventCh <- event:
 	default:
 		// reader gone
 	}
 }
 
+// receiveChunk tracks the result chunks of node, returns if all chunks of node received.
+// partial chunk carries its sequence(from 1) in chunks, and completed response carries the num. of
+// partial chunks sent before it, response without chunks is the whole result of node.
+func (c *metricTaskContext) receiveChunk(resp *protoCommonV1.TaskResponse, fromNode string) bool {
+	partial := !resp.Completed && resp.Chunks > 0
+	chunks, ok := c.chunks[fromNode]
+	if !ok {
+		if !partial && resp.Chunks == 0 {
+			// result not split
+			return true
+		}
+		if c.chunks == nil {
+			c.chunks = make(map[string]*resultChunks)
+		}
+		chunks = &resultChunks{}
+		c.chunks[fromNode] = chunks
+	}
+	if partial {
+		chunks.received++
+		return chunks.completed && chunks.received == chunks.expected
+	}
+	chunks.completed = true
+	chunks.expected = resp.Chunks
+	return chunks.received >= chunks.expected
+}
+
+// failedNodesErr returns the error of failed nodes, ordered by node.
+func (c *metricTaskContext) failedNodesErr() error {
+	nodes := make([]string, 0, len(c.failedNodes))
+	for node := range c.failedNodes {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+	return errors.New(c.failedNodes[nodes[0]])
+}
+
 func (c *metricTaskContext) handleStats(resp *protoCommonV1.TaskResponse, fromNode string) {
 	if len(resp.Stats) == 0 {
 		return
@@ -298,7 +429,7 @@ func (c *metricTaskContext) handleStats(resp *protoCommonV1.TaskResponse, fromNo
 func (c *metricTaskContext) handleTaskResponse(resp *protoCommonV1.TaskResponse, fromNode string) error {
 	c.handleStats(resp, fromNode)
 
-	ignoreReponse, err := c.checkError(resp.ErrMsg)
+	ignoreReponse, err := c.checkError(resp.ErrMsg, fromNode)
 	if err != nil {
 		return err
 	}
@@ -346,6 +477,12 @@ func (c *metricTaskContext) handleTaskResponse(resp *protoCommonV1.TaskResponse,
 			fields[field.Name(k)] = v
 		}
 		c.groupAgg.Aggregate(series.NewGroupedIterator(ts.Tags, fields))
+		if len(ts.Exemplars) > 0 {
+			if c.exemplars == nil {
+				c.exemplars = make(map[string][]*protoCommonV1.Exemplar)
+			}
+			c.exemplars[ts.Tags] = append(c.exemplars[ts.Tags], ts.Exemplars...)
+		}
 	}
 	return nil
 }
@@ -21,12 +21,17 @@ import (
 	"testing"
 	"time"
 
+	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/lindb/lindb/aggregation"
 	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/pkg/encoding"
+	"github.com/lindb/lindb/pkg/timeutil"
 	protoCommonV1 "github.com/lindb/lindb/proto/gen/v1/common"
 	"github.com/lindb/lindb/series"
+	"github.com/lindb/lindb/series/field"
+	"github.com/lindb/lindb/sql/stmt"
 )
 
 func Test_TaskContext_metaDataTaskContext(t *testing.T) {
@@ -158,3 +163,139 @@ func Test_TaskContext_metricTaskContext_notFound(t *testing.T) {
 	)
 
 }
+
+func Test_TaskContext_metricTaskContext_bestEffort(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		newGroupingAgg = aggregation.NewGroupingAggregator
+		ctrl.Finish()
+	}()
+	groupAgg := aggregation.NewMockGroupingAggregator(ctrl)
+	newGroupingAgg = func(_ timeutil.Interval, _ int, _ timeutil.TimeRange,
+		_ aggregation.AggregatorSpecs) aggregation.GroupingAggregator {
+		return groupAgg
+	}
+	tsList := &protoCommonV1.TimeSeriesList{
+		FieldAggSpecs: []*protoCommonV1.AggregatorSpec{{
+			FieldName: "f",
+			FieldType: uint32(field.SumField),
+		}},
+		TimeSeriesList: []*protoCommonV1.TimeSeries{{Fields: map[string][]byte{"f": {1}}}},
+	}
+	payload, _ := tsList.Marshal()
+	newTaskCtx := func(bestEffort bool, ch chan *series.TimeSeriesEvent) TaskContext {
+		return newMetricTaskContext("1", RootTask, "", "", &stmt.Query{BestEffort: bestEffort}, 2, ch)
+	}
+
+	// case 1: returns result of healthy node with failed node
+	ch := make(chan *series.TimeSeriesEvent, 1)
+	taskCtx := newTaskCtx(true, ch)
+	resultSet := series.GroupedIterators{series.NewMockGroupedIterator(ctrl)}
+	groupAgg.EXPECT().Aggregate(gomock.Any())
+	groupAgg.EXPECT().ResultSet().Return(resultSet)
+	taskCtx.WriteResponse(&protoCommonV1.TaskResponse{ErrMsg: "read shard err"}, "1.1.1.1:9000")
+	taskCtx.WriteResponse(&protoCommonV1.TaskResponse{Payload: payload}, "1.1.1.2:9000")
+	event := <-ch
+	assert.NoError(t, event.Err)
+	assert.Equal(t, resultSet, event.SeriesList)
+	assert.Equal(t, map[string]string{"1.1.1.1:9000": "read shard err"}, event.FailedNodes)
+
+	// case 2: no healthy node
+	ch = make(chan *series.TimeSeriesEvent, 1)
+	taskCtx = newTaskCtx(true, ch)
+	taskCtx.WriteResponse(&protoCommonV1.TaskResponse{ErrMsg: "read shard err"}, "1.1.1.1:9000")
+	taskCtx.WriteResponse(&protoCommonV1.TaskResponse{ErrMsg: "metricID not found"}, "1.1.1.2:9000")
+	event = <-ch
+	assert.EqualError(t, event.Err, "read shard err")
+
+	// case 3: strict mode fails the query
+	ch = make(chan *series.TimeSeriesEvent, 1)
+	taskCtx = newTaskCtx(false, ch)
+	taskCtx.WriteResponse(&protoCommonV1.TaskResponse{ErrMsg: "read shard err"}, "1.1.1.1:9000")
+	event = <-ch
+	assert.EqualError(t, event.Err, "read shard err")
+}
+
+func Test_TaskContext_metricTaskContext_chunks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		newGroupingAgg = aggregation.NewGroupingAggregator
+		ctrl.Finish()
+	}()
+	groupAgg := aggregation.NewMockGroupingAggregator(ctrl)
+	newGroupingAgg = func(_ timeutil.Interval, _ int, _ timeutil.TimeRange,
+		_ aggregation.AggregatorSpecs) aggregation.GroupingAggregator {
+		return groupAgg
+	}
+	tsList := &protoCommonV1.TimeSeriesList{
+		FieldAggSpecs: []*protoCommonV1.AggregatorSpec{{
+			FieldName: "f",
+			FieldType: uint32(field.SumField),
+		}},
+		TimeSeriesList: []*protoCommonV1.TimeSeries{{
+			Fields:    map[string][]byte{"f": {1}},
+			Exemplars: []*protoCommonV1.Exemplar{{FieldName: "f", TraceID: []byte{1}}},
+		}},
+	}
+	payload, _ := tsList.Marshal()
+	ch := make(chan *series.TimeSeriesEvent, 1)
+	taskCtx := newMetricTaskContext("1", RootTask, "", "", &stmt.Query{}, 2, ch)
+	resultSet := series.GroupedIterators{series.NewMockGroupedIterator(ctrl)}
+	groupAgg.EXPECT().Aggregate(gomock.Any()).Times(4)
+	groupAgg.EXPECT().ResultSet().Return(resultSet)
+
+	// node 1 returns whole result which may be stale
+	taskCtx.WriteResponse(&protoCommonV1.TaskResponse{Payload: payload, Completed: true, Stale: true}, "1.1.1.1:9000")
+	// node 2 returns result in 3 chunks, completed response arrives before partial chunks
+	taskCtx.WriteResponse(&protoCommonV1.TaskResponse{Payload: payload, Completed: true, Chunks: 2}, "1.1.1.2:9000")
+	assert.False(t, taskCtx.Done())
+	taskCtx.WriteResponse(&protoCommonV1.TaskResponse{Payload: payload, Chunks: 2}, "1.1.1.2:9000")
+	assert.False(t, taskCtx.Done())
+	assert.Len(t, ch, 0)
+	taskCtx.WriteResponse(&protoCommonV1.TaskResponse{Payload: payload, Chunks: 1}, "1.1.1.2:9000")
+	assert.True(t, taskCtx.Done())
+	event := <-ch
+	assert.NoError(t, event.Err)
+	assert.Equal(t, resultSet, event.SeriesList)
+	assert.True(t, event.Stale)
+	// exemplars of all nodes are merged by tags
+	assert.Len(t, event.Exemplars[""], 4)
+}
+
+func Test_TaskContext_metricTaskContext_stream(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		newGroupingAgg = aggregation.NewGroupingAggregator
+		ctrl.Finish()
+	}()
+	groupAgg := aggregation.NewMockGroupingAggregator(ctrl)
+	newGroupingAgg = func(_ timeutil.Interval, _ int, _ timeutil.TimeRange,
+		_ aggregation.AggregatorSpecs) aggregation.GroupingAggregator {
+		return groupAgg
+	}
+	tsList := &protoCommonV1.TimeSeriesList{
+		FieldAggSpecs: []*protoCommonV1.AggregatorSpec{{
+			FieldName: "f",
+			FieldType: uint32(field.SumField),
+		}},
+		TimeSeriesList: []*protoCommonV1.TimeSeries{{Fields: map[string][]byte{"f": {1}}}},
+	}
+	payload, _ := tsList.Marshal()
+	ch := make(chan *series.TimeSeriesEvent, 1)
+	taskCtx := newMetricTaskContext("1", RootTask, "", "", &stmt.Query{Stream: true}, 2, ch)
+	resultSet := series.GroupedIterators{series.NewMockGroupedIterator(ctrl)}
+	// chunks of all nodes are merged, not forwarded as they arrive
+	groupAgg.EXPECT().Aggregate(gomock.Any()).Times(3)
+	groupAgg.EXPECT().ResultSet().Return(resultSet).Times(1)
+
+	taskCtx.WriteResponse(&protoCommonV1.TaskResponse{Payload: payload, Chunks: 1}, "1.1.1.1:9000")
+	taskCtx.WriteResponse(&protoCommonV1.TaskResponse{Payload: payload, Completed: true, Chunks: 1}, "1.1.1.1:9000")
+	assert.Len(t, ch, 0)
+	taskCtx.WriteResponse(&protoCommonV1.TaskResponse{Payload: payload}, "1.1.1.2:9000")
+	assert.True(t, taskCtx.Done())
+	event := <-ch
+	assert.NoError(t, event.Err)
+	assert.Equal(t, resultSet, event.SeriesList)
+	_, ok := <-ch
+	assert.False(t, ok)
+}
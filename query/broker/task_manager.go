@@ -19,7 +19,9 @@ package brokerquery
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -77,6 +79,12 @@ type TaskManager interface {
 	Receive(req *protoCommonV1.TaskResponse, targetNode string) error
 }
 
+// leafRequests tracks the sent time of leaf requests which are waiting for the first response.
+type leafRequests struct {
+	mutex  sync.Mutex
+	sentAt map[string]time.Time // leaf node => sent time
+}
+
 // taskManager implements the task manager interface, tracks all task of the current node
 type taskManager struct {
 	ctx               context.Context
@@ -84,6 +92,8 @@ type taskManager struct {
 	seq               *atomic.Int64
 	taskClientFactory rpc.TaskClientFactory
 	taskServerFactory rpc.TaskServerFactory
+	circuitBreakers   rpc.CircuitBreakers // nil if circuit breaker disabled
+	pendingLeafs      sync.Map            // taskID -> *leafRequests, tracked if circuit breaker enabled
 
 	workerPool concurrent.Pool // workers for
 	tasks      sync.Map        // taskID -> taskCtx
@@ -108,6 +118,7 @@ func NewTaskManager(
 	taskServerFactory rpc.TaskServerFactory,
 	taskPool concurrent.Pool,
 	ttl time.Duration,
+	circuitBreakers rpc.CircuitBreakers,
 ) TaskManager {
 	taskManagerScope := linmetric.NewScope("lindb.broker.query")
 	tm := &taskManager{
@@ -115,6 +126,7 @@ func NewTaskManager(
 		currentNodeID:        currentNode.Indicator(),
 		taskClientFactory:    taskClientFactory,
 		taskServerFactory:    taskServerFactory,
+		circuitBreakers:      circuitBreakers,
 		seq:                  atomic.NewInt64(0),
 		workerPool:           taskPool,
 		logger:               logger.GetLogger("query", "TaskManager"),
@@ -147,6 +159,8 @@ func (t *taskManager) cleaner(duration time.Duration) {
 			t.tasks.Range(func(key, value interface{}) bool {
 				taskCtx := value.(TaskContext)
 				if taskCtx.Expired(t.ttl) {
+					// leaf nodes never respond before task expired
+					t.failPendingLeafs(key.(string))
 					t.aliveTaskGauge.Decr()
 					t.tasks.Delete(key)
 				}
@@ -159,6 +173,7 @@ func (t *taskManager) cleaner(duration time.Duration) {
 }
 
 func (t *taskManager) evictTask(taskID string) {
+	t.pendingLeafs.Delete(taskID)
 	_, loaded := t.tasks.LoadAndDelete(taskID)
 	if loaded {
 		t.aliveTaskGauge.Decr()
@@ -244,7 +259,8 @@ func (t *taskManager) SubmitMetricTask(
 		}
 	}
 
-	responseCh := make(chan *series.TimeSeriesEvent)
+	// buffered, so that the result completed before reader waiting isn't dropped
+	responseCh := make(chan *series.TimeSeriesEvent, 1)
 	taskCtx := newMetricTaskContext(
 		rootTaskID,
 		RootTask,
@@ -258,10 +274,7 @@ func (t *taskManager) SubmitMetricTask(
 
 	// return the channel for reader, then send the rpc request
 	// in case of too early response arriving without reader
-	var (
-		wg        sync.WaitGroup
-		sendError atomic.Error
-	)
+	var wg sync.WaitGroup
 	// notify error to other peer nodes
 	req := &protoCommonV1.TaskRequest{
 		ParentTaskID: rootTaskID,
@@ -270,22 +283,95 @@ func (t *taskManager) SubmitMetricTask(
 		PhysicalPlan: marshalledPhysicalPlan,
 		Payload:      marshalledPayload,
 	}
+	sendErrs := make([]error, len(physicalPlan.Leafs))
 	wg.Add(len(physicalPlan.Leafs))
-	for _, leaf := range physicalPlan.Leafs {
-		leaf := leaf
+	for idx := range physicalPlan.Leafs {
+		idx := idx
 		t.workerPool.Submit(func() {
 			defer wg.Done()
-			if err := t.SendRequest(leaf.Indicator, req); err != nil {
-				sendError.Store(err)
-			}
+			sendErrs[idx] = t.SendRequest(physicalPlan.Leafs[idx].Indicator, req)
 		})
 	}
 	wg.Wait()
 
-	if sendError.Load() != nil {
+	// best-effort query ignores the leaf nodes failed to send request if root task receives their responses
+	bestEffort := stmtQuery.BestEffort && len(physicalPlan.Intermediates) == 0
+	var (
+		failedLeafs []string
+		sendError   error
+	)
+	for idx, err := range sendErrs {
+		if err == nil {
+			continue
+		}
+		failedLeafs = append(failedLeafs, physicalPlan.Leafs[idx].Indicator)
+		if sendError == nil {
+			sendError = err
+		}
+	}
+	if sendError != nil && (!bestEffort || len(failedLeafs) == len(physicalPlan.Leafs)) {
 		t.evictTask(rootTaskID)
+		return responseCh, sendError
+	}
+	if len(failedLeafs) > 0 {
+		taskCtx.(*metricTaskContext).failNodes(failedLeafs, sendError)
+		if taskCtx.Done() {
+			t.evictTask(rootTaskID)
+			return responseCh, nil
+		}
+	}
+	go t.cancelOnDone(ctx, rootTaskID, physicalPlan)
+	return responseCh, nil
+}
+
+// cancelOnDone sends cancel request to leaf nodes if query is canceled by client or timeout before task completed,
+// so that leaf nodes stop scanning and sending the rest result.
+// Best-effort query completes with the result of leaf nodes responded in time, the rest are treated as failed.
+func (t *taskManager) cancelOnDone(ctx context.Context, taskID string, physicalPlan *models.PhysicalPlan) {
+	closed := false
+	select {
+	case <-ctx.Done():
+	case <-t.ctx.Done():
+		closed = true
+	}
+	taskCtx := t.Get(taskID)
+	if taskCtx == nil || taskCtx.Done() {
+		// task completed
+		return
+	}
+	if metricTaskCtx, ok := taskCtx.(*metricTaskContext); ok && metricTaskCtx.bestEffort() {
+		// reader of best-effort query waits the result after timeout, root task receives the responses of
+		// intermediate nodes if any, else leaf nodes
+		var nodes []string
+		for idx := range physicalPlan.Intermediates {
+			nodes = append(nodes, physicalPlan.Intermediates[idx].Indicator)
+		}
+		if len(nodes) == 0 {
+			for idx := range physicalPlan.Leafs {
+				nodes = append(nodes, physicalPlan.Leafs[idx].Indicator)
+			}
+		}
+		metricTaskCtx.failNodes(nodes, ErrTimeout)
+	}
+	if closed {
+		return
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		// leaf nodes not responding in time, client cancellation isn't their fault
+		t.failPendingLeafs(taskID)
+	}
+	t.evictTask(taskID)
+	req := &protoCommonV1.TaskRequest{
+		ParentTaskID: taskID,
+		Type:         protoCommonV1.TaskType_Leaf,
+		RequestType:  protoCommonV1.RequestType_Cancel,
+	}
+	for _, leaf := range physicalPlan.Leafs {
+		if err := t.SendRequest(leaf.Indicator, req); err != nil {
+			t.logger.Warn("send cancel request to leaf node err",
+				logger.String("taskID", taskID), logger.String("target", leaf.Indicator), logger.Error(err))
+		}
 	}
-	return responseCh, sendError.Load()
 }
 
 func (t *taskManager) SubmitIntermediateMetricTask(
@@ -376,12 +462,17 @@ func (t *taskManager) SendRequest(targetNodeID string, req *protoCommonV1.TaskRe
 	client := t.taskClientFactory.GetTaskClient(targetNodeID)
 	if client == nil {
 		t.sentRequestFailures.Incr()
+		t.recordFailure(targetNodeID)
 		return fmt.Errorf("SendRequest: %w, targetNodeID: %s", query.ErrNoSendStream, targetNodeID)
 	}
 	if err := client.Send(req); err != nil {
 		t.sentRequestFailures.Incr()
+		t.recordFailure(targetNodeID)
 		return fmt.Errorf("%w, targetNodeID: %s", query.ErrTaskSend, targetNodeID)
 	}
+	if req.Type == protoCommonV1.TaskType_Leaf && req.RequestType != protoCommonV1.RequestType_Cancel {
+		t.trackLeafRequest(req.ParentTaskID, targetNodeID)
+	}
 	t.sentRequestCounter.Incr()
 	return nil
 }
@@ -409,6 +500,7 @@ func (t *taskManager) Receive(resp *protoCommonV1.TaskResponse, targetNode strin
 		return fmt.Errorf("TaskID: %s may be evicted", resp.TaskID)
 	}
 	t.emitResponseCounter.Incr()
+	t.recordLeafResponse(resp, targetNode)
 	t.workerPool.Submit(func() {
 		// for root task and intermediate task
 		taskCtx.WriteResponse(resp, targetNode)
@@ -419,3 +511,69 @@ func (t *taskManager) Receive(resp *protoCommonV1.TaskResponse, targetNode strin
 	})
 	return nil
 }
+
+// recordLeafResponse records the response of leaf node into its circuit breaker,
+// not found error means no data in leaf node, which is not treated as failure.
+// Latency is measured from sending request to the leaf node until its first response.
+func (t *taskManager) recordLeafResponse(resp *protoCommonV1.TaskResponse, leafNode string) {
+	if t.circuitBreakers == nil || resp.Type != protoCommonV1.TaskType_Leaf {
+		return
+	}
+	sentAt, ok := t.takeLeafRequest(resp.TaskID, leafNode)
+	if resp.ErrMsg != "" && !strings.Contains(resp.ErrMsg, "not found") {
+		t.circuitBreakers.RecordFailure(leafNode)
+		return
+	}
+	if !ok {
+		// latency is recorded by the first response of leaf node, or request isn't sent by current node
+		return
+	}
+	t.circuitBreakers.RecordSuccess(leafNode, time.Since(sentAt))
+}
+
+// trackLeafRequest records the sent time of leaf request for measuring the latency of leaf node.
+func (t *taskManager) trackLeafRequest(taskID, leafNode string) {
+	if t.circuitBreakers == nil {
+		return
+	}
+	val, _ := t.pendingLeafs.LoadOrStore(taskID, &leafRequests{sentAt: make(map[string]time.Time)})
+	requests := val.(*leafRequests)
+	requests.mutex.Lock()
+	requests.sentAt[leafNode] = time.Now()
+	requests.mutex.Unlock()
+}
+
+// takeLeafRequest removes the leaf request waiting for response, returns its sent time if found.
+func (t *taskManager) takeLeafRequest(taskID, leafNode string) (time.Time, bool) {
+	val, ok := t.pendingLeafs.Load(taskID)
+	if !ok {
+		return time.Time{}, false
+	}
+	requests := val.(*leafRequests)
+	requests.mutex.Lock()
+	defer requests.mutex.Unlock()
+	sentAt, ok := requests.sentAt[leafNode]
+	delete(requests.sentAt, leafNode)
+	return sentAt, ok
+}
+
+// failPendingLeafs records the leaf nodes not responding the task as failure into their circuit breakers.
+func (t *taskManager) failPendingLeafs(taskID string) {
+	val, ok := t.pendingLeafs.LoadAndDelete(taskID)
+	if !ok {
+		return
+	}
+	requests := val.(*leafRequests)
+	requests.mutex.Lock()
+	defer requests.mutex.Unlock()
+	for leafNode := range requests.sentAt {
+		t.circuitBreakers.RecordFailure(leafNode)
+	}
+}
+
+// recordFailure records the failed request of node into its circuit breaker.
+func (t *taskManager) recordFailure(targetNodeID string) {
+	if t.circuitBreakers != nil {
+		t.circuitBreakers.RecordFailure(targetNodeID)
+	}
+}
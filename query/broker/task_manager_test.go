@@ -19,6 +19,7 @@ package brokerquery
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"testing"
 	"time"
@@ -56,6 +57,7 @@ func TestTaskManager_SubmitMetricTask_WithoutIntermediates(t *testing.T) {
 			linmetric.NewScope("test"),
 		),
 		time.Second*10,
+		nil,
 	)
 	physicalPlan := models.NewPhysicalPlan(models.Root{Indicator: "1.1.1.3:8000", NumOfTask: 2})
 	physicalPlan.AddLeaf(models.Leaf{
@@ -101,6 +103,133 @@ func TestTaskManager_SubmitMetricTask_WithoutIntermediates(t *testing.T) {
 		TaskID: "1.1.1.1:8000-3"}, ""))
 }
 
+func TestTaskManager_SubmitMetricTask_Cancel(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	currentNode := models.StatelessNode{HostIP: "1.1.1.3", GRPCPort: 8000}
+	taskClientFactory := rpc.NewMockTaskClientFactory(ctrl)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tm := NewTaskManager(ctx, &currentNode, taskClientFactory, nil,
+		concurrent.NewPool("p", 10, time.Minute, linmetric.NewScope("test_cancel")),
+		time.Second*10, nil)
+	physicalPlan := models.NewPhysicalPlan(models.Root{Indicator: "1.1.1.3:8000", NumOfTask: 1})
+	physicalPlan.AddLeaf(models.Leaf{
+		BaseNode:  models.BaseNode{Parent: "1.1.1.3:8000", Indicator: "1.1.1.1:9000"},
+		Receivers: []models.StatelessNode{{HostIP: "1.1.1.3", GRPCPort: 8000}},
+		ShardIDs:  []models.ShardID{1},
+	})
+	client := protoCommonV1.NewMockTaskService_HandleClient(ctrl)
+	taskClientFactory.EXPECT().GetTaskClient("1.1.1.1:9000").Return(client).AnyTimes()
+
+	// case 1: query canceled before completed, sends cancel request to leaf
+	client.EXPECT().Send(gomock.Any()).Return(nil)
+	queryCtx, queryCancel := context.WithCancel(context.Background())
+	_, err := tm.SubmitMetricTask(queryCtx, physicalPlan, &stmt.Query{})
+	assert.NoError(t, err)
+	canceled := make(chan *protoCommonV1.TaskRequest)
+	client.EXPECT().Send(gomock.Any()).DoAndReturn(func(req *protoCommonV1.TaskRequest) error {
+		canceled <- req
+		return nil
+	})
+	queryCancel()
+	req := <-canceled
+	assert.Equal(t, protoCommonV1.RequestType_Cancel, req.RequestType)
+	assert.Nil(t, tm.(*taskManager).Get(req.ParentTaskID))
+
+	// case 2: query completed, no cancel request
+	client.EXPECT().Send(gomock.Any()).Return(nil)
+	queryCtx, queryCancel = context.WithCancel(context.Background())
+	eventCh, err := tm.SubmitMetricTask(queryCtx, physicalPlan, &stmt.Query{})
+	assert.NoError(t, err)
+	tm.(*taskManager).tasks.Range(func(key, value interface{}) bool {
+		go value.(TaskContext).WriteResponse(&protoCommonV1.TaskResponse{
+			Completed: true,
+			ErrMsg:    "not found",
+		}, "1.1.1.1:9000")
+		return true
+	})
+	<-eventCh
+	queryCancel()
+	time.Sleep(10 * time.Millisecond)
+}
+
+func TestTaskManager_SubmitMetricTask_BestEffort(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	currentNode := models.StatelessNode{HostIP: "1.1.1.3", GRPCPort: 8000}
+	taskClientFactory := rpc.NewMockTaskClientFactory(ctrl)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tm := NewTaskManager(ctx, &currentNode, taskClientFactory, nil,
+		concurrent.NewPool("p", 10, time.Minute, linmetric.NewScope("test_best_effort")),
+		time.Second*10, nil)
+	physicalPlan := models.NewPhysicalPlan(models.Root{Indicator: "1.1.1.3:8000", NumOfTask: 2})
+	for _, indicator := range []string{"1.1.1.1:9000", "1.1.1.2:9000"} {
+		physicalPlan.AddLeaf(models.Leaf{
+			BaseNode:  models.BaseNode{Parent: "1.1.1.3:8000", Indicator: indicator},
+			Receivers: []models.StatelessNode{{HostIP: "1.1.1.3", GRPCPort: 8000}},
+			ShardIDs:  []models.ShardID{1},
+		})
+	}
+	client := protoCommonV1.NewMockTaskService_HandleClient(ctrl)
+	taskClientFactory.EXPECT().GetTaskClient("1.1.1.2:9000").Return(client).AnyTimes()
+	respond := func(indicator string) {
+		tm.(*taskManager).tasks.Range(func(key, value interface{}) bool {
+			value.(TaskContext).WriteResponse(&protoCommonV1.TaskResponse{Completed: true}, indicator)
+			return true
+		})
+	}
+
+	// case 1: send request to leaf failure, strict query fails
+	taskClientFactory.EXPECT().GetTaskClient("1.1.1.1:9000").Return(nil)
+	client.EXPECT().Send(gomock.Any()).Return(nil)
+	_, err := tm.SubmitMetricTask(context.TODO(), physicalPlan, &stmt.Query{})
+	assert.Error(t, err)
+	// case 2: best-effort query treats leaf of send failure as failed
+	taskClientFactory.EXPECT().GetTaskClient("1.1.1.1:9000").Return(nil)
+	client.EXPECT().Send(gomock.Any()).Return(nil)
+	eventCh, err := tm.SubmitMetricTask(context.TODO(), physicalPlan, &stmt.Query{BestEffort: true})
+	assert.NoError(t, err)
+	respond("1.1.1.2:9000")
+	event := <-eventCh
+	assert.NoError(t, event.Err)
+	assert.Len(t, event.FailedNodes, 1)
+	assert.Contains(t, event.FailedNodes["1.1.1.1:9000"], "send stream not found")
+	// case 3: best-effort query fails if all leafs fail
+	taskClientFactory.EXPECT().GetTaskClient("1.1.1.1:9000").Return(nil)
+	client.EXPECT().Send(gomock.Any()).Return(io.ErrClosedPipe)
+	_, err = tm.SubmitMetricTask(context.TODO(), physicalPlan, &stmt.Query{BestEffort: true})
+	assert.Error(t, err)
+
+	leaf1 := protoCommonV1.NewMockTaskService_HandleClient(ctrl)
+	taskClientFactory.EXPECT().GetTaskClient("1.1.1.1:9000").Return(leaf1).AnyTimes()
+	leaf1.EXPECT().Send(gomock.Any()).Return(nil).AnyTimes()
+	// case 4: best-effort query timeout, treats leaf not responded as failed
+	client.EXPECT().Send(gomock.Any()).Return(nil).Times(2) // query and cancel request
+	queryCtx, queryCancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer queryCancel()
+	eventCh, err = tm.SubmitMetricTask(queryCtx, physicalPlan, &stmt.Query{BestEffort: true})
+	assert.NoError(t, err)
+	respond("1.1.1.1:9000")
+	event = <-eventCh
+	assert.NoError(t, event.Err)
+	assert.Equal(t, map[string]string{"1.1.1.2:9000": ErrTimeout.Error()}, event.FailedNodes)
+	// case 5: best-effort query timeout, all leafs not responded
+	client.EXPECT().Send(gomock.Any()).Return(nil).Times(2)
+	queryCtx, queryCancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer queryCancel()
+	eventCh, err = tm.SubmitMetricTask(queryCtx, physicalPlan, &stmt.Query{BestEffort: true})
+	assert.NoError(t, err)
+	event = <-eventCh
+	assert.EqualError(t, event.Err, ErrTimeout.Error())
+	_, ok := <-eventCh
+	assert.False(t, ok)
+	time.Sleep(10 * time.Millisecond)
+}
+
 func TestTaskManager_SendResponse(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -119,7 +248,7 @@ func TestTaskManager_SendResponse(t *testing.T) {
 			10,
 			time.Minute,
 			linmetric.NewScope("test"),
-		), time.Second)
+		), time.Second, nil)
 
 	// empty stream
 	taskServerFactory.EXPECT().GetStream(gomock.Any()).Return(nil)
@@ -156,6 +285,7 @@ func TestTaskManager_SubmitMetaDataTask(t *testing.T) {
 			linmetric.NewScope("test"),
 		),
 		time.Second*10,
+		nil,
 	)
 
 	physicalPlan := models.NewPhysicalPlan(models.Root{Indicator: "1.1.1.3:8000", NumOfTask: 2})
@@ -201,6 +331,7 @@ func TestTaskManager_cleaner(t *testing.T) {
 			linmetric.NewScope("test"),
 		),
 		time.Second*10,
+		nil,
 	).(*taskManager)
 	go tm.cleaner(time.Millisecond * 10)
 	task := NewMockTaskContext(ctrl)
@@ -210,3 +341,120 @@ func TestTaskManager_cleaner(t *testing.T) {
 	time.Sleep(time.Second)
 
 }
+
+func TestTaskManager_CircuitBreaker(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	taskClientFactory := rpc.NewMockTaskClientFactory(ctrl)
+	breakers := rpc.NewMockCircuitBreakers(ctrl)
+	tm := NewTaskManager(
+		context.Background(),
+		&models.StatelessNode{},
+		taskClientFactory,
+		nil,
+		concurrent.NewPool(
+			"p",
+			10,
+			time.Minute,
+			linmetric.NewScope("test"),
+		),
+		time.Second*10,
+		breakers,
+	).(*taskManager)
+
+	// send request failure
+	taskClientFactory.EXPECT().GetTaskClient("1.1.1.1:9000").Return(nil)
+	breakers.EXPECT().RecordFailure("1.1.1.1:9000")
+	assert.Error(t, tm.SendRequest("1.1.1.1:9000", &protoCommonV1.TaskRequest{}))
+
+	client := protoCommonV1.NewMockTaskService_HandleClient(ctrl)
+	taskClientFactory.EXPECT().GetTaskClient(gomock.Any()).Return(client).AnyTimes()
+	client.EXPECT().Send(gomock.Any()).Return(nil).AnyTimes()
+	sendLeafRequests := func(taskID string, leafNodes ...string) {
+		for _, leafNode := range leafNodes {
+			assert.NoError(t, tm.SendRequest(leafNode, &protoCommonV1.TaskRequest{
+				ParentTaskID: taskID, Type: protoCommonV1.TaskType_Leaf}))
+		}
+	}
+
+	taskCtx := NewMockTaskContext(ctrl)
+	taskCtx.EXPECT().WriteResponse(gomock.Any(), gomock.Any()).AnyTimes()
+	taskCtx.EXPECT().Done().Return(false).AnyTimes()
+	tm.tasks.Store("1", taskCtx)
+	sendLeafRequests("1", "1.1.1.1:9000", "1.1.1.2:9000", "1.1.1.3:9000")
+	time.Sleep(50 * time.Millisecond)
+	// leaf response failure
+	breakers.EXPECT().RecordFailure("1.1.1.1:9000")
+	assert.NoError(t, tm.Receive(&protoCommonV1.TaskResponse{
+		TaskID: "1", Type: protoCommonV1.TaskType_Leaf, ErrMsg: "err"}, "1.1.1.1:9000"))
+	// not found is not failure, latency is measured from sending request to the leaf node
+	breakers.EXPECT().RecordSuccess("1.1.1.2:9000", gomock.Any()).Do(func(_ string, latency time.Duration) {
+		assert.GreaterOrEqual(t, latency, 50*time.Millisecond)
+		assert.Less(t, latency, time.Second)
+	})
+	assert.NoError(t, tm.Receive(&protoCommonV1.TaskResponse{
+		TaskID: "1", Type: protoCommonV1.TaskType_Leaf, ErrMsg: "metric not found"}, "1.1.1.2:9000"))
+	// latency is recorded by the first response only
+	assert.NoError(t, tm.Receive(&protoCommonV1.TaskResponse{
+		TaskID: "1", Type: protoCommonV1.TaskType_Leaf}, "1.1.1.2:9000"))
+	// intermediate response is ignored
+	assert.NoError(t, tm.Receive(&protoCommonV1.TaskResponse{
+		TaskID: "1", Type: protoCommonV1.TaskType_Intermediate, ErrMsg: "err"}, "1.1.1.4:9000"))
+	// leaf node not responding is failure when task expired
+	breakers.EXPECT().RecordFailure("1.1.1.3:9000")
+	tm.failPendingLeafs("1")
+	tm.failPendingLeafs("1")
+	// completed task has no pending leaf
+	sendLeafRequests("2", "1.1.1.1:9000")
+	tm.evictTask("2")
+	tm.failPendingLeafs("2")
+	time.Sleep(100 * time.Millisecond)
+}
+
+func TestTaskManager_CircuitBreaker_Timeout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	taskClientFactory := rpc.NewMockTaskClientFactory(ctrl)
+	breakers := rpc.NewMockCircuitBreakers(ctrl)
+	client := protoCommonV1.NewMockTaskService_HandleClient(ctrl)
+	taskClientFactory.EXPECT().GetTaskClient(gomock.Any()).Return(client).AnyTimes()
+	client.EXPECT().Send(gomock.Any()).Return(nil).AnyTimes()
+	tm := NewTaskManager(
+		context.Background(),
+		&models.StatelessNode{},
+		taskClientFactory,
+		nil,
+		concurrent.NewPool(
+			"p",
+			10,
+			time.Minute,
+			linmetric.NewScope("test"),
+		),
+		time.Second*10,
+		breakers,
+	).(*taskManager)
+	physicalPlan := models.NewPhysicalPlan(models.Root{Indicator: "1.1.1.3:8000", NumOfTask: 2})
+	physicalPlan.AddLeaf(models.Leaf{BaseNode: models.BaseNode{Parent: "1.1.1.3:8000", Indicator: "1.1.1.1:9000"}})
+	physicalPlan.AddLeaf(models.Leaf{BaseNode: models.BaseNode{Parent: "1.1.1.3:8000", Indicator: "1.1.1.2:9000"}})
+
+	// case 1: canceled by client, leaf nodes aren't failed
+	ctx, cancel := context.WithCancel(context.Background())
+	_, err := tm.SubmitMetricTask(ctx, physicalPlan, &stmt.Query{})
+	assert.NoError(t, err)
+	cancel()
+	time.Sleep(50 * time.Millisecond)
+
+	// case 2: timeout, leaf node not responding is failure
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = tm.SubmitMetricTask(ctx, physicalPlan, &stmt.Query{})
+	assert.NoError(t, err)
+	breakers.EXPECT().RecordSuccess("1.1.1.1:9000", gomock.Any())
+	assert.NoError(t, tm.Receive(&protoCommonV1.TaskResponse{
+		TaskID: fmt.Sprintf("%s-%d", tm.currentNodeID, 2), Type: protoCommonV1.TaskType_Leaf,
+	}, "1.1.1.1:9000"))
+	breakers.EXPECT().RecordFailure("1.1.1.2:9000")
+	time.Sleep(200 * time.Millisecond)
+}
@@ -0,0 +1,163 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package brokerquery
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/internal/linmetric"
+)
+
+// ErrQueryRateLimited represents the token exceeds its query rate, client can retry after a while.
+var ErrQueryRateLimited = errors.New("query rate of token exceeds the limit, please retry later")
+
+var throttledQueriesCounterVec = linmetric.NewScope("lindb.broker.query.token_limiter").
+	NewCounterVec("throttled_queries", "token")
+
+// tokenRate represents the query rate limit of token.
+type tokenRate struct {
+	qps   float64 // queries per second, <= 0 means no limit
+	burst float64 // max num. of queries in a burst
+}
+
+// tokenBucket represents the token bucket of api token.
+type tokenBucket struct {
+	rate      tokenRate
+	available float64   // available queries in bucket
+	last      time.Time // last time of refilling bucket
+
+	throttled *linmetric.BoundCounter
+}
+
+// unconfiguredToken is the bucket key shared by the tokens not configured by acl.
+const unconfiguredToken = ""
+
+// TokenRateLimiter limits the query rate of each api token by token bucket,
+// so that a misbehaving client cannot flood the broker with queries.
+// Only the tokens configured by acl have their own buckets, the others share the unconfigured bucket,
+// so that buckets are bounded even if clients send random tokens.
+// Queries without token are not limited.
+type TokenRateLimiter struct {
+	defaultRate tokenRate
+	tokens      map[string]struct{}     // api tokens configured by acl
+	overrides   map[string]tokenRate    // api token => rate configured by acl
+	buckets     map[string]*tokenBucket // api token => bucket
+	mutex       sync.Mutex
+	now         func() time.Time
+}
+
+// NewTokenRateLimiter creates the query rate limiter of api token, returns nil if no limit.
+func NewTokenRateLimiter(cfg config.Query, acls []config.ACL) *TokenRateLimiter {
+	tokens := make(map[string]struct{})
+	overrides := make(map[string]tokenRate)
+	limited := cfg.MaxQueriesPerSecondPerToken > 0
+	for _, acl := range acls {
+		tokens[acl.Token] = struct{}{}
+		if acl.MaxQueriesPerSecond <= 0 {
+			continue
+		}
+		burst := acl.QueryBurst
+		if burst <= 0 {
+			burst = cfg.QueryBurstPerToken
+		}
+		overrides[acl.Token] = newTokenRate(acl.MaxQueriesPerSecond, burst)
+		limited = true
+	}
+	if !limited {
+		return nil
+	}
+	return &TokenRateLimiter{
+		defaultRate: newTokenRate(cfg.MaxQueriesPerSecondPerToken, cfg.QueryBurstPerToken),
+		tokens:      tokens,
+		overrides:   overrides,
+		buckets:     make(map[string]*tokenBucket),
+		now:         time.Now,
+	}
+}
+
+// newTokenRate creates the rate limit, burst defaults to qps(at least 1).
+func newTokenRate(qps float64, burst int) tokenRate {
+	b := float64(burst)
+	if burst <= 0 {
+		b = math.Max(1, math.Ceil(qps))
+	}
+	return tokenRate{qps: qps, burst: b}
+}
+
+// Allow returns if the query of token is allowed, if not returns how long client should wait before retry.
+// Nil limiter allows all queries, and queries without token bypass the limiter.
+func (l *TokenRateLimiter) Allow(token string) (retryAfter time.Duration, allowed bool) {
+	if l == nil || token == "" {
+		return 0, true
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	bucket := l.getBucket(token)
+	if bucket.rate.qps <= 0 {
+		return 0, true
+	}
+	now := l.now()
+	elapsed := now.Sub(bucket.last).Seconds()
+	bucket.available = math.Min(bucket.rate.burst, bucket.available+elapsed*bucket.rate.qps)
+	bucket.last = now
+	if bucket.available >= 1 {
+		bucket.available--
+		return 0, true
+	}
+	bucket.throttled.Incr()
+	return time.Duration((1 - bucket.available) / bucket.rate.qps * float64(time.Second)), false
+}
+
+// getBucket returns the bucket of token, creates it if not exist, must be called with lock.
+// Tokens not configured by acl use the unconfigured bucket.
+func (l *TokenRateLimiter) getBucket(token string) *tokenBucket {
+	if _, ok := l.tokens[token]; !ok {
+		token = unconfiguredToken
+	}
+	bucket, ok := l.buckets[token]
+	if !ok {
+		rate, ok := l.overrides[token]
+		if !ok {
+			rate = l.defaultRate
+		}
+		bucket = &tokenBucket{
+			rate:      rate,
+			available: rate.burst,
+			last:      l.now(),
+			throttled: throttledQueriesCounterVec.WithTagValues(maskToken(token)),
+		}
+		l.buckets[token] = bucket
+	}
+	return bucket
+}
+
+// maskToken returns the token tag of metric, uses the prefix of token's sha256 so that token is not leaked by metric.
+func maskToken(token string) string {
+	if token == unconfiguredToken {
+		return "unconfigured"
+	}
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:8])
+}
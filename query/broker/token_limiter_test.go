@@ -0,0 +1,109 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package brokerquery
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/config"
+)
+
+func TestTokenRateLimiter_Allow(t *testing.T) {
+	// no limit
+	var limiter *TokenRateLimiter
+	assert.Nil(t, NewTokenRateLimiter(config.Query{}, []config.ACL{{Token: "a"}}))
+	_, allowed := limiter.Allow("a")
+	assert.True(t, allowed)
+
+	now := time.Unix(1000, 0)
+	limiter = NewTokenRateLimiter(config.Query{MaxQueriesPerSecondPerToken: 2}, []config.ACL{
+		{Token: "dashboard", MaxQueriesPerSecond: 1, QueryBurst: 3},
+	})
+	limiter.now = func() time.Time { return now }
+
+	// default rate, burst is same as qps
+	for i := 0; i < 2; i++ {
+		_, allowed = limiter.Allow("other")
+		assert.True(t, allowed)
+	}
+	// tokens not configured by acl share the unconfigured bucket
+	throttled := throttledQueriesCounterVec.WithTagValues("unconfigured")
+	before := throttled.Get()
+	retryAfter, allowed := limiter.Allow("other")
+	assert.False(t, allowed)
+	assert.Equal(t, 500*time.Millisecond, retryAfter)
+	assert.Equal(t, before+1, throttled.Get())
+	// bucket refilled
+	now = now.Add(500 * time.Millisecond)
+	_, allowed = limiter.Allow("other")
+	assert.True(t, allowed)
+
+	// overridden by acl
+	for i := 0; i < 3; i++ {
+		_, allowed = limiter.Allow("dashboard")
+		assert.True(t, allowed)
+	}
+	retryAfter, allowed = limiter.Allow("dashboard")
+	assert.False(t, allowed)
+	assert.Equal(t, time.Second, retryAfter)
+	_, allowed = limiter.Allow("random")
+	assert.False(t, allowed)
+	// query without token bypasses the limiter
+	_, allowed = limiter.Allow("")
+	assert.True(t, allowed)
+	// buckets are isolated by configured token
+	now = now.Add(time.Second)
+	_, allowed = limiter.Allow("random")
+	assert.True(t, allowed)
+	retryAfter, allowed = limiter.Allow("dashboard")
+	assert.True(t, allowed)
+	assert.Zero(t, retryAfter)
+	// buckets are bounded by configured tokens
+	for i := 0; i < 100; i++ {
+		limiter.Allow(fmt.Sprintf("token-%d", i))
+	}
+	assert.Len(t, limiter.buckets, 2)
+}
+
+func TestTokenRateLimiter_OverrideOnly(t *testing.T) {
+	limiter := NewTokenRateLimiter(config.Query{QueryBurstPerToken: 1}, []config.ACL{
+		{Token: "dashboard", MaxQueriesPerSecond: 0.5},
+	})
+	assert.NotNil(t, limiter)
+	for i := 0; i < 10; i++ {
+		_, allowed := limiter.Allow("other")
+		assert.True(t, allowed)
+	}
+	_, allowed := limiter.Allow("dashboard")
+	assert.True(t, allowed)
+	_, allowed = limiter.Allow("dashboard")
+	assert.False(t, allowed)
+}
+
+func TestMaskToken(t *testing.T) {
+	assert.Equal(t, "unconfigured", maskToken(""))
+	masked := maskToken("abcdefg")
+	assert.Len(t, masked, 16)
+	assert.NotContains(t, masked, "abcd")
+	assert.Equal(t, masked, maskToken("abcdefg"))
+	assert.NotEqual(t, masked, maskToken("abcdefh"))
+}
@@ -33,4 +33,7 @@ var (
 	ErrTaskSend                    = errors.New("send task request error")
 	ErrResponseSend                = errors.New("send response error")
 	ErrNoDatabase                  = errors.New("not found database")
+	ErrTooManySeries               = errors.New("too many series found for query")
+	ErrWriteNotVisible             = errors.New("written data of write token not visible in time")
+	ErrQueryCanceled               = errors.New("query canceled")
 )
@@ -37,8 +37,8 @@ func newMockDatabase(ctrl *gomock.Controller) *tsdb.MockDatabase {
 	metadataIndex := metadb.NewMockMetadataDatabase(ctrl)
 	metadata.EXPECT().MetadataDatabase().Return(metadataIndex).AnyTimes()
 	metadataIndex.EXPECT().GetMetricID(gomock.Any(), gomock.Any()).Return(uint32(10), nil).AnyTimes()
-	metadataIndex.EXPECT().GetField(gomock.Any(), gomock.Any(), gomock.Any()).
-		Return(field.Meta{ID: 10, Type: field.SumField}, nil).AnyTimes()
+	metadataIndex.EXPECT().GetFields(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(field.Metas{{ID: 10, Type: field.SumField}}, nil).AnyTimes()
 
 	mockedDatabase := tsdb.NewMockDatabase(ctrl)
 	mockedDatabase.EXPECT().GetShard(gomock.Any()).Return(shard, true).AnyTimes()
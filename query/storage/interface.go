@@ -33,4 +33,8 @@ type storageMetadataQuery interface {
 type StorageExecuteContext interface {
 	// QueryStats returns the storage query stats
 	QueryStats() *models.StorageStats
+	// IsStale returns if the result may be stale, e.g. series searched by cached tag keys when metadata unavailable
+	IsStale() bool
+	// Completed invokes after storage query completed, logs slow query
+	Completed()
 }
@@ -21,8 +21,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
 
+	"github.com/lindb/lindb/config"
 	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/flow"
 	"github.com/lindb/lindb/internal/linmetric"
 	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/pkg/encoding"
@@ -31,6 +34,7 @@ import (
 	protoCommonV1 "github.com/lindb/lindb/proto/gen/v1/common"
 	"github.com/lindb/lindb/query"
 	"github.com/lindb/lindb/rpc"
+	"github.com/lindb/lindb/series"
 	"github.com/lindb/lindb/sql/stmt"
 	"github.com/lindb/lindb/tsdb"
 )
@@ -45,9 +49,12 @@ type leafTaskProcessor struct {
 	taskServerFactory rpc.TaskServerFactory
 	logger            *logger.Logger
 
+	queryFlows sync.Map // parent task id => running query flow, for canceling query
+
 	storageMetricQueryCounter  *linmetric.BoundCounter
 	storageMetaQueryCounter    *linmetric.BoundCounter
 	storageOmitResponseCounter *linmetric.BoundCounter
+	storageCancelQueryCounter  *linmetric.BoundCounter
 }
 
 // NewLeafTaskProcessor creates the leaf task
@@ -66,6 +73,7 @@ func NewLeafTaskProcessor(
 		storageMetricQueryCounter:  storageQueryScope.NewCounter("metric_queries"),
 		storageMetaQueryCounter:    storageQueryScope.NewCounter("meta_queries"),
 		storageOmitResponseCounter: storageQueryScope.NewCounter("omitted_responses"),
+		storageCancelQueryCounter:  storageQueryScope.NewCounter("canceled_queries"),
 	}
 }
 
@@ -98,6 +106,10 @@ func (p *leafTaskProcessor) process(
 	ctx context.Context,
 	req *protoCommonV1.TaskRequest,
 ) error {
+	if req.RequestType == protoCommonV1.RequestType_Cancel {
+		p.cancel(req.ParentTaskID)
+		return nil
+	}
 	physicalPlan := models.PhysicalPlan{}
 	if err := encoding.JSONUnmarshal(req.PhysicalPlan, &physicalPlan); err != nil {
 		return fmt.Errorf("%w: %s", query.ErrUnmarshalPlan, err)
@@ -183,9 +195,20 @@ func (p *leafTaskProcessor) processDataSearch(
 	if err := stmtQuery.UnmarshalJSON(req.Payload); err != nil {
 		return query.ErrUnmarshalQuery
 	}
+	if stmtQuery.WriteToken != "" {
+		// read-your-writes, waits written data visible before scanning
+		if err := waitWriteVisible(ctx, db, shardIDs, stmtQuery.WriteToken); err != nil {
+			return err
+		}
+	}
 
 	// execute leaf task
 	storageExecuteCtx := newStorageExecuteContext(shardIDs, &stmtQuery)
+	storageExecuteCtx.database = db.Name()
+	// slow query threshold may be changed by reloading config
+	storageExecuteCtx.slowQueryThreshold = config.GlobalQueryConfig().SlowQueryThreshold.Duration()
+	storageExecuteCtx.maxSeries = config.GlobalQueryConfig().MaxSeriesPerQuery
+	storageExecuteCtx.bitmapBudget = series.NewBitmapBudget(uint64(config.GlobalQueryConfig().MaxBitmapMemoryPerQuery))
 	queryFlow := NewStorageQueryFlow(
 		ctx,
 		storageExecuteCtx,
@@ -195,7 +218,22 @@ func (p *leafTaskProcessor) processDataSearch(
 		leafNode,
 		db.ExecutorPool(),
 	)
+	// register running query flow, so that it can be canceled by client before completed
+	p.queryFlows.Store(req.ParentTaskID, queryFlow)
+	storageExecuteCtx.onCompleted = func() {
+		p.queryFlows.Delete(req.ParentTaskID)
+	}
 	exec := newStorageMetricQuery(queryFlow, db, storageExecuteCtx)
 	exec.Execute()
 	return nil
 }
+
+// cancel cancels the running query flow of parent task, ignores it if query flow completed.
+func (p *leafTaskProcessor) cancel(parentTaskID string) {
+	queryFlow, ok := p.queryFlows.LoadAndDelete(parentTaskID)
+	if !ok {
+		return
+	}
+	p.storageCancelQueryCounter.Incr()
+	queryFlow.(flow.StorageQueryFlow).Cancel()
+}
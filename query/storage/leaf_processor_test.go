@@ -23,12 +23,16 @@ import (
 	"fmt"
 	"io"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"go.uber.org/atomic"
 
+	"github.com/lindb/lindb/flow"
 	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/pkg/encoding"
+	"github.com/lindb/lindb/pkg/timeutil"
 	protoCommonV1 "github.com/lindb/lindb/proto/gen/v1/common"
 	"github.com/lindb/lindb/query"
 	"github.com/lindb/lindb/rpc"
@@ -60,6 +64,7 @@ func TestLeafTask_Process_Fail(t *testing.T) {
 	engine := tsdb.NewMockEngine(ctrl)
 	serverStream := protoCommonV1.NewMockTaskService_HandleServer(ctrl)
 	mockDatabase := tsdb.NewMockDatabase(ctrl)
+	mockDatabase.EXPECT().Name().Return("test_db").AnyTimes()
 
 	currentNode := models.StatelessNode{HostIP: "1.1.1.3", GRPCPort: 8000}
 	processorI := NewLeafTaskProcessor(&currentNode, engine, taskServerFactory)
@@ -130,6 +135,7 @@ func TestLeafProcessor_Process(t *testing.T) {
 	processorI := NewLeafTaskProcessor(&currentNode, engine, taskServerFactory)
 	processor := processorI.(*leafTaskProcessor)
 	mockDatabase := tsdb.NewMockDatabase(ctrl)
+	mockDatabase.EXPECT().Name().Return("test_db").AnyTimes()
 	plan := encoding.JSONMarshal(&models.PhysicalPlan{
 		Database: "test_db",
 		Leafs:    []models.Leaf{{BaseNode: models.BaseNode{Indicator: "1.1.1.3:8000"}}},
@@ -146,6 +152,102 @@ func TestLeafProcessor_Process(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestLeafProcessor_Process_WriteToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		writeVisibleCheckInterval = 10 * time.Millisecond
+		ctrl.Finish()
+	}()
+	writeVisibleCheckInterval = time.Millisecond
+
+	taskServerFactory := rpc.NewMockTaskServerFactory(ctrl)
+	engine := tsdb.NewMockEngine(ctrl)
+	currentNode := models.StatelessNode{HostIP: "1.1.1.3", GRPCPort: 8000}
+	processor := NewLeafTaskProcessor(&currentNode, engine, taskServerFactory).(*leafTaskProcessor)
+	mockDatabase := tsdb.NewMockDatabase(ctrl)
+	mockDatabase.EXPECT().Name().Return("test_db").AnyTimes()
+	shard := tsdb.NewMockShard(ctrl)
+	family := tsdb.NewMockDataFamily(ctrl)
+	mockDatabase.EXPECT().GetShard(models.ShardID(1)).Return(shard, true).AnyTimes()
+	shard.EXPECT().CurrentInterval().Return(timeutil.Interval(timeutil.OneSecond * 10)).AnyTimes()
+	shard.EXPECT().GetDataFamilies(gomock.Any(), gomock.Any()).Return([]tsdb.DataFamily{family}).AnyTimes()
+	family.EXPECT().FamilyTime().Return(int64(100)).AnyTimes()
+	engine.EXPECT().GetDatabase(gomock.Any()).Return(mockDatabase, true).AnyTimes()
+	serverStream := protoCommonV1.NewMockTaskService_HandleServer(ctrl)
+	taskServerFactory.EXPECT().GetStream(gomock.Any()).Return(serverStream).AnyTimes()
+
+	plan := encoding.JSONMarshal(&models.PhysicalPlan{
+		Database: "test_db",
+		Leafs: []models.Leaf{{
+			BaseNode: models.BaseNode{Indicator: "1.1.1.3:8000"},
+			ShardIDs: []models.ShardID{1},
+		}},
+	})
+	// data written with ack, query immediately with write token
+	token := models.WriteToken{{ShardID: 1, FamilyTime: 100, Leader: 1, Sequence: 10}}
+	data := encoding.JSONMarshal(&stmt.Query{MetricName: "cpu", WriteToken: token.Encode()})
+
+	// case 1: scans data after written data visible
+	var visible atomic.Bool
+	family.EXPECT().IsSequenceCommitted(int32(1), int64(10)).DoAndReturn(func(_ int32, _ int64) bool {
+		return visible.Load()
+	}).AnyTimes()
+	time.AfterFunc(20*time.Millisecond, func() { visible.Store(true) })
+	mockDatabase.EXPECT().ExecutorPool().DoAndReturn(func() *tsdb.ExecutorPool {
+		assert.True(t, visible.Load())
+		return &tsdb.ExecutorPool{}
+	})
+	// stops executing after scanning started
+	mockDatabase.EXPECT().NumOfShards().Return(0)
+	serverStream.EXPECT().Send(gomock.Any()).Return(nil).AnyTimes()
+	err := processor.process(context.Background(), &protoCommonV1.TaskRequest{PhysicalPlan: plan, Payload: data})
+	assert.NoError(t, err)
+
+	// case 2: query canceled before written data visible
+	token = models.WriteToken{{ShardID: 1, FamilyTime: 100, Leader: 1, Sequence: 11}}
+	data = encoding.JSONMarshal(&stmt.Query{MetricName: "cpu", WriteToken: token.Encode()})
+	family.EXPECT().IsSequenceCommitted(int32(1), int64(11)).Return(false).AnyTimes()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err = processor.process(ctx, &protoCommonV1.TaskRequest{PhysicalPlan: plan, Payload: data})
+	assert.True(t, errors.Is(err, query.ErrWriteNotVisible))
+}
+
+func TestLeafProcessor_Process_Cancel(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	currentNode := models.StatelessNode{HostIP: "1.1.1.3", GRPCPort: 8000}
+	processor := NewLeafTaskProcessor(&currentNode, nil, nil).(*leafTaskProcessor)
+	queryFlow := flow.NewMockStorageQueryFlow(ctrl)
+	processor.queryFlows.Store("task-1", queryFlow)
+
+	// case 1: query flow not found
+	err := processor.process(context.Background(), &protoCommonV1.TaskRequest{
+		ParentTaskID: "task-2",
+		RequestType:  protoCommonV1.RequestType_Cancel,
+	})
+	assert.NoError(t, err)
+	// case 2: cancel running query flow
+	queryFlow.EXPECT().Cancel()
+	err = processor.process(context.Background(), &protoCommonV1.TaskRequest{
+		ParentTaskID: "task-1",
+		RequestType:  protoCommonV1.RequestType_Cancel,
+	})
+	assert.NoError(t, err)
+	_, ok := processor.queryFlows.Load("task-1")
+	assert.False(t, ok)
+	// case 3: unregister query flow after completed
+	processor.queryFlows.Store("task-3", queryFlow)
+	storageExecuteCtx := newStorageExecuteContext(nil, &stmt.Query{})
+	storageExecuteCtx.onCompleted = func() {
+		processor.queryFlows.Delete("task-3")
+	}
+	storageExecuteCtx.Completed()
+	_, ok = processor.queryFlows.Load("task-3")
+	assert.False(t, ok)
+}
+
 func TestLeafTask_Suggest_Process(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -157,6 +259,7 @@ func TestLeafTask_Suggest_Process(t *testing.T) {
 	processorI := NewLeafTaskProcessor(&currentNode, engine, taskServerFactory)
 	processor := processorI.(*leafTaskProcessor)
 	mockDatabase := tsdb.NewMockDatabase(ctrl)
+	mockDatabase.EXPECT().Name().Return("test_db").AnyTimes()
 	plan := encoding.JSONMarshal(&models.PhysicalPlan{
 		Database: "test_db",
 		Leafs:    []models.Leaf{{BaseNode: models.BaseNode{Indicator: "1.1.1.3:8000"}}},
@@ -132,6 +132,26 @@ func (p *storageExecutePlan) getDownSamplingAggSpecs() aggregation.AggregatorSpe
 	return result
 }
 
+// getDownSamplingAggTypes returns the agg types merging field values of same down sampling slot,
+// selected by field type automatically(sum for delta sum, last value for gauge),
+// if only one function is applied on the field, such as max(gauge), the function overrides it.
+func (p *storageExecutePlan) getDownSamplingAggTypes() []field.AggType {
+	result := make([]field.AggType, len(p.fieldMetas))
+	for idx, f := range p.fieldMetas {
+		result[idx] = f.Type.AggType()
+		functions := p.fields[f.ID].DownSampling.Functions()
+		if len(functions) != 1 {
+			continue
+		}
+		for funcType := range functions {
+			if aggTypes := f.Type.GetFuncFieldParams(funcType); len(aggTypes) == 1 {
+				result[idx] = aggTypes[0]
+			}
+		}
+	}
+	return result
+}
+
 // getAggregatorSpecs returns aggregator specs for group by.
 func (p *storageExecutePlan) getAggregatorSpecs() aggregation.AggregatorSpecs {
 	result := make(aggregation.AggregatorSpecs, len(p.fieldMetas))
@@ -141,6 +161,22 @@ func (p *storageExecutePlan) getAggregatorSpecs() aggregation.AggregatorSpecs {
 	return result
 }
 
+// getReduceAggregatorSpecs returns the aggregator specs for reducing result of all fields,
+// the fields resolved by field alias share one aggregator spec.
+func (p *storageExecutePlan) getReduceAggregatorSpecs() aggregation.AggregatorSpecs {
+	result := make(aggregation.AggregatorSpecs, 0, len(p.fieldMetas))
+	seen := make(map[*aggregation.Aggregator]struct{}, len(p.fieldMetas))
+	for _, f := range p.fieldMetas {
+		aggregator := p.fields[f.ID]
+		if _, ok := seen[aggregator]; ok {
+			continue
+		}
+		seen[aggregator] = struct{}{}
+		result = append(result, aggregator.Aggregator)
+	}
+	return result
+}
+
 // getFields returns sorted of field.Metas.
 func (p *storageExecutePlan) getFields() field.Metas {
 	return p.fieldMetas
@@ -184,20 +220,22 @@ func (p *storageExecutePlan) field(parentFunc *stmt.CallExpr, expr stmt.Expr) {
 		p.field(nil, e.Left)
 		p.field(nil, e.Right)
 	case *stmt.FieldExpr:
-		fieldMeta, err := p.metadata.MetadataDatabase().GetField(p.namespace, p.query.MetricName, field.Name(e.Name))
+		// field alias resolves to multi fields, which share one aggregator so that data of them are merged
+		fieldMetas, err := p.metadata.MetadataDatabase().GetFields(p.namespace, p.query.MetricName, field.Name(e.Name))
 		if err != nil {
 			p.err = err
 			return
 		}
 
-		fieldType := fieldMeta.Type
-		fieldID := fieldMeta.ID
-		aggregator, exist := p.fields[fieldID]
+		fieldType := fieldMetas[0].Type
+		aggregator, exist := p.fields[fieldMetas[0].ID]
 		if !exist {
 			aggregator = &aggregation.Aggregator{}
 			aggregator.DownSampling = aggregation.NewAggregatorSpec(field.Name(e.Name), fieldType)
 			aggregator.Aggregator = aggregation.NewAggregatorSpec(field.Name(e.Name), fieldType)
-			p.fields[fieldID] = aggregator
+			for _, fieldMeta := range fieldMetas {
+				p.fields[fieldMeta.ID] = aggregator
+			}
 		}
 
 		var funcType function.FuncType
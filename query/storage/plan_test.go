@@ -46,11 +46,11 @@ func TestStoragePlan_Metric(t *testing.T) {
 	metadata.EXPECT().MetadataDatabase().Return(metadataDB).AnyTimes()
 
 	metadataDB.EXPECT().GetMetricID(gomock.Any(), gomock.Any()).Return(uint32(10), nil)
-	metadataDB.EXPECT().GetField(gomock.Any(), gomock.Any(), gomock.Any()).
-		Return(field.Meta{
+	metadataDB.EXPECT().GetFields(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(field.Metas{{
 			ID:   10,
 			Type: field.SumField,
-		}, nil).AnyTimes()
+		}}, nil).AnyTimes()
 
 	q, _ := sql.Parse("select f from cpu")
 	query := q.(*stmt.Query)
@@ -73,15 +73,15 @@ func TestStoragePlan_SelectList(t *testing.T) {
 	metadata.EXPECT().MetadataDatabase().Return(metadataDB).AnyTimes()
 
 	metadataDB.EXPECT().GetMetricID(gomock.Any(), gomock.Any()).Return(uint32(10), nil).AnyTimes()
-	metadataDB.EXPECT().GetField(gomock.Any(), gomock.Any(), field.Name("f")).
-		Return(field.Meta{ID: 10, Type: field.SumField}, nil).AnyTimes()
-	metadataDB.EXPECT().GetField(gomock.Any(), gomock.Any(), field.Name("a")).
-		Return(field.Meta{ID: 11, Type: field.MinField}, nil).AnyTimes()
-	metadataDB.EXPECT().GetField(gomock.Any(), gomock.Any(), field.Name("b")).
-		Return(field.Meta{ID: 12, Type: field.MaxField}, nil).AnyTimes()
+	metadataDB.EXPECT().GetFields(gomock.Any(), gomock.Any(), field.Name("f")).
+		Return(field.Metas{{ID: 10, Type: field.SumField}}, nil).AnyTimes()
+	metadataDB.EXPECT().GetFields(gomock.Any(), gomock.Any(), field.Name("a")).
+		Return(field.Metas{{ID: 11, Type: field.MinField}}, nil).AnyTimes()
+	metadataDB.EXPECT().GetFields(gomock.Any(), gomock.Any(), field.Name("b")).
+		Return(field.Metas{{ID: 12, Type: field.MaxField}}, nil).AnyTimes()
 
-	metadataDB.EXPECT().GetField(gomock.Any(), gomock.Any(), field.Name("no_f")).
-		Return(field.Meta{ID: 99, Type: field.SumField}, constants.ErrNotFound).AnyTimes()
+	metadataDB.EXPECT().GetFields(gomock.Any(), gomock.Any(), field.Name("no_f")).
+		Return(field.Metas{{ID: 99, Type: field.SumField}}, constants.ErrNotFound).AnyTimes()
 
 	// error
 	query := &stmt.Query{MetricName: "cpu"}
@@ -141,6 +141,70 @@ func TestStoragePlan_SelectList(t *testing.T) {
 		storagePlan.getFields())
 }
 
+func TestStoragePlan_FieldAlias(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	metadataDB := metadb.NewMockMetadataDatabase(ctrl)
+	metadata := metadb.NewMockMetadata(ctrl)
+	metadata.EXPECT().MetadataDatabase().Return(metadataDB).AnyTimes()
+
+	metadataDB.EXPECT().GetMetricID(gomock.Any(), gomock.Any()).Return(uint32(10), nil)
+	// new field(id:12) and old field(id:10) resolved by alias
+	metadataDB.EXPECT().GetFields(gomock.Any(), gomock.Any(), field.Name("usage_total")).
+		Return(field.Metas{{ID: 12, Name: "usage_total", Type: field.SumField},
+			{ID: 10, Name: "usage_total", Type: field.SumField}}, nil)
+	metadataDB.EXPECT().GetFields(gomock.Any(), gomock.Any(), field.Name("load")).
+		Return(field.Metas{{ID: 11, Name: "load", Type: field.GaugeField}}, nil)
+
+	q, _ := sql.Parse("select usage_total,load from cpu")
+	storagePlan := newStorageExecutePlan("ns", metadata, q.(*stmt.Query))
+	assert.NoError(t, storagePlan.Plan())
+	assert.Equal(t,
+		field.Metas{
+			{Name: "usage_total", ID: 10, Type: field.SumField},
+			{Name: "load", ID: 11, Type: field.GaugeField},
+			{Name: "usage_total", ID: 12, Type: field.SumField},
+		},
+		storagePlan.getFields())
+	// both fields share one aggregator, data of them are merged when reducing
+	assert.Same(t, storagePlan.fields[10], storagePlan.fields[12])
+	assert.Len(t, storagePlan.getAggregatorSpecs(), 3)
+	reduceSpecs := storagePlan.getReduceAggregatorSpecs()
+	assert.Len(t, reduceSpecs, 2)
+	assert.Equal(t, field.Name("usage_total"), reduceSpecs[0].FieldName())
+	assert.Equal(t, field.Name("load"), reduceSpecs[1].FieldName())
+}
+
+func TestStorageExecutePlan_getDownSamplingAggTypes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	metadataDB := metadb.NewMockMetadataDatabase(ctrl)
+	metadata := metadb.NewMockMetadata(ctrl)
+	metadata.EXPECT().MetadataDatabase().Return(metadataDB).AnyTimes()
+
+	metadataDB.EXPECT().GetMetricID(gomock.Any(), gomock.Any()).Return(uint32(10), nil).AnyTimes()
+	metadataDB.EXPECT().GetFields(gomock.Any(), gomock.Any(), field.Name("counter")).
+		Return(field.Metas{{ID: 10, Type: field.SumField}}, nil).AnyTimes()
+	metadataDB.EXPECT().GetFields(gomock.Any(), gomock.Any(), field.Name("gauge")).
+		Return(field.Metas{{ID: 11, Type: field.GaugeField}}, nil).AnyTimes()
+
+	planAggTypes := func(sqlStr string) []field.AggType {
+		q, err := sql.Parse(sqlStr)
+		assert.NoError(t, err)
+		storagePlan := newStorageExecutePlan("ns", metadata, q.(*stmt.Query))
+		assert.NoError(t, storagePlan.Plan())
+		return storagePlan.getDownSamplingAggTypes()
+	}
+	// default agg type by field type
+	assert.Equal(t, []field.AggType{field.Sum, field.LastValue}, planAggTypes("select counter,gauge from cpu"))
+	// override by function
+	assert.Equal(t, []field.AggType{field.Max, field.Min}, planAggTypes("select max(counter),min(gauge) from cpu"))
+	// multi functions, use default agg type
+	assert.Equal(t, []field.AggType{field.LastValue}, planAggTypes("select max(gauge),gauge from cpu"))
+}
+
 func TestStorageExecutePlan_groupBy(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -152,10 +216,10 @@ func TestStorageExecutePlan_groupBy(t *testing.T) {
 		metadataDB.EXPECT().GetMetricID(gomock.Any(), "disk").Return(uint32(10), nil),
 		metadataDB.EXPECT().GetTagKeyID(gomock.Any(), gomock.Any(), "host").Return(uint32(10), nil),
 		metadataDB.EXPECT().GetTagKeyID(gomock.Any(), gomock.Any(), "path").Return(uint32(11), nil),
-		metadataDB.EXPECT().GetField(gomock.Any(), gomock.Any(), field.Name("f")).
-			Return(field.Meta{ID: 12, Type: field.SumField}, nil),
-		metadataDB.EXPECT().GetField(gomock.Any(), gomock.Any(), field.Name("d")).
-			Return(field.Meta{ID: 10, Type: field.SumField}, nil),
+		metadataDB.EXPECT().GetFields(gomock.Any(), gomock.Any(), field.Name("f")).
+			Return(field.Metas{{ID: 12, Type: field.SumField}}, nil),
+		metadataDB.EXPECT().GetFields(gomock.Any(), gomock.Any(), field.Name("d")).
+			Return(field.Metas{{ID: 10, Type: field.SumField}}, nil),
 	)
 
 	// normal
@@ -209,8 +273,8 @@ func TestStorageExecutePlan_field_expr_fail(t *testing.T) {
 
 	gomock.InOrder(
 		metadataDB.EXPECT().GetMetricID(gomock.Any(), "disk").Return(uint32(10), nil),
-		metadataDB.EXPECT().GetField(gomock.Any(), gomock.Any(), field.Name("f")).
-			Return(field.Meta{ID: 10, Type: field.Unknown}, nil),
+		metadataDB.EXPECT().GetFields(gomock.Any(), gomock.Any(), field.Name("f")).
+			Return(field.Metas{{ID: 10, Type: field.Unknown}}, nil),
 	)
 	q, _ := sql.Parse("select f from disk")
 	query := q.(*stmt.Query)
@@ -269,12 +333,12 @@ func TestStorageExecutePlan_field_ok(t *testing.T) {
 
 	gomock.InOrder(
 		metadataDB.EXPECT().GetMetricID(gomock.Any(), "disk").Return(uint32(10), nil),
-		metadataDB.EXPECT().GetField(gomock.Any(), gomock.Any(), field.Name("d")).
-			Return(field.Meta{ID: 12, Type: field.SumField}, nil),
-		metadataDB.EXPECT().GetField(gomock.Any(), gomock.Any(), field.Name("b")).
-			Return(field.Meta{ID: 11, Type: field.SumField}, nil),
-		metadataDB.EXPECT().GetField(gomock.Any(), gomock.Any(), field.Name("e")).
-			Return(field.Meta{ID: 11, Type: field.SumField}, nil),
+		metadataDB.EXPECT().GetFields(gomock.Any(), gomock.Any(), field.Name("d")).
+			Return(field.Metas{{ID: 12, Type: field.SumField}}, nil),
+		metadataDB.EXPECT().GetFields(gomock.Any(), gomock.Any(), field.Name("b")).
+			Return(field.Metas{{ID: 11, Type: field.SumField}}, nil),
+		metadataDB.EXPECT().GetFields(gomock.Any(), gomock.Any(), field.Name("e")).
+			Return(field.Metas{{ID: 11, Type: field.SumField}}, nil),
 	)
 
 	q, err := sql.Parse("select (d+quantile(0.1)*10+b),e from disk")
@@ -19,10 +19,14 @@ package storagequery
 
 import (
 	"sort"
+	"time"
+
+	"go.uber.org/atomic"
 
 	"github.com/lindb/lindb/flow"
 	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/series"
 	"github.com/lindb/lindb/sql/stmt"
 
 	"github.com/lindb/roaring"
@@ -36,6 +40,19 @@ type storageExecuteContext struct {
 	tagFilterResult map[string]*tagFilterResult
 
 	stats *models.StorageStats // storage query stats track for explain query
+
+	database           string        // database name for slow query log
+	slowQueryThreshold time.Duration // 0 means disable slow query log
+	start              time.Time
+	numOfSeries        atomic.Uint64 // num. of series found in all shards
+
+	maxSeries           int         // 0 means no limit of series per query
+	seriesLimitExceeded atomic.Bool // if series limit exceeded, for counting rejected query
+	// bitmapBudget limits the memory of series ids bitmaps when evaluating tag filter, shared by all shards
+	bitmapBudget *series.BitmapBudget
+	stale        atomic.Bool // if result may be stale, because metadata of any shard is unavailable
+
+	onCompleted func() // invokes after query completed, e.g. unregisters query flow for canceling
 }
 
 // newStorageExecuteContext creates storage execute context
@@ -43,9 +60,10 @@ func newStorageExecuteContext(shardIDs []models.ShardID, query *stmt.Query) *sto
 	ctx := &storageExecuteContext{
 		query:    query,
 		shardIDs: shardIDs,
+		start:    time.Now(),
 	}
-	if query.Explain {
-		// if explain query, create storage query stats
+	if query.Explain || query.Trace {
+		// if explain/trace query, create storage query stats
 		ctx.stats = models.NewStorageStats()
 	}
 	return ctx
@@ -59,6 +77,11 @@ func (ctx *storageExecuteContext) QueryStats() *models.StorageStats {
 	return ctx.stats
 }
 
+// IsStale returns if the result may be stale, e.g. series searched by cached tag keys when metadata unavailable
+func (ctx *storageExecuteContext) IsStale() bool {
+	return ctx.stale.Load()
+}
+
 // setTagFilterResult sets tag filter result
 func (ctx *storageExecuteContext) setTagFilterResult(tagFilterResult map[string]*tagFilterResult) {
 	ctx.tagFilterResult = tagFilterResult
@@ -27,6 +27,7 @@ import (
 	"go.uber.org/atomic"
 
 	"github.com/lindb/lindb/aggregation"
+	"github.com/lindb/lindb/config"
 	"github.com/lindb/lindb/flow"
 	"github.com/lindb/lindb/internal/concurrent"
 	"github.com/lindb/lindb/models"
@@ -58,20 +59,27 @@ type storageQueryFlow struct {
 	taskIDSeq         atomic.Int32    // task id gen sequence
 	executorPool      *tsdb.ExecutorPool
 	reduceAgg         aggregation.GroupingAggregator
+	newReduceAgg      func() aggregation.GroupingAggregator
 	leafNode          *models.Leaf
 	req               *protoCommonV1.TaskRequest
 	ctx               context.Context
 	serverFactory     rpc.TaskServerFactory
 
 	aggregatorSpecs []*protoCommonV1.AggregatorSpec
+	exemplars       map[string][]*protoCommonV1.Exemplar // tag value ids => exemplars of fields
 
 	tagsMap      map[string]string   // tag value ids => tag values
 	tagValuesMap []map[uint32]string // tag value id=> tag value for each group by tag key
 	tagValues    []string
 	signal       sync.WaitGroup
+	// num. of group by tag keys whose tag values not collected
+	pendingTagKeys atomic.Int32
+	// num. of partial chunks sent for each receiver
+	sentChunks []int32
 
 	mux       sync.Mutex
 	completed atomic.Bool
+	canceled  atomic.Bool
 }
 
 func NewStorageQueryFlow(
@@ -92,6 +100,7 @@ func NewStorageQueryFlow(
 		serverFactory:     serverFactory,
 		executorPool:      executorPool,
 		pendingTasks:      make(map[int32]Stage),
+		sentChunks:        make([]int32, len(leafNode.Receivers)),
 	}
 }
 
@@ -101,7 +110,10 @@ func (qf *storageQueryFlow) Prepare(
 	timeRange timeutil.TimeRange,
 	aggregatorSpecs aggregation.AggregatorSpecs,
 ) {
-	qf.reduceAgg = aggregation.NewGroupingAggregator(interval, intervalRatio, timeRange, aggregatorSpecs)
+	qf.newReduceAgg = func() aggregation.GroupingAggregator {
+		return aggregation.NewGroupingAggregator(interval, intervalRatio, timeRange, aggregatorSpecs)
+	}
+	qf.reduceAgg = qf.newReduceAgg()
 	qf.aggregatorSpecs = make([]*protoCommonV1.AggregatorSpec, len(aggregatorSpecs))
 	for idx, spec := range aggregatorSpecs {
 		qf.aggregatorSpecs[idx] = &protoCommonV1.AggregatorSpec{
@@ -120,12 +132,14 @@ func (qf *storageQueryFlow) Prepare(
 		qf.tagsMap = make(map[string]string)
 		qf.tagValues = make([]string, groupByKenLen)
 		qf.signal.Add(groupByKenLen)
+		qf.pendingTagKeys.Store(int32(groupByKenLen))
 	}
 }
 
 // Complete completes the query flow with error
 func (qf *storageQueryFlow) Complete(err error) {
 	if err != nil && qf.completed.CAS(false, true) {
+		qf.storageExecuteCtx.Completed()
 		// if complete with err, need send err msg directly and mark task completed
 		for _, receiver := range qf.leafNode.Receivers {
 			stream := qf.serverFactory.GetStream(receiver.Indicator())
@@ -146,6 +160,12 @@ func (qf *storageQueryFlow) Complete(err error) {
 	}
 }
 
+// Cancel cancels the query flow, stops executing pending tasks and sending the rest result chunks.
+func (qf *storageQueryFlow) Cancel() {
+	qf.canceled.Store(true)
+	qf.Complete(query.ErrQueryCanceled)
+}
+
 func (qf *storageQueryFlow) Load(task concurrent.Task) {
 	qf.execute(Scanner, task)
 }
@@ -171,14 +191,67 @@ func (qf *storageQueryFlow) Reduce(_ string, it series.GroupedIterator) {
 	qf.reduceAgg.Aggregate(it)
 }
 
+// ReduceExemplars reduces the exemplars of fields for the grouped series.
+func (qf *storageQueryFlow) ReduceExemplars(tags string, exemplars []*protoCommonV1.Exemplar) {
+	if qf.completed.Load() {
+		return
+	}
+
+	qf.mux.Lock()
+	defer qf.mux.Unlock()
+
+	if qf.exemplars == nil {
+		qf.exemplars = make(map[string][]*protoCommonV1.Exemplar)
+	}
+	qf.exemplars[tags] = append(qf.exemplars[tags], exemplars...)
+}
+
 // ReduceTagValues reduces the group by tag values
 func (qf *storageQueryFlow) ReduceTagValues(tagKeyIndex int, tagValues map[uint32]string) {
 	qf.mux.Lock()
 	defer qf.mux.Unlock()
 	qf.tagValuesMap[tagKeyIndex] = tagValues
+	qf.pendingTagKeys.Dec()
 	qf.signal.Done()
 }
 
+// Emit sends the result reduced so far to upstream receivers as partial chunks if query streams result,
+// then resets the reduce aggregator, so that result is sent per scanned batch instead of being buffered
+// until all tasks completed. For group by query, result is kept until tag values of group by collected.
+func (qf *storageQueryFlow) Emit() {
+	if !qf.query.Stream || qf.reduceAgg == nil || qf.pendingTagKeys.Load() > 0 {
+		return
+	}
+	qf.mux.Lock()
+	defer qf.mux.Unlock()
+
+	if qf.completed.Load() {
+		return
+	}
+	timeSeriesList := qf.makeTimeSeriesList()
+	if len(timeSeriesList) == 0 {
+		return
+	}
+	qf.reduceAgg = qf.newReduceAgg()
+	qf.exemplars = nil
+	for idx, timeSeriesHashGroup := range qf.groupByReceiver(timeSeriesList) {
+		if len(timeSeriesHashGroup) == 0 {
+			continue
+		}
+		receiver := qf.leafNode.Receivers[idx]
+		stream := qf.serverFactory.GetStream(receiver.Indicator())
+		if stream == nil {
+			storageQueryFlowLogger.Error("unable to get stream for write response",
+				logger.String("target", receiver.Indicator()))
+			qf.Complete(query.ErrNoSendStream)
+			return
+		}
+		if !qf.sendPartialChunks(idx, stream, qf.makeChunks(timeSeriesHashGroup)) {
+			return
+		}
+	}
+}
+
 func (qf *storageQueryFlow) getTagValues(tags string) string {
 	tagValues, ok := qf.tagsMap[tags]
 	if ok {
@@ -212,46 +285,64 @@ func (qf *storageQueryFlow) completeTask(taskID int32) {
 	if !completed || !qf.completed.CAS(false, true) {
 		return
 	}
+	defer qf.storageExecuteCtx.Completed()
 
-	hashGroupData := make([][]byte, len(qf.leafNode.Receivers))
+	hashGroupData := make([][][]byte, len(qf.leafNode.Receivers))
 	if qf.reduceAgg != nil {
 		hasGroupBy := qf.query.HasGroupBy()
 		if hasGroupBy {
 			qf.signal.Wait() // wait collect group by tag value complete
 		}
 		timeSeriesList := qf.makeTimeSeriesList()
-		// root -> leaf task, return the raw total series
-		if len(qf.leafNode.Receivers) == 1 {
-			leaf2RootSeries := protoCommonV1.TimeSeriesList{
-				TimeSeriesList: timeSeriesList,
-				FieldAggSpecs:  qf.aggregatorSpecs,
-			}
-			leaf2RootSeriesPayload, _ := leaf2RootSeries.Marshal()
-			hashGroupData[0] = leaf2RootSeriesPayload
-		} else {
-			// during intermediate task, time series will be grouped by hash
-			// and send to multi intermediate receiver
-			// hash mod -> series list
-			var timeSeriesHashGroups = make([][]*protoCommonV1.TimeSeries, len(qf.leafNode.Receivers))
-			for _, ts := range timeSeriesList {
-				h := xxhash.Sum64String(ts.Tags)
-				index := int(h % uint64(len(qf.leafNode.Receivers)))
-				timeSeriesHashGroups[index] = append(timeSeriesHashGroups[index], ts)
-			}
-			for idx, timeSeriesHashGroup := range timeSeriesHashGroups {
-				leaf2IntermediateSeries := protoCommonV1.TimeSeriesList{
-					TimeSeriesList: timeSeriesHashGroup,
-					FieldAggSpecs:  qf.aggregatorSpecs,
-				}
-				leaf2IntermediatePayload, _ := leaf2IntermediateSeries.Marshal()
-				hashGroupData[idx] = leaf2IntermediatePayload
-			}
+		for idx, timeSeriesHashGroup := range qf.groupByReceiver(timeSeriesList) {
+			hashGroupData[idx] = qf.makeChunks(timeSeriesHashGroup)
 		}
 	}
 	qf.sendResponse(hashGroupData)
 }
 
-func (qf *storageQueryFlow) sendResponse(hashGroupData [][]byte) {
+// groupByReceiver groups the time series list for each receiver.
+func (qf *storageQueryFlow) groupByReceiver(timeSeriesList []*protoCommonV1.TimeSeries) [][]*protoCommonV1.TimeSeries {
+	// root -> leaf task, return the raw total series
+	if len(qf.leafNode.Receivers) == 1 {
+		return [][]*protoCommonV1.TimeSeries{timeSeriesList}
+	}
+	// during intermediate task, time series will be grouped by hash
+	// and send to multi intermediate receiver
+	// hash mod -> series list
+	var timeSeriesHashGroups = make([][]*protoCommonV1.TimeSeries, len(qf.leafNode.Receivers))
+	for _, ts := range timeSeriesList {
+		h := xxhash.Sum64String(ts.Tags)
+		index := int(h % uint64(len(qf.leafNode.Receivers)))
+		timeSeriesHashGroups[index] = append(timeSeriesHashGroups[index], ts)
+	}
+	return timeSeriesHashGroups
+}
+
+// makeChunks splits the time series list into chunks, the payload size of each chunk is bounded by
+// result chunk size(at least one time series), so that large result is not buffered in one response.
+func (qf *storageQueryFlow) makeChunks(timeSeriesList []*protoCommonV1.TimeSeries) (chunks [][]byte) {
+	chunkSize := int(config.GlobalQueryConfig().ResultChunkSize)
+	chunk := protoCommonV1.TimeSeriesList{FieldAggSpecs: qf.aggregatorSpecs}
+	size := 0
+	for _, ts := range timeSeriesList {
+		tsSize := ts.Size()
+		if len(chunk.TimeSeriesList) > 0 && chunkSize > 0 && size+tsSize > chunkSize {
+			payload, _ := chunk.Marshal()
+			chunks = append(chunks, payload)
+			chunk.TimeSeriesList = nil
+			size = 0
+		}
+		chunk.TimeSeriesList = append(chunk.TimeSeriesList, ts)
+		size += tsSize
+	}
+	payload, _ := chunk.Marshal()
+	return append(chunks, payload)
+}
+
+// sendResponse sends the result chunks to upstream receivers, only the last chunk is marked completed,
+// which carries the num. of partial chunks sent before it(including the chunks emitted) and the query stats.
+func (qf *storageQueryFlow) sendResponse(hashGroupData [][][]byte) {
 	var stats []byte
 	if qf.storageExecuteCtx.QueryStats() != nil {
 		stats = encoding.JSONMarshal(qf.storageExecuteCtx.QueryStats())
@@ -265,17 +356,54 @@ func (qf *storageQueryFlow) sendResponse(hashGroupData [][]byte) {
 			qf.Complete(query.ErrNoSendStream)
 			break
 		}
+		chunks := hashGroupData[idx]
+		if len(chunks) == 0 {
+			chunks = [][]byte{nil}
+		}
+		last := len(chunks) - 1
+		if !qf.sendPartialChunks(idx, stream, chunks[:last]) {
+			if qf.canceled.Load() {
+				return
+			}
+			continue
+		}
 		if err := stream.Send(&protoCommonV1.TaskResponse{
 			TaskID:    qf.req.ParentTaskID,
 			Type:      protoCommonV1.TaskType_Leaf,
 			Completed: true,
 			SendTime:  timeutil.NowNano(),
-			Payload:   hashGroupData[idx],
+			Payload:   chunks[last],
+			Chunks:    qf.sentChunks[idx],
 			Stats:     stats,
+			Stale:     qf.storageExecuteCtx.IsStale(),
+		}); err != nil {
+			storageQueryFlowLogger.Error("send storage query result", logger.Error(err))
+		}
+	}
+}
+
+// sendPartialChunks sends the not completed chunks to receiver with the sequence of chunk,
+// returns false if query canceled or send failure.
+func (qf *storageQueryFlow) sendPartialChunks(receiverIdx int, stream protoCommonV1.TaskService_HandleServer, chunks [][]byte) bool {
+	for _, chunk := range chunks {
+		if qf.canceled.Load() {
+			// query canceled by client, stop sending the rest chunks
+			return false
+		}
+		seq := qf.sentChunks[receiverIdx] + 1
+		if err := stream.Send(&protoCommonV1.TaskResponse{
+			TaskID:   qf.req.ParentTaskID,
+			Type:     protoCommonV1.TaskType_Leaf,
+			SendTime: timeutil.NowNano(),
+			Payload:  chunk,
+			Chunks:   seq, // sequence of partial chunk
 		}); err != nil {
 			storageQueryFlowLogger.Error("send storage query result", logger.Error(err))
+			return false
 		}
+		qf.sentChunks[receiverIdx] = seq
 	}
+	return true
 }
 
 func (qf *storageQueryFlow) makeTimeSeriesList() []*protoCommonV1.TimeSeries {
@@ -303,9 +431,14 @@ func (qf *storageQueryFlow) makeTimeSeriesList() []*protoCommonV1.TimeSeries {
 			if hasGroupBy {
 				tags = qf.getTagValues(groupedSeriesItr.Tags())
 			}
+			var exemplars []*protoCommonV1.Exemplar
+			if len(qf.exemplars) > 0 {
+				exemplars = qf.exemplars[groupedSeriesItr.Tags()]
+			}
 			timeSeriesList = append(timeSeriesList, &protoCommonV1.TimeSeries{
-				Tags:   tags,
-				Fields: fields,
+				Tags:      tags,
+				Fields:    fields,
+				Exemplars: exemplars,
 			})
 		}
 	}
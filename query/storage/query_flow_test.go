@@ -31,11 +31,14 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/lindb/lindb/aggregation"
+	"github.com/lindb/lindb/config"
 	"github.com/lindb/lindb/internal/concurrent"
 	"github.com/lindb/lindb/internal/linmetric"
 	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/ltoml"
 	"github.com/lindb/lindb/pkg/timeutil"
 	protoCommonV1 "github.com/lindb/lindb/proto/gen/v1/common"
+	"github.com/lindb/lindb/query"
 	"github.com/lindb/lindb/rpc"
 	"github.com/lindb/lindb/series"
 	"github.com/lindb/lindb/series/field"
@@ -71,6 +74,8 @@ func TestStorageQueryFlow_Execute(t *testing.T) {
 
 	storageExecuteCtx := NewMockStorageExecuteContext(ctrl)
 	storageExecuteCtx.EXPECT().QueryStats().Return(models.NewStorageStats()).AnyTimes()
+	storageExecuteCtx.EXPECT().IsStale().Return(false).AnyTimes()
+	storageExecuteCtx.EXPECT().Completed().AnyTimes()
 	taskServerFactory := rpc.NewMockTaskServerFactory(ctrl)
 	taskServerFactory.EXPECT().GetStream(gomock.Any()).Return(nil)
 
@@ -132,6 +137,8 @@ func TestStorageQueryFlow_completeTask(t *testing.T) {
 
 	storageExecuteCtx := NewMockStorageExecuteContext(ctrl)
 	storageExecuteCtx.EXPECT().QueryStats().Return(nil).AnyTimes()
+	storageExecuteCtx.EXPECT().IsStale().Return(false).AnyTimes()
+	storageExecuteCtx.EXPECT().Completed().AnyTimes()
 	taskServerFactory := rpc.NewMockTaskServerFactory(ctrl)
 	server := protoCommonV1.NewMockTaskService_HandleServer(ctrl)
 	server.EXPECT().Send(gomock.Any()).Return(nil).AnyTimes()
@@ -208,6 +215,8 @@ func TestStorageQueryFlow_getValues(t *testing.T) {
 
 	storageExecuteCtx := NewMockStorageExecuteContext(ctrl)
 	storageExecuteCtx.EXPECT().QueryStats().Return(nil).AnyTimes()
+	storageExecuteCtx.EXPECT().IsStale().Return(false).AnyTimes()
+	storageExecuteCtx.EXPECT().Completed().AnyTimes()
 	taskServerFactory := rpc.NewMockTaskServerFactory(ctrl)
 	server := protoCommonV1.NewMockTaskService_HandleServer(ctrl)
 	server.EXPECT().Send(gomock.Any()).Return(nil).AnyTimes()
@@ -248,6 +257,8 @@ func TestStorageQueryFlow_Task_panic(t *testing.T) {
 
 	storageExecuteCtx := NewMockStorageExecuteContext(ctrl)
 	storageExecuteCtx.EXPECT().QueryStats().Return(nil).AnyTimes()
+	storageExecuteCtx.EXPECT().IsStale().Return(false).AnyTimes()
+	storageExecuteCtx.EXPECT().Completed().AnyTimes()
 	queryFlow := NewStorageQueryFlow(context.TODO(),
 		storageExecuteCtx, &stmt.Query{},
 		&protoCommonV1.TaskRequest{},
@@ -279,6 +290,8 @@ func TestStorageQueryFlow_Complete(t *testing.T) {
 
 	storageExecuteCtx := NewMockStorageExecuteContext(ctrl)
 	storageExecuteCtx.EXPECT().QueryStats().Return(nil).AnyTimes()
+	storageExecuteCtx.EXPECT().IsStale().Return(false).AnyTimes()
+	storageExecuteCtx.EXPECT().Completed().AnyTimes()
 	taskServerFactory := rpc.NewMockTaskServerFactory(ctrl)
 	server := protoCommonV1.NewMockTaskService_HandleServer(ctrl)
 	taskServerFactory.EXPECT().GetStream(gomock.Any()).Return(server).Times(2)
@@ -311,3 +324,214 @@ func TestStorageQueryFlow_Complete(t *testing.T) {
 	queryFlow.Complete(fmt.Errorf("err")) // stream not found
 
 }
+
+func TestStorageQueryFlow_sendResponse_chunks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defaultCfg := config.GlobalQueryConfig()
+	defer func() {
+		config.SetGlobalQueryConfig(defaultCfg)
+		ctrl.Finish()
+	}()
+
+	storageExecuteCtx := NewMockStorageExecuteContext(ctrl)
+	storageExecuteCtx.EXPECT().QueryStats().Return(nil).AnyTimes()
+	storageExecuteCtx.EXPECT().IsStale().Return(false).AnyTimes()
+	taskServerFactory := rpc.NewMockTaskServerFactory(ctrl)
+	server := protoCommonV1.NewMockTaskService_HandleServer(ctrl)
+	taskServerFactory.EXPECT().GetStream(gomock.Any()).Return(server).AnyTimes()
+	var responses []*protoCommonV1.TaskResponse
+	server.EXPECT().Send(gomock.Any()).DoAndReturn(func(resp *protoCommonV1.TaskResponse) error {
+		responses = append(responses, resp)
+		return nil
+	}).AnyTimes()
+
+	queryFlow := NewStorageQueryFlow(context.TODO(),
+		storageExecuteCtx, &stmt.Query{},
+		&protoCommonV1.TaskRequest{ParentTaskID: "task-1"},
+		taskServerFactory,
+		&models.Leaf{Receivers: []models.StatelessNode{{HostIP: "1.1.1.1", GRPCPort: 1000}}},
+		testExecPool)
+	qf := queryFlow.(*storageQueryFlow)
+	timeSeriesList := []*protoCommonV1.TimeSeries{
+		{Tags: "a", Fields: map[string][]byte{"f": make([]byte, 100)}},
+		{Tags: "b", Fields: map[string][]byte{"f": make([]byte, 100)}},
+		{Tags: "c", Fields: map[string][]byte{"f": make([]byte, 100)}},
+	}
+	// case 1: each chunk only holds one time series
+	cfg := *defaultCfg
+	cfg.ResultChunkSize = ltoml.Size(150)
+	config.SetGlobalQueryConfig(&cfg)
+	qf.sendResponse([][][]byte{qf.makeChunks(timeSeriesList)})
+	assert.Len(t, responses, 3)
+	var tags []string
+	for idx, resp := range responses {
+		assert.Equal(t, "task-1", resp.TaskID)
+		assert.Equal(t, idx == 2, resp.Completed)
+		if idx < 2 {
+			assert.Equal(t, int32(idx+1), resp.Chunks)
+		}
+		tsList := &protoCommonV1.TimeSeriesList{}
+		assert.NoError(t, tsList.Unmarshal(resp.Payload))
+		assert.Len(t, tsList.TimeSeriesList, 1)
+		tags = append(tags, tsList.TimeSeriesList[0].Tags)
+	}
+	assert.Equal(t, []string{"a", "b", "c"}, tags)
+	assert.Equal(t, int32(2), responses[2].Chunks)
+	// case 2: all time series in one chunk
+	responses = nil
+	qf.sentChunks[0] = 0
+	cfg.ResultChunkSize = ltoml.Size(1024)
+	qf.sendResponse([][][]byte{qf.makeChunks(timeSeriesList)})
+	assert.Len(t, responses, 1)
+	assert.True(t, responses[0].Completed)
+	assert.Zero(t, responses[0].Chunks)
+	// case 3: empty result
+	responses = nil
+	qf.sendResponse([][][]byte{nil})
+	assert.Len(t, responses, 1)
+	assert.True(t, responses[0].Completed)
+}
+
+func TestStorageQueryFlow_sendResponse_stale(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storageExecuteCtx := NewMockStorageExecuteContext(ctrl)
+	storageExecuteCtx.EXPECT().QueryStats().Return(nil).AnyTimes()
+	storageExecuteCtx.EXPECT().IsStale().Return(true)
+	taskServerFactory := rpc.NewMockTaskServerFactory(ctrl)
+	server := protoCommonV1.NewMockTaskService_HandleServer(ctrl)
+	taskServerFactory.EXPECT().GetStream(gomock.Any()).Return(server)
+	server.EXPECT().Send(gomock.Any()).DoAndReturn(func(resp *protoCommonV1.TaskResponse) error {
+		assert.True(t, resp.Completed)
+		assert.True(t, resp.Stale)
+		return nil
+	})
+
+	queryFlow := NewStorageQueryFlow(context.TODO(),
+		storageExecuteCtx, &stmt.Query{},
+		&protoCommonV1.TaskRequest{ParentTaskID: "task-1"},
+		taskServerFactory,
+		&models.Leaf{Receivers: []models.StatelessNode{{HostIP: "1.1.1.1", GRPCPort: 1000}}},
+		testExecPool)
+	queryFlow.(*storageQueryFlow).sendResponse([][][]byte{nil})
+}
+
+func TestStorageQueryFlow_Emit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storageExecuteCtx := NewMockStorageExecuteContext(ctrl)
+	storageExecuteCtx.EXPECT().QueryStats().Return(nil).AnyTimes()
+	storageExecuteCtx.EXPECT().IsStale().Return(false).AnyTimes()
+	taskServerFactory := rpc.NewMockTaskServerFactory(ctrl)
+	server := protoCommonV1.NewMockTaskService_HandleServer(ctrl)
+	taskServerFactory.EXPECT().GetStream(gomock.Any()).Return(server).AnyTimes()
+	var responses []*protoCommonV1.TaskResponse
+	server.EXPECT().Send(gomock.Any()).DoAndReturn(func(resp *protoCommonV1.TaskResponse) error {
+		responses = append(responses, resp)
+		return nil
+	}).AnyTimes()
+	newQueryFlow := func(q *stmt.Query) *storageQueryFlow {
+		queryFlow := NewStorageQueryFlow(context.TODO(),
+			storageExecuteCtx, q,
+			&protoCommonV1.TaskRequest{ParentTaskID: "task-1"},
+			taskServerFactory,
+			&models.Leaf{Receivers: []models.StatelessNode{{HostIP: "1.1.1.1", GRPCPort: 1000}}},
+			testExecPool)
+		queryFlow.Prepare(timeutil.Interval(timeutil.OneSecond*10), 1, timeutil.TimeRange{},
+			aggregation.AggregatorSpecs{aggregation.NewAggregatorSpec("f", field.SumField)})
+		return queryFlow.(*storageQueryFlow)
+	}
+	mockResult := func(qf *storageQueryFlow) {
+		reduceAgg := aggregation.NewMockGroupingAggregator(ctrl)
+		groupedIt := series.NewMockGroupedIterator(ctrl)
+		it := series.NewMockIterator(ctrl)
+		gomock.InOrder(
+			groupedIt.EXPECT().HasNext().Return(true),
+			groupedIt.EXPECT().Next().Return(it),
+			groupedIt.EXPECT().HasNext().Return(false),
+		)
+		it.EXPECT().MarshalBinary().Return([]byte{1, 2, 3}, nil)
+		it.EXPECT().FieldName().Return(field.Name("f"))
+		groupedIt.EXPECT().Tags().Return("").AnyTimes()
+		reduceAgg.EXPECT().ResultSet().Return(series.GroupedIterators{groupedIt})
+		qf.reduceAgg = reduceAgg
+	}
+
+	// case 1: not streaming, result is sent after all tasks completed
+	qf := newQueryFlow(&stmt.Query{})
+	qf.Emit()
+	assert.Empty(t, responses)
+	// case 2: group by tag values not collected
+	qf = newQueryFlow(&stmt.Query{Stream: true, GroupBy: []string{"host"}})
+	qf.Emit()
+	assert.Empty(t, responses)
+	// case 3: no result reduced
+	qf = newQueryFlow(&stmt.Query{Stream: true})
+	qf.Emit()
+	assert.Empty(t, responses)
+	// case 4: sends result of scanned batch as partial chunk, then resets reduce aggregator and exemplars
+	mockResult(qf)
+	exemplar := &protoCommonV1.Exemplar{FieldName: "f", TraceID: []byte{1, 2}, Value: 1, Timestamp: 10}
+	qf.ReduceExemplars("", []*protoCommonV1.Exemplar{exemplar})
+	qf.Emit()
+	assert.Len(t, responses, 1)
+	assert.False(t, responses[0].Completed)
+	assert.Equal(t, int32(1), responses[0].Chunks)
+	tsList := &protoCommonV1.TimeSeriesList{}
+	assert.NoError(t, tsList.Unmarshal(responses[0].Payload))
+	assert.Equal(t, []byte{1, 2, 3}, tsList.TimeSeriesList[0].Fields["f"])
+	assert.Equal(t, []*protoCommonV1.Exemplar{exemplar}, tsList.TimeSeriesList[0].Exemplars)
+	mockResult(qf)
+	qf.Emit()
+	assert.Len(t, responses, 2)
+	assert.Equal(t, int32(2), responses[1].Chunks)
+	tsList = &protoCommonV1.TimeSeriesList{}
+	assert.NoError(t, tsList.Unmarshal(responses[1].Payload))
+	assert.Empty(t, tsList.TimeSeriesList[0].Exemplars)
+	// case 5: completed response carries the num. of chunks emitted
+	storageExecuteCtx.EXPECT().Completed()
+	qf.completeTask(0)
+	assert.Len(t, responses, 3)
+	assert.True(t, responses[2].Completed)
+	assert.Equal(t, int32(2), responses[2].Chunks)
+	// case 6: completed
+	qf.Emit()
+	assert.Len(t, responses, 3)
+}
+
+func TestStorageQueryFlow_Cancel(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storageExecuteCtx := NewMockStorageExecuteContext(ctrl)
+	storageExecuteCtx.EXPECT().QueryStats().Return(nil).AnyTimes()
+	storageExecuteCtx.EXPECT().IsStale().Return(false).AnyTimes()
+	storageExecuteCtx.EXPECT().Completed()
+	taskServerFactory := rpc.NewMockTaskServerFactory(ctrl)
+	server := protoCommonV1.NewMockTaskService_HandleServer(ctrl)
+	taskServerFactory.EXPECT().GetStream(gomock.Any()).Return(server).AnyTimes()
+
+	queryFlow := NewStorageQueryFlow(context.TODO(),
+		storageExecuteCtx, &stmt.Query{},
+		&protoCommonV1.TaskRequest{ParentTaskID: "task-1"},
+		taskServerFactory,
+		&models.Leaf{Receivers: []models.StatelessNode{{HostIP: "1.1.1.1", GRPCPort: 1000}}},
+		testExecPool)
+	qf := queryFlow.(*storageQueryFlow)
+	// cancel returns error to receiver
+	server.EXPECT().Send(gomock.Any()).DoAndReturn(func(resp *protoCommonV1.TaskResponse) error {
+		assert.True(t, resp.Completed)
+		assert.Equal(t, query.ErrQueryCanceled.Error(), resp.ErrMsg)
+		return nil
+	})
+	queryFlow.Cancel()
+	// stops scanning after canceled
+	queryFlow.Load(func() {
+		assert.Fail(t, "scan after canceled")
+	})
+	// stops sending rest chunks after canceled
+	qf.sendResponse([][][]byte{{[]byte{1}, []byte{2}}})
+	time.Sleep(10 * time.Millisecond)
+}
@@ -20,9 +20,11 @@ package storagequery
 import (
 	"fmt"
 
+	"github.com/lindb/lindb/config"
 	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/pkg/encoding"
+	"github.com/lindb/lindb/series"
 	"github.com/lindb/lindb/sql/stmt"
 	"github.com/lindb/lindb/tsdb"
 )
@@ -88,6 +90,8 @@ func (e *metadataStorageExecutor) Execute() (result []string, err error) {
 					constants.ErrTagFilterResultNotFound, req.Namespace, req.MetricName)
 			}
 			groupByTagKeyIDs := []uint32{tagKeyID}
+			// bitmap memory budget is shared by all shards
+			bitmapBudget := series.NewBitmapBudget(uint64(config.GlobalQueryConfig().MaxBitmapMemoryPerQuery))
 			// get shard by given query shard id list
 			for _, shardID := range e.shardIDs {
 				shard, ok := e.database.GetShard(shardID)
@@ -96,7 +100,7 @@ func (e *metadataStorageExecutor) Execute() (result []string, err error) {
 				}
 				// if shard exist, do series search
 				// if get tag filter result do series ids searching
-				seriesSearch := newSeriesSearchFunc(shard.IndexDatabase(), tagFilterResult, req.Condition)
+				seriesSearch := newSeriesSearchFunc(shard.IndexDatabase(), tagFilterResult, req.Condition, bitmapBudget)
 				seriesIDs, err := seriesSearch.Search()
 				if err != nil {
 					return nil, err
@@ -158,7 +158,7 @@ func TestMetadataStorageQuery_Execute_With_Tag_Condition(t *testing.T) {
 	tagSearch.EXPECT().Filter().Return(map[string]*tagFilterResult{"key": {}}, nil).AnyTimes()
 	// case 3: series search err
 	seriesSearch := NewMockSeriesSearch(ctrl)
-	newSeriesSearchFunc = func(filter series.Filter, filterResult map[string]*tagFilterResult, condition stmt.Expr) SeriesSearch {
+	newSeriesSearchFunc = func(filter series.Filter, filterResult map[string]*tagFilterResult, condition stmt.Expr, _ *series.BitmapBudget) SeriesSearch {
 		return seriesSearch
 	}
 	seriesSearch.EXPECT().Search().Return(nil, fmt.Errorf("err"))
@@ -28,9 +28,11 @@ import (
 	"github.com/lindb/lindb/aggregation"
 	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/flow"
+	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/pkg/encoding"
 	"github.com/lindb/lindb/pkg/logger"
 	"github.com/lindb/lindb/pkg/timeutil"
+	protoCommonV1 "github.com/lindb/lindb/proto/gen/v1/common"
 	"github.com/lindb/lindb/series"
 	"github.com/lindb/lindb/series/field"
 	"github.com/lindb/lindb/series/tag"
@@ -168,8 +170,12 @@ func (e *storageExecutor) Execute() {
 	e.queryTimeRange, e.queryIntervalRatio, e.queryInterval = downSamplingTimeRange(
 		e.ctx.query.Interval, interval, e.ctx.query.TimeRange)
 
+	if e.ctx.stats != nil {
+		e.ctx.stats.SetPlan(e.planStats())
+	}
+
 	// prepare storage query flow
-	e.queryFlow.Prepare(e.queryInterval, e.queryIntervalRatio, e.queryTimeRange, plan.getAggregatorSpecs())
+	e.queryFlow.Prepare(e.queryInterval, e.queryIntervalRatio, e.queryTimeRange, plan.getReduceAggregatorSpecs())
 
 	// execute query flow
 	e.executeQuery()
@@ -199,6 +205,11 @@ func (e *storageExecutor) executeQuery() {
 			if seriesIDs.IsEmpty() {
 				return
 			}
+			// reject broad query before data scanning
+			if err := e.ctx.checkSeriesLimit(); err != nil {
+				e.queryFlow.Complete(err)
+				return
+			}
 
 			rs := newTimeSpanResultSet()
 			// 2. filter data each data family in shard
@@ -213,6 +224,10 @@ func (e *storageExecutor) executeQuery() {
 				// data not found
 				return
 			}
+			if e.ctx.query.ExplainPlan {
+				// only explain plan, skip grouping and data scanning
+				return
+			}
 
 			// 3. execute group by
 			e.pendingForGrouping.Inc()
@@ -298,6 +313,7 @@ func (e *storageExecutor) executeGroupBy(shard tsdb.Shard, rs *timeSpanResultSet
 				fieldSeriesList := make([][]*encoding.TSDDecoder, len(e.fields))
 				fieldAggList := make(aggregation.FieldAggregates, len(e.fields))
 				aggSpecs := e.storageExecutePlan.getAggregatorSpecs()
+				downSamplingAggTypes := e.storageExecutePlan.getDownSamplingAggTypes()
 				for idx := range e.fields {
 					fieldSeriesList[idx] = make([]*encoding.TSDDecoder, rs.filterRSCount)
 					fieldAggList[idx] = aggregation.NewSeriesAggregator(
@@ -307,6 +323,8 @@ func (e *storageExecutor) executeGroupBy(shard tsdb.Shard, rs *timeSpanResultSet
 						aggSpecs[idx])
 				}
 
+				// exemplars are only kept in memory of shard, skips looking up if metric has no exemplar
+				hasExemplars := shard.HasExemplars(e.metricID)
 				defer func() {
 					if r := recover(); r != nil {
 						storageQueryFlowLogger.Error("executeGroupBy",
@@ -348,7 +366,7 @@ func (e *storageExecutor) executeGroupBy(shard tsdb.Shard, rs *timeSpanResultSet
 								}
 								aggregation.DownSamplingMultiSeriesInto(
 									target, uint16(e.queryIntervalRatio),
-									e.fields[idx].Type,
+									downSamplingAggTypes[idx],
 									fieldSeries,
 									agg.AggregateBySlot,
 								)
@@ -356,14 +374,59 @@ func (e *storageExecutor) executeGroupBy(shard tsdb.Shard, rs *timeSpanResultSet
 						}
 					}
 					e.queryFlow.Reduce(tags, fieldAggList.ResultSet(tags))
+					if hasExemplars {
+						if exemplars := e.getExemplars(shard, seriesIDHighKey, seriesIDs); len(exemplars) > 0 {
+							e.queryFlow.ReduceExemplars(tags, exemplars)
+						}
+					}
 					// reset aggregate context
 					fieldAggList.Reset()
 				}
+				// sends result of scanned batch if streaming
+				e.queryFlow.Emit()
 			})
 		})
 	}
 }
 
+// getExemplars returns the exemplars of query fields for the grouped series within query time range.
+func (e *storageExecutor) getExemplars(shard tsdb.Shard, seriesIDHighKey uint16, lowSeriesIDs []uint16) (rs []*protoCommonV1.Exemplar) {
+	for _, lowSeriesID := range lowSeriesIDs {
+		seriesID := encoding.ValueWithHighLowBits(uint32(seriesIDHighKey)<<16, lowSeriesID)
+		for _, f := range e.fields {
+			for _, exemplar := range shard.GetExemplars(e.metricID, seriesID, f.ID, e.ctx.query.TimeRange) {
+				rs = append(rs, &protoCommonV1.Exemplar{
+					FieldName: string(f.Name),
+					TraceID:   exemplar.TraceID,
+					SpanID:    exemplar.SpanID,
+					Duration:  exemplar.Duration,
+					Value:     exemplar.Value,
+					Timestamp: exemplar.Timestamp,
+				})
+			}
+		}
+	}
+	return rs
+}
+
+// planStats returns the storage execute plan for explain query
+func (e *storageExecutor) planStats() *models.StoragePlanStats {
+	stats := &models.StoragePlanStats{
+		MetricID:      e.metricID,
+		GroupBy:       e.ctx.query.GroupBy,
+		Interval:      e.queryInterval.Int64(),
+		IntervalRatio: e.queryIntervalRatio,
+		ScanData:      !e.ctx.query.ExplainPlan,
+	}
+	for _, f := range e.fields {
+		stats.Fields = append(stats.Fields, string(f.Name))
+	}
+	if e.ctx.query.Condition != nil {
+		stats.TagFilter = e.ctx.query.Condition.Rewrite()
+	}
+	return stats
+}
+
 // mergeGroupByTagValueIDs merges group by tag value ids for each shard
 func (e *storageExecutor) mergeGroupByTagValueIDs(tagValueIDs []*roaring.Bitmap) {
 	if tagValueIDs == nil {
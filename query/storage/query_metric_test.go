@@ -18,12 +18,14 @@
 package storagequery
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"testing"
 
 	"github.com/golang/mock/gomock"
 	"github.com/lindb/roaring"
+	"github.com/stretchr/testify/assert"
 
 	"github.com/lindb/lindb/aggregation"
 	"github.com/lindb/lindb/constants"
@@ -32,8 +34,11 @@ import (
 	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/pkg/option"
 	"github.com/lindb/lindb/pkg/timeutil"
+	protoCommonV1 "github.com/lindb/lindb/proto/gen/v1/common"
+	"github.com/lindb/lindb/query"
 	"github.com/lindb/lindb/series"
 	"github.com/lindb/lindb/series/field"
+	"github.com/lindb/lindb/series/metric"
 	"github.com/lindb/lindb/series/tag"
 	"github.com/lindb/lindb/sql"
 	"github.com/lindb/lindb/sql/stmt"
@@ -43,6 +48,8 @@ import (
 )
 
 type mockQueryFlow struct {
+	err       error
+	exemplars map[string][]*protoCommonV1.Exemplar
 }
 
 func (m *mockQueryFlow) ReduceTagValues(_ int, _ map[uint32]string) {
@@ -66,7 +73,24 @@ func (m *mockQueryFlow) Load(task concurrent.Task) {
 func (m *mockQueryFlow) Reduce(_ string, _ series.GroupedIterator) {
 }
 
-func (m *mockQueryFlow) Complete(_ error) {
+func (m *mockQueryFlow) ReduceExemplars(tags string, exemplars []*protoCommonV1.Exemplar) {
+	if m.exemplars == nil {
+		m.exemplars = make(map[string][]*protoCommonV1.Exemplar)
+	}
+	m.exemplars[tags] = append(m.exemplars[tags], exemplars...)
+}
+
+func (m *mockQueryFlow) Emit() {
+}
+
+func (m *mockQueryFlow) Complete(err error) {
+	if err != nil && m.err == nil {
+		m.err = err
+	}
+}
+
+func (m *mockQueryFlow) Cancel() {
+	m.Complete(query.ErrQueryCanceled)
 }
 
 func newMockQueryFlow() flow.StorageQueryFlow {
@@ -192,7 +216,7 @@ func TestStorageExecute_Execute(t *testing.T) {
 		"host": {tagValueIDs: roaring.BitmapOf(1, 2)},
 	}, nil).AnyTimes()
 	seriesSearch := NewMockSeriesSearch(ctrl)
-	newSeriesSearchFunc = func(filter series.Filter, filterResult map[string]*tagFilterResult, condition stmt.Expr) SeriesSearch {
+	newSeriesSearchFunc = func(filter series.Filter, filterResult map[string]*tagFilterResult, condition stmt.Expr, _ *series.BitmapBudget) SeriesSearch {
 		return seriesSearch
 	}
 	queryFlow := newMockQueryFlow()
@@ -216,8 +240,8 @@ func TestStorageExecute_Execute(t *testing.T) {
 	mockDatabase.EXPECT().GetShard(models.ShardID(3)).Return(shard, true).AnyTimes()
 	mockDatabase.EXPECT().Metadata().Return(metadata).AnyTimes()
 	metadataIndex.EXPECT().GetMetricID(gomock.Any(), "cpu").Return(uint32(10), nil).AnyTimes()
-	metadataIndex.EXPECT().GetField(gomock.Any(), gomock.Any(), field.Name("f")).
-		Return(field.Meta{ID: 10, Type: field.SumField}, nil).AnyTimes()
+	metadataIndex.EXPECT().GetFields(gomock.Any(), gomock.Any(), field.Name("f")).
+		Return(field.Metas{{ID: 10, Type: field.SumField}}, nil).AnyTimes()
 	shard.EXPECT().IndexDatabase().Return(nil).AnyTimes()
 
 	// case 1: series search err
@@ -308,6 +332,7 @@ func TestStorageExecutor_Execute_GroupBy(t *testing.T) {
 	indexDB := indexdb.NewMockIndexDatabase(ctrl)
 	shard := tsdb.NewMockShard(ctrl)
 	shard.EXPECT().IndexDatabase().Return(indexDB).AnyTimes()
+	shard.EXPECT().HasExemplars(gomock.Any()).Return(false).AnyTimes()
 	rs := flow.NewMockFilterResultSet(ctrl)
 	rs.EXPECT().SlotRange().Return(timeutil.SlotRange{}).AnyTimes()
 	rs.EXPECT().SlotRange().Return(timeutil.SlotRange{}).AnyTimes()
@@ -365,6 +390,26 @@ func TestStorageExecutor_Execute_GroupBy(t *testing.T) {
 	exec1.executeGroupBy(shard, &timeSpanResultSet{spanMap: map[int64]*timeSpan{1: {}}, filterRSCount: 1}, roaring.BitmapOf(1, 2, 3))
 }
 
+func TestStorageExecutor_getExemplars(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	q, _ := sql.Parse("select f from cpu where time>now()-1h")
+	query := q.(*stmt.Query)
+	exec := newStorageMetricQuery(newMockQueryFlow(), nil, newStorageExecuteContext([]models.ShardID{1}, query))
+	exec1 := exec.(*storageExecutor)
+	exec1.metricID = 10
+	exec1.fields = field.Metas{{ID: 1, Name: "f"}, {ID: 2, Name: "g"}}
+
+	shard := tsdb.NewMockShard(ctrl)
+	seriesID := uint32(1<<16 | 5)
+	shard.EXPECT().GetExemplars(uint32(10), seriesID, field.ID(1), query.TimeRange).
+		Return([]metric.Exemplar{{TraceID: []byte{1, 2}, Value: 3, Timestamp: 100}})
+	shard.EXPECT().GetExemplars(uint32(10), seriesID, field.ID(2), query.TimeRange).Return(nil)
+	exemplars := exec1.getExemplars(shard, 1, []uint16{5})
+	assert.Equal(t, []*protoCommonV1.Exemplar{{FieldName: "f", TraceID: []byte{1, 2}, Value: 3, Timestamp: 100}}, exemplars)
+}
+
 func TestStorageExecutor_merge_groupBy_tagValues(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer func() {
@@ -385,3 +430,138 @@ func TestStorageExecutor_merge_groupBy_tagValues(t *testing.T) {
 	// case 3: merge tag value
 	exec1.mergeGroupByTagValueIDs([]*roaring.Bitmap{roaring.BitmapOf(4, 5, 6), roaring.BitmapOf(1, 2, 3), nil})
 }
+
+func TestStorageExecute_ExplainPlan(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		newSeriesSearchFunc = newSeriesSearch
+		newTagSearchFunc = newTagSearch
+		newBuildGroupTaskFunc = newBuildGroupTask
+		ctrl.Finish()
+	}()
+
+	tagSearch := NewMockTagSearch(ctrl)
+	newTagSearchFunc = func(namespace, metricName string, condition stmt.Expr, metadata metadb.Metadata) TagSearch {
+		return tagSearch
+	}
+	tagSearch.EXPECT().Filter().Return(map[string]*tagFilterResult{
+		"host": {tagValueIDs: roaring.BitmapOf(1, 2)},
+	}, nil)
+	seriesSearch := NewMockSeriesSearch(ctrl)
+	newSeriesSearchFunc = func(filter series.Filter, filterResult map[string]*tagFilterResult, condition stmt.Expr, _ *series.BitmapBudget) SeriesSearch {
+		return seriesSearch
+	}
+	newBuildGroupTaskFunc = func(ctx *storageExecuteContext, shard tsdb.Shard, groupingCtx series.GroupingContext,
+		seriesIDHighKey uint16, lowSeriesIDsContainer roaring.Container, result *groupedSeriesResult) flow.QueryTask {
+		panic("explain plan cannot group/scan data")
+	}
+
+	metadata := metadb.NewMockMetadata(ctrl)
+	metadataIndex := metadb.NewMockMetadataDatabase(ctrl)
+	metadata.EXPECT().MetadataDatabase().Return(metadataIndex).AnyTimes()
+	metadataIndex.EXPECT().GetMetricID(gomock.Any(), "cpu").Return(uint32(10), nil)
+	metadataIndex.EXPECT().GetFields(gomock.Any(), gomock.Any(), field.Name("f")).
+		Return(field.Metas{{ID: 10, Type: field.SumField, Name: "f"}}, nil)
+	mockDatabase := tsdb.NewMockDatabase(ctrl)
+	mockDatabase.EXPECT().GetOption().Return(option.DatabaseOption{Interval: "10s"})
+	mockDatabase.EXPECT().NumOfShards().Return(2).AnyTimes()
+	mockDatabase.EXPECT().Metadata().Return(metadata).AnyTimes()
+
+	index := indexdb.NewMockIndexDatabase(ctrl)
+	family := tsdb.NewMockDataFamily(ctrl)
+	filterRS := flow.NewMockFilterResultSet(ctrl)
+	filterRS.EXPECT().Identifier().Return("memory").AnyTimes()
+	filterRS.EXPECT().FamilyTime().Return(int64(10)).AnyTimes()
+	filterRS.EXPECT().SlotRange().Return(timeutil.SlotRange{}).AnyTimes()
+	filterRS.EXPECT().SeriesIDs().Return(roaring.BitmapOf(1, 2)).AnyTimes()
+	family.EXPECT().Interval().Return(timeutil.Interval(10000)).AnyTimes()
+	family.EXPECT().Filter(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return([]flow.FilterResultSet{filterRS}, nil).Times(2)
+	for _, shardID := range []models.ShardID{1, 2} {
+		shard := tsdb.NewMockShard(ctrl)
+		shard.EXPECT().ShardID().Return(shardID).AnyTimes()
+		shard.EXPECT().IndexDatabase().Return(index).AnyTimes()
+		shard.EXPECT().GetDataFamilies(gomock.Any(), gomock.Any()).Return([]tsdb.DataFamily{family})
+		mockDatabase.EXPECT().GetShard(shardID).Return(shard, true)
+	}
+	seriesSearch.EXPECT().Search().Return(roaring.BitmapOf(1, 2, 3), nil).Times(2)
+
+	q, _ := sql.Parse("select f from cpu where host='1.1.1.1' and time>'20190729 11:00:00' and time<'20190729 12:00:00'")
+	query := q.(*stmt.Query)
+	query.Explain = true
+	query.ExplainPlan = true
+	ctx := newStorageExecuteContext([]models.ShardID{1, 2}, query)
+	exec := newStorageMetricQuery(newMockQueryFlow(), mockDatabase, ctx)
+	exec.Execute()
+
+	stats := ctx.QueryStats()
+	assert.Equal(t, &models.StoragePlanStats{
+		MetricID:      10,
+		Fields:        []string{"f"},
+		TagFilter:     "host=1.1.1.1",
+		Interval:      10000,
+		IntervalRatio: 1,
+		ScanData:      false,
+	}, stats.Plan)
+	assert.Len(t, stats.Shards, 2)
+	for _, shardID := range []models.ShardID{1, 2} {
+		assert.Equal(t, uint64(3), stats.Shards[shardID].NumOfSeries)
+		assert.Equal(t, 1, stats.Shards[shardID].NumOfFamilies)
+	}
+}
+
+func TestStorageExecute_TooManySeries(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		newSeriesSearchFunc = newSeriesSearch
+		newTagSearchFunc = newTagSearch
+		ctrl.Finish()
+	}()
+
+	tagSearch := NewMockTagSearch(ctrl)
+	newTagSearchFunc = func(namespace, metricName string, condition stmt.Expr, metadata metadb.Metadata) TagSearch {
+		return tagSearch
+	}
+	tagSearch.EXPECT().Filter().Return(map[string]*tagFilterResult{
+		"host": {tagValueIDs: roaring.BitmapOf(1, 2)},
+	}, nil)
+	seriesSearch := NewMockSeriesSearch(ctrl)
+	newSeriesSearchFunc = func(filter series.Filter, filterResult map[string]*tagFilterResult, condition stmt.Expr, _ *series.BitmapBudget) SeriesSearch {
+		return seriesSearch
+	}
+
+	metadata := metadb.NewMockMetadata(ctrl)
+	metadataIndex := metadb.NewMockMetadataDatabase(ctrl)
+	metadata.EXPECT().MetadataDatabase().Return(metadataIndex).AnyTimes()
+	metadataIndex.EXPECT().GetMetricID(gomock.Any(), "cpu").Return(uint32(10), nil)
+	metadataIndex.EXPECT().GetFields(gomock.Any(), gomock.Any(), field.Name("f")).
+		Return(field.Metas{{ID: 10, Type: field.SumField, Name: "f"}}, nil)
+	mockDatabase := tsdb.NewMockDatabase(ctrl)
+	mockDatabase.EXPECT().GetOption().Return(option.DatabaseOption{Interval: "10s"})
+	mockDatabase.EXPECT().NumOfShards().Return(2).AnyTimes()
+	mockDatabase.EXPECT().Metadata().Return(metadata).AnyTimes()
+
+	index := indexdb.NewMockIndexDatabase(ctrl)
+	for _, shardID := range []models.ShardID{1, 2} {
+		shard := tsdb.NewMockShard(ctrl)
+		shard.EXPECT().ShardID().Return(shardID).AnyTimes()
+		shard.EXPECT().IndexDatabase().Return(index).AnyTimes()
+		// rejected before data scanning
+		shard.EXPECT().GetDataFamilies(gomock.Any(), gomock.Any()).Return(nil).MaxTimes(1)
+		mockDatabase.EXPECT().GetShard(shardID).Return(shard, true)
+	}
+	seriesSearch.EXPECT().Search().Return(roaring.BitmapOf(1, 2, 3), nil).Times(2)
+
+	q, _ := sql.Parse("select f from cpu where host='1.1.1.1' and time>'20190729 11:00:00' and time<'20190729 12:00:00'")
+	ctx := newStorageExecuteContext([]models.ShardID{1, 2}, q.(*stmt.Query))
+	ctx.database = "broad_db"
+	ctx.maxSeries = 4
+	counter := broadQueryRejectedCounterVec.WithTagValues("broad_db")
+	rejected := counter.Get()
+	queryFlow := &mockQueryFlow{}
+	exec := newStorageMetricQuery(queryFlow, mockDatabase, ctx)
+	exec.Execute()
+
+	assert.True(t, errors.Is(queryFlow.err, query.ErrTooManySeries))
+	assert.Equal(t, rejected+1, counter.Get())
+}
@@ -18,6 +18,7 @@
 package storagequery
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -168,7 +169,8 @@ func newSeriesIDsSearchTask(ctx *storageExecuteContext, shard tsdb.Shard, result
 		shard:  shard,
 		result: result,
 	}
-	if ctx.query.Explain {
+	if ctx.query.Explain || ctx.query.Trace {
+		// trace query needs the num. of series of each shard
 		return &queryStatTask{
 			task: task,
 		}
@@ -182,11 +184,17 @@ func (t *seriesIDsSearchTask) Run() (err error) {
 	var seriesIDs *roaring.Bitmap
 	if condition != nil {
 		// if get tag filter result do series ids searching
-		seriesSearch := newSeriesSearchFunc(t.shard.IndexDatabase(), t.ctx.tagFilterResult, t.ctx.query.Condition)
+		seriesSearch := newSeriesSearchFunc(t.shard.IndexDatabase(), t.ctx.tagFilterResult, t.ctx.query.Condition,
+			t.ctx.bitmapBudget)
 		seriesIDs, err = seriesSearch.Search()
 	} else {
 		// get series ids for metric level
 		seriesIDs, err = t.shard.IndexDatabase().GetSeriesIDsForMetric(t.ctx.query.Namespace, t.ctx.query.MetricName)
+		if errors.Is(err, constants.ErrStaleResult) {
+			// metadata unavailable, serve best-effort result based on cached tag keys, marks result stale
+			t.ctx.stale.Store(true)
+			err = nil
+		}
 		if err == nil && !t.ctx.query.HasGroupBy() {
 			// add series id without tags, maybe metric has too many series, but one series without tags
 			seriesIDs.Add(constants.SeriesIDWithoutTags)
@@ -194,6 +202,7 @@ func (t *seriesIDsSearchTask) Run() (err error) {
 	}
 	if err == nil && seriesIDs != nil {
 		t.result.Or(seriesIDs)
+		t.ctx.numOfSeries.Add(seriesIDs.GetCardinality())
 	}
 	return
 }
@@ -261,7 +270,9 @@ func (t *familyFilterTask) Run() error {
 // AfterRun invokes after file data filtering, collects the file data filtering stats
 func (t *familyFilterTask) AfterRun() {
 	t.baseQueryTask.AfterRun()
-	t.ctx.stats.SetShardKVDataFilterCost(t.shard.ShardID(), t.cost)
+	shardID := t.shard.ShardID()
+	t.ctx.stats.SetShardKVDataFilterCost(shardID, t.cost)
+	t.ctx.stats.SetShardNumOfFamilies(shardID, len(t.rs.spanMap))
 }
 
 // groupingContextFindTask represents group by context find task
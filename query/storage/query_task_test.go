@@ -14,7 +14,6 @@
 // KIND, either express or implied.  See the License for the
 // specific language governing permissions and limitations
 // under the License.
-//
 package storagequery
 
 import (
@@ -108,7 +107,8 @@ func TestSeriesIDsSearchTask_Run(t *testing.T) {
 	indexDB := indexdb.NewMockIndexDatabase(ctrl)
 	shard.EXPECT().IndexDatabase().Return(indexDB).AnyTimes()
 	result := roaring.New()
-	task := newSeriesIDsSearchTask(newStorageExecuteContext(nil, &stmt.Query{}), shard, result)
+	ctx := newStorageExecuteContext(nil, &stmt.Query{})
+	task := newSeriesIDsSearchTask(ctx, shard, result)
 	// case 1: search err
 	indexDB.EXPECT().GetSeriesIDsForMetric(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("err"))
 	err := task.Run()
@@ -118,31 +118,40 @@ func TestSeriesIDsSearchTask_Run(t *testing.T) {
 	err = task.Run()
 	assert.NoError(t, err)
 	assert.Equal(t, roaring.BitmapOf(constants.SeriesIDWithoutTags), result)
+	assert.False(t, ctx.IsStale())
 	result.Clear()
-	// case 3: group by tag
+	// case 3: stale result, metadata unavailable
+	indexDB.EXPECT().GetSeriesIDsForMetric(gomock.Any(), gomock.Any()).
+		Return(roaring.BitmapOf(10), fmt.Errorf("%w, metadata err", constants.ErrStaleResult))
+	err = task.Run()
+	assert.NoError(t, err)
+	assert.Equal(t, roaring.BitmapOf(constants.SeriesIDWithoutTags, 10), result)
+	assert.True(t, ctx.IsStale())
+	result.Clear()
+	// case 4: group by tag
 	indexDB.EXPECT().GetSeriesIDsForMetric(gomock.Any(), gomock.Any()).Return(roaring.New(), nil)
 	task = newSeriesIDsSearchTask(newStorageExecuteContext(nil, &stmt.Query{GroupBy: []string{"host"}}), shard, result)
 	err = task.Run()
 	assert.NoError(t, err)
 	assert.Equal(t, uint64(0), result.GetCardinality())
-	// case 4: has condition, return err
+	// case 5: has condition, return err
 	q, _ := sql.Parse("select f from cpu where ip<>'1.1.1.1'")
 	query := q.(*stmt.Query)
 	seriesSearch := NewMockSeriesSearch(ctrl)
-	newSeriesSearchFunc = func(filter series.Filter, filterResult map[string]*tagFilterResult, condition stmt.Expr) SeriesSearch {
+	newSeriesSearchFunc = func(filter series.Filter, filterResult map[string]*tagFilterResult, condition stmt.Expr, _ *series.BitmapBudget) SeriesSearch {
 		return seriesSearch
 	}
 	seriesSearch.EXPECT().Search().Return(nil, fmt.Errorf("err"))
 	task = newSeriesIDsSearchTask(newStorageExecuteContext(nil, query), shard, result)
 	err = task.Run()
 	assert.Error(t, err)
-	// case 5: has condition, return series ids
+	// case 6: has condition, return series ids
 	seriesSearch.EXPECT().Search().Return(roaring.BitmapOf(1, 2, 3), nil)
 	err = task.Run()
 	assert.NoError(t, err)
 	assert.Equal(t, roaring.BitmapOf(1, 2, 3), result)
 	result.Clear()
-	// case 6: explain
+	// case 7: explain
 	q, _ = sql.Parse("explain select f from cpu where ip<>'1.1.1.1'")
 	query = q.(*stmt.Query)
 	seriesSearch.EXPECT().Search().Return(roaring.BitmapOf(1, 2, 3), nil)
@@ -151,6 +160,17 @@ func TestSeriesIDsSearchTask_Run(t *testing.T) {
 	err = task.Run()
 	assert.NoError(t, err)
 	assert.Equal(t, roaring.BitmapOf(1, 2, 3), result)
+	result.Clear()
+	// case 8: trace, collects num. of series of shard
+	query.Explain = false
+	query.Trace = true
+	seriesSearch.EXPECT().Search().Return(roaring.BitmapOf(1, 2), nil)
+	shard.EXPECT().ShardID().Return(models.ShardID(10))
+	ctx = newStorageExecuteContext(nil, query)
+	task = newSeriesIDsSearchTask(ctx, shard, result)
+	err = task.Run()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), ctx.QueryStats().Shards[10].NumOfSeries)
 }
 
 func TestFileDataFilterTask_Run(t *testing.T) {
@@ -190,14 +210,16 @@ func TestFileDataFilterTask_Run(t *testing.T) {
 	resultSet.EXPECT().FamilyTime().Return(int64(10))
 	resultSet.EXPECT().SeriesIDs().Return(roaring.New())
 	resultSet.EXPECT().FamilyTime().Return(int64(10)).MaxTimes(2)
-	task = newFamilyFilterTask(newStorageExecuteContext(nil, &stmt.Query{Explain: true}),
-		shard, 1, field.Metas{{ID: 10}}, seriesIDs, rs)
+	ctx := newStorageExecuteContext(nil, &stmt.Query{Explain: true})
+	ctx.stats.SetShardSeriesIDsSearchStats(10, 1, 0)
+	task = newFamilyFilterTask(ctx, shard, 1, field.Metas{{ID: 10}}, seriesIDs, rs)
 	family.EXPECT().Filter(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
 		Return([]flow.FilterResultSet{resultSet}, nil)
 	shard.EXPECT().ShardID().Return(models.ShardID(10))
 	err = task.Run()
 	assert.NoError(t, err)
 	assert.False(t, rs.isEmpty())
+	assert.Equal(t, 1, ctx.QueryStats().Shards[10].NumOfFamilies)
 }
 
 func TestGroupingContextFindTask_Run(t *testing.T) {
@@ -0,0 +1,45 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package storagequery
+
+import (
+	"fmt"
+
+	"github.com/lindb/lindb/internal/linmetric"
+	"github.com/lindb/lindb/query"
+)
+
+var broadQueryRejectedCounterVec = linmetric.NewScope("lindb.storage.query").
+	NewCounterVec("broad_queries_rejected", "db")
+
+// checkSeriesLimit checks the num. of series resolved so far against max series per query,
+// returns error before scanning if the limit is exceeded and query doesn't opt into large scan.
+func (ctx *storageExecuteContext) checkSeriesLimit() error {
+	if ctx.maxSeries <= 0 || ctx.query.AllowLargeScan {
+		return nil
+	}
+	numOfSeries := ctx.numOfSeries.Load()
+	if numOfSeries <= uint64(ctx.maxSeries) {
+		return nil
+	}
+	if ctx.seriesLimitExceeded.CAS(false, true) {
+		// count rejected query once, even if limit exceeded in multi-shards
+		broadQueryRejectedCounterVec.WithTagValues(ctx.database).Incr()
+	}
+	return fmt.Errorf("%w: %d > %d, please use a tighter tag filter or query with largeScan=true",
+		query.ErrTooManySeries, numOfSeries, ctx.maxSeries)
+}
@@ -0,0 +1,58 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package storagequery
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/query"
+	"github.com/lindb/lindb/sql"
+	"github.com/lindb/lindb/sql/stmt"
+)
+
+func TestStorageExecuteContext_checkSeriesLimit(t *testing.T) {
+	q, _ := sql.Parse("select f from cpu where host='1.1.1.1' and time>'20190729 11:00:00' and time<'20190729 12:00:00'")
+	newCtx := func(maxSeries int, allowLargeScan bool) *storageExecuteContext {
+		stmtQuery := *q.(*stmt.Query)
+		stmtQuery.AllowLargeScan = allowLargeScan
+		ctx := newStorageExecuteContext([]models.ShardID{1, 2}, &stmtQuery)
+		ctx.database = "limit_db"
+		ctx.maxSeries = maxSeries
+		ctx.numOfSeries.Add(100)
+		return ctx
+	}
+	counter := broadQueryRejectedCounterVec.WithTagValues("limit_db")
+	rejected := counter.Get()
+	// case 1: no limit
+	assert.NoError(t, newCtx(0, false).checkSeriesLimit())
+	// case 2: under limit
+	assert.NoError(t, newCtx(100, false).checkSeriesLimit())
+	// case 3: allow large scan
+	assert.NoError(t, newCtx(10, true).checkSeriesLimit())
+	assert.Equal(t, rejected, counter.Get())
+	// case 4: over limit, count rejected query once
+	ctx := newCtx(10, false)
+	err := ctx.checkSeriesLimit()
+	assert.True(t, errors.Is(err, query.ErrTooManySeries))
+	assert.Contains(t, err.Error(), "largeScan=true")
+	assert.Error(t, ctx.checkSeriesLimit())
+	assert.Equal(t, rejected+1, counter.Get())
+}
@@ -18,15 +18,23 @@
 package storagequery
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/lindb/roaring"
 
 	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/internal/linmetric"
 	"github.com/lindb/lindb/series"
 	"github.com/lindb/lindb/sql/stmt"
 )
 
+var (
+	seriesSearchScope           = linmetric.NewScope("lindb.storage.query.series_search")
+	peakBitmapMemoryMax         = seriesSearchScope.NewMax("peak_bitmap_memory")
+	bitmapMemoryExceededCounter = seriesSearchScope.NewCounter("bitmap_memory_exceeded")
+)
+
 //go:generate mockgen -source ./series_search.go -destination=./series_search_mock.go -package=storagequery
 
 // SeriesSearch represents a series search by condition expression
@@ -44,36 +52,52 @@ type seriesSearch struct {
 
 	filter series.Filter
 
+	budget *series.BitmapBudget // bitmap memory budget shared by all shards of query
+	memory uint64               // memory of intermediate bitmaps held currently
+
 	err error
 }
 
-// newSeriesSearch creates a a series search using query condition
-func newSeriesSearch(filter series.Filter, filterResult map[string]*tagFilterResult, condition stmt.Expr) SeriesSearch {
+// newSeriesSearch creates a a series search using query condition,
+// the intermediate bitmaps are accounted by budget which is shared by all shards of query.
+func newSeriesSearch(
+	filter series.Filter,
+	filterResult map[string]*tagFilterResult,
+	condition stmt.Expr,
+	budget *series.BitmapBudget,
+) SeriesSearch {
 	return &seriesSearch{
 		filterResult: filterResult,
 		filter:       filter,
 		condition:    condition,
+		budget:       budget,
 	}
 }
 
 // Search searches series ids base on condition, if search fail return nil, else return series ids
 func (s *seriesSearch) Search() (*roaring.Bitmap, error) {
 	_, seriesIDs := s.findSeriesIDsByExpr(s.condition)
+	// intermediate bitmaps are released after search completed
+	s.budget.Release(s.memory)
+	peakBitmapMemoryMax.Update(float64(s.budget.Peak()))
 	if s.err != nil {
+		if errors.Is(s.err, series.ErrTooLargeBitmapMemory) {
+			bitmapMemoryExceededCounter.Incr()
+		}
 		return nil, s.err
 	}
 	return seriesIDs, nil
 }
 
-// findSeriesIDsByExpr finds series ids by expr, recursion filter for expr
-func (s *seriesSearch) findSeriesIDsByExpr(condition stmt.Expr) (uint32, *roaring.Bitmap) {
-
+// findSeriesIDsByExpr finds series ids by expr, recursion filter for expr,
+// returns the tag key ids which expr references and the series ids matched.
+func (s *seriesSearch) findSeriesIDsByExpr(condition stmt.Expr) ([]uint32, *roaring.Bitmap) {
 	if condition == nil {
-		return 0, roaring.New() // create a empty series ids for parent expr
+		return nil, roaring.New() // create a empty series ids for parent expr
 	}
 
 	if s.err != nil {
-		return 0, roaring.New() // create a empty series ids for parent expr
+		return nil, roaring.New() // create a empty series ids for parent expr
 	}
 
 	switch expr := condition.(type) {
@@ -81,37 +105,96 @@ func (s *seriesSearch) findSeriesIDsByExpr(condition stmt.Expr) (uint32, *roarin
 		tagKey, seriesIDs, err := s.getSeriesIDsByExpr(expr)
 		if err != nil {
 			s.err = err
-			return tagKey, roaring.New() // create a empty series ids for parent expr
+			return nil, roaring.New() // create a empty series ids for parent expr
 		}
-		return tagKey, seriesIDs
+		// series ids are accounted by index when union tag values
+		s.memory += seriesIDs.GetSizeInBytes()
+		return []uint32{tagKey}, seriesIDs
 	case *stmt.ParenExpr:
 		return s.findSeriesIDsByExpr(expr.Expr)
 	case *stmt.NotExpr:
-
 		// get filter series ids
-		tagKey, matchResult := s.findSeriesIDsByExpr(expr.Expr)
+		tagKeys, matchResult := s.findSeriesIDsByExpr(expr.Expr)
+		if s.err != nil {
+			return nil, roaring.New() // create a empty series ids for parent expr
+		}
 
-		// get all series ids for tag key
-		all, err := s.filter.GetSeriesIDsForTag(tagKey)
+		// get all series ids for tag keys which not expr references,
+		// e.g. not (a=1 or b=2) => (series of a or series of b) and not (a=1 or b=2)
+		all, err := s.getSeriesIDsForTags(tagKeys)
 		if err != nil {
 			s.err = err
-			return tagKey, roaring.New() // create a empty series ids for parent expr
+			return nil, roaring.New() // create a empty series ids for parent expr
 		}
 
+		s.allocBitmap(all)
+		operandsSize := all.GetSizeInBytes() + matchResult.GetSizeInBytes()
 		// do and not got series ids not in 'a' list
 		all.AndNot(matchResult)
-		return 0, all
+		s.replaceBitmap(operandsSize, all)
+		return tagKeys, all
 	case *stmt.BinaryExpr:
-		_, left := s.findSeriesIDsByExpr(expr.Left)
-		_, right := s.findSeriesIDsByExpr(expr.Right)
+		leftTagKeys, left := s.findSeriesIDsByExpr(expr.Left)
+		rightTagKeys, right := s.findSeriesIDsByExpr(expr.Right)
+		operandsSize := left.GetSizeInBytes() + right.GetSizeInBytes()
 		if expr.Operator == stmt.AND {
 			left.And(right)
 		} else {
 			left.Or(right)
 		}
-		return 0, left
+		s.replaceBitmap(operandsSize, left)
+		return mergeTagKeys(leftTagKeys, rightTagKeys), left
+	}
+	return nil, roaring.New() // create a empty series ids for parent expr
+}
+
+// allocBitmap accounts the memory of bitmap materialized, aborts the search if exceeds max bitmap memory.
+func (s *seriesSearch) allocBitmap(bitmap *roaring.Bitmap) {
+	size := bitmap.GetSizeInBytes()
+	s.memory += size
+	if err := s.budget.Alloc(size); err != nil && s.err == nil {
+		s.err = err
+	}
+}
+
+// replaceBitmap releases the memory of operands, then accounts the result of bitmap operation.
+func (s *seriesSearch) replaceBitmap(operandsSize uint64, result *roaring.Bitmap) {
+	if operandsSize > s.memory {
+		operandsSize = s.memory
+	}
+	s.memory -= operandsSize
+	s.budget.Release(operandsSize)
+	s.allocBitmap(result)
+}
+
+// getSeriesIDsForTags returns the union of series ids for tag keys
+func (s *seriesSearch) getSeriesIDsForTags(tagKeys []uint32) (*roaring.Bitmap, error) {
+	all := roaring.New()
+	for _, tagKey := range tagKeys {
+		seriesIDs, err := s.filter.GetSeriesIDsForTag(tagKey)
+		if err != nil {
+			return nil, err
+		}
+		all.Or(seriesIDs)
+	}
+	return all, nil
+}
+
+// mergeTagKeys merges the right tag key ids into left, skips duplicate tag key id
+func mergeTagKeys(left, right []uint32) []uint32 {
+	for _, tagKey := range right {
+		exist := false
+		for _, leftTagKey := range left {
+			if leftTagKey == tagKey {
+				exist = true
+				break
+			}
+		}
+		if !exist {
+			left = append(left, tagKey)
+		}
 	}
-	return 0, roaring.New() // create a empty series ids for parent expr
+	return left
 }
 
 // getTagKeyID returns the tag key id by tag key
@@ -122,7 +205,7 @@ func (s *seriesSearch) getSeriesIDsByExpr(expr stmt.Expr) (uint32, *roaring.Bitm
 		return 0, nil, fmt.Errorf("%w, expr: %s", constants.ErrTagValueFilterResultNotFound, expr.Rewrite())
 	}
 
-	seriesIDs, err := s.filter.GetSeriesIDsByTagValueIDs(tagValues.tagKey, tagValues.tagValueIDs)
+	seriesIDs, err := s.filter.GetSeriesIDsByTagValueIDs(tagValues.tagKey, tagValues.tagValueIDs, s.budget)
 	if err != nil {
 		return 0, nil, err
 	}
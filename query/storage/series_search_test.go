@@ -18,6 +18,7 @@
 package storagequery
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 
@@ -39,24 +40,24 @@ func TestSeriesSearch_Search(t *testing.T) {
 	// case 1: empty filter expr
 	q, _ := sql.Parse("select f from cpu")
 	query := q.(*stmt.Query)
-	search := newSeriesSearch(mockFilter, nil, query.Condition)
+	search := newSeriesSearch(mockFilter, nil, query.Condition, nil)
 	resultSet, err := search.Search()
 	assert.NoError(t, err)
 	assert.Equal(t, uint64(0), resultSet.GetCardinality())
 	// case 2: equal tag filter
 	q, _ = sql.Parse("select f from cpu where ip='1.1.1.1'")
 	query = q.(*stmt.Query)
-	mockFilter.EXPECT().GetSeriesIDsByTagValueIDs(uint32(1), gomock.Any()).Return(seriesIDs.Clone(), nil)
-	search = newSeriesSearch(mockFilter, mockFilterResult(), query.Condition)
+	mockFilter.EXPECT().GetSeriesIDsByTagValueIDs(uint32(1), gomock.Any(), gomock.Any()).Return(seriesIDs.Clone(), nil)
+	search = newSeriesSearch(mockFilter, mockFilterResult(), query.Condition, nil)
 	resultSet, err = search.Search()
 	assert.NoError(t, err)
 	assert.Equal(t, seriesIDs, resultSet)
 	// case 3: not expr
 	q, _ = sql.Parse("select f from cpu where ip!='1.1.1.1'")
 	query = q.(*stmt.Query)
-	mockFilter.EXPECT().GetSeriesIDsByTagValueIDs(uint32(1), gomock.Any()).Return(seriesIDs.Clone(), nil)
+	mockFilter.EXPECT().GetSeriesIDsByTagValueIDs(uint32(1), gomock.Any(), gomock.Any()).Return(seriesIDs.Clone(), nil)
 	mockFilter.EXPECT().GetSeriesIDsForTag(uint32(1)).Return(roaring.BitmapOf(10, 20, 40, 50), nil)
-	search = newSeriesSearch(mockFilter, mockFilterResult(), query.Condition)
+	search = newSeriesSearch(mockFilter, mockFilterResult(), query.Condition, nil)
 	resultSet, err = search.Search()
 	assert.NoError(t, err)
 	assert.Equal(t, roaring.BitmapOf(40, 50), resultSet)
@@ -64,9 +65,9 @@ func TestSeriesSearch_Search(t *testing.T) {
 	q, _ = sql.Parse("select f from cpu " +
 		"where ip='1.1.1.1' and path='/data' and time>'20190410 00:00:00' and time<'20190410 10:00:00'")
 	query = q.(*stmt.Query)
-	mockFilter.EXPECT().GetSeriesIDsByTagValueIDs(uint32(1), gomock.Any()).Return(seriesIDs.Clone(), nil)
-	mockFilter.EXPECT().GetSeriesIDsByTagValueIDs(uint32(2), gomock.Any()).Return(roaring.BitmapOf(20), nil)
-	search = newSeriesSearch(mockFilter, mockFilterResult(), query.Condition)
+	mockFilter.EXPECT().GetSeriesIDsByTagValueIDs(uint32(1), gomock.Any(), gomock.Any()).Return(seriesIDs.Clone(), nil)
+	mockFilter.EXPECT().GetSeriesIDsByTagValueIDs(uint32(2), gomock.Any(), gomock.Any()).Return(roaring.BitmapOf(20), nil)
+	search = newSeriesSearch(mockFilter, mockFilterResult(), query.Condition, nil)
 	resultSet, err = search.Search()
 	assert.NoError(t, err)
 	assert.Equal(t, roaring.BitmapOf(20), resultSet)
@@ -74,17 +75,17 @@ func TestSeriesSearch_Search(t *testing.T) {
 	q, _ = sql.Parse("select f from cpu " +
 		"where ip='1.1.1.1' or path='/data' and time>'20190410 00:00:00' and time<'20190410 10:00:00'")
 	query = q.(*stmt.Query)
-	mockFilter.EXPECT().GetSeriesIDsByTagValueIDs(uint32(1), gomock.Any()).Return(seriesIDs.Clone(), nil)
-	mockFilter.EXPECT().GetSeriesIDsByTagValueIDs(uint32(2), gomock.Any()).Return(roaring.BitmapOf(200), nil)
-	search = newSeriesSearch(mockFilter, mockFilterResult(), query.Condition)
+	mockFilter.EXPECT().GetSeriesIDsByTagValueIDs(uint32(1), gomock.Any(), gomock.Any()).Return(seriesIDs.Clone(), nil)
+	mockFilter.EXPECT().GetSeriesIDsByTagValueIDs(uint32(2), gomock.Any(), gomock.Any()).Return(roaring.BitmapOf(200), nil)
+	search = newSeriesSearch(mockFilter, mockFilterResult(), query.Condition, nil)
 	resultSet, err = search.Search()
 	assert.NoError(t, err)
 	assert.Equal(t, roaring.BitmapOf(10, 20, 30, 200), resultSet)
 	// case 6: paren expr
 	q, _ = sql.Parse("select f from cpu where (ip='1.1.1.1')")
 	query = q.(*stmt.Query)
-	mockFilter.EXPECT().GetSeriesIDsByTagValueIDs(uint32(1), gomock.Any()).Return(seriesIDs.Clone(), nil)
-	search = newSeriesSearch(mockFilter, mockFilterResult(), query.Condition)
+	mockFilter.EXPECT().GetSeriesIDsByTagValueIDs(uint32(1), gomock.Any(), gomock.Any()).Return(seriesIDs.Clone(), nil)
+	search = newSeriesSearch(mockFilter, mockFilterResult(), query.Condition, nil)
 	resultSet, err = search.Search()
 	assert.NoError(t, err)
 	assert.Equal(t, seriesIDs, resultSet)
@@ -99,30 +100,30 @@ func TestSeriesSearch_Search_err(t *testing.T) {
 	// case 1: expr not exist
 	q, _ := sql.Parse("select f from cpu where ip='1.1.1.1'")
 	query := q.(*stmt.Query)
-	search := newSeriesSearch(mockFilter, make(map[string]*tagFilterResult), query.Condition)
+	search := newSeriesSearch(mockFilter, make(map[string]*tagFilterResult), query.Condition, nil)
 	resultSet, err := search.Search()
 	assert.Error(t, err)
 	assert.Nil(t, resultSet)
 	// case 2: get series id err
-	search = newSeriesSearch(mockFilter, mockFilterResult(), query.Condition)
-	mockFilter.EXPECT().GetSeriesIDsByTagValueIDs(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("err"))
+	search = newSeriesSearch(mockFilter, mockFilterResult(), query.Condition, nil)
+	mockFilter.EXPECT().GetSeriesIDsByTagValueIDs(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("err"))
 	resultSet, err = search.Search()
 	assert.Error(t, err)
 	assert.Nil(t, resultSet)
 	// case 3: not expr err
 	q, _ = sql.Parse("select f from cpu where ip!='1.1.1.1'")
 	query = q.(*stmt.Query)
-	mockFilter.EXPECT().GetSeriesIDsByTagValueIDs(uint32(1), gomock.Any()).Return(seriesIDs, nil)
+	mockFilter.EXPECT().GetSeriesIDsByTagValueIDs(uint32(1), gomock.Any(), gomock.Any()).Return(seriesIDs, nil)
 	mockFilter.EXPECT().GetSeriesIDsForTag(uint32(1)).Return(nil, fmt.Errorf("err"))
-	search = newSeriesSearch(mockFilter, mockFilterResult(), query.Condition)
+	search = newSeriesSearch(mockFilter, mockFilterResult(), query.Condition, nil)
 	resultSet, err = search.Search()
 	assert.Error(t, err)
 	assert.Nil(t, resultSet)
 	// case 4: recursion err
 	q, _ = sql.Parse("select f from cpu where ip='1.1.1.1' or ip='1.1.1.1'")
 	query = q.(*stmt.Query)
-	mockFilter.EXPECT().GetSeriesIDsByTagValueIDs(uint32(1), gomock.Any()).Return(nil, fmt.Errorf("err"))
-	search = newSeriesSearch(mockFilter, mockFilterResult(), query.Condition)
+	mockFilter.EXPECT().GetSeriesIDsByTagValueIDs(uint32(1), gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("err"))
+	search = newSeriesSearch(mockFilter, mockFilterResult(), query.Condition, nil)
 	resultSet, err = search.Search()
 	assert.Error(t, err)
 	assert.Nil(t, resultSet)
@@ -136,7 +137,7 @@ func TestSeriesSearch_Search_expr_not_match(t *testing.T) {
 	q, _ := sql.Parse("select f from cpu where ip='1.1.1.1'")
 	query := q.(*stmt.Query)
 	query.Condition = &stmt.CallExpr{}
-	search := newSeriesSearch(mockFilter, make(map[string]*tagFilterResult), query.Condition)
+	search := newSeriesSearch(mockFilter, make(map[string]*tagFilterResult), query.Condition, nil)
 	resultSet, err := search.Search()
 	assert.NoError(t, err)
 	assert.Equal(t, uint64(0), resultSet.GetCardinality())
@@ -150,23 +151,143 @@ func TestSeriesSearch_Search_complex(t *testing.T) {
 	q, _ := sql.Parse("select f from cpu" +
 		" where (ip not in ('1.1.1.1','2.2.2.2') and region='sh') and (path='/data' or path='/home')")
 	query := q.(*stmt.Query)
-	mockFilter.EXPECT().GetSeriesIDsByTagValueIDs(uint32(1), roaring.BitmapOf(5)).Return(roaring.BitmapOf(1, 2), nil)
+	mockFilter.EXPECT().GetSeriesIDsByTagValueIDs(uint32(1), roaring.BitmapOf(5), gomock.Any()).Return(roaring.BitmapOf(1, 2), nil)
 	mockFilter.EXPECT().GetSeriesIDsForTag(uint32(1)).Return(roaring.BitmapOf(1, 2, 3, 4, 5, 6, 7), nil)
-	mockFilter.EXPECT().GetSeriesIDsByTagValueIDs(uint32(3), roaring.BitmapOf(4)).Return(roaring.BitmapOf(3, 5, 6, 7), nil)
-	mockFilter.EXPECT().GetSeriesIDsByTagValueIDs(uint32(2), roaring.BitmapOf(2)).Return(roaring.BitmapOf(7), nil)
-	mockFilter.EXPECT().GetSeriesIDsByTagValueIDs(uint32(2), roaring.BitmapOf(3)).Return(roaring.BitmapOf(5), nil)
-	search := newSeriesSearch(mockFilter, mockFilterResult(), query.Condition)
+	mockFilter.EXPECT().GetSeriesIDsByTagValueIDs(uint32(3), roaring.BitmapOf(4), gomock.Any()).Return(roaring.BitmapOf(3, 5, 6, 7), nil)
+	mockFilter.EXPECT().GetSeriesIDsByTagValueIDs(uint32(2), roaring.BitmapOf(2), gomock.Any()).Return(roaring.BitmapOf(7), nil)
+	mockFilter.EXPECT().GetSeriesIDsByTagValueIDs(uint32(2), roaring.BitmapOf(3), gomock.Any()).Return(roaring.BitmapOf(5), nil)
+	search := newSeriesSearch(mockFilter, mockFilterResult(), query.Condition, nil)
 	resultSet, err := search.Search()
 	assert.NoError(t, err)
 	assert.Equal(t, roaring.BitmapOf(5, 7), resultSet)
 }
 
+func TestSeriesSearch_Search_set_operations(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockFilter := series.NewMockFilter(ctrl)
+
+	ip1 := &stmt.EqualsExpr{Key: "ip", Value: "1.1.1.1"}
+	pathData := &stmt.EqualsExpr{Key: "path", Value: "/data"}
+	regionSH := &stmt.EqualsExpr{Key: "region", Value: "sh"}
+	// not (a=1 or b=3)
+	notOr := &stmt.NotExpr{Expr: &stmt.ParenExpr{Expr: &stmt.BinaryExpr{Left: ip1, Operator: stmt.OR, Right: pathData}}}
+
+	// case 1: (a=1 or a=2) and not b=3
+	q, _ := sql.Parse("select f from cpu where (ip='1.1.1.1' or ip='2.2.2.2') and path<>'/data'")
+	query := q.(*stmt.Query)
+	mockFilter.EXPECT().GetSeriesIDsByTagValueIDs(uint32(1), roaring.BitmapOf(1), gomock.Any()).Return(roaring.BitmapOf(1, 2), nil)
+	mockFilter.EXPECT().GetSeriesIDsByTagValueIDs(uint32(1), roaring.BitmapOf(6), gomock.Any()).Return(roaring.BitmapOf(3, 4), nil)
+	mockFilter.EXPECT().GetSeriesIDsByTagValueIDs(uint32(2), roaring.BitmapOf(2), gomock.Any()).Return(roaring.BitmapOf(2, 3), nil)
+	mockFilter.EXPECT().GetSeriesIDsForTag(uint32(2)).Return(roaring.BitmapOf(1, 2, 3, 4, 5), nil)
+	search := newSeriesSearch(mockFilter, mockFilterResult(), query.Condition, nil)
+	resultSet, err := search.Search()
+	assert.NoError(t, err)
+	assert.Equal(t, roaring.BitmapOf(1, 4), resultSet)
+	// case 2: not (a=1 or b=3), complement of series which has tag a or b
+	mockFilter.EXPECT().GetSeriesIDsByTagValueIDs(uint32(1), roaring.BitmapOf(1), gomock.Any()).Return(roaring.BitmapOf(1, 2), nil)
+	mockFilter.EXPECT().GetSeriesIDsByTagValueIDs(uint32(2), roaring.BitmapOf(2), gomock.Any()).Return(roaring.BitmapOf(2, 3), nil)
+	mockFilter.EXPECT().GetSeriesIDsForTag(uint32(1)).Return(roaring.BitmapOf(1, 2, 4), nil)
+	mockFilter.EXPECT().GetSeriesIDsForTag(uint32(2)).Return(roaring.BitmapOf(2, 3, 5), nil)
+	search = newSeriesSearch(mockFilter, mockFilterResult(), notOr, nil)
+	resultSet, err = search.Search()
+	assert.NoError(t, err)
+	assert.Equal(t, roaring.BitmapOf(4, 5), resultSet)
+	// case 3: nested not, not (not a=1 and region=sh)
+	mockFilter.EXPECT().GetSeriesIDsByTagValueIDs(uint32(1), roaring.BitmapOf(1), gomock.Any()).Return(roaring.BitmapOf(1, 2), nil)
+	mockFilter.EXPECT().GetSeriesIDsForTag(uint32(1)).Return(roaring.BitmapOf(1, 2, 3, 4), nil)
+	mockFilter.EXPECT().GetSeriesIDsByTagValueIDs(uint32(3), roaring.BitmapOf(4), gomock.Any()).Return(roaring.BitmapOf(2, 3, 5), nil)
+	mockFilter.EXPECT().GetSeriesIDsForTag(uint32(1)).Return(roaring.BitmapOf(1, 2, 3, 4), nil)
+	mockFilter.EXPECT().GetSeriesIDsForTag(uint32(3)).Return(roaring.BitmapOf(2, 3, 5, 6), nil)
+	search = newSeriesSearch(mockFilter, mockFilterResult(),
+		&stmt.NotExpr{Expr: &stmt.ParenExpr{Expr: &stmt.BinaryExpr{
+			Left: &stmt.NotExpr{Expr: ip1}, Operator: stmt.AND, Right: regionSH,
+		}}}, nil)
+	resultSet, err = search.Search()
+	assert.NoError(t, err)
+	assert.Equal(t, roaring.BitmapOf(1, 2, 4, 5, 6), resultSet)
+	// case 4: get series ids for tag err
+	mockFilter.EXPECT().GetSeriesIDsByTagValueIDs(uint32(1), roaring.BitmapOf(1), gomock.Any()).Return(roaring.BitmapOf(1, 2), nil)
+	mockFilter.EXPECT().GetSeriesIDsByTagValueIDs(uint32(2), roaring.BitmapOf(2), gomock.Any()).Return(roaring.BitmapOf(2, 3), nil)
+	mockFilter.EXPECT().GetSeriesIDsForTag(uint32(1)).Return(roaring.BitmapOf(1, 2, 4), nil)
+	mockFilter.EXPECT().GetSeriesIDsForTag(uint32(2)).Return(nil, fmt.Errorf("err"))
+	search = newSeriesSearch(mockFilter, mockFilterResult(), notOr, nil)
+	resultSet, err = search.Search()
+	assert.Error(t, err)
+	assert.Nil(t, resultSet)
+	// case 5: inner expr err
+	mockFilter.EXPECT().GetSeriesIDsByTagValueIDs(uint32(1), roaring.BitmapOf(1), gomock.Any()).Return(nil, fmt.Errorf("err"))
+	search = newSeriesSearch(mockFilter, mockFilterResult(), notOr, nil)
+	resultSet, err = search.Search()
+	assert.Error(t, err)
+	assert.Nil(t, resultSet)
+}
+
+func TestSeriesSearch_Search_bitmap_memory_limit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockFilter := series.NewMockFilter(ctrl)
+	newSeriesIDs := func(start uint32) *roaring.Bitmap {
+		seriesIDs := roaring.New()
+		for i := start; i < 100000; i += 2 {
+			seriesIDs.Add(i)
+		}
+		return seriesIDs
+	}
+	// index accounts series ids of tag values in budget
+	mockGetSeriesIDs := func(tagValueIDs *roaring.Bitmap, start uint32) {
+		mockFilter.EXPECT().GetSeriesIDsByTagValueIDs(uint32(1), tagValueIDs, gomock.Any()).
+			DoAndReturn(func(_ uint32, _ *roaring.Bitmap, budget *series.BitmapBudget) (*roaring.Bitmap, error) {
+				seriesIDs := newSeriesIDs(start)
+				if err := budget.Alloc(seriesIDs.GetSizeInBytes()); err != nil {
+					return nil, err
+				}
+				return seriesIDs, nil
+			})
+	}
+	bitmapSize := newSeriesIDs(0).GetSizeInBytes()
+	q, _ := sql.Parse("select f from cpu where ip='1.1.1.1' or ip='2.2.2.2'")
+	queryStmt := q.(*stmt.Query)
+
+	// case 1: bitmap memory exceeds limit
+	budget := series.NewBitmapBudget(bitmapSize + bitmapSize/2)
+	mockGetSeriesIDs(roaring.BitmapOf(1), 0)
+	mockGetSeriesIDs(roaring.BitmapOf(6), 1)
+	search := newSeriesSearch(mockFilter, mockFilterResult(), queryStmt.Condition, budget)
+	resultSet, err := search.Search()
+	assert.True(t, errors.Is(err, series.ErrTooLargeBitmapMemory))
+	assert.Nil(t, resultSet)
+	assert.GreaterOrEqual(t, peakBitmapMemoryMax.Get(), float64(2*bitmapSize))
+	// case 2: bitmap memory under limit, intermediate bitmaps released after search
+	budget = series.NewBitmapBudget(4 * bitmapSize)
+	mockGetSeriesIDs(roaring.BitmapOf(1), 0)
+	mockGetSeriesIDs(roaring.BitmapOf(6), 1)
+	search = newSeriesSearch(mockFilter, mockFilterResult(), queryStmt.Condition, budget)
+	resultSet, err = search.Search()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(100000), resultSet.GetCardinality())
+	assert.NoError(t, budget.Alloc(4*bitmapSize))
+	// case 3: budget is shared by all shards of query
+	budget = series.NewBitmapBudget(4 * bitmapSize)
+	assert.NoError(t, budget.Alloc(3*bitmapSize)) // held by other shard
+	mockGetSeriesIDs(roaring.BitmapOf(1), 0)
+	mockGetSeriesIDs(roaring.BitmapOf(6), 1)
+	search = newSeriesSearch(mockFilter, mockFilterResult(), queryStmt.Condition, budget)
+	resultSet, err = search.Search()
+	assert.True(t, errors.Is(err, series.ErrTooLargeBitmapMemory))
+	assert.Nil(t, resultSet)
+}
+
 func mockFilterResult() map[string]*tagFilterResult {
 	result := make(map[string]*tagFilterResult)
 	result[(&stmt.EqualsExpr{Key: "ip", Value: "1.1.1.1"}).Rewrite()] = &tagFilterResult{
 		tagKey:      1,
 		tagValueIDs: roaring.BitmapOf(1),
 	}
+	result[(&stmt.EqualsExpr{Key: "ip", Value: "2.2.2.2"}).Rewrite()] = &tagFilterResult{
+		tagKey:      1,
+		tagValueIDs: roaring.BitmapOf(6),
+	}
 	result[(&stmt.EqualsExpr{Key: "path", Value: "/data"}).Rewrite()] = &tagFilterResult{
 		tagKey:      2,
 		tagValueIDs: roaring.BitmapOf(2),
@@ -252,3 +373,61 @@ func mockFilterResult() map[string]*tagFilterResult {
 //	assert.NotNil(t, err)
 //}
 //
+
+// BenchmarkSeriesSearch_selective compares resolving series ids on broker then shipping the bitmaps
+// with pushing the tag filter down to storage node which resolves series ids via local index.
+func BenchmarkSeriesSearch_selective(b *testing.B) {
+	ctrl := gomock.NewController(b)
+	defer ctrl.Finish()
+
+	ipSeriesIDs := roaring.New()
+	ipSeriesIDs.AddRange(0, 200000)
+	pathSeriesIDs := roaring.New()
+	for i := uint32(0); i < 400000; i += 1000 {
+		pathSeriesIDs.Add(i)
+	}
+	mockFilter := series.NewMockFilter(ctrl)
+	mockFilter.EXPECT().GetSeriesIDsByTagValueIDs(uint32(1), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ uint32, _ *roaring.Bitmap) (*roaring.Bitmap, error) {
+			return ipSeriesIDs.Clone(), nil
+		}).AnyTimes()
+	mockFilter.EXPECT().GetSeriesIDsByTagValueIDs(uint32(2), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ uint32, _ *roaring.Bitmap) (*roaring.Bitmap, error) {
+			return pathSeriesIDs.Clone(), nil
+		}).AnyTimes()
+	q, _ := sql.Parse("select f from cpu where ip='1.1.1.1' and path='/data'")
+	query := q.(*stmt.Query)
+
+	b.Run("bitmap-shipping", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			// storage node ships series ids of each tag filter, broker resolves final series ids
+			seriesIDs := roaring.New()
+			for idx, tagKeyID := range []uint32{1, 2} {
+				rs, _ := mockFilter.GetSeriesIDsByTagValueIDs(tagKeyID, nil, nil)
+				data, _ := rs.ToBytes()
+				shipped := roaring.New()
+				_ = shipped.UnmarshalBinary(data)
+				if idx == 0 {
+					seriesIDs = shipped
+				} else {
+					seriesIDs.And(shipped)
+				}
+			}
+			// broker ships final series ids to leaf task
+			data, _ := seriesIDs.ToBytes()
+			shipped := roaring.New()
+			_ = shipped.UnmarshalBinary(data)
+		}
+	})
+	b.Run("filter-pushdown", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			// broker ships tag filter expr, leaf task resolves series ids via local index
+			data, _ := query.MarshalJSON()
+			shipped := &stmt.Query{}
+			_ = shipped.UnmarshalJSON(data)
+			_, _ = newSeriesSearch(mockFilter, mockFilterResult(), shipped.Condition, nil).Search()
+		}
+	})
+}
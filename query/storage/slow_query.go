@@ -0,0 +1,63 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package storagequery
+
+import (
+	"time"
+
+	"github.com/lindb/lindb/internal/linmetric"
+	"github.com/lindb/lindb/pkg/logger"
+	"github.com/lindb/lindb/pkg/timeutil"
+)
+
+// for testing
+var (
+	slowQueryLogger = logger.GetLogger("query", "SlowQuery")
+	logSlowQuery    = slowQueryLogger.Warn
+)
+
+var slowQueryCounterVec = linmetric.NewScope("lindb.storage.query").NewCounterVec("slow_queries", "db")
+
+// Completed logs the query with filter, time range and series count if it costs more than slow query threshold.
+func (ctx *storageExecuteContext) Completed() {
+	if ctx.onCompleted != nil {
+		ctx.onCompleted()
+	}
+	if ctx.slowQueryThreshold <= 0 {
+		return
+	}
+	cost := time.Since(ctx.start)
+	if cost < ctx.slowQueryThreshold {
+		return
+	}
+	slowQueryCounterVec.WithTagValues(ctx.database).Incr()
+	filter := ""
+	if ctx.query.Condition != nil {
+		filter = ctx.query.Condition.Rewrite()
+	}
+	logSlowQuery("slow query",
+		logger.String("db", ctx.database),
+		logger.String("namespace", ctx.query.Namespace),
+		logger.String("metric", ctx.query.MetricName),
+		logger.String("filter", filter),
+		logger.String("start", timeutil.FormatTimestamp(ctx.query.TimeRange.Start, timeutil.DataTimeFormat2)),
+		logger.String("end", timeutil.FormatTimestamp(ctx.query.TimeRange.End, timeutil.DataTimeFormat2)),
+		logger.Any("shards", ctx.shardIDs),
+		logger.Int64("series", int64(ctx.numOfSeries.Load())),
+		logger.String("cost", cost.String()))
+}
@@ -0,0 +1,78 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package storagequery
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/sql"
+	"github.com/lindb/lindb/sql/stmt"
+)
+
+func TestStorageExecuteContext_Completed(t *testing.T) {
+	var logs []map[string]interface{}
+	logSlowQuery = func(msg string, fields ...zap.Field) {
+		log := map[string]interface{}{"msg": msg}
+		for _, f := range fields {
+			if f.String != "" {
+				log[f.Key] = f.String
+			} else {
+				log[f.Key] = f.Integer
+			}
+		}
+		logs = append(logs, log)
+	}
+	defer func() {
+		logSlowQuery = slowQueryLogger.Warn
+	}()
+
+	q, _ := sql.Parse("select f from cpu where host='1.1.1.1' and time>'20190729 11:00:00' and time<'20190729 12:00:00'")
+	newCtx := func(threshold, cost time.Duration) *storageExecuteContext {
+		ctx := newStorageExecuteContext([]models.ShardID{1, 2}, q.(*stmt.Query))
+		ctx.database = "slow_db"
+		ctx.slowQueryThreshold = threshold
+		// inject slow scan
+		ctx.start = time.Now().Add(-cost)
+		ctx.numOfSeries.Add(100)
+		return ctx
+	}
+	counter := slowQueryCounterVec.WithTagValues("slow_db")
+	slowQueries := counter.Get()
+	// case 1: slow query log disabled
+	newCtx(0, time.Hour).Completed()
+	// case 2: fast query
+	newCtx(time.Second, time.Millisecond).Completed()
+	assert.Empty(t, logs)
+	assert.Equal(t, slowQueries, counter.Get())
+	// case 3: slow query
+	newCtx(time.Second, 2*time.Second).Completed()
+	assert.Equal(t, slowQueries+1, counter.Get())
+	assert.Len(t, logs, 1)
+	assert.Equal(t, "slow query", logs[0]["msg"])
+	assert.Equal(t, "slow_db", logs[0]["db"])
+	assert.Equal(t, "cpu", logs[0]["metric"])
+	assert.Equal(t, "host=1.1.1.1", logs[0]["filter"])
+	assert.Equal(t, "2019-07-29 11:00:00", logs[0]["start"])
+	assert.Equal(t, "2019-07-29 12:00:00", logs[0]["end"])
+	assert.Equal(t, int64(100), logs[0]["series"])
+}
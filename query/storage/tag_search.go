@@ -22,6 +22,8 @@ import (
 
 	"github.com/lindb/roaring"
 
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/sql/stmt"
 	"github.com/lindb/lindb/tsdb/metadb"
 )
@@ -83,6 +85,10 @@ func (s *tagSearch) findTagValueIDsByExpr(expr stmt.Expr) {
 	}
 	switch expr := expr.(type) {
 	case stmt.TagFilter:
+		if _, ok := expr.(*stmt.NumericCompareExpr); ok && !config.GlobalQueryConfig().IsNumericTagKey(expr.TagKey()) {
+			s.err = fmt.Errorf("%w, tagKey: %s", constants.ErrTagKeyNotNumeric, expr.TagKey())
+			return
+		}
 		tagKeyID, err := s.getTagKeyID(expr.TagKey())
 		if err != nil {
 			s.err = err
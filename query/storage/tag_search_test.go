@@ -18,6 +18,7 @@
 package storagequery
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 
@@ -25,6 +26,8 @@ import (
 	"github.com/lindb/roaring"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/sql"
 	"github.com/lindb/lindb/sql/stmt"
 	"github.com/lindb/lindb/tsdb/metadb"
@@ -178,3 +181,32 @@ func TestTagSearch_Filter_Complex(t *testing.T) {
 	assert.Equal(t, tagValueIDs, resultSet[(&stmt.EqualsExpr{Key: "path", Value: "/data"}).Rewrite()].tagValueIDs)
 	assert.Equal(t, tagValueIDs, resultSet[(&stmt.EqualsExpr{Key: "path", Value: "/home"}).Rewrite()].tagValueIDs)
 }
+
+func TestTagSearch_Filter_NumericCompare(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		config.SetGlobalQueryConfig(config.NewDefaultQuery())
+		ctrl.Finish()
+	}()
+
+	tagMeta := metadb.NewMockTagMetadata(ctrl)
+	metadata := metadb.NewMockMetadata(ctrl)
+	metadataDB := metadb.NewMockMetadataDatabase(ctrl)
+	metadataDB.EXPECT().GetTagKeyID(gomock.Any(), gomock.Any(), gomock.Any()).Return(uint32(1), nil).AnyTimes()
+	metadata.EXPECT().TagMetadata().Return(tagMeta).AnyTimes()
+	metadata.EXPECT().MetadataDatabase().Return(metadataDB).AnyTimes()
+	condition := &stmt.NumericCompareExpr{Key: "port", Operator: stmt.GreaterThanOrEqual, Value: 8000}
+
+	// case 1: tag key not declared numeric
+	search := newTagSearch("ns", "cpu", condition, metadata)
+	resultSet, err := search.Filter()
+	assert.True(t, errors.Is(err, constants.ErrTagKeyNotNumeric))
+	assert.Nil(t, resultSet)
+	// case 2: tag key declared numeric
+	config.SetGlobalQueryConfig(&config.Query{NumericTagKeys: []string{"port"}})
+	tagMeta.EXPECT().FindTagValueDsByExpr(gomock.Any(), condition).Return(roaring.BitmapOf(1, 2), nil)
+	search = newTagSearch("ns", "cpu", condition, metadata)
+	resultSet, err = search.Filter()
+	assert.NoError(t, err)
+	assert.Equal(t, roaring.BitmapOf(1, 2), resultSet[condition.Rewrite()].tagValueIDs)
+}
@@ -0,0 +1,98 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package storagequery
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/query"
+	"github.com/lindb/lindb/tsdb"
+)
+
+// for testing
+var (
+	writeVisibleCheckInterval = 10 * time.Millisecond
+)
+
+// waitWriteVisible waits until the data written with write token is visible(written into memory database)
+// in the queried shards of current node(read-your-writes), returns ErrWriteNotVisible if not visible in time.
+func waitWriteVisible(ctx context.Context, db tsdb.Database, shardIDs []models.ShardID, writeToken string) error {
+	token, err := models.DecodeWriteToken(writeToken)
+	if err != nil {
+		return err
+	}
+	// only waits the write sequences of shards queried in current node
+	var pending models.WriteToken
+	for _, seq := range token {
+		for _, shardID := range shardIDs {
+			if seq.ShardID == shardID {
+				pending = append(pending, seq)
+				break
+			}
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+	ticker := time.NewTicker(writeVisibleCheckInterval)
+	defer ticker.Stop()
+	timer := time.NewTimer(config.GlobalQueryConfig().WriteVisibleTimeout.Duration())
+	defer timer.Stop()
+
+	for {
+		notVisible := pending[:0]
+		for _, seq := range pending {
+			if !isWriteVisible(db, seq) {
+				notVisible = append(notVisible, seq)
+			}
+		}
+		pending = notVisible
+		if len(pending) == 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %s", query.ErrWriteNotVisible, ctx.Err())
+		case <-timer.C:
+			return fmt.Errorf("%w, shard: %d, sequence: %d",
+				query.ErrWriteNotVisible, pending[0].ShardID, pending[0].Sequence)
+		case <-ticker.C:
+		}
+	}
+}
+
+// isWriteVisible checks if the write sequence is committed by the family of shard.
+func isWriteVisible(db tsdb.Database, seq models.WriteSequence) bool {
+	shard, ok := db.GetShard(seq.ShardID)
+	if !ok {
+		return false
+	}
+	families := shard.GetDataFamilies(shard.CurrentInterval().Type(),
+		timeutil.TimeRange{Start: seq.FamilyTime, End: seq.FamilyTime})
+	for _, family := range families {
+		if family.FamilyTime() == seq.FamilyTime {
+			return family.IsSequenceCommitted(int32(seq.Leader), seq.Sequence)
+		}
+	}
+	return false
+}
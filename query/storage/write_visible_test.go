@@ -0,0 +1,92 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package storagequery
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/atomic"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/ltoml"
+	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/query"
+	"github.com/lindb/lindb/tsdb"
+)
+
+func TestWaitWriteVisible(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defaultCfg := config.GlobalQueryConfig()
+	defer func() {
+		writeVisibleCheckInterval = 10 * time.Millisecond
+		config.SetGlobalQueryConfig(defaultCfg)
+		ctrl.Finish()
+	}()
+	writeVisibleCheckInterval = time.Millisecond
+	cfg := *defaultCfg
+	cfg.WriteVisibleTimeout = ltoml.Duration(100 * time.Millisecond)
+	config.SetGlobalQueryConfig(&cfg)
+
+	db := tsdb.NewMockDatabase(ctrl)
+	shard := tsdb.NewMockShard(ctrl)
+	family := tsdb.NewMockDataFamily(ctrl)
+	shard.EXPECT().CurrentInterval().Return(timeutil.Interval(timeutil.OneSecond * 10)).AnyTimes()
+	family.EXPECT().FamilyTime().Return(int64(100)).AnyTimes()
+	// local replicator commits the written sequence after writing into memory database
+	committed := atomic.NewInt64(0)
+	family.EXPECT().IsSequenceCommitted(int32(1), gomock.Any()).DoAndReturn(func(_ int32, seq int64) bool {
+		return seq <= committed.Load()
+	}).AnyTimes()
+
+	token := models.WriteToken{{ShardID: 1, FamilyTime: 100, Leader: 1, Sequence: 10}}.Encode()
+
+	// case 1: bad token
+	assert.Error(t, waitWriteVisible(context.TODO(), db, []models.ShardID{1}, "bad!"))
+	// case 2: shard not queried in current node
+	assert.NoError(t, waitWriteVisible(context.TODO(), db, []models.ShardID{2}, token))
+	// case 3: shard not found
+	db.EXPECT().GetShard(models.ShardID(1)).Return(nil, false).AnyTimes()
+	err := waitWriteVisible(context.TODO(), db, []models.ShardID{1}, token)
+	assert.True(t, errors.Is(err, query.ErrWriteNotVisible))
+
+	db = tsdb.NewMockDatabase(ctrl)
+	db.EXPECT().GetShard(models.ShardID(1)).Return(shard, true).AnyTimes()
+	// case 4: family not found
+	shard.EXPECT().GetDataFamilies(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+	shard.EXPECT().GetDataFamilies(gomock.Any(), gomock.Any()).Return([]tsdb.DataFamily{family}).AnyTimes()
+	// case 5: write visible after waiting
+	time.AfterFunc(20*time.Millisecond, func() { committed.Store(10) })
+	start := time.Now()
+	assert.NoError(t, waitWriteVisible(context.TODO(), db, []models.ShardID{1, 2}, token))
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+	// case 6: write not visible in time
+	token = models.WriteToken{{ShardID: 1, FamilyTime: 100, Leader: 1, Sequence: 11}}.Encode()
+	err = waitWriteVisible(context.TODO(), db, []models.ShardID{1}, token)
+	assert.True(t, errors.Is(err, query.ErrWriteNotVisible))
+	// case 7: query canceled
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	err = waitWriteVisible(ctx, db, []models.ShardID{1}, token)
+	assert.True(t, errors.Is(err, query.ErrWriteNotVisible))
+}
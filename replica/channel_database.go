@@ -24,6 +24,7 @@ import (
 
 	"go.uber.org/atomic"
 
+	"github.com/lindb/lindb/config"
 	"github.com/lindb/lindb/internal/linmetric"
 	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/pkg/logger"
@@ -39,6 +40,9 @@ var (
 	createChannel        = newChannel
 	databaseChannelScope = linmetric.NewScope("lindb.replica.database")
 	evictedCounterVec    = databaseChannelScope.NewCounterVec("metrics_out_of_time_range", "db")
+	backfillCounterVec   = databaseChannelScope.NewCounterVec("backfill_metrics", "db")
+	backfillEvictedVec   = databaseChannelScope.NewCounterVec("backfill_out_of_time_range", "db")
+	dedupCounterVec      = databaseChannelScope.NewCounterVec("metrics_deduplicated", "db")
 )
 
 // DatabaseChannel represents the database level replication channel
@@ -66,10 +70,14 @@ type (
 		numOfShard    atomic.Int32
 		shardChannels shardChannels
 		interval      timeutil.Interval
+		sampler       *sampler // nil if no sampling policy
 		logger        *logger.Logger
 
 		statistics struct {
-			evictedCounter *linmetric.BoundCounter
+			evictedCounter         *linmetric.BoundCounter
+			backfillCounter        *linmetric.BoundCounter
+			backfillEvictedCounter *linmetric.BoundCounter
+			dedupCounter           *linmetric.BoundCounter
 		}
 	}
 )
@@ -87,6 +95,7 @@ func newDatabaseChannel(
 		ctx:         c,
 		cancel:      cancel,
 		fct:         fct,
+		sampler:     newSampler(databaseCfg.Name, config.GlobalBrokerConfig().Ingestion.Sampling),
 		logger:      logger.GetLogger("replica", "DatabaseChannel"),
 	}
 	ch.shardChannels.value.Store(make(shard2Channel))
@@ -99,6 +108,9 @@ func newDatabaseChannel(
 
 	ch.numOfShard.Store(numOfShard)
 	ch.statistics.evictedCounter = evictedCounterVec.WithTagValues(databaseCfg.Name)
+	ch.statistics.backfillCounter = backfillCounterVec.WithTagValues(databaseCfg.Name)
+	ch.statistics.backfillEvictedCounter = backfillEvictedVec.WithTagValues(databaseCfg.Name)
+	ch.statistics.dedupCounter = dedupCounterVec.WithTagValues(databaseCfg.Name)
 
 	// start family channel garbage collect
 	ch.garbageCollectTask()
@@ -131,17 +143,37 @@ func (dc *databaseChannel) garbageCollect() {
 	for _, channel := range channels {
 		channel.garbageCollect(ahead, behind)
 	}
+	if dc.sampler != nil {
+		dc.sampler.garbageCollect()
+	}
 }
 
-// Write writes the metric data into channel's buffer
+// Write writes the metric data into channel's buffer, rows of other shards/families are still written
+// if writing fails, returns the first error.
 func (dc *databaseChannel) Write(ctx context.Context, brokerBatchRows *metric.BrokerBatchRows) error {
 	var err error
 
 	behind := dc.behind.Load()
 	ahead := dc.ahead.Load()
 
-	evicted := brokerBatchRows.EvictOutOfTimeRange(behind, ahead)
-	dc.statistics.evictedCounter.Add(float64(evicted))
+	if brokerBatchRows.IsBackfill() {
+		// backfill bypasses database's behind range, but cannot write data older than max backfill age
+		behind = config.GlobalBrokerConfig().Ingestion.MaxBackfillAge.Duration().Milliseconds()
+		evicted := brokerBatchRows.EvictOutOfTimeRange(behind, ahead)
+		dc.statistics.backfillEvictedCounter.Add(float64(evicted))
+		dc.statistics.backfillCounter.Add(float64(brokerBatchRows.Len() - evicted))
+	} else {
+		evicted := brokerBatchRows.EvictOutOfTimeRange(behind, ahead)
+		dc.statistics.evictedCounter.Add(float64(evicted))
+	}
+	// drop duplicate metrics within the batch, such as written twice by client
+	if dropped := brokerBatchRows.Dedup(config.GlobalBrokerConfig().Ingestion.DedupPolicy); dropped > 0 {
+		dc.statistics.dedupCounter.Add(float64(dropped))
+	}
+	if dc.sampler != nil {
+		// subsampling high-frequency metrics before writing into channel
+		dc.sampler.Sample(brokerBatchRows)
+	}
 
 	// sharding metrics to shards
 	shardingIterator := brokerBatchRows.NewShardGroupIterator(dc.numOfShard.Load())
@@ -150,7 +182,9 @@ func (dc *databaseChannel) Write(ctx context.Context, brokerBatchRows *metric.Br
 		shardID := models.ShardID(shardIdx)
 		channel, ok := dc.getChannelByShardID(shardID)
 		if !ok {
-			err = errChannelNotFound
+			if err == nil {
+				err = errChannelNotFound
+			}
 			// broker error, do not return to client
 			dc.logger.Error("shardChannel not found",
 				logger.String("database", dc.databaseCfg.Name),
@@ -160,14 +194,21 @@ func (dc *databaseChannel) Write(ctx context.Context, brokerBatchRows *metric.Br
 		for familyIterator.HasNextFamily() {
 			familyTime, rows := familyIterator.NextFamily()
 			familyChannel := channel.GetOrCreateFamilyChannel(familyTime)
-			if err = familyChannel.Write(ctx, rows); err != nil {
+			token, writeErr := familyChannel.Write(ctx, rows, brokerBatchRows.AckLevel(), brokerBatchRows.AcceptedAt())
+			if writeErr != nil {
+				if err == nil {
+					err = writeErr
+				}
 				dc.logger.Error("failed writing rows to family channel",
 					logger.String("database", dc.databaseCfg.Name),
 					logger.Int("shardID", shardID.Int()),
 					logger.Int("rows", len(rows)),
 					logger.Int64("familyTime", familyTime),
-					logger.Error(err))
+					logger.Error(writeErr))
+				continue
 			}
+			// collects write sequences acked by storage for read-your-writes
+			brokerBatchRows.AddWriteToken(token)
 		}
 	}
 	//TODO if need return nil?
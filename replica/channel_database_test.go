@@ -26,6 +26,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/option"
 	"github.com/lindb/lindb/pkg/timeutil"
 	protoMetricsV1 "github.com/lindb/lindb/proto/gen/v1/metrics"
 	"github.com/lindb/lindb/series/metric"
@@ -57,7 +58,7 @@ func TestDatabaseChannel_Write(t *testing.T) {
 	ch1 := ch.(*databaseChannel)
 	ch1.insertShardChannel(models.ShardID(0), shardCh)
 	familyChannel := NewMockFamilyChannel(ctrl)
-	familyChannel.EXPECT().Write(gomock.Any(), gomock.Any()).Return(fmt.Errorf("err"))
+	familyChannel.EXPECT().Write(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("err"))
 	shardCh.EXPECT().GetOrCreateFamilyChannel(gomock.Any()).Return(familyChannel).AnyTimes()
 
 	batch = metric.NewBrokerBatchRows()
@@ -74,6 +75,117 @@ func TestDatabaseChannel_Write(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestDatabaseChannel_Write_FirstErr(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ch, err := newDatabaseChannel(context.TODO(), models.Database{
+		Name:   "database",
+		Option: option.DatabaseOption{Interval: "10s", Behind: "1d", Ahead: "1h"},
+	}, 1, nil)
+	assert.NoError(t, err)
+	shardCh := NewMockChannel(ctrl)
+	ch.(*databaseChannel).insertShardChannel(models.ShardID(0), shardCh)
+	familyChannel := NewMockFamilyChannel(ctrl)
+	shardCh.EXPECT().GetOrCreateFamilyChannel(gomock.Any()).Return(familyChannel).AnyTimes()
+	// rows of first family fail, rows of second family are still written
+	gomock.InOrder(
+		familyChannel.EXPECT().Write(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("err")),
+		familyChannel.EXPECT().Write(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil),
+	)
+
+	converter := metric.NewProtoConverter()
+	batch := metric.NewBrokerBatchRows()
+	now := timeutil.Now()
+	for _, timestamp := range []int64{now, now - 3*timeutil.OneHour} {
+		_ = batch.TryAppend(func(row *metric.BrokerRow) error {
+			return converter.ConvertTo(&protoMetricsV1.Metric{
+				Name:      "cpu",
+				Timestamp: timestamp,
+				SimpleFields: []*protoMetricsV1.SimpleField{
+					{Name: "f1", Type: protoMetricsV1.SimpleFieldType_DELTA_SUM, Value: 1}},
+			}, row)
+		})
+	}
+	assert.EqualError(t, ch.Write(context.TODO(), batch), "err")
+}
+
+func TestDatabaseChannel_Write_backfill(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ch, err := newDatabaseChannel(context.TODO(), models.Database{
+		Name:   "database",
+		Option: option.DatabaseOption{Interval: "10s", Behind: "1h", Ahead: "1h"},
+	}, 1, nil)
+	assert.NoError(t, err)
+	ch1 := ch.(*databaseChannel)
+	shardCh := NewMockChannel(ctrl)
+	ch1.insertShardChannel(models.ShardID(0), shardCh)
+
+	now := timeutil.Now()
+	oldTimestamp := now - 10*timeutil.OneDay
+	newBatch := func(backfill bool, timestamps ...int64) *metric.BrokerBatchRows {
+		converter := metric.NewProtoConverter()
+		converter.SetBackfill(backfill)
+		batch := metric.NewBrokerBatchRows()
+		batch.SetBackfill(backfill)
+		for _, timestamp := range timestamps {
+			_ = batch.TryAppend(func(row *metric.BrokerRow) error {
+				return converter.ConvertTo(&protoMetricsV1.Metric{
+					Name:      "cpu",
+					Timestamp: timestamp,
+					SimpleFields: []*protoMetricsV1.SimpleField{
+						{Name: "f1", Type: protoMetricsV1.SimpleFieldType_DELTA_SUM, Value: 1}},
+					Tags: []*protoMetricsV1.KeyValue{{Key: "host", Value: "1.1.1.1"}},
+				}, row)
+			})
+		}
+		return batch
+	}
+	// case 1: normal ingest keeps the guard
+	evicted := ch1.statistics.evictedCounter.Get()
+	familyChannel := NewMockFamilyChannel(ctrl)
+	shardCh.EXPECT().GetOrCreateFamilyChannel(gomock.Any()).Return(familyChannel)
+	familyChannel.EXPECT().Write(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, rows []metric.BrokerRow, _ string, acceptedAt int64) (models.WriteToken, error) {
+			assert.Len(t, rows, 1)
+			assert.True(t, rows[0].IsOutOfTimeRange)
+			assert.Equal(t, int64(100), acceptedAt)
+			return models.WriteToken{{ShardID: 1, FamilyTime: 10, Leader: 1, Sequence: 5}}, nil
+		})
+	batch := newBatch(false, oldTimestamp)
+	batch.SetAcceptedAt(100)
+	err = ch.Write(context.TODO(), batch)
+	assert.NoError(t, err)
+	// collects write token acked by storage
+	assert.Equal(t, models.WriteToken{{ShardID: 1, FamilyTime: 10, Leader: 1, Sequence: 5}}, batch.WriteToken())
+	assert.Equal(t, evicted+1, ch1.statistics.evictedCounter.Get())
+
+	// case 2: backfill into old family, rows older than max backfill age are evicted
+	backfill := ch1.statistics.backfillCounter.Get()
+	backfillEvicted := ch1.statistics.backfillEvictedCounter.Get()
+	var familyTimes []int64
+	shardCh.EXPECT().GetOrCreateFamilyChannel(gomock.Any()).DoAndReturn(func(familyTime int64) FamilyChannel {
+		familyTimes = append(familyTimes, familyTime)
+		return familyChannel
+	}).Times(2)
+	var outOfRange []bool
+	familyChannel.EXPECT().Write(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, rows []metric.BrokerRow, _ string, _ int64) (models.WriteToken, error) {
+			for _, row := range rows {
+				outOfRange = append(outOfRange, row.IsOutOfTimeRange)
+			}
+			return nil, nil
+		}).Times(2)
+	err = ch.Write(context.TODO(), newBatch(true, oldTimestamp, now-60*timeutil.OneDay))
+	assert.NoError(t, err)
+	assert.Contains(t, familyTimes, oldTimestamp-oldTimestamp%timeutil.OneHour)
+	assert.ElementsMatch(t, []bool{false, true}, outOfRange)
+	assert.Equal(t, backfill+1, ch1.statistics.backfillCounter.Get())
+	assert.Equal(t, backfillEvicted+1, ch1.statistics.backfillEvictedCounter.Get())
+}
+
 func TestDatabaseChannel_CreateChannel(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
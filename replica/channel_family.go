@@ -19,14 +19,18 @@ package replica
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io"
 	"sync"
 	"time"
 
 	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/pkg/fasttime"
 	"github.com/lindb/lindb/pkg/logger"
+	protoWriteV1 "github.com/lindb/lindb/proto/gen/v1/write"
 	"github.com/lindb/lindb/rpc"
 	"github.com/lindb/lindb/series/metric"
 )
@@ -34,10 +38,12 @@ import (
 //go:generate mockgen -source=./channel_family.go -destination=./channel_family_mock.go -package=replica
 
 type FamilyChannel interface {
-	// Write writes the data into the channel,
+	// Write writes the data into the channel, waits storage acks the data based on ack level,
+	// empty ack level means using the ack level of database.
+	// Returns the write token of acked data for read-your-writes, nil if ack level is async.
 	// ErrCanceled is returned when the channel is canceled before data is written successfully.
 	// Concurrent safe.
-	Write(ctx context.Context, rows []metric.BrokerRow) error
+	Write(ctx context.Context, rows []metric.BrokerRow, ackLevel string, acceptedAt int64) (models.WriteToken, error)
 	// leaderChanged notifies family channel need change leader send stream
 	leaderChanged(shardState models.ShardState,
 		liveNodes map[models.NodeID]models.StatefulNode)
@@ -47,6 +53,30 @@ type FamilyChannel interface {
 	isExpire(ahead, behind int64) bool
 }
 
+// writeBatch represents the compressed chunk waiting for sending to storage.
+type writeBatch struct {
+	compressed *compressedChunk
+	acceptedAt int64 // time(unix nano) when broker accepts the first row of chunk
+	sync       bool  // if storage flushes wal to disk before responding
+	quorum     bool  // if storage waits majority replicas ack the chunk before responding
+	// ack notifies the writer waiting for storage response, nil if ack level is async
+	ack func(ack writeAck)
+}
+
+// writeAck represents the storage response of write batch.
+type writeAck struct {
+	leader models.NodeID // leader which writes the batch into wal
+	seq    int64         // wal sequence of the batch
+	err    error
+}
+
+// notify notifies the writer waiting for the batch acked if need.
+func (b *writeBatch) notify(ack writeAck) {
+	if b.ack != nil {
+		b.ack(ack)
+	}
+}
+
 type familyChannel struct {
 	// context to close channel
 	ctx        context.Context
@@ -59,6 +89,7 @@ type familyChannel struct {
 		ctx context.Context,
 		target models.Node,
 		database string, shardState *models.ShardState, familyTime int64,
+		streamWrite bool,
 		fct rpc.ClientStreamFactory,
 	) (rpc.WriteStream, error)
 
@@ -68,13 +99,20 @@ type familyChannel struct {
 	currentTarget models.Node
 
 	// channel to convert multiple goroutine writeTask to single goroutine writeTask to FanOutQueue
-	ch                  chan *compressedChunk
+	ch                  chan *writeBatch
 	leaderChangedSignal chan struct{}
 	chunk               Chunk // buffer current writeTask metric for compress
+	chunkAcceptedAt     int64 // time(unix nano) when the first row of current chunk is accepted
 
 	lastFlushTime      time.Time     // last flush time
 	checkFlushInterval time.Duration // interval for check flush
 	batchTimout        time.Duration // interval for flush
+	ackLevel           string        // default write ack level of database
+	streamWrite        bool          // if writes to storage by stream write with flow control
+
+	// batches rejected by storage because writes of shard are paused, re-sent by write task later
+	rejected      []*writeBatch
+	lock4rejected sync.Mutex
 
 	lock4write sync.Mutex
 	lock4meta  sync.Mutex
@@ -102,10 +140,12 @@ func newFamilyChannel(
 		shardState:          shardState,
 		liveNodes:           liveNodes,
 		newWriteStreamFn:    rpc.NewWriteStream,
-		ch:                  make(chan *compressedChunk, 2),
+		ch:                  make(chan *writeBatch, 2),
 		leaderChangedSignal: make(chan struct{}),
 		checkFlushInterval:  time.Second,
 		batchTimout:         cfg.BatchTimeout.Duration(),
+		ackLevel:            cfg.AckLevel,
+		streamWrite:         cfg.StreamWrite,
 		chunk:               newChunk(cfg.BatchBlockSize),
 		lastFlushTime:       time.Now(),
 		logger:              logger.GetLogger("replica", "FamilyChannel"),
@@ -117,21 +157,92 @@ func newFamilyChannel(
 
 // Write writes the data into the channel, ErrCanceled is returned when the ctx is canceled before
 // data is written successfully.
+// If ack level is leader or quorum, flushes the chunk immediately and waits storage acks the data.
+// acceptedAt is the time(unix nano) when ingestion accepts the request, current time is used if not set.
 // Concurrent safe.
-func (fc *familyChannel) Write(ctx context.Context, rows []metric.BrokerRow) error {
+func (fc *familyChannel) Write(
+	ctx context.Context,
+	rows []metric.BrokerRow,
+	ackLevel string,
+	acceptedAt int64,
+) (models.WriteToken, error) {
+	if ackLevel == "" {
+		ackLevel = fc.ackLevel
+	}
+	if acceptedAt <= 0 {
+		acceptedAt = time.Now().UnixNano()
+	}
+	acks, err := fc.write(ctx, rows, ackLevel, acceptedAt)
+	if err != nil {
+		return nil, err
+	}
+	// waits without write lock, so that other writers are not blocked
+	return fc.waitAcks(ctx, acks)
+}
+
+// write writes the data into chunk, returns the ack channels of flushed chunks if need wait ack.
+func (fc *familyChannel) write(
+	ctx context.Context,
+	rows []metric.BrokerRow,
+	ackLevel string,
+	acceptedAt int64,
+) ([]<-chan writeAck, error) {
 	fc.lock4write.Lock()
 	defer fc.lock4write.Unlock()
 
+	var acks []<-chan writeAck
 	for idx := 0; idx < len(rows); idx++ {
+		if fc.chunkAcceptedAt == 0 {
+			fc.chunkAcceptedAt = acceptedAt
+		}
 		if _, err := rows[idx].WriteTo(fc.chunk); err != nil {
-			return err
+			return nil, err
 		}
 
-		if err := fc.flushChunkOnFull(ctx); err != nil {
-			return err
+		if !fc.chunk.IsFull() {
+			continue
+		}
+		ack, err := fc.sendChunk(ctx, ackLevel)
+		if err != nil {
+			return nil, err
+		}
+		if ack != nil {
+			acks = append(acks, ack)
 		}
 	}
-	return nil
+	if ackLevel == config.WriteAckLevelAsync || fc.chunk.IsEmpty() {
+		return acks, nil
+	}
+	// flush remaining rows immediately, no need to wait batch timeout
+	ack, err := fc.sendChunk(ctx, ackLevel)
+	if err != nil {
+		return nil, err
+	}
+	return append(acks, ack), nil
+}
+
+// waitAcks waits all flushed chunks acked by storage, returns the write token of them.
+func (fc *familyChannel) waitAcks(ctx context.Context, acks []<-chan writeAck) (models.WriteToken, error) {
+	var token models.WriteToken
+	for _, ackCh := range acks {
+		select {
+		case ack := <-ackCh:
+			if ack.err != nil {
+				return nil, fmt.Errorf("%w, cause: %s", ErrWriteNotAcked, ack.err)
+			}
+			token.Add(models.WriteSequence{
+				ShardID:    fc.shardID,
+				FamilyTime: fc.familyTime,
+				Leader:     ack.leader,
+				Sequence:   ack.seq,
+			})
+		case <-ctx.Done(): // timeout of http ingestion api
+			return nil, ErrIngestTimeout
+		case <-fc.ctx.Done():
+			return nil, ErrFamilyChannelCanceled
+		}
+	}
+	return token, nil
 }
 
 // leaderChanged notifies family channel need change leader send stream
@@ -145,22 +256,30 @@ func (fc *familyChannel) leaderChanged(
 	fc.lock4meta.Unlock()
 }
 
-func (fc *familyChannel) flushChunkOnFull(ctx context.Context) error {
-	if !fc.chunk.IsFull() {
-		return nil
-	}
-	compressed, err := fc.chunk.Compress()
+// sendChunk compresses the chunk and puts it into channel,
+// returns the ack channel of it if ack level is leader or quorum.
+func (fc *familyChannel) sendChunk(ctx context.Context, ackLevel string) (<-chan writeAck, error) {
+	batch, err := fc.compressChunk()
 	if err != nil {
-		return err
+		return nil, err
+	}
+	var ackCh chan writeAck
+	if ackLevel != config.WriteAckLevelAsync {
+		// buffered, because batch is acked at most once
+		ackCh = make(chan writeAck, 1)
+		// data is durable in leader's disk before acked
+		batch.sync = true
+		batch.quorum = ackLevel == config.WriteAckLevelQuorum
+		batch.ack = func(ack writeAck) { ackCh <- ack }
 	}
 
 	select {
-	case fc.ch <- compressed:
-		return nil
+	case fc.ch <- batch:
+		return ackCh, nil
 	case <-ctx.Done(): // timeout of http ingestion api
-		return ErrIngestTimeout
+		return nil, ErrIngestTimeout
 	case <-fc.ctx.Done():
-		return ErrFamilyChannelCanceled
+		return nil, ErrFamilyChannelCanceled
 	}
 }
 
@@ -170,20 +289,38 @@ func (fc *familyChannel) writeTask() {
 	ticker := time.NewTicker(fc.checkFlushInterval)
 	defer ticker.Stop()
 
-	retryBuffers := make([]*compressedChunk, 0)
-	retry := func(compressed *compressedChunk) {
+	retryBuffers := make([]*writeBatch, 0)
+	retry := func(batch *writeBatch) {
 		//TODO add config
 		if len(retryBuffers) > 100 {
 			fc.logger.Error("too many retry messages, drop current message")
+			batch.notify(writeAck{err: errTooManyRetryMessages})
 		} else {
-			retryBuffers = append(retryBuffers, compressed)
+			retryBuffers = append(retryBuffers, batch)
 		}
 	}
-	send := func(stream rpc.WriteStream, compressed *compressedChunk) bool {
+	var leader models.NodeID // leader of current stream
+	send := func(stream rpc.WriteStream, batch *writeBatch) bool {
 		if stream == nil {
 			return false
 		}
-		if err := stream.Send(*compressed); err != nil {
+		req := &protoWriteV1.WriteRequest{
+			Record:     *batch.compressed,
+			AcceptedAt: batch.acceptedAt,
+			Sync:       batch.sync,
+			Quorum:     batch.quorum,
+		}
+		streamLeader := leader
+		// chunk is kept until storage responds, so that it can be re-sent if rejected by paused shard
+		ack := func(seq int64, err error) {
+			if errors.Is(err, constants.ErrShardWritePaused) {
+				fc.reject(batch)
+				return
+			}
+			batch.compressed.Release()
+			batch.notify(writeAck{leader: streamLeader, seq: seq, err: err})
+		}
+		if err := stream.Send(req, ack); err != nil {
 			fc.logger.Error(
 				"failed writing compressed chunk to storage",
 				logger.String("target", fc.currentTarget.Indicator()),
@@ -197,14 +334,39 @@ func (fc *familyChannel) writeTask() {
 				}
 			}
 			// retry if err
-			retry(compressed)
+			retry(batch)
 			return false
 		}
-		compressed.Release()
 		return true
 	}
+	// sendRetries sends pending retry messages, returns false if send failure.
+	sendRetries := func(stream rpc.WriteStream) bool {
+		messages := retryBuffers
+		retryBuffers = make([]*writeBatch, 0)
+		fail := false
+		for _, msg := range messages {
+			if fail {
+				retry(msg)
+				continue
+			}
+			if !send(stream, msg) {
+				fail = true
+			}
+		}
+		return !fail
+	}
 
 	var stream rpc.WriteStream
+	// newStream creates the write stream of current leader.
+	newStream := func() (rpc.WriteStream, error) {
+		fc.lock4meta.Lock()
+		target := fc.liveNodes[fc.shardState.Leader]
+		shardState := fc.shardState
+		fc.currentTarget = &target
+		fc.lock4meta.Unlock()
+		leader = shardState.Leader
+		return fc.newWriteStreamFn(fc.ctx, fc.currentTarget, fc.database, &shardState, fc.familyTime, fc.streamWrite, fc.fct)
+	}
 	defer func() {
 		if stream != nil {
 			if err := stream.Close(); err != nil {
@@ -229,38 +391,22 @@ func (fc *familyChannel) writeTask() {
 				}
 				stream = nil
 			}
-		case compressed := <-fc.ch:
-			if compressed == nil {
+		case batch := <-fc.ch:
+			if batch == nil {
 				// close chan
 				continue
 			}
 			if stream == nil {
-				fc.lock4meta.Lock()
-				leader := fc.liveNodes[fc.shardState.Leader]
-				shardState := fc.shardState
-				fc.currentTarget = &leader
-				fc.lock4meta.Unlock()
-				stream, err = fc.newWriteStreamFn(fc.ctx, fc.currentTarget, fc.database, &shardState, fc.familyTime, fc.fct)
+				stream, err = newStream()
 				if err != nil {
-					retry(compressed)
+					retry(batch)
 					continue
 				}
 			}
-			if send(stream, compressed) {
+			if send(stream, batch) {
 				// if send ok, do pending retry message
-				if len(retryBuffers) > 0 {
-					messages := retryBuffers
-					retryBuffers = make([]*compressedChunk, 0)
-					fail := false
-					for _, msg := range messages {
-						if fail {
-							retry(msg)
-							continue
-						}
-						if !send(stream, msg) {
-							fail = true
-						}
-					}
+				if len(retryBuffers) > 0 && !sendRetries(stream) {
+					stream = nil
 				}
 			} else {
 				stream = nil
@@ -268,12 +414,43 @@ func (fc *familyChannel) writeTask() {
 		case <-ticker.C:
 			// check
 			fc.checkFlush()
+			// re-sends the batches rejected by paused shard, even if no new data written
+			for _, batch := range fc.takeRejected() {
+				retry(batch)
+			}
+			if len(retryBuffers) == 0 {
+				continue
+			}
+			if stream == nil {
+				if stream, err = newStream(); err != nil {
+					continue
+				}
+			}
+			if !sendRetries(stream) {
+				stream = nil
+			}
 		}
 	}
 
 	//TODO write pending check after stop???
 }
 
+// reject keeps the batch rejected by storage because writes of shard are paused, write task re-sends it later.
+func (fc *familyChannel) reject(batch *writeBatch) {
+	fc.lock4rejected.Lock()
+	fc.rejected = append(fc.rejected, batch)
+	fc.lock4rejected.Unlock()
+}
+
+// takeRejected returns and clears the batches rejected by storage.
+func (fc *familyChannel) takeRejected() []*writeBatch {
+	fc.lock4rejected.Lock()
+	defer fc.lock4rejected.Unlock()
+	rejected := fc.rejected
+	fc.rejected = nil
+	return rejected
+}
+
 func (fc *familyChannel) writePendingBeforeClose() {
 	// flush chunk pending data if chunk not empty
 	if !fc.chunk.IsEmpty() {
@@ -302,21 +479,32 @@ func (fc *familyChannel) Stop() {
 
 // flushChunk flushes the chunk data and appends data into queue
 func (fc *familyChannel) flushChunk() {
-	compressed, err := fc.chunk.Compress()
+	batch, err := fc.compressChunk()
 	if err != nil {
 		fc.logger.Error("chunk marshal err", logger.Error(err))
 		return
 	}
-	if compressed == nil || len(*compressed) == 0 {
+	if batch.compressed == nil || len(*batch.compressed) == 0 {
 		return
 	}
 	select {
-	case fc.ch <- compressed:
+	case fc.ch <- batch:
 	case <-fc.ctx.Done():
 		fc.logger.Warn("writer is canceled")
 	}
 }
 
+// compressChunk compresses the chunk with the accepted time of its first row, must be called with write lock.
+func (fc *familyChannel) compressChunk() (*writeBatch, error) {
+	acceptedAt := fc.chunkAcceptedAt
+	fc.chunkAcceptedAt = 0
+	compressed, err := fc.chunk.Compress()
+	if err != nil {
+		return nil, err
+	}
+	return &writeBatch{compressed: compressed, acceptedAt: acceptedAt}, nil
+}
+
 func (fc *familyChannel) isExpire(ahead, _ int64) bool {
 	now := fasttime.UnixMilliseconds()
 	// add 15 minute buffer
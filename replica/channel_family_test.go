@@ -18,20 +18,29 @@
 package replica
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"testing"
 	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/golang/snappy"
 	"github.com/stretchr/testify/assert"
+	"go.uber.org/atomic"
 
 	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/ltoml"
+	"github.com/lindb/lindb/pkg/queue"
 	"github.com/lindb/lindb/pkg/timeutil"
 	protoMetricsV1 "github.com/lindb/lindb/proto/gen/v1/metrics"
+	protoWriteV1 "github.com/lindb/lindb/proto/gen/v1/write"
 	"github.com/lindb/lindb/rpc"
 	"github.com/lindb/lindb/series/metric"
+	"github.com/lindb/lindb/tsdb"
 )
 
 func TestChannel_Write(t *testing.T) {
@@ -41,7 +50,7 @@ func TestChannel_Write(t *testing.T) {
 	}()
 
 	stream := rpc.NewMockWriteStream(ctrl)
-	stream.EXPECT().Send(gomock.Any()).Return(nil).AnyTimes()
+	stream.EXPECT().Send(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
 	stream.EXPECT().Close().AnyTimes()
 
 	ctx, cancel := context.WithCancel(context.TODO())
@@ -49,7 +58,7 @@ func TestChannel_Write(t *testing.T) {
 	ch1 := ch.(*familyChannel)
 	ch1.lock4write.Lock()
 	ch1.newWriteStreamFn = func(ctx context.Context, target models.Node, database string,
-		shardState *models.ShardState, familyTime int64, fct rpc.ClientStreamFactory) (rpc.WriteStream, error) {
+		shardState *models.ShardState, familyTime int64, streamWrite bool, fct rpc.ClientStreamFactory) (rpc.WriteStream, error) {
 		return stream, nil
 	}
 	ch1.lock4write.Unlock()
@@ -62,9 +71,9 @@ func TestChannel_Write(t *testing.T) {
 		SimpleFields: []*protoMetricsV1.SimpleField{
 			{Name: "f1", Type: protoMetricsV1.SimpleFieldType_DELTA_SUM, Value: 1}},
 	}, &brokerRow))
-	err := ch.Write(context.TODO(), []metric.BrokerRow{brokerRow})
+	_, err := ch.Write(context.TODO(), []metric.BrokerRow{brokerRow}, "", 0)
 	assert.NoError(t, err)
-	err = ch.Write(context.TODO(), []metric.BrokerRow{brokerRow})
+	_, err = ch.Write(context.TODO(), []metric.BrokerRow{brokerRow}, "", 0)
 	assert.NoError(t, err)
 
 	cancel()
@@ -74,7 +83,7 @@ func TestChannel_Write(t *testing.T) {
 	ch1 = ch.(*familyChannel)
 	ch1.lock4write.Lock()
 	ch1.newWriteStreamFn = func(ctx context.Context, target models.Node, database string,
-		shardState *models.ShardState, familyTime int64, fct rpc.ClientStreamFactory) (rpc.WriteStream, error) {
+		shardState *models.ShardState, familyTime int64, streamWrite bool, fct rpc.ClientStreamFactory) (rpc.WriteStream, error) {
 		return stream, nil
 	}
 	ch1.lock4write.Unlock()
@@ -84,17 +93,212 @@ func TestChannel_Write(t *testing.T) {
 	ch1.chunk = chunk
 	// make sure chan is full
 	var data = compressedChunk([]byte{1, 2})
-	ch1.ch <- &data
-	ch1.ch <- &data
+	ch1.ch <- &writeBatch{compressed: &data}
+	ch1.ch <- &writeBatch{compressed: &data}
 	chunk.EXPECT().Write(gomock.Any())
 	chunk.EXPECT().IsFull().Return(true)
 	data2 := compressedChunk([]byte{1, 2, 3})
 	chunk.EXPECT().Compress().Return(&data2, nil)
-	err = ch.Write(context.TODO(), []metric.BrokerRow{brokerRow})
+	_, err = ch.Write(context.TODO(), []metric.BrokerRow{brokerRow}, "", 0)
 	assert.Error(t, err)
 	time.Sleep(time.Millisecond * 500)
 }
 
+func TestChannel_WriteAckLevel(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	stream := rpc.NewMockWriteStream(ctrl)
+	stream.EXPECT().Close().Return(nil).AnyTimes()
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	ch := newFamilyChannel(ctx, config.GlobalBrokerConfig().Write, "database", 1, 12, nil, models.ShardState{Leader: 2}, nil)
+	ch1 := ch.(*familyChannel)
+	ch1.lock4write.Lock()
+	ch1.newWriteStreamFn = func(ctx context.Context, target models.Node, database string,
+		shardState *models.ShardState, familyTime int64, streamWrite bool, fct rpc.ClientStreamFactory) (rpc.WriteStream, error) {
+		return stream, nil
+	}
+	ch1.lock4write.Unlock()
+
+	converter := metric.NewProtoConverter()
+	var brokerRow metric.BrokerRow
+	assert.NoError(t, converter.ConvertTo(&protoMetricsV1.Metric{
+		Name:      "cpu",
+		Timestamp: timeutil.Now(),
+		SimpleFields: []*protoMetricsV1.SimpleField{
+			{Name: "f1", Type: protoMetricsV1.SimpleFieldType_DELTA_SUM, Value: 1}},
+	}, &brokerRow))
+
+	// storage responds after delay
+	ackDelay := 50 * time.Millisecond
+	sendWithAck := func(quorum bool, seq int64, ackErr error) {
+		stream.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(req *protoWriteV1.WriteRequest, ack func(seq int64, err error)) error {
+				assert.Equal(t, quorum, req.Quorum)
+				// wal of leader is flushed before acked
+				assert.True(t, req.Sync)
+				assert.NotNil(t, ack)
+				time.AfterFunc(ackDelay, func() { ack(seq, ackErr) })
+				return nil
+			})
+	}
+
+	// case 1: async returns after rows buffered
+	start := time.Now()
+	token, err := ch.Write(context.TODO(), []metric.BrokerRow{brokerRow}, config.WriteAckLevelAsync, 0)
+	assert.NoError(t, err)
+	assert.Empty(t, token)
+	assert.Less(t, time.Since(start), ackDelay)
+	ch1.lock4write.Lock()
+	assert.False(t, ch1.chunk.IsEmpty())
+	// drop buffered rows
+	_, _ = ch1.compressChunk()
+	ch1.lock4write.Unlock()
+
+	// case 2: leader flushes immediately, waits storage wrote wal
+	sendWithAck(false, 10, nil)
+	start = time.Now()
+	token, err = ch.Write(context.TODO(), []metric.BrokerRow{brokerRow}, config.WriteAckLevelLeader, 0)
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), ackDelay)
+	assert.Equal(t, models.WriteToken{{ShardID: 1, FamilyTime: 12, Leader: 2, Sequence: 10}}, token)
+
+	// case 3: quorum waits majority replicas acked
+	sendWithAck(true, 11, nil)
+	start = time.Now()
+	token, err = ch.Write(context.TODO(), []metric.BrokerRow{brokerRow}, config.WriteAckLevelQuorum, 0)
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), ackDelay)
+	assert.Equal(t, models.WriteToken{{ShardID: 1, FamilyTime: 12, Leader: 2, Sequence: 11}}, token)
+
+	// case 4: storage responds err
+	sendWithAck(true, 0, ErrQuorumAckTimeout)
+	_, err = ch.Write(context.TODO(), []metric.BrokerRow{brokerRow}, config.WriteAckLevelQuorum, 0)
+	assert.True(t, errors.Is(err, ErrWriteNotAcked))
+
+	// case 5: ingest timeout before acked
+	ackDelay = time.Second
+	sendWithAck(false, 12, nil)
+	timeoutCtx, timeoutCancel := context.WithTimeout(context.TODO(), 10*time.Millisecond)
+	defer timeoutCancel()
+	_, err = ch.Write(timeoutCtx, []metric.BrokerRow{brokerRow}, config.WriteAckLevelLeader, 0)
+	assert.Equal(t, ErrIngestTimeout, err)
+
+	// case 6: family channel canceled before acked
+	sendWithAck(false, 13, nil)
+	time.AfterFunc(10*time.Millisecond, ch.Stop)
+	_, err = ch.Write(context.TODO(), []metric.BrokerRow{brokerRow}, config.WriteAckLevelLeader, 0)
+	assert.Equal(t, ErrFamilyChannelCanceled, err)
+}
+
+// walWriteStream writes the request into wal partition of storage directly, responds like storage write handler.
+type walWriteStream struct {
+	p        Partition
+	rejected atomic.Int32
+}
+
+func (s *walWriteStream) Send(req *protoWriteV1.WriteRequest, ack func(seq int64, err error)) error {
+	err := s.p.WriteLog(req.Record)
+	if errors.Is(err, constants.ErrShardWritePaused) {
+		s.rejected.Inc()
+	}
+	if ack != nil {
+		ack(s.p.ReplicaAckIndex(), err)
+	}
+	return nil
+}
+
+func (s *walWriteStream) Close() error {
+	return nil
+}
+
+func TestChannel_Write_ShardPaused(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	log, err := queue.NewFanOutQueue(t.TempDir(), 1024*1024, time.Minute)
+	assert.NoError(t, err)
+	defer log.Close()
+	var paused atomic.Bool
+	shard := tsdb.NewMockShard(ctrl)
+	shard.EXPECT().ShardID().Return(models.ShardID(1)).AnyTimes()
+	shard.EXPECT().IsWritePaused().DoAndReturn(paused.Load).AnyTimes()
+	stream := &walWriteStream{p: NewPartition(context.TODO(), config.WAL{}, shard, nil, 1, log, nil, nil)}
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	cfg := config.GlobalBrokerConfig().Write
+	cfg.BatchTimeout = ltoml.Duration(time.Millisecond)
+	ch := newFamilyChannel(ctx, cfg, "database", 1, 12, nil, models.ShardState{Leader: 1}, nil)
+	ch1 := ch.(*familyChannel)
+	ch1.lock4write.Lock()
+	ch1.newWriteStreamFn = func(ctx context.Context, target models.Node, database string,
+		shardState *models.ShardState, familyTime int64, streamWrite bool, fct rpc.ClientStreamFactory) (rpc.WriteStream, error) {
+		return stream, nil
+	}
+	ch1.lock4write.Unlock()
+
+	converter := metric.NewProtoConverter()
+	newRow := func(name string) (row metric.BrokerRow, data []byte) {
+		assert.NoError(t, converter.ConvertTo(&protoMetricsV1.Metric{
+			Name:      name,
+			Timestamp: timeutil.Now(),
+			SimpleFields: []*protoMetricsV1.SimpleField{
+				{Name: "f1", Type: protoMetricsV1.SimpleFieldType_DELTA_SUM, Value: 1}},
+		}, &row))
+		var buf bytes.Buffer
+		_, err := row.WriteTo(&buf)
+		assert.NoError(t, err)
+		return row, buf.Bytes()
+	}
+	asyncRow, asyncData := newRow("async")
+	leaderRow, leaderData := newRow("leader")
+
+	// pause writes of shard, all chunks are rejected
+	paused.Store(true)
+	_, err = ch.Write(context.TODO(), []metric.BrokerRow{asyncRow}, config.WriteAckLevelAsync, 0)
+	assert.NoError(t, err)
+	// wait async chunk flushed and rejected
+	assert.Eventually(t, func() bool { return stream.rejected.Load() >= 1 }, 5*time.Second, 10*time.Millisecond)
+	rejected := stream.rejected.Load()
+	acked := make(chan error, 1)
+	go func() {
+		_, err := ch.Write(context.TODO(), []metric.BrokerRow{leaderRow}, config.WriteAckLevelLeader, 0)
+		acked <- err
+	}()
+	assert.Eventually(t, func() bool { return stream.rejected.Load() > rejected }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, int64(0), log.HeadSeq())
+	select {
+	case <-acked:
+		assert.Fail(t, "write acked while shard paused")
+	default:
+	}
+
+	// resume writes of shard, rejected chunks are re-sent
+	paused.Store(false)
+	select {
+	case err = <-acked:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "write not acked after shard resumed")
+	}
+	assert.Eventually(t, func() bool { return log.HeadSeq() == 2 }, 5*time.Second, 10*time.Millisecond)
+	fanOut, err := log.GetOrCreateFanOut("check")
+	assert.NoError(t, err)
+	assert.NoError(t, fanOut.SetHeadSeq(0))
+	var written [][]byte
+	for seq := int64(0); seq < 2; seq++ {
+		msg, err := fanOut.Get(seq)
+		assert.NoError(t, err)
+		data, err := snappy.Decode(nil, msg)
+		assert.NoError(t, err)
+		written = append(written, data)
+	}
+	assert.ElementsMatch(t, [][]byte{asyncData, leaderData}, written)
+}
+
 func TestChannel_checkFlush(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer func() {
@@ -103,14 +307,14 @@ func TestChannel_checkFlush(t *testing.T) {
 
 	stream := rpc.NewMockWriteStream(ctrl)
 	stream.EXPECT().Close().Return(nil).AnyTimes()
-	stream.EXPECT().Send(gomock.Any()).Return(nil).AnyTimes()
+	stream.EXPECT().Send(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
 
 	ctx, cancel := context.WithCancel(context.TODO())
 	ch := newFamilyChannel(ctx, config.GlobalBrokerConfig().Write, "database", 1, 12, nil, models.ShardState{}, nil)
 	ch1 := ch.(*familyChannel)
 	ch1.lock4write.Lock()
 	ch1.newWriteStreamFn = func(ctx context.Context, target models.Node, database string,
-		shardState *models.ShardState, familyTime int64, fct rpc.ClientStreamFactory) (rpc.WriteStream, error) {
+		shardState *models.ShardState, familyTime int64, streamWrite bool, fct rpc.ClientStreamFactory) (rpc.WriteStream, error) {
 		return stream, nil
 	}
 	ch1.lock4write.Unlock()
@@ -124,7 +328,7 @@ func TestChannel_checkFlush(t *testing.T) {
 			{Name: "f1", Type: protoMetricsV1.SimpleFieldType_DELTA_SUM, Value: 1}},
 	}, &brokerRow))
 
-	err := ch.Write(context.TODO(), []metric.BrokerRow{brokerRow})
+	_, err := ch.Write(context.TODO(), []metric.BrokerRow{brokerRow}, "", 0)
 	assert.NoError(t, err)
 
 	time.Sleep(time.Second)
@@ -138,7 +342,7 @@ func TestChannel_write_pending_before_close(t *testing.T) {
 		ctrl.Finish()
 	}()
 	stream := rpc.NewMockWriteStream(ctrl)
-	stream.EXPECT().Send(gomock.Any()).Return(nil).AnyTimes()
+	stream.EXPECT().Send(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
 	stream.EXPECT().Close().Return(nil).AnyTimes()
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -147,7 +351,7 @@ func TestChannel_write_pending_before_close(t *testing.T) {
 	ch1 := ch.(*familyChannel)
 	ch1.lock4write.Lock()
 	ch1.newWriteStreamFn = func(ctx context.Context, target models.Node, database string,
-		shardState *models.ShardState, familyTime int64, fct rpc.ClientStreamFactory) (rpc.WriteStream, error) {
+		shardState *models.ShardState, familyTime int64, streamWrite bool, fct rpc.ClientStreamFactory) (rpc.WriteStream, error) {
 		return stream, nil
 	}
 	ch1.lock4write.Unlock()
@@ -161,11 +365,11 @@ func TestChannel_write_pending_before_close(t *testing.T) {
 			{Name: "f1", Type: protoMetricsV1.SimpleFieldType_DELTA_SUM, Value: 1}},
 	}, &brokerRow))
 
-	err := ch.Write(context.TODO(), []metric.BrokerRow{brokerRow})
+	_, err := ch.Write(context.TODO(), []metric.BrokerRow{brokerRow}, "", 0)
 	assert.NoError(t, err)
 
 	var data = compressedChunk([]byte{1, 2, 3})
-	ch1.ch <- &data
+	ch1.ch <- &writeBatch{compressed: &data}
 	ch1.writePendingBeforeClose()
 }
 
@@ -182,7 +386,7 @@ func TestChannel_chunk_marshal_err(t *testing.T) {
 	ch1 := ch.(*familyChannel)
 	ch1.lock4write.Lock()
 	ch1.newWriteStreamFn = func(ctx context.Context, target models.Node, database string,
-		shardState *models.ShardState, familyTime int64, fct rpc.ClientStreamFactory) (rpc.WriteStream, error) {
+		shardState *models.ShardState, familyTime int64, streamWrite bool, fct rpc.ClientStreamFactory) (rpc.WriteStream, error) {
 		return stream, nil
 	}
 	ch1.lock4write.Unlock()
@@ -202,7 +406,7 @@ func TestChannel_chunk_marshal_err(t *testing.T) {
 	chunk.EXPECT().Write(gomock.Any())
 	chunk.EXPECT().IsFull().Return(true)
 	chunk.EXPECT().Compress().Return(nil, fmt.Errorf("err"))
-	err := ch.Write(context.TODO(), []metric.BrokerRow{brokerRow})
+	_, err := ch.Write(context.TODO(), []metric.BrokerRow{brokerRow}, "", 0)
 	assert.Error(t, err)
 
 	chunk.EXPECT().Compress().Return(nil, fmt.Errorf("err"))
@@ -210,3 +414,45 @@ func TestChannel_chunk_marshal_err(t *testing.T) {
 	chunk.EXPECT().Compress().Return(nil, nil)
 	ch1.flushChunk()
 }
+
+func TestChannel_compressChunk(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := newFamilyChannel(ctx, config.GlobalBrokerConfig().Write, "database", 1, 12, nil, models.ShardState{}, nil)
+	ch1 := ch.(*familyChannel)
+
+	converter := metric.NewProtoConverter()
+	var brokerRow metric.BrokerRow
+	assert.NoError(t, converter.ConvertTo(&protoMetricsV1.Metric{
+		Name:      "cpu",
+		Timestamp: timeutil.Now(),
+		SimpleFields: []*protoMetricsV1.SimpleField{
+			{Name: "f1", Type: protoMetricsV1.SimpleFieldType_DELTA_SUM, Value: 1}},
+	}, &brokerRow))
+
+	start := time.Now().UnixNano()
+	_, err := ch.Write(context.TODO(), []metric.BrokerRow{brokerRow}, "", 0)
+	assert.NoError(t, err)
+	ch1.lock4write.Lock()
+	acceptedAt := ch1.chunkAcceptedAt
+	ch1.lock4write.Unlock()
+	// accepted time of chunk keeps the first row
+	_, err = ch.Write(context.TODO(), []metric.BrokerRow{brokerRow}, "", 0)
+	assert.NoError(t, err)
+
+	ch1.lock4write.Lock()
+	defer ch1.lock4write.Unlock()
+	assert.GreaterOrEqual(t, acceptedAt, start)
+	batch, err := ch1.compressChunk()
+	assert.NoError(t, err)
+	assert.NotNil(t, batch.compressed)
+	assert.Equal(t, acceptedAt, batch.acceptedAt)
+	assert.Zero(t, ch1.chunkAcceptedAt)
+	ch1.lock4write.Unlock()
+
+	// accepted time stamped by ingestion
+	_, err = ch.Write(context.TODO(), []metric.BrokerRow{brokerRow}, "", start-100)
+	ch1.lock4write.Lock()
+	assert.NoError(t, err)
+	assert.Equal(t, start-100, ch1.chunkAcceptedAt)
+}
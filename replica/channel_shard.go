@@ -70,7 +70,7 @@ func newChannel(
 ) Channel {
 	c := &channel{
 		ctx:      ctx,
-		cfg:      config.GlobalBrokerConfig().Write, //TODO
+		cfg:      config.GlobalBrokerConfig().Write.ForDatabase(database),
 		database: database,
 		shardID:  shardID,
 		families: newFamilyChannelSet(),
@@ -0,0 +1,61 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package replica
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+
+	"github.com/lindb/lindb/pkg/fileutil"
+)
+
+//go:generate mockgen -source=./dead_letter.go -destination=./dead_letter_mock.go -package=replica
+
+// for testing
+var (
+	mkDirIfNotExistFunc = fileutil.MkDirIfNotExist
+	writeDeadLetterFunc = ioutil.WriteFile
+)
+
+const deadLetterSuffix = ".msg"
+
+// DeadLetter preserves the replica messages which fail to apply, so that they can be replayed manually.
+type DeadLetter interface {
+	// Put stores the replica message of sequence.
+	Put(sequence int64, msg []byte) error
+}
+
+// fileDeadLetter stores each replica message as a file named by sequence.
+type fileDeadLetter struct {
+	dir string
+}
+
+// NewDeadLetter creates dead letter store under dir.
+func NewDeadLetter(dir string) DeadLetter {
+	return &fileDeadLetter{dir: dir}
+}
+
+// Put stores the replica message of sequence into file dir/sequence.msg.
+func (dl *fileDeadLetter) Put(sequence int64, msg []byte) error {
+	if err := mkDirIfNotExistFunc(dl.dir); err != nil {
+		return err
+	}
+	fileName := filepath.Join(dl.dir, strconv.FormatInt(sequence, 10)+deadLetterSuffix)
+	return writeDeadLetterFunc(fileName, msg, 0644)
+}
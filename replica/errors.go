@@ -21,10 +21,17 @@ import "errors"
 
 var (
 	// define error types
-	errChannelNotFound = errors.New("shard replica channel not found")
-	errInvalidShardID  = errors.New("numOfShard should be greater than 0 and shardID should less then numOfShard")
-	errInvalidShardNum = errors.New("numOfShard should be equal or greater than original setting")
+	errChannelNotFound      = errors.New("shard replica channel not found")
+	errInvalidShardID       = errors.New("numOfShard should be greater than 0 and shardID should less then numOfShard")
+	errInvalidShardNum      = errors.New("numOfShard should be equal or greater than original setting")
+	errTooManyRetryMessages = errors.New("too many retry messages")
 	// ErrFamilyChannelCanceled is the error returned when a family channel is closed.
 	ErrFamilyChannelCanceled = errors.New("family Channel is canceled")
 	ErrIngestTimeout         = errors.New("ingest timout")
+	// ErrReplicaSequenceGap is the error returned when follower receives a replica message ahead of its append index.
+	ErrReplicaSequenceGap = errors.New("replica sequence gap detected")
+	// ErrQuorumAckTimeout is the error returned when the write is not acked by quorum replicas in time.
+	ErrQuorumAckTimeout = errors.New("write not acked by quorum replicas in time")
+	// ErrWriteNotAcked is the error returned when the write is not acked by storage, such as stream closed.
+	ErrWriteNotAcked = errors.New("write not acked by storage")
 )
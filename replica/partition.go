@@ -19,12 +19,18 @@ package replica
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"sync"
 	"time"
 
+	"go.uber.org/atomic"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/coordinator/storage"
+	"github.com/lindb/lindb/internal/linmetric"
 	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/pkg/fasttime"
 	"github.com/lindb/lindb/pkg/logger"
@@ -35,10 +41,22 @@ import (
 
 //go:generate mockgen -source=./partition.go -destination=./partition_mock.go -package=replica
 
+var (
+	partitionScope            = linmetric.NewScope("lindb.replica.partition")
+	replicaSequenceGapsVec    = partitionScope.NewCounterVec("replica_sequence_gaps", "db", "shard")
+	backlogPolicyVec          = partitionScope.NewGaugeVec("wal_backlog_policy", "policy")
+	backlogBlocksVec          = partitionScope.NewCounterVec("wal_backlog_blocks", "db", "shard")
+	backlogBlockTimeoutsVec   = partitionScope.NewCounterVec("wal_backlog_block_timeouts", "db", "shard")
+	backlogDroppedBytesVec    = partitionScope.NewCounterVec("wal_backlog_dropped_bytes", "db", "shard")
+	backlogDroppedMessagesVec = partitionScope.NewCounterVec("wal_backlog_dropped_messages", "db", "shard")
+)
+
 var (
 	// for testing
 	newLocalReplicatorFn  = NewLocalReplicator
 	newRemoteReplicatorFn = NewRemoteReplicator
+	backlogRetryInterval  = 100 * time.Millisecond
+	quorumCheckInterval   = 10 * time.Millisecond
 )
 
 // Partition represents a partition of writeTask ahead log.
@@ -51,10 +69,19 @@ type Partition interface {
 	// ReplicaLog writes msg that leader send replica msg.
 	// return appended index, if success.
 	ReplicaLog(replicaIdx int64, msg []byte) (int64, error)
-	// WriteLog writes msg that leader handle client writeTask request.
+	// WriteLog writes msg that leader handle client writeTask request,
+	// returns constants.ErrShardWritePaused if writes of shard are paused.
 	WriteLog(msg []byte) error
 	// ReplicaAckIndex returns the index which replica appended index.
 	ReplicaAckIndex() int64
+	// SyncLog flushes the log to disk if the msg of sequence is not flushed yet.
+	SyncLog(seq int64) error
+	// WaitQuorum waits until the msg of sequence is acked by majority replicas,
+	// returns ErrQuorumAckTimeout if not acked in quorum ack timeout.
+	WaitQuorum(seq int64) error
+	// FlowControl returns the number of messages not acked by all replicators(queue depth),
+	// and if writing should be backpressured based on wal flow control watermarks or shard write paused.
+	FlowControl() (queueDepth int64, backpressure bool)
 	ResetReplicaIndex(idx int64)
 	IsExpire() bool
 	Path() string
@@ -64,6 +91,7 @@ type Partition interface {
 // partition implements Partition interface.
 type partition struct {
 	ctx           context.Context
+	cfg           config.WAL
 	currentNodeID models.NodeID
 	log           queue.FanOutQueue
 	shardID       models.ShardID
@@ -75,6 +103,11 @@ type partition struct {
 	stateMgr storage.StateManager
 
 	mutex sync.Mutex
+	// backpressure is set when queue depth reaches high watermark, reset when drops to low watermark.
+	backpressure atomic.Bool
+	// syncedSeq is the last sequence flushed to disk by SyncLog, sequence of msg starts with 0
+	syncedSeq atomic.Int64
+	syncLock  sync.Mutex
 
 	logger *logger.Logger
 }
@@ -82,6 +115,7 @@ type partition struct {
 // NewPartition creates a writeTask ahead log partition(db+shard+family time+leader).
 func NewPartition(
 	ctx context.Context,
+	cfg config.WAL,
 	shard tsdb.Shard,
 	family tsdb.DataFamily,
 	currentNodeID models.NodeID,
@@ -89,8 +123,9 @@ func NewPartition(
 	cliFct rpc.ClientStreamFactory,
 	stateMgr storage.StateManager,
 ) Partition {
-	return &partition{
+	p := &partition{
 		ctx:           ctx,
+		cfg:           cfg,
 		log:           log,
 		shardID:       shard.ShardID(),
 		shard:         shard,
@@ -101,16 +136,30 @@ func NewPartition(
 		peers:         make(map[models.NodeID]ReplicatorPeer),
 		logger:        logger.GetLogger("replica", "Partition"),
 	}
+	// no msg flushed
+	p.syncedSeq.Store(-1)
+	return p
 }
 
 // ReplicaLog writes msg that leader sends replica msg.
 // return appended index, if success.
+// If replica index > append index(sequence gap), rejects the msg and returns last appended index with
+// ErrReplicaSequenceGap, leader need re-replicate the missing range from last appended index.
 func (p *partition) ReplicaLog(replicaIdx int64, msg []byte) (int64, error) {
 	appendIdx := p.log.HeadSeq()
+	if replicaIdx > appendIdx {
+		replicaSequenceGapsVec.WithTagValues(p.shard.Database().Name(), p.shardID.String()).Incr()
+		p.logger.Warn("replica sequence gap detected, pause apply and wait leader re-replicate",
+			logger.String("database", p.shard.Database().Name()),
+			logger.Any("shardID", p.shardID),
+			logger.Int64("replicaIdx", replicaIdx),
+			logger.Int64("appendIdx", appendIdx))
+		return appendIdx - 1, ErrReplicaSequenceGap
+	}
 	if replicaIdx != appendIdx {
 		return appendIdx, nil
 	}
-	if err := p.log.Put(msg); err != nil {
+	if err := p.putLog(msg); err != nil {
 		return -1, err
 	}
 	return appendIdx, nil
@@ -120,6 +169,98 @@ func (p *partition) ReplicaAckIndex() int64 {
 	return p.log.HeadSeq() - 1
 }
 
+// SyncLog flushes the log to disk if the msg of sequence is not flushed yet,
+// msgs appended by other writers are flushed together, so that concurrent writers share one fsync.
+func (p *partition) SyncLog(seq int64) error {
+	if p.syncedSeq.Load() >= seq {
+		return nil
+	}
+	p.syncLock.Lock()
+	defer p.syncLock.Unlock()
+
+	if p.syncedSeq.Load() >= seq {
+		return nil
+	}
+	lastSeq := p.ReplicaAckIndex()
+	if err := p.log.Flush(); err != nil {
+		return err
+	}
+	p.syncedSeq.Store(lastSeq)
+	return nil
+}
+
+// WaitQuorum waits until the msg of sequence is acked by majority replicas,
+// returns ErrQuorumAckTimeout if not acked in quorum ack timeout.
+func (p *partition) WaitQuorum(seq int64) error {
+	if p.isQuorumAcked(seq) {
+		return nil
+	}
+	ticker := time.NewTicker(quorumCheckInterval)
+	defer ticker.Stop()
+	timer := time.NewTimer(p.cfg.QuorumAckTimeout.Duration())
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-p.ctx.Done():
+			return ErrQuorumAckTimeout
+		case <-timer.C:
+			return ErrQuorumAckTimeout
+		case <-ticker.C:
+			if p.isQuorumAcked(seq) {
+				return nil
+			}
+		}
+	}
+}
+
+// isQuorumAcked checks if the msg of sequence is acked by majority replicators(including local replicator).
+// Sequence of msg starts with 0, tail seq of replicator may be initialized as 0 before any msg acked,
+// so the msg must be consumed by replicator too, else tail seq 0 would be treated as msg 0 acked.
+func (p *partition) isQuorumAcked(seq int64) bool {
+	names := p.log.FanOutNames()
+	acked := 0
+	for _, name := range names {
+		fanOut, err := p.log.GetOrCreateFanOut(name)
+		if err != nil {
+			continue
+		}
+		if fanOut.HeadSeq() > seq && fanOut.TailSeq() >= seq {
+			acked++
+		}
+	}
+	return acked >= len(names)/2+1
+}
+
+// FlowControl returns the number of messages not acked by all replicators(queue depth),
+// and if writing should be backpressured based on wal flow control watermarks or shard write paused.
+func (p *partition) FlowControl() (queueDepth int64, backpressure bool) {
+	lastSeq := p.log.HeadSeq() - 1
+	for _, name := range p.log.FanOutNames() {
+		fanOut, err := p.log.GetOrCreateFanOut(name)
+		if err != nil {
+			continue
+		}
+		if depth := lastSeq - fanOut.TailSeq(); depth > queueDepth {
+			queueDepth = depth
+		}
+	}
+	if p.shard.IsWritePaused() {
+		// writes are rejected while shard is paused, so pauses sending of writer too
+		return queueDepth, true
+	}
+	if p.cfg.FlowControlHighWatermark <= 0 {
+		return queueDepth, false
+	}
+	switch {
+	case queueDepth >= p.cfg.FlowControlHighWatermark:
+		p.backpressure.Store(true)
+	case queueDepth <= p.cfg.FlowControlLowWatermark:
+		p.backpressure.Store(false)
+	}
+	return queueDepth, p.backpressure.Load()
+}
+
 func (p *partition) ResetReplicaIndex(idx int64) {
 	p.log.SetAppendSeq(idx)
 }
@@ -147,12 +288,66 @@ func (p *partition) IsExpire() bool {
 	return true
 }
 
-// WriteLog writes msg that leader send replica msg.
+// WriteLog writes msg that leader send replica msg,
+// returns constants.ErrShardWritePaused if writes of shard are paused.
 func (p *partition) WriteLog(msg []byte) error {
 	if len(msg) == 0 {
 		return nil
 	}
-	return p.log.Put(msg)
+	if p.shard.IsWritePaused() {
+		return constants.ErrShardWritePaused
+	}
+	span := tsdb.StartWriteSpan(p.shard)
+	defer span.Finish()
+
+	start := span.Now()
+	err := p.putLog(msg)
+	span.Record(tsdb.WriteStageWAL, start)
+	return err
+}
+
+// putLog puts msg into log, handles the backlog based on backlog policy when log exceeds the size limit.
+func (p *partition) putLog(msg []byte) error {
+	err := p.log.Put(msg)
+	if !errors.Is(err, queue.ErrExceedingTotalSizeLimit) {
+		return err
+	}
+	db := p.shard.Database().Name()
+	shard := p.shardID.String()
+	switch p.cfg.BacklogPolicy {
+	case config.WALBacklogPolicyDropOldest:
+		// drop the oldest half of backlog, replicator will reset the replica index of lagging peers.
+		tailSeq := p.log.TailSeq()
+		dropSeq := tailSeq + (p.log.HeadSeq()-tailSeq)/2
+		droppedBytes := p.log.Discard(dropSeq)
+		backlogDroppedBytesVec.WithTagValues(db, shard).Add(float64(droppedBytes))
+		backlogDroppedMessagesVec.WithTagValues(db, shard).Add(float64(dropSeq - tailSeq))
+		p.logger.Warn("wal backlog exceeds size limit, drop the oldest messages",
+			logger.String("database", db),
+			logger.Any("shardID", p.shardID),
+			logger.Int64("dropSeq", dropSeq),
+			logger.Int64("droppedBytes", droppedBytes))
+		return p.log.Put(msg)
+	default:
+		// block writing until replicators consume the backlog
+		backlogBlocksVec.WithTagValues(db, shard).Incr()
+		timer := time.NewTimer(p.cfg.BacklogBlockTimeout.Duration())
+		defer timer.Stop()
+		for {
+			select {
+			case <-p.ctx.Done():
+				return err
+			case <-timer.C:
+				backlogBlockTimeoutsVec.WithTagValues(db, shard).Incr()
+				return err
+			case <-time.After(backlogRetryInterval):
+				p.log.Release()
+				if err = p.log.Put(msg); !errors.Is(err, queue.ErrExceedingTotalSizeLimit) {
+					return err
+				}
+			}
+		}
+	}
 }
 
 // BuildReplicaForLeader builds replica relation when handle writeTask connection.
@@ -238,7 +433,7 @@ func (p *partition) buildReplica(leader models.NodeID, replica models.NodeID) er
 	}
 	if replica == p.currentNodeID {
 		// local replicator
-		replicator = newLocalReplicatorFn(&channel, p.shard, p.family)
+		replicator = newLocalReplicatorFn(&channel, p.shard, p.family, p.cfg)
 	} else {
 		// build remote replicator
 		replicator = newRemoteReplicatorFn(p.ctx, &channel, p.stateMgr, p.cliFct)
@@ -21,12 +21,16 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/coordinator/storage"
 	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/ltoml"
 	"github.com/lindb/lindb/pkg/queue"
 	"github.com/lindb/lindb/pkg/timeutil"
 	"github.com/lindb/lindb/rpc"
@@ -47,7 +51,7 @@ func TestPartition_BuildReplicaRelation(t *testing.T) {
 	shard.EXPECT().Database().Return(database).AnyTimes()
 	shard.EXPECT().ShardID().Return(models.ShardID(1)).AnyTimes()
 	r.EXPECT().String().Return("TestPartition_BuildReplicaRelation").AnyTimes()
-	newLocalReplicatorFn = func(_ *ReplicatorChannel, _ tsdb.Shard, _ tsdb.DataFamily) Replicator {
+	newLocalReplicatorFn = func(_ *ReplicatorChannel, _ tsdb.Shard, _ tsdb.DataFamily, _ config.WAL) Replicator {
 		return r
 	}
 	newRemoteReplicatorFn = func(_ context.Context, _ *ReplicatorChannel,
@@ -59,7 +63,7 @@ func TestPartition_BuildReplicaRelation(t *testing.T) {
 	log.EXPECT().GetOrCreateFanOut(gomock.Any()).Return(nil, nil).AnyTimes()
 	family := tsdb.NewMockDataFamily(ctrl)
 	family.EXPECT().TimeRange().Return(timeutil.TimeRange{}).AnyTimes()
-	p := NewPartition(context.TODO(), shard, family, 1, log, nil, nil)
+	p := NewPartition(context.TODO(), config.WAL{}, shard, family, 1, log, nil, nil)
 	err := p.BuildReplicaForLeader(2, []models.NodeID{1, 2, 3})
 	assert.Error(t, err)
 
@@ -89,7 +93,7 @@ func TestPartition_BuildReplicaForFollower(t *testing.T) {
 	shard.EXPECT().ShardID().Return(models.ShardID(1)).AnyTimes()
 	shard.EXPECT().Database().Return(database).AnyTimes()
 	r.EXPECT().String().Return("TestPartition_BuildReplicaForFollower").AnyTimes()
-	newLocalReplicatorFn = func(_ *ReplicatorChannel, _ tsdb.Shard, _ tsdb.DataFamily) Replicator {
+	newLocalReplicatorFn = func(_ *ReplicatorChannel, _ tsdb.Shard, _ tsdb.DataFamily, _ config.WAL) Replicator {
 		return r
 	}
 	newRemoteReplicatorFn = func(_ context.Context, _ *ReplicatorChannel,
@@ -101,7 +105,7 @@ func TestPartition_BuildReplicaForFollower(t *testing.T) {
 	log.EXPECT().GetOrCreateFanOut(gomock.Any()).Return(nil, nil).AnyTimes()
 	family := tsdb.NewMockDataFamily(ctrl)
 	family.EXPECT().TimeRange().Return(timeutil.TimeRange{}).AnyTimes()
-	p := NewPartition(context.TODO(), shard, family, 1, log, nil, nil)
+	p := NewPartition(context.TODO(), config.WAL{}, shard, family, 1, log, nil, nil)
 	err := p.BuildReplicaForFollower(2, 2)
 	assert.Error(t, err)
 
@@ -127,7 +131,7 @@ func TestPartition_Close(t *testing.T) {
 	l := queue.NewMockFanOutQueue(ctrl)
 	l.EXPECT().GetOrCreateFanOut(gomock.Any()).Return(nil, nil).AnyTimes()
 	r.EXPECT().String().Return("TestPartition_Close").AnyTimes()
-	newLocalReplicatorFn = func(_ *ReplicatorChannel, _ tsdb.Shard, _ tsdb.DataFamily) Replicator {
+	newLocalReplicatorFn = func(_ *ReplicatorChannel, _ tsdb.Shard, _ tsdb.DataFamily, _ config.WAL) Replicator {
 		return r
 	}
 	newRemoteReplicatorFn = func(_ context.Context, _ *ReplicatorChannel,
@@ -138,7 +142,7 @@ func TestPartition_Close(t *testing.T) {
 	l.EXPECT().Close().MaxTimes(2)
 	family := tsdb.NewMockDataFamily(ctrl)
 	family.EXPECT().TimeRange().Return(timeutil.TimeRange{}).AnyTimes()
-	p := NewPartition(context.TODO(), shard, family, 1, l, nil, nil)
+	p := NewPartition(context.TODO(), config.WAL{}, shard, family, 1, l, nil, nil)
 	err := p.Close()
 	assert.NoError(t, err)
 	r.EXPECT().IsReady().Return(true).AnyTimes()
@@ -157,13 +161,220 @@ func TestPartition_WriteLog(t *testing.T) {
 	l := queue.NewMockFanOutQueue(ctrl)
 	shard := tsdb.NewMockShard(ctrl)
 	shard.EXPECT().ShardID().Return(models.ShardID(1)).AnyTimes()
-	p := NewPartition(context.TODO(), shard, nil, 1, l, nil, nil)
+	p := NewPartition(context.TODO(), config.WAL{}, shard, nil, 1, l, nil, nil)
+	shard.EXPECT().IsWritePaused().Return(false)
 	l.EXPECT().Put(gomock.Any()).Return(fmt.Errorf("err"))
 	err := p.WriteLog([]byte{1})
 	assert.Error(t, err)
 	// msg is empty
 	err = p.WriteLog(nil)
 	assert.NoError(t, err)
+	// shard write paused, msg not put into log
+	shard.EXPECT().IsWritePaused().Return(true)
+	err = p.WriteLog([]byte{1})
+	assert.ErrorIs(t, err, constants.ErrShardWritePaused)
+}
+
+func TestPartition_WriteLog_Backlog(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		backlogRetryInterval = 100 * time.Millisecond
+		ctrl.Finish()
+	}()
+	backlogRetryInterval = time.Millisecond
+	l := queue.NewMockFanOutQueue(ctrl)
+	database := tsdb.NewMockDatabase(ctrl)
+	database.EXPECT().Name().Return("test_backlog").AnyTimes()
+	shard := tsdb.NewMockShard(ctrl)
+	shard.EXPECT().ShardID().Return(models.ShardID(1)).AnyTimes()
+	shard.EXPECT().Database().Return(database).AnyTimes()
+	shard.EXPECT().IsWritePaused().Return(false).AnyTimes()
+
+	// case 1: block until backlog consumed
+	p := NewPartition(context.TODO(), config.WAL{
+		BacklogPolicy:       config.WALBacklogPolicyBlock,
+		BacklogBlockTimeout: ltoml.Duration(time.Minute),
+	}, shard, nil, 1, l, nil, nil)
+	gomock.InOrder(
+		l.EXPECT().Put(gomock.Any()).Return(queue.ErrExceedingTotalSizeLimit),
+		l.EXPECT().Release(),
+		l.EXPECT().Put(gomock.Any()).Return(queue.ErrExceedingTotalSizeLimit),
+		l.EXPECT().Release(),
+		l.EXPECT().Put(gomock.Any()).Return(nil),
+	)
+	assert.NoError(t, p.WriteLog([]byte{1}))
+	assert.Equal(t, float64(1), backlogBlocksVec.WithTagValues("test_backlog", "1").Get())
+
+	// case 2: block timeout
+	p = NewPartition(context.TODO(), config.WAL{
+		BacklogPolicy:       config.WALBacklogPolicyBlock,
+		BacklogBlockTimeout: ltoml.Duration(10 * time.Millisecond),
+	}, shard, nil, 1, l, nil, nil)
+	l.EXPECT().Put(gomock.Any()).Return(queue.ErrExceedingTotalSizeLimit).MinTimes(1)
+	l.EXPECT().Release().AnyTimes()
+	assert.ErrorIs(t, p.WriteLog([]byte{1}), queue.ErrExceedingTotalSizeLimit)
+	assert.Equal(t, float64(1), backlogBlockTimeoutsVec.WithTagValues("test_backlog", "1").Get())
+
+	// case 3: drop oldest
+	l = queue.NewMockFanOutQueue(ctrl)
+	p = NewPartition(context.TODO(), config.WAL{
+		BacklogPolicy: config.WALBacklogPolicyDropOldest,
+	}, shard, nil, 1, l, nil, nil)
+	gomock.InOrder(
+		l.EXPECT().Put(gomock.Any()).Return(queue.ErrExceedingTotalSizeLimit),
+		l.EXPECT().TailSeq().Return(int64(9)),
+		l.EXPECT().HeadSeq().Return(int64(21)),
+		l.EXPECT().Discard(int64(15)).Return(int64(600)),
+		l.EXPECT().Put(gomock.Any()).Return(nil),
+	)
+	assert.NoError(t, p.WriteLog([]byte{1}))
+	assert.Equal(t, float64(600), backlogDroppedBytesVec.WithTagValues("test_backlog", "1").Get())
+	assert.Equal(t, float64(6), backlogDroppedMessagesVec.WithTagValues("test_backlog", "1").Get())
+}
+
+func TestPartition_FlowControl(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	l := queue.NewMockFanOutQueue(ctrl)
+	shard := tsdb.NewMockShard(ctrl)
+	shard.EXPECT().ShardID().Return(models.ShardID(1)).AnyTimes()
+	fanOut1 := queue.NewMockFanOut(ctrl)
+	fanOut2 := queue.NewMockFanOut(ctrl)
+	l.EXPECT().FanOutNames().Return([]string{"1", "2", "3"}).AnyTimes()
+	l.EXPECT().GetOrCreateFanOut("1").Return(fanOut1, nil).AnyTimes()
+	l.EXPECT().GetOrCreateFanOut("2").Return(fanOut2, nil).AnyTimes()
+	l.EXPECT().GetOrCreateFanOut("3").Return(nil, fmt.Errorf("err")).AnyTimes()
+	fanOut2.EXPECT().TailSeq().Return(int64(90)).AnyTimes()
+	shard.EXPECT().IsWritePaused().Return(false).Times(4)
+
+	// case 1: backpressure disabled
+	p := NewPartition(context.TODO(), config.WAL{}, shard, nil, 1, l, nil, nil)
+	l.EXPECT().HeadSeq().Return(int64(101))
+	fanOut1.EXPECT().TailSeq().Return(int64(0))
+	depth, backpressure := p.FlowControl()
+	assert.Equal(t, int64(100), depth)
+	assert.False(t, backpressure)
+
+	// case 2: backpressure until depth drops to low watermark
+	p = NewPartition(context.TODO(), config.WAL{
+		FlowControlHighWatermark: 50,
+		FlowControlLowWatermark:  20,
+	}, shard, nil, 1, l, nil, nil)
+	l.EXPECT().HeadSeq().Return(int64(101)).Times(3)
+	gomock.InOrder(
+		fanOut1.EXPECT().TailSeq().Return(int64(40)),
+		fanOut1.EXPECT().TailSeq().Return(int64(70)),
+		fanOut1.EXPECT().TailSeq().Return(int64(90)),
+	)
+	depth, backpressure = p.FlowControl()
+	assert.Equal(t, int64(60), depth)
+	assert.True(t, backpressure)
+	depth, backpressure = p.FlowControl()
+	assert.Equal(t, int64(30), depth)
+	assert.True(t, backpressure)
+	depth, backpressure = p.FlowControl()
+	assert.Equal(t, int64(10), depth)
+	assert.False(t, backpressure)
+
+	// case 3: backpressure when shard write paused
+	l.EXPECT().HeadSeq().Return(int64(101))
+	fanOut1.EXPECT().TailSeq().Return(int64(90))
+	shard.EXPECT().IsWritePaused().Return(true)
+	depth, backpressure = p.FlowControl()
+	assert.Equal(t, int64(10), depth)
+	assert.True(t, backpressure)
+}
+
+func TestPartition_WaitQuorum(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		quorumCheckInterval = 10 * time.Millisecond
+		ctrl.Finish()
+	}()
+	quorumCheckInterval = time.Millisecond
+
+	l := queue.NewMockFanOutQueue(ctrl)
+	shard := tsdb.NewMockShard(ctrl)
+	shard.EXPECT().ShardID().Return(models.ShardID(1)).AnyTimes()
+	fanOut1 := queue.NewMockFanOut(ctrl)
+	fanOut2 := queue.NewMockFanOut(ctrl)
+	l.EXPECT().FanOutNames().Return([]string{"1", "2", "3"}).AnyTimes()
+	l.EXPECT().GetOrCreateFanOut("1").Return(fanOut1, nil).AnyTimes()
+	l.EXPECT().GetOrCreateFanOut("2").Return(fanOut2, nil).AnyTimes()
+	l.EXPECT().GetOrCreateFanOut("3").Return(nil, fmt.Errorf("err")).AnyTimes()
+	fanOut1.EXPECT().TailSeq().Return(int64(100)).AnyTimes()
+	fanOut1.EXPECT().HeadSeq().Return(int64(101)).AnyTimes()
+	fanOut2.EXPECT().HeadSeq().Return(int64(102)).AnyTimes()
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	p := NewPartition(ctx, config.WAL{QuorumAckTimeout: ltoml.Duration(50 * time.Millisecond)}, shard, nil, 1, l, nil, nil)
+	// case 1: acked by majority replicas
+	fanOut2.EXPECT().TailSeq().Return(int64(100))
+	assert.NoError(t, p.WaitQuorum(100))
+	// case 2: acked by majority replicas after waiting
+	gomock.InOrder(
+		fanOut2.EXPECT().TailSeq().Return(int64(90)).Times(2),
+		fanOut2.EXPECT().TailSeq().Return(int64(101)),
+	)
+	assert.NoError(t, p.WaitQuorum(100))
+	// case 3: not acked in time
+	fanOut2.EXPECT().TailSeq().Return(int64(90)).AnyTimes()
+	assert.Equal(t, ErrQuorumAckTimeout, p.WaitQuorum(100))
+	// case 4: partition closed
+	cancel()
+	assert.Equal(t, ErrQuorumAckTimeout, p.WaitQuorum(100))
+}
+
+func TestPartition_WaitQuorum_FirstMsg(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	l := queue.NewMockFanOutQueue(ctrl)
+	shard := tsdb.NewMockShard(ctrl)
+	shard.EXPECT().ShardID().Return(models.ShardID(1)).AnyTimes()
+	fanOut := queue.NewMockFanOut(ctrl)
+	l.EXPECT().FanOutNames().Return([]string{"1"}).AnyTimes()
+	l.EXPECT().GetOrCreateFanOut("1").Return(fanOut, nil).AnyTimes()
+	p := NewPartition(context.TODO(), config.WAL{QuorumAckTimeout: ltoml.Duration(20 * time.Millisecond)},
+		shard, nil, 1, l, nil, nil)
+
+	// tail seq is 0 before msg 0 consumed and acked
+	fanOut.EXPECT().TailSeq().Return(int64(0)).AnyTimes()
+	gomock.InOrder(
+		fanOut.EXPECT().HeadSeq().Return(int64(0)).Times(2),
+		fanOut.EXPECT().HeadSeq().Return(int64(1)),
+	)
+	assert.False(t, p.(*partition).isQuorumAcked(0))
+	assert.NoError(t, p.WaitQuorum(0))
+}
+
+func TestPartition_SyncLog(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	l := queue.NewMockFanOutQueue(ctrl)
+	shard := tsdb.NewMockShard(ctrl)
+	shard.EXPECT().ShardID().Return(models.ShardID(1)).AnyTimes()
+	p := NewPartition(context.TODO(), config.WAL{}, shard, nil, 1, l, nil, nil)
+
+	// case 1: flush err
+	l.EXPECT().HeadSeq().Return(int64(11))
+	l.EXPECT().Flush().Return(fmt.Errorf("err"))
+	assert.Error(t, p.SyncLog(10))
+	// case 2: flushes all appended msgs
+	l.EXPECT().HeadSeq().Return(int64(13))
+	l.EXPECT().Flush().Return(nil)
+	assert.NoError(t, p.SyncLog(10))
+	// case 3: msg already flushed
+	assert.NoError(t, p.SyncLog(12))
+
+	// case 4: first msg(sequence 0) is flushed
+	p = NewPartition(context.TODO(), config.WAL{}, shard, nil, 1, l, nil, nil)
+	l.EXPECT().HeadSeq().Return(int64(1))
+	l.EXPECT().Flush().Return(nil)
+	assert.NoError(t, p.SyncLog(0))
 }
 
 func TestPartition_ReplicaLog(t *testing.T) {
@@ -174,12 +385,12 @@ func TestPartition_ReplicaLog(t *testing.T) {
 	l := queue.NewMockFanOutQueue(ctrl)
 	shard := tsdb.NewMockShard(ctrl)
 	shard.EXPECT().ShardID().Return(models.ShardID(1)).AnyTimes()
-	p := NewPartition(context.TODO(), shard, nil, 1, l, nil, nil)
-	// case 1: replica idx err
-	l.EXPECT().HeadSeq().Return(int64(8))
+	p := NewPartition(context.TODO(), config.WAL{}, shard, nil, 1, l, nil, nil)
+	// case 1: replica idx < append idx, ignore duplicate msg
+	l.EXPECT().HeadSeq().Return(int64(12))
 	idx, err := p.ReplicaLog(10, []byte{1})
 	assert.NoError(t, err)
-	assert.Equal(t, idx, int64(8))
+	assert.Equal(t, idx, int64(12))
 
 	// case 2: put err
 	l.EXPECT().Put(gomock.Any()).Return(fmt.Errorf("err"))
@@ -195,3 +406,25 @@ func TestPartition_ReplicaLog(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, idx, int64(10))
 }
+
+func TestPartition_ReplicaLog_SequenceGap(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		ctrl.Finish()
+	}()
+	l := queue.NewMockFanOutQueue(ctrl)
+	database := tsdb.NewMockDatabase(ctrl)
+	database.EXPECT().Name().Return("test").AnyTimes()
+	shard := tsdb.NewMockShard(ctrl)
+	shard.EXPECT().ShardID().Return(models.ShardID(1)).AnyTimes()
+	shard.EXPECT().Database().Return(database).AnyTimes()
+	p := NewPartition(context.TODO(), config.WAL{}, shard, nil, 1, l, nil, nil)
+	gaps := replicaSequenceGapsVec.WithTagValues("test", "1")
+	before := gaps.Get()
+	// replica idx > append idx, msg cannot be applied(no put)
+	l.EXPECT().HeadSeq().Return(int64(8))
+	idx, err := p.ReplicaLog(10, []byte{1})
+	assert.Equal(t, ErrReplicaSequenceGap, err)
+	assert.Equal(t, int64(7), idx)
+	assert.Equal(t, before+1, gaps.Get())
+}
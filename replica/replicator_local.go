@@ -18,14 +18,25 @@
 package replica
 
 import (
+	"path/filepath"
+	"strconv"
+	"time"
+
 	"github.com/golang/snappy"
 
+	"github.com/lindb/lindb/config"
 	"github.com/lindb/lindb/internal/linmetric"
 	"github.com/lindb/lindb/pkg/logger"
+	"github.com/lindb/lindb/pkg/timeutil"
 	"github.com/lindb/lindb/series/metric"
 	"github.com/lindb/lindb/tsdb"
 )
 
+// for testing
+var (
+	sleepFunc = time.Sleep
+)
+
 var (
 	localReplicaScope       = linmetric.NewScope("lindb.replica.local")
 	localMaxDecodedBlockVec = localReplicaScope.NewMaxVec("max_decoded_block", "db", "shard")
@@ -34,6 +45,9 @@ var (
 	localReplicaRowsVec     = localReplicaScope.NewCounterVec("replica_rows", "db", "shard")
 	localReplicaSequenceVec = localReplicaScope.NewGaugeVec("replica_sequence", "db", "shard")
 	localInvalidSequenceVec = localReplicaScope.NewCounterVec("invalid_sequence", "db", "shard")
+	localApplyRetriesVec    = localReplicaScope.NewCounterVec("apply_retries", "db", "shard")
+	localDeadLettersVec     = localReplicaScope.NewCounterVec("dead_letters", "db", "shard")
+	localDeadLetterFailsVec = localReplicaScope.NewCounterVec("dead_letter_failures", "db", "shard")
 )
 
 type localReplicator struct {
@@ -45,6 +59,10 @@ type localReplicator struct {
 	logger    *logger.Logger
 	batchRows *metric.StorageBatchRows
 
+	maxRetries   int
+	retryBackoff time.Duration
+	deadLetter   DeadLetter
+
 	block []byte
 
 	statistics struct {
@@ -54,20 +72,40 @@ type localReplicator struct {
 		localReplicaRows        *linmetric.BoundCounter
 		localReplicaSequence    *linmetric.BoundGauge
 		localInvalidSequenceVec *linmetric.BoundCounter
+		localApplyRetries       *linmetric.BoundCounter
+		localDeadLetters        *linmetric.BoundCounter
+		localDeadLetterFails    *linmetric.BoundCounter
 	}
 }
 
-func NewLocalReplicator(channel *ReplicatorChannel, shard tsdb.Shard, family tsdb.DataFamily) Replicator {
+// NewLocalReplicator creates local replicator which applies replica messages into shard/family,
+// retries failed applies with backoff, then moves the message to dead letter after all retries fail.
+func NewLocalReplicator(
+	channel *ReplicatorChannel,
+	shard tsdb.Shard,
+	family tsdb.DataFamily,
+	cfg config.WAL,
+) Replicator {
+	// dead letter path: dead letter dir + database + shard + family time + leader
+	deadLetterDir := filepath.Join(
+		cfg.DeadLetterDir,
+		shard.Database().Name(),
+		strconv.Itoa(int(shard.ShardID())),
+		timeutil.FormatTimestamp(family.TimeRange().Start, timeutil.DataTimeFormat4),
+		strconv.Itoa(int(channel.State.Leader)))
 	lr := &localReplicator{
 		leader: int32(channel.State.Leader),
 		replicator: replicator{
 			channel: channel,
 		},
-		shard:     shard,
-		family:    family,
-		batchRows: metric.NewStorageBatchRows(),
-		logger:    logger.GetLogger("replica", "LocalReplicator"),
-		block:     make([]byte, 256*1024),
+		shard:        shard,
+		family:       family,
+		batchRows:    metric.NewStorageBatchRows(),
+		maxRetries:   cfg.ApplyMaxRetries,
+		retryBackoff: cfg.ApplyRetryBackoff.Duration(),
+		deadLetter:   NewDeadLetter(deadLetterDir),
+		logger:       logger.GetLogger("replica", "LocalReplicator"),
+		block:        make([]byte, 256*1024),
 	}
 
 	//add ack sequence callback
@@ -86,6 +124,9 @@ func NewLocalReplicator(channel *ReplicatorChannel, shard tsdb.Shard, family tsd
 	lr.statistics.localReplicaRows = localReplicaRowsVec.WithTagValues(databaseName, shardStr)
 	lr.statistics.localReplicaSequence = localReplicaSequenceVec.WithTagValues(databaseName, shardStr)
 	lr.statistics.localInvalidSequenceVec = localInvalidSequenceVec.WithTagValues(databaseName, shardStr)
+	lr.statistics.localApplyRetries = localApplyRetriesVec.WithTagValues(databaseName, shardStr)
+	lr.statistics.localDeadLetters = localDeadLettersVec.WithTagValues(databaseName, shardStr)
+	lr.statistics.localDeadLetterFails = localDeadLetterFailsVec.WithTagValues(databaseName, shardStr)
 
 	lr.logger.Info("start local replicator", logger.String("replica", lr.String()))
 	return lr
@@ -95,16 +136,23 @@ func NewLocalReplicator(channel *ReplicatorChannel, shard tsdb.Shard, family tsd
 // 1. check replica replica if valid
 // 2. uncompress/unmarshal msg
 // 3. lookup metadata
-// 4. write metric data
+// 4. write metric data, retry with backoff if fail, move msg to dead letter after all retries fail
 // 5. commit sequence in data family
 func (r *localReplicator) Replica(sequence int64, msg []byte) {
-	if !r.family.ValidateSequence(r.leader, sequence) {
+	span := tsdb.StartWriteSpan(r.shard)
+	defer span.Finish()
+
+	start := span.Now()
+	valid := r.family.ValidateSequence(r.leader, sequence)
+	span.Record(tsdb.WriteStageChecker, start)
+	if !valid {
 		r.statistics.localInvalidSequenceVec.Incr()
 		return
 	}
 
 	//TODO add util
 	var err error
+	start = span.Now()
 	r.block, err = snappy.Decode(r.block, msg)
 	if err != nil {
 		r.logger.Error("decompress replica data error", logger.Error(err))
@@ -134,6 +182,7 @@ func (r *localReplicator) Replica(sequence int64, msg []byte) {
 	}()
 
 	r.batchRows.UnmarshalRows(r.block)
+	span.Record(tsdb.WriteStageDecode, start)
 	rowsLen := r.batchRows.Len()
 	if rowsLen == 0 {
 		return
@@ -141,21 +190,55 @@ func (r *localReplicator) Replica(sequence int64, msg []byte) {
 	r.statistics.localReplicaRows.Add(float64(rowsLen))
 	rows := r.batchRows.Rows()
 
-	// write metric metadata
-	if err := r.shard.WriteRows(rows); err != nil {
-		r.logger.Error("failed writing family rows",
-			logger.Int("rows", r.batchRows.Len()),
+	if err := r.applyWithRetry(rows, span); err != nil {
+		// move to dead letter, so that replica stream can proceed
+		r.statistics.localDeadLetters.Incr()
+		r.logger.Error("failed applying replica rows after retries, move msg to dead letter",
+			logger.Int("rows", rowsLen),
 			logger.String("database", r.shard.Database().Name()),
 			logger.Int("shardID", int(r.shard.ShardID())),
+			logger.Int64("sequence", sequence),
 			logger.Error(err))
-		return
+		if err := r.deadLetter.Put(sequence, msg); err != nil {
+			r.statistics.localDeadLetterFails.Incr()
+			r.logger.Error("failed putting msg into dead letter",
+				logger.String("database", r.shard.Database().Name()),
+				logger.Int("shardID", int(r.shard.ShardID())),
+				logger.Int64("sequence", sequence),
+				logger.Error(err))
+		}
 	}
-	// write metric data
-	if err := r.family.WriteRows(rows); err != nil {
-		r.logger.Error("failed writing family rows",
-			logger.Int("rows", r.batchRows.Len()),
+}
+
+// applyWithRetry applies rows, retries with exponential backoff if fail.
+func (r *localReplicator) applyWithRetry(rows []metric.StorageRow, span *tsdb.WriteSpan) error {
+	backoff := r.retryBackoff
+	for attempt := 0; ; attempt++ {
+		err := r.apply(rows, span)
+		if err == nil || attempt >= r.maxRetries {
+			return err
+		}
+		r.statistics.localApplyRetries.Incr()
+		r.logger.Warn("failed applying replica rows, retry later",
 			logger.String("database", r.shard.Database().Name()),
 			logger.Int("shardID", int(r.shard.ShardID())),
+			logger.Int("attempt", attempt+1),
+			logger.String("backoff", backoff.String()),
 			logger.Error(err))
+		sleepFunc(backoff)
+		backoff *= 2
 	}
 }
+
+// apply writes metric metadata and metric data, records the time of stages into span.
+func (r *localReplicator) apply(rows []metric.StorageRow, span *tsdb.WriteSpan) error {
+	// write metric metadata
+	if err := r.shard.WriteRows(rows, span); err != nil {
+		return err
+	}
+	// write metric data
+	start := span.Now()
+	err := r.family.WriteRows(rows)
+	span.Record(tsdb.WriteStageMemTable, start)
+	return err
+}
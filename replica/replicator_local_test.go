@@ -20,10 +20,16 @@ package replica
 import (
 	"bytes"
 	"fmt"
+	"io/ioutil"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/lindb/lindb/config"
 	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/pkg/fasttime"
+	"github.com/lindb/lindb/pkg/fileutil"
+	"github.com/lindb/lindb/pkg/ltoml"
 	"github.com/lindb/lindb/pkg/timeutil"
 	protoMetricsV1 "github.com/lindb/lindb/proto/gen/v1/metrics"
 	"github.com/lindb/lindb/series/metric"
@@ -50,8 +56,10 @@ func TestLocalReplicator_Replica(t *testing.T) {
 	family := tsdb.NewMockDataFamily(ctrl)
 	family.EXPECT().CommitSequence(gomock.Any(), gomock.Any()).AnyTimes()
 	family.EXPECT().AckSequence(gomock.Any(), gomock.Any()).AnyTimes()
+	family.EXPECT().TimeRange().Return(timeutil.TimeRange{}).AnyTimes()
 
-	replicator := NewLocalReplicator(&ReplicatorChannel{State: &models.ReplicaState{Leader: 1}}, shard, family)
+	replicator := NewLocalReplicator(&ReplicatorChannel{State: &models.ReplicaState{Leader: 1}}, shard, family,
+		config.WAL{DeadLetterDir: t.TempDir()})
 	assert.True(t, replicator.IsReady())
 	// bad sequence
 	family.EXPECT().ValidateSequence(gomock.Any(), gomock.Any()).Return(false)
@@ -77,13 +85,86 @@ func TestLocalReplicator_Replica(t *testing.T) {
 	_, _ = row.WriteTo(buf)
 	var dst []byte
 	dst = snappy.Encode(dst, buf.Bytes())
-	shard.EXPECT().WriteRows(gomock.Any()).Return(fmt.Errorf("err"))
+	shard.EXPECT().WriteRows(gomock.Any(), gomock.Any()).Return(fmt.Errorf("err"))
 	replicator.Replica(1, dst)
 
-	shard.EXPECT().WriteRows(gomock.Any()).Return(nil)
+	shard.EXPECT().WriteRows(gomock.Any(), gomock.Any()).Return(nil)
 	family.EXPECT().WriteRows(gomock.Any()).Return(fmt.Errorf("err"))
 	replicator.Replica(1, dst)
 	// bad data
 	dst = snappy.Encode(dst, []byte("bad-data"))
 	replicator.Replica(1, dst)
 }
+
+func TestLocalReplicator_Replica_DeadLetter(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		sleepFunc = time.Sleep
+		mkDirIfNotExistFunc = fileutil.MkDirIfNotExist
+		ctrl.Finish()
+	}()
+	var backoffs []time.Duration
+	sleepFunc = func(d time.Duration) {
+		backoffs = append(backoffs, d)
+	}
+	database := tsdb.NewMockDatabase(ctrl)
+	database.EXPECT().Name().Return("test-database").AnyTimes()
+	shard := tsdb.NewMockShard(ctrl)
+	shard.EXPECT().Database().Return(database).AnyTimes()
+	shard.EXPECT().ShardID().Return(models.ShardID(1)).AnyTimes()
+	family := tsdb.NewMockDataFamily(ctrl)
+	family.EXPECT().CommitSequence(int32(1), gomock.Any()).AnyTimes()
+	family.EXPECT().AckSequence(gomock.Any(), gomock.Any()).AnyTimes()
+	family.EXPECT().ValidateSequence(gomock.Any(), gomock.Any()).Return(true).AnyTimes()
+	familyTime, _ := timeutil.ParseTimestamp("20190904 10:00:00", "20060102 15:04:05")
+	family.EXPECT().TimeRange().Return(timeutil.TimeRange{Start: familyTime}).AnyTimes()
+
+	dir := t.TempDir()
+	replicator := NewLocalReplicator(&ReplicatorChannel{State: &models.ReplicaState{Leader: 1}}, shard, family,
+		config.WAL{
+			ApplyMaxRetries:   2,
+			ApplyRetryBackoff: ltoml.Duration(time.Millisecond),
+			DeadLetterDir:     dir,
+		})
+
+	buf := &bytes.Buffer{}
+	converter := metric.NewProtoConverter()
+	var row metric.BrokerRow
+	_ = converter.ConvertTo(&protoMetricsV1.Metric{
+		Namespace: "test",
+		Name:      "test",
+		Timestamp: fasttime.UnixMilliseconds(),
+		SimpleFields: []*protoMetricsV1.SimpleField{
+			{Name: "f1", Type: protoMetricsV1.SimpleFieldType_Min, Value: 1},
+		},
+	}, &row)
+	_, _ = row.WriteTo(buf)
+	msg := snappy.Encode(nil, buf.Bytes())
+
+	// case 1: apply ok after retry
+	shard.EXPECT().WriteRows(gomock.Any(), gomock.Any()).Return(fmt.Errorf("err"))
+	shard.EXPECT().WriteRows(gomock.Any(), gomock.Any()).Return(nil)
+	family.EXPECT().WriteRows(gomock.Any()).Return(nil)
+	replicator.Replica(1, msg)
+	assert.Equal(t, []time.Duration{time.Millisecond}, backoffs)
+
+	// case 2: apply fails repeatedly, move msg to dead letter
+	backoffs = nil
+	shard.EXPECT().WriteRows(gomock.Any(), gomock.Any()).Return(nil).Times(3)
+	family.EXPECT().WriteRows(gomock.Any()).Return(fmt.Errorf("err")).Times(3)
+	replicator.Replica(2, msg)
+	assert.Equal(t, []time.Duration{time.Millisecond, 2 * time.Millisecond}, backoffs)
+	deadLetterFile := filepath.Join(dir, "test-database", "1",
+		timeutil.FormatTimestamp(familyTime, timeutil.DataTimeFormat4), "1", "2.msg")
+	data, err := ioutil.ReadFile(deadLetterFile)
+	assert.NoError(t, err)
+	assert.Equal(t, msg, data)
+
+	// case 3: put dead letter err
+	mkDirIfNotExistFunc = func(path string) error {
+		return fmt.Errorf("err")
+	}
+	shard.EXPECT().WriteRows(gomock.Any(), gomock.Any()).Return(fmt.Errorf("err")).Times(3)
+	replicator.Replica(3, msg)
+	assert.False(t, fileutil.Exist(filepath.Join(filepath.Dir(deadLetterFile), "3.msg")))
+}
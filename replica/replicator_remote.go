@@ -235,6 +235,17 @@ func (r *remoteReplicator) Replica(idx int64, msg []byte) {
 		logger.String("replicator", r.String()),
 		logger.Int64("replicaIdx", resp.ReplicaIndex),
 		logger.Int64("ackIdx", resp.AckIndex))
+	if resp.Err != "" {
+		// follower rejects replica msg(e.g. sequence gap), pause replica,
+		// then re-sync replica index with follower when check replicator if ready.
+		r.logger.Warn("follower rejects replica msg, need re-sync replica index",
+			logger.String("replicator", r.String()),
+			logger.Int64("replicaIdx", resp.ReplicaIndex),
+			logger.Int64("ackIdx", resp.AckIndex),
+			logger.String("err", resp.Err))
+		r.state = ReplicatorFailureState
+		return
+	}
 	if resp.AckIndex == resp.ReplicaIndex {
 		// if ack index = replica, need ack wal
 		r.SetAckIndex(resp.AckIndex)
@@ -226,4 +226,15 @@ func TestRemoteReplicator_Replica(t *testing.T) {
 	}, nil)
 	q.EXPECT().Ack(int64(1))
 	r.Replica(1, []byte{})
+
+	// follower detects sequence gap, pause replica
+	r1.state = ReplicatorReadyState
+	cli.EXPECT().Send(gomock.Any()).Return(nil)
+	cli.EXPECT().Recv().Return(&protoReplicaV1.ReplicaResponse{
+		AckIndex:     1,
+		ReplicaIndex: 5,
+		Err:          ErrReplicaSequenceGap.Error(),
+	}, nil)
+	r.Replica(5, []byte{})
+	assert.Equal(t, ReplicatorFailureState, r1.state)
 }
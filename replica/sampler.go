@@ -0,0 +1,175 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package replica
+
+import (
+	"path"
+	"sync"
+	"time"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/internal/linmetric"
+	"github.com/lindb/lindb/pkg/fasttime"
+	"github.com/lindb/lindb/series/metric"
+)
+
+// samplingSeriesTTL is the idle time after which the sampling state of series is removed.
+const samplingSeriesTTL = time.Hour
+
+var samplingDroppedCounterVec = databaseChannelScope.NewCounterVec("sampling_dropped_metrics", "db", "pattern")
+
+// samplingSeries represents the sampling state of series.
+type samplingSeries struct {
+	count         int64 // number of samples since last kept sample
+	lastTimestamp int64 // timestamp of last kept sample
+	lastSeen      int64 // wall time of last sample, for removing idle series
+}
+
+// samplingPolicy subsamples the metrics whose name matches the pattern.
+type samplingPolicy struct {
+	cfg         config.Sampling
+	minInterval int64
+
+	series map[string]map[uint64]*samplingSeries // metric name => tags hash => series state
+	mutex  sync.Mutex
+
+	droppedCounter *linmetric.BoundCounter
+}
+
+// keep checks if the sample of series should be kept.
+func (p *samplingPolicy) keep(metricName string, tagsHash uint64, timestamp int64) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	metricSeries, ok := p.series[metricName]
+	if !ok {
+		metricSeries = make(map[uint64]*samplingSeries)
+		p.series[metricName] = metricSeries
+	}
+	s, ok := metricSeries[tagsHash]
+	now := fasttime.UnixMilliseconds()
+	if !ok {
+		// always keep first sample of series
+		metricSeries[tagsHash] = &samplingSeries{lastTimestamp: timestamp, lastSeen: now}
+		return true
+	}
+	s.lastSeen = now
+	switch p.cfg.Mode {
+	case config.SamplingModeKeepOneIn:
+		s.count++
+		if s.count < int64(p.cfg.KeepOneIn) {
+			return false
+		}
+		s.count = 0
+	case config.SamplingModeRateLimit:
+		if timestamp < s.lastTimestamp+p.minInterval {
+			return false
+		}
+	}
+	s.lastTimestamp = timestamp
+	return true
+}
+
+// garbageCollect removes the state of series which are idle for a long time.
+func (p *samplingPolicy) garbageCollect(expired int64) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for metricName, metricSeries := range p.series {
+		for tagsHash, s := range metricSeries {
+			if s.lastSeen < expired {
+				delete(metricSeries, tagsHash)
+			}
+		}
+		if len(metricSeries) == 0 {
+			delete(p.series, metricName)
+		}
+	}
+}
+
+// sampler drops samples of high-frequency metrics by subsampling policies before writing into channel.
+type sampler struct {
+	policies []*samplingPolicy
+
+	matched map[string]*samplingPolicy // metric name => matched policy(nil if not matched)
+	rwMutex sync.RWMutex
+}
+
+// newSampler creates the sampler of database, returns nil if no policy.
+func newSampler(database string, policies []config.Sampling) *sampler {
+	if len(policies) == 0 {
+		return nil
+	}
+	s := &sampler{
+		matched: make(map[string]*samplingPolicy),
+	}
+	for _, cfg := range policies {
+		s.policies = append(s.policies, &samplingPolicy{
+			cfg:            cfg,
+			minInterval:    cfg.MinInterval.Duration().Milliseconds(),
+			series:         make(map[string]map[uint64]*samplingSeries),
+			droppedCounter: samplingDroppedCounterVec.WithTagValues(database, cfg.Pattern),
+		})
+	}
+	return s
+}
+
+// policyOf returns the first policy which matches the metric name, caches the matched result.
+func (s *sampler) policyOf(metricName []byte) *samplingPolicy {
+	s.rwMutex.RLock()
+	policy, ok := s.matched[string(metricName)]
+	s.rwMutex.RUnlock()
+	if ok {
+		return policy
+	}
+	name := string(metricName)
+	for _, p := range s.policies {
+		if matched, _ := path.Match(p.cfg.Pattern, name); matched {
+			policy = p
+			break
+		}
+	}
+	s.rwMutex.Lock()
+	s.matched[name] = policy
+	s.rwMutex.Unlock()
+	return policy
+}
+
+// Sample evicts the samples which are dropped by policies, returns the number of dropped samples.
+func (s *sampler) Sample(brokerBatchRows *metric.BrokerBatchRows) int {
+	return brokerBatchRows.Evict(func(row *metric.BrokerRow) bool {
+		m := row.Metric()
+		policy := s.policyOf(m.Name())
+		if policy == nil {
+			return false
+		}
+		if policy.keep(string(m.Name()), m.Hash(), m.Timestamp()) {
+			return false
+		}
+		policy.droppedCounter.Incr()
+		return true
+	})
+}
+
+// garbageCollect removes the sampling state of idle series.
+func (s *sampler) garbageCollect() {
+	expired := fasttime.UnixMilliseconds() - samplingSeriesTTL.Milliseconds()
+	for _, p := range s.policies {
+		p.garbageCollect(expired)
+	}
+}
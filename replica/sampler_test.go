@@ -0,0 +1,127 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package replica
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/ltoml"
+	protoMetricsV1 "github.com/lindb/lindb/proto/gen/v1/metrics"
+	"github.com/lindb/lindb/series/metric"
+)
+
+func newSamplingBatch(t *testing.T, name string, hosts []string, timestamps []int64) *metric.BrokerBatchRows {
+	converter := metric.NewProtoConverter()
+	batch := metric.NewBrokerBatchRows()
+	for _, timestamp := range timestamps {
+		for _, host := range hosts {
+			assert.NoError(t, batch.TryAppend(func(row *metric.BrokerRow) error {
+				return converter.ConvertTo(&protoMetricsV1.Metric{
+					Name:      name,
+					Timestamp: timestamp,
+					SimpleFields: []*protoMetricsV1.SimpleField{
+						{Name: "f1", Type: protoMetricsV1.SimpleFieldType_DELTA_SUM, Value: 1}},
+					Tags: []*protoMetricsV1.KeyValue{{Key: "host", Value: host}},
+				}, row)
+			}))
+		}
+	}
+	return batch
+}
+
+func keptTimestamps(batch *metric.BrokerBatchRows) (timestamps []int64) {
+	for _, row := range batch.Rows() {
+		if !row.IsOutOfTimeRange {
+			m := row.Metric()
+			timestamps = append(timestamps, m.Timestamp())
+		}
+	}
+	return timestamps
+}
+
+func TestSampler_empty(t *testing.T) {
+	assert.Nil(t, newSampler("db", nil))
+}
+
+func TestSampler_keepOneIn(t *testing.T) {
+	s := newSampler("db", []config.Sampling{
+		{Pattern: "cpu_*", Mode: config.SamplingModeKeepOneIn, KeepOneIn: 3},
+	})
+	dropped := samplingDroppedCounterVec.WithTagValues("db", "cpu_*").Get()
+	// matched metric, keeps 1 in 3 samples of each series
+	batch := newSamplingBatch(t, "cpu_load", []string{"1.1.1.1", "1.1.1.2"}, []int64{1, 2, 3, 4, 5, 6, 7})
+	assert.Equal(t, 8, s.Sample(batch))
+	assert.Equal(t, []int64{1, 1, 4, 4, 7, 7}, keptTimestamps(batch))
+	assert.Equal(t, dropped+8, samplingDroppedCounterVec.WithTagValues("db", "cpu_*").Get())
+	// state of series is kept across batches
+	batch = newSamplingBatch(t, "cpu_load", []string{"1.1.1.1"}, []int64{8, 9, 10})
+	assert.Equal(t, 2, s.Sample(batch))
+	assert.Equal(t, []int64{10}, keptTimestamps(batch))
+	// not matched metric
+	batch = newSamplingBatch(t, "memory", []string{"1.1.1.1"}, []int64{1, 2, 3})
+	assert.Equal(t, 0, s.Sample(batch))
+	assert.Equal(t, []int64{1, 2, 3}, keptTimestamps(batch))
+}
+
+func TestSampler_rateLimit(t *testing.T) {
+	s := newSampler("db", []config.Sampling{
+		{Pattern: "cpu", Mode: config.SamplingModeRateLimit, MinInterval: ltoml.Duration(10 * time.Millisecond)},
+		{Pattern: "*", Mode: config.SamplingModeKeepOneIn, KeepOneIn: 1},
+	})
+	// keeps at most 1 sample of each series in 10ms
+	batch := newSamplingBatch(t, "cpu", []string{"1.1.1.1", "1.1.1.2"}, []int64{100, 105, 109, 110, 125, 134, 135})
+	assert.Equal(t, 6, s.Sample(batch))
+	assert.Equal(t, []int64{100, 100, 110, 110, 125, 125, 135, 135}, keptTimestamps(batch))
+	// the first matched policy works
+	batch = newSamplingBatch(t, "memory", []string{"1.1.1.1"}, []int64{100, 101, 102})
+	assert.Equal(t, 0, s.Sample(batch))
+
+	// remove idle series
+	s.garbageCollect()
+	assert.Len(t, s.policies[0].series["cpu"], 2)
+	s.policies[0].garbageCollect(time.Now().Add(time.Hour).UnixNano() / int64(time.Millisecond))
+	assert.Empty(t, s.policies[0].series)
+	batch = newSamplingBatch(t, "cpu", []string{"1.1.1.1"}, []int64{136})
+	assert.Equal(t, 0, s.Sample(batch))
+}
+
+func TestDatabaseChannel_Write_sampling(t *testing.T) {
+	defer config.SetGlobalBrokerConfig(config.NewDefaultBrokerBase())
+	cfg := config.NewDefaultBrokerBase()
+	cfg.Ingestion.Sampling = []config.Sampling{
+		{Pattern: "cpu", Mode: config.SamplingModeKeepOneIn, KeepOneIn: 2},
+	}
+	config.SetGlobalBrokerConfig(cfg)
+
+	ch, err := newDatabaseChannel(context.TODO(), models.Database{Name: "sampling-db"}, 1, nil)
+	assert.NoError(t, err)
+	dc := ch.(*databaseChannel)
+	assert.NotNil(t, dc.sampler)
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	batch := newSamplingBatch(t, "cpu", []string{"1.1.1.1"}, []int64{now, now + 1, now + 2})
+	_ = ch.Write(context.TODO(), batch)
+	assert.Equal(t, []int64{now, now + 2}, keptTimestamps(batch))
+	dc.garbageCollect()
+	ch.Stop()
+}
@@ -106,6 +106,7 @@ func NewWriteAheadLogManager(
 		stateMgr:      stateMgr,
 	}
 	mgr.databaseLogs.Store(make(databaseLogs))
+	backlogPolicyVec.WithTagValues(cfg.BacklogPolicy).Update(1)
 
 	mgr.garbageCollectTask()
 
@@ -282,7 +283,7 @@ func (w *writeAheadLog) GetOrCreatePartition(
 	if err != nil {
 		return nil, err
 	}
-	p = NewPartition(w.ctx, shard, family, w.currentNodeID, q, w.cliFct, w.stateMgr)
+	p = NewPartition(w.ctx, w.cfg, shard, family, w.currentNodeID, q, w.cliFct, w.stateMgr)
 
 	w.insertPartition(key, p)
 	return p, nil
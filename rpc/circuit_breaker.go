@@ -0,0 +1,176 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package rpc
+
+import (
+	"sync"
+	"time"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/internal/linmetric"
+	"github.com/lindb/lindb/pkg/logger"
+)
+
+//go:generate mockgen -source ./circuit_breaker.go -destination=./circuit_breaker_mock.go -package=rpc
+
+// CircuitBreakerState represents the state of node's circuit breaker.
+type CircuitBreakerState int
+
+const (
+	// CircuitClosed routes requests to the node normally.
+	CircuitClosed CircuitBreakerState = iota
+	// CircuitOpen routes requests around the node.
+	CircuitOpen
+	// CircuitHalfOpen allows a probe request to the node for checking if it recovers.
+	CircuitHalfOpen
+)
+
+var circuitBreakerStateGaugeVec = linmetric.NewScope("lindb.broker.circuit_breaker").
+	NewGaugeVec("state", "node")
+
+// CircuitBreakers represents the circuit breakers of storage nodes in broker,
+// the breaker of node trips after consecutive failed or slow responses,
+// so that requests are routed around the unhealthy node.
+type CircuitBreakers interface {
+	// Allow returns if the request can be routed to the node,
+	// a probe request is allowed after the breaker keeps open for open duration.
+	Allow(node string) bool
+	// RecordSuccess records the response of node, slow response is treated as failure.
+	RecordSuccess(node string, latency time.Duration)
+	// RecordFailure records the failed request/response of node.
+	RecordFailure(node string)
+	// State returns the breaker state of node.
+	State(node string) CircuitBreakerState
+}
+
+// circuitBreaker represents the breaker state of a node.
+type circuitBreaker struct {
+	state    CircuitBreakerState
+	failures int       // consecutive failures
+	openedAt time.Time // when the breaker opens or the last probe is allowed
+}
+
+// circuitBreakers implements CircuitBreakers interface.
+type circuitBreakers struct {
+	cfg      config.CircuitBreaker
+	breakers map[string]*circuitBreaker // node => breaker
+	mutex    sync.Mutex
+	now      func() time.Time
+
+	logger *logger.Logger
+}
+
+// NewCircuitBreakers creates the circuit breakers of nodes,
+// breaker never trips if failure threshold <= 0.
+func NewCircuitBreakers(cfg config.CircuitBreaker) CircuitBreakers {
+	return &circuitBreakers{
+		cfg:      cfg,
+		breakers: make(map[string]*circuitBreaker),
+		now:      time.Now,
+		logger:   logger.GetLogger("rpc", "CircuitBreaker"),
+	}
+}
+
+// Allow returns if the request can be routed to the node,
+// a probe request is allowed after the breaker keeps open for open duration.
+func (cb *circuitBreakers) Allow(node string) bool {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	breaker, ok := cb.breakers[node]
+	if !ok || breaker.state == CircuitClosed {
+		return true
+	}
+	// open or probing, allows next probe after open duration,
+	// in case of the response of last probe is lost.
+	if cb.now().Sub(breaker.openedAt) < cb.cfg.OpenDuration.Duration() {
+		return false
+	}
+	breaker.openedAt = cb.now()
+	cb.setState(node, breaker, CircuitHalfOpen)
+	return true
+}
+
+// RecordSuccess records the response of node, slow response is treated as failure.
+func (cb *circuitBreakers) RecordSuccess(node string, latency time.Duration) {
+	slowThreshold := cb.cfg.SlowThreshold.Duration()
+	if slowThreshold > 0 && latency > slowThreshold {
+		cb.RecordFailure(node)
+		return
+	}
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	breaker, ok := cb.breakers[node]
+	if !ok {
+		return
+	}
+	breaker.failures = 0
+	if breaker.state != CircuitClosed {
+		cb.setState(node, breaker, CircuitClosed)
+		cb.logger.Info("circuit breaker closed, node recovered", logger.String("node", node))
+	}
+}
+
+// RecordFailure records the failed request/response of node.
+func (cb *circuitBreakers) RecordFailure(node string) {
+	if cb.cfg.FailureThreshold <= 0 {
+		return
+	}
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	breaker, ok := cb.breakers[node]
+	if !ok {
+		breaker = &circuitBreaker{}
+		cb.breakers[node] = breaker
+	}
+	breaker.failures++
+	switch breaker.state {
+	case CircuitClosed:
+		if breaker.failures < cb.cfg.FailureThreshold {
+			return
+		}
+	case CircuitOpen:
+		// requests sent before breaker opens
+		return
+	}
+	// trips the breaker, or probe failed
+	breaker.openedAt = cb.now()
+	cb.setState(node, breaker, CircuitOpen)
+	cb.logger.Warn("circuit breaker opened, route requests around node",
+		logger.String("node", node), logger.Int("failures", breaker.failures))
+}
+
+// State returns the breaker state of node.
+func (cb *circuitBreakers) State(node string) CircuitBreakerState {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	breaker, ok := cb.breakers[node]
+	if !ok {
+		return CircuitClosed
+	}
+	return breaker.state
+}
+
+// setState sets the breaker state of node, must be called with lock.
+func (cb *circuitBreakers) setState(node string, breaker *circuitBreaker, state CircuitBreakerState) {
+	breaker.state = state
+	circuitBreakerStateGaugeVec.WithTagValues(node).Update(float64(state))
+}
@@ -0,0 +1,94 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package rpc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/pkg/ltoml"
+)
+
+func TestCircuitBreakers_TripAndRecover(t *testing.T) {
+	now := time.Now()
+	breakers := NewCircuitBreakers(config.CircuitBreaker{
+		FailureThreshold: 3,
+		OpenDuration:     ltoml.Duration(time.Minute),
+	}).(*circuitBreakers)
+	breakers.now = func() time.Time { return now }
+	node := "1.1.1.1:9000"
+	stateGauge := circuitBreakerStateGaugeVec.WithTagValues(node)
+
+	// success resets consecutive failures
+	breakers.RecordFailure(node)
+	breakers.RecordFailure(node)
+	breakers.RecordSuccess(node, time.Millisecond)
+	breakers.RecordFailure(node)
+	breakers.RecordFailure(node)
+	assert.Equal(t, CircuitClosed, breakers.State(node))
+	assert.True(t, breakers.Allow(node))
+	// trips after repeated failures
+	breakers.RecordFailure(node)
+	assert.Equal(t, CircuitOpen, breakers.State(node))
+	assert.Equal(t, float64(CircuitOpen), stateGauge.Get())
+	assert.False(t, breakers.Allow(node))
+	// probe allowed after open duration, probe failed
+	now = now.Add(time.Minute)
+	assert.True(t, breakers.Allow(node))
+	assert.Equal(t, CircuitHalfOpen, breakers.State(node))
+	assert.False(t, breakers.Allow(node))
+	breakers.RecordFailure(node)
+	assert.Equal(t, CircuitOpen, breakers.State(node))
+	assert.False(t, breakers.Allow(node))
+	// probe succeeded, recovered
+	now = now.Add(time.Minute)
+	assert.True(t, breakers.Allow(node))
+	breakers.RecordSuccess(node, time.Millisecond)
+	assert.Equal(t, CircuitClosed, breakers.State(node))
+	assert.Equal(t, float64(CircuitClosed), stateGauge.Get())
+	assert.True(t, breakers.Allow(node))
+	// unknown node
+	assert.Equal(t, CircuitClosed, breakers.State("2.2.2.2:9000"))
+	breakers.RecordSuccess("2.2.2.2:9000", time.Millisecond)
+}
+
+func TestCircuitBreakers_SlowResponse(t *testing.T) {
+	breakers := NewCircuitBreakers(config.CircuitBreaker{
+		FailureThreshold: 2,
+		SlowThreshold:    ltoml.Duration(time.Second),
+		OpenDuration:     ltoml.Duration(time.Minute),
+	})
+	node := "1.1.1.1:9001"
+	breakers.RecordSuccess(node, 2*time.Second)
+	breakers.RecordSuccess(node, 3*time.Second)
+	assert.Equal(t, CircuitOpen, breakers.State(node))
+	assert.False(t, breakers.Allow(node))
+}
+
+func TestCircuitBreakers_Disabled(t *testing.T) {
+	breakers := NewCircuitBreakers(config.CircuitBreaker{OpenDuration: ltoml.Duration(time.Minute)})
+	node := "1.1.1.1:9002"
+	for i := 0; i < 10; i++ {
+		breakers.RecordFailure(node)
+	}
+	assert.Equal(t, CircuitClosed, breakers.State(node))
+	assert.True(t, breakers.Allow(node))
+}
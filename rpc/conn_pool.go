@@ -0,0 +1,272 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package rpc
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/atomic"
+	"google.golang.org/grpc"
+
+	"github.com/lindb/lindb/internal/linmetric"
+	"github.com/lindb/lindb/models"
+)
+
+var (
+	connPoolScope          = linmetric.NewScope("lindb.rpc.conn_pool")
+	activeConnsGaugeVec    = connPoolScope.NewGaugeVec("active_conns", "target")
+	idleConnsGaugeVec      = connPoolScope.NewGaugeVec("idle_conns", "target")
+	createdConnsCounterVec = connPoolScope.NewCounterVec("created_conns", "target")
+)
+
+// ConnPoolOption represents the options of connection pool for each target node.
+type ConnPoolOption struct {
+	MinSize     int           // min num. of connections kept in pool
+	MaxSize     int           // max num. of connections in pool
+	IdleTimeout time.Duration // idle connection is evicted after this duration if pool has more than min size
+}
+
+// pooledConn represents a connection in pool, tracks the active streams/calls on it.
+type pooledConn struct {
+	conn     *grpc.ClientConn
+	pool     *connPool
+	streams  atomic.Int32 // num. of active streams/calls
+	lastUsed atomic.Int64 // unix nano, when the connection becomes idle
+}
+
+// connPool represents the grpc connections of a target node,
+// stream/call is created on the connection with the least active streams,
+// so that streams of queries and writes are spread on multi-connections.
+type connPool struct {
+	target models.Node
+	option ConnPoolOption
+	conns  []*pooledConn
+	mutex  sync.Mutex
+	dial   func(target models.Node, pc *pooledConn) (*grpc.ClientConn, error)
+
+	activeConns  *linmetric.BoundGauge
+	idleConns    *linmetric.BoundGauge
+	createdConns *linmetric.BoundCounter
+}
+
+// newConnPool creates the connection pool of target node.
+func newConnPool(
+	target models.Node,
+	option ConnPoolOption,
+	dial func(target models.Node, pc *pooledConn) (*grpc.ClientConn, error),
+) *connPool {
+	indicator := target.Indicator()
+	return &connPool{
+		target:       target,
+		option:       option,
+		dial:         dial,
+		activeConns:  activeConnsGaugeVec.WithTagValues(indicator),
+		idleConns:    idleConnsGaugeVec.WithTagValues(indicator),
+		createdConns: createdConnsCounterVec.WithTagValues(indicator),
+	}
+}
+
+// get returns the connection with the least active streams,
+// creates a new connection if all connections are active and pool is not full.
+// The returned connection is refreshed as just used, so that it is not evicted as idle
+// before the caller creates stream/call on it.
+func (p *connPool) get() (*grpc.ClientConn, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for len(p.conns) < p.option.MinSize {
+		if _, err := p.newConn(); err != nil {
+			return nil, err
+		}
+	}
+	var least *pooledConn
+	for _, pc := range p.conns {
+		if least == nil || pc.streams.Load() < least.streams.Load() {
+			least = pc
+		}
+	}
+	if least == nil || (least.streams.Load() > 0 && len(p.conns) < p.option.MaxSize) {
+		pc, err := p.newConn()
+		if err != nil {
+			return nil, err
+		}
+		least = pc
+	}
+	least.lastUsed.Store(time.Now().UnixNano())
+	return least.conn, nil
+}
+
+// newConn dials a new connection into pool, must be called with lock.
+func (p *connPool) newConn() (*pooledConn, error) {
+	pc := &pooledConn{pool: p}
+	pc.lastUsed.Store(time.Now().UnixNano())
+	conn, err := p.dial(p.target, pc)
+	if err != nil {
+		return nil, err
+	}
+	pc.conn = conn
+	p.conns = append(p.conns, pc)
+	p.createdConns.Incr()
+	p.updateStats()
+	return pc, nil
+}
+
+// evictIdle closes the connections idle longer than idle timeout, keeps min size connections.
+func (p *connPool) evictIdle(now time.Time) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	idleTimeout := p.option.IdleTimeout.Nanoseconds()
+	remaining := len(p.conns)
+	conns := p.conns[:0]
+	for _, pc := range p.conns {
+		if remaining > p.option.MinSize &&
+			pc.streams.Load() == 0 && now.UnixNano()-pc.lastUsed.Load() >= idleTimeout {
+			_ = pc.conn.Close()
+			remaining--
+			continue
+		}
+		conns = append(conns, pc)
+	}
+	p.conns = conns
+	p.updateStats()
+}
+
+// close closes all connections in pool.
+func (p *connPool) close() (err error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for _, pc := range p.conns {
+		if closeErr := pc.conn.Close(); closeErr != nil {
+			err = closeErr
+		}
+	}
+	p.conns = nil
+	p.updateStats()
+	return err
+}
+
+// updateStats updates the active/idle connections of pool, must be called with lock.
+func (p *connPool) updateStats() {
+	active := 0
+	for _, pc := range p.conns {
+		if pc.streams.Load() > 0 {
+			active++
+		}
+	}
+	p.activeConns.Update(float64(active))
+	p.idleConns.Update(float64(len(p.conns) - active))
+}
+
+// acquire marks a stream/call created on the connection.
+func (pc *pooledConn) acquire() {
+	pc.streams.Inc()
+	pc.pool.mutex.Lock()
+	pc.pool.updateStats()
+	pc.pool.mutex.Unlock()
+}
+
+// release marks a stream/call finished on the connection.
+func (pc *pooledConn) release() {
+	if pc.streams.Dec() == 0 {
+		pc.lastUsed.Store(time.Now().UnixNano())
+	}
+	pc.pool.mutex.Lock()
+	pc.pool.updateStats()
+	pc.pool.mutex.Unlock()
+}
+
+// unaryClientInterceptor tracks the active calls on the connection.
+func (pc *pooledConn) unaryClientInterceptor(
+	ctx context.Context,
+	method string,
+	req, reply interface{},
+	cc *grpc.ClientConn,
+	invoker grpc.UnaryInvoker,
+	opts ...grpc.CallOption,
+) error {
+	pc.acquire()
+	defer pc.release()
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+// streamClientInterceptor tracks the active streams on the connection.
+func (pc *pooledConn) streamClientInterceptor(
+	ctx context.Context,
+	desc *grpc.StreamDesc,
+	cc *grpc.ClientConn,
+	method string,
+	streamer grpc.Streamer,
+	opts ...grpc.CallOption,
+) (grpc.ClientStream, error) {
+	pc.acquire()
+	clientStream, err := streamer(ctx, desc, cc, method, opts...)
+	if err != nil {
+		pc.release()
+		return nil, err
+	}
+	stream := &pooledClientStream{
+		ClientStream: clientStream,
+		done:         make(chan struct{}),
+		release:      pc.release,
+	}
+	// stream finishes if context canceled
+	go func() {
+		select {
+		case <-ctx.Done():
+			stream.finish()
+		case <-stream.done:
+		}
+	}()
+	return stream, nil
+}
+
+// pooledClientStream wraps grpc.ClientStream, releases the connection when stream finishes.
+type pooledClientStream struct {
+	grpc.ClientStream
+	once    sync.Once
+	done    chan struct{}
+	release func()
+}
+
+func (s *pooledClientStream) SendMsg(m interface{}) error {
+	err := s.ClientStream.SendMsg(m)
+	if err != nil {
+		s.finish()
+	}
+	return err
+}
+
+func (s *pooledClientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err != nil {
+		s.finish()
+	}
+	return err
+}
+
+// finish releases the connection once.
+func (s *pooledClientStream) finish() {
+	s.once.Do(func() {
+		close(s.done)
+		s.release()
+	})
+}
@@ -0,0 +1,230 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+
+	"github.com/lindb/lindb/models"
+)
+
+type mockClientStream struct {
+	grpc.ClientStream
+	recvErr error
+}
+
+func (s *mockClientStream) SendMsg(_ interface{}) error { return nil }
+func (s *mockClientStream) RecvMsg(_ interface{}) error { return s.recvErr }
+
+func TestConnPool_Reuse(t *testing.T) {
+	target := &models.StatelessNode{HostIP: "127.0.0.10", GRPCPort: 9000}
+	fct := newClientConnFactory()
+	pool := newConnPool(target, ConnPoolOption{MinSize: 1, MaxSize: 2, IdleTimeout: time.Minute}, fct.dial)
+	defer func() {
+		_ = pool.close()
+	}()
+	created := createdConnsCounterVec.WithTagValues(target.Indicator())
+	active := activeConnsGaugeVec.WithTagValues(target.Indicator())
+	idle := idleConnsGaugeVec.WithTagValues(target.Indicator())
+	createdBefore := created.Get()
+
+	// idle connection is reused
+	conn1, err := pool.get()
+	assert.NoError(t, err)
+	conn2, err := pool.get()
+	assert.NoError(t, err)
+	assert.Same(t, conn1, conn2)
+	assert.Equal(t, createdBefore+1, created.Get())
+	// all connections are active, creates new one
+	pool.conns[0].acquire()
+	conn3, err := pool.get()
+	assert.NoError(t, err)
+	assert.NotSame(t, conn1, conn3)
+	assert.Equal(t, createdBefore+2, created.Get())
+	assert.Equal(t, float64(1), active.Get())
+	assert.Equal(t, float64(1), idle.Get())
+	// pool is full, reuses the connection with the least active streams
+	pool.conns[1].acquire()
+	pool.conns[1].acquire()
+	conn4, err := pool.get()
+	assert.NoError(t, err)
+	assert.Same(t, conn1, conn4)
+	assert.Equal(t, createdBefore+2, created.Get())
+	assert.Equal(t, float64(2), active.Get())
+}
+
+func TestConnPool_EvictIdle(t *testing.T) {
+	target := &models.StatelessNode{HostIP: "127.0.0.11", GRPCPort: 9000}
+	fct := newClientConnFactory()
+	pool := newConnPool(target, ConnPoolOption{MinSize: 1, MaxSize: 3, IdleTimeout: time.Minute}, fct.dial)
+	defer func() {
+		_ = pool.close()
+	}()
+	for i := 0; i < 3; i++ {
+		_, err := pool.get()
+		assert.NoError(t, err)
+		pool.conns[i].acquire()
+	}
+	assert.Len(t, pool.conns, 3)
+	// release 2 connections, one keeps active
+	pool.conns[0].release()
+	pool.conns[1].release()
+
+	// not timeout
+	pool.evictIdle(time.Now())
+	assert.Len(t, pool.conns, 3)
+	// evicts idle connections after timeout
+	pool.evictIdle(time.Now().Add(time.Minute))
+	assert.Len(t, pool.conns, 1)
+	assert.Equal(t, int32(1), pool.conns[0].streams.Load())
+	// keeps min size connections
+	pool.conns[0].release()
+	pool.evictIdle(time.Now().Add(time.Minute))
+	assert.Len(t, pool.conns, 1)
+	assert.Equal(t, float64(1), idleConnsGaugeVec.WithTagValues(target.Indicator()).Get())
+}
+
+func TestConnPool_EvictIdle_Got(t *testing.T) {
+	target := &models.StatelessNode{HostIP: "127.0.0.12", GRPCPort: 9000}
+	fct := newClientConnFactory()
+	pool := newConnPool(target, ConnPoolOption{MinSize: 0, MaxSize: 1, IdleTimeout: time.Minute}, fct.dial)
+	defer func() {
+		_ = pool.close()
+	}()
+	_, err := pool.get()
+	assert.NoError(t, err)
+	pool.conns[0].lastUsed.Store(time.Now().Add(-time.Hour).UnixNano())
+	// connection got from pool is not evicted before stream created on it
+	conn, err := pool.get()
+	assert.NoError(t, err)
+	pool.evictIdle(time.Now())
+	assert.Len(t, pool.conns, 1)
+	assert.Same(t, conn, pool.conns[0].conn)
+}
+
+func TestConnPool_StreamInterceptor(t *testing.T) {
+	target := &models.StatelessNode{HostIP: "127.0.0.12", GRPCPort: 9000}
+	fct := newClientConnFactory()
+	pool := newConnPool(target, ConnPoolOption{MinSize: 1, MaxSize: 1, IdleTimeout: time.Minute}, fct.dial)
+	defer func() {
+		_ = pool.close()
+	}()
+	_, err := pool.get()
+	assert.NoError(t, err)
+	pc := pool.conns[0]
+
+	// create stream failure
+	_, err = pc.streamClientInterceptor(context.TODO(), nil, nil, "",
+		func(_ context.Context, _ *grpc.StreamDesc, _ *grpc.ClientConn, _ string, _ ...grpc.CallOption) (grpc.ClientStream, error) {
+			return nil, fmt.Errorf("err")
+		})
+	assert.Error(t, err)
+	assert.Equal(t, int32(0), pc.streams.Load())
+
+	// stream finishes after receiving error
+	cs := &mockClientStream{}
+	stream, err := pc.streamClientInterceptor(context.TODO(), nil, nil, "",
+		func(_ context.Context, _ *grpc.StreamDesc, _ *grpc.ClientConn, _ string, _ ...grpc.CallOption) (grpc.ClientStream, error) {
+			return cs, nil
+		})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), pc.streams.Load())
+	assert.NoError(t, stream.SendMsg(nil))
+	assert.NoError(t, stream.RecvMsg(nil))
+	cs.recvErr = io.EOF
+	assert.Equal(t, io.EOF, stream.RecvMsg(nil))
+	assert.Equal(t, io.EOF, stream.RecvMsg(nil))
+	assert.Equal(t, int32(0), pc.streams.Load())
+
+	// stream finishes after context canceled
+	ctx, cancel := context.WithCancel(context.TODO())
+	_, err = pc.streamClientInterceptor(ctx, nil, nil, "",
+		func(_ context.Context, _ *grpc.StreamDesc, _ *grpc.ClientConn, _ string, _ ...grpc.CallOption) (grpc.ClientStream, error) {
+			return &mockClientStream{}, nil
+		})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), pc.streams.Load())
+	cancel()
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, int32(0), pc.streams.Load())
+
+	// unary call
+	err = pc.unaryClientInterceptor(context.TODO(), "", nil, nil, nil,
+		func(_ context.Context, _ string, _, _ interface{}, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+			assert.Equal(t, int32(1), pc.streams.Load())
+			return nil
+		})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(0), pc.streams.Load())
+}
+
+func TestClientConnFactory_ConnPool(t *testing.T) {
+	fct := newClientConnFactory()
+	fct.option = ConnPoolOption{MinSize: 2, MaxSize: 2, IdleTimeout: time.Minute}
+	target := &models.StatelessNode{HostIP: "127.0.0.13", GRPCPort: 9000}
+	conn, err := fct.GetClientConn(target)
+	assert.NoError(t, err)
+	assert.NotNil(t, conn)
+	assert.Len(t, fct.pools[target.Indicator()].conns, 2)
+	assert.NoError(t, fct.CloseClientConn(target))
+	assert.Empty(t, fct.pools)
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		fct.evictIdleConns(10*time.Millisecond, stop)
+		close(done)
+	}()
+	_, err = fct.GetClientConn(target)
+	assert.NoError(t, err)
+	time.Sleep(50 * time.Millisecond)
+	close(stop)
+	<-done
+	assert.NoError(t, fct.Close())
+}
+
+func TestClientConnFactory_Close(t *testing.T) {
+	fct := newClientConnFactory()
+	target := &models.StatelessNode{HostIP: "127.0.0.14", GRPCPort: 9000}
+	_, err := fct.GetClientConn(target)
+	assert.NoError(t, err)
+	stop := fct.evictStop
+	assert.NotNil(t, stop)
+
+	assert.NoError(t, fct.Close())
+	assert.Empty(t, fct.pools)
+	assert.Nil(t, fct.evictStop)
+	_, ok := <-stop
+	assert.False(t, ok)
+	// close again
+	assert.NoError(t, fct.Close())
+
+	// recreate pool and restart evicting after closed
+	_, err = fct.GetClientConn(target)
+	assert.NoError(t, err)
+	assert.Len(t, fct.pools, 1)
+	assert.NotNil(t, fct.evictStop)
+	assert.NoError(t, fct.Close())
+}
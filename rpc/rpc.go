@@ -21,6 +21,7 @@ import (
 	"context"
 	"errors"
 	"sync"
+	"time"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
@@ -39,30 +40,45 @@ var (
 	clientConnFct ClientConnFactory
 )
 
+// evictIdleConnsInterval is the interval of checking idle connections in pools.
+const evictIdleConnsInterval = 10 * time.Second
+
 func init() {
-	clientConnFct = &clientConnFactory{
-		connMap:       make(map[string]*grpc.ClientConn),
-		clientTracker: conntrack.NewGRPCClientTracker(),
-	}
+	clientConnFct = newClientConnFactory()
 }
 
 // ClientConnFactory is the factory for grpc ClientConn.
 type ClientConnFactory interface {
-	// GetClientConn returns the grpc ClientConn for target node.
-	// One connection for a target node.
+	// GetClientConn returns the grpc ClientConn for target node,
+	// connections of target node are pooled, returns the connection with the least active streams.
 	// Concurrent safe.
 	GetClientConn(target models.Node) (*grpc.ClientConn, error)
-	// CloseClientConn closes client connection for spec target node.
+	// CloseClientConn closes client connections for spec target node.
 	CloseClientConn(target models.Node) error
+	// Close stops evicting idle connections and closes all pooled connections,
+	// the factory is still usable after closed, pools are recreated on demand.
+	Close() error
 }
 
 // clientConnFactory implements ClientConnFactory.
 type clientConnFactory struct {
-	// target's indicator -> connection
-	connMap map[string]*grpc.ClientConn
-	// lock to protect connMap
+	// target's indicator -> connection pool
+	pools map[string]*connPool
+	// lock to protect pools
 	mu            sync.RWMutex
+	option        ConnPoolOption
 	clientTracker *conntrack.GRPCClientTracker
+	// evictStop stops the evicting goroutine, nil if not running, protected by mu
+	evictStop chan struct{}
+}
+
+// newClientConnFactory creates the ClientConnFactory with one connection for each target node by default.
+func newClientConnFactory() *clientConnFactory {
+	return &clientConnFactory{
+		pools:         make(map[string]*connPool),
+		option:        ConnPoolOption{MinSize: 1, MaxSize: 1},
+		clientTracker: conntrack.NewGRPCClientTracker(),
+	}
 }
 
 // GetClientConnFactory returns a singleton ClientConnFactory.
@@ -70,62 +86,125 @@ func GetClientConnFactory() ClientConnFactory {
 	return clientConnFct
 }
 
+// SetConnPoolOption sets the connection pool option of singleton ClientConnFactory,
+// should be called before connections are created.
+func SetConnPoolOption(option ConnPoolOption) {
+	fct := clientConnFct.(*clientConnFactory)
+	fct.mu.Lock()
+	defer fct.mu.Unlock()
+	fct.option = option
+}
+
 // GetClientConn returns the grpc ClientConn for a target node.
 // Concurrent safe.
 func (fct *clientConnFactory) GetClientConn(target models.Node) (*grpc.ClientConn, error) {
 	indicator := target.Indicator()
 	fct.mu.RLock()
-	conn, ok := fct.connMap[indicator]
+	pool, ok := fct.pools[indicator]
 	fct.mu.RUnlock()
 	if ok {
-		return conn, nil
+		return pool.get()
 	}
 
 	fct.mu.Lock()
-	defer fct.mu.Unlock()
-
 	// double check
-	conn, ok = fct.connMap[indicator]
-	if ok {
-		return conn, nil
+	pool, ok = fct.pools[indicator]
+	if !ok {
+		pool = newConnPool(target, fct.option, fct.dial)
+		fct.pools[indicator] = pool
+	}
+	if fct.evictStop == nil {
+		fct.evictStop = make(chan struct{})
+		go fct.evictIdleConns(evictIdleConnsInterval, fct.evictStop)
 	}
-	conn, err := grpc.Dial(
+	fct.mu.Unlock()
+
+	return pool.get()
+}
+
+// dial creates a grpc ClientConn for target node, tracks the active streams of connection in pool.
+func (fct *clientConnFactory) dial(target models.Node, pc *pooledConn) (*grpc.ClientConn, error) {
+	return grpc.Dial(
 		target.Indicator(),
 		grpc.WithInsecure(),
-		grpc.WithStreamInterceptor(fct.clientTracker.StreamClientInterceptor()),
-		grpc.WithUnaryInterceptor(fct.clientTracker.UnaryClientInterceptor()),
+		grpc.WithChainStreamInterceptor(
+			pc.streamClientInterceptor,
+			fct.clientTracker.StreamClientInterceptor(),
+		),
+		grpc.WithChainUnaryInterceptor(
+			pc.unaryClientInterceptor,
+			fct.clientTracker.UnaryClientInterceptor(),
+		),
 	)
-	if err != nil {
-		return nil, err
-	}
+}
 
-	fct.connMap[indicator] = conn
-	return conn, nil
+// evictIdleConns evicts the idle connections of all pools periodically until stop is closed.
+func (fct *clientConnFactory) evictIdleConns(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		var now time.Time
+		select {
+		case <-stop:
+			return
+		case now = <-ticker.C:
+		}
+		fct.mu.RLock()
+		pools := make([]*connPool, 0, len(fct.pools))
+		for _, pool := range fct.pools {
+			pools = append(pools, pool)
+		}
+		fct.mu.RUnlock()
+
+		for _, pool := range pools {
+			pool.evictIdle(now)
+		}
+	}
 }
 
-// CloseClientConn closes client connection for spec target node.
+// CloseClientConn closes client connections for spec target node.
 func (fct *clientConnFactory) CloseClientConn(target models.Node) error {
 	indicator := target.Indicator()
 
 	fct.mu.RLock()
-	conn, ok := fct.connMap[indicator]
+	pool, ok := fct.pools[indicator]
 	fct.mu.RUnlock()
 
 	if ok {
-		// if connection exist for node
-		if err := conn.Close(); err != nil {
+		// if connections exist for node
+		if err := pool.close(); err != nil {
 			// if close err, keep it, try reconnect, maybe get some err for connection closed before reconnected
 			return err
 		}
-		// if close success, need remove connection from cache
+		// if close success, need remove connection pool from cache
 		fct.mu.Lock()
-		delete(fct.connMap, indicator)
+		delete(fct.pools, indicator)
 		fct.mu.Unlock()
 	}
 
 	return nil
 }
 
+// Close stops evicting idle connections and closes all pooled connections.
+func (fct *clientConnFactory) Close() (err error) {
+	fct.mu.Lock()
+	if fct.evictStop != nil {
+		close(fct.evictStop)
+		fct.evictStop = nil
+	}
+	pools := fct.pools
+	fct.pools = make(map[string]*connPool)
+	fct.mu.Unlock()
+
+	for _, pool := range pools {
+		if closeErr := pool.close(); closeErr != nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
 // ClientStreamFactory is the factory to get ClientStream.
 type ClientStreamFactory interface {
 	// LogicNode returns the a logic Node which will be transferred to the target server for identification.
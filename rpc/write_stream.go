@@ -19,11 +19,17 @@ package rpc
 
 import (
 	"context"
+	"errors"
 	"io"
+	"sync"
+	"time"
 
 	"go.uber.org/atomic"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/internal/linmetric"
 	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/pkg/encoding"
 	"github.com/lindb/lindb/pkg/logger"
@@ -32,12 +38,21 @@ import (
 
 //go:generate mockgen -source=./write_stream.go -destination=./write_stream_mock.go -package=rpc
 
+// for testing
+var flowControlRetryInterval = 10 * time.Millisecond
+
+// writeLatencyTimerVec records the latency from broker ingestion accepting the write to storage syncing it into wal.
+var writeLatencyTimerVec = linmetric.NewScope("lindb.broker.write_stream").
+	Scope("end_to_end_write_duration").NewHistogramVec("db")
+
 // WriteStream represents the channel which writes metric to storage based on grpc stream,
 // and receives write response in background.
 type WriteStream interface {
 	io.Closer
-	// Send sends metric data to storage.
-	Send(data []byte) error
+	// Send sends write request to storage, blocks until backpressure is released if storage signals backpressure.
+	// ack is called once with wal sequence of request when storage responds the request or stream closed,
+	// nil if not waiting for ack.
+	Send(req *protoWriteV1.WriteRequest, ack func(seq int64, err error)) error
 }
 
 // writeStream implements WriteStream interface.
@@ -49,32 +64,48 @@ type writeStream struct {
 	database   string
 	shardState *models.ShardState
 	familyTime int64
+	// streamWrite writes by StreamWrite rpc with flow control, otherwise by Write rpc
+	streamWrite bool
 
-	fct    ClientStreamFactory
+	fct ClientStreamFactory
+	// cli is the client of Write or StreamWrite rpc, both have the same stream methods
 	cli    protoWriteV1.WriteService_WriteClient
 	closed *atomic.Bool
+	// paused is set when storage signals backpressure by flow control
+	paused *atomic.Bool
+
+	// acks of requests waiting for response, storage responds requests in order
+	pendingAcks []func(seq int64, err error)
+	ackLock     sync.Mutex
+
+	writeLatency *linmetric.BoundHistogram
 
 	logger *logger.Logger
 }
 
 // NewWriteStream creates a WriteStream instance, initialize grpc connection(stream) and receive response task.
+// If streamWrite is false, writes by Write rpc without flow control, which is supported by storage of old version.
 func NewWriteStream(
 	ctx context.Context,
 	target models.Node,
 	database string, shardState *models.ShardState, familyTime int64,
+	streamWrite bool,
 	fct ClientStreamFactory,
 ) (WriteStream, error) {
 	c, cancel := context.WithCancel(ctx)
 	s := &writeStream{
-		ctx:        c,
-		cancel:     cancel,
-		target:     target,
-		database:   database,
-		shardState: shardState,
-		familyTime: familyTime,
-		fct:        fct,
-		closed:     atomic.NewBool(false),
-		logger:     logger.GetLogger("rpc", "WriteStream"),
+		ctx:          c,
+		cancel:       cancel,
+		target:       target,
+		database:     database,
+		shardState:   shardState,
+		familyTime:   familyTime,
+		streamWrite:  streamWrite,
+		fct:          fct,
+		closed:       atomic.NewBool(false),
+		paused:       atomic.NewBool(false),
+		writeLatency: writeLatencyTimerVec.WithTagValues(database),
+		logger:       logger.GetLogger("rpc", "WriteStream"),
 	}
 
 	// initialize write stream
@@ -98,8 +129,12 @@ func (s *writeStream) initialize() error {
 		FamilyTime: s.familyTime,
 	})
 	ctx := CreateOutgoingContextWithPairs(s.ctx, constants.RPCMetaKeyFamilyState, string(familyState))
-	writeCli, err := writeService.Write(ctx)
-
+	var writeCli protoWriteV1.WriteService_WriteClient
+	if s.streamWrite {
+		writeCli, err = writeService.StreamWrite(ctx)
+	} else {
+		writeCli, err = writeService.Write(ctx)
+	}
 	if err != nil {
 		return err
 	}
@@ -117,13 +152,61 @@ func (s *writeStream) initialize() error {
 	return nil
 }
 
-// Send sends metric data to storage.
-func (s *writeStream) Send(data []byte) error {
+// Send sends write request to storage, blocks until backpressure is released if storage signals backpressure.
+// ack is called once with wal sequence of request when storage responds the request or stream closed,
+// nil if not waiting for ack.
+func (s *writeStream) Send(req *protoWriteV1.WriteRequest, ack func(seq int64, err error)) error {
+	for s.paused.Load() && !s.closed.Load() {
+		select {
+		case <-s.ctx.Done():
+			return io.EOF
+		case <-time.After(flowControlRetryInterval):
+		}
+	}
 	if s.closed.Load() {
 		// if write stream is closed, return EOF err
 		return io.EOF
 	}
-	return s.cli.Send(&protoWriteV1.WriteRequest{Record: data})
+	// every request has a response, so appends ack even if nil for matching response in order
+	s.ackLock.Lock()
+	s.pendingAcks = append(s.pendingAcks, ack)
+	s.ackLock.Unlock()
+	if err := s.cli.Send(req); err != nil {
+		// request not sent, removes the ack of it
+		s.ackLock.Lock()
+		s.pendingAcks = s.pendingAcks[:len(s.pendingAcks)-1]
+		s.ackLock.Unlock()
+		return err
+	}
+	return nil
+}
+
+// ack notifies the ack of the earliest pending request when storage responds it.
+func (s *writeStream) ack(seq int64, err error) {
+	s.ackLock.Lock()
+	if len(s.pendingAcks) == 0 {
+		s.ackLock.Unlock()
+		return
+	}
+	ack := s.pendingAcks[0]
+	s.pendingAcks = s.pendingAcks[1:]
+	s.ackLock.Unlock()
+	if ack != nil {
+		ack(seq, err)
+	}
+}
+
+// failPendingAcks notifies all pending requests not acked when stream closed.
+func (s *writeStream) failPendingAcks() {
+	s.ackLock.Lock()
+	acks := s.pendingAcks
+	s.pendingAcks = nil
+	s.ackLock.Unlock()
+	for _, ack := range acks {
+		if ack != nil {
+			ack(0, io.EOF)
+		}
+	}
 }
 
 // Close closes send stream, and cancel stream context, server will stop receive write request under this stream.
@@ -143,6 +226,7 @@ func (s *writeStream) recvLoop() {
 				logger.Stack())
 			s.closed.Store(true)
 		}
+		s.failPendingAcks()
 	}()
 
 	for {
@@ -157,18 +241,61 @@ func (s *writeStream) recvLoop() {
 		default:
 			resp, err := s.cli.Recv()
 			if err != nil {
-				s.logger.Error("receive error from write stream", logger.Error(err))
+				// grpc stream is terminated after any receive err, return it.
+				s.closed.Store(true)
 				if err == io.EOF {
-					s.closed.Store(true)
-					// stream is closed, return it.
+					s.logger.Info("write stream is closed by storage")
 					return
 				}
+				if status.Code(err) == codes.Unimplemented {
+					s.logger.Error("storage doesn't support stream write, disable stream-write of broker write config",
+						logger.String("target", s.target.Indicator()), logger.Error(err))
+					return
+				}
+				s.logger.Error("receive error from write stream", logger.Error(err))
+				return
+			}
+			if resp.FlowControl != nil {
+				s.handleFlowControl(resp.FlowControl)
 				continue
 			}
 			if resp.Err != "" {
 				// get err from response
 				s.logger.Error("get err write response", logger.String("err", resp.Err))
+				s.ack(0, toWriteErr(resp.Err))
+				continue
 			}
+			if resp.AcceptedAt > 0 {
+				// storage acks the data synced into wal
+				s.writeLatency.UpdateDuration(time.Duration(time.Now().UnixNano() - resp.AcceptedAt))
+			}
+			s.ack(resp.Sequence, nil)
 		}
 	}
 }
+
+// toWriteErr converts the err message of write response, keeps retriable err comparable for writer.
+func toWriteErr(errMsg string) error {
+	if errMsg == constants.ErrShardWritePaused.Error() {
+		return constants.ErrShardWritePaused
+	}
+	return errors.New(errMsg)
+}
+
+// handleFlowControl pauses/resumes sending based on backpressure signaled by storage.
+func (s *writeStream) handleFlowControl(flowControl *protoWriteV1.FlowControl) {
+	if s.paused.Swap(flowControl.Backpressure) == flowControl.Backpressure {
+		return
+	}
+	if flowControl.Backpressure {
+		s.logger.Warn("storage signals backpressure, pause sending",
+			logger.String("database", s.database),
+			logger.Any("shard", s.shardState.ID),
+			logger.Int64("queueDepth", flowControl.QueueDepth))
+	} else {
+		s.logger.Info("storage releases backpressure, resume sending",
+			logger.String("database", s.database),
+			logger.Any("shard", s.shardState.ID),
+			logger.Int64("queueDepth", flowControl.QueueDepth))
+	}
+}
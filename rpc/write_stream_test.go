@@ -19,14 +19,19 @@ package rpc
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/atomic"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
+	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/pkg/logger"
 	protoWriteV1 "github.com/lindb/lindb/proto/gen/v1/write"
@@ -40,45 +45,57 @@ func TestNewWriteStream(t *testing.T) {
 
 	// case 1: create write service cli err
 	fct.EXPECT().CreateWriteServiceClient(gomock.Any()).Return(nil, fmt.Errorf("err"))
-	stream, err := NewWriteStream(context.TODO(), nil, "test", &models.ShardState{}, 1, fct)
+	stream, err := NewWriteStream(context.TODO(), nil, "test", &models.ShardState{}, 1, true, fct)
 	assert.Error(t, err)
 	assert.Nil(t, stream)
 
 	// case 2: create write cli err
 	writeSrv := protoWriteV1.NewMockWriteServiceClient(ctrl)
 	fct.EXPECT().CreateWriteServiceClient(gomock.Any()).Return(writeSrv, nil).AnyTimes()
-	writeSrv.EXPECT().Write(gomock.Any()).Return(nil, fmt.Errorf("err"))
-	stream, err = NewWriteStream(context.TODO(), nil, "test", &models.ShardState{}, 1, fct)
+	writeSrv.EXPECT().StreamWrite(gomock.Any()).Return(nil, fmt.Errorf("err"))
+	stream, err = NewWriteStream(context.TODO(), nil, "test", &models.ShardState{}, 1, true, fct)
 	assert.Error(t, err)
 	assert.Nil(t, stream)
 
 	// case 3: create instance success
-	cli := protoWriteV1.NewMockWriteService_WriteClient(ctrl)
-	writeSrv.EXPECT().Write(gomock.Any()).Return(cli, nil)
+	cli := protoWriteV1.NewMockWriteService_StreamWriteClient(ctrl)
+	writeSrv.EXPECT().StreamWrite(gomock.Any()).Return(cli, nil)
 	cli.EXPECT().Recv().Return(nil, io.EOF).AnyTimes()
 	cli.EXPECT().Context().Return(context.TODO()).AnyTimes()
-	stream, err = NewWriteStream(context.TODO(), &models.StatefulNode{}, "test", &models.ShardState{}, 1, fct)
+	stream, err = NewWriteStream(context.TODO(), &models.StatefulNode{}, "test", &models.ShardState{}, 1, true, fct)
 	assert.NoError(t, err)
 	assert.NotNil(t, stream)
 
 	cli.EXPECT().CloseSend().Return(nil)
 	err = stream.Close()
 	assert.NoError(t, err)
+
+	// case 4: write by Write rpc if stream write disabled
+	writeCli := protoWriteV1.NewMockWriteService_WriteClient(ctrl)
+	writeSrv.EXPECT().Write(gomock.Any()).Return(writeCli, nil)
+	writeCli.EXPECT().Recv().Return(nil, io.EOF).AnyTimes()
+	writeCli.EXPECT().Context().Return(context.TODO()).AnyTimes()
+	stream, err = NewWriteStream(context.TODO(), &models.StatefulNode{}, "test", &models.ShardState{}, 1, false, fct)
+	assert.NoError(t, err)
+	assert.NotNil(t, stream)
+	writeCli.EXPECT().CloseSend().Return(nil)
+	assert.NoError(t, stream.Close())
 }
 
 func TestWriteStream_Send(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	cli := protoWriteV1.NewMockWriteService_WriteClient(ctrl)
+	cli := protoWriteV1.NewMockWriteService_StreamWriteClient(ctrl)
 	stream := &writeStream{
 		cli:    cli,
 		closed: atomic.NewBool(true),
+		paused: atomic.NewBool(false),
 	}
-	assert.Equal(t, io.EOF, stream.Send(nil))
+	assert.Equal(t, io.EOF, stream.Send(&protoWriteV1.WriteRequest{}, nil))
 	stream.closed.Store(false)
 	cli.EXPECT().Send(gomock.Any()).Return(nil)
-	assert.NoError(t, stream.Send(nil))
+	assert.NoError(t, stream.Send(&protoWriteV1.WriteRequest{}, nil))
 }
 
 func TestWriteStream_Recv(t *testing.T) {
@@ -87,6 +104,7 @@ func TestWriteStream_Recv(t *testing.T) {
 
 	stream := &writeStream{
 		closed: atomic.NewBool(false),
+		paused: atomic.NewBool(false),
 		logger: logger.GetLogger("rpc", "WriteStream"),
 	}
 	// case 1: panic
@@ -95,10 +113,11 @@ func TestWriteStream_Recv(t *testing.T) {
 	// case 2: context is done
 	ctx, cancel := context.WithCancel(context.TODO())
 	cancel()
-	cli := protoWriteV1.NewMockWriteService_WriteClient(ctrl)
+	cli := protoWriteV1.NewMockWriteService_StreamWriteClient(ctrl)
 	stream = &writeStream{
 		cli:    cli,
 		closed: atomic.NewBool(false),
+		paused: atomic.NewBool(false),
 		logger: logger.GetLogger("rpc", "WriteStream"),
 	}
 	cli.EXPECT().Context().Return(ctx).MaxTimes(2)
@@ -108,12 +127,186 @@ func TestWriteStream_Recv(t *testing.T) {
 	stream = &writeStream{
 		cli:    cli,
 		closed: atomic.NewBool(false),
+		paused: atomic.NewBool(false),
 		logger: logger.GetLogger("rpc", "WriteStream"),
 	}
 	cli.EXPECT().Context().Return(context.TODO()).AnyTimes()
 	cli.EXPECT().Recv().Return(nil, fmt.Errorf("err"))
+	stream.recvLoop()
+	assert.True(t, stream.closed.Load())
+	// case 4: err response, then stream closed
+	stream.closed.Store(false)
 	cli.EXPECT().Recv().Return(&protoWriteV1.WriteResponse{Err: "err"}, nil)
 	cli.EXPECT().Recv().Return(nil, io.EOF)
 	stream.recvLoop()
+	assert.True(t, stream.closed.Load())
+	// case 5: storage doesn't support stream write, not retry receiving
+	stream.closed.Store(false)
+	stream.target = &models.StatefulNode{}
+	cli.EXPECT().Recv().Return(nil, status.Error(codes.Unimplemented, "unknown method StreamWrite"))
+	stream.recvLoop()
+	assert.True(t, stream.closed.Load())
+}
+
+func TestWriteStream_WriteLatency(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cli := protoWriteV1.NewMockWriteService_StreamWriteClient(ctrl)
+	stream := &writeStream{
+		cli:          cli,
+		closed:       atomic.NewBool(false),
+		paused:       atomic.NewBool(false),
+		writeLatency: writeLatencyTimerVec.WithTagValues("write_latency_db"),
+		logger:       logger.GetLogger("rpc", "WriteStream"),
+	}
+	acceptedAt := time.Now().Add(-50 * time.Millisecond).UnixNano()
+	// accepted time is sent with data
+	cli.EXPECT().Send(gomock.Any()).DoAndReturn(func(req *protoWriteV1.WriteRequest) error {
+		assert.Equal(t, acceptedAt, req.AcceptedAt)
+		return nil
+	})
+	assert.NoError(t, stream.Send(&protoWriteV1.WriteRequest{Record: []byte{1}, AcceptedAt: acceptedAt}, nil))
+
+	count, sum := stream.writeLatency.Total()
+	cli.EXPECT().Context().Return(context.TODO()).AnyTimes()
+	gomock.InOrder(
+		// storage acks wal written
+		cli.EXPECT().Recv().Return(&protoWriteV1.WriteResponse{AcceptedAt: acceptedAt}, nil),
+		// failure and flow control are not recorded
+		cli.EXPECT().Recv().Return(&protoWriteV1.WriteResponse{AcceptedAt: acceptedAt, Err: "err"}, nil),
+		cli.EXPECT().Recv().Return(&protoWriteV1.WriteResponse{FlowControl: &protoWriteV1.FlowControl{}}, nil),
+		cli.EXPECT().Recv().Return(nil, io.EOF),
+	)
+	stream.recvLoop()
+	count2, sum2 := stream.writeLatency.Total()
+	assert.Equal(t, count+1, count2)
+	assert.GreaterOrEqual(t, sum2-sum, float64(50))
+}
+
+func TestWriteStream_Ack(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cli := protoWriteV1.NewMockWriteService_StreamWriteClient(ctrl)
+	stream := &writeStream{
+		cli:          cli,
+		closed:       atomic.NewBool(false),
+		paused:       atomic.NewBool(false),
+		writeLatency: writeLatencyTimerVec.WithTagValues("write_ack_db"),
+		logger:       logger.GetLogger("rpc", "WriteStream"),
+	}
+	var (
+		seqs []int64
+		acks []error
+	)
+	ack := func(seq int64, err error) {
+		seqs = append(seqs, seq)
+		acks = append(acks, err)
+	}
+	// case 1: send err, no pending ack
+	cli.EXPECT().Send(gomock.Any()).Return(fmt.Errorf("err"))
+	assert.Error(t, stream.Send(&protoWriteV1.WriteRequest{Quorum: true}, ack))
+	assert.Empty(t, stream.pendingAcks)
+	// case 2: responses ack requests in order, request without ack takes a response too
+	cli.EXPECT().Send(gomock.Any()).Return(nil).Times(5)
+	assert.NoError(t, stream.Send(&protoWriteV1.WriteRequest{Quorum: true}, ack))
+	assert.NoError(t, stream.Send(&protoWriteV1.WriteRequest{}, nil))
+	assert.NoError(t, stream.Send(&protoWriteV1.WriteRequest{}, ack))
+	assert.NoError(t, stream.Send(&protoWriteV1.WriteRequest{}, ack))
+	assert.NoError(t, stream.Send(&protoWriteV1.WriteRequest{}, ack))
+	cli.EXPECT().Context().Return(context.TODO()).AnyTimes()
+	gomock.InOrder(
+		cli.EXPECT().Recv().Return(&protoWriteV1.WriteResponse{Sequence: 1}, nil),
+		cli.EXPECT().Recv().Return(&protoWriteV1.WriteResponse{FlowControl: &protoWriteV1.FlowControl{}}, nil),
+		cli.EXPECT().Recv().Return(&protoWriteV1.WriteResponse{Sequence: 2}, nil),
+		cli.EXPECT().Recv().Return(&protoWriteV1.WriteResponse{Err: "err"}, nil),
+		// rejected by paused shard, err is retriable
+		cli.EXPECT().Recv().Return(&protoWriteV1.WriteResponse{Err: constants.ErrShardWritePaused.Error()}, nil),
+		// case 3: stream closed, fails pending acks
+		cli.EXPECT().Recv().Return(nil, io.EOF),
+	)
+	stream.recvLoop()
+	assert.Equal(t, []error{nil, errors.New("err"), constants.ErrShardWritePaused, io.EOF}, acks)
+	assert.Equal(t, []int64{1, 0, 0, 0}, seqs)
+	assert.Empty(t, stream.pendingAcks)
+}
+
+func TestWriteStream_FlowControl(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		flowControlRetryInterval = 10 * time.Millisecond
+		ctrl.Finish()
+	}()
+	flowControlRetryInterval = time.Millisecond
 
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	cli := protoWriteV1.NewMockWriteService_StreamWriteClient(ctrl)
+	stream := &writeStream{
+		ctx:        ctx,
+		cli:        cli,
+		shardState: &models.ShardState{ID: 1},
+		closed:     atomic.NewBool(false),
+		paused:     atomic.NewBool(false),
+		logger:     logger.GetLogger("rpc", "WriteStream"),
+	}
+	release := make(chan struct{})
+	finish := make(chan struct{})
+	cli.EXPECT().Context().Return(context.TODO()).AnyTimes()
+	gomock.InOrder(
+		cli.EXPECT().Recv().Return(&protoWriteV1.WriteResponse{}, nil),
+		// server sends backpressure mid-stream
+		cli.EXPECT().Recv().Return(&protoWriteV1.WriteResponse{
+			FlowControl: &protoWriteV1.FlowControl{Backpressure: true, QueueDepth: 100},
+		}, nil),
+		cli.EXPECT().Recv().DoAndReturn(func() (*protoWriteV1.WriteResponse, error) {
+			<-release
+			return &protoWriteV1.WriteResponse{
+				FlowControl: &protoWriteV1.FlowControl{QueueDepth: 10},
+			}, nil
+		}),
+		cli.EXPECT().Recv().DoAndReturn(func() (*protoWriteV1.WriteResponse, error) {
+			<-finish
+			return nil, io.EOF
+		}),
+	)
+	var sent atomic.Int32
+	cli.EXPECT().Send(gomock.Any()).DoAndReturn(func(_ *protoWriteV1.WriteRequest) error {
+		sent.Inc()
+		return nil
+	}).AnyTimes()
+
+	recvDone := make(chan struct{})
+	go func() {
+		stream.recvLoop()
+		close(recvDone)
+	}()
+	assert.Eventually(t, stream.paused.Load, time.Second, time.Millisecond)
+
+	// client pauses sending until backpressure released
+	sendDone := make(chan error)
+	go func() {
+		sendDone <- stream.Send(&protoWriteV1.WriteRequest{Record: []byte{1}}, nil)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	assert.Zero(t, sent.Load())
+	select {
+	case <-sendDone:
+		assert.Fail(t, "send should be paused by backpressure")
+	default:
+	}
+
+	close(release)
+	assert.NoError(t, <-sendDone)
+	assert.Equal(t, int32(1), sent.Load())
+	assert.False(t, stream.paused.Load())
+	close(finish)
+	<-recvDone
+
+	// paused stream returns EOF when stream context is done
+	stream.paused.Store(true)
+	stream.closed.Store(false)
+	cancel()
+	assert.Equal(t, io.EOF, stream.Send(&protoWriteV1.WriteRequest{Record: []byte{1}}, nil))
 }
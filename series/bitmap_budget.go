@@ -0,0 +1,96 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package series
+
+import (
+	"fmt"
+
+	"github.com/lindb/roaring"
+	"go.uber.org/atomic"
+)
+
+// BitmapBudget limits the memory of series ids bitmaps materialized when evaluating tag filter of a query,
+// it is shared by all shards of the query, so that the limit applies to the whole query.
+// Nil budget means no limit.
+type BitmapBudget struct {
+	max  uint64 // 0 means no limit
+	used atomic.Uint64
+	peak atomic.Uint64
+}
+
+// NewBitmapBudget creates a bitmap budget with max memory, 0 means no limit.
+func NewBitmapBudget(max uint64) *BitmapBudget {
+	return &BitmapBudget{max: max}
+}
+
+// Alloc accounts the memory of bitmap materialized, returns ErrTooLargeBitmapMemory if exceeds max memory.
+func (b *BitmapBudget) Alloc(size uint64) error {
+	if b == nil {
+		return nil
+	}
+	used := b.used.Add(size)
+	for {
+		peak := b.peak.Load()
+		if used <= peak || b.peak.CAS(peak, used) {
+			break
+		}
+	}
+	if b.max > 0 && used > b.max {
+		return fmt.Errorf("%w: %d > %d bytes, please use a tighter tag filter",
+			ErrTooLargeBitmapMemory, used, b.max)
+	}
+	return nil
+}
+
+// Release releases the memory of bitmap which is not used any more.
+func (b *BitmapBudget) Release(size uint64) {
+	if b == nil {
+		return
+	}
+	for {
+		used := b.used.Load()
+		if size > used {
+			size = used
+		}
+		if b.used.CAS(used, used-size) {
+			return
+		}
+	}
+}
+
+// Or unions other into bitmap, accounts the memory grown of bitmap.
+func (b *BitmapBudget) Or(bitmap, other *roaring.Bitmap) error {
+	if b == nil {
+		bitmap.Or(other)
+		return nil
+	}
+	before := bitmap.GetSizeInBytes()
+	bitmap.Or(other)
+	if after := bitmap.GetSizeInBytes(); after > before {
+		return b.Alloc(after - before)
+	}
+	return nil
+}
+
+// Peak returns the peak memory of bitmaps materialized.
+func (b *BitmapBudget) Peak() uint64 {
+	if b == nil {
+		return 0
+	}
+	return b.peak.Load()
+}
@@ -0,0 +1,61 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package series
+
+import (
+	"testing"
+
+	"github.com/lindb/roaring"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBitmapBudget(t *testing.T) {
+	// nil budget means no limit
+	var budget *BitmapBudget
+	assert.NoError(t, budget.Alloc(100))
+	budget.Release(100)
+	bitmap := roaring.New()
+	assert.NoError(t, budget.Or(bitmap, roaring.BitmapOf(1, 2)))
+	assert.Equal(t, roaring.BitmapOf(1, 2), bitmap)
+	assert.Zero(t, budget.Peak())
+
+	budget = NewBitmapBudget(100)
+	assert.NoError(t, budget.Alloc(60))
+	assert.ErrorIs(t, budget.Alloc(60), ErrTooLargeBitmapMemory)
+	budget.Release(60)
+	assert.NoError(t, budget.Alloc(40))
+	assert.Equal(t, uint64(120), budget.Peak())
+	// release more than used
+	budget.Release(1000)
+	assert.NoError(t, budget.Alloc(100))
+	budget.Release(100)
+
+	// union accounts the memory grown
+	bitmap = roaring.New()
+	other := roaring.New()
+	for i := uint32(0); i < 10000; i += 2 {
+		other.Add(i)
+	}
+	assert.ErrorIs(t, budget.Or(bitmap, other), ErrTooLargeBitmapMemory)
+	// no limit
+	budget = NewBitmapBudget(0)
+	bitmap = roaring.New()
+	assert.NoError(t, budget.Or(bitmap, other))
+	assert.Equal(t, other.GetCardinality(), bitmap.GetCardinality())
+	assert.True(t, budget.Peak() > 0)
+}
@@ -32,3 +32,7 @@ var ErrTooManyFields = errors.New("too many fields")
 var ErrWrongFieldType = errors.New("field type is wrong")
 
 var ErrFieldTypeUnspecified = errors.New("field type is unknown")
+
+// ErrTooLargeBitmapMemory is the error returned by query when
+// series ids bitmaps of tag filter exceed the max memory of query.
+var ErrTooLargeBitmapMemory = errors.New("too large bitmap memory for tag filter of query")
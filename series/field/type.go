@@ -101,8 +101,9 @@ func (t Type) AggType() AggType {
 		return Min
 	case MaxField:
 		return Max
+	case GaugeField:
+		return LastValue
 	default:
-		//FIXME(stone1100)
 		return Max
 	}
 }
@@ -205,6 +206,8 @@ func getFieldParamsForSumField(funcType function.FuncType) []AggType {
 	switch funcType {
 	case function.Max:
 		return []AggType{Max}
+	case function.Min:
+		return []AggType{Min}
 	default:
 		return []AggType{Sum}
 	}
@@ -223,6 +226,10 @@ func getFieldParamsForGaugeField(funcType function.FuncType) []AggType {
 	switch funcType {
 	case function.Max:
 		return []AggType{Max}
+	case function.Min:
+		return []AggType{Min}
+	case function.Sum:
+		return []AggType{Sum}
 	default:
 		return []AggType{LastValue}
 	}
@@ -85,5 +85,22 @@ func TestMaxAgg(t *testing.T) {
 }
 
 func TestReplaceAgg(t *testing.T) {
+	assert.Equal(t, LastValue, GaugeField.AggType())
 	assert.Equal(t, 99.0, GaugeField.AggType().Aggregate(1, 99.0))
+	assert.Equal(t, 1.0, GaugeField.AggType().Aggregate(99.0, 1))
+}
+
+func TestType_GetFuncFieldParams(t *testing.T) {
+	// default agg type
+	assert.Equal(t, []AggType{Sum}, SumField.GetFuncFieldParams(function.Sum))
+	assert.Equal(t, []AggType{LastValue}, GaugeField.GetFuncFieldParams(function.LastValue))
+	// override by function
+	assert.Equal(t, []AggType{Max}, SumField.GetFuncFieldParams(function.Max))
+	assert.Equal(t, []AggType{Min}, SumField.GetFuncFieldParams(function.Min))
+	assert.Equal(t, []AggType{Max}, GaugeField.GetFuncFieldParams(function.Max))
+	assert.Equal(t, []AggType{Min}, GaugeField.GetFuncFieldParams(function.Min))
+	assert.Equal(t, []AggType{Sum}, GaugeField.GetFuncFieldParams(function.Sum))
+	assert.Equal(t, []AggType{Min}, MinField.GetFuncFieldParams(function.Min))
+	assert.Equal(t, []AggType{Max}, MaxField.GetFuncFieldParams(function.Max))
+	assert.Nil(t, Unknown.GetFuncFieldParams(function.Sum))
 }
@@ -41,8 +41,9 @@ type TagValueSuggester interface {
 
 // Filter represents the query ability for filtering seriesIDs by expr from an index of tags.
 type Filter interface {
-	// GetSeriesIDsByTagValueIDs gets series ids by tag value ids for spec metric's tag key
-	GetSeriesIDsByTagValueIDs(tagKeyID uint32, tagValueIDs *roaring.Bitmap) (*roaring.Bitmap, error)
+	// GetSeriesIDsByTagValueIDs gets series ids by tag value ids for spec metric's tag key,
+	// the union of series ids is accounted by budget, returned series ids are still accounted in budget.
+	GetSeriesIDsByTagValueIDs(tagKeyID uint32, tagValueIDs *roaring.Bitmap, budget *BitmapBudget) (*roaring.Bitmap, error)
 	// GetSeriesIDsForTag gets series ids for spec metric's tag key
 	GetSeriesIDsForTag(tagKeyID uint32) (*roaring.Bitmap, error)
 	// GetSeriesIDsForMetric gets series ids for spec metric name
@@ -31,7 +31,10 @@ import (
 type TimeSeriesEvent struct {
 	SeriesList      GroupedIterators
 	AggregatorSpecs map[string]*protoCommonV1.AggregatorSpec
+	Exemplars       map[string][]*protoCommonV1.Exemplar // tags => exemplars of fields
 	Stats           *models.QueryStats
+	FailedNodes     map[string]string // node => error message, failed nodes ignored by best-effort query
+	Stale           bool              // result may be stale, e.g. metadata of storage unavailable
 	Err             error
 }
 
@@ -19,7 +19,19 @@ package metric
 
 import (
 	"bytes"
+	"fmt"
+	"math"
 	"strings"
+	"unicode/utf8"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/proto/gen/v1/flatMetricsV1"
+)
+
+// for testing
+var (
+	nowFunc = ingestNow
 )
 
 // SanitizeMetricName checks if metric-name is in necessary of sanitizing
@@ -70,6 +82,96 @@ func SanitizeFieldName(fieldName []byte) []byte {
 	}
 }
 
+// NormalizeFieldName normalizes field-name, lowercase and replaces separators('.', '-', ' ') with '_',
+// so that "CPU.Usage" and "cpu_usage" map to the same field.
+// Field-names of compound field(histogram) are reserved and normalized to themselves,
+// because storage writes compound field with them, and bucket upper-bound is parsed from bucket name.
+func NormalizeFieldName(fieldName []byte) []byte {
+	if bytes.HasPrefix(fieldName, []byte("__bucket_")) {
+		return fieldName
+	}
+	var dst = make([]byte, len(fieldName))
+	for idx, c := range fieldName {
+		switch {
+		case c >= 'A' && c <= 'Z':
+			dst[idx] = c + ('a' - 'A')
+		case c == '.' || c == '-' || c == ' ':
+			dst[idx] = '_'
+		default:
+			dst[idx] = c
+		}
+	}
+	if name, ok := normalizedHistogramFieldNames[string(dst)]; ok {
+		return []byte(name)
+	}
+	return dst
+}
+
+// normalizedHistogramFieldNames maps the normalized field-names of histogram to the reserved ones.
+var normalizedHistogramFieldNames = map[string]string{
+	"histogramsum":   string(histogramSum),
+	"histogramcount": string(histogramCount),
+	"histogrammax":   string(histogramMax),
+	"histogrammin":   string(histogramMin),
+}
+
+// ValidateFlatMetric checks if the flat metric is well-formed before ingesting,
+// returns the error which indicates the failure reason.
+func ValidateFlatMetric(m *flatMetricsV1.Metric) error {
+	name := m.Name()
+	if len(name) == 0 {
+		return ErrFlatMetricEmptyName
+	}
+	if !utf8.Valid(name) || !utf8.Valid(m.Namespace()) {
+		return ErrFlatMetricBadUTF8
+	}
+	tagsLen := m.KeyValuesLength()
+	if tagsLen > config.GlobalStorageConfig().TSDB.MaxTagKeysNumber {
+		return fmt.Errorf("%w: %d", ErrFlatMetricTooManyTags, tagsLen)
+	}
+	var kv flatMetricsV1.KeyValue
+	for i := 0; i < tagsLen; i++ {
+		if m.KeyValues(&kv, i) && (!utf8.Valid(kv.Key()) || !utf8.Valid(kv.Value())) {
+			return ErrFlatMetricBadUTF8
+		}
+	}
+	var f flatMetricsV1.SimpleField
+	for i := 0; i < m.SimpleFieldsLength(); i++ {
+		if !m.SimpleFields(&f, i) {
+			continue
+		}
+		if !utf8.Valid(f.Name()) {
+			return ErrFlatMetricBadUTF8
+		}
+		if math.IsNaN(f.Value()) {
+			return ErrFlatMetricNaNValue
+		}
+	}
+	return nil
+}
+
+// CheckTimestampWindow checks if timestamp is in acceptance window [now-MaxPastAge, now+MaxFutureSkew],
+// zero bound means no limit.
+func CheckTimestampWindow(timestamp int64) error {
+	ingestionCfg := config.GlobalBrokerConfig().Ingestion
+	now := nowFunc()
+	if skew := ingestionCfg.MaxFutureSkew.Duration().Milliseconds(); skew > 0 && timestamp > now+skew {
+		return fmt.Errorf("%w: %d", ErrTimestampTooNew, timestamp)
+	}
+	if age := ingestionCfg.MaxPastAge.Duration().Milliseconds(); age > 0 && timestamp < now-age {
+		return fmt.Errorf("%w: %d", ErrTimestampTooOld, timestamp)
+	}
+	return nil
+}
+
+// defaultNamespace returns the namespace of metric which is written without namespace.
+func defaultNamespace() string {
+	if namespace := config.GlobalBrokerConfig().Ingestion.DefaultNamespace; namespace != "" {
+		return namespace
+	}
+	return constants.DefaultNamespace
+}
+
 // JoinNamespaceMetric concat namespace and metric-name for storage with a delimiter
 func JoinNamespaceMetric(namespace, metricName string) string {
 	return namespace + "|" + metricName
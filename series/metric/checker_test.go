@@ -19,17 +19,25 @@ package metric
 
 import (
 	"bytes"
+	"errors"
+	"math"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/golang/snappy"
 	flatbuffers "github.com/google/flatbuffers/go"
 	"github.com/klauspost/compress/gzip"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/internal/mock"
 	"github.com/lindb/lindb/pkg/fasttime"
+	"github.com/lindb/lindb/pkg/ltoml"
+	"github.com/lindb/lindb/pkg/timeutil"
 	"github.com/lindb/lindb/proto/gen/v1/flatMetricsV1"
 	protoMetricsV1 "github.com/lindb/lindb/proto/gen/v1/metrics"
+	"github.com/lindb/lindb/series/field"
 )
 
 func Test_Sanitize(t *testing.T) {
@@ -77,6 +85,77 @@ func Test_SanitizeFieldName(t *testing.T) {
 	assert.Equal(t, []byte("bucket_1"), SanitizeFieldName([]byte("bucket_1")))
 }
 
+func Test_NormalizeFieldName(t *testing.T) {
+	assert.Equal(t, []byte("cpu_usage"), NormalizeFieldName([]byte("CPU.Usage")))
+	assert.Equal(t, []byte("cpu_usage"), NormalizeFieldName([]byte("cpu_usage")))
+	assert.Equal(t, []byte("cpu_usage"), NormalizeFieldName([]byte("Cpu-Usage")))
+	assert.Equal(t, []byte("counter0"), NormalizeFieldName([]byte("counter0")))
+	// field-names of compound field
+	for _, name := range []field.Name{histogramSum, histogramCount, histogramMax, histogramMin} {
+		assert.Equal(t, []byte(name), NormalizeFieldName([]byte(name)))
+	}
+	assert.Equal(t, []byte("HistogramSum"), NormalizeFieldName([]byte("histogramSum")))
+	assert.Equal(t, []byte("histogram_sum"), NormalizeFieldName([]byte("Histogram.Sum")))
+	assert.Equal(t, []byte("__bucket_0.5"), NormalizeFieldName([]byte(BucketNameOfHistogramExplicitBound(0.5))))
+	assert.Equal(t, []byte("__bucket_+Inf"), NormalizeFieldName([]byte(BucketNameOfHistogramExplicitBound(math.Inf(1)))))
+}
+
+// getFlatMetric returns the metric of size prefixed flat metric data.
+func getFlatMetric(data []byte) *flatMetricsV1.Metric {
+	return flatMetricsV1.GetRootAsMetric(data, flatbuffers.SizeUint32)
+}
+
+func Test_ValidateFlatMetric(t *testing.T) {
+	now := fasttime.UnixMilliseconds()
+	assert.NoError(t, ValidateFlatMetric(getFlatMetric(mock.BuildFlatMetric("cpu", 2, "v", 1, now))))
+	assert.NoError(t, ValidateFlatMetric(getFlatMetric(mock.BuildFlatMetric("cpu", 2, "v", 1, 0))))
+
+	cases := []struct {
+		m   *flatMetricsV1.Metric
+		err error
+	}{
+		{m: getFlatMetric(mock.BuildFlatMetric("", 2, "v", 1, now)), err: ErrFlatMetricEmptyName},
+		{m: getFlatMetric(mock.BuildFlatMetric("cpu\xff", 2, "v", 1, now)), err: ErrFlatMetricBadUTF8},
+		{m: getFlatMetric(mock.BuildFlatMetric("cpu", 2, "v\xc3", 1, now)), err: ErrFlatMetricBadUTF8},
+		{m: getFlatMetric(mock.BuildFlatMetric("cpu", 2, "v", math.NaN(), now)), err: ErrFlatMetricNaNValue},
+		{m: getFlatMetric(mock.BuildFlatMetric("cpu", 100, "v", 1, now)), err: ErrFlatMetricTooManyTags},
+	}
+	for _, c := range cases {
+		err := ValidateFlatMetric(c.m)
+		assert.True(t, errors.Is(err, c.err))
+		assert.True(t, errors.Is(err, ErrBadFlatMetric))
+	}
+	// timestamp acceptance window is checked by decoder
+	assert.NoError(t, ValidateFlatMetric(getFlatMetric(mock.BuildFlatMetric("cpu", 2, "v", 1, now+2*timeutil.OneHour))))
+}
+
+func Test_CheckTimestampWindow(t *testing.T) {
+	defer func() {
+		nowFunc = ingestNow
+		config.SetGlobalBrokerConfig(config.NewDefaultBrokerBase())
+	}()
+	now := int64(1_600_000_000_000)
+	nowFunc = func() int64 { return now }
+	cfg := config.NewDefaultBrokerBase()
+	cfg.Ingestion.MaxFutureSkew = ltoml.Duration(time.Minute)
+	cfg.Ingestion.MaxPastAge = ltoml.Duration(time.Hour)
+	config.SetGlobalBrokerConfig(cfg)
+
+	// future
+	assert.NoError(t, CheckTimestampWindow(now+timeutil.OneMinute))
+	assert.True(t, errors.Is(CheckTimestampWindow(now+timeutil.OneMinute+1), ErrTimestampTooNew))
+	// past
+	assert.NoError(t, CheckTimestampWindow(now-timeutil.OneHour))
+	assert.True(t, errors.Is(CheckTimestampWindow(now-timeutil.OneHour-1), ErrTimestampTooOld))
+	assert.True(t, errors.Is(CheckTimestampWindow(now-timeutil.OneHour-1), ErrTimestampOutOfWindow))
+
+	// no limit
+	cfg.Ingestion.MaxFutureSkew = 0
+	cfg.Ingestion.MaxPastAge = 0
+	assert.NoError(t, CheckTimestampWindow(now+timeutil.OneDay))
+	assert.NoError(t, CheckTimestampWindow(1))
+}
+
 func Benchmark_FlatMetric_Unmarshal10KeyValues(b *testing.B) {
 	builder := flatbuffers.NewBuilder(1024)
 	buildFlatMetric(builder)
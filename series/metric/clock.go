@@ -0,0 +1,65 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package metric
+
+import (
+	"sync"
+	"time"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/pkg/fasttime"
+	"github.com/lindb/lindb/pkg/logger"
+	"github.com/lindb/lindb/pkg/timeutil"
+)
+
+// clockSkewThreshold is the minimum backward jump of node clock recognized as clock skew.
+const clockSkewThreshold = timeutil.OneSecond
+
+var (
+	clockLogger = logger.GetLogger("metric", "Clock")
+	// ingestClock is the clock of ingestion, detects backward jumps of node clock.
+	ingestClock = timeutil.NewClock(fasttime.UnixMilliseconds, clockSkewThreshold, onClockSkew)
+
+	clockSkewHandlers     []func(skew int64)
+	clockSkewHandlersLock sync.RWMutex
+)
+
+// AddClockSkewHandler registers the handler invoked when node clock jumps backward,
+// such as recording the skew event.
+func AddClockSkewHandler(handler func(skew int64)) {
+	clockSkewHandlersLock.Lock()
+	defer clockSkewHandlersLock.Unlock()
+	clockSkewHandlers = append(clockSkewHandlers, handler)
+}
+
+func onClockSkew(skew int64) {
+	clockLogger.Warn("node clock jumps backward",
+		logger.String("skew", time.Duration(skew*int64(time.Millisecond)).String()),
+		logger.Any("clamp", config.GlobalBrokerConfig().Ingestion.ClampClockSkew))
+	clockSkewHandlersLock.RLock()
+	defer clockSkewHandlersLock.RUnlock()
+	for _, handler := range clockSkewHandlers {
+		handler(skew)
+	}
+}
+
+// ingestNow returns now(ms) for ingestion,
+// clamps to the latest time ever read when node clock jumps backward if enabled.
+func ingestNow() int64 {
+	return ingestClock.Now(config.GlobalBrokerConfig().Ingestion.ClampClockSkew)
+}
@@ -0,0 +1,87 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package metric
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/proto/gen/v1/flatMetricsV1"
+)
+
+func Test_IngestClock_BackwardJump(t *testing.T) {
+	oldClock := ingestClock
+	defer func() {
+		ingestClock = oldClock
+		clockSkewHandlersLock.Lock()
+		clockSkewHandlers = clockSkewHandlers[:len(clockSkewHandlers)-1]
+		clockSkewHandlersLock.Unlock()
+		config.SetGlobalBrokerConfig(config.NewDefaultBrokerBase())
+	}()
+	now := int64(1_600_000_000_000)
+	wall := now
+	ingestClock = timeutil.NewClock(func() int64 { return wall }, clockSkewThreshold, onClockSkew)
+	var skews []int64
+	AddClockSkewHandler(func(skew int64) { skews = append(skews, skew) })
+
+	buildRowWithoutTimestamp := func(row *BrokerRow) error {
+		builder, releaseFunc := NewRowBuilder()
+		defer releaseFunc(builder)
+		builder.AddMetricName([]byte("test"))
+		_ = builder.AddSimpleField([]byte("f1"), flatMetricsV1.SimpleFieldTypeDeltaSum, 1)
+		return builder.BuildTo(row)
+	}
+	var interval timeutil.Interval
+	_ = interval.ValueOf("10s")
+	calc := interval.Calculator()
+
+	// clamp disabled, timestamp follows node clock
+	rows := NewBrokerBatchRows()
+	defer rows.Release()
+	assert.NoError(t, rows.TryAppend(buildRowWithoutTimestamp))
+	wall = now - timeutil.OneHour
+	assert.NoError(t, rows.TryAppend(buildRowWithoutTimestamp))
+	assert.Equal(t, now, rows.rows[0].m.Timestamp())
+	assert.Equal(t, now-timeutil.OneHour, rows.rows[1].m.Timestamp())
+	assert.Equal(t, []int64{timeutil.OneHour}, skews)
+
+	// clamp enabled, timestamp never goes backward, so family and eviction keep stable
+	cfg := config.NewDefaultBrokerBase()
+	cfg.Ingestion.ClampClockSkew = true
+	config.SetGlobalBrokerConfig(cfg)
+	wall = now
+	rows2 := NewBrokerBatchRows()
+	defer rows2.Release()
+	assert.NoError(t, rows2.TryAppend(buildRowWithoutTimestamp))
+	wall = now - timeutil.OneHour
+	assert.NoError(t, rows2.TryAppend(buildRowWithoutTimestamp))
+	assert.Equal(t, now, rows2.rows[0].m.Timestamp())
+	assert.Equal(t, now, rows2.rows[1].m.Timestamp())
+	assert.Equal(t, calc.CalcFamily(now, calc.CalcSegmentTime(now)),
+		calc.CalcFamily(rows2.rows[1].m.Timestamp(), calc.CalcSegmentTime(rows2.rows[1].m.Timestamp())))
+	assert.Zero(t, rows2.EvictOutOfTimeRange(timeutil.OneMinute, timeutil.OneMinute))
+	assert.Equal(t, []int64{timeutil.OneHour, timeutil.OneHour}, skews)
+
+	// small jitter below threshold is not reported as skew
+	wall = now - clockSkewThreshold
+	assert.Equal(t, now, ingestNow())
+	assert.Len(t, skews, 2)
+}
@@ -37,4 +37,21 @@ var (
 	ErrMetricNanField = fmt.Errorf("%w, field is not a number", ErrBadMetricPBFormat)
 	// ErrMetricInfField represents field value is infinity, positive or negative
 	ErrMetricInfField = fmt.Errorf("%w, field is infinity", ErrBadMetricPBFormat)
+
+	// ErrBadFlatMetric represents write bad flat metric
+	ErrBadFlatMetric = errors.New("bad flat metric")
+	// ErrFlatMetricEmptyName represents metric name is empty in flat metric
+	ErrFlatMetricEmptyName = fmt.Errorf("%w, metric name is empty", ErrBadFlatMetric)
+	// ErrFlatMetricBadUTF8 represents name/tag/field contains invalid utf-8 in flat metric
+	ErrFlatMetricBadUTF8 = fmt.Errorf("%w, contains invalid utf-8", ErrBadFlatMetric)
+	// ErrFlatMetricNaNValue represents field value is not a number in flat metric
+	ErrFlatMetricNaNValue = fmt.Errorf("%w, field is not a number", ErrBadFlatMetric)
+	// ErrFlatMetricTooManyTags represents tags of flat metric exceed the limit
+	ErrFlatMetricTooManyTags = fmt.Errorf("%w, too many tags", ErrBadFlatMetric)
+	// ErrTimestampOutOfWindow represents timestamp of metric is out of acceptance window
+	ErrTimestampOutOfWindow = errors.New("timestamp out of acceptance window")
+	// ErrTimestampTooNew represents timestamp of metric exceeds max future skew
+	ErrTimestampTooNew = fmt.Errorf("%w, exceeds max future skew", ErrTimestampOutOfWindow)
+	// ErrTimestampTooOld represents timestamp of metric exceeds max past age
+	ErrTimestampTooOld = fmt.Errorf("%w, exceeds max past age", ErrTimestampOutOfWindow)
 )
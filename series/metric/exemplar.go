@@ -0,0 +1,95 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package metric
+
+import (
+	flatbuffers "github.com/google/flatbuffers/go"
+
+	"github.com/lindb/lindb/proto/gen/v1/flatMetricsV1"
+)
+
+// Exemplar represents a sample attached to a field, used for drill-down from metric to trace.
+type Exemplar struct {
+	TraceID   []byte
+	SpanID    []byte
+	Duration  int64
+	Value     float64
+	Timestamp int64
+}
+
+// vtable offsets of flat exemplar byte vectors, see proto/v1/metrics.fbs
+const (
+	exemplarSpanIDSlot  = 4
+	exemplarTraceIDSlot = 6
+)
+
+// readExemplar fills the exemplar from the flat exemplar without copying trace/span id.
+func readExemplar(fe *flatMetricsV1.Exemplar, e *Exemplar) {
+	tab := fe.Table()
+	e.SpanID = flatByteVector(&tab, exemplarSpanIDSlot)
+	e.TraceID = flatByteVector(&tab, exemplarTraceIDSlot)
+	e.Duration = fe.Duration()
+	e.Value = fe.Value()
+	e.Timestamp = fe.Timestamp()
+}
+
+func flatByteVector(tab *flatbuffers.Table, slot flatbuffers.VOffsetT) []byte {
+	o := flatbuffers.UOffsetT(tab.Offset(slot))
+	if o == 0 {
+		return nil
+	}
+	return tab.ByteVector(o + tab.Pos)
+}
+
+// buildExemplars serializes exemplars as flat exemplar vector, returns 0 if no exemplars.
+func buildExemplars(
+	builder *flatbuffers.Builder,
+	exemplars []Exemplar,
+	offsets []flatbuffers.UOffsetT,
+) (flatbuffers.UOffsetT, []flatbuffers.UOffsetT) {
+	if len(exemplars) == 0 {
+		return 0, offsets
+	}
+	offsets = offsets[:0]
+	for i := range exemplars {
+		e := &exemplars[i]
+		var spanID, traceID flatbuffers.UOffsetT
+		if len(e.SpanID) > 0 {
+			spanID = builder.CreateByteVector(e.SpanID)
+		}
+		if len(e.TraceID) > 0 {
+			traceID = builder.CreateByteVector(e.TraceID)
+		}
+		flatMetricsV1.ExemplarStart(builder)
+		if spanID != 0 {
+			flatMetricsV1.ExemplarAddSpanID(builder, spanID)
+		}
+		if traceID != 0 {
+			flatMetricsV1.ExemplarAddTraceID(builder, traceID)
+		}
+		flatMetricsV1.ExemplarAddDuration(builder, e.Duration)
+		flatMetricsV1.ExemplarAddValue(builder, e.Value)
+		flatMetricsV1.ExemplarAddTimestamp(builder, e.Timestamp)
+		offsets = append(offsets, flatMetricsV1.ExemplarEnd(builder))
+	}
+	flatMetricsV1.SimpleFieldStartExemplarsVector(builder, len(offsets))
+	for i := len(offsets) - 1; i >= 0; i-- {
+		builder.PrependUOffsetT(offsets[i])
+	}
+	return builder.EndVector(len(offsets)), offsets
+}
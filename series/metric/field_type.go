@@ -0,0 +1,93 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package metric
+
+import (
+	"sync"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/proto/gen/v1/flatMetricsV1"
+	protoMetricsV1 "github.com/lindb/lindb/proto/gen/v1/metrics"
+)
+
+var (
+	defaultedFieldTypeHandlers     []func()
+	defaultedFieldTypeHandlersLock sync.RWMutex
+)
+
+// AddDefaultedFieldTypeHandler registers the handler invoked when a simple field without type
+// is written with the configured default field type, such as counting the defaulted fields.
+func AddDefaultedFieldTypeHandler(handler func()) {
+	defaultedFieldTypeHandlersLock.Lock()
+	defer defaultedFieldTypeHandlersLock.Unlock()
+	defaultedFieldTypeHandlers = append(defaultedFieldTypeHandlers, handler)
+}
+
+func onDefaultedFieldType() {
+	defaultedFieldTypeHandlersLock.RLock()
+	defer defaultedFieldTypeHandlersLock.RUnlock()
+	for _, handler := range defaultedFieldTypeHandlers {
+		handler()
+	}
+}
+
+// defaultFlatFieldType returns the flat field type for the field written with unset/unknown type,
+// returns SimpleFieldTypeUnSpecified if default field type is not configured.
+func defaultFlatFieldType() flatMetricsV1.SimpleFieldType {
+	switch config.GlobalBrokerConfig().Ingestion.DefaultFieldType {
+	case config.FieldTypeGauge:
+		return flatMetricsV1.SimpleFieldTypeGauge
+	case config.FieldTypeDeltaSum:
+		return flatMetricsV1.SimpleFieldTypeDeltaSum
+	case config.FieldTypeMax:
+		return flatMetricsV1.SimpleFieldTypeMax
+	case config.FieldTypeMin:
+		return flatMetricsV1.SimpleFieldTypeMin
+	default:
+		return flatMetricsV1.SimpleFieldTypeUnSpecified
+	}
+}
+
+// defaultProtoFieldType returns the proto field type for the field written with unset/unknown type,
+// returns SIMPLE_UNSPECIFIED if default field type is not configured.
+func defaultProtoFieldType() protoMetricsV1.SimpleFieldType {
+	switch config.GlobalBrokerConfig().Ingestion.DefaultFieldType {
+	case config.FieldTypeGauge:
+		return protoMetricsV1.SimpleFieldType_GAUGE
+	case config.FieldTypeDeltaSum:
+		return protoMetricsV1.SimpleFieldType_DELTA_SUM
+	case config.FieldTypeMax:
+		return protoMetricsV1.SimpleFieldType_Max
+	case config.FieldTypeMin:
+		return protoMetricsV1.SimpleFieldType_Min
+	default:
+		return protoMetricsV1.SimpleFieldType_SIMPLE_UNSPECIFIED
+	}
+}
+
+// isKnownFlatFieldType checks if the flat field type is specified and known.
+func isKnownFlatFieldType(fieldType flatMetricsV1.SimpleFieldType) bool {
+	_, ok := flatMetricsV1.EnumNamesSimpleFieldType[fieldType]
+	return ok && fieldType != flatMetricsV1.SimpleFieldTypeUnSpecified
+}
+
+// isKnownProtoFieldType checks if the proto field type is specified and known.
+func isKnownProtoFieldType(fieldType protoMetricsV1.SimpleFieldType) bool {
+	_, ok := protoMetricsV1.SimpleFieldType_name[int32(fieldType)]
+	return ok && fieldType != protoMetricsV1.SimpleFieldType_SIMPLE_UNSPECIFIED
+}
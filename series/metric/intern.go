@@ -0,0 +1,143 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package metric
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/cespare/xxhash/v2"
+	"go.uber.org/atomic"
+)
+
+const (
+	// defaultInternCapacity is the max number of strings kept by the default interner.
+	defaultInternCapacity = 16384
+	// maxInternShards is the max number of shards of interner.
+	maxInternShards = 16
+	// minInternShardCapacity is the min number of strings kept by each shard of interner.
+	minInternShardCapacity = 1024
+)
+
+// defaultInterner interns the recurring metric names, namespaces, tag keys and field names of ingest path.
+var defaultInterner = NewStringInterner(defaultInternCapacity)
+
+// InternString returns the interned string of b by the default interner.
+func InternString(b []byte) string {
+	return defaultInterner.Intern(b)
+}
+
+// StringInterner interns recurring strings, so that they reuse a single backing string
+// instead of allocating a new one for each decoding. The number of strings is bounded,
+// the least recently used string is evicted when exceeds(approximately, by second chance).
+// Strings are sharded by hash, so that concurrent writers don't contend on a single lock.
+type StringInterner struct {
+	shards []*internShard
+}
+
+// internShard keeps a part of interned strings, hits only take the read lock,
+// and mark the string referenced instead of moving it in the list.
+type internShard struct {
+	capacity int
+	fifo     *list.List // front is the newest
+	strings  map[string]*list.Element
+	mutex    sync.RWMutex
+}
+
+// internEntry represents an interned string with the referenced mark since last eviction check.
+type internEntry struct {
+	s          string
+	referenced atomic.Bool
+}
+
+// NewStringInterner creates interner which keeps at most capacity strings.
+func NewStringInterner(capacity int) *StringInterner {
+	numOfShards := capacity / minInternShardCapacity
+	if numOfShards < 1 {
+		numOfShards = 1
+	}
+	if numOfShards > maxInternShards {
+		numOfShards = maxInternShards
+	}
+	shards := make([]*internShard, numOfShards)
+	for idx := range shards {
+		shards[idx] = &internShard{
+			capacity: capacity / numOfShards,
+			fifo:     list.New(),
+			strings:  make(map[string]*list.Element),
+		}
+	}
+	return &StringInterner{shards: shards}
+}
+
+// Intern returns the interned string which equals to b, creates it if not exist.
+func (i *StringInterner) Intern(b []byte) string {
+	if len(b) == 0 {
+		return ""
+	}
+	shard := i.shards[0]
+	if len(i.shards) > 1 {
+		shard = i.shards[xxhash.Sum64(b)%uint64(len(i.shards))]
+	}
+	return shard.intern(b)
+}
+
+// Len returns the number of interned strings.
+func (i *StringInterner) Len() (n int) {
+	for _, shard := range i.shards {
+		shard.mutex.RLock()
+		n += shard.fifo.Len()
+		shard.mutex.RUnlock()
+	}
+	return n
+}
+
+// intern returns the interned string of shard which equals to b, creates it if not exist.
+func (s *internShard) intern(b []byte) string {
+	// map lookup by string(b) doesn't allocate
+	s.mutex.RLock()
+	elem, ok := s.strings[string(b)]
+	s.mutex.RUnlock()
+	if ok {
+		entry := elem.Value.(*internEntry)
+		entry.referenced.Store(true)
+		return entry.s
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	// double check, may be interned by other goroutine
+	if elem, ok = s.strings[string(b)]; ok {
+		return elem.Value.(*internEntry).s
+	}
+	str := string(b)
+	s.strings[str] = s.fifo.PushFront(&internEntry{s: str})
+	for s.fifo.Len() > s.capacity {
+		oldest := s.fifo.Back()
+		entry := oldest.Value.(*internEntry)
+		if entry.referenced.Load() {
+			// referenced since last check, gives it a second chance
+			entry.referenced.Store(false)
+			s.fifo.MoveToFront(oldest)
+			continue
+		}
+		s.fifo.Remove(oldest)
+		delete(s.strings, entry.s)
+	}
+	return str
+}
@@ -0,0 +1,150 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package metric
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+	"unsafe"
+
+	flatbuffers "github.com/google/flatbuffers/go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/proto/gen/v1/flatMetricsV1"
+)
+
+func stringData(s string) uintptr {
+	return (*reflect.StringHeader)(unsafe.Pointer(&s)).Data
+}
+
+func TestStringInterner_Intern(t *testing.T) {
+	interner := NewStringInterner(2)
+	assert.Equal(t, "", interner.Intern(nil))
+	assert.Equal(t, 0, interner.Len())
+
+	s1 := interner.Intern([]byte("cpu"))
+	assert.Equal(t, "cpu", s1)
+	s2 := interner.Intern([]byte("cpu"))
+	assert.Equal(t, "cpu", s2)
+	// reuse the single backing string
+	assert.Equal(t, stringData(s1), stringData(s2))
+	assert.Equal(t, 1, interner.Len())
+}
+
+func TestStringInterner_LRU(t *testing.T) {
+	interner := NewStringInterner(2)
+	cpu := interner.Intern([]byte("cpu"))
+	_ = interner.Intern([]byte("memory"))
+	// touch cpu, memory becomes the least recently used
+	_ = interner.Intern([]byte("cpu"))
+	_ = interner.Intern([]byte("disk"))
+	assert.Equal(t, 2, interner.Len())
+	assert.Equal(t, stringData(cpu), stringData(interner.Intern([]byte("cpu"))))
+	_, ok := interner.shards[0].strings["memory"]
+	assert.False(t, ok)
+}
+
+func TestStringInterner_Shards(t *testing.T) {
+	interner := NewStringInterner(defaultInternCapacity)
+	assert.Len(t, interner.shards, maxInternShards)
+	for i := 0; i < 2*defaultInternCapacity; i++ {
+		s := interner.Intern([]byte("host" + strconv.Itoa(i%100)))
+		assert.Equal(t, "host"+strconv.Itoa(i%100), s)
+	}
+	assert.Equal(t, 100, interner.Len())
+	// each shard is bounded
+	for i := 0; i < 2*defaultInternCapacity; i++ {
+		_ = interner.Intern([]byte("ip" + strconv.Itoa(i)))
+	}
+	assert.Equal(t, defaultInternCapacity, interner.Len())
+}
+
+func TestRow_InternedNames(t *testing.T) {
+	builder := flatbuffers.NewBuilder(1024)
+	buildFlatMetric(builder)
+	var row StorageRow
+	row.m.Init(builder.FinishedBytes(), flatbuffers.GetUOffsetT(builder.FinishedBytes()))
+
+	assert.Equal(t, string(row.Name()), row.NameString())
+	assert.Equal(t, string(row.NameSpace()), row.NameSpaceString())
+	kvItr := row.NewKeyValueIterator()
+	assert.True(t, kvItr.HasNext())
+	assert.Equal(t, "key0", kvItr.NextKeyString())
+	fieldItr := row.NewSimpleFieldIterator()
+	assert.True(t, fieldItr.HasNext())
+	assert.Equal(t, fieldItr.NextName(), fieldItr.NextInternedName())
+}
+
+// Benchmark_DecodeNames decodes names of a batch of repeated metrics,
+// compares allocations with and without interning.
+func Benchmark_DecodeNames(b *testing.B) {
+	builder := flatbuffers.NewBuilder(1024)
+	var batch [][]byte
+	for i := 0; i < 100; i++ {
+		buildFlatMetric(builder)
+		batch = append(batch, append([]byte(nil), builder.FinishedBytes()...))
+	}
+	decode := func(b *testing.B, toString func(b []byte) string) {
+		var m flatMetricsV1.Metric
+		var kv flatMetricsV1.KeyValue
+		var f flatMetricsV1.SimpleField
+		b.ReportAllocs()
+		b.ResetTimer()
+		for n := 0; n < b.N; n++ {
+			for _, data := range batch {
+				m.Init(data, flatbuffers.GetUOffsetT(data))
+				_ = toString(m.Namespace())
+				_ = toString(m.Name())
+				for i := 0; i < m.KeyValuesLength(); i++ {
+					m.KeyValues(&kv, i)
+					_ = toString(kv.Key())
+				}
+				for i := 0; i < m.SimpleFieldsLength(); i++ {
+					m.SimpleFields(&f, i)
+					_ = toString(f.Name())
+				}
+			}
+		}
+	}
+	b.Run("string", func(b *testing.B) {
+		decode(b, func(b []byte) string { return string(b) })
+	})
+	b.Run("interned", func(b *testing.B) {
+		interner := NewStringInterner(defaultInternCapacity)
+		decode(b, interner.Intern)
+	})
+}
+
+// Benchmark_StringInterner_Parallel interns recurring names by concurrent writers.
+func Benchmark_StringInterner_Parallel(b *testing.B) {
+	var names [][]byte
+	for i := 0; i < 1000; i++ {
+		names = append(names, []byte("host_tag_value"+strconv.Itoa(i)))
+	}
+	interner := NewStringInterner(defaultInternCapacity)
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			_ = interner.Intern(names[i%len(names)])
+			i++
+		}
+	})
+}
@@ -23,10 +23,9 @@ import (
 	"sync"
 
 	flatbuffers "github.com/google/flatbuffers/go"
-	"github.com/lithammer/go-jump-consistent-hash"
 
+	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/pkg/encoding"
-	"github.com/lindb/lindb/pkg/fasttime"
 	"github.com/lindb/lindb/pkg/timeutil"
 	"github.com/lindb/lindb/proto/gen/v1/flatMetricsV1"
 )
@@ -39,6 +38,9 @@ type BrokerRow struct {
 	// IsOutOfTimeRange marks if this row is out-of time-range
 	// data is not accessible when its set to true
 	IsOutOfTimeRange bool
+	// IsDuplicate marks if all fields of this row are duplicate in batch,
+	// data is not accessible when its set to true
+	IsDuplicate bool
 }
 
 // FromBlock resets buffer, unmarshal from a new block,
@@ -53,14 +55,14 @@ func (row *BrokerRow) FromBlock(block []byte) {
 func (row *BrokerRow) Metric() flatMetricsV1.Metric { return row.m }
 
 func (row *BrokerRow) Size() int {
-	if row.IsOutOfTimeRange {
+	if row.IsOutOfTimeRange || row.IsDuplicate {
 		return 0
 	}
 	return len(row.buffer)
 }
 
 func (row *BrokerRow) WriteTo(writer io.Writer) (int, error) {
-	if row.IsOutOfTimeRange {
+	if row.IsOutOfTimeRange || row.IsDuplicate {
 		return 0, nil
 	}
 	return writer.Write(row.buffer)
@@ -68,11 +70,29 @@ func (row *BrokerRow) WriteTo(writer io.Writer) (int, error) {
 
 var brokerBatchRowsPool sync.Pool
 
+// RowRejection represents the metric rejected when decoding, index is the position of metric in request.
+type RowRejection struct {
+	Index int
+	Err   error
+}
+
 // BrokerBatchRows holds rows from ingestion
 // row will be putted into buffer after validation and re-building
 type BrokerBatchRows struct {
 	rows     []BrokerRow
 	rowCount int
+	// inputs is the number of metrics tried to append, including rejected ones
+	inputs     int
+	rejections []RowRejection
+	// backfill marks if rows are ingested by backfill mode,
+	// which bypasses the timestamp acceptance window.
+	backfill bool
+	// ackLevel overrides the write ack level of database if not empty.
+	ackLevel string
+	// acceptedAt is the time(unix nano) when ingestion accepts the request of rows.
+	acceptedAt int64
+	// writeToken is the write sequences of rows acked by storage, empty if ack level is async.
+	writeToken models.WriteToken
 
 	shardGroupIterator BrokerBatchShardIterator
 }
@@ -95,7 +115,43 @@ func NewBrokerBatchRows() (batch *BrokerBatchRows) {
 // Release releases rows context into sync.Pool
 func (br *BrokerBatchRows) Release() { brokerBatchRowsPool.Put(br) }
 
-func (br *BrokerBatchRows) reset() { br.rowCount = 0 }
+func (br *BrokerBatchRows) reset() {
+	br.rowCount = 0
+	br.inputs = 0
+	br.rejections = br.rejections[:0]
+	br.backfill = false
+	br.ackLevel = ""
+	br.acceptedAt = 0
+	br.writeToken = br.writeToken[:0]
+}
+
+// SetBackfill marks if rows are ingested by backfill mode.
+func (br *BrokerBatchRows) SetBackfill(backfill bool) { br.backfill = backfill }
+
+// IsBackfill returns if rows are ingested by backfill mode.
+func (br *BrokerBatchRows) IsBackfill() bool { return br.backfill }
+
+// SetAckLevel sets the write ack level of rows, overrides the ack level of database.
+func (br *BrokerBatchRows) SetAckLevel(ackLevel string) { br.ackLevel = ackLevel }
+
+// AckLevel returns the write ack level of rows, empty means using the ack level of database.
+func (br *BrokerBatchRows) AckLevel() string { return br.ackLevel }
+
+// SetAcceptedAt sets the time(unix nano) when ingestion accepts the request of rows.
+func (br *BrokerBatchRows) SetAcceptedAt(acceptedAt int64) { br.acceptedAt = acceptedAt }
+
+// AcceptedAt returns the time(unix nano) when ingestion accepts the request of rows, 0 if not set.
+func (br *BrokerBatchRows) AcceptedAt() int64 { return br.acceptedAt }
+
+// AddWriteToken adds the write sequences of rows acked by storage.
+func (br *BrokerBatchRows) AddWriteToken(token models.WriteToken) {
+	for _, seq := range token {
+		br.writeToken.Add(seq)
+	}
+}
+
+// WriteToken returns the write token of rows for read-your-writes, empty if rows are not acked by storage.
+func (br *BrokerBatchRows) WriteToken() models.WriteToken { return br.writeToken }
 
 func (br *BrokerBatchRows) Len() int { return br.rowCount }
 func (br *BrokerBatchRows) Less(i, j int) bool {
@@ -107,7 +163,7 @@ func (br *BrokerBatchRows) Rows() []BrokerRow { return br.rows[:br.rowCount] }
 // EvictOutOfTimeRange evicts and marks out-of-range metrics invalid
 func (br *BrokerBatchRows) EvictOutOfTimeRange(behind, ahead int64) (evicted int) {
 	// check metric timestamp if in acceptable time range
-	now := fasttime.UnixMilliseconds()
+	now := nowFunc()
 	for idx := 0; idx < br.Len(); idx++ {
 		if (behind > 0 && br.rows[idx].m.Timestamp() < now-behind) ||
 			(ahead > 0 && br.rows[idx].m.Timestamp() > now+ahead) {
@@ -118,21 +174,47 @@ func (br *BrokerBatchRows) EvictOutOfTimeRange(behind, ahead int64) (evicted int
 	return evicted
 }
 
+// Evict marks the metrics which filter returns true invalid like out-of-range metrics,
+// metrics already evicted or marked duplicate are skipped.
+func (br *BrokerBatchRows) Evict(filter func(row *BrokerRow) bool) (evicted int) {
+	for idx := 0; idx < br.Len(); idx++ {
+		if !br.rows[idx].IsOutOfTimeRange && !br.rows[idx].IsDuplicate && filter(&br.rows[idx]) {
+			br.rows[idx].IsOutOfTimeRange = true
+			evicted++
+		}
+	}
+	return evicted
+}
+
+// Reject records the metric which is rejected before appending, such as malformed line.
+func (br *BrokerBatchRows) Reject(err error) {
+	br.rejections = append(br.rejections, RowRejection{Index: br.inputs, Err: err})
+	br.inputs++
+}
+
+// Rejections returns the rejected metrics in order of index.
+func (br *BrokerBatchRows) Rejections() []RowRejection { return br.rejections }
+
 func (br *BrokerBatchRows) TryAppend(appendFunc func(row *BrokerRow) error) error {
 	if len(br.rows) <= br.rowCount {
 		br.rows = append(br.rows, BrokerRow{})
 	}
+	// row may be reused from pool, reset the eviction/duplicate marks
+	br.rows[br.rowCount].IsOutOfTimeRange = false
+	br.rows[br.rowCount].IsDuplicate = false
 	if err := appendFunc(&br.rows[br.rowCount]); err != nil {
+		br.Reject(err)
 		return err
 	}
 	// decoded successfully, move to next row index
+	br.inputs++
 	br.rowCount++
 	return nil
 }
 
 func (br *BrokerBatchRows) NewShardGroupIterator(numOfShards int32) *BrokerBatchShardIterator {
 	for i := 0; i < br.Len(); i++ {
-		br.rows[i].shardIdx = int(jump.Hash(br.rows[i].m.Hash(), numOfShards))
+		br.rows[i].shardIdx = int(models.GetShardID(br.rows[i].m.Hash(), numOfShards))
 	}
 	br.shardGroupIterator.batch = br
 	br.shardGroupIterator.Reset()
@@ -88,6 +88,27 @@ func assertBrokerBatchRows(t *testing.T, brokerRows *BrokerBatchRows) {
 		brokerRows.EvictOutOfTimeRange(100, 100), 100)
 }
 
+func Test_BrokerBatchRows_Evict(t *testing.T) {
+	batch := NewBrokerBatchRows()
+	defer batch.Release()
+	for i := 0; i < 10; i++ {
+		_ = batch.TryAppend(func(row *BrokerRow) error {
+			buildRow(row, int64(i+1))
+			return nil
+		})
+	}
+	batch.Rows()[1].IsOutOfTimeRange = true
+	// already evicted row is skipped
+	assert.Equal(t, 4, batch.Evict(func(row *BrokerRow) bool {
+		m := row.Metric()
+		return m.Timestamp()%2 == 0
+	}))
+	for _, row := range batch.Rows() {
+		m := row.Metric()
+		assert.Equal(t, m.Timestamp()%2 == 0, row.IsOutOfTimeRange)
+	}
+}
+
 func buildRow(row *BrokerRow, timestamp int64) {
 	builder, releaseFunc := NewRowBuilder()
 	defer releaseFunc(builder)
@@ -110,6 +131,29 @@ func Test_BrokerBatchRows_AppendError(t *testing.T) {
 	assert.Equal(t, 0, batch.Len())
 }
 
+func Test_BrokerBatchRows_Rejections(t *testing.T) {
+	batch := NewBrokerBatchRows()
+	_ = batch.TryAppend(func(row *BrokerRow) error {
+		buildRow(row, 1)
+		return nil
+	})
+	batch.Reject(io.ErrUnexpectedEOF)
+	_ = batch.TryAppend(func(row *BrokerRow) error {
+		return io.ErrShortBuffer
+	})
+	_ = batch.TryAppend(func(row *BrokerRow) error {
+		buildRow(row, 2)
+		return nil
+	})
+	assert.Equal(t, 2, batch.Len())
+	assert.Equal(t, []RowRejection{
+		{Index: 1, Err: io.ErrUnexpectedEOF},
+		{Index: 2, Err: io.ErrShortBuffer},
+	}, batch.Rejections())
+	batch.reset()
+	assert.Empty(t, batch.Rejections())
+}
+
 func Test_BrokerRow_Writer(t *testing.T) {
 	var row BrokerRow
 	row.IsOutOfTimeRange = true
@@ -28,8 +28,6 @@ import (
 	flatbuffers "github.com/google/flatbuffers/go"
 
 	"github.com/lindb/lindb/config"
-	"github.com/lindb/lindb/constants"
-	"github.com/lindb/lindb/pkg/fasttime"
 	"github.com/lindb/lindb/proto/gen/v1/flatMetricsV1"
 )
 
@@ -54,6 +52,9 @@ type rowSimpleField struct {
 	name  []byte
 	fType flatMetricsV1.SimpleFieldType
 	value float64
+
+	exemplars     []Exemplar
+	exemplarCount int
 }
 
 // RowBuilder builds a flat metric in order.
@@ -83,6 +84,9 @@ type RowBuilder struct {
 	kvs         []flatbuffers.UOffsetT
 	fieldNames  []flatbuffers.UOffsetT
 	fields      []flatbuffers.UOffsetT
+	// exemplars context, only used when fields carry exemplars
+	exemplars      []flatbuffers.UOffsetT
+	fieldExemplars []flatbuffers.UOffsetT
 }
 
 var rowBuilderPool sync.Pool
@@ -129,8 +133,13 @@ func (rb *RowBuilder) AddTag(key, value []byte) error {
 // AddSimpleField appends a simple field
 // Return false if field is invalid
 func (rb *RowBuilder) AddSimpleField(fieldName []byte, fieldType flatMetricsV1.SimpleFieldType, fieldValue float64) error {
-	if fieldType == flatMetricsV1.SimpleFieldTypeUnSpecified {
-		return fmt.Errorf("flat field type is unspecified")
+	if !isKnownFlatFieldType(fieldType) {
+		// apply default field type for schemaless ingestion
+		fieldType = defaultFlatFieldType()
+		if fieldType == flatMetricsV1.SimpleFieldTypeUnSpecified {
+			return fmt.Errorf("flat field type is unspecified")
+		}
+		onDefaultedFieldType()
 	}
 	if math.IsInf(fieldValue, 0) {
 		return fmt.Errorf("fieldValue is Inf :%f", fieldValue)
@@ -141,6 +150,9 @@ func (rb *RowBuilder) AddSimpleField(fieldName []byte, fieldType flatMetricsV1.S
 	if len(fieldName) == 0 {
 		return fmt.Errorf("fieldName is empty")
 	}
+	if config.GlobalBrokerConfig().Ingestion.NormalizeFieldName {
+		fieldName = NormalizeFieldName(fieldName)
+	}
 	if ShouldSanitizeFieldName(fieldName) {
 		fieldName = SanitizeFieldName(fieldName)
 	}
@@ -157,6 +169,34 @@ func (rb *RowBuilder) AddSimpleField(fieldName []byte, fieldType flatMetricsV1.S
 	// copy field type, field value
 	rb.simpleFields[sfIdx].fType = fieldType
 	rb.simpleFields[sfIdx].value = fieldValue
+	rb.simpleFields[sfIdx].exemplarCount = 0
+	return nil
+}
+
+// AddSimpleFieldExemplar attaches an exemplar to the last added simple field
+// Return error if there is no simple field or exemplar is invalid
+func (rb *RowBuilder) AddSimpleFieldExemplar(exemplar Exemplar) error {
+	if rb.simpleFieldCount == 0 {
+		return fmt.Errorf("no simple field for exemplar")
+	}
+	if len(exemplar.TraceID) == 0 {
+		return fmt.Errorf("exemplar trace id is empty")
+	}
+	if math.IsInf(exemplar.Value, 0) || math.IsNaN(exemplar.Value) {
+		return fmt.Errorf("exemplar value is invalid :%f", exemplar.Value)
+	}
+	sf := &rb.simpleFields[rb.simpleFieldCount-1]
+	sf.exemplarCount++
+	if sf.exemplarCount > len(sf.exemplars) {
+		sf.exemplars = append(sf.exemplars, Exemplar{})
+	}
+	e := &sf.exemplars[sf.exemplarCount-1]
+	// copy trace id, span id
+	e.TraceID = append(e.TraceID[:0], exemplar.TraceID...)
+	e.SpanID = append(e.SpanID[:0], exemplar.SpanID...)
+	e.Duration = exemplar.Duration
+	e.Value = exemplar.Value
+	e.Timestamp = exemplar.Timestamp
 	return nil
 }
 
@@ -220,8 +260,6 @@ func (rb *RowBuilder) AddMetricName(metricName []byte) {
 	rb.metricName = append(rb.metricName[:0], metricName...)
 }
 
-var defaultNameSpace = []byte(constants.DefaultNamespace)
-
 func (rb *RowBuilder) AddNameSpace(namespace []byte) {
 	if ShouldSanitizeNamespaceOrMetricName(namespace) {
 		namespace = SanitizeNamespaceOrMetricName(namespace)
@@ -256,6 +294,7 @@ func (rb *RowBuilder) Reset() {
 	rb.kvs = rb.kvs[:0]
 	rb.fieldNames = rb.fieldNames[:0]
 	rb.fields = rb.fields[:0]
+	rb.fieldExemplars = rb.fieldExemplars[:0]
 }
 
 var (
@@ -339,12 +378,22 @@ func (rb *RowBuilder) Build() ([]byte, error) {
 	for i := 0; i < rb.simpleFieldCount; i++ {
 		rb.fieldNames = append(rb.fieldNames, rb.flatBuilder.CreateByteString(rb.simpleFields[i].name))
 	}
+	// building exemplars of fields
+	for i := 0; i < rb.simpleFieldCount; i++ {
+		sf := &rb.simpleFields[i]
+		var exemplars flatbuffers.UOffsetT
+		exemplars, rb.exemplars = buildExemplars(rb.flatBuilder, sf.exemplars[:sf.exemplarCount], rb.exemplars)
+		rb.fieldExemplars = append(rb.fieldExemplars, exemplars)
+	}
 
 	for i := 0; i < rb.simpleFieldCount; i++ {
 		flatMetricsV1.SimpleFieldStart(rb.flatBuilder)
 		flatMetricsV1.SimpleFieldAddName(rb.flatBuilder, rb.fieldNames[i])
 		flatMetricsV1.SimpleFieldAddType(rb.flatBuilder, rb.simpleFields[i].fType)
 		flatMetricsV1.SimpleFieldAddValue(rb.flatBuilder, rb.simpleFields[i].value)
+		if rb.fieldExemplars[i] != 0 {
+			flatMetricsV1.SimpleFieldAddExemplars(rb.flatBuilder, rb.fieldExemplars[i])
+		}
 		rb.fields = append(rb.fields, flatMetricsV1.SimpleFieldEnd(rb.flatBuilder))
 	}
 	flatMetricsV1.MetricStartKeyValuesVector(rb.flatBuilder, rb.rowKVs.kvCount)
@@ -393,14 +442,14 @@ func (rb *RowBuilder) Build() ([]byte, error) {
 Serialize:
 	metricName := rb.flatBuilder.CreateByteString(rb.metricName)
 	if len(rb.nameSpace) == 0 {
-		rb.nameSpace = defaultNameSpace
+		rb.nameSpace = append(rb.nameSpace[:0], defaultNamespace()...)
 	}
 	namespace := rb.flatBuilder.CreateByteString(rb.nameSpace)
 	flatMetricsV1.MetricStart(rb.flatBuilder)
 	flatMetricsV1.MetricAddNamespace(rb.flatBuilder, namespace)
 	flatMetricsV1.MetricAddName(rb.flatBuilder, metricName)
 	if rb.timestamp == 0 {
-		rb.timestamp = fasttime.UnixMilliseconds()
+		rb.timestamp = nowFunc()
 	}
 	flatMetricsV1.MetricAddTimestamp(rb.flatBuilder, rb.timestamp)
 	flatMetricsV1.MetricAddKeyValues(rb.flatBuilder, kvs)
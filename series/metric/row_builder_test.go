@@ -21,6 +21,9 @@ import (
 	"math"
 	"testing"
 
+	flatbuffers "github.com/google/flatbuffers/go"
+
+	"github.com/lindb/lindb/config"
 	"github.com/lindb/lindb/proto/gen/v1/flatMetricsV1"
 
 	"github.com/stretchr/testify/assert"
@@ -219,3 +222,164 @@ func Test_dedupTagsThenXXHash_One(t *testing.T) {
 	_ = rb.dedupTagsThenXXHash()
 	assert.Equal(t, "ccc=g", rb.hashBuf.String())
 }
+
+func Test_RowBuilder_NormalizeFieldName(t *testing.T) {
+	defaultCfg := config.GlobalBrokerConfig()
+	defer config.SetGlobalBrokerConfig(defaultCfg)
+
+	buildFieldName := func(fieldName string) string {
+		rb := newRowBuilder()
+		assert.NoError(t, rb.AddSimpleField([]byte(fieldName), flatMetricsV1.SimpleFieldTypeDeltaSum, 1))
+		return string(rb.simpleFields[0].name)
+	}
+	// disable by default
+	assert.Equal(t, "CPU.Usage", buildFieldName("CPU.Usage"))
+
+	cfg := *defaultCfg
+	cfg.Ingestion.NormalizeFieldName = true
+	config.SetGlobalBrokerConfig(&cfg)
+	assert.Equal(t, "cpu_usage", buildFieldName("CPU.Usage"))
+	assert.Equal(t, buildFieldName("cpu_usage"), buildFieldName("CPU.Usage"))
+}
+
+func Test_RowBuilder_DefaultNamespace(t *testing.T) {
+	defaultCfg := config.GlobalBrokerConfig()
+	defer config.SetGlobalBrokerConfig(defaultCfg)
+
+	buildNamespace := func(namespace string) string {
+		rb := newRowBuilder()
+		assert.NoError(t, rb.AddSimpleField([]byte("f1"), flatMetricsV1.SimpleFieldTypeDeltaSum, 1))
+		rb.AddMetricName([]byte("cpu"))
+		rb.AddNameSpace([]byte(namespace))
+		var row BrokerRow
+		assert.NoError(t, rb.BuildTo(&row))
+		m := row.Metric()
+		return string(m.Namespace())
+	}
+	assert.Equal(t, "default-ns", buildNamespace(""))
+	assert.Equal(t, "ns", buildNamespace("ns"))
+
+	cfg := *defaultCfg
+	cfg.Ingestion.DefaultNamespace = "my-ns"
+	config.SetGlobalBrokerConfig(&cfg)
+	assert.Equal(t, "my-ns", buildNamespace(""))
+	assert.Equal(t, "ns", buildNamespace("ns"))
+	cfg.Ingestion.DefaultNamespace = ""
+	assert.Equal(t, "default-ns", buildNamespace(""))
+}
+
+func Test_RowBuilder_DefaultFieldType(t *testing.T) {
+	defaultCfg := config.GlobalBrokerConfig()
+	defer config.SetGlobalBrokerConfig(defaultCfg)
+
+	defaulted := 0
+	AddDefaultedFieldTypeHandler(func() { defaulted++ })
+
+	buildFieldType := func(fieldType flatMetricsV1.SimpleFieldType) (flatMetricsV1.SimpleFieldType, error) {
+		rb := newRowBuilder()
+		if err := rb.AddSimpleField([]byte("f1"), fieldType, 1); err != nil {
+			return 0, err
+		}
+		return rb.simpleFields[0].fType, nil
+	}
+	// reject unset type by default
+	_, err := buildFieldType(flatMetricsV1.SimpleFieldTypeUnSpecified)
+	assert.Error(t, err)
+	_, err = buildFieldType(flatMetricsV1.SimpleFieldType(100))
+	assert.Error(t, err)
+
+	cfg := *defaultCfg
+	cfg.Ingestion.DefaultFieldType = config.FieldTypeGauge
+	config.SetGlobalBrokerConfig(&cfg)
+	// set type
+	fType, err := buildFieldType(flatMetricsV1.SimpleFieldTypeDeltaSum)
+	assert.NoError(t, err)
+	assert.Equal(t, flatMetricsV1.SimpleFieldTypeDeltaSum, fType)
+	assert.Zero(t, defaulted)
+	// unset/unknown type
+	fType, err = buildFieldType(flatMetricsV1.SimpleFieldTypeUnSpecified)
+	assert.NoError(t, err)
+	assert.Equal(t, flatMetricsV1.SimpleFieldTypeGauge, fType)
+	fType, err = buildFieldType(flatMetricsV1.SimpleFieldType(100))
+	assert.NoError(t, err)
+	assert.Equal(t, flatMetricsV1.SimpleFieldTypeGauge, fType)
+	assert.Equal(t, 2, defaulted)
+
+	cfg.Ingestion.DefaultFieldType = config.FieldTypeMin
+	fType, err = buildFieldType(flatMetricsV1.SimpleFieldTypeUnSpecified)
+	assert.NoError(t, err)
+	assert.Equal(t, flatMetricsV1.SimpleFieldTypeMin, fType)
+}
+
+func Test_RowBuilder_Exemplar(t *testing.T) {
+	rb := newRowBuilder()
+	rb.AddMetricName([]byte("http"))
+	rb.AddTimestamp(1000)
+	// no simple field
+	assert.Error(t, rb.AddSimpleFieldExemplar(Exemplar{TraceID: []byte("t1")}))
+	assert.NoError(t, rb.AddSimpleField([]byte("latency"), flatMetricsV1.SimpleFieldTypeGauge, 10))
+	assert.Error(t, rb.AddSimpleFieldExemplar(Exemplar{}))
+	assert.Error(t, rb.AddSimpleFieldExemplar(Exemplar{TraceID: []byte("t1"), Value: math.NaN()}))
+	assert.Error(t, rb.AddSimpleFieldExemplar(Exemplar{TraceID: []byte("t1"), Value: math.Inf(1)}))
+	assert.NoError(t, rb.AddSimpleFieldExemplar(Exemplar{
+		TraceID: []byte("t1"), SpanID: []byte("s1"), Duration: 5, Value: 10, Timestamp: 999}))
+	assert.NoError(t, rb.AddSimpleFieldExemplar(Exemplar{TraceID: []byte("t2"), Value: 12, Timestamp: 998}))
+	assert.NoError(t, rb.AddSimpleField([]byte("count"), flatMetricsV1.SimpleFieldTypeDeltaSum, 1))
+
+	data, err := rb.Build()
+	assert.NoError(t, err)
+	var row StorageRow
+	row.Unmarshal(data[flatbuffers.SizeUOffsetT:])
+	itr := row.NewSimpleFieldIterator()
+	var e Exemplar
+	// latency with exemplars
+	assert.True(t, itr.HasNext())
+	assert.Equal(t, 2, itr.NextExemplarsLen())
+	assert.True(t, itr.NextExemplar(0, &e))
+	assert.Equal(t, Exemplar{TraceID: []byte("t1"), SpanID: []byte("s1"), Duration: 5, Value: 10, Timestamp: 999}, e)
+	assert.True(t, itr.NextExemplar(1, &e))
+	assert.Equal(t, "t2", string(e.TraceID))
+	assert.Nil(t, e.SpanID)
+	assert.Equal(t, float64(12), e.Value)
+	assert.Equal(t, int64(998), e.Timestamp)
+	assert.False(t, itr.NextExemplar(2, &e))
+	// count without exemplars
+	assert.True(t, itr.HasNext())
+	assert.Equal(t, 0, itr.NextExemplarsLen())
+	assert.False(t, itr.NextExemplar(0, &e))
+	assert.False(t, itr.HasNext())
+
+	// exemplars are not kept after reset
+	rb.Reset()
+	rb.AddMetricName([]byte("http"))
+	rb.AddTimestamp(1000)
+	assert.NoError(t, rb.AddSimpleField([]byte("latency"), flatMetricsV1.SimpleFieldTypeGauge, 10))
+	data, err = rb.Build()
+	assert.NoError(t, err)
+	row.Unmarshal(data[flatbuffers.SizeUOffsetT:])
+	itr = row.NewSimpleFieldIterator()
+	assert.True(t, itr.HasNext())
+	assert.Equal(t, 0, itr.NextExemplarsLen())
+}
+
+func Test_RowBuilder_Exemplar_ZeroOverhead(t *testing.T) {
+	build := func(withExemplar bool) []byte {
+		rb := newRowBuilder()
+		rb.AddMetricName([]byte("http"))
+		rb.AddTimestamp(1000)
+		assert.NoError(t, rb.AddSimpleField([]byte("latency"), flatMetricsV1.SimpleFieldTypeGauge, 10))
+		if withExemplar {
+			assert.NoError(t, rb.AddSimpleFieldExemplar(Exemplar{TraceID: []byte("t1")}))
+		}
+		data, err := rb.Build()
+		assert.NoError(t, err)
+		return append([]byte{}, data...)
+	}
+	var row BrokerRow
+	plain := build(false)
+	row.FromBlock(plain)
+	var f flatMetricsV1.SimpleField
+	assert.True(t, row.m.SimpleFields(&f, 0))
+	assert.Equal(t, 0, f.ExemplarsLength())
+	assert.Greater(t, len(build(true)), len(plain))
+}
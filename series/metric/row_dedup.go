@@ -0,0 +1,176 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package metric
+
+import (
+	"encoding/binary"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/proto/gen/v1/flatMetricsV1"
+)
+
+// compoundFieldIdx represents the compound field of metric in dedupField.
+const compoundFieldIdx = -1
+
+// dedupField represents the occurrence of (series, timestamp, field) kept in batch.
+type dedupField struct {
+	rowIdx   int // index of row in batch
+	fieldIdx int // index of simple field in row, compoundFieldIdx for compound field
+}
+
+// Dedup marks the duplicate metrics in the batch, fields are identified by (series, timestamp, field),
+// metric is duplicate if all of its fields are kept by other metrics,
+// metric with only part of fields duplicated is kept because field cannot be removed from metric.
+// Policy decides which occurrence is kept:
+// first/last keeps the first/last occurrence,
+// sum keeps the first occurrence with simple field values of duplicates summed up,
+// metrics with compound field or whose values cannot be summed in place are not deduplicated by sum.
+// Metrics already evicted are skipped, returns the number of duplicates marked.
+func (br *BrokerBatchRows) Dedup(policy string) (dropped int) {
+	switch policy {
+	case config.DedupPolicyFirst, config.DedupPolicyLast, config.DedupPolicySum:
+	default:
+		return 0
+	}
+	if br.Len() < 2 {
+		return 0
+	}
+	var (
+		keys dedupKeys
+		kept = make(map[string]dedupField, br.Len())
+	)
+	dedup := func(idx int) {
+		row := &br.rows[idx]
+		if row.IsOutOfTimeRange || row.IsDuplicate {
+			return
+		}
+		keys.build(row)
+		duplicate := keys.len() > 0
+		for i := 0; i < keys.len() && duplicate; i++ {
+			_, duplicate = kept[string(keys.key(i))]
+		}
+		if duplicate && (policy != config.DedupPolicySum || br.sumFields(row, &keys, kept)) {
+			row.IsDuplicate = true
+			dropped++
+			return
+		}
+		for i := 0; i < keys.len(); i++ {
+			if _, ok := kept[string(keys.key(i))]; !ok {
+				kept[string(keys.key(i))] = dedupField{rowIdx: idx, fieldIdx: keys.fields[i]}
+			}
+		}
+	}
+	if policy == config.DedupPolicyLast {
+		// the last occurrence is the first one in reverse order
+		for idx := br.Len() - 1; idx >= 0; idx-- {
+			dedup(idx)
+		}
+		return dropped
+	}
+	for idx := 0; idx < br.Len(); idx++ {
+		dedup(idx)
+	}
+	return dropped
+}
+
+// sumFields adds up the simple field values of duplicate row into the kept fields,
+// returns false without any change if row has compound field or any kept value cannot be mutated in place.
+func (br *BrokerBatchRows) sumFields(row *BrokerRow, keys *dedupKeys, kept map[string]dedupField) bool {
+	if row.m.CompoundField(nil) != nil {
+		return false
+	}
+	var f, kf flatMetricsV1.SimpleField
+	sums := make([]float64, keys.len())
+	for i := 0; i < keys.len(); i++ {
+		field := kept[string(keys.key(i))]
+		if field.fieldIdx == compoundFieldIdx ||
+			!row.m.SimpleFields(&f, keys.fields[i]) ||
+			!br.rows[field.rowIdx].m.SimpleFields(&kf, field.fieldIdx) {
+			return false
+		}
+		sums[i] = kf.Value() + f.Value()
+		// zero value is not stored in flat buffer, probes if value can be mutated in place
+		if sums[i] != kf.Value() && !kf.MutateValue(kf.Value()) {
+			return false
+		}
+	}
+	for i := 0; i < keys.len(); i++ {
+		field := kept[string(keys.key(i))]
+		if br.rows[field.rowIdx].m.SimpleFields(&kf, field.fieldIdx) {
+			kf.MutateValue(sums[i])
+		}
+	}
+	return true
+}
+
+// dedupKeys represents the keys of (series, timestamp, field) of each field in row, reused between rows.
+type dedupKeys struct {
+	buf       []byte // series/timestamp prefix, then keys of fields
+	prefixLen int
+	ends      []int // end offset of each key in buf
+	fields    []int // index of simple field of each key, compoundFieldIdx for compound field
+}
+
+// build builds the keys of all fields in row.
+func (k *dedupKeys) build(row *BrokerRow) {
+	k.buf = append(k.buf[:0], row.m.Namespace()...)
+	k.buf = append(k.buf, 0)
+	k.buf = append(k.buf, row.m.Name()...)
+	k.buf = append(k.buf, 0)
+	var scratch [8]byte
+	binary.LittleEndian.PutUint64(scratch[:], row.m.Hash())
+	k.buf = append(k.buf, scratch[:]...)
+	binary.LittleEndian.PutUint64(scratch[:], uint64(row.m.Timestamp()))
+	k.buf = append(k.buf, scratch[:]...)
+	k.prefixLen = len(k.buf)
+	k.ends = k.ends[:0]
+	k.fields = k.fields[:0]
+
+	var f flatMetricsV1.SimpleField
+	for j := 0; j < row.m.SimpleFieldsLength(); j++ {
+		if row.m.SimpleFields(&f, j) {
+			k.buf = append(k.buf, k.buf[:k.prefixLen]...)
+			k.buf = append(k.buf, f.Name()...)
+			k.add(j)
+		}
+	}
+	if row.m.CompoundField(nil) != nil {
+		// compound field has no name, marks it with an empty field name
+		k.buf = append(k.buf, k.buf[:k.prefixLen]...)
+		k.add(compoundFieldIdx)
+	}
+}
+
+// add ends the key of field being built.
+func (k *dedupKeys) add(fieldIdx int) {
+	k.buf = append(k.buf, 0)
+	k.ends = append(k.ends, len(k.buf))
+	k.fields = append(k.fields, fieldIdx)
+}
+
+// len returns the number of keys.
+func (k *dedupKeys) len() int { return len(k.ends) }
+
+// key returns the key of i-th field.
+func (k *dedupKeys) key(i int) []byte {
+	start := k.prefixLen
+	if i > 0 {
+		start = k.ends[i-1]
+	}
+	return k.buf[start:k.ends[i]]
+}
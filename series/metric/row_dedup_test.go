@@ -0,0 +1,169 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package metric
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/proto/gen/v1/flatMetricsV1"
+)
+
+func buildDedupRow(row *BrokerRow, host string, timestamp int64, fields map[string]float64) {
+	builder, releaseFunc := NewRowBuilder()
+	defer releaseFunc(builder)
+
+	builder.AddMetricName([]byte("cpu"))
+	_ = builder.AddTag([]byte("host"), []byte(host))
+	for name, value := range fields {
+		_ = builder.AddSimpleField([]byte(name), flatMetricsV1.SimpleFieldTypeDeltaSum, value)
+	}
+	builder.AddTimestamp(timestamp)
+	_ = builder.BuildTo(row)
+}
+
+// newDedupBatch returns a batch with duplicates: row 0/2/4 are duplicates, row 1 is another series,
+// row 3 has another timestamp, row 5 has part of fields.
+func newDedupBatch() *BrokerBatchRows {
+	batch := NewBrokerBatchRows()
+	appendRow := func(host string, timestamp int64, fields map[string]float64) {
+		_ = batch.TryAppend(func(row *BrokerRow) error {
+			buildDedupRow(row, host, timestamp, fields)
+			return nil
+		})
+	}
+	appendRow("1.1.1.1", 10, map[string]float64{"f1": 1, "f2": 10})
+	appendRow("1.1.1.2", 10, map[string]float64{"f1": 2, "f2": 20})
+	appendRow("1.1.1.1", 10, map[string]float64{"f2": 30, "f1": 3})
+	appendRow("1.1.1.1", 20, map[string]float64{"f1": 4, "f2": 40})
+	appendRow("1.1.1.1", 10, map[string]float64{"f1": 5, "f2": 50})
+	appendRow("1.1.1.1", 10, map[string]float64{"f1": 6})
+	return batch
+}
+
+func fieldValues(row *BrokerRow) map[string]float64 {
+	values := make(map[string]float64)
+	m := row.Metric()
+	var f flatMetricsV1.SimpleField
+	for j := 0; j < m.SimpleFieldsLength(); j++ {
+		if m.SimpleFields(&f, j) {
+			values[string(f.Name())] = f.Value()
+		}
+	}
+	return values
+}
+
+func duplicateRows(batch *BrokerBatchRows) (duplicates []int) {
+	for idx, row := range batch.Rows() {
+		if row.IsDuplicate {
+			duplicates = append(duplicates, idx)
+		}
+	}
+	return duplicates
+}
+
+func Test_BrokerBatchRows_Dedup_None(t *testing.T) {
+	for _, policy := range []string{"", config.DedupPolicyNone} {
+		batch := newDedupBatch()
+		assert.Zero(t, batch.Dedup(policy))
+		assert.Empty(t, duplicateRows(batch))
+		batch.Release()
+	}
+}
+
+func Test_BrokerBatchRows_Dedup_First(t *testing.T) {
+	batch := newDedupBatch()
+	defer batch.Release()
+
+	assert.Equal(t, 3, batch.Dedup(config.DedupPolicyFirst))
+	assert.Equal(t, []int{2, 4, 5}, duplicateRows(batch))
+	assert.Equal(t, map[string]float64{"f1": 1, "f2": 10}, fieldValues(&batch.Rows()[0]))
+	for _, row := range batch.Rows() {
+		assert.False(t, row.IsOutOfTimeRange)
+	}
+	assert.Zero(t, batch.Rows()[2].Size())
+}
+
+func Test_BrokerBatchRows_Dedup_Last(t *testing.T) {
+	batch := newDedupBatch()
+	defer batch.Release()
+
+	// row 4 keeps the last f2, row 5 keeps the last f1
+	assert.Equal(t, 2, batch.Dedup(config.DedupPolicyLast))
+	assert.Equal(t, []int{0, 2}, duplicateRows(batch))
+	assert.Equal(t, map[string]float64{"f1": 5, "f2": 50}, fieldValues(&batch.Rows()[4]))
+}
+
+func Test_BrokerBatchRows_Dedup_Sum(t *testing.T) {
+	batch := newDedupBatch()
+	defer batch.Release()
+
+	assert.Equal(t, 3, batch.Dedup(config.DedupPolicySum))
+	assert.Equal(t, []int{2, 4, 5}, duplicateRows(batch))
+	assert.Equal(t, map[string]float64{"f1": 15, "f2": 90}, fieldValues(&batch.Rows()[0]))
+	// other rows are untouched
+	assert.Equal(t, map[string]float64{"f1": 2, "f2": 20}, fieldValues(&batch.Rows()[1]))
+	assert.Equal(t, map[string]float64{"f1": 4, "f2": 40}, fieldValues(&batch.Rows()[3]))
+}
+
+func Test_BrokerBatchRows_Dedup_PartialFields(t *testing.T) {
+	batch := NewBrokerBatchRows()
+	defer batch.Release()
+	for _, fields := range []map[string]float64{{"f1": 1}, {"f2": 2}, {"f1": 3, "f3": 3}, {"f1": 4, "f2": 4}} {
+		fields := fields
+		_ = batch.TryAppend(func(row *BrokerRow) error {
+			buildDedupRow(row, "1.1.1.1", 10, fields)
+			return nil
+		})
+	}
+	// row 2 has new field f3, row 3 has all fields kept by row 0/1
+	assert.Equal(t, 1, batch.Dedup(config.DedupPolicySum))
+	assert.Equal(t, []int{3}, duplicateRows(batch))
+	assert.Equal(t, map[string]float64{"f1": 5}, fieldValues(&batch.Rows()[0]))
+	assert.Equal(t, map[string]float64{"f2": 6}, fieldValues(&batch.Rows()[1]))
+	assert.Equal(t, map[string]float64{"f1": 3, "f3": 3}, fieldValues(&batch.Rows()[2]))
+}
+
+func Test_BrokerBatchRows_Dedup_Sum_ZeroValue(t *testing.T) {
+	batch := NewBrokerBatchRows()
+	defer batch.Release()
+	for _, value := range []float64{0, 1} {
+		value := value
+		_ = batch.TryAppend(func(row *BrokerRow) error {
+			buildDedupRow(row, "1.1.1.1", 10, map[string]float64{"f1": value})
+			return nil
+		})
+	}
+	// zero value cannot be mutated in place, both rows are kept
+	assert.Zero(t, batch.Dedup(config.DedupPolicySum))
+	assert.Empty(t, duplicateRows(batch))
+}
+
+func Test_BrokerBatchRows_Dedup_SkipEvicted(t *testing.T) {
+	batch := newDedupBatch()
+	defer batch.Release()
+
+	batch.Rows()[0].IsOutOfTimeRange = true
+	assert.Equal(t, 2, batch.Dedup(config.DedupPolicyFirst))
+	assert.Equal(t, []int{4, 5}, duplicateRows(batch))
+	assert.False(t, batch.Rows()[0].IsDuplicate)
+	// duplicate metrics are skipped by evict
+	assert.Equal(t, 3, batch.Evict(func(_ *BrokerRow) bool { return true }))
+}
@@ -45,6 +45,7 @@ type BrokerRowFlatDecoder struct {
 
 	namespace    []byte
 	enrichedTags tag.Tags
+	backfill     bool // if backfill, skip timestamp acceptance window checking
 }
 
 var brokerRowFlatDecoderPool sync.Pool
@@ -60,6 +61,7 @@ func NewBrokerRowFlatDecoder(
 	releaseFunc = func(decoder *BrokerRowFlatDecoder) {
 		decoder.reader = nil
 		decoder.readLen = 0
+		decoder.backfill = false
 		brokerRowFlatDecoderPool.Put(decoder)
 	}
 	item := brokerRowFlatDecoderPool.Get()
@@ -74,6 +76,9 @@ func NewBrokerRowFlatDecoder(
 	return decoder, releaseFunc
 }
 
+// SetBackfill sets if decoding in backfill mode, which bypasses timestamp acceptance window.
+func (itr *BrokerRowFlatDecoder) SetBackfill(backfill bool) { itr.backfill = backfill }
+
 // resetForNextDecode resets context for decoding next row
 func (itr *BrokerRowFlatDecoder) resetForNextDecode() {
 	itr.rowBuilder.Reset()
@@ -117,6 +122,15 @@ func (itr *BrokerRowFlatDecoder) DecodeTo(row *BrokerRow) error {
 	itr.readLen += n
 
 	itr.originRow.m.Init(itr.buf, flatbuffers.GetUOffsetT(itr.buf))
+	if err := ValidateFlatMetric(&itr.originRow.m); err != nil {
+		return err
+	}
+	// zero timestamp will be set as now when building row
+	if ts := itr.originRow.m.Timestamp(); ts != 0 && !itr.backfill {
+		if err := CheckTimestampWindow(ts); err != nil {
+			return err
+		}
+	}
 
 	if err := itr.rebuild(); err != nil {
 		return err
@@ -19,11 +19,14 @@ package metric
 
 import (
 	"bytes"
+	"errors"
 	"math"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/lindb/lindb/pkg/fasttime"
+	"github.com/lindb/lindb/pkg/timeutil"
 	protoMetricsV1 "github.com/lindb/lindb/proto/gen/v1/metrics"
 	"github.com/lindb/lindb/series/tag"
 )
@@ -91,3 +94,29 @@ func Test_NewBrokerRowFlatDecoder(t *testing.T) {
 	assert.Error(t, decoder.DecodeTo(&row))
 	assert.Equal(t, len(buf.Bytes()), decoder.ReadLen())
 }
+
+func Test_BrokerRowFlatDecoder_backfill(t *testing.T) {
+	converter := NewProtoConverter()
+	converter.SetBackfill(true)
+	data, err := converter.MarshalProtoMetricV1(&protoMetricsV1.Metric{
+		Name:      "test",
+		Timestamp: fasttime.UnixMilliseconds() + 2*timeutil.OneHour,
+		SimpleFields: []*protoMetricsV1.SimpleField{
+			{Name: "F1", Type: protoMetricsV1.SimpleFieldType_Min, Value: 1},
+		},
+	})
+	assert.NoError(t, err)
+
+	var row BrokerRow
+	// timestamp out of acceptance window
+	decoder, releaseFunc := NewBrokerRowFlatDecoder(bytes.NewReader(data), nil, nil)
+	assert.True(t, decoder.HasNext())
+	assert.True(t, errors.Is(decoder.DecodeTo(&row), ErrTimestampTooNew))
+	releaseFunc(decoder)
+	// backfill bypasses timestamp acceptance window
+	decoder, releaseFunc = NewBrokerRowFlatDecoder(bytes.NewReader(data), nil, nil)
+	defer releaseFunc(decoder)
+	decoder.SetBackfill(true)
+	assert.True(t, decoder.HasNext())
+	assert.NoError(t, decoder.DecodeTo(&row))
+}
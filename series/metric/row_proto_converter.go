@@ -25,7 +25,7 @@ import (
 
 	flatbuffers "github.com/google/flatbuffers/go"
 
-	"github.com/lindb/lindb/pkg/fasttime"
+	"github.com/lindb/lindb/config"
 	"github.com/lindb/lindb/pkg/strutil"
 	"github.com/lindb/lindb/proto/gen/v1/flatMetricsV1"
 	protoMetricsV1 "github.com/lindb/lindb/proto/gen/v1/metrics"
@@ -40,10 +40,15 @@ type BrokerRowProtoConverter struct {
 	kvs        []flatbuffers.UOffsetT
 	fieldNames []flatbuffers.UOffsetT
 	fields     []flatbuffers.UOffsetT
+	// exemplars context, only used when fields carry exemplars
+	exemplars      []flatbuffers.UOffsetT
+	fieldExemplars []flatbuffers.UOffsetT
+	exemplarValues []Exemplar
 
 	// ingestion meta info
 	namespace    []byte
 	enrichedTags tag.Tags
+	backfill     bool // if backfill, skip timestamp acceptance window checking
 }
 
 // Reset resets all data-structures
@@ -51,8 +56,12 @@ func (rc *BrokerRowProtoConverter) Reset() {
 	rc.resetForNextConverter()
 	rc.namespace = rc.namespace[:0]
 	rc.enrichedTags = rc.enrichedTags[:0]
+	rc.backfill = false
 }
 
+// SetBackfill sets if converting in backfill mode, which bypasses timestamp acceptance window.
+func (rc *BrokerRowProtoConverter) SetBackfill(backfill bool) { rc.backfill = backfill }
+
 func (rc *BrokerRowProtoConverter) resetForNextConverter() {
 	rc.flatBuilder.Reset()
 	rc.keys = rc.keys[:0]
@@ -60,6 +69,7 @@ func (rc *BrokerRowProtoConverter) resetForNextConverter() {
 	rc.fieldNames = rc.fieldNames[:0]
 	rc.kvs = rc.kvs[:0]
 	rc.fields = rc.fields[:0]
+	rc.fieldExemplars = rc.fieldExemplars[:0]
 }
 
 func (rc *BrokerRowProtoConverter) validateMetric(m *protoMetricsV1.Metric) error {
@@ -76,7 +86,11 @@ func (rc *BrokerRowProtoConverter) validateMetric(m *protoMetricsV1.Metric) erro
 	}
 	// re-set timestamp on zero
 	if m.Timestamp == 0 {
-		m.Timestamp = fasttime.UnixMilliseconds()
+		m.Timestamp = nowFunc()
+	} else if !rc.backfill {
+		if err := CheckTimestampWindow(m.Timestamp); err != nil {
+			return err
+		}
 	}
 	for i := 0; i < len(rc.enrichedTags); i++ {
 		m.Tags = append(m.Tags, &protoMetricsV1.KeyValue{
@@ -116,12 +130,21 @@ func (rc *BrokerRowProtoConverter) validateMetric(m *protoMetricsV1.Metric) erro
 		}
 		// check sanitize
 		fieldName := strutil.String2ByteSlice(m.SimpleFields[idx].Name)
+		if config.GlobalBrokerConfig().Ingestion.NormalizeFieldName {
+			fieldName = NormalizeFieldName(fieldName)
+			m.SimpleFields[idx].Name = string(fieldName)
+		}
 		if ShouldSanitizeFieldName(fieldName) {
 			m.SimpleFields[idx].Name = string(SanitizeFieldName(fieldName))
 		}
-		// field type unspecified
-		if m.SimpleFields[idx].Type == protoMetricsV1.SimpleFieldType_SIMPLE_UNSPECIFIED {
-			return ErrBadMetricPBFormat
+		// field type unspecified, apply default field type for schemaless ingestion
+		if !isKnownProtoFieldType(m.SimpleFields[idx].Type) {
+			fieldType := defaultProtoFieldType()
+			if fieldType == protoMetricsV1.SimpleFieldType_SIMPLE_UNSPECIFIED {
+				return ErrBadMetricPBFormat
+			}
+			m.SimpleFields[idx].Type = fieldType
+			onDefaultedFieldType()
 		}
 		v := m.SimpleFields[idx].Value
 		if math.IsNaN(v) {
@@ -210,6 +233,26 @@ func (rc *BrokerRowProtoConverter) MarshalProtoMetricV1(m *protoMetricsV1.Metric
 	for i := 0; i < len(m.SimpleFields); i++ {
 		rc.fieldNames = append(rc.fieldNames, rc.flatBuilder.CreateString(m.SimpleFields[i].Name))
 	}
+	// building exemplars of fields, proto exemplar is attached to the sample of field
+	for i := 0; i < len(m.SimpleFields); i++ {
+		sf := m.SimpleFields[i]
+		rc.exemplarValues = rc.exemplarValues[:0]
+		for _, e := range sf.Exemplars {
+			if e == nil || len(e.TraceId) == 0 {
+				continue
+			}
+			rc.exemplarValues = append(rc.exemplarValues, Exemplar{
+				TraceID:   e.TraceId,
+				SpanID:    e.SpanId,
+				Duration:  e.Duration,
+				Value:     sf.Value,
+				Timestamp: m.Timestamp,
+			})
+		}
+		var exemplars flatbuffers.UOffsetT
+		exemplars, rc.exemplars = buildExemplars(rc.flatBuilder, rc.exemplarValues, rc.exemplars)
+		rc.fieldExemplars = append(rc.fieldExemplars, exemplars)
+	}
 
 	// building field names
 	for i := 0; i < len(m.SimpleFields); i++ {
@@ -227,6 +270,9 @@ func (rc *BrokerRowProtoConverter) MarshalProtoMetricV1(m *protoMetricsV1.Metric
 			flatMetricsV1.SimpleFieldAddType(rc.flatBuilder, flatMetricsV1.SimpleFieldTypeMin)
 		}
 		flatMetricsV1.SimpleFieldAddValue(rc.flatBuilder, sf.Value)
+		if rc.fieldExemplars[i] != 0 {
+			flatMetricsV1.SimpleFieldAddExemplars(rc.flatBuilder, rc.fieldExemplars[i])
+		}
 		rc.fields = append(rc.fields, flatMetricsV1.SimpleFieldEnd(rc.flatBuilder))
 	}
 
@@ -19,13 +19,19 @@ package metric
 
 import (
 	"bytes"
+	"errors"
 	"math"
 	"sort"
 	"strconv"
 	"testing"
 
+	flatbuffers "github.com/google/flatbuffers/go"
+
+	"github.com/lindb/lindb/config"
 	"github.com/lindb/lindb/pkg/fasttime"
 	"github.com/lindb/lindb/pkg/strutil"
+	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/proto/gen/v1/flatMetricsV1"
 	protoMetricsV1 "github.com/lindb/lindb/proto/gen/v1/metrics"
 	"github.com/lindb/lindb/series/tag"
 
@@ -241,6 +247,53 @@ func Test_BrokerRowProtoConverter_ValidateMetric(t *testing.T) {
 			Values:         []float64{1, 2, 3, 4, 5},
 		},
 	}))
+	// timestamp too far in the future
+	err := converter.validateMetric(&protoMetricsV1.Metric{
+		Name:         "test-metric",
+		Timestamp:    fasttime.UnixMilliseconds() + 2*timeutil.OneHour,
+		SimpleFields: []*protoMetricsV1.SimpleField{{Name: "f1", Type: protoMetricsV1.SimpleFieldType_Max, Value: 1}},
+	})
+	assert.True(t, errors.Is(err, ErrTimestampTooNew))
+}
+
+func Test_BrokerRowProtoConverter_DefaultFieldType(t *testing.T) {
+	defaultCfg := config.GlobalBrokerConfig()
+	defer config.SetGlobalBrokerConfig(defaultCfg)
+
+	converter, releaseFunc := NewBrokerRowProtoConverter(nil, nil)
+	defer releaseFunc(converter)
+
+	newMetric := func(fieldType protoMetricsV1.SimpleFieldType) *protoMetricsV1.Metric {
+		return &protoMetricsV1.Metric{
+			Name:         "test-metric",
+			SimpleFields: []*protoMetricsV1.SimpleField{{Name: "f1", Type: fieldType, Value: 1}},
+		}
+	}
+	// reject unset type by default
+	assert.Equal(t, ErrBadMetricPBFormat, converter.validateMetric(newMetric(protoMetricsV1.SimpleFieldType_SIMPLE_UNSPECIFIED)))
+	assert.Equal(t, ErrBadMetricPBFormat, converter.validateMetric(newMetric(protoMetricsV1.SimpleFieldType(100))))
+
+	cfg := *defaultCfg
+	cfg.Ingestion.DefaultFieldType = config.FieldTypeDeltaSum
+	config.SetGlobalBrokerConfig(&cfg)
+	// set type
+	m := newMetric(protoMetricsV1.SimpleFieldType_Max)
+	assert.NoError(t, converter.validateMetric(m))
+	assert.Equal(t, protoMetricsV1.SimpleFieldType_Max, m.SimpleFields[0].Type)
+	// unset/unknown type
+	m = newMetric(protoMetricsV1.SimpleFieldType_SIMPLE_UNSPECIFIED)
+	assert.NoError(t, converter.validateMetric(m))
+	assert.Equal(t, protoMetricsV1.SimpleFieldType_DELTA_SUM, m.SimpleFields[0].Type)
+	m = newMetric(protoMetricsV1.SimpleFieldType(100))
+	assert.NoError(t, converter.validateMetric(m))
+	assert.Equal(t, protoMetricsV1.SimpleFieldType_DELTA_SUM, m.SimpleFields[0].Type)
+
+	var row BrokerRow
+	assert.NoError(t, converter.ConvertTo(newMetric(protoMetricsV1.SimpleFieldType_SIMPLE_UNSPECIFIED), &row))
+	var f flatMetricsV1.SimpleField
+	fm := row.Metric()
+	assert.True(t, fm.SimpleFields(&f, 0))
+	assert.Equal(t, flatMetricsV1.SimpleFieldTypeDeltaSum, f.Type())
 }
 
 func Test_BrokerRowProtoConverter_MarshalProtoMetricV1(t *testing.T) {
@@ -287,6 +340,51 @@ func Test_BrokerRowProtoConverter_MarshalProtoMetricV1(t *testing.T) {
 
 }
 
+func Test_BrokerRowProtoConverter_Exemplars(t *testing.T) {
+	converter, releaseFunc := NewBrokerRowProtoConverter(nil, nil)
+	defer releaseFunc(converter)
+
+	m := &protoMetricsV1.Metric{
+		Name:      "test-metric",
+		Timestamp: timeutil.Now(),
+		SimpleFields: []*protoMetricsV1.SimpleField{
+			{
+				Name:  "latency",
+				Type:  protoMetricsV1.SimpleFieldType_GAUGE,
+				Value: 12,
+				Exemplars: []*protoMetricsV1.Exemplar{
+					{TraceId: []byte("trace-1"), SpanId: []byte("span-1"), Duration: 10},
+					nil,
+					{SpanId: []byte("span-2")}, // no trace id, ignored
+				},
+			},
+			{
+				Name:  "count",
+				Type:  protoMetricsV1.SimpleFieldType_DELTA_SUM,
+				Value: 1,
+			}},
+	}
+	data, err := converter.MarshalProtoMetricV1(m)
+	assert.NoError(t, err)
+	var row StorageRow
+	row.Unmarshal(data[flatbuffers.SizeUOffsetT:])
+
+	itr := row.NewSimpleFieldIterator()
+	var e Exemplar
+	assert.True(t, itr.HasNext())
+	assert.Equal(t, 1, itr.NextExemplarsLen())
+	assert.True(t, itr.NextExemplar(0, &e))
+	assert.Equal(t, Exemplar{
+		TraceID:   []byte("trace-1"),
+		SpanID:    []byte("span-1"),
+		Duration:  10,
+		Value:     12,
+		Timestamp: m.Timestamp,
+	}, e)
+	assert.True(t, itr.HasNext())
+	assert.Equal(t, 0, itr.NextExemplarsLen())
+}
+
 func Test_BrokerRowProtoConverter_deDupTags(t *testing.T) {
 	converter, releaseFunc := NewBrokerRowProtoConverter(
 		nil, nil)
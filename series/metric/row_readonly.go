@@ -43,6 +43,13 @@ func (mr *readOnlyRow) NameSpace() []byte    { return mr.m.Namespace() }
 func (mr *readOnlyRow) TagsHash() uint64     { return mr.m.Hash() }
 func (mr *readOnlyRow) TagsLen() int         { return mr.m.KeyValuesLength() }
 func (mr *readOnlyRow) SimpleFieldsLen() int { return mr.m.SimpleFieldsLength() }
+
+// NameString returns the interned metric name.
+func (mr *readOnlyRow) NameString() string { return InternString(mr.m.Name()) }
+
+// NameSpaceString returns the interned namespace.
+func (mr *readOnlyRow) NameSpaceString() string { return InternString(mr.m.Namespace()) }
+
 func (mr *readOnlyRow) NewKeyValueIterator() *KeyValueIterator {
 	mr.keyValueIterator.idx = -1
 	mr.keyValueIterator.m = &mr.m
@@ -89,9 +96,13 @@ func (itr *KeyValueIterator) NextKey() []byte   { return itr.kv.Key() }
 func (itr *KeyValueIterator) NextValue() []byte { return itr.kv.Value() }
 func (itr *KeyValueIterator) Reset()            { itr.idx = -1 }
 
+// NextKeyString returns the interned tag key.
+func (itr *KeyValueIterator) NextKeyString() string { return InternString(itr.kv.Key()) }
+
 type SimpleFieldIterator struct {
 	m   *flatMetricsV1.Metric
 	f   flatMetricsV1.SimpleField
+	e   flatMetricsV1.Exemplar
 	idx int
 	num int
 }
@@ -111,6 +122,25 @@ func (itr *SimpleFieldIterator) NextName() field.Name                       { re
 func (itr *SimpleFieldIterator) NextRawName() []byte                        { return itr.f.Name() }
 func (itr *SimpleFieldIterator) NextValue() float64                         { return itr.f.Value() }
 func (itr *SimpleFieldIterator) NextRawType() flatMetricsV1.SimpleFieldType { return itr.f.Type() }
+
+// NextInternedName returns the interned field name.
+func (itr *SimpleFieldIterator) NextInternedName() field.Name {
+	return field.Name(InternString(itr.f.Name()))
+}
+
+// NextExemplarsLen returns the count of exemplars attached to current field.
+func (itr *SimpleFieldIterator) NextExemplarsLen() int { return itr.f.ExemplarsLength() }
+
+// NextExemplar reads the j-th exemplar of current field into e,
+// trace id and span id reference the underlying block.
+func (itr *SimpleFieldIterator) NextExemplar(j int, e *Exemplar) bool {
+	if j < 0 || j >= itr.f.ExemplarsLength() || !itr.f.Exemplars(&itr.e, j) {
+		return false
+	}
+	readExemplar(&itr.e, e)
+	return true
+}
+
 func (itr *SimpleFieldIterator) NextType() field.Type {
 	switch itr.f.Type() {
 	// assertion: cumulative should be converted before writing into memdb
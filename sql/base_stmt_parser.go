@@ -137,11 +137,38 @@ func (b *baseStmtParser) createTagFilterExpr(tagKey grammar.ITagKeyContext,
 			} else {
 				expr = &stmt.InExpr{Key: tagKeyStr}
 			}
+		case ctx.L_INT() != nil || ctx.L_DEC() != nil:
+			expr = b.createNumericCompareExpr(tagKeyStr, ctx)
 		}
 	}
 	return expr
 }
 
+// createNumericCompareExpr creates numeric comparison expr of tag value, like port>8000
+func (b *baseStmtParser) createNumericCompareExpr(tagKey string, ctx *grammar.TagFilterExprContext) stmt.Expr {
+	expr := &stmt.NumericCompareExpr{Key: tagKey}
+	switch {
+	case ctx.T_GREATER() != nil:
+		expr.Operator = stmt.GreaterThan
+	case ctx.T_GREATEREQUAL() != nil:
+		expr.Operator = stmt.GreaterThanOrEqual
+	case ctx.T_LESS() != nil:
+		expr.Operator = stmt.LessThan
+	case ctx.T_LESSEQUAL() != nil:
+		expr.Operator = stmt.LessThanOrEqual
+	}
+	value := ctx.L_INT()
+	if value == nil {
+		value = ctx.L_DEC()
+	}
+	v, err := strconv.ParseFloat(value.GetText(), 64)
+	if err != nil {
+		b.err = err
+	}
+	expr.Value = v
+	return expr
+}
+
 // completeTagFilterExpr completes a tag filter expression for query condition
 func (b *baseStmtParser) completeTagFilterExpr() {
 	expr := b.exprStack.Pop()
@@ -42,6 +42,7 @@ tagFilterExpr           :
                          T_OPEN_P tagFilterExpr T_CLOSE_P
                         | tagKey (T_EQUAL | T_LIKE | T_NOT T_LIKE | T_REGEXP | T_NEQREGEXP | T_NOTEQUAL | T_NOTEQUAL2) tagValue
                        | tagKey (T_IN | T_NOT T_IN) T_OPEN_P tagValueList T_CLOSE_P
+                       | tagKey (T_LESS | T_LESSEQUAL | T_GREATER | T_GREATEREQUAL) (L_INT | L_DEC)
                        | tagFilterExpr (T_AND | T_OR) tagFilterExpr
                        ;
 
@@ -463,6 +463,50 @@ func TestInExpr(t *testing.T) {
 	assert.Equal(t, stmt.NotExpr{Expr: &stmt.InExpr{Key: "ip", Values: []string{"1.1.1.1", "2.2.2.2"}}}, *notExpr)
 }
 
+func TestNumericCompareExpr(t *testing.T) {
+	cases := []struct {
+		sql  string
+		expr stmt.NumericCompareExpr
+	}{
+		{"select f from cpu where port>8000", stmt.NumericCompareExpr{Key: "port", Operator: stmt.GreaterThan, Value: 8000}},
+		{"select f from cpu where port >= 8000", stmt.NumericCompareExpr{Key: "port", Operator: stmt.GreaterThanOrEqual, Value: 8000}},
+		{"select f from cpu where port<8000", stmt.NumericCompareExpr{Key: "port", Operator: stmt.LessThan, Value: 8000}},
+		{"select f from cpu where load<=1.5", stmt.NumericCompareExpr{Key: "load", Operator: stmt.LessThanOrEqual, Value: 1.5}},
+	}
+	for _, c := range cases {
+		q, err := Parse(c.sql)
+		assert.NoError(t, err, c.sql)
+		query := q.(*stmt.Query)
+		expr := query.Condition.(*stmt.NumericCompareExpr)
+		assert.Equal(t, c.expr, *expr, c.sql)
+	}
+
+	// mixed with other tag filter and time range
+	sql := "select f from cpu where port>8000 and host='1.1.1.1' and time>'20190410 00:00:00'"
+	q, err := Parse(sql)
+	assert.NoError(t, err)
+	query := q.(*stmt.Query)
+	assert.Equal(t,
+		stmt.BinaryExpr{
+			Left:     &stmt.NumericCompareExpr{Key: "port", Operator: stmt.GreaterThan, Value: 8000},
+			Operator: stmt.AND,
+			Right:    &stmt.EqualsExpr{Key: "host", Value: "1.1.1.1"},
+		}, *query.Condition.(*stmt.BinaryExpr))
+	assert.True(t, query.TimeRange.Start > 0)
+
+	// time range still wins over tag filter of time key
+	sql = "select f from cpu where time>'20190410 00:00:00' and time<'20190410 10:00:00'"
+	q, err = Parse(sql)
+	assert.NoError(t, err)
+	query = q.(*stmt.Query)
+	assert.Nil(t, query.Condition)
+	assert.True(t, query.TimeRange.Start < query.TimeRange.End)
+
+	// numeric comparison only accepts number
+	_, err = Parse("select f from cpu where port>'8000'")
+	assert.Error(t, err)
+}
+
 func TestTagFilterBinary(t *testing.T) {
 	sql := "select f from cpu where ip in ('1.1.1.1','2.2.2.2') and path='/data'"
 	q, _ := Parse(sql)
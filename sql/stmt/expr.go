@@ -20,6 +20,8 @@ package stmt
 import (
 	"encoding/json"
 	"fmt"
+	"math"
+	"strconv"
 	"strings"
 
 	"github.com/lindb/lindb/aggregation/function"
@@ -125,6 +127,39 @@ type RegexExpr struct {
 	Regexp string `json:"regexp"`
 }
 
+// CompareOP represents the operator of numeric comparison
+type CompareOP int
+
+const (
+	GreaterThan CompareOP = iota + 1
+	GreaterThanOrEqual
+	LessThan
+	LessThanOrEqual
+)
+
+// String returns the string value of compare operator
+func (op CompareOP) String() string {
+	switch op {
+	case GreaterThan:
+		return ">"
+	case GreaterThanOrEqual:
+		return ">="
+	case LessThan:
+		return "<"
+	case LessThanOrEqual:
+		return "<="
+	default:
+		return "unknown"
+	}
+}
+
+// NumericCompareExpr represents a numeric comparison expression of tag value, such as port>8000
+type NumericCompareExpr struct {
+	Key      string    `json:"key"`
+	Operator CompareOP `json:"operator"`
+	Value    float64   `json:"value"`
+}
+
 // NotExpr represents a not expression
 type NotExpr struct {
 	Expr Expr
@@ -192,6 +227,31 @@ func (e *RegexExpr) Rewrite() string {
 	return fmt.Sprintf("%s=~%s", e.Key, e.Regexp)
 }
 
+// Rewrite rewrites the numeric compare expr after parse
+func (e *NumericCompareExpr) Rewrite() string {
+	return fmt.Sprintf("%s%s%s", e.Key, e.Operator, strconv.FormatFloat(e.Value, 'f', -1, 64))
+}
+
+// Match returns if the tag value is numeric and satisfies the comparison, non-numeric value never matches.
+func (e *NumericCompareExpr) Match(tagValue string) bool {
+	v, err := strconv.ParseFloat(tagValue, 64)
+	if err != nil || math.IsNaN(v) {
+		return false
+	}
+	switch e.Operator {
+	case GreaterThan:
+		return v > e.Value
+	case GreaterThanOrEqual:
+		return v >= e.Value
+	case LessThan:
+		return v < e.Value
+	case LessThanOrEqual:
+		return v <= e.Value
+	default:
+		return false
+	}
+}
+
 // Marshal returns json of expr using custom json marshal
 func Marshal(expr Expr) []byte {
 	switch e := expr.(type) {
@@ -203,6 +263,8 @@ func Marshal(expr Expr) []byte {
 		return encoding.JSONMarshal(&exprData{Type: "in", Expr: encoding.JSONMarshal(expr)})
 	case *EqualsExpr:
 		return encoding.JSONMarshal(&exprData{Type: "equals", Expr: encoding.JSONMarshal(expr)})
+	case *NumericCompareExpr:
+		return encoding.JSONMarshal(&exprData{Type: "numericCompare", Expr: encoding.JSONMarshal(expr)})
 	case *NumberLiteral:
 		return encoding.JSONMarshal(&exprData{Type: "number", Expr: encoding.JSONMarshal(expr)})
 	case *FieldExpr:
@@ -258,6 +320,8 @@ func Unmarshal(value []byte) (Expr, error) {
 		return unmarshal(&exprData, &InExpr{})
 	case "equals":
 		return unmarshal(&exprData, &EqualsExpr{})
+	case "numericCompare":
+		return unmarshal(&exprData, &NumericCompareExpr{})
 	case "number":
 		return unmarshal(&exprData, &NumberLiteral{})
 	case field:
@@ -359,3 +423,6 @@ func (e *LikeExpr) TagKey() string { return e.Key }
 
 // TagKey returns the regex filter's tag key
 func (e *RegexExpr) TagKey() string { return e.Key }
+
+// TagKey returns the numeric compare filter's tag key
+func (e *NumericCompareExpr) TagKey() string { return e.Key }
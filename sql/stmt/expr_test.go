@@ -212,3 +212,39 @@ func TestBinaryExpr_Marshal(t *testing.T) {
 	e := exprData.(*BinaryExpr)
 	assert.Equal(t, *expr, *e)
 }
+
+func TestNumericCompareExpr(t *testing.T) {
+	expr := &NumericCompareExpr{Key: "port", Operator: GreaterThanOrEqual, Value: 8000.5}
+	assert.Equal(t, "port>=8000.5", expr.Rewrite())
+	assert.Equal(t, "port", expr.TagKey())
+	data := Marshal(expr)
+	exprData, err := Unmarshal(data)
+	assert.NoError(t, err)
+	assert.Equal(t, *expr, *exprData.(*NumericCompareExpr))
+
+	cases := []struct {
+		op     CompareOP
+		value  string
+		expect bool
+	}{
+		{op: GreaterThan, value: "8080", expect: true},
+		{op: GreaterThan, value: "8000", expect: false},
+		{op: GreaterThanOrEqual, value: "8000", expect: true},
+		{op: GreaterThanOrEqual, value: "7999.9", expect: false},
+		{op: LessThan, value: "-1", expect: true},
+		{op: LessThan, value: "8000", expect: false},
+		{op: LessThanOrEqual, value: "8000", expect: true},
+		{op: LessThanOrEqual, value: "1e4", expect: false},
+		{op: GreaterThan, value: "http", expect: false},
+		{op: LessThan, value: "NaN", expect: false},
+		{op: CompareOP(0), value: "1", expect: false},
+	}
+	for _, c := range cases {
+		e := &NumericCompareExpr{Key: "port", Operator: c.op, Value: 8000}
+		assert.Equal(t, c.expect, e.Match(c.value), e.Rewrite()+" "+c.value)
+	}
+	assert.Equal(t, ">", GreaterThan.String())
+	assert.Equal(t, "<", LessThan.String())
+	assert.Equal(t, "<=", LessThanOrEqual.String())
+	assert.Equal(t, "unknown", CompareOP(0).String())
+}
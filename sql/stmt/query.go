@@ -26,12 +26,18 @@ import (
 
 // Query represents search statement
 type Query struct {
-	Explain     bool     // need explain query execute stat
-	Namespace   string   // namespace
-	MetricName  string   // like table name
-	SelectItems []Expr   // select list, such as field, function call, math expression etc.
-	FieldNames  []string // select field names
-	Condition   Expr     // tag filter condition expression
+	Explain        bool     // need explain query execute stat
+	ExplainPlan    bool     // only explain query execute plan, without scanning data
+	Trace          bool     // need trace the shards queried and num. of series each shard contributes
+	AllowLargeScan bool     // allows scanning more series than max series per query
+	BestEffort     bool     // returns result of healthy shards if some shards fail
+	Stream         bool     // storage nodes send result of each scanned batch, merged by broker before streaming
+	WriteToken     string   // waits until data written with token is visible before scanning(read-your-writes)
+	Namespace      string   // namespace
+	MetricName     string   // like table name
+	SelectItems    []Expr   // select list, such as field, function call, math expression etc.
+	FieldNames     []string // select field names
+	Condition      Expr     // tag filter condition expression
 
 	TimeRange timeutil.TimeRange // query time range
 	Interval  timeutil.Interval  // down sampling interval
@@ -47,12 +53,18 @@ func (q *Query) HasGroupBy() bool {
 
 // innerQuery represents a wrapper of query for json encoding
 type innerQuery struct {
-	Explain     bool              `json:"Explain,omitempty"`
-	Namespace   string            `json:"namespace,omitempty"`
-	MetricName  string            `json:"metricName,omitempty"`
-	SelectItems []json.RawMessage `json:"selectItems,omitempty"`
-	FieldNames  []string          `json:"fieldNames,omitempty"`
-	Condition   json.RawMessage   `json:"condition,omitempty"`
+	Explain        bool              `json:"Explain,omitempty"`
+	ExplainPlan    bool              `json:"explainPlan,omitempty"`
+	Trace          bool              `json:"trace,omitempty"`
+	AllowLargeScan bool              `json:"allowLargeScan,omitempty"`
+	BestEffort     bool              `json:"bestEffort,omitempty"`
+	Stream         bool              `json:"stream,omitempty"`
+	WriteToken     string            `json:"writeToken,omitempty"`
+	Namespace      string            `json:"namespace,omitempty"`
+	MetricName     string            `json:"metricName,omitempty"`
+	SelectItems    []json.RawMessage `json:"selectItems,omitempty"`
+	FieldNames     []string          `json:"fieldNames,omitempty"`
+	Condition      json.RawMessage   `json:"condition,omitempty"`
 
 	TimeRange timeutil.TimeRange `json:"timeRange,omitempty"`
 	Interval  timeutil.Interval  `json:"interval,omitempty"`
@@ -64,15 +76,21 @@ type innerQuery struct {
 // MarshalJSON returns json data of query
 func (q *Query) MarshalJSON() ([]byte, error) {
 	inner := innerQuery{
-		Explain:    q.Explain,
-		MetricName: q.MetricName,
-		Namespace:  q.Namespace,
-		Condition:  Marshal(q.Condition),
-		FieldNames: q.FieldNames,
-		TimeRange:  q.TimeRange,
-		Interval:   q.Interval,
-		GroupBy:    q.GroupBy,
-		Limit:      q.Limit,
+		Explain:        q.Explain,
+		ExplainPlan:    q.ExplainPlan,
+		Trace:          q.Trace,
+		AllowLargeScan: q.AllowLargeScan,
+		BestEffort:     q.BestEffort,
+		Stream:         q.Stream,
+		WriteToken:     q.WriteToken,
+		MetricName:     q.MetricName,
+		Namespace:      q.Namespace,
+		Condition:      Marshal(q.Condition),
+		FieldNames:     q.FieldNames,
+		TimeRange:      q.TimeRange,
+		Interval:       q.Interval,
+		GroupBy:        q.GroupBy,
+		Limit:          q.Limit,
 	}
 	for _, item := range q.SelectItems {
 		inner.SelectItems = append(inner.SelectItems, Marshal(item))
@@ -102,6 +120,12 @@ func (q *Query) UnmarshalJSON(value []byte) error {
 		selectItems = append(selectItems, selectItem)
 	}
 	q.Explain = inner.Explain
+	q.ExplainPlan = inner.ExplainPlan
+	q.Trace = inner.Trace
+	q.AllowLargeScan = inner.AllowLargeScan
+	q.BestEffort = inner.BestEffort
+	q.Stream = inner.Stream
+	q.WriteToken = inner.WriteToken
 	q.MetricName = inner.MetricName
 	q.Namespace = inner.Namespace
 	q.SelectItems = selectItems
@@ -29,8 +29,13 @@ import (
 
 func TestQuery_Marshal(t *testing.T) {
 	query := Query{
-		Namespace:  "ns",
-		MetricName: "test",
+		Trace:          true,
+		AllowLargeScan: true,
+		BestEffort:     true,
+		Stream:         true,
+		WriteToken:     "token",
+		Namespace:      "ns",
+		MetricName:     "test",
 		SelectItems: []Expr{
 			&SelectItem{Expr: &FieldExpr{Name: "a"}},
 			&SelectItem{Expr: &FieldExpr{Name: "b"}},
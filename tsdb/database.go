@@ -29,10 +29,12 @@ import (
 
 	"go.uber.org/atomic"
 
+	"github.com/lindb/lindb/config"
 	"github.com/lindb/lindb/internal/concurrent"
 	"github.com/lindb/lindb/internal/linmetric"
 	"github.com/lindb/lindb/kv"
 	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/fileutil"
 	"github.com/lindb/lindb/pkg/logger"
 	"github.com/lindb/lindb/pkg/ltoml"
 	"github.com/lindb/lindb/pkg/option"
@@ -69,6 +71,10 @@ type Database interface {
 	CreateShards(option option.DatabaseOption, shardIDs []models.ShardID) error
 	// GetShard returns shard by given shard id
 	GetShard(shardID models.ShardID) (Shard, bool)
+	// Shards returns all shards sorted by shard id
+	Shards() []Shard
+	// ShardPath returns the storage directory of shard
+	ShardPath(shardID models.ShardID) string
 	// ExecutorPool returns the pool for querying tasks
 	ExecutorPool() *ExecutorPool
 	// Closer closes database's underlying resource
@@ -79,6 +85,8 @@ type Database interface {
 	FlushMeta() error
 	// Flush flushes memory data of all families to disk
 	Flush() error
+	// FlushAll flushes index and memory data of all shards, then metadata synchronously
+	FlushAll() error
 }
 
 // databaseConfig represents a database configuration about config and families
@@ -179,7 +187,7 @@ func newDatabase(
 			shard, err = newShardFunc(
 				db,
 				shardID,
-				filepath.Join(databasePath, shardDir, strconv.Itoa(int(shardID))),
+				db.ShardPath(shardID),
 				db.config.Option)
 			if err != nil {
 				return nil, fmt.Errorf("cannot create shard[%d] of database[%s] with error: %s",
@@ -244,7 +252,7 @@ func (db *database) createShard(shardID models.ShardID, option option.DatabaseOp
 	createdShard, err := newShardFunc(
 		db,
 		shardID,
-		filepath.Join(db.path, shardDir, strconv.Itoa(int(shardID))),
+		db.ShardPath(shardID),
 		option)
 	if err != nil {
 		return fmt.Errorf("create shard[%d] for engine[%s] with error: %s", shardID, db.name, err)
@@ -265,6 +273,16 @@ func (db *database) GetShard(shardID models.ShardID) (Shard, bool) {
 	return db.shardSet.GetShard(shardID)
 }
 
+// Shards returns all shards sorted by shard id
+func (db *database) Shards() []Shard {
+	entries := db.shardSet.Entries()
+	shards := make([]Shard, len(entries))
+	for idx := range entries {
+		shards[idx] = entries[idx].shard
+	}
+	return shards
+}
+
 // ExecutorPool returns the query task execute pool
 func (db *database) ExecutorPool() *ExecutorPool {
 	return db.executorPool
@@ -272,12 +290,7 @@ func (db *database) ExecutorPool() *ExecutorPool {
 
 // Close closes database's underlying resource
 func (db *database) Close() error {
-	if err := db.metadata.Close(); err != nil {
-		return err
-	}
-	if err := db.metaStore.Close(); err != nil {
-		return err
-	}
+	// close shards(flush index/data) first, because index database of shard depends on metadata
 	for _, shardEntry := range db.shardSet.Entries() {
 		thisShard := shardEntry.shard
 		if err := thisShard.Close(); err != nil {
@@ -285,7 +298,10 @@ func (db *database) Close() error {
 				"close shard[%d] of database[%s]", shardEntry.shardID, db.name), logger.Error(err))
 		}
 	}
-	return nil
+	if err := db.metadata.Close(); err != nil {
+		return err
+	}
+	return db.metaStore.Close()
 }
 
 // dumpDatabaseConfig persists option info to OPTIONS file
@@ -347,6 +363,41 @@ func (db *database) Flush() error {
 	return nil
 }
 
+// FlushAll flushes index and memory data of all shards, then metadata synchronously
+func (db *database) FlushAll() error {
+	for _, shardEntry := range db.shardSet.Entries() {
+		shard := shardEntry.shard
+		if err := shard.Flush(); err != nil {
+			return err
+		}
+		for _, family := range GetFamilyManager().GetFamiliesByShard(shard) {
+			if err := family.Flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return db.FlushMeta()
+}
+
+// ShardPath returns the storage directory of shard, shards are distributed across data dirs by shard id,
+// shard created before data dirs configured keeps its data under database path.
+// Existing shard keeps its directory even if data dirs changed(added/removed/reordered),
+// so new directory is assigned only if the shard not found in any data dir.
+func (db *database) ShardPath(shardID models.ShardID) string {
+	path := filepath.Join(db.path, shardDir, strconv.Itoa(int(shardID)))
+	tsdbCfg := config.GlobalStorageConfig().TSDB
+	if len(tsdbCfg.DataDirs) == 0 || fileutil.Exist(path) {
+		return path
+	}
+	for _, dataDir := range tsdbCfg.DataDirs {
+		path = filepath.Join(dataDir, db.name, shardDir, strconv.Itoa(int(shardID)))
+		if fileutil.Exist(path) {
+			return path
+		}
+	}
+	return filepath.Join(tsdbCfg.ShardDataDir(int(shardID)), db.name, shardDir, strconv.Itoa(int(shardID)))
+}
+
 // optionsPath returns options file path
 func optionsPath(path string) string {
 	return filepath.Join(path, options)
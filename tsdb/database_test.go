@@ -20,6 +20,7 @@ package tsdb
 import (
 	"context"
 	"fmt"
+	"path/filepath"
 	"sync"
 	"testing"
 
@@ -27,8 +28,10 @@ import (
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/atomic"
 
+	"github.com/lindb/lindb/config"
 	"github.com/lindb/lindb/kv"
 	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/fileutil"
 	"github.com/lindb/lindb/pkg/ltoml"
 	"github.com/lindb/lindb/pkg/option"
 	"github.com/lindb/lindb/tsdb/metadb"
@@ -180,6 +183,44 @@ func TestDatabase_CreateShards(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestDatabase_CreateShards_DataDirs(t *testing.T) {
+	writeConfigTestLock.Lock()
+	defer writeConfigTestLock.Unlock()
+
+	tmpDir := t.TempDir()
+	cfg := config.GlobalStorageConfig()
+	cfg.TSDB.DataDirs = []string{filepath.Join(tmpDir, "disk1"), filepath.Join(tmpDir, "disk2")}
+	defer func() {
+		cfg.TSDB.DataDirs = nil
+		newShardFunc = newShard
+	}()
+	shardPaths := make(map[models.ShardID]string)
+	newShardFunc = func(db Database, shardID models.ShardID,
+		shardPath string, option option.DatabaseOption) (s Shard, err error) {
+		shardPaths[shardID] = shardPath
+		return nil, nil
+	}
+	dbPath := filepath.Join(tmpDir, "data", "db")
+	// shard created before data dirs configured
+	assert.NoError(t, fileutil.MkDirIfNotExist(filepath.Join(dbPath, shardDir, "3")))
+	// shard created before data dirs reordered
+	assert.NoError(t, fileutil.MkDirIfNotExist(filepath.Join(tmpDir, "disk2", "db", shardDir, "4")))
+	db, err := newDatabase("db", dbPath, &databaseConfig{
+		ShardIDs: []models.ShardID{3, 4},
+		Option:   option.DatabaseOption{Interval: "10s"},
+	}, nil)
+	assert.NoError(t, err)
+	err = db.CreateShards(option.DatabaseOption{Interval: "10s"}, []models.ShardID{0, 1, 2})
+	assert.NoError(t, err)
+	assert.Equal(t, map[models.ShardID]string{
+		0: filepath.Join(tmpDir, "disk1", "db", shardDir, "0"),
+		1: filepath.Join(tmpDir, "disk2", "db", shardDir, "1"),
+		2: filepath.Join(tmpDir, "disk1", "db", shardDir, "2"),
+		3: filepath.Join(dbPath, shardDir, "3"),
+		4: filepath.Join(tmpDir, "disk2", "db", shardDir, "4"),
+	}, shardPaths)
+}
+
 func TestDatabase_Close(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -254,6 +295,37 @@ func TestDatabase_Flush(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestDatabase_FlushAll(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	metadata := metadb.NewMockMetadata(ctrl)
+	db := &database{
+		shardSet:   *newShardSet(),
+		metadata:   metadata,
+		isFlushing: *atomic.NewBool(false)}
+	shard1 := NewMockShard(ctrl)
+	shard1.EXPECT().Indicator().Return("db/flush-all/1").AnyTimes()
+	family := NewMockDataFamily(ctrl)
+	family.EXPECT().Indicator().Return("db/flush-all/1/family").AnyTimes()
+	family.EXPECT().Shard().Return(shard1).AnyTimes()
+	GetFamilyManager().AddFamily(family)
+	defer GetFamilyManager().RemoveFamily(family)
+	db.shardSet.InsertShard(1, shard1)
+
+	// case 1: flush index failure
+	shard1.EXPECT().Flush().Return(fmt.Errorf("err"))
+	assert.Error(t, db.FlushAll())
+	// case 2: flush family failure
+	shard1.EXPECT().Flush().Return(nil).AnyTimes()
+	family.EXPECT().Flush().Return(fmt.Errorf("err"))
+	assert.Error(t, db.FlushAll())
+	// case 3: flush index, family and metadata
+	family.EXPECT().Flush().Return(nil)
+	metadata.EXPECT().Flush().Return(nil)
+	assert.NoError(t, db.FlushAll())
+}
+
 func Test_ShardSet_multi(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -264,6 +336,8 @@ func Test_ShardSet_multi(t *testing.T) {
 		set.InsertShard(models.ShardID(i), shard1)
 	}
 	assert.Equal(t, set.GetShardNum(), 50)
+	db := &database{shardSet: *set}
+	assert.Len(t, db.Shards(), 50)
 	_, ok := set.GetShard(0)
 	assert.True(t, ok)
 	_, ok = set.GetShard(11)
@@ -20,16 +20,21 @@ package tsdb
 import (
 	"context"
 	"fmt"
+	"io"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
 
 	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/pkg/encoding"
 	"github.com/lindb/lindb/pkg/fileutil"
 	"github.com/lindb/lindb/pkg/logger"
 	"github.com/lindb/lindb/pkg/ltoml"
 	"github.com/lindb/lindb/pkg/option"
+	"github.com/lindb/lindb/tsdb/indexdb"
 )
 
 //go:generate mockgen -source=./engine.go -destination=./engine_mock.go -package=tsdb
@@ -40,6 +45,8 @@ var (
 	listDir         = fileutil.ListDir
 	decodeToml      = ltoml.DecodeToml
 	newDatabaseFunc = newDatabase
+	dirSizeFunc     = fileutil.DirSize
+	checkWritable   = fileutil.CheckWritable
 )
 
 var engineLogger = logger.GetLogger("tsdb", "Engine")
@@ -69,11 +76,105 @@ type Engine interface {
 
 	// FlushDatabase produces a signal to workers for flushing memory database by name
 	FlushDatabase(ctx context.Context, databaseName string) bool
-
-	// Close closes the cached time series databases
+	// FlushAll flushes index and memory data of all databases synchronously,
+	// at most index-flush-concurrency databases are flushed concurrently,
+	// returns DatabaseErrors aggregating the errors of failed databases.
+	FlushAll() error
+
+	// DatabaseNames returns the names of all databases sorted by name
+	DatabaseNames() []string
+	// Databases returns the summary of all databases sorted by name
+	Databases() []DatabaseInfo
+
+	// ShardsOf returns the summary of all shards under database, returns nil if database not exist
+	ShardsOf(databaseName string) []ShardInfo
+
+	// SeriesWALStatus returns the series wal recovery status of all databases sorted by name
+	SeriesWALStatus() []DatabaseRecoveryStatus
+	// DumpMetricIDMapping returns the cached and backend stored id mapping of metric for each shard under database,
+	// returns constants.ErrDatabaseNotFound if database not exist
+	DumpMetricIDMapping(databaseName string, metricID uint32) ([]ShardMetricIDMappingDump, error)
+
+	// PauseShard pauses writes of shard, writes are rejected with retriable constants.ErrShardWritePaused
+	// until ResumeShard is called, reads continue during the pause,
+	// returns constants.ErrShardNotFound if shard not exist.
+	PauseShard(databaseName string, shardID models.ShardID) error
+	// ResumeShard resumes writes of shard paused by PauseShard,
+	// returns constants.ErrShardNotFound if shard not exist.
+	ResumeShard(databaseName string, shardID models.ShardID) error
+	// CompactShard compacts the level0 files of shard's data families immediately(all families if familyTime is 0),
+	// waits until compaction completed or ctx done,
+	// returns constants.ErrShardNotFound/ErrDataFamilyNotFound if shard/family not exist.
+	CompactShard(ctx context.Context, databaseName string, shardID models.ShardID, familyTime int64) error
+
+	// ExportShard writes a consistent snapshot of shard's data(segments and index) into writer,
+	// memory data is flushed before taking the snapshot.
+	ExportShard(databaseName string, shardID models.ShardID, writer io.Writer) error
+	// ImportShard loads the shard snapshot produced by ExportShard, then opens the shard,
+	// returns error if shard exists or snapshot is incompatible with database option.
+	ImportShard(databaseName string, shardID models.ShardID, databaseOption option.DatabaseOption, reader io.Reader) error
+
+	// Close closes the cached time series databases.
+	// A clean close guarantees durability of all data written before it returns:
+	// index databases and data families of all shards are flushed, pending series/metadata
+	// are synced into backend storage, then the resources are released,
+	// so that restarting engine does not need to replay wal.
+	// Databases are closed concurrently, bounded by index-flush-concurrency.
 	Close()
 }
 
+// DatabaseErrors represents the errors of operating multiple databases, key: database name.
+type DatabaseErrors map[string]error
+
+// Error returns the errors of all failed databases sorted by name.
+func (e DatabaseErrors) Error() string {
+	names := make([]string, 0, len(e))
+	for name := range e {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	msgs := make([]string, len(names))
+	for idx, name := range names {
+		msgs[idx] = fmt.Sprintf("database[%s]: %s", name, e[name])
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// DatabaseInfo represents the summary of database in storage engine.
+type DatabaseInfo struct {
+	Name     string           `json:"name"`
+	ShardIDs []models.ShardID `json:"shardIDs"`
+	Size     int64            `json:"size"` // total size of shards' storage(bytes)
+}
+
+// ShardInfo represents the summary of shard in storage engine.
+type ShardInfo struct {
+	ShardID  models.ShardID `json:"shardID"`
+	Path     string         `json:"path"`
+	Segments int            `json:"segments"`
+	Size     int64          `json:"size"` // size of shard's storage(bytes)
+}
+
+// DatabaseRecoveryStatus represents the series wal recovery status of database, aggregated by index databases of shards.
+type DatabaseRecoveryStatus struct {
+	Name           string                `json:"name"`
+	NeedRecovery   bool                  `json:"needRecovery"`   // true if any shard needs recovery
+	PendingEntries int64                 `json:"pendingEntries"` // total pending entries of all shards
+	LastSyncTime   int64                 `json:"lastSyncTime"`   // last sync time of the most lagging shard
+	Shards         []ShardRecoveryStatus `json:"shards"`
+}
+
+// ShardRecoveryStatus represents the series wal recovery status of shard.
+type ShardRecoveryStatus struct {
+	ShardID models.ShardID `json:"shardID"`
+	indexdb.RecoveryStatus
+}
+
+// ShardMetricIDMappingDump represents the metric id mapping dump of shard.
+type ShardMetricIDMappingDump struct {
+	ShardID models.ShardID `json:"shardID"`
+	*indexdb.MetricIDMappingDump
+}
 
 // engine implements Engine
 type engine struct {
@@ -82,6 +183,7 @@ type engine struct {
 	ctx              context.Context    // context
 	cancel           context.CancelFunc // cancel function of flusher
 	dataFlushChecker DataFlushChecker
+	retentionChecker FieldRetentionChecker
 }
 
 // NewEngine creates an engine for manipulating the databases
@@ -92,6 +194,12 @@ func NewEngine() (Engine, error) {
 		return nil, fmt.Errorf("create time sereis storage path[%s] erorr: %s",
 			config.GlobalStorageConfig().TSDB.Dir, err)
 	}
+	// all data directories of shards must be writable
+	for _, dir := range config.GlobalStorageConfig().TSDB.DataDirs {
+		if err := checkWritable(dir); err != nil {
+			return nil, fmt.Errorf("time series data path[%s] is not writable: %s", dir, err)
+		}
+	}
 
 	e := &engine{
 		dbSet: *newDatabaseSet(),
@@ -99,6 +207,8 @@ func NewEngine() (Engine, error) {
 	e.ctx, e.cancel = context.WithCancel(context.Background())
 	e.dataFlushChecker = newDataFlushChecker(e.ctx)
 	e.dataFlushChecker.Start()
+	e.retentionChecker = newFieldRetentionChecker(e.ctx)
+	e.retentionChecker.Start()
 
 	//
 	if err := e.load(); err != nil {
@@ -150,21 +260,9 @@ func (e *engine) CreateShards(
 	if len(shardIDs) == 0 {
 		return fmt.Errorf("cannot create empty shard for database[%s]", databaseName)
 	}
-	db, ok := e.GetDatabase(databaseName)
-	if !ok {
-		e.mutex.Lock()
-		defer e.mutex.Unlock()
-		if db, ok = e.GetDatabase(databaseName); !ok {
-			// double check
-			var err error
-			db, err = e.createDatabase(databaseName)
-			if err != nil {
-				engineLogger.Error("failed to create database",
-					logger.Error(err))
-				return err
-			}
-			engineLogger.Info("create database successfully", logger.String("database", databaseName))
-		}
+	db, err := e.getOrCreateDatabase(databaseName)
+	if err != nil {
+		return err
 	}
 
 	// create families for database
@@ -177,6 +275,28 @@ func (e *engine) CreateShards(
 	return nil
 }
 
+// getOrCreateDatabase returns the time series database by given name, creates it if not exist
+func (e *engine) getOrCreateDatabase(databaseName string) (Database, error) {
+	db, ok := e.GetDatabase(databaseName)
+	if ok {
+		return db, nil
+	}
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	// double check
+	if db, ok = e.GetDatabase(databaseName); ok {
+		return db, nil
+	}
+	db, err := e.createDatabase(databaseName)
+	if err != nil {
+		engineLogger.Error("failed to create database",
+			logger.Error(err))
+		return nil, err
+	}
+	engineLogger.Info("create database successfully", logger.String("database", databaseName))
+	return db, nil
+}
+
 // GetDatabase returns the time series database by given name
 func (e *engine) GetDatabase(databaseName string) (Database, bool) {
 	return e.dbSet.GetDatabase(databaseName)
@@ -191,16 +311,66 @@ func (e *engine) GetShard(databaseName string, shardID models.ShardID) (Shard, b
 	return db.GetShard(shardID)
 }
 
-// Close closes the cached time series databases
+// Close stops flush checker, then closes the cached time series databases,
+// each database flushes its shards before closing metadata.
 func (e *engine) Close() {
 	if e.dataFlushChecker != nil {
 		e.dataFlushChecker.Stop()
 	}
-	for dbName, db := range e.dbSet.Entries() {
-		if err := db.Close(); err != nil {
-			engineLogger.Error("close database", logger.String("name", dbName), logger.Error(err))
-		}
+	if e.retentionChecker != nil {
+		e.retentionChecker.Stop()
+	}
+	err := forEachDatabase(e.dbSet.Entries(), config.GlobalStorageConfig().TSDB.IndexFlushConcurrency,
+		func(db Database) error {
+			return db.Close()
+		})
+	if err != nil {
+		engineLogger.Error("close databases", logger.Error(err))
+	}
+}
+
+// FlushAll flushes index and memory data of all databases synchronously,
+// at most index-flush-concurrency databases are flushed concurrently,
+// returns DatabaseErrors aggregating the errors of failed databases.
+func (e *engine) FlushAll() error {
+	return forEachDatabase(e.dbSet.Entries(), config.GlobalStorageConfig().TSDB.IndexFlushConcurrency,
+		func(db Database) error {
+			return db.FlushAll()
+		})
+}
+
+// forEachDatabase calls fn for each database concurrently, at most concurrency databases at the same time,
+// waits all calls completed, returns DatabaseErrors if any call fails.
+func forEachDatabase(dbs map[string]Database, concurrency int, fn func(db Database) error) error {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	var (
+		wg    sync.WaitGroup
+		mutex sync.Mutex
+		errs  = make(DatabaseErrors)
+		limit = make(chan struct{}, concurrency)
+	)
+	for name, db := range dbs {
+		wg.Add(1)
+		limit <- struct{}{}
+		go func(name string, db Database) {
+			defer func() {
+				<-limit
+				wg.Done()
+			}()
+			if err := fn(db); err != nil {
+				mutex.Lock()
+				errs[name] = err
+				mutex.Unlock()
+			}
+		}(name, db)
+	}
+	wg.Wait()
+	if len(errs) > 0 {
+		return errs
 	}
+	return nil
 }
 
 // FlushDatabase produces a signal to workers for flushing memory database by name
@@ -215,6 +385,144 @@ func (e *engine) FlushDatabase(_ context.Context, name string) bool {
 	return true
 }
 
+// DatabaseNames returns the names of all databases sorted by name
+func (e *engine) DatabaseNames() []string {
+	dbs := e.dbSet.Entries()
+	names := make([]string, 0, len(dbs))
+	for name := range dbs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Databases returns the summary of all databases sorted by name
+func (e *engine) Databases() []DatabaseInfo {
+	dbs := e.dbSet.Entries()
+	result := make([]DatabaseInfo, 0, len(dbs))
+	for name := range dbs {
+		info := DatabaseInfo{Name: name}
+		for _, shard := range e.ShardsOf(name) {
+			info.ShardIDs = append(info.ShardIDs, shard.ShardID)
+			info.Size += shard.Size
+		}
+		result = append(result, info)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// ShardsOf returns the summary of all shards under database, returns nil if database not exist
+func (e *engine) ShardsOf(databaseName string) []ShardInfo {
+	db, ok := e.dbSet.GetDatabase(databaseName)
+	if !ok {
+		return nil
+	}
+	shards := db.Shards()
+	result := make([]ShardInfo, 0, len(shards))
+	for _, shard := range shards {
+		size, err := dirSizeFunc(shard.Path())
+		if err != nil {
+			engineLogger.Warn("get shard storage size error",
+				logger.String("database", databaseName), logger.Int("shardID", shard.ShardID().Int()), logger.Error(err))
+		}
+		result = append(result, ShardInfo{
+			ShardID:  shard.ShardID(),
+			Path:     shard.Path(),
+			Segments: shard.NumOfSegments(),
+			Size:     size,
+		})
+	}
+	return result
+}
+
+// SeriesWALStatus returns the series wal recovery status of all databases sorted by name
+func (e *engine) SeriesWALStatus() []DatabaseRecoveryStatus {
+	dbs := e.dbSet.Entries()
+	result := make([]DatabaseRecoveryStatus, 0, len(dbs))
+	for name, db := range dbs {
+		status := DatabaseRecoveryStatus{Name: name}
+		for idx, shard := range db.Shards() {
+			shardStatus := shard.IndexDatabase().RecoveryStatus()
+			status.NeedRecovery = status.NeedRecovery || shardStatus.NeedRecovery
+			status.PendingEntries += shardStatus.PendingEntries
+			if idx == 0 || shardStatus.LastSyncTime < status.LastSyncTime {
+				status.LastSyncTime = shardStatus.LastSyncTime
+			}
+			status.Shards = append(status.Shards, ShardRecoveryStatus{
+				ShardID:        shard.ShardID(),
+				RecoveryStatus: shardStatus,
+			})
+		}
+		result = append(result, status)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// DumpMetricIDMapping returns the cached and backend stored id mapping of metric for each shard under database,
+// returns constants.ErrDatabaseNotFound if database not exist
+func (e *engine) DumpMetricIDMapping(databaseName string, metricID uint32) ([]ShardMetricIDMappingDump, error) {
+	db, ok := e.dbSet.GetDatabase(databaseName)
+	if !ok {
+		return nil, fmt.Errorf("%w, database: %s", constants.ErrDatabaseNotFound, databaseName)
+	}
+	shards := db.Shards()
+	result := make([]ShardMetricIDMappingDump, 0, len(shards))
+	for _, shard := range shards {
+		dump, err := shard.IndexDatabase().DumpMetricIDMapping(metricID)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, ShardMetricIDMappingDump{
+			ShardID:             shard.ShardID(),
+			MetricIDMappingDump: dump,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ShardID < result[j].ShardID
+	})
+	return result, nil
+}
+
+// PauseShard pauses writes of shard, writes are rejected with retriable constants.ErrShardWritePaused
+// until ResumeShard is called, reads continue during the pause,
+// returns constants.ErrShardNotFound if shard not exist.
+func (e *engine) PauseShard(databaseName string, shardID models.ShardID) error {
+	shard, ok := e.GetShard(databaseName, shardID)
+	if !ok {
+		return fmt.Errorf("%w, database: %s, shardID: %d", constants.ErrShardNotFound, databaseName, shardID)
+	}
+	shard.PauseWrite()
+	return nil
+}
+
+// ResumeShard resumes writes of shard paused by PauseShard,
+// returns constants.ErrShardNotFound if shard not exist.
+func (e *engine) ResumeShard(databaseName string, shardID models.ShardID) error {
+	shard, ok := e.GetShard(databaseName, shardID)
+	if !ok {
+		return fmt.Errorf("%w, database: %s, shardID: %d", constants.ErrShardNotFound, databaseName, shardID)
+	}
+	shard.ResumeWrite()
+	return nil
+}
+
+// CompactShard compacts the level0 files of shard's data families immediately(all families if familyTime is 0),
+// waits until compaction completed or ctx done,
+// returns constants.ErrShardNotFound/ErrDataFamilyNotFound if shard/family not exist.
+func (e *engine) CompactShard(ctx context.Context, databaseName string, shardID models.ShardID, familyTime int64) error {
+	shard, ok := e.GetShard(databaseName, shardID)
+	if !ok {
+		return fmt.Errorf("%w, database: %s, shardID: %d", constants.ErrShardNotFound, databaseName, shardID)
+	}
+	return shard.Compact(ctx, familyTime)
+}
+
 // load loads the time series engines if exist
 func (e *engine) load() error {
 	// 获取所有子目录，每个子目录对应一个 database
@@ -226,6 +534,8 @@ func (e *engine) load() error {
 	// 加载每个 database
 	e.mutex.Lock()
 	defer e.mutex.Unlock()
+	startLoadingSegments()
+	defer completeLoadingSegments()
 	for _, databaseName := range databaseNames {
 		_, err := e.createDatabase(databaseName)
 		if err != nil {
@@ -19,18 +19,30 @@ package tsdb
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"path/filepath"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/atomic"
 
 	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/pkg/fileutil"
+	"github.com/lindb/lindb/pkg/logger"
 	"github.com/lindb/lindb/pkg/ltoml"
+	"github.com/lindb/lindb/pkg/option"
+	"github.com/lindb/lindb/pkg/timeutil"
+	protoMetricsV1 "github.com/lindb/lindb/proto/gen/v1/metrics"
+	"github.com/lindb/lindb/series/field"
+	"github.com/lindb/lindb/series/metric"
+	"github.com/lindb/lindb/tsdb/indexdb"
+	"github.com/lindb/lindb/tsdb/wal"
 )
 
 var writeConfigTestLock sync.Mutex
@@ -70,6 +82,17 @@ func TestNew(t *testing.T) {
 	assert.Nil(t, e)
 	listDir = fileutil.ListDir
 
+	// test new err when data dir not writable
+	config.GlobalStorageConfig().TSDB.DataDirs = []string{filepath.Join(tmpDir, "disk1")}
+	checkWritable = func(path string) error {
+		return fmt.Errorf("err")
+	}
+	e, err = NewEngine()
+	assert.Error(t, err)
+	assert.Nil(t, e)
+	checkWritable = fileutil.CheckWritable
+	config.GlobalStorageConfig().TSDB.DataDirs = nil
+
 	e, err = NewEngine()
 	assert.NoError(t, err)
 
@@ -204,6 +227,292 @@ func Test_Engine_Flush_Database(t *testing.T) {
 	assert.False(t, ok)
 }
 
+func Test_Engine_FlushAll(t *testing.T) {
+	writeConfigTestLock.Lock()
+	defer writeConfigTestLock.Unlock()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	withTestPath(t.TempDir())
+
+	e, _ := NewEngine()
+	engineImpl := e.(*engine)
+	defer engineImpl.cancel()
+	// case 1: no database
+	assert.NoError(t, e.FlushAll())
+
+	var flushed atomic.Int32
+	for i := 0; i < 10; i++ {
+		db := NewMockDatabase(ctrl)
+		db.EXPECT().FlushAll().DoAndReturn(func() error {
+			flushed.Inc()
+			return nil
+		})
+		engineImpl.dbSet.PutDatabase(fmt.Sprintf("db-%d", i), db)
+	}
+	// case 2: flush all databases
+	assert.NoError(t, e.FlushAll())
+	assert.Equal(t, int32(10), flushed.Load())
+	// case 3: aggregate errors of failed databases
+	for i := 0; i < 10; i++ {
+		db := NewMockDatabase(ctrl)
+		i := i
+		db.EXPECT().FlushAll().DoAndReturn(func() error {
+			flushed.Inc()
+			if i%3 == 0 {
+				return fmt.Errorf("err-%d", i)
+			}
+			return nil
+		})
+		engineImpl.dbSet.PutDatabase(fmt.Sprintf("db-%d", i), db)
+	}
+	err := e.FlushAll()
+	assert.Equal(t, int32(20), flushed.Load())
+	var errs DatabaseErrors
+	assert.True(t, errors.As(err, &errs))
+	assert.Len(t, errs, 4)
+	assert.Equal(t, "database[db-0]: err-0; database[db-3]: err-3; database[db-6]: err-6; database[db-9]: err-9",
+		err.Error())
+}
+
+func Test_forEachDatabase(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dbs := make(map[string]Database)
+	for i := 0; i < 20; i++ {
+		dbs[fmt.Sprintf("db-%d", i)] = NewMockDatabase(ctrl)
+	}
+	for _, concurrency := range []int{0, 1, 3} {
+		var running, maxRunning, called atomic.Int32
+		err := forEachDatabase(dbs, concurrency, func(db Database) error {
+			n := running.Inc()
+			for {
+				max := maxRunning.Load()
+				if n <= max || maxRunning.CAS(max, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			running.Dec()
+			called.Inc()
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, int32(20), called.Load())
+		expectMax := int32(concurrency)
+		if expectMax <= 0 {
+			expectMax = 1
+		}
+		assert.True(t, maxRunning.Load() <= expectMax)
+	}
+}
+
+func Test_Engine_Databases(t *testing.T) {
+	writeConfigTestLock.Lock()
+	defer writeConfigTestLock.Unlock()
+
+	ctrl := gomock.NewController(t)
+	defer func() {
+		dirSizeFunc = fileutil.DirSize
+		ctrl.Finish()
+	}()
+	dirSizeFunc = func(path string) (int64, error) {
+		if path == "err" {
+			return 0, fmt.Errorf("err")
+		}
+		return int64(len(path)), nil
+	}
+
+	withTestPath(t.TempDir())
+
+	e, _ := NewEngine()
+	engineImpl := e.(*engine)
+	defer engineImpl.cancel()
+	assert.Empty(t, e.Databases())
+	assert.Empty(t, e.DatabaseNames())
+	assert.Nil(t, e.ShardsOf("db"))
+
+	newShard := func(shardID models.ShardID, path string, segments int) Shard {
+		shard := NewMockShard(ctrl)
+		shard.EXPECT().ShardID().Return(shardID).AnyTimes()
+		shard.EXPECT().Path().Return(path).AnyTimes()
+		shard.EXPECT().NumOfSegments().Return(segments).AnyTimes()
+		return shard
+	}
+	db1 := NewMockDatabase(ctrl)
+	db1.EXPECT().Shards().Return([]Shard{newShard(1, "db1/1", 2), newShard(2, "err", 1)}).AnyTimes()
+	db2 := NewMockDatabase(ctrl)
+	db2.EXPECT().Shards().Return([]Shard{newShard(3, "db2/3", 0)}).AnyTimes()
+	engineImpl.dbSet.PutDatabase("db2", db2)
+	engineImpl.dbSet.PutDatabase("db1", db1)
+
+	assert.Equal(t, []ShardInfo{
+		{ShardID: 1, Path: "db1/1", Segments: 2, Size: 5},
+		{ShardID: 2, Path: "err", Segments: 1, Size: 0},
+	}, e.ShardsOf("db1"))
+	assert.Equal(t, []DatabaseInfo{
+		{Name: "db1", ShardIDs: []models.ShardID{1, 2}, Size: 5},
+		{Name: "db2", ShardIDs: []models.ShardID{3}, Size: 5},
+	}, e.Databases())
+	assert.Equal(t, []string{"db1", "db2"}, e.DatabaseNames())
+}
+
+func Test_Engine_SeriesWALStatus(t *testing.T) {
+	writeConfigTestLock.Lock()
+	defer writeConfigTestLock.Unlock()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	withTestPath(t.TempDir())
+
+	e, _ := NewEngine()
+	engineImpl := e.(*engine)
+	defer engineImpl.cancel()
+	assert.Empty(t, e.SeriesWALStatus())
+
+	newShard := func(shardID models.ShardID, status indexdb.RecoveryStatus) Shard {
+		indexDB := indexdb.NewMockIndexDatabase(ctrl)
+		indexDB.EXPECT().RecoveryStatus().Return(status)
+		shard := NewMockShard(ctrl)
+		shard.EXPECT().ShardID().Return(shardID)
+		shard.EXPECT().IndexDatabase().Return(indexDB)
+		return shard
+	}
+	db1 := NewMockDatabase(ctrl)
+	db1.EXPECT().Shards().Return([]Shard{
+		newShard(1, indexdb.RecoveryStatus{LastSyncTime: 20}),
+		newShard(2, indexdb.RecoveryStatus{NeedRecovery: true, PendingEntries: 100, LastSyncTime: 10}),
+	})
+	db2 := NewMockDatabase(ctrl)
+	db2.EXPECT().Shards().Return([]Shard{newShard(3, indexdb.RecoveryStatus{PendingEntries: 5, LastSyncTime: 30})})
+	engineImpl.dbSet.PutDatabase("db2", db2)
+	engineImpl.dbSet.PutDatabase("db1", db1)
+
+	assert.Equal(t, []DatabaseRecoveryStatus{
+		{
+			Name: "db1", NeedRecovery: true, PendingEntries: 100, LastSyncTime: 10,
+			Shards: []ShardRecoveryStatus{
+				{ShardID: 1, RecoveryStatus: indexdb.RecoveryStatus{LastSyncTime: 20}},
+				{ShardID: 2, RecoveryStatus: indexdb.RecoveryStatus{NeedRecovery: true, PendingEntries: 100, LastSyncTime: 10}},
+			},
+		},
+		{
+			Name: "db2", NeedRecovery: false, PendingEntries: 5, LastSyncTime: 30,
+			Shards: []ShardRecoveryStatus{
+				{ShardID: 3, RecoveryStatus: indexdb.RecoveryStatus{PendingEntries: 5, LastSyncTime: 30}},
+			},
+		},
+	}, e.SeriesWALStatus())
+}
+
+func Test_Engine_DumpMetricIDMapping(t *testing.T) {
+	writeConfigTestLock.Lock()
+	defer writeConfigTestLock.Unlock()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	withTestPath(t.TempDir())
+
+	e, _ := NewEngine()
+	engineImpl := e.(*engine)
+	defer engineImpl.cancel()
+	// case 1: database not found
+	dumps, err := e.DumpMetricIDMapping("db", 1)
+	assert.True(t, errors.Is(err, constants.ErrDatabaseNotFound))
+	assert.Nil(t, dumps)
+
+	indexDB1 := indexdb.NewMockIndexDatabase(ctrl)
+	shard1 := NewMockShard(ctrl)
+	shard1.EXPECT().IndexDatabase().Return(indexDB1).AnyTimes()
+	shard1.EXPECT().ShardID().Return(models.ShardID(1)).AnyTimes()
+	indexDB2 := indexdb.NewMockIndexDatabase(ctrl)
+	shard2 := NewMockShard(ctrl)
+	shard2.EXPECT().IndexDatabase().Return(indexDB2).AnyTimes()
+	shard2.EXPECT().ShardID().Return(models.ShardID(2)).AnyTimes()
+	db := NewMockDatabase(ctrl)
+	db.EXPECT().Shards().Return([]Shard{shard2, shard1}).AnyTimes()
+	engineImpl.dbSet.PutDatabase("db", db)
+	// case 2: dump failure
+	indexDB2.EXPECT().DumpMetricIDMapping(uint32(1)).Return(nil, fmt.Errorf("err"))
+	dumps, err = e.DumpMetricIDMapping("db", 1)
+	assert.Error(t, err)
+	assert.Nil(t, dumps)
+	// case 3: dump each shard
+	dump1 := &indexdb.MetricIDMappingDump{MetricID: 1}
+	dump2 := &indexdb.MetricIDMappingDump{MetricID: 1, SequenceDiverged: true}
+	indexDB1.EXPECT().DumpMetricIDMapping(uint32(1)).Return(dump1, nil)
+	indexDB2.EXPECT().DumpMetricIDMapping(uint32(1)).Return(dump2, nil)
+	dumps, err = e.DumpMetricIDMapping("db", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []ShardMetricIDMappingDump{
+		{ShardID: 1, MetricIDMappingDump: dump1},
+		{ShardID: 2, MetricIDMappingDump: dump2},
+	}, dumps)
+}
+
+func Test_Engine_PauseShard(t *testing.T) {
+	writeConfigTestLock.Lock()
+	defer writeConfigTestLock.Unlock()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	withTestPath(t.TempDir())
+
+	e, _ := NewEngine()
+	engineImpl := e.(*engine)
+	defer engineImpl.cancel()
+	// case 1: shard not found
+	assert.True(t, errors.Is(e.PauseShard("db", 1), constants.ErrShardNotFound))
+	assert.True(t, errors.Is(e.ResumeShard("db", 1), constants.ErrShardNotFound))
+
+	db := NewMockDatabase(ctrl)
+	db.EXPECT().Name().Return("db").AnyTimes()
+	s := &shard{db: db, id: 1, logger: logger.GetLogger("tsdb", "Shard")}
+	db.EXPECT().GetShard(models.ShardID(1)).Return(s, true).AnyTimes()
+	engineImpl.dbSet.PutDatabase("db", db)
+	// case 2: pause shard write
+	assert.NoError(t, e.PauseShard("db", 1))
+	assert.True(t, s.IsWritePaused())
+	// pause again
+	assert.NoError(t, e.PauseShard("db", 1))
+	assert.True(t, s.IsWritePaused())
+	// case 3: resume shard write
+	assert.NoError(t, e.ResumeShard("db", 1))
+	assert.False(t, s.IsWritePaused())
+	// resume again
+	assert.NoError(t, e.ResumeShard("db", 1))
+	assert.False(t, s.IsWritePaused())
+}
+
+func Test_Engine_CompactShard(t *testing.T) {
+	writeConfigTestLock.Lock()
+	defer writeConfigTestLock.Unlock()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	withTestPath(t.TempDir())
+
+	e, _ := NewEngine()
+	engineImpl := e.(*engine)
+	defer engineImpl.cancel()
+	// case 1: shard not found
+	assert.True(t, errors.Is(e.CompactShard(context.TODO(), "db", 1, 0), constants.ErrShardNotFound))
+
+	db := NewMockDatabase(ctrl)
+	shard := NewMockShard(ctrl)
+	db.EXPECT().GetShard(models.ShardID(1)).Return(shard, true).AnyTimes()
+	engineImpl.dbSet.PutDatabase("db", db)
+	// case 2: compact shard
+	shard.EXPECT().Compact(gomock.Any(), int64(10)).Return(nil)
+	assert.NoError(t, e.CompactShard(context.TODO(), "db", 1, 10))
+}
+
 var testDatabaseNames = []string{
 	"_internal", "system", "docker", "network", "java",
 	"runtime", "go", "php", "k8s", "infra", "prometheus",
@@ -264,3 +573,77 @@ func BenchmarkEngine_DatabaseWithLockFreeSlice(b *testing.B) {
 		}
 	})
 }
+
+func Test_Engine_Close_FlushBeforeRelease(t *testing.T) {
+	writeConfigTestLock.Lock()
+	defer writeConfigTestLock.Unlock()
+
+	withTestPath(t.TempDir())
+
+	e, err := NewEngine()
+	assert.NoError(t, err)
+	err = e.CreateShards("db", option.DatabaseOption{Interval: "10s"}, models.ShardID(1))
+	assert.NoError(t, err)
+	s, ok := e.GetShard("db", models.ShardID(1))
+	assert.True(t, ok)
+	db, _ := e.GetDatabase("db")
+	dbPath := db.(*database).path
+	shardPath := s.(*shard).path
+
+	// write data just before closing engine
+	timestamp := timeutil.Now()
+	row := mockBatchRows(&protoMetricsV1.Metric{
+		Name:      "cpu",
+		Timestamp: timestamp,
+		Tags:      []*protoMetricsV1.KeyValue{{Key: "host", Value: "1.1.1.1"}},
+		SimpleFields: []*protoMetricsV1.SimpleField{{
+			Name: "f1", Type: protoMetricsV1.SimpleFieldType_DELTA_SUM, Value: 1,
+		}},
+	})
+	rows := []metric.StorageRow{*row}
+	assert.NoError(t, s.WriteRows(rows, nil))
+	family, err := s.GetOrCrateDataFamily(timestamp)
+	assert.NoError(t, err)
+	assert.NoError(t, family.WriteRows(rows))
+	metricID := rows[0].MetricID
+	e.Close()
+
+	// clean shutdown leaves no pending entries in series/meta wal to replay
+	replayed := 0
+	seriesWAL, err := wal.NewSeriesWAL(filepath.Join(shardPath, metaDir, "wal", "series"))
+	assert.NoError(t, err)
+	seriesWAL.Recovery(func(metricID uint32, tagsHash uint64, seriesID uint32) error {
+		replayed++
+		return nil
+	}, func() error { return nil })
+	assert.NoError(t, seriesWAL.Close())
+	metaWAL, err := wal.NewMetricMetaWAL(filepath.Join(dbPath, metaDir, metricMetaDir, "wal"))
+	assert.NoError(t, err)
+	metaWAL.Recovery(func(namespace, metricName string, metricID uint32) error {
+		replayed++
+		return nil
+	}, func(metricID uint32, fID field.ID, fieldName field.Name, fType field.Type) error {
+		replayed++
+		return nil
+	}, func(metricID uint32, tagKeyID uint32, tagKey string) error {
+		replayed++
+		return nil
+	}, func() error { return nil })
+	assert.NoError(t, metaWAL.Close())
+	assert.Zero(t, replayed)
+
+	// data written before closing is queryable after restart
+	e, err = NewEngine()
+	assert.NoError(t, err)
+	defer e.Close()
+	s, ok = e.GetShard("db", models.ShardID(1))
+	assert.True(t, ok)
+	metricIDs, err := s.IndexDatabase().AllMetricIDs()
+	assert.NoError(t, err)
+	assert.True(t, metricIDs.Contains(metricID))
+	family, err = s.GetOrCrateDataFamily(timestamp)
+	assert.NoError(t, err)
+	snapshot := family.Family().GetSnapshot()
+	defer snapshot.Close()
+	assert.NotEmpty(t, snapshot.GetCurrent().GetAllFiles())
+}
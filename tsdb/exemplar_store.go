@@ -0,0 +1,166 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tsdb
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/series/field"
+	"github.com/lindb/lindb/series/metric"
+)
+
+// noExemplar represents the end of exemplar chain of series field.
+const noExemplar = -1
+
+// exemplarKey represents the series field which exemplars belong to.
+type exemplarKey struct {
+	metricID uint32
+	seriesID uint32
+	fieldID  field.ID
+}
+
+// exemplarEntry represents an exemplar in ring buffer, links to the next newer exemplar of same series field.
+type exemplarEntry struct {
+	key      exemplarKey
+	exemplar metric.Exemplar
+	next     int
+}
+
+// exemplarChain represents the positions of oldest/newest exemplar of series field in ring buffer.
+type exemplarChain struct {
+	oldest, newest int
+}
+
+// exemplarStore keeps the latest exemplars of shard in ring buffer, the oldest exemplars are overwritten
+// when the buffer is full, exemplars of same series field are chained in chronological order.
+// Buffer is allocated on demand, so that it costs nothing if no exemplar written.
+type exemplarStore struct {
+	capacity int
+	entries  []exemplarEntry
+	next     int // position of next exemplar
+	chains   map[exemplarKey]*exemplarChain
+	metrics  map[uint32]int // metric id => num. of exemplars
+	mutex    sync.RWMutex
+}
+
+// newExemplarStore creates the exemplar store which keeps at most capacity exemplars.
+func newExemplarStore(capacity int) *exemplarStore {
+	return &exemplarStore{
+		capacity: capacity,
+		chains:   make(map[exemplarKey]*exemplarChain),
+		metrics:  make(map[uint32]int),
+	}
+}
+
+// add appends the exemplar of series field, trace/span id are copied,
+// ignores if store is nil/disabled or exemplar is the same as the newest one of series field.
+func (s *exemplarStore) add(metricID, seriesID uint32, fieldID field.ID, exemplar *metric.Exemplar) {
+	if s == nil || s.capacity <= 0 {
+		return
+	}
+	key := exemplarKey{metricID: metricID, seriesID: seriesID, fieldID: fieldID}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	chain, ok := s.chains[key]
+	if ok {
+		newest := &s.entries[chain.newest].exemplar
+		if newest.Timestamp == exemplar.Timestamp && bytes.Equal(newest.TraceID, exemplar.TraceID) {
+			return
+		}
+	}
+	pos := s.next
+	if pos < len(s.entries) {
+		// buffer is full, overwrites the oldest exemplar, which is the oldest one of its series field
+		s.evict(pos)
+		// chain may be removed if evicted exemplar is the last one of same series field
+		chain, ok = s.chains[key]
+	} else {
+		s.entries = append(s.entries, exemplarEntry{})
+	}
+	s.entries[pos] = exemplarEntry{
+		key: key,
+		exemplar: metric.Exemplar{
+			TraceID:   append([]byte(nil), exemplar.TraceID...),
+			SpanID:    append([]byte(nil), exemplar.SpanID...),
+			Duration:  exemplar.Duration,
+			Value:     exemplar.Value,
+			Timestamp: exemplar.Timestamp,
+		},
+		next: noExemplar,
+	}
+	if ok {
+		s.entries[chain.newest].next = pos
+		chain.newest = pos
+	} else {
+		s.chains[key] = &exemplarChain{oldest: pos, newest: pos}
+	}
+	s.metrics[metricID]++
+	s.next++
+	if s.next == s.capacity {
+		s.next = 0
+	}
+}
+
+// evict removes the exemplar at pos from the chain of its series field.
+func (s *exemplarStore) evict(pos int) {
+	entry := &s.entries[pos]
+	if entry.next == noExemplar {
+		delete(s.chains, entry.key)
+	} else {
+		s.chains[entry.key].oldest = entry.next
+	}
+	if s.metrics[entry.key.metricID] <= 1 {
+		delete(s.metrics, entry.key.metricID)
+	} else {
+		s.metrics[entry.key.metricID]--
+	}
+}
+
+// hasExemplars returns if any exemplar of metric is kept.
+func (s *exemplarStore) hasExemplars(metricID uint32) bool {
+	if s == nil {
+		return false
+	}
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.metrics[metricID] > 0
+}
+
+// get returns the exemplars of series field within time range in the order written,
+// trace/span id of returned exemplars must not be modified.
+func (s *exemplarStore) get(metricID, seriesID uint32, fieldID field.ID, timeRange timeutil.TimeRange) (rs []metric.Exemplar) {
+	if s == nil {
+		return nil
+	}
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	chain, ok := s.chains[exemplarKey{metricID: metricID, seriesID: seriesID, fieldID: fieldID}]
+	if !ok {
+		return nil
+	}
+	for pos := chain.oldest; pos != noExemplar; pos = s.entries[pos].next {
+		if timeRange.Contains(s.entries[pos].exemplar.Timestamp) {
+			rs = append(rs, s.entries[pos].exemplar)
+		}
+	}
+	return rs
+}
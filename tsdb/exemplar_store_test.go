@@ -0,0 +1,88 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tsdb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/series/field"
+	"github.com/lindb/lindb/series/metric"
+)
+
+func TestExemplarStore_nil(t *testing.T) {
+	var nilStore *exemplarStore
+	nilStore.add(1, 1, 1, &metric.Exemplar{TraceID: []byte{1}})
+	assert.False(t, nilStore.hasExemplars(1))
+	assert.Nil(t, nilStore.get(1, 1, 1, timeutil.TimeRange{End: 10}))
+
+	// disabled
+	s := newExemplarStore(0)
+	s.add(1, 1, 1, &metric.Exemplar{TraceID: []byte{1}})
+	assert.False(t, s.hasExemplars(1))
+	assert.Nil(t, s.entries)
+}
+
+func TestExemplarStore_add(t *testing.T) {
+	allTime := timeutil.TimeRange{End: 100}
+	s := newExemplarStore(3)
+	traceID := []byte{1, 2}
+	s.add(1, 1, 1, &metric.Exemplar{TraceID: traceID, Value: 1, Timestamp: 1})
+	// trace id is copied
+	traceID[0] = 9
+	// same as newest exemplar, ignore it
+	s.add(1, 1, 1, &metric.Exemplar{TraceID: []byte{1, 2}, Value: 1, Timestamp: 1})
+	s.add(1, 2, 1, &metric.Exemplar{TraceID: []byte{2}, Value: 2, Timestamp: 2})
+	s.add(1, 1, 1, &metric.Exemplar{TraceID: []byte{3}, Value: 3, Timestamp: 3})
+	assert.True(t, s.hasExemplars(1))
+	assert.False(t, s.hasExemplars(2))
+	assert.Equal(t, []metric.Exemplar{
+		{TraceID: []byte{1, 2}, Value: 1, Timestamp: 1},
+		{TraceID: []byte{3}, Value: 3, Timestamp: 3},
+	}, s.get(1, 1, 1, allTime))
+	assert.Len(t, s.get(1, 2, 1, allTime), 1)
+	assert.Nil(t, s.get(1, 1, field.ID(2), allTime))
+	// filter by time range
+	assert.Equal(t, []metric.Exemplar{
+		{TraceID: []byte{3}, Value: 3, Timestamp: 3},
+	}, s.get(1, 1, 1, timeutil.TimeRange{Start: 2, End: 10}))
+
+	// buffer is full, overwrites the oldest exemplars
+	s.add(2, 1, 1, &metric.Exemplar{TraceID: []byte{4}, Value: 4, Timestamp: 4})
+	s.add(2, 1, 1, &metric.Exemplar{TraceID: []byte{5}, Value: 5, Timestamp: 5})
+	assert.Len(t, s.entries, 3)
+	assert.Equal(t, []metric.Exemplar{
+		{TraceID: []byte{3}, Value: 3, Timestamp: 3},
+	}, s.get(1, 1, 1, allTime))
+	assert.Nil(t, s.get(1, 2, 1, allTime))
+	assert.True(t, s.hasExemplars(1))
+	s.add(2, 1, 1, &metric.Exemplar{TraceID: []byte{6}, Value: 6, Timestamp: 6})
+	assert.False(t, s.hasExemplars(1))
+	assert.Nil(t, s.get(1, 1, 1, allTime))
+	assert.Len(t, s.chains, 1)
+	// overwrites the oldest exemplar of same series field
+	s.add(2, 1, 1, &metric.Exemplar{TraceID: []byte{7}, Value: 7, Timestamp: 7})
+	assert.Equal(t, []metric.Exemplar{
+		{TraceID: []byte{5}, Value: 5, Timestamp: 5},
+		{TraceID: []byte{6}, Value: 6, Timestamp: 6},
+		{TraceID: []byte{7}, Value: 7, Timestamp: 7},
+	}, s.get(2, 1, 1, allTime))
+	assert.Equal(t, 3, s.metrics[2])
+}
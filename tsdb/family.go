@@ -32,6 +32,7 @@ import (
 	"github.com/lindb/lindb/flow"
 	"github.com/lindb/lindb/internal/linmetric"
 	"github.com/lindb/lindb/kv"
+	"github.com/lindb/lindb/pkg/fileutil"
 	"github.com/lindb/lindb/pkg/logger"
 	"github.com/lindb/lindb/pkg/ltoml"
 	"github.com/lindb/lindb/pkg/timeutil"
@@ -65,6 +66,8 @@ type DataFamily interface {
 	WriteRows(rows []metric.StorageRow) error
 	ValidateSequence(leader int32, seq int64) bool
 	CommitSequence(leader int32, seq int64)
+	// IsSequenceCommitted checks if the sequence of leader's wal is committed(written into memory database).
+	IsSequenceCommitted(leader int32, seq int64) bool
 	AckSequence(leader int32, fn func(seq int64))
 
 	NeedFlush() bool
@@ -86,6 +89,10 @@ type dataFamily struct {
 	familyTime   int64
 	timeRange    timeutil.TimeRange
 	family       kv.Family
+	coordinator  kv.Coordinator
+	events       *eventLog // lifecycle events of shard
+	// series bloom filter of family's files, nil means cannot skip family when querying
+	seriesBloom *seriesBloom
 
 	mutableMemDB   memdb.MemoryDatabase
 	immutableMemDB memdb.MemoryDatabase
@@ -111,18 +118,25 @@ type dataFamily struct {
 		memdbNumber         *linmetric.BoundGauge
 		memFlushTimer       *linmetric.BoundHistogram
 		indexFlushTimer     *linmetric.BoundHistogram
+		skippedFamilies     *linmetric.BoundCounter
 	}
 
 	logger *logger.Logger
 }
 
-// newDataFamily creates a data family storage unit
+// newDataFamily creates a data family storage unit,
+// flush is coordinated with other flush/compaction of shard if coordinator isn't nil,
+// series bloom filter is maintained under familyPath if familyPath isn't empty,
+// flush/close events are recorded into events if it isn't nil.
 func newDataFamily(
 	shard Shard,
 	interval timeutil.Interval,
 	timeRange timeutil.TimeRange,
 	familyTime int64,
 	family kv.Family,
+	familyPath string,
+	coordinator kv.Coordinator,
+	events *eventLog,
 ) DataFamily {
 
 	f := &dataFamily{
@@ -132,6 +146,8 @@ func newDataFamily(
 		timeRange:    timeRange,
 		familyTime:   familyTime,
 		family:       family,
+		coordinator:  coordinator,
+		events:       events,
 		seq:          make(map[int32]atomic.Int64),
 		persistSeq:   make(map[int32]atomic.Int64),
 		callbacks:    make(map[int32][]func(seq int64)),
@@ -161,8 +177,12 @@ func newDataFamily(
 	f.statistics.memdbNumber = memdbNumberVec.WithTagValues(dbName, shardIDStr)
 	f.statistics.memFlushTimer = memFlushTimerVec.WithTagValues(dbName, shardIDStr)
 	f.statistics.indexFlushTimer = indexFlushTimerVec.WithTagValues(dbName, shardIDStr)
+	f.statistics.skippedFamilies = skippedFamiliesVec.WithTagValues(dbName, shardIDStr)
 
 	f.indicator = fmt.Sprintf("%s/%s/%d", dbName, shardIDStr, familyTime)
+	if familyPath != "" {
+		f.seriesBloom = f.openSeriesBloom(seriesBloomPath(familyPath), len(snapshot.GetCurrent().GetAllFiles()) > 0)
+	}
 
 	// add data family into global family manager
 	GetFamilyManager().AddFamily(f)
@@ -309,6 +329,7 @@ func (f *dataFamily) Flush() error {
 			logger.Int64("familyTime", f.familyTime),
 			logger.Int64("memDBSize", waitingFlushMemDB.MemSize()))
 		f.statistics.memFlushTimer.UpdateDuration(endTime.Sub(startTime))
+		f.events.record(FamilyFlushEvent, f.indicator, waitingFlushMemDB.MemSize())
 	}
 
 	// another flush process is running
@@ -375,6 +396,15 @@ func (f *dataFamily) fileFilter(metricID uint32,
 	seriesIDs *roaring.Bitmap, _ timeutil.TimeRange,
 	fields field.Metas,
 ) (resultSet []flow.FilterResultSet, err error) {
+	if f.seriesBloom != nil && !f.seriesBloom.mayContain(metricID, seriesIDs) {
+		// family's files definitely lack all series, skip it
+		f.statistics.skippedFamilies.Incr()
+		return nil, nil
+	}
+	if openFamilies := getOpenFamilies(); openFamilies != nil {
+		// mark family open before reading files, readers closed by lru are reopened on demand
+		openFamilies.touch(f)
+	}
 	snapShot := f.family.GetSnapshot()
 	defer func() {
 		if err != nil || len(resultSet) == 0 {
@@ -424,31 +454,51 @@ func (f *dataFamily) WriteRows(rows []metric.StorageRow) error {
 	db.AcquireWrite()
 	defer db.CompleteWrite()
 
-	releaseFunc := db.WithLock()
-	defer releaseFunc()
+	numOfPartitions := db.NumOfPartitions()
+	if numOfPartitions == 1 {
+		releaseFunc := db.WithLock(0)
+		defer releaseFunc()
 
-	for idx := range rows {
-		if !rows[idx].Writable {
-			f.statistics.writeMetricFailures.Incr()
-			continue
+		for idx := range rows {
+			f.writeRow(db, &rows[idx])
 		}
-		rows[idx].SlotIndex = uint16(f.intervalCalc.CalcSlot(
-			rows[idx].Timestamp(),
-			f.familyTime,
-			f.interval.Int64()),
-		)
-		if err = db.WriteRow(&rows[idx]); err == nil {
-			f.statistics.writeMetrics.Incr()
-			f.statistics.writeFields.Add(float64(len(rows[idx].FieldIDs)))
-		} else {
-			f.statistics.writeMetricFailures.Incr()
-			f.logger.Error("failed writing row", logger.Error(err))
+		// check memory database size in background flush checker job
+		return nil
+	}
+	// lock each write partition once, writes rows of metrics which belong to it
+	for partition := 0; partition < numOfPartitions; partition++ {
+		releaseFunc := db.WithLock(partition)
+		for idx := range rows {
+			if db.PartitionOf(rows[idx].MetricID) == partition {
+				f.writeRow(db, &rows[idx])
+			}
 		}
+		releaseFunc()
 	}
 	// check memory database size in background flush checker job
 	return nil
 }
 
+// writeRow writes the row into memory database, must be called with the lock of row's partition held.
+func (f *dataFamily) writeRow(db memdb.MemoryDatabase, row *metric.StorageRow) {
+	if !row.Writable {
+		f.statistics.writeMetricFailures.Incr()
+		return
+	}
+	row.SlotIndex = uint16(f.intervalCalc.CalcSlot(
+		row.Timestamp(),
+		f.familyTime,
+		f.interval.Int64()),
+	)
+	if err := db.WriteRow(row); err == nil {
+		f.statistics.writeMetrics.Incr()
+		f.statistics.writeFields.Add(float64(len(row.FieldIDs)))
+	} else {
+		f.statistics.writeMetricFailures.Incr()
+		f.logger.Error("failed writing row", logger.Error(err))
+	}
+}
+
 func (f *dataFamily) ValidateSequence(leader int32, seq int64) bool {
 	f.mutex.Lock()
 	defer f.mutex.Unlock()
@@ -468,6 +518,17 @@ func (f *dataFamily) CommitSequence(leader int32, seq int64) {
 	f.seq[leader] = seqForLeader
 }
 
+// IsSequenceCommitted checks if the sequence of leader's wal is committed(written into memory database).
+func (f *dataFamily) IsSequenceCommitted(leader int32, seq int64) bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	seqForLeader, ok := f.seq[leader]
+	if !ok {
+		return false
+	}
+	return seq <= seqForLeader.Load()
+}
+
 func (f *dataFamily) AckSequence(leader int32, fn func(seq int64)) {
 	f.mutex.Lock()
 	defer f.mutex.Unlock()
@@ -491,6 +552,7 @@ func (f *dataFamily) GetOrCreateMemoryDatabase(familyTime int64) (memdb.MemoryDa
 			FamilyTime: familyTime,
 			Name:       f.shard.Database().Name(),
 			BufferMgr:  f.shard.BufferManager(),
+			Partitions: config.GlobalStorageConfig().TSDB.WritePartitions,
 		})
 		if err != nil {
 			return nil, err
@@ -503,7 +565,9 @@ func (f *dataFamily) GetOrCreateMemoryDatabase(familyTime int64) (memdb.MemoryDa
 func (f *dataFamily) Close() error {
 	f.flushCondition.Wait()
 
+	flushedSize := int64(0)
 	if f.immutableMemDB != nil {
+		flushedSize += f.immutableMemDB.MemSize()
 		if err := f.flushMemoryDatabase(f.immutableSeq, f.immutableMemDB); err != nil {
 			return err
 		}
@@ -513,16 +577,26 @@ func (f *dataFamily) Close() error {
 		for leader, seq := range f.seq {
 			sequences[leader] = seq.Load()
 		}
+		flushedSize += f.mutableMemDB.MemSize()
 		if err := f.flushMemoryDatabase(sequences, f.mutableMemDB); err != nil {
 			return err
 		}
 	}
 
+	if openFamilies := getOpenFamilies(); openFamilies != nil {
+		openFamilies.remove(f)
+	}
 	GetFamilyManager().RemoveFamily(f)
+	f.events.record(FamilyCloseEvent, f.indicator, flushedSize)
 	return nil
 }
 
 func (f *dataFamily) flushMemoryDatabase(sequences map[int32]int64, memDB memdb.MemoryDatabase) error {
+	if f.coordinator != nil {
+		// memory database is immutable, waiting doesn't block writing
+		release := f.coordinator.AcquireFlush()
+		defer release()
+	}
 	flusher := f.family.NewFlusher()
 	for leader, seq := range sequences {
 		flusher.Sequence(leader, seq)
@@ -532,6 +606,9 @@ func (f *dataFamily) flushMemoryDatabase(sequences map[int32]int64, memDB memdb.
 	if err != nil {
 		return err
 	}
+	if f.seriesBloom != nil {
+		dataFlusher = newSeriesBloomFlusher(dataFlusher, f.seriesBloom)
+	}
 	// flush family data
 	if err := memDB.FlushFamilyTo(dataFlusher); err != nil {
 		f.logger.Error("failed to flush memory database",
@@ -540,15 +617,20 @@ func (f *dataFamily) flushMemoryDatabase(sequences map[int32]int64, memDB memdb.
 		return err
 	}
 
-	// invoke sequence ack callback
+	// invoke sequence ack callback after flushed data synced, so that wal isn't truncated before data is durable
+	var acks []func()
 	for leader, seq := range sequences {
-		callbacks, ok := f.callbacks[leader]
-		if ok {
-			for _, fn := range callbacks {
-				fn(seq)
-			}
+		seq := seq
+		for _, fn := range f.callbacks[leader] {
+			fn := fn
+			acks = append(acks, func() { fn(seq) })
 		}
 	}
+	f.family.AfterSync(func() {
+		for _, ack := range acks {
+			ack()
+		}
+	})
 
 	if err := memDB.Close(); err != nil {
 		// ignore close memory database err, if not maybe write duplicate data into file storage
@@ -559,3 +641,21 @@ func (f *dataFamily) flushMemoryDatabase(sequences map[int32]int64, memDB memdb.
 	}
 	return nil
 }
+
+// openSeriesBloom opens the series bloom filter of family,
+// returns nil if the filter is corrupted or missing for existing files, because series of files are unknown.
+func (f *dataFamily) openSeriesBloom(path string, hasFiles bool) *seriesBloom {
+	if fileutil.Exist(path) {
+		bloom, err := loadSeriesBloom(path)
+		if err == nil {
+			return bloom
+		}
+		f.logger.Warn("failed to load series bloom filter, disable skipping family when querying",
+			logger.String("family", f.indicator), logger.Error(err))
+		return nil
+	}
+	if hasFiles {
+		return nil
+	}
+	return newSeriesBloom(path)
+}
@@ -0,0 +1,118 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tsdb
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/internal/linmetric"
+	"github.com/lindb/lindb/pkg/logger"
+)
+
+var (
+	familyScope          = linmetric.NewScope("lindb.tsdb.family")
+	openFamiliesGauge    = familyScope.NewGauge("open_families")
+	evictedFamiliesCount = familyScope.NewCounter("evicted_families")
+)
+
+var (
+	openFamiliesOnce sync.Once
+	openFamilies     *familyLRU
+)
+
+// getOpenFamilies returns the lru of open data families shared by all shards of the node,
+// returns nil if the number of open families is unlimited.
+func getOpenFamilies() *familyLRU {
+	openFamiliesOnce.Do(func() {
+		openFamilies = newFamilyLRU(config.GlobalStorageConfig().TSDB.MaxOpenFamilies)
+	})
+	return openFamilies
+}
+
+// familyLRU tracks the data families whose file readers are open,
+// closes the readers of the least recently accessed families when the number of open families exceeds capacity.
+// Closed families reopen their readers on demand when accessed again.
+type familyLRU struct {
+	capacity int
+	lru      *list.List
+	families map[DataFamily]*list.Element
+	mutex    sync.Mutex
+}
+
+// newFamilyLRU creates the lru which keeps at most capacity families open,
+// returns nil if capacity <= 0, which means unlimited.
+func newFamilyLRU(capacity int) *familyLRU {
+	if capacity <= 0 {
+		return nil
+	}
+	return &familyLRU{
+		capacity: capacity,
+		lru:      list.New(),
+		families: make(map[DataFamily]*list.Element),
+	}
+}
+
+// touch marks the family as recently accessed(opened),
+// then closes the least recently accessed families if exceeds capacity.
+func (l *familyLRU) touch(family DataFamily) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if elem, ok := l.families[family]; ok {
+		l.lru.MoveToFront(elem)
+		return
+	}
+	l.families[family] = l.lru.PushFront(family)
+
+	// skip the families in use, they will be closed when exceeding capacity next time
+	elem := l.lru.Back()
+	for len(l.families) > l.capacity && elem != l.lru.Front() {
+		prev := elem.Prev()
+		evicted := elem.Value.(DataFamily)
+		if evicted.Family().EvictReaders() {
+			l.lru.Remove(elem)
+			delete(l.families, evicted)
+			evictedFamiliesCount.Incr()
+			engineLogger.Debug("close readers of least recently used family",
+				logger.String("family", evicted.Indicator()))
+		}
+		elem = prev
+	}
+	openFamiliesGauge.Update(float64(len(l.families)))
+}
+
+// remove removes the closed family from lru.
+func (l *familyLRU) remove(family DataFamily) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if elem, ok := l.families[family]; ok {
+		l.lru.Remove(elem)
+		delete(l.families, family)
+	}
+	openFamiliesGauge.Update(float64(len(l.families)))
+}
+
+// size returns the number of open families.
+func (l *familyLRU) size() int {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return len(l.families)
+}
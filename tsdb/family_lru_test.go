@@ -0,0 +1,81 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tsdb
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/kv"
+)
+
+func TestFamilyLRU_New(t *testing.T) {
+	assert.Nil(t, newFamilyLRU(0))
+	assert.Nil(t, newFamilyLRU(-1))
+	assert.NotNil(t, newFamilyLRU(1))
+}
+
+func TestFamilyLRU_touch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	newFamily := func() (*MockDataFamily, *kv.MockFamily) {
+		kvFamily := kv.NewMockFamily(ctrl)
+		family := NewMockDataFamily(ctrl)
+		family.EXPECT().Family().Return(kvFamily).AnyTimes()
+		family.EXPECT().Indicator().Return("db/1/1").AnyTimes()
+		return family, kvFamily
+	}
+	f1, kvF1 := newFamily()
+	f2, kvF2 := newFamily()
+	f3, kvF3 := newFamily()
+	f4, _ := newFamily()
+
+	lru := newFamilyLRU(2)
+	isOpen := func(family DataFamily) bool {
+		_, ok := lru.families[family]
+		return ok
+	}
+	lru.touch(f1)
+	lru.touch(f2)
+	lru.touch(f1)
+	assert.Equal(t, 2, lru.size())
+	// case 1: exceed capacity, close least recently used family
+	kvF2.EXPECT().EvictReaders().Return(true)
+	lru.touch(f3)
+	assert.Equal(t, 2, lru.size())
+	assert.False(t, isOpen(f2))
+	// case 2: reopen closed family, families in use cannot be closed
+	kvF1.EXPECT().EvictReaders().Return(false)
+	kvF3.EXPECT().EvictReaders().Return(false)
+	lru.touch(f2)
+	assert.Equal(t, 3, lru.size())
+	// case 3: close families not in use any more
+	kvF1.EXPECT().EvictReaders().Return(true)
+	kvF3.EXPECT().EvictReaders().Return(true)
+	lru.touch(f4)
+	assert.Equal(t, 2, lru.size())
+	assert.False(t, isOpen(f1))
+	assert.False(t, isOpen(f3))
+	// case 4: remove closed family
+	lru.remove(f2)
+	lru.remove(f2)
+	assert.Equal(t, 1, lru.size())
+}
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"io"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -30,6 +31,8 @@ import (
 	"github.com/lindb/lindb/kv/version"
 	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/series/metric"
+	"github.com/lindb/lindb/tsdb/memdb"
 	"github.com/lindb/lindb/tsdb/tblstore/metricsdata"
 )
 
@@ -53,7 +56,7 @@ func TestDataFamily_BaseTime(t *testing.T) {
 	shard := NewMockShard(ctrl)
 	shard.EXPECT().Database().Return(database)
 	shard.EXPECT().ShardID().Return(models.ShardID(1))
-	dataFamily := newDataFamily(shard, timeutil.Interval(timeutil.OneSecond*10), timeRange, 10, family)
+	dataFamily := newDataFamily(shard, timeutil.Interval(timeutil.OneSecond*10), timeRange, 10, family, "", nil, nil)
 	assert.Equal(t, timeRange, dataFamily.TimeRange())
 	assert.Equal(t, timeutil.Interval(10000), dataFamily.Interval())
 	assert.NotNil(t, dataFamily.Family())
@@ -62,6 +65,36 @@ func TestDataFamily_BaseTime(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestDataFamily_IsSequenceCommitted(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	family := kv.NewMockFamily(ctrl)
+	database := NewMockDatabase(ctrl)
+	database.EXPECT().Name().Return("test").AnyTimes()
+	snapshot := version.NewMockSnapshot(ctrl)
+	v := version.NewMockVersion(ctrl)
+	v.EXPECT().GetSequences().Return(map[int32]int64{1: 10})
+	snapshot.EXPECT().GetCurrent().Return(v)
+	snapshot.EXPECT().Close()
+	family.EXPECT().GetSnapshot().Return(snapshot)
+	shard := NewMockShard(ctrl)
+	shard.EXPECT().Database().Return(database)
+	shard.EXPECT().ShardID().Return(models.ShardID(1))
+	dataFamily := newDataFamily(shard, timeutil.Interval(timeutil.OneSecond*10),
+		timeutil.TimeRange{Start: 10, End: 50}, 10, family, "", nil, nil)
+
+	// persisted sequence
+	assert.True(t, dataFamily.IsSequenceCommitted(1, 10))
+	assert.False(t, dataFamily.IsSequenceCommitted(1, 11))
+	// unknown leader
+	assert.False(t, dataFamily.IsSequenceCommitted(2, 1))
+	dataFamily.CommitSequence(1, 11)
+	dataFamily.CommitSequence(2, 1)
+	assert.True(t, dataFamily.IsSequenceCommitted(1, 11))
+	assert.True(t, dataFamily.IsSequenceCommitted(2, 1))
+}
+
 func TestDataFamily_Filter(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer func() {
@@ -86,7 +119,7 @@ func TestDataFamily_Filter(t *testing.T) {
 	shard := NewMockShard(ctrl)
 	shard.EXPECT().Database().Return(database)
 	shard.EXPECT().ShardID().Return(models.ShardID(1))
-	dataFamily := newDataFamily(shard, timeutil.Interval(timeutil.OneSecond*10), timeRange, 10, family)
+	dataFamily := newDataFamily(shard, timeutil.Interval(timeutil.OneSecond*10), timeRange, 10, family, "", nil, nil)
 
 	// test find kv readers err
 	snapshot.EXPECT().FindReaders(gomock.Any()).Return(nil, fmt.Errorf("err"))
@@ -136,3 +169,128 @@ func TestDataFamily_Filter(t *testing.T) {
 	err = dataFamily.Close()
 	assert.NoError(t, err)
 }
+
+func TestDataFamily_Flush_Coordinated(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		newMemoryDBFunc = memdb.NewMemoryDatabase
+		ctrl.Finish()
+	}()
+
+	database := NewMockDatabase(ctrl)
+	database.EXPECT().Name().Return("test").AnyTimes()
+	shard := NewMockShard(ctrl)
+	shard.EXPECT().Database().Return(database).AnyTimes()
+	shard.EXPECT().ShardID().Return(models.ShardID(1)).AnyTimes()
+	shard.EXPECT().BufferManager().Return(nil).AnyTimes()
+	family := kv.NewMockFamily(ctrl)
+	snapshot := version.NewMockSnapshot(ctrl)
+	v := version.NewMockVersion(ctrl)
+	v.EXPECT().GetSequences().Return(map[int32]int64{1: 10})
+	snapshot.EXPECT().GetCurrent().Return(v)
+	snapshot.EXPECT().Close()
+	family.EXPECT().GetSnapshot().Return(snapshot)
+
+	coordinator := kv.NewCoordinator(
+		flushWaitTimerVec.WithTagValues("test", "1"),
+		deferredCompactionsVec.WithTagValues("test", "1"))
+	dataFamily := newDataFamily(shard, timeutil.Interval(timeutil.OneSecond*10),
+		timeutil.TimeRange{Start: 10, End: 50}, 10, family, "", coordinator, nil)
+	defer GetFamilyManager().RemoveFamily(dataFamily)
+
+	var memDBs []*memdb.MockMemoryDatabase
+	newMemoryDBFunc = func(cfg memdb.MemoryDatabaseCfg) (memdb.MemoryDatabase, error) {
+		memDB := memdb.NewMockMemoryDatabase(ctrl)
+		memDB.EXPECT().AcquireWrite().AnyTimes()
+		memDB.EXPECT().CompleteWrite().AnyTimes()
+		memDB.EXPECT().NumOfPartitions().Return(1).AnyTimes()
+		memDB.EXPECT().WithLock(0).Return(func() {}).AnyTimes()
+		memDB.EXPECT().Size().Return(100).AnyTimes()
+		memDB.EXPECT().MemSize().Return(int64(100)).AnyTimes()
+		memDBs = append(memDBs, memDB)
+		return memDB, nil
+	}
+	rows := []metric.StorageRow{{}}
+	assert.NoError(t, dataFamily.WriteRows(rows))
+
+	// compaction of shard is running
+	releaseCompaction, ok := coordinator.TryAcquireCompaction()
+	assert.True(t, ok)
+	flushed := make(chan error)
+	go func() {
+		flushed <- dataFamily.Flush()
+	}()
+	// wait flush switching mutable memory database to immutable
+	assert.Eventually(t, func() bool {
+		return dataFamily.IsFlushing() && dataFamily.MemDBSize() == 0
+	}, time.Second, time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+
+	// flush waits for compaction, but writing isn't blocked
+	start := time.Now()
+	assert.NoError(t, dataFamily.WriteRows(rows))
+	assert.Less(t, int64(time.Since(start)), int64(100*time.Millisecond))
+	assert.Len(t, memDBs, 2)
+	select {
+	case <-flushed:
+		assert.Fail(t, "flush should wait for compaction")
+	default:
+	}
+
+	// flush runs after compaction completes
+	kvFlusher := kv.NewMockFlusher(ctrl)
+	kvFlusher.EXPECT().Sequence(int32(1), int64(10))
+	kvFlusher.EXPECT().StreamWriter().Return(nil, fmt.Errorf("err"))
+	family.EXPECT().NewFlusher().Return(kvFlusher)
+	releaseCompaction()
+	select {
+	case err := <-flushed:
+		assert.Error(t, err)
+	case <-time.After(time.Second):
+		assert.Fail(t, "flush should run after compaction completes")
+	}
+}
+
+func TestDataFamily_AckSequence_AfterSync(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	database := NewMockDatabase(ctrl)
+	database.EXPECT().Name().Return("test").AnyTimes()
+	shard := NewMockShard(ctrl)
+	shard.EXPECT().Database().Return(database).AnyTimes()
+	shard.EXPECT().ShardID().Return(models.ShardID(1)).AnyTimes()
+	family := kv.NewMockFamily(ctrl)
+	snapshot := version.NewMockSnapshot(ctrl)
+	v := version.NewMockVersion(ctrl)
+	v.EXPECT().GetSequences().Return(map[int32]int64{1: 10})
+	snapshot.EXPECT().GetCurrent().Return(v)
+	snapshot.EXPECT().Close()
+	family.EXPECT().GetSnapshot().Return(snapshot)
+	f := newDataFamily(shard, timeutil.Interval(timeutil.OneSecond*10),
+		timeutil.TimeRange{Start: 10, End: 50}, 10, family, "", nil, nil)
+	defer GetFamilyManager().RemoveFamily(f)
+
+	var acked []int64
+	f.AckSequence(1, func(seq int64) {
+		acked = append(acked, seq)
+	})
+	assert.Equal(t, []int64{10}, acked)
+
+	kvFlusher := kv.NewMockFlusher(ctrl)
+	kvFlusher.EXPECT().Sequence(int32(1), int64(20))
+	kvFlusher.EXPECT().StreamWriter().Return(table.NewMockStreamWriter(ctrl), nil)
+	family.EXPECT().NewFlusher().Return(kvFlusher)
+	memDB := memdb.NewMockMemoryDatabase(ctrl)
+	memDB.EXPECT().FlushFamilyTo(gomock.Any()).Return(nil)
+	memDB.EXPECT().Close().Return(nil)
+	var afterSync func()
+	family.EXPECT().AfterSync(gomock.Any()).Do(func(fn func()) {
+		afterSync = fn
+	})
+	assert.NoError(t, f.(*dataFamily).flushMemoryDatabase(map[int32]int64{1: 20}, memDB))
+	// sequence is acked after flushed data synced
+	assert.Equal(t, []int64{10}, acked)
+	afterSync()
+	assert.Equal(t, []int64{10, 20}, acked)
+}
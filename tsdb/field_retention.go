@@ -0,0 +1,229 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tsdb
+
+import (
+	"context"
+	"errors"
+	"path"
+	"sync"
+	"time"
+
+	"go.uber.org/atomic"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/internal/linmetric"
+	"github.com/lindb/lindb/kv"
+	"github.com/lindb/lindb/pkg/fasttime"
+	"github.com/lindb/lindb/pkg/logger"
+	"github.com/lindb/lindb/tsdb/metadb"
+	"github.com/lindb/lindb/tsdb/tblstore/metricsdata"
+)
+
+// defaultFieldRetentionInterval is the check interval if field retention interval isn't set.
+const defaultFieldRetentionInterval = 10 * time.Minute
+
+//go:generate mockgen -source=./field_retention.go -destination=./field_retention_mock.go -package=tsdb
+
+var (
+	fieldRetentionScope        = linmetric.NewScope("lindb.tsdb.field_retention")
+	purgedFamiliesCounter      = fieldRetentionScope.NewCounter("purged_families")
+	purgeFamilyFailuresCounter = fieldRetentionScope.NewCounter("purge_failures")
+)
+
+// FieldRetentionChecker represents the checker which purges the data of fields with retention override,
+// data of matched fields is dropped from the data family older than retention, other fields of same series are kept.
+type FieldRetentionChecker interface {
+	// Start starts the checker goroutine in background.
+	Start()
+	// Stop stops the background check goroutine.
+	Stop()
+}
+
+// fieldRetentionChecker implements FieldRetentionChecker interface.
+type fieldRetentionChecker struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	running *atomic.Bool
+
+	// family indicator => purged fields, avoids rewriting family again,
+	// family is rewritten once more after restarting, the rewrite is idempotent.
+	purged map[string]metricsdata.PurgeFields
+	mutex  sync.Mutex
+
+	walkFamiliesFunc func(fn func(family DataFamily)) // used for mocking
+	logger           *logger.Logger
+}
+
+// newFieldRetentionChecker creates the field retention checker.
+func newFieldRetentionChecker(ctx context.Context) FieldRetentionChecker {
+	c, cancel := context.WithCancel(ctx)
+	return &fieldRetentionChecker{
+		ctx:              c,
+		cancel:           cancel,
+		running:          atomic.NewBool(false),
+		purged:           make(map[string]metricsdata.PurgeFields),
+		walkFamiliesFunc: GetFamilyManager().WalkEntry,
+		logger:           engineLogger,
+	}
+}
+
+// Start starts the checker goroutine in background.
+func (rc *fieldRetentionChecker) Start() {
+	if rc.running.CAS(false, true) {
+		go rc.startCheckFieldRetention()
+	}
+}
+
+// Stop stops the background check goroutine.
+func (rc *fieldRetentionChecker) Stop() {
+	if rc.running.CAS(true, false) {
+		rc.cancel()
+	}
+}
+
+// startCheckFieldRetention checks the data families periodically.
+func (rc *fieldRetentionChecker) startCheckFieldRetention() {
+	interval := config.GlobalStorageConfig().TSDB.FieldRetentionInterval.Duration()
+	if interval <= 0 {
+		interval = defaultFieldRetentionInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-rc.ctx.Done():
+			return
+		case <-ticker.C:
+			rc.check(fasttime.UnixMilliseconds())
+		}
+	}
+}
+
+// check purges the data of fields whose retention is expired for each data family.
+func (rc *fieldRetentionChecker) check(now int64) {
+	var retentions []config.FieldRetention
+	for _, retention := range config.GlobalStorageConfig().TSDB.FieldRetentions {
+		fieldRetention, err := config.ParseFieldRetention(retention)
+		if err != nil {
+			rc.logger.Warn("ignore invalid field retention", logger.String("retention", retention), logger.Error(err))
+			continue
+		}
+		retentions = append(retentions, fieldRetention)
+	}
+	if len(retentions) == 0 {
+		return
+	}
+	var families []DataFamily
+	rc.walkFamiliesFunc(func(family DataFamily) {
+		families = append(families, family)
+	})
+
+	rc.mutex.Lock()
+	defer rc.mutex.Unlock()
+
+	// remove purged fields of closed families
+	walked := make(map[string]struct{}, len(families))
+	for _, family := range families {
+		walked[family.Indicator()] = struct{}{}
+	}
+	for indicator := range rc.purged {
+		if _, ok := walked[indicator]; !ok {
+			delete(rc.purged, indicator)
+		}
+	}
+
+	for _, family := range families {
+		if rc.ctx.Err() != nil {
+			return
+		}
+		if family.MemDBSize() > 0 || family.IsFlushing() {
+			// purge after memory data flushed, else purged fields may be flushed again
+			continue
+		}
+		age := time.Duration(now-family.TimeRange().End) * time.Millisecond
+		purgeFields := rc.expiredFields(family, retentions, age)
+		if len(purgeFields) == 0 {
+			continue
+		}
+		err := family.Family().Rewrite(map[string]interface{}{metricsdata.PurgeFieldsContext: purgeFields})
+		switch {
+		case errors.Is(err, kv.ErrFamilyBusy):
+			// retry in next check cycle
+			continue
+		case err != nil:
+			purgeFamilyFailuresCounter.Incr()
+			rc.logger.Error("purge fields of family failure",
+				logger.String("family", family.Indicator()), logger.Error(err))
+			continue
+		}
+		purgedFamiliesCounter.Incr()
+		rc.logger.Info("purge fields of family successfully",
+			logger.String("family", family.Indicator()), logger.Any("fields", purgeFields))
+		// purged fields include the fields purged before
+		rc.purged[family.Indicator()] = purgeFields
+	}
+}
+
+// expiredFields returns the fields need to be purged under family, excludes the fields purged before,
+// returns empty if all expired fields are purged.
+func (rc *fieldRetentionChecker) expiredFields(
+	family DataFamily,
+	retentions []config.FieldRetention,
+	age time.Duration,
+) metricsdata.PurgeFields {
+	metadataDB := family.Shard().Database().Metadata().MetadataDatabase()
+	purged := rc.purged[family.Indicator()]
+	purgeFields := make(metricsdata.PurgeFields)
+	hasNew := false
+	for _, retention := range retentions {
+		if age < retention.Retention {
+			continue
+		}
+		namespace, metricName := metadb.ParseNamespaceMetric(retention.Metric)
+		metricID, err := metadataDB.GetMetricID(namespace, metricName)
+		if err != nil {
+			if !errors.Is(err, constants.ErrNotFound) {
+				rc.logger.Warn("get metric id failure when checking field retention",
+					logger.String("metric", retention.Metric), logger.Error(err))
+			}
+			continue
+		}
+		fields, err := metadataDB.GetAllFields(namespace, metricName)
+		if err != nil {
+			rc.logger.Warn("get fields failure when checking field retention",
+				logger.String("metric", retention.Metric), logger.Error(err))
+			continue
+		}
+		for _, f := range fields {
+			if ok, _ := path.Match(retention.FieldPattern, string(f.Name)); !ok || purgeFields.Contains(metricID, f.ID) {
+				continue
+			}
+			purgeFields[metricID] = append(purgeFields[metricID], f.ID)
+			if !purged.Contains(metricID, f.ID) {
+				hasNew = true
+			}
+		}
+	}
+	if !hasNew {
+		return nil
+	}
+	return purgeFields
+}
@@ -0,0 +1,134 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tsdb
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/kv"
+	"github.com/lindb/lindb/pkg/ltoml"
+	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/series/field"
+	"github.com/lindb/lindb/tsdb/metadb"
+	"github.com/lindb/lindb/tsdb/tblstore/metricsdata"
+)
+
+func TestFieldRetentionChecker_Start(t *testing.T) {
+	defaultCfg := config.GlobalStorageConfig()
+	defer config.SetGlobalStorageConfig(defaultCfg)
+	cfg := *defaultCfg
+	cfg.TSDB.FieldRetentionInterval = ltoml.Duration(10 * time.Millisecond)
+	config.SetGlobalStorageConfig(&cfg)
+
+	checker := newFieldRetentionChecker(context.TODO())
+	checker.Start()
+	time.Sleep(50 * time.Millisecond)
+	checker.Stop()
+}
+
+func TestFieldRetentionChecker_check(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defaultCfg := config.GlobalStorageConfig()
+	defer func() {
+		config.SetGlobalStorageConfig(defaultCfg)
+		ctrl.Finish()
+	}()
+	cfg := *defaultCfg
+	cfg.TSDB.FieldRetentions = []string{"cpu:detail_*=1h", "cpu:summary=24h", "memory:*=1h", "bad"}
+	config.SetGlobalStorageConfig(&cfg)
+
+	metadataDB := metadb.NewMockMetadataDatabase(ctrl)
+	metadata := metadb.NewMockMetadata(ctrl)
+	metadata.EXPECT().MetadataDatabase().Return(metadataDB).AnyTimes()
+	db := NewMockDatabase(ctrl)
+	db.EXPECT().Metadata().Return(metadata).AnyTimes()
+	shard := NewMockShard(ctrl)
+	shard.EXPECT().Database().Return(db).AnyTimes()
+	metadataDB.EXPECT().GetMetricID(constants.DefaultNamespace, "cpu").Return(uint32(1), nil).AnyTimes()
+	metadataDB.EXPECT().GetAllFields(constants.DefaultNamespace, "cpu").Return(field.Metas{
+		{ID: 1, Name: "detail_a", Type: field.SumField},
+		{ID: 2, Name: "summary", Type: field.SumField},
+		{ID: 3, Name: "detail_b", Type: field.GaugeField},
+	}, nil).AnyTimes()
+	metadataDB.EXPECT().GetMetricID(constants.DefaultNamespace, "memory").Return(uint32(0), constants.ErrNotFound).AnyTimes()
+
+	now := timeutil.Now()
+	newFamily := func(indicator string, age time.Duration) (*MockDataFamily, *kv.MockFamily) {
+		family := NewMockDataFamily(ctrl)
+		kvFamily := kv.NewMockFamily(ctrl)
+		family.EXPECT().Indicator().Return(indicator).AnyTimes()
+		family.EXPECT().Shard().Return(shard).AnyTimes()
+		family.EXPECT().Family().Return(kvFamily).AnyTimes()
+		family.EXPECT().IsFlushing().Return(false).AnyTimes()
+		family.EXPECT().TimeRange().Return(timeutil.TimeRange{End: now - age.Milliseconds()}).AnyTimes()
+		return family, kvFamily
+	}
+	// family older than short retention
+	family1, kvFamily1 := newFamily("family1", 2*time.Hour)
+	family1.EXPECT().MemDBSize().Return(int64(0)).AnyTimes()
+	// family within retention
+	family2, kvFamily2 := newFamily("family2", 30*time.Minute)
+	family2.EXPECT().MemDBSize().Return(int64(0)).AnyTimes()
+	// family has memory data
+	family3, _ := newFamily("family3", 2*time.Hour)
+	family3.EXPECT().MemDBSize().Return(int64(10)).AnyTimes()
+	// family older than all retentions
+	family4, kvFamily4 := newFamily("family4", 48*time.Hour)
+	family4.EXPECT().MemDBSize().Return(int64(0)).AnyTimes()
+
+	families := []DataFamily{family1, family2, family3, family4}
+	checker := newFieldRetentionChecker(context.TODO()).(*fieldRetentionChecker)
+	checker.walkFamiliesFunc = func(fn func(family DataFamily)) {
+		for _, family := range families {
+			fn(family)
+		}
+	}
+	purgeShort := map[string]interface{}{metricsdata.PurgeFieldsContext: metricsdata.PurgeFields{1: {1, 3}}}
+	purgeAll := map[string]interface{}{metricsdata.PurgeFieldsContext: metricsdata.PurgeFields{1: {1, 3, 2}}}
+	// case 1: purge short retention fields, family4 is busy
+	kvFamily1.EXPECT().Rewrite(purgeShort).Return(nil)
+	kvFamily4.EXPECT().Rewrite(purgeAll).Return(kv.ErrFamilyBusy)
+	checker.check(now)
+	// case 2: family1 purged, retry family4 failure
+	kvFamily4.EXPECT().Rewrite(purgeAll).Return(fmt.Errorf("err"))
+	checker.check(now)
+	// case 3: retry family4 successfully
+	kvFamily4.EXPECT().Rewrite(purgeAll).Return(nil)
+	checker.check(now)
+	// case 4: all purged
+	checker.check(now)
+	// case 5: family1 older than long retention, purge again, family2 older than all retentions
+	kvFamily1.EXPECT().Rewrite(purgeAll).Return(nil)
+	kvFamily2.EXPECT().Rewrite(purgeAll).Return(nil)
+	checker.check(now + 24*timeutil.OneHour)
+	// case 6: closed family is removed
+	families = []DataFamily{family1}
+	checker.check(now + 24*timeutil.OneHour)
+	assert.Len(t, checker.purged, 1)
+	// case 7: no retention
+	cfg.TSDB.FieldRetentions = nil
+	checker.check(now)
+}
@@ -19,14 +19,20 @@ package indexdb
 
 import (
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
 	"path"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/lindb/roaring"
 	"go.etcd.io/bbolt"
 
+	"github.com/lindb/lindb/config"
 	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/pkg/collections"
 	"github.com/lindb/lindb/pkg/fileutil"
 	"github.com/lindb/lindb/pkg/logger"
 )
@@ -42,10 +48,26 @@ var (
 	setSequenceFunc  = setSequence
 	createBucketFunc = createBucket
 	putFunc          = put
+	checkBackendFunc = checkBackend
 )
 
+// ErrBackendCorrupted represents the id mapping backend storage fails the integrity check.
+var ErrBackendCorrupted = errors.New("series id mapping storage corrupted")
+
 var (
 	seriesBucketName = []byte("s")
+	bloomBucketName  = []byte("b")
+)
+
+const (
+	bloomFilterInitCapacity = 1024
+	bloomFilterFPRate       = 0.01
+)
+
+var (
+	bloomNegativeCounter      = indexDBScope.NewCounter("series_bloom_negative")
+	bloomPositiveCounter      = indexDBScope.NewCounter("series_bloom_positive")
+	bloomFalsePositiveCounter = indexDBScope.NewCounter("series_bloom_false_positive")
 )
 
 // IDMappingBackend represents the id mapping backend storage,
@@ -59,18 +81,33 @@ type IDMappingBackend interface {
 	//
 	loadMetricIDMapping(metricID uint32) (idMapping MetricIDMapping, err error)
 
+	// loadMetricIDs loads all metric ids which have id mapping in backend storage
+	loadMetricIDs() (metricIDs *roaring.Bitmap, err error)
+
+	// countSeries returns the sum of series id sequences of all metrics in backend storage
+	countSeries() (count uint64, err error)
 
 	// getSeriesID gets series id by metric id/tags hash, if not exist return constants.ErrNotFount
 	getSeriesID(metricID uint32, tagsHash uint64) (seriesID uint32, err error)
 
+	// loadSeriesIDs loads all series ids under metric into the metric id mapping
+	loadSeriesIDs(idMapping MetricIDMapping) (err error)
 
 	// saveMapping saves the id mapping event
 	saveMapping(event *mappingEvent) (err error)
+
+	// removeSeriesIDs removes all tags hash which series id in spec series ids under metric
+	removeSeriesIDs(metricID uint32, seriesIDs *roaring.Bitmap) (err error)
 }
 
 // idMappingBackend implements IDMappingBackend interface
 type idMappingBackend struct {
 	db *bbolt.DB
+
+	// bloom filters of tags hash under metric, key: metric id.
+	// filter is immutable after stored(copy on write), so that getSeriesID can skip negative lookups without lock.
+	filters map[uint32]*collections.BloomFilter
+	lock    sync.RWMutex
 }
 
 // newIDMappingBackend creates new id mapping backend storage
@@ -86,7 +123,17 @@ func newIDMappingBackend(parent string) (IDMappingBackend, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err = checkBackendIntegrity(db, parent); err != nil {
+		if e := closeFunc(db); e != nil {
+			indexLogger.Error("close bbolt.db err when check mapping backend fail", logger.String("db", parent), logger.Error(e))
+		}
+		return nil, err
+	}
 
+	backend := &idMappingBackend{
+		db:      db,
+		filters: make(map[uint32]*collections.BloomFilter),
+	}
 	// 创建 bucket "s"
 	if err = db.Update(func(tx *bbolt.Tx) error {
 		// create series root bucket for save metric's id mapping
@@ -94,7 +141,11 @@ func newIDMappingBackend(parent string) (IDMappingBackend, error) {
 		if err != nil {
 			return err
 		}
-		return nil
+		// create bloom root bucket for save metric's bloom filter of tags hash
+		if _, err = tx.CreateBucketIfNotExists(bloomBucketName); err != nil {
+			return err
+		}
+		return backend.loadBloomFilters(tx)
 	}); err != nil {
 		// close bbolt.DB if init mapping backend err
 		if e := closeFunc(db); e != nil {
@@ -103,11 +154,69 @@ func newIDMappingBackend(parent string) (IDMappingBackend, error) {
 		return nil, err
 	}
 
-	return &idMappingBackend{
-		db: db,
-	}, nil
+	return backend, nil
+}
+
+// checkBackendIntegrity checks the integrity of backend storage based on config,
+// returns err if corrupted and config refuses to open.
+func checkBackendIntegrity(db *bbolt.DB, parent string) error {
+	mode := config.GlobalStorageConfig().TSDB.BackendIntegrityCheck
+	if mode == "" || mode == config.BackendIntegrityCheckOff {
+		return nil
+	}
+	err := checkBackendFunc(db)
+	if err == nil {
+		return nil
+	}
+	if mode == config.BackendIntegrityCheckRefuse {
+		indexLogger.Error("series id mapping storage corrupted, refuse to open, "+
+			"please recover it from replica/backup or rebuild it",
+			logger.String("db", parent), logger.Error(err))
+		return fmt.Errorf("%w, path: %s", err, path.Join(parent, MappingDB))
+	}
+	indexLogger.Warn("series id mapping storage corrupted, continue opening, "+
+		"series id may be wrong, please recover it from replica/backup or rebuild it",
+		logger.String("db", parent), logger.Error(err))
+	return nil
+}
+
+// checkBackend checks the consistency of all pages in backend storage.
+func checkBackend(db *bbolt.DB) error {
+	return db.View(func(tx *bbolt.Tx) error {
+		var corruptions []string
+		for err := range tx.Check() {
+			corruptions = append(corruptions, err.Error())
+		}
+		if len(corruptions) > 0 {
+			return fmt.Errorf("%w, %s", ErrBackendCorrupted, strings.Join(corruptions, "; "))
+		}
+		return nil
+	})
 }
 
+// loadBloomFilters loads the bloom filters of all metrics, rebuilds the filter if not exist or corrupted.
+func (imb *idMappingBackend) loadBloomFilters(tx *bbolt.Tx) error {
+	root := tx.Bucket(seriesBucketName)
+	bloomBucket := tx.Bucket(bloomBucketName)
+	return root.ForEach(func(k, v []byte) error {
+		// value is nil for nested metric bucket
+		if v != nil || len(k) != 4 {
+			return nil
+		}
+		metricID := binary.LittleEndian.Uint32(k)
+		if data := bloomBucket.Get(k); data != nil {
+			if filter, err := collections.UnmarshalBloomFilter(data); err == nil {
+				imb.filters[metricID] = filter
+				return nil
+			}
+		}
+		filter := buildBloomFilter(root.Bucket(k))
+		imb.filters[metricID] = filter
+		return putFunc(bloomBucket, k, filter.MarshalBinary())
+	})
+}
+
+
 // loadMetricIDMapping loads metric id mapping include id sequence
 // 根据 metricId 加载 <metricID, sequence>
 func (imb *idMappingBackend) loadMetricIDMapping(metricID uint32) (idMapping MetricIDMapping, err error) {
@@ -131,10 +240,63 @@ func (imb *idMappingBackend) loadMetricIDMapping(metricID uint32) (idMapping Met
 	return newMetricIDMapping(metricID, sequence), nil
 }
 
+// loadMetricIDs loads all metric ids which have id mapping in backend storage
+func (imb *idMappingBackend) loadMetricIDs() (metricIDs *roaring.Bitmap, err error) {
+	metricIDs = roaring.New()
+	err = imb.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(seriesBucketName).ForEach(func(k, v []byte) error {
+			// value is nil for nested metric bucket
+			if v == nil && len(k) == 4 {
+				metricIDs.Add(binary.LittleEndian.Uint32(k))
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return metricIDs, nil
+}
+
+// countSeries returns the sum of series id sequences of all metrics in backend storage
+func (imb *idMappingBackend) countSeries() (count uint64, err error) {
+	err = imb.db.View(func(tx *bbolt.Tx) error {
+		root := tx.Bucket(seriesBucketName)
+		return root.ForEach(func(k, v []byte) error {
+			// value is nil for nested metric bucket
+			if v == nil && len(k) == 4 {
+				count += root.Bucket(k).Sequence()
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
 // getSeriesID gets series id by metric id/tags hash, if not exist return constants.ErrNotFount
 //
 // 根据 metricId, tagsHash 获取 seriesID
 func (imb *idMappingBackend) getSeriesID(metricID uint32, tagsHash uint64) (seriesID uint32, err error) {
+	imb.lock.RLock()
+	filter, ok := imb.filters[metricID]
+	imb.lock.RUnlock()
+	if ok {
+		if !filter.Contains(tagsHash) {
+			// definitely absent, skip reading backend storage
+			bloomNegativeCounter.Incr()
+			return 0, fmt.Errorf("%w, metricID: %d, tagsHash: %d",
+				constants.ErrSeriesIDNotFound, metricID, tagsHash)
+		}
+		bloomPositiveCounter.Incr()
+		defer func() {
+			if errors.Is(err, constants.ErrSeriesIDNotFound) {
+				bloomFalsePositiveCounter.Incr()
+			}
+		}()
+	}
 	var scratch [4]byte
 	binary.LittleEndian.PutUint32(scratch[:], metricID)
 	err = imb.db.View(func(tx *bbolt.Tx) error {
@@ -162,7 +324,9 @@ func (imb *idMappingBackend) getSeriesID(metricID uint32, tagsHash uint64) (seri
 
 // saveMapping saves the id mapping event
 func (imb *idMappingBackend) saveMapping(event *mappingEvent) (err error) {
+	filters := make(map[uint32]*collections.BloomFilter, len(event.events))
 	err = imb.db.Update(func(tx *bbolt.Tx) error {
+		bloomBucket := tx.Bucket(bloomBucketName)
 
 		for metricID, metricEvent := range event.events {
 
@@ -183,6 +347,9 @@ func (imb *idMappingBackend) saveMapping(event *mappingEvent) (err error) {
 				}
 			}
 
+			// copy on write, because filter may be read by getSeriesID concurrently
+			filter := imb.getBloomFilter(metricID)
+
 			// save series data
 			for _, seriesEvent := range metricEvent.events {
 
@@ -195,7 +362,16 @@ func (imb *idMappingBackend) saveMapping(event *mappingEvent) (err error) {
 				if err = putFunc(metricBucket, hash[:], seriesID[:]); err != nil {
 					return err
 				}
+				filter.Add(seriesEvent.tagsHash)
+			}
+			if filter.Count() > filter.Capacity() {
+				// rebuild filter with larger capacity, keep false positive rate
+				filter = buildBloomFilter(metricBucket)
+			}
+			if err = putFunc(bloomBucket, id, filter.MarshalBinary()); err != nil {
+				return err
 			}
+			filters[metricID] = filter
 
 			// save metric id sequence
 			if err = setSequenceFunc(metricBucket, uint64(metricEvent.metricIDSeq)); err != nil {
@@ -204,6 +380,51 @@ func (imb *idMappingBackend) saveMapping(event *mappingEvent) (err error) {
 		}
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+	imb.lock.Lock()
+	for metricID, filter := range filters {
+		imb.filters[metricID] = filter
+	}
+	imb.lock.Unlock()
+	return nil
+}
+
+// getBloomFilter returns a copy of the bloom filter under metric, creates a new one if not exist.
+func (imb *idMappingBackend) getBloomFilter(metricID uint32) *collections.BloomFilter {
+	imb.lock.RLock()
+	filter, ok := imb.filters[metricID]
+	imb.lock.RUnlock()
+	if !ok {
+		return collections.NewBloomFilter(bloomFilterInitCapacity, bloomFilterFPRate)
+	}
+	return filter.Clone()
+}
+
+// removeSeriesIDs removes all tags hash which series id in spec series ids under metric
+func (imb *idMappingBackend) removeSeriesIDs(metricID uint32, seriesIDs *roaring.Bitmap) (err error) {
+	var scratch [4]byte
+	binary.LittleEndian.PutUint32(scratch[:], metricID)
+	err = imb.db.Update(func(tx *bbolt.Tx) error {
+		metricBucket := tx.Bucket(seriesBucketName).Bucket(scratch[:])
+		if metricBucket == nil {
+			return nil
+		}
+		c := metricBucket.Cursor()
+		for k, v := c.First(); k != nil; {
+			if len(v) == 4 && seriesIDs.Contains(binary.LittleEndian.Uint32(v)) {
+				// cursor moves to next item after delete
+				if err := c.Delete(); err != nil {
+					return err
+				}
+				k, v = c.Seek(k)
+				continue
+			}
+			k, v = c.Next()
+		}
+		return nil
+	})
 	return err
 }
 
@@ -230,6 +451,45 @@ func createBucket(parentBucket *bbolt.Bucket, name []byte) (*bbolt.Bucket, error
 	return parentBucket.CreateBucket(name)
 }
 
+// buildBloomFilter builds the bloom filter with all tags hash under metric bucket.
+func buildBloomFilter(metricBucket *bbolt.Bucket) *collections.BloomFilter {
+	var hashes []uint64
+	_ = metricBucket.ForEach(func(k, v []byte) error {
+		if len(k) == 8 {
+			hashes = append(hashes, binary.LittleEndian.Uint64(k))
+		}
+		return nil
+	})
+	capacity := uint32(bloomFilterInitCapacity)
+	for capacity < uint32(len(hashes))*2 {
+		capacity *= 2
+	}
+	filter := collections.NewBloomFilter(capacity, bloomFilterFPRate)
+	for _, hash := range hashes {
+		filter.Add(hash)
+	}
+	return filter
+}
+
+// loadSeriesIDs loads all series ids under metric into the metric id mapping
+func (imb *idMappingBackend) loadSeriesIDs(idMapping MetricIDMapping) (err error) {
+	metricID := idMapping.GetMetricID()
+	var scratch [4]byte
+	binary.LittleEndian.PutUint32(scratch[:], metricID)
+	return imb.db.View(func(tx *bbolt.Tx) error {
+		metricBucket := tx.Bucket(seriesBucketName).Bucket(scratch[:])
+		if metricBucket == nil {
+			return fmt.Errorf("%w, metricID: %d", constants.ErrMetricBucketNotFound, metricID)
+		}
+		return metricBucket.ForEach(func(k, v []byte) error {
+			if len(k) == 8 && len(v) == 4 {
+				idMapping.AddSeriesID(binary.LittleEndian.Uint64(k), binary.LittleEndian.Uint32(v))
+			}
+			return nil
+		})
+	})
+}
+
 // put puts the key/value
 func put(bucket *bbolt.Bucket, key, value []byte) error {
 	return bucket.Put(key, value)
@@ -0,0 +1,135 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package indexdb
+
+import (
+	"errors"
+	"syscall"
+	"time"
+
+	"github.com/lindb/roaring"
+	"go.etcd.io/bbolt"
+
+	"github.com/lindb/lindb/pkg/logger"
+)
+
+// for testing
+var (
+	sleepFunc = time.Sleep
+)
+
+var backendRetryCounterVec = indexDBScope.NewCounterVec("backend_retries", "db", "op")
+
+// transientErrors represents the errors which may succeed after retry,
+// others(such as corruption, not found) must fail fast.
+var transientErrors = []error{
+	syscall.EAGAIN,
+	syscall.EINTR,
+	syscall.EBUSY,
+	syscall.ETIMEDOUT,
+	bbolt.ErrTimeout,
+}
+
+// isTransientError checks if the error of backend storage is transient.
+func isTransientError(err error) bool {
+	for _, transientErr := range transientErrors {
+		if errors.Is(err, transientErr) {
+			return true
+		}
+	}
+	return false
+}
+
+// retryIDMappingBackend retries the backend storage operation with backoff for transient errors.
+type retryIDMappingBackend struct {
+	IDMappingBackend
+
+	databaseName string
+	maxRetries   int
+	backoff      time.Duration
+}
+
+// newRetryIDMappingBackend wraps the backend storage with bounded retry.
+func newRetryIDMappingBackend(backend IDMappingBackend, databaseName string,
+	maxRetries int, backoff time.Duration,
+) IDMappingBackend {
+	return &retryIDMappingBackend{
+		IDMappingBackend: backend,
+		databaseName:     databaseName,
+		maxRetries:       maxRetries,
+		backoff:          backoff,
+	}
+}
+
+// retry invokes the operation, retries it if fail with transient error until exceeds max retries.
+func (b *retryIDMappingBackend) retry(op string, fn func() error) (err error) {
+	backoff := b.backoff
+	for i := 0; ; i++ {
+		if err = fn(); err == nil || i >= b.maxRetries || !isTransientError(err) {
+			return err
+		}
+		backendRetryCounterVec.WithTagValues(b.databaseName, op).Incr()
+		indexLogger.Warn("retry series id mapping backend operation",
+			logger.String("db", b.databaseName), logger.String("op", op),
+			logger.Int32("retries", int32(i+1)), logger.Error(err))
+		sleepFunc(backoff)
+		backoff *= 2
+	}
+}
+
+func (b *retryIDMappingBackend) loadMetricIDMapping(metricID uint32) (idMapping MetricIDMapping, err error) {
+	err = b.retry("loadMetricIDMapping", func() (err error) {
+		idMapping, err = b.IDMappingBackend.loadMetricIDMapping(metricID)
+		return err
+	})
+	return idMapping, err
+}
+
+func (b *retryIDMappingBackend) loadMetricIDs() (metricIDs *roaring.Bitmap, err error) {
+	err = b.retry("loadMetricIDs", func() (err error) {
+		metricIDs, err = b.IDMappingBackend.loadMetricIDs()
+		return err
+	})
+	return metricIDs, err
+}
+
+func (b *retryIDMappingBackend) getSeriesID(metricID uint32, tagsHash uint64) (seriesID uint32, err error) {
+	err = b.retry("getSeriesID", func() (err error) {
+		seriesID, err = b.IDMappingBackend.getSeriesID(metricID, tagsHash)
+		return err
+	})
+	return seriesID, err
+}
+
+func (b *retryIDMappingBackend) loadSeriesIDs(idMapping MetricIDMapping) error {
+	return b.retry("loadSeriesIDs", func() error {
+		return b.IDMappingBackend.loadSeriesIDs(idMapping)
+	})
+}
+
+func (b *retryIDMappingBackend) saveMapping(event *mappingEvent) error {
+	return b.retry("saveMapping", func() error {
+		return b.IDMappingBackend.saveMapping(event)
+	})
+}
+
+func (b *retryIDMappingBackend) removeSeriesIDs(metricID uint32, seriesIDs *roaring.Bitmap) error {
+	return b.retry("removeSeriesIDs", func() error {
+		return b.IDMappingBackend.removeSeriesIDs(metricID, seriesIDs)
+	})
+}
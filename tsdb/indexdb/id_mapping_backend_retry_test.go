@@ -0,0 +1,100 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package indexdb
+
+import (
+	"fmt"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/lindb/roaring"
+	"github.com/stretchr/testify/assert"
+	"go.etcd.io/bbolt"
+)
+
+func TestIsTransientError(t *testing.T) {
+	assert.True(t, isTransientError(syscall.EAGAIN))
+	assert.True(t, isTransientError(fmt.Errorf("read page: %w", syscall.EINTR)))
+	assert.True(t, isTransientError(bbolt.ErrTimeout))
+	assert.False(t, isTransientError(bbolt.ErrChecksum))
+	assert.False(t, isTransientError(bbolt.ErrInvalid))
+	assert.False(t, isTransientError(fmt.Errorf("err")))
+}
+
+func TestRetryIDMappingBackend(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	var sleeps []time.Duration
+	defer func() {
+		sleepFunc = time.Sleep
+		ctrl.Finish()
+	}()
+	sleepFunc = func(d time.Duration) {
+		sleeps = append(sleeps, d)
+	}
+
+	backend := NewMockIDMappingBackend(ctrl)
+	b := newRetryIDMappingBackend(backend, "test", 2, time.Millisecond)
+
+	// case 1: transient failure succeeds on retry
+	gomock.InOrder(
+		backend.EXPECT().getSeriesID(uint32(1), uint64(2)).Return(uint32(0), syscall.EAGAIN),
+		backend.EXPECT().getSeriesID(uint32(1), uint64(2)).Return(uint32(3), nil),
+	)
+	seriesID, err := b.getSeriesID(1, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(3), seriesID)
+	assert.Equal(t, []time.Duration{time.Millisecond}, sleeps)
+
+	sleeps = nil
+	gomock.InOrder(
+		backend.EXPECT().loadSeriesIDs(gomock.Any()).Return(bbolt.ErrTimeout),
+		backend.EXPECT().loadSeriesIDs(gomock.Any()).Return(nil),
+	)
+	assert.NoError(t, b.loadSeriesIDs(newMetricIDMapping(1, 0)))
+	assert.Equal(t, []time.Duration{time.Millisecond}, sleeps)
+
+	// case 2: exceed max retries, backoff doubles
+	sleeps = nil
+	backend.EXPECT().saveMapping(gomock.Any()).Return(syscall.EBUSY).Times(3)
+	assert.Equal(t, syscall.EBUSY, b.saveMapping(newMappingEvent()))
+	assert.Equal(t, []time.Duration{time.Millisecond, 2 * time.Millisecond}, sleeps)
+
+	// case 3: permanent error fails fast
+	sleeps = nil
+	backend.EXPECT().loadMetricIDMapping(uint32(1)).Return(nil, bbolt.ErrChecksum)
+	_, err = b.loadMetricIDMapping(1)
+	assert.Equal(t, bbolt.ErrChecksum, err)
+	assert.Empty(t, sleeps)
+
+	// case 4: other operations
+	gomock.InOrder(
+		backend.EXPECT().loadMetricIDs().Return(nil, bbolt.ErrTimeout),
+		backend.EXPECT().loadMetricIDs().Return(roaring.BitmapOf(1), nil),
+	)
+	metricIDs, err := b.loadMetricIDs()
+	assert.NoError(t, err)
+	assert.Equal(t, []uint32{1}, metricIDs.ToArray())
+	backend.EXPECT().removeSeriesIDs(uint32(1), gomock.Any()).Return(nil)
+	assert.NoError(t, b.removeSeriesIDs(1, roaring.BitmapOf(1)))
+	backend.EXPECT().loadMetricIDMapping(uint32(2)).Return(newMetricIDMapping(2, 0), nil)
+	mapping, err := b.loadMetricIDMapping(2)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(2), mapping.GetMetricID())
+}
@@ -18,14 +18,18 @@
 package indexdb
 
 import (
+	"encoding/binary"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"path/filepath"
 	"testing"
 
+	"github.com/lindb/roaring"
 	"github.com/stretchr/testify/assert"
 	"go.etcd.io/bbolt"
 
+	"github.com/lindb/lindb/config"
 	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/pkg/fileutil"
 )
@@ -100,12 +104,28 @@ func TestIdMappingBackend_mapping(t *testing.T) {
 	mapping, err := backend.loadMetricIDMapping(30)
 	assert.True(t, errors.Is(err, constants.ErrNotFound))
 	assert.Nil(t, mapping)
-	// case 5: load mapping not exist
+	// case 5: load metric ids
+	metricIDs, err := backend.loadMetricIDs()
+	assert.NoError(t, err)
+	assert.Equal(t, []uint32{1, 2}, metricIDs.ToArray())
+	// case 6: load mapping not exist
 	mapping, err = backend.loadMetricIDMapping(2)
 	assert.NoError(t, err)
 	assert.Equal(t, uint32(2), mapping.GetMetricID())
 	mapping1 := mapping.(*metricIDMapping)
 	assert.Equal(t, uint32(300), mapping1.idSequence.Load())
+	// case 7: load all series ids of metric
+	assert.NoError(t, backend.loadSeriesIDs(mapping))
+	for tagsHash, expect := range map[uint64]uint32{10: 100, 30: 300, 50: 50} {
+		seriesID, ok := mapping.GetSeriesID(tagsHash)
+		assert.True(t, ok)
+		assert.Equal(t, expect, seriesID)
+	}
+	assert.True(t, errors.Is(backend.loadSeriesIDs(newMetricIDMapping(30, 0)), constants.ErrNotFound))
+	// case 8: count series by id sequences
+	count, err := backend.countSeries()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(500), count)
 
 	err = backend.Close()
 	assert.NoError(t, err)
@@ -119,6 +139,35 @@ func TestIdMappingBackend_mapping(t *testing.T) {
 	assert.Equal(t, uint32(300), mapping1.idSequence.Load())
 }
 
+func TestIdMappingBackend_removeSeriesIDs(t *testing.T) {
+	testPath := t.TempDir()
+	backend, err := newIDMappingBackend(testPath)
+	assert.NoError(t, err)
+	event := newMappingEvent()
+	for i := 1; i <= 10; i++ {
+		event.addSeriesID(1, uint64(i*10), uint32(i))
+	}
+	err = backend.saveMapping(event)
+	assert.NoError(t, err)
+	// metric not exist
+	err = backend.removeSeriesIDs(2, roaring.BitmapOf(1))
+	assert.NoError(t, err)
+
+	err = backend.removeSeriesIDs(1, roaring.BitmapOf(2, 3, 4, 10))
+	assert.NoError(t, err)
+	for i := 1; i <= 10; i++ {
+		seriesID, err := backend.getSeriesID(1, uint64(i*10))
+		if i == 1 || (i >= 5 && i <= 9) {
+			assert.NoError(t, err)
+			assert.Equal(t, uint32(i), seriesID)
+		} else {
+			assert.True(t, errors.Is(err, constants.ErrNotFound))
+		}
+	}
+	err = backend.Close()
+	assert.NoError(t, err)
+}
+
 func TestIdMappingBackend_save_err(t *testing.T) {
 	testPath := t.TempDir()
 	defer func() {
@@ -151,3 +200,117 @@ func TestIdMappingBackend_save_err(t *testing.T) {
 	err = backend.saveMapping(event)
 	assert.Error(t, err)
 }
+
+func TestIdMappingBackend_integrityCheck(t *testing.T) {
+	defer func() {
+		checkBackendFunc = checkBackend
+		config.SetGlobalStorageConfig(config.NewDefaultStorageBase())
+	}()
+	testPath := t.TempDir()
+	backend, err := newIDMappingBackend(testPath)
+	assert.NoError(t, err)
+	for i := 1; i <= 10; i++ {
+		event := newMappingEvent()
+		event.addSeriesID(uint32(i), uint64(i), uint32(i))
+		assert.NoError(t, backend.saveMapping(event))
+	}
+	assert.NoError(t, backend.Close())
+	cfg := config.NewDefaultStorageBase()
+	// case 1: check ok
+	cfg.TSDB.BackendIntegrityCheck = config.BackendIntegrityCheckRefuse
+	config.SetGlobalStorageConfig(cfg)
+	backend, err = newIDMappingBackend(testPath)
+	assert.NoError(t, err)
+	assert.NoError(t, backend.Close())
+
+	// case 2: corrupt file, make free page double freed
+	corruptFreelist(t, filepath.Join(testPath, MappingDB))
+	backend, err = newIDMappingBackend(testPath)
+	assert.True(t, errors.Is(err, ErrBackendCorrupted))
+	assert.Nil(t, backend)
+	// case 3: only warn corruption
+	testPath = t.TempDir()
+	cfg.TSDB.BackendIntegrityCheck = config.BackendIntegrityCheckWarn
+	checkBackendFunc = func(db *bbolt.DB) error {
+		return ErrBackendCorrupted
+	}
+	backend, err = newIDMappingBackend(testPath)
+	assert.NoError(t, err)
+	assert.NoError(t, backend.Close())
+	// case 4: check off
+	cfg.TSDB.BackendIntegrityCheck = config.BackendIntegrityCheckOff
+	checkBackendFunc = func(db *bbolt.DB) error {
+		panic("check backend")
+	}
+	backend, err = newIDMappingBackend(testPath)
+	assert.NoError(t, err)
+	assert.NoError(t, backend.Close())
+}
+
+// corruptFreelist duplicates the first free page id of freelist page in bbolt file.
+func corruptFreelist(t *testing.T, file string) {
+	db, err := bbolt.Open(file, 0600, nil)
+	assert.NoError(t, err)
+	pageSize := db.Info().PageSize
+	assert.NoError(t, db.Close())
+
+	data, err := ioutil.ReadFile(file)
+	assert.NoError(t, err)
+	// meta: page header(16 bytes) + magic/version/page size/flags(16 bytes) + root bucket(16 bytes)
+	// + freelist(8 bytes) + pgid(8 bytes) + txid(8 bytes), use the meta page with latest txid.
+	meta := 16
+	if binary.LittleEndian.Uint64(data[pageSize+16+48:]) > binary.LittleEndian.Uint64(data[16+48:]) {
+		meta += pageSize
+	}
+	freelistPage := binary.LittleEndian.Uint64(data[meta+32:])
+	offset := int(freelistPage) * pageSize
+	count := binary.LittleEndian.Uint16(data[offset+10:])
+	assert.True(t, count >= 2, "free pages count %d", count)
+	copy(data[offset+16+8:], data[offset+16:offset+16+8])
+	assert.NoError(t, ioutil.WriteFile(file, data, 0600))
+}
+
+func TestIdMappingBackend_bloomFilter(t *testing.T) {
+	testPath := t.TempDir()
+	backend, err := newIDMappingBackend(testPath)
+	assert.NoError(t, err)
+	// add series more than init capacity of bloom filter
+	event := newMappingEvent()
+	for i := 1; i <= 3*bloomFilterInitCapacity; i++ {
+		event.addSeriesID(1, uint64(i), uint32(i))
+	}
+	assert.NoError(t, backend.saveMapping(event))
+	negative := bloomNegativeCounter.Get()
+	_, err = backend.getSeriesID(1, 0)
+	assert.True(t, errors.Is(err, constants.ErrSeriesIDNotFound))
+	_, err = backend.getSeriesID(1, uint64(100*bloomFilterInitCapacity))
+	assert.True(t, errors.Is(err, constants.ErrSeriesIDNotFound))
+	assert.True(t, bloomNegativeCounter.Get() > negative)
+	assert.NoError(t, backend.Close())
+
+	// case 1: re-open, load bloom filter, no false negative
+	backend, err = newIDMappingBackend(testPath)
+	assert.NoError(t, err)
+	for i := 1; i <= 3*bloomFilterInitCapacity; i++ {
+		seriesID, err := backend.getSeriesID(1, uint64(i))
+		assert.NoError(t, err)
+		assert.Equal(t, uint32(i), seriesID)
+	}
+	assert.NoError(t, backend.Close())
+
+	// case 2: bloom filter not exist(created by old version), rebuild it
+	db, err := bbolt.Open(filepath.Join(testPath, MappingDB), 0600, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, db.Update(func(tx *bbolt.Tx) error {
+		return tx.DeleteBucket(bloomBucketName)
+	}))
+	assert.NoError(t, db.Close())
+	backend, err = newIDMappingBackend(testPath)
+	assert.NoError(t, err)
+	for i := 1; i <= 3*bloomFilterInitCapacity; i++ {
+		seriesID, err := backend.getSeriesID(1, uint64(i))
+		assert.NoError(t, err)
+		assert.Equal(t, uint32(i), seriesID)
+	}
+	assert.NoError(t, backend.Close())
+}
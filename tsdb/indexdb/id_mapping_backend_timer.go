@@ -0,0 +1,83 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package indexdb
+
+import (
+	"time"
+
+	"github.com/lindb/roaring"
+
+	"github.com/lindb/lindb/internal/linmetric"
+)
+
+var backendOpTimerVec = indexDBScope.Scope("backend_op_duration").NewHistogramVec("db", "op")
+
+// timedIDMappingBackend records the latency of each backend storage operation.
+type timedIDMappingBackend struct {
+	IDMappingBackend
+
+	loadMetricIDMappingTimer *linmetric.BoundHistogram
+	loadMetricIDsTimer       *linmetric.BoundHistogram
+	getSeriesIDTimer         *linmetric.BoundHistogram
+	loadSeriesIDsTimer       *linmetric.BoundHistogram
+	saveMappingTimer         *linmetric.BoundHistogram
+	removeSeriesIDsTimer     *linmetric.BoundHistogram
+}
+
+// newTimedIDMappingBackend wraps the backend storage with latency metrics of database.
+func newTimedIDMappingBackend(backend IDMappingBackend, databaseName string) IDMappingBackend {
+	return &timedIDMappingBackend{
+		IDMappingBackend:         backend,
+		loadMetricIDMappingTimer: backendOpTimerVec.WithTagValues(databaseName, "loadMetricIDMapping"),
+		loadMetricIDsTimer:       backendOpTimerVec.WithTagValues(databaseName, "loadMetricIDs"),
+		getSeriesIDTimer:         backendOpTimerVec.WithTagValues(databaseName, "getSeriesID"),
+		loadSeriesIDsTimer:       backendOpTimerVec.WithTagValues(databaseName, "loadSeriesIDs"),
+		saveMappingTimer:         backendOpTimerVec.WithTagValues(databaseName, "saveMapping"),
+		removeSeriesIDsTimer:     backendOpTimerVec.WithTagValues(databaseName, "removeSeriesIDs"),
+	}
+}
+
+func (b *timedIDMappingBackend) loadMetricIDMapping(metricID uint32) (MetricIDMapping, error) {
+	defer b.loadMetricIDMappingTimer.UpdateSince(time.Now())
+	return b.IDMappingBackend.loadMetricIDMapping(metricID)
+}
+
+func (b *timedIDMappingBackend) loadMetricIDs() (*roaring.Bitmap, error) {
+	defer b.loadMetricIDsTimer.UpdateSince(time.Now())
+	return b.IDMappingBackend.loadMetricIDs()
+}
+
+func (b *timedIDMappingBackend) getSeriesID(metricID uint32, tagsHash uint64) (uint32, error) {
+	defer b.getSeriesIDTimer.UpdateSince(time.Now())
+	return b.IDMappingBackend.getSeriesID(metricID, tagsHash)
+}
+
+func (b *timedIDMappingBackend) loadSeriesIDs(idMapping MetricIDMapping) error {
+	defer b.loadSeriesIDsTimer.UpdateSince(time.Now())
+	return b.IDMappingBackend.loadSeriesIDs(idMapping)
+}
+
+func (b *timedIDMappingBackend) saveMapping(event *mappingEvent) error {
+	defer b.saveMappingTimer.UpdateSince(time.Now())
+	return b.IDMappingBackend.saveMapping(event)
+}
+
+func (b *timedIDMappingBackend) removeSeriesIDs(metricID uint32, seriesIDs *roaring.Bitmap) error {
+	defer b.removeSeriesIDsTimer.UpdateSince(time.Now())
+	return b.IDMappingBackend.removeSeriesIDs(metricID, seriesIDs)
+}
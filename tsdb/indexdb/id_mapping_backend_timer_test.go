@@ -0,0 +1,61 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package indexdb
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/lindb/roaring"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimedIDMappingBackend(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	backend := NewMockIDMappingBackend(ctrl)
+	timed := newTimedIDMappingBackend(backend, "test").(*timedIDMappingBackend)
+
+	backend.EXPECT().loadMetricIDMapping(uint32(1)).Return(nil, fmt.Errorf("err"))
+	_, err := timed.loadMetricIDMapping(1)
+	assert.Error(t, err)
+
+	backend.EXPECT().loadMetricIDs().Return(roaring.BitmapOf(1), nil)
+	metricIDs, err := timed.loadMetricIDs()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), metricIDs.GetCardinality())
+
+	backend.EXPECT().getSeriesID(uint32(1), uint64(2)).Return(uint32(3), nil)
+	seriesID, err := timed.getSeriesID(1, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(3), seriesID)
+
+	backend.EXPECT().loadSeriesIDs(gomock.Any()).Return(nil)
+	assert.NoError(t, timed.loadSeriesIDs(newMetricIDMapping(1, 0)))
+
+	backend.EXPECT().saveMapping(gomock.Any()).Return(nil)
+	assert.NoError(t, timed.saveMapping(newMappingEvent()))
+
+	backend.EXPECT().removeSeriesIDs(uint32(1), gomock.Any()).Return(nil)
+	assert.NoError(t, timed.removeSeriesIDs(1, roaring.BitmapOf(1)))
+
+	backend.EXPECT().Close().Return(nil)
+	assert.NoError(t, timed.Close())
+}
@@ -20,10 +20,14 @@ package indexdb
 import (
 	"context"
 	"errors"
+	"fmt"
 	"path/filepath"
 	"sync"
 	"time"
 
+	"go.uber.org/atomic"
+
+	"github.com/lindb/lindb/config"
 	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/internal/linmetric"
 	"github.com/lindb/lindb/kv"
@@ -31,6 +35,7 @@ import (
 	"github.com/lindb/lindb/pkg/timeutil"
 	"github.com/lindb/lindb/series"
 	"github.com/lindb/lindb/series/metric"
+	"github.com/lindb/lindb/series/tag"
 	"github.com/lindb/lindb/tsdb/metadb"
 	"github.com/lindb/lindb/tsdb/wal"
 
@@ -44,9 +49,20 @@ var (
 )
 
 var (
-	indexDBScope                 = linmetric.NewScope("lindb.tsdb.indexdb")
-	buildInvertedIndexCounterVec = indexDBScope.NewCounterVec("build_inverted_index_counter", "db")
-	recoverySeriesWALTimerVec    = indexDBScope.Scope("recovery_series_wal_duration").NewHistogramVec("db")
+	indexDBScope                    = linmetric.NewScope("lindb.tsdb.indexdb")
+	buildInvertedIndexCounterVec    = indexDBScope.NewCounterVec("build_inverted_index_counter", "db")
+	recoverySeriesWALTimerVec       = indexDBScope.Scope("recovery_series_wal_duration").NewHistogramVec("db")
+	tombstoneSeriesVec              = indexDBScope.NewGaugeVec("tombstone_series", "db")
+	pendingPurgeSeriesVec           = indexDBScope.NewGaugeVec("pending_purge_series", "db")
+	purgedSeriesCounterVec          = indexDBScope.NewCounterVec("purged_series", "db")
+	purgeSeriesFailCounterVec       = indexDBScope.NewCounterVec("purge_series_fails", "db")
+	recoverySeriesWALFailCounterVec = indexDBScope.NewCounterVec("recovery_series_wal_fails", "db")
+	seriesWALPendingBytesVec        = indexDBScope.NewGaugeVec("series_wal_pending_bytes", "db")
+	seriesCountVec                  = indexDBScope.NewGaugeVec("series_count", "db")
+	seriesGrowthRateVec             = indexDBScope.NewGaugeVec("series_growth_rate", "db")
+	estimatedTagValuesVec           = indexDBScope.NewGaugeVec("estimated_tag_values", "db")
+	staleTagKeysCounterVec          = indexDBScope.NewCounterVec("stale_tag_keys_served", "db")
+	backgroundGoroutinesVec         = indexDBScope.NewGaugeVec("background_goroutines", "db")
 )
 
 const (
@@ -67,6 +83,9 @@ type indexDatabase struct {
 	backend          IDMappingBackend           // id mapping backend storage
 	metricID2Mapping map[uint32]MetricIDMapping // key: metric id, value: metric id mapping
 	metadata         metadb.Metadata            // the metadata for generating ID of metric, field
+	// metric ids exist in backend storage after recovery, nil if unknown.
+	// metric not in it and not cached must be new, so that no need to load it from backend storage.
+	backendMetricIDs *roaring.Bitmap
 
 	// 倒排索引
 	index InvertedIndex
@@ -74,7 +93,32 @@ type indexDatabase struct {
 	// WAL 日志
 	seriesWAL wal.SeriesWAL
 
-	syncInterval int64
+	tombstone *tombstone // deleted series ids
+
+	// tag keys of metric got from metadata last time, used for best-effort result when metadata is unavailable.
+	tagKeysCache        *tagKeysCache
+	maxTagKeysStaleness int64 // max age of cached tag keys(ms)
+
+	syncInterval    int64
+	syncConcurrency int          // concurrency of saving series wal into backend
+	syncFailures    int          // consecutive failures of syncing series wal
+	pendingSize     int64        // pending size of series wal reported last time
+	lastSyncTime    atomic.Int64 // timestamp(ms) of last successful series wal sync
+
+	seriesReportInterval int64   // interval(ms) of reporting series count/growth rate
+	lastSeriesReportTime int64   // timestamp(ms) of reporting series count last time
+	numOfSeries          int64   // series count reported last time
+	seriesGrowthRate     float64 // series growth rate(per second) reported last time
+	estimatedTagValues   uint64  // sum of estimated tag values of all tag keys reported last time
+
+	warmupTopN     int               // number of hot metrics to persist/warmup, 0 if disabled
+	metricAccesses map[uint32]uint64 // write accesses of metric, key: metric id, nil if warmup disabled
+	purgeSeq       uint64            // num. of tombstone purges, warmup drops the mapping loaded during purge
+	warmupWG       sync.WaitGroup
+	syncWG         sync.WaitGroup // wait group of checkSync goroutine
+
+	closeOnce sync.Once
+	closeErr  error // result of first close, returned by repeated close
 
 	rwMutex sync.RWMutex // lock of create metric index
 }
@@ -92,7 +136,10 @@ func NewIndexDatabase(
 	error,
 ) {
 
-	var err error
+	var (
+		err    error
+		cancel context.CancelFunc
+	)
 
 	// 创建 boltdb 数据库
 	backend, err := createBackend(parent)
@@ -102,6 +149,9 @@ func NewIndexDatabase(
 	defer func() {
 		// if init index database err, need close backend
 		if err != nil {
+			if cancel != nil {
+				cancel()
+			}
 			if err1 := backend.Close(); err1 != nil {
 				indexLogger.Info("close series id mapping backend error when init index database", logger.String("db", parent), logger.Error(err))
 			}
@@ -113,8 +163,19 @@ func NewIndexDatabase(
 	if err != nil {
 		return nil, err
 	}
+	tombstone, err := newTombstone(parent)
+	if err != nil {
+		return nil, err
+	}
+
+	dbName := metadata.DatabaseName()
+	tsdbCfg := config.GlobalStorageConfig().TSDB
+	backend = newTimedIDMappingBackend(
+		newRetryIDMappingBackend(backend, dbName, tsdbCfg.BackendMaxRetries, tsdbCfg.BackendRetryBackoff.Duration()),
+		dbName)
 
-	c, cancel := context.WithCancel(ctx)
+	var c context.Context
+	c, cancel = context.WithCancel(ctx)
 	db := &indexDatabase{
 		path:    parent,
 		ctx:     c,
@@ -130,13 +191,26 @@ func NewIndexDatabase(
 		// 索引
 		index: newInvertedIndex(metadata, forwardFamily, invertedFamily),
 
-		seriesWAL:    seriesWAL,
-		syncInterval: syncInterval,
+		seriesWAL:       seriesWAL,
+		tombstone:       tombstone,
+		syncInterval:    syncInterval,
+		syncConcurrency: tsdbCfg.SeriesSyncConcurrency,
+
+		tagKeysCache:         newTagKeysCache(tsdbCfg.MaxCachedTagKeys),
+		maxTagKeysStaleness:  tsdbCfg.MaxTagKeysStaleness.Duration().Milliseconds(),
+		seriesReportInterval: tsdbCfg.SeriesReportInterval.Duration().Milliseconds(),
+		warmupTopN:           tsdbCfg.WarmupTopN,
+	}
+	if db.warmupTopN > 0 {
+		db.metricAccesses = make(map[uint32]uint64)
 	}
 
 	// series recovery
 	// 执行 recovery 将 wal 中数据同步到 boltdb 。
-	db.seriesRecovery()
+	if recoveryErr := db.seriesRecovery(); recoveryErr != nil {
+		err = fmt.Errorf("%w, %w", ErrNeedRecoveryWAL, recoveryErr)
+		return nil, err
+	}
 
 	// if recovery series wal fail, need return err
 	// 执行 recovery 失败，报错
@@ -145,7 +219,20 @@ func NewIndexDatabase(
 		return nil, err
 	}
 
+	// load metric ids after recovery, fallback to load metric id mapping from backend storage if fail
+	if metricIDs, loadErr := backend.loadMetricIDs(); loadErr != nil {
+		indexLogger.Warn("load metric ids from series id mapping backend error",
+			logger.String("db", parent), logger.Error(loadErr))
+	} else {
+		db.backendMetricIDs = metricIDs
+	}
+
+	if db.warmupTopN > 0 {
+		db.startWarmup()
+	}
+
 	// 启动定时任务，定时将 wal 同步到 boltdb 。
+	db.syncWG.Add(1)
 	go db.checkSync()
 
 	return db, nil
@@ -170,6 +257,8 @@ func (db *indexDatabase) GetOrCreateSeriesID(metricID uint32, tagsHash uint64,
 	db.rwMutex.Lock()
 	defer db.rwMutex.Unlock()
 
+	db.accessMetric(metricID, 1)
+
 	// 从缓存中查询 metricId 的 mapping
 	metricIDMapping, ok := db.metricID2Mapping[metricID]
 	if ok {
@@ -179,6 +268,11 @@ func (db *indexDatabase) GetOrCreateSeriesID(metricID uint32, tagsHash uint64,
 		if ok {
 			return seriesID, false, nil
 		}
+	} else if db.backendMetricIDs != nil && !db.backendMetricIDs.Contains(metricID) {
+		// new metric which not exist in backend storage, skip loading from backend storage
+		metricIDMapping = newMetricIDMapping(metricID, 0)
+		// cache metric id mapping
+		db.metricID2Mapping[metricID] = metricIDMapping
 	} else {
 		// metric mapping not exist, need load from backend storage
 		// 从磁盘 boltdb 中查询 metricId 的 mapping
@@ -232,33 +326,169 @@ func (db *indexDatabase) GetOrCreateSeriesID(metricID uint32, tagsHash uint64,
 }
 
 // GetSeriesIDsByTagValueIDs gets series ids by tag value ids for spec metric's tag key
-func (db *indexDatabase) GetSeriesIDsByTagValueIDs(tagKeyID uint32, tagValueIDs *roaring.Bitmap) (*roaring.Bitmap, error) {
-	return db.index.GetSeriesIDsByTagValueIDs(tagKeyID, tagValueIDs)
+func (db *indexDatabase) GetSeriesIDsByTagValueIDs(
+	tagKeyID uint32,
+	tagValueIDs *roaring.Bitmap,
+	budget *series.BitmapBudget,
+) (*roaring.Bitmap, error) {
+	seriesIDs, err := db.index.GetSeriesIDsByTagValueIDs(tagKeyID, tagValueIDs, budget)
+	if err != nil || db.tombstone.isEmpty() {
+		return seriesIDs, err
+	}
+	size := seriesIDs.GetSizeInBytes()
+	seriesIDs = db.tombstone.filterByTagKey(tagKeyID, seriesIDs)
+	if filteredSize := seriesIDs.GetSizeInBytes(); filteredSize < size {
+		budget.Release(size - filteredSize)
+	}
+	return seriesIDs, nil
 }
 
 // GetSeriesIDsForTag gets series ids for spec metric's tag key
 func (db *indexDatabase) GetSeriesIDsForTag(tagKeyID uint32) (*roaring.Bitmap, error) {
-	return db.index.GetSeriesIDsForTag(tagKeyID)
+	seriesIDs, err := db.index.GetSeriesIDsForTag(tagKeyID)
+	if err != nil || db.tombstone.isEmpty() {
+		return seriesIDs, err
+	}
+	return db.tombstone.filterByTagKey(tagKeyID, seriesIDs), nil
 }
 
-// GetSeriesIDsForMetric gets series ids for spec metric name
+// GetSeriesIDsForMetric gets series ids for spec metric name,
+// if metadata is unavailable, returns the best-effort result based on cached tag keys with constants.ErrStaleResult.
 func (db *indexDatabase) GetSeriesIDsForMetric(namespace, metricName string) (*roaring.Bitmap, error) {
 	// get all tags under metric
-	tags, err := db.metadata.MetadataDatabase().GetAllTagKeys(namespace, metricName)
-	if err != nil {
-		return nil, err
+	tags, staleErr := db.getAllTagKeys(namespace, metricName)
+	if staleErr != nil && !errors.Is(staleErr, constants.ErrStaleResult) {
+		return nil, staleErr
 	}
 	tagLength := len(tags)
 	if tagLength == 0 {
 		// if metric hasn't any tags, returns default series id(0)
-		return roaring.BitmapOf(constants.SeriesIDWithoutTags), nil
+		return roaring.BitmapOf(constants.SeriesIDWithoutTags), staleErr
 	}
 	tagKeyIDs := make([]uint32, tagLength)
 	for idx, tag := range tags {
 		tagKeyIDs[idx] = tag.ID
 	}
 	// get series ids under all tag key ids
-	return db.index.GetSeriesIDsForTags(tagKeyIDs)
+	seriesIDs, err := db.index.GetSeriesIDsForTags(tagKeyIDs)
+	if err != nil {
+		return nil, err
+	}
+	if db.tombstone.isEmpty() {
+		return seriesIDs, staleErr
+	}
+	metricID, staleErr := db.getMetricID(namespace, metricName, staleErr)
+	if staleErr != nil && !errors.Is(staleErr, constants.ErrStaleResult) {
+		return nil, staleErr
+	}
+	return db.tombstone.filterByMetric(metricID, seriesIDs), staleErr
+}
+
+// getMetricID returns the metric id from metadata, caches it with tag keys for metadata unavailable.
+// If metadata fails, returns cached metric id if tag keys are served from cache(staleErr != nil) or still fresh.
+func (db *indexDatabase) getMetricID(namespace, metricName string, staleErr error) (uint32, error) {
+	key := namespace + "/" + metricName
+	metricID, err := db.metadata.MetadataDatabase().GetMetricID(namespace, metricName)
+	if err == nil {
+		db.tagKeysCache.putMetricID(key, metricID)
+		return metricID, staleErr
+	}
+	if errors.Is(err, constants.ErrNotFound) {
+		return 0, err
+	}
+	cached, ok := db.tagKeysCache.get(key)
+	if !ok || !cached.hasMetricID || timeutil.Now()-cached.cachedAt > db.maxTagKeysStaleness {
+		return 0, err
+	}
+	if staleErr != nil {
+		return cached.metricID, staleErr
+	}
+	staleTagKeysCounterVec.WithTagValues(db.metadata.DatabaseName()).Incr()
+	return cached.metricID, fmt.Errorf("%w, metadata err: %s", constants.ErrStaleResult, err)
+}
+
+// getAllTagKeys returns all tag keys of metric from metadata, caches them for metadata unavailable.
+// If metadata fails(except not found), returns cached tag keys not older than max staleness with constants.ErrStaleResult.
+func (db *indexDatabase) getAllTagKeys(namespace, metricName string) ([]tag.Meta, error) {
+	key := namespace + "/" + metricName
+	tags, err := db.metadata.MetadataDatabase().GetAllTagKeys(namespace, metricName)
+	if err == nil {
+		db.tagKeysCache.putTagKeys(key, tags)
+		return tags, nil
+	}
+	if errors.Is(err, constants.ErrNotFound) {
+		db.tagKeysCache.remove(key)
+		return nil, err
+	}
+	cached, ok := db.tagKeysCache.get(key)
+	if !ok {
+		return nil, err
+	}
+	if timeutil.Now()-cached.cachedAt > db.maxTagKeysStaleness {
+		return nil, err
+	}
+	staleTagKeysCounterVec.WithTagValues(db.metadata.DatabaseName()).Incr()
+	indexLogger.Warn("get tag keys from metadata failure, use cached tag keys",
+		logger.String("db", db.path), logger.String("namespace", namespace),
+		logger.String("metric", metricName), logger.Error(err))
+	return cached.tags, fmt.Errorf("%w, metadata err: %s", constants.ErrStaleResult, err)
+}
+
+// DeleteSeriesByTagValueIDs marks series ids of spec metric's tag values as tombstone,
+// tombstoned series ids are invisible for query immediately, then purged in background.
+func (db *indexDatabase) DeleteSeriesByTagValueIDs(
+	namespace, metricName string,
+	tagKeyID uint32,
+	tagValueIDs *roaring.Bitmap,
+) error {
+	metadataDB := db.metadata.MetadataDatabase()
+	metricID, err := metadataDB.GetMetricID(namespace, metricName)
+	if err != nil {
+		return err
+	}
+	tags, err := metadataDB.GetAllTagKeys(namespace, metricName)
+	if err != nil {
+		return err
+	}
+	tagKeyIDs := make([]uint32, len(tags))
+	for idx, tag := range tags {
+		tagKeyIDs[idx] = tag.ID
+	}
+	seriesIDs, err := db.index.GetSeriesIDsByTagValueIDs(tagKeyID, tagValueIDs, nil)
+	if err != nil {
+		return err
+	}
+	if seriesIDs.IsEmpty() {
+		return nil
+	}
+	if err := db.tombstone.add(metricID, tagKeyIDs, seriesIDs); err != nil {
+		return err
+	}
+	// cache metric id with tag keys, so that tombstone can be applied when metadata is unavailable
+	key := namespace + "/" + metricName
+	db.tagKeysCache.putTagKeys(key, tags)
+	db.tagKeysCache.putMetricID(key, metricID)
+	db.updateTombstoneStats()
+	return nil
+}
+
+// AllMetricIDs returns all metric ids in index database, includes cached in memory and stored in backend storage.
+func (db *indexDatabase) AllMetricIDs() (*roaring.Bitmap, error) {
+	// copy cached metric ids first, metric created after it will be saved into backend storage later.
+	db.rwMutex.RLock()
+	cachedMetricIDs := make([]uint32, 0, len(db.metricID2Mapping))
+	for metricID := range db.metricID2Mapping {
+		cachedMetricIDs = append(cachedMetricIDs, metricID)
+	}
+	db.rwMutex.RUnlock()
+
+	// iterate metric buckets of backend storage by cursor
+	metricIDs, err := db.backend.loadMetricIDs()
+	if err != nil {
+		return nil, err
+	}
+	metricIDs.AddMany(cachedMetricIDs)
+	return metricIDs, nil
 }
 
 // BuildInvertIndex builds the inverted index for tag value => series ids,
@@ -287,63 +517,244 @@ func (db *indexDatabase) Flush() error {
 
 // Close closes the database, releases the resources
 func (db *indexDatabase) Close() error {
+	// close may be called more than once during shutdown, only release resources once
+	db.closeOnce.Do(func() {
+		db.closeErr = db.close()
+	})
+	return db.closeErr
+}
+
+// close cancels background goroutines, closes wal/backend storage and flushes inverted index
+func (db *indexDatabase) close() error {
 	db.cancel()
+	// wait warmup/checkSync stopped before closing backend storage
+	db.warmupWG.Wait()
+	db.syncWG.Wait()
 	db.rwMutex.Lock()
 	defer db.rwMutex.Unlock()
 
+	markSyncHealth(db.path, "")
+	// remove the pending size of series wal reported by this index database from the shared gauge
+	db.reportPendingSize(0)
+	db.removeCardinality()
+	if db.warmupTopN > 0 {
+		if err := db.saveHotMetrics(); err != nil {
+			indexLogger.Error("save hot metrics err when close index database", logger.String("db", db.path), logger.Error(err))
+		}
+	}
+
+	// sync pending series into backend, so that no series wal need recovery when reopening
+	if err := db.seriesWAL.Rotate(); err != nil {
+		indexLogger.Error("rotate series wal err when close index database", logger.String("db", db.path), logger.Error(err))
+	}
+	if db.seriesWAL.NeedRecovery() {
+		if err := db.seriesRecovery(); err != nil {
+			indexLogger.Error("sync series wal err when close index database", logger.String("db", db.path), logger.Error(err))
+		}
+	}
 	if err := db.seriesWAL.Close(); err != nil {
 		indexLogger.Error("sync series wal err when close index database", logger.String("db", db.path), logger.Error(err))
 	}
 	if err := db.backend.Close(); err != nil {
 		return err
 	}
-	return db.index.Flush()
+	return db.index.FlushAll()
 }
 
 // checkSync checks if need sync pending series event in period
 func (db *indexDatabase) checkSync() {
+	goroutines := backgroundGoroutinesVec.WithTagValues(db.metadata.DatabaseName())
+	goroutines.Incr()
+	defer func() {
+		goroutines.Decr()
+		db.syncWG.Done()
+	}()
+
 	ticker := time.NewTicker(time.Duration(db.syncInterval * 1000000))
 	for {
 		select {
 		case <-ticker.C:
 			if db.seriesWAL.NeedRecovery() {
-				db.seriesRecovery()
+				if err := db.seriesRecovery(); err != nil {
+					indexLogger.Error("sync series wal err, retry next round",
+						logger.String("db", db.path), logger.Error(err))
+				}
+			}
+			db.checkSyncHealth()
+			db.reportCardinality(timeutil.Now())
+			if err := db.index.evictIdleTagKeys(timeutil.Now()); err != nil {
+				indexLogger.Error("evict idle tag keys of inverted index err",
+					logger.String("db", db.path), logger.Error(err))
+			}
+			// purge deleted series after series wal sync, make sure mapping not be overwritten by recovery
+			if !db.seriesWAL.NeedRecovery() {
+				db.purgeTombstone()
 			}
 		case <-db.ctx.Done():
 			ticker.Stop()
+			markSyncHealth(db.path, "")
 			indexLogger.Info("received ctx.Done(), stopped checkSync", logger.String("db", db.path))
 			return
 		}
 	}
 }
 
-// seriesRecovery recovers series wal data
+// seriesRecovery recovers series wal data, returns the error of saving id mapping if fail.
 //
 // 解析 wal 将新数据同步到 boltdb 。
-func (db *indexDatabase) seriesRecovery() {
+func (db *indexDatabase) seriesRecovery() error {
 
 	startTime := time.Now()
 	defer recoverySeriesWALTimerVec.WithTagValues(db.metadata.DatabaseName()).UpdateSince(startTime)
 
-	event := newMappingEvent()
+	syncer := newSeriesSyncer(db.backend, db.syncConcurrency)
+	defer syncer.close()
 
-	db.seriesWAL.Recovery(func(metricID uint32, tagsHash uint64, seriesID uint32) error {
-		event.addSeriesID(metricID, tagsHash, seriesID)
-		if event.isFull() {
-			// 保存到 boltdb
-			if err := db.backend.saveMapping(event); err != nil {
-				return err
-			}
-			// 重置
-			event = newMappingEvent()
-		}
-		return nil
-	}, func() error {
-		if !event.isEmpty() {
-			if err := db.backend.saveMapping(event); err != nil {
-				return err
-			}
-		}
-		return nil
-	})
+	db.seriesWAL.Recovery(syncer.add, syncer.flush)
+
+	if err := syncer.getErr(); err != nil {
+		recoverySeriesWALFailCounterVec.WithTagValues(db.metadata.DatabaseName()).Incr()
+		db.syncFailures++
+		return fmt.Errorf("save series id mapping err: %w", err)
+	}
+	db.syncFailures = 0
+	db.lastSyncTime.Store(timeutil.Now())
+	return nil
+}
+
+// RecoveryStatus returns the recovery status of series wal
+func (db *indexDatabase) RecoveryStatus() RecoveryStatus {
+	// pending entries of current page are appended under lock
+	db.rwMutex.RLock()
+	defer db.rwMutex.RUnlock()
+
+	return RecoveryStatus{
+		NeedRecovery:   db.seriesWAL.NeedRecovery(),
+		PendingEntries: db.seriesWAL.PendingEntries(),
+		LastSyncTime:   db.lastSyncTime.Load(),
+	}
+}
+
+// checkSyncHealth reports the backlog of series wal, marks index database degraded
+// if backlog exceeds the threshold or sync fails consecutively.
+func (db *indexDatabase) checkSyncHealth() {
+	pendingSize := db.seriesWAL.PendingSize()
+	db.reportPendingSize(pendingSize)
+
+	reason := ""
+	maxBacklog := int64(config.GlobalStorageConfig().TSDB.MaxSeriesWALBacklog)
+	switch {
+	case db.syncFailures >= maxSyncFailures:
+		reason = fmt.Sprintf("series wal sync failed %d times consecutively", db.syncFailures)
+	case maxBacklog > 0 && pendingSize > maxBacklog:
+		reason = fmt.Sprintf("series wal backlog %d bytes exceeds %d bytes", pendingSize, maxBacklog)
+	}
+	markSyncHealth(db.path, reason)
+}
+
+// reportPendingSize updates the pending size gauge of series wal,
+// uses delta because index databases of all shards in one database share the gauge.
+func (db *indexDatabase) reportPendingSize(pendingSize int64) {
+	dbName := db.metadata.DatabaseName()
+	seriesWALPendingBytesVec.WithTagValues(dbName).Add(float64(pendingSize - db.pendingSize))
+	db.pendingSize = pendingSize
+}
+
+// reportCardinality reports series count/growth rate and estimated tag values in period,
+// uses delta because index databases of all shards in one database share the gauges.
+func (db *indexDatabase) reportCardinality(now int64) {
+	if now-db.lastSeriesReportTime < db.seriesReportInterval {
+		return
+	}
+	db.reportSeriesGrowth(now)
+	db.reportEstimatedTagValues()
+}
+
+// reportSeriesGrowth reports the series count and growth rate(series per second) since last report,
+// series are counted by id sequences of all metrics in backend storage, because id mappings are cached lazily.
+func (db *indexDatabase) reportSeriesGrowth(now int64) {
+	count, err := db.backend.countSeries()
+	if err != nil {
+		indexLogger.Error("count series of backend storage err",
+			logger.String("db", db.path), logger.Error(err))
+		return
+	}
+	numOfSeries := int64(count)
+
+	dbName := db.metadata.DatabaseName()
+	seriesCountVec.WithTagValues(dbName).Add(float64(numOfSeries - db.numOfSeries))
+	if db.lastSeriesReportTime > 0 {
+		growthRate := float64(numOfSeries-db.numOfSeries) * 1000 / float64(now-db.lastSeriesReportTime)
+		seriesGrowthRateVec.WithTagValues(dbName).Add(growthRate - db.seriesGrowthRate)
+		db.seriesGrowthRate = growthRate
+	}
+	db.numOfSeries = numOfSeries
+	db.lastSeriesReportTime = now
+}
+
+// removeCardinality removes the series count/growth rate and estimated tag values
+// reported by this index database from the shared gauges.
+func (db *indexDatabase) removeCardinality() {
+	dbName := db.metadata.DatabaseName()
+	seriesCountVec.WithTagValues(dbName).Add(-float64(db.numOfSeries))
+	seriesGrowthRateVec.WithTagValues(dbName).Add(-db.seriesGrowthRate)
+	estimatedTagValuesVec.WithTagValues(dbName).Add(-float64(db.estimatedTagValues))
+	db.numOfSeries = 0
+	db.seriesGrowthRate = 0
+	db.estimatedTagValues = 0
+}
+
+// reportEstimatedTagValues reports the sum of estimated distinct tag values of all tag keys,
+// gauge is not tagged by tag key, so that the number of metric series is bounded.
+func (db *indexDatabase) reportEstimatedTagValues() {
+	var total uint64
+	for _, count := range db.index.estimateAllTagValues() {
+		total += count
+	}
+	estimatedTagValuesVec.WithTagValues(db.metadata.DatabaseName()).
+		Add(float64(total) - float64(db.estimatedTagValues))
+	db.estimatedTagValues = total
+}
+
+// EstimateTagValues returns the estimated number of distinct tag values of tag key,
+// returns false if estimation is disabled, no series of tag key created since opened
+// or tag key exceeds the estimation limit.
+func (db *indexDatabase) EstimateTagValues(tagKeyID uint32) (count uint64, ok bool) {
+	return db.index.EstimateTagValues(tagKeyID)
+}
+
+// purgeTombstone purges deleted series ids of one metric from id mapping/memory index in each round.
+func (db *indexDatabase) purgeTombstone() {
+	metricID, tagKeyIDs, seriesIDs, ok := db.tombstone.nextPurge()
+	if !ok {
+		return
+	}
+	dbName := db.metadata.DatabaseName()
+	db.rwMutex.Lock()
+	db.purgeSeq++
+	if metricIDMapping, ok := db.metricID2Mapping[metricID]; ok {
+		metricIDMapping.RemoveSeriesIDs(seriesIDs)
+	}
+	err := db.backend.removeSeriesIDs(metricID, seriesIDs)
+	db.rwMutex.Unlock()
+	if err == nil {
+		db.index.removeSeriesIDs(tagKeyIDs, seriesIDs)
+		err = db.tombstone.markPurged(metricID, seriesIDs)
+	}
+	if err != nil {
+		purgeSeriesFailCounterVec.WithTagValues(dbName).Incr()
+		indexLogger.Error("purge deleted series err",
+			logger.String("db", db.path), logger.Any("metricID", metricID), logger.Error(err))
+		return
+	}
+	purgedSeriesCounterVec.WithTagValues(dbName).Add(float64(seriesIDs.GetCardinality()))
+	db.updateTombstoneStats()
+}
+
+// updateTombstoneStats updates the statistics of tombstone.
+func (db *indexDatabase) updateTombstoneStats() {
+	total, pending := db.tombstone.stats()
+	dbName := db.metadata.DatabaseName()
+	tombstoneSeriesVec.WithTagValues(dbName).Update(float64(total))
+	pendingPurgeSeriesVec.WithTagValues(dbName).Update(float64(pending))
 }
@@ -20,6 +20,7 @@ package indexdb
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"testing"
 	"time"
@@ -28,7 +29,9 @@ import (
 	"github.com/lindb/roaring"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/atomic"
+	"go.uber.org/goleak"
 
+	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/pkg/timeutil"
 	protoMetricsV1 "github.com/lindb/lindb/proto/gen/v1/metrics"
 	"github.com/lindb/lindb/series/metric"
@@ -95,6 +98,135 @@ func TestNewIndexDatabase_err(t *testing.T) {
 	assert.Nil(t, db)
 }
 
+func TestIndexDatabase_RecoveryStatus(t *testing.T) {
+	testPath := t.TempDir()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockMetadata := metadb.NewMockMetadata(ctrl)
+	mockMetadata.EXPECT().DatabaseName().Return("test").AnyTimes()
+	db, err := NewIndexDatabase(context.TODO(), testPath, mockMetadata, nil, nil)
+	assert.NoError(t, err)
+	// series wal recovered when open
+	status := db.RecoveryStatus()
+	assert.False(t, status.NeedRecovery)
+	assert.Zero(t, status.PendingEntries)
+	assert.True(t, status.LastSyncTime > 0)
+	lastSyncTime := status.LastSyncTime
+
+	_, isCreated, err := db.GetOrCreateSeriesID(1, 100)
+	assert.NoError(t, err)
+	assert.True(t, isCreated)
+	assert.Equal(t, RecoveryStatus{PendingEntries: 1, LastSyncTime: lastSyncTime}, db.RecoveryStatus())
+
+	db1 := db.(*indexDatabase)
+	assert.NoError(t, db1.seriesWAL.Rotate())
+	assert.Equal(t, RecoveryStatus{NeedRecovery: true, PendingEntries: 1, LastSyncTime: lastSyncTime}, db.RecoveryStatus())
+	time.Sleep(time.Millisecond)
+	assert.NoError(t, db1.seriesRecovery())
+	status = db.RecoveryStatus()
+	assert.False(t, status.NeedRecovery)
+	assert.Zero(t, status.PendingEntries)
+	assert.True(t, status.LastSyncTime > lastSyncTime)
+
+	err = db.Close()
+	assert.NoError(t, err)
+}
+
+func TestIndexDatabase_reportSeriesGrowth(t *testing.T) {
+	testPath := t.TempDir()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockMetadata := metadb.NewMockMetadata(ctrl)
+	mockMetadata.EXPECT().DatabaseName().Return("series-growth").AnyTimes()
+	db, err := NewIndexDatabase(context.TODO(), testPath, mockMetadata, nil, nil)
+	assert.NoError(t, err)
+	db1 := db.(*indexDatabase)
+	db1.seriesReportInterval = 1000
+	count := seriesCountVec.WithTagValues("series-growth")
+	growthRate := seriesGrowthRateVec.WithTagValues("series-growth")
+
+	syncSeries := func() {
+		assert.NoError(t, db1.seriesWAL.Rotate())
+		assert.NoError(t, db1.seriesRecovery())
+	}
+	for i := 0; i < 10; i++ {
+		_, _, err = db.GetOrCreateSeriesID(1, uint64(i))
+		assert.NoError(t, err)
+	}
+	syncSeries()
+	db1.reportCardinality(10 * 1000)
+	assert.Equal(t, float64(10), count.Get())
+	assert.Zero(t, growthRate.Get())
+	// case: not reach report interval
+	_, _, err = db.GetOrCreateSeriesID(2, 1)
+	assert.NoError(t, err)
+	syncSeries()
+	db1.reportCardinality(10*1000 + 10)
+	assert.Equal(t, float64(10), count.Get())
+	// case: count increases as new series created, metric id mapping not cached is counted
+	for i := 2; i <= 20; i++ {
+		_, _, err = db.GetOrCreateSeriesID(2, uint64(i))
+		assert.NoError(t, err)
+	}
+	syncSeries()
+	db1.rwMutex.Lock()
+	delete(db1.metricID2Mapping, 1)
+	db1.rwMutex.Unlock()
+	db1.reportCardinality(20 * 1000)
+	assert.Equal(t, float64(30), count.Get())
+	assert.Equal(t, float64(2), growthRate.Get())
+	// case: no series created
+	db1.reportCardinality(30 * 1000)
+	assert.Equal(t, float64(30), count.Get())
+	assert.Zero(t, growthRate.Get())
+	// case: gauges are removed after closed
+	_, _, err = db.GetOrCreateSeriesID(3, 1)
+	assert.NoError(t, err)
+	syncSeries()
+	db1.reportCardinality(40 * 1000)
+	assert.NotZero(t, growthRate.Get())
+
+	err = db.Close()
+	assert.NoError(t, err)
+	assert.Zero(t, count.Get())
+	assert.Zero(t, growthRate.Get())
+}
+
+func TestIndexDatabase_EstimateTagValues(t *testing.T) {
+	testPath := t.TempDir()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockMetadata := metadb.NewMockMetadata(ctrl)
+	mockMetadata.EXPECT().DatabaseName().Return("estimate-tag-values").AnyTimes()
+	db, err := NewIndexDatabase(context.TODO(), testPath, mockMetadata, nil, nil)
+	assert.NoError(t, err)
+	db1 := db.(*indexDatabase)
+	index := db1.index
+	mockIndex := NewMockInvertedIndex(ctrl)
+	db1.index = mockIndex
+
+	mockIndex.EXPECT().EstimateTagValues(uint32(1)).Return(uint64(100), true)
+	count, ok := db.EstimateTagValues(1)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(100), count)
+
+	gauge := estimatedTagValuesVec.WithTagValues("estimate-tag-values")
+	mockIndex.EXPECT().estimateAllTagValues().Return(map[uint32]uint64{1: 100})
+	db1.reportEstimatedTagValues()
+	assert.Equal(t, float64(100), gauge.Get())
+	mockIndex.EXPECT().estimateAllTagValues().Return(map[uint32]uint64{1: 150, 2: 10})
+	db1.reportEstimatedTagValues()
+	assert.Equal(t, float64(160), gauge.Get())
+
+	db1.index = index
+	err = db.Close()
+	assert.NoError(t, err)
+	assert.Zero(t, gauge.Get())
+}
+
 func TestIndexDatabase_SuggestTagValues(t *testing.T) {
 	testPath := t.TempDir()
 	ctrl := gomock.NewController(t)
@@ -152,7 +284,7 @@ func TestIndexDatabase_BuildInvertIndex(t *testing.T) {
 	index.EXPECT().buildInvertIndex(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
 	db.BuildInvertIndex("ns", "cpu", mockTagKeyValueIterator(map[string]string{"ip": "1.1.1.1"}), 10)
 
-	index.EXPECT().Flush().Return(nil)
+	index.EXPECT().FlushAll().Return(nil)
 	err = db.Close()
 	assert.NoError(t, err)
 }
@@ -175,17 +307,20 @@ func TestIndexDatabase_series_Recovery_err(t *testing.T) {
 		assert.NoError(t, err)
 		assert.True(t, isCreated)
 	}
-	err = db.Close()
-	assert.NoError(t, err)
+	// crash without syncing series wal into backend
+	crashIndexDatabase(t, db)
 
 	backend := NewMockIDMappingBackend(ctrl)
 	backend.EXPECT().Close().Return(nil).AnyTimes()
 	createBackend = func(parent string) (IDMappingBackend, error) {
 		return backend, nil
 	}
-	backend.EXPECT().saveMapping(gomock.Any()).Return(fmt.Errorf("err"))
+	errDiskFull := fmt.Errorf("disk full")
+	backend.EXPECT().saveMapping(gomock.Any()).Return(errDiskFull)
 	db, err = NewIndexDatabase(context.TODO(), testPath, meta, nil, nil)
-	assert.Error(t, err)
+	assert.True(t, errors.Is(err, ErrNeedRecoveryWAL))
+	assert.True(t, errors.Is(err, errDiskFull))
+	assert.Contains(t, err.Error(), "disk full")
 	assert.Nil(t, db)
 
 	createBackend = newIDMappingBackend
@@ -199,8 +334,8 @@ func TestIndexDatabase_series_Recovery_err(t *testing.T) {
 		assert.NoError(t, err)
 		assert.True(t, isCreated)
 	}
-	err = db.Close()
-	assert.NoError(t, err)
+	// crash without syncing series wal into backend
+	crashIndexDatabase(t, db)
 
 	createBackend = func(parent string) (IDMappingBackend, error) {
 		return backend, nil
@@ -292,6 +427,8 @@ func TestIndexDatabase_GetOrCreateSeriesID_err(t *testing.T) {
 	metadataDB := metadb.NewMockMetadataDatabase(ctrl)
 	metadata.EXPECT().MetadataDatabase().Return(metadataDB).AnyTimes()
 	metadataDB.EXPECT().GenTagKeyID(gomock.Any(), gomock.Any(), gomock.Any()).Return(uint32(1), nil).AnyTimes()
+	// load metric ids err, fallback to load metric mapping
+	backend.EXPECT().loadMetricIDs().Return(nil, fmt.Errorf("err"))
 	db, err := NewIndexDatabase(context.TODO(), testPath, metadata, nil, nil)
 	assert.NoError(t, err)
 	// case 1: load metric mapping err
@@ -314,6 +451,121 @@ func TestIndexDatabase_GetOrCreateSeriesID_err(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestIndexDatabase_GetOrCreateSeriesID_newMetric(t *testing.T) {
+	testPath := t.TempDir()
+	ctrl := gomock.NewController(t)
+	defer func() {
+		createBackend = newIDMappingBackend
+
+		ctrl.Finish()
+	}()
+
+	backend := NewMockIDMappingBackend(ctrl)
+	createBackend = func(parent string) (IDMappingBackend, error) {
+		return backend, nil
+	}
+	metadata := metadb.NewMockMetadata(ctrl)
+	metadata.EXPECT().DatabaseName().Return("test").AnyTimes()
+	backend.EXPECT().loadMetricIDs().Return(roaring.BitmapOf(1), nil)
+	db, err := NewIndexDatabase(context.TODO(), testPath, metadata, nil, nil)
+	assert.NoError(t, err)
+	// case 1: new metric, skip loading from backend
+	seriesID, isCreated, err := db.GetOrCreateSeriesID(2, 30)
+	assert.NoError(t, err)
+	assert.True(t, isCreated)
+	assert.Equal(t, uint32(1), seriesID)
+	// new series is synced into backend when closing
+	backend.EXPECT().saveMapping(gomock.Any()).Return(nil)
+	// case 2: metric exist in backend, load from backend
+	backend.EXPECT().loadMetricIDMapping(uint32(1)).Return(newMetricIDMapping(1, 10), nil)
+	backend.EXPECT().getSeriesID(uint32(1), uint64(30)).Return(uint32(5), nil)
+	seriesID, isCreated, err = db.GetOrCreateSeriesID(1, 30)
+	assert.NoError(t, err)
+	assert.False(t, isCreated)
+	assert.Equal(t, uint32(5), seriesID)
+
+	backend.EXPECT().Close().Return(nil)
+	err = db.Close()
+	assert.NoError(t, err)
+}
+
+func BenchmarkIndexDatabase_GetOrCreateSeriesID_newMetrics(b *testing.B) {
+	ctrl := gomock.NewController(b)
+	defer ctrl.Finish()
+
+	metadata := metadb.NewMockMetadata(ctrl)
+	metadata.EXPECT().DatabaseName().Return("test").AnyTimes()
+	for _, hint := range []bool{true, false} {
+		b.Run(fmt.Sprintf("hint-%v", hint), func(b *testing.B) {
+			db, err := NewIndexDatabase(context.TODO(), b.TempDir(), metadata, nil, nil)
+			if err != nil {
+				b.Fatal(err)
+			}
+			// existing metrics in backend storage
+			event := newMappingEvent()
+			for i := 0; i < 10000; i++ {
+				event.addSeriesID(uint32(i+1), uint64(i), 1)
+			}
+			db1 := db.(*indexDatabase)
+			if err := db1.backend.saveMapping(event); err != nil {
+				b.Fatal(err)
+			}
+			if hint {
+				db1.backendMetricIDs, _ = db1.backend.loadMetricIDs()
+			} else {
+				db1.backendMetricIDs = nil
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				// new metric dominated workload
+				if _, _, err := db.GetOrCreateSeriesID(uint32(100000+i), uint64(i)); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.StopTimer()
+			_ = db.Close()
+		})
+	}
+}
+
+func TestIndexDatabase_AllMetricIDs(t *testing.T) {
+	testPath := t.TempDir()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	meta := metadb.NewMockMetadata(ctrl)
+	meta.EXPECT().DatabaseName().Return("test").AnyTimes()
+	db, err := NewIndexDatabase(context.TODO(), testPath, meta, nil, nil)
+	assert.NoError(t, err)
+	// cached metrics
+	_, _, err = db.GetOrCreateSeriesID(1, 10)
+	assert.NoError(t, err)
+	_, _, err = db.GetOrCreateSeriesID(2, 10)
+	assert.NoError(t, err)
+	// on-disk only metric
+	db1 := db.(*indexDatabase)
+	event := newMappingEvent()
+	event.addSeriesID(3, 10, 1)
+	event.addSeriesID(1, 10, 1)
+	assert.NoError(t, db1.backend.saveMapping(event))
+
+	metricIDs, err := db.AllMetricIDs()
+	assert.NoError(t, err)
+	assert.Equal(t, []uint32{1, 2, 3}, metricIDs.ToArray())
+
+	// case: backend err
+	backend := NewMockIDMappingBackend(ctrl)
+	backend.EXPECT().loadMetricIDs().Return(nil, fmt.Errorf("err"))
+	realBackend := db1.backend
+	db1.backend = backend
+	metricIDs, err = db.AllMetricIDs()
+	assert.Error(t, err)
+	assert.Nil(t, metricIDs)
+
+	db1.backend = realBackend
+	assert.NoError(t, db.Close())
+}
+
 func TestIndexDatabase_GetGroupingContext(t *testing.T) {
 	testPath := t.TempDir()
 	ctrl := gomock.NewController(t)
@@ -332,7 +584,7 @@ func TestIndexDatabase_GetGroupingContext(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Nil(t, ctx)
 
-	index.EXPECT().Flush().Return(nil)
+	index.EXPECT().FlushAll().Return(nil)
 	err = db.Close()
 	assert.NoError(t, err)
 }
@@ -359,8 +611,8 @@ func TestIndexDatabase_GetSeriesIDs(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, seriesIDs)
 	// case 2: get series ids by tag value ids
-	index.EXPECT().GetSeriesIDsByTagValueIDs(uint32(1), roaring.BitmapOf(1, 2, 3)).Return(roaring.BitmapOf(1, 2), nil)
-	seriesIDs, err = db.GetSeriesIDsByTagValueIDs(1, roaring.BitmapOf(1, 2, 3))
+	index.EXPECT().GetSeriesIDsByTagValueIDs(uint32(1), roaring.BitmapOf(1, 2, 3), gomock.Any()).Return(roaring.BitmapOf(1, 2), nil)
+	seriesIDs, err = db.GetSeriesIDsByTagValueIDs(1, roaring.BitmapOf(1, 2, 3), nil)
 	assert.NoError(t, err)
 	assert.NotNil(t, seriesIDs)
 	// case 3: get tags err
@@ -380,7 +632,203 @@ func TestIndexDatabase_GetSeriesIDs(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, seriesIDs)
 
-	index.EXPECT().Flush().Return(nil)
+	index.EXPECT().FlushAll().Return(nil)
+	err = db.Close()
+	assert.NoError(t, err)
+}
+
+func TestIndexDatabase_GetSeriesIDsForMetric_stale(t *testing.T) {
+	testPath := t.TempDir()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	index := NewMockInvertedIndex(ctrl)
+	metaDB := metadb.NewMockMetadataDatabase(ctrl)
+	meta := metadb.NewMockMetadata(ctrl)
+	meta.EXPECT().DatabaseName().Return("test").AnyTimes()
+	meta.EXPECT().MetadataDatabase().Return(metaDB).AnyTimes()
+	db, err := NewIndexDatabase(context.TODO(), testPath, meta, nil, nil)
+	assert.NoError(t, err)
+	db1 := db.(*indexDatabase)
+	db1.index = index
+	index.EXPECT().GetSeriesIDsForTags([]uint32{1, 2}).Return(roaring.BitmapOf(1, 2, 3), nil).AnyTimes()
+
+	// case 1: metadata err without cache
+	metaDB.EXPECT().GetAllTagKeys("ns", "name").Return(nil, fmt.Errorf("err"))
+	seriesIDs, err := db.GetSeriesIDsForMetric("ns", "name")
+	assert.Error(t, err)
+	assert.False(t, errors.Is(err, constants.ErrStaleResult))
+	assert.Nil(t, seriesIDs)
+	// case 2: metadata ok, cache tag keys
+	metaDB.EXPECT().GetAllTagKeys("ns", "name").Return([]tag.Meta{{ID: 1}, {ID: 2}}, nil)
+	seriesIDs, err = db.GetSeriesIDsForMetric("ns", "name")
+	assert.NoError(t, err)
+	assert.Equal(t, roaring.BitmapOf(1, 2, 3), seriesIDs)
+	// case 3: metadata err, use cached tag keys
+	metaDB.EXPECT().GetAllTagKeys("ns", "name").Return(nil, fmt.Errorf("err"))
+	served := staleTagKeysCounterVec.WithTagValues("test").Get()
+	seriesIDs, err = db.GetSeriesIDsForMetric("ns", "name")
+	assert.True(t, errors.Is(err, constants.ErrStaleResult))
+	assert.Equal(t, roaring.BitmapOf(1, 2, 3), seriesIDs)
+	assert.Equal(t, served+1, staleTagKeysCounterVec.WithTagValues("test").Get())
+	// case 4: cached tag keys too old
+	db1.tagKeysCache.entries["ns/name"].Value.(*cachedTagKeys).cachedAt -= db1.maxTagKeysStaleness + 1
+	metaDB.EXPECT().GetAllTagKeys("ns", "name").Return(nil, fmt.Errorf("err"))
+	seriesIDs, err = db.GetSeriesIDsForMetric("ns", "name")
+	assert.Error(t, err)
+	assert.False(t, errors.Is(err, constants.ErrStaleResult))
+	assert.Nil(t, seriesIDs)
+	// case 5: metric not found, remove cache
+	metaDB.EXPECT().GetAllTagKeys("ns", "name").Return(nil, constants.ErrMetricIDNotFound)
+	_, err = db.GetSeriesIDsForMetric("ns", "name")
+	assert.True(t, errors.Is(err, constants.ErrNotFound))
+	_, ok := db1.tagKeysCache.get("ns/name")
+	assert.False(t, ok)
+
+	index.EXPECT().FlushAll().Return(nil)
+	assert.NoError(t, db.Close())
+}
+
+func TestIndexDatabase_GetSeriesIDsForMetric_stale_tombstone(t *testing.T) {
+	testPath := t.TempDir()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	index := NewMockInvertedIndex(ctrl)
+	metaDB := metadb.NewMockMetadataDatabase(ctrl)
+	meta := metadb.NewMockMetadata(ctrl)
+	meta.EXPECT().DatabaseName().Return("test").AnyTimes()
+	meta.EXPECT().MetadataDatabase().Return(metaDB).AnyTimes()
+	db, err := NewIndexDatabase(context.TODO(), testPath, meta, nil, nil)
+	assert.NoError(t, err)
+	db1 := db.(*indexDatabase)
+	db1.index = index
+	index.EXPECT().GetSeriesIDsForTags([]uint32{1}).Return(roaring.BitmapOf(1, 2, 3), nil).AnyTimes()
+	assert.NoError(t, db1.tombstone.add(10, []uint32{1}, roaring.BitmapOf(2)))
+
+	// case 1: metadata ok, cache tag keys and metric id
+	metaDB.EXPECT().GetAllTagKeys("ns", "name").Return([]tag.Meta{{ID: 1}}, nil)
+	metaDB.EXPECT().GetMetricID("ns", "name").Return(uint32(10), nil)
+	seriesIDs, err := db.GetSeriesIDsForMetric("ns", "name")
+	assert.NoError(t, err)
+	assert.Equal(t, roaring.BitmapOf(1, 3), seriesIDs)
+	// case 2: metadata err, filter tombstone by cached metric id
+	metaDB.EXPECT().GetAllTagKeys("ns", "name").Return(nil, fmt.Errorf("err"))
+	metaDB.EXPECT().GetMetricID("ns", "name").Return(uint32(0), fmt.Errorf("err"))
+	seriesIDs, err = db.GetSeriesIDsForMetric("ns", "name")
+	assert.True(t, errors.Is(err, constants.ErrStaleResult))
+	assert.Equal(t, roaring.BitmapOf(1, 3), seriesIDs)
+	// case 3: only get metric id err, filter tombstone by cached metric id
+	metaDB.EXPECT().GetAllTagKeys("ns", "name").Return([]tag.Meta{{ID: 1}}, nil)
+	metaDB.EXPECT().GetMetricID("ns", "name").Return(uint32(0), fmt.Errorf("err"))
+	seriesIDs, err = db.GetSeriesIDsForMetric("ns", "name")
+	assert.True(t, errors.Is(err, constants.ErrStaleResult))
+	assert.Equal(t, roaring.BitmapOf(1, 3), seriesIDs)
+	// case 4: metric id not cached
+	metaDB.EXPECT().GetAllTagKeys("ns", "name2").Return([]tag.Meta{{ID: 1}}, nil)
+	metaDB.EXPECT().GetMetricID("ns", "name2").Return(uint32(0), fmt.Errorf("err"))
+	seriesIDs, err = db.GetSeriesIDsForMetric("ns", "name2")
+	assert.Error(t, err)
+	assert.False(t, errors.Is(err, constants.ErrStaleResult))
+	assert.Nil(t, seriesIDs)
+	// case 5: metric not found
+	metaDB.EXPECT().GetAllTagKeys("ns", "name").Return([]tag.Meta{{ID: 1}}, nil)
+	metaDB.EXPECT().GetMetricID("ns", "name").Return(uint32(0), constants.ErrMetricIDNotFound)
+	seriesIDs, err = db.GetSeriesIDsForMetric("ns", "name")
+	assert.True(t, errors.Is(err, constants.ErrNotFound))
+	assert.Nil(t, seriesIDs)
+
+	index.EXPECT().FlushAll().Return(nil)
+	assert.NoError(t, db.Close())
+}
+
+func TestIndexDatabase_DeleteSeriesByTagValueIDs(t *testing.T) {
+	testPath := t.TempDir()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	index := NewMockInvertedIndex(ctrl)
+	metaDB := metadb.NewMockMetadataDatabase(ctrl)
+	meta := metadb.NewMockMetadata(ctrl)
+	meta.EXPECT().DatabaseName().Return("test").AnyTimes()
+	meta.EXPECT().MetadataDatabase().Return(metaDB).AnyTimes()
+	db, err := NewIndexDatabase(context.TODO(), testPath, meta, nil, nil)
+	assert.NoError(t, err)
+	db1 := db.(*indexDatabase)
+	db1.index = index
+	// mock series wal recovered into id mapping backend
+	event := newMappingEvent()
+	for i := 1; i <= 3; i++ {
+		_, _, err = db.GetOrCreateSeriesID(1, uint64(i))
+		assert.NoError(t, err)
+		event.addSeriesID(1, uint64(i), uint32(i))
+	}
+	assert.NoError(t, db1.backend.saveMapping(event))
+
+	// case 1: get metric id err
+	metaDB.EXPECT().GetMetricID("ns", "name").Return(uint32(0), fmt.Errorf("err"))
+	err = db.DeleteSeriesByTagValueIDs("ns", "name", 1, roaring.BitmapOf(1))
+	assert.Error(t, err)
+	metaDB.EXPECT().GetMetricID("ns", "name").Return(uint32(1), nil).AnyTimes()
+	// case 2: get tag keys err
+	metaDB.EXPECT().GetAllTagKeys("ns", "name").Return(nil, fmt.Errorf("err"))
+	err = db.DeleteSeriesByTagValueIDs("ns", "name", 1, roaring.BitmapOf(1))
+	assert.Error(t, err)
+	metaDB.EXPECT().GetAllTagKeys("ns", "name").Return([]tag.Meta{{ID: 1}, {ID: 2}}, nil).AnyTimes()
+	// case 3: get series ids err
+	index.EXPECT().GetSeriesIDsByTagValueIDs(uint32(1), roaring.BitmapOf(1), gomock.Any()).Return(nil, fmt.Errorf("err"))
+	err = db.DeleteSeriesByTagValueIDs("ns", "name", 1, roaring.BitmapOf(1))
+	assert.Error(t, err)
+	// case 4: series not found
+	index.EXPECT().GetSeriesIDsByTagValueIDs(uint32(1), roaring.BitmapOf(1), gomock.Any()).Return(roaring.New(), nil)
+	err = db.DeleteSeriesByTagValueIDs("ns", "name", 1, roaring.BitmapOf(1))
+	assert.NoError(t, err)
+	assert.True(t, db1.tombstone.isEmpty())
+	// case 5: delete series
+	index.EXPECT().GetSeriesIDsByTagValueIDs(uint32(1), roaring.BitmapOf(1), gomock.Any()).Return(roaring.BitmapOf(1, 2), nil)
+	err = db.DeleteSeriesByTagValueIDs("ns", "name", 1, roaring.BitmapOf(1))
+	assert.NoError(t, err)
+
+	// tombstoned series are invisible before purge
+	assertInvisible := func() {
+		index.EXPECT().GetSeriesIDsByTagValueIDs(uint32(2), roaring.BitmapOf(5), gomock.Any()).Return(roaring.BitmapOf(1, 3), nil)
+		seriesIDs, err := db.GetSeriesIDsByTagValueIDs(2, roaring.BitmapOf(5), nil)
+		assert.NoError(t, err)
+		assert.Equal(t, roaring.BitmapOf(3), seriesIDs)
+		index.EXPECT().GetSeriesIDsForTag(uint32(1)).Return(roaring.BitmapOf(1, 2, 3), nil)
+		seriesIDs, err = db.GetSeriesIDsForTag(1)
+		assert.NoError(t, err)
+		assert.Equal(t, roaring.BitmapOf(3), seriesIDs)
+		index.EXPECT().GetSeriesIDsForTags([]uint32{1, 2}).Return(roaring.BitmapOf(1, 2, 3), nil)
+		seriesIDs, err = db.GetSeriesIDsForMetric("ns", "name")
+		assert.NoError(t, err)
+		assert.Equal(t, roaring.BitmapOf(3), seriesIDs)
+	}
+	assertInvisible()
+	_, pending := db1.tombstone.stats()
+	assert.Equal(t, uint64(2), pending)
+
+	// purge tombstone
+	index.EXPECT().removeSeriesIDs([]uint32{1, 2}, roaring.BitmapOf(1, 2))
+	db1.purgeTombstone()
+	_, pending = db1.tombstone.stats()
+	assert.Equal(t, uint64(0), pending)
+	_, err = db1.backend.getSeriesID(1, 1)
+	assert.Error(t, err)
+	seriesID, err := db1.backend.getSeriesID(1, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(3), seriesID)
+	// write purged series again, generate new series id
+	seriesID, isCreated, err := db.GetOrCreateSeriesID(1, 1)
+	assert.NoError(t, err)
+	assert.True(t, isCreated)
+	assert.Equal(t, uint32(4), seriesID)
+	// purged series still invisible
+	assertInvisible()
+	// nothing to purge
+	db1.purgeTombstone()
+
+	index.EXPECT().FlushAll().Return(nil)
 	err = db.Close()
 	assert.NoError(t, err)
 }
@@ -395,10 +843,15 @@ func TestIndexDatabase_Close(t *testing.T) {
 	}()
 
 	backend := NewMockIDMappingBackend(ctrl)
+	backend.EXPECT().loadMetricIDs().Return(roaring.New(), nil)
 	createBackend = func(parent string) (IDMappingBackend, error) {
 		return backend, nil
 	}
 	mockSeriesWAL := wal.NewMockSeriesWAL(ctrl)
+	// sync pending series before closing wal
+	mockSeriesWAL.EXPECT().NeedRecovery().Return(true)
+	mockSeriesWAL.EXPECT().Recovery(gomock.Any(), gomock.Any())
+	mockSeriesWAL.EXPECT().Rotate().Return(fmt.Errorf("err"))
 	mockSeriesWAL.EXPECT().Close().Return(fmt.Errorf("err"))
 
 	meta := metadb.NewMockMetadata(ctrl)
@@ -411,6 +864,22 @@ func TestIndexDatabase_Close(t *testing.T) {
 	backend.EXPECT().Close().Return(fmt.Errorf("err"))
 	err = db.Close()
 	assert.Error(t, err)
+	// close again, resources not closed twice, same result returned
+	assert.Equal(t, err, db.Close())
+}
+
+func TestIndexDatabase_Close_twice(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	meta := metadb.NewMockMetadata(ctrl)
+	meta.EXPECT().DatabaseName().Return("test").AnyTimes()
+	db, err := NewIndexDatabase(context.TODO(), t.TempDir(), meta, nil, nil)
+	assert.NoError(t, err)
+	assert.NotPanics(t, func() {
+		assert.NoError(t, db.Close())
+		assert.NoError(t, db.Close())
+	})
 }
 
 func TestIndexDatabase_Flush(t *testing.T) {
@@ -422,6 +891,7 @@ func TestIndexDatabase_Flush(t *testing.T) {
 		ctrl.Finish()
 	}()
 	mockSeriesWAL := wal.NewMockSeriesWAL(ctrl)
+	mockSeriesWAL.EXPECT().Rotate().Return(nil)
 	mockSeriesWAL.EXPECT().Close().Return(nil)
 	mockSeriesWAL.EXPECT().Recovery(gomock.Any(), gomock.Any())
 	mockSeriesWAL.EXPECT().NeedRecovery().Return(false).AnyTimes()
@@ -458,6 +928,7 @@ func TestIndexDatabase_checkSync(t *testing.T) {
 		return count.Load() != 1
 	}).AnyTimes()
 	mockSeriesWAL.EXPECT().Recovery(gomock.Any(), gomock.Any()).AnyTimes()
+	mockSeriesWAL.EXPECT().PendingSize().Return(int64(0)).AnyTimes()
 	createSeriesWAL = func(path string) (wal.SeriesWAL, error) {
 		return mockSeriesWAL, nil
 	}
@@ -470,7 +941,145 @@ func TestIndexDatabase_checkSync(t *testing.T) {
 
 	time.Sleep(time.Second)
 
+	mockSeriesWAL.EXPECT().Rotate().Return(nil)
 	mockSeriesWAL.EXPECT().Close().Return(nil)
 	err = db.Close()
 	assert.NoError(t, err)
 }
+
+func TestIndexDatabase_checkSyncHealth(t *testing.T) {
+	testPath := t.TempDir()
+	syncInterval = 100
+	ctrl := gomock.NewController(t)
+	defer func() {
+		syncInterval = 2 * timeutil.OneSecond
+		createSeriesWAL = wal.NewSeriesWAL
+		createBackend = newIDMappingBackend
+
+		ctrl.Finish()
+	}()
+
+	backend := NewMockIDMappingBackend(ctrl)
+	// recovery success when open, then fails
+	backend.EXPECT().saveMapping(gomock.Any()).Return(nil)
+	backend.EXPECT().saveMapping(gomock.Any()).Return(fmt.Errorf("err")).AnyTimes()
+	backend.EXPECT().loadMetricIDs().Return(roaring.New(), nil)
+	backend.EXPECT().countSeries().Return(uint64(0), nil).AnyTimes()
+	backend.EXPECT().Close().Return(nil)
+	createBackend = func(parent string) (IDMappingBackend, error) {
+		return backend, nil
+	}
+	var count atomic.Int32
+	mockSeriesWAL := wal.NewMockSeriesWAL(ctrl)
+	mockSeriesWAL.EXPECT().NeedRecovery().DoAndReturn(func() bool {
+		return count.Inc() != 1
+	}).AnyTimes()
+	mockSeriesWAL.EXPECT().Recovery(gomock.Any(), gomock.Any()).
+		Do(func(recovery wal.SeriesRecoveryFunc, commit wal.CommitFunc) {
+			if recovery(1, 1, 1) == nil {
+				_ = commit()
+			}
+		}).AnyTimes()
+	var pages atomic.Int64
+	mockSeriesWAL.EXPECT().PendingSize().DoAndReturn(func() int64 {
+		// wal grows because saving mapping fails
+		return pages.Inc() * 1024
+	}).AnyTimes()
+	createSeriesWAL = func(path string) (wal.SeriesWAL, error) {
+		return mockSeriesWAL, nil
+	}
+
+	meta := metadb.NewMockMetadata(ctrl)
+	meta.EXPECT().DatabaseName().Return("sync-health").AnyTimes()
+	db, err := NewIndexDatabase(context.TODO(), testPath, meta, nil, nil)
+	assert.NoError(t, err)
+	assert.NotNil(t, db)
+	_, degraded := degradedDatabases.Load(testPath)
+	assert.False(t, degraded)
+
+	gauge := seriesWALPendingBytesVec.WithTagValues("sync-health")
+	time.Sleep(300 * time.Millisecond)
+	pending := gauge.Get()
+	assert.True(t, pending > 0)
+	time.Sleep(500 * time.Millisecond)
+	assert.True(t, gauge.Get() > pending)
+	err = CheckSyncHealth()
+	assert.True(t, errors.Is(err, ErrSyncDegraded))
+	assert.Contains(t, err.Error(), testPath)
+
+	mockSeriesWAL.EXPECT().Rotate().Return(nil)
+	mockSeriesWAL.EXPECT().Close().Return(nil)
+	err = db.Close()
+	assert.NoError(t, err)
+	_, degraded = degradedDatabases.Load(testPath)
+	assert.False(t, degraded)
+	// pending size is removed when closed
+	assert.Zero(t, gauge.Get())
+}
+
+func TestIndexDatabase_seriesRecovery_err(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	meta := metadb.NewMockMetadata(ctrl)
+	meta.EXPECT().DatabaseName().Return("test").AnyTimes()
+	backend := NewMockIDMappingBackend(ctrl)
+	mockSeriesWAL := wal.NewMockSeriesWAL(ctrl)
+	mockSeriesWAL.EXPECT().Recovery(gomock.Any(), gomock.Any()).
+		Do(func(recovery wal.SeriesRecoveryFunc, commit wal.CommitFunc) {
+			if recovery(1, 1, 1) == nil {
+				_ = commit()
+			}
+		}).AnyTimes()
+	db := &indexDatabase{
+		path:      t.TempDir(),
+		backend:   backend,
+		metadata:  meta,
+		seriesWAL: mockSeriesWAL,
+	}
+	// case 1: save mapping err surfaces
+	saveErr := fmt.Errorf("disk full")
+	backend.EXPECT().saveMapping(gomock.Any()).Return(saveErr)
+	err := db.seriesRecovery()
+	assert.True(t, errors.Is(err, saveErr))
+	assert.Equal(t, 1, db.syncFailures)
+	// case 2: recovery success
+	backend.EXPECT().saveMapping(gomock.Any()).Return(nil)
+	assert.NoError(t, db.seriesRecovery())
+	assert.Zero(t, db.syncFailures)
+}
+
+func TestIndexDatabase_Close_NoGoroutineLeak(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	oldSyncInterval := syncInterval
+	syncInterval = 1
+	defer func() {
+		syncInterval = oldSyncInterval
+	}()
+
+	meta := metadb.NewMockMetadata(ctrl)
+	meta.EXPECT().DatabaseName().Return("goroutine-leak").AnyTimes()
+	goroutines := backgroundGoroutinesVec.WithTagValues("goroutine-leak")
+	testPath := t.TempDir()
+	// open and close repeatedly, background goroutines must stop on close
+	for i := 0; i < 5; i++ {
+		db, err := NewIndexDatabase(context.TODO(), testPath, meta, nil, nil)
+		assert.NoError(t, err)
+		time.Sleep(5 * time.Millisecond)
+		assert.Equal(t, float64(1), goroutines.Get())
+		assert.NoError(t, db.Close())
+		assert.Zero(t, goroutines.Get())
+	}
+}
+
+// crashIndexDatabase closes index database without syncing series wal into backend, like process crash.
+func crashIndexDatabase(t *testing.T, db IndexDatabase) {
+	idb := db.(*indexDatabase)
+	idb.cancel()
+	idb.syncWG.Wait()
+	assert.NoError(t, idb.seriesWAL.Close())
+	assert.NoError(t, idb.backend.Close())
+}
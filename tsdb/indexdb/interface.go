@@ -20,6 +20,8 @@ package indexdb
 import (
 	"io"
 
+	"github.com/lindb/roaring"
+
 	"github.com/lindb/lindb/pkg/logger"
 	"github.com/lindb/lindb/series"
 	"github.com/lindb/lindb/series/metric"
@@ -51,6 +53,27 @@ type IndexDatabase interface {
 	// BuildInvertIndex builds the inverted index for tag value => series ids,
 	// the tags is considered as a empty key-value pair while tags is nil.
 	BuildInvertIndex(namespace, metricName string, tagIterator *metric.KeyValueIterator, seriesID uint32)
+	// DeleteSeriesByTagValueIDs marks series ids of spec metric's tag values as tombstone,
+	// tombstoned series ids are invisible for query immediately, then purged in background.
+	DeleteSeriesByTagValueIDs(namespace, metricName string, tagKeyID uint32, tagValueIDs *roaring.Bitmap) error
+	// AllMetricIDs returns all metric ids in index database, includes cached in memory and stored in backend storage.
+	AllMetricIDs() (*roaring.Bitmap, error)
+	// EstimateTagValues returns the estimated number of distinct tag values of tag key,
+	// returns false if estimation is disabled, no series of tag key created since opened
+	// or tag key exceeds the estimation limit.
+	EstimateTagValues(tagKeyID uint32) (count uint64, ok bool)
+	// RecoveryStatus returns the recovery status of series wal
+	RecoveryStatus() RecoveryStatus
+	// DumpMetricIDMapping returns the cached and backend stored id mapping of metric with divergences,
+	// used for diagnosing missing series.
+	DumpMetricIDMapping(metricID uint32) (*MetricIDMappingDump, error)
 	// Flush flushes index data to disk
 	Flush() error
 }
+
+// RecoveryStatus represents the recovery status of series wal in index database.
+type RecoveryStatus struct {
+	NeedRecovery   bool  `json:"needRecovery"`
+	PendingEntries int64 `json:"pendingEntries"` // number of series entries not synced into backend storage
+	LastSyncTime   int64 `json:"lastSyncTime"`   // timestamp(ms) of last successful sync, 0 if never synced
+}
@@ -19,10 +19,17 @@ package indexdb
 
 import (
 	"sync"
+	"time"
 
+	"github.com/cespare/xxhash/v2"
+	"go.uber.org/atomic"
+
+	"github.com/lindb/lindb/config"
 	"github.com/lindb/lindb/internal/linmetric"
 	"github.com/lindb/lindb/kv"
 	"github.com/lindb/lindb/kv/version"
+	"github.com/lindb/lindb/pkg/collections"
+	"github.com/lindb/lindb/pkg/fasttime"
 	"github.com/lindb/lindb/pkg/logger"
 	"github.com/lindb/lindb/series"
 	"github.com/lindb/lindb/series/metric"
@@ -43,16 +50,28 @@ var (
 	newInvertedFlusherFunc = tagindex.NewInvertedFlusher
 )
 
+// defaultInvertedIndexShards represents the number of memory inverted index shards by tag key id,
+// so that building index of different tag keys concurrently does not contend on one lock.
+const defaultInvertedIndexShards = 16
+
+// maxEstimatedTagKeysPerShard bounds the memory of tag values cardinality estimators in each shard,
+// tag keys built after the limit reached are not estimated.
+var maxEstimatedTagKeysPerShard = 256
+
 var (
 	genTagKeyFailCounterVec   = indexDBScope.NewCounterVec("gen_tag_key_id_fails", "db")
 	genTagValueFailCounterVec = indexDBScope.NewCounterVec("gen_tag_value_id_fails", "db")
+	invertedFlushTimerVec     = indexDBScope.Scope("inverted_index_flush_duration").NewHistogramVec("db")
+	evictedTagKeysCounterVec  = indexDBScope.NewCounterVec("evicted_tag_keys", "db")
+	reloadedTagKeysCounterVec = indexDBScope.NewCounterVec("reloaded_tag_keys", "db")
 )
 
 // InvertedIndex represents the tag's inverted index (tag values => series id list)
 type InvertedIndex interface {
 
-	// GetSeriesIDsByTagValueIDs gets series ids by tag value ids for spec metric's tag key
-	GetSeriesIDsByTagValueIDs(tagKeyID uint32, tagValueIDs *roaring.Bitmap) (*roaring.Bitmap, error)
+	// GetSeriesIDsByTagValueIDs gets series ids by tag value ids for spec metric's tag key,
+	// the union of series ids is accounted by budget.
+	GetSeriesIDsByTagValueIDs(tagKeyID uint32, tagValueIDs *roaring.Bitmap, budget *series.BitmapBudget) (*roaring.Bitmap, error)
 
 	// GetSeriesIDsForTag gets series ids for spec metric's tag key
 	GetSeriesIDsForTag(tagKeyID uint32) (*roaring.Bitmap, error)
@@ -67,8 +86,39 @@ type InvertedIndex interface {
 	// the tags is considered as a empty key-value pair while tags is nil.
 	buildInvertIndex(namespace, metricName string, tagIterator *metric.KeyValueIterator, seriesID uint32)
 
-	// Flush flushes the inverted-index of tag value id=>series ids under tag key
+	// removeSeriesIDs removes series ids from memory inverted index of spec tag keys
+	removeSeriesIDs(tagKeyIDs []uint32, seriesIDs *roaring.Bitmap)
+
+	// EstimateTagValues returns the estimated number of distinct tag values of tag key,
+	// which are built since index opened, deleted tag values are still counted.
+	// returns false if estimation is disabled, no index built for tag key or tag key exceeds the estimation limit.
+	EstimateTagValues(tagKeyID uint32) (uint64, bool)
+
+	// estimateAllTagValues returns the estimated number of distinct tag values of all tag keys, key: tag key id.
+	estimateAllTagValues() map[uint32]uint64
+
+	// Flush flushes the inverted-index of tag value id=>series ids under tag key,
+	// with incremental strategy, only flushes a bounded chunk of dirty tag keys.
 	Flush() error
+
+	// FlushAll flushes all dirty inverted-index regardless of flush strategy
+	FlushAll() error
+
+	// evictIdleTagKeys flushes the tag keys in memory which are not accessed within idle ttl into kv store,
+	// then evicts them from memory.
+	evictIdleTagKeys(now int64) error
+}
+
+// invertedIndexShard stores the memory inverted index of tag keys which belong to this shard.
+type invertedIndexShard struct {
+	mutable   *TagIndexStore
+	immutable *TagIndexStore
+	// cardinality estimator of tag values, key: tag key id, nil if estimation is disabled
+	estimators map[uint32]*collections.HyperLogLog
+	// last access time(ms) of tag keys in mutable, key: tag key id, nil if eviction is disabled
+	accessTimes map[uint32]*atomic.Int64
+
+	rwMutex sync.RWMutex
 }
 
 type invertedIndex struct {
@@ -78,27 +128,75 @@ type invertedIndex struct {
 
 	metadata       metadb.Metadata
 
-	mutable   *TagIndexStore
-	immutable *TagIndexStore
+	shards []*invertedIndexShard // memory inverted index shards, key: tag key id % len(shards)
+
+	flushStrategy  string
+	flushChunkSize int
+	// precision of tag values cardinality estimator, 0 if disabled
+	cardinalityPrecision int
+	tagKeyIdleTTL        int64    // idle window(ms) of evicting tag keys from memory, 0 if disabled
+	evicted              sync.Map // tag keys evicted and not queried since, key: tag key id
+	// tag keys in immutable which are not flushed yet, nil if no flush in progress
+	pending    *roaring.Bitmap
+	flushMutex sync.Mutex
 
-	rwMutex                sync.RWMutex
 	genTagKeyFailCounter   *linmetric.BoundCounter
 	genTagValueFailCounter *linmetric.BoundCounter
+	flushTimer             *linmetric.BoundHistogram
+	evictedTagKeysCounter  *linmetric.BoundCounter
+	reloadedTagKeysCounter *linmetric.BoundCounter
 }
 
 func newInvertedIndex(metadata metadb.Metadata, forwardFamily kv.Family, invertedFamily kv.Family) InvertedIndex {
+	return newShardedInvertedIndex(metadata, forwardFamily, invertedFamily, defaultInvertedIndexShards)
+}
+
+// newShardedInvertedIndex creates the inverted index with spec number of memory shards.
+func newShardedInvertedIndex(metadata metadb.Metadata, forwardFamily kv.Family, invertedFamily kv.Family,
+	numOfShards int,
+) *invertedIndex {
+	if numOfShards <= 0 {
+		numOfShards = 1
+	}
+	tsdbCfg := config.GlobalStorageConfig().TSDB
+	shards := make([]*invertedIndexShard, numOfShards)
+	for i := range shards {
+		shards[i] = &invertedIndexShard{mutable: NewTagIndexStore()}
+		if tsdbCfg.TagCardinalityPrecision > 0 {
+			shards[i].estimators = make(map[uint32]*collections.HyperLogLog)
+		}
+		if tsdbCfg.IndexTagKeyIdleTTL > 0 {
+			shards[i].accessTimes = make(map[uint32]*atomic.Int64)
+		}
+	}
 	return &invertedIndex{
 		invertedFamily:         invertedFamily,
 		forwardFamily:          forwardFamily,
 		metadata:               metadata,
-		mutable:                NewTagIndexStore(),
+		shards:                 shards,
+		flushStrategy:          tsdbCfg.IndexFlushStrategy,
+		flushChunkSize:         tsdbCfg.IndexFlushChunkSize,
+		cardinalityPrecision:   tsdbCfg.TagCardinalityPrecision,
+		tagKeyIdleTTL:          tsdbCfg.IndexTagKeyIdleTTL.Duration().Milliseconds(),
 		genTagKeyFailCounter:   genTagKeyFailCounterVec.WithTagValues(metadata.DatabaseName()),
 		genTagValueFailCounter: genTagValueFailCounterVec.WithTagValues(metadata.DatabaseName()),
+		flushTimer:             invertedFlushTimerVec.WithTagValues(metadata.DatabaseName()),
+		evictedTagKeysCounter:  evictedTagKeysCounterVec.WithTagValues(metadata.DatabaseName()),
+		reloadedTagKeysCounter: reloadedTagKeysCounterVec.WithTagValues(metadata.DatabaseName()),
 	}
 }
 
+// getShard returns the memory inverted index shard of tag key.
+func (index *invertedIndex) getShard(tagKeyID uint32) *invertedIndexShard {
+	return index.shards[tagKeyID%uint32(len(index.shards))]
+}
+
 // GetSeriesIDsByTagValueIDs finds series ids by tag filter expr
-func (index *invertedIndex) GetSeriesIDsByTagValueIDs(tagKeyID uint32, tagValueIDs *roaring.Bitmap) (*roaring.Bitmap, error) {
+func (index *invertedIndex) GetSeriesIDsByTagValueIDs(
+	tagKeyID uint32,
+	tagValueIDs *roaring.Bitmap,
+	budget *series.BitmapBudget,
+) (*roaring.Bitmap, error) {
 
 	// 新建 bitmap 位图
 	result := roaring.New()
@@ -106,28 +204,46 @@ func (index *invertedIndex) GetSeriesIDsByTagValueIDs(tagKeyID uint32, tagValueI
 	// read data from mem
 	//
 	//
+	var memErr error
 	index.loadSeriesIDsInMem(tagKeyID, func(tagIndex TagIndex) {
-		seriesIDs := tagIndex.getSeriesIDsByTagValueIDs(tagValueIDs)
+		if memErr != nil {
+			return
+		}
+		var seriesIDs *roaring.Bitmap
+		seriesIDs, memErr = tagIndex.getSeriesIDsByTagValueIDs(tagValueIDs, budget)
 		if seriesIDs != nil {
-			result.Or(seriesIDs)
+			memErr = mergeSeriesIDs(result, seriesIDs, memErr, budget)
 		}
 	})
+	if memErr != nil {
+		budget.Release(result.GetSizeInBytes())
+		return nil, memErr
+	}
 
 	// read data from kv store
 	if err := index.loadSeriesIDsInKV(tagKeyID, func(reader tagindex.InvertedReader) error {
-		seriesIDs, err := reader.GetSeriesIDsByTagValueIDs(tagKeyID, tagValueIDs)
-		if err != nil {
-			return err
+		seriesIDs, err := reader.GetSeriesIDsByTagValueIDs(tagKeyID, tagValueIDs, budget)
+		if seriesIDs != nil {
+			err = mergeSeriesIDs(result, seriesIDs, err, budget)
 		}
-		result.Or(seriesIDs)
-		return nil
+		return err
 	}); err != nil {
+		budget.Release(result.GetSizeInBytes())
 		return nil, err
 	}
 
 	return result, nil
 }
 
+// mergeSeriesIDs merges the series ids of one source into result, then releases the memory of source from budget.
+func mergeSeriesIDs(result, seriesIDs *roaring.Bitmap, err error, budget *series.BitmapBudget) error {
+	if err == nil {
+		err = budget.Or(result, seriesIDs)
+	}
+	budget.Release(seriesIDs.GetSizeInBytes())
+	return err
+}
+
 // GetSeriesIDsForTag get series ids by tagKeyId
 func (index *invertedIndex) GetSeriesIDsForTag(tagKeyID uint32) (*roaring.Bitmap, error) {
 	// get snapshot for getting data
@@ -251,11 +367,6 @@ func (index *invertedIndex) getGroupingScanners(
 // buildInvertIndex builds the inverted index for tag value => series ids,
 // the tags is considered as a empty key-value pair while tags is nil.
 func (index *invertedIndex) buildInvertIndex(namespace, metricName string, tagIterator *metric.KeyValueIterator, seriesID uint32) {
-
-	index.rwMutex.Lock()
-	defer index.rwMutex.Unlock()
-
-
 	metadataDB := index.metadata.MetadataDatabase()
 	tagMetadata := index.metadata.TagMetadata()
 
@@ -264,7 +375,7 @@ func (index *invertedIndex) buildInvertIndex(namespace, metricName string, tagIt
 	for tagIterator.HasNext() {
 
 		//
-		tagKey := string(tagIterator.NextKey())
+		tagKey := tagIterator.NextKeyString()
 		tagValue := string(tagIterator.NextValue())
 
 		// 查询 tagKeyID
@@ -280,13 +391,6 @@ func (index *invertedIndex) buildInvertIndex(namespace, metricName string, tagIt
 			continue
 		}
 
-		// 查询 tagIndex
-		tagIndex, ok := index.mutable.Get(tagKeyID)
-		if !ok {
-			tagIndex = newTagIndex()
-			index.mutable.Put(tagKeyID, tagIndex)
-		}
-
 		//
 		tagValueID, err := tagMetadata.GenTagValueID(tagKeyID, tagValue)
 		if err != nil {
@@ -300,15 +404,129 @@ func (index *invertedIndex) buildInvertIndex(namespace, metricName string, tagIt
 			continue
 		}
 
-		//
+		// only lock the shard of tag key, other tag keys can be built concurrently
+		shard := index.getShard(tagKeyID)
+		shard.rwMutex.Lock()
+		// 查询 tagIndex
+		tagIndex, ok := shard.mutable.Get(tagKeyID)
+		if !ok {
+			tagIndex = newTagIndex()
+			shard.mutable.Put(tagKeyID, tagIndex)
+		}
 		tagIndex.buildInvertedIndex(tagValueID, seriesID)
+		shard.touch(tagKeyID, true)
+		if shard.estimators != nil {
+			estimator, ok := shard.estimators[tagKeyID]
+			if !ok && len(shard.estimators) < maxEstimatedTagKeysPerShard {
+				estimator = collections.NewHyperLogLog(index.cardinalityPrecision)
+				shard.estimators[tagKeyID] = estimator
+			}
+			if estimator != nil {
+				estimator.Add(xxhash.Sum64String(tagValue))
+			}
+		}
+		shard.rwMutex.Unlock()
 	}
 }
 
-// Flush flushes the inverted-index of tag value id=>series ids under tag key
+// EstimateTagValues returns the estimated number of distinct tag values of tag key,
+// which are built since index opened, deleted tag values are still counted.
+// returns false if estimation is disabled, no index built for tag key or tag key exceeds the estimation limit.
+func (index *invertedIndex) EstimateTagValues(tagKeyID uint32) (uint64, bool) {
+	shard := index.getShard(tagKeyID)
+	shard.rwMutex.RLock()
+	defer shard.rwMutex.RUnlock()
+
+	estimator, ok := shard.estimators[tagKeyID]
+	if !ok {
+		return 0, false
+	}
+	return estimator.Count(), true
+}
+
+// estimateAllTagValues returns the estimated number of distinct tag values of all tag keys, key: tag key id.
+func (index *invertedIndex) estimateAllTagValues() map[uint32]uint64 {
+	result := make(map[uint32]uint64)
+	for _, shard := range index.shards {
+		shard.rwMutex.RLock()
+		for tagKeyID, estimator := range shard.estimators {
+			result[tagKeyID] = estimator.Count()
+		}
+		shard.rwMutex.RUnlock()
+	}
+	return result
+}
+
+// removeSeriesIDs removes series ids from memory inverted index of spec tag keys,
+// series ids in kv store cannot be removed, need filter them by tombstone.
+func (index *invertedIndex) removeSeriesIDs(tagKeyIDs []uint32, seriesIDs *roaring.Bitmap) {
+	remove := func(tagKeyID uint32, tagIndexStore *TagIndexStore) {
+		if tagIndex, ok := tagIndexStore.Get(tagKeyID); ok {
+			tagIndex.removeSeriesIDs(seriesIDs)
+		}
+	}
+	for _, tagKeyID := range tagKeyIDs {
+		shard := index.getShard(tagKeyID)
+		shard.rwMutex.Lock()
+		remove(tagKeyID, shard.mutable)
+		if shard.immutable != nil {
+			remove(tagKeyID, shard.immutable)
+		}
+		shard.rwMutex.Unlock()
+	}
+}
+
+// Flush flushes the inverted-index of tag value id=>series ids under tag key,
+// with incremental strategy, only flushes a bounded chunk of dirty tag keys.
 func (index *invertedIndex) Flush() error {
-	if !index.checkFlush() {
-		return nil
+	index.flushMutex.Lock()
+	defer index.flushMutex.Unlock()
+
+	limit := 0
+	if index.flushStrategy == config.IndexFlushStrategyIncremental {
+		limit = index.flushChunkSize
+	}
+	return index.flush(limit)
+}
+
+// FlushAll flushes all dirty inverted-index regardless of flush strategy
+func (index *invertedIndex) FlushAll() error {
+	index.flushMutex.Lock()
+	defer index.flushMutex.Unlock()
+
+	// finish the flush in progress first, then flush the new data
+	for i := 0; i < 2; i++ {
+		if err := index.flush(0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// flush flushes at most limit tag keys in immutable(0 means no limit),
+// clears immutable after all tag keys are flushed.
+func (index *invertedIndex) flush(limit int) error {
+	if index.pending == nil {
+		if !index.checkFlush() {
+			return nil
+		}
+		pending := roaring.New()
+		for _, shard := range index.shards {
+			if shard.immutable != nil {
+				pending.Or(shard.immutable.Keys())
+			}
+		}
+		index.pending = pending
+	}
+	defer index.flushTimer.UpdateSince(time.Now())
+
+	tagKeyIDs := index.pending
+	if limit > 0 && tagKeyIDs.GetCardinality() > uint64(limit) {
+		tagKeyIDs = roaring.New()
+		it := index.pending.Iterator()
+		for i := 0; i < limit && it.HasNext(); i++ {
+			tagKeyIDs.Add(it.Next())
+		}
 	}
 
 	// flush immutable data into kv store
@@ -322,13 +540,14 @@ func (index *invertedIndex) Flush() error {
 	if err != nil {
 		return err
 	}
-	if err := index.immutable.WalkEntry(func(key uint32, value TagIndex) error {
-		if err := value.flush(key, forward, inverted); err != nil {
+	// flush tag keys of all shards in order, kv store requires keys are sorted
+	it := tagKeyIDs.Iterator()
+	for it.HasNext() {
+		tagKeyID := it.Next()
+		tagIndex, _ := index.getShard(tagKeyID).immutable.Get(tagKeyID)
+		if err := tagIndex.flush(tagKeyID, forward, inverted); err != nil {
 			return err
 		}
-		return nil
-	}); err != nil {
-		return err
 	}
 	// commit kv stone meta
 	if err := forward.Close(); err != nil {
@@ -337,30 +556,158 @@ func (index *invertedIndex) Flush() error {
 	if err := inverted.Close(); err != nil {
 		return err
 	}
+	index.pending = roaring.AndNot(index.pending, tagKeyIDs)
+	if !index.pending.IsEmpty() {
+		// remaining tag keys are flushed in next cycles, immutable still serves read
+		return nil
+	}
+	index.pending = nil
 	// finally clear immutable
-	index.rwMutex.Lock()
-	index.immutable = nil
-	index.rwMutex.Unlock()
+	for _, shard := range index.shards {
+		shard.rwMutex.Lock()
+		shard.immutable = nil
+		shard.rwMutex.Unlock()
+	}
 	return nil
 }
 
 // checkFlush checks if need do flush job, if need, do switch mutable/immutable
 func (index *invertedIndex) checkFlush() bool {
-	index.rwMutex.Lock()
-	defer index.rwMutex.Unlock()
+	needFlush := false
+	for _, shard := range index.shards {
+		if shard.checkFlush() {
+			needFlush = true
+		}
+	}
+	return needFlush
+}
 
-	if index.mutable.Size() == 0 && index.immutable == nil {
+// checkFlush checks if need flush the shard, if need, do switch mutable/immutable
+func (shard *invertedIndexShard) checkFlush() bool {
+	shard.rwMutex.Lock()
+	defer shard.rwMutex.Unlock()
+
+	if shard.mutable.Size() == 0 && shard.immutable == nil {
 		// no new data or immutable is not nil
 		return false
 	}
-	if index.mutable.Size() > 0 && index.immutable == nil {
+	if shard.mutable.Size() > 0 && shard.immutable == nil {
 		// reset mutable, if flush fail immutable is not nil
-		index.immutable = index.mutable
-		index.mutable = NewTagIndexStore()
+		shard.immutable = shard.mutable
+		shard.mutable = NewTagIndexStore()
+		if shard.accessTimes != nil {
+			// tag keys in immutable are evicted after flush
+			shard.accessTimes = make(map[uint32]*atomic.Int64)
+		}
 	}
 	return true
 }
 
+// touch updates the last access time of tag key in mutable,
+// creates the access time if tag key is added into mutable(must hold write lock).
+func (shard *invertedIndexShard) touch(tagKeyID uint32, create bool) {
+	if shard.accessTimes == nil {
+		return
+	}
+	now := fasttime.UnixMilliseconds()
+	accessTime, ok := shard.accessTimes[tagKeyID]
+	switch {
+	case ok:
+		accessTime.Store(now)
+	case create:
+		shard.accessTimes[tagKeyID] = atomic.NewInt64(now)
+	}
+}
+
+// evictIdleTagKeys flushes the tag keys in mutable which are not written/queried within idle ttl into kv store,
+// then evicts them from memory, queries read them from kv store on demand.
+// tag keys accessed during flushing are kept in memory, the data flushed twice is merged when reading.
+func (index *invertedIndex) evictIdleTagKeys(now int64) error {
+	if index.tagKeyIdleTTL <= 0 {
+		return nil
+	}
+	index.flushMutex.Lock()
+	defer index.flushMutex.Unlock()
+
+	idleBefore := now - index.tagKeyIdleTTL
+	tagKeyIDs := roaring.New()
+	for _, shard := range index.shards {
+		shard.rwMutex.RLock()
+		for tagKeyID, accessTime := range shard.accessTimes {
+			if accessTime.Load() <= idleBefore {
+				tagKeyIDs.Add(tagKeyID)
+			}
+		}
+		shard.rwMutex.RUnlock()
+	}
+	if tagKeyIDs.IsEmpty() {
+		return nil
+	}
+
+	forward, err := newForwardFlusherFunc(index.forwardFamily.NewFlusher())
+	if err != nil {
+		return err
+	}
+	inverted, err := newInvertedFlusherFunc(index.invertedFamily.NewFlusher())
+	if err != nil {
+		return err
+	}
+	// flush tag keys in order, mutable may be written concurrently, so holds read lock of shard
+	it := tagKeyIDs.Iterator()
+	for it.HasNext() {
+		tagKeyID := it.Next()
+		shard := index.getShard(tagKeyID)
+		shard.rwMutex.RLock()
+		tagIndex, ok := shard.mutable.Get(tagKeyID)
+		if ok {
+			err = tagIndex.flush(tagKeyID, forward, inverted)
+		}
+		shard.rwMutex.RUnlock()
+		if err != nil {
+			return err
+		}
+	}
+	if err := forward.Close(); err != nil {
+		return err
+	}
+	if err := inverted.Close(); err != nil {
+		return err
+	}
+	evictedShards := make(map[*invertedIndexShard]struct{})
+	it = tagKeyIDs.Iterator()
+	for it.HasNext() {
+		evictedShards[index.getShard(it.Next())] = struct{}{}
+	}
+	for shard := range evictedShards {
+		index.evictShard(shard, tagKeyIDs, idleBefore)
+	}
+	return nil
+}
+
+// evictShard removes the flushed tag keys which are still idle from mutable of shard.
+func (index *invertedIndex) evictShard(shard *invertedIndexShard, tagKeyIDs *roaring.Bitmap, idleBefore int64) {
+	shard.rwMutex.Lock()
+	defer shard.rwMutex.Unlock()
+
+	evicted := 0
+	mutable := NewTagIndexStore()
+	_ = shard.mutable.WalkEntry(func(tagKeyID uint32, tagIndex TagIndex) error {
+		accessTime, ok := shard.accessTimes[tagKeyID]
+		if tagKeyIDs.Contains(tagKeyID) && ok && accessTime.Load() <= idleBefore {
+			delete(shard.accessTimes, tagKeyID)
+			index.evicted.Store(tagKeyID, struct{}{})
+			evicted++
+			return nil
+		}
+		mutable.Put(tagKeyID, tagIndex)
+		return nil
+	})
+	if evicted > 0 {
+		shard.mutable = mutable
+		index.evictedTagKeysCounter.Add(float64(evicted))
+	}
+}
+
 // loadTagValueIDsInKV loads series ids in kv store
 func (index *invertedIndex) loadSeriesIDsInKV(tagKeyID uint32, fn func(reader tagindex.InvertedReader) error) error {
 	// try get tag key id from kv store
@@ -398,15 +745,23 @@ func (index *invertedIndex) loadSeriesIDsInMem(tagKeyID uint32, fn func(tagIndex
 		}
 	}
 
-	// read data with read lock
-	index.rwMutex.RLock()
-	defer index.rwMutex.RUnlock()
+	if _, ok := index.evicted.LoadAndDelete(tagKeyID); ok {
+		// evicted tag key is read from kv store
+		index.reloadedTagKeysCounter.Incr()
+	}
+
+	// read data with read lock of tag key's shard
+	shard := index.getShard(tagKeyID)
+	shard.rwMutex.RLock()
+	defer shard.rwMutex.RUnlock()
+
+	shard.touch(tagKeyID, false)
 
 	//
-	getSeriesIDsIDs(index.mutable)
+	getSeriesIDsIDs(shard.mutable)
 
 	//
-	if index.immutable != nil {
-		getSeriesIDsIDs(index.immutable)
+	if shard.immutable != nil {
+		getSeriesIDsIDs(shard.immutable)
 	}
 }
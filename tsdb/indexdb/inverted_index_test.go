@@ -19,15 +19,22 @@ package indexdb
 
 import (
 	"fmt"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/lindb/roaring"
 	"github.com/stretchr/testify/assert"
+	"go.uber.org/atomic"
 
+	"github.com/lindb/lindb/config"
 	"github.com/lindb/lindb/kv"
 	"github.com/lindb/lindb/kv/table"
 	"github.com/lindb/lindb/kv/version"
+	"github.com/lindb/lindb/pkg/fasttime"
+	"github.com/lindb/lindb/pkg/ltoml"
+	"github.com/lindb/lindb/pkg/timeutil"
 	"github.com/lindb/lindb/tsdb/metadb"
 	"github.com/lindb/lindb/tsdb/tblstore/tagindex"
 )
@@ -53,59 +60,59 @@ func TestInvertedIndex_buildInvertIndex(t *testing.T) {
 
 	// case 1: get series ids by tag value ids
 	snapshot.EXPECT().FindReaders(gomock.Any()).Return(nil, nil)
-	seriesIDs, err := index.GetSeriesIDsByTagValueIDs(1, roaring.BitmapOf(1))
+	seriesIDs, err := index.GetSeriesIDsByTagValueIDs(1, roaring.BitmapOf(1), nil)
 	assert.NoError(t, err)
 	assert.Equal(t, roaring.BitmapOf(1, 2), seriesIDs)
 	snapshot.EXPECT().FindReaders(gomock.Any()).Return(nil, nil)
-	seriesIDs, err = index.GetSeriesIDsByTagValueIDs(2, roaring.BitmapOf(2))
+	seriesIDs, err = index.GetSeriesIDsByTagValueIDs(2, roaring.BitmapOf(2), nil)
 	assert.NoError(t, err)
 	assert.Equal(t, roaring.BitmapOf(2), seriesIDs)
 
 	// case 2: tag key is not exist
 	snapshot.EXPECT().FindReaders(gomock.Any()).Return(nil, nil)
-	seriesIDs, err = index.GetSeriesIDsByTagValueIDs(4, roaring.BitmapOf(1))
+	seriesIDs, err = index.GetSeriesIDsByTagValueIDs(4, roaring.BitmapOf(1), nil)
 	assert.NoError(t, err)
 	assert.Equal(t, roaring.New(), seriesIDs)
 
 	// case 3: tag value ids is not exist
 	snapshot.EXPECT().FindReaders(gomock.Any()).Return(nil, nil)
-	seriesIDs, err = index.GetSeriesIDsByTagValueIDs(1, roaring.BitmapOf(10, 20))
+	seriesIDs, err = index.GetSeriesIDsByTagValueIDs(1, roaring.BitmapOf(10, 20), nil)
 	assert.NoError(t, err)
 	assert.Equal(t, roaring.New(), seriesIDs)
 	// case 4: tag key not exist
 	snapshot.EXPECT().FindReaders(gomock.Any()).Return(nil, nil)
-	seriesIDs, err = index.GetSeriesIDsByTagValueIDs(4, roaring.BitmapOf(10, 20))
+	seriesIDs, err = index.GetSeriesIDsByTagValueIDs(4, roaring.BitmapOf(10, 20), nil)
 	assert.NoError(t, err)
 	assert.Equal(t, roaring.New(), seriesIDs)
 	// case 5: get series ids, get empty reader
 	snapshot.EXPECT().FindReaders(gomock.Any()).Return(nil, nil)
-	seriesIDs, err = index.GetSeriesIDsByTagValueIDs(1, roaring.BitmapOf(10, 20))
+	seriesIDs, err = index.GetSeriesIDsByTagValueIDs(1, roaring.BitmapOf(10, 20), nil)
 	assert.NoError(t, err)
 	assert.Equal(t, roaring.New(), seriesIDs)
 	// case 6: get kv readers err
 	snapshot.EXPECT().FindReaders(gomock.Any()).Return(nil, fmt.Errorf("err"))
-	seriesIDs, err = index.GetSeriesIDsByTagValueIDs(1, roaring.BitmapOf(10, 20))
+	seriesIDs, err = index.GetSeriesIDsByTagValueIDs(1, roaring.BitmapOf(10, 20), nil)
 	assert.Error(t, err)
 	assert.Nil(t, seriesIDs)
 	// case 6: reader get data err
 	snapshot.EXPECT().FindReaders(gomock.Any()).Return([]table.Reader{table.NewMockReader(ctrl)}, nil).AnyTimes()
-	reader.EXPECT().GetSeriesIDsByTagValueIDs(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("err"))
-	seriesIDs, err = index.GetSeriesIDsByTagValueIDs(1, roaring.BitmapOf(10, 20))
+	reader.EXPECT().GetSeriesIDsByTagValueIDs(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("err"))
+	seriesIDs, err = index.GetSeriesIDsByTagValueIDs(1, roaring.BitmapOf(10, 20), nil)
 	assert.Error(t, err)
 	assert.Nil(t, seriesIDs)
 	// case 6: reader get data success
-	reader.EXPECT().GetSeriesIDsByTagValueIDs(gomock.Any(), gomock.Any()).Return(roaring.BitmapOf(1, 2, 3), nil)
-	seriesIDs, err = index.GetSeriesIDsByTagValueIDs(1, roaring.BitmapOf(1, 2, 3))
+	reader.EXPECT().GetSeriesIDsByTagValueIDs(gomock.Any(), gomock.Any(), gomock.Any()).Return(roaring.BitmapOf(1, 2, 3), nil)
+	seriesIDs, err = index.GetSeriesIDsByTagValueIDs(1, roaring.BitmapOf(1, 2, 3), nil)
 	assert.NoError(t, err)
 	assert.Equal(t, roaring.BitmapOf(1, 2, 3), seriesIDs)
 
 	//case 7: get immutable data
 	tagIndex := NewMockTagIndex(ctrl)
-	idx.immutable = NewTagIndexStore()
-	idx.immutable.Put(50, tagIndex)
-	reader.EXPECT().GetSeriesIDsByTagValueIDs(gomock.Any(), gomock.Any()).Return(roaring.BitmapOf(), nil)
-	tagIndex.EXPECT().getSeriesIDsByTagValueIDs(gomock.Any()).Return(roaring.BitmapOf(10, 200, 3000))
-	seriesIDs, err = index.GetSeriesIDsByTagValueIDs(50, roaring.BitmapOf(1, 2, 3))
+	idx.getShard(50).immutable = NewTagIndexStore()
+	idx.getShard(50).immutable.Put(50, tagIndex)
+	reader.EXPECT().GetSeriesIDsByTagValueIDs(gomock.Any(), gomock.Any(), gomock.Any()).Return(roaring.BitmapOf(), nil)
+	tagIndex.EXPECT().getSeriesIDsByTagValueIDs(gomock.Any(), gomock.Any()).Return(roaring.BitmapOf(10, 200, 3000), nil)
+	seriesIDs, err = index.GetSeriesIDsByTagValueIDs(50, roaring.BitmapOf(1, 2, 3), nil)
 	assert.NoError(t, err)
 	assert.Equal(t, roaring.BitmapOf(10, 200, 3000), seriesIDs)
 }
@@ -245,7 +252,7 @@ func TestInvertedIndex_FlushInvertedIndexTo(t *testing.T) {
 	// mock data
 	idx := index.(*invertedIndex)
 	tagIndex := NewMockTagIndex(ctrl)
-	idx.mutable.Put(5, tagIndex)
+	idx.getShard(5).mutable.Put(5, tagIndex)
 
 	// case 1: flush tag index flush err, immutable cannot set nil
 	gomock.InOrder(
@@ -255,7 +262,7 @@ func TestInvertedIndex_FlushInvertedIndexTo(t *testing.T) {
 	)
 	err = index.Flush()
 	assert.Error(t, err)
-	assert.NotNil(t, idx.immutable)
+	assert.NotNil(t, idx.getShard(5).immutable)
 	// case 2: commit forward err
 	gomock.InOrder(
 		forwardFamily.EXPECT().NewFlusher().Return(nil),
@@ -265,7 +272,7 @@ func TestInvertedIndex_FlushInvertedIndexTo(t *testing.T) {
 	)
 	err = index.Flush()
 	assert.Error(t, err)
-	assert.NotNil(t, idx.immutable)
+	assert.NotNil(t, idx.getShard(5).immutable)
 	// case 3: commit inverted err
 	gomock.InOrder(
 		forwardFamily.EXPECT().NewFlusher().Return(nil),
@@ -276,7 +283,7 @@ func TestInvertedIndex_FlushInvertedIndexTo(t *testing.T) {
 	)
 	err = index.Flush()
 	assert.Error(t, err)
-	assert.NotNil(t, idx.immutable)
+	assert.NotNil(t, idx.getShard(5).immutable)
 	// case 4: commit success
 	gomock.InOrder(
 		forwardFamily.EXPECT().NewFlusher().Return(nil),
@@ -287,7 +294,301 @@ func TestInvertedIndex_FlushInvertedIndexTo(t *testing.T) {
 	)
 	err = index.Flush()
 	assert.NoError(t, err)
-	assert.Nil(t, idx.immutable)
+	assert.Nil(t, idx.getShard(5).immutable)
+}
+
+func TestInvertedIndex_Flush_shards(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		newInvertedFlusherFunc = tagindex.NewInvertedFlusher
+		newForwardFlusherFunc = tagindex.NewForwardFlusher
+		ctrl.Finish()
+	}()
+	inverted := tagindex.NewMockInvertedFlusher(ctrl)
+	newInvertedFlusherFunc = func(kvFlusher kv.Flusher) (tagindex.InvertedFlusher, error) {
+		return inverted, nil
+	}
+	forward := tagindex.NewMockForwardFlusher(ctrl)
+	newForwardFlusherFunc = func(kvFlusher kv.Flusher) (tagindex.ForwardFlusher, error) {
+		return forward, nil
+	}
+	family := kv.NewMockFamily(ctrl)
+	family.EXPECT().NewFlusher().Return(nil).AnyTimes()
+	meta := metadb.NewMockMetadata(ctrl)
+	meta.EXPECT().DatabaseName().Return("test").AnyTimes()
+	idx := newShardedInvertedIndex(meta, family, family, 2)
+	assert.Len(t, idx.shards, 2)
+	assert.Len(t, newShardedInvertedIndex(meta, family, family, 0).shards, 1)
+
+	// tag keys in different shards are flushed in order
+	var tagIndexes []*MockTagIndex
+	for tagKeyID := uint32(1); tagKeyID <= 3; tagKeyID++ {
+		tagIndex := NewMockTagIndex(ctrl)
+		idx.getShard(tagKeyID).mutable.Put(tagKeyID, tagIndex)
+		tagIndexes = append(tagIndexes, tagIndex)
+	}
+	assert.NotEqual(t, idx.getShard(1), idx.getShard(2))
+	gomock.InOrder(
+		tagIndexes[0].EXPECT().flush(uint32(1), gomock.Any(), gomock.Any()).Return(nil),
+		tagIndexes[1].EXPECT().flush(uint32(2), gomock.Any(), gomock.Any()).Return(nil),
+		tagIndexes[2].EXPECT().flush(uint32(3), gomock.Any(), gomock.Any()).Return(nil),
+		forward.EXPECT().Close().Return(nil),
+		inverted.EXPECT().Close().Return(nil),
+	)
+	assert.NoError(t, idx.Flush())
+	for _, shard := range idx.shards {
+		assert.Nil(t, shard.immutable)
+		assert.Zero(t, shard.mutable.Size())
+	}
+}
+
+func TestInvertedIndex_Flush_incremental(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		newInvertedFlusherFunc = tagindex.NewInvertedFlusher
+		newForwardFlusherFunc = tagindex.NewForwardFlusher
+		ctrl.Finish()
+	}()
+	inverted := tagindex.NewMockInvertedFlusher(ctrl)
+	newInvertedFlusherFunc = func(kvFlusher kv.Flusher) (tagindex.InvertedFlusher, error) {
+		return inverted, nil
+	}
+	forward := tagindex.NewMockForwardFlusher(ctrl)
+	newForwardFlusherFunc = func(kvFlusher kv.Flusher) (tagindex.ForwardFlusher, error) {
+		return forward, nil
+	}
+	family := kv.NewMockFamily(ctrl)
+	family.EXPECT().NewFlusher().Return(nil).AnyTimes()
+	forward.EXPECT().Close().Return(nil).AnyTimes()
+	inverted.EXPECT().Close().Return(nil).AnyTimes()
+	meta := metadb.NewMockMetadata(ctrl)
+	meta.EXPECT().DatabaseName().Return("test").AnyTimes()
+	idx := newShardedInvertedIndex(meta, family, family, 2)
+	idx.flushStrategy = config.IndexFlushStrategyIncremental
+	idx.flushChunkSize = 2
+
+	var flushed []uint32
+	put := func(tagKeyID uint32) {
+		tagIndex := NewMockTagIndex(ctrl)
+		tagIndex.EXPECT().flush(tagKeyID, gomock.Any(), gomock.Any()).DoAndReturn(
+			func(tagKeyID uint32, _ tagindex.ForwardFlusher, _ tagindex.InvertedFlusher) error {
+				flushed = append(flushed, tagKeyID)
+				return nil
+			})
+		idx.getShard(tagKeyID).mutable.Put(tagKeyID, tagIndex)
+	}
+	for tagKeyID := uint32(1); tagKeyID <= 5; tagKeyID++ {
+		put(tagKeyID)
+	}
+	// cycle 1: flush first chunk, immutable still serves read
+	assert.NoError(t, idx.Flush())
+	assert.Equal(t, []uint32{1, 2}, flushed)
+	assert.NotNil(t, idx.getShard(1).immutable)
+	// new data is not flushed until all dirty tag keys flushed
+	put(6)
+	// cycle 2
+	assert.NoError(t, idx.Flush())
+	assert.Equal(t, []uint32{1, 2, 3, 4}, flushed)
+	// cycle 3: all dirty tag keys flushed, clear immutable
+	assert.NoError(t, idx.Flush())
+	assert.Equal(t, []uint32{1, 2, 3, 4, 5}, flushed)
+	assert.Nil(t, idx.pending)
+	for _, shard := range idx.shards {
+		assert.Nil(t, shard.immutable)
+	}
+	// cycle 4: flush new data
+	assert.NoError(t, idx.Flush())
+	assert.Equal(t, []uint32{1, 2, 3, 4, 5, 6}, flushed)
+	assert.NoError(t, idx.Flush())
+
+	// flush all ignores chunk size
+	flushed = nil
+	for tagKeyID := uint32(1); tagKeyID <= 3; tagKeyID++ {
+		put(tagKeyID)
+	}
+	assert.NoError(t, idx.Flush())
+	put(4)
+	assert.NoError(t, idx.FlushAll())
+	assert.Equal(t, []uint32{1, 2, 3, 4}, flushed)
+	assert.Nil(t, idx.pending)
+	for _, shard := range idx.shards {
+		assert.Nil(t, shard.immutable)
+		assert.Zero(t, shard.mutable.Size())
+	}
+}
+
+func TestInvertedIndex_EstimateTagValues(t *testing.T) {
+	cfg := config.GlobalStorageConfig()
+	defer func() {
+		cfg.TSDB.TagCardinalityPrecision = 10
+	}()
+	metadataDB := &benchMetadataDatabase{tagKeyIDs: map[string]uint32{"host": 1, "zone": 2}}
+	meta := &benchMetadata{metadataDB: metadataDB, tagMetadata: &benchTagMetadata{}}
+
+	cfg.TSDB.TagCardinalityPrecision = 10
+	index := newShardedInvertedIndex(meta, nil, nil, 2)
+	zones := []string{"sh", "bj", "hz"}
+	for i := 0; i < 5000; i++ {
+		index.buildInvertIndex("ns", "name", mockTagKeyValueIterator(map[string]string{
+			"host": fmt.Sprintf("host-%d", i),
+			"zone": zones[i%len(zones)],
+		}), uint32(i+1))
+	}
+	hosts, ok := index.EstimateTagValues(1)
+	assert.True(t, ok)
+	// standard error is about 3.25% with precision 10
+	assert.InDelta(t, 5000, hosts, 500)
+	numOfZones, ok := index.EstimateTagValues(2)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(3), numOfZones)
+	_, ok = index.EstimateTagValues(3)
+	assert.False(t, ok)
+	assert.Equal(t, map[uint32]uint64{1: hosts, 2: 3}, index.estimateAllTagValues())
+
+	// case: tag keys exceed the estimation limit
+	maxEstimatedTagKeysPerShard = 1
+	index = newShardedInvertedIndex(meta, nil, nil, 1)
+	index.buildInvertIndex("ns", "name", mockTagKeyValueIterator(map[string]string{"host": "host-1"}), 1)
+	index.buildInvertIndex("ns", "name", mockTagKeyValueIterator(map[string]string{"zone": "sh"}), 2)
+	maxEstimatedTagKeysPerShard = 256
+	_, ok = index.EstimateTagValues(1)
+	assert.True(t, ok)
+	_, ok = index.EstimateTagValues(2)
+	assert.False(t, ok)
+	assert.Len(t, index.estimateAllTagValues(), 1)
+
+	// case: estimation disabled
+	cfg.TSDB.TagCardinalityPrecision = 0
+	index = newShardedInvertedIndex(meta, nil, nil, 2)
+	index.buildInvertIndex("ns", "name", mockTagKeyValueIterator(map[string]string{"host": "host-1"}), 1)
+	_, ok = index.EstimateTagValues(1)
+	assert.False(t, ok)
+	assert.Empty(t, index.estimateAllTagValues())
+}
+
+func TestInvertedIndex_evictIdleTagKeys(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	cfg := config.GlobalStorageConfig()
+	defer func() {
+		cfg.TSDB.IndexTagKeyIdleTTL = 0
+		ctrl.Finish()
+	}()
+	// case 1: eviction disabled
+	meta := metadb.NewMockMetadata(ctrl)
+	meta.EXPECT().DatabaseName().Return("evict-tag-keys").AnyTimes()
+	assert.NoError(t, newInvertedIndex(meta, nil, nil).evictIdleTagKeys(timeutil.Now()))
+
+	cfg.TSDB.IndexTagKeyIdleTTL = ltoml.Duration(time.Minute)
+	store, err := kv.NewStore("evict-tag-keys", kv.DefaultStoreOption(filepath.Join(t.TempDir(), "index")))
+	assert.NoError(t, err)
+	defer func() {
+		_ = store.Close()
+	}()
+	forwardFamily, err := store.CreateFamily("forward", kv.FamilyOption{Merger: string(tagindex.SeriesForwardMerger)})
+	assert.NoError(t, err)
+	invertedFamily, err := store.CreateFamily("inverted", kv.FamilyOption{Merger: string(tagindex.SeriesInvertedMerger)})
+	assert.NoError(t, err)
+	metadataDB := metadb.NewMockMetadataDatabase(ctrl)
+	tagMetadata := metadb.NewMockTagMetadata(ctrl)
+	meta.EXPECT().MetadataDatabase().Return(metadataDB).AnyTimes()
+	meta.EXPECT().TagMetadata().Return(tagMetadata).AnyTimes()
+	metadataDB.EXPECT().GenTagKeyID(gomock.Any(), gomock.Any(), "host").Return(uint32(1), nil).AnyTimes()
+	metadataDB.EXPECT().GenTagKeyID(gomock.Any(), gomock.Any(), "zone").Return(uint32(2), nil).AnyTimes()
+	tagValueIDs := map[string]uint32{"h1": 1, "h2": 2, "sh": 1, "bj": 2}
+	tagMetadata.EXPECT().GenTagValueID(gomock.Any(), gomock.Any()).DoAndReturn(func(_ uint32, tagValue string) (uint32, error) {
+		return tagValueIDs[tagValue], nil
+	}).AnyTimes()
+
+	index := newShardedInvertedIndex(meta, forwardFamily, invertedFamily, 2)
+	index.buildInvertIndex("ns", "name", mockTagKeyValueIterator(map[string]string{"host": "h1", "zone": "sh"}), 1)
+	index.buildInvertIndex("ns", "name", mockTagKeyValueIterator(map[string]string{"host": "h2", "zone": "bj"}), 2)
+
+	// case 2: host is idle, zone is accessed recently
+	now := fasttime.UnixMilliseconds() + time.Minute.Milliseconds()
+	index.getShard(2).accessTimes[2].Store(now)
+	assert.NoError(t, index.evictIdleTagKeys(now))
+	_, ok := index.getShard(1).mutable.Get(1)
+	assert.False(t, ok)
+	_, ok = index.getShard(2).mutable.Get(2)
+	assert.True(t, ok)
+	assert.Equal(t, float64(1), index.evictedTagKeysCounter.Get())
+
+	// case 3: evicted tag key is read from inverted family
+	seriesIDs, err := index.GetSeriesIDsByTagValueIDs(1, roaring.BitmapOf(1), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint32{1}, seriesIDs.ToArray())
+	seriesIDs, err = index.GetSeriesIDsByTagValueIDs(1, roaring.BitmapOf(1, 2), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint32{1, 2}, seriesIDs.ToArray())
+	seriesIDs, err = index.GetSeriesIDsForTag(1)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint32{1, 2}, seriesIDs.ToArray())
+	assert.Equal(t, float64(1), index.reloadedTagKeysCounter.Get())
+
+	// case 4: new series of evicted tag key is merged with flushed data
+	index.buildInvertIndex("ns", "name", mockTagKeyValueIterator(map[string]string{"host": "h1", "zone": "sh"}), 3)
+	seriesIDs, err = index.GetSeriesIDsByTagValueIDs(1, roaring.BitmapOf(1), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint32{1, 3}, seriesIDs.ToArray())
+	assert.NoError(t, index.FlushAll())
+	seriesIDs, err = index.GetSeriesIDsByTagValueIDs(1, roaring.BitmapOf(1), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint32{1, 3}, seriesIDs.ToArray())
+	seriesIDs, err = index.GetSeriesIDsByTagValueIDs(2, roaring.BitmapOf(1, 2), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint32{1, 2, 3}, seriesIDs.ToArray())
+}
+
+// benchMetadata generates tag key/value id without lock, so that benchmark measures inverted index only.
+type benchMetadata struct {
+	metadb.Metadata
+	metadataDB  *benchMetadataDatabase
+	tagMetadata *benchTagMetadata
+}
+
+func (m *benchMetadata) DatabaseName() string                      { return "bench" }
+func (m *benchMetadata) MetadataDatabase() metadb.MetadataDatabase { return m.metadataDB }
+func (m *benchMetadata) TagMetadata() metadb.TagMetadata           { return m.tagMetadata }
+
+type benchMetadataDatabase struct {
+	metadb.MetadataDatabase
+	tagKeyIDs map[string]uint32
+}
+
+func (m *benchMetadataDatabase) GenTagKeyID(_, _, tagKey string) (uint32, error) {
+	return m.tagKeyIDs[tagKey], nil
+}
+
+type benchTagMetadata struct {
+	metadb.TagMetadata
+}
+
+func (m *benchTagMetadata) GenTagValueID(_ uint32, _ string) (uint32, error) { return 1, nil }
+
+func BenchmarkInvertedIndex_buildInvertIndex(b *testing.B) {
+	tags := make(map[string]string)
+	metadataDB := &benchMetadataDatabase{tagKeyIDs: make(map[string]uint32)}
+	meta := &benchMetadata{metadataDB: metadataDB, tagMetadata: &benchTagMetadata{}}
+	for i := 0; i < 64; i++ {
+		tagKey := fmt.Sprintf("key-%d", i)
+		tags[tagKey] = "value"
+		metadataDB.tagKeyIDs[tagKey] = uint32(i + 1)
+	}
+	for _, numOfShards := range []int{1, defaultInvertedIndexShards} {
+		b.Run(fmt.Sprintf("shards-%d", numOfShards), func(b *testing.B) {
+			index := newShardedInvertedIndex(meta, nil, nil, numOfShards)
+			var seriesID atomic.Uint32
+			b.ReportAllocs()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				it := mockTagKeyValueIterator(tags)
+				for pb.Next() {
+					it.Reset()
+					index.buildInvertIndex("ns", "name", it, seriesID.Inc())
+				}
+			})
+		})
+	}
 }
 
 func prepareInvertedIndex(ctrl *gomock.Controller) InvertedIndex {
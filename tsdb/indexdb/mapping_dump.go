@@ -0,0 +1,108 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package indexdb
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/lindb/lindb/constants"
+)
+
+// MetricIDMappingDump represents the id mapping state of metric in memory cache and backend storage.
+type MetricIDMappingDump struct {
+	MetricID uint32                `json:"metricID"`
+	Cached   *MetricIDMappingState `json:"cached"`  // nil if not cached
+	Backend  *MetricIDMappingState `json:"backend"` // nil if not stored in backend
+	// true if the series id sequences of cache and backend are different
+	SequenceDiverged bool `json:"sequenceDiverged"`
+	// cached tags hash whose series id differs from backend, sorted by tags hash
+	Divergences []MappingDivergence `json:"divergences"`
+}
+
+// MetricIDMappingState represents the series id sequence and tags hash => series id entries of metric.
+type MetricIDMappingState struct {
+	Sequence uint32            `json:"sequence"`
+	Series   map[uint64]uint32 `json:"series"` // key: tags hash, value: series id
+}
+
+// MappingDivergence represents the different series id of cached tags hash between cache and backend,
+// backend series id is 0 if the tags hash is missing in backend,
+// which may be pending in series wal, not synced into backend yet.
+// Series only in backend are not divergences, because series ids are loaded into cache lazily.
+type MappingDivergence struct {
+	TagsHash        uint64 `json:"tagsHash"`
+	CachedSeriesID  uint32 `json:"cachedSeriesID"`
+	BackendSeriesID uint32 `json:"backendSeriesID"`
+}
+
+// DumpMetricIDMapping returns the cached and backend stored id mapping of metric with divergences,
+// used for diagnosing missing series.
+// Cached id mapping is copied under read lock, backend is loaded after releasing the lock,
+// so that creating series is not blocked by reading backend storage.
+func (db *indexDatabase) DumpMetricIDMapping(metricID uint32) (*MetricIDMappingDump, error) {
+	dump := &MetricIDMappingDump{MetricID: metricID}
+	db.rwMutex.RLock()
+	if cached, ok := db.metricID2Mapping[metricID]; ok {
+		dump.Cached = &MetricIDMappingState{
+			Sequence: cached.SeriesSequence(),
+			Series:   cached.SeriesIDs(),
+		}
+	}
+	db.rwMutex.RUnlock()
+
+	stored, err := db.backend.loadMetricIDMapping(metricID)
+	switch {
+	case errors.Is(err, constants.ErrNotFound):
+		// metric not synced into backend yet
+	case err != nil:
+		return nil, err
+	default:
+		if err := db.backend.loadSeriesIDs(stored); err != nil {
+			return nil, err
+		}
+		dump.Backend = &MetricIDMappingState{
+			Sequence: stored.SeriesSequence(),
+			Series:   stored.SeriesIDs(),
+		}
+	}
+	dump.diff()
+	return dump, nil
+}
+
+// diff finds the divergences between cached and backend stored id mapping.
+func (dump *MetricIDMappingDump) diff() {
+	if dump.Cached == nil || dump.Backend == nil {
+		// metric not cached or not stored, nothing to compare
+		return
+	}
+	dump.SequenceDiverged = dump.Cached.Sequence != dump.Backend.Sequence
+	// series only in backend are not loaded into cache yet, not divergences
+	for tagsHash, seriesID := range dump.Cached.Series {
+		if dump.Backend.Series[tagsHash] != seriesID {
+			dump.Divergences = append(dump.Divergences, MappingDivergence{
+				TagsHash:        tagsHash,
+				CachedSeriesID:  seriesID,
+				BackendSeriesID: dump.Backend.Series[tagsHash],
+			})
+		}
+	}
+	sort.Slice(dump.Divergences, func(i, j int) bool {
+		return dump.Divergences[i].TagsHash < dump.Divergences[j].TagsHash
+	})
+}
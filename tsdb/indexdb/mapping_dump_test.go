@@ -0,0 +1,117 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package indexdb
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/tsdb/metadb"
+)
+
+func TestIndexDatabase_DumpMetricIDMapping(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockMetadata := metadb.NewMockMetadata(ctrl)
+	mockMetadata.EXPECT().DatabaseName().Return("test").AnyTimes()
+	db, err := NewIndexDatabase(context.TODO(), t.TempDir(), mockMetadata, nil, nil)
+	assert.NoError(t, err)
+	defer func() {
+		assert.NoError(t, db.Close())
+	}()
+	db1 := db.(*indexDatabase)
+
+	// metric not exist
+	dump, err := db.DumpMetricIDMapping(1)
+	assert.NoError(t, err)
+	assert.Equal(t, &MetricIDMappingDump{MetricID: 1}, dump)
+
+	// series only in cache, pending in series wal
+	_, _, err = db.GetOrCreateSeriesID(1, 100)
+	assert.NoError(t, err)
+	dump, err = db.DumpMetricIDMapping(1)
+	assert.NoError(t, err)
+	assert.Equal(t, &MetricIDMappingState{Sequence: 1, Series: map[uint64]uint32{100: 1}}, dump.Cached)
+	assert.Nil(t, dump.Backend)
+	assert.False(t, dump.SequenceDiverged)
+	assert.Empty(t, dump.Divergences)
+
+	// sync series into backend
+	assert.NoError(t, db1.seriesWAL.Rotate())
+	assert.NoError(t, db1.seriesRecovery())
+	_, _, err = db.GetOrCreateSeriesID(1, 200)
+	assert.NoError(t, err)
+	dump, err = db.DumpMetricIDMapping(1)
+	assert.NoError(t, err)
+	assert.Equal(t, &MetricIDMappingState{Sequence: 2, Series: map[uint64]uint32{100: 1, 200: 2}}, dump.Cached)
+	assert.Equal(t, &MetricIDMappingState{Sequence: 1, Series: map[uint64]uint32{100: 1}}, dump.Backend)
+	assert.True(t, dump.SequenceDiverged)
+	assert.Equal(t, []MappingDivergence{{TagsHash: 200, CachedSeriesID: 2}}, dump.Divergences)
+
+	// cache diverged from backend
+	db1.rwMutex.Lock()
+	db1.metricID2Mapping[1] = newMetricIDMapping(1, 1)
+	db1.metricID2Mapping[1].AddSeriesID(100, 3)
+	db1.rwMutex.Unlock()
+	dump, err = db.DumpMetricIDMapping(1)
+	assert.NoError(t, err)
+	assert.False(t, dump.SequenceDiverged)
+	assert.Equal(t, []MappingDivergence{{TagsHash: 100, CachedSeriesID: 3, BackendSeriesID: 1}}, dump.Divergences)
+
+	// series not loaded into cache lazily are not divergences
+	db1.rwMutex.Lock()
+	db1.metricID2Mapping[1] = newMetricIDMapping(1, 1)
+	db1.rwMutex.Unlock()
+	dump, err = db.DumpMetricIDMapping(1)
+	assert.NoError(t, err)
+	assert.Empty(t, dump.Cached.Series)
+	assert.Equal(t, map[uint64]uint32{100: 1}, dump.Backend.Series)
+	assert.Empty(t, dump.Divergences)
+
+	// backend only
+	db1.rwMutex.Lock()
+	delete(db1.metricID2Mapping, 1)
+	db1.rwMutex.Unlock()
+	dump, err = db.DumpMetricIDMapping(1)
+	assert.NoError(t, err)
+	assert.Nil(t, dump.Cached)
+	assert.Equal(t, &MetricIDMappingState{Sequence: 1, Series: map[uint64]uint32{100: 1}}, dump.Backend)
+	assert.Empty(t, dump.Divergences)
+}
+
+func TestIndexDatabase_DumpMetricIDMapping_Err(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	backend := NewMockIDMappingBackend(ctrl)
+	db := &indexDatabase{backend: backend, metricID2Mapping: make(map[uint32]MetricIDMapping)}
+	backend.EXPECT().loadMetricIDMapping(gomock.Any()).Return(nil, fmt.Errorf("err"))
+	dump, err := db.DumpMetricIDMapping(1)
+	assert.Error(t, err)
+	assert.Nil(t, dump)
+	backend.EXPECT().loadMetricIDMapping(gomock.Any()).Return(newMetricIDMapping(1, 1), nil)
+	backend.EXPECT().loadSeriesIDs(gomock.Any()).Return(fmt.Errorf("err"))
+	dump, err = db.DumpMetricIDMapping(1)
+	assert.Error(t, err)
+	assert.Nil(t, dump)
+}
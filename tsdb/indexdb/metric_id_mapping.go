@@ -18,9 +18,10 @@
 package indexdb
 
 import (
-	"github.com/lindb/lindb/config"
-
+	"github.com/lindb/roaring"
 	"go.uber.org/atomic"
+
+	"github.com/lindb/lindb/config"
 )
 
 // MetricIDMapping represents the metric id mapping,
@@ -34,12 +35,18 @@ type MetricIDMapping interface {
 	GenSeriesID(tagsHash uint64) (seriesID uint32)
 	// RemoveSeriesID removes series id by tags hash
 	RemoveSeriesID(tagsHash uint64)
+	// RemoveSeriesIDs removes all tags hash which series id in spec series ids
+	RemoveSeriesIDs(seriesIDs *roaring.Bitmap)
 	// AddSeriesID adds the series id init cache
 	AddSeriesID(tagsHash uint64, seriesID uint32)
 	// SetMaxSeriesIDsLimit sets the max series ids limit
 	SetMaxSeriesIDsLimit(limit uint32)
 	// GetMaxSeriesIDsLimit returns the max series ids limit
 	GetMaxSeriesIDsLimit() uint32
+	// SeriesSequence returns the sequence of generated series ids, equals the number of series of metric
+	SeriesSequence() uint32
+	// SeriesIDs returns a copy of the cached tags hash => series id entries
+	SeriesIDs() map[uint64]uint32
 }
 
 // metricIDMapping implements MetricIDMapping interface
@@ -113,6 +120,16 @@ func (mim *metricIDMapping) RemoveSeriesID(tagsHash uint64) {
 	}
 }
 
+// RemoveSeriesIDs removes all tags hash which series id in spec series ids,
+// removed series id will not be recycled.
+func (mim *metricIDMapping) RemoveSeriesIDs(seriesIDs *roaring.Bitmap) {
+	for tagsHash, seriesID := range mim.hash2SeriesID {
+		if seriesIDs.Contains(seriesID) {
+			delete(mim.hash2SeriesID, tagsHash)
+		}
+	}
+}
+
 // SetMaxSeriesIDsLimit sets the max series ids limit
 func (mim *metricIDMapping) SetMaxSeriesIDsLimit(limit uint32) {
 	mim.maxSeriesIDsLimit.Store(limit)
@@ -122,3 +139,17 @@ func (mim *metricIDMapping) SetMaxSeriesIDsLimit(limit uint32) {
 func (mim *metricIDMapping) GetMaxSeriesIDsLimit() uint32 {
 	return mim.maxSeriesIDsLimit.Load()
 }
+
+// SeriesSequence returns the sequence of generated series ids, equals the number of series of metric
+func (mim *metricIDMapping) SeriesSequence() uint32 {
+	return mim.idSequence.Load()
+}
+
+// SeriesIDs returns a copy of the cached tags hash => series id entries
+func (mim *metricIDMapping) SeriesIDs() map[uint64]uint32 {
+	result := make(map[uint64]uint32, len(mim.hash2SeriesID))
+	for tagsHash, seriesID := range mim.hash2SeriesID {
+		result[tagsHash] = seriesID
+	}
+	return result
+}
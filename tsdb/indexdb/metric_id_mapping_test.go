@@ -20,6 +20,7 @@ package indexdb
 import (
 	"testing"
 
+	"github.com/lindb/roaring"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -35,6 +36,8 @@ func TestMetricIDMapping_GetOrCreateSeriesID(t *testing.T) {
 	assert.Equal(t, uint32(0), seriesID)
 	seriesID = idMapping.GenSeriesID(100)
 	assert.Equal(t, uint32(1), seriesID)
+	assert.Equal(t, uint32(1), idMapping.SeriesSequence())
+	assert.Equal(t, map[uint64]uint32{100: 1}, idMapping.SeriesIDs())
 	// get exist series id
 	seriesID, ok = idMapping.GetSeriesID(100)
 	assert.Equal(t, uint32(1), seriesID)
@@ -67,3 +70,17 @@ func TestMetricIDMapping_RemoveSeriesID(t *testing.T) {
 	assert.Equal(t, uint32(1), seriesID)
 	idMapping.RemoveSeriesID(1200)
 }
+
+func TestMetricIDMapping_RemoveSeriesIDs(t *testing.T) {
+	idMapping := newMetricIDMapping(10, 0)
+	_ = idMapping.GenSeriesID(100)
+	_ = idMapping.GenSeriesID(200)
+	idMapping.RemoveSeriesIDs(roaring.BitmapOf(1))
+	_, ok := idMapping.GetSeriesID(100)
+	assert.False(t, ok)
+	seriesID, ok := idMapping.GetSeriesID(200)
+	assert.True(t, ok)
+	assert.Equal(t, uint32(2), seriesID)
+	// removed series id not recycle
+	assert.Equal(t, uint32(3), idMapping.GenSeriesID(100))
+}
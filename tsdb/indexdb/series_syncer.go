@@ -0,0 +1,125 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package indexdb
+
+import (
+	"sync"
+)
+
+// seriesSyncer persists the series id mapping events of series wal into backend storage by workers.
+// Series are partitioned by metric id, so events of one metric are always saved by the same worker in order,
+// and wal decoding goes on while workers are saving previous events.
+//
+// NOTICE: not thread-safe, add/flush/close must be invoked by one goroutine(wal recovery).
+type seriesSyncer struct {
+	backend IDMappingBackend
+	events  []*mappingEvent      // pending events of each shard
+	workers []chan *mappingEvent // full events waiting for saving of each shard
+
+	pending sync.WaitGroup // events in flight
+	stopped sync.WaitGroup // running workers
+
+	mutex sync.Mutex
+	err   error // first error of saving
+}
+
+// newSeriesSyncer creates a series syncer with concurrency workers, at least 1 worker.
+func newSeriesSyncer(backend IDMappingBackend, concurrency int) *seriesSyncer {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	s := &seriesSyncer{
+		backend: backend,
+		events:  make([]*mappingEvent, concurrency),
+		workers: make([]chan *mappingEvent, concurrency),
+	}
+	for i := 0; i < concurrency; i++ {
+		s.events[i] = newMappingEvent()
+		s.workers[i] = make(chan *mappingEvent, 1)
+		s.stopped.Add(1)
+		go s.run(s.workers[i])
+	}
+	return s
+}
+
+// add adds series data, dispatches the event of shard to worker if full.
+func (s *seriesSyncer) add(metricID uint32, tagsHash uint64, seriesID uint32) error {
+	if err := s.getErr(); err != nil {
+		return err
+	}
+	shard := int(metricID % uint32(len(s.events)))
+	event := s.events[shard]
+	event.addSeriesID(metricID, tagsHash, seriesID)
+	if event.isFull() {
+		s.dispatch(shard)
+	}
+	return nil
+}
+
+// flush dispatches all pending events, then waits all events saved, returns the first error if saves fail.
+func (s *seriesSyncer) flush() error {
+	for shard := range s.events {
+		if !s.events[shard].isEmpty() {
+			s.dispatch(shard)
+		}
+	}
+	s.pending.Wait()
+	return s.getErr()
+}
+
+// close stops all workers, pending events which are not flushed will be dropped.
+func (s *seriesSyncer) close() {
+	for _, worker := range s.workers {
+		close(worker)
+	}
+	s.stopped.Wait()
+}
+
+// dispatch sends pending event of shard to worker, then resets it.
+func (s *seriesSyncer) dispatch(shard int) {
+	s.pending.Add(1)
+	s.workers[shard] <- s.events[shard]
+	s.events[shard] = newMappingEvent()
+}
+
+// run saves the events of worker until closed, skips saving after any error.
+func (s *seriesSyncer) run(worker chan *mappingEvent) {
+	defer s.stopped.Done()
+	for event := range worker {
+		if s.getErr() == nil {
+			if err := s.backend.saveMapping(event); err != nil {
+				s.setErr(err)
+			}
+		}
+		s.pending.Done()
+	}
+}
+
+func (s *seriesSyncer) getErr() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.err
+}
+
+func (s *seriesSyncer) setErr(err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.err == nil {
+		s.err = err
+	}
+}
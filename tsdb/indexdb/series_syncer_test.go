@@ -0,0 +1,101 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package indexdb
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeriesSyncer_flush(t *testing.T) {
+	backend, err := newIDMappingBackend(filepath.Join(t.TempDir(), "test"))
+	assert.NoError(t, err)
+	defer func() {
+		assert.NoError(t, backend.Close())
+	}()
+	syncer := newSeriesSyncer(backend, 4)
+	defer syncer.close()
+
+	for i := 1; i <= 3*full; i++ {
+		assert.NoError(t, syncer.add(uint32(i%10+1), uint64(i), uint32(i)))
+	}
+	assert.NoError(t, syncer.flush())
+	for i := 1; i <= 3*full; i++ {
+		seriesID, err := backend.getSeriesID(uint32(i%10+1), uint64(i))
+		assert.NoError(t, err)
+		assert.Equal(t, uint32(i), seriesID)
+	}
+	// case: flush without pending events
+	assert.NoError(t, syncer.flush())
+}
+
+func TestSeriesSyncer_err(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	backend := NewMockIDMappingBackend(ctrl)
+	backend.EXPECT().saveMapping(gomock.Any()).Return(fmt.Errorf("err")).AnyTimes()
+	// case: invalid concurrency
+	syncer := newSeriesSyncer(backend, 0)
+	defer syncer.close()
+	assert.Len(t, syncer.workers, 1)
+
+	assert.NoError(t, syncer.add(1, 1, 1))
+	assert.Error(t, syncer.flush())
+	// case: stop adding after save failure
+	assert.Error(t, syncer.add(1, 2, 2))
+}
+
+func BenchmarkSeriesSyncer(b *testing.B) {
+	// simulate series wal with 200K series of 1000 metrics
+	const (
+		metrics = 1000
+		series  = 200 * 1000
+	)
+	for _, concurrency := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("concurrency-%d", concurrency), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				backend, err := newIDMappingBackend(filepath.Join(b.TempDir(), "test"))
+				if err != nil {
+					b.Fatal(err)
+				}
+				b.StartTimer()
+
+				syncer := newSeriesSyncer(backend, concurrency)
+				for j := 0; j < series; j++ {
+					if err := syncer.add(uint32(j%metrics+1), uint64(j), uint32(j/metrics+1)); err != nil {
+						b.Fatal(err)
+					}
+				}
+				if err := syncer.flush(); err != nil {
+					b.Fatal(err)
+				}
+				syncer.close()
+
+				b.StopTimer()
+				_ = backend.Close()
+				b.StartTimer()
+			}
+		})
+	}
+}
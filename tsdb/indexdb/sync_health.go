@@ -0,0 +1,59 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package indexdb
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// maxSyncFailures represents the number of consecutive series wal sync failures which marks index database degraded.
+const maxSyncFailures = 3
+
+// ErrSyncDegraded represents index database cannot keep up syncing series wal into id mapping backend.
+var ErrSyncDegraded = errors.New("index database series sync degraded")
+
+// degradedDatabases stores the degraded index databases, key: path, value: reason.
+var degradedDatabases sync.Map
+
+// CheckSyncHealth checks if all index databases keep up syncing series wal,
+// returns ErrSyncDegraded with the degraded databases if not.
+func CheckSyncHealth() error {
+	var reasons []string
+	degradedDatabases.Range(func(key, value interface{}) bool {
+		reasons = append(reasons, fmt.Sprintf("%s: %s", key, value))
+		return true
+	})
+	if len(reasons) == 0 {
+		return nil
+	}
+	sort.Strings(reasons)
+	return fmt.Errorf("%w, %s", ErrSyncDegraded, strings.Join(reasons, "; "))
+}
+
+// markSyncHealth marks the sync health state of index database, reason is empty if healthy.
+func markSyncHealth(path, reason string) {
+	if reason == "" {
+		degradedDatabases.Delete(path)
+		return
+	}
+	degradedDatabases.Store(path, reason)
+}
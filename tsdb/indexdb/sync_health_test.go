@@ -0,0 +1,39 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package indexdb
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckSyncHealth(t *testing.T) {
+	markSyncHealth("db/1", "backlog")
+	markSyncHealth("db/2", "fail")
+	err := CheckSyncHealth()
+	assert.True(t, errors.Is(err, ErrSyncDegraded))
+	assert.Contains(t, err.Error(), "db/1: backlog; db/2: fail")
+	markSyncHealth("db/1", "")
+	markSyncHealth("db/2", "")
+	_, ok := degradedDatabases.Load("db/1")
+	assert.False(t, ok)
+	_, ok = degradedDatabases.Load("db/2")
+	assert.False(t, ok)
+}
@@ -34,12 +34,15 @@ type TagIndex interface {
 	GetGroupingScanner(seriesIDs *roaring.Bitmap) ([]series.GroupingScanner, error)
 	// buildInvertedIndex builds inverted index for tag value id
 	buildInvertedIndex(tagValueID uint32, seriesID uint32)
-	// getSeriesIDsByTagValueIDs returns series ids by tag value ids
-	getSeriesIDsByTagValueIDs(tagValueIDs *roaring.Bitmap) *roaring.Bitmap
+	// getSeriesIDsByTagValueIDs returns series ids by tag value ids, the union of series ids is accounted by budget,
+	// returns the series ids unioned even if fails, so that caller can release them from budget.
+	getSeriesIDsByTagValueIDs(tagValueIDs *roaring.Bitmap, budget *series.BitmapBudget) (*roaring.Bitmap, error)
 	// getValues returns the all tag values and series ids
 	getValues() *InvertedStore
 	// getAllSeriesIDs returns all series ids
 	getAllSeriesIDs() *roaring.Bitmap
+	// removeSeriesIDs removes series ids from tag value's inverted index
+	removeSeriesIDs(seriesIDs *roaring.Bitmap)
 	// flush flushes tag index under spec tag key,
 	// write series ids of tag key level with constants.TagValueIDForTag
 	flush(tagKeyID uint32, forward tagindex.ForwardFlusher, inverted tagindex.InvertedFlusher) error
@@ -108,7 +111,7 @@ func (index *tagIndex) buildInvertedIndex(tagValueID uint32, seriesID uint32) {
 }
 
 // getSeriesIDsByTagValueIDs returns series ids by tag value ids
-func (index *tagIndex) getSeriesIDsByTagValueIDs(tagValueIDs *roaring.Bitmap) *roaring.Bitmap {
+func (index *tagIndex) getSeriesIDsByTagValueIDs(tagValueIDs *roaring.Bitmap, budget *series.BitmapBudget) (*roaring.Bitmap, error) {
 
 	result := roaring.New()
 
@@ -128,10 +131,12 @@ func (index *tagIndex) getSeriesIDsByTagValueIDs(tagValueIDs *roaring.Bitmap) *r
 			lowTagValueID := it.Next()
 			// get the index of low tag value id in container
 			lowIdx := lowContainer.Rank(lowTagValueID)
-			result.Or(values[lowContainerIdx][lowIdx-1])
+			if err := budget.Or(result, values[lowContainerIdx][lowIdx-1]); err != nil {
+				return result, err
+			}
 		}
 	}
-	return result
+	return result, nil
 }
 
 // getAllSeriesIDs returns all series ids
@@ -139,6 +144,15 @@ func (index *tagIndex) getAllSeriesIDs() *roaring.Bitmap {
 	return index.forward.keys.Clone()
 }
 
+// removeSeriesIDs removes series ids from tag value's inverted index,
+// forward index keeps them, because it is filtered by series ids when grouping.
+func (index *tagIndex) removeSeriesIDs(seriesIDs *roaring.Bitmap) {
+	_ = index.inverted.WalkEntry(func(_ uint32, value *roaring.Bitmap) error {
+		value.AndNot(seriesIDs)
+		return nil
+	})
+}
+
 // getValues returns the all tag values and series ids
 func (index *tagIndex) getValues() *InvertedStore {
 	return index.inverted
@@ -72,9 +72,28 @@ func TestTagIndex_buildInvertedIndex(t *testing.T) {
 func TestTagIndex_getSeriesIDsByTagValueIDs(t *testing.T) {
 	tagIndex := prepareTagIdx()
 	// tag-value not exist
-	assert.Equal(t, roaring.New(), tagIndex.getSeriesIDsByTagValueIDs(roaring.BitmapOf(40, 50, 30)))
+	seriesIDs, err := tagIndex.getSeriesIDsByTagValueIDs(roaring.BitmapOf(40, 50, 30), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, roaring.New(), seriesIDs)
 	// tag-value exist
-	assert.Equal(t, roaring.BitmapOf(4), tagIndex.getSeriesIDsByTagValueIDs(roaring.BitmapOf(4)))
+	seriesIDs, err = tagIndex.getSeriesIDsByTagValueIDs(roaring.BitmapOf(4), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, roaring.BitmapOf(4), seriesIDs)
+	// union of tag values exceeds bitmap budget
+	budget := series.NewBitmapBudget(roaring.BitmapOf(1).GetSizeInBytes())
+	_, err = tagIndex.getSeriesIDsByTagValueIDs(roaring.BitmapOf(1, 2, 3, 4, 5, 6, 7, 8), budget)
+	assert.ErrorIs(t, err, series.ErrTooLargeBitmapMemory)
+}
+
+func TestTagIndex_removeSeriesIDs(t *testing.T) {
+	index := newTagIndex()
+	index.buildInvertedIndex(1, 1)
+	index.buildInvertedIndex(1, 2)
+	index.buildInvertedIndex(2, 3)
+	index.removeSeriesIDs(roaring.BitmapOf(2, 3))
+	seriesIDs, err := index.getSeriesIDsByTagValueIDs(roaring.BitmapOf(1, 2), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, roaring.BitmapOf(1), seriesIDs)
 }
 
 func TestTagIndex_getAllSeriesIDs(t *testing.T) {
@@ -0,0 +1,126 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package indexdb
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/series/tag"
+)
+
+// cachedTagKeys represents the tag keys/metric id of metric with the time got from metadata.
+type cachedTagKeys struct {
+	key         string
+	tags        []tag.Meta
+	metricID    uint32
+	hasMetricID bool
+	cachedAt    int64
+}
+
+// tagKeysCache caches the tag keys of metric got from metadata last time, which are used for
+// best-effort result when metadata is unavailable, evicts the least recently used metric if exceeds size.
+type tagKeysCache struct {
+	size    int
+	entries map[string]*list.Element // key: namespace/metric name
+	lru     *list.List               // front is the most recently used
+	mutex   sync.Mutex
+}
+
+// newTagKeysCache creates the tag keys cache with max entries.
+func newTagKeysCache(size int) *tagKeysCache {
+	if size <= 0 {
+		size = 1
+	}
+	return &tagKeysCache{
+		size:    size,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// get returns the copy of cached tag keys by key.
+func (c *tagKeysCache) get(key string) (cachedTagKeys, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return cachedTagKeys{}, false
+	}
+	c.lru.MoveToFront(elem)
+	return *elem.Value.(*cachedTagKeys), true
+}
+
+// putTagKeys caches the tag keys of metric, keeps the metric id cached before.
+func (c *tagKeysCache) putTagKeys(key string, tags []tag.Meta) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entry := c.getOrCreate(key)
+	entry.tags = tags
+	entry.cachedAt = timeutil.Now()
+}
+
+// putMetricID caches the metric id of metric if its tag keys are cached.
+func (c *tagKeysCache) putMetricID(key string, metricID uint32) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*cachedTagKeys)
+		entry.metricID = metricID
+		entry.hasMetricID = true
+	}
+}
+
+// remove removes the cached tag keys by key.
+func (c *tagKeysCache) remove(key string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		c.lru.Remove(elem)
+		delete(c.entries, key)
+	}
+}
+
+// len returns the number of cached metrics.
+func (c *tagKeysCache) len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.lru.Len()
+}
+
+// getOrCreate returns the entry of key, creates it and evicts the oldest if not exist, must be called with lock held.
+func (c *tagKeysCache) getOrCreate(key string) *cachedTagKeys {
+	if elem, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(elem)
+		return elem.Value.(*cachedTagKeys)
+	}
+	entry := &cachedTagKeys{key: key}
+	c.entries[key] = c.lru.PushFront(entry)
+	for c.lru.Len() > c.size {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cachedTagKeys).key)
+	}
+	return entry
+}
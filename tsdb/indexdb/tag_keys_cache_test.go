@@ -0,0 +1,65 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package indexdb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/series/tag"
+)
+
+func TestTagKeysCache(t *testing.T) {
+	cache := newTagKeysCache(2)
+	_, ok := cache.get("ns/a")
+	assert.False(t, ok)
+	// metric id not cached without tag keys
+	cache.putMetricID("ns/a", 1)
+	assert.Zero(t, cache.len())
+
+	cache.putTagKeys("ns/a", []tag.Meta{{ID: 1}})
+	cache.putMetricID("ns/a", 1)
+	cache.putTagKeys("ns/b", []tag.Meta{{ID: 2}})
+	// keep metric id when updating tag keys
+	cache.putTagKeys("ns/a", []tag.Meta{{ID: 1}, {ID: 3}})
+	entry, ok := cache.get("ns/a")
+	assert.True(t, ok)
+	assert.Equal(t, []tag.Meta{{ID: 1}, {ID: 3}}, entry.tags)
+	assert.True(t, entry.hasMetricID)
+	assert.Equal(t, uint32(1), entry.metricID)
+
+	// evict least recently used
+	cache.putTagKeys("ns/c", []tag.Meta{{ID: 4}})
+	assert.Equal(t, 2, cache.len())
+	_, ok = cache.get("ns/b")
+	assert.False(t, ok)
+	_, ok = cache.get("ns/a")
+	assert.True(t, ok)
+
+	cache.remove("ns/a")
+	cache.remove("ns/a")
+	_, ok = cache.get("ns/a")
+	assert.False(t, ok)
+	assert.Equal(t, 1, cache.len())
+
+	cache = newTagKeysCache(0)
+	cache.putTagKeys("ns/a", nil)
+	cache.putTagKeys("ns/b", nil)
+	assert.Equal(t, 1, cache.len())
+}
@@ -0,0 +1,215 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package indexdb
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/lindb/roaring"
+
+	"github.com/lindb/lindb/pkg/encoding"
+	"github.com/lindb/lindb/pkg/fileutil"
+)
+
+const tombstoneFile = "tombstone"
+
+// for testing
+var (
+	writeTombstoneFunc = ioutil.WriteFile
+	readTombstoneFunc  = ioutil.ReadFile
+)
+
+// tombstoneEntry represents the persist format of metric's deleted series ids.
+type tombstoneEntry struct {
+	MetricID  uint32   `json:"metricID"`
+	TagKeyIDs []uint32 `json:"tagKeyIDs"`
+	SeriesIDs []byte   `json:"seriesIDs"`
+	Purged    bool     `json:"purged"`
+}
+
+// metricTombstone represents the deleted series ids under metric.
+type metricTombstone struct {
+	tagKeyIDs []uint32
+	seriesIDs *roaring.Bitmap
+	purged    bool // if purged series ids from id mapping/memory index
+}
+
+// tombstone records the deleted series ids of metric, deleted series ids are invisible for query immediately,
+// then background worker purges them from id mapping backend/memory index incrementally.
+// because series ids in index files cannot be removed, tombstone keeps them for filtering after purged.
+type tombstone struct {
+	path      string
+	metrics   map[uint32]*metricTombstone // metric id => deleted series ids
+	tagKeyIDs map[uint32]uint32           // tag key id => metric id
+
+	rwMutex sync.RWMutex
+}
+
+// newTombstone creates a tombstone, then loads deleted series ids from tombstone file if exist.
+func newTombstone(parent string) (*tombstone, error) {
+	t := &tombstone{
+		path:      filepath.Join(parent, tombstoneFile),
+		metrics:   make(map[uint32]*metricTombstone),
+		tagKeyIDs: make(map[uint32]uint32),
+	}
+	if !fileutil.Exist(t.path) {
+		return t, nil
+	}
+	data, err := readTombstoneFunc(t.path)
+	if err != nil {
+		return nil, err
+	}
+	var entries []tombstoneEntry
+	if err := encoding.JSONUnmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		seriesIDs := roaring.New()
+		if err := seriesIDs.UnmarshalBinary(entry.SeriesIDs); err != nil {
+			return nil, fmt.Errorf("unmarshal tombstone of metric: %d, error: %w", entry.MetricID, err)
+		}
+		t.addMetric(entry.MetricID, entry.TagKeyIDs, seriesIDs, entry.Purged)
+	}
+	return t, nil
+}
+
+// isEmpty returns if no deleted series ids.
+func (t *tombstone) isEmpty() bool {
+	t.rwMutex.RLock()
+	defer t.rwMutex.RUnlock()
+
+	return len(t.metrics) == 0
+}
+
+// add adds deleted series ids under metric, then persists tombstone.
+func (t *tombstone) add(metricID uint32, tagKeyIDs []uint32, seriesIDs *roaring.Bitmap) error {
+	t.rwMutex.Lock()
+	defer t.rwMutex.Unlock()
+
+	t.addMetric(metricID, tagKeyIDs, seriesIDs, false)
+	return t.persist()
+}
+
+// addMetric adds deleted series ids under metric, need hold the lock.
+func (t *tombstone) addMetric(metricID uint32, tagKeyIDs []uint32, seriesIDs *roaring.Bitmap, purged bool) {
+	m, ok := t.metrics[metricID]
+	if !ok {
+		m = &metricTombstone{seriesIDs: roaring.New()}
+		t.metrics[metricID] = m
+	}
+	m.seriesIDs.Or(seriesIDs)
+	m.purged = purged
+	for _, tagKeyID := range tagKeyIDs {
+		if _, ok := t.tagKeyIDs[tagKeyID]; !ok {
+			m.tagKeyIDs = append(m.tagKeyIDs, tagKeyID)
+			t.tagKeyIDs[tagKeyID] = metricID
+		}
+	}
+}
+
+// filterByMetric removes deleted series ids of metric from series ids.
+func (t *tombstone) filterByMetric(metricID uint32, seriesIDs *roaring.Bitmap) *roaring.Bitmap {
+	t.rwMutex.RLock()
+	defer t.rwMutex.RUnlock()
+
+	if m, ok := t.metrics[metricID]; ok {
+		seriesIDs.AndNot(m.seriesIDs)
+	}
+	return seriesIDs
+}
+
+// filterByTagKey removes deleted series ids of metric which tag key belongs from series ids.
+func (t *tombstone) filterByTagKey(tagKeyID uint32, seriesIDs *roaring.Bitmap) *roaring.Bitmap {
+	t.rwMutex.RLock()
+	defer t.rwMutex.RUnlock()
+
+	if metricID, ok := t.tagKeyIDs[tagKeyID]; ok {
+		seriesIDs.AndNot(t.metrics[metricID].seriesIDs)
+	}
+	return seriesIDs
+}
+
+// nextPurge returns the next metric which need purge deleted series ids.
+func (t *tombstone) nextPurge() (metricID uint32, tagKeyIDs []uint32, seriesIDs *roaring.Bitmap, ok bool) {
+	t.rwMutex.RLock()
+	defer t.rwMutex.RUnlock()
+
+	for id, m := range t.metrics {
+		if !m.purged {
+			return id, m.tagKeyIDs, m.seriesIDs.Clone(), true
+		}
+	}
+	return 0, nil, nil, false
+}
+
+// markPurged marks the deleted series ids of metric purged, then persists tombstone.
+func (t *tombstone) markPurged(metricID uint32, seriesIDs *roaring.Bitmap) error {
+	t.rwMutex.Lock()
+	defer t.rwMutex.Unlock()
+
+	m, ok := t.metrics[metricID]
+	if !ok {
+		return nil
+	}
+	// new series ids deleted when purging, need purge again
+	if m.seriesIDs.GetCardinality() == seriesIDs.GetCardinality() {
+		m.purged = true
+	}
+	return t.persist()
+}
+
+// stats returns the number of deleted series ids and pending purge series ids.
+func (t *tombstone) stats() (total, pending uint64) {
+	t.rwMutex.RLock()
+	defer t.rwMutex.RUnlock()
+
+	for _, m := range t.metrics {
+		num := m.seriesIDs.GetCardinality()
+		total += num
+		if !m.purged {
+			pending += num
+		}
+	}
+	return
+}
+
+// persist writes tombstone into file, write tmp file first, if success then rename tmp => target file.
+func (t *tombstone) persist() error {
+	entries := make([]tombstoneEntry, 0, len(t.metrics))
+	for metricID, m := range t.metrics {
+		seriesIDs, err := m.seriesIDs.ToBytes()
+		if err != nil {
+			return err
+		}
+		entries = append(entries, tombstoneEntry{
+			MetricID:  metricID,
+			TagKeyIDs: m.tagKeyIDs,
+			SeriesIDs: seriesIDs,
+			Purged:    m.purged,
+		})
+	}
+	tmp := t.path + ".tmp"
+	if err := writeTombstoneFunc(tmp, encoding.JSONMarshal(entries), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, t.path)
+}
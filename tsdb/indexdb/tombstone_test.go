@@ -0,0 +1,115 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package indexdb
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lindb/roaring"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTombstone(t *testing.T) {
+	testPath := t.TempDir()
+	ts, err := newTombstone(testPath)
+	assert.NoError(t, err)
+	assert.True(t, ts.isEmpty())
+
+	err = ts.add(1, []uint32{10, 11}, roaring.BitmapOf(1, 2))
+	assert.NoError(t, err)
+	assert.False(t, ts.isEmpty())
+	assert.Equal(t, roaring.BitmapOf(3), ts.filterByMetric(1, roaring.BitmapOf(1, 2, 3)))
+	assert.Equal(t, roaring.BitmapOf(1, 2, 3), ts.filterByMetric(2, roaring.BitmapOf(1, 2, 3)))
+	assert.Equal(t, roaring.BitmapOf(3), ts.filterByTagKey(11, roaring.BitmapOf(1, 2, 3)))
+	assert.Equal(t, roaring.BitmapOf(1, 2, 3), ts.filterByTagKey(20, roaring.BitmapOf(1, 2, 3)))
+	total, pending := ts.stats()
+	assert.Equal(t, uint64(2), total)
+	assert.Equal(t, uint64(2), pending)
+
+	metricID, tagKeyIDs, seriesIDs, ok := ts.nextPurge()
+	assert.True(t, ok)
+	assert.Equal(t, uint32(1), metricID)
+	assert.Equal(t, []uint32{10, 11}, tagKeyIDs)
+	assert.Equal(t, roaring.BitmapOf(1, 2), seriesIDs)
+	// new series deleted when purging
+	err = ts.add(1, []uint32{10, 11}, roaring.BitmapOf(5))
+	assert.NoError(t, err)
+	err = ts.markPurged(1, seriesIDs)
+	assert.NoError(t, err)
+	_, _, seriesIDs, ok = ts.nextPurge()
+	assert.True(t, ok)
+	err = ts.markPurged(1, seriesIDs)
+	assert.NoError(t, err)
+	_, _, _, ok = ts.nextPurge()
+	assert.False(t, ok)
+	total, pending = ts.stats()
+	assert.Equal(t, uint64(3), total)
+	assert.Equal(t, uint64(0), pending)
+	// metric not exist
+	assert.NoError(t, ts.markPurged(2, seriesIDs))
+
+	// reload tombstone, purged series ids still filtered
+	ts, err = newTombstone(testPath)
+	assert.NoError(t, err)
+	assert.Equal(t, roaring.BitmapOf(3), ts.filterByTagKey(10, roaring.BitmapOf(1, 2, 3, 5)))
+	_, _, _, ok = ts.nextPurge()
+	assert.False(t, ok)
+}
+
+func TestTombstone_err(t *testing.T) {
+	testPath := t.TempDir()
+	defer func() {
+		writeTombstoneFunc = ioutil.WriteFile
+		readTombstoneFunc = ioutil.ReadFile
+	}()
+	ts, err := newTombstone(testPath)
+	assert.NoError(t, err)
+	writeTombstoneFunc = func(filename string, data []byte, perm os.FileMode) error {
+		return fmt.Errorf("err")
+	}
+	err = ts.add(1, []uint32{10}, roaring.BitmapOf(1, 2))
+	assert.Error(t, err)
+	writeTombstoneFunc = ioutil.WriteFile
+	err = ts.add(1, []uint32{10}, roaring.BitmapOf(1, 2))
+	assert.NoError(t, err)
+	// read file err
+	readTombstoneFunc = func(filename string) ([]byte, error) {
+		return nil, fmt.Errorf("err")
+	}
+	ts, err = newTombstone(testPath)
+	assert.Error(t, err)
+	assert.Nil(t, ts)
+	// bad json
+	readTombstoneFunc = func(filename string) ([]byte, error) {
+		return []byte("bad"), nil
+	}
+	ts, err = newTombstone(testPath)
+	assert.Error(t, err)
+	assert.Nil(t, ts)
+	// bad bitmap
+	readTombstoneFunc = ioutil.ReadFile
+	err = ioutil.WriteFile(filepath.Join(testPath, tombstoneFile), []byte(`[{"metricID":1,"seriesIDs":"YWJj"}]`), 0644)
+	assert.NoError(t, err)
+	ts, err = newTombstone(testPath)
+	assert.Error(t, err)
+	assert.Nil(t, ts)
+}
@@ -0,0 +1,219 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package indexdb
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/lindb/lindb/pkg/encoding"
+	"github.com/lindb/lindb/pkg/fileutil"
+	"github.com/lindb/lindb/pkg/logger"
+)
+
+const (
+	// hotMetricsFile stores the hottest metric ids persisted when closing index database.
+	hotMetricsFile = "hot_metrics"
+	// metricAccessesFactor bounds the num. of metrics tracked for ranking to the multiple of warmup top n.
+	metricAccessesFactor = 4
+)
+
+// for testing
+var (
+	writeHotMetricsFunc = ioutil.WriteFile
+	readHotMetricsFunc  = ioutil.ReadFile
+)
+
+var (
+	warmupMetricsCounterVec = indexDBScope.NewCounterVec("warmup_metrics", "db")
+	warmupFailCounterVec    = indexDBScope.NewCounterVec("warmup_fails", "db")
+)
+
+// ErrWarmingUp represents index database is loading hot metrics into cache.
+var ErrWarmingUp = errors.New("index database is warming up")
+
+// warmingDatabases stores the index databases which are warming up, key: path.
+var warmingDatabases sync.Map
+
+// CheckWarmup checks if all index databases complete warmup,
+// returns ErrWarmingUp with the warming databases if not.
+func CheckWarmup() error {
+	var paths []string
+	warmingDatabases.Range(func(key, _ interface{}) bool {
+		paths = append(paths, key.(string))
+		return true
+	})
+	if len(paths) == 0 {
+		return nil
+	}
+	sort.Strings(paths)
+	return fmt.Errorf("%w, %s", ErrWarmingUp, strings.Join(paths, "; "))
+}
+
+// loadHotMetrics loads the hottest metric ids persisted last time, returns nil if not exist.
+func loadHotMetrics(parent string) ([]uint32, error) {
+	path := filepath.Join(parent, hotMetricsFile)
+	if !fileutil.Exist(path) {
+		return nil, nil
+	}
+	data, err := readHotMetricsFunc(path)
+	if err != nil {
+		return nil, err
+	}
+	var metricIDs []uint32
+	if err := encoding.JSONUnmarshal(data, &metricIDs); err != nil {
+		return nil, err
+	}
+	return metricIDs, nil
+}
+
+// startWarmup loads the id mapping of hot metrics into cache in background,
+// index database is marked warming up until it completes.
+func (db *indexDatabase) startWarmup() {
+	metricIDs, err := loadHotMetrics(db.path)
+	if err != nil {
+		warmupFailCounterVec.WithTagValues(db.metadata.DatabaseName()).Incr()
+		indexLogger.Warn("load hot metrics error, skip warmup",
+			logger.String("db", db.path), logger.Error(err))
+		return
+	}
+	if len(metricIDs) > db.warmupTopN {
+		metricIDs = metricIDs[:db.warmupTopN]
+	}
+	if len(metricIDs) == 0 {
+		return
+	}
+	warmingDatabases.Store(db.path, struct{}{})
+	db.warmupWG.Add(1)
+	go func() {
+		defer func() {
+			warmingDatabases.Delete(db.path)
+			db.warmupWG.Done()
+		}()
+		db.warmup(metricIDs)
+	}()
+}
+
+// warmup loads metric id mapping with all series ids from backend storage for each metric.
+func (db *indexDatabase) warmup(metricIDs []uint32) {
+	dbName := db.metadata.DatabaseName()
+	for _, metricID := range metricIDs {
+		select {
+		case <-db.ctx.Done():
+			return
+		default:
+		}
+		if err := db.warmupMetric(metricID); err != nil {
+			warmupFailCounterVec.WithTagValues(dbName).Incr()
+			indexLogger.Warn("warmup metric id mapping error",
+				logger.String("db", db.path), logger.Uint32("metricID", metricID), logger.Error(err))
+			continue
+		}
+		warmupMetricsCounterVec.WithTagValues(dbName).Incr()
+	}
+	indexLogger.Info("warmup index database completed",
+		logger.String("db", db.path), logger.Int("metrics", len(metricIDs)))
+}
+
+// warmupMetric loads the metric id mapping into cache if not cached.
+// Loads from backend storage without holding the lock, so that write/query are not blocked,
+// then puts it into cache if the mapping is not created by write and no tombstone purged while loading.
+func (db *indexDatabase) warmupMetric(metricID uint32) error {
+	db.rwMutex.Lock()
+	// keep the warmed metric ranked, even if no write before closing
+	db.accessMetric(metricID, 0)
+	_, cached := db.metricID2Mapping[metricID]
+	notExist := db.backendMetricIDs != nil && !db.backendMetricIDs.Contains(metricID)
+	purgeSeq := db.purgeSeq
+	db.rwMutex.Unlock()
+
+	if cached || notExist {
+		return nil
+	}
+	metricIDMapping, err := db.backend.loadMetricIDMapping(metricID)
+	if err != nil {
+		return err
+	}
+	if err := db.backend.loadSeriesIDs(metricIDMapping); err != nil {
+		return err
+	}
+
+	db.rwMutex.Lock()
+	defer db.rwMutex.Unlock()
+
+	if _, ok := db.metricID2Mapping[metricID]; ok || purgeSeq != db.purgeSeq {
+		// mapping created by write or loaded mapping may be stale, write path loads it if need
+		return nil
+	}
+	db.metricID2Mapping[metricID] = metricIDMapping
+	return nil
+}
+
+// accessMetric records the write accesses of metric for ranking hot metrics, must be called with lock held.
+// The num. of tracked metrics is bounded, the least accessed metrics are dropped when exceeds.
+func (db *indexDatabase) accessMetric(metricID uint32, accesses uint64) {
+	if db.metricAccesses == nil {
+		return
+	}
+	if _, ok := db.metricAccesses[metricID]; !ok && len(db.metricAccesses) >= db.warmupTopN*metricAccessesFactor {
+		// keeps half of the max tracked metrics, so that dropping is amortized
+		for _, dropped := range db.rankMetrics()[db.warmupTopN*metricAccessesFactor/2:] {
+			delete(db.metricAccesses, dropped)
+		}
+	}
+	db.metricAccesses[metricID] += accesses
+}
+
+// rankMetrics returns the tracked metric ids sorted by write accesses desc, must be called with lock held.
+func (db *indexDatabase) rankMetrics() []uint32 {
+	metricIDs := make([]uint32, 0, len(db.metricAccesses))
+	for metricID := range db.metricAccesses {
+		metricIDs = append(metricIDs, metricID)
+	}
+	sort.Slice(metricIDs, func(i, j int) bool {
+		ci, cj := db.metricAccesses[metricIDs[i]], db.metricAccesses[metricIDs[j]]
+		if ci != cj {
+			return ci > cj
+		}
+		return metricIDs[i] < metricIDs[j]
+	})
+	return metricIDs
+}
+
+// saveHotMetrics persists the top n metric ids by write accesses, must be called with lock held.
+func (db *indexDatabase) saveHotMetrics() error {
+	if len(db.metricAccesses) == 0 {
+		return nil
+	}
+	metricIDs := db.rankMetrics()
+	if len(metricIDs) > db.warmupTopN {
+		metricIDs = metricIDs[:db.warmupTopN]
+	}
+	path := filepath.Join(db.path, hotMetricsFile)
+	tmp := path + ".tmp"
+	if err := writeHotMetricsFunc(tmp, encoding.JSONMarshal(metricIDs), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,190 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package indexdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/tsdb/metadb"
+)
+
+func TestCheckWarmup(t *testing.T) {
+	assert.NoError(t, CheckWarmup())
+	warmingDatabases.Store("db/1", struct{}{})
+	warmingDatabases.Store("db/2", struct{}{})
+	err := CheckWarmup()
+	assert.True(t, errors.Is(err, ErrWarmingUp))
+	assert.Contains(t, err.Error(), "db/1; db/2")
+	warmingDatabases.Delete("db/1")
+	warmingDatabases.Delete("db/2")
+	assert.NoError(t, CheckWarmup())
+}
+
+func TestIndexDatabase_warmup(t *testing.T) {
+	defer config.SetGlobalStorageConfig(config.NewDefaultStorageBase())
+	cfg := config.NewDefaultStorageBase()
+	cfg.TSDB.WarmupTopN = 2
+	config.SetGlobalStorageConfig(cfg)
+
+	testPath := t.TempDir()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	meta := metadb.NewMockMetadata(ctrl)
+	meta.EXPECT().DatabaseName().Return("test").AnyTimes()
+	db, err := NewIndexDatabase(context.TODO(), testPath, meta, nil, nil)
+	assert.NoError(t, err)
+	// metric 1 is the hottest, metric 3 is the coldest
+	for metricID, writes := range map[uint32]int{1: 3, 2: 2, 3: 1} {
+		for i := 0; i < writes; i++ {
+			_, _, err = db.GetOrCreateSeriesID(metricID, uint64(i+1))
+			assert.NoError(t, err)
+		}
+	}
+	assert.NoError(t, db.Close())
+	metricIDs, err := loadHotMetrics(testPath)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint32{1, 2}, metricIDs)
+
+	// reopen, load hot metrics into cache
+	db, err = NewIndexDatabase(context.TODO(), testPath, meta, nil, nil)
+	assert.NoError(t, err)
+	db1 := db.(*indexDatabase)
+	db1.warmupWG.Wait()
+	assert.NoError(t, CheckWarmup())
+	assert.Len(t, db1.metricID2Mapping, 2)
+	seriesID, ok := db1.metricID2Mapping[1].GetSeriesID(3)
+	assert.True(t, ok)
+	assert.Equal(t, uint32(3), seriesID)
+	_, ok = db1.metricID2Mapping[2].GetSeriesID(2)
+	assert.True(t, ok)
+	_, ok = db1.metricID2Mapping[3]
+	assert.False(t, ok)
+	// warmed metrics are kept even if no write
+	assert.NoError(t, db.Close())
+	metricIDs, err = loadHotMetrics(testPath)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint32{1, 2}, metricIDs)
+}
+
+func TestIndexDatabase_warmup_err(t *testing.T) {
+	defer func() {
+		readHotMetricsFunc = ioutil.ReadFile
+		writeHotMetricsFunc = ioutil.WriteFile
+		config.SetGlobalStorageConfig(config.NewDefaultStorageBase())
+	}()
+	cfg := config.NewDefaultStorageBase()
+	cfg.TSDB.WarmupTopN = 2
+	config.SetGlobalStorageConfig(cfg)
+
+	testPath := t.TempDir()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	meta := metadb.NewMockMetadata(ctrl)
+	meta.EXPECT().DatabaseName().Return("test").AnyTimes()
+	// case 1: bad hot metrics file, skip warmup
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(testPath, hotMetricsFile), []byte("bad"), 0644))
+	db, err := NewIndexDatabase(context.TODO(), testPath, meta, nil, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, CheckWarmup())
+	// case 2: write hot metrics err, close ok
+	_, _, err = db.GetOrCreateSeriesID(1, 1)
+	assert.NoError(t, err)
+	writeHotMetricsFunc = func(filename string, data []byte, perm os.FileMode) error {
+		return fmt.Errorf("err")
+	}
+	assert.NoError(t, db.Close())
+	writeHotMetricsFunc = ioutil.WriteFile
+	// case 3: read hot metrics err, skip warmup
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(testPath, hotMetricsFile), []byte("[1,2]"), 0644))
+	readHotMetricsFunc = func(filename string) ([]byte, error) {
+		return nil, fmt.Errorf("err")
+	}
+	db, err = NewIndexDatabase(context.TODO(), testPath, meta, nil, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, db.(*indexDatabase).metricID2Mapping)
+	readHotMetricsFunc = ioutil.ReadFile
+	// case 4: load metric id mapping err, skip metric
+	db1 := db.(*indexDatabase)
+	backend := NewMockIDMappingBackend(ctrl)
+	oldBackend := db1.backend
+	db1.backend = backend
+	db1.backendMetricIDs = nil
+	backend.EXPECT().loadMetricIDMapping(uint32(1)).Return(nil, fmt.Errorf("err"))
+	backend.EXPECT().loadMetricIDMapping(uint32(2)).Return(newMetricIDMapping(2, 0), nil)
+	backend.EXPECT().loadSeriesIDs(gomock.Any()).Return(fmt.Errorf("err"))
+	fails := warmupFailCounterVec.WithTagValues("test").Get()
+	db1.warmup([]uint32{1, 2})
+	assert.Equal(t, fails+2, warmupFailCounterVec.WithTagValues("test").Get())
+	assert.Empty(t, db1.metricID2Mapping)
+	// case 5: loads without lock, keeps the mapping created by write while loading
+	created := newMetricIDMapping(3, 5)
+	backend.EXPECT().loadMetricIDMapping(uint32(3)).DoAndReturn(func(metricID uint32) (MetricIDMapping, error) {
+		db1.rwMutex.Lock()
+		db1.metricID2Mapping[metricID] = created
+		db1.rwMutex.Unlock()
+		return newMetricIDMapping(metricID, 0), nil
+	})
+	// case 6: drops the mapping loaded while purging tombstone
+	backend.EXPECT().loadMetricIDMapping(uint32(4)).DoAndReturn(func(metricID uint32) (MetricIDMapping, error) {
+		db1.rwMutex.Lock()
+		db1.purgeSeq++
+		db1.rwMutex.Unlock()
+		return newMetricIDMapping(metricID, 0), nil
+	})
+	backend.EXPECT().loadSeriesIDs(gomock.Any()).Return(nil).Times(2)
+	db1.warmup([]uint32{3, 4})
+	assert.Equal(t, map[uint32]MetricIDMapping{3: created}, db1.metricID2Mapping)
+	db1.backend = oldBackend
+	assert.NoError(t, db.Close())
+}
+
+func TestIndexDatabase_accessMetric(t *testing.T) {
+	db := &indexDatabase{warmupTopN: 2, metricAccesses: make(map[uint32]uint64)}
+	// metric 1 is the hottest
+	db.accessMetric(1, 100)
+	for metricID := uint32(2); metricID <= 2*metricAccessesFactor; metricID++ {
+		db.accessMetric(metricID, uint64(metricID))
+	}
+	assert.Len(t, db.metricAccesses, 2*metricAccessesFactor)
+	// exceeds, drops the least accessed metrics
+	db.accessMetric(100, 1)
+	assert.Len(t, db.metricAccesses, metricAccessesFactor+1)
+	assert.Equal(t, uint64(100), db.metricAccesses[1])
+	assert.Equal(t, uint64(1), db.metricAccesses[100])
+	_, ok := db.metricAccesses[2]
+	assert.False(t, ok)
+	// existing metric doesn't trigger dropping
+	db.accessMetric(1, 1)
+	assert.Equal(t, uint64(101), db.metricAccesses[1])
+	// disabled
+	db = &indexDatabase{}
+	db.accessMetric(1, 1)
+	assert.Nil(t, db.metricAccesses)
+}
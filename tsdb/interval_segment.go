@@ -18,40 +18,110 @@
 package tsdb
 
 import (
+	"errors"
 	"fmt"
 	"path/filepath"
 	"sync"
 
+	"go.uber.org/atomic"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/kv"
 	"github.com/lindb/lindb/pkg/timeutil"
 )
 
 //go:generate mockgen -source=./interval_segment.go -destination=./interval_segment_mock.go -package=tsdb
 
+// for testing
+var (
+	newSegmentFunc = newSegment
+)
+
+// ErrOpeningSegments represents existing segments are being opened.
+var ErrOpeningSegments = errors.New("opening segments")
+
+// segmentOpenProgress tracks the existing segments being opened by all shards of the node.
+var segmentOpenProgress struct {
+	loading atomic.Bool  // engine is loading existing databases
+	total   atomic.Int64 // num. of segments need to be opened
+	opened  atomic.Int64 // num. of segments opened
+}
+
+// segmentOpenLimiter limits the num. of segments opened concurrently by all shards of engine,
+// created with segment-open-concurrency when used first time.
+var segmentOpenLimiter struct {
+	once  sync.Once
+	limit chan struct{}
+}
+
+// getSegmentOpenLimit returns the limit of segments opened concurrently shared by all shards of engine.
+func getSegmentOpenLimit() chan struct{} {
+	segmentOpenLimiter.once.Do(func() {
+		concurrency := config.GlobalStorageConfig().TSDB.SegmentOpenConcurrency
+		if concurrency <= 0 {
+			concurrency = 1
+		}
+		segmentOpenLimiter.limit = make(chan struct{}, concurrency)
+	})
+	return segmentOpenLimiter.limit
+}
+
+// startLoadingSegments marks engine starts loading existing databases, the progress accumulates the segments
+// of all shards until loading completed, so that the node isn't reported ready between shards.
+func startLoadingSegments() {
+	segmentOpenProgress.loading.Store(true)
+}
+
+// completeLoadingSegments marks engine completes loading existing databases, then resets the progress.
+func completeLoadingSegments() {
+	segmentOpenProgress.loading.Store(false)
+	segmentOpenProgress.total.Store(0)
+	segmentOpenProgress.opened.Store(0)
+}
+
+// CheckSegmentOpen checks if all existing segments are opened,
+// returns ErrOpeningSegments with the progress if not.
+func CheckSegmentOpen() error {
+	total := segmentOpenProgress.total.Load()
+	if total == 0 && !segmentOpenProgress.loading.Load() {
+		return nil
+	}
+	return fmt.Errorf("%w, opened %d of %d", ErrOpeningSegments, segmentOpenProgress.opened.Load(), total)
+}
+
 // IntervalSegment represents a interval segment, there are some segments in a shard.
 type IntervalSegment interface {
 	// GetOrCreateSegment creates new segment if not exist, if exist return it
 	GetOrCreateSegment(segmentName string) (Segment, error)
 	// getDataFamilies returns data family list by time range, return nil if not match
 	getDataFamilies(timeRange timeutil.TimeRange) []DataFamily
+	// NumOfSegments returns the number of segments
+	NumOfSegments() int
 	// Close closes interval segment, release resource
 	Close()
 }
 
 // intervalSegment implements IntervalSegment interface
 type intervalSegment struct {
-	shard    Shard
-	path     string
-	interval timeutil.Interval
-	segments sync.Map
+	shard       Shard
+	path        string
+	interval    timeutil.Interval
+	coordinator kv.Coordinator
+	events      *eventLog
+	segments    sync.Map
 
 	mutex sync.Mutex
 }
 
-// newIntervalSegment create interval segment based on interval/type/path etc.
+// newIntervalSegment create interval segment based on interval/type/path etc.,
+// flush/compaction of all segments are coordinated by coordinator,
+// lifecycle events of segments are recorded into events if it isn't nil.
 func newIntervalSegment(
 	shard Shard,
 	interval timeutil.Interval,
 	path string,
+	coordinator kv.Coordinator,
+	events *eventLog,
 ) (
 	segment IntervalSegment,
 	err error,
@@ -63,9 +133,11 @@ func newIntervalSegment(
 	}
 
 	intervalSegment := &intervalSegment{
-		shard:    shard,
-		path:     path,
-		interval: interval,
+		shard:       shard,
+		path:        path,
+		interval:    interval,
+		coordinator: coordinator,
+		events:      events,
 	}
 
 	defer func() {
@@ -76,20 +148,12 @@ func newIntervalSegment(
 	}()
 
 	// load segments if exist
-	// TODO too many kv store load???
-
 	segmentNames, err := listDir(path)
 	if err != nil {
 		return segment, err
 	}
-
-	for _, segmentName := range segmentNames {
-		seg, err := newSegment(shard, segmentName, intervalSegment.interval, filepath.Join(path, segmentName))
-		if err != nil {
-			err = fmt.Errorf("create segmenet error: %s", err)
-			return segment, err
-		}
-		intervalSegment.segments.Store(segmentName, seg)
+	if err = intervalSegment.openSegments(segmentNames, getSegmentOpenLimit()); err != nil {
+		return segment, err
 	}
 
 	// set segment
@@ -97,6 +161,54 @@ func newIntervalSegment(
 	return segment, err
 }
 
+// openSegments opens the existing segments in parallel, the segments opened concurrently by all shards
+// are bounded by the shared limit, returns the first error after all opening completed.
+func (s *intervalSegment) openSegments(segmentNames []string, limit chan struct{}) error {
+	numOfSegments := int64(len(segmentNames))
+	segmentOpenProgress.total.Add(numOfSegments)
+	var opened atomic.Int64
+	defer func() {
+		if segmentOpenProgress.loading.Load() {
+			// progress is reset after engine loading completed
+			return
+		}
+		segmentOpenProgress.total.Sub(numOfSegments)
+		segmentOpenProgress.opened.Sub(opened.Load())
+	}()
+
+	var (
+		wg       sync.WaitGroup
+		errMutex sync.Mutex
+		openErr  error
+	)
+	for _, segmentName := range segmentNames {
+		wg.Add(1)
+		limit <- struct{}{}
+		go func(segmentName string) {
+			defer func() {
+				<-limit
+				wg.Done()
+			}()
+			segmentPath := filepath.Join(s.path, segmentName)
+			seg, err := newSegmentFunc(s.shard, segmentName, s.interval, segmentPath, s.coordinator, s.events)
+			if err != nil {
+				errMutex.Lock()
+				if openErr == nil {
+					openErr = fmt.Errorf("create segmenet error: %s", err)
+				}
+				errMutex.Unlock()
+				return
+			}
+			s.events.record(SegmentOpenEvent, segmentPath, 0)
+			s.segments.Store(segmentName, seg)
+			opened.Inc()
+			segmentOpenProgress.opened.Inc()
+		}(segmentName)
+	}
+	wg.Wait()
+	return openErr
+}
+
 // GetOrCreateSegment creates new segment if not exist, if exist return it
 func (s *intervalSegment) GetOrCreateSegment(segmentName string) (Segment, error) {
 	segment, ok := s.getSegment(segmentName)
@@ -107,10 +219,12 @@ func (s *intervalSegment) GetOrCreateSegment(segmentName string) (Segment, error
 		segment, ok = s.getSegment(segmentName)
 		if !ok {
 			//
-			seg, err := newSegment(s.shard, segmentName, s.interval, filepath.Join(s.path, segmentName))
+			segmentPath := filepath.Join(s.path, segmentName)
+			seg, err := newSegment(s.shard, segmentName, s.interval, segmentPath, s.coordinator, s.events)
 			if err != nil {
 				return nil, fmt.Errorf("create segmenet error: %s", err)
 			}
+			s.events.record(SegmentCreateEvent, segmentPath, 0)
 			s.segments.Store(segmentName, seg)
 			return seg, nil
 		}
@@ -143,6 +257,16 @@ func (s *intervalSegment) getDataFamilies(timeRange timeutil.TimeRange) []DataFa
 	return result
 }
 
+// NumOfSegments returns the number of segments
+func (s *intervalSegment) NumOfSegments() int {
+	num := 0
+	s.segments.Range(func(_, _ interface{}) bool {
+		num++
+		return true
+	})
+	return num
+}
+
 // Close closes interval segment, release resource
 func (s *intervalSegment) Close() {
 	s.segments.Range(func(k, v interface{}) bool {
@@ -18,13 +18,18 @@
 package tsdb
 
 import (
+	"errors"
 	"fmt"
 	"path/filepath"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"go.uber.org/atomic"
 
+	"github.com/lindb/lindb/kv"
 	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/pkg/fileutil"
 	"github.com/lindb/lindb/pkg/timeutil"
@@ -40,7 +45,7 @@ func TestIntervalSegment_New(t *testing.T) {
 	mkDirIfNotExist = func(path string) error {
 		return fmt.Errorf("err")
 	}
-	s, err := newIntervalSegment(nil, timeutil.Interval(timeutil.OneSecond*10), segPath)
+	s, err := newIntervalSegment(nil, timeutil.Interval(timeutil.OneSecond*10), segPath, nil, nil)
 	assert.Error(t, err)
 	assert.Nil(t, s)
 	mkDirIfNotExist = fileutil.MkDirIfNotExist
@@ -49,13 +54,13 @@ func TestIntervalSegment_New(t *testing.T) {
 	listDir = func(path string) (strings []string, err error) {
 		return nil, fmt.Errorf("err")
 	}
-	s, err = newIntervalSegment(nil, timeutil.Interval(timeutil.OneSecond*10), segPath)
+	s, err = newIntervalSegment(nil, timeutil.Interval(timeutil.OneSecond*10), segPath, nil, nil)
 	assert.Error(t, err)
 	assert.Nil(t, s)
 	listDir = fileutil.ListDir
 
 	// case 3: create segment success
-	s, err = newIntervalSegment(nil, timeutil.Interval(timeutil.OneSecond*10), segPath)
+	s, err = newIntervalSegment(nil, timeutil.Interval(timeutil.OneSecond*10), segPath, nil, nil)
 	assert.NoError(t, err)
 	assert.NotNil(t, s)
 	assert.True(t, fileutil.Exist(segPath))
@@ -66,22 +71,130 @@ func TestIntervalSegment_New(t *testing.T) {
 		nil,
 		"20190903",
 		timeutil.Interval(timeutil.OneSecond*10),
-		filepath.Join(segPath, "20190903"))
+		filepath.Join(segPath, "20190903"),
+		nil, nil)
 	assert.NoError(t, err)
 	assert.NotNil(t, s1)
 	// case 5: cannot re-open kv-store
-	s, err = newIntervalSegment(nil, timeutil.Interval(timeutil.OneSecond*10), segPath)
+	s, err = newIntervalSegment(nil, timeutil.Interval(timeutil.OneSecond*10), segPath, nil, nil)
 	assert.Nil(t, s)
 	assert.Error(t, err)
 }
 
+func TestIntervalSegment_openSegments(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		newSegmentFunc = newSegment
+		ctrl.Finish()
+	}()
+
+	var segmentNames []string
+	for i := 1; i <= 10; i++ {
+		segmentNames = append(segmentNames, fmt.Sprintf("201909%02d", i))
+	}
+	s := &intervalSegment{path: createSegPath(t), interval: timeutil.Interval(timeutil.OneSecond * 10)}
+	var running, maxRunning atomic.Int32
+	newSegmentFunc = func(_ Shard, segmentName string, _ timeutil.Interval, _ string,
+		_ kv.Coordinator, _ *eventLog) (Segment, error) {
+		n := running.Inc()
+		defer running.Dec()
+		for {
+			max := maxRunning.Load()
+			if n <= max || maxRunning.CAS(max, n) {
+				break
+			}
+		}
+		// progress is exposed while opening
+		err := CheckSegmentOpen()
+		assert.True(t, errors.Is(err, ErrOpeningSegments))
+		assert.Contains(t, err.Error(), "of 10")
+		time.Sleep(time.Millisecond)
+		return NewMockSegment(ctrl), nil
+	}
+	// case 1: open all segments, concurrency is bounded
+	assert.NoError(t, s.openSegments(segmentNames, make(chan struct{}, 3)))
+	assert.Equal(t, 10, s.NumOfSegments())
+	assert.True(t, maxRunning.Load() <= 3)
+	assert.NoError(t, CheckSegmentOpen())
+
+	// case 2: open segment failure
+	s = &intervalSegment{path: createSegPath(t), interval: timeutil.Interval(timeutil.OneSecond * 10)}
+	newSegmentFunc = func(_ Shard, segmentName string, _ timeutil.Interval, _ string,
+		_ kv.Coordinator, _ *eventLog) (Segment, error) {
+		if segmentName == "20190905" {
+			return nil, fmt.Errorf("err")
+		}
+		return NewMockSegment(ctrl), nil
+	}
+	assert.Error(t, s.openSegments(segmentNames, make(chan struct{}, 1)))
+	assert.Equal(t, 9, s.NumOfSegments())
+	assert.NoError(t, CheckSegmentOpen())
+}
+
+func TestIntervalSegment_openSegments_SharedLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		newSegmentFunc = newSegment
+		completeLoadingSegments()
+		ctrl.Finish()
+	}()
+
+	var running, maxRunning atomic.Int32
+	newSegmentFunc = func(_ Shard, segmentName string, _ timeutil.Interval, _ string,
+		_ kv.Coordinator, _ *eventLog) (Segment, error) {
+		n := running.Inc()
+		defer running.Dec()
+		for {
+			max := maxRunning.Load()
+			if n <= max || maxRunning.CAS(max, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		return NewMockSegment(ctrl), nil
+	}
+	var segmentNames []string
+	for i := 1; i <= 10; i++ {
+		segmentNames = append(segmentNames, fmt.Sprintf("201909%02d", i))
+	}
+	// case 1: segments opened concurrently by all shards are bounded by shared limit
+	limit := make(chan struct{}, 2)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s := &intervalSegment{path: createSegPath(t), interval: timeutil.Interval(timeutil.OneSecond * 10)}
+			assert.NoError(t, s.openSegments(segmentNames, limit))
+		}()
+	}
+	wg.Wait()
+	assert.True(t, maxRunning.Load() <= 2)
+	assert.NoError(t, CheckSegmentOpen())
+
+	// case 2: progress accumulates the segments of all shards when engine loading
+	startLoadingSegments()
+	assert.True(t, errors.Is(CheckSegmentOpen(), ErrOpeningSegments))
+	for i := 0; i < 2; i++ {
+		s := &intervalSegment{path: createSegPath(t), interval: timeutil.Interval(timeutil.OneSecond * 10)}
+		assert.NoError(t, s.openSegments(segmentNames, limit))
+	}
+	err := CheckSegmentOpen()
+	assert.True(t, errors.Is(err, ErrOpeningSegments))
+	assert.Contains(t, err.Error(), "opened 20 of 20")
+	completeLoadingSegments()
+	assert.NoError(t, CheckSegmentOpen())
+}
+
 func TestIntervalSegment_GetOrCreateSegment(t *testing.T) {
 	segPath := createSegPath(t)
-	s, _ := newIntervalSegment(nil, timeutil.Interval(timeutil.OneSecond*10), segPath)
+	s, _ := newIntervalSegment(nil, timeutil.Interval(timeutil.OneSecond*10), segPath, nil, nil)
+	assert.Zero(t, s.NumOfSegments())
 	seg, err := s.GetOrCreateSegment("20190702")
 	assert.Nil(t, err)
 	assert.NotNil(t, seg)
 	assert.True(t, fileutil.Exist(filepath.Join(segPath, "20190702")))
+	assert.Equal(t, 1, s.NumOfSegments())
 
 	seg1, err1 := s.GetOrCreateSegment("20190702")
 	if err1 != nil {
@@ -96,7 +209,7 @@ func TestIntervalSegment_GetOrCreateSegment(t *testing.T) {
 
 	s.Close()
 
-	s, _ = newIntervalSegment(nil, timeutil.Interval(timeutil.OneSecond*10), segPath)
+	s, _ = newIntervalSegment(nil, timeutil.Interval(timeutil.OneSecond*10), segPath, nil, nil)
 
 	s1, ok := s.(*intervalSegment)
 	if ok {
@@ -119,7 +232,7 @@ func TestIntervalSegment_getDataFamilies(t *testing.T) {
 	shard := NewMockShard(ctrl)
 	shard.EXPECT().Database().Return(database).AnyTimes()
 	shard.EXPECT().ShardID().Return(models.ShardID(1)).AnyTimes()
-	s, _ := newIntervalSegment(shard, timeutil.Interval(timeutil.OneSecond*10), createSegPath(t))
+	s, _ := newIntervalSegment(shard, timeutil.Interval(timeutil.OneSecond*10), createSegPath(t), nil, nil)
 	segment1, _ := s.GetOrCreateSegment("20190902")
 	now, _ := timeutil.ParseTimestamp("20190902 19:10:48", "20060102 15:04:05")
 	_, _ = segment1.GetOrCreateDataFamily(now)
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"io"
 	"path/filepath"
+	"sync"
 
 	"go.uber.org/atomic"
 
@@ -60,6 +61,7 @@ type dataPointBuffer struct {
 	buf       [][]byte
 	pageIDSeq atomic.Int32
 	dirty     atomic.Bool
+	lock      sync.Mutex // pages are allocated concurrently by write partitions of memory database
 }
 
 // newDataPointBuffer creates data point buffer for writing points of metric.
@@ -75,6 +77,9 @@ func newDataPointBuffer(path string) (DataPointBuffer, error) {
 
 // AllocPage allocates the page buffer for writing data point
 func (d *dataPointBuffer) AllocPage() (buf []byte, err error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
 	pageID := d.pageIDSeq.Inc()
 	if pageID%pageCount == 0 {
 		if err := mkdirFunc(d.path); err != nil {
@@ -43,15 +43,21 @@ var (
 	memDBScope               = linmetric.NewScope("lindb.tsdb.memdb")
 	pageAllocatedCounterVec  = memDBScope.NewCounterVec("allocated_pages", "db")
 	pageAllocatedFailuresVec = memDBScope.NewCounterVec("allocated_page_failures", "db")
+	writeLockWaitTimerVec    = memDBScope.Scope("write_lock_wait_duration").NewHistogramVec("db")
 )
 
 // MemoryDatabase is a database-like concept of Shard as memTable in cassandra.
 type MemoryDatabase interface {
 	// AcquireWrite acquires writing data points
 	AcquireWrite()
-	// WithLock retrieves the lock of memdb, and returns the release function
-	WithLock() (release func())
-	// WriteRow must be called after WithLock
+	// NumOfPartitions returns the number of write partitions,
+	// writes to different partitions do not contend on lock.
+	NumOfPartitions() int
+	// PartitionOf returns the write partition which metric belongs to
+	PartitionOf(metricID uint32) int
+	// WithLock retrieves the lock of write partition, and returns the release function
+	WithLock(partition int) (release func())
+	// WriteRow must be called after WithLock of the row metric's partition
 	// Used for batch write
 	WriteRow(row *metric.StorageRow) error
 	// CompleteWrite completes writing data points
@@ -76,12 +82,14 @@ type MemoryDatabase interface {
 type memoryDBMetrics struct {
 	allocatedPages        *linmetric.BoundCounter
 	allocatedPageFailures *linmetric.BoundCounter
+	writeLockWaitTimer    *linmetric.BoundHistogram
 }
 
 func newMemoryDBMetrics(name string) *memoryDBMetrics {
 	return &memoryDBMetrics{
 		allocatedPages:        pageAllocatedCounterVec.WithTagValues(name),
 		allocatedPageFailures: pageAllocatedFailuresVec.WithTagValues(name),
+		writeLockWaitTimer:    writeLockWaitTimerVec.WithTagValues(name),
 	}
 }
 
@@ -90,6 +98,7 @@ type MemoryDatabaseCfg struct {
 	FamilyTime int64
 	Name       string
 	BufferMgr  BufferManager
+	Partitions int // number of write partitions, 1 if less than 1
 }
 
 // flushContext holds the context for flushing
@@ -106,11 +115,10 @@ type memoryDatabase struct {
 	familyTime int64
 	name       string
 
-	mStores *MetricBucketStore // metric id => mStoreINTF
-	buf     DataPointBuffer
+	partitions []*memPartition // write partitions, key: metric id % len(partitions)
+	buf        DataPointBuffer
 
 	writeCondition sync.WaitGroup
-	rwMutex        sync.RWMutex // lock of create metric store
 
 	metrics     memoryDBMetrics
 	createdTime int64
@@ -122,11 +130,19 @@ func NewMemoryDatabase(cfg MemoryDatabaseCfg) (MemoryDatabase, error) {
 	if err != nil {
 		return nil, err
 	}
+	numOfPartitions := cfg.Partitions
+	if numOfPartitions < 1 {
+		numOfPartitions = 1
+	}
+	partitions := make([]*memPartition, numOfPartitions)
+	for idx := range partitions {
+		partitions[idx] = &memPartition{mStores: NewMetricBucketStore()}
+	}
 	return &memoryDatabase{
 		familyTime:  cfg.FamilyTime,
 		name:        cfg.Name,
 		buf:         buf,
-		mStores:     NewMetricBucketStore(),
+		partitions:  partitions,
 		allocSize:   *atomic.NewInt64(0),
 		metrics:     *newMemoryDBMetrics(cfg.Name),
 		createdTime: fasttime.UnixNano(),
@@ -135,27 +151,39 @@ func NewMemoryDatabase(cfg MemoryDatabaseCfg) (MemoryDatabase, error) {
 
 func (md *memoryDatabase) FamilyTime() int64 { return md.familyTime }
 
-func (md *memoryDatabase) metricBucketSize() int {
+// memPartition stores the metric stores of metrics which belong to this write partition.
+type memPartition struct {
+	mStores *MetricBucketStore // metric id => mStoreINTF
+	rwMutex sync.RWMutex       // lock of create metric store
+}
+
+func (p *memPartition) metricBucketSize() int {
 	var size int
-	size += cap(md.mStores.values)*24 + 24
-	for idx := range md.mStores.values {
-		size += cap(md.mStores.values[idx])*8 + 24
+	size += cap(p.mStores.values)*24 + 24
+	for idx := range p.mStores.values {
+		size += cap(p.mStores.values[idx])*8 + 24
 	}
 	return size
 }
 
+// getPartition returns the write partition of metric.
+func (md *memoryDatabase) getPartition(metricID uint32) *memPartition {
+	return md.partitions[md.PartitionOf(metricID)]
+}
+
 // getOrCreateMStore returns the mStore by metricHash.
 func (md *memoryDatabase) getOrCreateMStore(metricID uint32) (mStore mStoreINTF) {
-	mStore, ok := md.mStores.Get(metricID)
+	partition := md.getPartition(metricID)
+	mStore, ok := partition.mStores.Get(metricID)
 	if !ok {
 		// not found need create new metric store
-		beforeMetricBucketSize := md.metricBucketSize()
+		beforeMetricBucketSize := partition.metricBucketSize()
 		mStore = newMetricStore()
 		// add metric-store size
 		md.allocSize.Add(int64(mStore.Capacity()))
 		// add metric-bucket increased
-		md.mStores.Put(metricID, mStore)
-		md.allocSize.Add(int64(md.metricBucketSize() - beforeMetricBucketSize))
+		partition.mStores.Put(metricID, mStore)
+		md.allocSize.Add(int64(partition.metricBucketSize() - beforeMetricBucketSize))
 	}
 	// found metric store in current memory database
 	return
@@ -171,9 +199,23 @@ func (md *memoryDatabase) CompleteWrite() {
 	md.writeCondition.Done()
 }
 
-func (md *memoryDatabase) WithLock() (release func()) {
-	md.rwMutex.Lock()
-	return md.rwMutex.Unlock
+// NumOfPartitions returns the number of write partitions
+func (md *memoryDatabase) NumOfPartitions() int {
+	return len(md.partitions)
+}
+
+// PartitionOf returns the write partition which metric belongs to
+func (md *memoryDatabase) PartitionOf(metricID uint32) int {
+	return int(metricID % uint32(len(md.partitions)))
+}
+
+// WithLock retrieves the lock of write partition, records the waiting time as contention
+func (md *memoryDatabase) WithLock(partition int) (release func()) {
+	p := md.partitions[partition]
+	start := time.Now()
+	p.rwMutex.Lock()
+	md.metrics.writeLockWaitTimer.UpdateSince(start)
+	return p.rwMutex.Unlock
 }
 
 func (md *memoryDatabase) WriteRow(row *metric.StorageRow) error {
@@ -312,17 +354,20 @@ func (md *memoryDatabase) FlushFamilyTo(flusher metricsdata.Flusher) error {
 	// waiting current writing complete
 	md.writeCondition.Wait()
 
-	var flushCtx flushContext
-	if err := md.mStores.WalkEntry(func(key uint32, value mStoreINTF) error {
-		flushCtx.metricID = key
-		if err := value.FlushMetricsDataTo(flusher, &flushContext{
-			metricID: key,
+	// flush metrics of all partitions in order, kv store requires keys are sorted
+	metricIDs := roaring.New()
+	for _, partition := range md.partitions {
+		metricIDs.Or(partition.mStores.Keys())
+	}
+	it := metricIDs.Iterator()
+	for it.HasNext() {
+		metricID := it.Next()
+		mStore, _ := md.getPartition(metricID).mStores.Get(metricID)
+		if err := mStore.FlushMetricsDataTo(flusher, &flushContext{
+			metricID: metricID,
 		}); err != nil {
 			return err
 		}
-		return nil
-	}); err != nil {
-		return err
 	}
 	return flusher.Close()
 }
@@ -335,10 +380,11 @@ func (md *memoryDatabase) Filter(
 	_ timeutil.TimeRange,
 	fields field.Metas,
 ) ([]flow.FilterResultSet, error) {
-	md.rwMutex.RLock()
-	defer md.rwMutex.RUnlock()
+	partition := md.getPartition(metricID)
+	partition.rwMutex.RLock()
+	defer partition.rwMutex.RUnlock()
 
-	mStore, ok := md.mStores.Get(metricID)
+	mStore, ok := partition.mStores.Get(metricID)
 	if !ok {
 		return nil, nil
 	}
@@ -364,5 +410,9 @@ func (md *memoryDatabase) Uptime() time.Duration {
 
 // Size returns the number of metric names.
 func (md *memoryDatabase) Size() int {
-	return md.mStores.Size()
+	size := 0
+	for _, partition := range md.partitions {
+		size += partition.mStores.Size()
+	}
+	return size
 }
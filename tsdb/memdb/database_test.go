@@ -22,11 +22,14 @@ import (
 	"fmt"
 	"math"
 	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/lindb/roaring"
 	"github.com/stretchr/testify/assert"
+	"go.uber.org/atomic"
 
 	"github.com/lindb/lindb/flow"
 	"github.com/lindb/lindb/pkg/timeutil"
@@ -130,7 +133,7 @@ func TestMemoryDatabase_Write(t *testing.T) {
 	assert.Zero(t, md.MemSize())
 
 	// load mock
-	md.mStores.Put(uint32(1), mockMStore)
+	md.partitions[0].mStores.Put(uint32(1), mockMStore)
 	// case 1: write ok
 	gomock.InOrder(
 		tStore.EXPECT().GetFStore(gomock.Any()).Return(fStore, true),
@@ -191,7 +194,7 @@ func TestMemoryDatabase_Write(t *testing.T) {
 	tStore.EXPECT().InsertFStore(gomock.Any()).AnyTimes()
 	mockMStore.EXPECT().AddField(gomock.Any(), gomock.Any()).AnyTimes()
 	mockMStore.EXPECT().SetSlot(gomock.Any()).AnyTimes()
-	releaseLock := md.WithLock()
+	releaseLock := md.WithLock(0)
 
 	row = protoToStorageRow(&protoMetricsV1.Metric{
 		Name:      "test1",
@@ -243,7 +246,7 @@ func TestMemoryDatabase_Write_err(t *testing.T) {
 	md := mdINTF.(*memoryDatabase)
 
 	// load mock
-	md.mStores.Put(uint32(1), mockMStore)
+	md.partitions[0].mStores.Put(uint32(1), mockMStore)
 	// case 1: write ok
 	tStore.EXPECT().GetFStore(gomock.Any()).Return(nil, false)
 
@@ -284,7 +287,7 @@ func TestMemoryDatabase_FlushFamilyTo(t *testing.T) {
 	flusher.EXPECT().Close().Return(nil).AnyTimes()
 	// mock mStore
 	mockMStore := NewMockmStoreINTF(ctrl)
-	md.mStores.Put(uint32(3333), mockMStore)
+	md.partitions[0].mStores.Put(uint32(3333), mockMStore)
 
 	// case 1: flusher ok
 	mockMStore.EXPECT().FlushMetricsDataTo(gomock.Any(), gomock.Any()).Return(nil)
@@ -327,7 +330,7 @@ func TestMemoryDatabase_Filter(t *testing.T) {
 	// mock mStore
 	mockMStore := NewMockmStoreINTF(ctrl)
 	mockMStore.EXPECT().Filter(gomock.Any(), gomock.Any(), gomock.Any()).Return([]flow.FilterResultSet{}, nil)
-	md.mStores.Put(uint32(3333), mockMStore)
+	md.partitions[0].mStores.Put(uint32(3333), mockMStore)
 	rs, err = md.Filter(uint32(3333), nil, timeutil.TimeRange{Start: now - 10, End: now + 20}, field.Metas{{ID: 1}})
 	assert.NoError(t, err)
 	assert.NotNil(t, rs)
@@ -335,3 +338,115 @@ func TestMemoryDatabase_Filter(t *testing.T) {
 	err = md.Close()
 	assert.NoError(t, err)
 }
+
+func TestMemoryDatabase_partitions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	bufferMgr := NewMockBufferManager(ctrl)
+	buf, err := newDataPointBuffer(filepath.Join(t.TempDir(), "db_dir"))
+	assert.NoError(t, err)
+	bufferMgr.EXPECT().AllocBuffer().Return(buf, nil).AnyTimes()
+
+	// case 1: partitions less than 1
+	mdINTF, err := NewMemoryDatabase(MemoryDatabaseCfg{BufferMgr: bufferMgr, Partitions: -1})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, mdINTF.NumOfPartitions())
+	assert.Equal(t, 0, mdINTF.PartitionOf(10))
+
+	// case 2: concurrent writes to different partitions
+	mdINTF, err = NewMemoryDatabase(MemoryDatabaseCfg{BufferMgr: bufferMgr, Partitions: 4})
+	assert.NoError(t, err)
+	assert.Equal(t, 4, mdINTF.NumOfPartitions())
+	assert.Equal(t, 2, mdINTF.PartitionOf(10))
+	var wait sync.WaitGroup
+	for metricID := uint32(1); metricID <= 8; metricID++ {
+		wait.Add(1)
+		go func(metricID uint32) {
+			defer wait.Done()
+			row := protoToStorageRow(&protoMetricsV1.Metric{
+				Name:      "test",
+				Namespace: "ns",
+				SimpleFields: []*protoMetricsV1.SimpleField{
+					{Name: "f1", Type: protoMetricsV1.SimpleFieldType_DELTA_SUM, Value: 10},
+				},
+			})
+			row.MetricID = metricID
+			row.FieldIDs = []field.ID{1}
+			release := mdINTF.WithLock(mdINTF.PartitionOf(metricID))
+			defer release()
+			for seriesID := uint32(0); seriesID < 100; seriesID++ {
+				row.SeriesID = seriesID
+				assert.NoError(t, mdINTF.WriteRow(row))
+			}
+		}(metricID)
+	}
+	wait.Wait()
+	assert.Equal(t, 8, mdINTF.Size())
+	assert.True(t, mdINTF.MemSize() > 0)
+	rs, err := mdINTF.Filter(5, roaring.BitmapOf(1, 2), timeutil.TimeRange{Start: 0, End: math.MaxInt64}, field.Metas{{ID: 1}})
+	assert.NoError(t, err)
+	assert.Len(t, rs, 1)
+
+	assert.NoError(t, mdINTF.Close())
+
+	// case 3: flush metrics of all partitions in order
+	mdINTF, err = NewMemoryDatabase(MemoryDatabaseCfg{BufferMgr: bufferMgr, Partitions: 4})
+	assert.NoError(t, err)
+	md := mdINTF.(*memoryDatabase)
+	var calls []*gomock.Call
+	for metricID := uint32(1); metricID <= 8; metricID++ {
+		mStore := NewMockmStoreINTF(ctrl)
+		md.getPartition(metricID).mStores.Put(metricID, mStore)
+		calls = append(calls,
+			mStore.EXPECT().FlushMetricsDataTo(gomock.Any(), &flushContext{metricID: metricID}).Return(nil))
+	}
+	gomock.InOrder(calls...)
+	flusher := metricsdata.NewMockFlusher(ctrl)
+	flusher.EXPECT().Close().Return(nil)
+	assert.NoError(t, md.FlushFamilyTo(flusher))
+	assert.NoError(t, md.Close())
+}
+
+func BenchmarkMemoryDatabase_WriteRow_partitions(b *testing.B) {
+	run := func(b *testing.B, partitions int) {
+		bufferMgr := NewBufferManager(filepath.Join(b.TempDir(), "data_temp"))
+		db, err := NewMemoryDatabase(MemoryDatabaseCfg{BufferMgr: bufferMgr, Partitions: partitions})
+		if err != nil {
+			b.Fatal(err)
+		}
+		defer func() {
+			_ = db.Close()
+		}()
+		var metricSeq atomic.Uint32
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			// each goroutine writes disjoint series of its own metric
+			metricID := metricSeq.Inc()
+			row := protoToStorageRow(&protoMetricsV1.Metric{
+				Name:      "test",
+				Namespace: "ns",
+				SimpleFields: []*protoMetricsV1.SimpleField{
+					{Name: "f1", Type: protoMetricsV1.SimpleFieldType_DELTA_SUM, Value: 10},
+				},
+			})
+			row.MetricID = metricID
+			row.FieldIDs = []field.ID{1}
+			partition := db.PartitionOf(metricID)
+			i := 0
+			for pb.Next() {
+				row.SeriesID = uint32(i % 10000)
+				row.SlotIndex = uint16(i % 60)
+				release := db.WithLock(partition)
+				_ = db.WriteRow(row)
+				release()
+				i++
+			}
+		})
+	}
+	b.Run("striping-off", func(b *testing.B) {
+		run(b, 1)
+	})
+	b.Run("striping-on", func(b *testing.B) {
+		run(b, 16)
+	})
+}
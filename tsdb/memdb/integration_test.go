@@ -46,7 +46,7 @@ func BenchmarkMemoryDatabase_write(b *testing.B) {
 		_ = http.ListenAndServe("0.0.0.0:6060", nil)
 	}()
 	// batch write
-	release := db.WithLock()
+	release := db.WithLock(db.PartitionOf(1))
 
 	row := protoToStorageRow(&protoMetricsV1.Metric{
 		Name:      "test",
@@ -82,7 +82,7 @@ func BenchmarkMemoryDatabase_write(b *testing.B) {
 		row.SeriesID = uint32(i)
 		row.SlotIndex = uint16(i % 1024)
 		row.FieldIDs = []field.ID{1}
-		release := db.WithLock()
+		release := db.WithLock(db.PartitionOf(1))
 		_ = db.WriteRow(row)
 		release()
 	}
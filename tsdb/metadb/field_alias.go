@@ -0,0 +1,88 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package metadb
+
+import (
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/pkg/logger"
+	"github.com/lindb/lindb/series/field"
+	metricchecker "github.com/lindb/lindb/series/metric"
+)
+
+// fieldAliases represents the field aliases of metrics,
+// key: namespace + delimiter + metric-name, value: new field name => old field name.
+type fieldAliases map[string]map[field.Name]field.Name
+
+// newFieldAliases creates the field aliases from config, invalid alias is skipped.
+func newFieldAliases(aliases []string) fieldAliases {
+	result := make(fieldAliases)
+	for _, alias := range aliases {
+		fieldAlias, err := config.ParseFieldAlias(alias)
+		if err != nil {
+			metaLogger.Warn("skip invalid field alias", logger.String("alias", alias), logger.Error(err))
+			continue
+		}
+		namespace, metricName := ParseNamespaceMetric(fieldAlias.Metric)
+		key := metricchecker.JoinNamespaceMetric(namespace, metricName)
+		fields, ok := result[key]
+		if !ok {
+			fields = make(map[field.Name]field.Name)
+			result[key] = fields
+		}
+		fields[field.Name(fieldAlias.Field)] = field.Name(fieldAlias.AliasOf)
+	}
+	return result
+}
+
+// aliasOf returns the old field name if field name is alias of metric.
+func (aliases fieldAliases) aliasOf(key string, fieldName field.Name) (field.Name, bool) {
+	fields, ok := aliases[key]
+	if !ok {
+		return "", false
+	}
+	oldName, ok := fields[fieldName]
+	return oldName, ok
+}
+
+// rename renames the old fields to the new field names of metric,
+// the old field is kept if the new field exists, returns the input fields if metric has no alias.
+func (aliases fieldAliases) rename(key string, fields []field.Meta) []field.Meta {
+	metricAliases, ok := aliases[key]
+	if !ok {
+		return fields
+	}
+	names := make(map[field.Name]struct{}, len(fields))
+	for _, f := range fields {
+		names[f.Name] = struct{}{}
+	}
+	oldToNew := make(map[field.Name]field.Name, len(metricAliases))
+	for newName, oldName := range metricAliases {
+		if _, ok := names[newName]; ok {
+			continue
+		}
+		oldToNew[oldName] = newName
+	}
+	result := make([]field.Meta, len(fields))
+	for idx, f := range fields {
+		if newName, ok := oldToNew[f.Name]; ok {
+			f.Name = newName
+		}
+		result[idx] = f
+	}
+	return result
+}
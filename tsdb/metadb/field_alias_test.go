@@ -0,0 +1,56 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package metadb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/series/field"
+	metricchecker "github.com/lindb/lindb/series/metric"
+)
+
+func TestFieldAliases(t *testing.T) {
+	aliases := newFieldAliases([]string{"cpu:usage_total=usage", "ns|cpu:idle_total=idle", "bad-alias"})
+	cpuKey := metricchecker.JoinNamespaceMetric(constants.DefaultNamespace, "cpu")
+	nsCPUKey := metricchecker.JoinNamespaceMetric("ns", "cpu")
+
+	oldName, ok := aliases.aliasOf(cpuKey, "usage_total")
+	assert.True(t, ok)
+	assert.Equal(t, field.Name("usage"), oldName)
+	_, ok = aliases.aliasOf(cpuKey, "idle_total")
+	assert.False(t, ok)
+	oldName, ok = aliases.aliasOf(nsCPUKey, "idle_total")
+	assert.True(t, ok)
+	assert.Equal(t, field.Name("idle"), oldName)
+	_, ok = aliases.aliasOf("mem", "usage_total")
+	assert.False(t, ok)
+
+	// rename old field
+	fields := []field.Meta{{ID: 1, Name: "usage", Type: field.SumField}, {ID: 2, Name: "load", Type: field.GaugeField}}
+	assert.Equal(t, []field.Meta{{ID: 1, Name: "usage_total", Type: field.SumField}, {ID: 2, Name: "load", Type: field.GaugeField}},
+		aliases.rename(cpuKey, fields))
+	assert.Equal(t, field.Name("usage"), fields[0].Name)
+	// metric without alias
+	assert.Equal(t, fields, aliases.rename("mem", fields))
+	// new field exists, old field kept
+	fields = []field.Meta{{ID: 1, Name: "usage", Type: field.SumField}, {ID: 2, Name: "usage_total", Type: field.SumField}}
+	assert.Equal(t, fields, aliases.rename(cpuKey, fields))
+}
@@ -24,12 +24,17 @@ import (
 	"github.com/lindb/lindb/series"
 	"github.com/lindb/lindb/series/field"
 	"github.com/lindb/lindb/series/tag"
+
+	"github.com/lindb/roaring"
 )
 
 //go:generate mockgen -source ./interface.go -destination=./interface_mock.go -package=metadb
 
 var metaLogger = logger.GetLogger("tsdb", "MetaDB")
 
+// UnresolvedName represents the name of tag key/value id which cannot be resolved.
+const UnresolvedName = "<unresolved>"
+
 // IDGenerator generates unique ID numbers for metric, tag and field.
 type IDGenerator interface {
 	// GenMetricID generates the metric id in the memory
@@ -53,8 +58,16 @@ type IDGetter interface {
 	// GetAllTagKeys returns the all tag keys by namespace/metric name,
 	// if not exist return  constants.ErrMetricIDNotFound, constants.ErrMetricBucketNotFound
 	GetAllTagKeys(namespace, metricName string) (tags []tag.Meta, err error)
+	// ResolveTagKeyIDs resolves the tag keys by tag key ids of namespace/metric name in one call,
+	// the tag key of unknown tag key id is UnresolvedName,
+	// if metric not exist return constants.ErrMetricIDNotFound
+	ResolveTagKeyIDs(namespace, metricName string, tagKeyIDs []uint32) (tagKeys map[uint32]string, err error)
 	// GetField gets the field meta by namespace/metric name/field name, if not exist return series.ErrNotFound
 	GetField(namespace, metricName string, fieldName field.Name) (field field.Meta, err error)
+	// GetFields gets the fields which field name resolves to, includes the field of field name and
+	// the old field if field name is alias of it, all named with field name, data of them need be merged,
+	// if not exist return series.ErrNotFound
+	GetFields(namespace, metricName string, fieldName field.Name) (fields field.Metas, err error)
 	// GetAllFields returns the all visible fields by namespace/metric name,
 	// if not exist return series.ErrNotFound
 	GetAllFields(namespace, metricName string) (fields []field.Meta, err error)
@@ -73,6 +86,14 @@ type Metadata interface {
 	TagMetadata() TagMetadata
 	// Flush flushes the metadata to disk
 	Flush() error
+	// Preload loads the tag keys and tag value dictionaries of metrics into memory,
+	// metric in spec namespace is declared as "namespace|metric-name", default namespace if omitted.
+	Preload(metrics []string) error
+	// Export returns the metadata of metrics by metric ids, includes fields, tag keys and tag values.
+	Export(metricIDs *roaring.Bitmap) ([]MetricSnapshot, error)
+	// Import installs the metadata of metrics if there is no metadata in database,
+	// else checks if the ids of metadata are same as database's, returns constants.ErrIncompatibleMetadata if not.
+	Import(metrics []MetricSnapshot) error
 }
 
 // MetadataDatabase represents the metadata storage includes namespace/metric metadata
@@ -84,6 +105,14 @@ type MetadataDatabase interface {
 
 	// SuggestNamespace suggests the namespace by namespace's prefix
 	SuggestNamespace(prefix string, limit int) (namespaces []string, err error)
+	// PreloadMetric loads the metric metadata into memory cache if not cached, returns all tag keys of metric,
+	// if not exist return constants.ErrNotFound
+	PreloadMetric(namespace, metricName string) (tags []tag.Meta, err error)
 	// Sync syncs the pending metadata update event
 	Sync() error
+	// ExportMetrics returns the metadata of metrics by metric ids, includes fields and tag keys.
+	ExportMetrics(metricIDs *roaring.Bitmap) ([]MetricSnapshot, error)
+	// ImportMetrics installs the metadata of metrics with same ids if there is no metadata, returns true,
+	// else checks if the ids of metadata are same as current's, returns constants.ErrIncompatibleMetadata if not.
+	ImportMetrics(metrics []MetricSnapshot) (installed bool, err error)
 }
@@ -19,7 +19,9 @@ package metadb
 
 import (
 	"context"
+	"sync"
 
+	"github.com/lindb/lindb/config"
 	"github.com/lindb/lindb/kv"
 )
 
@@ -31,6 +33,9 @@ type metadata struct {
 	metadataDatabase MetadataDatabase
 	// 标签元数据
 	tagMetadata      TagMetadata
+
+	ctx       context.Context
+	preloadWG sync.WaitGroup // wait group of preload goroutine
 }
 
 // NewMetadata creates a metadata
@@ -39,11 +44,14 @@ func NewMetadata(ctx context.Context, databaseName, parent string, tagFamily kv.
 	if err != nil {
 		return nil, err
 	}
-	return &metadata{
+	m := &metadata{
 		metadataDatabase: db,
 		databaseName:     databaseName,
 		tagMetadata:      NewTagMetadata(databaseName, tagFamily),
-	}, nil
+		ctx:              ctx,
+	}
+	m.startPreload(config.GlobalStorageConfig().TSDB.PreloadMetrics)
+	return m, nil
 }
 
 // DatabaseName returns the database name
@@ -63,6 +71,8 @@ func (m *metadata) TagMetadata() TagMetadata {
 
 // Close closes the metadata backend storage
 func (m *metadata) Close() error {
+	// wait preload stopped before closing metadata database
+	m.preloadWG.Wait()
 	if err := m.metadataDatabase.Close(); err != nil {
 		return err
 	}
@@ -21,11 +21,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/lindb/lindb/config"
 	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/internal/linmetric"
 	"github.com/lindb/lindb/pkg/logger"
@@ -35,6 +38,8 @@ import (
 	metricchecker "github.com/lindb/lindb/series/metric"
 	"github.com/lindb/lindb/series/tag"
 	"github.com/lindb/lindb/tsdb/wal"
+
+	"github.com/lindb/roaring"
 )
 
 // for testing
@@ -52,6 +57,11 @@ var (
 	getFieldIDCounterVec    = metaDBScope.NewCounterVec("get_field_ids", "db")
 	genFieldIDCounterVec    = metaDBScope.NewCounterVec("gen_field_ids", "db")
 	recoveryMetaWALTimerVec = metaDBScope.Scope("recovery_wal_duration").NewHistogramVec("db")
+	backgroundGoroutinesVec = metaDBScope.NewGaugeVec("background_goroutines", "db")
+	metricCacheSizeVec      = metaDBScope.NewGaugeVec("metric_cache_size", "db")
+	metricCacheEvictionsVec = metaDBScope.NewCounterVec("metric_cache_evictions", "db")
+	tagValueCacheSizeVec    = metaDBScope.NewGaugeVec("tag_value_cache_size", "db")
+	tagValueCacheEvictsVec  = metaDBScope.NewCounterVec("tag_value_cache_evictions", "db")
 )
 
 var (
@@ -71,11 +81,13 @@ type metadataDatabase struct {
 	ctx          context.Context
 	cancel       context.CancelFunc
 	backend      MetadataBackend
-	metrics      map[string]MetricMetadata // metadata cache(key: namespace + delimiter + metric-name, value: metric metadata)
+	metrics      *metricCache // metadata cache(key: namespace + delimiter + metric-name, value: metric metadata)
+	fieldAliases fieldAliases // field aliases of metrics, new field name resolves to old field
 
 	metaWAL wal.MetricMetaWAL
 
 	syncInterval int64
+	syncWG       sync.WaitGroup // wait group of checkSync goroutine
 
 	rwMux sync.RWMutex
 
@@ -87,6 +99,7 @@ type metadataDatabase struct {
 		genFieldIDCounter    *linmetric.BoundCounter
 		getFieldIDCounter    *linmetric.BoundCounter
 		recoveryMetaWALTimer *linmetric.BoundHistogram
+		backgroundGoroutines *linmetric.BoundGauge
 	}
 }
 
@@ -118,7 +131,9 @@ func NewMetadataDatabase(ctx context.Context, databaseName, parent string) (Meta
 		ctx:          c,
 		cancel:       cancel,
 		backend:      backend,
-		metrics:      make(map[string]MetricMetadata),
+		metrics: newMetricCache(config.GlobalStorageConfig().TSDB.MetadataCacheSize, metaWAL.CommittedPage,
+			metricCacheSizeVec.WithTagValues(databaseName), metricCacheEvictionsVec.WithTagValues(databaseName)),
+		fieldAliases: newFieldAliases(config.GlobalStorageConfig().TSDB.FieldAliases),
 		metaWAL:      metaWAL,
 		syncInterval: syncInterval,
 	}
@@ -129,15 +144,18 @@ func NewMetadataDatabase(ctx context.Context, databaseName, parent string) (Meta
 	mdb.statistics.genTagKeyIDCounter = genTagKeyIDCounterVec.WithTagValues(databaseName)
 	mdb.statistics.getTagKeyIDCounter = getTagKeyIDCounterVec.WithTagValues(databaseName)
 	mdb.statistics.recoveryMetaWALTimer = recoveryMetaWALTimerVec.WithTagValues(databaseName)
+	mdb.statistics.backgroundGoroutines = backgroundGoroutinesVec.WithTagValues(databaseName)
 
 	// meta recovery
 	mdb.metaRecovery()
 
 	// if recovery meta wal fail, need return err
 	if mdb.metaWAL.NeedRecovery() {
+		cancel()
 		err = ErrNeedRecoveryWAL
 		return nil, err
 	}
+	mdb.syncWG.Add(1)
 	go mdb.checkSync()
 	return mdb, nil
 }
@@ -147,6 +165,24 @@ func (mdb *metadataDatabase) SuggestNamespace(prefix string, limit int) (namespa
 	return mdb.backend.suggestNamespace(prefix, limit)
 }
 
+// PreloadMetric loads the metric metadata into memory cache if not cached, returns all tag keys of metric,
+// so that the following tag key lookups of metric do not read backend storage.
+func (mdb *metadataDatabase) PreloadMetric(namespace, metricName string) (tags []tag.Meta, err error) {
+	key := metricchecker.JoinNamespaceMetric(namespace, metricName)
+	mdb.rwMux.Lock()
+	defer mdb.rwMux.Unlock()
+
+	metricMetadata, ok := mdb.metrics.get(key)
+	if !ok {
+		metricMetadata, err = mdb.backend.loadMetricMetadata(namespace, metricName)
+		if err != nil {
+			return nil, err
+		}
+		mdb.metrics.put(key, metricMetadata)
+	}
+	return metricMetadata.getAllTagKeys(), nil
+}
+
 // SuggestMetricName suggests the metric name by name's prefix
 func (mdb *metadataDatabase) SuggestMetricName(namespace, prefix string, limit int) (metricNames []string, err error) {
 	return mdb.backend.suggestMetricName(namespace, prefix, limit)
@@ -159,7 +195,7 @@ func (mdb *metadataDatabase) GetMetricID(namespace, metricName string) (metricID
 	mdb.rwMux.RLock()
 	// read from memory
 	key := metricchecker.JoinNamespaceMetric(namespace, metricName)
-	metricMetadata, ok := mdb.metrics[key]
+	metricMetadata, ok := mdb.metrics.get(key)
 	if ok {
 		defer mdb.rwMux.RUnlock()
 		return metricMetadata.getMetricID(), nil
@@ -177,7 +213,7 @@ func (mdb *metadataDatabase) GetTagKeyID(namespace, metricName string, tagKey st
 	key := metricchecker.JoinNamespaceMetric(namespace, metricName)
 
 	mdb.rwMux.RLock()
-	metricMetadata, ok := mdb.metrics[key]
+	metricMetadata, ok := mdb.metrics.get(key)
 	if ok {
 		defer mdb.rwMux.RUnlock()
 		tagKeyID, ok = metricMetadata.getTagKeyID(tagKey)
@@ -201,7 +237,7 @@ func (mdb *metadataDatabase) GetTagKeyID(namespace, metricName string, tagKey st
 func (mdb *metadataDatabase) GetAllTagKeys(namespace, metricName string) (tags []tag.Meta, err error) {
 	key := metricchecker.JoinNamespaceMetric(namespace, metricName)
 	mdb.rwMux.RLock()
-	metricMetadata, ok := mdb.metrics[key]
+	metricMetadata, ok := mdb.metrics.get(key)
 	if ok {
 		defer mdb.rwMux.RUnlock()
 		return metricMetadata.getAllTagKeys(), nil
@@ -216,11 +252,77 @@ func (mdb *metadataDatabase) GetAllTagKeys(namespace, metricName string) (tags [
 	return mdb.backend.getAllTagKeys(metricID)
 }
 
-// GetField gets the field meta by namespace/metric name/field name, if not exist return constants.ErrNotFound
+// ResolveTagKeyIDs resolves the tag keys by tag key ids of namespace/metric name in one call,
+// the tag key of unknown tag key id is UnresolvedName.
+func (mdb *metadataDatabase) ResolveTagKeyIDs(namespace, metricName string,
+	tagKeyIDs []uint32,
+) (tagKeys map[uint32]string, err error) {
+	tags, err := mdb.GetAllTagKeys(namespace, metricName)
+	if err != nil {
+		return nil, err
+	}
+	tagKeys = make(map[uint32]string, len(tagKeyIDs))
+	for _, tagKeyID := range tagKeyIDs {
+		tagKeys[tagKeyID] = UnresolvedName
+	}
+	for _, t := range tags {
+		if _, ok := tagKeys[t.ID]; ok {
+			tagKeys[t.ID] = t.Key
+		}
+	}
+	return tagKeys, nil
+}
+
+// GetField gets the field meta by namespace/metric name/field name, if not exist return constants.ErrNotFound,
+// if field name is alias of old field, returns the old field with field name.
 func (mdb *metadataDatabase) GetField(namespace, metricName string, fieldName field.Name) (f field.Meta, err error) {
 	key := metricchecker.JoinNamespaceMetric(namespace, metricName)
+	if oldName, ok := mdb.fieldAliases.aliasOf(key, fieldName); ok {
+		f, err = mdb.getField(namespace, metricName, key, oldName)
+		if err == nil {
+			f.Name = fieldName
+			return f, nil
+		}
+		if !errors.Is(err, constants.ErrNotFound) {
+			return field.Meta{}, err
+		}
+	}
+	return mdb.getField(namespace, metricName, key, fieldName)
+}
+
+// GetFields gets the fields which field name resolves to, includes the field of field name and the old field
+// if field name is alias of it, all named with field name, old field of different type is not included.
+func (mdb *metadataDatabase) GetFields(namespace, metricName string, fieldName field.Name) (fields field.Metas, err error) {
+	key := metricchecker.JoinNamespaceMetric(namespace, metricName)
+	f, err := mdb.getField(namespace, metricName, key, fieldName)
+	switch {
+	case err == nil:
+		fields = append(fields, f)
+	case !errors.Is(err, constants.ErrNotFound):
+		return nil, err
+	}
+	oldName, ok := mdb.fieldAliases.aliasOf(key, fieldName)
+	if !ok {
+		return fields, err
+	}
+	oldField, oldErr := mdb.getField(namespace, metricName, key, oldName)
+	switch {
+	case oldErr == nil:
+		if len(fields) > 0 && fields[0].Type != oldField.Type {
+			return fields, nil
+		}
+		oldField.Name = fieldName
+		return append(fields, oldField), nil
+	case !errors.Is(oldErr, constants.ErrNotFound):
+		return nil, oldErr
+	}
+	return fields, err
+}
+
+// getField gets the field meta by field name from memory, if not exist reads from backend storage.
+func (mdb *metadataDatabase) getField(namespace, metricName, key string, fieldName field.Name) (f field.Meta, err error) {
 	mdb.rwMux.RLock()
-	metricMetadata, ok := mdb.metrics[key]
+	metricMetadata, ok := mdb.metrics.get(key)
 	if ok {
 		defer mdb.rwMux.RUnlock()
 		f, ok = metricMetadata.getField(fieldName)
@@ -241,26 +343,31 @@ func (mdb *metadataDatabase) GetField(namespace, metricName string, fieldName fi
 	return mdb.backend.getField(metricID, fieldName)
 }
 
+// GetAllFields returns the all visible fields by namespace/metric name, old field is renamed by field alias.
 func (mdb *metadataDatabase) GetAllFields(namespace, metricName string) (fields []field.Meta, err error) {
 	key := metricchecker.JoinNamespaceMetric(namespace, metricName)
 	mdb.rwMux.RLock()
-	metricMetadata, ok := mdb.metrics[key]
+	metricMetadata, ok := mdb.metrics.get(key)
 	if ok {
 		defer mdb.rwMux.RUnlock()
-		return metricMetadata.getAllFields(), nil
+		return mdb.fieldAliases.rename(key, metricMetadata.getAllFields()), nil
 	}
 	mdb.rwMux.RUnlock()
 	metricID, err := mdb.GetMetricID(namespace, metricName)
 	if err != nil {
 		return nil, err
 	}
-	return mdb.backend.getAllFields(metricID)
+	fields, err = mdb.backend.getAllFields(metricID)
+	if err != nil {
+		return nil, err
+	}
+	return mdb.fieldAliases.rename(key, fields), nil
 }
 
 func (mdb *metadataDatabase) GetAllHistogramFields(namespace, metricName string) (fields field.Metas, err error) {
 	key := metricchecker.JoinNamespaceMetric(namespace, metricName)
 	mdb.rwMux.RLock()
-	metricMetadata, ok := mdb.metrics[key]
+	metricMetadata, ok := mdb.metrics.get(key)
 	if ok {
 		defer mdb.rwMux.RUnlock()
 		return metricMetadata.getAllHistogramFields(), nil
@@ -280,7 +387,7 @@ func (mdb *metadataDatabase) GenMetricID(namespace, metricName string) (metricID
 	key := metricchecker.JoinNamespaceMetric(namespace, metricName)
 	mdb.rwMux.RLock()
 	// get metric id from memory, add read lock
-	metricMetadata, ok := mdb.metrics[key]
+	metricMetadata, ok := mdb.metrics.get(key)
 	if ok {
 		defer mdb.rwMux.RUnlock()
 		mdb.statistics.getMetricIDCounter.Incr()
@@ -292,7 +399,7 @@ func (mdb *metadataDatabase) GenMetricID(namespace, metricName string) (metricID
 	mdb.rwMux.Lock()
 	defer mdb.rwMux.Unlock()
 	// double check with memory
-	metricMetadata, ok = mdb.metrics[key]
+	metricMetadata, ok = mdb.metrics.get(key)
 	if ok {
 		mdb.statistics.getMetricIDCounter.Incr()
 		return metricMetadata.getMetricID(), nil
@@ -302,7 +409,7 @@ func (mdb *metadataDatabase) GenMetricID(namespace, metricName string) (metricID
 	metricMetadata, err = mdb.backend.loadMetricMetadata(namespace, metricName)
 	if err == nil {
 		// get metric metadata from backend
-		mdb.metrics[key] = metricMetadata
+		mdb.metrics.put(key, metricMetadata)
 		mdb.statistics.getMetricIDCounter.Incr()
 		return metricMetadata.getMetricID(), nil
 	}
@@ -320,7 +427,8 @@ func (mdb *metadataDatabase) GenMetricID(namespace, metricName string) (metricID
 		return 0, err
 	}
 
-	mdb.metrics[key] = newMetricMetadata(metricID, 0)
+	mdb.metrics.put(key, newMetricMetadata(metricID, 0))
+	mdb.metrics.markDirty(key, mdb.metaWAL.CurrentPage())
 
 	mdb.statistics.genMetricIDCounter.Incr()
 
@@ -328,7 +436,7 @@ func (mdb *metadataDatabase) GenMetricID(namespace, metricName string) (metricID
 }
 
 // GenFieldID generates the field id in the memory,
-// !!!!! NOTICE: metric metadata must be exist in memory, because gen metric has been saved
+// !!!!! NOTICE: metric metadata must be exist, because gen metric has been saved, reloads it if evicted from memory
 func (mdb *metadataDatabase) GenFieldID(
 	namespace, metricName string,
 	fieldName field.Name, fieldType field.Type,
@@ -340,8 +448,20 @@ func (mdb *metadataDatabase) GenFieldID(
 
 	mdb.rwMux.Lock()
 	defer mdb.rwMux.Unlock()
-	// read from memory metric metadata
-	metricMetadata := mdb.metrics[key]
+	// read from memory metric metadata, reload it if evicted
+	metricMetadata, err := mdb.getOrLoadMetricMetadata(namespace, metricName, key)
+	if err != nil {
+		return 0, err
+	}
+	// write into the old field if field name is alias of it, else the field of field name if exists
+	if oldName, ok := mdb.fieldAliases.aliasOf(key, fieldName); ok {
+		if f, ok := metricMetadata.getField(oldName); ok {
+			newField, newOK := metricMetadata.getField(fieldName)
+			if f.Type == fieldType || !newOK || newField.Type != fieldType {
+				fieldName = oldName
+			}
+		}
+	}
 	f, ok := metricMetadata.getField(fieldName)
 	if ok {
 		mdb.statistics.getFieldIDCounter.Incr()
@@ -368,6 +488,7 @@ func (mdb *metadataDatabase) GenFieldID(
 		Type: fieldType,
 		Name: fieldName,
 	})
+	mdb.metrics.markDirty(key, mdb.metaWAL.CurrentPage())
 
 	mdb.statistics.genFieldIDCounter.Incr()
 
@@ -375,7 +496,7 @@ func (mdb *metadataDatabase) GenFieldID(
 }
 
 // GenTagKeyID generates the tag key id in the memory
-// !!!!! NOTICE: metric metadata must be exist in memory, because gen metric has been saved
+// !!!!! NOTICE: metric metadata must be exist, because gen metric has been saved, reloads it if evicted from memory
 //
 //
 func (mdb *metadataDatabase) GenTagKeyID(namespace, metricName, tagKey string) (tagKeyID uint32, err error) {
@@ -384,8 +505,11 @@ func (mdb *metadataDatabase) GenTagKeyID(namespace, metricName, tagKey string) (
 	mdb.rwMux.Lock()
 	defer mdb.rwMux.Unlock()
 
-	// read from memory metric metadata
-	metricMetadata := mdb.metrics[key]
+	// read from memory metric metadata, reload it if evicted
+	metricMetadata, err := mdb.getOrLoadMetricMetadata(namespace, metricName, key)
+	if err != nil {
+		return 0, err
+	}
 	tagKeyID, ok := metricMetadata.getTagKeyID(tagKey)
 	if ok {
 		mdb.statistics.genTagKeyIDCounter.Incr()
@@ -407,11 +531,27 @@ func (mdb *metadataDatabase) GenTagKeyID(namespace, metricName, tagKey string) (
 	}
 
 	metricMetadata.createTagKey(tagKey, tagKeyID)
+	mdb.metrics.markDirty(key, mdb.metaWAL.CurrentPage())
 
 	mdb.statistics.genTagKeyIDCounter.Incr()
 	return
 }
 
+// getOrLoadMetricMetadata returns the metric metadata in memory, loads it from backend storage if evicted,
+// must be called with write lock held.
+func (mdb *metadataDatabase) getOrLoadMetricMetadata(namespace, metricName, key string) (MetricMetadata, error) {
+	metricMetadata, ok := mdb.metrics.get(key)
+	if ok {
+		return metricMetadata, nil
+	}
+	metricMetadata, err := mdb.backend.loadMetricMetadata(namespace, metricName)
+	if err != nil {
+		return nil, err
+	}
+	mdb.metrics.put(key, metricMetadata)
+	return metricMetadata, nil
+}
+
 // Sync syncs the bbolt.DB's data file and metadata write ahead log
 func (mdb *metadataDatabase) Sync() error {
 	if err := mdb.metaWAL.Sync(); err != nil {
@@ -420,13 +560,175 @@ func (mdb *metadataDatabase) Sync() error {
 	return nil
 }
 
+// ExportMetrics returns the metadata of metrics by metric ids, includes fields and tag keys.
+func (mdb *metadataDatabase) ExportMetrics(metricIDs *roaring.Bitmap) ([]MetricSnapshot, error) {
+	mdb.rwMux.Lock()
+	defer mdb.rwMux.Unlock()
+
+	// save pending metadata into backend, then all metrics can be found in backend storage
+	mdb.commitPending()
+
+	namespaces, err := mdb.backend.suggestNamespace("", math.MaxInt32)
+	if err != nil {
+		return nil, err
+	}
+	var metrics []MetricSnapshot
+	for _, namespace := range namespaces {
+		metricNames, err := mdb.backend.suggestMetricName(namespace, "", math.MaxInt32)
+		if err != nil {
+			return nil, err
+		}
+		for _, metricName := range metricNames {
+			metricID, err := mdb.backend.getMetricID(namespace, metricName)
+			if err != nil {
+				return nil, err
+			}
+			if !metricIDs.Contains(metricID) {
+				continue
+			}
+			metricMetadata, err := mdb.backend.getMetricMetadata(metricID)
+			if err != nil {
+				return nil, err
+			}
+			metric := MetricSnapshot{
+				Namespace: namespace,
+				Name:      metricName,
+				ID:        metricID,
+				Fields:    metricMetadata.getAllFields(),
+			}
+			for _, tagKey := range metricMetadata.getAllTagKeys() {
+				metric.TagKeys = append(metric.TagKeys, TagKeySnapshot{Key: tagKey.Key, ID: tagKey.ID})
+			}
+			metrics = append(metrics, metric)
+		}
+	}
+	return metrics, nil
+}
+
+// ImportMetrics installs the metadata of metrics with same ids if there is no metadata, returns true,
+// else checks if the ids of metadata are same as current's, returns constants.ErrIncompatibleMetadata if not.
+func (mdb *metadataDatabase) ImportMetrics(metrics []MetricSnapshot) (installed bool, err error) {
+	mdb.rwMux.Lock()
+	defer mdb.rwMux.Unlock()
+
+	mdb.commitPending()
+
+	namespaces, err := mdb.backend.suggestNamespace("", 1)
+	if err != nil {
+		return false, err
+	}
+	if len(namespaces) == 0 {
+		// no metric in database, install metadata directly
+		if err := mdb.installMetrics(metrics); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+	for idx := range metrics {
+		if err := mdb.checkMetric(&metrics[idx]); err != nil {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// commitPending saves the pending metadata in meta wal into backend storage,
+// must be called with write lock held.
+func (mdb *metadataDatabase) commitPending() {
+	if err := mdb.metaWAL.Rotate(); err != nil {
+		metaLogger.Error("rotate meta wal err when commit pending metadata",
+			logger.String("db", mdb.path), logger.Error(err))
+	}
+	if mdb.metaWAL.NeedRecovery() {
+		mdb.metaRecovery()
+	}
+}
+
+// installMetrics saves the metadata of metrics with spec ids into backend storage,
+// id sequences are set as the max id, so that new ids are generated after imported ids.
+func (mdb *metadataDatabase) installMetrics(metrics []MetricSnapshot) error {
+	if len(metrics) == 0 {
+		return nil
+	}
+	event := newMetadataUpdateEvent()
+	var metricSeq, tagKeySeq uint32
+	for idx := range metrics {
+		metric := &metrics[idx]
+		event.addMetric(metric.Namespace, metric.Name, metric.ID)
+		if metric.ID > metricSeq {
+			metricSeq = metric.ID
+		}
+		fields := make(field.Metas, len(metric.Fields))
+		copy(fields, metric.Fields)
+		// field id sequence is set by the last field
+		sort.Slice(fields, func(i, j int) bool { return fields[i].ID < fields[j].ID })
+		for _, f := range fields {
+			event.addField(metric.ID, f)
+		}
+		for _, tagKey := range metric.TagKeys {
+			event.addTagKey(metric.ID, tag.Meta{Key: tagKey.Key, ID: tagKey.ID})
+			if tagKey.ID > tagKeySeq {
+				tagKeySeq = tagKey.ID
+			}
+		}
+	}
+	event.metricSeqID = metricSeq
+	event.tagKeySeqID = tagKeySeq
+	if err := mdb.backend.saveMetadata(event); err != nil {
+		return err
+	}
+	return mdb.backend.sync()
+}
+
+// checkMetric checks if the ids of metric/fields/tag keys are same as current's.
+func (mdb *metadataDatabase) checkMetric(metric *MetricSnapshot) error {
+	metricMetadata, err := mdb.getOrLoadMetricMetadata(metric.Namespace, metric.Name,
+		metricchecker.JoinNamespaceMetric(metric.Namespace, metric.Name))
+	if err != nil {
+		if errors.Is(err, constants.ErrNotFound) {
+			return fmt.Errorf("%w, namespace: %s, metricName: %s not found",
+				constants.ErrIncompatibleMetadata, metric.Namespace, metric.Name)
+		}
+		return err
+	}
+	if metricMetadata.getMetricID() != metric.ID {
+		return fmt.Errorf("%w, namespace: %s, metricName: %s, metricID: %d, expect: %d",
+			constants.ErrIncompatibleMetadata, metric.Namespace, metric.Name, metricMetadata.getMetricID(), metric.ID)
+	}
+	for _, expect := range metric.Fields {
+		f, ok := metricMetadata.getField(expect.Name)
+		if !ok || f.ID != expect.ID || f.Type != expect.Type {
+			return fmt.Errorf("%w, metricName: %s, field: %s is different",
+				constants.ErrIncompatibleMetadata, metric.Name, expect.Name)
+		}
+	}
+	for _, expect := range metric.TagKeys {
+		tagKeyID, ok := metricMetadata.getTagKeyID(expect.Key)
+		if !ok || tagKeyID != expect.ID {
+			return fmt.Errorf("%w, metricName: %s, tagKey: %s is different",
+				constants.ErrIncompatibleMetadata, metric.Name, expect.Key)
+		}
+	}
+	return nil
+}
+
 // Close closes the resources
 func (mdb *metadataDatabase) Close() error {
 	mdb.cancel()
+	// wait checkSync stopped before closing wal/backend storage
+	mdb.syncWG.Wait()
 
 	mdb.rwMux.Lock()
 	defer mdb.rwMux.Unlock()
 
+	// sync pending metadata into backend, so that no meta wal need recovery when reopening
+	if err := mdb.metaWAL.Rotate(); err != nil {
+		metaLogger.Error("rotate meta wal err when close metadata database",
+			logger.String("db", mdb.path), logger.Error(err))
+	}
+	if mdb.metaWAL.NeedRecovery() {
+		mdb.metaRecovery()
+	}
 	if err := mdb.metaWAL.Close(); err != nil {
 		metaLogger.Error("sync meta wal err when close metadata database",
 			logger.String("db", mdb.path), logger.Error(err))
@@ -462,12 +764,22 @@ func (mdb *metadataDatabase) SuggestTagKeys(namespace, metricName, tagKeyPrefix
 
 // checkSync checks if need sync pending metadata event in period
 func (mdb *metadataDatabase) checkSync() {
+	mdb.statistics.backgroundGoroutines.Incr()
+	defer func() {
+		mdb.statistics.backgroundGoroutines.Decr()
+		mdb.syncWG.Done()
+	}()
+
 	ticker := time.NewTicker(time.Duration(mdb.syncInterval * 1000000))
 	for {
 		select {
 		case <-ticker.C:
 			if mdb.metaWAL.NeedRecovery() {
 				mdb.metaRecovery()
+				// committed metric metadata can be evicted
+				mdb.rwMux.Lock()
+				mdb.metrics.shrink()
+				mdb.rwMux.Unlock()
 			}
 		case <-mdb.ctx.Done():
 			ticker.Stop()
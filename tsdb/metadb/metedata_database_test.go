@@ -27,11 +27,13 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/atomic"
+	"go.uber.org/goleak"
 
 	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/pkg/timeutil"
 	"github.com/lindb/lindb/series"
 	"github.com/lindb/lindb/series/field"
+	metricchecker "github.com/lindb/lindb/series/metric"
 	"github.com/lindb/lindb/series/tag"
 	"github.com/lindb/lindb/tsdb/wal"
 )
@@ -163,6 +165,7 @@ func TestMetadataDatabase_GetMetricID(t *testing.T) {
 	assert.Equal(t, uint32(10), metricID)
 
 	mockBackend.EXPECT().saveMetadata(gomock.Any()).AnyTimes()
+	mockBackend.EXPECT().sync().Return(nil).AnyTimes()
 	mockBackend.EXPECT().Close().Return(nil)
 	_ = db.Close()
 }
@@ -233,10 +236,82 @@ func TestMetadataDatabase_GetTagKey(t *testing.T) {
 	assert.Equal(t, []tag.Meta{{ID: 10, Key: "tag-key"}}, tagKeys)
 
 	mockBackend.EXPECT().saveMetadata(gomock.Any()).AnyTimes()
+	mockBackend.EXPECT().sync().Return(nil).AnyTimes()
 	mockBackend.EXPECT().Close().Return(nil)
 	_ = db.Close()
 }
 
+func TestMetadataDatabase_PreloadMetric(t *testing.T) {
+	testPath := t.TempDir()
+	ctrl := gomock.NewController(t)
+	defer func() {
+		createMetadataBackend = newMetadataBackend
+
+		ctrl.Finish()
+	}()
+	mockBackend := NewMockMetadataBackend(ctrl)
+	createMetadataBackend = func(parent string) (backend MetadataBackend, err error) {
+		return mockBackend, nil
+	}
+	db, err := NewMetadataDatabase(context.TODO(), "test", testPath)
+	assert.NoError(t, err)
+
+	// case 1: metric not exist
+	mockBackend.EXPECT().loadMetricMetadata("ns-1", "name2").Return(nil, constants.ErrNotFound)
+	tagKeys, err := db.PreloadMetric("ns-1", "name2")
+	assert.True(t, errors.Is(err, constants.ErrNotFound))
+	assert.Nil(t, tagKeys)
+
+	// case 2: load from backend
+	meta := newMetricMetadata(1, 0)
+	meta.initialize(nil, []tag.Meta{{ID: 10, Key: "tag-key"}})
+	mockBackend.EXPECT().loadMetricMetadata("ns-1", "name1").Return(meta, nil)
+	tagKeys, err = db.PreloadMetric("ns-1", "name1")
+	assert.NoError(t, err)
+	assert.Equal(t, []tag.Meta{{ID: 10, Key: "tag-key"}}, tagKeys)
+
+	// case 3: preloaded metric resolves from memory, backend not read again
+	tagKeys, err = db.PreloadMetric("ns-1", "name1")
+	assert.NoError(t, err)
+	assert.Equal(t, []tag.Meta{{ID: 10, Key: "tag-key"}}, tagKeys)
+	tagKeys, err = db.GetAllTagKeys("ns-1", "name1")
+	assert.NoError(t, err)
+	assert.Equal(t, []tag.Meta{{ID: 10, Key: "tag-key"}}, tagKeys)
+	tagKeyID, err := db.GetTagKeyID("ns-1", "name1", "tag-key")
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(10), tagKeyID)
+	metricID, err := db.GetMetricID("ns-1", "name1")
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(1), metricID)
+
+	mockBackend.EXPECT().saveMetadata(gomock.Any()).AnyTimes()
+	mockBackend.EXPECT().sync().Return(nil).AnyTimes()
+	mockBackend.EXPECT().Close().Return(nil)
+	_ = db.Close()
+}
+
+func TestMetadataDatabase_ResolveTagKeyIDs(t *testing.T) {
+	db := newMockMetadataDatabase(t, t.TempDir())
+	defer func() {
+		_ = db.Close()
+	}()
+	_, err := db.GenMetricID("ns", "name")
+	assert.NoError(t, err)
+	tagKeyID1, err := db.GenTagKeyID("ns", "name", "host")
+	assert.NoError(t, err)
+	tagKeyID2, err := db.GenTagKeyID("ns", "name", "ip")
+	assert.NoError(t, err)
+
+	// case 1: resolve tag keys, unknown tag key id marked
+	tagKeys, err := db.ResolveTagKeyIDs("ns", "name", []uint32{tagKeyID1, tagKeyID2, 1000})
+	assert.NoError(t, err)
+	assert.Equal(t, map[uint32]string{tagKeyID1: "host", tagKeyID2: "ip", 1000: UnresolvedName}, tagKeys)
+	// case 2: metric not exist
+	tagKeys, err = db.ResolveTagKeyIDs("ns", "name2", []uint32{tagKeyID1})
+	assert.True(t, errors.Is(err, constants.ErrNotFound))
+	assert.Nil(t, tagKeys)
+}
+
 func TestMetadataDatabase_SuggestTagKeys(t *testing.T) {
 	testPath := t.TempDir()
 	ctrl := gomock.NewController(t)
@@ -335,6 +410,7 @@ func TestMetadataDatabase_GetField(t *testing.T) {
 	assert.Equal(t, []field.Meta{{ID: 19, Type: field.SumField}}, fields)
 
 	mockBackend.EXPECT().saveMetadata(gomock.Any()).AnyTimes()
+	mockBackend.EXPECT().sync().Return(nil).AnyTimes()
 	mockBackend.EXPECT().Close().Return(nil)
 	_ = db.Close()
 }
@@ -382,6 +458,7 @@ func TestMetadataDatabase_GenMetricID(t *testing.T) {
 	assert.Equal(t, uint32(100), metricID)
 
 	mockBackend.EXPECT().saveMetadata(gomock.Any()).AnyTimes()
+	mockBackend.EXPECT().sync().Return(nil).AnyTimes()
 	mockBackend.EXPECT().Close().Return(nil)
 	_ = db.Close()
 }
@@ -463,6 +540,7 @@ func TestMetadataDatabase_GenFieldID(t *testing.T) {
 	assert.Equal(t, field.ID(0), fieldID)
 
 	mockBackend.EXPECT().saveMetadata(gomock.Any()).AnyTimes()
+	mockBackend.EXPECT().sync().Return(nil).AnyTimes()
 	mockBackend.EXPECT().Close().Return(nil)
 	_ = db.Close()
 }
@@ -540,6 +618,7 @@ func TestMetadataDatabase_GenTagKeyID(t *testing.T) {
 	assert.Equal(t, uint32(0), tagKeyID)
 
 	mockBackend.EXPECT().saveMetadata(gomock.Any()).AnyTimes()
+	mockBackend.EXPECT().sync().Return(nil).AnyTimes()
 	mockBackend.EXPECT().Close().Return(nil)
 	_ = db.Close()
 }
@@ -592,6 +671,10 @@ func TestMetadataDatabase_Close(t *testing.T) {
 	}
 	db, err := NewMetadataDatabase(context.TODO(), "test", testPath)
 	assert.NoError(t, err)
+	// sync pending metadata before closing wal
+	mockWAL.EXPECT().Rotate().Return(fmt.Errorf("err"))
+	mockWAL.EXPECT().NeedRecovery().Return(true)
+	mockWAL.EXPECT().Recovery(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
 	mockBackend.EXPECT().Close().Return(fmt.Errorf("err"))
 	mockWAL.EXPECT().Close().Return(fmt.Errorf("err"))
 	err = db.Close()
@@ -638,6 +721,8 @@ func TestMetadataDatabase_Sync(t *testing.T) {
 	db1.metaWAL = mockWAL
 	err := db.Sync()
 	assert.NoError(t, err)
+	mockWAL.EXPECT().Rotate().Return(nil)
+	mockWAL.EXPECT().NeedRecovery().Return(false)
 	mockWAL.EXPECT().Close().Return(nil)
 	err = db.Close()
 	assert.NoError(t, err)
@@ -670,11 +755,34 @@ func TestIndexDatabase_checkSync(t *testing.T) {
 
 	time.Sleep(time.Second)
 
+	mockMetaWAL.EXPECT().Rotate().Return(nil)
 	mockMetaWAL.EXPECT().Close().Return(nil)
 	err = db.Close()
 	assert.NoError(t, err)
 }
 
+func TestMetadataDatabase_Close_NoGoroutineLeak(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	oldSyncInterval := syncInterval
+	syncInterval = 1
+	defer func() {
+		syncInterval = oldSyncInterval
+	}()
+
+	goroutines := backgroundGoroutinesVec.WithTagValues("goroutine-leak")
+	testPath := t.TempDir()
+	// open and close repeatedly, background goroutines must stop on close
+	for i := 0; i < 5; i++ {
+		db, err := NewMetadataDatabase(context.TODO(), "goroutine-leak", testPath)
+		assert.NoError(t, err)
+		time.Sleep(5 * time.Millisecond)
+		assert.Equal(t, float64(1), goroutines.Get())
+		assert.NoError(t, db.Close())
+		assert.Zero(t, goroutines.Get())
+	}
+}
+
 func TestMetadataDatabase_recovery_metric(t *testing.T) {
 	testPath := t.TempDir()
 	ctrl := gomock.NewController(t)
@@ -691,8 +799,8 @@ func TestMetadataDatabase_recovery_metric(t *testing.T) {
 		_, err := db.GenMetricID("ns", fmt.Sprintf("metric-%d", i))
 		assert.NoError(t, err)
 	}
-	err = db.Close()
-	assert.NoError(t, err)
+	// crash without syncing wal into backend
+	crashMetadataDatabase(t, db)
 
 	backend := NewMockMetadataBackend(ctrl)
 	backend.EXPECT().Close().Return(nil).AnyTimes()
@@ -714,8 +822,8 @@ func TestMetadataDatabase_recovery_metric(t *testing.T) {
 		_, err := db.GenMetricID("ns", fmt.Sprintf("metric-%d", i))
 		assert.NoError(t, err)
 	}
-	err = db.Close()
-	assert.NoError(t, err)
+	// crash without syncing wal into backend
+	crashMetadataDatabase(t, db)
 
 	createMetadataBackend = func(parent string) (MetadataBackend, error) {
 		return backend, nil
@@ -745,8 +853,8 @@ func TestMetadataDatabase_recovery_field(t *testing.T) {
 		_, err := db.GenFieldID("ns", "metric-1", field.Name(fmt.Sprintf("f-%d", i)), field.SumField)
 		assert.NoError(t, err)
 	}
-	err = db.Close()
-	assert.NoError(t, err)
+	// crash without syncing wal into backend
+	crashMetadataDatabase(t, db)
 
 	backend := NewMockMetadataBackend(ctrl)
 	backend.EXPECT().Close().Return(nil).AnyTimes()
@@ -764,8 +872,8 @@ func TestMetadataDatabase_recovery_field(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, db)
 
-	err = db.Close()
-	assert.NoError(t, err)
+	// crash without syncing wal into backend
+	crashMetadataDatabase(t, db)
 }
 
 func TestMetadataDatabase_recovery_tagKey(t *testing.T) {
@@ -787,8 +895,8 @@ func TestMetadataDatabase_recovery_tagKey(t *testing.T) {
 		_, err := db.GenTagKeyID("ns", "metric-1", fmt.Sprintf("tagKey-%d", i))
 		assert.NoError(t, err)
 	}
-	err = db.Close()
-	assert.NoError(t, err)
+	// crash without syncing wal into backend
+	crashMetadataDatabase(t, db)
 
 	backend := NewMockMetadataBackend(ctrl)
 	backend.EXPECT().Close().Return(nil).AnyTimes()
@@ -806,8 +914,203 @@ func TestMetadataDatabase_recovery_tagKey(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, db)
 
-	err = db.Close()
+	// crash without syncing wal into backend
+	crashMetadataDatabase(t, db)
+}
+
+func TestMetadataDatabase_MetricCacheEviction(t *testing.T) {
+	db := newMockMetadataDatabase(t, t.TempDir())
+	defer func() {
+		assert.NoError(t, db.Close())
+	}()
+	mdb := db.(*metadataDatabase)
+	mdb.metrics.capacity = 2
+
+	type metricMeta struct {
+		metricID uint32
+		fieldID  field.ID
+		tagKeyID uint32
+	}
+	var metas []metricMeta
+	for i := 0; i < 5; i++ {
+		metricName := fmt.Sprintf("metric-%d", i)
+		metricID, err := db.GenMetricID("ns", metricName)
+		assert.NoError(t, err)
+		fieldID, err := db.GenFieldID("ns", metricName, "f", field.SumField)
+		assert.NoError(t, err)
+		tagKeyID, err := db.GenTagKeyID("ns", metricName, "host")
+		assert.NoError(t, err)
+		metas = append(metas, metricMeta{metricID: metricID, fieldID: fieldID, tagKeyID: tagKeyID})
+	}
+	// changes are not committed into backend, cannot be evicted
+	assert.Equal(t, 5, mdb.metrics.len())
+
+	// commit changes into backend, cache shrinks to capacity
+	assert.NoError(t, mdb.metaWAL.Rotate())
+	mdb.metaRecovery()
+	mdb.metrics.shrink()
+	assert.Equal(t, 2, mdb.metrics.len())
+	_, ok := mdb.metrics.get(metricchecker.JoinNamespaceMetric("ns", "metric-0"))
+	assert.False(t, ok)
+
+	// evicted metrics reload from backend with accurate results
+	for i, meta := range metas {
+		metricName := fmt.Sprintf("metric-%d", i)
+		metricID, err := db.GenMetricID("ns", metricName)
+		assert.NoError(t, err)
+		assert.Equal(t, meta.metricID, metricID)
+		f, err := db.GetField("ns", metricName, "f")
+		assert.NoError(t, err)
+		assert.Equal(t, field.Meta{ID: meta.fieldID, Name: "f", Type: field.SumField}, f)
+		tagKeys, err := db.GetAllTagKeys("ns", metricName)
+		assert.NoError(t, err)
+		assert.Equal(t, []tag.Meta{{ID: meta.tagKeyID, Key: "host"}}, tagKeys)
+		fieldID, err := db.GenFieldID("ns", metricName, "f", field.SumField)
+		assert.NoError(t, err)
+		assert.Equal(t, meta.fieldID, fieldID)
+		tagKeyID, err := db.GenTagKeyID("ns", metricName, "host")
+		assert.NoError(t, err)
+		assert.Equal(t, meta.tagKeyID, tagKeyID)
+	}
+	assert.Equal(t, 2, mdb.metrics.len())
+
+	// create new field/tag key for evicted metric
+	_, ok = mdb.metrics.get(metricchecker.JoinNamespaceMetric("ns", "metric-0"))
+	assert.False(t, ok)
+	fieldID, err := db.GenFieldID("ns", "metric-0", "f2", field.SumField)
+	assert.NoError(t, err)
+	assert.NotEqual(t, metas[0].fieldID, fieldID)
+	tagKeyID, err := db.GenTagKeyID("ns", "metric-0", "ip")
+	assert.NoError(t, err)
+	assert.NotEqual(t, metas[0].tagKeyID, tagKeyID)
+	fields, err := db.GetAllFields("ns", "metric-0")
+	assert.NoError(t, err)
+	assert.Len(t, fields, 2)
+}
+
+func TestMetadataDatabase_FieldAlias(t *testing.T) {
+	db := newMockMetadataDatabase(t, t.TempDir())
+	defer func() {
+		assert.NoError(t, db.Close())
+	}()
+	mdb := db.(*metadataDatabase)
+	mdb.fieldAliases = newFieldAliases([]string{"cpu:usage_total=usage", "cpu:idle_total=idle"})
+	ns := constants.DefaultNamespace
+
+	_, err := db.GenMetricID(ns, "cpu")
+	assert.NoError(t, err)
+	// historical data written under old name
+	oldFieldID, err := db.GenFieldID(ns, "cpu", "usage", field.SumField)
 	assert.NoError(t, err)
+	// write new name into old field
+	fieldID, err := db.GenFieldID(ns, "cpu", "usage_total", field.SumField)
+	assert.NoError(t, err)
+	assert.Equal(t, oldFieldID, fieldID)
+	_, err = db.GenFieldID(ns, "cpu", "usage_total", field.GaugeField)
+	assert.True(t, errors.Is(err, series.ErrWrongFieldType))
+	// old field not exist, new field created
+	idleFieldID, err := db.GenFieldID(ns, "cpu", "idle_total", field.GaugeField)
+	assert.NoError(t, err)
+	assert.NotEqual(t, oldFieldID, idleFieldID)
+
+	check := func() {
+		// query new name resolves old field which includes both historical and new data
+		f, err := db.GetField(ns, "cpu", "usage_total")
+		assert.NoError(t, err)
+		assert.Equal(t, field.Meta{ID: oldFieldID, Name: "usage_total", Type: field.SumField}, f)
+		f, err = db.GetField(ns, "cpu", "usage")
+		assert.NoError(t, err)
+		assert.Equal(t, field.Meta{ID: oldFieldID, Name: "usage", Type: field.SumField}, f)
+		f, err = db.GetField(ns, "cpu", "idle_total")
+		assert.NoError(t, err)
+		assert.Equal(t, field.Meta{ID: idleFieldID, Name: "idle_total", Type: field.GaugeField}, f)
+		// only old field exists
+		fields, err := db.GetFields(ns, "cpu", "usage_total")
+		assert.NoError(t, err)
+		assert.Equal(t, field.Metas{{ID: oldFieldID, Name: "usage_total", Type: field.SumField}}, fields)
+		_, err = db.GetField(ns, "cpu", "not-exist")
+		assert.True(t, errors.Is(err, constants.ErrNotFound))
+		fields, err = db.GetAllFields(ns, "cpu")
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []field.Meta{
+			{ID: oldFieldID, Name: "usage_total", Type: field.SumField},
+			{ID: idleFieldID, Name: "idle_total", Type: field.GaugeField},
+		}, fields)
+	}
+	// case 1: from memory
+	check()
+	// case 2: from backend storage
+	assert.NoError(t, mdb.metaWAL.Rotate())
+	mdb.metaRecovery()
+	mdb.metrics = newMetricCache(0, mdb.metaWAL.CommittedPage,
+		metricCacheSizeVec.WithTagValues("test"), metricCacheEvictionsVec.WithTagValues("test"))
+	check()
+}
+
+func TestMetadataDatabase_FieldAlias_merge(t *testing.T) {
+	db := newMockMetadataDatabase(t, t.TempDir())
+	defer func() {
+		assert.NoError(t, db.Close())
+	}()
+	mdb := db.(*metadataDatabase)
+	ns := constants.DefaultNamespace
+
+	_, err := db.GenMetricID(ns, "cpu")
+	assert.NoError(t, err)
+	// data written under both names before alias configured
+	oldFieldID, err := db.GenFieldID(ns, "cpu", "usage", field.SumField)
+	assert.NoError(t, err)
+	newFieldID, err := db.GenFieldID(ns, "cpu", "usage_total", field.SumField)
+	assert.NoError(t, err)
+	idleFieldID, err := db.GenFieldID(ns, "cpu", "idle", field.SumField)
+	assert.NoError(t, err)
+	idleTotalFieldID, err := db.GenFieldID(ns, "cpu", "idle_total", field.GaugeField)
+	assert.NoError(t, err)
+	mdb.fieldAliases = newFieldAliases([]string{"cpu:usage_total=usage", "cpu:idle_total=idle", "cpu:load_total=load"})
+
+	// write new name into old field
+	fieldID, err := db.GenFieldID(ns, "cpu", "usage_total", field.SumField)
+	assert.NoError(t, err)
+	assert.Equal(t, oldFieldID, fieldID)
+	// old field of different type, write into the existing new field
+	fieldID, err = db.GenFieldID(ns, "cpu", "idle_total", field.GaugeField)
+	assert.NoError(t, err)
+	assert.Equal(t, idleTotalFieldID, fieldID)
+	_, err = db.GenFieldID(ns, "cpu", "idle_total", field.MaxField)
+	assert.True(t, errors.Is(err, series.ErrWrongFieldType))
+
+	check := func() {
+		// query new name resolves both new field and old field
+		fields, err := db.GetFields(ns, "cpu", "usage_total")
+		assert.NoError(t, err)
+		assert.Equal(t, field.Metas{
+			{ID: newFieldID, Name: "usage_total", Type: field.SumField},
+			{ID: oldFieldID, Name: "usage_total", Type: field.SumField},
+		}, fields)
+		// old field of different type not merged
+		fields, err = db.GetFields(ns, "cpu", "idle_total")
+		assert.NoError(t, err)
+		assert.Equal(t, field.Metas{{ID: idleTotalFieldID, Name: "idle_total", Type: field.GaugeField}}, fields)
+		fields, err = db.GetFields(ns, "cpu", "usage")
+		assert.NoError(t, err)
+		assert.Equal(t, field.Metas{{ID: oldFieldID, Name: "usage", Type: field.SumField}}, fields)
+		fields, err = db.GetFields(ns, "cpu", "idle")
+		assert.NoError(t, err)
+		assert.Equal(t, field.Metas{{ID: idleFieldID, Name: "idle", Type: field.SumField}}, fields)
+		// neither new field nor old field exists
+		_, err = db.GetFields(ns, "cpu", "load_total")
+		assert.True(t, errors.Is(err, constants.ErrNotFound))
+		_, err = db.GetFields(ns, "cpu", "not-exist")
+		assert.True(t, errors.Is(err, constants.ErrNotFound))
+	}
+	// case 1: from memory
+	check()
+	// case 2: from backend storage
+	assert.NoError(t, mdb.metaWAL.Rotate())
+	mdb.metaRecovery()
+	mdb.metrics = newMetricCache(0, mdb.metaWAL.CommittedPage,
+		metricCacheSizeVec.WithTagValues("test"), metricCacheEvictionsVec.WithTagValues("test"))
+	check()
 }
 
 func newMockMetadataDatabase(t *testing.T, dir string) MetadataDatabase {
@@ -817,3 +1120,12 @@ func newMockMetadataDatabase(t *testing.T, dir string) MetadataDatabase {
 
 	return db
 }
+
+// crashMetadataDatabase closes metadata database without syncing meta wal into backend, like process crash.
+func crashMetadataDatabase(t *testing.T, db MetadataDatabase) {
+	mdb := db.(*metadataDatabase)
+	mdb.cancel()
+	mdb.syncWG.Wait()
+	assert.NoError(t, mdb.metaWAL.Close())
+	assert.NoError(t, mdb.backend.Close())
+}
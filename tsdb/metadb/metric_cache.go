@@ -0,0 +1,175 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package metadb
+
+import (
+	"container/list"
+	"sync"
+
+	"go.uber.org/atomic"
+
+	"github.com/lindb/lindb/internal/linmetric"
+)
+
+// metricCacheEntry represents the cached metric metadata in clean or dirty list.
+type metricCacheEntry struct {
+	key      string
+	metadata MetricMetadata
+	dirty    bool          // metadata has changes which are not committed into backend storage
+	walPage  int64         // page index of meta wal which the last change is appended into
+	elem     *list.Element // element of clean or dirty list
+	accessed atomic.Bool   // accessed since last eviction scan, gives entry a second chance
+}
+
+// metricCache caches the metric metadata bounded by the number of metrics, evicts the least recently used one
+// approximately by second chance(clock), so that get only needs read lock.
+// The metadata which has changes in meta wal not committed into backend storage is never evicted,
+// because reloading it from backend storage would lose the changes, these entries are kept in dirty list
+// ordered by wal page, and moved into clean list after the wal page committed.
+type metricCache struct {
+	capacity      int                          // max number of cached metrics, 0 means unlimited
+	entries       map[string]*metricCacheEntry // key: namespace + delimiter + metric-name
+	clean         *list.List                   // entries can be evicted, front is the most recently put
+	dirty         *list.List                   // entries with uncommitted changes, front is the oldest wal page
+	committedPage func() int64                 // returns the last committed page index of meta wal
+	rwMutex       sync.RWMutex
+
+	size      *linmetric.BoundGauge
+	evictions *linmetric.BoundCounter
+}
+
+// newMetricCache creates the metric metadata cache with capacity.
+func newMetricCache(capacity int, committedPage func() int64,
+	size *linmetric.BoundGauge, evictions *linmetric.BoundCounter,
+) *metricCache {
+	return &metricCache{
+		capacity:      capacity,
+		entries:       make(map[string]*metricCacheEntry),
+		clean:         list.New(),
+		dirty:         list.New(),
+		committedPage: committedPage,
+		size:          size,
+		evictions:     evictions,
+	}
+}
+
+// get returns the cached metric metadata, marks it recently used.
+func (c *metricCache) get(key string) (MetricMetadata, bool) {
+	c.rwMutex.RLock()
+	defer c.rwMutex.RUnlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry.accessed.Store(true)
+	return entry.metadata, true
+}
+
+// put caches the metric metadata, then evicts the least recently used ones if cache is full.
+func (c *metricCache) put(key string, metadata MetricMetadata) {
+	c.rwMutex.Lock()
+	defer c.rwMutex.Unlock()
+
+	if entry, ok := c.entries[key]; ok {
+		entry.metadata = metadata
+		entry.accessed.Store(true)
+		return
+	}
+	entry := &metricCacheEntry{key: key, metadata: metadata}
+	entry.elem = c.clean.PushFront(entry)
+	c.entries[key] = entry
+	c.evict(entry)
+}
+
+// markDirty marks the metric metadata has changes appended into the page of meta wal.
+func (c *metricCache) markDirty(key string, walPage int64) {
+	c.rwMutex.Lock()
+	defer c.rwMutex.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return
+	}
+	if entry.dirty {
+		// wal page is increasing, keeps dirty list ordered by wal page
+		c.dirty.MoveToBack(entry.elem)
+	} else {
+		c.clean.Remove(entry.elem)
+		entry.elem = c.dirty.PushBack(entry)
+		entry.dirty = true
+	}
+	entry.walPage = walPage
+}
+
+// shrink evicts the least recently used metric metadata until cache is not full,
+// invoked after the changes in meta wal are committed.
+func (c *metricCache) shrink() {
+	c.rwMutex.Lock()
+	defer c.rwMutex.Unlock()
+
+	c.evict(nil)
+}
+
+// len returns the number of cached metric metadata.
+func (c *metricCache) len() int {
+	c.rwMutex.RLock()
+	defer c.rwMutex.RUnlock()
+
+	return len(c.entries)
+}
+
+// evict removes the least recently used metric metadata which has no uncommitted changes,
+// the entry kept is never evicted, such as the one just put, must be called with lock held.
+func (c *metricCache) evict(keep *metricCacheEntry) {
+	defer func() {
+		c.size.Update(float64(len(c.entries)))
+	}()
+
+	if c.capacity <= 0 || len(c.entries) <= c.capacity {
+		return
+	}
+	// moves the entries whose changes committed into back of clean list, the oldest wal page is the last one
+	committedPage := c.committedPage()
+	var committed []*metricCacheEntry
+	for elem := c.dirty.Front(); elem != nil; elem = c.dirty.Front() {
+		entry := elem.Value.(*metricCacheEntry)
+		if entry.walPage > committedPage {
+			break
+		}
+		c.dirty.Remove(elem)
+		entry.dirty = false
+		committed = append(committed, entry)
+	}
+	for i := len(committed) - 1; i >= 0; i-- {
+		committed[i].elem = c.clean.PushBack(committed[i])
+	}
+	// scans clean entries from back, accessed one is moved to front for second chance,
+	// all accessed flags are cleared in first round, so two rounds at most.
+	for i := 2 * c.clean.Len(); i > 0 && c.clean.Len() > 0 && len(c.entries) > c.capacity; i-- {
+		elem := c.clean.Back()
+		entry := elem.Value.(*metricCacheEntry)
+		if entry == keep || entry.accessed.CAS(true, false) {
+			c.clean.MoveToFront(elem)
+			continue
+		}
+		c.clean.Remove(elem)
+		delete(c.entries, entry.key)
+		c.evictions.Incr()
+	}
+}
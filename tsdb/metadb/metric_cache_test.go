@@ -0,0 +1,128 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package metadb
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetricCache_LRU(t *testing.T) {
+	committedPage := int64(0)
+	cache := newMetricCache(2, func() int64 { return committedPage },
+		metricCacheSizeVec.WithTagValues("test"), metricCacheEvictionsVec.WithTagValues("test"))
+	cache.put("a", newMetricMetadata(1, 0))
+	cache.put("b", newMetricMetadata(2, 0))
+	// case 1: evict the least recently used
+	m, ok := cache.get("a")
+	assert.True(t, ok)
+	assert.Equal(t, uint32(1), m.getMetricID())
+	cache.put("c", newMetricMetadata(3, 0))
+	assert.Equal(t, 2, cache.len())
+	_, ok = cache.get("b")
+	assert.False(t, ok)
+	// case 2: put exist metric
+	cache.put("c", newMetricMetadata(30, 0))
+	m, _ = cache.get("c")
+	assert.Equal(t, uint32(30), m.getMetricID())
+	assert.Equal(t, 2, cache.len())
+	// case 3: dirty metric not evicted
+	cache.markDirty("a", 1)
+	cache.markDirty("not-exist", 1)
+	cache.put("d", newMetricMetadata(4, 0))
+	_, ok = cache.get("a")
+	assert.True(t, ok)
+	_, ok = cache.get("c")
+	assert.False(t, ok)
+	// case 4: committed metric evicted when shrink
+	cache.capacity = 1
+	cache.markDirty("a", 1)
+	cache.put("e", newMetricMetadata(5, 0))
+	assert.Equal(t, 2, cache.len())
+	_, ok = cache.get("e") // e is used after a
+	assert.True(t, ok)
+	committedPage = 1
+	cache.shrink()
+	assert.Equal(t, 1, cache.len())
+	_, ok = cache.get("a")
+	assert.False(t, ok)
+	// case 5: most recently used always kept
+	cache.markDirty("e", 5)
+	cache.put("f", newMetricMetadata(6, 0))
+	assert.Equal(t, 2, cache.len())
+	_, ok = cache.get("f")
+	assert.True(t, ok)
+}
+
+func TestMetricCache_Unlimited(t *testing.T) {
+	cache := newMetricCache(0, func() int64 { return 0 },
+		metricCacheSizeVec.WithTagValues("test"), metricCacheEvictionsVec.WithTagValues("test"))
+	for _, key := range []string{"a", "b", "c", "d"} {
+		cache.put(key, newMetricMetadata(1, 0))
+	}
+	cache.shrink()
+	assert.Equal(t, 4, cache.len())
+}
+
+func TestMetricCache_DirtyNotScanned(t *testing.T) {
+	committedPage := int64(0)
+	cache := newMetricCache(1, func() int64 { return committedPage },
+		metricCacheSizeVec.WithTagValues("test"), metricCacheEvictionsVec.WithTagValues("test"))
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("dirty-%d", i)
+		cache.put(key, newMetricMetadata(uint32(i), 0))
+		cache.markDirty(key, int64(i+1))
+	}
+	// dirty entries are kept in dirty list, clean one is evicted
+	cache.put("a", newMetricMetadata(1000, 0))
+	cache.put("b", newMetricMetadata(1001, 0))
+	assert.Equal(t, 101, cache.len())
+	assert.Equal(t, 1, cache.clean.Len())
+	_, ok := cache.get("a")
+	assert.False(t, ok)
+	// entries whose changes committed are evicted
+	committedPage = 50
+	cache.shrink()
+	assert.Equal(t, 50, cache.len())
+	assert.Equal(t, 50, cache.dirty.Len())
+	_, ok = cache.get("dirty-50")
+	assert.True(t, ok)
+}
+
+func TestMetricCache_ConcurrentGet(t *testing.T) {
+	cache := newMetricCache(10, func() int64 { return 0 },
+		metricCacheSizeVec.WithTagValues("test"), metricCacheEvictionsVec.WithTagValues("test"))
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				key := fmt.Sprintf("%d", j%20)
+				if _, ok := cache.get(key); !ok {
+					cache.put(key, newMetricMetadata(uint32(j), 0))
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.LessOrEqual(t, cache.len(), 10)
+}
@@ -0,0 +1,128 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package metadb
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/pkg/logger"
+)
+
+var (
+	preloadMetricsCounterVec = metaDBScope.NewCounterVec("preload_metrics", "db")
+	preloadFailCounterVec    = metaDBScope.NewCounterVec("preload_fails", "db")
+)
+
+// ErrPreloading represents metadata is loading hot metrics into memory.
+var ErrPreloading = errors.New("metadata is preloading")
+
+// preloadingDatabases stores the databases whose metadata are preloading, key: database name.
+var preloadingDatabases sync.Map
+
+// CheckPreload checks if the metadata of all databases complete preload,
+// returns ErrPreloading with the preloading databases if not.
+func CheckPreload() error {
+	var databases []string
+	preloadingDatabases.Range(func(key, _ interface{}) bool {
+		databases = append(databases, key.(string))
+		return true
+	})
+	if len(databases) == 0 {
+		return nil
+	}
+	sort.Strings(databases)
+	return fmt.Errorf("%w, %s", ErrPreloading, strings.Join(databases, "; "))
+}
+
+// ParseNamespaceMetric parses the namespace and metric name from "namespace|metric-name",
+// uses default namespace if namespace omitted.
+func ParseNamespaceMetric(metric string) (namespace, metricName string) {
+	parts := strings.SplitN(metric, "|", 2)
+	if len(parts) == 1 {
+		return constants.DefaultNamespace, parts[0]
+	}
+	return parts[0], parts[1]
+}
+
+// startPreload loads the metadata of metrics into memory in background,
+// database is marked preloading until it completes.
+func (m *metadata) startPreload(metrics []string) {
+	if len(metrics) == 0 {
+		return
+	}
+	preloadingDatabases.Store(m.databaseName, struct{}{})
+	m.preloadWG.Add(1)
+	go func() {
+		defer func() {
+			preloadingDatabases.Delete(m.databaseName)
+			m.preloadWG.Done()
+		}()
+		if err := m.Preload(metrics); err != nil {
+			metaLogger.Warn("preload metadata error",
+				logger.String("db", m.databaseName), logger.Error(err))
+			return
+		}
+		metaLogger.Info("preload metadata completed",
+			logger.String("db", m.databaseName), logger.Int("metrics", len(metrics)))
+	}()
+}
+
+// Preload loads the tag keys and tag value dictionaries of metrics into memory,
+// metric which does not exist is skipped, returns the first error after all metrics are loaded.
+func (m *metadata) Preload(metrics []string) error {
+	var firstErr error
+	for _, metric := range metrics {
+		select {
+		case <-m.ctx.Done():
+			return m.ctx.Err()
+		default:
+		}
+		if err := m.preloadMetric(metric); err != nil {
+			preloadFailCounterVec.WithTagValues(m.databaseName).Incr()
+			if firstErr == nil {
+				firstErr = fmt.Errorf("preload metric: %s, error: %w", metric, err)
+			}
+			continue
+		}
+		preloadMetricsCounterVec.WithTagValues(m.databaseName).Incr()
+	}
+	return firstErr
+}
+
+// preloadMetric loads the tag keys of metric into metadata database cache, then tag value dictionaries.
+func (m *metadata) preloadMetric(metric string) error {
+	namespace, metricName := ParseNamespaceMetric(metric)
+	tags, err := m.metadataDatabase.PreloadMetric(namespace, metricName)
+	if err != nil {
+		if errors.Is(err, constants.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	for _, tagKey := range tags {
+		if err := m.tagMetadata.PreloadTagValues(tagKey.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package metadb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/series/tag"
+)
+
+func TestParseNamespaceMetric(t *testing.T) {
+	namespace, metricName := ParseNamespaceMetric("cpu")
+	assert.Equal(t, constants.DefaultNamespace, namespace)
+	assert.Equal(t, "cpu", metricName)
+	namespace, metricName = ParseNamespaceMetric("ns|cpu")
+	assert.Equal(t, "ns", namespace)
+	assert.Equal(t, "cpu", metricName)
+}
+
+func TestMetadata_Preload(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	db := NewMockMetadataDatabase(ctrl)
+	tagMeta := NewMockTagMetadata(ctrl)
+	m := &metadata{
+		databaseName:     "test",
+		metadataDatabase: db,
+		tagMetadata:      tagMeta,
+		ctx:              context.TODO(),
+	}
+	// case 1: preload tag keys and tag values, metric not exist is skipped
+	db.EXPECT().PreloadMetric(constants.DefaultNamespace, "cpu").
+		Return([]tag.Meta{{ID: 1, Key: "host"}, {ID: 2, Key: "ip"}}, nil)
+	db.EXPECT().PreloadMetric("ns", "memory").Return(nil, constants.ErrMetricIDNotFound)
+	tagMeta.EXPECT().PreloadTagValues(uint32(1)).Return(nil)
+	tagMeta.EXPECT().PreloadTagValues(uint32(2)).Return(nil)
+	assert.NoError(t, m.Preload([]string{"cpu", "ns|memory"}))
+	// case 2: load tag keys err, continue next metric
+	db.EXPECT().PreloadMetric(constants.DefaultNamespace, "cpu").Return(nil, fmt.Errorf("err"))
+	db.EXPECT().PreloadMetric(constants.DefaultNamespace, "disk").Return([]tag.Meta{{ID: 3, Key: "path"}}, nil)
+	tagMeta.EXPECT().PreloadTagValues(uint32(3)).Return(nil)
+	assert.Error(t, m.Preload([]string{"cpu", "disk"}))
+	// case 3: load tag values err
+	db.EXPECT().PreloadMetric(constants.DefaultNamespace, "disk").Return([]tag.Meta{{ID: 3, Key: "path"}}, nil)
+	tagMeta.EXPECT().PreloadTagValues(uint32(3)).Return(fmt.Errorf("err"))
+	assert.Error(t, m.Preload([]string{"disk"}))
+	// case 4: context canceled
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	m.ctx = ctx
+	assert.Error(t, m.Preload([]string{"disk"}))
+}
+
+func TestMetadata_startPreload(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	db := NewMockMetadataDatabase(ctrl)
+	tagMeta := NewMockTagMetadata(ctrl)
+	m := &metadata{
+		databaseName:     "test",
+		metadataDatabase: db,
+		tagMetadata:      tagMeta,
+		ctx:              context.TODO(),
+	}
+	// case 1: no metrics
+	m.startPreload(nil)
+	assert.NoError(t, CheckPreload())
+
+	// case 2: node not ready until preload completes
+	loading := make(chan struct{})
+	db.EXPECT().PreloadMetric(constants.DefaultNamespace, "cpu").DoAndReturn(func(_, _ string) ([]tag.Meta, error) {
+		<-loading
+		return nil, nil
+	})
+	m.startPreload([]string{"cpu"})
+	err := CheckPreload()
+	assert.True(t, errors.Is(err, ErrPreloading))
+	close(loading)
+	m.preloadWG.Wait()
+	assert.NoError(t, CheckPreload())
+
+	// case 3: preload fail
+	db.EXPECT().PreloadMetric(constants.DefaultNamespace, "cpu").Return(nil, fmt.Errorf("err"))
+	m.startPreload([]string{"cpu"})
+	m.preloadWG.Wait()
+	assert.NoError(t, CheckPreload())
+}
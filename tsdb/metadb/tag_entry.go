@@ -18,15 +18,22 @@
 package metadb
 
 import (
-	"regexp"
+	"fmt"
 	"strings"
 
 	"github.com/lindb/roaring"
 	"go.uber.org/atomic"
 
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/pkg/strutil"
 	"github.com/lindb/lindb/sql/stmt"
 )
 
+// for testing
+var (
+	maxRegexScanTagValues = constants.MaxRegexScanTagValues
+)
+
 // TagEntry represents the tag value=>id under tag key
 type TagEntry interface {
 	// genTagValueID generates a new tag value id for new tag value, start with 1
@@ -36,7 +43,7 @@ type TagEntry interface {
 	// addTagValue adds tag value=>id mapping
 	addTagValue(tagValue string, tagValueID uint32)
 	// findSeriesIDsByExpr finds tag value ids by tag filter expr
-	findSeriesIDsByExpr(expr stmt.TagFilter) *roaring.Bitmap
+	findSeriesIDsByExpr(expr stmt.TagFilter) (*roaring.Bitmap, error)
 	// getTagValueID gets the tag value id by tag value under the tag key
 	getTagValueID(tagValue string) (uint32, bool)
 	// getTagValueIDs returns all tag value ids under the tag key
@@ -98,19 +105,21 @@ func (t *tagEntry) getTagValueIDs() *roaring.Bitmap {
 }
 
 // findSeriesIDsByExpr finds tag value ids by tag filter expr
-func (t *tagEntry) findSeriesIDsByExpr(expr stmt.TagFilter) *roaring.Bitmap {
+func (t *tagEntry) findSeriesIDsByExpr(expr stmt.TagFilter) (*roaring.Bitmap, error) {
 	switch expression := expr.(type) {
 	case *stmt.EqualsExpr:
-		return t.findSeriesIDsByEqual(expression.Value)
+		return t.findSeriesIDsByEqual(expression.Value), nil
 	case *stmt.InExpr:
-		return t.findSeriesIDsByIn(expression)
+		return t.findSeriesIDsByIn(expression), nil
 	case *stmt.LikeExpr:
-		return t.findSeriesIDsByLike(expression)
+		return t.findSeriesIDsByLike(expression), nil
 	case *stmt.RegexExpr:
 		return t.findSeriesIDsByRegex(expression)
+	case *stmt.NumericCompareExpr:
+		return t.findSeriesIDsByNumericCompare(expression)
 	}
 	metaLogger.Warn("expr type is not tag filter when find tag value ids by expr")
-	return nil
+	return nil, nil
 }
 
 // findSeriesIDsByEqual finds tag value ids by tag value - equal
@@ -183,24 +192,48 @@ func (t *tagEntry) findSeriesIDsByLike(expr *stmt.LikeExpr) *roaring.Bitmap {
 	return result
 }
 
-// findSeriesIDsByRegex finds tag value ids by tag value - regex
-func (t *tagEntry) findSeriesIDsByRegex(expr *stmt.RegexExpr) *roaring.Bitmap {
-	pattern, err := regexp.Compile(expr.Regexp)
+// findSeriesIDsByNumericCompare finds tag value ids by numeric comparison of tag value,
+// non-numeric tag values are skipped, returns constants.ErrRegexScanLimitExceeded
+// if more than constants.MaxRegexScanTagValues need be scanned, same as regex.
+func (t *tagEntry) findSeriesIDsByNumericCompare(expr *stmt.NumericCompareExpr) (*roaring.Bitmap, error) {
+	if len(t.tagValues) > maxRegexScanTagValues {
+		return nil, fmt.Errorf("%w, numeric compare: %s, limit: %d",
+			constants.ErrRegexScanLimitExceeded, expr.Rewrite(), maxRegexScanTagValues)
+	}
+	result := roaring.New()
+	for value, tagValueID := range t.tagValues {
+		if expr.Match(value) {
+			result.Add(tagValueID)
+		}
+	}
+	return result, nil
+}
+
+// findSeriesIDsByRegex finds tag value ids by tag value - regex,
+// only scans the tag values with the literal prefix if pattern is anchored,
+// returns constants.ErrRegexScanLimitExceeded if more than constants.MaxRegexScanTagValues need be scanned.
+func (t *tagEntry) findSeriesIDsByRegex(expr *stmt.RegexExpr) (*roaring.Bitmap, error) {
+	pattern, err := strutil.CompileRegexp(expr.Regexp)
 	if err != nil {
-		return nil
+		return nil, nil
 	}
-	// the regex pattern is regarded as a prefix string + pattern
-	literalPrefix, _ := pattern.LiteralPrefix()
+	literalPrefix := strutil.AnchoredLiteralPrefix(pattern)
 	result := roaring.New()
+	scanned := 0
 	for value, tagValueID := range t.tagValues {
 		if !strings.HasPrefix(value, literalPrefix) {
 			continue
 		}
+		if scanned >= maxRegexScanTagValues {
+			return nil, fmt.Errorf("%w, pattern: %s, limit: %d",
+				constants.ErrRegexScanLimitExceeded, expr.Regexp, maxRegexScanTagValues)
+		}
+		scanned++
 		if pattern.MatchString(value) {
 			result.Add(tagValueID)
 		}
 	}
-	return result
+	return result, nil
 }
 
 // collectTagValues collects the tag values by tag value ids,
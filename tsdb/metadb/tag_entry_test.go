@@ -18,12 +18,16 @@
 package metadb
 
 import (
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/golang/mock/gomock"
 	"github.com/lindb/roaring"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/pkg/strutil"
 	"github.com/lindb/lindb/sql/stmt"
 )
 
@@ -52,36 +56,36 @@ func TestTagEntry_getTagValueID(t *testing.T) {
 func TestTagEntry_findSeriesIDsByEquals(t *testing.T) {
 	tagIndex := prepareTagEntry()
 	// tag-value not exist
-	assert.Nil(t, tagIndex.findSeriesIDsByExpr(&stmt.EqualsExpr{Key: "host", Value: "alpha"}))
+	assert.Nil(t, findSeriesIDsByExpr(t, tagIndex, &stmt.EqualsExpr{Key: "host", Value: "alpha"}))
 	// tag-value exist
-	assert.Equal(t, roaring.BitmapOf(4), tagIndex.findSeriesIDsByExpr(&stmt.EqualsExpr{Key: "host", Value: "c"}))
+	assert.Equal(t, roaring.BitmapOf(4), findSeriesIDsByExpr(t, tagIndex, &stmt.EqualsExpr{Key: "host", Value: "c"}))
 	// tag-value exist
-	assert.Equal(t, roaring.BitmapOf(5), tagIndex.findSeriesIDsByExpr(&stmt.EqualsExpr{Key: "host", Value: "bc"}))
+	assert.Equal(t, roaring.BitmapOf(5), findSeriesIDsByExpr(t, tagIndex, &stmt.EqualsExpr{Key: "host", Value: "bc"}))
 }
 
 func TestTagEntry_findSeriesIDsByLike(t *testing.T) {
 	tagIndex := prepareTagEntry()
 
 	// tag-value is empty
-	assert.Nil(t, tagIndex.findSeriesIDsByExpr(&stmt.LikeExpr{Key: "host"}))
+	assert.Nil(t, findSeriesIDsByExpr(t, tagIndex, &stmt.LikeExpr{Key: "host"}))
 	// tag-value exist
-	assert.Equal(t, roaring.BitmapOf(2, 5, 8), tagIndex.findSeriesIDsByExpr(&stmt.LikeExpr{Key: "host", Value: "*bc*"}))
+	assert.Equal(t, roaring.BitmapOf(2, 5, 8), findSeriesIDsByExpr(t, tagIndex, &stmt.LikeExpr{Key: "host", Value: "*bc*"}))
 	// tag-value not exist
-	assert.Equal(t, roaring.New(), tagIndex.findSeriesIDsByExpr(&stmt.LikeExpr{Key: "host", Value: "zz*"}))
+	assert.Equal(t, roaring.New(), findSeriesIDsByExpr(t, tagIndex, &stmt.LikeExpr{Key: "host", Value: "zz*"}))
 	// tag-value is *
-	assert.Equal(t, roaring.BitmapOf(1, 2, 3, 4, 5, 6, 7, 8), tagIndex.findSeriesIDsByExpr(&stmt.LikeExpr{Key: "host", Value: "*"}))
+	assert.Equal(t, roaring.BitmapOf(1, 2, 3, 4, 5, 6, 7, 8), findSeriesIDsByExpr(t, tagIndex, &stmt.LikeExpr{Key: "host", Value: "*"}))
 	// tag-value is "abc" ==> equals
-	assert.Equal(t, roaring.BitmapOf(2), tagIndex.findSeriesIDsByExpr(&stmt.LikeExpr{Key: "host", Value: "abc"}))
+	assert.Equal(t, roaring.BitmapOf(2), findSeriesIDsByExpr(t, tagIndex, &stmt.LikeExpr{Key: "host", Value: "abc"}))
 	// tag-value is "*cd"
-	assert.Equal(t, roaring.BitmapOf(8), tagIndex.findSeriesIDsByExpr(&stmt.LikeExpr{Key: "host", Value: "*cd"}))
+	assert.Equal(t, roaring.BitmapOf(8), findSeriesIDsByExpr(t, tagIndex, &stmt.LikeExpr{Key: "host", Value: "*cd"}))
 	// tag-value is "b*"
-	assert.Equal(t, roaring.BitmapOf(3, 5, 6, 7, 8), tagIndex.findSeriesIDsByExpr(&stmt.LikeExpr{Key: "host", Value: "b*"}))
+	assert.Equal(t, roaring.BitmapOf(3, 5, 6, 7, 8), findSeriesIDsByExpr(t, tagIndex, &stmt.LikeExpr{Key: "host", Value: "b*"}))
 }
 
 func TestTagEntry_findSeriesIDsByIn(t *testing.T) {
 	tagIndex := prepareTagEntry()
 	// tag-value exist
-	assert.Equal(t, roaring.BitmapOf(3, 5, 8), tagIndex.findSeriesIDsByExpr(&stmt.InExpr{Key: "host", Values: []string{"b", "bc", "bcd", "ahi"}}))
+	assert.Equal(t, roaring.BitmapOf(3, 5, 8), findSeriesIDsByExpr(t, tagIndex, &stmt.InExpr{Key: "host", Values: []string{"b", "bc", "bcd", "ahi"}}))
 }
 
 func TestTagEntry_findSeriesIDsByExpr_not_tagFilter(t *testing.T) {
@@ -90,19 +94,67 @@ func TestTagEntry_findSeriesIDsByExpr_not_tagFilter(t *testing.T) {
 
 	tagIndex := prepareTagEntry()
 	tagFilter := stmt.NewMockTagFilter(ctrl)
-	assert.Nil(t, tagIndex.findSeriesIDsByExpr(tagFilter))
+	assert.Nil(t, findSeriesIDsByExpr(t, tagIndex, tagFilter))
 }
 
 func TestTagEntry_findSeriesIDsByRegex(t *testing.T) {
 	tagIndex := prepareTagEntry()
 	// pattern not match
-	assert.Equal(t, roaring.New(), tagIndex.findSeriesIDsByExpr(&stmt.RegexExpr{Key: "host", Regexp: "bbbbbbbbbbb"}))
+	assert.Equal(t, roaring.New(), findSeriesIDsByExpr(t, tagIndex, &stmt.RegexExpr{Key: "host", Regexp: "bbbbbbbbbbb"}))
 	// pattern error
-	assert.Nil(t, tagIndex.findSeriesIDsByExpr(&stmt.RegexExpr{Key: "host", Regexp: "b.32*++++\n"}))
+	assert.Nil(t, findSeriesIDsByExpr(t, tagIndex, &stmt.RegexExpr{Key: "host", Regexp: "b.32*++++\n"}))
 	// tag-value exist
-	assert.Equal(t, roaring.BitmapOf(6, 7), tagIndex.findSeriesIDsByExpr(&stmt.RegexExpr{Key: "host", Regexp: `b2[0-9]+`}))
-	// literal prefix:22 not exist
-	assert.Equal(t, roaring.New(), tagIndex.findSeriesIDsByExpr(&stmt.RegexExpr{Key: "host", Regexp: `22+`}))
+	assert.Equal(t, roaring.BitmapOf(6, 7), findSeriesIDsByExpr(t, tagIndex, &stmt.RegexExpr{Key: "host", Regexp: `b2[0-9]+`}))
+	// unanchored literal 22 matches inside tag value
+	assert.Equal(t, roaring.BitmapOf(7), findSeriesIDsByExpr(t, tagIndex, &stmt.RegexExpr{Key: "host", Regexp: `22+`}))
+	// anchored literal prefix:22 not exist
+	assert.Equal(t, roaring.New(), findSeriesIDsByExpr(t, tagIndex, &stmt.RegexExpr{Key: "host", Regexp: `^22+`}))
+	// anchored pattern
+	assert.Equal(t, roaring.BitmapOf(5, 8), findSeriesIDsByExpr(t, tagIndex, &stmt.RegexExpr{Key: "host", Regexp: `^bc`}))
+	assert.Equal(t, roaring.BitmapOf(5), findSeriesIDsByExpr(t, tagIndex, &stmt.RegexExpr{Key: "host", Regexp: `^bc$`}))
+	// unanchored pattern
+	assert.Equal(t, roaring.BitmapOf(2, 5, 8), findSeriesIDsByExpr(t, tagIndex, &stmt.RegexExpr{Key: "host", Regexp: `bc`}))
+	// empty match
+	assert.Equal(t, roaring.New(), findSeriesIDsByExpr(t, tagIndex, &stmt.RegexExpr{Key: "host", Regexp: `^x.*`}))
+	// pattern too long
+	assert.Nil(t, findSeriesIDsByExpr(t, tagIndex, &stmt.RegexExpr{Key: "host", Regexp: strings.Repeat("a", strutil.MaxRegexpLength+1)}))
+	// scan limit exceeded
+	defer func() {
+		maxRegexScanTagValues = constants.MaxRegexScanTagValues
+	}()
+	maxRegexScanTagValues = 2
+	assert.Equal(t, roaring.BitmapOf(5, 8), findSeriesIDsByExpr(t, tagIndex, &stmt.RegexExpr{Key: "host", Regexp: `^bc`}))
+	ids, err := tagIndex.findSeriesIDsByExpr(&stmt.RegexExpr{Key: "host", Regexp: `^b`})
+	assert.True(t, errors.Is(err, constants.ErrRegexScanLimitExceeded))
+	assert.Nil(t, ids)
+}
+
+func TestTagEntry_findSeriesIDsByNumericCompare(t *testing.T) {
+	tagIndex := newTagEntry(0)
+	tagIndex.addTagValue("80", 1)
+	tagIndex.addTagValue("443", 2)
+	tagIndex.addTagValue("8080", 3)
+	tagIndex.addTagValue("8080.5", 4)
+	tagIndex.addTagValue("http", 5)
+	// non-numeric tag value never matches
+	assert.Equal(t, roaring.BitmapOf(3, 4),
+		findSeriesIDsByExpr(t, tagIndex, &stmt.NumericCompareExpr{Key: "port", Operator: stmt.GreaterThan, Value: 443}))
+	assert.Equal(t, roaring.BitmapOf(2, 3, 4),
+		findSeriesIDsByExpr(t, tagIndex, &stmt.NumericCompareExpr{Key: "port", Operator: stmt.GreaterThanOrEqual, Value: 443}))
+	assert.Equal(t, roaring.BitmapOf(1),
+		findSeriesIDsByExpr(t, tagIndex, &stmt.NumericCompareExpr{Key: "port", Operator: stmt.LessThan, Value: 443}))
+	assert.Equal(t, roaring.BitmapOf(1, 2, 3),
+		findSeriesIDsByExpr(t, tagIndex, &stmt.NumericCompareExpr{Key: "port", Operator: stmt.LessThanOrEqual, Value: 8080}))
+	assert.Equal(t, roaring.New(),
+		findSeriesIDsByExpr(t, tagIndex, &stmt.NumericCompareExpr{Key: "port", Operator: stmt.GreaterThan, Value: 10000}))
+	// scan limit exceeded
+	defer func() {
+		maxRegexScanTagValues = constants.MaxRegexScanTagValues
+	}()
+	maxRegexScanTagValues = 4
+	ids, err := tagIndex.findSeriesIDsByExpr(&stmt.NumericCompareExpr{Key: "port", Operator: stmt.GreaterThan, Value: 443})
+	assert.True(t, errors.Is(err, constants.ErrRegexScanLimitExceeded))
+	assert.Nil(t, ids)
 }
 
 func TestTagEntry_collectTagValues(t *testing.T) {
@@ -128,6 +180,12 @@ func TestTagEntry_collectTagValues(t *testing.T) {
 	assert.Equal(t, "b", result[3])
 }
 
+func findSeriesIDsByExpr(t *testing.T, tagIndex TagEntry, expr stmt.TagFilter) *roaring.Bitmap {
+	ids, err := tagIndex.findSeriesIDsByExpr(expr)
+	assert.NoError(t, err)
+	return ids
+}
+
 func prepareTagEntry() TagEntry {
 	tagIndex := newTagEntry(0)
 	tagIndex.addTagValue("a", 1)
@@ -22,6 +22,7 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/lindb/lindb/config"
 	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/kv"
 	"github.com/lindb/lindb/pkg/strutil"
@@ -56,6 +57,15 @@ type TagMetadata interface {
 		tagValueIDs *roaring.Bitmap,
 		tagValues map[uint32]string,
 	) error
+	// ResolveTagValueIDs resolves the tag values by tag value ids for spec tag key in one call,
+	// the tag value of unknown tag value id is UnresolvedName.
+	ResolveTagValueIDs(tagKeyID uint32, tagValueIDs []uint32) (map[uint32]string, error)
+	// PreloadTagValues loads the tag value dictionary of tag key in kv store into tag value cache,
+	// so that generating tag value ids of tag key does not read it from disk.
+	PreloadTagValues(tagKeyID uint32) error
+	// ImportTagValues adds the tag value dictionary of tag key with spec tag value ids into memory,
+	// which is written into kv store when flushing.
+	ImportTagValues(tagKeyID uint32, tagValues map[string]uint32)
 	// Flush flushes the memory tag metadata into kv store
 	Flush() error
 }
@@ -63,9 +73,10 @@ type TagMetadata interface {
 // tagMetadata implements TagMetadata interface
 type tagMetadata struct {
 	databaseName string
-	family       kv.Family // store tag key/value data using common kv store
-	mutable      *TagStore // mutable store current writeable memory store
-	immutable    *TagStore // immutable need to flush into kv store
+	family       kv.Family      // store tag key/value data using common kv store
+	mutable      *TagStore      // mutable store current writeable memory store
+	immutable    *TagStore      // immutable need to flush into kv store
+	cache        *tagValueCache // bounded cache of tag value dictionaries read from kv store
 
 	rwMutex sync.RWMutex
 }
@@ -76,6 +87,8 @@ func NewTagMetadata(databaseName string, family kv.Family) TagMetadata {
 		databaseName: databaseName,
 		family:       family,
 		mutable:      NewTagStore(),
+		cache: newTagValueCache(config.GlobalStorageConfig().TSDB.TagValueCacheSize,
+			tagValueCacheSizeVec.WithTagValues(databaseName), tagValueCacheEvictsVec.WithTagValues(databaseName)),
 	}
 	return m
 }
@@ -90,6 +103,9 @@ func (m *tagMetadata) GenTagValueID(tagKeyID uint32, tagValue string) (tagValueI
 		return tagValueID, nil
 	}
 	m.rwMutex.RUnlock()
+	if tagValueID, ok = m.cache.get(tagKeyID, tagValue); ok {
+		return tagValueID, nil
+	}
 
 	// try load tag value id from kv store
 	snapshot := m.family.GetSnapshot()
@@ -107,6 +123,7 @@ func (m *tagMetadata) GenTagValueID(tagKeyID uint32, tagValue string) (tagValueI
 		tagValueID, err = reader.GetTagValueID(tagKeyID, tagValue)
 		if err == nil {
 			// got tag value id from kv store
+			m.cache.put(tagKeyID, tagValue, tagValueID)
 			return tagValueID, nil
 		}
 		if !errors.Is(err, constants.ErrNotFound) {
@@ -183,12 +200,23 @@ func (m *tagMetadata) SuggestTagValues(tagKeyID uint32, tagValuePrefix string, l
 // if not exist, return nil, constants.ErrNotFound, else returns tag value ids
 func (m *tagMetadata) FindTagValueDsByExpr(tagKeyID uint32, expr stmt.TagFilter) (*roaring.Bitmap, error) {
 	result := roaring.New()
+	var memErr error
 	m.loadTagValueIDsInMem(tagKeyID, func(tagEntry TagEntry) {
-		ids := tagEntry.findSeriesIDsByExpr(expr)
+		if memErr != nil {
+			return
+		}
+		ids, err := tagEntry.findSeriesIDsByExpr(expr)
+		if err != nil {
+			memErr = err
+			return
+		}
 		if ids != nil {
 			result.Or(ids)
 		}
 	})
+	if memErr != nil {
+		return nil, memErr
+	}
 
 	err := m.loadTagValueIDsInKV(tagKeyID, func(reader tagkeymeta.Reader) error {
 		tagValueIDs, err := reader.FindValueIDsByExprForTagKeyID(tagKeyID, expr)
@@ -255,6 +283,24 @@ func (m *tagMetadata) CollectTagValues(tagKeyID uint32,
 	return nil
 }
 
+// ResolveTagValueIDs resolves the tag values by tag value ids for spec tag key in one call,
+// the tag value of unknown tag value id is UnresolvedName.
+func (m *tagMetadata) ResolveTagValueIDs(tagKeyID uint32, tagValueIDs []uint32) (map[uint32]string, error) {
+	tagValues := make(map[uint32]string, len(tagValueIDs))
+	if len(tagValueIDs) == 0 {
+		return tagValues, nil
+	}
+	if err := m.CollectTagValues(tagKeyID, roaring.BitmapOf(tagValueIDs...), tagValues); err != nil {
+		return nil, err
+	}
+	for _, tagValueID := range tagValueIDs {
+		if _, ok := tagValues[tagValueID]; !ok {
+			tagValues[tagValueID] = UnresolvedName
+		}
+	}
+	return tagValues, nil
+}
+
 // Flush flushes the memory tag metadata into kv store
 func (m *tagMetadata) Flush() error {
 	if !m.checkFlush() {
@@ -306,6 +352,43 @@ func (m *tagMetadata) checkFlush() bool {
 	return true
 }
 
+// PreloadTagValues walks all tag values of tag key in kv store, loads the dictionary into tag value cache,
+// at most the capacity of cache tag values are loaded.
+func (m *tagMetadata) PreloadTagValues(tagKeyID uint32) error {
+	tagValues := make(map[string]uint32)
+	if err := m.loadTagValueIDsInKV(tagKeyID, func(reader tagkeymeta.Reader) error {
+		return reader.WalkTagValues(tagKeyID, "", func(tagValue []byte, tagValueID uint32) bool {
+			tagValues[string(tagValue)] = tagValueID
+			return m.cache.capacity <= 0 || len(tagValues) < m.cache.capacity
+		})
+	}); err != nil {
+		return err
+	}
+	if len(tagValues) > 0 {
+		m.cache.putAll(tagKeyID, tagValues)
+	}
+	return nil
+}
+
+// ImportTagValues adds the tag value dictionary of tag key with spec tag value ids into memory,
+// which is written into kv store when flushing.
+func (m *tagMetadata) ImportTagValues(tagKeyID uint32, tagValues map[string]uint32) {
+	var seq uint32
+	for _, tagValueID := range tagValues {
+		if tagValueID > seq {
+			seq = tagValueID
+		}
+	}
+	m.rwMutex.Lock()
+	defer m.rwMutex.Unlock()
+
+	tag := newTagEntry(seq)
+	for tagValue, tagValueID := range tagValues {
+		tag.addTagValue(tagValue, tagValueID)
+	}
+	m.mutable.Put(tagKeyID, tag)
+}
+
 // loadTagValueIDsInKV loads tag value ids in kv store
 func (m *tagMetadata) loadTagValueIDsInKV(tagKeyID uint32, fn func(reader tagkeymeta.Reader) error) error {
 	// try load tag value id from kv store
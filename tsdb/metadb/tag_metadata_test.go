@@ -18,6 +18,7 @@
 package metadb
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 
@@ -67,6 +68,10 @@ func TestTagMetadata_GenTagValueID(t *testing.T) {
 	tagValueID, err = meta.GenTagValueID(1, "tag-value-2")
 	assert.NoError(t, err)
 	assert.Equal(t, uint32(2), tagValueID)
+	// tag value id read from kv store is cached
+	tagValueID, err = meta.GenTagValueID(1, "tag-value-2")
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(2), tagValueID)
 	// case 5: get tag value from kv store err
 	tagReader.EXPECT().GetTagValueID(uint32(1), "tag-value-2-err").Return(uint32(0), fmt.Errorf("err"))
 	tagValueID, err = meta.GenTagValueID(1, "tag-value-2-err")
@@ -188,6 +193,14 @@ func TestTagMetadata_FindTagValueDsByExpr(t *testing.T) {
 	ids, err = meta.FindTagValueDsByExpr(uint32(10), &stmt.EqualsExpr{Value: "tag-value-20"})
 	assert.NoError(t, err)
 	assert.Equal(t, roaring.BitmapOf(20, 30, 40), ids)
+	// case 6: regex scan limit exceeded in memory
+	defer func() {
+		maxRegexScanTagValues = constants.MaxRegexScanTagValues
+	}()
+	maxRegexScanTagValues = 0
+	ids, err = meta.FindTagValueDsByExpr(uint32(10), &stmt.RegexExpr{Regexp: "tag-value"})
+	assert.True(t, errors.Is(err, constants.ErrRegexScanLimitExceeded))
+	assert.Nil(t, ids)
 }
 
 func TestTagMetadata_GetTagValueIDsForTag(t *testing.T) {
@@ -239,6 +252,57 @@ func TestTagMetadata_GetTagValueIDsForTag(t *testing.T) {
 	assert.Equal(t, roaring.BitmapOf(20, 30, 40), ids)
 }
 
+func TestTagMetadata_PreloadTagValues(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		newTagReaderFunc = tagkeymeta.NewReader
+		ctrl.Finish()
+	}()
+
+	meta, _, snapshot := mockTagMetadata(ctrl)
+
+	tagReader := tagkeymeta.NewMockReader(ctrl)
+	newTagReaderFunc = func(readers []table.Reader) tagkeymeta.Reader {
+		return tagReader
+	}
+	// case 1: no data in kv store
+	snapshot.EXPECT().FindReaders(gomock.Any()).Return(nil, nil)
+	assert.NoError(t, meta.PreloadTagValues(uint32(10)))
+	// case 2: kv store find readers err
+	snapshot.EXPECT().FindReaders(gomock.Any()).Return(nil, fmt.Errorf("err"))
+	assert.Error(t, meta.PreloadTagValues(uint32(10)))
+	// case 3: walk tag values err
+	snapshot.EXPECT().FindReaders(gomock.Any()).Return([]table.Reader{table.NewMockReader(ctrl)}, nil)
+	tagReader.EXPECT().WalkTagValues(uint32(10), "", gomock.Any()).Return(fmt.Errorf("err"))
+	assert.Error(t, meta.PreloadTagValues(uint32(10)))
+	walkTagValues := func(_ uint32, _ string, fn func(tagValue []byte, tagValueID uint32) bool) error {
+		for i := uint32(1); i <= 3; i++ {
+			if !fn([]byte(fmt.Sprintf("value-%d", i)), i) {
+				break
+			}
+		}
+		return nil
+	}
+	// case 4: walk tag values into tag value cache
+	snapshot.EXPECT().FindReaders(gomock.Any()).Return([]table.Reader{table.NewMockReader(ctrl)}, nil)
+	tagReader.EXPECT().WalkTagValues(uint32(10), "", gomock.Any()).
+		DoAndReturn(walkTagValues)
+	assert.NoError(t, meta.PreloadTagValues(uint32(10)))
+	m := meta.(*tagMetadata)
+	assert.Equal(t, 3, m.cache.len())
+	// preloaded tag value id is got from cache without reading kv store
+	tagValueID, err := meta.GenTagValueID(10, "value-2")
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(2), tagValueID)
+	// case 5: load at most the capacity of cache
+	m.cache = newTagValueCache(2, tagValueCacheSizeVec.WithTagValues("test"), tagValueCacheEvictsVec.WithTagValues("test"))
+	snapshot.EXPECT().FindReaders(gomock.Any()).Return([]table.Reader{table.NewMockReader(ctrl)}, nil)
+	tagReader.EXPECT().WalkTagValues(uint32(10), "", gomock.Any()).
+		DoAndReturn(walkTagValues)
+	assert.NoError(t, meta.PreloadTagValues(uint32(10)))
+	assert.Equal(t, 2, m.cache.len())
+}
+
 func TestTagMetadata_CollectTagValues(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer func() {
@@ -271,6 +335,29 @@ func TestTagMetadata_CollectTagValues(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestTagMetadata_ResolveTagValueIDs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	meta, _, snapshot := mockTagMetadata(ctrl)
+	mockTagMetadataMemData(meta)
+
+	// case 1: empty tag value ids
+	tagValues, err := meta.ResolveTagValueIDs(10, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, tagValues)
+	// case 2: unknown tag value id marked
+	snapshot.EXPECT().FindReaders(gomock.Any()).Return(nil, nil)
+	tagValues, err = meta.ResolveTagValueIDs(10, []uint32{20, 30})
+	assert.NoError(t, err)
+	assert.Equal(t, map[uint32]string{20: "tag-value-20", 30: UnresolvedName}, tagValues)
+	// case 3: collect from kv err
+	snapshot.EXPECT().FindReaders(gomock.Any()).Return(nil, fmt.Errorf("err"))
+	tagValues, err = meta.ResolveTagValueIDs(10, []uint32{30})
+	assert.Error(t, err)
+	assert.Nil(t, tagValues)
+}
+
 func TestTagMetadata_Flush(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer func() {
@@ -0,0 +1,146 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package metadb
+
+import (
+	"container/list"
+	"sync"
+
+	"go.uber.org/atomic"
+
+	"github.com/lindb/lindb/internal/linmetric"
+)
+
+// tagValueDict represents the cached tag value dictionary(tag value => tag value id) of tag key.
+type tagValueDict struct {
+	tagKeyID  uint32
+	tagValues map[string]uint32
+	elem      *list.Element
+	accessed  atomic.Bool // accessed since last eviction scan, gives dictionary a second chance
+}
+
+// tagValueCache caches the tag value dictionaries read from kv store, bounded by the total number of tag values,
+// evicts the dictionary of least recently used tag key approximately by second chance(clock).
+// Tag value id is immutable after assigned, so cached dictionary never needs invalidation.
+type tagValueCache struct {
+	capacity int // max number of cached tag values, 0 means unlimited
+	size     int // number of cached tag values
+	dicts    map[uint32]*tagValueDict
+	lru      *list.List // front is the most recently put
+	rwMutex  sync.RWMutex
+
+	sizeGauge *linmetric.BoundGauge
+	evictions *linmetric.BoundCounter
+}
+
+// newTagValueCache creates the tag value dictionary cache with capacity.
+func newTagValueCache(capacity int, sizeGauge *linmetric.BoundGauge, evictions *linmetric.BoundCounter) *tagValueCache {
+	return &tagValueCache{
+		capacity:  capacity,
+		dicts:     make(map[uint32]*tagValueDict),
+		lru:       list.New(),
+		sizeGauge: sizeGauge,
+		evictions: evictions,
+	}
+}
+
+// get returns the cached tag value id of tag value under tag key.
+func (c *tagValueCache) get(tagKeyID uint32, tagValue string) (uint32, bool) {
+	c.rwMutex.RLock()
+	defer c.rwMutex.RUnlock()
+
+	dict, ok := c.dicts[tagKeyID]
+	if !ok {
+		return 0, false
+	}
+	dict.accessed.Store(true)
+	tagValueID, ok := dict.tagValues[tagValue]
+	return tagValueID, ok
+}
+
+// put caches the tag value id of tag value under tag key.
+func (c *tagValueCache) put(tagKeyID uint32, tagValue string, tagValueID uint32) {
+	c.rwMutex.Lock()
+	defer c.rwMutex.Unlock()
+
+	dict := c.getOrCreateDict(tagKeyID)
+	if _, ok := dict.tagValues[tagValue]; !ok {
+		dict.tagValues[tagValue] = tagValueID
+		c.size++
+	}
+	c.evict(dict)
+}
+
+// putAll caches the tag value dictionary of tag key, merges it with the cached one.
+func (c *tagValueCache) putAll(tagKeyID uint32, tagValues map[string]uint32) {
+	c.rwMutex.Lock()
+	defer c.rwMutex.Unlock()
+
+	dict := c.getOrCreateDict(tagKeyID)
+	for tagValue, tagValueID := range tagValues {
+		if _, ok := dict.tagValues[tagValue]; !ok {
+			dict.tagValues[tagValue] = tagValueID
+			c.size++
+		}
+	}
+	c.evict(dict)
+}
+
+// getOrCreateDict returns the dictionary of tag key, creates it if not exist, must be called with lock held.
+func (c *tagValueCache) getOrCreateDict(tagKeyID uint32) *tagValueDict {
+	dict, ok := c.dicts[tagKeyID]
+	if ok {
+		dict.accessed.Store(true)
+		return dict
+	}
+	dict = &tagValueDict{tagKeyID: tagKeyID, tagValues: make(map[string]uint32)}
+	dict.elem = c.lru.PushFront(dict)
+	c.dicts[tagKeyID] = dict
+	return dict
+}
+
+// len returns the number of cached tag values.
+func (c *tagValueCache) len() int {
+	c.rwMutex.RLock()
+	defer c.rwMutex.RUnlock()
+
+	return c.size
+}
+
+// evict removes the dictionaries of least recently used tag keys until cache is not full,
+// the dictionary kept is never evicted, must be called with lock held.
+func (c *tagValueCache) evict(keep *tagValueDict) {
+	defer func() {
+		c.sizeGauge.Update(float64(c.size))
+	}()
+
+	// scans dictionaries from back, accessed one is moved to front for second chance,
+	// all accessed flags are cleared in first round, so two rounds at most.
+	for i := 2 * c.lru.Len(); i > 0 && c.lru.Len() > 0 && c.capacity > 0 && c.size > c.capacity; i-- {
+		elem := c.lru.Back()
+		dict := elem.Value.(*tagValueDict)
+		if dict == keep || dict.accessed.CAS(true, false) {
+			c.lru.MoveToFront(elem)
+			continue
+		}
+		c.lru.Remove(elem)
+		delete(c.dicts, dict.tagKeyID)
+		c.size -= len(dict.tagValues)
+		c.evictions.Incr()
+	}
+}
@@ -0,0 +1,63 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package metadb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTagValueCache(t *testing.T) {
+	cache := newTagValueCache(3, tagValueCacheSizeVec.WithTagValues("test"), tagValueCacheEvictsVec.WithTagValues("test"))
+	cache.put(1, "a", 1)
+	cache.put(1, "b", 2)
+	cache.put(1, "b", 2)
+	cache.put(2, "a", 1)
+	assert.Equal(t, 3, cache.len())
+	tagValueID, ok := cache.get(1, "b")
+	assert.True(t, ok)
+	assert.Equal(t, uint32(2), tagValueID)
+	_, ok = cache.get(1, "c")
+	assert.False(t, ok)
+	_, ok = cache.get(3, "a")
+	assert.False(t, ok)
+	// case 1: evict dictionary of least recently used tag key
+	cache.put(3, "a", 1)
+	assert.Equal(t, 3, cache.len())
+	_, ok = cache.get(2, "a")
+	assert.False(t, ok)
+	_, ok = cache.get(1, "a")
+	assert.True(t, ok)
+	// case 2: dictionary just put is always kept
+	cache.put(4, "a", 1)
+	cache.put(4, "b", 2)
+	cache.put(4, "c", 3)
+	cache.put(4, "d", 4)
+	assert.Equal(t, 4, cache.len())
+	_, ok = cache.get(4, "d")
+	assert.True(t, ok)
+}
+
+func TestTagValueCache_Unlimited(t *testing.T) {
+	cache := newTagValueCache(0, tagValueCacheSizeVec.WithTagValues("test"), tagValueCacheEvictsVec.WithTagValues("test"))
+	for tagKeyID := uint32(0); tagKeyID < 10; tagKeyID++ {
+		cache.put(tagKeyID, "a", 1)
+	}
+	assert.Equal(t, 10, cache.len())
+}
@@ -0,0 +1,120 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package metadb
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/series/field"
+
+	"github.com/lindb/roaring"
+)
+
+// MetricSnapshot represents the metadata of metric which is exported with shard snapshot,
+// because metric/field/tag ids referenced by shard's data are generated by database level metadata.
+type MetricSnapshot struct {
+	Namespace string           `json:"namespace"`
+	Name      string           `json:"name"`
+	ID        uint32           `json:"id"`
+	Fields    []field.Meta     `json:"fields,omitempty"`
+	TagKeys   []TagKeySnapshot `json:"tagKeys,omitempty"`
+}
+
+// TagKeySnapshot represents the tag key of metric with tag value dictionary.
+type TagKeySnapshot struct {
+	Key    string            `json:"key"`
+	ID     uint32            `json:"id"`
+	Values map[string]uint32 `json:"values,omitempty"`
+}
+
+// Export returns the metadata of metrics by metric ids, includes fields, tag keys and tag values.
+func (m *metadata) Export(metricIDs *roaring.Bitmap) ([]MetricSnapshot, error) {
+	metrics, err := m.metadataDatabase.ExportMetrics(metricIDs)
+	if err != nil {
+		return nil, err
+	}
+	for idx := range metrics {
+		tagKeys := metrics[idx].TagKeys
+		for i := range tagKeys {
+			tagValueIDs, err := m.tagMetadata.GetTagValueIDsForTag(tagKeys[i].ID)
+			if err != nil {
+				if errors.Is(err, constants.ErrNotFound) {
+					continue
+				}
+				return nil, err
+			}
+			tagValues := make(map[uint32]string)
+			if err := m.tagMetadata.CollectTagValues(tagKeys[i].ID, tagValueIDs.Clone(), tagValues); err != nil {
+				return nil, err
+			}
+			tagKeys[i].Values = make(map[string]uint32, len(tagValues))
+			for tagValueID, tagValue := range tagValues {
+				tagKeys[i].Values[tagValue] = tagValueID
+			}
+		}
+	}
+	return metrics, nil
+}
+
+// Import installs the metadata of metrics if there is no metadata in database,
+// else checks if the ids of metadata are same as database's, returns constants.ErrIncompatibleMetadata if not.
+func (m *metadata) Import(metrics []MetricSnapshot) error {
+	installed, err := m.metadataDatabase.ImportMetrics(metrics)
+	if err != nil {
+		return err
+	}
+	for idx := range metrics {
+		for _, tagKey := range metrics[idx].TagKeys {
+			if installed {
+				m.tagMetadata.ImportTagValues(tagKey.ID, tagKey.Values)
+				continue
+			}
+			if err := m.checkTagValues(metrics[idx].Name, &tagKey); err != nil {
+				return err
+			}
+		}
+	}
+	if !installed {
+		return nil
+	}
+	return m.tagMetadata.Flush()
+}
+
+// checkTagValues checks if the tag value ids of tag key are same as database's.
+func (m *metadata) checkTagValues(metricName string, tagKey *TagKeySnapshot) error {
+	if len(tagKey.Values) == 0 {
+		return nil
+	}
+	tagValueIDs := make([]uint32, 0, len(tagKey.Values))
+	for _, tagValueID := range tagKey.Values {
+		tagValueIDs = append(tagValueIDs, tagValueID)
+	}
+	tagValues, err := m.tagMetadata.ResolveTagValueIDs(tagKey.ID, tagValueIDs)
+	if err != nil {
+		return err
+	}
+	for tagValue, tagValueID := range tagKey.Values {
+		if tagValues[tagValueID] != tagValue {
+			return fmt.Errorf("%w, metricName: %s, tagKey: %s, tagValue: %s is different",
+				constants.ErrIncompatibleMetadata, metricName, tagKey.Key, tagValue)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package metadb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/series/field"
+
+	"github.com/lindb/roaring"
+)
+
+func TestMetadataDatabase_ExportImportMetrics(t *testing.T) {
+	src, err := NewMetadataDatabase(context.TODO(), "test", t.TempDir())
+	assert.NoError(t, err)
+	defer func() {
+		_ = src.Close()
+	}()
+	_, err = src.GenMetricID("ns", "unused")
+	assert.NoError(t, err)
+	metricID, err := src.GenMetricID("ns", "cpu")
+	assert.NoError(t, err)
+	_, err = src.GenFieldID("ns", "cpu", "f", field.SumField)
+	assert.NoError(t, err)
+	_, err = src.GenTagKeyID("ns", "cpu", "host")
+	assert.NoError(t, err)
+	metrics, err := src.ExportMetrics(roaring.BitmapOf(metricID))
+	assert.NoError(t, err)
+	assert.Equal(t, []MetricSnapshot{{
+		Namespace: "ns", Name: "cpu", ID: metricID,
+		Fields:  []field.Meta{{ID: 1, Type: field.SumField, Name: "f"}},
+		TagKeys: []TagKeySnapshot{{Key: "host", ID: 1}},
+	}}, metrics)
+
+	target, err := NewMetadataDatabase(context.TODO(), "test", t.TempDir())
+	assert.NoError(t, err)
+	defer func() {
+		_ = target.Close()
+	}()
+	// install into empty metadata
+	installed, err := target.ImportMetrics(metrics)
+	assert.NoError(t, err)
+	assert.True(t, installed)
+	// same metadata
+	installed, err = target.ImportMetrics(metrics)
+	assert.NoError(t, err)
+	assert.False(t, installed)
+
+	cases := []MetricSnapshot{
+		{Namespace: "ns", Name: "not-exist", ID: metricID},
+		{Namespace: "ns", Name: "cpu", ID: 10},
+		{Namespace: "ns", Name: "cpu", ID: metricID, Fields: []field.Meta{{ID: 2, Type: field.SumField, Name: "f"}}},
+		{Namespace: "ns", Name: "cpu", ID: metricID, Fields: []field.Meta{{ID: 1, Type: field.MaxField, Name: "f"}}},
+		{Namespace: "ns", Name: "cpu", ID: metricID, TagKeys: []TagKeySnapshot{{Key: "ip", ID: 1}}},
+	}
+	for _, metric := range cases {
+		_, err = target.ImportMetrics([]MetricSnapshot{metric})
+		assert.True(t, errors.Is(err, constants.ErrIncompatibleMetadata))
+	}
+}
+
+func TestMetadata_ExportImport(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	db := NewMockMetadataDatabase(ctrl)
+	tagMeta := NewMockTagMetadata(ctrl)
+	m := &metadata{metadataDatabase: db, tagMetadata: tagMeta}
+	metrics := []MetricSnapshot{{Name: "cpu", ID: 1, TagKeys: []TagKeySnapshot{{Key: "host", ID: 1}}}}
+
+	// export
+	db.EXPECT().ExportMetrics(gomock.Any()).Return(nil, fmt.Errorf("err"))
+	_, err := m.Export(roaring.BitmapOf(1))
+	assert.Error(t, err)
+	db.EXPECT().ExportMetrics(gomock.Any()).Return(metrics, nil).AnyTimes()
+	tagMeta.EXPECT().GetTagValueIDsForTag(uint32(1)).Return(nil, fmt.Errorf("err"))
+	_, err = m.Export(roaring.BitmapOf(1))
+	assert.Error(t, err)
+	tagMeta.EXPECT().GetTagValueIDsForTag(uint32(1)).Return(nil, constants.ErrNotFound)
+	rs, err := m.Export(roaring.BitmapOf(1))
+	assert.NoError(t, err)
+	assert.Empty(t, rs[0].TagKeys[0].Values)
+	tagMeta.EXPECT().GetTagValueIDsForTag(uint32(1)).Return(roaring.BitmapOf(1), nil).AnyTimes()
+	tagMeta.EXPECT().CollectTagValues(uint32(1), gomock.Any(), gomock.Any()).Return(fmt.Errorf("err"))
+	_, err = m.Export(roaring.BitmapOf(1))
+	assert.Error(t, err)
+	tagMeta.EXPECT().CollectTagValues(uint32(1), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ uint32, _ *roaring.Bitmap, tagValues map[uint32]string) error {
+			tagValues[1] = "1.1.1.1"
+			return nil
+		})
+	rs, err = m.Export(roaring.BitmapOf(1))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]uint32{"1.1.1.1": 1}, rs[0].TagKeys[0].Values)
+
+	// import
+	metrics = rs
+	db.EXPECT().ImportMetrics(metrics).Return(false, fmt.Errorf("err"))
+	assert.Error(t, m.Import(metrics))
+	db.EXPECT().ImportMetrics(metrics).Return(true, nil)
+	tagMeta.EXPECT().ImportTagValues(uint32(1), map[string]uint32{"1.1.1.1": 1})
+	tagMeta.EXPECT().Flush().Return(nil)
+	assert.NoError(t, m.Import(metrics))
+	db.EXPECT().ImportMetrics(metrics).Return(false, nil).AnyTimes()
+	tagMeta.EXPECT().ResolveTagValueIDs(uint32(1), []uint32{1}).Return(nil, fmt.Errorf("err"))
+	assert.Error(t, m.Import(metrics))
+	tagMeta.EXPECT().ResolveTagValueIDs(uint32(1), []uint32{1}).Return(map[uint32]string{1: UnresolvedName}, nil)
+	assert.True(t, errors.Is(m.Import(metrics), constants.ErrIncompatibleMetadata))
+	tagMeta.EXPECT().ResolveTagValueIDs(uint32(1), []uint32{1}).Return(map[uint32]string{1: "1.1.1.1"}, nil)
+	assert.NoError(t, m.Import(metrics))
+}
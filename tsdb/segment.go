@@ -19,11 +19,14 @@ package tsdb
 
 import (
 	"fmt"
+	"path/filepath"
 	"strconv"
 	"sync"
 
+	"github.com/lindb/lindb/config"
 	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/kv"
+	"github.com/lindb/lindb/kv/table"
 	"github.com/lindb/lindb/pkg/logger"
 	"github.com/lindb/lindb/pkg/timeutil"
 	"github.com/lindb/lindb/tsdb/tblstore/metricsdata"
@@ -36,6 +39,20 @@ var (
 	newStore = kv.NewStore
 )
 
+var (
+	dataBlockCacheOnce sync.Once
+	dataBlockCache     table.BlockCache
+)
+
+// getDataBlockCache returns the block cache shared by all data families of the node,
+// returns nil if block cache is disabled.
+func getDataBlockCache() table.BlockCache {
+	dataBlockCacheOnce.Do(func() {
+		dataBlockCache = table.NewBlockCache(int64(config.GlobalStorageConfig().TSDB.BlockCacheSize))
+	})
+	return dataBlockCache
+}
+
 // Segment represents a time based segment, there are some segments in a interval segment.
 // A segment use k/v store for storing time series data.
 type Segment interface {
@@ -51,23 +68,30 @@ type Segment interface {
 
 // segment implements Segment interface
 type segment struct {
-	shard    Shard
-	baseTime int64
-	kvStore  kv.Store
-	interval timeutil.Interval
-	families sync.Map
+	shard       Shard
+	baseTime    int64
+	kvStore     kv.Store
+	path        string
+	interval    timeutil.Interval
+	coordinator kv.Coordinator
+	events      *eventLog
+	families    sync.Map
 
 	mutex sync.Mutex
 
 	logger *logger.Logger
 }
 
-// newSegment returns segment, segment is wrapper of kv store
+// newSegment returns segment, segment is wrapper of kv store,
+// flush/compaction of data families are coordinated by coordinator,
+// lifecycle events of data families are recorded into events if it isn't nil.
 func newSegment(
 	shard Shard,
 	segmentName string,
 	interval timeutil.Interval,
 	path string,
+	coordinator kv.Coordinator,
+	events *eventLog,
 ) (
 	Segment,
 	error,
@@ -80,7 +104,13 @@ func newSegment(
 		return nil, fmt.Errorf("parse segment[%s] base time error", path)
 	}
 
-	kvStore, err := newStore(segmentName, kv.DefaultStoreOption(path))
+	storeOption := kv.DefaultStoreOption(path)
+	storeOption.ReadStrategy = config.GlobalStorageConfig().TSDB.ReadStrategy
+	storeOption.BlockCache = getDataBlockCache()
+	storeOption.Coordinator = coordinator
+	storeOption.SyncPolicy = config.GlobalStorageConfig().TSDB.FlushSyncPolicy
+	storeOption.SyncInterval = config.GlobalStorageConfig().TSDB.FlushSyncInterval.Duration()
+	kvStore, err := newStore(segmentName, storeOption)
 	if err != nil {
 		return nil, fmt.Errorf("create kv store for segment error:%s", err)
 	}
@@ -88,11 +118,14 @@ func newSegment(
 	familyNames := kvStore.ListFamilyNames()
 
 	s := &segment{
-		shard:    shard,
-		baseTime: baseTime,
-		kvStore:  kvStore,
-		interval: interval,
-		logger:   logger.GetLogger("tsdb", "Segment"),
+		shard:       shard,
+		baseTime:    baseTime,
+		kvStore:     kvStore,
+		path:        path,
+		interval:    interval,
+		coordinator: coordinator,
+		events:      events,
+		logger:      logger.GetLogger("tsdb", "Segment"),
 	}
 
 	for _, familyName := range familyNames {
@@ -100,7 +133,8 @@ func newSegment(
 		if err != nil {
 			return nil, fmt.Errorf("load data family error:%s", err)
 		}
-		_ = s.initDataFamily(familyTime, kvStore.GetFamily(familyName))
+		dataFamily := s.initDataFamily(familyTime, kvStore.GetFamily(familyName))
+		events.record(FamilyOpenEvent, dataFamily.Indicator(), 0)
 	}
 
 	return s, nil
@@ -165,6 +199,7 @@ func (s *segment) GetOrCreateDataFamily(timestamp int64) (DataFamily, error) {
 					constants.ErrDataFamilyNotFound, err)
 			}
 			dataFamily := s.initDataFamily(familyTime, f)
+			s.events.record(FamilyCreateEvent, dataFamily.Indicator(), 0)
 			return dataFamily, nil
 		}
 	}
@@ -192,6 +227,7 @@ func (s *segment) Close() {
 	if err := s.kvStore.Close(); err != nil {
 		s.logger.Error("close kv store error", logger.Error(err))
 	}
+	s.events.record(SegmentCloseEvent, s.path, 0)
 }
 
 func (s *segment) initDataFamily(familyTime int, family kv.Family) DataFamily {
@@ -201,7 +237,7 @@ func (s *segment) initDataFamily(familyTime int, family kv.Family) DataFamily {
 	dataFamily := newDataFamily(s.shard, s.interval, timeutil.TimeRange{
 		Start: familyStartTime,
 		End:   calc.CalcFamilyEndTime(familyStartTime),
-	}, familyStartTime, family)
+	}, familyStartTime, family, filepath.Join(s.path, family.Name()), s.coordinator, s.events)
 	s.families.Store(familyTime, dataFamily)
 	return dataFamily
 }
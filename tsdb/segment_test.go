@@ -37,7 +37,7 @@ func createSegPath(t *testing.T) string {
 }
 
 func TestSegment_Close(t *testing.T) {
-	s, _ := newIntervalSegment(nil, timeutil.Interval(timeutil.OneSecond*10), createSegPath(t))
+	s, _ := newIntervalSegment(nil, timeutil.Interval(timeutil.OneSecond*10), createSegPath(t), nil, nil)
 	seg, _ := s.GetOrCreateSegment("20190702")
 	seg1 := seg.(*segment)
 
@@ -61,7 +61,7 @@ func TestSegment_GetDataFamily(t *testing.T) {
 	shard := NewMockShard(ctrl)
 	shard.EXPECT().Database().Return(database).AnyTimes()
 	shard.EXPECT().ShardID().Return(models.ShardID(1)).AnyTimes()
-	s, _ := newIntervalSegment(shard, timeutil.Interval(timeutil.OneSecond*10), createSegPath(t))
+	s, _ := newIntervalSegment(shard, timeutil.Interval(timeutil.OneSecond*10), createSegPath(t), nil, nil)
 	seg, _ := s.GetOrCreateSegment("20190904")
 	now, _ := timeutil.ParseTimestamp("20190904 19:10:48", "20060102 15:04:05")
 	familyBaseTime, _ := timeutil.ParseTimestamp("20190904 19:00:00", "20060102 15:04:05")
@@ -120,7 +120,7 @@ func TestSegment_New(t *testing.T) {
 	shard.EXPECT().ShardID().Return(models.ShardID(1)).AnyTimes()
 
 	segPath := createSegPath(t)
-	s, err := newSegment(shard, "20190904", timeutil.Interval(timeutil.OneSecond*10), segPath)
+	s, err := newSegment(shard, "20190904", timeutil.Interval(timeutil.OneSecond*10), segPath, nil, nil)
 	assert.NoError(t, err)
 	assert.NotNil(t, s)
 	now, _ := timeutil.ParseTimestamp("20190904 19:10:40", "20060102 15:04:05")
@@ -130,7 +130,7 @@ func TestSegment_New(t *testing.T) {
 	s.Close()
 
 	// reopen
-	s, err = newSegment(shard, "20190904", timeutil.Interval(timeutil.OneSecond*10), segPath)
+	s, err = newSegment(shard, "20190904", timeutil.Interval(timeutil.OneSecond*10), segPath, nil, nil)
 	assert.NoError(t, err)
 	assert.NotNil(t, s)
 	f, err = s.GetOrCreateDataFamily(now)
@@ -138,7 +138,7 @@ func TestSegment_New(t *testing.T) {
 	assert.NotNil(t, f)
 
 	// cannot reopen
-	s2, err := newSegment(shard, "20190904", timeutil.Interval(timeutil.OneSecond*10), segPath)
+	s2, err := newSegment(shard, "20190904", timeutil.Interval(timeutil.OneSecond*10), segPath, nil, nil)
 	assert.Error(t, err)
 	assert.Nil(t, s2)
 
@@ -158,7 +158,7 @@ func TestSegment_loadFamily_err(t *testing.T) {
 		return kvStore, nil
 	}
 	kvStore.EXPECT().ListFamilyNames().Return([]string{"abc"})
-	s, err := newSegment(nil, "20190904", timeutil.Interval(timeutil.OneSecond*10), createSegPath(t))
+	s, err := newSegment(nil, "20190904", timeutil.Interval(timeutil.OneSecond*10), createSegPath(t), nil, nil)
 	assert.Error(t, err)
 	assert.Nil(t, s)
 }
@@ -0,0 +1,204 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tsdb
+
+import (
+	"encoding/binary"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"sync"
+
+	"github.com/lindb/roaring"
+
+	"github.com/lindb/lindb/pkg/collections"
+	"github.com/lindb/lindb/series/field"
+	"github.com/lindb/lindb/tsdb/tblstore/metricsdata"
+)
+
+// for testing
+var (
+	writeSeriesBloomFunc = ioutil.WriteFile
+)
+
+const (
+	// seriesBloomFile is the file name of series bloom filter under family's directory.
+	seriesBloomFile = "SERIES_BLOOM"
+	// seriesBloomMinCapacity is the minimum capacity of each bloom filter.
+	seriesBloomMinCapacity = 1024
+	seriesBloomFPRate      = 0.01
+)
+
+// errBadSeriesBloom represents the series bloom file is corrupted.
+var errBadSeriesBloom = errors.New("bad series bloom file")
+
+// seriesBloom is a scalable bloom filter of metric id + series id which flushed into family's files,
+// used for skipping the families which definitely lack all requested series when querying.
+// A new filter with double capacity is appended when the last one is full.
+type seriesBloom struct {
+	path    string
+	filters []*collections.BloomFilter
+
+	mutex sync.RWMutex
+}
+
+// newSeriesBloom creates an empty series bloom filter which is saved into path.
+func newSeriesBloom(path string) *seriesBloom {
+	return &seriesBloom{path: path}
+}
+
+// loadSeriesBloom loads the series bloom filter from path.
+func loadSeriesBloom(path string) (*seriesBloom, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	b := newSeriesBloom(path)
+	for len(data) > 0 {
+		if len(data) < 4 {
+			return nil, errBadSeriesBloom
+		}
+		size := int(binary.LittleEndian.Uint32(data))
+		data = data[4:]
+		if size > len(data) {
+			return nil, errBadSeriesBloom
+		}
+		filter, err := collections.UnmarshalBloomFilter(data[:size])
+		if err != nil {
+			return nil, err
+		}
+		b.filters = append(b.filters, filter)
+		data = data[size:]
+	}
+	return b, nil
+}
+
+// seriesKey returns the key of series under metric.
+func seriesKey(metricID, seriesID uint32) uint64 {
+	return uint64(metricID)<<32 | uint64(seriesID)
+}
+
+// add adds the keys of series.
+func (b *seriesBloom) add(keys []uint64) {
+	if len(keys) == 0 {
+		return
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for _, key := range keys {
+		var last *collections.BloomFilter
+		if len(b.filters) > 0 {
+			last = b.filters[len(b.filters)-1]
+		}
+		if last == nil || last.Count() >= last.Capacity() {
+			capacity := uint32(seriesBloomMinCapacity)
+			if last != nil {
+				capacity = last.Capacity() * 2
+			}
+			if remaining := uint32(len(keys)); remaining > capacity {
+				capacity = remaining
+			}
+			last = collections.NewBloomFilter(capacity, seriesBloomFPRate)
+			b.filters = append(b.filters, last)
+		}
+		last.Add(key)
+	}
+}
+
+// mayContain returns false if all series of metric are definitely absent.
+func (b *seriesBloom) mayContain(metricID uint32, seriesIDs *roaring.Bitmap) bool {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	it := seriesIDs.Iterator()
+	for it.HasNext() {
+		key := seriesKey(metricID, it.Next())
+		for _, filter := range b.filters {
+			if filter.Contains(key) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// save writes the filters into file atomically.
+func (b *seriesBloom) save() error {
+	b.mutex.RLock()
+	var data []byte
+	for _, filter := range b.filters {
+		buf := filter.MarshalBinary()
+		var size [4]byte
+		binary.LittleEndian.PutUint32(size[:], uint32(len(buf)))
+		data = append(data, size[:]...)
+		data = append(data, buf...)
+	}
+	b.mutex.RUnlock()
+
+	tmp := b.path + ".tmp"
+	if err := writeSeriesBloomFunc(tmp, data, 0644); err != nil {
+		return err
+	}
+	return renameFunc(tmp, b.path)
+}
+
+// seriesBloomFlusher wraps the metric data flusher, collects series of flushed metrics,
+// then saves the series bloom filter before committing the flushed file,
+// so that the filter never lacks series which are in family's files.
+type seriesBloomFlusher struct {
+	metricsdata.Flusher
+
+	bloom    *seriesBloom
+	metricID uint32
+	keys     []uint64
+}
+
+// newSeriesBloomFlusher creates the flusher which updates series bloom filter.
+func newSeriesBloomFlusher(flusher metricsdata.Flusher, bloom *seriesBloom) metricsdata.Flusher {
+	return &seriesBloomFlusher{
+		Flusher: flusher,
+		bloom:   bloom,
+	}
+}
+
+// PrepareMetric prepares to write a new metric block.
+func (f *seriesBloomFlusher) PrepareMetric(metricID uint32, fieldMetas field.Metas) {
+	f.metricID = metricID
+	f.Flusher.PrepareMetric(metricID, fieldMetas)
+}
+
+// FlushSeries writes a full series, records the series.
+func (f *seriesBloomFlusher) FlushSeries(seriesID uint32) error {
+	f.keys = append(f.keys, seriesKey(f.metricID, seriesID))
+	return f.Flusher.FlushSeries(seriesID)
+}
+
+// Close saves the series bloom filter, then commits the flushed file.
+func (f *seriesBloomFlusher) Close() error {
+	f.bloom.add(f.keys)
+	if err := f.bloom.save(); err != nil {
+		return err
+	}
+	return f.Flusher.Close()
+}
+
+// seriesBloomPath returns the path of series bloom filter under family's directory.
+func seriesBloomPath(familyPath string) string {
+	return filepath.Join(familyPath, seriesBloomFile)
+}
@@ -0,0 +1,194 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tsdb
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/lindb/roaring"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/kv"
+	"github.com/lindb/lindb/kv/version"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/tsdb/tblstore/metricsdata"
+)
+
+func TestSeriesBloom_SkipFamily(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	familyPath := t.TempDir()
+	newFamily := func(files []*version.FileMeta) (DataFamily, *version.MockSnapshot) {
+		database := NewMockDatabase(ctrl)
+		database.EXPECT().Name().Return("test").AnyTimes()
+		family := kv.NewMockFamily(ctrl)
+		snapshot := version.NewMockSnapshot(ctrl)
+		snapshot.EXPECT().Close().AnyTimes()
+		family.EXPECT().GetSnapshot().Return(snapshot).AnyTimes()
+		v := version.NewMockVersion(ctrl)
+		v.EXPECT().GetSequences().Return(nil)
+		v.EXPECT().GetAllFiles().Return(files)
+		snapshot.EXPECT().GetCurrent().Return(v).AnyTimes()
+		shard := NewMockShard(ctrl)
+		shard.EXPECT().Database().Return(database)
+		shard.EXPECT().ShardID().Return(models.ShardID(1))
+		f := newDataFamily(shard, timeutil.Interval(timeutil.OneSecond*10),
+			timeutil.TimeRange{Start: 10, End: 50}, 10, family, familyPath, nil, nil)
+		return f, snapshot
+	}
+	f, snapshot := newFamily(nil)
+	defer GetFamilyManager().RemoveFamily(f)
+	bloom := f.(*dataFamily).seriesBloom
+	assert.NotNil(t, bloom)
+
+	// flush series 1~100 of metric 1
+	dataFlusher := metricsdata.NewMockFlusher(ctrl)
+	dataFlusher.EXPECT().PrepareMetric(gomock.Any(), gomock.Any())
+	dataFlusher.EXPECT().FlushSeries(gomock.Any()).Return(nil).Times(100)
+	dataFlusher.EXPECT().CommitMetric(gomock.Any()).Return(nil)
+	dataFlusher.EXPECT().Close().Return(nil)
+	flusher := newSeriesBloomFlusher(dataFlusher, bloom)
+	flusher.PrepareMetric(1, nil)
+	for seriesID := uint32(1); seriesID <= 100; seriesID++ {
+		assert.NoError(t, flusher.FlushSeries(seriesID))
+	}
+	assert.NoError(t, flusher.CommitMetric(timeutil.SlotRange{}))
+	assert.NoError(t, flusher.Close())
+	assert.FileExists(t, seriesBloomPath(familyPath))
+
+	skipped := f.(*dataFamily).statistics.skippedFamilies.Get()
+	// family lacks all series, skip without reading files
+	rs, err := f.Filter(1, roaring.BitmapOf(1000, 2000), timeutil.TimeRange{}, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, rs)
+	// metric not in family
+	rs, err = f.Filter(2, roaring.BitmapOf(1, 2), timeutil.TimeRange{}, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, rs)
+	assert.Equal(t, skipped+2, f.(*dataFamily).statistics.skippedFamilies.Get())
+	// family contains series, must read files
+	snapshot.EXPECT().FindReaders(uint32(1)).Return(nil, nil)
+	_, err = f.Filter(1, roaring.BitmapOf(1000, 50), timeutil.TimeRange{}, nil)
+	assert.NoError(t, err)
+
+	// reopen family, load the filter from file
+	f2, snapshot2 := newFamily([]*version.FileMeta{version.NewFileMeta(1, 1, 1, 1)})
+	defer GetFamilyManager().RemoveFamily(f2)
+	rs, err = f2.Filter(1, roaring.BitmapOf(1000, 2000), timeutil.TimeRange{}, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, rs)
+	snapshot2.EXPECT().FindReaders(uint32(1)).Return(nil, nil)
+	_, err = f2.Filter(1, roaring.BitmapOf(100), timeutil.TimeRange{}, nil)
+	assert.NoError(t, err)
+
+	// filter is corrupted, cannot skip family
+	assert.NoError(t, ioutil.WriteFile(seriesBloomPath(familyPath), []byte{1, 2, 3}, 0644))
+	f3, _ := newFamily([]*version.FileMeta{version.NewFileMeta(1, 1, 1, 1)})
+	defer GetFamilyManager().RemoveFamily(f3)
+	assert.Nil(t, f3.(*dataFamily).seriesBloom)
+	// filter is missing for existing files, cannot skip family
+	assert.NoError(t, os.Remove(seriesBloomPath(familyPath)))
+	f4, _ := newFamily([]*version.FileMeta{version.NewFileMeta(1, 1, 1, 1)})
+	defer GetFamilyManager().RemoveFamily(f4)
+	assert.Nil(t, f4.(*dataFamily).seriesBloom)
+}
+
+func TestSeriesBloom_NeverSkipFamilyWithSeries(t *testing.T) {
+	dir := t.TempDir()
+	r := rand.New(rand.NewSource(1))
+	type familySeries struct {
+		bloom  *seriesBloom
+		series map[uint64]struct{}
+	}
+	var families []familySeries
+	for i := 0; i < 20; i++ {
+		bloom := newSeriesBloom(filepath.Join(dir, fmt.Sprintf("%d", i)))
+		series := make(map[uint64]struct{})
+		// flush several times, filter scales when full
+		for flush := 0; flush < 3; flush++ {
+			var keys []uint64
+			for j := 0; j < r.Intn(2000); j++ {
+				key := seriesKey(uint32(r.Intn(3)), uint32(r.Intn(100000)))
+				keys = append(keys, key)
+				series[key] = struct{}{}
+			}
+			bloom.add(keys)
+			assert.NoError(t, bloom.save())
+		}
+		loaded, err := loadSeriesBloom(bloom.path)
+		assert.NoError(t, err)
+		families = append(families, familySeries{bloom: loaded, series: series})
+	}
+	skipped := 0
+	for q := 0; q < 200; q++ {
+		metricID := uint32(r.Intn(3))
+		seriesIDs := roaring.New()
+		for j := 0; j < 1+r.Intn(5); j++ {
+			seriesIDs.Add(uint32(r.Intn(100000)))
+		}
+		for _, family := range families {
+			contains := false
+			for _, seriesID := range seriesIDs.ToArray() {
+				if _, ok := family.series[seriesKey(metricID, seriesID)]; ok {
+					contains = true
+				}
+			}
+			mayContain := family.bloom.mayContain(metricID, seriesIDs)
+			if contains {
+				assert.True(t, mayContain)
+			}
+			if !mayContain {
+				skipped++
+			}
+		}
+	}
+	assert.True(t, skipped > 0)
+}
+
+func TestSeriesBloom_Err(t *testing.T) {
+	defer func() {
+		writeSeriesBloomFunc = ioutil.WriteFile
+	}()
+	dir := t.TempDir()
+	path := filepath.Join(dir, seriesBloomFile)
+	// file not exist
+	_, err := loadSeriesBloom(path)
+	assert.Error(t, err)
+	// corrupted
+	for _, data := range [][]byte{{1}, {100, 0, 0, 0, 1}, {1, 0, 0, 0, 1}} {
+		assert.NoError(t, ioutil.WriteFile(path, data, 0644))
+		_, err = loadSeriesBloom(path)
+		assert.Error(t, err)
+	}
+	// save err
+	writeSeriesBloomFunc = func(filename string, data []byte, perm os.FileMode) error {
+		return fmt.Errorf("err")
+	}
+	bloom := newSeriesBloom(path)
+	assert.Error(t, bloom.save())
+	flusher := newSeriesBloomFlusher(nil, bloom)
+	assert.Error(t, flusher.Close())
+}
@@ -20,6 +20,7 @@ package tsdb
 import (
 	"context"
 	"fmt"
+	"math"
 	"io"
 	"path/filepath"
 	"strconv"
@@ -28,6 +29,7 @@ import (
 
 	"go.uber.org/atomic"
 
+	"github.com/lindb/lindb/config"
 	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/internal/linmetric"
 	"github.com/lindb/lindb/kv"
@@ -63,6 +65,9 @@ var (
 	memdbNumberVec         = shardScope.NewGaugeVec("memdb_number", "db", "shard")
 	memFlushTimerVec       = shardScope.Scope("memdb_flush_duration").NewHistogramVec("db", "shard")
 	indexFlushTimerVec     = shardScope.Scope("indexdb_flush_duration").NewHistogramVec("db", "shard")
+	flushWaitTimerVec      = shardScope.Scope("flush_wait_duration").NewHistogramVec("db", "shard")
+	deferredCompactionsVec = shardScope.NewCounterVec("deferred_compactions", "db", "shard")
+	skippedFamiliesVec     = shardScope.NewCounterVec("query_skipped_families", "db", "shard")
 )
 
 const (
@@ -92,14 +97,36 @@ type Shard interface {
 	GetOrCrateDataFamily(familyTime int64) (DataFamily, error)
 	// GetDataFamilies returns data family list by interval type and time range, return nil if not match
 	GetDataFamilies(intervalType timeutil.IntervalType, timeRange timeutil.TimeRange) []DataFamily
+	// NumOfSegments returns the number of segments of all intervals.
+	NumOfSegments() int
+	// Events returns the latest lifecycle events of segments/families in chronological order.
+	Events() []ShardEvent
 	// IndexDatabase returns the index-database
 	IndexDatabase() indexdb.IndexDatabase
+	// HasExemplars returns if any exemplar of metric is kept in memory.
+	HasExemplars(metricID uint32) bool
+	// GetExemplars returns the latest exemplars of series field within time range in the order written.
+	GetExemplars(metricID, seriesID uint32, fieldID field.ID, timeRange timeutil.TimeRange) []metric.Exemplar
 	BufferManager() memdb.BufferManager
 
-	// WriteRows writes metric rows with same family in batch
-	WriteRows(rows []metric.StorageRow) error
+	// WriteRows writes metric rows with same family in batch,
+	// records the time of metadata/index stages into span if not nil.
+	WriteRows(rows []metric.StorageRow, span *WriteSpan) error
 
 	Flush() error
+	// PauseWrite pauses writes of shard, new writes are rejected with constants.ErrShardWritePaused,
+	// reads are not affected.
+	PauseWrite()
+	// ResumeWrite resumes writes of shard paused by PauseWrite.
+	ResumeWrite()
+	// IsWritePaused returns if writes of shard are paused.
+	IsWritePaused() bool
+	// Snapshot flushes index and memory data, then calls fn with shard's storage directory,
+	// flush and compaction of shard are blocked until fn returns, so that files under the directory are consistent.
+	Snapshot(fn func(path string) error) error
+	// Compact compacts the level0 files of data families immediately(all families if familyTime is 0),
+	// waits until compaction completed or ctx done, returns constants.ErrDataFamilyNotFound if family not exist.
+	Compact(ctx context.Context, familyTime int64) error
 	// initIndexDatabase initializes index database
 	initIndexDatabase() error
 	// Closer releases shard's resource, such as flush data, spawned goroutines etc.
@@ -135,7 +162,11 @@ type shard struct {
 	// includes one smallest interval segment for writing data, and rollup interval segments
 	segments       map[timeutil.IntervalType]IntervalSegment
 	segment        IntervalSegment // smallest interval for writing data
+	coordinator    kv.Coordinator  // coordinates flush and compaction of data families
+	events         *eventLog       // latest lifecycle events of segments/families
+	exemplars      *exemplarStore  // latest exemplars of series fields
 	isFlushing     atomic.Bool     // restrict flusher concurrency
+	writePaused    atomic.Bool     // reject new writes if paused
 	flushCondition sync.WaitGroup  // flush condition
 
 	indexStore     kv.Store  // kv stores
@@ -181,6 +212,8 @@ func newShard(
 		interval:   interval,
 		segments:   make(map[timeutil.IntervalType]IntervalSegment),
 		isFlushing: *atomic.NewBool(false),
+		events:     newEventLog(config.GlobalStorageConfig().TSDB.ShardEventLogSize),
+		exemplars:  newExemplarStore(config.GlobalStorageConfig().TSDB.MaxExemplars),
 		logger:     logger.GetLogger("tsdb", "Shard"),
 	}
 
@@ -193,11 +226,18 @@ func newShard(
 	createdShard.statistics.writeMetricFailures = writeMetricFailuresVec.WithTagValues(db.Name(), shardIDStr)
 	createdShard.statistics.indexFlushTimer = indexFlushTimerVec.WithTagValues(db.Name(), shardIDStr)
 
+	// at most one flush/compaction of data families runs at a time in shard
+	createdShard.coordinator = kv.NewCoordinator(
+		flushWaitTimerVec.WithTagValues(db.Name(), shardIDStr),
+		deferredCompactionsVec.WithTagValues(db.Name(), shardIDStr),
+	)
 	// new segment for writing
 	createdShard.segment, err = newIntervalSegmentFunc(
 		createdShard,
 		interval,
 		filepath.Join(shardPath, segmentDir, interval.Type().String()),
+		createdShard.coordinator,
+		createdShard.events,
 	)
 	if err != nil {
 		return nil, err
@@ -275,19 +315,48 @@ func (s *shard) GetDataFamilies(intervalType timeutil.IntervalType, timeRange ti
 	return nil
 }
 
-func (s *shard) lookupRowMeta(row *metric.StorageRow) (err error) {
+// NumOfSegments returns the number of segments of all intervals.
+func (s *shard) NumOfSegments() int {
+	num := 0
+	for _, segment := range s.segments {
+		num += segment.NumOfSegments()
+	}
+	return num
+}
+
+// Events returns the latest lifecycle events of segments/families in chronological order.
+func (s *shard) Events() []ShardEvent {
+	return s.events.list()
+}
+
+// HasExemplars returns if any exemplar of metric is kept in memory.
+func (s *shard) HasExemplars(metricID uint32) bool {
+	return s.exemplars.hasExemplars(metricID)
+}
+
+// GetExemplars returns the latest exemplars of series field within time range in the order written.
+func (s *shard) GetExemplars(metricID, seriesID uint32, fieldID field.ID, timeRange timeutil.TimeRange) []metric.Exemplar {
+	return s.exemplars.get(metricID, seriesID, fieldID, timeRange)
+}
+
+// lookupRowMeta generates metric id/series id/field ids of row, records the time of metadata/index stages into span.
+func (s *shard) lookupRowMeta(row *metric.StorageRow, span *WriteSpan) (err error) {
 	namespace := constants.DefaultNamespace
-	metricName := string(row.Name())
+	// interned, recurring metric names/namespaces don't allocate
+	metricName := row.NameString()
 
 	if len(row.NameSpace()) > 0 {
-		namespace = string(row.NameSpace())
+		namespace = row.NameSpaceString()
 	}
 
+	start := span.Now()
 	row.MetricID, err = s.metadata.MetadataDatabase().GenMetricID(namespace, metricName)
+	span.Record(WriteStageMetadata, start)
 	if err != nil {
 		s.statistics.writeMetricFailures.Incr()
 		return err
 	}
+	start = span.Now()
 	var isCreated bool
 	if row.TagsLen() == 0 {
 		// if metric without tags, uses default series id(0)
@@ -307,17 +376,20 @@ func (s *shard) lookupRowMeta(row *metric.StorageRow) (err error) {
 			row.NewKeyValueIterator(),
 			row.SeriesID)
 	}
+	span.Record(WriteStageIndex, start)
 	// set field id
+	start = span.Now()
 	simpleFieldItr := row.NewSimpleFieldIterator()
 	var fieldID field.ID
 	for simpleFieldItr.HasNext() {
 		if fieldID, err = s.metadata.MetadataDatabase().GenFieldID(
 			namespace, metricName,
-			simpleFieldItr.NextName(),
+			simpleFieldItr.NextInternedName(),
 			simpleFieldItr.NextType()); err != nil {
 			return err
 		}
 		row.FieldIDs = append(row.FieldIDs, fieldID)
+		s.addExemplars(row, fieldID, simpleFieldItr)
 	}
 
 	compoundFieldItr, ok := row.NewCompoundFieldIterator()
@@ -363,13 +435,51 @@ func (s *shard) lookupRowMeta(row *metric.StorageRow) (err error) {
 	}
 
 Done:
+	span.Record(WriteStageMetadata, start)
 	row.Writable = true
 	return nil
 }
 
-func (s *shard) WriteRows(rows []metric.StorageRow) error {
+// addExemplars keeps the exemplars attached to current simple field of row.
+func (s *shard) addExemplars(row *metric.StorageRow, fieldID field.ID, itr *metric.SimpleFieldIterator) {
+	numOfExemplars := itr.NextExemplarsLen()
+	if numOfExemplars == 0 {
+		return
+	}
+	var exemplar metric.Exemplar
+	for idx := 0; idx < numOfExemplars; idx++ {
+		if itr.NextExemplar(idx, &exemplar) {
+			s.exemplars.add(row.MetricID, row.SeriesID, fieldID, &exemplar)
+		}
+	}
+}
+
+// PauseWrite pauses writes of shard, new writes are rejected with constants.ErrShardWritePaused,
+// reads are not affected.
+func (s *shard) PauseWrite() {
+	if s.writePaused.CAS(false, true) {
+		s.logger.Info("pause shard write",
+			logger.String("database", s.db.Name()), logger.Any("shardID", s.id))
+	}
+}
+
+// ResumeWrite resumes writes of shard paused by PauseWrite.
+func (s *shard) ResumeWrite() {
+	if s.writePaused.CAS(true, false) {
+		s.logger.Info("resume shard write",
+			logger.String("database", s.db.Name()), logger.Any("shardID", s.id))
+	}
+}
+
+// IsWritePaused returns if writes of shard are paused.
+func (s *shard) IsWritePaused() bool {
+	return s.writePaused.Load()
+}
+
+// WriteRows looks up metric metadata and series id of rows, records the time of stages into span if not nil.
+func (s *shard) WriteRows(rows []metric.StorageRow, span *WriteSpan) error {
 	for idx := range rows {
-		if err := s.lookupRowMeta(&rows[idx]); err != nil {
+		if err := s.lookupRowMeta(&rows[idx], span); err != nil {
 			s.logger.Error("failed to lookup meta of row", logger.Error(err))
 			continue
 		}
@@ -377,23 +487,23 @@ func (s *shard) WriteRows(rows []metric.StorageRow) error {
 	return nil
 }
 
-func (s *shard) Close() error {
+// Close flushes index and memory data of all data families, then releases shard's resource,
+// data families are flushed even if closing index fails, returns the first error.
+func (s *shard) Close() (err error) {
 	// wait previous flush job completed
 	s.flushCondition.Wait()
 
 	if s.indexDB != nil {
-		if err := s.indexDB.Close(); err != nil {
-			return err
-		}
+		err = s.indexDB.Close()
 	}
 	if s.indexStore != nil {
-		if err := s.indexStore.Close(); err != nil {
-			return err
+		if closeErr := s.indexStore.Close(); closeErr != nil && err == nil {
+			err = closeErr
 		}
 	}
 	// close segment/flush family data
 	s.segment.Close()
-	return nil
+	return err
 }
 
 // Flush flushes index and memory data to disk
@@ -412,6 +522,11 @@ func (s *shard) Flush() (err error) {
 		s.isFlushing.Store(false)
 	}()
 
+	return s.flushIndex()
+}
+
+// flushIndex flushes index database to disk
+func (s *shard) flushIndex() (err error) {
 	startTime := time.Now()
 	//FIXME stone1100
 	// index flush
@@ -434,6 +549,59 @@ func (s *shard) Flush() (err error) {
 	return nil
 }
 
+// Snapshot flushes index and memory data, then calls fn with shard's storage directory,
+// flush and compaction of shard are blocked until fn returns.
+// Data written after flushing is not included in the files.
+func (s *shard) Snapshot(fn func(path string) error) error {
+	// wait running index flush job completed, then block index flushing
+	for !s.isFlushing.CAS(false, true) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	s.flushCondition.Add(1)
+	defer func() {
+		s.flushCondition.Done()
+		s.isFlushing.Store(false)
+	}()
+
+	if err := s.flushIndex(); err != nil {
+		return err
+	}
+	allTime := timeutil.TimeRange{Start: 0, End: math.MaxInt64}
+	for _, segment := range s.segments {
+		for _, family := range segment.getDataFamilies(allTime) {
+			if err := family.Flush(); err != nil {
+				return err
+			}
+		}
+	}
+	// block flush and compaction of data families
+	release := s.coordinator.AcquireFlush()
+	defer release()
+	return fn(s.path)
+}
+
+// Compact compacts the level0 files of data families immediately(all families if familyTime is 0),
+// waits until compaction completed or ctx done, returns constants.ErrDataFamilyNotFound if family not exist.
+func (s *shard) Compact(ctx context.Context, familyTime int64) error {
+	allTime := timeutil.TimeRange{Start: 0, End: math.MaxInt64}
+	found := false
+	for _, segment := range s.segments {
+		for _, family := range segment.getDataFamilies(allTime) {
+			if familyTime > 0 && family.FamilyTime() != familyTime {
+				continue
+			}
+			found = true
+			if err := family.Family().Compact(ctx); err != nil {
+				return fmt.Errorf("compact data family[%s] error: %w", family.Indicator(), err)
+			}
+		}
+	}
+	if familyTime > 0 && !found {
+		return fmt.Errorf("%w, familyTime: %d", constants.ErrDataFamilyNotFound, familyTime)
+	}
+	return nil
+}
+
 // initIndexDatabase initializes the index database
 func (s *shard) initIndexDatabase() error {
 
@@ -0,0 +1,101 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tsdb
+
+import (
+	"sync"
+
+	"github.com/lindb/lindb/pkg/timeutil"
+)
+
+// lifecycle event types of segment/family under shard
+const (
+	SegmentCreateEvent = "segment_create"
+	SegmentOpenEvent   = "segment_open"
+	SegmentCloseEvent  = "segment_close"
+	FamilyCreateEvent  = "family_create"
+	FamilyOpenEvent    = "family_open"
+	FamilyFlushEvent   = "family_flush"
+	FamilyCloseEvent   = "family_close"
+)
+
+// for testing
+var (
+	eventNowFunc = timeutil.Now
+)
+
+// ShardEvent represents a lifecycle event of segment/family under shard.
+type ShardEvent struct {
+	Timestamp int64  `json:"timestamp"` // time of event(ms)
+	Type      string `json:"type"`
+	Target    string `json:"target"` // segment path or family indicator
+	Size      int64  `json:"size"`   // size of memory database flushed by flush/close(bytes)
+}
+
+// eventLog keeps the latest lifecycle events of shard in ring buffer for post-mortem analysis,
+// the oldest events are overwritten when the buffer is full.
+type eventLog struct {
+	events []ShardEvent
+	next   int  // position of next event
+	full   bool // if buffer wrapped around
+	mutex  sync.Mutex
+}
+
+// newEventLog creates the event log which keeps at most size events.
+func newEventLog(size int) *eventLog {
+	return &eventLog{
+		events: make([]ShardEvent, size),
+	}
+}
+
+// record appends the event into ring buffer, ignores if event log is nil.
+func (l *eventLog) record(eventType, target string, size int64) {
+	if l == nil || len(l.events) == 0 {
+		return
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.events[l.next] = ShardEvent{
+		Timestamp: eventNowFunc(),
+		Type:      eventType,
+		Target:    target,
+		Size:      size,
+	}
+	l.next++
+	if l.next == len(l.events) {
+		l.next = 0
+		l.full = true
+	}
+}
+
+// list returns the events in chronological order.
+func (l *eventLog) list() []ShardEvent {
+	if l == nil {
+		return nil
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if !l.full {
+		return append([]ShardEvent(nil), l.events[:l.next]...)
+	}
+	result := make([]ShardEvent, 0, len(l.events))
+	result = append(result, l.events[l.next:]...)
+	return append(result, l.events[:l.next]...)
+}
@@ -0,0 +1,102 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tsdb
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/timeutil"
+)
+
+func TestEventLog_record(t *testing.T) {
+	defer func() {
+		eventNowFunc = timeutil.Now
+	}()
+	now := int64(0)
+	eventNowFunc = func() int64 {
+		now++
+		return now
+	}
+	var nilLog *eventLog
+	nilLog.record(FamilyFlushEvent, "f", 10)
+	assert.Nil(t, nilLog.list())
+
+	l := newEventLog(3)
+	assert.Empty(t, l.list())
+	l.record(SegmentCreateEvent, "s", 0)
+	l.record(FamilyCreateEvent, "f", 0)
+	assert.Equal(t, []ShardEvent{
+		{Timestamp: 1, Type: SegmentCreateEvent, Target: "s"},
+		{Timestamp: 2, Type: FamilyCreateEvent, Target: "f"},
+	}, l.list())
+	// oldest events are overwritten
+	l.record(FamilyFlushEvent, "f", 10)
+	l.record(FamilyCloseEvent, "f", 5)
+	l.record(SegmentCloseEvent, "s", 0)
+	assert.Equal(t, []ShardEvent{
+		{Timestamp: 3, Type: FamilyFlushEvent, Target: "f", Size: 10},
+		{Timestamp: 4, Type: FamilyCloseEvent, Target: "f", Size: 5},
+		{Timestamp: 5, Type: SegmentCloseEvent, Target: "s"},
+	}, l.list())
+}
+
+func TestEventLog_SegmentLifecycle(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	database := NewMockDatabase(ctrl)
+	database.EXPECT().Name().Return("test").AnyTimes()
+	shard := NewMockShard(ctrl)
+	shard.EXPECT().Database().Return(database).AnyTimes()
+	shard.EXPECT().ShardID().Return(models.ShardID(1)).AnyTimes()
+
+	events := newEventLog(10)
+	segPath := createSegPath(t)
+	s, err := newIntervalSegment(shard, timeutil.Interval(timeutil.OneSecond*10), segPath, nil, events)
+	assert.NoError(t, err)
+	seg, err := s.GetOrCreateSegment("20190904")
+	assert.NoError(t, err)
+	now, _ := timeutil.ParseTimestamp("20190904 19:10:48", "20060102 15:04:05")
+	family, err := seg.GetOrCreateDataFamily(now)
+	assert.NoError(t, err)
+	s.Close()
+	// reopen
+	s, err = newIntervalSegment(shard, timeutil.Interval(timeutil.OneSecond*10), segPath, nil, events)
+	assert.NoError(t, err)
+	s.Close()
+
+	var types, targets []string
+	for _, e := range events.list() {
+		types = append(types, e.Type)
+		targets = append(targets, e.Target)
+	}
+	segmentPath := filepath.Join(segPath, "20190904")
+	assert.Equal(t, []string{
+		SegmentCreateEvent, FamilyCreateEvent, FamilyCloseEvent, SegmentCloseEvent,
+		FamilyOpenEvent, SegmentOpenEvent, FamilyCloseEvent, SegmentCloseEvent,
+	}, types)
+	assert.Equal(t, []string{
+		segmentPath, family.Indicator(), family.Indicator(), segmentPath,
+		family.Indicator(), segmentPath, family.Indicator(), segmentPath,
+	}, targets)
+}
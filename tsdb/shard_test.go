@@ -20,7 +20,9 @@ package tsdb
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"math"
 	"path/filepath"
 	"testing"
 	"time"
@@ -32,6 +34,8 @@ import (
 	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/kv"
 	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/bit"
+	"github.com/lindb/lindb/pkg/encoding"
 	"github.com/lindb/lindb/pkg/fileutil"
 	"github.com/lindb/lindb/pkg/option"
 	"github.com/lindb/lindb/pkg/timeutil"
@@ -42,6 +46,7 @@ import (
 	"github.com/lindb/lindb/tsdb/indexdb"
 	"github.com/lindb/lindb/tsdb/memdb"
 	"github.com/lindb/lindb/tsdb/metadb"
+	"github.com/lindb/lindb/tsdb/tblstore/metricsdata"
 )
 
 func createShardTestDir(t *testing.T) string {
@@ -83,7 +88,7 @@ func TestShard_New(t *testing.T) {
 	assert.Nil(t, thisShard)
 	// case 4: new interval segment err
 	mkDirIfNotExist = fileutil.MkDirIfNotExist
-	newIntervalSegmentFunc = func(_ Shard, interval timeutil.Interval, path string) (segment IntervalSegment, err error) {
+	newIntervalSegmentFunc = func(_ Shard, interval timeutil.Interval, path string, _ kv.Coordinator, _ *eventLog) (segment IntervalSegment, err error) {
 		return nil, fmt.Errorf("err")
 	}
 	thisShard, err = newShard(db, 1, _testShard1Path, option.DatabaseOption{Interval: "10s"})
@@ -151,6 +156,7 @@ func TestShard_GetDataFamilies(t *testing.T) {
 	assert.Nil(t, s.GetDataFamilies(timeutil.Month, timeutil.TimeRange{}))
 	assert.Nil(t, s.GetDataFamilies(timeutil.Day, timeutil.TimeRange{}))
 	assert.Equal(t, 0, len(s.GetDataFamilies(timeutil.Day, timeutil.TimeRange{})))
+	assert.Zero(t, s.NumOfSegments())
 }
 
 func mockBatchRows(m *protoMetricsV1.Metric) *metric.StorageRow {
@@ -200,7 +206,7 @@ func TestShard_Write(t *testing.T) {
 			Value: 1.0,
 			Type:  protoMetricsV1.SimpleFieldType_DELTA_SUM,
 		}},
-	})))
+	}), nil))
 	// case 5: gen series id err
 	metadataDB.EXPECT().GenMetricID(constants.DefaultNamespace, "test").Return(uint32(10), nil).AnyTimes()
 	indexDB.EXPECT().GetOrCreateSeriesID(uint32(10), gomock.Any()).Return(uint32(0), false, fmt.Errorf("err"))
@@ -214,11 +220,12 @@ func TestShard_Write(t *testing.T) {
 			Value: 1.0,
 			Type:  protoMetricsV1.SimpleFieldType_DELTA_SUM,
 		}},
-	})))
+	}), nil))
 	// case 6: get old series id
 	metadataDB.EXPECT().GenMetricID(constants.DefaultNamespace, "test").Return(uint32(10), nil).AnyTimes()
 	metadataDB.EXPECT().GenFieldID(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(field.ID(1), nil)
 	indexDB.EXPECT().GetOrCreateSeriesID(uint32(10), gomock.Any()).Return(uint32(10), false, nil)
+	span := &WriteSpan{database: "test-db"}
 	assert.NoError(t, shardIns.lookupRowMeta(mockBatchRows(&protoMetricsV1.Metric{
 		Name:      "test",
 		Timestamp: timestamp,
@@ -229,7 +236,35 @@ func TestShard_Write(t *testing.T) {
 			Value: 1.0,
 			Type:  protoMetricsV1.SimpleFieldType_DELTA_SUM,
 		}},
-	})))
+	}), span))
+	// metadata/index stages are recorded
+	_, ok := span.Duration(WriteStageMetadata)
+	assert.True(t, ok)
+	_, ok = span.Duration(WriteStageIndex)
+	assert.True(t, ok)
+	_, ok = span.Duration(WriteStageMemTable)
+	assert.False(t, ok)
+	assert.False(t, shardIns.HasExemplars(10))
+
+	// case 7: keep exemplars of field
+	metadataDB.EXPECT().GenFieldID(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(field.ID(2), nil)
+	indexDB.EXPECT().GetOrCreateSeriesID(uint32(10), gomock.Any()).Return(uint32(10), false, nil)
+	assert.NoError(t, shardIns.lookupRowMeta(mockBatchRows(&protoMetricsV1.Metric{
+		Name:      "test",
+		Timestamp: timestamp,
+		TagsHash:  11,
+		Tags:      tag.KeyValuesFromMap(map[string]string{"ip": "1.1.1.1"}),
+		SimpleFields: []*protoMetricsV1.SimpleField{{
+			Name:      "f2",
+			Value:     2.0,
+			Type:      protoMetricsV1.SimpleFieldType_DELTA_SUM,
+			Exemplars: []*protoMetricsV1.Exemplar{{TraceId: []byte{1, 2}, SpanId: []byte{3}, Duration: 10}},
+		}},
+	}), nil))
+	assert.True(t, shardIns.HasExemplars(10))
+	assert.Equal(t, []metric.Exemplar{{
+		TraceID: []byte{1, 2}, SpanID: []byte{3}, Duration: 10, Value: 2, Timestamp: timestamp,
+	}}, shardIns.GetExemplars(10, 10, 2, timeutil.TimeRange{Start: timestamp, End: timestamp}))
 }
 
 func TestShard_Close(t *testing.T) {
@@ -255,19 +290,23 @@ func TestShard_Close(t *testing.T) {
 	s1 := s.(*shard)
 	s1.indexDB = index
 
-	// case 1: close index err
+	mockSegment := NewMockIntervalSegment(ctrl)
+	s1.segment = mockSegment
+
+	// case 1: close index err, index store/data families are still closed
 	index.EXPECT().Close().Return(fmt.Errorf("err"))
+	kvStore.EXPECT().Close().Return(nil)
+	mockSegment.EXPECT().Close()
 	err := s.Close()
 	assert.Error(t, err)
 	// case 2: close index store err
 	index.EXPECT().Close().Return(nil).AnyTimes()
 	kvStore.EXPECT().Close().Return(fmt.Errorf("exx"))
+	mockSegment.EXPECT().Close()
 	err = s.Close()
 	assert.Error(t, err)
 	// case3: close success
 	kvStore.EXPECT().Close().Return(nil)
-	mockSegment := NewMockIntervalSegment(ctrl)
-	s1.segment = mockSegment
 	mockSegment.EXPECT().Close()
 	err = s.Close()
 	assert.NoError(t, err)
@@ -340,3 +379,68 @@ func TestShard_NeedFlush(t *testing.T) {
 	db6.EXPECT().Uptime().Return(time.Second).AnyTimes()
 	db6.EXPECT().MemSize().Return(int64(config.GlobalStorageConfig().TSDB.MaxMemDBTotalSize) + 10000).AnyTimes()
 }
+
+func TestShard_Compact(t *testing.T) {
+	_testShard1Path := createShardTestDir(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	meta := metadb.NewMockMetadata(ctrl)
+	meta.EXPECT().DatabaseName().Return("test").AnyTimes()
+	db := NewMockDatabase(ctrl)
+	db.EXPECT().Name().Return("test-db").AnyTimes()
+	db.EXPECT().Metadata().Return(meta).AnyTimes()
+	s, err := newShard(db, 1, _testShard1Path, option.DatabaseOption{Interval: "10s"})
+	assert.NoError(t, err)
+	defer func() {
+		s1 := s.(*shard)
+		s1.indexDB = indexdb.NewMockIndexDatabase(ctrl)
+		s1.indexDB.(*indexdb.MockIndexDatabase).EXPECT().Close().Return(nil)
+		_ = s.Close()
+	}()
+
+	// case 1: no data family
+	assert.NoError(t, s.Compact(context.TODO(), 0))
+	// case 2: data family not found
+	familyTime, _ := timeutil.ParseTimestamp("20190702 19:00:00", "20060102 15:04:05")
+	err = s.Compact(context.TODO(), familyTime)
+	assert.True(t, errors.Is(err, constants.ErrDataFamilyNotFound))
+
+	// case 3: compact multiple small files of family
+	dataFamily, err := s.GetOrCrateDataFamily(familyTime)
+	assert.NoError(t, err)
+	for metricID := uint32(1); metricID <= 3; metricID++ {
+		flusher, err := metricsdata.NewFlusher(dataFamily.Family().NewFlusher())
+		assert.NoError(t, err)
+		flusher.PrepareMetric(metricID, field.Metas{{ID: 1, Type: field.SumField}})
+		encoder := encoding.NewTSDEncoder(5)
+		encoder.AppendTime(bit.One)
+		encoder.AppendValue(math.Float64bits(10.0))
+		data, _ := encoder.BytesWithoutTime()
+		assert.NoError(t, flusher.FlushField(data))
+		assert.NoError(t, flusher.FlushSeries(10))
+		assert.NoError(t, flusher.CommitMetric(timeutil.SlotRange{Start: 5, End: 5}))
+		assert.NoError(t, flusher.Close())
+	}
+	numOfFiles := func() int {
+		snapshot := dataFamily.Family().GetSnapshot()
+		defer snapshot.Close()
+		return snapshot.GetCurrent().NumberOfFilesInLevel(0) + snapshot.GetCurrent().NumberOfFilesInLevel(1)
+	}
+	assert.Equal(t, 3, numOfFiles())
+	assert.NoError(t, s.Compact(context.TODO(), familyTime))
+	assert.Equal(t, 1, numOfFiles())
+
+	// case 4: compact timeout
+	kvFamily := kv.NewMockFamily(ctrl)
+	kvFamily.EXPECT().Compact(gomock.Any()).Return(context.DeadlineExceeded)
+	segment := NewMockIntervalSegment(ctrl)
+	family := NewMockDataFamily(ctrl)
+	family.EXPECT().FamilyTime().Return(familyTime).AnyTimes()
+	family.EXPECT().Indicator().Return("family").AnyTimes()
+	family.EXPECT().Family().Return(kvFamily).AnyTimes()
+	segment.EXPECT().getDataFamilies(gomock.Any()).Return([]DataFamily{family})
+	s2 := &shard{segments: map[timeutil.IntervalType]IntervalSegment{timeutil.Day: segment}}
+	err = s2.Compact(context.TODO(), 0)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+}
@@ -0,0 +1,311 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tsdb
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lindb/lindb/kv/version"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/fileutil"
+	"github.com/lindb/lindb/pkg/logger"
+	"github.com/lindb/lindb/pkg/option"
+	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/tsdb/metadb"
+)
+
+// for testing
+var (
+	renameFunc = os.Rename
+)
+
+const (
+	// shardSnapshotManifest is the first entry of shard snapshot, describes the snapshot.
+	shardSnapshotManifest = "SNAPSHOT"
+	// importingSuffix is the suffix of temp directory which the snapshot is extracted into.
+	importingSuffix = ".importing"
+)
+
+// shardManifest represents the meta of shard snapshot.
+type shardManifest struct {
+	Database string                `json:"database"`
+	ShardID  models.ShardID        `json:"shardID"`
+	Option   option.DatabaseOption `json:"option"`
+	// Metrics is the database level metadata of metrics which are referenced by shard's data and index.
+	Metrics []metadb.MetricSnapshot `json:"metrics,omitempty"`
+}
+
+// ExportShard writes a consistent snapshot of shard's data(segments and index) into writer,
+// memory data is flushed before taking the snapshot.
+// The snapshot is a tar stream, the first entry is the manifest with metadata of metrics referenced by shard,
+// then files of shard's directory.
+func (e *engine) ExportShard(databaseName string, shardID models.ShardID, writer io.Writer) error {
+	db, ok := e.GetDatabase(databaseName)
+	if !ok {
+		return fmt.Errorf("database[%s] not found", databaseName)
+	}
+	shard, ok := db.GetShard(shardID)
+	if !ok {
+		return fmt.Errorf("shard[%d] of database[%s] not found", shardID, databaseName)
+	}
+	tw := tar.NewWriter(writer)
+	if err := shard.Snapshot(func(path string) error {
+		// exports metadata after index flushed, includes all metrics referenced by shard's files
+		metricIDs, err := shard.IndexDatabase().AllMetricIDs()
+		if err != nil {
+			return err
+		}
+		metrics, err := db.Metadata().Export(metricIDs)
+		if err != nil {
+			return err
+		}
+		manifest, err := json.Marshal(&shardManifest{
+			Database: databaseName,
+			ShardID:  shardID,
+			Option:   db.GetOption(),
+			Metrics:  metrics,
+		})
+		if err != nil {
+			return err
+		}
+		if err := tw.WriteHeader(&tar.Header{
+			Name: shardSnapshotManifest,
+			Mode: 0644,
+			Size: int64(len(manifest)),
+		}); err != nil {
+			return err
+		}
+		if _, err := tw.Write(manifest); err != nil {
+			return err
+		}
+		return archiveShardDir(tw, path)
+	}); err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	engineLogger.Info("export shard successfully",
+		logger.String("database", databaseName), logger.Any("shardID", shardID))
+	return nil
+}
+
+// ImportShard loads the shard snapshot produced by ExportShard, then opens the shard,
+// returns error if shard exists or snapshot is incompatible with database option/metadata.
+// The metadata of snapshot is installed if database has no metadata, else the ids of metadata must be same
+// as database's, because shard's data and index reference metric/field/tag ids without remapping.
+func (e *engine) ImportShard(
+	databaseName string,
+	shardID models.ShardID,
+	databaseOption option.DatabaseOption,
+	reader io.Reader,
+) error {
+	if _, ok := e.GetShard(databaseName, shardID); ok {
+		return fmt.Errorf("shard[%d] of database[%s] already exists", shardID, databaseName)
+	}
+	tr := tar.NewReader(reader)
+	manifest, err := readShardManifest(tr)
+	if err != nil {
+		return err
+	}
+	if err := checkShardManifest(manifest, databaseName, shardID, databaseOption); err != nil {
+		return err
+	}
+	db, err := e.getOrCreateDatabase(databaseName)
+	if err != nil {
+		return err
+	}
+	if db.NumOfShards() > 0 {
+		// shards of same database must have same interval
+		if err := checkShardManifest(manifest, databaseName, shardID, db.GetOption()); err != nil {
+			return err
+		}
+	}
+	shardPath := db.ShardPath(shardID)
+	if fileutil.Exist(shardPath) {
+		return fmt.Errorf("shard[%d] of database[%s] path[%s] already exists", shardID, databaseName, shardPath)
+	}
+	if err := db.Metadata().Import(manifest.Metrics); err != nil {
+		return fmt.Errorf("import metadata of shard snapshot into database[%s] error: %w", databaseName, err)
+	}
+	// extracts into temp directory, then renames it, avoids opening partial shard
+	tmpPath := shardPath + importingSuffix
+	if err := fileutil.RemoveDir(tmpPath); err != nil {
+		return err
+	}
+	if err := extractShardDir(tr, tmpPath); err != nil {
+		_ = fileutil.RemoveDir(tmpPath)
+		return err
+	}
+	if err := mkDirIfNotExist(filepath.Dir(shardPath)); err != nil {
+		_ = fileutil.RemoveDir(tmpPath)
+		return err
+	}
+	if err := renameFunc(tmpPath, shardPath); err != nil {
+		_ = fileutil.RemoveDir(tmpPath)
+		return err
+	}
+	if err := db.CreateShards(databaseOption, []models.ShardID{shardID}); err != nil {
+		return err
+	}
+	engineLogger.Info("import shard successfully",
+		logger.String("database", databaseName), logger.Any("shardID", shardID))
+	return nil
+}
+
+// readShardManifest reads the manifest which is the first entry of shard snapshot.
+func readShardManifest(tr *tar.Reader) (*shardManifest, error) {
+	header, err := tr.Next()
+	if err != nil {
+		return nil, fmt.Errorf("read shard snapshot manifest error: %s", err)
+	}
+	if header.Name != shardSnapshotManifest {
+		return nil, fmt.Errorf("shard snapshot manifest not found, first entry: %s", header.Name)
+	}
+	manifest := &shardManifest{}
+	if err := json.NewDecoder(tr).Decode(manifest); err != nil {
+		return nil, fmt.Errorf("decode shard snapshot manifest error: %s", err)
+	}
+	return manifest, nil
+}
+
+// checkShardManifest checks if the shard snapshot is compatible with target shard.
+func checkShardManifest(
+	manifest *shardManifest,
+	databaseName string,
+	shardID models.ShardID,
+	databaseOption option.DatabaseOption,
+) error {
+	if manifest.Database != databaseName {
+		return fmt.Errorf("shard snapshot of database[%s] cannot be imported into database[%s]",
+			manifest.Database, databaseName)
+	}
+	if manifest.ShardID != shardID {
+		return fmt.Errorf("shard snapshot of shard[%d] cannot be imported as shard[%d]",
+			manifest.ShardID, shardID)
+	}
+	var snapshotInterval, interval timeutil.Interval
+	if err := snapshotInterval.ValueOf(manifest.Option.Interval); err != nil {
+		return fmt.Errorf("interval of shard snapshot is invalid: %s", err)
+	}
+	if err := interval.ValueOf(databaseOption.Interval); err != nil {
+		return fmt.Errorf("interval of database[%s] is invalid: %s", databaseName, err)
+	}
+	if snapshotInterval != interval {
+		return fmt.Errorf("interval of shard snapshot: %s is not compatible with database[%s] interval: %s",
+			manifest.Option.Interval, databaseName, databaseOption.Interval)
+	}
+	return nil
+}
+
+// archiveShardDir writes the files under shard directory into tar,
+// skips write buffer and file lock which are only valid for the running shard.
+func archiveShardDir(tw *tar.Writer, shardPath string) error {
+	return filepath.Walk(shardPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(shardPath, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		if info.IsDir() && rel == bufferDir {
+			return filepath.SkipDir
+		}
+		if !info.IsDir() && (!info.Mode().IsRegular() || info.Name() == version.Lock) {
+			return nil
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = f.Close()
+		}()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+}
+
+// extractShardDir extracts the files of shard snapshot into target directory.
+func extractShardDir(tr *tar.Reader, target string) error {
+	if err := mkDirIfNotExist(target); err != nil {
+		return err
+	}
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		name := filepath.Clean(filepath.FromSlash(header.Name))
+		if filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("invalid file path in shard snapshot: %s", header.Name)
+		}
+		path := filepath.Join(target, name)
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := mkDirIfNotExist(path); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := extractFile(tr, path, os.FileMode(header.Mode)); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unsupported file type of %s in shard snapshot", header.Name)
+		}
+	}
+}
+
+func extractFile(r io.Reader, path string, mode os.FileMode) error {
+	if err := mkDirIfNotExist(filepath.Dir(path)); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
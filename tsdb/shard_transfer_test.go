@@ -0,0 +1,295 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tsdb
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/option"
+	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/series/field"
+)
+
+func listShardFiles(t *testing.T, shardPath string) map[string]string {
+	files := make(map[string]string)
+	err := filepath.Walk(shardPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(shardPath, path)
+		if info.IsDir() && rel == bufferDir {
+			return filepath.SkipDir
+		}
+		if info.Mode().IsRegular() && info.Name() != "LOCK" {
+			data, err := ioutil.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			files[rel] = string(data)
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	return files
+}
+
+func TestEngine_ExportImportShard(t *testing.T) {
+	writeConfigTestLock.Lock()
+	defer writeConfigTestLock.Unlock()
+
+	srcDir := t.TempDir()
+	targetDir := t.TempDir()
+	dbOption := option.DatabaseOption{Interval: "10s"}
+
+	// source node
+	withTestPath(srcDir)
+	src, err := NewEngine()
+	assert.NoError(t, err)
+	assert.NoError(t, src.CreateShards("db", dbOption, 1, 2))
+	shard, ok := src.GetShard("db", 1)
+	assert.True(t, ok)
+	_, err = shard.GetOrCrateDataFamily(timeutil.Now())
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(shard.Path(), "segment", "data.sst"), []byte("data"), 0644))
+	assert.NoError(t, os.MkdirAll(filepath.Join(shard.Path(), bufferDir), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(shard.Path(), bufferDir, "tmp"), []byte("buffer"), 0644))
+
+	// export not found
+	var buf bytes.Buffer
+	assert.Error(t, src.ExportShard("not-exist", 1, &buf))
+	assert.Error(t, src.ExportShard("db", 10, &buf))
+	// export ok
+	assert.NoError(t, src.ExportShard("db", 1, &buf))
+	srcFiles := listShardFiles(t, shard.Path())
+	assert.Contains(t, srcFiles, filepath.Join("segment", "data.sst"))
+	src.Close()
+	// snapshot holds same files of source shard
+	assert.Equal(t, srcFiles, readSnapshotFiles(t, buf.Bytes()))
+
+	// target node
+	withTestPath(targetDir)
+	target, err := NewEngine()
+	assert.NoError(t, err)
+	defer target.Close()
+	snapshot := buf.Bytes()
+	// incompatible snapshot
+	assert.Error(t, target.ImportShard("db2", 1, dbOption, bytes.NewReader(snapshot)))
+	assert.Error(t, target.ImportShard("db", 2, dbOption, bytes.NewReader(snapshot)))
+	assert.Error(t, target.ImportShard("db", 1, option.DatabaseOption{Interval: "1m"}, bytes.NewReader(snapshot)))
+	assert.Error(t, target.ImportShard("db", 1, dbOption, bytes.NewReader(nil)))
+	// import ok
+	assert.NoError(t, target.ImportShard("db", 1, dbOption, bytes.NewReader(snapshot)))
+	importedShard, ok := target.GetShard("db", 1)
+	assert.True(t, ok)
+	assert.Equal(t, filepath.Join(targetDir, "db", shardDir, "1"), importedShard.Path())
+	targetFiles := listShardFiles(t, importedShard.Path())
+	assert.Equal(t, "data", targetFiles[filepath.Join("segment", "data.sst")])
+	assert.False(t, fileExist(filepath.Join(importedShard.Path(), bufferDir, "tmp")))
+	assert.False(t, fileExist(importedShard.Path()+importingSuffix))
+	// shard exists
+	assert.Error(t, target.ImportShard("db", 1, dbOption, bytes.NewReader(snapshot)))
+	target.Close()
+
+	// re-open target engine, imported shard is loaded
+	target, err = NewEngine()
+	assert.NoError(t, err)
+	_, ok = target.GetShard("db", 1)
+	assert.True(t, ok)
+}
+
+func TestEngine_ExportImportShard_Metadata(t *testing.T) {
+	writeConfigTestLock.Lock()
+	defer writeConfigTestLock.Unlock()
+
+	dbOption := option.DatabaseOption{Interval: "10s"}
+	// genMetadata generates metadata of metric, returns metric id and creates series in shard if not nil
+	genMetadata := func(e Engine, metricName string, shardIDs ...models.ShardID) uint32 {
+		db, ok := e.GetDatabase("db")
+		assert.True(t, ok)
+		mdb := db.Metadata().MetadataDatabase()
+		metricID, err := mdb.GenMetricID("ns", metricName)
+		assert.NoError(t, err)
+		_, err = mdb.GenFieldID("ns", metricName, "f", field.SumField)
+		assert.NoError(t, err)
+		tagKeyID, err := mdb.GenTagKeyID("ns", metricName, "host")
+		assert.NoError(t, err)
+		_, err = db.Metadata().TagMetadata().GenTagValueID(tagKeyID, "1.1.1.1")
+		assert.NoError(t, err)
+		for _, shardID := range shardIDs {
+			shard, ok := db.GetShard(shardID)
+			assert.True(t, ok)
+			_, _, err = shard.IndexDatabase().GetOrCreateSeriesID(metricID, 1)
+			assert.NoError(t, err)
+		}
+		return metricID
+	}
+
+	// source node, metric id of cpu is 2
+	withTestPath(t.TempDir())
+	src, err := NewEngine()
+	assert.NoError(t, err)
+	assert.NoError(t, src.CreateShards("db", dbOption, 1, 2))
+	genMetadata(src, "unused")
+	cpuID := genMetadata(src, "cpu", 1, 2)
+	var snapshot1, snapshot2 bytes.Buffer
+	assert.NoError(t, src.ExportShard("db", 1, &snapshot1))
+	assert.NoError(t, src.ExportShard("db", 2, &snapshot2))
+	src.Close()
+
+	// import into database with different metadata, metric id of cpu is 1
+	withTestPath(t.TempDir())
+	target, err := NewEngine()
+	assert.NoError(t, err)
+	assert.NoError(t, target.CreateShards("db", dbOption, 3))
+	genMetadata(target, "cpu")
+	err = target.ImportShard("db", 1, dbOption, bytes.NewReader(snapshot1.Bytes()))
+	assert.True(t, errors.Is(err, constants.ErrIncompatibleMetadata))
+	_, ok := target.GetShard("db", 1)
+	assert.False(t, ok)
+	target.Close()
+
+	// import into new database, metadata is installed
+	withTestPath(t.TempDir())
+	target, err = NewEngine()
+	assert.NoError(t, err)
+	defer target.Close()
+	assert.NoError(t, target.ImportShard("db", 1, dbOption, bytes.NewReader(snapshot1.Bytes())))
+	db, ok := target.GetDatabase("db")
+	assert.True(t, ok)
+	mdb := db.Metadata().MetadataDatabase()
+	metricID, err := mdb.GetMetricID("ns", "cpu")
+	assert.NoError(t, err)
+	assert.Equal(t, cpuID, metricID)
+	_, err = mdb.GetMetricID("ns", "unused")
+	assert.Error(t, err)
+	f, err := mdb.GetField("ns", "cpu", "f")
+	assert.NoError(t, err)
+	assert.Equal(t, field.ID(1), f.ID)
+	tagKeyID, err := mdb.GetTagKeyID("ns", "cpu", "host")
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(2), tagKeyID)
+	tagValues, err := db.Metadata().TagMetadata().ResolveTagValueIDs(tagKeyID, []uint32{1})
+	assert.NoError(t, err)
+	assert.Equal(t, map[uint32]string{1: "1.1.1.1"}, tagValues)
+	// new ids are generated after imported ids
+	metricID, err = mdb.GenMetricID("ns", "mem")
+	assert.NoError(t, err)
+	assert.Equal(t, cpuID+1, metricID)
+	tagValueID, err := db.Metadata().TagMetadata().GenTagValueID(tagKeyID, "1.1.1.2")
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(2), tagValueID)
+	// import another shard with same metadata
+	assert.NoError(t, target.ImportShard("db", 2, dbOption, bytes.NewReader(snapshot2.Bytes())))
+	_, ok = target.GetShard("db", 2)
+	assert.True(t, ok)
+}
+
+func TestEngine_ImportShard_Error(t *testing.T) {
+	writeConfigTestLock.Lock()
+	defer writeConfigTestLock.Unlock()
+
+	tmpDir := t.TempDir()
+	withTestPath(tmpDir)
+	e, err := NewEngine()
+	assert.NoError(t, err)
+	defer e.Close()
+	dbOption := option.DatabaseOption{Interval: "10s"}
+
+	newSnapshot := func(manifest string, files map[string]string) []byte {
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		assert.NoError(t, tw.WriteHeader(&tar.Header{Name: shardSnapshotManifest, Mode: 0644, Size: int64(len(manifest))}))
+		_, _ = tw.Write([]byte(manifest))
+		names := make([]string, 0, len(files))
+		for name := range files {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			assert.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(files[name]))}))
+			_, _ = tw.Write([]byte(files[name]))
+		}
+		assert.NoError(t, tw.Close())
+		return buf.Bytes()
+	}
+	manifest := `{"database":"db","shardID":1,"option":{"interval":"10s"}}`
+	// bad manifest
+	assert.Error(t, e.ImportShard("db", 1, dbOption, bytes.NewReader(newSnapshot("xx", nil))))
+	assert.Error(t, e.ImportShard("db", 1, dbOption,
+		bytes.NewReader(newSnapshot(`{"database":"db","shardID":1,"option":{"interval":"x"}}`, nil))))
+	assert.Error(t, e.ImportShard("db", 1, option.DatabaseOption{Interval: "x"},
+		bytes.NewReader(newSnapshot(manifest, nil))))
+	// manifest not first entry
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	assert.NoError(t, tw.WriteHeader(&tar.Header{Name: "a", Mode: 0644}))
+	assert.NoError(t, tw.Close())
+	assert.Error(t, e.ImportShard("db", 1, dbOption, bytes.NewReader(buf.Bytes())))
+	// invalid path
+	assert.Error(t, e.ImportShard("db", 1, dbOption,
+		bytes.NewReader(newSnapshot(manifest, map[string]string{"../a": "a"}))))
+	assert.False(t, fileExist(filepath.Join(tmpDir, "db", shardDir, "1"+importingSuffix)))
+	// rename err
+	renameFunc = func(oldpath, newpath string) error {
+		return fmt.Errorf("err")
+	}
+	assert.Error(t, e.ImportShard("db", 1, dbOption,
+		bytes.NewReader(newSnapshot(manifest, map[string]string{"a": "a"}))))
+	renameFunc = os.Rename
+	assert.False(t, fileExist(filepath.Join(tmpDir, "db", shardDir, "1"+importingSuffix)))
+	// interval not compatible with exist shards
+	assert.NoError(t, e.CreateShards("db", option.DatabaseOption{Interval: "1m"}, 2))
+	assert.Error(t, e.ImportShard("db", 1, dbOption, bytes.NewReader(newSnapshot(manifest, nil))))
+	_, ok := e.GetShard("db", models.ShardID(1))
+	assert.False(t, ok)
+}
+
+func readSnapshotFiles(t *testing.T, snapshot []byte) map[string]string {
+	files := make(map[string]string)
+	tr := tar.NewReader(bytes.NewReader(snapshot))
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return files
+		}
+		assert.NoError(t, err)
+		if header.Typeflag == tar.TypeReg && header.Name != shardSnapshotManifest {
+			data, err := ioutil.ReadAll(tr)
+			assert.NoError(t, err)
+			files[filepath.FromSlash(header.Name)] = string(data)
+		}
+	}
+}
+
+func fileExist(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -30,6 +30,22 @@ import (
 
 var MetricDataMerger kv.MergerType = "MetricDataMerger"
 
+// PurgeFieldsContext is the merger param key of PurgeFields, the fields are dropped when merging.
+const PurgeFieldsContext = "PurgeFieldsContext"
+
+// PurgeFields represents the fields need to be purged of metrics, metric id => field ids.
+type PurgeFields map[uint32][]field.ID
+
+// Contains checks if the field of metric need to be purged.
+func (pf PurgeFields) Contains(metricID uint32, fieldID field.ID) bool {
+	for _, id := range pf[metricID] {
+		if id == fieldID {
+			return true
+		}
+	}
+	return false
+}
+
 // init registers metric data merger create function
 func init() {
 	kv.RegisterMerger(MetricDataMerger, NewMerger)
@@ -49,6 +65,7 @@ type merger struct {
 	dataFlusher  Flusher
 	seriesMerger SeriesMerger
 	rollup       kv.Rollup
+	purgeFields  PurgeFields
 }
 
 // NewMerger creates a metric data merger
@@ -69,16 +86,24 @@ func (m *merger) Init(params map[string]interface{}) {
 	if ok {
 		m.rollup = rollupCtx.(kv.Rollup)
 	}
+	purgeFields, ok := params[PurgeFieldsContext]
+	if ok {
+		m.purgeFields = purgeFields.(PurgeFields)
+	}
 }
 
 // Merge merges the multi metric data into one target metric data for same metric id
 func (m *merger) Merge(key uint32, metricBlocks [][]byte) error {
 	blockCount := len(metricBlocks)
 	// 1. prepare readers and metric level data(field/time slot/series ids)
-	mergeCtx, err := m.prepare(metricBlocks)
+	mergeCtx, err := m.prepare(key, metricBlocks)
 	if err != nil {
 		return err
 	}
+	if len(mergeCtx.targetFields) == 0 {
+		// all fields of metric are purged
+		return nil
+	}
 	// 2. Prepare metric
 	m.dataFlusher.PrepareMetric(key, mergeCtx.targetFields)
 	// 3. merge series data by roaring container
@@ -125,7 +150,7 @@ func (m *merger) Merge(key uint32, metricBlocks [][]byte) error {
 	return nil
 }
 
-func (m *merger) prepare(metricBlocks [][]byte) (*mergerContext, error) {
+func (m *merger) prepare(metricID uint32, metricBlocks [][]byte) (*mergerContext, error) {
 	ctx := &mergerContext{
 		scanners:     make([]*dataScanner, len(metricBlocks)),
 		seriesIDs:    roaring.New(),
@@ -140,7 +165,7 @@ func (m *merger) prepare(metricBlocks [][]byte) (*mergerContext, error) {
 		ctx.seriesIDs.Or(reader.GetSeriesIDs())
 		// get target slot range(start/end)
 		timeRange := reader.GetTimeRange()
-		if len(ctx.targetFields) == 0 {
+		if idx == 0 {
 			ctx.sourceRange.Start = timeRange.Start
 			ctx.sourceRange.End = timeRange.End
 		} else {
@@ -153,6 +178,9 @@ func (m *merger) prepare(metricBlocks [][]byte) (*mergerContext, error) {
 		}
 		// merge target fields under metric level
 		for _, f := range reader.GetFields() {
+			if m.purgeFields.Contains(metricID, f.ID) {
+				continue
+			}
 			_, ok := ctx.targetFields.GetFromID(f.ID)
 			if !ok {
 				ctx.targetFields = ctx.targetFields.Insert(f)
@@ -84,6 +84,40 @@ func assertMergeReentrant(t *testing.T, flusher kv.Flusher, mergerIntf kv.Merger
 	assert.Len(t, blocks, 2)
 }
 
+func Test_Compact_PurgeFields(t *testing.T) {
+	blocks := [][]byte{
+		mockRealMetricBlock([]uint32{1, 2}, 11, 15),
+		mockRealMetricBlock([]uint32{2, 20}, 16, 20),
+	}
+	// case 1: purge short retention field, long retention field remains
+	flusher := kv.NewNopFlusher()
+	mergerIntf, err := NewMerger(flusher)
+	assert.NoError(t, err)
+	mergerIntf.Init(map[string]interface{}{PurgeFieldsContext: PurgeFields{1: {10}, 2: {2}}})
+	assert.NoError(t, mergerIntf.Merge(1, blocks))
+	r, err := NewReader("test", flusher.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, field.Metas{{ID: 2, Type: field.SumField}}, r.GetFields())
+	assert.Equal(t, timeutil.SlotRange{Start: 11, End: 20}, r.GetTimeRange())
+	loader := r.Load(0, r.GetSeriesIDs().GetContainer(0), r.GetFields())
+	slotRange, fieldBlocks := loader.Load(2)
+	assert.Len(t, fieldBlocks, 1)
+	decoder := encoding.NewTSDDecoder(fieldBlocks[0])
+	decoder.ResetWithTimeRange(fieldBlocks[0], slotRange.Start, slotRange.End)
+	for slot := uint16(11); slot <= 20; slot++ {
+		assert.True(t, decoder.HasValueWithSlot(slot))
+		// series 2 exists in both blocks, slot 11~15 and 16~20
+		assert.Equal(t, float64(slot), math.Float64frombits(decoder.Value()))
+	}
+	// case 2: purge all fields of metric
+	flusher = kv.NewNopFlusher()
+	mergerIntf, err = NewMerger(flusher)
+	assert.NoError(t, err)
+	mergerIntf.Init(map[string]interface{}{PurgeFieldsContext: PurgeFields{1: {2, 10}}})
+	assert.NoError(t, mergerIntf.Merge(1, blocks))
+	assert.Empty(t, flusher.Bytes())
+}
+
 func mockRealMetricBlock(seriesIDs []uint32, start, end uint16) []byte {
 	nopKVFlusher := kv.NewNopFlusher()
 	flusher, _ := NewFlusher(nopKVFlusher)
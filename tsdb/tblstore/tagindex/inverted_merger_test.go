@@ -43,11 +43,11 @@ func TestInvertedMerger_Merge(t *testing.T) {
 	reader, err := newTagInvertedReader(nopFlusher.Bytes())
 	assert.NoError(t, err)
 	assert.EqualValues(t, roaring.BitmapOf(1, 2, 3, 4, 5, 6, 7, 8000000, 9000000).ToArray(), reader.keys.ToArray())
-	seriesIDs, _ := reader.getSeriesIDsByTagValueIDs(roaring.BitmapOf(1))
+	seriesIDs, _ := reader.getSeriesIDsByTagValueIDs(roaring.BitmapOf(1), nil)
 	assert.EqualValues(t, roaring.BitmapOf(1, 10).ToArray(), seriesIDs.ToArray())
-	seriesIDs, _ = reader.getSeriesIDsByTagValueIDs(roaring.BitmapOf(2))
+	seriesIDs, _ = reader.getSeriesIDsByTagValueIDs(roaring.BitmapOf(2), nil)
 	assert.EqualValues(t, roaring.BitmapOf(2).ToArray(), seriesIDs.ToArray())
-	seriesIDs, _ = reader.getSeriesIDsByTagValueIDs(roaring.BitmapOf(8000000))
+	seriesIDs, _ = reader.getSeriesIDsByTagValueIDs(roaring.BitmapOf(8000000), nil)
 	assert.EqualValues(t, roaring.BitmapOf(8000000).ToArray(), seriesIDs.ToArray())
 	// case 2: new reader err
 	_ = nopFlusher.Commit()
@@ -143,11 +143,11 @@ func TestInvertedMerger_Merge_same_tagValues(t *testing.T) {
 	reader, err := newTagInvertedReader(append([]byte{}, nopFlusher.Bytes()...))
 	assert.NoError(t, err)
 	assert.EqualValues(t, roaring.BitmapOf(1, 2, 3, 4).ToArray(), reader.keys.ToArray())
-	seriesIDs, _ := reader.getSeriesIDsByTagValueIDs(roaring.BitmapOf(1))
+	seriesIDs, _ := reader.getSeriesIDsByTagValueIDs(roaring.BitmapOf(1), nil)
 	assert.EqualValues(t, roaring.BitmapOf(1).ToArray(), seriesIDs.ToArray())
-	seriesIDs, _ = reader.getSeriesIDsByTagValueIDs(roaring.BitmapOf(2))
+	seriesIDs, _ = reader.getSeriesIDsByTagValueIDs(roaring.BitmapOf(2), nil)
 	assert.EqualValues(t, roaring.BitmapOf(2).ToArray(), seriesIDs.ToArray())
-	seriesIDs, _ = reader.getSeriesIDsByTagValueIDs(roaring.BitmapOf(4))
+	seriesIDs, _ = reader.getSeriesIDsByTagValueIDs(roaring.BitmapOf(4), nil)
 	assert.EqualValues(t, roaring.BitmapOf(4).ToArray(), seriesIDs.ToArray())
 	err = merge.Merge(1, [][]byte{
 		nopFlusher.Bytes(),
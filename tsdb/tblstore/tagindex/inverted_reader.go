@@ -25,14 +25,16 @@ import (
 	"github.com/lindb/lindb/kv/table"
 	"github.com/lindb/lindb/pkg/encoding"
 	"github.com/lindb/lindb/pkg/stream"
+	"github.com/lindb/lindb/series"
 )
 
 //go:generate mockgen -source ./inverted_reader.go -destination=./inverted_reader_mock.go -package tagindex
 
 // InvertedReader reads seriesID bitmap from series-index-table
 type InvertedReader interface {
-	// GetSeriesIDsByTagValueIDs finds series ids by tag key id and tag value ids
-	GetSeriesIDsByTagValueIDs(tagKeyID uint32, tagValueIDs *roaring.Bitmap) (*roaring.Bitmap, error)
+	// GetSeriesIDsByTagValueIDs finds series ids by tag key id and tag value ids,
+	// the union of series ids is accounted by budget.
+	GetSeriesIDsByTagValueIDs(tagKeyID uint32, tagValueIDs *roaring.Bitmap, budget *series.BitmapBudget) (*roaring.Bitmap, error)
 }
 
 // inverterReader implements InvertedReader
@@ -48,18 +50,27 @@ func NewInvertedReader(readers []table.Reader) InvertedReader {
 }
 
 // GetSeriesIDsByTagValueIDs finds series ids by tag key id and tag value ids
-func (r *inverterReader) GetSeriesIDsByTagValueIDs(tagKeyID uint32, tagValueIDs *roaring.Bitmap) (*roaring.Bitmap, error) {
+func (r *inverterReader) GetSeriesIDsByTagValueIDs(
+	tagKeyID uint32,
+	tagValueIDs *roaring.Bitmap,
+	budget *series.BitmapBudget,
+) (*roaring.Bitmap, error) {
 	if tagValueIDs == nil || tagValueIDs.IsEmpty() {
 		return roaring.New(), nil
 	}
 	fn := func(indexReader *tagInvertedReader) (*roaring.Bitmap, error) {
-		return indexReader.getSeriesIDsByTagValueIDs(tagValueIDs)
+		return indexReader.getSeriesIDsByTagValueIDs(tagValueIDs, budget)
 	}
-	return r.loadSeriesIDs(tagKeyID, fn)
+	return r.loadSeriesIDs(tagKeyID, fn, budget)
 }
 
-// loadSeriesIDs loads the series ids by tag key id, function need implement condition
-func (r *inverterReader) loadSeriesIDs(tagKeyID uint32, fn func(indexReader *tagInvertedReader) (*roaring.Bitmap, error)) (*roaring.Bitmap, error) {
+// loadSeriesIDs loads the series ids by tag key id, function need implement condition,
+// series ids of each reader are released from budget after merged into result.
+func (r *inverterReader) loadSeriesIDs(
+	tagKeyID uint32,
+	fn func(indexReader *tagInvertedReader) (*roaring.Bitmap, error),
+	budget *series.BitmapBudget,
+) (*roaring.Bitmap, error) {
 	seriesIDs := roaring.New()
 	for _, reader := range r.readers {
 		value, err := reader.Get(tagKeyID)
@@ -68,13 +79,20 @@ func (r *inverterReader) loadSeriesIDs(tagKeyID uint32, fn func(indexReader *tag
 		}
 		indexReader, err := newTagInvertedReader(value)
 		if err != nil {
+			budget.Release(seriesIDs.GetSizeInBytes())
 			return nil, err
 		}
 		ids, err := fn(indexReader)
+		if err == nil {
+			err = budget.Or(seriesIDs, ids)
+		}
+		if ids != nil {
+			budget.Release(ids.GetSizeInBytes())
+		}
 		if err != nil {
+			budget.Release(seriesIDs.GetSizeInBytes())
 			return nil, err
 		}
-		seriesIDs.Or(ids)
 	}
 	return seriesIDs, nil
 }
@@ -95,8 +113,9 @@ func newTagInvertedReader(buf []byte) (*tagInvertedReader, error) {
 	return r, nil
 }
 
-// getSeriesIDsByTagValueIDs finds series ids by tag value ids under this tag key
-func (r *tagInvertedReader) getSeriesIDsByTagValueIDs(tagValueIDs *roaring.Bitmap) (*roaring.Bitmap, error) {
+// getSeriesIDsByTagValueIDs finds series ids by tag value ids under this tag key, the union is accounted by budget,
+// returns the series ids unioned even if fails, so that caller can release them from budget.
+func (r *tagInvertedReader) getSeriesIDsByTagValueIDs(tagValueIDs *roaring.Bitmap, budget *series.BitmapBudget) (*roaring.Bitmap, error) {
 	result := roaring.New()
 	// get final tag value ids need to load
 	finalTagValueIDs := roaring.And(tagValueIDs, r.keys)
@@ -106,7 +125,7 @@ func (r *tagInvertedReader) getSeriesIDsByTagValueIDs(tagValueIDs *roaring.Bitma
 	lowOffsets := encoding.NewFixedOffsetDecoder()
 
 	if _, err := highOffsets.Unmarshal(r.buf[r.baseReader.offsetsAt:]); err != nil {
-		return nil, err
+		return result, err
 	}
 	entries := r.buf[:r.baseReader.tagValueBitmapAt]
 
@@ -117,15 +136,15 @@ func (r *tagInvertedReader) getSeriesIDsByTagValueIDs(tagValueIDs *roaring.Bitma
 
 		tagValueBucket, err := highOffsets.GetBlock(lowContainerIdx, entries)
 		if err != nil {
-			return nil, err
+			return result, err
 		}
 		lowKeyOffsetsBlockLen, uVariantEncodingLen := stream.UvarintLittleEndian(tagValueBucket)
 		lowKeyOffsetsAt := len(tagValueBucket) - int(lowKeyOffsetsBlockLen) - uVariantEncodingLen
 		if uVariantEncodingLen <= 0 || lowKeyOffsetsAt <= 0 || lowKeyOffsetsAt >= len(tagValueBucket) {
-			return nil, fmt.Errorf("read lowkey offsets error")
+			return result, fmt.Errorf("read lowkey offsets error")
 		}
 		if _, err = lowOffsets.Unmarshal(tagValueBucket[lowKeyOffsetsAt:]); err != nil {
-			return nil, err
+			return result, err
 		}
 		level3Block := tagValueBucket[:lowKeyOffsetsAt]
 
@@ -142,9 +161,11 @@ func (r *tagInvertedReader) getSeriesIDsByTagValueIDs(tagValueIDs *roaring.Bitma
 			// unmarshal series ids
 			seriesIDs := roaring.New()
 			if err := encoding.BitmapUnmarshal(seriesIDs, block); err != nil {
-				return nil, err
+				return result, err
+			}
+			if err := budget.Or(result, seriesIDs); err != nil {
+				return result, err
 			}
-			result.Or(seriesIDs)
 		}
 	}
 	return result, nil
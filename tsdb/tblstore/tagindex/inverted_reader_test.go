@@ -118,25 +118,25 @@ func TestReader_FindSeriesIDsByTagValueIDs(t *testing.T) {
 
 	reader := buildInvertedIndexReader(ctrl)
 	// read not tag key id
-	idSet, err := reader.GetSeriesIDsByTagValueIDs(19, roaring.BitmapOf(1))
+	idSet, err := reader.GetSeriesIDsByTagValueIDs(19, roaring.BitmapOf(1), nil)
 	assert.NoError(t, err)
 	assert.Equal(t, roaring.New(), idSet)
 	// tag value ids is empty
-	idSet, err = reader.GetSeriesIDsByTagValueIDs(19, nil)
+	idSet, err = reader.GetSeriesIDsByTagValueIDs(19, nil, nil)
 	assert.NoError(t, err)
 	assert.Equal(t, roaring.New(), idSet)
 	// not found
-	idSet, err = reader.GetSeriesIDsByTagValueIDs(10, roaring.BitmapOf(1))
+	idSet, err = reader.GetSeriesIDsByTagValueIDs(10, roaring.BitmapOf(1), nil)
 	assert.Error(t, err)
 	assert.Nil(t, idSet)
 
 	// read zone block
-	idSet, err = reader.GetSeriesIDsByTagValueIDs(21, roaring.BitmapOf(2, 49, 6000000, 6000033, 7000000))
+	idSet, err = reader.GetSeriesIDsByTagValueIDs(21, roaring.BitmapOf(2, 49, 6000000, 6000033, 7000000), nil)
 	a := roaring.BitmapOf(2, 6000000, 7000000)
 	assert.NoError(t, err)
 	assert.EqualValues(t, a.ToArray(), idSet.ToArray())
 
-	idSet, err = reader.GetSeriesIDsByTagValueIDs(20, roaring.BitmapOf(1, 2))
+	idSet, err = reader.GetSeriesIDsByTagValueIDs(20, roaring.BitmapOf(1, 2), nil)
 	assert.NoError(t, err)
 	assert.EqualValues(t, roaring.BitmapOf(1, 2).ToArray(), idSet.ToArray())
 
@@ -150,7 +150,7 @@ func TestReader_FindSeriesIDsByTagValueIDs(t *testing.T) {
 		// for other unmarshal
 		return bitmap.UnmarshalBinary(data)
 	}
-	idSet, err = reader.GetSeriesIDsByTagValueIDs(20, roaring.BitmapOf(1, 2))
+	idSet, err = reader.GetSeriesIDsByTagValueIDs(20, roaring.BitmapOf(1, 2), nil)
 	assert.Error(t, err)
 	assert.Nil(t, idSet)
 }
@@ -171,9 +171,9 @@ func TestReader_InvertedIndex_reader_err(t *testing.T) {
 		return fmt.Errorf("err")
 	}
 	// case 1: unmarshal series id err
-	idSet, err := reader.getSeriesIDsByTagValueIDs(roaring.BitmapOf(1, 2))
+	idSet, err := reader.getSeriesIDsByTagValueIDs(roaring.BitmapOf(1, 2), nil)
 	assert.Error(t, err)
-	assert.Nil(t, idSet)
+	assert.True(t, idSet.IsEmpty())
 	// case 2: init inverted inverterReader err
 	reader, err = newTagInvertedReader(zoneBlock)
 	assert.Error(t, err)
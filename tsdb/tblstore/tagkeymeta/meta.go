@@ -20,7 +20,7 @@ package tagkeymeta
 import (
 	"bytes"
 	"encoding/binary"
-	"regexp"
+	"fmt"
 	"sort"
 	"strings"
 
@@ -28,12 +28,18 @@ import (
 	"github.com/lindb/lindb/pkg/encoding"
 	"github.com/lindb/lindb/pkg/strutil"
 	"github.com/lindb/lindb/pkg/trie"
+	"github.com/lindb/lindb/sql/stmt"
 
 	"github.com/lindb/roaring"
 )
 
 //go:generate mockgen -source ./meta.go -destination=./meta_mock.go -package tagkeymeta
 
+// for testing
+var (
+	maxRegexScanTagValues = constants.MaxRegexScanTagValues
+)
+
 type TagKeyMeta interface {
 	// TagValueIDSeq returns the auto sequence of tag value id under this tag key
 	TagValueIDSeq() uint32
@@ -53,7 +59,11 @@ type TagKeyMeta interface {
 	// 3 cases: *sdb, ts*, *sd*
 	FindTagValueIDsByLike(tagValue string) (tagValueIDs []uint32)
 	// FindTagValueIDsByRegex finds tagValueIDs by regex pattern,
-	FindTagValueIDsByRegex(tagValuePattern string) (tagValueIDs []uint32)
+	// returns constants.ErrRegexScanLimitExceeded if too many tag values need be scanned.
+	FindTagValueIDsByRegex(tagValuePattern string) (tagValueIDs []uint32, err error)
+	// FindTagValueIDsByNumericCompare finds tagValueIDs by numeric comparison, non-numeric tag values are skipped,
+	// returns constants.ErrRegexScanLimitExceeded if too many tag values need be scanned.
+	FindTagValueIDsByNumericCompare(expr *stmt.NumericCompareExpr) (tagValueIDs []uint32, err error)
 }
 
 const (
@@ -331,22 +341,49 @@ func (meta *tagKeyMeta) FindTagValueIDsByLike(tagValue string) (tagValueIDs []ui
 	return tagValueIDs
 }
 
-func (meta *tagKeyMeta) FindTagValueIDsByRegex(tagValuePattern string) (tagValueIDs []uint32) {
-	rp, err := regexp.Compile(tagValuePattern)
+// FindTagValueIDsByRegex finds tag value ids by regex pattern, only iterates the tag values with
+// the literal prefix if pattern is anchored, returns constants.ErrRegexScanLimitExceeded
+// if more than constants.MaxRegexScanTagValues tag values need be scanned.
+func (meta *tagKeyMeta) FindTagValueIDsByRegex(tagValuePattern string) (tagValueIDs []uint32, err error) {
+	rp, err := strutil.CompileRegexp(tagValuePattern)
 	if err != nil {
-		return nil
+		return nil, nil
 	}
-	literalPrefix, _ := rp.LiteralPrefix()
-	literalPrefixByte := strutil.String2ByteSlice(literalPrefix)
+	literalPrefixByte := strutil.String2ByteSlice(strutil.AnchoredLiteralPrefix(rp))
 	itr, err := meta.PrefixIterator(literalPrefixByte)
 	if err != nil {
-		return nil
+		return nil, nil
 	}
-	for itr.Valid() {
+	for scanned := 0; itr.Valid(); scanned++ {
+		if scanned >= maxRegexScanTagValues {
+			return nil, fmt.Errorf("%w, pattern: %s, limit: %d",
+				constants.ErrRegexScanLimitExceeded, tagValuePattern, maxRegexScanTagValues)
+		}
 		if rp.Match(itr.Key()) {
 			tagValueIDs = append(tagValueIDs, encoding.ByteSlice2Uint32(itr.Value()))
 		}
 		itr.Next()
 	}
-	return tagValueIDs
+	return tagValueIDs, nil
+}
+
+// FindTagValueIDsByNumericCompare finds tag value ids which are numeric and satisfy the comparison,
+// returns constants.ErrRegexScanLimitExceeded if more than constants.MaxRegexScanTagValues tag values
+// need be scanned, same as regex.
+func (meta *tagKeyMeta) FindTagValueIDsByNumericCompare(expr *stmt.NumericCompareExpr) (tagValueIDs []uint32, err error) {
+	itr, err := meta.PrefixIterator(nil)
+	if err != nil {
+		return nil, err
+	}
+	for scanned := 0; itr.Valid(); scanned++ {
+		if scanned >= maxRegexScanTagValues {
+			return nil, fmt.Errorf("%w, numeric compare: %s, limit: %d",
+				constants.ErrRegexScanLimitExceeded, expr.Rewrite(), maxRegexScanTagValues)
+		}
+		if expr.Match(strutil.ByteSlice2String(itr.Key())) {
+			tagValueIDs = append(tagValueIDs, encoding.ByteSlice2Uint32(itr.Value()))
+		}
+		itr.Next()
+	}
+	return tagValueIDs, nil
 }
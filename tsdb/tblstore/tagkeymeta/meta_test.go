@@ -18,11 +18,14 @@
 package tagkeymeta
 
 import (
+	"errors"
 	"fmt"
 	"sync"
 	"testing"
 
+	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/kv"
+	"github.com/lindb/lindb/sql/stmt"
 
 	"github.com/lindb/roaring"
 	"github.com/stretchr/testify/assert"
@@ -166,13 +169,81 @@ func TestTagKeyMeta_FindTagValueIDsByRegex(t *testing.T) {
 	meta, _ := newTagKeyMeta(buildTestTrieData())
 
 	// case1: bad pattern
-	assert.Len(t, meta.FindTagValueIDsByRegex("1["), 0)
+	assert.Len(t, findTagValueIDsByRegex(t, meta, "1["), 0)
 
 	// case2: prefix regex
-	assert.Len(t, meta.FindTagValueIDsByRegex("1\\.1\\.1\\.[1-3]"), 4)
+	assert.Len(t, findTagValueIDsByRegex(t, meta, "1\\.1\\.1\\.[1-3]"), 4)
 
 	// case3: regex all
-	assert.Len(t, meta.FindTagValueIDsByRegex(".*"), 10000)
+	assert.Len(t, findTagValueIDsByRegex(t, meta, ".*"), 10000)
+
+	// case4: anchored pattern
+	assert.Len(t, findTagValueIDsByRegex(t, meta, "^10\\.10\\.10\\."), 10)
+	assert.Len(t, findTagValueIDsByRegex(t, meta, "^10\\.10\\.10\\.1$"), 1)
+
+	// case5: unanchored pattern
+	assert.Len(t, findTagValueIDsByRegex(t, meta, "\\.10\\.10\\.10$"), 10)
+
+	// case6: empty match
+	assert.Len(t, findTagValueIDsByRegex(t, meta, "^11\\."), 0)
+	assert.Len(t, findTagValueIDsByRegex(t, meta, "[a-z]+"), 0)
+
+	// case7: scan limit exceeded
+	defer func() {
+		maxRegexScanTagValues = constants.MaxRegexScanTagValues
+	}()
+	maxRegexScanTagValues = 10
+	assert.Len(t, findTagValueIDsByRegex(t, meta, "^10\\.10\\.10\\."), 10)
+	tagValueIDs, err := meta.FindTagValueIDsByRegex("^10\\.10\\.1")
+	assert.True(t, errors.Is(err, constants.ErrRegexScanLimitExceeded))
+	assert.Nil(t, tagValueIDs)
+}
+
+func findTagValueIDsByRegex(t *testing.T, meta TagKeyMeta, tagValuePattern string) []uint32 {
+	tagValueIDs, err := meta.FindTagValueIDsByRegex(tagValuePattern)
+	assert.NoError(t, err)
+	return tagValueIDs
+}
+
+func TestTagKeyMeta_FindTagValueIDsByNumericCompare(t *testing.T) {
+	// case1: tag values not numeric
+	meta, _ := newTagKeyMeta(buildTestTrieData())
+	assert.Len(t, findTagValueIDsByNumericCompare(t, meta,
+		&stmt.NumericCompareExpr{Key: "ip", Operator: stmt.GreaterThan, Value: 0}), 0)
+
+	// case2: numeric tag values
+	kvFlusher := kv.NewNopFlusher()
+	flusher, _ := NewFlusher(kvFlusher)
+	for idx, value := range []string{"80", "443", "8080", "8080.5", "http"} {
+		flusher.FlushTagValue([]byte(value), uint32(idx+1))
+	}
+	_ = flusher.FlushTagKeyID(2, 5)
+	meta, err := newTagKeyMeta(kvFlusher.Bytes())
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []uint32{3, 4}, findTagValueIDsByNumericCompare(t, meta,
+		&stmt.NumericCompareExpr{Key: "port", Operator: stmt.GreaterThan, Value: 443}))
+	assert.ElementsMatch(t, []uint32{2, 3, 4}, findTagValueIDsByNumericCompare(t, meta,
+		&stmt.NumericCompareExpr{Key: "port", Operator: stmt.GreaterThanOrEqual, Value: 443}))
+	assert.ElementsMatch(t, []uint32{1}, findTagValueIDsByNumericCompare(t, meta,
+		&stmt.NumericCompareExpr{Key: "port", Operator: stmt.LessThan, Value: 443}))
+	assert.ElementsMatch(t, []uint32{1, 2, 3}, findTagValueIDsByNumericCompare(t, meta,
+		&stmt.NumericCompareExpr{Key: "port", Operator: stmt.LessThanOrEqual, Value: 8080}))
+
+	// case3: scan limit exceeded
+	defer func() {
+		maxRegexScanTagValues = constants.MaxRegexScanTagValues
+	}()
+	maxRegexScanTagValues = 4
+	tagValueIDs, err := meta.FindTagValueIDsByNumericCompare(
+		&stmt.NumericCompareExpr{Key: "port", Operator: stmt.GreaterThan, Value: 443})
+	assert.True(t, errors.Is(err, constants.ErrRegexScanLimitExceeded))
+	assert.Nil(t, tagValueIDs)
+}
+
+func findTagValueIDsByNumericCompare(t *testing.T, meta TagKeyMeta, expr *stmt.NumericCompareExpr) []uint32 {
+	tagValueIDs, err := meta.FindTagValueIDsByNumericCompare(expr)
+	assert.NoError(t, err)
+	return tagValueIDs
 }
 
 func TestTagKeyMeta_CollectTagValues(t *testing.T) {
@@ -234,7 +305,11 @@ func TestTagKeyMeta_Error(t *testing.T) {
 	metaImpl.trieBlock = append([]byte{1, 2, 3, 4}, metaImpl.trieBlock...)
 
 	// FindTagValueIDsByRegex error
-	assert.Len(t, meta.FindTagValueIDsByRegex("x"), 0)
+	assert.Len(t, findTagValueIDsByRegex(t, meta, "x"), 0)
+	// FindTagValueIDsByNumericCompare error
+	tagValueIDs, err := meta.FindTagValueIDsByNumericCompare(&stmt.NumericCompareExpr{Key: "port", Operator: stmt.GreaterThan})
+	assert.Error(t, err)
+	assert.Nil(t, tagValueIDs)
 	// FindTagValueIDsByLike error
 	assert.Len(t, meta.FindTagValueIDsByLike("x*"), 0)
 	assert.Len(t, meta.FindTagValueIDsByLike("*x*"), 0)
@@ -131,7 +131,17 @@ func (r *tagReader) FindValueIDsByExprForTagKeyID(tagKeyID uint32, expr stmt.Tag
 		case *stmt.LikeExpr:
 			tagValueIDs.AddMany(tagKeyMeta.FindTagValueIDsByLike(expression.Value))
 		case *stmt.RegexExpr:
-			tagValueIDs.AddMany(tagKeyMeta.FindTagValueIDsByRegex(expression.Regexp))
+			ids, err := tagKeyMeta.FindTagValueIDsByRegex(expression.Regexp)
+			if err != nil {
+				return nil, err
+			}
+			tagValueIDs.AddMany(ids)
+		case *stmt.NumericCompareExpr:
+			ids, err := tagKeyMeta.FindTagValueIDsByNumericCompare(expression)
+			if err != nil {
+				return nil, err
+			}
+			tagValueIDs.AddMany(ids)
 		default:
 			return nil, fmt.Errorf("%w, unsupported expr, tagKeyID: %d",
 				constants.ErrTagKeyMetaNotFound, tagKeyID)
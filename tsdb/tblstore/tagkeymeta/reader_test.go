@@ -263,6 +263,15 @@ func TestReader_FindSeriesIDsByExprForTagID_RegexExpr(t *testing.T) {
 	// find not existed host
 	_, err = reader.FindValueIDsByExprForTagKeyID(22, &stmt.RegexExpr{Key: "host", Regexp: "eleme-prod-sh-"})
 	assert.Error(t, err)
+
+	// scan limit exceeded
+	defer func() {
+		maxRegexScanTagValues = constants.MaxRegexScanTagValues
+	}()
+	maxRegexScanTagValues = 1
+	idSet, err = reader.FindValueIDsByExprForTagKeyID(22, &stmt.RegexExpr{Key: "host", Regexp: "eleme-dev-sh-"})
+	assert.True(t, errors.Is(err, constants.ErrRegexScanLimitExceeded))
+	assert.Nil(t, idSet)
 }
 
 func TestReader_SuggestTagValues(t *testing.T) {
@@ -130,32 +130,47 @@ func (wal *baseWAL) checkPage(length int) error {
 	// prepare the data pointer
 	// 检查是否写满
 	if wal.offset+length > wal.pageSize {
+		// not enough space in current data page, need create new page
+		return wal.rotatePage()
+	}
 
-		// sync previous data page
-		// 落盘
-		if err := wal.currentPage.Sync(); err != nil {
-			walLogger.Error("sync data page err when alloc", logger.String("wal", wal.path), logger.Error(err))
-		}
 
-		// not enough space in current data page, need create new page
-		// 获取新页
-		walPage, err := wal.walFactory.AcquirePage(wal.pageIndex.Load() + 1)
-		if err != nil {
-			return err
-		}
+	return nil
+}
 
-		// 设置为当前页
-		wal.currentPage = walPage
-		wal.pageIndex.Inc()
+// rotatePage syncs current data page, then acquires new page for appending,
+// the previous page can be recovered without reopening wal.
+func (wal *baseWAL) rotatePage() error {
+	// sync previous data page
+	// 落盘
+	if err := wal.currentPage.Sync(); err != nil {
+		walLogger.Error("sync data page err when alloc", logger.String("wal", wal.path), logger.Error(err))
+	}
 
-		// 重置页内偏移
-		wal.offset = 0 // need reset message offset for new page append
+	// 获取新页
+	walPage, err := wal.walFactory.AcquirePage(wal.pageIndex.Load() + 1)
+	if err != nil {
+		return err
 	}
 
+	// 设置为当前页
+	wal.currentPage = walPage
+	wal.pageIndex.Inc()
 
+	// 重置页内偏移
+	wal.offset = 0 // need reset message offset for new page append
 	return nil
 }
 
+// rotate switches to new page if current page has entries,
+// so that all entries can be recovered before closing.
+func (wal *baseWAL) rotate() error {
+	if wal.offset == 0 {
+		return nil
+	}
+	return wal.rotatePage()
+}
+
 func (wal *baseWAL) putUint8(value uint8) {
 	wal.currentPage.PutUint8(value, wal.offset)
 	wal.offset++
@@ -178,6 +193,18 @@ func (wal *baseWAL) putString(value string) {
 	wal.offset += length
 }
 
+// commitPage removes the page whose entries are fully persisted into backend storage,
+// then moves the committed page index forward. the remaining page files are the durable checkpoint,
+// so that recovery only replays the tail after restart.
+func (wal *baseWAL) commitPage(pageIndex int64) {
+	if err := wal.walFactory.ReleasePage(pageIndex); err != nil {
+		releaseWALPageFailCounter.Incr()
+		walLogger.Error("release wal page error",
+			logger.String("wal", wal.path), logger.Any("page", pageIndex), logger.Error(err))
+	}
+	wal.commitPageIndex.Store(pageIndex)
+}
+
 // sync flushes data into disk
 func (wal *baseWAL) sync() error {
 	return wal.currentPage.Sync()
@@ -195,6 +222,15 @@ func (wal *baseWAL) needRecovery() bool {
 	return wal.pageIndex.Load()-wal.commitPageIndex.Load() > 1
 }
 
+// pendingSize returns the data size of full pages waiting for recovery
+func (wal *baseWAL) pendingSize() int64 {
+	pages := wal.pageIndex.Load() - wal.commitPageIndex.Load() - 1
+	if pages <= 0 {
+		return 0
+	}
+	return pages * int64(wal.pageSize)
+}
+
 func readString(dataPage page.MappedPage, offset int) (val string, n int) {
 	length := int(dataPage.ReadUint8(offset))
 	data := dataPage.ReadBytes(offset+1, length)
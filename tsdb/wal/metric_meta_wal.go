@@ -77,6 +77,12 @@ type MetricMetaWAL interface {
 	// NeedRecovery checks if wal log need to recover
 	NeedRecovery() bool
 
+	// CurrentPage returns the index of page which the entries are appended into
+	CurrentPage() int64
+
+	// CommittedPage returns the index of last page whose entries are recovered and committed
+	CommittedPage() int64
+
 	// Recovery recoveries wal log, then writes data via recovery function
 	Recovery(metricRecovery MetricRecoveryFunc,
 		fieldRecovery FieldRecoveryFunc,
@@ -86,6 +92,10 @@ type MetricMetaWAL interface {
 	// Sync flushes data into disk
 	Sync() error
 
+	// Rotate switches to new page if current page has entries,
+	// so that all entries can be recovered before closing.
+	Rotate() error
+
 	// Close closes the wal log
 	Close() error
 }
@@ -148,6 +158,16 @@ func (m *metricMetaWAL) AppendTagKey(metricID uint32, tagKeyID uint32, tagKey st
 	return nil
 }
 
+// CurrentPage returns the index of page which the entries are appended into
+func (m *metricMetaWAL) CurrentPage() int64 {
+	return m.base.pageIndex.Load()
+}
+
+// CommittedPage returns the index of last page whose entries are recovered and committed
+func (m *metricMetaWAL) CommittedPage() int64 {
+	return m.base.commitPageIndex.Load()
+}
+
 // NeedRecovery checks if wal log need to recover
 func (m *metricMetaWAL) NeedRecovery() bool {
 	return m.base.needRecovery()
@@ -167,11 +187,13 @@ func (m *metricMetaWAL) Recovery(
 	committed := m.base.commitPageIndex.Load()
 
 	// 遍历 [commitPage, currPage] ，逐页 redo 。
-	for i := committed; i < current; i++ {
+	for i := committed + 1; i < current; i++ {
 
 		// 获取 Page
 		walPage, ok := m.base.walFactory.GetPage(i)
 		if !ok {
+			// page not exist, skip it
+			m.base.commitPageIndex.Store(i)
 			continue
 		}
 
@@ -243,15 +265,8 @@ func (m *metricMetaWAL) Recovery(
 			return
 		}
 
-		// 释放当前页
-		if err := m.base.walFactory.ReleasePage(i); err != nil {
-			releaseWALPageFailCounter.Incr()
-			walLogger.Error("release meta wal page error",
-				logger.String("wal", m.base.path), logger.Error(err))
-		}
-
-		// 递增已提交页索引
-		m.base.commitPageIndex.Inc()
+		// 释放当前页，递增已提交页索引
+		m.base.commitPage(i)
 	}
 }
 
@@ -260,6 +275,12 @@ func (m *metricMetaWAL) Sync() error {
 	return m.base.sync()
 }
 
+// Rotate switches to new page if current page has entries,
+// so that all entries can be recovered before closing.
+func (m *metricMetaWAL) Rotate() error {
+	return m.base.rotate()
+}
+
 // Close closes the wal log
 func (m *metricMetaWAL) Close() error {
 	return m.base.close()
@@ -137,6 +137,8 @@ func TestMetricMetaWAL_Recovery(t *testing.T) {
 	})
 	assert.Equal(t, 7, count)
 	assert.False(t, metaWAL.NeedRecovery())
+	// all pages before current page are committed
+	assert.Equal(t, metaWAL.CurrentPage()-1, metaWAL.CommittedPage())
 
 	err = metaWAL.Close()
 	assert.NoError(t, err)
@@ -201,7 +203,6 @@ func TestMetricMetaWAL_Recovery_err(t *testing.T) {
 	fct := page.NewMockFactory(ctrl)
 	mockPage := page.NewMockMappedPage(ctrl)
 	wal1.base.walFactory = fct
-	fct.EXPECT().GetPage(int64(0)).Return(nil, false)
 	fct.EXPECT().GetPage(int64(1)).Return(mockPage, true)
 	mockPage.EXPECT().ReadUint8(0).Return(uint8(0))
 	fct.EXPECT().ReleasePage(gomock.Any()).Return(fmt.Errorf("err"))
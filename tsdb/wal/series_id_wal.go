@@ -18,9 +18,19 @@
 package wal
 
 import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.uber.org/atomic"
+
 	"github.com/lindb/lindb/pkg/fileutil"
 	"github.com/lindb/lindb/pkg/logger"
 	"github.com/lindb/lindb/pkg/queue/page"
+	"github.com/lindb/lindb/pkg/stream"
 )
 
 //go:generate mockgen -source=./series_id_wal.go -destination=./series_id_wal_mock.go -package=wal
@@ -29,6 +39,8 @@ import (
 var (
 	mkDirFunc          = fileutil.MkDirIfNotExist
 	newPageFactoryFunc = page.NewFactory
+	listDirFunc        = fileutil.ListDir
+	mapFileFunc        = fileutil.Map
 )
 
 var (
@@ -36,11 +48,11 @@ var (
 )
 
 const (
-	seriesEntryLength = 4 + 8 + 4                      // metric id + tags hash + series id
-	seriesPageSize    = seriesEntryLength * 512 * 1024 // series wal page size
-	metricIDOffset    = 0                              // metric id offset
-	tagsHashOffset    = metricIDOffset + 4             // tags hash offset
-	seriesIDOffset    = tagsHashOffset + 8             // series id offset
+	pageFileSuffix    = ".bat"             // suffix of page file, same as page factory
+	seriesEntryLength = 4 + 8 + 4          // metric id + tags hash + series id
+	metricIDOffset    = 0                  // metric id offset
+	tagsHashOffset    = metricIDOffset + 4 // tags hash offset
+	seriesIDOffset    = tagsHashOffset + 8 // series id offset
 )
 
 // SeriesWAL represents write ahead log which stores series data for index database
@@ -49,17 +61,28 @@ type SeriesWAL interface {
 	Append(metricID uint32, tagsHash uint64, seriesID uint32) error
 	// NeedRecovery checks if wal log need to recover
 	NeedRecovery() bool
+	// PendingSize returns the size of series data(bytes) which need to recover
+	PendingSize() int64
+	// PendingEntries returns the number of series entries which are not synced into backend storage,
+	// includes the entries of current page.
+	PendingEntries() int64
 	// Recovery recoveries wal log, then writes data via recovery function
 	Recovery(recovery SeriesRecoveryFunc, commit CommitFunc)
 	// Sync flushes data into disk
 	Sync() error
+	// Rotate switches to new page if current page has entries,
+	// so that all entries can be recovered before closing.
+	Rotate() error
+	// DumpWAL writes all series entries of wal log in page order as readable text, without mutating wal log
+	DumpWAL(w io.Writer) error
 	// Close closes the wal log
 	Close() error
 }
 
 // seriesWAL implements SeriesWAL interface
 type seriesWAL struct {
-	base *baseWAL
+	base           *baseWAL
+	pendingEntries atomic.Int64 // number of entries not recovered
 }
 
 // NewSeriesWAL creates a new series write ahead log
@@ -69,7 +92,14 @@ func NewSeriesWAL(path string) (SeriesWAL, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &seriesWAL{base: base}, nil
+	wal := &seriesWAL{base: base}
+	// count entries of pages which are not recovered before reopening
+	for i := base.commitPageIndex.Load() + 1; i < base.pageIndex.Load(); i++ {
+		if walPage, ok := base.walFactory.GetPage(i); ok {
+			wal.pendingEntries.Add(int64(countSeriesEntries(walPage, base.pageSize)))
+		}
+	}
+	return wal, nil
 }
 
 // Append appends "metricID/tagsHash/seriesID" into wal log
@@ -80,6 +110,7 @@ func (wal *seriesWAL) Append(metricID uint32, tagsHash uint64, seriesID uint32)
 	wal.base.putUint32(metricID)
 	wal.base.putUint64(tagsHash)
 	wal.base.putUint32(seriesID)
+	wal.pendingEntries.Inc()
 
 	return nil
 }
@@ -89,27 +120,40 @@ func (wal *seriesWAL) NeedRecovery() bool {
 	return wal.base.needRecovery()
 }
 
+// PendingSize returns the size of series data(bytes) which need to recover
+func (wal *seriesWAL) PendingSize() int64 {
+	return wal.base.pendingSize()
+}
+
+// PendingEntries returns the number of series entries which are not synced into backend storage,
+// includes the entries of current page.
+func (wal *seriesWAL) PendingEntries() int64 {
+	return wal.pendingEntries.Load()
+}
+
 // Recovery recoveries wal log, then writes data via recovery function
 func (wal *seriesWAL) Recovery(recovery SeriesRecoveryFunc, commit CommitFunc) {
 	current := wal.base.pageIndex.Load()
 	committed := wal.base.commitPageIndex.Load()
 
 	// 遍历 [commitPage, currPage] ，逐页 redo 。
-	for i := committed; i < current; i++ {
+	for i := committed + 1; i < current; i++ {
 
 		// 获取 Page
 		walPage, ok := wal.base.walFactory.GetPage(i)
 		if !ok {
+			// page not exist, skip it
+			wal.base.commitPageIndex.Store(i)
 			continue
 		}
 
 		// 逐个 Entry 读取、解析、重做
 		offset := 0
-		for offset < seriesPageSize {
+		for offset+seriesEntryLength <= wal.base.pageSize {
 			// 解析
 			metricID := walPage.ReadUint32(offset + metricIDOffset)
-			tagsHash := walPage.ReadUint64(offset+tagsHashOffset)
-			seriesID := walPage.ReadUint32(offset+seriesIDOffset)
+			tagsHash := walPage.ReadUint64(offset + tagsHashOffset)
+			seriesID := walPage.ReadUint32(offset + seriesIDOffset)
 
 			// 出错
 			if metricID == 0 {
@@ -135,16 +179,111 @@ func (wal *seriesWAL) Recovery(recovery SeriesRecoveryFunc, commit CommitFunc) {
 			return
 		}
 
-		// 释放页面
-		if err := wal.base.walFactory.ReleasePage(i); err != nil {
-			releaseWALPageFailCounter.Incr()
-			walLogger.Error("release series wal page error",
-				logger.String("wal", wal.base.path), logger.Error(err))
+		// 释放页面，递增已提交页索引
+		wal.base.commitPage(i)
+		wal.pendingEntries.Sub(int64(offset / seriesEntryLength))
+	}
+}
+
+// DumpWAL writes all series entries of wal log in page order as readable text, without mutating wal log
+func (wal *seriesWAL) DumpWAL(w io.Writer) error {
+	fct := wal.base.walFactory
+	for _, pageID := range fct.GetPageIDs() {
+		walPage, ok := fct.GetPage(pageID)
+		if !ok {
+			continue
+		}
+		if err := dumpSeriesPage(pageID, walPage, wal.base.pageSize, w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DumpSeriesWAL writes all series entries of wal log under path as readable text, used by offline tool.
+// The existing page files are mapped read-only, so that dumping never creates, truncates or writes wal files.
+func DumpSeriesWAL(path string, w io.Writer) error {
+	if !fileutil.Exist(path) {
+		return fmt.Errorf("series wal path: %s not exist", path)
+	}
+	fileNames, err := listDirFunc(path)
+	if err != nil {
+		return err
+	}
+	var pageIDs []int64
+	for _, fileName := range fileNames {
+		if !strings.HasSuffix(fileName, pageFileSuffix) {
+			continue
 		}
+		pageID, err := strconv.ParseInt(strings.TrimSuffix(fileName, pageFileSuffix), 10, 64)
+		if err != nil {
+			return err
+		}
+		pageIDs = append(pageIDs, pageID)
+	}
+	sort.Slice(pageIDs, func(i, j int) bool { return pageIDs[i] < pageIDs[j] })
+	for _, pageID := range pageIDs {
+		if err := dumpSeriesPageFile(path, pageID, w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-		// 递增已提交页索引
-		wal.base.commitPageIndex.Inc()
+// dumpSeriesPageFile maps the page file read-only, then writes series entries of it.
+func dumpSeriesPageFile(path string, pageID int64, w io.Writer) error {
+	data, err := mapFileFunc(filepath.Join(path, strconv.FormatInt(pageID, 10)+pageFileSuffix))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := fileutil.Unmap(data); err != nil {
+			walLogger.Error("unmap wal page error when dump series wal",
+				logger.String("wal", path), logger.Error(err))
+		}
+	}()
+	return dumpSeriesPage(pageID, readOnlyPage(data), len(data), w)
+}
+
+// countSeriesEntries returns the number of series entries in wal page.
+func countSeriesEntries(walPage page.MappedPage, pageSize int) int {
+	n := 0
+	for offset := 0; offset+seriesEntryLength <= pageSize; offset += seriesEntryLength {
+		if walPage.ReadUint32(offset+metricIDOffset) == 0 {
+			break
+		}
+		n++
 	}
+	return n
+}
+
+// pageReader represents the page which series entries are read from.
+type pageReader interface {
+	ReadUint32(offset int) uint32
+	ReadUint64(offset int) uint64
+}
+
+// readOnlyPage represents the page file mapped read-only.
+type readOnlyPage []byte
+
+func (p readOnlyPage) ReadUint32(offset int) uint32 { return stream.ReadUint32(p, offset) }
+func (p readOnlyPage) ReadUint64(offset int) uint64 { return stream.ReadUint64(p, offset) }
+
+// dumpSeriesPage writes series entries of page, one entry per line.
+func dumpSeriesPage(pageID int64, walPage pageReader, pageSize int, w io.Writer) error {
+	for offset := 0; offset+seriesEntryLength <= pageSize; offset += seriesEntryLength {
+		metricID := walPage.ReadUint32(offset + metricIDOffset)
+		if metricID == 0 {
+			break
+		}
+		if _, err := fmt.Fprintf(w, "page=%d offset=%d metric=%d tags-hash=%d series=%d\n",
+			pageID, offset, metricID,
+			walPage.ReadUint64(offset+tagsHashOffset),
+			walPage.ReadUint32(offset+seriesIDOffset)); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // Sync flushes data into disk
@@ -152,6 +291,12 @@ func (wal *seriesWAL) Sync() error {
 	return wal.base.sync()
 }
 
+// Rotate switches to new page if current page has entries,
+// so that all entries can be recovered before closing.
+func (wal *seriesWAL) Rotate() error {
+	return wal.base.rotate()
+}
+
 // Close closes the wal log
 func (wal *seriesWAL) Close() error {
 	return wal.base.close()
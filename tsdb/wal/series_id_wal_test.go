@@ -18,8 +18,12 @@
 package wal
 
 import (
+	"bytes"
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -68,6 +72,7 @@ func TestNewSeriesWAL(t *testing.T) {
 	// case 4: init wal success with re-open
 	fct.EXPECT().GetPageIDs().Return([]int64{19, 20, 21})
 	fct.EXPECT().AcquirePage(int64(22)).Return(nil, nil)
+	fct.EXPECT().GetPage(gomock.Any()).Return(nil, false).Times(3)
 	wal, err = NewSeriesWAL(testSeriesWALPath)
 	assert.NoError(t, err)
 	assert.NotNil(t, wal)
@@ -186,6 +191,159 @@ func TestSeriesWAL_Recovery(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestSeriesWAL_PendingEntries(t *testing.T) {
+	wal, err := NewSeriesWAL(t.TempDir())
+	assert.NoError(t, err)
+	wal1 := wal.(*seriesWAL)
+	// 2 entries per page
+	wal1.base.pageSize = 2 * seriesEntryLength
+	assert.Zero(t, wal.PendingEntries())
+	for i := 1; i <= 3; i++ {
+		assert.NoError(t, wal.Append(uint32(i), uint64(i), uint32(i)))
+	}
+	// one full page + one entry of current page
+	assert.Equal(t, int64(3), wal.PendingEntries())
+	wal.Recovery(func(metricID uint32, tagsHash uint64, seriesID uint32) error {
+		return nil
+	}, func() error {
+		return nil
+	})
+	assert.Equal(t, int64(1), wal.PendingEntries())
+	// entries of pages not recovered are counted after reopening
+	assert.NoError(t, wal.Append(4, 4, 4))
+	assert.NoError(t, wal.Close())
+	wal, err = NewSeriesWAL(wal1.base.path)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), wal.PendingEntries())
+	assert.NoError(t, wal.Close())
+}
+
+func TestSeriesWAL_Recovery_truncate(t *testing.T) {
+	testSeriesWALPath := t.TempDir()
+	wal, err := NewSeriesWAL(testSeriesWALPath)
+	assert.NoError(t, err)
+	wal1 := wal.(*seriesWAL)
+	// 2 entries per page
+	wal1.base.pageSize = 2 * seriesEntryLength
+	for i := 1; i <= 5; i++ {
+		assert.NoError(t, wal.Append(uint32(i), uint64(i), uint32(i)))
+	}
+	assert.NoError(t, wal.Close())
+	// case: replay all pages, committed pages removed
+	wal, err = NewSeriesWAL(testSeriesWALPath)
+	assert.NoError(t, err)
+	assert.True(t, wal.NeedRecovery())
+	assert.Equal(t, int64(3*metricMetaPageSize), wal.PendingSize())
+	var seriesIDs []uint32
+	commits := 0
+	wal.Recovery(func(metricID uint32, tagsHash uint64, seriesID uint32) error {
+		seriesIDs = append(seriesIDs, seriesID)
+		return nil
+	}, func() error {
+		commits++
+		return nil
+	})
+	assert.Equal(t, []uint32{1, 2, 3, 4, 5}, seriesIDs)
+	assert.Equal(t, 3, commits)
+	assert.False(t, wal.NeedRecovery())
+	assert.Zero(t, wal.PendingSize())
+	wal1 = wal.(*seriesWAL)
+	assert.Equal(t, []int64{4}, wal1.base.walFactory.GetPageIDs())
+	// case: append after recovery, only replay tail after restart
+	assert.NoError(t, wal.Append(6, 6, 6))
+	assert.NoError(t, wal.Close())
+	wal, err = NewSeriesWAL(testSeriesWALPath)
+	assert.NoError(t, err)
+	assert.True(t, wal.NeedRecovery())
+	seriesIDs = nil
+	wal.Recovery(func(metricID uint32, tagsHash uint64, seriesID uint32) error {
+		seriesIDs = append(seriesIDs, seriesID)
+		return nil
+	}, func() error {
+		return nil
+	})
+	assert.Equal(t, []uint32{6}, seriesIDs)
+	assert.False(t, wal.NeedRecovery())
+	assert.NoError(t, wal.Close())
+}
+
+func TestSeriesWAL_DumpWAL(t *testing.T) {
+	testSeriesWALPath := t.TempDir()
+	wal, err := NewSeriesWAL(testSeriesWALPath)
+	assert.NoError(t, err)
+	wal1 := wal.(*seriesWAL)
+	// 2 entries per page
+	wal1.base.pageSize = 2 * seriesEntryLength
+	for i := 1; i <= 3; i++ {
+		assert.NoError(t, wal.Append(uint32(i), uint64(i*10), uint32(i*100)))
+	}
+	expect := "page=1 offset=0 metric=1 tags-hash=10 series=100\n" +
+		"page=1 offset=16 metric=2 tags-hash=20 series=200\n" +
+		"page=2 offset=0 metric=3 tags-hash=30 series=300\n"
+	var buf bytes.Buffer
+	assert.NoError(t, wal.DumpWAL(&buf))
+	assert.Equal(t, expect, buf.String())
+	// dump not mutate wal
+	assert.Equal(t, []int64{1, 2}, wal1.base.walFactory.GetPageIDs())
+	assert.NoError(t, wal.Close())
+
+	// case: dump offline, wal files are not modified
+	type fileStat struct {
+		size    int64
+		modTime time.Time
+	}
+	snapshot := func() map[string]fileStat {
+		stats := make(map[string]fileStat)
+		entries, err := os.ReadDir(testSeriesWALPath)
+		assert.NoError(t, err)
+		for _, entry := range entries {
+			info, err := entry.Info()
+			assert.NoError(t, err)
+			stats[entry.Name()] = fileStat{size: info.Size(), modTime: info.ModTime()}
+		}
+		return stats
+	}
+	past := time.Now().Add(-time.Hour)
+	entries, err := os.ReadDir(testSeriesWALPath)
+	assert.NoError(t, err)
+	for _, entry := range entries {
+		assert.NoError(t, os.Chtimes(filepath.Join(testSeriesWALPath, entry.Name()), past, past))
+	}
+	before := snapshot()
+	buf.Reset()
+	assert.NoError(t, DumpSeriesWAL(testSeriesWALPath, &buf))
+	assert.Equal(t, expect, buf.String())
+	assert.Equal(t, before, snapshot())
+	// case: path not exist
+	assert.Error(t, DumpSeriesWAL(filepath.Join(testSeriesWALPath, "not_exist"), &buf))
+	assert.False(t, fileutil.Exist(filepath.Join(testSeriesWALPath, "not_exist")))
+	// case: write err
+	assert.Error(t, DumpSeriesWAL(testSeriesWALPath, &errWriter{}))
+	// case: map page err
+	mapFileFunc = func(path string) ([]byte, error) {
+		return nil, fmt.Errorf("err")
+	}
+	assert.Error(t, DumpSeriesWAL(testSeriesWALPath, &buf))
+	mapFileFunc = fileutil.Map
+	// case: list dir err
+	listDirFunc = func(path string) ([]string, error) {
+		return nil, fmt.Errorf("err")
+	}
+	assert.Error(t, DumpSeriesWAL(testSeriesWALPath, &buf))
+	// case: ignore non page file, bad page file name
+	listDirFunc = func(path string) ([]string, error) {
+		return []string{"LOCK", "a.bat"}, nil
+	}
+	assert.Error(t, DumpSeriesWAL(testSeriesWALPath, &buf))
+	listDirFunc = fileutil.ListDir
+}
+
+type errWriter struct{}
+
+func (w *errWriter) Write(_ []byte) (int, error) {
+	return 0, fmt.Errorf("err")
+}
+
 func TestSeriesWAL_Recovery_err(t *testing.T) {
 	testSeriesWALPath := t.TempDir()
 	ctrl := gomock.NewController(t)
@@ -204,18 +362,22 @@ func TestSeriesWAL_Recovery_err(t *testing.T) {
 	wal, err := NewSeriesWAL(testSeriesWALPath)
 	assert.NoError(t, err)
 	wal1 := wal.(*seriesWAL)
-	wal1.base.commitPageIndex.Store(10)
+	wal1.base.commitPageIndex.Store(9)
 	wal1.base.pageIndex.Store(11)
-	// case 1: get nil page by page id
+	// case 1: get nil page by page id, skip it
 	fct.EXPECT().GetPage(int64(10)).Return(nil, false)
 	wal.Recovery(func(metricID uint32, tagsHash uint64, seriesID uint32) error {
 		return fmt.Errorf("err")
 	}, func() error {
 		return fmt.Errorf("err")
 	})
+	assert.False(t, wal.NeedRecovery())
+	wal1.base.commitPageIndex.Store(9)
 	// case 2: metric id = 0
 	fct.EXPECT().GetPage(int64(10)).Return(mockPage, true).AnyTimes()
 	mockPage.EXPECT().ReadUint32(0).Return(uint32(0))
+	mockPage.EXPECT().ReadUint64(4).Return(uint64(0))
+	mockPage.EXPECT().ReadUint32(12).Return(uint32(0))
 	wal.Recovery(func(metricID uint32, tagsHash uint64, seriesID uint32) error {
 		return fmt.Errorf("err")
 	}, func() error {
@@ -232,6 +394,8 @@ func TestSeriesWAL_Recovery_err(t *testing.T) {
 	})
 	// case 4: release page err
 	mockPage.EXPECT().ReadUint32(0).Return(uint32(0))
+	mockPage.EXPECT().ReadUint64(4).Return(uint64(0))
+	mockPage.EXPECT().ReadUint32(12).Return(uint32(0))
 	fct.EXPECT().ReleasePage(int64(10)).Return(fmt.Errorf("err"))
 	wal.Recovery(func(metricID uint32, tagsHash uint64, seriesID uint32) error {
 		return fmt.Errorf("err")
@@ -0,0 +1,119 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package tsdb
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/internal/linmetric"
+)
+
+// WriteStage represents a stage of storage write path.
+type WriteStage int
+
+// stages of storage write path
+const (
+	// WriteStageWAL appends write request into write ahead log.
+	WriteStageWAL WriteStage = iota
+	// WriteStageChecker validates replica sequence of data family.
+	WriteStageChecker
+	// WriteStageDecode decompresses and unmarshals replica message into rows.
+	WriteStageDecode
+	// WriteStageMetadata generates metric id and field ids of rows.
+	WriteStageMetadata
+	// WriteStageIndex gets or creates series id of rows.
+	WriteStageIndex
+	// WriteStageMemTable writes rows into memory database.
+	WriteStageMemTable
+
+	numOfWriteStages
+)
+
+var writeStageNames = [numOfWriteStages]string{"wal", "checker", "decode", "metadata", "index", "memtable"}
+
+// String returns the name of write stage.
+func (s WriteStage) String() string {
+	return writeStageNames[s]
+}
+
+// for testing
+var (
+	randFloat64 = rand.Float64
+)
+
+var (
+	writeProfileScope  = linmetric.NewScope("lindb.tsdb.write_profile")
+	writeStageTimerVec = writeProfileScope.Scope("stage_duration").NewHistogramVec("db", "stage")
+)
+
+// WriteSpan is a lightweight recorder of latency breakdown of a sampled write request by stage,
+// all methods of nil span are no-op, so that un-sampled requests pay nothing but a nil check.
+type WriteSpan struct {
+	database  string
+	durations [numOfWriteStages]time.Duration
+	recorded  [numOfWriteStages]bool
+}
+
+// StartWriteSpan returns a span for recording the write request of shard
+// with probability of write-profile-sample-rate, returns nil if the request is not sampled.
+func StartWriteSpan(shard Shard) *WriteSpan {
+	rate := config.GlobalStorageConfig().TSDB.WriteProfileSampleRate
+	if rate <= 0 || (rate < 1 && randFloat64() >= rate) {
+		return nil
+	}
+	return &WriteSpan{database: shard.Database().Name()}
+}
+
+// Now returns current time if span is sampled, else returns zero time without calling clock.
+func (s *WriteSpan) Now() time.Time {
+	if s == nil {
+		return time.Time{}
+	}
+	return time.Now()
+}
+
+// Record accumulates the elapsed time since start into stage,
+// a stage may be recorded many times for a request, e.g. index lookup of each row.
+func (s *WriteSpan) Record(stage WriteStage, start time.Time) {
+	if s == nil {
+		return
+	}
+	s.durations[stage] += time.Since(start)
+	s.recorded[stage] = true
+}
+
+// Duration returns the accumulated time of stage, returns false if stage not recorded.
+func (s *WriteSpan) Duration(stage WriteStage) (time.Duration, bool) {
+	if s == nil {
+		return 0, false
+	}
+	return s.durations[stage], s.recorded[stage]
+}
+
+// Finish reports the accumulated time of recorded stages into stage histograms.
+func (s *WriteSpan) Finish() {
+	if s == nil {
+		return
+	}
+	for stage := WriteStage(0); stage < numOfWriteStages; stage++ {
+		if s.recorded[stage] {
+			writeStageTimerVec.WithTagValues(s.database, stage.String()).UpdateDuration(s.durations[stage])
+		}
+	}
+}
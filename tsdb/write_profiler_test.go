@@ -0,0 +1,82 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+package tsdb
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/config"
+)
+
+func TestStartWriteSpan(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		randFloat64 = rand.Float64
+		config.SetGlobalStorageConfig(config.NewDefaultStorageBase())
+		ctrl.Finish()
+	}()
+	db := NewMockDatabase(ctrl)
+	db.EXPECT().Name().Return("db").AnyTimes()
+	shard := NewMockShard(ctrl)
+	shard.EXPECT().Database().Return(db).AnyTimes()
+
+	cfg := config.NewDefaultStorageBase()
+	config.SetGlobalStorageConfig(cfg)
+	// case 1: profiling disabled
+	assert.Nil(t, StartWriteSpan(shard))
+	// case 2: sample all requests
+	cfg.TSDB.WriteProfileSampleRate = 1
+	span := StartWriteSpan(shard)
+	assert.NotNil(t, span)
+	assert.Equal(t, "db", span.database)
+	// case 3: sample by rate
+	cfg.TSDB.WriteProfileSampleRate = 0.1
+	randFloat64 = func() float64 { return 0.5 }
+	assert.Nil(t, StartWriteSpan(shard))
+	randFloat64 = func() float64 { return 0.05 }
+	assert.NotNil(t, StartWriteSpan(shard))
+}
+
+func TestWriteSpan_Record(t *testing.T) {
+	// nil span records nothing
+	var nilSpan *WriteSpan
+	assert.True(t, nilSpan.Now().IsZero())
+	nilSpan.Record(WriteStageWAL, time.Now())
+	_, ok := nilSpan.Duration(WriteStageWAL)
+	assert.False(t, ok)
+	nilSpan.Finish()
+
+	span := &WriteSpan{database: "db"}
+	start := span.Now()
+	assert.False(t, start.IsZero())
+	span.Record(WriteStageIndex, start.Add(-time.Second))
+	span.Record(WriteStageIndex, start.Add(-time.Second))
+	d, ok := span.Duration(WriteStageIndex)
+	assert.True(t, ok)
+	assert.True(t, d >= 2*time.Second)
+	_, ok = span.Duration(WriteStageWAL)
+	assert.False(t, ok)
+	span.Finish()
+
+	assert.Equal(t, "wal", WriteStageWAL.String())
+	assert.Equal(t, "memtable", WriteStageMemTable.String())
+}